@@ -0,0 +1,72 @@
+#!/usr/bin/env bash
+# Comprehensive Bash example covering the constructs the parser indexes:
+# functions in both forms, top-level variables and constants, sourced
+# files and command invocations.
+
+set -euo pipefail
+
+ROOT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
+readonly VERSION_FILE="$ROOT_DIR/VERSION"
+declare -r MAX_RETRIES=3
+declare -x BUILD_MODE="${BUILD_MODE:-release}"
+export PATH="$ROOT_DIR/bin:$PATH"
+counter=0
+
+# shellcheck source=lib/common.sh
+source "${BASH_SOURCE%/*}/lib/common.sh"
+. "$ROOT_DIR/scripts/lib/logging.sh"
+
+# Prints a message prefixed with the current time
+log() {
+    printf '[%s] %s\n' "$(date +%H:%M:%S)" "$*"
+}
+
+# Retries a command until it succeeds or MAX_RETRIES is reached
+retry() {
+    local attempt=1
+    until "$@"; do
+        if (( attempt >= MAX_RETRIES )); then
+            return 1
+        fi
+        log "retrying ($attempt)"
+        attempt=$((attempt + 1))
+        sleep 1
+    done
+}
+
+function version {
+    cat "$VERSION_FILE"
+}
+
+# Nested definitions become global once the outer function runs
+setup_hooks() {
+    on_exit() {
+        log "cleaning up"
+        rm -rf "$TMP_DIR"
+    }
+    trap on_exit EXIT
+}
+
+build() {
+    local mode="$1"
+    case "$mode" in
+        release) cargo build --release ;;
+        debug) cargo build ;;
+        *) log "unknown mode: $mode"; return 1 ;;
+    esac
+}
+
+publish() {
+    retry docker push "app:$(version)"
+    git tag "v$(version)" && git push --tags
+    find dist -name '*.tar.gz' | xargs -n1 sha256sum > dist/SHA256SUMS
+}
+
+main() {
+    setup_hooks
+    TMP_DIR="$(mktemp -d)" build "$BUILD_MODE"
+    publish
+    counter=$((counter + 1))
+}
+
+main "$@"
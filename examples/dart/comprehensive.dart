@@ -0,0 +1,168 @@
+// Comprehensive Dart example for parser auditing
+// Covers classes, mixins, enums, extensions, typedefs, constructors,
+// accessors, operators, async bodies and Flutter widgets
+
+library inventory;
+
+import 'dart:async';
+import 'package:flutter/material.dart';
+import 'package:http/http.dart' as http show Client, Response;
+import 'models/product.dart' hide InternalSku;
+
+/// Library version
+const String version = '1.0.0';
+
+/// Products created in this isolate
+var instances = 0;
+
+final _registry = <String, Product>{};
+
+/// Converts a price in cents to a display string
+typedef PriceFormatter = String Function(int cents);
+
+/// Product categories
+enum Category {
+  hardware,
+  software,
+  service;
+
+  /// Human readable label
+  String get label => name.toUpperCase();
+}
+
+/// Adds change notification to any class
+mixin Observable on Object {
+  final _listeners = <void Function()>[];
+
+  void addListener(void Function() listener) => _listeners.add(listener);
+
+  void notify() {
+    for (final listener in _listeners) {
+      listener();
+    }
+  }
+}
+
+/// Something that can be priced
+abstract interface class Priced {
+  int get cents;
+}
+
+/// A product in the inventory
+class Product with Observable implements Priced, Comparable<Product> {
+  static const int maxStock = 999;
+
+  final String sku;
+  int _stock = 0;
+
+  @override
+  final int cents;
+
+  Product(this.sku, this.cents);
+
+  Product.free(String sku) : this(sku, 0);
+
+  factory Product.fromJson(Map<String, dynamic> json) {
+    return Product(json['sku'] as String, json['cents'] as int);
+  }
+
+  int get stock => _stock;
+
+  set stock(int value) {
+    _stock = value.clamp(0, maxStock);
+    notify();
+  }
+
+  bool operator ==(Object other) => other is Product && other.sku == sku;
+
+  @override
+  int compareTo(Product other) => cents.compareTo(other.cents);
+
+  void _audit() {}
+}
+
+/// Loads products from the network
+class ProductRepository {
+  ProductRepository(this._client);
+
+  final http.Client _client;
+
+  /// Fetches a single product
+  Future<Product> fetch(String sku) async {
+    final http.Response response = await _client.get(Uri.parse('/products/$sku'));
+    return Product.fromJson(decode(response.body));
+  }
+
+  /// Streams stock updates
+  Stream<int> watch(String sku) async* {
+    yield 0;
+  }
+
+  Iterable<String> skus() sync* {
+    yield* _registry.keys;
+  }
+}
+
+extension PriceFormatting on int {
+  String toPrice() => '\$${(this / 100).toStringAsFixed(2)}';
+}
+
+extension on String {
+  bool get isSku => length == 8;
+}
+
+/// Entry widget
+class InventoryApp extends StatelessWidget {
+  const InventoryApp({super.key});
+
+  @override
+  Widget build(BuildContext context) {
+    return const MaterialApp(home: InventoryPage());
+  }
+}
+
+class InventoryPage extends StatefulWidget {
+  const InventoryPage({super.key});
+
+  @override
+  State<InventoryPage> createState() => _InventoryPageState();
+}
+
+class _InventoryPageState extends State<InventoryPage> {
+  final products = <Product>[];
+
+  @override
+  void initState() {
+    super.initState();
+    _load();
+  }
+
+  Future<void> _load() async {
+    final repository = ProductRepository(http.Client());
+    final product = await repository.fetch('ABC12345');
+    setState(() => products.add(product));
+  }
+
+  /// Lists every product with its price
+  @override
+  Widget build(BuildContext context) {
+    return Scaffold(
+      appBar: AppBar(title: const Text('Inventory')),
+      body: ListView(
+        children: [
+          for (final product in products)
+            ListTile(
+              title: Text(product.sku),
+              trailing: Text(product.cents.toPrice()),
+            ),
+        ],
+      ),
+    );
+  }
+}
+
+Map<String, dynamic> decode(String body) => {};
+
+void main() {
+  runApp(const InventoryApp());
+}
@@ -0,0 +1,86 @@
+# Comprehensive Elixir example covering the constructs the parser indexes
+
+defmodule Shop.Inventory do
+  @moduledoc """
+  Stock levels for the shop, backed by an Agent.
+  """
+
+  use Agent
+  import Enum, only: [map: 2, sum: 1]
+  alias Shop.{Product, Warehouse}
+  alias Shop.Notifications, as: Notify
+  require Logger
+
+  defstruct [:sku, :quantity, location: :main]
+
+  @type stock :: %{optional(String.t()) => non_neg_integer()}
+
+  @doc "Starts the inventory agent"
+  @spec start_link(keyword()) :: Agent.on_start()
+  def start_link(opts \\ []) do
+    Agent.start_link(fn -> %{} end, name: Keyword.get(opts, :name, __MODULE__))
+  end
+
+  @doc """
+  Adds `count` units of a product.
+  """
+  def add(sku, count) when is_binary(sku) and count > 0 do
+    Agent.update(__MODULE__, &Map.update(&1, sku, count, fn n -> n + count end))
+    log_change(sku, count)
+  end
+
+  def add(_sku, _count), do: {:error, :invalid}
+
+  def total do
+    __MODULE__
+    |> Agent.get(& &1)
+    |> Map.values()
+    |> sum()
+  end
+
+  def restock(products) do
+    products
+    |> map(&Product.sku/1)
+    |> Enum.each(&Warehouse.request/1)
+  end
+
+  defp log_change(sku, count) do
+    Logger.info("stock changed")
+    Notify.broadcast({:stock, sku, count})
+  end
+
+  defmacro with_stock(sku, do: block) do
+    quote do
+      if unquote(sku) in Map.keys(Agent.get(unquote(__MODULE__), & &1)) do
+        unquote(block)
+      end
+    end
+  end
+
+  defguard is_quantity(value) when is_integer(value) and value >= 0
+
+  defdelegate fetch(sku), to: Warehouse, as: :lookup
+end
+
+defmodule Shop.OutOfStock do
+  defexception [:sku, message: "out of stock"]
+end
+
+defprotocol Shop.Priced do
+  @moduledoc "Anything with a price"
+
+  @doc "Price in cents"
+  def price(item)
+end
+
+defimpl Shop.Priced, for: [Shop.Product, Shop.Bundle] do
+  def price(item), do: item.cents
+end
+
+defmodule Shop.Product do
+  defstruct sku: nil, cents: 0
+
+  defimpl Inspect do
+    def inspect(product, _opts), do: "#Product<" <> product.sku <> ">"
+  end
+end
@@ -0,0 +1,107 @@
+# Comprehensive Terraform example covering the constructs the parser
+# indexes: variables, locals, providers, data sources, resources, module
+# calls with local and registry sources, and outputs.
+
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "region" {
+  type        = string
+  description = "AWS region to deploy into"
+  default     = "eu-west-1"
+}
+
+variable "environment" {
+  type        = string
+  description = "Deployment stage, used in names and tags"
+}
+
+variable "web_instances" {
+  type    = number
+  default = 2
+}
+
+/*
+ * Naming and tagging shared by every resource
+ */
+locals {
+  prefix = "shop-${var.environment}"
+  tags = {
+    Environment = var.environment
+    ManagedBy   = "terraform"
+  }
+}
+
+provider "aws" {
+  region = var.region
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+  owners      = ["099720109477"]
+
+  filter {
+    name   = "name"
+    values = ["ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-*"]
+  }
+}
+
+// Network for the whole stack
+module "vpc" {
+  source = "./modules/vpc"
+
+  name       = local.prefix
+  cidr_block = "10.0.0.0/16"
+  tags       = local.tags
+}
+
+module "bucket" {
+  source  = "terraform-aws-modules/s3-bucket/aws"
+  version = "4.1.0"
+
+  bucket = "${local.prefix}-assets"
+}
+
+resource "aws_security_group" "web" {
+  name   = "${local.prefix}-web"
+  vpc_id = module.vpc.vpc_id
+
+  dynamic "ingress" {
+    for_each = [80, 443]
+    content {
+      from_port   = ingress.value
+      to_port     = ingress.value
+      protocol    = "tcp"
+      cidr_blocks = ["0.0.0.0/0"]
+    }
+  }
+}
+
+# Web frontend instances
+resource "aws_instance" "web" {
+  count                  = var.web_instances
+  ami                    = data.aws_ami.ubuntu.id
+  instance_type          = "t3.small"
+  subnet_id              = module.vpc.public_subnet_ids[count.index]
+  vpc_security_group_ids = [aws_security_group.web.id]
+  tags                   = merge(local.tags, { Name = "${local.prefix}-web-${count.index}" })
+
+  depends_on = [module.vpc]
+}
+
+output "web_ips" {
+  description = "Public addresses of the web instances"
+  value       = aws_instance.web[*].public_ip
+}
+
+output "assets_bucket" {
+  value = module.bucket.s3_bucket_id
+}
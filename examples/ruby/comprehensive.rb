@@ -0,0 +1,134 @@
+# Comprehensive Ruby example for parser auditing
+# Covers classes, modules, singleton methods, accessors, mixins and visibility
+
+require "json"
+require "set"
+require_relative "support/money"
+
+# Top-level constant
+VERSION = "1.0.0"
+
+# Mixin module used as a concern
+module Auditable
+  def audit_log
+    @audit_log ||= []
+  end
+
+  def record(event)
+    audit_log << event
+  end
+end
+
+module Billing
+  # Namespaced constant
+  DEFAULT_CURRENCY = "EUR"
+
+  # Base class for persisted records
+  class Record
+    attr_reader :id
+
+    def initialize(id)
+      @id = id
+    end
+
+    def persisted?
+      !id.nil?
+    end
+  end
+
+  # An invoice line
+  class LineItem < Record
+    attr_accessor :quantity, :unit_price
+    attr_writer :note
+
+    def subtotal
+      quantity * unit_price
+    end
+  end
+
+  # Invoice aggregate
+  class Invoice < Billing::Record
+    include Comparable
+    include Auditable
+    extend Forwardable
+    prepend Validation
+
+    def_delegators :@items, :each, :size
+
+    TAX_RATE = 0.2
+
+    class << self
+      # Alternative constructor
+      def build(attrs)
+        new(attrs.fetch(:id))
+      end
+
+      def empty
+        build(id: nil)
+      end
+    end
+
+    def self.from_json(payload)
+      build(JSON.parse(payload, symbolize_names: true))
+    end
+
+    def initialize(id)
+      super(id)
+      @items = []
+    end
+
+    def add(item)
+      @items << item
+      record(:item_added)
+      self
+    end
+
+    def total
+      @items.sum(&:subtotal) * (1 + TAX_RATE)
+    end
+
+    def <=>(other)
+      total <=> other.total
+    end
+
+    protected
+
+    def raw_items
+      @items
+    end
+
+    private
+
+    def recalculate!
+      Money.round(total)
+    end
+
+    public
+
+    def to_s
+      "Invoice(#{id})"
+    end
+
+    private def secret_token
+      SecureRandom.hex(8)
+    end
+
+    def helper_one; end
+    def helper_two; end
+    private :helper_one, :helper_two
+  end
+end
+
+=begin
+Block documentation for the top-level helper
+=end
+def format_invoice(invoice)
+  invoice.to_s
+end
+
+def main
+  invoice = Billing::Invoice.new(1)
+  item = Billing::LineItem.new(2)
+  invoice.add(item)
+  puts format_invoice(invoice)
+end
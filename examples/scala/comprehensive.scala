@@ -0,0 +1,120 @@
+// Comprehensive Scala example for parser auditing
+// Covers classes, case classes, objects, traits, enums, givens/implicits,
+// extension methods, type aliases and visibility modifiers
+
+package com.example.billing
+
+import scala.collection.mutable
+import scala.collection.mutable.{Map => MutableMap, ListBuffer}
+import scala.concurrent.{ExecutionContext, Future}
+import scala.util._
+
+// Top-level type alias
+type Cents = Long
+
+/** Anything with a price */
+trait Priced {
+  def total: Cents
+}
+
+/** Adds percentage discounts to priced things */
+trait Discountable extends Priced {
+  def discount(percent: Int): Cents = total * percent / 100
+}
+
+trait Show[A] {
+  def show(value: A): String
+}
+
+/** Base class for persisted documents */
+abstract class Document(val kind: String) {
+  def id: String
+
+  protected def touch(): Unit = ()
+}
+
+/** A single invoice line */
+case class LineItem(sku: String, cents: Cents)
+
+case object EmptyCart
+
+/** An invoice with line items */
+final class Invoice(val items: List[LineItem])
+    extends Document("invoice")
+    with Discountable
+    with Serializable {
+
+  val currency: String = "EUR"
+  var revision: Int = 0
+  private val history = ListBuffer.empty[String]
+  private[billing] val cache = MutableMap.empty[String, Cents]
+
+  def id: String = s"inv-${items.hashCode}"
+
+  def total: Cents = items.map(_.cents).sum
+
+  def add(item: LineItem): Invoice = {
+    val updated = new Invoice(items :+ item)
+    updated.touch()
+    updated
+  }
+
+  private def record(event: String): Unit = history += event
+
+  override def toString: String = s"Invoice($id, $total)"
+}
+
+/** Companion object with factories and implicits */
+object Invoice {
+  val Empty: Invoice = new Invoice(Nil)
+
+  def apply(items: LineItem*): Invoice = new Invoice(items.toList)
+
+  implicit val invoiceOrdering: Ordering[Invoice] = Ordering.by(_.total)
+
+  implicit def itemsToInvoice(items: List[LineItem]): Invoice = Invoice(items: _*)
+
+  implicit class RichInvoice(invoice: Invoice) {
+    def isEmpty: Boolean = invoice.items.isEmpty
+  }
+}
+
+/** Payment lifecycle */
+enum Status:
+  case Draft, Sent, Paid
+  case Refunded(reason: String)
+
+enum Currency(val symbol: String):
+  case EUR extends Currency("€")
+  case USD extends Currency("$")
+
+// Scala 3 givens
+given Ordering[LineItem] = Ordering.by(_.cents)
+
+given invoiceShow: Show[Invoice] with {
+  def show(value: Invoice): String = value.toString
+}
+
+given executionContext: ExecutionContext = ExecutionContext.global
+
+// Scala 3 extension methods
+extension (item: LineItem)
+  def formatted: String = s"${item.sku}: ${item.cents}"
+  def isFree: Boolean = item.cents == 0
+
+// Top-level definitions
+val DefaultCurrency: Currency = Currency.EUR
+
+def summarize(invoice: Invoice)(using show: Show[Invoice]): String =
+  show.show(invoice)
+
+def fetch(id: String)(implicit ec: ExecutionContext): Future[Invoice] =
+  Future(Invoice.Empty)
+
+object Main {
+  def main(args: Array[String]): Unit = {
+    val invoice = Invoice(LineItem("A-1", 1200), LineItem("B-2", 800))
+    println(summarize(invoice))
+    println(invoice.discount(10))
+  }
+}
@@ -0,0 +1,82 @@
+-- Comprehensive SQL example covering the constructs the parser indexes:
+-- tables and their columns, views, materialized views, enum and composite
+-- types, functions, procedures and the statements that read or write tables.
+
+BEGIN;
+
+-- Customers who can place orders
+CREATE TABLE customers (
+    id bigserial PRIMARY KEY,
+    email text NOT NULL UNIQUE,
+    display_name text,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TYPE order_state AS ENUM ('pending', 'paid', 'shipped', 'cancelled');
+
+CREATE TYPE money_amount AS (
+    currency char(3),
+    cents bigint
+);
+
+CREATE TABLE sales.orders (
+    id bigserial PRIMARY KEY,
+    customer_id bigint NOT NULL REFERENCES customers(id),
+    state order_state NOT NULL DEFAULT 'pending',
+    total numeric(12, 2) NOT NULL DEFAULT 0
+);
+
+CREATE TABLE sales.order_lines (
+    order_id bigint NOT NULL REFERENCES sales.orders(id) ON DELETE CASCADE,
+    sku text NOT NULL,
+    quantity integer NOT NULL CHECK (quantity > 0),
+    price numeric(12, 2) NOT NULL
+);
+
+CREATE TABLE audit_log (
+    id bigserial PRIMARY KEY,
+    entity text NOT NULL,
+    payload jsonb
+);
+
+COMMIT;
+
+/* Orders with their customer's email */
+CREATE VIEW order_summaries AS
+SELECT o.id, c.email, o.state, o.total
+FROM sales.orders o
+JOIN customers c ON c.id = o.customer_id;
+
+-- Revenue per customer, refreshed nightly
+CREATE MATERIALIZED VIEW customer_revenue AS
+WITH paid AS (
+    SELECT customer_id, total FROM sales.orders WHERE state = 'paid'
+)
+SELECT customer_id, sum(total) AS revenue
+FROM paid
+GROUP BY customer_id;
+
+-- Recomputes an order's total from its lines
+CREATE FUNCTION order_total(order_id bigint) RETURNS numeric AS $$
+    SELECT coalesce(sum(quantity * price), 0)
+    FROM sales.order_lines
+    WHERE order_lines.order_id = order_total.order_id;
+$$ LANGUAGE sql STABLE;
+
+-- Moves cancelled orders into the audit log
+CREATE OR REPLACE PROCEDURE archive_cancelled() LANGUAGE plpgsql AS $$
+BEGIN
+    INSERT INTO audit_log (entity, payload)
+    SELECT 'order', to_jsonb(o) FROM sales.orders o WHERE o.state = 'cancelled';
+    DELETE FROM sales.orders WHERE state = 'cancelled';
+END;
+$$;
+
+-- Migration: track last login
+ALTER TABLE customers ADD COLUMN last_login_at timestamptz;
+
+UPDATE sales.orders SET total = order_total(id) WHERE total = 0;
+
+INSERT INTO customers (email, display_name) VALUES ('ops@example.com', 'Operations');
+
+CALL archive_cancelled();
@@ -0,0 +1,138 @@
+//! Comprehensive Zig example for parser auditing
+//! Covers structs, enums, unions, error sets, opaque types, generic type
+//! constructors, comptime blocks, tests and pub visibility
+
+const std = @import("std");
+const mem = @import("std").mem;
+const Allocator = std.mem.Allocator;
+const vector = @import("math/vector.zig");
+
+/// Library version
+pub const version = "1.0.0";
+
+/// Global counter (file-private)
+var instances: usize = 0;
+
+/// Errors raised by the inventory
+pub const InventoryError = error{
+    OutOfStock,
+    InvalidSku,
+};
+
+/// Product categories
+pub const Category = enum(u8) {
+    hardware,
+    software,
+    service,
+
+    pub fn label(self: Category) []const u8 {
+        return switch (self) {
+            .hardware => "Hardware",
+            .software => "Software",
+            .service => "Service",
+        };
+    }
+};
+
+/// Price in one of several representations
+pub const Price = union(enum) {
+    cents: u64,
+    free: void,
+};
+
+/// Handle to a foreign resource
+pub const Handle = opaque {};
+
+/// A product in the inventory
+pub const Product = struct {
+    sku: []const u8,
+    category: Category,
+    price: Price,
+    stock: u32 = 0,
+
+    pub const default_stock: u32 = 10;
+
+    /// Create a product with default stock
+    pub fn init(sku: []const u8, category: Category, price: Price) Product {
+        instances += 1;
+        return .{ .sku = sku, .category = category, .price = price, .stock = default_stock };
+    }
+
+    pub fn take(self: *Product, count: u32) InventoryError!void {
+        if (self.stock < count) return InventoryError.OutOfStock;
+        self.stock -= count;
+    }
+
+    fn isFree(self: Product) bool {
+        return self.price == .free;
+    }
+};
+
+/// Generic bounded queue
+pub fn Queue(comptime T: type, comptime capacity: usize) type {
+    return struct {
+        const Self = @This();
+
+        items: [capacity]T = undefined,
+        len: usize = 0,
+
+        pub fn push(self: *Self, item: T) !void {
+            if (self.len == capacity) return error.Full;
+            self.items[self.len] = item;
+            self.len += 1;
+        }
+
+        pub fn pop(self: *Self) ?T {
+            if (self.len == 0) return null;
+            self.len -= 1;
+            return self.items[self.len];
+        }
+    };
+}
+
+/// Inventory backed by an allocator
+pub const Inventory = struct {
+    allocator: Allocator,
+    products: std.ArrayList(Product),
+
+    pub fn init(allocator: Allocator) Inventory {
+        return .{
+            .allocator = allocator,
+            .products = std.ArrayList(Product).init(allocator),
+        };
+    }
+
+    pub fn deinit(self: *Inventory) void {
+        self.products.deinit();
+    }
+
+    pub fn add(self: *Inventory, product: Product) !void {
+        try self.products.append(product);
+    }
+};
+
+comptime {
+    std.debug.assert(Product.default_stock > 0);
+}
+
+fn helper(value: u32) u32 {
+    return value * 2;
+}
+
+pub fn main() !void {
+    var gpa = std.heap.GeneralPurposeAllocator(.{}){};
+    defer _ = gpa.deinit();
+
+    var inventory = Inventory.init(gpa.allocator());
+    defer inventory.deinit();
+
+    const widget = Product.init("W-1", .hardware, .{ .cents = 1999 });
+    try inventory.add(widget);
+    _ = helper(3);
+}
+
+test "queue push and pop" {
+    var queue = Queue(u32, 4){};
+    try queue.push(1);
+    try std.testing.expectEqual(@as(?u32, 1), queue.pop());
+}
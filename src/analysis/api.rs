@@ -0,0 +1,309 @@
+//! The API surface of the indexed code
+//!
+//! The surface is every symbol at or above a visibility: `pub` items of a
+//! crate, `export`ed TypeScript declarations, Python names without a
+//! leading underscore. Locals, parameters and test code are not part of
+//! it, nor are members of a type the surface leaves out. Rows carry no line
+//! numbers and signatures are kept on one line, so the listings of two
+//! releases diff to the changes of the API.
+
+use crate::analysis::breakdown::is_documented;
+use crate::analysis::test_map::is_test_function;
+use crate::export::GraphFilter;
+use crate::symbol::Visibility;
+use crate::{ScopeContext, Symbol, SymbolKind};
+use serde::Serialize;
+use std::collections::HashSet;
+use std::fmt;
+
+/// One item of the API surface
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct ApiItem {
+    /// `Parser::parse` for a member, the bare name otherwise
+    pub name: String,
+    pub kind: SymbolKind,
+    pub visibility: Visibility,
+    /// The signature on one line
+    pub signature: Option<String>,
+    pub module: Option<String>,
+    pub file: String,
+    pub line: u32,
+}
+
+impl fmt::Display for ApiItem {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "{:<7}  {:<9}  {}",
+            self.visibility.as_str(),
+            format!("{:?}", self.kind).to_lowercase(),
+            self.name
+        )?;
+        if let Some(signature) = &self.signature {
+            write!(f, "  {signature}")?;
+        }
+        Ok(())
+    }
+}
+
+/// How visible a symbol is, most visible first: the API at `Crate` holds
+/// the public and crate-wide symbols
+fn rank(visibility: Visibility) -> u8 {
+    match visibility {
+        Visibility::Public => 0,
+        Visibility::Crate => 1,
+        Visibility::Module => 2,
+        Visibility::Private => 3,
+    }
+}
+
+/// Whether a symbol is test code: a test function or a symbol of a test
+/// module or file
+fn is_test_code(symbol: &Symbol) -> bool {
+    let file = symbol.file_path.trim_start_matches("./");
+    is_test_function(symbol)
+        || file.starts_with("tests/")
+        || file.contains("/tests/")
+        || symbol.module_path.as_deref().is_some_and(|module| {
+            module
+                .split([':', '.', '/'])
+                .any(|segment| segment == "tests" || segment == "test")
+        })
+}
+
+pub(crate) fn in_scope(symbol: &Symbol) -> bool {
+    !matches!(
+        symbol.scope_context,
+        Some(ScopeContext::Local { .. } | ScopeContext::Parameter)
+    ) && !matches!(symbol.kind, SymbolKind::Parameter)
+}
+
+/// The type a symbol is a member of
+pub(crate) fn owner_of(symbol: &Symbol) -> Option<&str> {
+    match &symbol.scope_context {
+        Some(ScopeContext::ClassMember {
+            class_name: Some(class_name),
+        }) => Some(class_name.as_ref()),
+        _ => None,
+    }
+}
+
+/// `Parser::parse` for a member, the bare name otherwise
+pub(crate) fn qualified_name(symbol: &Symbol) -> String {
+    match owner_of(symbol) {
+        Some(owner) => format!("{owner}::{}", symbol.name),
+        None => symbol.name.to_string(),
+    }
+}
+
+/// The signature with its whitespace collapsed
+pub(crate) fn one_line_signature(symbol: &Symbol) -> Option<String> {
+    symbol
+        .signature
+        .as_deref()
+        .map(|signature| signature.split_whitespace().collect::<Vec<_>>().join(" "))
+}
+
+/// The symbols the filter accepts at or above `visibility`, by module, then
+/// name
+pub fn api_surface(
+    symbols: &[Symbol],
+    visibility: Visibility,
+    filter: &GraphFilter,
+) -> Vec<ApiItem> {
+    surface_where(symbols, visibility, filter, |_| true)
+}
+
+/// The items of the API surface without a doc comment
+pub fn undocumented_api(
+    symbols: &[Symbol],
+    visibility: Visibility,
+    filter: &GraphFilter,
+) -> Vec<ApiItem> {
+    surface_where(symbols, visibility, filter, |symbol| !is_documented(symbol))
+}
+
+/// The API surface, of the symbols `keep` takes
+fn surface_where(
+    symbols: &[Symbol],
+    visibility: Visibility,
+    filter: &GraphFilter,
+    keep: impl Fn(&Symbol) -> bool,
+) -> Vec<ApiItem> {
+    let visible = |symbol: &Symbol| {
+        rank(symbol.visibility) <= rank(visibility) && in_scope(symbol) && !is_test_code(symbol)
+    };
+    // Types left out take their members with them
+    let hidden_types: HashSet<(&str, &str)> = symbols
+        .iter()
+        .filter(|symbol| {
+            matches!(
+                symbol.kind,
+                SymbolKind::Struct
+                    | SymbolKind::Enum
+                    | SymbolKind::Trait
+                    | SymbolKind::Interface
+                    | SymbolKind::Class
+            ) && !visible(symbol)
+        })
+        .map(|symbol| (&*symbol.file_path, symbol.name.as_ref()))
+        .collect();
+
+    let mut items: Vec<ApiItem> = symbols
+        .iter()
+        .filter(|symbol| filter.accepts(symbol) && visible(symbol) && keep(symbol))
+        .filter_map(|symbol| {
+            let owner = owner_of(symbol);
+            if owner.is_some_and(|owner| hidden_types.contains(&(&*symbol.file_path, owner))) {
+                return None;
+            }
+            Some(ApiItem {
+                name: qualified_name(symbol),
+                kind: symbol.kind,
+                visibility: symbol.visibility,
+                signature: one_line_signature(symbol),
+                module: symbol.module_path.as_deref().map(str::to_string),
+                file: symbol.file_path.to_string(),
+                line: symbol.range.start_line + 1,
+            })
+        })
+        .collect();
+    items.sort_by(|a, b| {
+        (&a.module, &a.file, &a.name, &a.signature).cmp(&(
+            &b.module,
+            &b.file,
+            &b.name,
+            &b.signature,
+        ))
+    });
+    items
+}
+
+/// The surface by module, one item a row
+pub fn render_api(items: &[ApiItem]) -> String {
+    let mut out = String::new();
+    let mut current: Option<(&Option<String>, &str)> = None;
+    for item in items {
+        let group = (&item.module, item.file.as_str());
+        if current != Some(group) {
+            if current.is_some() {
+                out.push('\n');
+            }
+            match &item.module {
+                Some(module) => out.push_str(&format!("{module} ({})\n", item.file)),
+                None => out.push_str(&format!("{}\n", item.file)),
+            }
+            current = Some(group);
+        }
+        out.push_str(&format!("  {item}\n"));
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{FileId, Range, SymbolId};
+
+    fn symbol(id: u32, name: &str, kind: SymbolKind, visibility: Visibility) -> Symbol {
+        Symbol::new(
+            SymbolId::new(id).unwrap(),
+            name,
+            kind,
+            FileId::new(1).unwrap(),
+            Range::new(id, 0, id, 10),
+        )
+        .with_file_path("src/config.rs")
+        .with_module_path("crate::config")
+        .with_visibility(visibility)
+        .with_language_id(crate::parsing::LanguageId::new("rust"))
+    }
+
+    fn member(symbol: Symbol, class_name: &str) -> Symbol {
+        symbol.with_scope(ScopeContext::ClassMember {
+            class_name: Some(class_name.into()),
+        })
+    }
+
+    fn symbols() -> Vec<Symbol> {
+        vec![
+            symbol(1, "Settings", SymbolKind::Struct, Visibility::Public)
+                .with_signature("pub struct Settings {\n    debug: bool,\n}"),
+            member(
+                symbol(2, "load", SymbolKind::Method, Visibility::Public)
+                    .with_signature("pub fn load(path: &Path) -> Self"),
+                "Settings",
+            ),
+            member(
+                symbol(3, "merge", SymbolKind::Method, Visibility::Private),
+                "Settings",
+            ),
+            symbol(4, "Cache", SymbolKind::Struct, Visibility::Crate),
+            member(
+                symbol(5, "clear", SymbolKind::Method, Visibility::Public),
+                "Cache",
+            ),
+            symbol(6, "scratch", SymbolKind::Variable, Visibility::Public).with_scope(
+                ScopeContext::Local {
+                    hoisted: false,
+                    parent_name: None,
+                    parent_kind: None,
+                },
+            ),
+            symbol(7, "test_load", SymbolKind::Function, Visibility::Public)
+                .with_signature("#[test]\nfn test_load()"),
+        ]
+    }
+
+    fn names(items: &[ApiItem]) -> Vec<&str> {
+        items.iter().map(|item| item.name.as_str()).collect()
+    }
+
+    #[test]
+    fn test_public_surface() {
+        let items = api_surface(&symbols(), Visibility::Public, &GraphFilter::default());
+
+        assert_eq!(
+            names(&items),
+            ["Settings", "Settings::load"],
+            "no private members, members of crate types, locals or tests"
+        );
+        assert_eq!(
+            items[0].signature.as_deref(),
+            Some("pub struct Settings { debug: bool, }")
+        );
+    }
+
+    #[test]
+    fn test_wider_visibility_keeps_internal_items() {
+        let items = api_surface(&symbols(), Visibility::Crate, &GraphFilter::default());
+
+        assert_eq!(
+            names(&items),
+            ["Cache", "Cache::clear", "Settings", "Settings::load"]
+        );
+        let everything = api_surface(&symbols(), Visibility::Private, &GraphFilter::default());
+        assert!(names(&everything).contains(&"Settings::merge"));
+    }
+
+    #[test]
+    fn test_undocumented_api() {
+        let mut symbols = symbols();
+        symbols[0] = symbols[0].clone().with_doc("Settings of a project.");
+        let items = undocumented_api(&symbols, Visibility::Public, &GraphFilter::default());
+
+        assert_eq!(names(&items), ["Settings::load"]);
+    }
+
+    #[test]
+    fn test_render_api() {
+        let items = api_surface(&symbols(), Visibility::Public, &GraphFilter::default());
+
+        assert_eq!(
+            render_api(&items),
+            "crate::config (src/config.rs)\n  \
+             public   struct     Settings  pub struct Settings { debug: bool, }\n  \
+             public   method     Settings::load  pub fn load(path: &Path) -> Self\n"
+        );
+    }
+}
@@ -0,0 +1,253 @@
+//! Symbol counts, doc coverage and complexity by group
+//!
+//! The totals of `codanna stats` split by language, directory or symbol
+//! kind, so a team can see where documentation is thin and follow the
+//! coverage from one index to the next. Locals and parameters are not
+//! counted: nobody documents them, and they would drown the coverage of
+//! the symbols that matter.
+
+use super::modules::module_of;
+use crate::Symbol;
+use crate::SymbolKind;
+use crate::export::GraphFilter;
+use crate::symbol::ScopeContext;
+use serde::Serialize;
+use std::collections::BTreeMap;
+use std::str::FromStr;
+
+/// What the symbols are grouped by
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Serialize)]
+#[serde(rename_all = "lowercase")]
+pub enum Grouping {
+    Language,
+    /// The directory of the file, cut to a depth
+    Directory,
+    Kind,
+}
+
+impl Grouping {
+    pub fn as_str(self) -> &'static str {
+        match self {
+            Self::Language => "language",
+            Self::Directory => "directory",
+            Self::Kind => "kind",
+        }
+    }
+}
+
+impl FromStr for Grouping {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "language" | "lang" => Ok(Self::Language),
+            "directory" | "dir" => Ok(Self::Directory),
+            "kind" => Ok(Self::Kind),
+            _ => Err(format!(
+                "unknown grouping '{s}'; expected one of: language, directory, kind"
+            )),
+        }
+    }
+}
+
+/// The symbols of one group
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct GroupStats {
+    pub name: String,
+    pub symbols: usize,
+    /// Symbols with a doc comment
+    pub documented: usize,
+    /// Percentage of the symbols with a doc comment
+    pub doc_coverage: f32,
+    /// Functions and methods measured at index time
+    pub functions: usize,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub average_complexity: Option<f32>,
+}
+
+/// Whether a symbol counts towards the coverage
+pub fn is_documentable(symbol: &Symbol) -> bool {
+    let local = matches!(
+        symbol.scope_context,
+        Some(ScopeContext::Local { .. } | ScopeContext::Parameter)
+    );
+    !local && symbol.kind != SymbolKind::Parameter
+}
+
+/// Whether a symbol has a doc comment with some text in it
+pub fn is_documented(symbol: &Symbol) -> bool {
+    symbol
+        .doc_comment
+        .as_deref()
+        .is_some_and(|doc| !doc.trim().is_empty())
+}
+
+/// The symbols the filter accepts, grouped by `grouping`, largest group
+/// first; directories are cut to `depth` components, as in
+/// [`module_of`]
+pub fn breakdown(
+    symbols: &[Symbol],
+    filter: &GraphFilter,
+    grouping: Grouping,
+    depth: Option<usize>,
+) -> Vec<GroupStats> {
+    #[derive(Default)]
+    struct Totals {
+        symbols: usize,
+        documented: usize,
+        functions: usize,
+        complexity: u64,
+    }
+
+    let mut groups: BTreeMap<String, Totals> = BTreeMap::new();
+    for symbol in symbols {
+        if !is_documentable(symbol) || !filter.accepts(symbol) {
+            continue;
+        }
+        let name = match grouping {
+            Grouping::Language => symbol
+                .language_id
+                .map_or("unknown", |id| id.as_str())
+                .to_string(),
+            Grouping::Directory => module_of(&symbol.file_path, depth),
+            Grouping::Kind => symbol.kind_name(),
+        };
+        let totals = groups.entry(name).or_default();
+        totals.symbols += 1;
+        if is_documented(symbol) {
+            totals.documented += 1;
+        }
+        if let Some(metrics) = symbol.metrics {
+            totals.functions += 1;
+            totals.complexity += u64::from(metrics.complexity);
+        }
+    }
+
+    let mut rows: Vec<GroupStats> = groups
+        .into_iter()
+        .map(|(name, totals)| GroupStats {
+            name,
+            symbols: totals.symbols,
+            documented: totals.documented,
+            doc_coverage: percentage(totals.documented, totals.symbols),
+            functions: totals.functions,
+            average_complexity: (totals.functions > 0)
+                .then(|| totals.complexity as f32 / totals.functions as f32),
+        })
+        .collect();
+    rows.sort_by(|a, b| b.symbols.cmp(&a.symbols).then_with(|| a.name.cmp(&b.name)));
+    rows
+}
+
+/// `part` of `whole` in percent, 0 of nothing
+pub fn percentage(part: usize, whole: usize) -> f32 {
+    if whole == 0 {
+        0.0
+    } else {
+        part as f32 * 100.0 / whole as f32
+    }
+}
+
+/// The rows of a breakdown as a table, headed by the grouping
+pub fn render_breakdown(grouping: Grouping, rows: &[GroupStats]) -> String {
+    let width = rows
+        .iter()
+        .map(|row| row.name.len())
+        .max()
+        .unwrap_or(0)
+        .max(grouping.as_str().len());
+    let mut out = format!(
+        "  {:<width$}  symbols  documented  coverage  functions  avg complexity\n",
+        grouping.as_str()
+    );
+    for row in rows {
+        let complexity = row
+            .average_complexity
+            .map_or_else(|| "-".to_string(), |average| format!("{average:.1}"));
+        out.push_str(&format!(
+            "  {:<width$}  {:>7}  {:>10}  {:>7.1}%  {:>9}  {:>14}\n",
+            row.name, row.symbols, row.documented, row.doc_coverage, row.functions, complexity
+        ));
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::symbol::SymbolMetrics;
+    use crate::{FileId, Range, SymbolId};
+
+    fn symbol(id: u32, file: &str, kind: SymbolKind, doc: Option<&str>) -> Symbol {
+        let mut symbol = Symbol::new(
+            SymbolId::new(id).unwrap(),
+            format!("s{id}"),
+            kind,
+            FileId::new(1).unwrap(),
+            Range::new(id, 0, id, 1),
+        )
+        .with_file_path(file);
+        if let Some(doc) = doc {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol
+    }
+
+    #[test]
+    fn test_breakdown_by_directory_and_kind() {
+        let mut measured = symbol(1, "src/a/x.rs", SymbolKind::Function, Some("Does x."));
+        measured.metrics = Some(SymbolMetrics {
+            complexity: 4,
+            lines: 10,
+            parameters: 1,
+        });
+        let symbols = vec![
+            measured,
+            symbol(2, "src/a/y.rs", SymbolKind::Struct, None),
+            symbol(3, "src/b/z.rs", SymbolKind::Function, Some("  ")),
+            symbol(4, "src/b/z.rs", SymbolKind::Parameter, None),
+        ];
+
+        let rows = breakdown(
+            &symbols,
+            &GraphFilter::default(),
+            Grouping::Directory,
+            Some(2),
+        );
+        assert_eq!(rows.len(), 2);
+        assert_eq!(
+            (rows[0].name.as_str(), rows[0].symbols, rows[0].documented),
+            ("src/a", 2, 1)
+        );
+        assert_eq!(rows[0].doc_coverage, 50.0);
+        assert_eq!(rows[0].average_complexity, Some(4.0));
+        assert_eq!((rows[1].name.as_str(), rows[1].documented), ("src/b", 0));
+        assert_eq!(rows[1].average_complexity, None);
+
+        let rows = breakdown(&symbols, &GraphFilter::default(), Grouping::Kind, None);
+        let kinds: Vec<(&str, usize)> = rows
+            .iter()
+            .map(|row| (row.name.as_str(), row.symbols))
+            .collect();
+        assert_eq!(kinds, [("Function", 2), ("Struct", 1)]);
+        assert!(render_breakdown(Grouping::Kind, &rows).contains("Function"));
+    }
+
+    #[test]
+    fn test_breakdown_counts_framework_kinds() {
+        let symbols = vec![
+            symbol(1, "app/views.py", SymbolKind::Function, None)
+                .with_framework_kind("django_view"),
+            symbol(2, "app/views.py", SymbolKind::Function, None)
+                .with_framework_kind("django_view"),
+            symbol(3, "app/util.py", SymbolKind::Function, None),
+        ];
+
+        let rows = breakdown(&symbols, &GraphFilter::default(), Grouping::Kind, None);
+        let kinds: Vec<(&str, usize)> = rows
+            .iter()
+            .map(|row| (row.name.as_str(), row.symbols))
+            .collect();
+        assert_eq!(kinds, [("django_view", 2), ("Function", 1)]);
+    }
+}
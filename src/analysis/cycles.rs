@@ -0,0 +1,362 @@
+//! Circular dependencies between modules or symbols
+//!
+//! Each strongly connected component of the graph is one tangle of mutual
+//! dependencies. A component is reported through its shortest cycle, the
+//! smallest set of edges to look at when breaking it.
+
+use crate::export::{EdgeKind, SymbolGraph, UnknownExportValue};
+use serde::Serialize;
+use std::collections::{BTreeMap, BTreeSet, HashMap, VecDeque};
+use std::fmt;
+use std::str::FromStr;
+
+/// What the nodes of a cycle are
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum CycleLevel {
+    /// Files, depending on each other through the edges of their symbols
+    Module,
+    /// Symbols
+    Symbol,
+}
+
+impl CycleLevel {
+    /// Relationships followed when none are selected
+    pub fn default_relations(self) -> &'static [EdgeKind] {
+        match self {
+            Self::Module => &[EdgeKind::Imports, EdgeKind::Calls],
+            Self::Symbol => &[EdgeKind::Calls],
+        }
+    }
+
+    /// Name of a node of this level
+    pub fn noun(self) -> &'static str {
+        match self {
+            Self::Module => "module",
+            Self::Symbol => "symbol",
+        }
+    }
+}
+
+impl FromStr for CycleLevel {
+    type Err = UnknownExportValue;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "module" | "file" => Ok(Self::Module),
+            "symbol" => Ok(Self::Symbol),
+            _ => Err(UnknownExportValue {
+                option: "level",
+                input: s.to_string(),
+                expected: "module, symbol",
+            }),
+        }
+    }
+}
+
+/// A node of a cycle
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct CycleNode {
+    /// The file for modules, the symbol's name for symbols
+    pub name: String,
+    pub file: String,
+}
+
+/// The shortest cycle of a strongly connected component
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct Cycle {
+    /// Nodes in dependency order; the last depends on the first
+    pub path: Vec<CycleNode>,
+    /// Relationships of each step: `relations[i]` leads from `path[i]` to
+    /// the next node
+    pub relations: Vec<Vec<EdgeKind>>,
+    /// Nodes of the component, at least the length of the cycle
+    pub component_size: usize,
+}
+
+impl fmt::Display for Cycle {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let label = |node: &CycleNode| {
+            if node.name == node.file {
+                node.name.clone()
+            } else {
+                format!("{} ({})", node.name, node.file)
+            }
+        };
+        writeln!(f, "  {}", label(&self.path[0]))?;
+        for (step, relations) in self.relations.iter().enumerate() {
+            let next = &self.path[(step + 1) % self.path.len()];
+            let relations: Vec<&str> = relations.iter().map(|kind| kind.as_str()).collect();
+            writeln!(f, "  -> {} [{}]", label(next), relations.join(", "))?;
+        }
+        Ok(())
+    }
+}
+
+/// The graph with the nodes of one level
+struct Reduced {
+    nodes: Vec<CycleNode>,
+    edges: Vec<BTreeMap<usize, BTreeSet<EdgeKind>>>,
+}
+
+impl Reduced {
+    fn new(graph: &SymbolGraph, level: CycleLevel) -> Self {
+        // Key of the reduced node each exported node belongs to: its file
+        // for modules, itself for symbols (importing files have none)
+        let keys: Vec<Option<&str>> = graph
+            .nodes
+            .iter()
+            .map(|node| match level {
+                CycleLevel::Module => Some(node.file.as_str()),
+                CycleLevel::Symbol if node.kind == "File" => None,
+                CycleLevel::Symbol => Some(node.id.as_str()),
+            })
+            .collect();
+
+        // Number nodes by file, then name, so results are stable
+        let mut distinct: BTreeMap<&str, CycleNode> = BTreeMap::new();
+        for (node, key) in graph.nodes.iter().zip(&keys) {
+            if let Some(key) = key {
+                distinct.entry(*key).or_insert_with(|| CycleNode {
+                    name: match level {
+                        CycleLevel::Module => node.file.clone(),
+                        CycleLevel::Symbol => node.label.clone(),
+                    },
+                    file: node.file.clone(),
+                });
+            }
+        }
+        let mut sorted: Vec<(&str, CycleNode)> = distinct.into_iter().collect();
+        sorted.sort_by(|(_, a), (_, b)| (&a.file, &a.name).cmp(&(&b.file, &b.name)));
+        let number: HashMap<&str, usize> = sorted
+            .iter()
+            .enumerate()
+            .map(|(number, (key, _))| (*key, number))
+            .collect();
+        let position: Vec<Option<usize>> =
+            keys.iter().map(|key| key.map(|key| number[key])).collect();
+        let nodes: Vec<CycleNode> = sorted.into_iter().map(|(_, node)| node).collect();
+
+        let mut edges = vec![BTreeMap::<usize, BTreeSet<EdgeKind>>::new(); nodes.len()];
+        for edge in &graph.edges {
+            if let (Some(from), Some(to)) = (position[edge.from], position[edge.to]) {
+                if from != to {
+                    edges[from].entry(to).or_default().insert(edge.kind);
+                }
+            }
+        }
+        Self { nodes, edges }
+    }
+
+    /// Strongly connected components with more than one node (Tarjan's
+    /// algorithm, without recursion so deep graphs don't overflow the stack)
+    fn components(&self) -> Vec<Vec<usize>> {
+        let adjacency: Vec<Vec<usize>> = self
+            .edges
+            .iter()
+            .map(|targets| targets.keys().copied().collect())
+            .collect();
+        let count = adjacency.len();
+        let mut index = vec![usize::MAX; count];
+        let mut low = vec![0; count];
+        let mut on_stack = vec![false; count];
+        let mut stack = Vec::new();
+        let mut components = Vec::new();
+        let mut next = 0;
+
+        for root in 0..count {
+            if index[root] != usize::MAX {
+                continue;
+            }
+            // (node, next child to visit)
+            let mut work = vec![(root, 0usize)];
+            while let Some((node, child)) = work.pop() {
+                if child == 0 {
+                    index[node] = next;
+                    low[node] = next;
+                    next += 1;
+                    stack.push(node);
+                    on_stack[node] = true;
+                }
+                if let Some(&target) = adjacency[node].get(child) {
+                    work.push((node, child + 1));
+                    if index[target] == usize::MAX {
+                        work.push((target, 0));
+                    } else if on_stack[target] {
+                        low[node] = low[node].min(index[target]);
+                    }
+                    continue;
+                }
+
+                if low[node] == index[node] {
+                    let mut component = Vec::new();
+                    while let Some(member) = stack.pop() {
+                        on_stack[member] = false;
+                        component.push(member);
+                        if member == node {
+                            break;
+                        }
+                    }
+                    if component.len() > 1 {
+                        component.sort_unstable();
+                        components.push(component);
+                    }
+                }
+                if let Some(&(parent, _)) = work.last() {
+                    low[parent] = low[parent].min(low[node]);
+                }
+            }
+        }
+        components
+    }
+
+    /// The shortest cycle through the nodes of a component
+    fn shortest_cycle(&self, component: &[usize]) -> Vec<usize> {
+        let mut best: Vec<usize> = Vec::new();
+        for &start in component {
+            // Breadth-first from `start`, inside the component, until an
+            // edge leads back to it
+            let mut parent: BTreeMap<usize, usize> = BTreeMap::new();
+            let mut queue = VecDeque::from([start]);
+            let mut closing = None;
+            'search: while let Some(node) = queue.pop_front() {
+                for &target in self.edges[node].keys() {
+                    if target == start {
+                        closing = Some(node);
+                        break 'search;
+                    }
+                    if component.binary_search(&target).is_ok()
+                        && target != start
+                        && !parent.contains_key(&target)
+                    {
+                        parent.insert(target, node);
+                        queue.push_back(target);
+                    }
+                }
+            }
+
+            let Some(mut node) = closing else {
+                continue;
+            };
+            let mut cycle = vec![node];
+            while node != start {
+                node = parent[&node];
+                cycle.push(node);
+            }
+            cycle.reverse();
+            if best.is_empty() || cycle.len() < best.len() {
+                best = cycle;
+                if best.len() == 2 {
+                    break;
+                }
+            }
+        }
+        best
+    }
+}
+
+/// The shortest cycle of every tangle of mutual dependencies, shortest first
+pub fn find_cycles(graph: &SymbolGraph, level: CycleLevel) -> Vec<Cycle> {
+    let reduced = Reduced::new(graph, level);
+    let mut cycles: Vec<Cycle> = reduced
+        .components()
+        .into_iter()
+        .map(|component| {
+            let path = reduced.shortest_cycle(&component);
+            let relations: Vec<Vec<EdgeKind>> = (0..path.len())
+                .map(|step| {
+                    let to = path[(step + 1) % path.len()];
+                    reduced.edges[path[step]][&to].iter().copied().collect()
+                })
+                .collect();
+            Cycle {
+                path: path
+                    .into_iter()
+                    .map(|node| reduced.nodes[node].clone())
+                    .collect(),
+                relations,
+                component_size: component.len(),
+            }
+        })
+        .collect();
+    cycles.sort_by(|a, b| {
+        a.path.len().cmp(&b.path.len()).then_with(|| {
+            (&a.path[0].file, &a.path[0].name).cmp(&(&b.path[0].file, &b.path[0].name))
+        })
+    });
+    cycles
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::export::{Edge, GraphNode};
+
+    fn node(id: &str, label: &str, kind: &str, file: &str) -> GraphNode {
+        GraphNode {
+            id: id.to_string(),
+            label: label.to_string(),
+            kind: kind.to_string(),
+            file: file.to_string(),
+        }
+    }
+
+    fn edge(from: usize, to: usize, kind: EdgeKind) -> Edge {
+        Edge { from, to, kind }
+    }
+
+    /// a.ts -> b.ts -> c.ts -> a.ts, with a shortcut c.ts -> b.ts
+    fn graph() -> SymbolGraph {
+        SymbolGraph {
+            nodes: vec![
+                node("f1", "a.ts", "File", "a.ts"),
+                node("s1", "b", "Function", "b.ts"),
+                node("s2", "c", "Function", "c.ts"),
+                node("s3", "a", "Function", "a.ts"),
+                node("s4", "isolated", "Function", "d.ts"),
+            ],
+            edges: vec![
+                edge(0, 1, EdgeKind::Imports),
+                edge(1, 2, EdgeKind::Calls),
+                edge(2, 3, EdgeKind::Calls),
+                edge(2, 1, EdgeKind::Calls),
+                edge(4, 3, EdgeKind::Calls),
+            ],
+        }
+    }
+
+    #[test]
+    fn test_module_cycles_report_the_shortest_path() {
+        let cycles = find_cycles(&graph(), CycleLevel::Module);
+
+        assert_eq!(cycles.len(), 1, "d.ts only depends on the tangle");
+        let files: Vec<&str> = cycles[0].path.iter().map(|n| n.file.as_str()).collect();
+        assert_eq!(files, ["b.ts", "c.ts"], "c.ts -> b.ts shortcuts a.ts");
+        assert_eq!(cycles[0].component_size, 3);
+        assert_eq!(
+            cycles[0].relations,
+            [vec![EdgeKind::Calls], vec![EdgeKind::Calls]]
+        );
+    }
+
+    #[test]
+    fn test_symbol_cycles_ignore_files() {
+        let cycles = find_cycles(&graph(), CycleLevel::Symbol);
+
+        assert_eq!(cycles.len(), 1);
+        let names: Vec<&str> = cycles[0].path.iter().map(|n| n.name.as_str()).collect();
+        assert_eq!(names, ["b", "c"]);
+        assert_eq!(cycles[0].component_size, 2, "a only closes a module cycle");
+        assert_eq!(
+            cycles[0].to_string(),
+            "  b (b.ts)\n  -> c (c.ts) [calls]\n  -> b (b.ts) [calls]\n"
+        );
+    }
+
+    #[test]
+    fn test_acyclic_graph() {
+        let mut graph = graph();
+        graph.edges.retain(|edge| edge.from != 2);
+        assert!(find_cycles(&graph, CycleLevel::Module).is_empty());
+        assert!(find_cycles(&graph, CycleLevel::Symbol).is_empty());
+    }
+}
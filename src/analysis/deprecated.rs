@@ -0,0 +1,197 @@
+//! Code still using deprecated symbols
+//!
+//! Symbols are marked deprecated at index time, from the attributes,
+//! annotations and doc comments of their language (see
+//! [`crate::parsing::deprecation`]) or a `codanna:tag=deprecated`
+//! directive. Every call, use, reference, extension or implementation of
+//! one from another symbol is a site left to migrate.
+
+use crate::analysis::api::qualified_name;
+use crate::export::GraphFilter;
+use crate::indexing::facade::IndexFacade;
+use crate::parsing::deprecation::DEPRECATED_TAG;
+use crate::{RelationKind, Symbol, SymbolId};
+use serde::Serialize;
+use std::collections::HashMap;
+use std::fmt;
+
+/// Relationships that use their target
+const USING_RELATIONS: &[RelationKind] = &[
+    RelationKind::Calls,
+    RelationKind::Uses,
+    RelationKind::References,
+    RelationKind::Extends,
+    RelationKind::Implements,
+];
+
+/// A site using a deprecated symbol
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct DeprecatedUsage {
+    pub deprecated_id: SymbolId,
+    /// `Parser::parse` for a member, the bare name otherwise
+    pub deprecated: String,
+    /// Where the deprecated symbol is defined, `file:line`
+    pub defined_at: String,
+    pub user_id: SymbolId,
+    pub user: String,
+    pub kind: RelationKind,
+    pub file: String,
+    /// Line of the site, or of the using symbol when the index has none
+    pub line: u32,
+}
+
+impl fmt::Display for DeprecatedUsage {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "{} {} at {}:{} [symbol_id:{}]",
+            format!("{:?}", self.kind).to_lowercase(),
+            self.user,
+            self.file,
+            self.line,
+            self.user_id.value()
+        )
+    }
+}
+
+/// Whether a symbol is deprecated
+pub fn is_deprecated(symbol: &Symbol) -> bool {
+    symbol.tags.iter().any(|tag| tag == DEPRECATED_TAG)
+}
+
+/// The sites using a deprecated symbol from a symbol the filter accepts,
+/// by file and line
+pub fn find_deprecated_usages(facade: &IndexFacade, filter: &GraphFilter) -> Vec<DeprecatedUsage> {
+    let mut users: HashMap<SymbolId, Option<Symbol>> = HashMap::new();
+    let mut usages = Vec::new();
+    for deprecated in facade.get_all_symbols().into_iter().filter(is_deprecated) {
+        let defined_at = format!(
+            "{}:{}",
+            deprecated.file_path,
+            deprecated.range.start_line + 1
+        );
+        for kind in USING_RELATIONS {
+            let edges = facade
+                .document_index()
+                .get_relationships_to(deprecated.id, *kind)
+                .unwrap_or_default();
+            for (from_id, _, relationship) in edges {
+                if from_id == deprecated.id {
+                    continue;
+                }
+                let user = users
+                    .entry(from_id)
+                    .or_insert_with(|| facade.get_symbol(from_id));
+                let Some(user) = user.as_ref().filter(|user| filter.accepts(user)) else {
+                    continue;
+                };
+                let line = relationship
+                    .metadata
+                    .as_ref()
+                    .and_then(|meta| meta.line)
+                    .unwrap_or(user.range.start_line);
+                usages.push(DeprecatedUsage {
+                    deprecated_id: deprecated.id,
+                    deprecated: qualified_name(&deprecated),
+                    defined_at: defined_at.clone(),
+                    user_id: user.id,
+                    user: qualified_name(user),
+                    kind: *kind,
+                    file: user.file_path.to_string(),
+                    line: line + 1,
+                });
+            }
+        }
+    }
+    usages.sort_by(|a, b| {
+        (&a.file, a.line, &a.deprecated, &a.user).cmp(&(&b.file, b.line, &b.deprecated, &b.user))
+    });
+    usages.dedup();
+    usages
+}
+
+/// The usages grouped by the deprecated symbol they use
+pub fn render_deprecated_usages(usages: &[DeprecatedUsage]) -> String {
+    let mut order: Vec<SymbolId> = Vec::new();
+    let mut groups: HashMap<SymbolId, Vec<&DeprecatedUsage>> = HashMap::new();
+    for usage in usages {
+        groups
+            .entry(usage.deprecated_id)
+            .or_insert_with(|| {
+                order.push(usage.deprecated_id);
+                Vec::new()
+            })
+            .push(usage);
+    }
+
+    let mut out = String::new();
+    for id in order {
+        let group = &groups[&id];
+        let first = group[0];
+        if !out.is_empty() {
+            out.push('\n');
+        }
+        out.push_str(&format!(
+            "{} ({}) [symbol_id:{}], {} use(s):\n",
+            first.deprecated,
+            first.defined_at,
+            id.value(),
+            group.len()
+        ));
+        for usage in group {
+            out.push_str(&format!("  {usage}\n"));
+        }
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::Settings;
+
+    #[test]
+    fn test_find_deprecated_usages() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("tool.py");
+        std::fs::write(
+            &source,
+            "def load():\n    pass\n\n\n# Deprecated: use load.\ndef fetch():\n    return load()\n\n\ndef main():\n    fetch()\n    load()\n",
+        )
+        .unwrap();
+
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+
+        let usages = find_deprecated_usages(&facade, &GraphFilter::default());
+        let found: Vec<(&str, &str, RelationKind, u32)> = usages
+            .iter()
+            .map(|usage| {
+                (
+                    usage.deprecated.as_str(),
+                    usage.user.as_str(),
+                    usage.kind,
+                    usage.line,
+                )
+            })
+            .collect();
+        assert_eq!(found, [("fetch", "main", RelationKind::Calls, 11)]);
+        assert!(usages[0].defined_at.ends_with("tool.py:6"));
+
+        let rendered = render_deprecated_usages(&usages);
+        assert!(rendered.contains("], 1 use(s):\n"), "{rendered}");
+        assert!(rendered.contains("  calls main at "), "{rendered}");
+
+        // The filter picks the sites, not the deprecated symbols
+        let filter = GraphFilter {
+            path: Some("elsewhere".to_string()),
+            ..Default::default()
+        };
+        assert!(find_deprecated_usages(&facade, &filter).is_empty());
+    }
+}
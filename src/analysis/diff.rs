@@ -0,0 +1,520 @@
+//! Semantic diff of two indexes
+//!
+//! Symbol IDs are handed out per index, so the symbols of two snapshots are
+//! matched by what they are instead: their file, their kind and their name,
+//! qualified by the type they are a member of. A matched symbol changed
+//! when its signature, compared on one line, or its visibility did; moving
+//! within its file is not a change. Relationships are matched by the
+//! symbols at both ends.
+
+use crate::analysis::api::{in_scope, one_line_signature, qualified_name};
+use crate::export::{EdgeKind, GraphFilter};
+use crate::indexing::facade::IndexFacade;
+use crate::symbol::Visibility;
+use crate::{Symbol, SymbolId, SymbolKind};
+use serde::Serialize;
+use std::collections::{HashMap, HashSet};
+use std::fmt;
+
+/// Relationships compared when none are selected
+const DEFAULT_RELATIONS: &[EdgeKind] = &[
+    EdgeKind::Calls,
+    EdgeKind::Implements,
+    EdgeKind::Extends,
+    EdgeKind::Uses,
+];
+
+/// File, qualified name and kind
+type SymbolKey = (String, String, SymbolKind);
+
+fn key(symbol: &Symbol) -> SymbolKey {
+    (
+        symbol.file_path.to_string(),
+        qualified_name(symbol),
+        symbol.kind,
+    )
+}
+
+/// The symbols and relationships of one index
+#[derive(Debug, Clone, Default)]
+pub struct DiffSide {
+    pub symbols: Vec<Symbol>,
+    /// Source, target and kind of each relationship
+    pub edges: Vec<(SymbolId, SymbolId, EdgeKind)>,
+}
+
+impl DiffSide {
+    /// The symbols of the index the filter accepts, locals left out, and
+    /// the relationships between them
+    pub fn from_index(facade: &IndexFacade, filter: &GraphFilter) -> Self {
+        let symbols: Vec<Symbol> = facade
+            .get_all_symbols()
+            .into_iter()
+            .filter(|symbol| filter.accepts(symbol) && in_scope(symbol))
+            .collect();
+        let ids: HashSet<SymbolId> = symbols.iter().map(|symbol| symbol.id).collect();
+        let relations = if filter.relations.is_empty() {
+            DEFAULT_RELATIONS
+        } else {
+            filter.relations.as_slice()
+        };
+
+        let mut edges = Vec::new();
+        for symbol in &symbols {
+            for &kind in relations {
+                let targets = match kind {
+                    EdgeKind::Calls => facade.get_called_functions(symbol.id),
+                    EdgeKind::Implements => facade.get_implemented_traits(symbol.id),
+                    EdgeKind::Extends => facade.get_extends(symbol.id),
+                    EdgeKind::Uses => facade.get_uses(symbol.id),
+                    // File edges have no symbol to match by
+                    EdgeKind::Imports => continue,
+                };
+                edges.extend(
+                    targets
+                        .into_iter()
+                        .filter(|target| ids.contains(&target.id))
+                        .map(|target| (symbol.id, target.id, kind)),
+                );
+            }
+        }
+        Self { symbols, edges }
+    }
+}
+
+/// A symbol as one side of the diff has it
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct DiffSymbol {
+    /// `Parser::parse` for a member, the bare name otherwise
+    pub name: String,
+    pub kind: SymbolKind,
+    pub visibility: Visibility,
+    /// The signature on one line
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub signature: Option<String>,
+    pub file: String,
+    pub line: u32,
+}
+
+impl DiffSymbol {
+    fn new(symbol: &Symbol) -> Self {
+        Self {
+            name: qualified_name(symbol),
+            kind: symbol.kind,
+            visibility: symbol.visibility,
+            signature: one_line_signature(symbol),
+            file: symbol.file_path.to_string(),
+            line: symbol.range.start_line + 1,
+        }
+    }
+
+    fn same_declaration(&self, other: &Self) -> bool {
+        self.signature == other.signature && self.visibility == other.visibility
+    }
+}
+
+impl fmt::Display for DiffSymbol {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "{:<9}  {}  ({}:{})",
+            format!("{:?}", self.kind).to_lowercase(),
+            self.name,
+            self.file,
+            self.line
+        )
+    }
+}
+
+/// A symbol of both sides whose declaration changed
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct SymbolChange {
+    pub old: DiffSymbol,
+    pub new: DiffSymbol,
+}
+
+impl SymbolChange {
+    pub fn signature_changed(&self) -> bool {
+        self.old.signature != self.new.signature
+    }
+
+    pub fn visibility_changed(&self) -> bool {
+        self.old.visibility != self.new.visibility
+    }
+}
+
+/// A relationship only one side has
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct RelationshipDiff {
+    pub from: String,
+    pub from_file: String,
+    pub kind: EdgeKind,
+    pub to: String,
+    pub to_file: String,
+}
+
+impl fmt::Display for RelationshipDiff {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "{} {} {}", self.from, self.kind.as_str(), self.to)
+    }
+}
+
+/// What changed from one index to the other, each list by file
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize)]
+pub struct IndexDiff {
+    pub added: Vec<DiffSymbol>,
+    pub removed: Vec<DiffSymbol>,
+    pub changed: Vec<SymbolChange>,
+    pub added_relationships: Vec<RelationshipDiff>,
+    pub removed_relationships: Vec<RelationshipDiff>,
+}
+
+impl IndexDiff {
+    pub fn is_empty(&self) -> bool {
+        self.added.is_empty()
+            && self.removed.is_empty()
+            && self.changed.is_empty()
+            && self.added_relationships.is_empty()
+            && self.removed_relationships.is_empty()
+    }
+}
+
+/// The relationships of a side by the keys of their ends
+fn edge_keys(side: &DiffSide) -> HashSet<(SymbolKey, EdgeKind, SymbolKey)> {
+    let keys: HashMap<SymbolId, SymbolKey> = side
+        .symbols
+        .iter()
+        .map(|symbol| (symbol.id, key(symbol)))
+        .collect();
+    side.edges
+        .iter()
+        .filter_map(|(from, to, kind)| {
+            Some((keys.get(from)?.clone(), *kind, keys.get(to)?.clone()))
+        })
+        .collect()
+}
+
+fn relationships(
+    edges: &HashSet<(SymbolKey, EdgeKind, SymbolKey)>,
+    other: &HashSet<(SymbolKey, EdgeKind, SymbolKey)>,
+) -> Vec<RelationshipDiff> {
+    let mut only: Vec<RelationshipDiff> = edges
+        .difference(other)
+        .map(|(from, kind, to)| RelationshipDiff {
+            from: from.1.clone(),
+            from_file: from.0.clone(),
+            kind: *kind,
+            to: to.1.clone(),
+            to_file: to.0.clone(),
+        })
+        .collect();
+    only.sort_by(|a, b| {
+        (&a.from_file, &a.from, a.kind, &a.to_file, &a.to).cmp(&(
+            &b.from_file,
+            &b.from,
+            b.kind,
+            &b.to_file,
+            &b.to,
+        ))
+    });
+    only
+}
+
+/// The changes from `old` to `new`. Symbols sharing a key, like overloads,
+/// first cancel out when declared alike; the rest pair up in source order.
+pub fn diff_indexes(old: &DiffSide, new: &DiffSide) -> IndexDiff {
+    let group = |side: &DiffSide| {
+        let mut groups: HashMap<SymbolKey, Vec<DiffSymbol>> = HashMap::new();
+        for symbol in &side.symbols {
+            groups
+                .entry(key(symbol))
+                .or_default()
+                .push(DiffSymbol::new(symbol));
+        }
+        for symbols in groups.values_mut() {
+            symbols.sort_by_key(|symbol| symbol.line);
+        }
+        groups
+    };
+    let old_groups = group(old);
+    let mut new_groups = group(new);
+
+    let mut diff = IndexDiff::default();
+    for (key, mut olds) in old_groups {
+        let mut news = new_groups.remove(&key).unwrap_or_default();
+        olds.retain(
+            |old| match news.iter().position(|new| new.same_declaration(old)) {
+                Some(index) => {
+                    news.remove(index);
+                    false
+                }
+                None => true,
+            },
+        );
+        let paired = olds.len().min(news.len());
+        let extra_news = news.split_off(paired);
+        let extra_olds = olds.split_off(paired);
+        diff.changed.extend(
+            olds.into_iter()
+                .zip(news)
+                .map(|(old, new)| SymbolChange { old, new }),
+        );
+        diff.removed.extend(extra_olds);
+        diff.added.extend(extra_news);
+    }
+    diff.added.extend(new_groups.into_values().flatten());
+
+    let by_file = |a: &DiffSymbol, b: &DiffSymbol| (&a.file, a.line).cmp(&(&b.file, b.line));
+    diff.added.sort_by(by_file);
+    diff.removed.sort_by(by_file);
+    diff.changed.sort_by(|a, b| by_file(&a.new, &b.new));
+
+    let old_edges = edge_keys(old);
+    let new_edges = edge_keys(new);
+    diff.added_relationships = relationships(&new_edges, &old_edges);
+    diff.removed_relationships = relationships(&old_edges, &new_edges);
+    diff
+}
+
+fn section(out: &mut String, title: &str, count: usize) {
+    if !out.is_empty() {
+        out.push('\n');
+    }
+    out.push_str(&format!("{title} ({count}):\n"));
+}
+
+/// The diff as sections of added, removed and changed symbols, then
+/// relationships; empty sections are left out
+pub fn render_diff(diff: &IndexDiff) -> String {
+    let mut out = String::new();
+    if !diff.added.is_empty() {
+        section(&mut out, "Added symbols", diff.added.len());
+        for symbol in &diff.added {
+            out.push_str(&format!("  + {symbol}\n"));
+        }
+    }
+    if !diff.removed.is_empty() {
+        section(&mut out, "Removed symbols", diff.removed.len());
+        for symbol in &diff.removed {
+            out.push_str(&format!("  - {symbol}\n"));
+        }
+    }
+    if !diff.changed.is_empty() {
+        section(&mut out, "Changed symbols", diff.changed.len());
+        for change in &diff.changed {
+            out.push_str(&format!("  ~ {}\n", change.new));
+            if change.visibility_changed() {
+                out.push_str(&format!(
+                    "      visibility {} -> {}\n",
+                    change.old.visibility.as_str(),
+                    change.new.visibility.as_str()
+                ));
+            }
+            if change.signature_changed() {
+                let signature = |symbol: &DiffSymbol| symbol.signature.clone().unwrap_or_default();
+                out.push_str(&format!("      - {}\n", signature(&change.old)));
+                out.push_str(&format!("      + {}\n", signature(&change.new)));
+            }
+        }
+    }
+    if !diff.added_relationships.is_empty() {
+        section(
+            &mut out,
+            "Added relationships",
+            diff.added_relationships.len(),
+        );
+        for relationship in &diff.added_relationships {
+            out.push_str(&format!("  + {relationship}\n"));
+        }
+    }
+    if !diff.removed_relationships.is_empty() {
+        section(
+            &mut out,
+            "Removed relationships",
+            diff.removed_relationships.len(),
+        );
+        for relationship in &diff.removed_relationships {
+            out.push_str(&format!("  - {relationship}\n"));
+        }
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{FileId, Range, ScopeContext};
+
+    fn symbol(id: u32, name: &str, kind: SymbolKind, line: u32, signature: &str) -> Symbol {
+        Symbol::new(
+            SymbolId::new(id).unwrap(),
+            name,
+            kind,
+            FileId::new(1).unwrap(),
+            Range::new(line - 1, 0, line, 1),
+        )
+        .with_file_path("src/config.rs")
+        .with_signature(signature)
+        .with_visibility(Visibility::Public)
+    }
+
+    fn method(id: u32, name: &str, line: u32, signature: &str) -> Symbol {
+        symbol(id, name, SymbolKind::Method, line, signature).with_scope(
+            ScopeContext::ClassMember {
+                class_name: Some("Settings".into()),
+            },
+        )
+    }
+
+    fn old() -> DiffSide {
+        DiffSide {
+            symbols: vec![
+                symbol(1, "Settings", SymbolKind::Struct, 1, "pub struct Settings"),
+                method(2, "load", 5, "pub fn load(path: &Path) -> Self"),
+                method(3, "save", 9, "pub fn save(&self)"),
+                symbol(4, "legacy", SymbolKind::Function, 20, "pub fn legacy()"),
+            ],
+            edges: vec![
+                (sid(2), sid(4), EdgeKind::Calls),
+                (sid(3), sid(2), EdgeKind::Calls),
+            ],
+        }
+    }
+
+    fn new() -> DiffSide {
+        DiffSide {
+            // Other IDs and lines for the same symbols
+            symbols: vec![
+                symbol(11, "Settings", SymbolKind::Struct, 3, "pub struct Settings"),
+                method(
+                    12,
+                    "load",
+                    8,
+                    "pub fn load(\n    path: &Path,\n) -> Result<Self>",
+                ),
+                method(13, "save", 14, "pub fn save(&self)").with_visibility(Visibility::Crate),
+                symbol(
+                    14,
+                    "validate",
+                    SymbolKind::Function,
+                    30,
+                    "pub fn validate()",
+                ),
+            ],
+            edges: vec![
+                (sid(12), sid(14), EdgeKind::Calls),
+                (sid(13), sid(12), EdgeKind::Calls),
+            ],
+        }
+    }
+
+    fn sid(id: u32) -> SymbolId {
+        SymbolId::new(id).unwrap()
+    }
+
+    #[test]
+    fn test_added_removed_and_changed_symbols() {
+        let diff = diff_indexes(&old(), &new());
+
+        let names = |symbols: &[DiffSymbol]| -> Vec<String> {
+            symbols.iter().map(|symbol| symbol.name.clone()).collect()
+        };
+        assert_eq!(names(&diff.added), ["validate"]);
+        assert_eq!(names(&diff.removed), ["legacy"]);
+        let changed: Vec<(&str, bool, bool)> = diff
+            .changed
+            .iter()
+            .map(|change| {
+                (
+                    change.new.name.as_str(),
+                    change.signature_changed(),
+                    change.visibility_changed(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            changed,
+            [
+                ("Settings::load", true, false),
+                ("Settings::save", false, true)
+            ],
+            "moving the struct is no change"
+        );
+        assert_eq!(
+            diff.changed[0].new.signature.as_deref(),
+            Some("pub fn load( path: &Path, ) -> Result<Self>")
+        );
+    }
+
+    #[test]
+    fn test_relationships_matched_by_their_ends() {
+        let diff = diff_indexes(&old(), &new());
+
+        let render = |relationships: &[RelationshipDiff]| -> Vec<String> {
+            relationships.iter().map(ToString::to_string).collect()
+        };
+        assert_eq!(
+            render(&diff.added_relationships),
+            ["Settings::load calls validate"]
+        );
+        assert_eq!(
+            render(&diff.removed_relationships),
+            ["Settings::load calls legacy"],
+            "save still calls load"
+        );
+        assert!(diff_indexes(&old(), &old()).is_empty());
+    }
+
+    #[test]
+    fn test_overloads_cancel_out_before_pairing() {
+        let side = |signatures: &[&str]| DiffSide {
+            symbols: signatures
+                .iter()
+                .enumerate()
+                .map(|(index, signature)| {
+                    let id = index as u32 + 1;
+                    method(id, "get", id * 10, signature)
+                })
+                .collect(),
+            edges: Vec::new(),
+        };
+        let old = side(&["get(int)", "get(String)"]);
+        let new = side(&["get(String)", "get(long)", "get(char)"]);
+
+        let diff = diff_indexes(&old, &new);
+        assert_eq!(diff.changed.len(), 1);
+        assert_eq!(diff.changed[0].old.signature.as_deref(), Some("get(int)"));
+        assert_eq!(diff.changed[0].new.signature.as_deref(), Some("get(long)"));
+        assert_eq!(diff.added.len(), 1);
+        assert!(diff.removed.is_empty());
+    }
+
+    #[test]
+    fn test_render_diff() {
+        let diff = diff_indexes(&old(), &new());
+
+        let expected = [
+            "Added symbols (1):",
+            "  + function   validate  (src/config.rs:30)",
+            "",
+            "Removed symbols (1):",
+            "  - function   legacy  (src/config.rs:20)",
+            "",
+            "Changed symbols (2):",
+            "  ~ method     Settings::load  (src/config.rs:8)",
+            "      - pub fn load(path: &Path) -> Self",
+            "      + pub fn load( path: &Path, ) -> Result<Self>",
+            "  ~ method     Settings::save  (src/config.rs:14)",
+            "      visibility public -> crate",
+            "",
+            "Added relationships (1):",
+            "  + Settings::load calls validate",
+            "",
+            "Removed relationships (1):",
+            "  - Settings::load calls legacy",
+            "",
+        ];
+        assert_eq!(render_diff(&diff), expected.join("\n"));
+        assert_eq!(render_diff(&IndexDiff::default()), "");
+    }
+}
@@ -0,0 +1,295 @@
+//! Likely copy-pasted implementations
+//!
+//! Two functions are near clones when their embeddings are at least
+//! `threshold` similar and their bodies are about as long: the shorter has
+//! at least `size_ratio` of the tokens of the longer. Clones group
+//! transitively, so three copies of one helper make one group. Only symbols
+//! with an embedding take part, which are the documented ones.
+
+use crate::export::GraphFilter;
+use crate::indexing::facade::{FacadeResult, IndexFacade};
+use crate::semantic::cosine_similarity;
+use crate::{Symbol, SymbolKind};
+use serde::Serialize;
+use std::collections::{BTreeMap, HashMap};
+
+/// When two functions count as clones
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct DuplicateRules {
+    /// Lowest cosine similarity of the embeddings
+    pub threshold: f32,
+    /// Lowest ratio of the shorter body's tokens to the longer's
+    pub size_ratio: f32,
+    /// Bodies shorter than this are too small to be worth reporting
+    pub min_tokens: usize,
+}
+
+impl Default for DuplicateRules {
+    fn default() -> Self {
+        Self {
+            threshold: crate::semantic::thresholds::DUPLICATE,
+            size_ratio: 0.8,
+            min_tokens: 20,
+        }
+    }
+}
+
+/// A function or method to compare
+#[derive(Debug, Clone)]
+pub struct Candidate {
+    pub symbol: Symbol,
+    pub embedding: Vec<f32>,
+    /// Tokens of its body
+    pub tokens: usize,
+}
+
+/// One copy in a group of clones
+#[derive(Debug, Clone, Serialize)]
+pub struct DuplicateMember {
+    pub symbol: Symbol,
+    pub tokens: usize,
+}
+
+/// Functions that look copy-pasted from one another
+#[derive(Debug, Clone, Serialize)]
+pub struct DuplicateGroup {
+    /// Sorted by file, then line
+    pub members: Vec<DuplicateMember>,
+    /// Lowest similarity of the pairs joining the group
+    pub similarity: f32,
+}
+
+/// Tokens of source code: each identifier or number, and each other
+/// character that is not whitespace
+pub fn token_count(code: &str) -> usize {
+    let mut count = 0;
+    let mut in_word = false;
+    for c in code.chars() {
+        if c.is_alphanumeric() || c == '_' {
+            if !in_word {
+                count += 1;
+                in_word = true;
+            }
+        } else {
+            in_word = false;
+            if !c.is_whitespace() {
+                count += 1;
+            }
+        }
+    }
+    count
+}
+
+fn find(parents: &mut [usize], node: usize) -> usize {
+    let mut root = node;
+    while parents[root] != root {
+        root = parents[root];
+    }
+    let mut node = node;
+    while parents[node] != root {
+        let next = parents[node];
+        parents[node] = root;
+        node = next;
+    }
+    root
+}
+
+/// Groups of clones among the candidates, largest first
+///
+/// Candidates are sorted by size, so each is compared only with the ones
+/// close enough in size to be its clone.
+pub fn group_duplicates(
+    mut candidates: Vec<Candidate>,
+    rules: &DuplicateRules,
+) -> Vec<DuplicateGroup> {
+    candidates.retain(|candidate| candidate.tokens >= rules.min_tokens.max(1));
+    candidates.sort_by_key(|candidate| candidate.tokens);
+
+    let mut parents: Vec<usize> = (0..candidates.len()).collect();
+    let mut joins: Vec<(usize, f32)> = Vec::new();
+    for (shorter, candidate) in candidates.iter().enumerate() {
+        for longer in shorter + 1..candidates.len() {
+            let other = &candidates[longer];
+            if (candidate.tokens as f32) < rules.size_ratio * other.tokens as f32 {
+                break;
+            }
+            let similarity = cosine_similarity(&candidate.embedding, &other.embedding);
+            if similarity >= rules.threshold {
+                let (a, b) = (find(&mut parents, shorter), find(&mut parents, longer));
+                if a != b {
+                    parents[b] = a;
+                }
+                joins.push((shorter, similarity));
+            }
+        }
+    }
+
+    let mut lowest: HashMap<usize, f32> = HashMap::new();
+    for (member, similarity) in joins {
+        let root = find(&mut parents, member);
+        let entry = lowest.entry(root).or_insert(similarity);
+        *entry = entry.min(similarity);
+    }
+    let mut members: BTreeMap<usize, Vec<DuplicateMember>> = BTreeMap::new();
+    for (index, candidate) in candidates.into_iter().enumerate() {
+        let root = find(&mut parents, index);
+        if lowest.contains_key(&root) {
+            members.entry(root).or_default().push(DuplicateMember {
+                symbol: candidate.symbol,
+                tokens: candidate.tokens,
+            });
+        }
+    }
+
+    let mut groups: Vec<DuplicateGroup> = members
+        .into_iter()
+        .map(|(root, mut members)| {
+            members.sort_by(|a, b| {
+                (&a.symbol.file_path, a.symbol.range.start_line)
+                    .cmp(&(&b.symbol.file_path, b.symbol.range.start_line))
+            });
+            DuplicateGroup {
+                members,
+                similarity: lowest[&root],
+            }
+        })
+        .collect();
+    groups.sort_by(|a, b| {
+        b.members
+            .len()
+            .cmp(&a.members.len())
+            .then(b.similarity.total_cmp(&a.similarity))
+    });
+    groups
+}
+
+/// Clones among the indexed functions and methods the filter accepts,
+/// sizing each body from its source file
+pub fn find_duplicates(
+    facade: &IndexFacade,
+    rules: &DuplicateRules,
+    filter: &GraphFilter,
+) -> FacadeResult<Vec<DuplicateGroup>> {
+    let mut sources: HashMap<Box<str>, Option<String>> = HashMap::new();
+    let candidates = facade
+        .semantic_embeddings()?
+        .into_iter()
+        .filter_map(|(id, embedding)| {
+            let symbol = facade.get_symbol(id)?;
+            if !matches!(symbol.kind, SymbolKind::Function | SymbolKind::Method)
+                || !filter.accepts(&symbol)
+            {
+                return None;
+            }
+            let source = sources
+                .entry(symbol.file_path.clone())
+                .or_insert_with(|| std::fs::read_to_string(&*symbol.file_path).ok())
+                .as_deref()?;
+            let body: Vec<&str> = source
+                .lines()
+                .skip(symbol.range.start_line as usize)
+                .take((symbol.range.end_line - symbol.range.start_line) as usize + 1)
+                .collect();
+            let tokens = token_count(&body.join("\n"));
+            Some(Candidate {
+                symbol,
+                embedding,
+                tokens,
+            })
+        })
+        .collect();
+    Ok(group_duplicates(candidates, rules))
+}
+
+/// Rows of a duplicate report, one block a group
+pub fn render_duplicates(groups: &[DuplicateGroup]) -> String {
+    let mut out = String::new();
+    for group in groups {
+        out.push_str(&format!(
+            "  {} copies, similarity {:.2}\n",
+            group.members.len(),
+            group.similarity
+        ));
+        for member in &group.members {
+            out.push_str(&format!(
+                "    {}:{}  {} ({} tokens)\n",
+                member.symbol.file_path,
+                member.symbol.range.start_line + 1,
+                member.symbol.name,
+                member.tokens
+            ));
+        }
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{FileId, Range, SymbolId};
+
+    fn candidate(id: u32, file: &str, embedding: &[f32], tokens: usize) -> Candidate {
+        Candidate {
+            symbol: Symbol::new(
+                SymbolId::new(id).unwrap(),
+                format!("f{id}"),
+                SymbolKind::Function,
+                FileId::new(1).unwrap(),
+                Range::new(id * 10, 0, id * 10 + 5, 1),
+            )
+            .with_file_path(file),
+            embedding: embedding.to_vec(),
+            tokens,
+        }
+    }
+
+    fn names(group: &DuplicateGroup) -> Vec<&str> {
+        group
+            .members
+            .iter()
+            .map(|member| member.symbol.name.as_ref())
+            .collect()
+    }
+
+    #[test]
+    fn test_token_count() {
+        assert_eq!(token_count("fn add(a: u32) -> u32 { a + 1 }"), 15);
+        assert_eq!(token_count("  \n "), 0);
+    }
+
+    #[test]
+    fn test_groups_similar_bodies_of_similar_size() {
+        let candidates = vec![
+            candidate(1, "src/a.rs", &[1.0, 0.0, 0.0], 100),
+            candidate(2, "src/b.rs", &[0.99, 0.1, 0.0], 95),
+            candidate(3, "src/c.rs", &[0.98, 0.15, 0.0], 90),
+            // Same meaning, a third of the size
+            candidate(4, "src/d.rs", &[1.0, 0.0, 0.0], 30),
+            // Same size, unrelated
+            candidate(5, "src/e.rs", &[0.0, 1.0, 0.0], 100),
+            // Too small to report
+            candidate(6, "src/f.rs", &[0.0, 0.0, 1.0], 5),
+            candidate(7, "src/g.rs", &[0.0, 0.0, 1.0], 5),
+        ];
+        let groups = group_duplicates(candidates, &DuplicateRules::default());
+
+        assert_eq!(groups.len(), 1, "got {groups:?}");
+        assert_eq!(names(&groups[0]), ["f1", "f2", "f3"]);
+        assert!(groups[0].similarity >= DuplicateRules::default().threshold);
+        assert!(groups[0].similarity < 1.0);
+    }
+
+    #[test]
+    fn test_render_duplicates() {
+        let candidates = vec![
+            candidate(1, "src/a.rs", &[1.0, 0.0], 40),
+            candidate(2, "src/b.rs", &[1.0, 0.0], 40),
+        ];
+        let groups = group_duplicates(candidates, &DuplicateRules::default());
+
+        assert_eq!(
+            render_duplicates(&groups),
+            "  2 copies, similarity 1.00\n    src/a.rs:11  f1 (40 tokens)\n    src/b.rs:21  f2 (40 tokens)\n"
+        );
+    }
+}
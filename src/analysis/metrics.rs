@@ -0,0 +1,237 @@
+//! Complexity hotspots
+//!
+//! Functions and methods carry their metrics from index time; this rolls
+//! them up into the most complex functions and the files holding the most
+//! complexity, which is where refactoring pays first.
+
+use crate::export::GraphFilter;
+use crate::symbol::SymbolMetrics;
+use crate::{Symbol, SymbolKind};
+use serde::Serialize;
+use std::collections::HashMap;
+
+/// One measured function or method
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct Hotspot {
+    pub name: String,
+    pub kind: SymbolKind,
+    pub file: String,
+    pub line: u32,
+    #[serde(flatten)]
+    pub metrics: SymbolMetrics,
+}
+
+/// The measured functions of one file
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct FileMetrics {
+    pub file: String,
+    pub functions: usize,
+    /// Sum of the functions' complexity
+    pub complexity: u32,
+    pub max_complexity: u32,
+    pub lines: u32,
+}
+
+/// Metrics of the functions the filter accepts
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct MetricsReport {
+    pub functions: usize,
+    pub average_complexity: f32,
+    pub average_lines: f32,
+    pub average_parameters: f32,
+    /// Most complex first
+    pub hotspots: Vec<Hotspot>,
+    /// Most total complexity first
+    pub files: Vec<FileMetrics>,
+}
+
+/// The report of the measured symbols, keeping `limit` hotspots and files
+pub fn metrics_report(symbols: &[Symbol], filter: &GraphFilter, limit: usize) -> MetricsReport {
+    let measured: Vec<(&Symbol, SymbolMetrics)> = symbols
+        .iter()
+        .filter(|symbol| filter.accepts(symbol))
+        .filter_map(|symbol| symbol.metrics.map(|metrics| (symbol, metrics)))
+        .collect();
+
+    let average = |value: fn(&SymbolMetrics) -> u32| {
+        if measured.is_empty() {
+            return 0.0;
+        }
+        let total: u64 = measured.iter().map(|(_, m)| value(m) as u64).sum();
+        total as f32 / measured.len() as f32
+    };
+
+    let mut hotspots: Vec<Hotspot> = measured
+        .iter()
+        .map(|(symbol, metrics)| Hotspot {
+            name: symbol.name.to_string(),
+            kind: symbol.kind,
+            file: symbol.file_path.to_string(),
+            line: symbol.range.start_line + 1,
+            metrics: *metrics,
+        })
+        .collect();
+    hotspots.sort_by(|a, b| {
+        (b.metrics.complexity, b.metrics.lines)
+            .cmp(&(a.metrics.complexity, a.metrics.lines))
+            .then_with(|| (&a.file, a.line).cmp(&(&b.file, b.line)))
+    });
+    hotspots.truncate(limit);
+
+    let mut by_file: HashMap<&str, FileMetrics> = HashMap::new();
+    for (symbol, metrics) in &measured {
+        let file = by_file
+            .entry(&*symbol.file_path)
+            .or_insert_with(|| FileMetrics {
+                file: symbol.file_path.to_string(),
+                functions: 0,
+                complexity: 0,
+                max_complexity: 0,
+                lines: 0,
+            });
+        file.functions += 1;
+        file.complexity += metrics.complexity;
+        file.max_complexity = file.max_complexity.max(metrics.complexity);
+        file.lines += metrics.lines;
+    }
+    let mut files: Vec<FileMetrics> = by_file.into_values().collect();
+    files.sort_by(|a, b| b.complexity.cmp(&a.complexity).then(a.file.cmp(&b.file)));
+    files.truncate(limit);
+
+    MetricsReport {
+        functions: measured.len(),
+        average_complexity: average(|m| m.complexity),
+        average_lines: average(|m| m.lines),
+        average_parameters: average(|m| m.parameters),
+        hotspots,
+        files,
+    }
+}
+
+/// The report as two tables, functions then files
+pub fn render_metrics(report: &MetricsReport) -> String {
+    let mut out = format!(
+        "Functions: {}  (average complexity {:.1}, {:.1} lines, {:.1} parameters)\n",
+        report.functions,
+        report.average_complexity,
+        report.average_lines,
+        report.average_parameters
+    );
+    if !report.hotspots.is_empty() {
+        out.push_str("\nMost complex functions:\n");
+        out.push_str("  complexity  lines  params  function\n");
+        for hotspot in &report.hotspots {
+            out.push_str(&format!(
+                "  {:>10}  {:>5}  {:>6}  {} ({}:{})\n",
+                hotspot.metrics.complexity,
+                hotspot.metrics.lines,
+                hotspot.metrics.parameters,
+                hotspot.name,
+                hotspot.file,
+                hotspot.line
+            ));
+        }
+    }
+    if !report.files.is_empty() {
+        out.push_str("\nMost complex files:\n");
+        out.push_str("  complexity  max  functions  file\n");
+        for file in &report.files {
+            out.push_str(&format!(
+                "  {:>10}  {:>3}  {:>9}  {}\n",
+                file.complexity, file.max_complexity, file.functions, file.file
+            ));
+        }
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{FileId, Range, SymbolId};
+
+    fn function(id: u32, file: &str, complexity: u32, lines: u32) -> Symbol {
+        Symbol::new(
+            SymbolId::new(id).unwrap(),
+            format!("f{id}"),
+            SymbolKind::Function,
+            FileId::new(1).unwrap(),
+            Range::new(id * 10, 0, id * 10 + lines - 1, 1),
+        )
+        .with_file_path(file)
+        .with_metrics(SymbolMetrics {
+            complexity,
+            lines,
+            parameters: 1,
+        })
+    }
+
+    fn symbols() -> Vec<Symbol> {
+        vec![
+            function(1, "src/a.rs", 12, 40),
+            function(2, "src/a.rs", 2, 5),
+            function(3, "src/b.rs", 9, 30),
+            function(4, "src/b.rs", 9, 20),
+            // Not measured
+            Symbol::new(
+                SymbolId::new(5).unwrap(),
+                "Config",
+                SymbolKind::Struct,
+                FileId::new(1).unwrap(),
+                Range::new(50, 0, 60, 1),
+            )
+            .with_file_path("src/a.rs"),
+        ]
+    }
+
+    #[test]
+    fn test_hotspots_and_files_by_complexity() {
+        let report = metrics_report(&symbols(), &GraphFilter::default(), 10);
+
+        assert_eq!(report.functions, 4);
+        assert_eq!(report.average_complexity, 8.0);
+        assert_eq!(report.average_lines, 23.75);
+        let names: Vec<&str> = report.hotspots.iter().map(|h| h.name.as_str()).collect();
+        assert_eq!(names, ["f1", "f3", "f4", "f2"], "ties go to the longer");
+        let files: Vec<(&str, u32, u32)> = report
+            .files
+            .iter()
+            .map(|f| (f.file.as_str(), f.complexity, f.max_complexity))
+            .collect();
+        assert_eq!(files, [("src/b.rs", 18, 9), ("src/a.rs", 14, 12)]);
+    }
+
+    #[test]
+    fn test_filter_and_limit() {
+        let filter = GraphFilter {
+            path: Some("src/b.rs".to_string()),
+            ..GraphFilter::default()
+        };
+        let report = metrics_report(&symbols(), &filter, 1);
+
+        assert_eq!(report.functions, 2);
+        assert_eq!(report.hotspots.len(), 1);
+        assert_eq!(report.hotspots[0].name, "f3");
+        assert_eq!(report.files.len(), 1);
+    }
+
+    #[test]
+    fn test_render_metrics() {
+        let report = metrics_report(&symbols()[..2], &GraphFilter::default(), 10);
+
+        let expected = [
+            "Functions: 2  (average complexity 7.0, 22.5 lines, 1.0 parameters)",
+            "",
+            "Most complex functions:",
+            "  complexity  lines  params  function",
+            "          12     40       1  f1 (src/a.rs:11)",
+            "           2      5       1  f2 (src/a.rs:21)",
+            "",
+            "Most complex files:",
+            "  complexity  max  functions  file",
+            "          14   12          2  src/a.rs",
+            "",
+        ];
+        assert_eq!(render_metrics(&report), expected.join("\n"));
+    }
+}
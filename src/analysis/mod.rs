@@ -0,0 +1,57 @@
+//! Analyses of the indexed relationship graph
+//!
+//! Cycle detection runs on the [`SymbolGraph`](crate::export::SymbolGraph)
+//! the export builds, so it sees the same symbols and edges a rendered
+//! diagram shows, and so does the module dependency matrix, which weighs
+//! those edges by the directories they cross. Unused symbol detection and
+//! test mapping ask the index directly for each symbol's incoming edges.
+//! The API surface needs no edges at all, only each symbol's visibility,
+//! and duplicate detection compares the symbols' embeddings instead.
+//! Signature search reads the types out of each symbol's signature, and
+//! asks the index only which types implement or extend which.
+//! Complexity hotspots roll up the metrics measured at index time, and the
+//! todo listing the tagged comments found then. The diff of two snapshots
+//! matches their symbols by name, since their IDs differ. The breakdowns
+//! of `codanna stats` count symbols, doc comments and complexity by
+//! language, directory or kind. Ownership needs no index at all: the
+//! CODEOWNERS file of the workspace names the owners of each symbol's file.
+//! Deprecated usages are the incoming edges of the symbols marked
+//! deprecated at index time. String search matches the notable literals
+//! indexed then, format strings taking the text their placeholders print.
+
+pub mod api;
+pub mod breakdown;
+pub mod cycles;
+pub mod deprecated;
+pub mod diff;
+pub mod duplicates;
+pub mod metrics;
+pub mod modules;
+pub mod owners;
+pub mod signature;
+pub mod strings;
+pub mod test_map;
+pub mod todos;
+pub mod unused;
+
+pub use api::{ApiItem, api_surface, render_api, undocumented_api};
+pub use breakdown::{GroupStats, Grouping, breakdown, render_breakdown};
+pub use cycles::{Cycle, CycleLevel, CycleNode, find_cycles};
+pub use deprecated::{DeprecatedUsage, find_deprecated_usages, render_deprecated_usages};
+pub use diff::{
+    DiffSide, DiffSymbol, IndexDiff, RelationshipDiff, SymbolChange, diff_indexes, render_diff,
+};
+pub use duplicates::{
+    DuplicateGroup, DuplicateMember, DuplicateRules, find_duplicates, render_duplicates,
+};
+pub use metrics::{FileMetrics, Hotspot, MetricsReport, metrics_report, render_metrics};
+pub use modules::{ModuleDependency, ModuleMatrix, module_matrix, module_of};
+pub use owners::{CodeOwners, OwnedItem, is_owner, owned_by, render_owned};
+pub use signature::{
+    SignatureMatch, TypeExpr, TypeSignature, find_by_signature, parse_signature,
+    render_signature_matches, supertype_names,
+};
+pub use strings::{StringFilter, StringItem, list_strings, render_strings};
+pub use test_map::{CoveringTest, is_test_function, render_tests, tests_for};
+pub use todos::{TodoFilter, TodoItem, list_todos, render_todos};
+pub use unused::{UnusedRules, find_unused, render_unused};
@@ -0,0 +1,278 @@
+//! Dependencies between modules
+//!
+//! A module is the directory of a file, optionally cut to its first
+//! `depth` components so `src/indexing/pipeline/stages` and
+//! `src/indexing/facade` both count as `src/indexing`. Every edge of the
+//! graph between symbols of two different modules adds one to the weight
+//! of that dependency; edges inside a module are not counted.
+
+use crate::export::{EdgeKind, SymbolGraph};
+use serde::Serialize;
+use std::collections::{BTreeMap, BTreeSet};
+use std::fmt;
+use std::path::{Component, Path};
+
+/// One module depending on another
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct ModuleDependency {
+    pub from: String,
+    pub to: String,
+    /// Edges from symbols of `from` to symbols of `to`
+    pub weight: usize,
+    /// The weight split by relationship
+    pub relations: BTreeMap<EdgeKind, usize>,
+}
+
+/// Who depends on whom, between the modules of the graph
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize)]
+pub struct ModuleMatrix {
+    /// Modules with at least one symbol in the graph, sorted
+    pub modules: Vec<String>,
+    /// Dependencies sorted by `from`, then `to`
+    pub dependencies: Vec<ModuleDependency>,
+}
+
+impl ModuleMatrix {
+    /// Weight of the dependency of `from` on `to`, 0 if there is none
+    pub fn weight(&self, from: &str, to: &str) -> usize {
+        self.dependencies
+            .iter()
+            .find(|dependency| dependency.from == from && dependency.to == to)
+            .map_or(0, |dependency| dependency.weight)
+    }
+
+    /// Dependencies heaviest first, in one line each
+    pub fn render_dependencies(&self) -> String {
+        let mut sorted: Vec<&ModuleDependency> = self.dependencies.iter().collect();
+        sorted.sort_by(|a, b| b.weight.cmp(&a.weight));
+        let mut out = String::new();
+        for dependency in sorted {
+            let relations: Vec<String> = dependency
+                .relations
+                .iter()
+                .map(|(kind, count)| format!("{} {count}", kind.as_str()))
+                .collect();
+            out.push_str(&format!(
+                "  {} -> {}  {} ({})\n",
+                dependency.from,
+                dependency.to,
+                dependency.weight,
+                relations.join(", ")
+            ));
+        }
+        out
+    }
+}
+
+/// The matrix: one row per module, its dependencies in the columns, with
+/// the modules numbered in a legend below
+impl fmt::Display for ModuleMatrix {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let index: BTreeMap<&str, usize> = self
+            .modules
+            .iter()
+            .enumerate()
+            .map(|(number, module)| (module.as_str(), number))
+            .collect();
+        let count = self.modules.len();
+        let mut cells = vec![vec![0usize; count]; count];
+        for dependency in &self.dependencies {
+            cells[index[dependency.from.as_str()]][index[dependency.to.as_str()]] =
+                dependency.weight;
+        }
+
+        let width = self
+            .dependencies
+            .iter()
+            .map(|dependency| dependency.weight.to_string().len())
+            .chain(std::iter::once(count.to_string().len()))
+            .max()
+            .unwrap_or(1);
+        let label = count.to_string().len();
+
+        write!(f, "  {:>label$}", "")?;
+        for column in 1..=count {
+            write!(f, " {column:>width$}")?;
+        }
+        writeln!(f)?;
+        for (row, weights) in cells.iter().enumerate() {
+            write!(f, "  {:>label$}", row + 1)?;
+            for (column, weight) in weights.iter().enumerate() {
+                let cell = match *weight {
+                    _ if row == column => "-".to_string(),
+                    0 => ".".to_string(),
+                    weight => weight.to_string(),
+                };
+                write!(f, " {cell:>width$}")?;
+            }
+            writeln!(f)?;
+        }
+        writeln!(f)?;
+        for (number, module) in self.modules.iter().enumerate() {
+            writeln!(f, "  {:>label$}  {module}", number + 1)?;
+        }
+        Ok(())
+    }
+}
+
+/// The module of a file: its directory, cut to the first `depth`
+/// components when given, or `.` for files at the root
+pub fn module_of(file: &str, depth: Option<usize>) -> String {
+    let directory = Path::new(file.trim_start_matches("./"))
+        .parent()
+        .unwrap_or(Path::new(""));
+    let components: Vec<&str> = directory
+        .components()
+        .filter_map(|component| match component {
+            Component::Normal(name) => name.to_str(),
+            _ => None,
+        })
+        .collect();
+    let kept = depth.map_or(components.len(), |depth| depth.min(components.len()));
+    if kept == 0 {
+        ".".to_string()
+    } else {
+        components[..kept].join("/")
+    }
+}
+
+/// The dependency matrix of the modules of a graph
+pub fn module_matrix(graph: &SymbolGraph, depth: Option<usize>) -> ModuleMatrix {
+    let modules: Vec<String> = graph
+        .nodes
+        .iter()
+        .map(|node| module_of(&node.file, depth))
+        .collect();
+
+    let mut weights: BTreeMap<(&str, &str), BTreeMap<EdgeKind, usize>> = BTreeMap::new();
+    for edge in &graph.edges {
+        let (from, to) = (modules[edge.from].as_str(), modules[edge.to].as_str());
+        if from != to {
+            *weights
+                .entry((from, to))
+                .or_default()
+                .entry(edge.kind)
+                .or_default() += 1;
+        }
+    }
+
+    let dependencies = weights
+        .into_iter()
+        .map(|((from, to), relations)| ModuleDependency {
+            from: from.to_string(),
+            to: to.to_string(),
+            weight: relations.values().sum(),
+            relations,
+        })
+        .collect();
+    let distinct: BTreeSet<String> = modules.iter().cloned().collect();
+    ModuleMatrix {
+        modules: distinct.into_iter().collect(),
+        dependencies,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::export::{Edge, GraphNode};
+
+    fn node(id: &str, kind: &str, file: &str) -> GraphNode {
+        GraphNode {
+            id: id.to_string(),
+            label: id.to_string(),
+            kind: kind.to_string(),
+            file: file.to_string(),
+        }
+    }
+
+    fn edge(from: usize, to: usize, kind: EdgeKind) -> Edge {
+        Edge { from, to, kind }
+    }
+
+    fn graph() -> SymbolGraph {
+        SymbolGraph {
+            nodes: vec![
+                node("f1", "File", "src/cli/args.rs"),
+                node("s1", "Function", "src/cli/run.rs"),
+                node("s2", "Function", "src/indexing/pipeline/parse.rs"),
+                node("s3", "Struct", "src/indexing/facade.rs"),
+                node("s4", "Function", "main.rs"),
+            ],
+            edges: vec![
+                edge(0, 3, EdgeKind::Imports),
+                edge(1, 2, EdgeKind::Calls),
+                edge(1, 3, EdgeKind::Calls),
+                edge(2, 3, EdgeKind::Calls),
+                edge(0, 1, EdgeKind::Calls),
+                edge(4, 1, EdgeKind::Calls),
+            ],
+        }
+    }
+
+    #[test]
+    fn test_module_of() {
+        assert_eq!(
+            module_of("src/indexing/pipeline/parse.rs", None),
+            "src/indexing/pipeline"
+        );
+        assert_eq!(
+            module_of("./src/indexing/pipeline/parse.rs", Some(2)),
+            "src/indexing"
+        );
+        assert_eq!(module_of("src/lib.rs", Some(3)), "src");
+        assert_eq!(module_of("main.rs", None), ".");
+    }
+
+    #[test]
+    fn test_matrix_weighs_edges_between_modules() {
+        let matrix = module_matrix(&graph(), None);
+
+        assert_eq!(
+            matrix.modules,
+            [".", "src/cli", "src/indexing", "src/indexing/pipeline"]
+        );
+        assert_eq!(matrix.weight("src/cli", "src/indexing"), 2);
+        assert_eq!(matrix.weight("src/cli", "src/indexing/pipeline"), 1);
+        assert_eq!(matrix.weight(".", "src/cli"), 1);
+        assert_eq!(
+            matrix.weight("src/cli", "src/cli"),
+            0,
+            "inner edges are not counted"
+        );
+        assert_eq!(matrix.weight("src/indexing", "src/cli"), 0);
+
+        let cli = &matrix.dependencies[1];
+        assert_eq!(
+            (cli.from.as_str(), cli.to.as_str()),
+            ("src/cli", "src/indexing")
+        );
+        assert_eq!(
+            cli.relations,
+            BTreeMap::from([(EdgeKind::Calls, 1), (EdgeKind::Imports, 1)])
+        );
+    }
+
+    #[test]
+    fn test_depth_merges_submodules() {
+        let matrix = module_matrix(&graph(), Some(2));
+
+        assert_eq!(matrix.modules, [".", "src/cli", "src/indexing"]);
+        assert_eq!(matrix.weight("src/cli", "src/indexing"), 3);
+        assert_eq!(matrix.dependencies.len(), 2);
+    }
+
+    #[test]
+    fn test_render_matrix() {
+        let matrix = module_matrix(&graph(), Some(2));
+
+        assert_eq!(
+            matrix.to_string(),
+            "    1 2 3\n  1 - 1 .\n  2 . - 3\n  3 . . -\n\n  1  .\n  2  src/cli\n  3  src/indexing\n"
+        );
+        assert_eq!(
+            matrix.render_dependencies(),
+            "  src/cli -> src/indexing  3 (calls 2, imports 1)\n  . -> src/cli  1 (calls 1)\n"
+        );
+    }
+}
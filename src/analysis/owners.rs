@@ -0,0 +1,320 @@
+//! Who owns the code
+//!
+//! A CODEOWNERS file maps path patterns to the teams and people who review
+//! changes under them: `/src/parsing/ @org/parsers`. The patterns follow
+//! gitignore: one without a slash matches a file or directory at any
+//! depth, one with a slash is anchored at the root, a directory owns what
+//! is under it, and `docs/*` owns the files of `docs` but not of its
+//! subdirectories. Where several patterns match, the last one wins, and a
+//! pattern without owners leaves its files unowned.
+//!
+//! Owners are read when asked for rather than stored with the symbols, so
+//! an edited CODEOWNERS takes effect without reindexing.
+
+use crate::analysis::api::{in_scope, qualified_name};
+use crate::export::GraphFilter;
+use crate::{Symbol, SymbolId, SymbolKind};
+use glob::{MatchOptions, Pattern};
+use serde::Serialize;
+use std::collections::HashMap;
+use std::fmt;
+use std::path::Path;
+
+/// Where a repository keeps its CODEOWNERS, in the order GitHub looks
+pub const CODEOWNERS_LOCATIONS: &[&str] = &[".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"];
+
+/// `*` and `?` stop at `/`, as in gitignore
+const MATCH_OPTIONS: MatchOptions = MatchOptions {
+    case_sensitive: true,
+    require_literal_separator: true,
+    require_literal_leading_dot: false,
+};
+
+/// One line of a CODEOWNERS file
+#[derive(Debug, Clone)]
+struct OwnerRule {
+    glob: Pattern,
+    /// Written with a trailing `/`: only a directory matches
+    dir_only: bool,
+    /// Whether a matching directory owns its whole tree; false for `dir/*`
+    nested: bool,
+    owners: Vec<String>,
+}
+
+impl OwnerRule {
+    /// The rule of a pattern as written; None for one that cannot match
+    fn new(pattern: &str, owners: Vec<String>) -> Option<Self> {
+        let dir_only = pattern.ends_with('/');
+        let trimmed = pattern.trim_end_matches('/');
+        let body = trimmed.trim_start_matches('/');
+        if body.is_empty() {
+            return None;
+        }
+        // Without a slash before its end it matches at any depth
+        let anchored = trimmed.starts_with('/') || body.contains('/');
+        let glob = if anchored {
+            Pattern::new(body)
+        } else {
+            Pattern::new(&format!("**/{body}"))
+        };
+        Some(Self {
+            glob: glob.ok()?,
+            dir_only,
+            nested: dir_only || !body.ends_with("/*"),
+            owners,
+        })
+    }
+
+    fn matches(&self, path: &str) -> bool {
+        if !self.dir_only && self.glob.matches_with(path, MATCH_OPTIONS) {
+            return true;
+        }
+        self.nested
+            && path
+                .match_indices('/')
+                .any(|(at, _)| self.glob.matches_with(&path[..at], MATCH_OPTIONS))
+    }
+}
+
+/// The rules of a CODEOWNERS file
+#[derive(Debug, Clone, Default)]
+pub struct CodeOwners {
+    rules: Vec<OwnerRule>,
+}
+
+impl CodeOwners {
+    /// Rules of the text of a CODEOWNERS file. Lines that are blank,
+    /// comments or patterns that cannot be compiled are skipped, as GitHub
+    /// skips them.
+    pub fn parse(text: &str) -> Self {
+        let rules = text
+            .lines()
+            .filter_map(|line| {
+                let line = line.trim();
+                if line.is_empty() || line.starts_with('#') {
+                    return None;
+                }
+                let mut tokens = line.split_whitespace();
+                let pattern = tokens.next()?;
+                // `\#` starts a pattern rather than a comment
+                let pattern = pattern.strip_prefix('\\').unwrap_or(pattern);
+                let owners = tokens
+                    .take_while(|token| !token.starts_with('#'))
+                    .map(str::to_string)
+                    .collect();
+                OwnerRule::new(pattern, owners)
+            })
+            .collect();
+        Self { rules }
+    }
+
+    /// The CODEOWNERS of the repository at `root`, the first of
+    /// [`CODEOWNERS_LOCATIONS`] that exists; None when it has none
+    pub fn load(root: &Path) -> Option<Self> {
+        CODEOWNERS_LOCATIONS.iter().find_map(|location| {
+            std::fs::read_to_string(root.join(location))
+                .ok()
+                .map(|text| Self::parse(&text))
+        })
+    }
+
+    /// Whether no rule was read
+    pub fn is_empty(&self) -> bool {
+        self.rules.is_empty()
+    }
+
+    /// Owners of the file at `path`, relative to the repository root; none
+    /// when no rule matches it
+    pub fn owners_of(&self, path: &str) -> &[String] {
+        let path = path.replace('\\', "/");
+        let path = path.trim_start_matches("./").trim_start_matches('/');
+        self.rules
+            .iter()
+            .rev()
+            .find(|rule| rule.matches(path))
+            .map(|rule| rule.owners.as_slice())
+            .unwrap_or_default()
+    }
+}
+
+/// Whether `owner` is one of `owners`: case-insensitive, and the `@` of a
+/// team or user may be left out (`org/team` for `@org/team`)
+pub fn is_owner(owners: &[String], owner: &str) -> bool {
+    let wanted = owner.trim_start_matches('@');
+    owners
+        .iter()
+        .any(|name| name.trim_start_matches('@').eq_ignore_ascii_case(wanted))
+}
+
+/// A symbol of a file an owner owns
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct OwnedItem {
+    pub symbol_id: SymbolId,
+    /// `Parser::parse` for a member, the bare name otherwise
+    pub name: String,
+    pub kind: SymbolKind,
+    pub file: String,
+    pub line: u32,
+    /// Every owner of the file, the one asked for among them
+    pub owners: Vec<String>,
+}
+
+impl fmt::Display for OwnedItem {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "{:<9}  {}:{}  [symbol_id:{}]",
+            format!("{:?}", self.kind).to_lowercase(),
+            self.name,
+            self.line,
+            self.symbol_id.value()
+        )
+    }
+}
+
+/// The symbols the filter accepts in files `owner` owns, by file, then
+/// line. `owners_of` gives the owners of a file, and is asked once a file.
+pub fn owned_by(
+    symbols: &[Symbol],
+    owner: &str,
+    filter: &GraphFilter,
+    owners_of: impl Fn(&str) -> Vec<String>,
+) -> Vec<OwnedItem> {
+    let mut files: HashMap<&str, Vec<String>> = HashMap::new();
+    let mut items: Vec<OwnedItem> = symbols
+        .iter()
+        .filter(|symbol| filter.accepts(symbol) && in_scope(symbol))
+        .filter_map(|symbol| {
+            let owners = files
+                .entry(&*symbol.file_path)
+                .or_insert_with(|| owners_of(&symbol.file_path));
+            if !is_owner(owners, owner) {
+                return None;
+            }
+            Some(OwnedItem {
+                symbol_id: symbol.id,
+                name: qualified_name(symbol),
+                kind: symbol.kind,
+                file: symbol.file_path.to_string(),
+                line: symbol.range.start_line + 1,
+                owners: owners.clone(),
+            })
+        })
+        .collect();
+    items.sort_by(|a, b| (&a.file, a.line, &a.name).cmp(&(&b.file, b.line, &b.name)));
+    items
+}
+
+/// The owned symbols by file, one a row
+pub fn render_owned(items: &[OwnedItem]) -> String {
+    let mut out = String::new();
+    let mut current: Option<&str> = None;
+    for item in items {
+        if current != Some(item.file.as_str()) {
+            if current.is_some() {
+                out.push('\n');
+            }
+            out.push_str(&format!("{} ({})\n", item.file, item.owners.join(" ")));
+            current = Some(&item.file);
+        }
+        out.push_str(&format!("  {item}\n"));
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{FileId, Range};
+
+    const CODEOWNERS: &str = "\
+# Default owners
+*                   @org/core
+*.md                @org/docs docs@example.com
+/src/parsing/       @org/parsers   # tree-sitter grammars
+apps/               @org/apps
+docs/*              @org/writers
+/src/parsing/vendor
+**/generated/**     @org/codegen
+\\#notes            @org/notes
+";
+
+    fn owners(path: &str) -> Vec<String> {
+        CodeOwners::parse(CODEOWNERS).owners_of(path).to_vec()
+    }
+
+    #[test]
+    fn test_last_matching_rule_wins() {
+        assert_eq!(owners("src/main.rs"), vec!["@org/core"]);
+        assert_eq!(owners("README.md"), vec!["@org/docs", "docs@example.com"]);
+        assert_eq!(owners("src/parsing/rust/parser.rs"), vec!["@org/parsers"]);
+        assert_eq!(owners("./src/parsing/mod.rs"), vec!["@org/parsers"]);
+        assert_eq!(
+            owners("src/indexing/generated/api.rs"),
+            vec!["@org/codegen"]
+        );
+        assert_eq!(owners("#notes"), vec!["@org/notes"]);
+    }
+
+    #[test]
+    fn test_directories_own_their_tree() {
+        // Unanchored directories match at any depth, anchored ones at the root
+        assert_eq!(owners("apps/web/index.ts"), vec!["@org/apps"]);
+        assert_eq!(owners("services/apps/api.go"), vec!["@org/apps"]);
+        assert_eq!(owners("lib/src/parsing/x.rs"), vec!["@org/core"]);
+
+        // `docs/*` stops at the files of `docs`
+        assert_eq!(owners("docs/guide.txt"), vec!["@org/writers"]);
+        assert_eq!(owners("docs/api/index.txt"), vec!["@org/core"]);
+
+        // A pattern without owners leaves its tree unowned
+        assert!(owners("src/parsing/vendor/grammar.c").is_empty());
+    }
+
+    #[test]
+    fn test_no_rules() {
+        let owners = CodeOwners::parse("# nothing yet\n\n");
+        assert!(owners.is_empty());
+        assert!(owners.owners_of("src/lib.rs").is_empty());
+    }
+
+    #[test]
+    fn test_is_owner() {
+        let owners = vec!["@Org/Parsers".to_string(), "dev@example.com".to_string()];
+        assert!(is_owner(&owners, "@org/parsers"));
+        assert!(is_owner(&owners, "org/parsers"));
+        assert!(is_owner(&owners, "dev@example.com"));
+        assert!(!is_owner(&owners, "@org/core"));
+    }
+
+    fn symbol(id: u32, name: &str, file: &str, line: u32) -> Symbol {
+        Symbol::new(
+            SymbolId::new(id).unwrap(),
+            name,
+            SymbolKind::Function,
+            FileId::new(1).unwrap(),
+            Range::new(line, 0, line + 2, 1),
+        )
+        .with_file_path(file)
+    }
+
+    #[test]
+    fn test_owned_by() {
+        let codeowners = CodeOwners::parse(CODEOWNERS);
+        let symbols = vec![
+            symbol(1, "parse", "src/parsing/mod.rs", 20),
+            symbol(2, "main", "src/main.rs", 0),
+            symbol(3, "new", "src/parsing/mod.rs", 4),
+        ];
+        let items = owned_by(&symbols, "org/parsers", &GraphFilter::default(), |path| {
+            codeowners.owners_of(path).to_vec()
+        });
+        let names: Vec<&str> = items.iter().map(|item| item.name.as_str()).collect();
+        assert_eq!(names, vec!["new", "parse"]);
+        assert_eq!(items[0].owners, vec!["@org/parsers".to_string()]);
+
+        let rendered = render_owned(&items);
+        assert!(rendered.starts_with("src/parsing/mod.rs (@org/parsers)\n"));
+        assert!(rendered.contains("function   new:5  [symbol_id:3]"));
+    }
+}
@@ -0,0 +1,1119 @@
+//! Search by type signature
+//!
+//! A query like `fn(&Path) -> Result<Vec<Symbol>>` lists the functions and
+//! methods whose parameters and return type fit it. Both the query and each
+//! symbol's signature are parsed into types, whatever the language writes
+//! them like: `name: Type` in Rust, TypeScript, Python or Kotlin, `Type name`
+//! in Java, C# or C++, `name Type` in Go. References, pointers, lifetimes
+//! and module paths are left out, and spellings of one type across
+//! languages compare equal: `PathBuf` and `Path`, `String` and `str`, `Vec`,
+//! `List` and `list`, `Optional<T>` and `T?`.
+//!
+//! The comparison is tolerant. A type parameter of the symbol, or a single
+//! uppercase letter or `_` in the query, fits any type. Wrappers like `Box`,
+//! `Arc` or `impl AsRef<Path>` fit what they wrap, and type arguments left out
+//! of the query fit any, so `Result<T>` fits `Result<T, Error>`. A parameter
+//! fits a supertype of the type in the query and a return type a subtype, as
+//! the index records them. A trailing `..` in the query fits any further
+//! parameters; a query without a return type fits any return type, `-> ()`
+//! only functions returning nothing.
+
+use crate::analysis::api::{in_scope, one_line_signature, owner_of, qualified_name};
+use crate::export::GraphFilter;
+use crate::{Symbol, SymbolId, SymbolKind};
+use serde::Serialize;
+use std::collections::{HashMap, HashSet, VecDeque};
+use std::fmt;
+
+/// Score of a type that fits exactly, or as another spelling of it
+const EXACT: f32 = 1.0;
+/// Score of a type fitting through a wrapper, or a number as an integer
+const WRAPPED: f32 = 0.9;
+/// Score of a subtype or supertype
+const SUBTYPE: f32 = 0.8;
+/// Score of a type parameter or query wildcard
+const GENERIC: f32 = 0.6;
+/// Score of a parameter or return type the code leaves untyped
+const UNTYPED: f32 = 0.3;
+
+/// Types standing for the one type they wrap
+const WRAPPERS: &[&str] = &[
+    "Box", "Rc", "Arc", "Cow", "Pin", "Ref", "RefMut", "AsRef", "AsMut", "Borrow", "Into",
+];
+
+/// Words of a type that do not change what it is
+const QUALIFIERS: &[&str] = &[
+    "mut", "const", "dyn", "impl", "final", "readonly", "struct", "volatile", "unsigned", "signed",
+    "ref", "in", "out", "inout", "typename", "extends", "super",
+];
+
+/// Words ahead of the return type of a C-like declaration
+const MODIFIERS: &[&str] = &[
+    "public",
+    "private",
+    "protected",
+    "internal",
+    "static",
+    "final",
+    "abstract",
+    "virtual",
+    "override",
+    "sealed",
+    "async",
+    "extern",
+    "inline",
+    "constexpr",
+    "synchronized",
+    "native",
+    "default",
+    "explicit",
+    "unsafe",
+    "partial",
+    "readonly",
+    "new",
+    "strictfp",
+    "function",
+    "export",
+    "template",
+];
+
+/// Languages writing the type ahead of the name
+const TYPE_FIRST: &[&str] = &["java", "csharp", "c", "cpp", "dart", "php"];
+
+/// Languages where a missing return type means none
+const UNIT_WHEN_OMITTED: &[&str] = &["rust", "go", "swift", "kotlin"];
+
+/// A type, with its names reduced to one spelling
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct TypeExpr {
+    /// Last segment of the name; `()` for no type, `_` for an untyped one,
+    /// `tuple`, `fn`, `seq`, `map`, `set` or `Option` for the shapes those
+    /// are spelled many ways
+    pub name: String,
+    pub args: Vec<TypeExpr>,
+}
+
+impl TypeExpr {
+    fn new(name: &str, args: Vec<TypeExpr>) -> Self {
+        Self {
+            name: name.to_string(),
+            args,
+        }
+    }
+
+    fn untyped() -> Self {
+        Self::new("_", Vec::new())
+    }
+
+    fn unit() -> Self {
+        Self::new("()", Vec::new())
+    }
+
+    fn is_unit(&self) -> bool {
+        self.name == "()"
+    }
+
+    /// Replace `Self` with the type a method is a member of
+    fn resolve_self(&mut self, owner: &str) {
+        if self.name == "Self" {
+            self.name = canonical(owner).to_string();
+        }
+        for arg in &mut self.args {
+            arg.resolve_self(owner);
+        }
+    }
+}
+
+/// The parameter and return types of a query or a symbol
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct TypeSignature {
+    pub params: Vec<TypeExpr>,
+    /// Whether further parameters fit too (a query ending in `..`)
+    pub rest: bool,
+    /// None in a query fits any return type
+    pub returns: Option<TypeExpr>,
+    /// Declared type parameters
+    pub generics: Vec<String>,
+}
+
+/// One symbol fitting a signature query
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct SignatureMatch {
+    pub symbol_id: SymbolId,
+    /// `Parser::parse` for a member, the bare name otherwise
+    pub name: String,
+    pub kind: SymbolKind,
+    /// The signature on one line
+    pub signature: String,
+    pub file: String,
+    pub line: u32,
+    /// How closely the symbol fits, from 0 to 1
+    pub score: f32,
+}
+
+impl fmt::Display for SignatureMatch {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "{:.2}  {:?} {} at {}:{} [symbol_id:{}]\n      {}",
+            self.score,
+            self.kind,
+            self.name,
+            self.file,
+            self.line,
+            self.symbol_id.value(),
+            self.signature
+        )
+    }
+}
+
+#[derive(Debug, Clone, PartialEq, Eq)]
+enum Token {
+    Ident(String),
+    Arrow,
+    Punct(char),
+}
+
+/// Tokens of a type, without references, pointers, lifetimes and varargs
+fn tokenize(text: &str) -> Vec<Token> {
+    let chars: Vec<char> = text.chars().collect();
+    let mut tokens = Vec::new();
+    let mut i = 0;
+    while i < chars.len() {
+        let c = chars[i];
+        if c.is_alphanumeric() || c == '_' || c == '$' {
+            let start = i;
+            while i < chars.len()
+                && (chars[i].is_alphanumeric() || chars[i] == '_' || chars[i] == '$')
+            {
+                i += 1;
+            }
+            let word: String = chars[start..i].iter().collect();
+            let word = word.trim_start_matches('$');
+            if !word.is_empty() {
+                tokens.push(Token::Ident(word.to_string()));
+            }
+            continue;
+        }
+        match (c, chars.get(i + 1).copied()) {
+            ('-' | '=', Some('>')) => {
+                tokens.push(Token::Arrow);
+                i += 2;
+            }
+            (':', Some(':')) => {
+                tokens.push(Token::Punct('.'));
+                i += 2;
+            }
+            ('.', Some('.')) => {
+                while i < chars.len() && chars[i] == '.' {
+                    i += 1;
+                }
+            }
+            ('\'', _) => {
+                // A lifetime
+                i += 1;
+                while i < chars.len() && (chars[i].is_alphanumeric() || chars[i] == '_') {
+                    i += 1;
+                }
+            }
+            ('&' | '*' | '!' | '^' | '%' | '~', _) => i += 1,
+            _ if c.is_whitespace() => i += 1,
+            _ => {
+                tokens.push(Token::Punct(c));
+                i += 1;
+            }
+        }
+    }
+    tokens
+}
+
+/// One spelling for the names of a type across languages
+fn canonical(name: &str) -> &str {
+    match name {
+        "String" | "string" | "str" | "Str" => "str",
+        "PathBuf" | "Path" => "Path",
+        "OsString" | "OsStr" => "OsStr",
+        "Vec" | "VecDeque" | "List" | "list" | "Array" | "ArrayList" | "Sequence" | "slice" => {
+            "seq"
+        }
+        "HashMap" | "BTreeMap" | "IndexMap" | "Map" | "dict" | "Dict" | "Dictionary" => "map",
+        "HashSet" | "BTreeSet" | "IndexSet" | "Set" | "set" | "frozenset" => "set",
+        "Option" | "Optional" | "Maybe" => "Option",
+        "i8" | "i16" | "i32" | "i64" | "i128" | "isize" | "u8" | "u16" | "u32" | "u64" | "u128"
+        | "usize" | "int" | "Int" | "Integer" | "long" | "Long" | "short" | "Short" | "int8"
+        | "int16" | "int32" | "int64" | "uint" | "uint8" | "uint16" | "uint32" | "uint64" => "int",
+        "f32" | "f64" | "float" | "Float" | "double" | "Double" | "float32" | "float64" => "float",
+        "bool" | "boolean" | "Boolean" | "Bool" => "bool",
+        "void" | "Unit" | "None" | "undefined" | "null" | "nil" => "()",
+        _ => name,
+    }
+}
+
+struct TypeParser {
+    tokens: Vec<Token>,
+    at: usize,
+}
+
+impl TypeParser {
+    fn new(text: &str) -> Self {
+        Self {
+            tokens: tokenize(text),
+            at: 0,
+        }
+    }
+
+    fn peek(&self) -> Option<&Token> {
+        self.tokens.get(self.at)
+    }
+
+    fn eat(&mut self, token: &Token) -> bool {
+        if self.peek() == Some(token) {
+            self.at += 1;
+            true
+        } else {
+            false
+        }
+    }
+
+    fn parse_type(&mut self) -> Option<TypeExpr> {
+        let mut ty = self.parse_base()?;
+        loop {
+            match self.peek() {
+                Some(Token::Punct('['))
+                    if self.tokens.get(self.at + 1) == Some(&Token::Punct(']')) =>
+                {
+                    self.at += 2;
+                    ty = TypeExpr::new("seq", vec![ty]);
+                }
+                Some(Token::Punct('?')) => {
+                    self.at += 1;
+                    ty = TypeExpr::new("Option", vec![ty]);
+                }
+                Some(Token::Punct('|')) => {
+                    self.at += 1;
+                    let other = self.parse_type()?;
+                    ty = match (ty.is_unit(), other.is_unit()) {
+                        (true, _) => TypeExpr::new("Option", vec![other]),
+                        (_, true) => TypeExpr::new("Option", vec![ty]),
+                        _ => TypeExpr::new("union", vec![ty, other]),
+                    };
+                }
+                _ => return Some(ty),
+            }
+        }
+    }
+
+    fn parse_base(&mut self) -> Option<TypeExpr> {
+        match self.peek()?.clone() {
+            Token::Punct('(') => {
+                self.at += 1;
+                let mut items = self.parse_list(')');
+                if self.eat(&Token::Arrow) {
+                    let returns = self.parse_type().unwrap_or_else(TypeExpr::unit);
+                    items.push(returns);
+                    return Some(TypeExpr::new("fn", items));
+                }
+                Some(match items.len() {
+                    0 => TypeExpr::unit(),
+                    1 => items.remove(0),
+                    _ => TypeExpr::new("tuple", items),
+                })
+            }
+            Token::Punct('[') => {
+                self.at += 1;
+                if self.eat(&Token::Punct(']')) {
+                    // Go slice: []T
+                    let element = self.parse_type()?;
+                    return Some(TypeExpr::new("seq", vec![element]));
+                }
+                let element = self.parse_type()?;
+                while !matches!(self.peek(), None | Some(Token::Punct(']'))) {
+                    self.at += 1;
+                }
+                self.eat(&Token::Punct(']'));
+                if element.name.chars().all(|c| c.is_ascii_digit()) {
+                    // Go array: [4]T
+                    let element = self.parse_type()?;
+                    return Some(TypeExpr::new("seq", vec![element]));
+                }
+                Some(TypeExpr::new("seq", vec![element]))
+            }
+            Token::Punct('?') => {
+                self.at += 1;
+                Some(TypeExpr::untyped())
+            }
+            Token::Ident(word) if QUALIFIERS.contains(&word.as_str()) => {
+                self.at += 1;
+                self.parse_base()
+            }
+            Token::Ident(mut name) => {
+                self.at += 1;
+                while self.peek() == Some(&Token::Punct('.')) {
+                    match self.tokens.get(self.at + 1) {
+                        Some(Token::Ident(segment)) => {
+                            name = segment.clone();
+                            self.at += 2;
+                        }
+                        _ => break,
+                    }
+                }
+                if name == "map" && self.eat(&Token::Punct('[')) {
+                    // Go map: map[K]V
+                    let key = self.parse_type()?;
+                    self.eat(&Token::Punct(']'));
+                    let value = self.parse_type()?;
+                    return Some(TypeExpr::new("map", vec![key, value]));
+                }
+                let mut args = Vec::new();
+                if self.eat(&Token::Punct('<')) {
+                    args = self.parse_list('>');
+                } else if self.peek() == Some(&Token::Punct('['))
+                    && self.tokens.get(self.at + 1) != Some(&Token::Punct(']'))
+                {
+                    // Python generics: list[int]
+                    self.at += 1;
+                    args = self.parse_list(']');
+                }
+                if matches!(
+                    name.as_str(),
+                    "Fn" | "FnMut" | "FnOnce" | "func" | "Func" | "Callable"
+                ) && self.eat(&Token::Punct('('))
+                {
+                    let mut items = self.parse_list(')');
+                    // Go writes the return type of func(int) error without an arrow
+                    let returns = if self.eat(&Token::Arrow) || name == "func" {
+                        self.parse_type()
+                    } else {
+                        None
+                    };
+                    items.push(returns.unwrap_or_else(TypeExpr::unit));
+                    return Some(TypeExpr::new("fn", items));
+                }
+                Some(TypeExpr::new(canonical(&name), args))
+            }
+            _ => None,
+        }
+    }
+
+    /// Types separated by commas, up to and past `close`
+    fn parse_list(&mut self, close: char) -> Vec<TypeExpr> {
+        let mut items = Vec::new();
+        loop {
+            match self.peek() {
+                None => return items,
+                Some(Token::Punct(c)) if *c == close => {
+                    self.at += 1;
+                    return items;
+                }
+                Some(Token::Punct(',')) => self.at += 1,
+                _ => match self.parse_type() {
+                    Some(item) => items.push(item),
+                    // Skip what is not a type, like a trait bound
+                    None => self.at += 1,
+                },
+            }
+        }
+    }
+}
+
+/// Parse the type spelled by `text`
+fn parse_type(text: &str) -> Option<TypeExpr> {
+    TypeParser::new(text).parse_type()
+}
+
+/// Whether `chars[i]` ends an arrow, and so closes no bracket
+fn is_arrow_head(chars: &[char], i: usize) -> bool {
+    chars[i] == '>' && i > 0 && matches!(chars[i - 1], '-' | '=')
+}
+
+/// The parts of `text` between top-level occurrences of `separator`
+fn split_top_level(text: &str, separator: impl Fn(char) -> bool) -> Vec<String> {
+    let chars: Vec<char> = text.chars().collect();
+    let mut parts = Vec::new();
+    let mut current = String::new();
+    let mut depth = 0i32;
+    for (i, &c) in chars.iter().enumerate() {
+        match c {
+            '(' | '<' | '[' | '{' => depth += 1,
+            '>' if is_arrow_head(&chars, i) => {}
+            ')' | '>' | ']' | '}' => depth -= 1,
+            _ if depth == 0 && separator(c) => {
+                parts.push(std::mem::take(&mut current));
+                continue;
+            }
+            _ => {}
+        }
+        current.push(c);
+    }
+    parts.push(current);
+    parts
+        .into_iter()
+        .map(|part| part.trim().to_string())
+        .filter(|part| !part.is_empty())
+        .collect()
+}
+
+/// Index past the bracket closing the one `open` at `start` opens
+fn group_end(chars: &[char], start: usize, open: char, close: char) -> Option<usize> {
+    let mut depth = 0;
+    for (i, &c) in chars.iter().enumerate().skip(start) {
+        if c == open {
+            depth += 1;
+        } else if c == close && !is_arrow_head(chars, i) {
+            depth -= 1;
+            if depth == 0 {
+                return Some(i + 1);
+            }
+        }
+    }
+    None
+}
+
+/// Index of the first top-level `:` of `text` that is not part of `::`
+fn type_colon(text: &str) -> Option<usize> {
+    let bytes = text.as_bytes();
+    let mut depth = 0i32;
+    for (i, &b) in bytes.iter().enumerate() {
+        match b {
+            b'(' | b'<' | b'[' | b'{' => depth += 1,
+            b')' | b'>' | b']' | b'}' => depth -= 1,
+            b':' if depth == 0 => {
+                let doubled = bytes.get(i + 1) == Some(&b':') || (i > 0 && bytes[i - 1] == b':');
+                if !doubled {
+                    return Some(i);
+                }
+            }
+            _ => {}
+        }
+    }
+    None
+}
+
+/// The type in the text of one parameter, without its name and default
+fn param_type(param: &str, type_first: bool, query: bool) -> Option<TypeExpr> {
+    let param = match split_top_level(param, |c| c == '=').first() {
+        Some(before_default) if !param.contains("=>") => before_default.clone(),
+        _ => param.to_string(),
+    };
+    if let Some(colon) = type_colon(&param) {
+        return Some(parse_type(&param[colon + 1..]).unwrap_or_else(TypeExpr::untyped));
+    }
+    if query {
+        return parse_type(&param);
+    }
+    let words = split_top_level(&param, char::is_whitespace);
+    match words.len() {
+        0 => None,
+        1 if type_first && !param.starts_with('$') => parse_type(&param),
+        1 => Some(TypeExpr::untyped()),
+        n => parse_type(&words[..n - 1].join(" ")),
+    }
+}
+
+/// Whether a parameter is the receiver of a method
+fn is_receiver(param: &str, position: usize) -> bool {
+    let name = param
+        .split(|c: char| c == ':' || c.is_whitespace() || c == '&')
+        .find(|word| !word.is_empty() && *word != "mut" && !word.starts_with('\''))
+        .unwrap_or_default();
+    name == "self" || name == "this" || (position == 0 && name == "cls")
+}
+
+/// The first identifier of each declared type parameter
+fn generic_names(group: &str) -> Vec<String> {
+    split_top_level(group, |c| c == ',')
+        .iter()
+        .filter_map(|param| {
+            tokenize(param).into_iter().find_map(|token| match token {
+                Token::Ident(name) if !matches!(name.as_str(), "typename" | "class" | "const") => {
+                    Some(name)
+                }
+                _ => None,
+            })
+        })
+        .collect()
+}
+
+/// Parse a signature query like `fn(&Path) -> Result<Vec<Symbol>>`,
+/// `(str, int) -> list[str]` or `func(string) error`
+pub fn parse_signature(query: &str) -> Result<TypeSignature, String> {
+    let chars: Vec<char> = query.trim().chars().collect();
+    let open = chars
+        .iter()
+        .position(|&c| c == '(')
+        .ok_or_else(|| format!("no parameter list in '{query}', as in fn(&str) -> bool"))?;
+    let close = group_end(&chars, open, '(', ')')
+        .ok_or_else(|| format!("unclosed parameter list in '{query}'"))?;
+
+    let head: String = chars[..open].iter().collect();
+    let generics = match (head.find('<'), head.rfind('>')) {
+        (Some(start), Some(end)) if start < end => generic_names(&head[start + 1..end]),
+        _ => Vec::new(),
+    };
+    let params_text: String = chars[open + 1..close - 1].iter().collect();
+    let mut params = Vec::new();
+    let mut rest = false;
+    for param in split_top_level(&params_text, |c| c == ',') {
+        if param.chars().all(|c| c == '.') {
+            rest = true;
+        } else if let Some(ty) = param_type(&param, true, true) {
+            params.push(ty);
+        } else {
+            return Err(format!("cannot read the parameter type '{param}'"));
+        }
+    }
+
+    let tail: String = chars[close..].iter().collect();
+    let tail = tail.trim();
+    let returns = if tail.is_empty() {
+        None
+    } else {
+        let tail = ["->", "=>", ":"]
+            .iter()
+            .find_map(|arrow| tail.strip_prefix(*arrow))
+            .unwrap_or(tail);
+        Some(parse_type(tail).ok_or_else(|| format!("cannot read the return type '{tail}'"))?)
+    };
+    Ok(TypeSignature {
+        params,
+        rest,
+        returns,
+        generics,
+    })
+}
+
+/// Parse the parameter and return types of a function or method from its
+/// signature, by the conventions of its language
+pub fn symbol_signature(symbol: &Symbol) -> Option<TypeSignature> {
+    let text = one_line_signature(symbol)?;
+    let language = symbol.language_id.map(|id| id.as_str()).unwrap_or_default();
+    let type_first = TYPE_FIRST.contains(&language);
+    let chars: Vec<char> = text.chars().collect();
+
+    // The parameter list follows the name, and any type parameters
+    let name: Vec<char> = symbol.name.chars().collect();
+    let is_ident = |c: char| c.is_alphanumeric() || c == '_';
+    let mut open = None;
+    let mut generics = Vec::new();
+    let mut name_start = None;
+    let starts = if name.is_empty() {
+        0
+    } else {
+        (chars.len() + 1).saturating_sub(name.len())
+    };
+    for start in 0..starts {
+        if chars[start..start + name.len()] != name[..] || (start > 0 && is_ident(chars[start - 1]))
+        {
+            continue;
+        }
+        let mut at = start + name.len();
+        let mut group = Vec::new();
+        for (opening, closing) in [('<', '>'), ('[', ']')] {
+            if chars.get(at) == Some(&opening) {
+                let end = group_end(&chars, at, opening, closing)?;
+                group = generic_names(&chars[at + 1..end - 1].iter().collect::<String>());
+                at = end;
+            }
+        }
+        if chars.get(at) == Some(&'(') {
+            open = Some(at);
+            generics = group;
+            name_start = Some(start);
+            break;
+        }
+    }
+    let open = open.or_else(|| chars.iter().position(|&c| c == '('))?;
+    let close = group_end(&chars, open, '(', ')')?;
+    let prefix: String = chars[..name_start.unwrap_or(open)].iter().collect();
+
+    let params_text: String = chars[open + 1..close - 1].iter().collect();
+    let raw: Vec<String> = split_top_level(&params_text, |c| c == ',')
+        .into_iter()
+        .enumerate()
+        .filter(|(position, param)| {
+            !is_receiver(param, *position) && !param.starts_with('*') && param != "/"
+        })
+        .map(|(_, param)| param)
+        .collect();
+    let mut params: Vec<TypeExpr> = if language == "go" {
+        go_params(&raw)
+    } else {
+        raw.iter()
+            .filter_map(|param| param_type(param, type_first, false))
+            // C's foo(void)
+            .filter(|ty| !ty.is_unit())
+            .collect()
+    };
+
+    let tail: String = chars[close..].iter().collect();
+    let tail = tail.trim();
+    let arrow = ["->", "=>", ":"]
+        .iter()
+        .find_map(|arrow| tail.strip_prefix(*arrow));
+    let mut returns = if let Some(returns) = arrow {
+        parse_type(returns)
+    } else if matches!(language, "go" | "zig") && !tail.is_empty() && !tail.starts_with('{') {
+        parse_type(tail)
+    } else if type_first {
+        let (declared, returns) = prefix_return(&prefix);
+        generics.extend(declared);
+        returns
+    } else {
+        None
+    };
+    if returns.is_none() {
+        // A type-first declaration without a return type is a constructor
+        if type_first && owner_of(symbol) == Some(symbol.name.as_ref()) {
+            returns = Some(TypeExpr::new(canonical(&symbol.name), Vec::new()));
+        } else if type_first || UNIT_WHEN_OMITTED.contains(&language) {
+            returns = Some(TypeExpr::unit());
+        } else {
+            returns = Some(TypeExpr::untyped());
+        }
+    }
+    if language != "go" && !type_first {
+        // Kotlin and TypeScript declare type parameters ahead of the name
+        let head = prefix.trim_end();
+        if let (Some(start), Some(end)) = (head.find('<'), head.rfind('>')) {
+            if start < end {
+                generics.extend(generic_names(&head[start + 1..end]));
+            }
+        }
+    }
+
+    if let Some(owner) = owner_of(symbol) {
+        for param in &mut params {
+            param.resolve_self(owner);
+        }
+        if let Some(returns) = &mut returns {
+            returns.resolve_self(owner);
+        }
+    }
+    Some(TypeSignature {
+        params,
+        rest: false,
+        returns,
+        generics,
+    })
+}
+
+/// Go parameter types: in `a, b int` the names ahead share the next type,
+/// but a list without names is only types
+fn go_params(raw: &[String]) -> Vec<TypeExpr> {
+    let words: Vec<Vec<String>> = raw
+        .iter()
+        .map(|param| split_top_level(param, char::is_whitespace))
+        .collect();
+    if words.iter().all(|words| words.len() < 2) {
+        return raw.iter().filter_map(|param| parse_type(param)).collect();
+    }
+    let mut params = Vec::new();
+    let mut shared = None;
+    for words in words.iter().rev() {
+        if words.len() >= 2 {
+            shared = parse_type(&words[1..].join(" "));
+        }
+        params.push(shared.clone().unwrap_or_else(TypeExpr::untyped));
+    }
+    params.reverse();
+    params
+}
+
+/// The type parameters and return type written ahead of the name, past the
+/// annotations and modifiers
+fn prefix_return(prefix: &str) -> (Vec<String>, Option<TypeExpr>) {
+    let chars: Vec<char> = prefix.trim().chars().collect();
+    let mut kept = String::new();
+    let mut i = 0;
+    while i < chars.len() {
+        match chars[i] {
+            // Annotations and attributes: @Override, @Get("/"), [HttpGet], #[inline]
+            '@' => {
+                i += 1;
+                while i < chars.len()
+                    && (chars[i].is_alphanumeric() || matches!(chars[i], '_' | '.'))
+                {
+                    i += 1;
+                }
+                if chars.get(i) == Some(&'(') {
+                    i = group_end(&chars, i, '(', ')').unwrap_or(chars.len());
+                }
+            }
+            '[' if kept.trim().is_empty() || kept.trim_end().ends_with('#') => {
+                i = group_end(&chars, i, '[', ']').unwrap_or(chars.len());
+                kept = kept.trim_end().trim_end_matches('#').to_string();
+            }
+            c => {
+                kept.push(c);
+                i += 1;
+            }
+        }
+    }
+
+    let mut words: Vec<String> = split_top_level(&kept, char::is_whitespace)
+        .into_iter()
+        .map(|word| match word.strip_prefix("template") {
+            Some(generics) if generics.starts_with('<') => generics.to_string(),
+            _ => word,
+        })
+        .filter(|word| !MODIFIERS.contains(&word.as_str()))
+        .collect();
+    let mut generics = Vec::new();
+    // Declared type parameters, as in `public static <T> List<T>` or
+    // `template<typename T>`
+    while let Some(first) = words.first() {
+        if !first.starts_with('<') {
+            break;
+        }
+        let first = words.remove(0);
+        generics.extend(generic_names(
+            first.trim_start_matches('<').trim_end_matches('>'),
+        ));
+    }
+    let returns = parse_type(&words.join(" ")).filter(|returns| returns.name != "_");
+    (generics, returns)
+}
+
+/// Which way a type of the query may differ from the symbol's
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Variance {
+    /// The symbol may take a supertype of the query's
+    Param,
+    /// The symbol may return a subtype of the query's
+    Return,
+}
+
+struct Matcher<'a> {
+    supertypes: &'a HashMap<String, Vec<String>>,
+    query_generics: &'a [String],
+    generics: &'a [String],
+}
+
+impl Matcher<'_> {
+    /// How closely `actual` fits `query`, None when it does not
+    fn score(&self, query: &TypeExpr, actual: &TypeExpr, variance: Variance) -> Option<f32> {
+        if actual.name == "_" {
+            return Some(UNTYPED);
+        }
+        if self.is_wildcard(query)
+            || (actual.args.is_empty() && self.generics.contains(&actual.name))
+        {
+            return Some(GENERIC);
+        }
+        if query.name == actual.name {
+            let shared = query.args.len().min(actual.args.len());
+            let mut total = EXACT;
+            for (query_arg, actual_arg) in query.args.iter().zip(&actual.args) {
+                total += self.score(query_arg, actual_arg, variance)?;
+            }
+            return Some(total / (1 + shared) as f32);
+        }
+        let number = |ty: &TypeExpr| ty.name == "number";
+        let numeric = |ty: &TypeExpr| matches!(ty.name.as_str(), "int" | "float");
+        if (number(query) && numeric(actual)) || (numeric(query) && number(actual)) {
+            return Some(WRAPPED);
+        }
+        if WRAPPERS.contains(&actual.name.as_str()) && !actual.args.is_empty() {
+            if let Some(score) = self.score(query, &actual.args[0], variance) {
+                return Some(score * WRAPPED);
+            }
+        }
+        if WRAPPERS.contains(&query.name.as_str()) && !query.args.is_empty() {
+            if let Some(score) = self.score(&query.args[0], actual, variance) {
+                return Some(score * WRAPPED);
+            }
+        }
+        let (sub, sup) = match variance {
+            Variance::Param => (&query.name, &actual.name),
+            Variance::Return => (&actual.name, &query.name),
+        };
+        self.is_subtype(sub, sup).then_some(SUBTYPE)
+    }
+
+    fn is_wildcard(&self, query: &TypeExpr) -> bool {
+        query.args.is_empty()
+            && (query.name == "_"
+                || self.query_generics.contains(&query.name)
+                || (query.name.len() == 1 && query.name.chars().all(|c| c.is_ascii_uppercase())))
+    }
+
+    fn is_subtype(&self, sub: &str, sup: &str) -> bool {
+        let mut seen = HashSet::new();
+        let mut queue = VecDeque::from([sub]);
+        while let Some(name) = queue.pop_front() {
+            if !seen.insert(name) {
+                continue;
+            }
+            for parent in self.supertypes.get(name).into_iter().flatten() {
+                if parent == sup {
+                    return true;
+                }
+                queue.push_back(parent);
+            }
+        }
+        false
+    }
+}
+
+/// How closely a symbol's signature fits the query: the mean score of its
+/// parameters and return type, None when one does not fit or only untyped
+/// ones do
+fn fit(
+    query: &TypeSignature,
+    actual: &TypeSignature,
+    supertypes: &HashMap<String, Vec<String>>,
+) -> Option<f32> {
+    let count_fits = if query.rest {
+        actual.params.len() >= query.params.len()
+    } else {
+        actual.params.len() == query.params.len()
+    };
+    if !count_fits {
+        return None;
+    }
+    let matcher = Matcher {
+        supertypes,
+        query_generics: &query.generics,
+        generics: &actual.generics,
+    };
+    let mut scores = Vec::new();
+    for (query_param, param) in query.params.iter().zip(&actual.params) {
+        scores.push(matcher.score(query_param, param, Variance::Param)?);
+    }
+    if let (Some(query_returns), Some(returns)) = (&query.returns, &actual.returns) {
+        scores.push(matcher.score(query_returns, returns, Variance::Return)?);
+    }
+    if scores.is_empty() {
+        return Some(EXACT);
+    }
+    if scores.iter().all(|&score| score <= UNTYPED) {
+        return None;
+    }
+    Some(scores.iter().sum::<f32>() / scores.len() as f32)
+}
+
+/// The supertypes of each type, as names, from the types the index says
+/// each one implements or extends
+pub fn supertype_names<'a>(
+    edges: impl IntoIterator<Item = (&'a str, &'a str)>,
+) -> HashMap<String, Vec<String>> {
+    let mut supertypes: HashMap<String, Vec<String>> = HashMap::new();
+    for (sub, sup) in edges {
+        let parents = supertypes.entry(canonical(sub).to_string()).or_default();
+        let sup = canonical(sup).to_string();
+        if !parents.contains(&sup) {
+            parents.push(sup);
+        }
+    }
+    supertypes
+}
+
+/// The functions and methods the filter accepts whose signatures fit the
+/// query, best first. Without kinds in the filter, functions, methods and
+/// constructors are searched.
+pub fn find_by_signature(
+    symbols: &[Symbol],
+    query: &TypeSignature,
+    filter: &GraphFilter,
+    supertypes: &HashMap<String, Vec<String>>,
+) -> Vec<SignatureMatch> {
+    let callable = |kind: SymbolKind| {
+        if filter.kinds.is_empty() {
+            matches!(kind, SymbolKind::Function | SymbolKind::Method)
+        } else {
+            filter.kinds.contains(&kind)
+        }
+    };
+    let mut matches: Vec<SignatureMatch> = symbols
+        .iter()
+        .filter(|symbol| callable(symbol.kind) && in_scope(symbol) && filter.accepts(symbol))
+        .filter_map(|symbol| {
+            let score = fit(query, &symbol_signature(symbol)?, supertypes)?;
+            Some(SignatureMatch {
+                symbol_id: symbol.id,
+                name: qualified_name(symbol),
+                kind: symbol.kind,
+                signature: one_line_signature(symbol).unwrap_or_default(),
+                file: symbol.file_path.to_string(),
+                line: symbol.range.start_line + 1,
+                score,
+            })
+        })
+        .collect();
+    matches.sort_by(|a, b| {
+        b.score
+            .total_cmp(&a.score)
+            .then_with(|| (&a.name, &a.file, a.line).cmp(&(&b.name, &b.file, b.line)))
+    });
+    matches
+}
+
+/// The matches best first, each with its signature under it
+pub fn render_signature_matches(matches: &[SignatureMatch]) -> String {
+    let mut out = String::new();
+    for item in matches {
+        out.push_str(&format!("  {item}\n"));
+    }
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::LanguageId;
+    use crate::{FileId, Range, ScopeContext};
+
+    fn function(id: u32, name: &str, language: &str, signature: &str) -> Symbol {
+        Symbol::new(
+            SymbolId::new(id).unwrap(),
+            name,
+            SymbolKind::Function,
+            FileId::new(1).unwrap(),
+            Range::new(id, 0, id, 10),
+        )
+        .with_file_path("src/lib.rs")
+        .with_signature(signature)
+        .with_language_id(LanguageId::new(language))
+    }
+
+    fn ty(name: &str, args: Vec<TypeExpr>) -> TypeExpr {
+        TypeExpr::new(name, args)
+    }
+
+    #[test]
+    fn test_parse_signature() {
+        let query = parse_signature("fn(&Path) -> Result<Vec<Symbol>>").unwrap();
+        assert_eq!(query.params, vec![ty("Path", vec![])]);
+        assert_eq!(
+            query.returns,
+            Some(ty("Result", vec![ty("seq", vec![ty("Symbol", vec![])])]))
+        );
+        assert!(!query.rest);
+
+        let query = parse_signature("(str, ..) => list[int]").unwrap();
+        assert_eq!(query.params, vec![ty("str", vec![])]);
+        assert!(query.rest);
+        assert_eq!(query.returns, Some(ty("seq", vec![ty("int", vec![])])));
+
+        let query = parse_signature("fn<K>(K)").unwrap();
+        assert_eq!(query.generics, ["K"]);
+        assert!(query.returns.is_none());
+        assert_eq!(
+            parse_signature("fn() -> ()").unwrap().returns,
+            Some(TypeExpr::unit())
+        );
+        assert!(parse_signature("Result<Vec<Symbol>>").is_err());
+    }
+
+    #[test]
+    fn test_signatures_across_languages() {
+        let path_to_symbols = |symbol: &Symbol| symbol_signature(symbol).unwrap();
+        let expected = TypeSignature {
+            params: vec![ty("Path", vec![])],
+            rest: false,
+            returns: Some(ty("seq", vec![ty("Symbol", vec![])])),
+            generics: Vec::new(),
+        };
+        for symbol in [
+            function(
+                1,
+                "load",
+                "rust",
+                "pub fn load(path: &std::path::Path) -> Vec<Symbol>",
+            ),
+            function(
+                2,
+                "load",
+                "typescript",
+                "export function load(path: Path): Symbol[]",
+            ),
+            function(3, "load", "python", "def load(path: Path) -> list[Symbol]:"),
+            function(
+                4,
+                "load",
+                "java",
+                "@Override public static List<Symbol> load(final Path path)",
+            ),
+            function(5, "load", "go", "func (r *Repo) load(path Path) []Symbol"),
+            function(6, "load", "kotlin", "fun load(path: Path): List<Symbol>"),
+        ] {
+            assert_eq!(
+                path_to_symbols(&symbol),
+                expected,
+                "{:?}",
+                symbol.language_id
+            );
+        }
+
+        let method = function(
+            7,
+            "merge",
+            "rust",
+            "fn merge<'a, T: Into<PathBuf>>(&mut self, other: &'a T)",
+        )
+        .with_scope(ScopeContext::ClassMember {
+            class_name: Some("Settings".into()),
+        });
+        let signature = symbol_signature(&method).unwrap();
+        assert_eq!(signature.params, vec![ty("T", vec![])]);
+        assert_eq!(signature.generics, ["T"]);
+        assert_eq!(signature.returns, Some(TypeExpr::unit()));
+
+        let go = function(8, "copy", "go", "func copy(dst, src []byte) (int, error)");
+        let signature = symbol_signature(&go).unwrap();
+        assert_eq!(signature.params.len(), 2);
+        assert_eq!(signature.params[0], signature.params[1]);
+        assert_eq!(signature.returns.unwrap().name, "tuple");
+    }
+
+    #[test]
+    fn test_find_by_signature_is_tolerant() {
+        let symbols = vec![
+            function(
+                1,
+                "parse_file",
+                "rust",
+                "pub fn parse_file(path: &Path) -> Result<Vec<Symbol>, Error>",
+            ),
+            function(
+                2,
+                "parse_any",
+                "rust",
+                "pub fn parse_any<P: AsRef<Path>>(path: P) -> Result<Vec<Symbol>>",
+            ),
+            function(
+                3,
+                "open",
+                "rust",
+                "pub fn open(path: impl AsRef<Path>) -> Result<Vec<Symbol>>",
+            ),
+            function(4, "count", "rust", "pub fn count(path: &Path) -> usize"),
+            function(5, "guess", "python", "def guess(path):"),
+            function(
+                6,
+                "animals",
+                "rust",
+                "fn animals(path: &Path) -> Result<Vec<Dog>>",
+            ),
+        ];
+        let supertypes = supertype_names([("Dog", "Symbol")]);
+        let query = parse_signature("fn(&Path) -> Result<Vec<Symbol>>").unwrap();
+        let matches = find_by_signature(&symbols, &query, &GraphFilter::default(), &supertypes);
+        let names: Vec<&str> = matches.iter().map(|m| m.name.as_str()).collect();
+
+        // A wrapper and a subtype fit nearly as well, a type parameter less
+        assert_eq!(names, ["parse_file", "animals", "open", "parse_any"]);
+        assert_eq!(matches[0].score, 1.0);
+        assert!(matches[1..].iter().all(|m| m.score < 1.0));
+        assert!(
+            render_signature_matches(&matches[..1])
+                .contains("[symbol_id:1]\n      pub fn parse_file(path: &Path)")
+        );
+
+        // Any return type without one in the query, but not untyped code only
+        let query = parse_signature("fn(PathBuf)").unwrap();
+        let matches = find_by_signature(&symbols, &query, &GraphFilter::default(), &supertypes);
+        assert_eq!(matches.len(), 5);
+        assert!(!matches.iter().any(|m| m.name == "guess"));
+    }
+}
@@ -0,0 +1,218 @@
+//! Where a message comes from
+//!
+//! The URLs, routes, format strings and messages of string literals are
+//! indexed with the symbol holding them (see [`crate::parsing::strings`]),
+//! so an error seen in a log leads back to the code writing it. A pattern
+//! matches a literal holding it, case-insensitive and with `*` for any
+//! text, and a format string whose text around the placeholders it holds:
+//! `failed to open config.toml: denied` finds `failed to open {}: {}`.
+
+use crate::SymbolId;
+use crate::parsing::strings::{IndexedString, StringKind, template_segments};
+use serde::Serialize;
+
+/// Which literals to list
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct StringFilter {
+    /// Only literals matching this pattern
+    pub pattern: Option<String>,
+    /// Only these kinds (all if empty)
+    pub kinds: Vec<StringKind>,
+    /// Only literals in files under this path
+    pub path: Option<String>,
+}
+
+/// Whether `parts` appear in `text`, in order
+fn contains_in_order<'a>(text: &str, parts: impl IntoIterator<Item = &'a str>) -> bool {
+    let mut rest = text;
+    for part in parts.into_iter().filter(|part| !part.is_empty()) {
+        match rest.find(part) {
+            Some(at) => rest = &rest[at + part.len()..],
+            None => return false,
+        }
+    }
+    true
+}
+
+/// Whether `pattern` matches the literal `text`: found in it, or holding
+/// the text around its placeholders
+pub fn matches_pattern(pattern: &str, text: &str) -> bool {
+    let pattern = pattern.to_lowercase();
+    if contains_in_order(&text.to_lowercase(), pattern.split('*')) {
+        return true;
+    }
+    let segments = template_segments(text);
+    if segments.concat().len() == text.len() {
+        return false;
+    }
+    let segments: Vec<String> = segments.iter().map(|part| part.to_lowercase()).collect();
+    contains_in_order(&pattern, segments.iter().map(String::as_str))
+}
+
+impl StringFilter {
+    pub fn accepts(&self, literal: &IndexedString) -> bool {
+        if !self.kinds.is_empty() && !self.kinds.contains(&literal.kind) {
+            return false;
+        }
+        if let Some(path) = &self.path {
+            let prefix = path.trim_start_matches("./");
+            if !literal
+                .file_path
+                .trim_start_matches("./")
+                .starts_with(prefix)
+            {
+                return false;
+            }
+        }
+        self.pattern
+            .as_ref()
+            .is_none_or(|pattern| matches_pattern(pattern, &literal.text))
+    }
+}
+
+/// One listed literal
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct StringItem {
+    pub kind: StringKind,
+    pub text: String,
+    pub file: String,
+    pub line: u32,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_id: Option<SymbolId>,
+    /// Name of the symbol holding the literal
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol: Option<String>,
+}
+
+/// The literals the filter accepts, named after their symbols
+pub fn list_strings(
+    strings: Vec<IndexedString>,
+    filter: &StringFilter,
+    symbol_name: impl Fn(SymbolId) -> Option<String>,
+) -> Vec<StringItem> {
+    strings
+        .into_iter()
+        .filter(|literal| filter.accepts(literal))
+        .map(|literal| StringItem {
+            symbol: literal.symbol_id.and_then(&symbol_name),
+            kind: literal.kind,
+            text: literal.text,
+            file: literal.file_path,
+            line: literal.line + 1,
+            symbol_id: literal.symbol_id,
+        })
+        .collect()
+}
+
+/// Rows of a string listing
+pub fn render_strings(items: &[StringItem]) -> String {
+    let mut rows = String::new();
+    for item in items {
+        rows.push_str(&format!(
+            "  {} at {}:{}: \"{}\"",
+            item.kind.as_str(),
+            item.file,
+            item.line,
+            item.text
+        ));
+        if let (Some(symbol), Some(id)) = (&item.symbol, item.symbol_id) {
+            rows.push_str(&format!(" [in {symbol}, symbol_id:{}]", id.value()));
+        }
+        rows.push('\n');
+    }
+    rows
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::FileId;
+
+    fn literal(kind: StringKind, text: &str, file: &str, line: u32) -> IndexedString {
+        IndexedString {
+            kind,
+            text: text.to_string(),
+            file_id: FileId::new(1).unwrap(),
+            file_path: file.to_string(),
+            line,
+            column: 4,
+            symbol_id: SymbolId::new(line),
+        }
+    }
+
+    fn strings() -> Vec<IndexedString> {
+        vec![
+            literal(
+                StringKind::Format,
+                "failed to open {}: {err}",
+                "src/config.rs",
+                11,
+            ),
+            literal(StringKind::Route, "/users/{id}", "src/routes.rs", 3),
+            literal(StringKind::Message, "User not found", "./src/routes.rs", 20),
+        ]
+    }
+
+    fn names(id: SymbolId) -> Option<String> {
+        (id.value() == 3).then(|| "show".to_string())
+    }
+
+    #[test]
+    fn test_matches_pattern() {
+        assert!(matches_pattern("USER NOT", "User not found"));
+        assert!(matches_pattern("user*found", "User not found"));
+        assert!(!matches_pattern("found*user", "User not found"));
+
+        // A concrete message matches the format printing it
+        let format = "failed to open {}: {err}";
+        assert!(matches_pattern("Failed to open app.toml: denied", format));
+        assert!(!matches_pattern("failed to read app.toml: denied", format));
+        assert!(matches_pattern("retry 3 of 5", "retry %d of %d"));
+        assert!(!matches_pattern("user 42", "User not found"));
+    }
+
+    #[test]
+    fn test_filter_by_pattern_kind_and_path() {
+        let listed = |filter: StringFilter| -> Vec<u32> {
+            list_strings(strings(), &filter, names)
+                .iter()
+                .map(|item| item.line)
+                .collect()
+        };
+
+        assert_eq!(listed(StringFilter::default()), [12, 4, 21]);
+        assert_eq!(
+            listed(StringFilter {
+                pattern: Some("failed to open /etc/app.toml: permission denied".to_string()),
+                ..StringFilter::default()
+            }),
+            [12]
+        );
+        assert_eq!(
+            listed(StringFilter {
+                kinds: vec![StringKind::Route, StringKind::Message],
+                ..StringFilter::default()
+            }),
+            [4, 21]
+        );
+        assert_eq!(
+            listed(StringFilter {
+                path: Some("src/routes".to_string()),
+                pattern: Some("user".to_string()),
+                ..StringFilter::default()
+            }),
+            [4, 21]
+        );
+    }
+
+    #[test]
+    fn test_render_strings() {
+        let items = list_strings(strings(), &StringFilter::default(), names);
+
+        assert_eq!(
+            render_strings(&items[..2]),
+            "  format at src/config.rs:12: \"failed to open {}: {err}\"\n  \
+             route at src/routes.rs:4: \"/users/{id}\" [in show, symbol_id:3]\n"
+        );
+    }
+}
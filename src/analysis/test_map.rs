@@ -0,0 +1,178 @@
+//! Which tests exercise a symbol
+//!
+//! Tests are recognized by their framework's convention: Rust functions with
+//! a test attribute, pytest `test*` functions in `test_*.py`/`*_test.py`
+//! files, Go `TestXxx` functions in `_test.go` files and Jest, Vitest or
+//! Mocha `it`/`test` blocks. A test covers a symbol when it calls it,
+//! directly or through helpers.
+
+use crate::indexing::facade::IndexFacade;
+use crate::parsing::rust::attributes::{is_test_attribute, signature_attributes};
+use crate::{Symbol, SymbolId, SymbolKind};
+use serde::Serialize;
+
+/// A test reaching a symbol through its calls
+#[derive(Debug, Clone, Serialize)]
+pub struct CoveringTest {
+    pub test: Symbol,
+    /// Call edges between the test and the symbol (1 = the test calls it)
+    pub distance: usize,
+    /// The function the test calls on its way to the symbol: the symbol
+    /// itself at distance 1
+    pub via: SymbolId,
+}
+
+/// Whether a symbol is a test function of its language's test framework
+pub fn is_test_function(symbol: &Symbol) -> bool {
+    if !matches!(symbol.kind, SymbolKind::Function | SymbolKind::Method) {
+        return false;
+    }
+    let file = symbol
+        .file_path
+        .rsplit(['/', '\\'])
+        .next()
+        .unwrap_or_default();
+    let signature = symbol.signature.as_deref().unwrap_or_default();
+    match symbol.language_id.map(|id| id.as_str()) {
+        // The parser keeps a test's test attribute on the signature
+        Some("rust") => signature_attributes(signature).any(is_test_attribute),
+        Some("python") => {
+            let stem = file.strip_suffix(".py").unwrap_or_default();
+            symbol.name.starts_with("test")
+                && (stem.starts_with("test_") || stem.ends_with("_test"))
+        }
+        Some("go") => {
+            file.ends_with("_test.go")
+                && symbol.name.strip_prefix("Test").is_some_and(|rest| {
+                    rest.chars()
+                        .next()
+                        .is_none_or(|first| !first.is_lowercase())
+                })
+        }
+        Some("javascript" | "typescript") => ["it(", "it.", "test(", "test."]
+            .iter()
+            .any(|callee| signature.starts_with(callee)),
+        _ => false,
+    }
+}
+
+/// Tests calling a symbol within `max_depth` call edges, nearest first
+pub fn tests_for(facade: &IndexFacade, symbol_id: SymbolId, max_depth: usize) -> Vec<CoveringTest> {
+    let mut tests: Vec<CoveringTest> = facade
+        .get_transitive_callers(symbol_id, max_depth)
+        .into_iter()
+        .filter(|entry| is_test_function(&entry.symbol))
+        .map(|entry| CoveringTest {
+            test: entry.symbol,
+            distance: entry.depth,
+            via: entry.via,
+        })
+        .collect();
+    tests.sort_by(|a, b| {
+        (a.distance, &a.test.file_path, a.test.range.start_line).cmp(&(
+            b.distance,
+            &b.test.file_path,
+            b.test.range.start_line,
+        ))
+    });
+    tests
+}
+
+/// Rows of a covering test listing
+pub fn render_tests(tests: &[CoveringTest]) -> String {
+    let mut rows = String::new();
+    for covering in tests {
+        let test = &covering.test;
+        let reach = if covering.distance == 1 {
+            "direct".to_string()
+        } else {
+            format!("{} calls away", covering.distance)
+        };
+        rows.push_str(&format!(
+            "  {} at {}:{} [symbol_id:{}] ({reach})\n",
+            test.name,
+            test.file_path,
+            test.range.start_line + 1,
+            test.id.value()
+        ));
+    }
+    rows
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::Settings;
+    use crate::parsing::registry::LanguageId;
+    use crate::{FileId, Range};
+
+    fn symbol(language: &'static str, name: &str, file_path: &str, signature: &str) -> Symbol {
+        let mut symbol = Symbol::new(
+            SymbolId::new(1).unwrap(),
+            name,
+            SymbolKind::Function,
+            FileId::new(1).unwrap(),
+            Range::new(0, 0, 1, 0),
+        )
+        .with_signature(signature);
+        symbol.file_path = file_path.into();
+        symbol.language_id = Some(LanguageId::new(language));
+        symbol
+    }
+
+    #[test]
+    fn test_functions_follow_framework_conventions() {
+        let cases = [
+            ("rust", "parses", "src/lib.rs", "#[test]\nfn parses()", true),
+            ("rust", "fixture", "src/lib.rs", "fn fixture()", false),
+            ("rust", "add", "src/lib.rs", "#[napi]\nfn add()", false),
+            ("python", "test_add", "tests/test_math.py", "", true),
+            ("python", "test_add", "math_test.py", "", true),
+            ("python", "test_add", "math.py", "", false),
+            ("go", "TestAdd", "math_test.go", "func TestAdd(t)", true),
+            ("go", "Testify", "math_test.go", "func Testify()", false),
+            ("go", "TestAdd", "math.go", "func TestAdd()", false),
+            ("typescript", "adds", "math.test.ts", "it(\"adds\")", true),
+            ("javascript", "adds", "m.test.js", "test.only(\"x\")", true),
+            ("javascript", "item", "list.js", "function item()", false),
+        ];
+        for (language, name, file, signature, expected) in cases {
+            assert_eq!(
+                is_test_function(&symbol(language, name, file, signature)),
+                expected,
+                "{language} {name} in {file}"
+            );
+        }
+    }
+
+    #[test]
+    fn test_tests_for_follows_helpers() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("test_math.py");
+        std::fs::write(
+            &source,
+            "def add(a, b):\n    return a + b\n\n\ndef check(a, b):\n    assert add(a, b) == a + b\n\n\ndef test_add():\n    add(1, 2)\n\n\ndef test_check():\n    check(1, 2)\n",
+        )
+        .unwrap();
+
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+        let add = facade.find_symbols_by_name("add", None).pop().unwrap();
+
+        let found: Vec<(String, usize)> = tests_for(&facade, add.id, 3)
+            .into_iter()
+            .map(|covering| (covering.test.name.to_string(), covering.distance))
+            .collect();
+        assert_eq!(
+            found,
+            [("test_add".to_string(), 1), ("test_check".to_string(), 2)],
+            "check is a helper, not a test"
+        );
+        assert_eq!(tests_for(&facade, add.id, 1).len(), 1);
+    }
+}
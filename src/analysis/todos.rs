@@ -0,0 +1,176 @@
+//! Known debt in the code
+//!
+//! TODO, FIXME and the other tagged comments are indexed with the symbol
+//! they are about, so a listing narrows to the code at hand: by tag, by the
+//! files under a path, and by the author a `TODO(author):` names.
+
+use crate::SymbolId;
+use crate::parsing::todo::Todo;
+use serde::Serialize;
+
+/// Which todos to list
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct TodoFilter {
+    /// Only these tags, case-insensitive (all if empty)
+    pub tags: Vec<String>,
+    /// Only todos in files under this path
+    pub path: Option<String>,
+    /// Only todos of this author, case-insensitive
+    pub author: Option<String>,
+}
+
+impl TodoFilter {
+    pub fn accepts(&self, todo: &Todo) -> bool {
+        let tagged = |tag: &String| tag.eq_ignore_ascii_case(&todo.tag);
+        if !self.tags.is_empty() && !self.tags.iter().any(tagged) {
+            return false;
+        }
+        if let Some(path) = &self.path {
+            let prefix = path.trim_start_matches("./");
+            if !todo.file_path.trim_start_matches("./").starts_with(prefix) {
+                return false;
+            }
+        }
+        match &self.author {
+            Some(author) => todo
+                .author
+                .as_ref()
+                .is_some_and(|name| name.eq_ignore_ascii_case(author)),
+            None => true,
+        }
+    }
+}
+
+/// One listed todo
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct TodoItem {
+    pub tag: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub author: Option<String>,
+    pub text: String,
+    pub file: String,
+    pub line: u32,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_id: Option<SymbolId>,
+    /// Name of the symbol the todo is about
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol: Option<String>,
+}
+
+/// The todos the filter accepts, named after their symbols
+pub fn list_todos(
+    todos: Vec<Todo>,
+    filter: &TodoFilter,
+    symbol_name: impl Fn(SymbolId) -> Option<String>,
+) -> Vec<TodoItem> {
+    todos
+        .into_iter()
+        .filter(|todo| filter.accepts(todo))
+        .map(|todo| TodoItem {
+            symbol: todo.symbol_id.and_then(&symbol_name),
+            tag: todo.tag,
+            author: todo.author,
+            text: todo.text,
+            file: todo.file_path,
+            line: todo.line + 1,
+            symbol_id: todo.symbol_id,
+        })
+        .collect()
+}
+
+/// Rows of a todo listing
+pub fn render_todos(items: &[TodoItem]) -> String {
+    let mut rows = String::new();
+    for item in items {
+        let author = item
+            .author
+            .as_ref()
+            .map(|author| format!("({author})"))
+            .unwrap_or_default();
+        rows.push_str(&format!(
+            "  {}{author} at {}:{}: {}",
+            item.tag, item.file, item.line, item.text
+        ));
+        if let (Some(symbol), Some(id)) = (&item.symbol, item.symbol_id) {
+            rows.push_str(&format!(" [in {symbol}, symbol_id:{}]", id.value()));
+        }
+        rows.push('\n');
+    }
+    rows
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::FileId;
+
+    fn todo(tag: &str, author: Option<&str>, file: &str, line: u32, symbol: Option<u32>) -> Todo {
+        Todo {
+            tag: tag.to_string(),
+            author: author.map(String::from),
+            text: format!("{tag} at {line}"),
+            file_id: FileId::new(1).unwrap(),
+            file_path: file.to_string(),
+            line,
+            column: 0,
+            symbol_id: symbol.and_then(SymbolId::new),
+        }
+    }
+
+    fn todos() -> Vec<Todo> {
+        vec![
+            todo("TODO", Some("alice"), "src/net/client.rs", 9, Some(1)),
+            todo("FIXME", None, "src/net/client.rs", 30, None),
+            todo("HACK", Some("Bob"), "./src/main.rs", 4, Some(2)),
+        ]
+    }
+
+    fn names(id: SymbolId) -> Option<String> {
+        (id.value() == 1).then(|| "fetch".to_string())
+    }
+
+    #[test]
+    fn test_filter_by_tag_path_and_author() {
+        let listed = |filter: TodoFilter| -> Vec<u32> {
+            list_todos(todos(), &filter, names)
+                .iter()
+                .map(|item| item.line)
+                .collect()
+        };
+
+        assert_eq!(listed(TodoFilter::default()), [10, 31, 5]);
+        let tags = vec!["todo".to_string(), "hack".to_string()];
+        assert_eq!(
+            listed(TodoFilter {
+                tags,
+                ..TodoFilter::default()
+            }),
+            [10, 5]
+        );
+        assert_eq!(
+            listed(TodoFilter {
+                path: Some("src/net".to_string()),
+                ..TodoFilter::default()
+            }),
+            [10, 31]
+        );
+        assert_eq!(
+            listed(TodoFilter {
+                author: Some("bob".to_string()),
+                ..TodoFilter::default()
+            }),
+            [5]
+        );
+    }
+
+    #[test]
+    fn test_render_todos() {
+        let items = list_todos(todos(), &TodoFilter::default(), names);
+
+        assert_eq!(
+            render_todos(&items[..2]),
+            "  TODO(alice) at src/net/client.rs:10: TODO at 9 [in fetch, symbol_id:1]\n  \
+             FIXME at src/net/client.rs:31: FIXME at 30\n"
+        );
+    }
+}
@@ -0,0 +1,222 @@
+//! Symbols nothing depends on
+//!
+//! A symbol is unused when no other symbol calls, uses, implements, extends
+//! or references it and no other file imports it. Entry points, test code
+//! and public API are used from outside the index, so they are left out
+//! unless `[analysis.unused]` in the settings asks for them.
+
+use crate::analysis::test_map::is_test_function;
+use crate::config::UnusedConfig;
+use crate::export::GraphFilter;
+use crate::export::graph::{path_segments, resolve_import};
+use crate::indexing::facade::IndexFacade;
+use crate::symbol::Visibility;
+use crate::{FileId, Symbol, SymbolId, SymbolKind};
+use glob::Pattern;
+use std::collections::HashSet;
+
+/// The checks of an unused symbol report, compiled from [`UnusedConfig`]
+#[derive(Debug, Clone)]
+pub struct UnusedRules {
+    kinds: Vec<SymbolKind>,
+    entry_points: Vec<Pattern>,
+    test_patterns: Vec<Pattern>,
+    include_tests: bool,
+    include_public: bool,
+}
+
+impl UnusedRules {
+    /// Rules of the settings; names the setting of an invalid kind or pattern
+    pub fn new(config: &UnusedConfig) -> Result<Self, String> {
+        let kinds = config
+            .kinds
+            .iter()
+            .map(|kind| {
+                kind.parse::<SymbolKind>()
+                    .map_err(|e| format!("analysis.unused.kinds: {e}"))
+            })
+            .collect::<Result<_, _>>()?;
+        let patterns = |setting: &str, patterns: &[String]| {
+            patterns
+                .iter()
+                .map(|pattern| {
+                    Pattern::new(pattern).map_err(|e| {
+                        format!("analysis.unused.{setting}: invalid pattern '{pattern}': {e}")
+                    })
+                })
+                .collect::<Result<Vec<_>, _>>()
+        };
+        Ok(Self {
+            kinds,
+            entry_points: patterns("entry_points", &config.entry_points)?,
+            test_patterns: patterns("test_patterns", &config.test_patterns)?,
+            include_tests: config.include_tests,
+            include_public: config.include_public,
+        })
+    }
+
+    /// Whether a symbol is in test code: a test function, file or module
+    pub fn is_test(&self, symbol: &Symbol) -> bool {
+        let file = symbol.file_path.trim_start_matches("./");
+        is_test_function(symbol)
+            || self
+                .test_patterns
+                .iter()
+                .any(|pattern| pattern.matches(file))
+            || symbol.module_path.as_deref().is_some_and(|module| {
+                path_segments(module)
+                    .iter()
+                    .any(|segment| *segment == "tests" || *segment == "test")
+            })
+    }
+
+    /// Whether a symbol is left out of the report whatever depends on it
+    fn exempt(&self, symbol: &Symbol) -> bool {
+        self.entry_points
+            .iter()
+            .any(|pattern| pattern.matches(&symbol.name))
+            || (!self.include_public && symbol.visibility == Visibility::Public)
+            || (!self.include_tests && self.is_test(symbol))
+    }
+}
+
+/// Indexed symbols the filter accepts that nothing depends on, by file and
+/// line. The filter's kinds, when given, replace the kinds of the rules.
+pub fn find_unused(facade: &IndexFacade, rules: &UnusedRules, filter: &GraphFilter) -> Vec<Symbol> {
+    let symbols = facade.get_all_symbols();
+    let imported = imported_symbols(facade, &symbols);
+
+    let mut unused: Vec<Symbol> = symbols
+        .into_iter()
+        .filter(|symbol| filter.accepts(symbol))
+        .filter(|symbol| !filter.kinds.is_empty() || rules.kinds.contains(&symbol.kind))
+        .filter(|symbol| !rules.exempt(symbol))
+        .filter(|symbol| !imported.contains(&symbol.id))
+        .filter(|symbol| !facade.is_referenced(symbol.id))
+        .collect();
+    unused.sort_by(|a, b| {
+        (&a.file_path, a.range.start_line).cmp(&(&b.file_path, b.range.start_line))
+    });
+    unused
+}
+
+/// Symbols another indexed file imports
+fn imported_symbols(facade: &IndexFacade, symbols: &[Symbol]) -> HashSet<SymbolId> {
+    let mut files: Vec<FileId> = symbols.iter().map(|symbol| symbol.file_id).collect();
+    files.sort_by_key(|id| id.value());
+    files.dedup();
+    files
+        .into_iter()
+        .flat_map(|file_id| facade.get_imports_for_file(file_id))
+        .filter_map(|import| resolve_import(facade, &import))
+        .map(|symbol| symbol.id)
+        .collect()
+}
+
+/// Rows of an unused symbol listing
+pub fn render_unused(symbols: &[Symbol]) -> String {
+    let mut rows = String::new();
+    for symbol in symbols {
+        rows.push_str(&format!(
+            "  {:?} {} at {}:{} [symbol_id:{}]\n",
+            symbol.kind,
+            symbol.name,
+            symbol.file_path,
+            symbol.range.start_line + 1,
+            symbol.id.value()
+        ));
+    }
+    rows
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::Settings;
+
+    fn symbol(name: &str, file_path: &str, module_path: &str) -> Symbol {
+        let mut symbol = Symbol::new(
+            SymbolId::new(1).unwrap(),
+            name,
+            SymbolKind::Function,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 1, 0),
+        );
+        symbol.file_path = file_path.into();
+        symbol.module_path = Some(module_path.into());
+        symbol
+    }
+
+    #[test]
+    fn test_rules_exempt_entry_points_tests_and_public_api() {
+        let rules = UnusedRules::new(&UnusedConfig::default()).unwrap();
+
+        assert!(rules.exempt(&symbol("main", "src/main.rs", "crate")));
+        assert!(rules.exempt(&symbol("__init__", "app/models.py", "app.models")));
+        assert!(rules.exempt(&symbol("helper", "src/parser_test.go", "parser")));
+        assert!(rules.exempt(&symbol("helper", "src/lib.rs", "crate::parser::tests")));
+        assert!(!rules.exempt(&symbol("helper", "src/lib.rs", "crate::parser")));
+
+        let public = symbol("helper", "src/lib.rs", "crate").with_visibility(Visibility::Public);
+        assert!(rules.exempt(&public));
+        let config = UnusedConfig {
+            include_public: true,
+            ..Default::default()
+        };
+        assert!(!UnusedRules::new(&config).unwrap().exempt(&public));
+    }
+
+    #[test]
+    fn test_rules_name_invalid_settings() {
+        let config = UnusedConfig {
+            kinds: vec!["function".to_string(), "procedure".to_string()],
+            ..Default::default()
+        };
+        let err = UnusedRules::new(&config).unwrap_err();
+        assert!(err.starts_with("analysis.unused.kinds: "), "{err}");
+
+        let config = UnusedConfig {
+            test_patterns: vec!["tests/[".to_string()],
+            ..Default::default()
+        };
+        let err = UnusedRules::new(&config).unwrap_err();
+        assert!(err.starts_with("analysis.unused.test_patterns: "), "{err}");
+    }
+
+    #[test]
+    fn test_find_unused_skips_called_functions() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("tool.py");
+        std::fs::write(
+            &source,
+            "def main():\n    helper()\n\n\ndef helper():\n    pass\n\n\ndef orphan():\n    pass\n\n\ndef _orphan():\n    _orphan()\n",
+        )
+        .unwrap();
+
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+
+        let names = |config: &UnusedConfig| -> Vec<String> {
+            let rules = UnusedRules::new(config).unwrap();
+            find_unused(&facade, &rules, &GraphFilter::default())
+                .into_iter()
+                .map(|symbol| symbol.name.to_string())
+                .collect()
+        };
+        assert_eq!(
+            names(&UnusedConfig::default()),
+            ["_orphan"],
+            "public functions are API, recursion is no use"
+        );
+        let config = UnusedConfig {
+            include_public: true,
+            ..Default::default()
+        };
+        assert_eq!(names(&config), ["orphan", "_orphan"]);
+    }
+}
@@ -0,0 +1,448 @@
+//! Portable archives of the index
+//!
+//! `codanna snapshot create` packs the index directory into one `.tar.gz`
+//! with a manifest of what built it: the codanna version, the emission
+//! semantics of the index and a fingerprint of the settings that shape it.
+//! CI can build the index once and publish the archive, and a developer
+//! restores it with `codanna snapshot restore` instead of indexing the
+//! repository again. Stored file paths are relative to the workspace root;
+//! the indexed directories are recorded relative to it as well and joined
+//! to the local root on restore, so the archive is not tied to the checkout
+//! it was built in. These archives are not the `index --rev` snapshots of
+//! [`crate::snapshot`], which index a commit rather than pack an index.
+
+use crate::indexing::calculate_hash;
+use crate::storage::{
+    DataSource, EMISSION_SEMANTICS_VERSION, IndexLease, IndexMetadata, IndexPersistence,
+};
+use crate::{IndexError, IndexResult, Settings};
+use flate2::Compression;
+use flate2::read::GzDecoder;
+use flate2::write::GzEncoder;
+use serde::{Deserialize, Serialize};
+use std::collections::BTreeMap;
+use std::fs::File;
+use std::path::{Path, PathBuf};
+
+/// Layout of the archives this version writes and reads
+pub const ARCHIVE_FORMAT: u32 = 1;
+
+/// Extension of the archives
+pub const ARCHIVE_EXTENSION: &str = "tar.gz";
+
+/// Entry of the manifest, first in the archive
+const MANIFEST_ENTRY: &str = "manifest.json";
+
+/// Directory of the index files in the archive
+const INDEX_ENTRY: &str = "index";
+
+/// Files of the index that belong to the process holding them, and the
+/// generation, which counts the writes readers on this machine have seen
+const LOCAL_FILES: &[&str] = &[
+    "serve.lock",
+    "servers.lock",
+    "embed.lock",
+    "embed.log",
+    "writer.lock",
+    "writer.json",
+    "generation",
+];
+
+/// What an archive holds and what built it
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ArchiveManifest {
+    pub format: u32,
+    /// Version of the codanna that built the index
+    pub codanna_version: String,
+    /// Emission semantics of the index, as `index.meta` stamps it
+    pub emission_version: Option<u32>,
+    /// [`settings_fingerprint`] of the settings it was built with
+    pub fingerprint: String,
+    /// Seconds since the Unix epoch
+    pub created_at: u64,
+    /// Commit checked out where it was built, if in a git repository
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub commit: Option<String>,
+    pub files: u32,
+    pub symbols: u32,
+    /// Indexed directories, relative to the workspace root where under it
+    pub indexed_paths: Vec<PathBuf>,
+}
+
+impl ArchiveManifest {
+    /// Why the index in the archive does not fit the index `settings` build;
+    /// empty when it does
+    pub fn problems(&self, settings: &Settings) -> Vec<String> {
+        let mut problems = Vec::new();
+        if self.emission_version != Some(EMISSION_SEMANTICS_VERSION) {
+            problems.push(format!(
+                "built by codanna {}, whose indexes this version rebuilds on load",
+                self.codanna_version
+            ));
+        }
+        if self.fingerprint != settings_fingerprint(settings) {
+            problems.push(
+                "built with other indexing, language or embedding settings than .codanna/settings.toml"
+                    .to_string(),
+            );
+        }
+        problems
+    }
+
+    /// The commit abbreviated as git log shows it
+    pub fn short_commit(&self) -> Option<&str> {
+        let commit = self.commit.as_deref()?;
+        Some(commit.get(..8).unwrap_or(commit))
+    }
+}
+
+/// Where `codanna snapshot create` writes archives by default
+pub fn archives_dir(settings: &Settings) -> PathBuf {
+    settings
+        .index_path
+        .parent()
+        .unwrap_or(Path::new("."))
+        .join("archives")
+}
+
+/// The default file name of an archive created now
+pub fn archive_name() -> String {
+    format!(
+        "index-{}.{ARCHIVE_EXTENSION}",
+        chrono::Utc::now().format("%Y%m%d-%H%M%S")
+    )
+}
+
+/// Hash of the settings that decide what the index holds: what is parsed
+/// and how, and the embedding model. Threads, batch sizes and the like
+/// are left out, as they change how fast the same index is built.
+pub fn settings_fingerprint(settings: &Settings) -> String {
+    let indexing = &settings.indexing;
+    let languages: BTreeMap<&str, String> = settings
+        .languages
+        .iter()
+        .filter(|(_, config)| config.enabled)
+        .map(|(name, config)| {
+            // Sorted, as the options are a HashMap
+            let options: BTreeMap<_, _> = config.parser_options.iter().collect();
+            let language = serde_json::to_string(&(
+                &config.extensions,
+                options,
+                &config.limits,
+                &config.kinds,
+            ))
+            .unwrap_or_default();
+            (name.as_str(), language)
+        })
+        .collect();
+    let shaping = serde_json::to_string(&(
+        &indexing.ignore_patterns,
+        indexing.symlinks,
+        indexing.max_ast_depth,
+        indexing.embedded_sql,
+        indexing.rust_macros,
+        indexing.embedded_languages,
+        indexing.string_literals,
+        indexing.symbol_metrics,
+        &indexing.todo_tags,
+        indexing.git_blame,
+        indexing.keep_unresolved,
+        languages,
+        settings.semantic_search.enabled,
+        &settings.semantic_search.model,
+    ))
+    .unwrap_or_default();
+    calculate_hash(&shaping)
+}
+
+/// Pack the index at `settings.index_path` into the archive `output`,
+/// replacing a file of that name
+pub fn create_archive(settings: &Settings, output: &Path) -> IndexResult<ArchiveManifest> {
+    let persistence = IndexPersistence::new(settings.index_path.clone());
+    if !persistence.exists() {
+        return Err(IndexError::General(format!(
+            "No index at {}. Run 'codanna index' to build it first",
+            settings.index_path.display()
+        )));
+    }
+    if persistence.has_checkpoint() {
+        return Err(IndexError::General(
+            "The last indexing run was interrupted. Finish it with 'codanna index --resume' first"
+                .to_string(),
+        ));
+    }
+
+    let metadata = IndexMetadata::load(&settings.index_path)?;
+    let root = workspace_root(settings);
+    let manifest = ArchiveManifest {
+        format: ARCHIVE_FORMAT,
+        codanna_version: env!("CARGO_PKG_VERSION").to_string(),
+        emission_version: metadata.emission_version,
+        fingerprint: settings_fingerprint(settings),
+        created_at: crate::indexing::get_utc_timestamp(),
+        commit: crate::git::get_commit_sha(&root).ok(),
+        files: metadata.file_count,
+        symbols: metadata.symbol_count,
+        indexed_paths: metadata
+            .indexed_paths
+            .unwrap_or_default()
+            .iter()
+            .map(|path| path.strip_prefix(&root).unwrap_or(path).to_path_buf())
+            .collect(),
+    };
+    let json = serde_json::to_vec_pretty(&manifest)
+        .map_err(|e| IndexError::General(format!("Failed to serialize archive manifest: {e}")))?;
+
+    // Written beside the output and renamed over it once complete
+    let dir = match output.parent() {
+        Some(dir) if !dir.as_os_str().is_empty() => dir,
+        _ => Path::new("."),
+    };
+    std::fs::create_dir_all(dir)?;
+    let partial = tempfile::NamedTempFile::new_in(dir)?;
+    let mut builder = tar::Builder::new(GzEncoder::new(partial.reopen()?, Compression::default()));
+    let mut header = tar::Header::new_gnu();
+    header.set_size(json.len() as u64);
+    header.set_mode(0o644);
+    header.set_mtime(manifest.created_at);
+    header.set_cksum();
+    builder.append_data(&mut header, MANIFEST_ENTRY, json.as_slice())?;
+
+    for entry in walkdir::WalkDir::new(&settings.index_path).sort_by_file_name() {
+        let entry = entry.map_err(std::io::Error::other)?;
+        let Ok(relative) = entry.path().strip_prefix(&settings.index_path) else {
+            continue;
+        };
+        if relative.as_os_str().is_empty() || is_local(relative) {
+            continue;
+        }
+        let name = Path::new(INDEX_ENTRY).join(relative);
+        if entry.file_type().is_dir() {
+            builder.append_dir(&name, entry.path())?;
+        } else if entry.file_type().is_file() {
+            builder.append_path_with_name(entry.path(), &name)?;
+        }
+    }
+    builder.into_inner()?.finish()?;
+    partial
+        .persist(output)
+        .map_err(|e| IndexError::General(format!("Failed to write {}: {e}", output.display())))?;
+    Ok(manifest)
+}
+
+/// The manifest of the archive at `archive`
+pub fn read_manifest(archive: &Path) -> IndexResult<ArchiveManifest> {
+    let mut entries = open(archive)?;
+    let mut entry = entries
+        .entries()?
+        .next()
+        .ok_or_else(|| not_an_archive(archive))??;
+    if *entry.path()? != *Path::new(MANIFEST_ENTRY) {
+        return Err(not_an_archive(archive));
+    }
+    let manifest: ArchiveManifest =
+        serde_json::from_reader(&mut entry).map_err(|_| not_an_archive(archive))?;
+    if manifest.format > ARCHIVE_FORMAT {
+        return Err(IndexError::General(format!(
+            "{} was written by codanna {}, in a format this version cannot read. Upgrade codanna",
+            archive.display(),
+            manifest.codanna_version
+        )));
+    }
+    Ok(manifest)
+}
+
+/// The archives in `dir` with their manifests, newest first. Files that
+/// are not archives are left out.
+pub fn list_archives(dir: &Path) -> Vec<(PathBuf, ArchiveManifest)> {
+    let Ok(entries) = std::fs::read_dir(dir) else {
+        return Vec::new();
+    };
+    let mut archives: Vec<(PathBuf, ArchiveManifest)> = entries
+        .filter_map(Result::ok)
+        .map(|entry| entry.path())
+        .filter(|path| path.to_string_lossy().ends_with(ARCHIVE_EXTENSION))
+        .filter_map(|path| read_manifest(&path).ok().map(|manifest| (path, manifest)))
+        .collect();
+    archives.sort_by(|a, b| b.1.created_at.cmp(&a.1.created_at).then(a.0.cmp(&b.0)));
+    archives
+}
+
+/// Replace the index at `settings.index_path` with the one in `archive`.
+/// The index is unpacked beside it first, so a broken archive leaves the
+/// current index in place. The caller holds the writer lease, whose
+/// release then moves the generation past that of the replaced index.
+pub fn restore_archive(settings: &Settings, archive: &Path) -> IndexResult<ArchiveManifest> {
+    let manifest = read_manifest(archive)?;
+    let index_path = std::path::absolute(&settings.index_path)?;
+    let parent = index_path.parent().unwrap_or(Path::new("."));
+    std::fs::create_dir_all(parent)?;
+
+    let staging = tempfile::Builder::new()
+        .prefix(".codanna-restore-")
+        .tempdir_in(parent)?;
+    let mut entries = open(archive)?;
+    for entry in entries.entries()? {
+        let mut entry = entry?;
+        if entry.path()?.starts_with(INDEX_ENTRY) {
+            // Refuses entries reaching outside the staging directory
+            entry.unpack_in(staging.path())?;
+        }
+    }
+    let restored = staging.path().join(INDEX_ENTRY);
+    if !IndexPersistence::new(restored.clone()).exists() {
+        return Err(IndexError::General(format!(
+            "{} holds no index",
+            archive.display()
+        )));
+    }
+
+    // The indexed directories of this checkout, which a sync would
+    // otherwise take for new ones
+    let root = workspace_root(settings);
+    let mut metadata = IndexMetadata::load(&restored)?;
+    metadata.indexed_paths = Some(
+        manifest
+            .indexed_paths
+            .iter()
+            .map(|path| {
+                let path = root.join(path);
+                path.canonicalize().unwrap_or(path)
+            })
+            .collect(),
+    );
+    if let DataSource::Tantivy { path, .. } = &mut metadata.data_source {
+        *path = index_path.join("tantivy");
+    }
+    metadata.save(&restored)?;
+    IndexLease::carry_generation(&index_path, &restored)?;
+
+    let previous = staging.path().join("previous");
+    if index_path.exists() {
+        std::fs::rename(&index_path, &previous)?;
+    }
+    if let Err(e) = std::fs::rename(&restored, &index_path) {
+        if previous.exists() {
+            let _ = std::fs::rename(&previous, &index_path);
+        }
+        return Err(e.into());
+    }
+    // Dropping the staging directory removes the previous index
+    Ok(manifest)
+}
+
+fn open(archive: &Path) -> IndexResult<tar::Archive<GzDecoder<File>>> {
+    let file = File::open(archive).map_err(|e| IndexError::FileRead {
+        path: archive.to_path_buf(),
+        source: e,
+    })?;
+    Ok(tar::Archive::new(GzDecoder::new(file)))
+}
+
+fn not_an_archive(archive: &Path) -> IndexError {
+    IndexError::General(format!(
+        "{} is not an index archive of 'codanna snapshot create'",
+        archive.display()
+    ))
+}
+
+/// Whether `relative`, under the index directory, belongs to this machine
+/// only: locks and logs of running processes
+fn is_local(relative: &Path) -> bool {
+    relative.file_name().is_some_and(|name| {
+        let name = name.to_string_lossy();
+        LOCAL_FILES.iter().any(|file| name == *file) || name.starts_with(".tantivy-")
+    })
+}
+
+/// The canonical workspace root, the current directory when there is none
+fn workspace_root(settings: &Settings) -> PathBuf {
+    let root = settings
+        .workspace_root
+        .clone()
+        .unwrap_or_else(|| PathBuf::from("."));
+    root.canonicalize().unwrap_or(root)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+
+    /// Settings of a workspace at `root` with an index holding only the
+    /// files `restore` looks at
+    fn workspace(root: &Path) -> Settings {
+        let root = root.canonicalize().unwrap();
+        let mut settings = Settings::default();
+        settings.workspace_root = Some(root.clone());
+        settings.index_path = root.join(".codanna/index");
+        fs::create_dir_all(root.join("src")).unwrap();
+        fs::create_dir_all(settings.index_path.join("tantivy")).unwrap();
+        fs::create_dir_all(settings.index_path.join("semantic")).unwrap();
+        settings
+    }
+
+    #[test]
+    fn test_archive_restores_in_another_checkout() {
+        let ci = tempfile::tempdir().unwrap();
+        let built = workspace(ci.path());
+        let index = &built.index_path;
+        fs::write(index.join("tantivy/meta.json"), "{\"segments\":[]}").unwrap();
+        fs::write(index.join("tantivy/.tantivy-writer.lock"), "").unwrap();
+        fs::write(index.join("semantic/embed.lock"), "4242").unwrap();
+        fs::write(index.join("generation"), "3").unwrap();
+        let mut metadata = IndexMetadata::new();
+        metadata.update_counts(120, 8);
+        metadata.update_indexed_paths(vec![built.workspace_root.clone().unwrap().join("src")]);
+        metadata.emission_version = Some(EMISSION_SEMANTICS_VERSION);
+        metadata.save(index).unwrap();
+
+        let output = ci.path().join("out").join(archive_name());
+        let manifest = create_archive(&built, &output).unwrap();
+        assert_eq!(manifest.symbols, 120);
+        assert_eq!(manifest.indexed_paths, vec![PathBuf::from("src")]);
+        assert_eq!(read_manifest(&output).unwrap(), manifest);
+        assert_eq!(list_archives(output.parent().unwrap()).len(), 1);
+
+        let local = tempfile::tempdir().unwrap();
+        let settings = workspace(local.path());
+        fs::write(settings.index_path.join("tantivy/meta.json"), "stale").unwrap();
+        fs::write(settings.index_path.join("generation"), "7").unwrap();
+        assert!(manifest.problems(&settings).is_empty());
+        let lease = IndexLease::try_acquire(&settings.index_path, "restore").unwrap();
+        restore_archive(&settings, &output).unwrap();
+        drop(lease);
+
+        let index = &settings.index_path;
+        assert_eq!(
+            fs::read_to_string(index.join("tantivy/meta.json")).unwrap(),
+            "{\"segments\":[]}"
+        );
+        assert!(!index.join("tantivy/.tantivy-writer.lock").exists());
+        assert!(!index.join("semantic/embed.lock").exists());
+        // Readers of the replaced index see a write they have not seen
+        assert_eq!(IndexLease::generation(index), 8);
+        let restored = IndexMetadata::load(index).unwrap();
+        assert_eq!(
+            restored.indexed_paths,
+            Some(vec![settings.workspace_root.clone().unwrap().join("src")])
+        );
+
+        let mut other = settings.clone();
+        other.indexing.max_ast_depth += 1;
+        assert_eq!(manifest.problems(&other).len(), 1);
+    }
+
+    #[test]
+    fn test_other_files_are_not_archives() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("notes.tar.gz");
+        fs::write(&path, "not gzip").unwrap();
+        assert!(read_manifest(&path).is_err());
+        assert!(list_archives(dir.path()).is_empty());
+
+        let settings = workspace(dir.path());
+        // No tantivy/meta.json: nothing to archive
+        assert!(create_archive(&settings, &dir.path().join("a.tar.gz")).is_err());
+    }
+}
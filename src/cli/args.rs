@@ -62,13 +62,15 @@ fn create_custom_help() -> String {
     help.push_str("  list-dirs     List all directories that are being indexed\n");
     help.push_str("  retrieve      Query symbols, relationships, and dependencies\n");
     help.push_str("  serve         Start MCP server\n");
+    help.push_str("  lsp           Start LSP server for editors\n");
     help.push_str("  config        Display active settings\n");
     help.push_str("  mcp-test      Test MCP connection\n");
     help.push_str("  mcp           Execute MCP tools directly\n");
-    help.push_str("  benchmark     Benchmark parser performance\n");
+    help.push_str("  benchmark     Benchmark parser and project performance\n");
     help.push_str("  parse         Output AST nodes in JSONL format\n");
     help.push_str("  plugin        Manage Claude Code plugins\n");
     help.push_str("  documents     Index and search document collections\n");
+    help.push_str("  projects      List and alias the projects indexed on this machine\n");
     help.push_str("  help          Print this message or the help of the given subcommand(s)\n\n");
 
     help.push_str("See 'codanna help <command>' for more information on a specific command.\n\n");
@@ -80,7 +82,10 @@ fn create_custom_help() -> String {
         help.push_str(&format!("{}\n", style("Options:").cyan().bold()));
     }
     help.push_str("  -c, --config <CONFIG>  Path to custom settings.toml file\n");
+    help.push_str("  -p, --project <NAME>   Run in a project of 'codanna projects list'\n");
+    help.push_str("      --profile <NAME>   Apply [profiles.<NAME>] of settings.toml\n");
     help.push_str("      --info             Show detailed loading information\n");
+    help.push_str("      --jsonl            JSON Lines output for retrieve, analyze and mcp\n");
     help.push_str("  -h, --help             Print help\n");
     help.push_str("  -V, --version          Print version\n\n");
 
@@ -115,6 +120,32 @@ pub struct Cli {
     #[arg(long, global = true)]
     pub info: bool,
 
+    /// Use the index of a shard from [indexing.shards] instead of the main
+    /// index: `index` indexes only its subtree, queries read only its index
+    #[arg(long, global = true, value_name = "NAME")]
+    pub shard: Option<String>,
+
+    /// Run in a project of the registry, by alias or directory name,
+    /// from wherever codanna is run
+    #[arg(
+        short = 'p',
+        long,
+        global = true,
+        value_name = "NAME",
+        conflicts_with = "config"
+    )]
+    pub project: Option<String>,
+
+    /// Lay the [profiles.<NAME>] table of settings.toml over the rest of
+    /// it, e.g. `ci`, `local` or `agent`
+    #[arg(long, global = true, value_name = "NAME", env = "CODANNA_PROFILE")]
+    pub profile: Option<String>,
+
+    /// Print the JSON output of retrieve, analyze and mcp as JSON Lines:
+    /// one record per line, implying --json
+    #[arg(long, global = true)]
+    pub jsonl: bool,
+
     #[command(subcommand)]
     pub command: Commands,
 }
@@ -131,8 +162,14 @@ pub enum Commands {
     },
 
     /// Index source files or directories
-    #[command(about = "Build searchable index from codebase")]
+    #[command(
+        about = "Build searchable index from codebase",
+        args_conflicts_with_subcommands = true
+    )]
     Index {
+        #[command(subcommand)]
+        action: Option<IndexAction>,
+
         /// Paths to files or directories to index (multiple paths allowed)
         #[arg(value_name = "PATH")]
         paths: Vec<PathBuf>,
@@ -156,6 +193,116 @@ pub enum Commands {
         /// Maximum number of files to index
         #[arg(long)]
         max_files: Option<usize>,
+
+        /// Memory budget in MB (overrides config): commits more often and
+        /// spills pending relationships to disk to stay within it
+        #[arg(long, value_name = "MB")]
+        max_memory: Option<usize>,
+
+        /// Continue an interrupted run: files it committed are kept and
+        /// only the rest are indexed (without it, an interrupted run is
+        /// rebuilt from scratch)
+        #[arg(long, conflicts_with_all = ["force", "dry_run", "rev"])]
+        resume: bool,
+
+        /// Reindex only the files git reports as changed since a commit,
+        /// branch or tag, plus the files with relationships into them,
+        /// instead of hashing every file
+        #[arg(long, value_name = "REF", conflicts_with_all = ["force", "dry_run", "max_files", "resume", "rev"])]
+        since: Option<String>,
+
+        /// Index a commit, branch or tag into a named snapshot instead,
+        /// reading it from git without touching the working tree
+        #[arg(long, value_name = "REV", conflicts_with_all = ["paths", "dry_run", "max_files"])]
+        rev: Option<String>,
+
+        /// Snapshot name for --rev (default: the revision)
+        #[arg(long, requires = "rev")]
+        name: Option<String>,
+
+        /// Build the index reproducibly: the same sources give the same
+        /// symbol IDs and commits (overrides config). Holds every parsed
+        /// file in memory until parsing ends.
+        #[arg(long, conflicts_with_all = ["dry_run", "worker"])]
+        deterministic: bool,
+
+        /// Commit symbols and relationships right away and compute
+        /// embeddings in a background process (overrides config)
+        #[arg(long, conflicts_with_all = ["dry_run", "rev", "worker"])]
+        background_embed: bool,
+
+        /// Also hand batches of files to `index --worker` processes
+        /// connecting to this address (e.g. 0.0.0.0:7878); beyond loopback,
+        /// both sides need the same CODANNA_WORKER_TOKEN
+        #[arg(long, value_name = "ADDR", conflicts_with_all = ["dry_run", "rev"])]
+        listen: Option<String>,
+
+        /// Parse files for the run listening at this address instead of
+        /// indexing, from a checkout of the same commit
+        #[arg(long, value_name = "ADDR", conflicts_with_all = ["paths", "force", "dry_run", "max_files", "resume", "since", "rev", "listen"])]
+        worker: Option<String>,
+
+        /// Print a summary of the run with the problems of its files as
+        /// JSON
+        #[arg(long, conflicts_with_all = ["dry_run", "rev", "worker"])]
+        json: bool,
+    },
+
+    /// Report on the last indexing run
+    #[command(
+        about = "Report on the files the last index run could not fully parse",
+        after_help = "Examples:\n  codanna report parse-failures\n  codanna report parse-failures --failed\n  codanna report parse-failures --json"
+    )]
+    Report {
+        #[command(subcommand)]
+        action: ReportAction,
+    },
+
+    /// Archive the index, or restore it from an archive
+    #[command(
+        about = "Pack the index into a portable archive, list archives or restore one",
+        long_about = "Pack the index into one .tar.gz with the codanna version and a fingerprint of the indexing settings, so CI can build the index once and others restore it instead of reindexing.\nThese archives are not the snapshots of 'codanna index --rev'.",
+        after_help = "Examples:\n  codanna snapshot create\n  codanna snapshot create --output codanna-index.tar.gz\n  codanna snapshot list\n  codanna snapshot restore codanna-index.tar.gz"
+    )]
+    Snapshot {
+        #[command(subcommand)]
+        action: SnapshotAction,
+    },
+
+    /// Query the shards of a monorepo together
+    #[command(
+        about = "List the shards, find symbols in all of them and the links between them",
+        long_about = "Open the index of every shard in [indexing.shards] at once.\nEach shard keeps the names it resolved no symbol for; 'links' matches them with the symbols of the other shards, by name, language and kind, and leaves ambiguous names out.",
+        after_help = "Examples:\n  codanna --shard api index\n  codanna shards list\n  codanna shards find serve\n  codanna shards links\n  codanna shards links serve --json"
+    )]
+    Shards {
+        #[command(subcommand)]
+        action: ShardsAction,
+    },
+
+    /// Compute embeddings left to the background
+    #[command(
+        about = "Compute pending embeddings or report their progress",
+        after_help = "Examples:\n  codanna index --background-embed\n  codanna embed --status\n  codanna embed --status --json\n  codanna embed"
+    )]
+    Embed {
+        /// Report the progress of the background embedding run
+        #[arg(long)]
+        status: bool,
+
+        /// Output in JSON format (with --status)
+        #[arg(long, requires = "status")]
+        json: bool,
+    },
+
+    /// Manage embedding models
+    #[command(
+        about = "Download models for machines without internet access",
+        after_help = "Examples:\n  codanna models fetch\n  codanna models fetch --model multilingual-e5,jina-code --rerank\n  codanna models fetch --output /mnt/share/codanna-models\n\nCopy the directory to the offline machine and point semantic_search.model_path at it."
+    )]
+    Models {
+        #[command(subcommand)]
+        action: ModelAction,
     },
 
     /// Add a directory to the indexed paths list
@@ -180,11 +327,173 @@ pub enum Commands {
     #[command(
         about = "Search symbols, find callers/callees, analyze impact",
         long_about = "Query indexed symbols, relationships, and dependencies.",
-        after_help = "Examples:\n  codanna retrieve symbol main\n  codanna retrieve callers process_file\n  codanna retrieve callers symbol_id:1771\n  codanna retrieve calls init\n  codanna retrieve calls symbol_id:1771\n  codanna retrieve implementations Parser\n  codanna retrieve describe OutputManager\n  codanna retrieve search \"parse\" --limit 10\n\nJSON paths:\n  retrieve symbol     .data.items[0].symbol.name\n  retrieve search     .data.items[].symbol.name\n  retrieve callers    .data.items[].symbol.name\n  retrieve describe   .data.items[0].symbol.name"
+        after_help = "Examples:\n  codanna retrieve symbol main\n  codanna retrieve callers process_file\n  codanna retrieve callers symbol_id:1771\n  codanna retrieve calls init\n  codanna retrieve calls symbol_id:1771\n  codanna retrieve implementations Parser\n  codanna retrieve describe OutputManager\n  codanna retrieve search \"parse\" --limit 10\n  codanna retrieve api --public\n  codanna retrieve todos tag:FIXME\n  codanna retrieve strings --pattern \"connection refused\"\n  codanna retrieve owned-by @org/team\n\nJSON paths:\n  retrieve symbol     .data.items[0].symbol.name\n  retrieve search     .data.items[].symbol.name\n  retrieve callers    .data.items[].symbol.name\n  retrieve describe   .data.items[0].symbol.name\n\nTemplates (--format):\n  codanna retrieve search parse --format '{file}:{line}:{column} {name}' > quickfix.txt\n  codanna retrieve callers main --format '{file}:{line} {kind} {name} — {doc_summary}'\n  Fields: name kind file line column signature doc doc_summary module id, or any\n  JSON key of a result (dotted for nested ones)"
     )]
     Retrieve {
         #[command(subcommand)]
         query: RetrieveQuery,
+
+        /// Print each result through a template, e.g. '{file}:{line} {kind} {name}'
+        #[arg(long, global = true, value_name = "TEMPLATE")]
+        format: Option<String>,
+    },
+
+    /// Analyze the relationship graph
+    #[command(
+        about = "Find circular dependencies and other structural problems",
+        long_about = "Analyze the indexed relationship graph.",
+        after_help = "Examples:\n  codanna analyze cycles\n  codanna analyze cycles --level symbol\n  codanna analyze cycles --path packages/app --lang typescript --check\n  codanna analyze cycles --relations imports --json\n  codanna analyze modules --depth 2\n  codanna analyze modules --path src --relations imports --json\n  codanna analyze unused\n  codanna analyze unused --path src/legacy --include-public --json\n  codanna analyze duplicates --threshold 0.95\n  codanna analyze deprecated-usages --path src"
+    )]
+    Analyze {
+        #[command(subcommand)]
+        analysis: AnalyzeTarget,
+    },
+
+    /// Export index data for other tools
+    #[command(
+        about = "Export the relationship graph, a SCIP or LSIF index, editor tags, or a SQLite database",
+        long_about = "Export indexed symbols and their relationships for rendering elsewhere.",
+        after_help = "Examples:\n  codanna export graph > graph.dot\n  codanna export graph --format mermaid --path src/indexing\n  codanna export graph --format graphml --lang rust -o graph.graphml\n  codanna export graph --kind struct,trait --relations implements,extends\n  codanna export scip --output index.scip\n  codanna export lsif --output dump.lsif\n  codanna export tags --format etags\n  codanna export sqlite codanna.db\n  codanna export cypher -o graph.cypher"
+    )]
+    Export {
+        #[command(subcommand)]
+        target: ExportTarget,
+    },
+
+    /// Import index data from other tools
+    #[command(
+        about = "Add the references of an LSIF dump to the index",
+        long_about = "Import relationships resolved by other indexers, such as the LSIF dump of a language's own indexer, where they are more precise than codanna's.",
+        after_help = "Examples:\n  codanna import lsif dump.lsif\n  lsif-go && codanna import lsif dump.lsif"
+    )]
+    Import {
+        #[command(subcommand)]
+        target: ImportTarget,
+    },
+
+    /// Show index statistics
+    #[command(
+        about = "Show index statistics and complexity hotspots",
+        after_help = "Examples:\n  codanna stats\n  codanna stats --metrics\n  codanna stats --metrics --path src/indexing --limit 20\n  codanna stats --metrics --lang python --json\n  codanna stats --by language,kind\n  codanna stats --by directory --depth 2 --json\n\nA breakdown counts the symbols of each group, the share of them with a doc\ncomment and the average complexity of its measured functions. Locals and\nparameters are left out."
+    )]
+    Stats {
+        /// Break down the complexity, lines and parameters of functions
+        #[arg(long)]
+        metrics: bool,
+        /// Break down symbols and doc coverage (comma-separated: language, directory, kind)
+        #[arg(long, value_delimiter = ',', value_parser = ["language", "directory", "kind"])]
+        by: Vec<String>,
+        /// Directory levels of a group with --by directory (default: the file's whole directory)
+        #[arg(long)]
+        depth: Option<usize>,
+        /// Only symbols in files under this path (with --metrics or --by)
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language, e.g. rust, typescript (with --metrics or --by)
+        #[arg(long)]
+        lang: Option<String>,
+        /// Number of functions and files to list (default: 10)
+        #[arg(long, default_value = "10")]
+        limit: usize,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Hold the index to quality thresholds
+    #[command(
+        about = "Fail when the index misses documentation or cycle thresholds",
+        long_about = "Measure doc coverage, module cycles and undocumented public symbols against thresholds and exit non-zero when one fails, to gate merges in CI.\nThresholds are taken from the flags, then from [analysis.check] of .codanna/settings.toml; one left unset is not checked.",
+        after_help = "Examples:\n  codanna check --min-doc-coverage 60 --max-cycles 0 --max-undocumented-public 10\n  codanna check --path src/api --max-undocumented-public 0\n  codanna check --json"
+    )]
+    Check {
+        /// Lowest percentage of symbols with a doc comment
+        #[arg(long)]
+        min_doc_coverage: Option<f32>,
+        /// Most module cycles
+        #[arg(long)]
+        max_cycles: Option<usize>,
+        /// Most public symbols without a doc comment
+        #[arg(long)]
+        max_undocumented_public: Option<usize>,
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language, e.g. rust, typescript
+        #[arg(long)]
+        lang: Option<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Print a shell completion script
+    #[command(
+        about = "Print a shell completion script that completes symbol names",
+        after_help = "Examples:\n  source <(codanna completions bash)     # in ~/.bashrc\n  source <(codanna completions zsh)      # in ~/.zshrc\n  codanna completions fish | source      # in ~/.config/fish/config.fish\n\nBesides commands and flags, the symbol arguments of retrieve, browse and mcp\ncomplete to the names of symbols in the index of the current project."
+    )]
+    Completions {
+        /// Shell to complete in
+        #[arg(value_parser = ["bash", "zsh", "fish"])]
+        shell: String,
+    },
+
+    /// Complete a command line, for the completion scripts
+    #[command(name = "__complete", hide = true)]
+    Complete {
+        /// Words after `codanna`, the last one being completed
+        #[arg(num_args = 0.., allow_hyphen_values = true, trailing_var_arg = true)]
+        words: Vec<String>,
+    },
+
+    /// Browse the index in the terminal
+    #[command(
+        about = "Browse symbols, their callers, calls and implementations in the terminal",
+        after_help = "Examples:\n  codanna browse\n  codanna browse parse --lang rust\n  vim $(codanna browse)\n\nKeys: type to filter by fuzzy name, Up/Down to select, Right for calls, Left for callers,\nCtrl-T for implementations, Esc to step back, Enter to print file:line and exit, Ctrl-C to quit."
+    )]
+    Browse {
+        /// Initial filter
+        query: Option<String>,
+        /// Only symbols of this language, e.g. rust, python
+        #[arg(long)]
+        lang: Option<String>,
+    },
+
+    /// Diagnose the setup and the index
+    #[command(
+        about = "Check settings, index, models and file watching for problems",
+        long_about = "Validate settings.toml, check the index segments and that symbol and embedding counts agree, look for the embedding model and test that the project can be watched.\nEach problem comes with the command that fixes it. Exits non-zero when a check fails.",
+        after_help = "Examples:\n  codanna doctor\n  codanna doctor --json\n  codanna --config ci/settings.toml doctor"
+    )]
+    Doctor {
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Compare two index snapshots
+    #[command(
+        about = "Show symbols and relationships changed between two snapshots",
+        long_about = "Compare the snapshot FROM with the snapshot TO, or with the current index when TO is left out.\nSnapshots are built with 'codanna index --rev <commit>'.",
+        after_help = "Examples:\n  codanna index --rev main\n  codanna diff main\n  codanna index --rev v1.0 && codanna index --rev v2.0\n  codanna diff v1.0 v2.0 --path src/api\n  codanna diff main --relations calls --json"
+    )]
+    Diff {
+        /// Snapshot to compare from
+        from: String,
+        /// Snapshot to compare to (default: the current index)
+        to: Option<String>,
+        /// Relationships to compare (comma-separated, default: calls, implements, extends, uses)
+        #[arg(long, value_delimiter = ',')]
+        relations: Vec<String>,
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, typescript)
+        #[arg(long)]
+        lang: Option<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
     },
 
     /// Show current configuration settings
@@ -195,9 +504,13 @@ pub enum Commands {
     #[command(
         about = "Start MCP server",
         long_about = "Start MCP server with optional HTTP/HTTPS modes.",
-        after_help = "Examples:\n  codanna serve\n  codanna serve --http --watch\n  codanna serve --https --watch\n  codanna serve --http --bind 0.0.0.0:3000\n\nModes:\n  Default: stdio\n  --http: HTTP with OAuth\n  --https: HTTPS with TLS"
+        after_help = "Examples:\n  codanna serve\n  codanna serve --http --watch\n  codanna serve --https --watch\n  codanna serve --http --bind 0.0.0.0:3000\n  codanna serve --project backend=../backend\n  codanna serve token create ci\n\nModes:\n  Default: stdio\n  --http: HTTP with OAuth\n  --https: HTTPS with TLS\n\nHTTP/HTTPS serve Prometheus metrics at /metrics.\nTokens listed under [[server.tokens]] are required on every HTTP/HTTPS MCP request and on /metrics.",
+        args_conflicts_with_subcommands = true
     )]
     Serve {
+        #[command(subcommand)]
+        action: Option<ServeAction>,
+
         /// Watch index file for changes and auto-reload
         #[arg(long, help = "Enable hot-reload when index changes")]
         watch: bool,
@@ -210,6 +523,13 @@ pub enum Commands {
         )]
         watch_interval: u64,
 
+        /// Never write the index, reloading it as others change it
+        #[arg(
+            long,
+            help = "Serve without writing the index, so `codanna index` can run alongside"
+        )]
+        read_only: bool,
+
         /// Enable HTTP server mode instead of stdio
         #[arg(long, help = "Run as HTTP server instead of stdio transport")]
         http: bool,
@@ -222,6 +542,46 @@ pub enum Commands {
         )]
         https: bool,
 
+        /// Serve MCP over WebSocket at /ws as well
+        #[arg(
+            long,
+            help = "Also serve MCP over WebSocket at /ws (implies --http unless --https)"
+        )]
+        ws: bool,
+
+        /// Serve MCP over the HTTP+SSE transport at /sse as well
+        #[arg(
+            long,
+            help = "Also serve MCP over SSE at /sse, resumable with Last-Event-ID (implies --http unless --https)"
+        )]
+        sse: bool,
+
+        /// Serve MCP on a Unix domain socket instead of stdio
+        #[arg(
+            long,
+            value_name = "PATH",
+            conflicts_with_all = ["http", "https", "ws", "sse"],
+            help = "Serve MCP on the Unix socket at PATH, one session per connection"
+        )]
+        uds: Option<PathBuf>,
+
+        /// Stop the socket server once no client has been connected this long
+        #[arg(
+            long,
+            value_name = "SECS",
+            requires = "uds",
+            help = "Exit once no client has been connected to the --uds socket for SECS seconds"
+        )]
+        idle_timeout: Option<u64>,
+
+        /// Other projects to serve, as name=path
+        #[arg(
+            long = "project",
+            value_name = "NAME=PATH",
+            help = "Also serve the project at PATH, picked by tools as project=NAME (repeatable)"
+        )]
+        projects: Vec<String>,
+
         /// Bind address for HTTP/HTTPS server
         #[arg(
             long,
@@ -231,6 +591,14 @@ pub enum Commands {
         bind: String,
     },
 
+    /// Serve the index to editors over the Language Server Protocol
+    #[command(
+        about = "Start an LSP server on stdio for editors",
+        long_about = "Serve definition, references, hover, workspace symbols and call hierarchy from the index, over the Language Server Protocol on stdin and stdout. Point the editor's LSP client at 'codanna lsp' for the languages it should cover; a newer index saved by 'codanna index' or the watcher is picked up as it runs.",
+        after_help = "Examples:\n  codanna lsp\n  codanna -p backend lsp"
+    )]
+    Lsp,
+
     /// Test MCP connection
     #[command(name = "mcp-test", about = "Test MCP connection and list tools")]
     McpTest {
@@ -259,7 +627,7 @@ pub enum Commands {
     #[command(
         about = "Execute MCP tools directly",
         long_about = "Execute MCP tools directly without spawning a server.\n\nSupports positional arguments, key=value pairs, and JSON arguments.",
-        after_help = "Tools:\n  find_symbol       <name>              Exact name lookup\n  search_symbols    query:<text>        Fuzzy text search (kind:<type> limit:<n>)\n  get_calls         <name|symbol_id:N>  What this symbol calls\n  find_callers      <name|symbol_id:N>  What calls this symbol\n  analyze_impact    <name|symbol_id:N>  Full dependency graph\n  semantic_search_docs query:<text>     Code search by meaning\n  semantic_search_with_context query:<text>  Search with relationships\n  search_documents  query:<text>        Search markdown/text docs\n  get_index_info                        Index stats\n\nExamples:\n  codanna mcp find_symbol <name>\n  codanna mcp search_symbols query:<text> kind:function\n  codanna mcp get_calls <name>\n  codanna mcp get_calls symbol_id:<N>\n  codanna mcp semantic_search_docs query:\"<text>\" limit:5\n  codanna mcp search_symbols query:<text> --json | jq '.data[].symbol_id'"
+        after_help = "Tools:\n  find_symbol       <name>              Exact name lookup\n  search_symbols    query:<text>        Fuzzy text search (kind:<type> limit:<n>)\n  query_symbols     <query>             Compound filter (kind:<type> path:<glob> calls:<name>)\n  get_calls         <name|symbol_id:N>  What this symbol calls\n  find_callers      <name|symbol_id:N>  What calls this symbol\n  get_call_hierarchy <name|symbol_id:N> Callers and callees as trees (depth:<n>)\n  analyze_impact    <name|symbol_id:N>  Full dependency graph\n  get_type_hierarchy <name|symbol_id:N> Supertypes and subtypes\n  get_file_outline  <path>              Symbols of a file in order\n  impact_of_change  <name|symbol_id:N>  Dependents by distance (max_depth:<n>)\n  find_tests        <name|symbol_id:N>  Tests that exercise it\n  find_similar_symbols <name|symbol_id:N> Symbols close in meaning (kind:<type> limit:<n>)\n  find_unused_symbols [path]            Dead code candidates (kind:<type> lang:<lang>)\n  find_todos        [path]              TODO/FIXME comments (tag:<tag> author:<name>)\n  semantic_search_docs query:<text>     Code search by meaning\n  semantic_search_with_context query:<text>  Search with relationships (context_lines:<n>)\n  search_documents  query:<text>        Search markdown/text docs\n  get_index_info                        Index stats\n\nExamples:\n  codanna mcp find_symbol <name>\n  codanna mcp search_symbols query:<text> kind:function\n  codanna mcp get_calls <name>\n  codanna mcp get_calls symbol_id:<N>\n  codanna mcp semantic_search_docs query:\"<text>\" limit:5\n  codanna mcp semantic_search_docs query:\"<text>\" --relevant <N>,<N> --irrelevant <N>\n  codanna mcp search_symbols query:<text> --json | jq '.data[].symbol_id'\n  codanna mcp search_symbols query:<text> --jsonl | jq -c 'select(.type == \"result\") | .data'"
     )]
     Mcp {
         /// Tool to call
@@ -281,13 +649,41 @@ pub enum Commands {
         #[arg(long, value_delimiter = ',')]
         fields: Option<Vec<String>>,
 
+        /// Print each result through a template, e.g. '{file}:{line} {kind} {name}'
+        #[arg(long, value_name = "TEMPLATE")]
+        format: Option<String>,
+
         /// Check for file changes and reindex before running tool
         #[arg(long)]
         watch: bool,
+
+        /// Mark these symbol IDs relevant results of the search's query, which
+        /// later searches rank higher (comma-separated)
+        #[arg(long, value_delimiter = ',', value_name = "IDS")]
+        relevant: Vec<u32>,
+
+        /// Mark these symbol IDs irrelevant results of the search's query, which
+        /// later searches rank lower (comma-separated)
+        #[arg(long, value_delimiter = ',', value_name = "IDS")]
+        irrelevant: Vec<u32>,
+    },
+
+    /// Save queries under a name and run them again
+    #[command(
+        about = "Save, list and rerun named queries",
+        after_help = "Examples:\n  codanna query save hotpaths \"allocation in hot loops\" limit:5\n  codanna query save fixmes --tool find_todos tag:FIXME path:src\n  codanna query run hotpaths --json\n  codanna query list\n  codanna query delete hotpaths\n\nA saved query is an MCP tool call; the run_saved_query MCP tool runs it by name too."
+    )]
+    Query {
+        #[command(subcommand)]
+        action: QueryAction,
     },
 
     /// Benchmark parser performance
-    #[command(about = "Benchmark parser performance")]
+    #[command(
+        about = "Benchmark parser performance",
+        alias = "bench",
+        after_help = "Examples:\n  codanna benchmark rust\n  codanna bench --project\n  codanna bench --project --json > bench.json"
+    )]
     Benchmark {
         /// Language to benchmark (rust, python, php, typescript, go, csharp, all)
         #[arg(default_value = "all")]
@@ -296,6 +692,15 @@ pub enum Commands {
         /// Custom file to benchmark
         #[arg(short, long)]
         file: Option<PathBuf>,
+
+        /// Benchmark against the current project: parse throughput per
+        /// language, embedding throughput, commit and query latency
+        #[arg(long, conflicts_with = "file")]
+        project: bool,
+
+        /// Output the project benchmark in JSON format
+        #[arg(long, requires = "project")]
+        json: bool,
     },
 
     /// Parse a file and output AST nodes in JSONL format
@@ -349,107 +754,382 @@ pub enum Commands {
         #[command(subcommand)]
         action: crate::profiles::commands::ProfileAction,
     },
-}
 
-/// Plugin management actions
-#[derive(Subcommand)]
-pub enum PluginAction {
-    /// Install a plugin from a marketplace
+    /// The projects indexed on this machine
     #[command(
-        about = "Install a plugin from a marketplace repository",
-        after_help = "Examples:\n  codanna plugin add https://github.com/user/marketplace plugin-name\n  codanna plugin add ./local-marketplace my-plugin --ref v1.0"
+        about = "List, alias and forget the projects indexed on this machine",
+        long_about = "Every project 'codanna init' sets up is kept in a registry under the home directory. An alias or the directory name of a project reaches it from anywhere with --project.",
+        after_help = "Examples:\n  codanna projects list\n  codanna projects alias . backend\n  codanna -p backend retrieve symbol Foo\n  codanna projects remove old-service"
     )]
-    Add {
-        /// Marketplace repository URL or local path
-        marketplace: String,
-
-        /// Plugin name to install
-        plugin_name: String,
-
-        /// Git reference (branch, tag, or commit SHA)
-        #[arg(long)]
-        r#ref: Option<String>,
+    Projects {
+        #[command(subcommand)]
+        action: ProjectsAction,
+    },
+}
 
-        /// Force installation even if conflicts exist
-        #[arg(short, long)]
-        force: bool,
+impl Commands {
+    /// Turn on `--json` for `--jsonl`; false for a command without JSON
+    /// Lines output
+    pub fn enable_json(&mut self) -> bool {
+        let json = match self {
+            Commands::Retrieve { query, .. } => match query {
+                RetrieveQuery::Symbol { json, .. }
+                | RetrieveQuery::Calls { json, .. }
+                | RetrieveQuery::Callers { json, .. }
+                | RetrieveQuery::Implementations { json, .. }
+                | RetrieveQuery::Hierarchy { json, .. }
+                | RetrieveQuery::Impact { json, .. }
+                | RetrieveQuery::TestsFor { json, .. }
+                | RetrieveQuery::References { json, .. }
+                | RetrieveQuery::Api { json, .. }
+                | RetrieveQuery::Todos { json, .. }
+                | RetrieveQuery::Strings { json, .. }
+                | RetrieveQuery::OwnedBy { json, .. }
+                | RetrieveQuery::Signature { json, .. }
+                | RetrieveQuery::History { json, .. }
+                | RetrieveQuery::Similar { json, .. }
+                | RetrieveQuery::Search { json, .. }
+                | RetrieveQuery::Query { json, .. }
+                | RetrieveQuery::Describe { json, .. } => json,
+            },
+            Commands::Analyze { analysis } => match analysis {
+                AnalyzeTarget::Cycles { json, .. }
+                | AnalyzeTarget::Modules { json, .. }
+                | AnalyzeTarget::Duplicates { json, .. }
+                | AnalyzeTarget::Unused { json, .. }
+                | AnalyzeTarget::DeprecatedUsages { json, .. } => json,
+            },
+            Commands::Mcp { json, .. } => json,
+            _ => return false,
+        };
+        *json = true;
+        true
+    }
 
-        /// Perform a dry run without making changes
-        #[arg(long)]
-        dry_run: bool,
-    },
+    /// The `--format` template of the command, if given
+    pub fn output_template(&self) -> Option<&str> {
+        match self {
+            Commands::Retrieve { format, .. } | Commands::Mcp { format, .. } => format.as_deref(),
+            _ => None,
+        }
+    }
+}
 
-    /// Remove an installed plugin
+/// Index maintenance actions
+#[derive(Subcommand)]
+pub enum IndexAction {
+    /// Merge segments and drop dead data
     #[command(
-        about = "Remove an installed plugin and clean up its files",
-        after_help = "Example:\n  codanna plugin remove plugin-name"
+        about = "Merge index segments, drop orphaned symbols and stale embeddings",
+        long_about = "Merge every index segment into one, purging the documents incremental runs deleted, drop the symbols of files no longer indexed and the relationships left dangling, rewrite the vector file without stale embeddings, and report the space reclaimed."
     )]
-    Remove {
-        /// Plugin name to remove
-        plugin_name: String,
-
-        /// Force removal even if other plugins depend on it
-        #[arg(short, long)]
-        force: bool,
+    Compact,
+}
 
-        /// Perform a dry run without making changes
+/// Index archive actions
+#[derive(Subcommand)]
+pub enum SnapshotAction {
+    /// Pack the index into an archive
+    #[command(about = "Pack the index into a .tar.gz archive")]
+    Create {
+        /// Archive to write (default: .codanna/archives/index-<time>.tar.gz)
+        #[arg(short, long, value_name = "FILE")]
+        output: Option<PathBuf>,
+        /// Output in JSON format
         #[arg(long)]
-        dry_run: bool,
+        json: bool,
     },
-
-    /// Update an installed plugin
+    /// List archives with what built them
+    #[command(about = "List index archives, newest first")]
+    List {
+        /// Directory to list (default: .codanna/archives)
+        #[arg(long, value_name = "DIR")]
+        dir: Option<PathBuf>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+    /// Replace the index with the one in an archive
     #[command(
-        about = "Update a plugin to a newer version",
-        after_help = "Examples:\n  codanna plugin update plugin-name\n  codanna plugin update plugin-name --ref v2.0"
+        about = "Replace the index with the one in an archive",
+        long_about = "Replace the index with the one in an archive, a file or the name of one in .codanna/archives.\nAn archive built by another codanna version, or with other indexing, language or embedding settings, is refused unless --force is given."
     )]
-    Update {
-        /// Plugin name to update
-        plugin_name: String,
-
-        /// Git reference to update to (branch, tag, or commit SHA)
+    Restore {
+        /// Archive file, or the name of one in .codanna/archives
+        archive: PathBuf,
+        /// Restore an archive that does not fit this codanna or its settings
         #[arg(long)]
-        r#ref: Option<String>,
-
-        /// Force update even if local modifications exist
-        #[arg(short, long)]
         force: bool,
-
-        /// Perform a dry run without making changes
-        #[arg(long)]
-        dry_run: bool,
     },
+}
 
-    /// List installed plugins
-    #[command(
-        about = "List all installed plugins with their versions",
-        after_help = "Example:\n  codanna plugin list"
-    )]
+/// Shard actions
+#[derive(Subcommand)]
+pub enum ShardsAction {
+    /// The configured shards with the size of their indexes
+    #[command(about = "List the shards and what their indexes hold")]
     List {
-        /// Show detailed information
-        #[arg(short, long)]
-        verbose: bool,
-
         /// Output in JSON format
         #[arg(long)]
         json: bool,
     },
+    /// Symbols of a name in every shard
+    #[command(about = "Find the symbols of a name in every shard")]
+    Find {
+        /// Symbol name
+        name: String,
+        /// Only symbols of this language, e.g. rust, python
+        #[arg(long)]
+        lang: Option<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+    /// Relationships from one shard into another
+    #[command(about = "Show the calls, uses and implementations linking one shard to another")]
+    Links {
+        /// Only links from or to symbols of this name
+        symbol: Option<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+}
 
-    /// Verify plugin integrity
+/// Report actions
+#[derive(Subcommand)]
+pub enum ReportAction {
+    /// Files that failed to read or parse, or parsed with errors
     #[command(
-        about = "Verify that a plugin's files match their expected checksums",
-        after_help = "Examples:\n  codanna plugin verify plugin-name\n  codanna plugin verify --all"
+        about = "List the files the last index run failed, skipped or parsed with errors",
+        long_about = "List the files of the last index run that could not be read or decoded, were skipped for their size, failed to parse, or parsed with grammar errors or nesting past max_ast_depth, with the first line affected.\nAn incremental run reports on the files it parsed and those that keep failing; 'codanna index --force' reports on every file."
     )]
-    Verify {
-        /// Plugin name to verify (omit to verify all)
-        plugin_name: Option<String>,
-
-        /// Verify all installed plugins
+    ParseFailures {
+        /// Only the files that got no symbols at all
         #[arg(long)]
-        all: bool,
-
-        /// Show detailed verification results
-        #[arg(short, long)]
-        verbose: bool,
+        failed: bool,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+}
+
+/// Project registry actions
+#[derive(Subcommand)]
+pub enum ProjectsAction {
+    /// List the projects with their index location, size and last index time
+    List {
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Give a project an alias, or take it away
+    Alias {
+        /// Path, alias, directory name or ID of the project
+        project: String,
+
+        /// The alias; without it the project loses the one it has
+        alias: Option<String>,
+    },
+
+    /// Forget a project; its index is left in place
+    Remove {
+        /// Path, alias, directory name or ID of the project
+        project: String,
+    },
+}
+
+/// Model management actions
+#[derive(Subcommand)]
+pub enum ModelAction {
+    /// Download models into a portable directory
+    #[command(
+        about = "Download embedding and reranker models into a portable bundle",
+        long_about = "Download models into a directory in the layout codanna loads them from. Run it once on a machine with internet access, copy the directory (or a tarball of it) to machines without, and set semantic_search.model_path to it there."
+    )]
+    Fetch {
+        /// Embedding models to fetch, comma-separated (default: semantic_search.model)
+        #[arg(long, value_delimiter = ',')]
+        model: Vec<String>,
+
+        /// Also fetch the reranker (semantic_search.rerank_model)
+        #[arg(long)]
+        rerank: bool,
+
+        /// Bundle directory (default: semantic_search.model_path, or ./codanna-models)
+        #[arg(short, long)]
+        output: Option<PathBuf>,
+    },
+}
+
+/// Serve actions
+#[derive(Subcommand)]
+pub enum ServeAction {
+    /// Manage the bearer tokens of HTTP/HTTPS serve
+    Token {
+        #[command(subcommand)]
+        action: TokenAction,
+    },
+}
+
+/// Bearer token actions
+#[derive(Subcommand)]
+pub enum TokenAction {
+    /// Create a token, add its digest to settings and print it once
+    #[command(
+        after_help = "Example:\n  codanna serve token create ci\n\nClients send it as: Authorization: Bearer <token>"
+    )]
+    Create {
+        /// Name of the token in the audit log
+        name: String,
+    },
+}
+
+/// Saved query actions
+#[derive(Subcommand)]
+pub enum QueryAction {
+    /// Save a query under a name, replacing any saved under it before
+    Save {
+        /// Name to run it by
+        name: String,
+
+        /// MCP tool the query calls
+        #[arg(long, default_value = "semantic_search_docs")]
+        tool: String,
+
+        /// The tool's arguments, as codanna mcp takes them (query and key:value pairs)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+    },
+
+    /// Run a saved query
+    Run {
+        /// Name the query was saved under
+        name: String,
+
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// List the saved queries
+    List {
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Delete a saved query
+    Delete {
+        /// Name the query was saved under
+        name: String,
+    },
+}
+
+/// Plugin management actions
+#[derive(Subcommand)]
+pub enum PluginAction {
+    /// Install a plugin from a marketplace
+    #[command(
+        about = "Install a plugin from a marketplace repository",
+        after_help = "Examples:\n  codanna plugin add https://github.com/user/marketplace plugin-name\n  codanna plugin add ./local-marketplace my-plugin --ref v1.0"
+    )]
+    Add {
+        /// Marketplace repository URL or local path
+        marketplace: String,
+
+        /// Plugin name to install
+        plugin_name: String,
+
+        /// Git reference (branch, tag, or commit SHA)
+        #[arg(long)]
+        r#ref: Option<String>,
+
+        /// Force installation even if conflicts exist
+        #[arg(short, long)]
+        force: bool,
+
+        /// Perform a dry run without making changes
+        #[arg(long)]
+        dry_run: bool,
+    },
+
+    /// Remove an installed plugin
+    #[command(
+        about = "Remove an installed plugin and clean up its files",
+        after_help = "Example:\n  codanna plugin remove plugin-name"
+    )]
+    Remove {
+        /// Plugin name to remove
+        plugin_name: String,
+
+        /// Force removal even if other plugins depend on it
+        #[arg(short, long)]
+        force: bool,
+
+        /// Perform a dry run without making changes
+        #[arg(long)]
+        dry_run: bool,
+    },
+
+    /// Update an installed plugin
+    #[command(
+        about = "Update a plugin to a newer version",
+        after_help = "Examples:\n  codanna plugin update plugin-name\n  codanna plugin update plugin-name --ref v2.0"
+    )]
+    Update {
+        /// Plugin name to update
+        plugin_name: String,
+
+        /// Git reference to update to (branch, tag, or commit SHA)
+        #[arg(long)]
+        r#ref: Option<String>,
+
+        /// Force update even if local modifications exist
+        #[arg(short, long)]
+        force: bool,
+
+        /// Perform a dry run without making changes
+        #[arg(long)]
+        dry_run: bool,
+    },
+
+    /// List installed plugins
+    #[command(
+        about = "List all installed plugins with their versions",
+        after_help = "Example:\n  codanna plugin list"
+    )]
+    List {
+        /// Show detailed information
+        #[arg(short, long)]
+        verbose: bool,
+
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Verify plugin integrity
+    #[command(
+        about = "Verify that a plugin's files match their expected checksums",
+        after_help = "Examples:\n  codanna plugin verify plugin-name\n  codanna plugin verify --all"
+    )]
+    Verify {
+        /// Plugin name to verify (omit to verify all)
+        plugin_name: Option<String>,
+
+        /// Verify all installed plugins
+        #[arg(long)]
+        all: bool,
+
+        /// Show detailed verification results
+        #[arg(short, long)]
+        verbose: bool,
     },
 }
 
@@ -544,7 +1224,7 @@ pub enum DocumentAction {
         path: PathBuf,
 
         /// Glob pattern for file matching (default: **/*.md)
-        #[arg(short, long)]
+        #[arg(long)]
         pattern: Option<String>,
     },
 
@@ -559,6 +1239,262 @@ pub enum DocumentAction {
     },
 }
 
+/// What `codanna analyze` looks for.
+#[derive(Subcommand)]
+pub enum AnalyzeTarget {
+    /// Circular dependencies
+    #[command(
+        after_help = "Levels:\n  module  Files depending on each other (default relations: imports, calls)\n  symbol  Symbols depending on each other (default relations: calls)\n\nEach group of mutually dependent modules or symbols is reported once,\nthrough its shortest cycle.\n\nRelations: calls, implements, extends, uses, imports"
+    )]
+    Cycles {
+        /// Cycle level: module or symbol
+        #[arg(long, default_value = "module")]
+        level: String,
+        /// Relationships to follow (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        relations: Vec<String>,
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, typescript)
+        #[arg(long)]
+        lang: Option<String>,
+        /// Maximum number of cycles to show
+        #[arg(long)]
+        limit: Option<usize>,
+        /// Exit with code 1 when a cycle is found
+        #[arg(long)]
+        check: bool,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Dependency matrix between directories
+    #[command(
+        after_help = "A module is the directory of a file; --depth N keeps only its first N\ncomponents, so deeper directories count as their ancestor. Each edge\nbetween symbols of two modules adds one to the weight of that dependency.\n\nRelations: calls, implements, extends, uses, imports\n(default: calls, implements, extends, imports)"
+    )]
+    Modules {
+        /// Directory levels of a module (default: the file's whole directory)
+        #[arg(long)]
+        depth: Option<usize>,
+        /// Relationships to count (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        relations: Vec<String>,
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, typescript)
+        #[arg(long)]
+        lang: Option<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Functions that look copy-pasted from one another
+    #[command(
+        after_help = "Compares the doc comment embeddings of functions and methods, so it needs\nan index built with semantic search. Bodies must also be about as long.\n\nExamples:\n  codanna analyze duplicates\n  codanna analyze duplicates --threshold 0.95 --path src\n  codanna analyze duplicates --lang python --size-ratio 0.9 --json"
+    )]
+    Duplicates {
+        /// Lowest embedding similarity of two clones (default: 0.92)
+        #[arg(long)]
+        threshold: Option<f32>,
+        /// Lowest ratio of the shorter body's tokens to the longer's (default: 0.8)
+        #[arg(long)]
+        size_ratio: Option<f32>,
+        /// Skip bodies with fewer tokens (default: 20)
+        #[arg(long)]
+        min_tokens: Option<usize>,
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, typescript)
+        #[arg(long)]
+        lang: Option<String>,
+        /// Maximum number of groups to show
+        #[arg(long)]
+        limit: Option<usize>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Symbols nothing calls, uses or imports
+    #[command(
+        after_help = "Entry points, test code and public symbols are skipped.\nConfigure what is skipped in [analysis.unused] of .codanna/settings.toml."
+    )]
+    Unused {
+        /// Only symbols of these kinds (comma-separated; default: analysis.unused.kinds)
+        #[arg(long, value_delimiter = ',')]
+        kind: Vec<String>,
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, typescript)
+        #[arg(long)]
+        lang: Option<String>,
+        /// Also report public symbols
+        #[arg(long)]
+        include_public: bool,
+        /// Also report test code
+        #[arg(long)]
+        include_tests: bool,
+        /// Maximum number of symbols to show
+        #[arg(long)]
+        limit: Option<usize>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Call sites still using deprecated symbols
+    #[command(
+        after_help = "A symbol is deprecated when its language marks it so (#[deprecated],\n@Deprecated, [Obsolete], JSDoc @deprecated, Go's \"Deprecated:\" comments) or a\n`codanna:tag=deprecated` comment tags it. Markers are read at index time.\n\nExamples:\n  codanna analyze deprecated-usages\n  codanna analyze deprecated-usages --path src/api --json"
+    )]
+    DeprecatedUsages {
+        /// Only sites in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only sites of this language (e.g. rust, typescript)
+        #[arg(long)]
+        lang: Option<String>,
+        /// Maximum number of sites to show
+        #[arg(long)]
+        limit: Option<usize>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+}
+
+/// What `codanna export` writes.
+#[derive(Subcommand)]
+pub enum ExportTarget {
+    /// Symbol relationship graph
+    #[command(
+        after_help = "Formats:\n  dot      Graphviz (render with: dot -Tsvg graph.dot > graph.svg)\n  mermaid  Mermaid flowchart\n  graphml  GraphML for yEd, Gephi, and similar tools\n\nRelations: calls, implements, extends, uses, imports\n(default: calls, implements, extends, imports)"
+    )]
+    Graph {
+        /// Output format: dot, mermaid, or graphml
+        #[arg(long, default_value = "dot")]
+        format: String,
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, python)
+        #[arg(long)]
+        lang: Option<String>,
+        /// Only symbols of these kinds (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        kind: Vec<String>,
+        /// Relationships to export (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        relations: Vec<String>,
+        /// Write to this file instead of stdout
+        #[arg(short, long)]
+        output: Option<PathBuf>,
+    },
+
+    /// SCIP index for Sourcegraph-style code navigation
+    #[command(
+        after_help = "Examples:\n  codanna export scip\n  codanna export scip --path src --output src.scip\n  codanna export scip --lang python"
+    )]
+    Scip {
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, python)
+        #[arg(long)]
+        lang: Option<String>,
+        /// File to write the index to
+        #[arg(short, long, default_value = "index.scip")]
+        output: PathBuf,
+    },
+
+    /// LSIF dump of definitions, references and hovers
+    #[command(
+        after_help = "Examples:\n  codanna export lsif\n  codanna export lsif --path src --output src.lsif\n  codanna export lsif --lang typescript"
+    )]
+    Lsif {
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, python)
+        #[arg(long)]
+        lang: Option<String>,
+        /// File to write the dump to
+        #[arg(short, long, default_value = "dump.lsif")]
+        output: PathBuf,
+    },
+
+    /// Tags file for Vim, Emacs and other editors
+    #[command(
+        after_help = "Formats:\n  ctags  universal-ctags extended format, written to tags\n  etags  Emacs format, written to TAGS\n\nExamples:\n  codanna export tags\n  codanna export tags --format etags\n  codanna export tags --path src --lang rust"
+    )]
+    Tags {
+        /// Output format: ctags or etags
+        #[arg(long, default_value = "ctags")]
+        format: String,
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, python)
+        #[arg(long)]
+        lang: Option<String>,
+        /// File to write the tags to (default: tags, or TAGS for etags)
+        #[arg(short, long)]
+        output: Option<PathBuf>,
+    },
+
+    /// Cypher script loading files, symbols and relationships into Neo4j
+    #[command(
+        after_help = "Nodes: File, and Symbol with a label per kind (Function, Struct, ...).\nRelationships: CONTAINS, CALLS, EXTENDS, IMPLEMENTS, USES, DEFINES, REFERENCES.\n\nExamples:\n  codanna export cypher\n  codanna export cypher --path src --kind function,method -o calls.cypher\n  cypher-shell -u neo4j -f graph.cypher\n\nThen, in Neo4j:\n  MATCH p = shortestPath((a:Symbol {name: 'handle_request'})-[:CALLS*]->(b:Symbol {name: 'query'}))\n  RETURN p"
+    )]
+    Cypher {
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, python)
+        #[arg(long)]
+        lang: Option<String>,
+        /// Only symbols of these kinds (comma-separated, e.g. function,struct)
+        #[arg(long, value_delimiter = ',')]
+        kind: Vec<String>,
+        /// File to write the script to
+        #[arg(short, long, default_value = "graph.cypher")]
+        output: PathBuf,
+    },
+
+    /// SQLite database of files, symbols, relationships and metrics
+    #[command(
+        after_help = "Tables: files, symbols, relationships, metrics. An existing file is replaced.\nRequires a build with: cargo build --features sqlite-export\n\nExamples:\n  codanna export sqlite codanna.db\n  codanna export sqlite api.db --path src/api\n  sqlite3 codanna.db \"SELECT author, count(*) FROM symbols GROUP BY author\""
+    )]
+    Sqlite {
+        /// Database file to write
+        output: PathBuf,
+        /// Only symbols in files under this path
+        #[arg(long)]
+        path: Option<String>,
+        /// Only symbols of this language (e.g. rust, python)
+        #[arg(long)]
+        lang: Option<String>,
+    },
+}
+
+/// What `codanna import` reads.
+#[derive(Subcommand)]
+pub enum ImportTarget {
+    /// References from an LSIF dump
+    #[command(
+        after_help = "The references the dump resolves are stored where the index lacks them:\ncalls of functions and methods, uses of types, and references to fields,\nconstants and variables. Re-indexing a file drops what was imported for it.\n\nExamples:\n  codanna import lsif dump.lsif"
+    )]
+    Lsif {
+        /// LSIF dump, JSON Lines or a JSON array
+        file: PathBuf,
+    },
+}
+
 /// Query types for retrieving indexed information.
 ///
 /// Supports symbol lookups, relationship queries, impact analysis, and full-text search.
@@ -582,12 +1518,15 @@ pub enum RetrieveQuery {
 
     /// Show what functions a given function calls
     #[command(
-        after_help = "Examples:\n  codanna retrieve calls process_file\n  codanna retrieve calls symbol_id:1771\n  codanna retrieve calls function:process_file --json\n  codanna retrieve calls main --json --fields=name,file_path"
+        after_help = "Examples:\n  codanna retrieve calls process_file\n  codanna retrieve calls symbol_id:1771\n  codanna retrieve calls function:process_file --json\n  codanna retrieve calls main --json --fields=name,file_path\n  codanna retrieve calls main --depth 3"
     )]
     Calls {
         /// Positional arguments (function name and/or key:value pairs)
         #[arg(num_args = 0..)]
         args: Vec<String>,
+        /// Follow calls transitively up to N edges (default: 1, direct calls only)
+        #[arg(long)]
+        depth: Option<usize>,
         /// Output in JSON format
         #[arg(long)]
         json: bool,
@@ -598,12 +1537,15 @@ pub enum RetrieveQuery {
 
     /// Show what functions call a given function
     #[command(
-        after_help = "Examples:\n  codanna retrieve callers main\n  codanna retrieve callers symbol_id:1771\n  codanna retrieve callers function:main --json\n  codanna retrieve callers main --json --fields=name,file_path"
+        after_help = "Examples:\n  codanna retrieve callers main\n  codanna retrieve callers symbol_id:1771\n  codanna retrieve callers function:main --json\n  codanna retrieve callers main --json --fields=name,file_path\n  codanna retrieve callers write_index --depth 5"
     )]
     Callers {
         /// Positional arguments (function name and/or key:value pairs)
         #[arg(num_args = 0..)]
         args: Vec<String>,
+        /// Follow callers transitively up to N edges (default: 1, direct callers only)
+        #[arg(long)]
+        depth: Option<usize>,
         /// Output in JSON format
         #[arg(long)]
         json: bool,
@@ -628,9 +1570,194 @@ pub enum RetrieveQuery {
         fields: Option<Vec<String>>,
     },
 
+    /// Show the supertypes and subtypes of a type
+    #[command(
+        after_help = "Examples:\n  codanna retrieve hierarchy Parser\n  codanna retrieve hierarchy type:Shape lang:python\n  codanna retrieve hierarchy symbol_id:1771 --json"
+    )]
+    Hierarchy {
+        /// Positional arguments (type name and/or key:value pairs)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// Show everything a change to a symbol affects, grouped by distance
+    #[command(
+        after_help = "Examples:\n  codanna retrieve impact parse_file\n  codanna retrieve impact symbol_id:1771 --depth 5\n  codanna retrieve impact Parser lang:rust --json"
+    )]
+    Impact {
+        /// Positional arguments (symbol name and/or key:value pairs)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Follow dependents up to N edges away (default: 3)
+        #[arg(long)]
+        depth: Option<usize>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// Show the tests that exercise a function
+    #[command(
+        after_help = "Examples:\n  codanna retrieve tests-for parse_file\n  codanna retrieve tests-for symbol_id:1771 --depth 1\n  codanna retrieve tests-for add lang:python --json"
+    )]
+    TestsFor {
+        /// Positional arguments (function name and/or key:value pairs)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Follow calls through test helpers up to N edges (default: 3)
+        #[arg(long)]
+        depth: Option<usize>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// Show where a field, constant or global is read or written
+    #[command(
+        after_help = "Examples:\n  codanna retrieve references Settings::debug\n  codanna retrieve references MAX_RETRIES lang:python\n  codanna retrieve references symbol_id:1771 --json"
+    )]
+    References {
+        /// Positional arguments (symbol name and/or key:value pairs)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// List the API surface: every symbol visible outside its module
+    #[command(
+        after_help = "Examples:\n  codanna retrieve api --public\n  codanna retrieve api path:src/parsing lang:rust\n  codanna retrieve api kind:function --public --json\n  codanna retrieve api --public > api-v1.txt  # diff against the next release"
+    )]
+    Api {
+        /// Positional arguments (key:value pairs: path, lang, kind)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Only public symbols, leaving out crate-wide and module-wide ones
+        #[arg(long)]
+        public: bool,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// List TODO, FIXME and other tagged comments, with the symbol each is about
+    #[command(
+        after_help = "Examples:\n  codanna retrieve todos\n  codanna retrieve todos tag:FIXME path:src/parsing\n  codanna retrieve todos tag:TODO,HACK author:alice --json"
+    )]
+    Todos {
+        /// Positional arguments (key:value pairs: tag, path, author)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// Find the string literals holding a URL, route, format or message
+    #[command(
+        after_help = "Examples:\n  codanna retrieve strings --pattern \"failed to open config.toml: denied\"\n  codanna retrieve strings --pattern '/users/*' kind:route\n  codanna retrieve strings kind:url,message path:src/api --json\n\nA format string matches the messages it prints: its text around the placeholders\nappears in the pattern. Needs indexing.string_literals = true."
+    )]
+    Strings {
+        /// Only literals holding this text, case-insensitive, `*` for any text
+        #[arg(long)]
+        pattern: Option<String>,
+        /// Positional arguments (key:value pairs: kind, path)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// List the symbols of the files CODEOWNERS gives a team or person
+    #[command(
+        after_help = "Examples:\n  codanna retrieve owned-by @org/parsers\n  codanna retrieve owned-by org/parsers kind:function path:src/parsing\n  codanna retrieve owned-by dev@example.com lang:rust --json"
+    )]
+    OwnedBy {
+        /// Positional arguments (owner, then key:value pairs: path, lang, kind)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// Find functions and methods by parameter and return types
+    #[command(
+        after_help = "Examples:\n  codanna retrieve signature \"fn(&Path) -> Result<Vec<Symbol>>\"\n  codanna retrieve signature \"fn(&str, ..)\" lang:rust limit:5\n  codanna retrieve signature \"(string) => Promise<User>\" path:src/api --json\n\nTypes fit through wrappers (Box, Arc, impl AsRef<Path>), subtypes and type parameters;\na single uppercase letter or _ fits any type, and a trailing .. any further parameters."
+    )]
+    Signature {
+        /// The signature, then key:value pairs (lang, kind, path, limit)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// List the searches run recently in this project
+    #[command(
+        after_help = "Examples:\n  codanna retrieve history\n  codanna retrieve history limit:50 --json\n\nRecords the searches of codanna mcp, codanna retrieve search and the MCP server.\nKeep one with: codanna query save <name> --tool <tool> <args>"
+    )]
+    History {
+        /// Positional arguments (key:value pairs: limit)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+    },
+
+    /// Find the symbols most similar in meaning to a symbol
+    #[command(
+        after_help = "Examples:\n  codanna retrieve similar parse_config\n  codanna retrieve similar symbol_id:1771 kind:function limit:5\n  codanna retrieve similar retry_with_backoff lang:python path:src/services --json\n\nCompares the doc comment embedding of the symbol, or its signature when undocumented,\nwith those of the index. Needs semantic search."
+    )]
+    Similar {
+        /// Symbol name or symbol_id:N, then key:value pairs (lang, kind, path, limit)
+        #[arg(num_args = 0..)]
+        args: Vec<String>,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
     /// Search for symbols using full-text search
     #[command(
-        after_help = "Examples:\n  # Traditional flag format\n  codanna retrieve search \"parse\" --limit 5 --kind function\n  \n  # Key:value format (Unix-style)\n  codanna retrieve search query:parse limit:5 kind:function\n  \n  # Mixed format\n  codanna retrieve search \"parse\" limit:5 --json\n  codanna retrieve search \"parse\" --json --fields=name,file_path"
+        after_help = "Examples:\n  # Traditional flag format\n  codanna retrieve search \"parse\" --limit 5 --kind function\n  \n  # Key:value format (Unix-style)\n  codanna retrieve search query:parse limit:5 kind:function\n  \n  # Mixed format\n  codanna retrieve search \"parse\" limit:5 --json\n  codanna retrieve search \"parse\" --json --fields=name,file_path\n  \n  # Name matching: typo-tolerant, or a regular expression\n  codanna retrieve search parseFiel --mode fuzzy\n  codanna retrieve search \"^get_.*_id$\" mode:regex kind:function\n  \n  # Source of each result, with 3 lines around it\n  codanna retrieve search \"parse\" context_lines:3 --json"
     )]
     Search {
         /// Positional arguments (query and/or key:value pairs)
@@ -649,6 +1776,14 @@ pub enum RetrieveQuery {
         #[arg(short, long)]
         module: Option<String>,
 
+        /// Match mode: text (default), fuzzy or regex (flag format)
+        #[arg(long)]
+        mode: Option<String>,
+
+        /// Include each symbol's source with this many lines around it (flag format)
+        #[arg(long)]
+        context_lines: Option<usize>,
+
         /// Output in JSON format
         #[arg(long)]
         json: bool,
@@ -658,6 +1793,35 @@ pub enum RetrieveQuery {
         fields: Option<Vec<String>>,
     },
 
+    /// Find the symbols matching a compound filter
+    #[command(
+        after_help = "Examples:\n  codanna retrieve query kind:function lang:rust path:src/parsing/** calls:parse_file\n  codanna retrieve query 'kind:function,method -path:tests/** doc:\"utf-8\"' --json\n  codanna retrieve query implements:Parser called_by:main --limit 50\n  codanna retrieve query name:parse_* author:'Jane Doe' --json --fields=name,file_path\n\nFilters: kind, lang, name, module, path, author, doc, sig, and the relationships\ncalls, called_by, implements, extends, uses. Every term must hold; -term negates\nit, a,b takes either value, and a bare word is searched for like 'retrieve search'."
+    )]
+    Query {
+        /// The query: key:value filters and words
+        #[arg(num_args = 1.., required = true)]
+        args: Vec<String>,
+        /// Maximum number of results
+        #[arg(short, long, default_value = "20")]
+        limit: usize,
+        /// Output in JSON format
+        #[arg(long)]
+        json: bool,
+        /// Filter output to specific fields (comma-separated)
+        #[arg(long, value_delimiter = ',')]
+        fields: Option<Vec<String>>,
+    },
+
+    /// Run many queries in one process
+    #[command(
+        after_help = "Examples:\n  codanna retrieve batch --input queries.jsonl\n  generate-audit | codanna retrieve batch > results.jsonl\n\nEach input line is an MCP tool call with an optional id:\n  {\"id\": \"q1\", \"tool\": \"find_symbol\", \"arguments\": {\"name\": \"main\"}}\n  {\"id\": \"q2\", \"tool\": \"find_callers\", \"arguments\": {\"function_name\": \"parse_file\"}}\n  {\"id\": \"q3\", \"tool\": \"semantic_search_docs\", \"arguments\": {\"query\": \"retry logic\"}}\n\nPrints one JSON line per query with its id, status and result. The index and\nthe embedding model are loaded once for all of them."
+    )]
+    Batch {
+        /// JSON Lines file of queries, or - for stdin
+        #[arg(long, default_value = "-")]
+        input: PathBuf,
+    },
+
     /// Show information about a symbol
     #[command(
         after_help = "Examples:\n  codanna retrieve describe SimpleIndexer\n  codanna retrieve describe symbol:SimpleIndexer --json\n  codanna retrieve describe main --json --fields=name,kind,calls"
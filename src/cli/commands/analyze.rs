@@ -0,0 +1,316 @@
+//! Analyze command - find structural problems in the relationship graph.
+
+use crate::analysis::{
+    CycleLevel, DuplicateRules, UnusedRules, find_cycles, find_deprecated_usages, find_duplicates,
+    find_unused, module_matrix, render_deprecated_usages, render_duplicates, render_unused,
+};
+use crate::cli::AnalyzeTarget;
+use crate::export::{EdgeKind, GraphFilter, SymbolGraph};
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+
+/// Run the analyze command.
+pub fn run(analysis: AnalyzeTarget, indexer: &IndexFacade) -> ExitCode {
+    match analysis {
+        AnalyzeTarget::Cycles {
+            level,
+            relations,
+            path,
+            lang,
+            limit,
+            check,
+            json,
+        } => {
+            let (level, relations) = match parse_cycle_options(&level, &relations) {
+                Ok(parsed) => parsed,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            };
+
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                kinds: Vec::new(),
+                relations: if relations.is_empty() {
+                    level.default_relations().to_vec()
+                } else {
+                    relations
+                },
+            };
+            let graph = SymbolGraph::build(indexer, &filter);
+            let mut cycles = find_cycles(&graph, level);
+            let found = cycles.len();
+            if let Some(limit) = limit {
+                cycles.truncate(limit);
+            }
+
+            let noun = level.noun();
+            if json {
+                let envelope = Envelope::success(&cycles)
+                    .with_entity_type(EntityType::Cycle)
+                    .with_count(found)
+                    .with_truncated(cycles.len() < found)
+                    .with_message(format!("Found {found} {noun} cycle(s)"));
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else if found == 0 {
+                println!("No {noun} cycles found");
+            } else {
+                println!("Found {found} {noun} cycle(s), shortest first:");
+                for (number, cycle) in cycles.iter().enumerate() {
+                    println!();
+                    println!(
+                        "Cycle {} ({} {noun}s, {} in the tangle):",
+                        number + 1,
+                        cycle.path.len(),
+                        cycle.component_size
+                    );
+                    print!("{cycle}");
+                }
+                if cycles.len() < found {
+                    println!();
+                    println!(
+                        "... {} more (raise --limit to see them)",
+                        found - cycles.len()
+                    );
+                }
+            }
+
+            if check && found > 0 {
+                ExitCode::GeneralError
+            } else {
+                ExitCode::Success
+            }
+        }
+        AnalyzeTarget::Modules {
+            depth,
+            relations,
+            path,
+            lang,
+            json,
+        } => {
+            let relations = match relations
+                .iter()
+                .map(|relation| relation.trim().parse())
+                .collect::<Result<Vec<EdgeKind>, _>>()
+            {
+                Ok(relations) => relations,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            };
+
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                kinds: Vec::new(),
+                relations,
+            };
+            let graph = SymbolGraph::build(indexer, &filter);
+            let matrix = module_matrix(&graph, depth);
+            let found = matrix.dependencies.len();
+
+            if json {
+                let envelope = Envelope::success(&matrix)
+                    .with_entity_type(EntityType::Dependency)
+                    .with_count(found)
+                    .with_message(format!(
+                        "Found {found} dependencies between {} modules",
+                        matrix.modules.len()
+                    ));
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else if found == 0 {
+                println!("No dependencies between modules found");
+            } else {
+                println!(
+                    "{found} dependencies between {} modules (row depends on column):",
+                    matrix.modules.len()
+                );
+                println!();
+                print!("{matrix}");
+                println!();
+                println!("Dependencies, heaviest first:");
+                print!("{}", matrix.render_dependencies());
+            }
+            ExitCode::Success
+        }
+        AnalyzeTarget::Unused {
+            kind,
+            path,
+            lang,
+            include_public,
+            include_tests,
+            limit,
+            json,
+        } => {
+            let mut config = indexer.settings().analysis.unused.clone();
+            config.include_public |= include_public;
+            config.include_tests |= include_tests;
+            let rules = match UnusedRules::new(&config) {
+                Ok(rules) => rules,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::ConfigError;
+                }
+            };
+            let kinds = match kind
+                .iter()
+                .map(|kind| kind.trim().parse())
+                .collect::<Result<Vec<_>, _>>()
+            {
+                Ok(kinds) => kinds,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            };
+
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                kinds,
+                relations: Vec::new(),
+            };
+            let mut unused = find_unused(indexer, &rules, &filter);
+            let found = unused.len();
+            if let Some(limit) = limit {
+                unused.truncate(limit);
+            }
+
+            if json {
+                let envelope = Envelope::success(&unused)
+                    .with_entity_type(EntityType::Symbol)
+                    .with_count(found)
+                    .with_truncated(unused.len() < found)
+                    .with_message(format!("Found {found} unused symbol(s)"))
+                    .with_hint("Confirm with find_callers before removing");
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else if found == 0 {
+                println!("No unused symbols found");
+            } else {
+                println!("Found {found} unused symbol(s):");
+                print!("{}", render_unused(&unused));
+                if unused.len() < found {
+                    println!(
+                        "  ... {} more (raise --limit to see them)",
+                        found - unused.len()
+                    );
+                }
+            }
+            ExitCode::Success
+        }
+        AnalyzeTarget::DeprecatedUsages {
+            path,
+            lang,
+            limit,
+            json,
+        } => {
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                kinds: Vec::new(),
+                relations: Vec::new(),
+            };
+            let mut usages = find_deprecated_usages(indexer, &filter);
+            let found = usages.len();
+            if let Some(limit) = limit {
+                usages.truncate(limit);
+            }
+
+            if json {
+                let envelope = Envelope::success(&usages)
+                    .with_entity_type(EntityType::Reference)
+                    .with_count(found)
+                    .with_truncated(usages.len() < found)
+                    .with_message(format!("Found {found} use(s) of deprecated symbols"))
+                    .with_hint("Markers are read at index time; reindex after adding one");
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else if found == 0 {
+                println!("No uses of deprecated symbols found");
+            } else {
+                println!("Found {found} use(s) of deprecated symbols:");
+                print!("{}", render_deprecated_usages(&usages));
+                if usages.len() < found {
+                    println!(
+                        "  ... {} more (raise --limit to see them)",
+                        found - usages.len()
+                    );
+                }
+            }
+            ExitCode::Success
+        }
+        AnalyzeTarget::Duplicates {
+            threshold,
+            size_ratio,
+            min_tokens,
+            path,
+            lang,
+            limit,
+            json,
+        } => {
+            let defaults = DuplicateRules::default();
+            let rules = DuplicateRules {
+                threshold: threshold.unwrap_or(defaults.threshold),
+                size_ratio: size_ratio.unwrap_or(defaults.size_ratio),
+                min_tokens: min_tokens.unwrap_or(defaults.min_tokens),
+            };
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                kinds: Vec::new(),
+                relations: Vec::new(),
+            };
+            let mut groups = match find_duplicates(indexer, &rules, &filter) {
+                Ok(groups) => groups,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            };
+            let found = groups.len();
+            if let Some(limit) = limit {
+                groups.truncate(limit);
+            }
+
+            if json {
+                let envelope = Envelope::success(&groups)
+                    .with_entity_type(EntityType::Duplicate)
+                    .with_count(found)
+                    .with_truncated(groups.len() < found)
+                    .with_message(format!("Found {found} group(s) of likely duplicates"))
+                    .with_hint(
+                        "Compare the bodies before merging; similar docs can hide different code",
+                    );
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else if found == 0 {
+                println!("No duplicates found");
+            } else {
+                println!("Found {found} group(s) of likely duplicates:");
+                print!("{}", render_duplicates(&groups));
+                if groups.len() < found {
+                    println!(
+                        "  ... {} more (raise --limit to see them)",
+                        found - groups.len()
+                    );
+                }
+            }
+            ExitCode::Success
+        }
+    }
+}
+
+/// Parse the level and relations of `analyze cycles`.
+fn parse_cycle_options(
+    level: &str,
+    relations: &[String],
+) -> Result<(CycleLevel, Vec<EdgeKind>), Box<dyn std::error::Error>> {
+    let level = level.parse()?;
+    let relations = relations
+        .iter()
+        .map(|relation| relation.trim().parse())
+        .collect::<Result<_, _>>()?;
+    Ok((level, relations))
+}
@@ -1,16 +1,28 @@
-//! Benchmark command - parser performance testing.
+//! Benchmark command - parser performance testing, and the performance of
+//! the whole index against the current project.
 
+use std::collections::BTreeMap;
 use std::path::PathBuf;
-use std::time::Instant;
+use std::sync::Arc;
+use std::time::{Duration, Instant};
 
 use crate::display::tables::create_benchmark_table;
 use crate::display::theme::Theme;
+use crate::indexing::FileWalker;
+use crate::indexing::facade::{IndexFacade, build_embedding_backend};
+use crate::indexing::pipeline::stages::ReadStage;
+use crate::indexing::pipeline::{FileRegistration, ParseStage, init_parser_cache};
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope, ResultCode};
+use crate::parsing::LanguageId;
 use crate::parsing::{
     CSharpParser, GoParser, LanguageParser, LuaParser, PhpParser, PythonParser, RustParser,
     TypeScriptParser,
 };
-use crate::types::{FileId, SymbolCounter};
+use crate::types::{FileId, SymbolCounter, SymbolId};
+use crate::{IndexError, IndexResult, Settings};
 use console::style;
+use serde::Serialize;
 
 /// Run parser performance benchmarks
 pub fn run(language: &str, custom_file: Option<PathBuf>) {
@@ -761,3 +773,422 @@ fn generate_csharp_benchmark_code() -> String {
 
     code
 }
+
+/// Latency of a repeated operation, in milliseconds
+#[derive(Debug, Serialize)]
+struct Latency {
+    samples: usize,
+    min: f64,
+    p50: f64,
+    p95: f64,
+    max: f64,
+}
+
+impl Latency {
+    fn of(mut samples: Vec<Duration>) -> Option<Self> {
+        if samples.is_empty() {
+            return None;
+        }
+        samples.sort_unstable();
+        let ms = |d: Duration| d.as_secs_f64() * 1000.0;
+        let at = |q: f64| ms(samples[((samples.len() - 1) as f64 * q).round() as usize]);
+        Some(Self {
+            samples: samples.len(),
+            min: ms(samples[0]),
+            p50: at(0.5),
+            p95: at(0.95),
+            max: ms(samples[samples.len() - 1]),
+        })
+    }
+}
+
+/// Parse throughput of one language
+#[derive(Debug, Default, Serialize)]
+struct LanguageThroughput {
+    files: usize,
+    bytes: usize,
+    symbols: usize,
+    seconds: f64,
+    files_per_sec: f64,
+    symbols_per_sec: f64,
+    mb_per_sec: f64,
+}
+
+#[derive(Debug, Serialize)]
+struct EmbeddingThroughput {
+    model: String,
+    texts: usize,
+    seconds: f64,
+    texts_per_sec: f64,
+}
+
+#[derive(Debug, Serialize)]
+struct IndexThroughput {
+    files: usize,
+    symbols: usize,
+    seconds: f64,
+    files_per_sec: f64,
+}
+
+#[derive(Debug, Serialize)]
+struct QueryLatency {
+    #[serde(skip_serializing_if = "Option::is_none")]
+    find_by_name: Option<Latency>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    full_text: Option<Latency>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    callers: Option<Latency>,
+}
+
+/// Everything `codanna bench --project` reports
+#[derive(Debug, Serialize)]
+struct ProjectBenchmark {
+    version: &'static str,
+    roots: Vec<PathBuf>,
+    parse: BTreeMap<String, LanguageThroughput>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    embedding: Option<EmbeddingThroughput>,
+    index: IndexThroughput,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    commit_latency_ms: Option<Latency>,
+    query_latency_ms: QueryLatency,
+}
+
+/// Texts embedded to measure embedding throughput
+const EMBED_SAMPLE: usize = 512;
+/// Commits timed to measure commit latency
+const COMMIT_SAMPLES: usize = 20;
+/// Symbols looked up to measure query latency
+const QUERY_SAMPLES: usize = 100;
+
+fn per_sec(count: usize, seconds: f64) -> f64 {
+    if seconds > 0.0 {
+        count as f64 / seconds
+    } else {
+        0.0
+    }
+}
+
+/// Benchmark codanna against the current project: parse throughput per
+/// language, embedding throughput, Tantivy commit latency and query
+/// latency. The project's own index is left alone: the index under test
+/// is built in a temporary directory.
+pub fn run_project(config: &Settings, json: bool) -> ExitCode {
+    match benchmark_project(config) {
+        Ok(report) => {
+            if json {
+                let envelope = project_envelope(&report);
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else {
+                print_project_benchmark(&report);
+            }
+            ExitCode::Success
+        }
+        Err(e) => {
+            if json {
+                let envelope = Envelope::<()>::error(ResultCode::InternalError, e.to_string());
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else {
+                eprintln!("Benchmark failed: {e}");
+            }
+            ExitCode::GeneralError
+        }
+    }
+}
+
+/// The `--json` envelope of a project benchmark
+fn project_envelope(report: &ProjectBenchmark) -> Envelope<&ProjectBenchmark> {
+    Envelope::success(report)
+        .with_entity_type(EntityType::Benchmark)
+        .with_count(report.index.files)
+        .with_message(format!(
+            "Benchmarked {} files in {:.2}s",
+            report.index.files, report.index.seconds
+        ))
+}
+
+fn benchmark_project(config: &Settings) -> IndexResult<ProjectBenchmark> {
+    let scratch = tempfile::tempdir()?;
+    // Cached parses and embeddings would measure the cache, and the index
+    // under test must not replace the project's
+    let mut settings = config.clone();
+    settings.indexing.content_cache = false;
+    settings.index_path = scratch.path().join("index");
+    let settings = Arc::new(settings);
+
+    let roots: Vec<PathBuf> = if settings.indexing.indexed_paths.is_empty() {
+        vec![
+            settings
+                .workspace_root
+                .clone()
+                .unwrap_or_else(|| PathBuf::from(".")),
+        ]
+    } else {
+        settings.indexing.indexed_paths.clone()
+    };
+
+    // Parse throughput, one file at a time on this thread
+    init_parser_cache(Arc::clone(&settings));
+    let walker = FileWalker::new(Arc::clone(&settings));
+    let reader = ReadStage::new(1);
+    let parser = ParseStage::new(Arc::clone(&settings));
+    let mut parse: BTreeMap<String, LanguageThroughput> = BTreeMap::new();
+    let mut docs: Vec<(String, String)> = Vec::new();
+    for path in roots.iter().flat_map(|root| walker.walk(root)) {
+        let Ok(content) = reader.read_single(&path) else {
+            continue;
+        };
+        let bytes = content.content.len();
+        let started = Instant::now();
+        let Ok(parsed) = parser.parse(content) else {
+            continue;
+        };
+        let elapsed = started.elapsed().as_secs_f64();
+
+        let language = parsed.language_id.as_str();
+        let entry = parse.entry(language.to_string()).or_default();
+        entry.files += 1;
+        entry.bytes += bytes;
+        entry.symbols += parsed.raw_symbols.len();
+        entry.seconds += elapsed;
+        for symbol in &parsed.raw_symbols {
+            if docs.len() == EMBED_SAMPLE {
+                break;
+            }
+            if let Some(doc) = &symbol.doc_comment {
+                docs.push((doc.to_string(), language.to_string()));
+            }
+        }
+    }
+    for entry in parse.values_mut() {
+        entry.files_per_sec = per_sec(entry.files, entry.seconds);
+        entry.symbols_per_sec = per_sec(entry.symbols, entry.seconds);
+        entry.mb_per_sec = per_sec(entry.bytes, entry.seconds) / (1024.0 * 1024.0);
+    }
+
+    // Embedding throughput, on doc comments of the project
+    let embedding = if settings.semantic_search.enabled && !docs.is_empty() {
+        let backend = build_embedding_backend(&settings.semantic_search)
+            .map_err(|e| IndexError::General(format!("Failed to load embedding model: {e}")))?;
+        let items: Vec<(SymbolId, &str, &str)> = docs
+            .iter()
+            .enumerate()
+            .filter_map(|(i, (doc, language))| {
+                Some((
+                    SymbolId::new(i as u32 + 1)?,
+                    doc.as_str(),
+                    language.as_str(),
+                ))
+            })
+            .collect();
+        let started = Instant::now();
+        backend
+            .embed_parallel(&items)
+            .map_err(|e| IndexError::General(format!("Embedding failed: {e}")))?;
+        let seconds = started.elapsed().as_secs_f64();
+        Some(EmbeddingThroughput {
+            model: backend.model_id(),
+            texts: items.len(),
+            seconds,
+            texts_per_sec: per_sec(items.len(), seconds),
+        })
+    } else {
+        None
+    };
+
+    // Full index of the project, without embeddings, as they were measured
+    // above
+    let mut index_settings = (*settings).clone();
+    index_settings.semantic_search.enabled = false;
+    let mut facade = IndexFacade::new(Arc::new(index_settings))?;
+    let started = Instant::now();
+    let (mut files, mut symbols) = (0, 0);
+    for root in &roots {
+        let stats = facade.index_directory_with_options(root, false, false, true, None)?;
+        files += stats.files_indexed;
+        symbols += stats.symbols_found;
+    }
+    let seconds = started.elapsed().as_secs_f64();
+    let index = IndexThroughput {
+        files,
+        symbols,
+        seconds,
+        files_per_sec: per_sec(files, seconds),
+    };
+
+    // Commit latency: one file registration per commit
+    let documents = facade.document_index();
+    let mut commits = Vec::with_capacity(COMMIT_SAMPLES);
+    for i in 0..COMMIT_SAMPLES {
+        let registration = FileRegistration {
+            path: PathBuf::from(format!(".codanna-bench/{i}")),
+            file_id: FileId::new(u32::MAX - i as u32).expect("non-zero file id"),
+            content_hash: String::new(),
+            language_id: LanguageId::new("rust"),
+            timestamp: 0,
+            mtime: 0,
+            encoding: None,
+        };
+        let started = Instant::now();
+        let committed = documents
+            .start_batch()
+            .and_then(|()| documents.store_file_registration(&registration))
+            .and_then(|()| documents.commit_batch());
+        if committed.is_err() {
+            break;
+        }
+        commits.push(started.elapsed());
+    }
+
+    // Query latency, for symbols spread over the index
+    let all = facade.get_all_symbols();
+    let step = (all.len() / QUERY_SAMPLES).max(1);
+    let sample: Vec<_> = all.iter().step_by(step).take(QUERY_SAMPLES).collect();
+    let (mut by_name, mut full_text, mut callers) = (Vec::new(), Vec::new(), Vec::new());
+    for symbol in sample {
+        let started = Instant::now();
+        facade.find_symbols_by_name(&symbol.name, None);
+        by_name.push(started.elapsed());
+
+        let started = Instant::now();
+        if facade.search(&symbol.name, 10, None, None, None).is_ok() {
+            full_text.push(started.elapsed());
+        }
+
+        let started = Instant::now();
+        facade.get_calling_functions(symbol.id);
+        callers.push(started.elapsed());
+    }
+
+    Ok(ProjectBenchmark {
+        version: env!("CARGO_PKG_VERSION"),
+        roots,
+        parse,
+        embedding,
+        index,
+        commit_latency_ms: Latency::of(commits),
+        query_latency_ms: QueryLatency {
+            find_by_name: Latency::of(by_name),
+            full_text: Latency::of(full_text),
+            callers: Latency::of(callers),
+        },
+    })
+}
+
+fn print_project_benchmark(report: &ProjectBenchmark) {
+    if Theme::should_disable_colors() {
+        println!("\n=== Codanna Project Benchmark ===\n");
+    } else {
+        println!(
+            "\n{}\n",
+            style("=== Codanna Project Benchmark ===").cyan().bold()
+        );
+    }
+    for root in &report.roots {
+        println!("Root: {}", root.display());
+    }
+
+    println!("\nParse throughput:");
+    for (language, t) in &report.parse {
+        println!(
+            "  {language:<12} {:>6} files {:>8} symbols  {:>10.0} symbols/s  {:>6.2} MB/s",
+            t.files, t.symbols, t.symbols_per_sec, t.mb_per_sec
+        );
+    }
+
+    match &report.embedding {
+        Some(e) => println!(
+            "\nEmbedding: {} texts in {:.2}s ({:.0} texts/s) with {}",
+            e.texts, e.seconds, e.texts_per_sec, e.model
+        ),
+        None => println!("\nEmbedding: skipped (semantic search disabled or no doc comments)"),
+    }
+
+    let index = &report.index;
+    println!(
+        "Index: {} files, {} symbols in {:.2}s ({:.0} files/s)",
+        index.files, index.symbols, index.seconds, index.files_per_sec
+    );
+
+    println!("\nLatency (ms)       p50      p95      max");
+    let rows = [
+        ("commit", &report.commit_latency_ms),
+        ("find by name", &report.query_latency_ms.find_by_name),
+        ("full-text", &report.query_latency_ms.full_text),
+        ("callers", &report.query_latency_ms.callers),
+    ];
+    for (name, latency) in rows {
+        if let Some(l) = latency {
+            println!("  {name:<14} {:>8.2} {:>8.2} {:>8.2}", l.p50, l.p95, l.max);
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// Whether `ms` is `expected` milliseconds, give or take rounding
+    fn is_ms(ms: f64, expected: f64) -> bool {
+        (ms - expected).abs() < 1e-9
+    }
+
+    #[test]
+    fn test_latency_percentiles() {
+        assert!(Latency::of(Vec::new()).is_none());
+
+        let one = Latency::of(vec![Duration::from_millis(7)]).unwrap();
+        assert_eq!(one.samples, 1);
+        assert!(
+            [one.min, one.p50, one.p95, one.max]
+                .iter()
+                .all(|&ms| is_ms(ms, 7.0))
+        );
+
+        // 1ms to 101ms, out of order
+        let samples = (1..=101).rev().map(Duration::from_millis).collect();
+        let latency = Latency::of(samples).unwrap();
+        assert_eq!(latency.samples, 101);
+        assert!(is_ms(latency.min, 1.0));
+        assert!(is_ms(latency.p50, 51.0));
+        assert!(is_ms(latency.p95, 96.0));
+        assert!(is_ms(latency.max, 101.0));
+    }
+
+    #[test]
+    fn test_project_report_is_the_standard_envelope() {
+        let report = ProjectBenchmark {
+            version: env!("CARGO_PKG_VERSION"),
+            roots: vec![PathBuf::from("src")],
+            parse: BTreeMap::new(),
+            embedding: None,
+            index: IndexThroughput {
+                files: 12,
+                symbols: 340,
+                seconds: 0.5,
+                files_per_sec: 24.0,
+            },
+            commit_latency_ms: Latency::of(vec![Duration::from_millis(3)]),
+            query_latency_ms: QueryLatency {
+                find_by_name: None,
+                full_text: None,
+                callers: Latency::of(vec![Duration::from_millis(2)]),
+            },
+        };
+        let json: serde_json::Value =
+            serde_json::from_str(&project_envelope(&report).to_json_compact().unwrap()).unwrap();
+
+        assert_eq!(json["type"], "result");
+        assert_eq!(json["status"], "success");
+        assert_eq!(json["code"], "OK");
+        assert_eq!(json["exit_code"], 0);
+        assert_eq!(json["message"], "Benchmarked 12 files in 0.50s");
+        assert_eq!(json["meta"]["entity_type"], "benchmark");
+        assert_eq!(json["meta"]["count"], 12);
+        assert_eq!(json["data"]["index"]["symbols"], 340);
+        let p95 = json["data"]["commit_latency_ms"]["p95"].as_f64().unwrap();
+        assert!(is_ms(p95, 3.0));
+        assert_eq!(json["data"]["query_latency_ms"]["callers"]["samples"], 1);
+        assert!(json["data"]["query_latency_ms"].get("full_text").is_none());
+    }
+}
@@ -0,0 +1,490 @@
+//! Browse command - a terminal browser for the index.
+//!
+//! Typing narrows the list of symbols by fuzzy name match, as
+//! `retrieve search mode:fuzzy` does, and the pane beside the list shows
+//! the signature, doc comment and source of the selected one. From there
+//! the callers, calls and implementations of a symbol open as lists of
+//! their own, which Esc steps back out of. Enter prints the `file:line` of
+//! the selection on exit, so `vim $(codanna browse)` opens it.
+//!
+//! The browser draws on stderr, leaving stdout for the selection.
+
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+use crate::symbol::ScopeContext;
+use crate::symbol::name_match::NameMatcher;
+use crate::symbol::snippet::SourceSnippet;
+use crate::{Symbol, SymbolId, SymbolKind};
+use crossterm::cursor::{Hide, MoveTo, Show};
+use crossterm::event::{self, Event, KeyCode, KeyEvent, KeyEventKind, KeyModifiers};
+use crossterm::style::{Attribute, Print, SetAttribute};
+use crossterm::terminal::{self, ClearType, EnterAlternateScreen, LeaveAlternateScreen};
+use crossterm::{execute, queue};
+use std::io::{self, IsTerminal, Write};
+
+/// Most matches kept for a query; more than a screen holds is only scrolled
+const MAX_MATCHES: usize = 1000;
+
+/// Lines of context around the source of the selected symbol
+const PREVIEW_CONTEXT_LINES: usize = 2;
+
+const HELP: &str = "type to filter  ↑↓ select  → calls  ← callers  ^T implementations  Esc back  Enter print location  ^C quit";
+
+/// Run the browse command.
+pub fn run(query: Option<String>, lang: Option<String>, indexer: &IndexFacade) -> ExitCode {
+    if !io::stdin().is_terminal() || !io::stderr().is_terminal() {
+        eprintln!("Error: codanna browse needs a terminal");
+        eprintln!("Search from scripts with: codanna retrieve search <query> --json");
+        return ExitCode::GeneralError;
+    }
+    let lang = lang.map(|lang| lang.to_lowercase());
+    let mut browser = Browser::new(indexer, query.as_deref().unwrap_or(""), lang.as_deref());
+
+    let picked = match run_terminal(&mut browser) {
+        Ok(picked) => picked,
+        Err(e) => {
+            eprintln!("Error: terminal failed: {e}");
+            return ExitCode::IoError;
+        }
+    };
+    if let Some(symbol) = picked {
+        println!("{}:{}", symbol.file_path, symbol.range.start_line + 1);
+    }
+    ExitCode::Success
+}
+
+/// Restores the terminal however the browser exits
+struct TerminalGuard;
+
+impl TerminalGuard {
+    fn enter() -> io::Result<Self> {
+        terminal::enable_raw_mode()?;
+        execute!(io::stderr(), EnterAlternateScreen, Hide)?;
+        Ok(Self)
+    }
+}
+
+impl Drop for TerminalGuard {
+    fn drop(&mut self) {
+        let _ = execute!(io::stderr(), Show, LeaveAlternateScreen);
+        let _ = terminal::disable_raw_mode();
+    }
+}
+
+fn run_terminal(browser: &mut Browser) -> io::Result<Option<Symbol>> {
+    let _guard = TerminalGuard::enter()?;
+    let mut out = io::stderr();
+    loop {
+        let (width, height) = terminal::size()?;
+        browser.draw(&mut out, width, height)?;
+        match event::read()? {
+            Event::Key(key) if key.kind != KeyEventKind::Release => {
+                match browser.handle_key(key, usize::from(height.saturating_sub(2))) {
+                    Action::Continue => {}
+                    Action::Quit => return Ok(None),
+                    Action::Pick(symbol) => return Ok(Some(symbol)),
+                }
+            }
+            _ => {}
+        }
+    }
+}
+
+/// What a key press leaves the browser to do
+#[derive(Debug)]
+enum Action {
+    Continue,
+    Quit,
+    Pick(Symbol),
+}
+
+/// Related symbols a list can be opened on
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Relation {
+    Calls,
+    Callers,
+    Implementations,
+}
+
+/// A list of symbols narrowed by a query
+struct View {
+    title: String,
+    symbols: Vec<Symbol>,
+    query: String,
+    /// Indices into `symbols` of the matches of `query`, best first
+    matches: Vec<usize>,
+    selected: usize,
+    /// First match on screen
+    offset: usize,
+}
+
+impl View {
+    fn new(title: String, symbols: Vec<Symbol>, query: &str) -> Self {
+        let mut view = Self {
+            title,
+            symbols,
+            query: query.to_string(),
+            matches: Vec::new(),
+            selected: 0,
+            offset: 0,
+        };
+        view.filter();
+        view
+    }
+
+    /// Match the symbols against the query again, from the top
+    fn filter(&mut self) {
+        let query = self.query.trim();
+        self.matches = if query.is_empty() {
+            (0..self.symbols.len()).collect()
+        } else {
+            let matcher = NameMatcher::fuzzy(query);
+            let mut scored: Vec<(usize, f32)> = self
+                .symbols
+                .iter()
+                .enumerate()
+                .filter_map(|(i, symbol)| Some((i, matcher.score(&symbol.name)?)))
+                .collect();
+            // Best first, shorter names first among equals
+            scored.sort_by(|(a, a_score), (b, b_score)| {
+                b_score.total_cmp(a_score).then_with(|| {
+                    self.symbols[*a]
+                        .name
+                        .len()
+                        .cmp(&self.symbols[*b].name.len())
+                })
+            });
+            scored.into_iter().map(|(i, _)| i).collect()
+        };
+        self.matches.truncate(MAX_MATCHES);
+        self.selected = 0;
+        self.offset = 0;
+    }
+
+    fn selected_symbol(&self) -> Option<&Symbol> {
+        self.matches.get(self.selected).map(|&i| &self.symbols[i])
+    }
+
+    /// Move the selection by `delta`, scrolling so it stays among the
+    /// `rows` on screen
+    fn move_selection(&mut self, delta: isize, rows: usize) {
+        if self.matches.is_empty() {
+            return;
+        }
+        self.selected = self
+            .selected
+            .saturating_add_signed(delta)
+            .min(self.matches.len() - 1);
+        self.scroll(rows);
+    }
+
+    fn scroll(&mut self, rows: usize) {
+        let rows = rows.max(1);
+        if self.selected < self.offset {
+            self.offset = self.selected;
+        } else if self.selected >= self.offset + rows {
+            self.offset = self.selected + 1 - rows;
+        }
+    }
+}
+
+/// State of the browser, apart from the terminal it draws on
+struct Browser<'a> {
+    facade: &'a IndexFacade,
+    /// The search list first, then each list opened from it
+    views: Vec<View>,
+    /// Shown on the help line until the next key
+    status: Option<String>,
+    /// Preview lines of the last symbol previewed
+    preview: Option<(SymbolId, Vec<String>)>,
+}
+
+impl<'a> Browser<'a> {
+    /// Browse the symbols of `facade` in `lang`, or of every language.
+    /// Locals and parameters are left out, as in `export tags`.
+    fn new(facade: &'a IndexFacade, query: &str, lang: Option<&str>) -> Self {
+        let mut symbols: Vec<Symbol> = facade
+            .get_all_symbols()
+            .into_iter()
+            .filter(|symbol| {
+                let local = matches!(
+                    symbol.scope_context,
+                    Some(ScopeContext::Local { .. } | ScopeContext::Parameter)
+                );
+                !local
+                    && symbol.kind != SymbolKind::Parameter
+                    && lang
+                        .is_none_or(|lang| symbol.language_id.map(|id| id.as_str()) == Some(lang))
+            })
+            .collect();
+        symbols.sort_by(|a, b| {
+            a.name
+                .cmp(&b.name)
+                .then_with(|| a.file_path.cmp(&b.file_path))
+        });
+        let title = format!("{} symbols", symbols.len());
+        Self {
+            facade,
+            views: vec![View::new(title, symbols, query)],
+            status: None,
+            preview: None,
+        }
+    }
+
+    fn view(&self) -> &View {
+        self.views.last().expect("the search view is never popped")
+    }
+
+    fn view_mut(&mut self) -> &mut View {
+        self.views
+            .last_mut()
+            .expect("the search view is never popped")
+    }
+
+    /// Act on `key`; `rows` is the height of the list on screen
+    fn handle_key(&mut self, key: KeyEvent, rows: usize) -> Action {
+        self.status = None;
+        let page = rows.max(1) as isize;
+        let ctrl = key.modifiers.contains(KeyModifiers::CONTROL);
+        match key.code {
+            KeyCode::Char('c') if ctrl => return Action::Quit,
+            KeyCode::Char('t') if ctrl => self.follow(Relation::Implementations),
+            KeyCode::Char('u') if ctrl => {
+                let view = self.view_mut();
+                view.query.clear();
+                view.filter();
+            }
+            KeyCode::Char(c) if !ctrl => {
+                let view = self.view_mut();
+                view.query.push(c);
+                view.filter();
+            }
+            KeyCode::Backspace => {
+                let view = self.view_mut();
+                if view.query.pop().is_some() {
+                    view.filter();
+                }
+            }
+            KeyCode::Up => self.view_mut().move_selection(-1, rows),
+            KeyCode::Down => self.view_mut().move_selection(1, rows),
+            KeyCode::PageUp => self.view_mut().move_selection(-page, rows),
+            KeyCode::PageDown => self.view_mut().move_selection(page, rows),
+            KeyCode::Home => self.view_mut().move_selection(isize::MIN, rows),
+            KeyCode::End => self.view_mut().move_selection(isize::MAX, rows),
+            KeyCode::Right => self.follow(Relation::Calls),
+            KeyCode::Left => self.follow(Relation::Callers),
+            KeyCode::Esc => {
+                if self.views.len() == 1 {
+                    return Action::Quit;
+                }
+                self.views.pop();
+            }
+            KeyCode::Enter => {
+                if let Some(symbol) = self.view().selected_symbol() {
+                    return Action::Pick(symbol.clone());
+                }
+            }
+            _ => {}
+        }
+        Action::Continue
+    }
+
+    /// Open the symbols related to the selected one as a list, or say
+    /// there are none
+    fn follow(&mut self, relation: Relation) {
+        let Some(symbol) = self.view().selected_symbol().cloned() else {
+            return;
+        };
+        let (mut symbols, label) = match relation {
+            Relation::Calls => (self.facade.get_called_functions(symbol.id), "calls of"),
+            Relation::Callers => (self.facade.get_calling_functions(symbol.id), "callers of"),
+            Relation::Implementations => (
+                self.facade.get_implementations(symbol.id),
+                "implementations of",
+            ),
+        };
+        if symbols.is_empty() {
+            self.status = Some(format!("no {label} {}", symbol.name));
+            return;
+        }
+        symbols.sort_by(|a, b| {
+            a.file_path
+                .cmp(&b.file_path)
+                .then(a.range.start_line.cmp(&b.range.start_line))
+        });
+        symbols.dedup_by_key(|symbol| symbol.id);
+        let title = format!("{} {label} {}", symbols.len(), symbol.name);
+        self.views.push(View::new(title, symbols, ""));
+    }
+
+    /// The lines of the preview pane for `symbol`
+    fn preview_lines(&mut self, symbol: &Symbol) -> &[String] {
+        if self.preview.as_ref().is_none_or(|(id, _)| *id != symbol.id) {
+            let mut lines = vec![
+                format!("{:?} {}", symbol.kind, symbol.name),
+                format!("{}:{}", symbol.file_path, symbol.range.start_line + 1),
+            ];
+            if let Some(module) = symbol.module_path.as_deref().filter(|m| !m.is_empty()) {
+                lines.push(module.to_string());
+            }
+            let callers = self.facade.get_calling_functions(symbol.id).len();
+            let calls = self.facade.get_called_functions(symbol.id).len();
+            lines.push(format!("{callers} callers, {calls} calls"));
+            if let Some(signature) = symbol.signature.as_deref() {
+                lines.push(String::new());
+                lines.extend(signature.lines().map(str::to_string));
+            }
+            if let Some(doc) = symbol.doc_comment.as_deref() {
+                lines.push(String::new());
+                lines.extend(doc.lines().map(str::to_string));
+            }
+            let root = self.facade.settings().workspace_root.as_deref();
+            if let Some(snippet) = SourceSnippet::of_symbol(symbol, root, PREVIEW_CONTEXT_LINES) {
+                lines.push(String::new());
+                for (i, line) in snippet.code.lines().enumerate() {
+                    let number = snippet.start_line as usize + i;
+                    lines.push(format!("{number:>5} │ {}", line.replace('\t', "    ")));
+                }
+            }
+            self.preview = Some((symbol.id, lines));
+        }
+        &self.preview.as_ref().expect("preview just set").1
+    }
+
+    /// Draw the prompt, the list, the preview and the help line
+    fn draw(&mut self, out: &mut impl Write, width: u16, height: u16) -> io::Result<()> {
+        let width = usize::from(width);
+        let rows = usize::from(height.saturating_sub(2));
+        let list_width = (width * 2 / 5).max(20).min(width);
+        let preview_width = width.saturating_sub(list_width + 3);
+        self.view_mut().scroll(rows);
+
+        queue!(out, terminal::Clear(ClearType::All), MoveTo(0, 0))?;
+        let view = self.view();
+        let prompt = format!(
+            "{} ({} matches) > {}",
+            view.title,
+            view.matches.len(),
+            view.query
+        );
+        queue!(out, Print(fit(&prompt, width)))?;
+
+        let items: Vec<(bool, String)> = view
+            .matches
+            .iter()
+            .enumerate()
+            .skip(view.offset)
+            .take(rows)
+            .map(|(i, &index)| {
+                let symbol = &view.symbols[index];
+                let kind = format!("{:?}", symbol.kind);
+                (i == view.selected, format!("{kind:<10} {}", symbol.name))
+            })
+            .collect();
+        for (row, (selected, item)) in items.into_iter().enumerate() {
+            let text = format!("{:<list_width$}", fit(&item, list_width));
+            queue!(out, MoveTo(0, row as u16 + 1))?;
+            if selected {
+                queue!(
+                    out,
+                    SetAttribute(Attribute::Reverse),
+                    Print(text),
+                    SetAttribute(Attribute::Reset)
+                )?;
+            } else {
+                queue!(out, Print(text))?;
+            }
+        }
+
+        if let Some(symbol) = self.view().selected_symbol().cloned() {
+            let column = (list_width + 1) as u16;
+            for (row, line) in self.preview_lines(&symbol).iter().take(rows).enumerate() {
+                queue!(
+                    out,
+                    MoveTo(column, row as u16 + 1),
+                    Print("│ "),
+                    Print(fit(line, preview_width))
+                )?;
+            }
+        }
+
+        let help = self.status.as_deref().unwrap_or(HELP);
+        queue!(
+            out,
+            MoveTo(0, height.saturating_sub(1)),
+            SetAttribute(Attribute::Dim),
+            Print(fit(help, width)),
+            SetAttribute(Attribute::Reset)
+        )?;
+        out.flush()
+    }
+}
+
+/// `text` cut to `width` characters
+fn fit(text: &str, width: usize) -> String {
+    text.chars().take(width).collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::Settings;
+    use std::sync::Arc;
+
+    fn key(code: KeyCode) -> KeyEvent {
+        KeyEvent::new(code, KeyModifiers::NONE)
+    }
+
+    #[test]
+    fn test_filter_and_follow_calls() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("shapes.py");
+        std::fs::write(
+            &source,
+            "def make():\n    pass\n\n\ndef area():\n    return make()\n",
+        )
+        .unwrap();
+        let mut facade = IndexFacade::new(Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+
+        let mut browser = Browser::new(&facade, "", None);
+        assert_eq!(browser.view().matches.len(), 2);
+        for c in "are".chars() {
+            browser.handle_key(key(KeyCode::Char(c)), 10);
+        }
+        let selected = browser.view().selected_symbol().unwrap();
+        assert_eq!(&*selected.name, "area");
+
+        browser.handle_key(key(KeyCode::Right), 10);
+        assert_eq!(browser.views.len(), 2);
+        let make = browser.view().selected_symbol().unwrap().clone();
+        assert_eq!(&*make.name, "make");
+        assert!(
+            browser
+                .preview_lines(&make)
+                .iter()
+                .any(|line| line.ends_with("def make():"))
+        );
+
+        // make calls nothing, so no list opens
+        browser.handle_key(key(KeyCode::Right), 10);
+        assert_eq!(browser.views.len(), 2);
+        assert_eq!(browser.status.as_deref(), Some("no calls of make"));
+
+        browser.handle_key(key(KeyCode::Esc), 10);
+        assert_eq!(browser.view().query, "are");
+        assert!(matches!(
+            browser.handle_key(key(KeyCode::Enter), 10),
+            Action::Pick(symbol) if &*symbol.name == "area"
+        ));
+        assert!(matches!(
+            browser.handle_key(key(KeyCode::Esc), 10),
+            Action::Quit
+        ));
+    }
+}
@@ -0,0 +1,213 @@
+//! Check command - hold the index to quality thresholds.
+//!
+//! Each threshold, set by a flag or in `[analysis.check]` of the settings,
+//! is measured against the index and passes or fails; any failure exits
+//! non-zero, so `codanna check` can gate a merge in CI. Only what a
+//! threshold asks for is measured: the cycles need the whole graph.
+
+use crate::analysis::breakdown::{is_documentable, is_documented, percentage};
+use crate::analysis::{ApiItem, CycleLevel, find_cycles, undocumented_api};
+use crate::config::CheckConfig;
+use crate::export::{GraphFilter, SymbolGraph};
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+use crate::symbol::Visibility;
+use serde::Serialize;
+
+/// Undocumented public symbols listed in the text output
+const LISTED: usize = 10;
+
+/// What the thresholds were measured against
+#[derive(Debug, Default, Serialize)]
+pub struct Measures {
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub doc_coverage: Option<f32>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub cycles: Option<usize>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub undocumented_public: Option<Vec<ApiItem>>,
+}
+
+/// One threshold and whether the index keeps to it
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct Gate {
+    /// The threshold, as named in the settings
+    pub name: &'static str,
+    pub passed: bool,
+    /// The measured value against the threshold
+    pub message: String,
+}
+
+/// Everything `codanna check` reports
+#[derive(Debug, Serialize)]
+struct CheckReport {
+    gates: Vec<Gate>,
+    #[serde(flatten)]
+    measures: Measures,
+}
+
+/// Run the check command.
+pub fn run(
+    flags: CheckConfig,
+    config: &CheckConfig,
+    path: Option<String>,
+    lang: Option<String>,
+    json: bool,
+    indexer: &IndexFacade,
+) -> ExitCode {
+    // A flag overrides the settings
+    let thresholds = CheckConfig {
+        min_doc_coverage: flags.min_doc_coverage.or(config.min_doc_coverage),
+        max_cycles: flags.max_cycles.or(config.max_cycles),
+        max_undocumented_public: flags
+            .max_undocumented_public
+            .or(config.max_undocumented_public),
+    };
+    if thresholds == CheckConfig::default() {
+        eprintln!(
+            "Error: no thresholds to check; pass --min-doc-coverage, --max-cycles or --max-undocumented-public, or set them in [analysis.check] of .codanna/settings.toml"
+        );
+        return ExitCode::ConfigError;
+    }
+
+    let filter = GraphFilter {
+        path,
+        language: lang.map(|lang| lang.to_lowercase()),
+        kinds: Vec::new(),
+        relations: Vec::new(),
+    };
+    let measures = measure(&thresholds, &filter, indexer);
+    let gates = evaluate(&thresholds, &measures);
+    let failed = gates.iter().filter(|gate| !gate.passed).count();
+    let exit_code = if failed > 0 {
+        ExitCode::GeneralError
+    } else {
+        ExitCode::Success
+    };
+    let summary = match failed {
+        0 => format!("All {} thresholds passed", gates.len()),
+        failed => format!("{failed} of {} thresholds failed", gates.len()),
+    };
+
+    if json {
+        let report = CheckReport { gates, measures };
+        let mut envelope = Envelope::success(&report)
+            .with_entity_type(EntityType::Diagnostic)
+            .with_count(report.gates.len())
+            .with_message(summary);
+        envelope.exit_code = exit_code as u8;
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+        return exit_code;
+    }
+
+    for gate in &gates {
+        let mark = if gate.passed { "ok  " } else { "FAIL" };
+        println!("[{mark}] {}", gate.message);
+    }
+    if let Some(items) = &measures.undocumented_public {
+        let failed = gates
+            .iter()
+            .any(|gate| gate.name == "max_undocumented_public" && !gate.passed);
+        if failed {
+            println!("\nUndocumented public symbols:");
+            for item in items.iter().take(LISTED) {
+                println!("  {}:{}  {}", item.file, item.line, item.name);
+            }
+            if items.len() > LISTED {
+                println!("  ... {} more (see --json)", items.len() - LISTED);
+            }
+        }
+    }
+    println!("\n{summary}");
+    exit_code
+}
+
+/// Measure what the thresholds ask for
+fn measure(thresholds: &CheckConfig, filter: &GraphFilter, indexer: &IndexFacade) -> Measures {
+    let mut measures = Measures::default();
+    if thresholds.min_doc_coverage.is_some() || thresholds.max_undocumented_public.is_some() {
+        let symbols = indexer.get_all_symbols();
+        if thresholds.min_doc_coverage.is_some() {
+            let counted: Vec<_> = symbols
+                .iter()
+                .filter(|symbol| is_documentable(symbol) && filter.accepts(symbol))
+                .collect();
+            let documented = counted
+                .iter()
+                .filter(|symbol| is_documented(symbol))
+                .count();
+            measures.doc_coverage = Some(percentage(documented, counted.len()));
+        }
+        if thresholds.max_undocumented_public.is_some() {
+            measures.undocumented_public =
+                Some(undocumented_api(&symbols, Visibility::Public, filter));
+        }
+    }
+    if thresholds.max_cycles.is_some() {
+        let level = CycleLevel::Module;
+        let filter = GraphFilter {
+            relations: level.default_relations().to_vec(),
+            ..filter.clone()
+        };
+        let graph = SymbolGraph::build(indexer, &filter);
+        measures.cycles = Some(find_cycles(&graph, level).len());
+    }
+    measures
+}
+
+/// The gates of the thresholds that are set, against what was measured
+pub fn evaluate(thresholds: &CheckConfig, measures: &Measures) -> Vec<Gate> {
+    let mut gates = Vec::new();
+    if let (Some(min), Some(coverage)) = (thresholds.min_doc_coverage, measures.doc_coverage) {
+        gates.push(Gate {
+            name: "min_doc_coverage",
+            passed: coverage >= min,
+            message: format!("doc coverage {coverage:.1}% (min {min}%)"),
+        });
+    }
+    if let (Some(max), Some(cycles)) = (thresholds.max_cycles, measures.cycles) {
+        gates.push(Gate {
+            name: "max_cycles",
+            passed: cycles <= max,
+            message: format!("{cycles} module cycles (max {max})"),
+        });
+    }
+    if let (Some(max), Some(items)) = (
+        thresholds.max_undocumented_public,
+        &measures.undocumented_public,
+    ) {
+        gates.push(Gate {
+            name: "max_undocumented_public",
+            passed: items.len() <= max,
+            message: format!("{} undocumented public symbols (max {max})", items.len()),
+        });
+    }
+    gates
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_evaluate_thresholds() {
+        let thresholds = CheckConfig {
+            min_doc_coverage: Some(60.0),
+            max_cycles: Some(0),
+            max_undocumented_public: None,
+        };
+        let measures = Measures {
+            doc_coverage: Some(72.5),
+            cycles: Some(2),
+            undocumented_public: None,
+        };
+        let gates = evaluate(&thresholds, &measures);
+
+        let results: Vec<(&str, bool)> =
+            gates.iter().map(|gate| (gate.name, gate.passed)).collect();
+        assert_eq!(results, [("min_doc_coverage", true), ("max_cycles", false)]);
+        assert_eq!(gates[0].message, "doc coverage 72.5% (min 60%)");
+        assert_eq!(gates[1].message, "2 module cycles (max 0)");
+    }
+}
@@ -0,0 +1,327 @@
+//! Completions command - shell completion that knows the index.
+//!
+//! `codanna completions <shell>` prints a script for bash, zsh or fish
+//! that hands the words of the command line to `codanna __complete`. That
+//! walks the command tree of the CLI for subcommands and flags, and where
+//! a command takes a symbol, as `retrieve callers` and `mcp find_symbol`
+//! do, completes the names of symbols in the index of the current project.
+
+use crate::cli::Cli;
+use crate::config::Settings;
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+use crate::storage::IndexPersistence;
+use crate::symbol::dsl::SymbolQuery;
+use clap::CommandFactory;
+use std::collections::BTreeSet;
+use std::sync::Arc;
+
+/// Retrieve commands whose first positional argument is a symbol
+const SYMBOL_COMMANDS: &[&str] = &[
+    "symbol",
+    "callers",
+    "calls",
+    "implementations",
+    "hierarchy",
+    "impact",
+    "tests-for",
+    "references",
+    "signature",
+    "similar",
+    "describe",
+];
+
+/// MCP tools whose first positional argument is a symbol
+const SYMBOL_TOOLS: &[&str] = &[
+    "find_symbol",
+    "get_calls",
+    "find_callers",
+    "get_call_hierarchy",
+    "analyze_impact",
+    "get_type_hierarchy",
+    "impact_of_change",
+    "find_tests",
+    "find_similar_symbols",
+];
+
+/// `key:` prefixes that take a symbol name
+const SYMBOL_KEYS: &[&str] = &[
+    "name",
+    "symbol",
+    "function",
+    "function_name",
+    "symbol_name",
+    "type_name",
+    "trait",
+];
+
+/// Most symbol names offered for one word
+const MAX_SYMBOLS: usize = 200;
+
+const BASH_SCRIPT: &str = r#"# codanna completion for bash: source <(codanna completions bash)
+_codanna() {
+    local line=${COMP_LINE:0:COMP_POINT}
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *' ' ]] && words+=("")
+    local cur=${words[-1]}
+    local IFS=$'\n'
+    COMPREPLY=($(codanna __complete -- "${words[@]:1}" 2>/dev/null))
+    # bash splits words at ':', so key:value completes past the colon
+    if [[ $cur == *:* && $COMP_WORDBREAKS == *:* ]]; then
+        local colon_prefix=${cur%"${cur##*:}"}
+        local i=${#COMPREPLY[@]}
+        while [[ $((--i)) -ge 0 ]]; do
+            COMPREPLY[$i]=${COMPREPLY[$i]#"$colon_prefix"}
+        done
+    fi
+}
+complete -o default -F _codanna codanna
+"#;
+
+const ZSH_SCRIPT: &str = r#"#compdef codanna
+# codanna completion for zsh: source <(codanna completions zsh)
+_codanna() {
+    local -a candidates
+    candidates=("${(@f)$(codanna __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if (( ${#candidates} )) && [[ -n ${candidates[1]} ]]; then
+        compadd -Q -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef _codanna codanna
+"#;
+
+const FISH_SCRIPT: &str = r#"# codanna completion for fish: codanna completions fish | source
+function __codanna_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    codanna __complete -- $tokens[2..-1] 2>/dev/null
+end
+complete -c codanna -f -a '(__codanna_complete)'
+"#;
+
+/// Run the completions command: print the script of `shell`.
+pub fn run_script(shell: &str) -> ExitCode {
+    let script = match shell.to_lowercase().as_str() {
+        "bash" => BASH_SCRIPT,
+        "zsh" => ZSH_SCRIPT,
+        "fish" => FISH_SCRIPT,
+        _ => {
+            eprintln!("Error: unknown shell '{shell}'; expected one of: bash, zsh, fish");
+            return ExitCode::GeneralError;
+        }
+    };
+    print!("{script}");
+    ExitCode::Success
+}
+
+/// Run `__complete`: print the candidates for the last of `words`, one per
+/// line. Never fails, so a broken index leaves the shell to its defaults.
+pub fn run(words: &[String], config: &Settings) -> ExitCode {
+    // Opened only when a symbol is completed, and quietly
+    let mut facade: Option<Option<IndexFacade>> = None;
+    let candidates = candidates(words, |prefix| {
+        let facade = facade.get_or_insert_with(|| {
+            let persistence = IndexPersistence::new(config.index_path.clone());
+            if !persistence.exists() {
+                return None;
+            }
+            persistence.load_facade_lite(Arc::new(config.clone())).ok()
+        });
+        facade
+            .as_ref()
+            .map(|facade| symbol_names(facade, prefix))
+            .unwrap_or_default()
+    });
+    for candidate in candidates {
+        println!("{candidate}");
+    }
+    ExitCode::Success
+}
+
+/// Names of indexed symbols starting with `prefix`
+pub fn symbol_names(facade: &IndexFacade, prefix: &str) -> Vec<String> {
+    // Names are matched as globs; a prefix that is not a plain name has
+    // no completions
+    if prefix.is_empty() || prefix.contains(|c: char| c.is_whitespace() || "\"*?,".contains(c)) {
+        return Vec::new();
+    }
+    let Ok(query) = SymbolQuery::parse(&format!("name:{prefix}*")) else {
+        return Vec::new();
+    };
+    let names: BTreeSet<String> = facade
+        .query_symbols(&query, MAX_SYMBOLS * 5)
+        .unwrap_or_default()
+        .into_iter()
+        .map(|result| result.name)
+        .collect();
+    names.into_iter().take(MAX_SYMBOLS).collect()
+}
+
+/// Candidates for the last of `words`, the command line after `codanna`;
+/// `symbols` gives the symbol names starting with a prefix
+pub fn candidates(words: &[String], mut symbols: impl FnMut(&str) -> Vec<String>) -> Vec<String> {
+    let (current, before) = match words.split_last() {
+        Some((current, before)) => (current.as_str(), before),
+        None => ("", &[][..]),
+    };
+    // Built, so global flags are on every subcommand
+    let mut root = Cli::command();
+    root.build();
+    let mut command = &root;
+    let mut path: Vec<String> = Vec::new();
+    let mut positionals: Vec<&str> = Vec::new();
+    let mut expects_value: Option<&clap::Arg> = None;
+    for word in before {
+        if expects_value.take().is_some() {
+            continue;
+        }
+        if let Some(flag) = word.strip_prefix("--") {
+            if flag.is_empty() {
+                continue;
+            }
+            if !flag.contains('=') {
+                expects_value = command
+                    .get_arguments()
+                    .find(|arg| arg.get_long() == Some(flag))
+                    .filter(|arg| takes_value(arg));
+            }
+        } else if let Some(flags) = word.strip_prefix('-').filter(|flags| !flags.is_empty()) {
+            // The last of bundled short flags may take the next word
+            let last = flags.chars().last();
+            expects_value = command
+                .get_arguments()
+                .find(|arg| arg.get_short() == last)
+                .filter(|arg| takes_value(arg));
+        } else if let Some(subcommand) = command.find_subcommand(word) {
+            command = subcommand;
+            path.push(subcommand.get_name().to_string());
+            positionals.clear();
+        } else {
+            positionals.push(word);
+        }
+    }
+
+    let starting = |names: Vec<String>| -> Vec<String> {
+        names
+            .into_iter()
+            .filter(|name| name.starts_with(current))
+            .collect()
+    };
+
+    // The value of a flag
+    if let Some(arg) = expects_value {
+        return starting(
+            arg.get_possible_values()
+                .iter()
+                .map(|value| value.get_name().to_string())
+                .collect(),
+        );
+    }
+    if current.starts_with('-') {
+        return starting(
+            command
+                .get_arguments()
+                .filter(|arg| !arg.is_hide_set())
+                .filter_map(|arg| arg.get_long().map(|long| format!("--{long}")))
+                .collect(),
+        );
+    }
+    if command.has_subcommands() {
+        return starting(
+            command
+                .get_subcommands()
+                .filter(|subcommand| !subcommand.is_hide_set())
+                .map(|subcommand| subcommand.get_name().to_string())
+                .collect(),
+        );
+    }
+
+    let takes_symbol = match path.iter().map(String::as_str).collect::<Vec<_>>()[..] {
+        ["retrieve", name] => SYMBOL_COMMANDS.contains(&name),
+        ["browse"] => true,
+        ["mcp"] => {
+            if positionals.is_empty() {
+                return starting(
+                    super::mcp::KNOWN_TOOLS
+                        .iter()
+                        .map(|tool| tool.to_string())
+                        .collect(),
+                );
+            }
+            SYMBOL_TOOLS.contains(&positionals[0])
+        }
+        _ => false,
+    };
+    if !takes_symbol {
+        return Vec::new();
+    }
+    if let Some((key, prefix)) = current.split_once(':') {
+        if !SYMBOL_KEYS.contains(&key) {
+            return Vec::new();
+        }
+        return symbols(prefix)
+            .into_iter()
+            .map(|name| format!("{key}:{name}"))
+            .collect();
+    }
+    // The symbol is the first word that is not a key:value pair
+    let named = positionals
+        .iter()
+        .skip(usize::from(path == ["mcp"]))
+        .any(|word| !word.contains(':'));
+    if named { Vec::new() } else { symbols(current) }
+}
+
+fn takes_value(arg: &clap::Arg) -> bool {
+    arg.get_num_args().is_some_and(|range| range.takes_values())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn complete(line: &str) -> Vec<String> {
+        let mut words: Vec<String> = line.split(' ').map(str::to_string).collect();
+        if words == [""] {
+            words.clear();
+        }
+        candidates(&words, |prefix| {
+            ["authenticate", "authorize", "parse"]
+                .iter()
+                .filter(|name| name.starts_with(prefix))
+                .map(|name| name.to_string())
+                .collect()
+        })
+    }
+
+    #[test]
+    fn test_complete_commands_and_flags() {
+        assert_eq!(complete("retr"), vec!["retrieve"]);
+        assert!(complete("retrieve ca").contains(&"callers".to_string()));
+        assert_eq!(complete("stats --me"), vec!["--metrics"]);
+        assert!(complete("mcp find_").contains(&"find_callers".to_string()));
+        // The value of --config is not taken for a subcommand
+        assert_eq!(complete("--config retrieve ret"), vec!["retrieve"]);
+        assert!(!complete("").contains(&"__complete".to_string()));
+    }
+
+    #[test]
+    fn test_complete_symbol_names() {
+        assert_eq!(
+            complete("retrieve callers auth"),
+            vec!["authenticate", "authorize"]
+        );
+        assert_eq!(
+            complete("retrieve callers function:autho"),
+            vec!["function:authorize"]
+        );
+        assert_eq!(complete("mcp find_symbol pa"), vec!["parse"]);
+        assert_eq!(complete("mcp find_symbol lang:rust pa"), vec!["parse"]);
+        // One symbol per command
+        assert!(complete("retrieve callers parse auth").is_empty());
+        assert!(complete("retrieve search auth").is_empty());
+        assert!(complete("mcp search_symbols auth").is_empty());
+    }
+}
@@ -0,0 +1,115 @@
+//! Diff command - symbol and relationship changes between two snapshots.
+
+use crate::Settings;
+use crate::analysis::{DiffSide, IndexDiff, diff_indexes, render_diff};
+use crate::export::{EdgeKind, GraphFilter};
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+use crate::snapshot::{SnapshotInfo, open_snapshot};
+use serde::Serialize;
+
+/// Everything `codanna diff` reports
+#[derive(Debug, Serialize)]
+struct DiffReport {
+    from: String,
+    to: String,
+    #[serde(flatten)]
+    diff: IndexDiff,
+}
+
+/// `v1.0 (1a2b3c4d)`
+fn label(info: &SnapshotInfo) -> String {
+    format!("{} ({})", info.name, info.short_commit())
+}
+
+/// Run the diff command.
+#[allow(clippy::too_many_arguments)]
+pub fn run(
+    from: String,
+    to: Option<String>,
+    relations: Vec<String>,
+    path: Option<String>,
+    lang: Option<String>,
+    json: bool,
+    config: &Settings,
+    indexer: &IndexFacade,
+) -> ExitCode {
+    let relations = match relations
+        .iter()
+        .map(|relation| relation.trim().parse())
+        .collect::<Result<Vec<EdgeKind>, _>>()
+    {
+        Ok(relations) => relations,
+        Err(e) => {
+            eprintln!("Error: {e}");
+            return ExitCode::GeneralError;
+        }
+    };
+    let filter = GraphFilter {
+        path,
+        language: lang.map(|lang| lang.to_lowercase()),
+        kinds: Vec::new(),
+        relations,
+    };
+
+    let (from_info, from_index) = match open_snapshot(config, &from) {
+        Ok(opened) => opened,
+        Err(e) => {
+            eprintln!("Error: {e}");
+            return ExitCode::NotFound;
+        }
+    };
+    let old = DiffSide::from_index(&from_index, &filter);
+    let (to, new) = match &to {
+        Some(to) => match open_snapshot(config, to) {
+            Ok((to_info, to_index)) => (label(&to_info), DiffSide::from_index(&to_index, &filter)),
+            Err(e) => {
+                eprintln!("Error: {e}");
+                return ExitCode::NotFound;
+            }
+        },
+        None => (
+            "the current index".to_string(),
+            DiffSide::from_index(indexer, &filter),
+        ),
+    };
+
+    let report = DiffReport {
+        from: label(&from_info),
+        to,
+        diff: diff_indexes(&old, &new),
+    };
+    let diff = &report.diff;
+    let summary = format!(
+        "{} added, {} removed, {} changed symbols; {} added, {} removed relationships",
+        diff.added.len(),
+        diff.removed.len(),
+        diff.changed.len(),
+        diff.added_relationships.len(),
+        diff.removed_relationships.len()
+    );
+
+    if json {
+        let count = diff.added.len()
+            + diff.removed.len()
+            + diff.changed.len()
+            + diff.added_relationships.len()
+            + diff.removed_relationships.len();
+        let envelope = Envelope::success(&report)
+            .with_entity_type(EntityType::Diff)
+            .with_count(count)
+            .with_message(format!("{} -> {}: {summary}", report.from, report.to));
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+        return ExitCode::Success;
+    }
+
+    println!("Diff {} -> {}", report.from, report.to);
+    if diff.is_empty() {
+        println!("No differences");
+    } else {
+        println!("{summary}\n");
+        print!("{}", render_diff(diff));
+    }
+    ExitCode::Success
+}
@@ -169,12 +169,16 @@ pub fn run_list_dirs(config: &Settings) {
         println!("\nTo add directories: codanna add-dir <path>");
     } else {
         for path in &config.indexing.indexed_paths {
-            println!("  - {}", path.display());
+            if config.workspace_roots_cache.contains(path) {
+                println!("  - {} (workspace root)", path.display());
+            } else {
+                println!("  - {}", path.display());
+            }
         }
     }
 }
 
-fn resolve_config_path(cli_config: Option<&Path>) -> PathBuf {
+pub(crate) fn resolve_config_path(cli_config: Option<&Path>) -> PathBuf {
     if let Some(custom_path) = cli_config {
         custom_path.to_path_buf()
     } else {
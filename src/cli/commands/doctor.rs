@@ -0,0 +1,587 @@
+//! Doctor command - diagnose the setup and the index.
+//!
+//! Each check reports what it found and, when something is wrong, the
+//! command that fixes it. Nothing is repaired or downloaded: the checks
+//! only read the settings, the index and the model cache, so the command
+//! is safe to run on a broken project.
+
+use crate::config::Settings;
+use crate::indexing::facade::{IndexFacade, configured_model_name};
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+use crate::semantic::{SemanticMetadata, SimpleSemanticSearch};
+use crate::storage::{EMISSION_SEMANTICS_VERSION, IndexMetadata, IndexPersistence};
+use serde::Serialize;
+use std::collections::BTreeSet;
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+
+/// Watches a project asks of inotify beyond its own directories
+#[cfg(target_os = "linux")]
+const INOTIFY_HEADROOM: usize = 1024;
+
+/// Outcome of one check
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize)]
+#[serde(rename_all = "lowercase")]
+pub enum CheckStatus {
+    Ok,
+    Warn,
+    Fail,
+}
+
+/// One finding of `codanna doctor`
+#[derive(Debug, Clone, Serialize)]
+pub struct Check {
+    pub area: &'static str,
+    pub status: CheckStatus,
+    pub message: String,
+    /// What to run or change to resolve it
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub fix: Option<String>,
+}
+
+impl Check {
+    fn ok(area: &'static str, message: impl Into<String>) -> Self {
+        Self {
+            area,
+            status: CheckStatus::Ok,
+            message: message.into(),
+            fix: None,
+        }
+    }
+
+    fn warn(area: &'static str, message: impl Into<String>, fix: impl Into<String>) -> Self {
+        Self {
+            area,
+            status: CheckStatus::Warn,
+            message: message.into(),
+            fix: Some(fix.into()),
+        }
+    }
+
+    fn fail(area: &'static str, message: impl Into<String>, fix: impl Into<String>) -> Self {
+        Self {
+            area,
+            status: CheckStatus::Fail,
+            message: message.into(),
+            fix: Some(fix.into()),
+        }
+    }
+}
+
+/// Run the doctor command. Fails when any check fails, so scripts can gate
+/// on it; warnings alone succeed.
+pub fn run(config: &Settings, config_path: Option<&Path>, json: bool) -> ExitCode {
+    let checks = diagnose(config, config_path);
+    let failed = checks
+        .iter()
+        .filter(|check| check.status == CheckStatus::Fail)
+        .count();
+    let warned = checks
+        .iter()
+        .filter(|check| check.status == CheckStatus::Warn)
+        .count();
+    let exit_code = if failed > 0 {
+        ExitCode::GeneralError
+    } else {
+        ExitCode::Success
+    };
+    let summary = match (failed, warned) {
+        (0, 0) => "No problems found".to_string(),
+        (failed, warned) => format!("{failed} failed, {warned} warnings"),
+    };
+
+    if json {
+        let mut envelope = Envelope::success(&checks)
+            .with_entity_type(EntityType::Diagnostic)
+            .with_count(checks.len())
+            .with_message(summary);
+        envelope.exit_code = exit_code as u8;
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+        return exit_code;
+    }
+
+    let mut area = "";
+    for check in &checks {
+        if check.area != area {
+            area = check.area;
+            println!("\n{area}:");
+        }
+        let mark = match check.status {
+            CheckStatus::Ok => "ok  ",
+            CheckStatus::Warn => "warn",
+            CheckStatus::Fail => "FAIL",
+        };
+        println!("  [{mark}] {}", check.message);
+        if let Some(fix) = &check.fix {
+            println!("         fix: {fix}");
+        }
+    }
+    println!("\n{summary}");
+    exit_code
+}
+
+/// Every check, in the order they are printed
+pub fn diagnose(config: &Settings, config_path: Option<&Path>) -> Vec<Check> {
+    let mut checks = check_settings(config, config_path);
+    let facade = check_index(config, &mut checks);
+    check_semantic(config, facade.as_ref(), &mut checks);
+    check_watcher(config, facade.as_ref(), &mut checks);
+    checks
+}
+
+/// The settings file parses and names directories that exist
+fn check_settings(config: &Settings, config_path: Option<&Path>) -> Vec<Check> {
+    const AREA: &str = "settings";
+    let mut checks = Vec::new();
+    let path = config_path.map(Path::to_path_buf).unwrap_or_else(|| {
+        Settings::find_workspace_config()
+            .unwrap_or_else(|| PathBuf::from(crate::init::local_dir_name()).join("settings.toml"))
+    });
+    match std::fs::read_to_string(&path) {
+        Ok(content) => match toml::from_str::<Settings>(&content) {
+            Ok(_) => checks.push(Check::ok(AREA, format!("{} parses", path.display()))),
+            Err(e) => checks.push(Check::fail(
+                AREA,
+                format!("{} is invalid: {}", path.display(), e.message()),
+                "fix the file, or regenerate it with 'codanna init --force'",
+            )),
+        },
+        Err(e) if e.kind() == std::io::ErrorKind::NotFound => checks.push(Check::warn(
+            AREA,
+            format!("{} not found; using the defaults", path.display()),
+            "codanna init",
+        )),
+        Err(e) => checks.push(Check::fail(
+            AREA,
+            format!("cannot read {}: {e}", path.display()),
+            format!("check the permissions of {}", path.display()),
+        )),
+    }
+
+    let indexed_paths = &config.indexing.indexed_paths;
+    if indexed_paths.is_empty() {
+        checks.push(Check::warn(
+            AREA,
+            "no directories are indexed",
+            "codanna add-dir <dir>, or codanna index <dir>",
+        ));
+    }
+    for dir in indexed_paths {
+        if dir.is_dir() {
+            checks.push(Check::ok(AREA, format!("indexes {}", dir.display())));
+        } else {
+            checks.push(Check::fail(
+                AREA,
+                format!("indexed directory {} does not exist", dir.display()),
+                format!("codanna remove-dir {}", dir.display()),
+            ));
+        }
+    }
+    checks
+}
+
+/// The index opens, its segments are intact and its counts agree; returns
+/// the index for the checks that read it
+fn check_index(config: &Settings, checks: &mut Vec<Check>) -> Option<IndexFacade> {
+    const AREA: &str = "index";
+    let index_path = &config.index_path;
+    if !index_path.join("tantivy").join("meta.json").exists() {
+        checks.push(Check::fail(
+            AREA,
+            format!("no index at {}", index_path.display()),
+            "codanna index",
+        ));
+        return None;
+    }
+
+    // Without the file, load() reads as an empty index
+    let metadata = match IndexMetadata::load(index_path) {
+        Ok(_) if !index_path.join("index.meta").exists() => None,
+        Ok(metadata) => Some(metadata),
+        Err(e) => {
+            checks.push(Check::warn(
+                AREA,
+                format!("index metadata is unreadable: {e}"),
+                "codanna index --force",
+            ));
+            None
+        }
+    };
+    if let Some(metadata) = &metadata {
+        if metadata.emission_version != Some(EMISSION_SEMANTICS_VERSION) {
+            checks.push(Check::warn(
+                AREA,
+                "the index was built by an older codanna and misses newer relationships",
+                "codanna index --force",
+            ));
+        }
+    }
+
+    let facade = match IndexPersistence::new(index_path.clone())
+        .load_facade_lite(Arc::new(config.clone()))
+    {
+        Ok(facade) => facade,
+        Err(e) => {
+            checks.push(Check::fail(
+                AREA,
+                format!("the index does not open: {e}"),
+                "codanna index --force",
+            ));
+            return None;
+        }
+    };
+
+    match facade.document_index().segment_health() {
+        Ok(health) if !health.corrupted_files.is_empty() => {
+            let files: Vec<String> = health
+                .corrupted_files
+                .iter()
+                .map(|file| file.display().to_string())
+                .collect();
+            checks.push(Check::fail(
+                AREA,
+                format!("corrupted index files: {}", files.join(", ")),
+                "codanna index --force",
+            ));
+        }
+        Ok(health) => {
+            checks.push(Check::ok(
+                AREA,
+                format!(
+                    "{} segments, {} documents, checksums match",
+                    health.segments, health.documents
+                ),
+            ));
+            // A tenth of the documents deleted is worth reclaiming
+            if health.deleted_documents * 10 > health.documents {
+                checks.push(Check::warn(
+                    AREA,
+                    format!(
+                        "{} deleted documents still take space",
+                        health.deleted_documents
+                    ),
+                    "codanna index compact",
+                ));
+            }
+        }
+        Err(e) => checks.push(Check::fail(
+            AREA,
+            format!("cannot read the index segments: {e}"),
+            "codanna index --force",
+        )),
+    }
+
+    let symbols = facade.symbol_count();
+    let files = facade.file_count();
+    if symbols == 0 {
+        checks.push(Check::warn(
+            AREA,
+            "the index holds no symbols",
+            "codanna index",
+        ));
+    } else {
+        checks.push(Check::ok(
+            AREA,
+            format!("{symbols} symbols across {files} files"),
+        ));
+    }
+    if let Some(metadata) = &metadata {
+        if metadata.symbol_count as usize != symbols || metadata.file_count != files {
+            checks.push(Check::warn(
+                AREA,
+                format!(
+                    "metadata records {} symbols across {} files",
+                    metadata.symbol_count, metadata.file_count
+                ),
+                "codanna index",
+            ));
+        }
+    }
+
+    let root = config.workspace_root.as_deref();
+    let missing: Vec<String> = facade
+        .get_indexed_files()
+        .into_iter()
+        .map(|(_, file)| file)
+        .filter(|file| {
+            let path = Path::new(file);
+            !match root {
+                Some(root) if path.is_relative() => root.join(path).exists(),
+                _ => path.exists(),
+            }
+        })
+        .collect();
+    if !missing.is_empty() {
+        checks.push(Check::warn(
+            AREA,
+            format!(
+                "{} indexed files no longer exist, e.g. {}",
+                missing.len(),
+                missing[0]
+            ),
+            "codanna index",
+        ));
+    }
+    Some(facade)
+}
+
+/// The embeddings were built by the configured model, match the symbols,
+/// and the model is at hand
+fn check_semantic(config: &Settings, facade: Option<&IndexFacade>, checks: &mut Vec<Check>) {
+    const AREA: &str = "semantic search";
+    let semantic = &config.semantic_search;
+    if !semantic.enabled {
+        checks.push(Check::ok(AREA, "disabled"));
+        return;
+    }
+
+    check_model(config, checks);
+
+    let path = config.index_path.join("semantic");
+    let metadata = match SemanticMetadata::load(&path) {
+        Ok(metadata) => metadata,
+        Err(_) => {
+            checks.push(Check::warn(
+                AREA,
+                "no embeddings have been built",
+                "codanna index --force",
+            ));
+            return;
+        }
+    };
+    let configured = configured_model_name(semantic);
+    if metadata.model_name != configured {
+        checks.push(Check::fail(
+            AREA,
+            format!(
+                "embeddings were built with '{}' but '{configured}' is configured",
+                metadata.model_name
+            ),
+            "codanna index --force",
+        ));
+    }
+    if let Some(dim) = semantic.remote_dim.filter(|_| semantic.is_remote()) {
+        if dim != metadata.dimension {
+            checks.push(Check::fail(
+                AREA,
+                format!(
+                    "embeddings have {} dimensions but remote_dim is {dim}",
+                    metadata.dimension
+                ),
+                "codanna index --force",
+            ));
+        }
+    }
+
+    // Read the vectors without a model, which may not be available
+    let search = match SimpleSemanticSearch::load_remote(&path) {
+        Ok(search) => search,
+        Err(e) => {
+            checks.push(Check::fail(
+                AREA,
+                format!("the embeddings do not load: {e}"),
+                "codanna index --force",
+            ));
+            return;
+        }
+    };
+    let ids: Vec<_> = search.embeddings().map(|(id, _)| id).collect();
+    if ids.len() != metadata.embedding_count {
+        checks.push(Check::warn(
+            AREA,
+            format!(
+                "{} embeddings stored but metadata records {}",
+                ids.len(),
+                metadata.embedding_count
+            ),
+            "codanna index --force",
+        ));
+    }
+    if let Some(facade) = facade {
+        let stale = ids
+            .iter()
+            .filter(|id| facade.get_symbol(**id).is_none())
+            .count();
+        if stale > 0 {
+            checks.push(Check::warn(
+                AREA,
+                format!("{stale} embeddings belong to symbols no longer indexed"),
+                "codanna index compact",
+            ));
+        }
+    }
+    checks.push(Check::ok(
+        AREA,
+        format!(
+            "{} embeddings of {} dimensions from '{}'",
+            ids.len(),
+            metadata.dimension,
+            metadata.model_name
+        ),
+    ));
+}
+
+/// The embedding model is known and downloaded, or a server is configured
+fn check_model(config: &Settings, checks: &mut Vec<Check>) {
+    const AREA: &str = "semantic search";
+    let semantic = &config.semantic_search;
+    if semantic.is_remote() {
+        match semantic.remote_endpoint() {
+            Some(url) => checks.push(Check::ok(AREA, format!("embeds remotely at {url}"))),
+            None => checks.push(Check::fail(
+                AREA,
+                "provider is http but no remote_url is set",
+                "set semantic_search.remote_url, or CODANNA_EMBED_URL",
+            )),
+        }
+        return;
+    }
+
+    let model = match crate::vector::parse_embedding_model(&semantic.model) {
+        Ok(model) => model,
+        Err(e) => {
+            checks.push(Check::fail(
+                AREA,
+                format!("unknown model '{}': {e}", semantic.model),
+                "set semantic_search.model to a model 'codanna init' lists",
+            ));
+            return;
+        }
+    };
+    let name = crate::vector::model_to_string(&model);
+    let Ok(info) = fastembed::TextEmbedding::get_model_info(&model) else {
+        return;
+    };
+    // Models are cached in the Hugging Face layout
+    let cache = crate::init::model_cache_dir();
+    let dir = cache.join(format!("models--{}", info.model_code.replace('/', "--")));
+    if dir.is_dir() {
+        checks.push(Check::ok(
+            AREA,
+            format!("model '{name}' is in {}", cache.display()),
+        ));
+    } else {
+        checks.push(Check::warn(
+            AREA,
+            format!(
+                "model '{name}' is not in {}; it downloads on first use",
+                cache.display()
+            ),
+            "on a machine without internet access, 'codanna models fetch' elsewhere and set semantic_search.model_path",
+        ));
+    }
+}
+
+/// The watcher can watch the project, and inotify allows a watch for each
+/// directory of indexed files
+fn check_watcher(config: &Settings, facade: Option<&IndexFacade>, checks: &mut Vec<Check>) {
+    use notify::{RecursiveMode, Watcher};
+
+    const AREA: &str = "file watcher";
+    if !config.file_watch.enabled {
+        checks.push(Check::ok(AREA, "disabled"));
+        return;
+    }
+    let root = config
+        .workspace_root
+        .clone()
+        .or_else(|| std::env::current_dir().ok())
+        .unwrap_or_else(|| PathBuf::from("."));
+    let watched = notify::recommended_watcher(|_: notify::Result<notify::Event>| {})
+        .and_then(|mut watcher| watcher.watch(&root, RecursiveMode::NonRecursive));
+    match watched {
+        Ok(()) => checks.push(Check::ok(AREA, format!("can watch {}", root.display()))),
+        Err(e) => {
+            checks.push(Check::fail(
+                AREA,
+                format!("cannot watch {}: {e}", root.display()),
+                "check the directory permissions, or set file_watch.enabled = false",
+            ));
+            return;
+        }
+    }
+
+    // The watcher watches the directory of each indexed file
+    let Some(facade) = facade else {
+        return;
+    };
+    let directories: BTreeSet<PathBuf> = facade
+        .get_indexed_files()
+        .into_iter()
+        .filter_map(|(_, file)| Path::new(&file).parent().map(Path::to_path_buf))
+        .collect();
+    check_watch_limit(directories.len(), checks);
+}
+
+#[cfg(target_os = "linux")]
+fn check_watch_limit(directories: usize, checks: &mut Vec<Check>) {
+    const AREA: &str = "file watcher";
+    let Some(limit) = std::fs::read_to_string("/proc/sys/fs/inotify/max_user_watches")
+        .ok()
+        .and_then(|limit| limit.trim().parse::<usize>().ok())
+    else {
+        return;
+    };
+    checks.push(watch_limit_check(AREA, directories, limit));
+}
+
+#[cfg(not(target_os = "linux"))]
+fn check_watch_limit(_directories: usize, _checks: &mut Vec<Check>) {}
+
+/// A warning when `directories` plus headroom exceed the inotify limit
+#[cfg(target_os = "linux")]
+fn watch_limit_check(area: &'static str, directories: usize, limit: usize) -> Check {
+    let needed = directories + INOTIFY_HEADROOM;
+    if needed <= limit {
+        return Check::ok(
+            area,
+            format!("{directories} directories to watch, inotify allows {limit}"),
+        );
+    }
+    let suggested = (needed * 2).next_power_of_two().max(524_288);
+    Check::warn(
+        area,
+        format!("{directories} directories to watch, but inotify allows only {limit}"),
+        format!("sudo sysctl fs.inotify.max_user_watches={suggested}"),
+    )
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_missing_index_fails_with_fix() {
+        let dir = tempfile::tempdir().unwrap();
+        let config = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let checks = diagnose(&config, Some(&dir.path().join("settings.toml")));
+        let settings = checks
+            .iter()
+            .find(|check| check.area == "settings")
+            .unwrap();
+        assert_eq!(settings.status, CheckStatus::Warn);
+        let index = checks.iter().find(|check| check.area == "index").unwrap();
+        assert_eq!(index.status, CheckStatus::Fail);
+        assert_eq!(index.fix.as_deref(), Some("codanna index"));
+    }
+
+    #[cfg(target_os = "linux")]
+    #[test]
+    fn test_watch_limit() {
+        assert_eq!(
+            watch_limit_check("file watcher", 100, 8192).status,
+            CheckStatus::Ok
+        );
+        let check = watch_limit_check("file watcher", 10_000, 8192);
+        assert_eq!(check.status, CheckStatus::Warn);
+        assert_eq!(
+            check.fix.as_deref(),
+            Some("sudo sysctl fs.inotify.max_user_watches=524288")
+        );
+    }
+}
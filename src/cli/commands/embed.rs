@@ -0,0 +1,486 @@
+//! Embed command - compute the embeddings an index run left to the background.
+//!
+//! With `semantic_search.background`, `codanna index` commits symbols and
+//! relationships without their embeddings and starts `codanna embed` in a
+//! process of its own. It embeds the documented symbols the semantic store
+//! has no vector for, saving as it goes, and records its progress in
+//! `semantic/queue.json` for `codanna embed --status`. Search and
+//! relationships work from the start; semantic search finds more symbols
+//! as the queue drains.
+//!
+//! One process embeds an index at a time. Each index run stops a running
+//! one before it writes, and starts another once it has saved.
+
+use std::collections::HashSet;
+use std::fs::OpenOptions;
+use std::io::Write;
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+use std::time::{Duration, Instant};
+
+use serde::{Deserialize, Serialize};
+
+use super::serve::{pid_is_alive, read_lock_pid};
+use crate::config::Settings;
+use crate::indexing::facade::{build_embedding_backend, configured_model_name};
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+use crate::semantic::{EmbeddingBackend, SemanticSearchError, SimpleSemanticSearch};
+use crate::storage::lease::WRITER_WAIT;
+use crate::storage::{IndexLease, IndexPersistence};
+use crate::{IndexError, IndexResult, Symbol, SymbolId};
+
+/// Symbols embedded per batch
+const BATCH_SIZE: usize = 256;
+
+/// Longest a run goes without saving what it embedded
+const SAVE_INTERVAL: Duration = Duration::from_secs(30);
+
+/// Progress of the last embedding run, in `semantic/queue.json`
+#[derive(Debug, Default, Clone, Serialize, Deserialize)]
+pub struct QueueStatus {
+    /// Process running the queue
+    pub pid: u32,
+    /// Symbols embedded plus those still pending
+    pub total: usize,
+    pub done: usize,
+    /// Symbols the backend returned no embedding for
+    pub failed: usize,
+    pub started_at: u64,
+    pub updated_at: u64,
+    pub finished: bool,
+}
+
+impl QueueStatus {
+    fn path(semantic_path: &Path) -> PathBuf {
+        semantic_path.join("queue.json")
+    }
+
+    pub fn load(semantic_path: &Path) -> Option<Self> {
+        let json = std::fs::read_to_string(Self::path(semantic_path)).ok()?;
+        serde_json::from_str(&json).ok()
+    }
+
+    fn save(&self, semantic_path: &Path) -> IndexResult<()> {
+        let json = serde_json::to_string_pretty(self)
+            .map_err(|e| IndexError::General(format!("Failed to serialize queue status: {e}")))?;
+        std::fs::write(Self::path(semantic_path), json)?;
+        Ok(())
+    }
+
+    /// Still being worked on by a live process
+    pub fn is_running(&self) -> bool {
+        !self.finished && pid_is_alive(self.pid)
+    }
+}
+
+/// PID lockfile held by the process embedding an index, removed on drop
+struct EmbedLockGuard {
+    path: PathBuf,
+}
+
+impl EmbedLockGuard {
+    fn lock_path(semantic_path: &Path) -> PathBuf {
+        semantic_path.join("embed.lock")
+    }
+
+    /// The lock, or the PID of the live process holding it
+    fn acquire(semantic_path: &Path) -> Result<Self, u32> {
+        let path = Self::lock_path(semantic_path);
+        for _ in 0..3 {
+            match OpenOptions::new().write(true).create_new(true).open(&path) {
+                Ok(mut f) => {
+                    let _ = f.write_all(std::process::id().to_string().as_bytes());
+                    return Ok(Self { path });
+                }
+                Err(e) if e.kind() == std::io::ErrorKind::AlreadyExists => {
+                    if let Some(pid) = read_lock_pid(&path) {
+                        if pid_is_alive(pid) {
+                            return Err(pid);
+                        }
+                    }
+                    // Left by a process that died; reclaim it
+                    let _ = std::fs::remove_file(&path);
+                }
+                Err(_) => break,
+            }
+        }
+        Err(read_lock_pid(&path).unwrap_or(0))
+    }
+}
+
+impl Drop for EmbedLockGuard {
+    fn drop(&mut self) {
+        let _ = std::fs::remove_file(&self.path);
+    }
+}
+
+fn semantic_path(config: &Settings) -> PathBuf {
+    config.index_path.join("semantic")
+}
+
+/// Run the embed command: report the queue with `status`, otherwise embed
+/// what the index is missing.
+pub fn run(status: bool, json: bool, config: &Settings) -> ExitCode {
+    if status {
+        return print_status(config, json);
+    }
+    if !config.semantic_search.enabled {
+        eprintln!("Semantic search is disabled: set semantic_search.enabled = true");
+        return ExitCode::ConfigError;
+    }
+    let path = semantic_path(config);
+    if !path.join("metadata.json").exists() {
+        eprintln!(
+            "No semantic index at {}. Run 'codanna index' first.",
+            path.display()
+        );
+        return ExitCode::NotFound;
+    }
+
+    let _lock = match EmbedLockGuard::acquire(&path) {
+        Ok(lock) => lock,
+        Err(pid) => {
+            eprintln!("Embeddings are already being computed by process {pid}");
+            return ExitCode::Success;
+        }
+    };
+
+    match embed_queue(config, &path) {
+        Ok(status) => {
+            eprintln!(
+                "Embedded {} of {} symbols ({} failed)",
+                status.done, status.total, status.failed
+            );
+            ExitCode::Success
+        }
+        Err(e) => {
+            eprintln!("Error: {e}");
+            ExitCode::GeneralError
+        }
+    }
+}
+
+/// Embed pending symbols until none are left, rereading the index after
+/// each pass for symbols an index run added in the meantime.
+fn embed_queue(config: &Settings, path: &Path) -> IndexResult<QueueStatus> {
+    let backend = build_embedding_backend(&config.semantic_search)?;
+    let settings = Arc::new(config.clone());
+    let persistence = IndexPersistence::new(config.index_path.clone());
+    let now = crate::utils::get_utc_timestamp();
+    let mut status = QueueStatus {
+        pid: std::process::id(),
+        started_at: now,
+        updated_at: now,
+        ..Default::default()
+    };
+    let mut failed = HashSet::new();
+
+    loop {
+        let symbols = persistence
+            .load_facade_lite(Arc::clone(&settings))?
+            .get_all_symbols();
+        let stored = SimpleSemanticSearch::load_remote(path)?;
+        let configured = configured_model_name(&config.semantic_search);
+        if let Some(metadata) = stored.metadata() {
+            if metadata.model_name != configured {
+                return Err(IndexError::SemanticSearch(
+                    SemanticSearchError::ModelChanged {
+                        index: metadata.model_name.clone(),
+                        configured,
+                    },
+                ));
+            }
+        }
+        if stored.dimensions() != backend.dimensions() {
+            return Err(IndexError::SemanticSearch(
+                SemanticSearchError::DimensionMismatch {
+                    expected: backend.dimensions(),
+                    actual: stored.dimensions(),
+                    suggestion: "Run 'codanna index' to re-embed".to_string(),
+                },
+            ));
+        }
+        let pending = pending_symbols(symbols, &stored, &failed);
+        drop(stored);
+
+        status.total = status.done + pending.len();
+        status.updated_at = crate::utils::get_utc_timestamp();
+        status.finished = pending.is_empty();
+        status.save(path)?;
+        if pending.is_empty() {
+            return Ok(status);
+        }
+        tracing::info!(target: "semantic", "embedding {} pending symbols", pending.len());
+
+        embed_pass(
+            &backend,
+            &pending,
+            &config.index_path,
+            path,
+            &mut status,
+            &mut failed,
+        )?;
+    }
+}
+
+/// Embed `pending` in batches, merging into the stored embeddings at least
+/// every `SAVE_INTERVAL`
+fn embed_pass(
+    backend: &EmbeddingBackend,
+    pending: &[(SymbolId, String, String)],
+    index_path: &Path,
+    path: &Path,
+    status: &mut QueueStatus,
+    failed: &mut HashSet<SymbolId>,
+) -> IndexResult<()> {
+    let mut unsaved = Vec::new();
+    let mut last_save = Instant::now();
+
+    for batch in pending.chunks(BATCH_SIZE) {
+        let items: Vec<(SymbolId, &str, &str)> = batch
+            .iter()
+            .map(|(id, doc, language)| (*id, doc.as_str(), language.as_str()))
+            .collect();
+        let embeddings = match backend.embed_parallel(&items) {
+            Ok(embeddings) => embeddings,
+            Err(e) => {
+                tracing::warn!(target: "semantic", "embedding batch failed: {e}");
+                Vec::new()
+            }
+        };
+
+        let embedded: HashSet<SymbolId> = embeddings.iter().map(|(id, _, _)| *id).collect();
+        for (id, _, _) in batch {
+            if !embedded.contains(id) {
+                failed.insert(*id);
+                status.failed += 1;
+            }
+        }
+        status.done += embeddings.len();
+        unsaved.extend(embeddings);
+
+        if last_save.elapsed() >= SAVE_INTERVAL {
+            merge_embeddings(index_path, path, std::mem::take(&mut unsaved))?;
+            last_save = Instant::now();
+        }
+        status.updated_at = crate::utils::get_utc_timestamp();
+        status.save(path)?;
+    }
+
+    merge_embeddings(index_path, path, unsaved)
+}
+
+/// Add `embeddings` to the store on disk, as it is now, holding the writer
+/// lease of the index at `index_path` so no index run saves in between
+fn merge_embeddings(
+    index_path: &Path,
+    path: &Path,
+    embeddings: Vec<(SymbolId, Vec<f32>, String)>,
+) -> IndexResult<()> {
+    if embeddings.is_empty() {
+        return Ok(());
+    }
+    let _lease = IndexLease::acquire(index_path, "codanna embed", WRITER_WAIT, |held| {
+        tracing::info!(target: "semantic", "{held}; waiting to save embeddings");
+    })
+    .map_err(|e| IndexError::General(e.to_string()))?;
+    let mut stored = SimpleSemanticSearch::load_remote(path)?;
+    stored.store_embeddings(embeddings);
+    stored.save(path)?;
+    Ok(())
+}
+
+/// Symbols `stored` has no embedding for, leaving out those that already
+/// failed
+fn pending_symbols(
+    symbols: Vec<Symbol>,
+    stored: &SimpleSemanticSearch,
+    failed: &HashSet<SymbolId>,
+) -> Vec<(SymbolId, String, String)> {
+    let mut pending = stored.missing_embeddings(symbols);
+    pending.retain(|(id, _, _)| !failed.contains(id));
+    pending
+}
+
+/// Start `codanna embed` for the index of `config` in a process of its own,
+/// logging to `semantic/embed.log`.
+pub fn spawn_background(cli_config: Option<&Path>, shard: Option<&str>, config: &Settings) {
+    let path = semantic_path(config);
+    let spawned = (|| {
+        let log = std::fs::File::create(path.join("embed.log"))?;
+        let mut command = std::process::Command::new(std::env::current_exe()?);
+        if let Some(config_path) = cli_config {
+            command.arg("--config").arg(config_path);
+        }
+        if let Some(name) = shard {
+            command.arg("--shard").arg(name);
+        }
+        if let Some(name) = &config.profile {
+            command.arg("--profile").arg(name);
+        }
+        command
+            .arg("embed")
+            .stdin(std::process::Stdio::null())
+            .stdout(std::process::Stdio::null())
+            .stderr(log);
+        // Out of the terminal's process group, so Ctrl-C there leaves it be
+        #[cfg(unix)]
+        std::os::unix::process::CommandExt::process_group(&mut command, 0);
+        command.spawn()
+    })();
+
+    match spawned {
+        Ok(child) => eprintln!(
+            "Computing embeddings in the background (pid {}): codanna embed --status",
+            child.id()
+        ),
+        Err(e) => eprintln!(
+            "Warning: could not start the background embedding process: {e}. Run 'codanna embed'."
+        ),
+    }
+}
+
+/// Stop the process embedding the index of `config`, if one runs, so that
+/// an index run is the only writer of the store. Its progress is saved up
+/// to its last merge, and the next `codanna embed` picks up the rest.
+pub fn stop_background(config: &Settings) {
+    use sysinfo::{Pid, ProcessRefreshKind, ProcessesToUpdate, System};
+
+    let lock_path = EmbedLockGuard::lock_path(&semantic_path(config));
+    let Some(pid) = read_lock_pid(&lock_path) else {
+        return;
+    };
+    if pid != std::process::id() && pid_is_alive(pid) {
+        let mut sys = System::new();
+        let pid = Pid::from_u32(pid);
+        sys.refresh_processes_specifics(
+            ProcessesToUpdate::Some(&[pid]),
+            true,
+            ProcessRefreshKind::nothing(),
+        );
+        if let Some(process) = sys.process(pid) {
+            tracing::debug!(target: "semantic", "stopping background embedding process {pid}");
+            if process.kill() {
+                let _ = process.wait();
+            }
+        }
+    }
+    let _ = std::fs::remove_file(&lock_path);
+}
+
+fn print_status(config: &Settings, json: bool) -> ExitCode {
+    let Some(status) = QueueStatus::load(&semantic_path(config)) else {
+        let message = "No embedding run recorded for this index";
+        if json {
+            let envelope = Envelope::<()>::not_found(message);
+            println!("{}", envelope.to_json().expect("envelope serialization"));
+        } else {
+            eprintln!("{message}");
+        }
+        return ExitCode::NotFound;
+    };
+
+    let running = status.is_running();
+    let state = if status.finished {
+        "finished".to_string()
+    } else if running {
+        format!("running in process {}", status.pid)
+    } else {
+        "stopped; run 'codanna embed' to continue".to_string()
+    };
+    let percent = if status.total == 0 {
+        100
+    } else {
+        status.done * 100 / status.total
+    };
+    let summary = format!(
+        "Embedded {} of {} symbols ({percent}%), {} failed: {state}",
+        status.done, status.total, status.failed
+    );
+
+    if json {
+        let envelope = Envelope::success(&status)
+            .with_entity_type(EntityType::Embeddings)
+            .with_count(status.done)
+            .with_message(summary);
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+    } else {
+        println!("{summary}");
+        if running {
+            let age = crate::utils::get_utc_timestamp().saturating_sub(status.updated_at);
+            println!("Last progress {age}s ago");
+        }
+    }
+    ExitCode::Success
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::SymbolKind;
+    use crate::parsing::LanguageId;
+    use crate::types::{FileId, Range};
+
+    fn symbol(id: u32, doc: Option<&str>) -> Symbol {
+        let symbol = Symbol::new(
+            SymbolId::new(id).unwrap(),
+            format!("symbol{id}"),
+            SymbolKind::Function,
+            FileId::new(1).unwrap(),
+            Range::new(0, 0, 0, 10),
+        )
+        .with_language_id(LanguageId::new("rust"));
+        match doc {
+            Some(doc) => symbol.with_doc(doc),
+            None => symbol,
+        }
+    }
+
+    #[test]
+    fn test_pending_symbols_are_documented_and_unembedded() {
+        let mut stored = SimpleSemanticSearch::new_empty(3, "test-model");
+        stored.store_embeddings(vec![(
+            SymbolId::new(2).unwrap(),
+            vec![0.1, 0.2, 0.3],
+            "rust".to_string(),
+        )]);
+        let failed = HashSet::from([SymbolId::new(4).unwrap()]);
+        let symbols = vec![
+            symbol(5, Some("Parse a file")),
+            symbol(1, Some("Serve requests")),
+            symbol(2, Some("Already embedded")),
+            symbol(3, None),
+            symbol(4, Some("Failed before")),
+        ];
+
+        let pending = pending_symbols(symbols, &stored, &failed);
+        let ids: Vec<u32> = pending.iter().map(|(id, _, _)| id.to_u32()).collect();
+        assert_eq!(ids, [1, 5]);
+        assert_eq!(pending[0].1, "Serve requests");
+        assert_eq!(pending[0].2, "rust");
+    }
+
+    #[test]
+    fn test_queue_status_round_trip() {
+        let dir = tempfile::tempdir().unwrap();
+        assert!(QueueStatus::load(dir.path()).is_none());
+
+        let status = QueueStatus {
+            pid: std::process::id(),
+            total: 10,
+            done: 4,
+            ..Default::default()
+        };
+        status.save(dir.path()).unwrap();
+        let loaded = QueueStatus::load(dir.path()).unwrap();
+        assert_eq!((loaded.total, loaded.done), (10, 4));
+        assert!(loaded.is_running());
+
+        let finished = QueueStatus {
+            finished: true,
+            ..loaded
+        };
+        assert!(!finished.is_running());
+    }
+}
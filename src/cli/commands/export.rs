@@ -0,0 +1,242 @@
+//! Export command - write index data in formats other tools read.
+
+use crate::SymbolKind;
+use crate::cli::ExportTarget;
+use crate::export::{
+    CypherScript, EdgeKind, GraphFilter, GraphFormat, LsifDump, ScipIndex, SymbolGraph, TagsFile,
+    TagsFormat,
+};
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+
+/// Run the export command.
+pub fn run(target: ExportTarget, indexer: &IndexFacade) -> ExitCode {
+    match target {
+        ExportTarget::Graph {
+            format,
+            path,
+            lang,
+            kind,
+            relations,
+            output,
+        } => {
+            let (format, kinds, relations) = match parse_graph_options(&format, &kind, &relations) {
+                Ok(parsed) => parsed,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            };
+
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                kinds,
+                relations,
+            };
+            let graph = SymbolGraph::build(indexer, &filter);
+            let rendered = format.render(&graph);
+
+            match output {
+                Some(file) => {
+                    if let Err(e) = std::fs::write(&file, rendered) {
+                        eprintln!("Error: failed to write {}: {e}", file.display());
+                        return ExitCode::IoError;
+                    }
+                    eprintln!(
+                        "Exported {} nodes and {} edges to {}",
+                        graph.nodes.len(),
+                        graph.edges.len(),
+                        file.display()
+                    );
+                }
+                None => print!("{rendered}"),
+            }
+            ExitCode::Success
+        }
+        ExportTarget::Scip { path, lang, output } => {
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                ..Default::default()
+            };
+            let index = ScipIndex::build(indexer, &filter, &project_root(indexer));
+
+            if let Err(e) = std::fs::write(&output, &index.bytes) {
+                eprintln!("Error: failed to write {}: {e}", output.display());
+                return ExitCode::IoError;
+            }
+            eprintln!(
+                "Exported {} symbols in {} documents to {}",
+                index.symbols,
+                index.documents,
+                output.display()
+            );
+            ExitCode::Success
+        }
+        ExportTarget::Lsif { path, lang, output } => {
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                ..Default::default()
+            };
+            let dump = LsifDump::build(indexer, &filter, &project_root(indexer));
+
+            if let Err(e) = std::fs::write(&output, &dump.text) {
+                eprintln!("Error: failed to write {}: {e}", output.display());
+                return ExitCode::IoError;
+            }
+            eprintln!(
+                "Exported {} symbols in {} documents to {}",
+                dump.symbols,
+                dump.documents,
+                output.display()
+            );
+            ExitCode::Success
+        }
+        ExportTarget::Tags {
+            format,
+            path,
+            lang,
+            output,
+        } => {
+            let format: TagsFormat = match format.parse() {
+                Ok(format) => format,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            };
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                ..Default::default()
+            };
+            let tags = TagsFile::build(indexer, &filter, &project_root(indexer), format);
+            let output = output.unwrap_or_else(|| format.default_file().into());
+
+            if let Err(e) = std::fs::write(&output, &tags.text) {
+                eprintln!("Error: failed to write {}: {e}", output.display());
+                return ExitCode::IoError;
+            }
+            eprintln!(
+                "Exported {} tags in {} files to {}",
+                tags.tags,
+                tags.files,
+                output.display()
+            );
+            ExitCode::Success
+        }
+        ExportTarget::Cypher {
+            path,
+            lang,
+            kind,
+            output,
+        } => {
+            let kinds = match kind
+                .iter()
+                .map(|kind| kind.trim().parse::<SymbolKind>())
+                .collect::<Result<_, _>>()
+            {
+                Ok(kinds) => kinds,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            };
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                kinds,
+                ..Default::default()
+            };
+            let script = CypherScript::build(indexer, &filter);
+
+            if let Err(e) = std::fs::write(&output, &script.text) {
+                eprintln!("Error: failed to write {}: {e}", output.display());
+                return ExitCode::IoError;
+            }
+            eprintln!(
+                "Exported {} symbols, {} relationships and {} files to {}",
+                script.symbols,
+                script.relationships,
+                script.files,
+                output.display()
+            );
+            eprintln!("Load it with: cypher-shell -f {}", output.display());
+            ExitCode::Success
+        }
+        ExportTarget::Sqlite { output, path, lang } => {
+            let filter = GraphFilter {
+                path,
+                language: lang.map(|lang| lang.to_lowercase()),
+                ..Default::default()
+            };
+            export_sqlite(indexer, &filter, &output)
+        }
+    }
+}
+
+#[cfg(feature = "sqlite-export")]
+fn export_sqlite(
+    indexer: &IndexFacade,
+    filter: &GraphFilter,
+    output: &std::path::Path,
+) -> ExitCode {
+    match crate::export::sqlite::export(indexer, filter, output) {
+        Ok(written) => {
+            eprintln!(
+                "Exported {} symbols, {} relationships and {} metrics of {} files to {}",
+                written.symbols,
+                written.relationships,
+                written.metrics,
+                written.files,
+                output.display()
+            );
+            ExitCode::Success
+        }
+        Err(e) => {
+            eprintln!("Error: {e}");
+            ExitCode::IoError
+        }
+    }
+}
+
+#[cfg(not(feature = "sqlite-export"))]
+fn export_sqlite(
+    _indexer: &IndexFacade,
+    _filter: &GraphFilter,
+    _output: &std::path::Path,
+) -> ExitCode {
+    eprintln!("SQLite export support is not compiled in.");
+    eprintln!("Please rebuild with: cargo build --features sqlite-export");
+    ExitCode::GeneralError
+}
+
+/// The workspace root, else the current directory: what exported paths are
+/// relative to.
+pub(crate) fn project_root(indexer: &IndexFacade) -> std::path::PathBuf {
+    indexer
+        .settings()
+        .workspace_root
+        .clone()
+        .unwrap_or_else(|| std::env::current_dir().unwrap_or_default())
+}
+
+/// Parse the format, kinds and relations of `export graph`.
+fn parse_graph_options(
+    format: &str,
+    kinds: &[String],
+    relations: &[String],
+) -> Result<(GraphFormat, Vec<SymbolKind>, Vec<EdgeKind>), Box<dyn std::error::Error>> {
+    let format = format.parse()?;
+    let kinds = kinds
+        .iter()
+        .map(|kind| kind.trim().parse())
+        .collect::<Result<_, _>>()?;
+    let relations = relations
+        .iter()
+        .map(|relation| relation.trim().parse())
+        .collect::<Result<_, _>>()?;
+    Ok((format, kinds, relations))
+}
@@ -0,0 +1,49 @@
+//! Import command - read index data other tools wrote.
+
+use crate::IndexPersistence;
+use crate::cli::ImportTarget;
+use crate::export::lsif;
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+
+/// Run the import command, saving the index when it changed.
+pub fn run(
+    target: ImportTarget,
+    indexer: &mut IndexFacade,
+    persistence: &IndexPersistence,
+) -> ExitCode {
+    match target {
+        ImportTarget::Lsif { file } => {
+            let text = match std::fs::read_to_string(&file) {
+                Ok(text) => text,
+                Err(e) => {
+                    eprintln!("Error: failed to read {}: {e}", file.display());
+                    return ExitCode::IoError;
+                }
+            };
+            let project_root = super::export::project_root(indexer);
+            let imported = match lsif::import(indexer, &text, &project_root) {
+                Ok(imported) => imported,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            };
+            if imported.added > 0 {
+                if let Err(e) = persistence.save_facade(indexer) {
+                    eprintln!("Error: failed to save index: {e}");
+                    return ExitCode::IoError;
+                }
+            }
+            eprintln!(
+                "Imported {} of {} references from {} ({} already indexed, {} not on indexed symbols)",
+                imported.added,
+                imported.references,
+                file.display(),
+                imported.known,
+                imported.unmatched
+            );
+            ExitCode::Success
+        }
+    }
+}
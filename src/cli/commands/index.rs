@@ -1,10 +1,21 @@
 //! Index command - index source code files and directories.
 
-use std::path::PathBuf;
+use std::collections::HashSet;
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+
+use serde::Serialize;
 
 use crate::cli::commands::directories::{SkipReason, add_paths_to_settings};
+use crate::cli::commands::embed;
 use crate::config::Settings;
+use crate::indexing::IndexStats;
+use crate::indexing::diagnostics::{FileDiagnostic, ParseReport};
 use crate::indexing::facade::IndexFacade;
+use crate::indexing::pipeline::metrics::format_bytes;
+use crate::indexing::webhooks::{self, IndexSummary};
+use crate::io::envelope::{EntityType, Envelope};
+use crate::snapshot;
 use crate::storage::IndexPersistence;
 use crate::types::SymbolKind;
 
@@ -15,7 +26,15 @@ pub struct IndexArgs {
     pub progress: bool,
     pub dry_run: bool,
     pub max_files: Option<usize>,
+    /// Only reindex what changed since this git revision
+    pub since: Option<String>,
+    /// Print the summary of the run and its diagnostics as JSON
+    pub json: bool,
     pub cli_config: Option<PathBuf>,
+    /// Shard being indexed, passed on to the background embedding process
+    pub shard: Option<String>,
+    /// The embedding model changed: embed every documented symbol
+    pub reembed: bool,
 }
 
 /// Run the index command.
@@ -35,13 +54,21 @@ pub fn run(
         progress,
         dry_run,
         max_files,
+        since,
+        json,
         cli_config,
+        shard,
+        reembed,
     } = args;
+    // Symbols are committed without embeddings; a process of their own
+    // computes them once the index is saved
+    let embed_in_background =
+        !dry_run && config.semantic_search.background && indexer.has_semantic_search();
 
     // Determine paths to index
     let paths_to_index = if !paths.is_empty() {
         // CLI paths provided - add them to settings.toml first
-        let config_path = if let Some(custom_path) = cli_config {
+        let config_path = if let Some(custom_path) = cli_config.clone() {
             custom_path
         } else {
             Settings::find_workspace_config().unwrap_or_else(|| {
@@ -98,10 +125,19 @@ pub fn run(
             match sync_made_changes {
                 Some(true) => {
                     // Sync added new directories, already indexed - save and return
+                    if reembed && !embed_in_background {
+                        embed_missing_symbols(indexer);
+                    }
                     if let Err(e) = persistence.save_facade(indexer) {
                         eprintln!("Error saving index: {e}");
                         std::process::exit(1);
                     }
+                    if embed_in_background {
+                        embed::spawn_background(cli_config.as_deref(), shard.as_deref(), config);
+                    }
+                    if json {
+                        print_run(IndexSummary::new(force), ParseReport::default());
+                    }
                     return;
                 }
                 Some(false) | None => {
@@ -116,26 +152,166 @@ pub fn run(
     };
 
     // Process each path, tracking total changes
+    let started = std::time::Instant::now();
+    let symbols_before = indexer.symbol_count();
+    let mut summary = IndexSummary::new(force);
     let mut total_indexed = 0usize;
+    let mut diagnostics = Vec::new();
     for path in &paths_to_index {
         if path.is_file() {
             if index_single_file(indexer, path, force) {
                 total_indexed += 1;
+                summary.files_indexed += 1;
             }
         } else if path.is_dir() {
-            total_indexed += index_directory(indexer, path, progress, dry_run, force, max_files);
+            let stats = index_directory(
+                indexer,
+                path,
+                progress,
+                dry_run,
+                force,
+                max_files,
+                since.as_deref(),
+            );
+            total_indexed += stats.files_indexed;
+            summary.add(&stats);
+            diagnostics.extend(stats.diagnostics);
         } else {
             eprintln!("Error: Path does not exist: {}", path.display());
             std::process::exit(1);
         }
     }
 
+    // The files unchanged since the last run still need their embeddings
+    // of the new model
+    if reembed && !embed_in_background {
+        embed_missing_symbols(indexer);
+    }
+
     // Only save if changes were made and not in dry-run mode
-    if !dry_run && total_indexed > 0 {
-        save_index(indexer, persistence, config);
+    if !dry_run && (total_indexed > 0 || reembed) {
+        save_index(indexer, persistence, config, json);
     } else if !dry_run && total_indexed == 0 {
         tracing::debug!(target: "indexing", "no changes detected, skipping save");
     }
+    // A run that parsed nothing leaves the last report standing
+    let report = ParseReport::new(force, total_indexed, diagnostics);
+    if !dry_run && (total_indexed > 0 || summary.files_failed > 0) {
+        if let Err(e) = report.save(&config.index_path) {
+            eprintln!("Warning: Could not save the parse report: {e}");
+        }
+    }
+    summary.set_symbols(symbols_before, indexer.symbol_count());
+    summary.duration_ms = started.elapsed().as_millis() as u64;
+    // Tell [[indexing.webhooks]] what the run changed, now that it is saved
+    if !dry_run && summary.changed() {
+        webhooks::notify(&config.indexing.webhooks, &summary);
+    }
+    if json {
+        print_run(summary, report);
+    }
+    // Also after a run without changes: the previous run's embeddings may
+    // not have finished
+    if embed_in_background {
+        embed::spawn_background(cli_config.as_deref(), shard.as_deref(), config);
+    }
+}
+
+/// Run the index command for a git revision.
+///
+/// The revision is indexed into a snapshot of its own, named after it unless
+/// `name` is given; the working tree and the main index are not touched.
+pub fn run_rev(rev: &str, name: Option<String>, progress: bool, config: &Settings) {
+    let repo = config
+        .workspace_root
+        .clone()
+        .unwrap_or_else(|| PathBuf::from("."));
+    let name = name.unwrap_or_else(|| snapshot::snapshot_name(rev));
+
+    eprintln!("Indexing {rev} into snapshot '{name}'");
+    match snapshot::create_snapshot(config, &repo, rev, &name, progress) {
+        Ok(info) => {
+            println!(
+                "Snapshot '{}' of {} ({}): {} symbols across {} files",
+                info.name,
+                info.rev,
+                info.short_commit(),
+                info.symbols,
+                info.files
+            );
+            println!(
+                "Snapshot saved to: {}",
+                snapshot::snapshots_dir(config).join(&info.name).display()
+            );
+        }
+        Err(e) => {
+            eprintln!("Error indexing {rev}: {e}");
+            std::process::exit(1);
+        }
+    }
+}
+
+/// Run `index --worker`: parse files for the run listening at `addr` until
+/// it has none left.
+pub fn run_worker(addr: &str, config: &Settings) {
+    eprintln!("Parsing files for the indexing run at {addr}");
+    match crate::indexing::pipeline::run_worker(addr, Arc::new(config.clone())) {
+        Ok(files) => println!("Parsed {files} files for {addr}"),
+        Err(e) => {
+            eprintln!("Error: {e}");
+            std::process::exit(1);
+        }
+    }
+}
+
+/// Run `index compact`: merge the index segments, drop the dead data and
+/// rewrite the index, reporting the space reclaimed.
+pub fn run_compact(indexer: &IndexFacade, persistence: &IndexPersistence, config: &Settings) {
+    if !persistence.exists() {
+        eprintln!("Error: no index at {}", config.index_path.display());
+        eprintln!("Run 'codanna index' to build it first.");
+        std::process::exit(1);
+    }
+    let size_before = dir_size(&config.index_path);
+
+    let (stats, embeddings_removed) = match indexer.compact() {
+        Ok(result) => result,
+        Err(e) => {
+            eprintln!("Error compacting index: {e}");
+            std::process::exit(1);
+        }
+    };
+    if let Err(e) = persistence.save_facade(indexer) {
+        eprintln!("Error: Could not save index: {e}");
+        std::process::exit(1);
+    }
+    let size_after = dir_size(&config.index_path);
+
+    println!(
+        "Removed {} orphaned symbols, {} dangling relationships, {} stale embeddings",
+        stats.orphaned_symbols, stats.dangling_relationships, embeddings_removed
+    );
+    println!(
+        "Purged {} deleted documents, segments: {} -> {}",
+        stats.purged_documents, stats.segments_before, stats.segments_after
+    );
+    println!(
+        "Reclaimed {} ({} -> {})",
+        format_bytes(size_before.saturating_sub(size_after)),
+        format_bytes(size_before),
+        format_bytes(size_after)
+    );
+}
+
+/// Total size of the files under `dir`
+pub(crate) fn dir_size(dir: &Path) -> u64 {
+    walkdir::WalkDir::new(dir)
+        .into_iter()
+        .filter_map(Result::ok)
+        .filter_map(|entry| entry.metadata().ok())
+        .filter(|metadata| metadata.is_file())
+        .map(|metadata| metadata.len())
+        .sum()
 }
 
 /// Index a single file. Returns true if file was indexed (not cached).
@@ -217,7 +393,7 @@ fn index_single_file(indexer: &mut IndexFacade, path: &PathBuf, force: bool) ->
     }
 }
 
-/// Index a directory. Returns the number of files indexed.
+/// Index a directory. Returns the stats of the run.
 fn index_directory(
     indexer: &mut IndexFacade,
     path: &PathBuf,
@@ -225,7 +401,8 @@ fn index_directory(
     dry_run: bool,
     force: bool,
     max_files: Option<usize>,
-) -> usize {
+    since: Option<&str>,
+) -> IndexStats {
     // Visual separator between directory cycles (use stderr to sync with progress bars)
     eprintln!();
 
@@ -241,7 +418,11 @@ fn index_directory(
     // Track this directory as indexed
     indexer.add_indexed_path(path);
 
-    match indexer.index_directory_with_options(path, progress, dry_run, force, max_files) {
+    let result = match since {
+        Some(since) => indexer.index_directory_since(path, since, progress),
+        None => indexer.index_directory_with_options(path, progress, dry_run, force, max_files),
+    };
+    match result {
         Ok(stats) => {
             // Deletions leave the progress trace at zero width; report them
             // explicitly so a cleanup-only run does not read as a no-op.
@@ -251,11 +432,37 @@ fn index_directory(
                     stats.files_removed, stats.symbols_removed
                 );
             }
+            if !stats.files_skipped.is_empty() {
+                eprintln!(
+                    "Skipped {} file(s) over their language's size limits:",
+                    stats.files_skipped.len()
+                );
+                for (path, reason) in stats.files_skipped.iter().take(10) {
+                    eprintln!("  {}: {reason}", path.display());
+                }
+                if stats.files_skipped.len() > 10 {
+                    eprintln!("  ... and {} more", stats.files_skipped.len() - 10);
+                }
+            }
+            let flagged = stats
+                .diagnostics
+                .iter()
+                .map(|d| &d.path)
+                .collect::<HashSet<_>>()
+                .len();
+            if flagged > 0 {
+                eprintln!(
+                    "{flagged} file(s) failed or parsed with errors (see 'codanna report parse-failures')"
+                );
+            }
+            if let Some(warning) = stats.doc_language_warning() {
+                eprintln!("{warning}");
+            }
             // Print message only when no work happened (pipeline trace handles the rest)
             if stats.files_indexed == 0 && stats.files_removed == 0 {
                 eprintln!("Index up to date: {}", path.display());
             }
-            stats.files_indexed
+            stats
         }
         Err(e) => {
             eprintln!("Error indexing directory {}: {e}", path.display());
@@ -273,7 +480,50 @@ fn index_directory(
     }
 }
 
-fn save_index(indexer: &mut IndexFacade, persistence: &IndexPersistence, config: &Settings) {
+/// Embed the documented symbols with no embedding of the configured model
+fn embed_missing_symbols(indexer: &mut IndexFacade) {
+    match indexer.embed_missing_symbols() {
+        Ok(count) => eprintln!("Embedded {count} doc comments"),
+        Err(e) => {
+            eprintln!("Error: Could not embed doc comments: {e}");
+            std::process::exit(1);
+        }
+    }
+}
+
+/// The summary of a run and the problems of its files, as `--json` prints them
+#[derive(Serialize)]
+struct RunOutput {
+    #[serde(flatten)]
+    summary: IndexSummary,
+    diagnostics: Vec<FileDiagnostic>,
+}
+
+fn print_run(summary: IndexSummary, report: ParseReport) {
+    let count = report.by_file().len();
+    let message = format!(
+        "Indexed {} files, {count} with problems",
+        summary.files_indexed
+    );
+    let output = RunOutput {
+        summary,
+        diagnostics: report.diagnostics,
+    };
+    let envelope = Envelope::success(&output)
+        .with_entity_type(EntityType::Diagnostic)
+        .with_count(count)
+        .with_duration_ms(output.summary.duration_ms)
+        .with_message(message);
+    println!("{}", envelope.to_json().expect("envelope serialization"));
+}
+
+/// Save the index; `json` keeps stdout for the JSON summary
+fn save_index(
+    indexer: &mut IndexFacade,
+    persistence: &IndexPersistence,
+    config: &Settings,
+    json: bool,
+) {
     // Save the index
     eprintln!(
         "\nSaving index with {} total symbols, {} total relationships...",
@@ -281,6 +531,7 @@ fn save_index(indexer: &mut IndexFacade, persistence: &IndexPersistence, config:
         indexer.relationship_count()
     );
     match persistence.save_facade(indexer) {
+        Ok(_) if json => eprintln!("Index saved to: {}", config.index_path.display()),
         Ok(_) => {
             println!("Index saved to: {}", config.index_path.display());
         }
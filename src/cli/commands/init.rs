@@ -31,7 +31,10 @@ pub fn run_init(force: bool) {
 
 /// Run config command - display current configuration.
 pub fn run_config(config: &Settings) {
-    println!("Current Configuration:");
+    match &config.profile {
+        Some(profile) => println!("Current Configuration (profile {profile}):"),
+        None => println!("Current Configuration:"),
+    }
     println!("{}", "=".repeat(50));
     match toml::to_string_pretty(config) {
         Ok(toml_str) => println!("{toml_str}"),
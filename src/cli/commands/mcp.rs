@@ -5,10 +5,14 @@ use crate::config::Settings;
 use crate::indexing::facade::IndexFacade;
 use crate::io::args::parse_positional_args;
 use crate::io::envelope::EntityType;
+use crate::mcp::pagination::Page;
 use crate::mcp::service::{
-    FindSymbolTarget, SymbolResolution, accepted_params_line, missing_param_message,
-    resolve_find_symbol_target, resolve_symbol_or_id, tool_param_spec,
+    CallRelation, FindSymbolTarget, SearchSymbolResult, SymbolResolution, accepted_params_line,
+    find_todos, find_unused_symbols, missing_param_message, resolve_find_symbol_target,
+    resolve_symbol_or_id, symbol_cards, tool_param_spec,
 };
+use crate::semantic::SemanticFilter;
+use crate::symbol::name_match::SearchMode;
 use serde::Serialize;
 
 /// Print a terminal envelope and exit with the envelope's own exit_code.
@@ -19,6 +23,20 @@ fn emit_envelope_and_exit<T: Serialize>(envelope: crate::io::envelope::Envelope<
     std::process::exit(envelope.exit_code.into());
 }
 
+/// A legacy JSON response, on one line under `--jsonl`, its data through
+/// the template under `--format`
+fn json_response_text<T: Serialize>(response: &T) -> String {
+    if let Some(template) = crate::io::template::output_template() {
+        let response = serde_json::to_value(response).unwrap();
+        return template.render_data(&response["data"]);
+    }
+    if crate::io::envelope::json_lines() {
+        serde_json::to_string(response).unwrap()
+    } else {
+        serde_json::to_string_pretty(response).unwrap()
+    }
+}
+
 /// Print an INVALID_QUERY envelope for an ambiguous symbol name and exit 2.
 /// Mirrors the MCP handlers' refuse-and-list policy: JSON mode must never
 /// merge relationships across same-named symbols.
@@ -50,6 +68,25 @@ fn exit_index_error(entity: EntityType, query: &str, error: impl std::fmt::Displ
     emit_envelope_and_exit(envelope);
 }
 
+/// Print an INVALID_QUERY envelope for an invalid argument value and exit 2.
+fn exit_invalid_query(query: &str, message: String) -> ! {
+    use crate::io::envelope::{Envelope, ResultCode};
+    let envelope: Envelope<()> = Envelope::error(ResultCode::InvalidQuery, message)
+        .with_entity_type(EntityType::SearchResult)
+        .with_query(query);
+    emit_envelope_and_exit(envelope);
+}
+
+/// The structured filters of a semantic search call; exits on an invalid one
+fn semantic_filter(
+    arguments: Option<&serde_json::Map<String, serde_json::Value>>,
+    query: &str,
+) -> SemanticFilter {
+    let arg = |key: &str| arguments.and_then(|m| m.get(key)).and_then(|v| v.as_str());
+    SemanticFilter::parse(arg("lang"), arg("kind"), arg("path"), arg("visibility"))
+        .unwrap_or_else(|e| exit_invalid_query(query, e))
+}
+
 /// Reject an invalid argument set: INVALID_QUERY envelope (JSON) or stderr
 /// message (text), exit 2 in both modes. One emitter for missing required
 /// params and unknown keys alike — the two failure directions of the same
@@ -110,89 +147,248 @@ struct SemanticSearchInfo {
     updated: Option<String>,
 }
 
-/// Flattened call/caller info combining symbol with call site metadata.
-/// Avoids tuple waste like `[[symbol, null], ...]` in JSON output.
-#[derive(Debug, Serialize)]
-struct CallRelation {
-    #[serde(flatten)]
-    symbol: Symbol,
-    /// Line number of the call site (1-indexed)
-    #[serde(skip_serializing_if = "Option::is_none")]
-    call_line: Option<u32>,
-    /// Column of the call site
-    #[serde(skip_serializing_if = "Option::is_none")]
-    call_column: Option<u16>,
-}
+/// Tools `codanna mcp` runs
+pub(crate) const KNOWN_TOOLS: &[&str] = &[
+    "find_symbol",
+    "get_calls",
+    "find_callers",
+    "get_call_hierarchy",
+    "analyze_impact",
+    "get_type_hierarchy",
+    "get_file_outline",
+    "impact_of_change",
+    "find_tests",
+    "find_similar_symbols",
+    "find_unused_symbols",
+    "find_todos",
+    "get_index_info",
+    "search_symbols",
+    "query_symbols",
+    "semantic_search_docs",
+    "semantic_search_with_context",
+    "search_documents",
+];
+
+/// Tools that embed their query, so need the embedding model loaded
+pub const SEMANTIC_TOOLS: &[&str] = &[
+    "semantic_search_docs",
+    "semantic_search_with_context",
+    "find_similar_symbols",
+];
+
+/// Tools whose results `--relevant` and `--irrelevant` mark
+pub const FEEDBACK_TOOLS: &[&str] = &["semantic_search_docs", "semantic_search_with_context"];
+
+/// Fold the positional arguments of `codanna mcp <tool>` into its argument
+/// map: the first one is the tool's main parameter, `key:value` pairs the
+/// others, typed as numbers or booleans when they parse as one.
+pub(crate) fn add_positional_arguments(
+    tool: &str,
+    positional: &[String],
+    args_map: &mut serde_json::Map<String, serde_json::Value>,
+) {
+    // The query language has key:value terms of its own: every argument
+    // but the tool's own options is part of the query
+    if tool == "query_symbols" {
+        let mut terms = Vec::new();
+        for arg in positional {
+            let option = arg
+                .split_once(':')
+                .filter(|(key, _)| matches!(*key, "limit" | "page_size" | "cursor"));
+            if let Some((key, value)) = option {
+                let value = value.parse::<u64>().map_or_else(
+                    |_| serde_json::Value::String(value.to_string()),
+                    serde_json::Value::from,
+                );
+                args_map.insert(key.to_string(), value);
+            } else if arg.contains(char::is_whitespace) && !arg.contains('"') {
+                terms.push(format!("\"{arg}\""));
+            } else {
+                terms.push(arg.clone());
+            }
+        }
+        if !terms.is_empty() {
+            args_map.insert(
+                "query".to_string(),
+                serde_json::Value::String(terms.join(" ")),
+            );
+        }
+        return;
+    }
 
-/// Symbol info extracted from search result for consistent JSON shape.
-/// Matches the nested `symbol: {...}` pattern used by semantic_search_docs.
-#[derive(Debug, Serialize)]
-struct SymbolInfo {
-    id: crate::types::SymbolId,
-    name: String,
-    kind: crate::types::SymbolKind,
-    file_path: String,
-    line: u32,
-    column: u16,
-    #[serde(skip_serializing_if = "Option::is_none")]
-    doc_comment: Option<String>,
-    #[serde(skip_serializing_if = "Option::is_none")]
-    signature: Option<String>,
-    module_path: String,
-    #[serde(skip_serializing_if = "Option::is_none")]
-    language_id: Option<String>,
-}
+    // Use the unified parser from args.rs
+    let (first_positional, params) = parse_positional_args(positional);
 
-/// Search result with nested symbol for consistent JSON output.
-/// Standardizes on `symbol: {...}` rather than flat `symbol_id: ...`.
-#[derive(Debug, Serialize)]
-struct SearchSymbolResult {
-    symbol: SymbolInfo,
-    score: f32,
-    highlights: Vec<crate::storage::tantivy::TextHighlight>,
-    #[serde(skip_serializing_if = "Option::is_none")]
-    context: Option<String>,
-}
+    // Handle the first positional argument based on tool type
+    if let Some(pos_arg) = first_positional {
+        match tool {
+            "find_symbol" => {
+                args_map.insert(
+                    "name".to_string(),
+                    serde_json::Value::String(pos_arg.clone()),
+                );
+            }
+            "get_calls" | "find_callers" | "get_call_hierarchy" => {
+                args_map.insert(
+                    "function_name".to_string(),
+                    serde_json::Value::String(pos_arg.clone()),
+                );
+            }
+            "analyze_impact" | "impact_of_change" | "find_tests" | "find_similar_symbols" => {
+                args_map.insert(
+                    "symbol_name".to_string(),
+                    serde_json::Value::String(pos_arg.clone()),
+                );
+            }
+            "get_type_hierarchy" => {
+                args_map.insert(
+                    "type_name".to_string(),
+                    serde_json::Value::String(pos_arg.clone()),
+                );
+            }
+            "get_file_outline" | "find_unused_symbols" | "find_todos" => {
+                args_map.insert(
+                    "path".to_string(),
+                    serde_json::Value::String(pos_arg.clone()),
+                );
+            }
+            "semantic_search_docs" | "semantic_search_with_context" | "search_documents" => {
+                args_map.insert(
+                    "query".to_string(),
+                    serde_json::Value::String(pos_arg.clone()),
+                );
+            }
+            "search_symbols" => {
+                args_map.insert(
+                    "query".to_string(),
+                    serde_json::Value::String(pos_arg.clone()),
+                );
+            }
+            _ => {
+                eprintln!("Warning: Unknown tool '{tool}', ignoring positional argument");
+            }
+        }
+    }
 
-impl From<crate::storage::tantivy::SearchResult> for SearchSymbolResult {
-    fn from(sr: crate::storage::tantivy::SearchResult) -> Self {
-        Self {
-            symbol: SymbolInfo {
-                id: sr.symbol_id,
-                name: sr.name,
-                kind: sr.kind,
-                file_path: sr.file_path,
-                line: sr.line,
-                column: sr.column,
-                doc_comment: sr.doc_comment,
-                signature: sr.signature,
-                module_path: sr.module_path,
-                language_id: sr.language_id,
-            },
-            score: sr.score,
-            highlights: sr.highlights,
-            context: sr.context,
+    // Special handling: find_symbol supports symbol_id:XXX as positional
+    // If symbol_id is in params but name wasn't set, use it as the name
+    if tool == "find_symbol" && !args_map.contains_key("name") {
+        if let Some(id) = params.get("symbol_id") {
+            args_map.insert(
+                "name".to_string(),
+                serde_json::Value::String(format!("symbol_id:{id}")),
+            );
         }
     }
+
+    // Add all key:value pairs from params
+    for (key, value) in params {
+        // Try to parse as number first, then boolean, fallback to string
+        let json_value = if let Ok(n) = value.parse::<i64>() {
+            serde_json::Value::Number(n.into())
+        } else if let Ok(f) = value.parse::<f64>() {
+            serde_json::Value::Number(
+                serde_json::Number::from_f64(f).unwrap_or_else(|| serde_json::Number::from(0)),
+            )
+        } else if let Ok(b) = value.parse::<bool>() {
+            serde_json::Value::Bool(b)
+        } else {
+            serde_json::Value::String(value)
+        };
+        args_map.insert(key, json_value);
+    }
 }
 
-/// Run the MCP direct tool invocation command.
-pub async fn run(
-    tool: String,
-    positional: Vec<String>,
-    args: Option<String>,
+/// The exit code of a text-mode call: 1 when its target is not found, 2
+/// when it is ambiguous, from the resolution the JSON envelopes use. None
+/// when the call names no target.
+pub(crate) fn text_exit_code(
+    facade: &IndexFacade,
+    tool: &str,
+    arguments: &Option<serde_json::Map<String, serde_json::Value>>,
+) -> Option<i32> {
+    let code = match tool {
+        "find_symbol" => {
+            let name = arguments
+                .as_ref()
+                .and_then(|m| m.get("name"))
+                .and_then(|v| v.as_str())
+                .expect("required param validated upstream");
+            let lang = arguments
+                .as_ref()
+                .and_then(|m| m.get("lang"))
+                .and_then(|v| v.as_str());
+            let found = match resolve_find_symbol_target(facade, name, lang) {
+                FindSymbolTarget::Symbols { symbols, .. } => !symbols.is_empty(),
+                FindSymbolTarget::InvalidId(_) => false,
+            };
+            if found { 0 } else { 1 }
+        }
+        "get_calls"
+        | "find_callers"
+        | "get_call_hierarchy"
+        | "analyze_impact"
+        | "get_type_hierarchy"
+        | "impact_of_change"
+        | "find_tests"
+        | "find_similar_symbols" => {
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
+            let name_key = match tool {
+                "analyze_impact" | "impact_of_change" | "find_tests" | "find_similar_symbols" => {
+                    "symbol_name"
+                }
+                "get_type_hierarchy" => "type_name",
+                _ => "function_name",
+            };
+            let symbol_name = arguments
+                .as_ref()
+                .and_then(|m| m.get(name_key))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            match resolve_symbol_or_id(facade, symbol_id, symbol_name) {
+                SymbolResolution::Resolved { .. } => 0,
+                SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => 1,
+                SymbolResolution::Ambiguous { .. } => 2,
+                SymbolResolution::MissingParam => return None,
+            }
+        }
+        "get_file_outline" => {
+            let path = arguments
+                .as_ref()
+                .and_then(|m| m.get("path"))
+                .and_then(|v| v.as_str())
+                .expect("required param validated upstream");
+            match facade.find_indexed_files(path).len() {
+                0 => 1,
+                1 => 0,
+                _ => 2,
+            }
+        }
+        _ => 0,
+    };
+    Some(code)
+}
+
+/// The arguments of a call of `tool`, from `--args` and the positional
+/// arguments, checked against what the tool accepts. Exits on an unknown
+/// tool or invalid arguments.
+fn tool_arguments(
+    tool: &str,
+    positional: &[String],
+    args: Option<&str>,
     json: bool,
-    fields: Option<Vec<String>>,
-    facade: IndexFacade,
-    config: &Settings,
-) {
+) -> Option<serde_json::Map<String, serde_json::Value>> {
     // Build arguments from both positional and --args
-    let mut arguments = if let Some(args_str) = &args {
+    let mut arguments = if let Some(args_str) = args {
         // Parse JSON arguments if provided (backward compatibility)
         match serde_json::from_str::<serde_json::Value>(args_str) {
             Ok(serde_json::Value::Object(map)) => Some(map),
-            Ok(_) => exit_invalid_args(&tool, "Arguments must be a JSON object", &[], json),
-            Err(e) => exit_invalid_args(&tool, &format!("Failed to parse --args: {e}"), &[], json),
+            Ok(_) => exit_invalid_args(tool, "Arguments must be a JSON object", &[], json),
+            Err(e) => exit_invalid_args(tool, &format!("Failed to parse --args: {e}"), &[], json),
         }
     } else {
         // Start with empty map if no --args
@@ -202,78 +398,7 @@ pub async fn run(
     // Process positional arguments using unified parser
     if !positional.is_empty() {
         if let Some(ref mut args_map) = arguments {
-            // Use the unified parser from args.rs
-            let (first_positional, params) = parse_positional_args(&positional);
-
-            // Handle the first positional argument based on tool type
-            if let Some(pos_arg) = first_positional {
-                match tool.as_str() {
-                    "find_symbol" => {
-                        args_map.insert(
-                            "name".to_string(),
-                            serde_json::Value::String(pos_arg.clone()),
-                        );
-                    }
-                    "get_calls" | "find_callers" => {
-                        args_map.insert(
-                            "function_name".to_string(),
-                            serde_json::Value::String(pos_arg.clone()),
-                        );
-                    }
-                    "analyze_impact" => {
-                        args_map.insert(
-                            "symbol_name".to_string(),
-                            serde_json::Value::String(pos_arg.clone()),
-                        );
-                    }
-                    "semantic_search_docs"
-                    | "semantic_search_with_context"
-                    | "search_documents" => {
-                        args_map.insert(
-                            "query".to_string(),
-                            serde_json::Value::String(pos_arg.clone()),
-                        );
-                    }
-                    "search_symbols" => {
-                        args_map.insert(
-                            "query".to_string(),
-                            serde_json::Value::String(pos_arg.clone()),
-                        );
-                    }
-                    _ => {
-                        eprintln!("Warning: Unknown tool '{tool}', ignoring positional argument");
-                    }
-                }
-            }
-
-            // Special handling: find_symbol supports symbol_id:XXX as positional
-            // If symbol_id is in params but name wasn't set, use it as the name
-            if tool == "find_symbol" && !args_map.contains_key("name") {
-                if let Some(id) = params.get("symbol_id") {
-                    args_map.insert(
-                        "name".to_string(),
-                        serde_json::Value::String(format!("symbol_id:{id}")),
-                    );
-                }
-            }
-
-            // Add all key:value pairs from params
-            for (key, value) in params {
-                // Try to parse as number first, then boolean, fallback to string
-                let json_value = if let Ok(n) = value.parse::<i64>() {
-                    serde_json::Value::Number(n.into())
-                } else if let Ok(f) = value.parse::<f64>() {
-                    serde_json::Value::Number(
-                        serde_json::Number::from_f64(f)
-                            .unwrap_or_else(|| serde_json::Number::from(0)),
-                    )
-                } else if let Ok(b) = value.parse::<bool>() {
-                    serde_json::Value::Bool(b)
-                } else {
-                    serde_json::Value::String(value)
-                };
-                args_map.insert(key, json_value);
-            }
+            add_positional_arguments(tool, positional, args_map);
         }
     }
 
@@ -281,19 +406,8 @@ pub async fn run(
     let arguments = arguments.filter(|map| !map.is_empty());
 
     // Validate the tool name up front: JSON mode never reaches the dispatch
-    // match below, so its unknown-tool arm cannot cover this.
-    const KNOWN_TOOLS: &[&str] = &[
-        "find_symbol",
-        "get_calls",
-        "find_callers",
-        "analyze_impact",
-        "get_index_info",
-        "search_symbols",
-        "semantic_search_docs",
-        "semantic_search_with_context",
-        "search_documents",
-    ];
-    if !KNOWN_TOOLS.contains(&tool.as_str()) {
+    // match of `run`, so its unknown-tool arm cannot cover this.
+    if !KNOWN_TOOLS.contains(&tool) {
         if json {
             use crate::io::exit_code::ExitCode;
             use crate::io::format::JsonResponse;
@@ -301,14 +415,14 @@ pub async fn run(
                 ExitCode::GeneralError,
                 &format!("Unknown tool: {tool}"),
                 vec![
-                    "Available tools: find_symbol, get_calls, find_callers, analyze_impact, get_index_info, search_symbols, semantic_search_docs, semantic_search_with_context, search_documents",
+                    "Available tools: find_symbol, get_calls, find_callers, get_call_hierarchy, analyze_impact, get_type_hierarchy, get_file_outline, impact_of_change, find_tests, find_similar_symbols, find_unused_symbols, find_todos, get_index_info, search_symbols, query_symbols, semantic_search_docs, semantic_search_with_context, search_documents",
                 ],
             );
-            println!("{}", serde_json::to_string_pretty(&response).unwrap());
+            println!("{}", json_response_text(&response));
         } else {
             eprintln!("Unknown tool: {tool}");
             eprintln!(
-                "Available tools: find_symbol, get_calls, find_callers, analyze_impact, get_index_info, search_symbols, semantic_search_docs, semantic_search_with_context, search_documents"
+                "Available tools: find_symbol, get_calls, find_callers, get_call_hierarchy, analyze_impact, get_type_hierarchy, get_file_outline, impact_of_change, find_tests, find_similar_symbols, find_unused_symbols, find_todos, get_index_info, search_symbols, query_symbols, semantic_search_docs, semantic_search_with_context, search_documents"
             );
         }
         std::process::exit(1);
@@ -320,7 +434,7 @@ pub async fn run(
     // and missing required params error as INVALID_QUERY, exit 2.
     let mut arguments = arguments;
     {
-        let (accepted, requires_one_of) = tool_param_spec(&tool);
+        let (accepted, requires_one_of) = tool_param_spec(tool);
 
         // `depth:` is a documented alias of `max_depth:` on analyze_impact —
         // the envelope's own meta field is named `depth`, so the surface must
@@ -330,7 +444,7 @@ pub async fn run(
                 if let Some(depth) = map.remove("depth") {
                     if map.contains_key("max_depth") {
                         exit_invalid_args(
-                            &tool,
+                            tool,
                             "analyze_impact accepts either 'depth' or 'max_depth', not both",
                             accepted,
                             json,
@@ -345,7 +459,7 @@ pub async fn run(
             for key in map.keys() {
                 if !accepted.contains(&key.as_str()) {
                     exit_invalid_args(
-                        &tool,
+                        tool,
                         &format!("Unknown parameter '{key}' for {tool}"),
                         accepted,
                         json,
@@ -359,179 +473,834 @@ pub async fn run(
                 .as_ref()
                 .is_some_and(|map| requires_one_of.iter().any(|k| map.contains_key(*k)));
             if !satisfied {
-                exit_invalid_args(&tool, &missing_param_message(&tool), accepted, json);
+                exit_invalid_args(tool, &missing_param_message(tool), accepted, json);
             }
         }
     }
-    let arguments = arguments;
+    arguments
+}
 
-    // Collect data for find_symbol if JSON output is requested
-    let find_symbol_data = if json && tool == "find_symbol" {
-        let name = arguments
-            .as_ref()
-            .and_then(|m| m.get("name"))
-            .and_then(|v| v.as_str());
-        let language = arguments
-            .as_ref()
-            .and_then(|m| m.get("lang"))
-            .and_then(|v| v.as_str());
+/// Call `tool` on `server` with the arguments of a text-mode call, as
+/// checked by [`tool_arguments`]
+pub(crate) async fn call_tool_text(
+    server: &crate::mcp::CodeIntelligenceServer,
+    tool: &str,
+    arguments: &Option<serde_json::Map<String, serde_json::Value>>,
+) -> Result<rmcp::model::CallToolResult, rmcp::model::ErrorData> {
+    use crate::mcp::*;
+    use rmcp::handler::server::wrapper::Parameters;
 
-        if let Some(symbol_name) = name {
-            // One resolution policy with the MCP handler: the symbol_id:
-            // form resolves by id here exactly as it does in text mode.
-            let symbols = match resolve_find_symbol_target(&facade, symbol_name, language) {
-                FindSymbolTarget::Symbols { symbols, .. } => symbols,
-                // Non-numeric id: nothing to look up; renders the
-                // not_found envelope exactly like an unmatched name.
-                FindSymbolTarget::InvalidId(_) => Vec::new(),
-            };
-            if !symbols.is_empty() {
-                use crate::symbol::context::ContextIncludes;
-                let mut results = Vec::new();
-
-                for symbol in symbols {
-                    // Get full context with callers using the same approach as MCP
-                    let context =
-                        facade.get_symbol_context(symbol.id, ContextIncludes::SYMBOL_CARD);
-
-                    // Build result with context if available
-                    if let Some(ctx) = context {
-                        results.push(ctx);
-                    } else {
-                        // Fallback: create minimal context
-                        let file_path = facade
-                            .get_file_path(symbol.file_id)
-                            .unwrap_or_else(|| "unknown".to_string());
-
-                        results.push(crate::symbol::context::SymbolContext {
-                            symbol,
-                            file_path,
-                            relationships: Default::default(),
-                        });
-                    }
-                }
-                Some(results)
-            } else {
-                Some(Vec::new())
-            }
-        } else {
-            None
+    let call_depth = arguments
+        .as_ref()
+        .and_then(|m| m.get("depth"))
+        .and_then(|v| v.as_u64())
+        .unwrap_or(1)
+        .max(1) as usize;
+    let page_size = arguments
+        .as_ref()
+        .and_then(|m| m.get("page_size"))
+        .and_then(|v| v.as_u64())
+        .map(|n| n as u32);
+    let cursor = arguments
+        .as_ref()
+        .and_then(|m| m.get("cursor"))
+        .map(|v| v.as_str().map_or_else(|| v.to_string(), str::to_string));
+
+    match tool {
+        "find_symbol" => {
+            let name = arguments
+                .as_ref()
+                .and_then(|m| m.get("name"))
+                .and_then(|v| v.as_str())
+                .expect("required param validated upstream");
+            let lang = arguments
+                .as_ref()
+                .and_then(|m| m.get("lang"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            server
+                .find_symbol(Parameters(FindSymbolRequest {
+                    name: name.to_string(),
+                    lang,
+                    project: None,
+                }))
+                .await
         }
-    } else {
-        None
-    };
+        "get_calls" => {
+            let function_name = arguments
+                .as_ref()
+                .and_then(|m| m.get("function_name"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
 
-    // Collect data for get_calls if JSON output is requested.
-    // Resolution goes through the shared service layer: ambiguous names
-    // refuse-and-list (exit 2) exactly like the MCP handler, never aggregate.
-    let get_calls_data = if json && tool == "get_calls" {
-        let symbol_id = arguments
-            .as_ref()
-            .and_then(|m| m.get("symbol_id"))
-            .and_then(|v| v.as_u64())
-            .map(|id| id as u32);
-        let function_name = arguments
-            .as_ref()
-            .and_then(|m| m.get("function_name"))
-            .and_then(|v| v.as_str())
-            .map(|s| s.to_string());
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
 
-        use crate::symbol::context::ContextIncludes;
-        match resolve_symbol_or_id(&facade, symbol_id, function_name) {
-            SymbolResolution::Resolved { symbol, .. } => {
-                let mut all_calls = Vec::new();
-                if let Some(ctx) = facade.get_symbol_context(symbol.id, ContextIncludes::CALLS) {
-                    if let Some(calls) = ctx.relationships.calls {
-                        for (called, metadata) in calls {
-                            all_calls.push(CallRelation {
-                                symbol: called,
-                                call_line: metadata.as_ref().and_then(|m| m.line).map(|l| l + 1),
-                                call_column: metadata.as_ref().and_then(|m| m.column),
-                            });
-                        }
-                    }
-                }
-                Some(all_calls)
-            }
-            SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => None,
-            SymbolResolution::Ambiguous { name, candidates } => {
-                exit_ambiguous(EntityType::Calls, &name, candidates)
-            }
-            SymbolResolution::MissingParam => exit_invalid_args(
-                &tool,
-                &missing_param_message(&tool),
-                tool_param_spec(&tool).0,
-                json,
-            ),
+            server
+                .get_calls(Parameters(GetCallsRequest {
+                    function_name,
+                    symbol_id,
+                    depth: call_depth as u32,
+                    page_size,
+                    cursor: cursor.clone(),
+                    project: None,
+                }))
+                .await
         }
-    } else {
-        None
-    };
+        "find_callers" => {
+            let function_name = arguments
+                .as_ref()
+                .and_then(|m| m.get("function_name"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
 
-    // Collect data for find_callers if JSON output is requested.
-    // Same shared resolution policy as get_calls: refuse-and-list on
-    // ambiguity, never merge callers of unrelated same-named symbols.
-    let find_callers_data = if json && tool == "find_callers" {
-        let symbol_id = arguments
-            .as_ref()
-            .and_then(|m| m.get("symbol_id"))
-            .and_then(|v| v.as_u64())
-            .map(|id| id as u32);
-        let function_name = arguments
-            .as_ref()
-            .and_then(|m| m.get("function_name"))
-            .and_then(|v| v.as_str())
-            .map(|s| s.to_string());
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
 
-        match resolve_symbol_or_id(&facade, symbol_id, function_name) {
-            SymbolResolution::Resolved { symbol, .. } => {
-                let callers = facade.get_calling_functions_with_metadata(symbol.id);
-                let all_callers: Vec<_> = callers
-                    .into_iter()
-                    .map(|(caller, metadata)| CallRelation {
-                        symbol: caller,
-                        call_line: metadata.as_ref().and_then(|m| m.line).map(|l| l + 1),
-                        call_column: metadata.as_ref().and_then(|m| m.column),
-                    })
-                    .collect();
-                Some(all_callers)
-            }
-            SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => None,
-            SymbolResolution::Ambiguous { name, candidates } => {
-                exit_ambiguous(EntityType::Callers, &name, candidates)
-            }
-            SymbolResolution::MissingParam => exit_invalid_args(
-                &tool,
-                &missing_param_message(&tool),
-                tool_param_spec(&tool).0,
-                json,
-            ),
+            server
+                .find_callers(Parameters(FindCallersRequest {
+                    function_name,
+                    symbol_id,
+                    depth: call_depth as u32,
+                    page_size,
+                    cursor: cursor.clone(),
+                    project: None,
+                }))
+                .await
         }
-    } else {
-        None
-    };
-
-    // Collect data for analyze_impact if JSON output is requested
-    let analyze_impact_data = if json && tool == "analyze_impact" {
-        let symbol_id = arguments
-            .as_ref()
-            .and_then(|m| m.get("symbol_id"))
-            .and_then(|v| v.as_u64())
-            .map(|id| id as u32);
-        let symbol_name = arguments
-            .as_ref()
-            .and_then(|m| m.get("symbol_name"))
-            .and_then(|v| v.as_str());
+        "analyze_impact" => {
+            let symbol_name = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_name"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
 
-        match resolve_symbol_or_id(&facade, symbol_id, symbol_name.map(|s| s.to_string())) {
-            SymbolResolution::Resolved { symbol, .. } => {
-                let max_depth = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("max_depth"))
-                    .and_then(|v| v.as_u64())
-                    .unwrap_or(3) as usize;
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
 
-                let impacted_ids = facade.get_impact_radius(symbol.id, Some(max_depth));
+            let max_depth = arguments
+                .as_ref()
+                .and_then(|m| m.get("max_depth"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(3) as u32;
+            server
+                .analyze_impact(Parameters(AnalyzeImpactRequest {
+                    symbol_name,
+                    symbol_id,
+                    max_depth,
+                    page_size,
+                    cursor: cursor.clone(),
+                    project: None,
+                }))
+                .await
+        }
+        "get_call_hierarchy" => {
+            let function_name = arguments
+                .as_ref()
+                .and_then(|m| m.get("function_name"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
+
+            let depth = arguments
+                .as_ref()
+                .and_then(|m| m.get("depth"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(3) as u32;
+            server
+                .get_call_hierarchy(Parameters(GetCallHierarchyRequest {
+                    function_name,
+                    symbol_id,
+                    depth,
+                    project: None,
+                }))
+                .await
+        }
+        "get_type_hierarchy" => {
+            use crate::mcp::GetTypeHierarchyRequest;
+
+            let type_name = arguments
+                .as_ref()
+                .and_then(|m| m.get("type_name"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
+
+            server
+                .get_type_hierarchy(Parameters(GetTypeHierarchyRequest {
+                    type_name,
+                    symbol_id,
+                    project: None,
+                }))
+                .await
+        }
+        "get_file_outline" => {
+            use crate::mcp::GetFileOutlineRequest;
+
+            let path = arguments
+                .as_ref()
+                .and_then(|m| m.get("path"))
+                .and_then(|v| v.as_str())
+                .expect("required param validated upstream");
+            server
+                .get_file_outline(Parameters(GetFileOutlineRequest {
+                    path: path.to_string(),
+                    project: None,
+                }))
+                .await
+        }
+        "impact_of_change" => {
+            let symbol_name = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_name"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
+
+            let max_depth = arguments
+                .as_ref()
+                .and_then(|m| m.get("max_depth"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(3) as u32;
+            server
+                .impact_of_change(Parameters(ImpactOfChangeRequest {
+                    symbol_name,
+                    symbol_id,
+                    max_depth,
+                    project: None,
+                }))
+                .await
+        }
+        "find_tests" => {
+            let symbol_name = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_name"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
+
+            let max_depth = arguments
+                .as_ref()
+                .and_then(|m| m.get("max_depth"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(3) as u32;
+            server
+                .find_tests(Parameters(FindTestsRequest {
+                    symbol_name,
+                    symbol_id,
+                    max_depth,
+                    project: None,
+                }))
+                .await
+        }
+        "find_similar_symbols" => {
+            let arg = |key: &str| {
+                arguments
+                    .as_ref()
+                    .and_then(|m| m.get(key))
+                    .and_then(|v| v.as_str())
+                    .map(|s| s.to_string())
+            };
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
+            let limit = arguments
+                .as_ref()
+                .and_then(|m| m.get("limit"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(10) as u32;
+            server
+                .find_similar_symbols(Parameters(FindSimilarSymbolsRequest {
+                    symbol_name: arg("symbol_name"),
+                    symbol_id,
+                    limit,
+                    lang: arg("lang"),
+                    kind: arg("kind"),
+                    path: arg("path"),
+                    project: None,
+                }))
+                .await
+        }
+        "find_unused_symbols" => {
+            let kind = arguments
+                .as_ref()
+                .and_then(|m| m.get("kind"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let path = arguments
+                .as_ref()
+                .and_then(|m| m.get("path"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let lang = arguments
+                .as_ref()
+                .and_then(|m| m.get("lang"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let include_public = arguments
+                .as_ref()
+                .and_then(|m| m.get("include_public"))
+                .and_then(|v| v.as_bool())
+                .unwrap_or(false);
+            let limit = arguments
+                .as_ref()
+                .and_then(|m| m.get("limit"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(50) as u32;
+            server
+                .find_unused_symbols(Parameters(FindUnusedSymbolsRequest {
+                    kind,
+                    path,
+                    lang,
+                    include_public,
+                    limit,
+                    project: None,
+                }))
+                .await
+        }
+        "find_todos" => {
+            let text = |key: &str| {
+                arguments
+                    .as_ref()
+                    .and_then(|m| m.get(key))
+                    .and_then(|v| v.as_str())
+                    .map(|s| s.to_string())
+            };
+            let limit = arguments
+                .as_ref()
+                .and_then(|m| m.get("limit"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(50) as u32;
+            server
+                .find_todos(Parameters(FindTodosRequest {
+                    tag: text("tag"),
+                    path: text("path"),
+                    author: text("author"),
+                    limit,
+                    project: None,
+                }))
+                .await
+        }
+        "get_index_info" => {
+            use crate::mcp::GetIndexInfoRequest;
+            use rmcp::handler::server::wrapper::Parameters;
+            server
+                .get_index_info(Parameters(GetIndexInfoRequest { project: None }))
+                .await
+        }
+        "search_symbols" => {
+            let query = arguments
+                .as_ref()
+                .and_then(|m| m.get("query"))
+                .and_then(|v| v.as_str())
+                .expect("required param validated upstream");
+            let limit = arguments
+                .as_ref()
+                .and_then(|m| m.get("limit"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(10) as u32;
+            let kind = arguments
+                .as_ref()
+                .and_then(|m| m.get("kind"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let module = arguments
+                .as_ref()
+                .and_then(|m| m.get("module"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let lang = arguments
+                .as_ref()
+                .and_then(|m| m.get("lang"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let mode = arguments
+                .as_ref()
+                .and_then(|m| m.get("mode"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            server
+                .search_symbols(Parameters(SearchSymbolsRequest {
+                    query: query.to_string(),
+                    limit,
+                    kind,
+                    module,
+                    lang,
+                    mode,
+                    page_size,
+                    cursor: cursor.clone(),
+                    project: None,
+                }))
+                .await
+        }
+        "query_symbols" => {
+            let query = arguments
+                .as_ref()
+                .and_then(|m| m.get("query"))
+                .and_then(|v| v.as_str())
+                .expect("required param validated upstream");
+            let limit = arguments
+                .as_ref()
+                .and_then(|m| m.get("limit"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(10) as u32;
+            server
+                .query_symbols(Parameters(QuerySymbolsRequest {
+                    query: query.to_string(),
+                    limit,
+                    page_size,
+                    cursor: cursor.clone(),
+                    project: None,
+                }))
+                .await
+        }
+        "semantic_search_docs" => {
+            let query = arguments
+                .as_ref()
+                .and_then(|m| m.get("query"))
+                .and_then(|v| v.as_str())
+                .expect("required param validated upstream");
+            let limit = arguments
+                .as_ref()
+                .and_then(|m| m.get("limit"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(10) as u32;
+            let threshold = arguments
+                .as_ref()
+                .and_then(|m| m.get("threshold"))
+                .and_then(|v| v.as_f64())
+                .map(|v| v as f32);
+            let lang = arguments
+                .as_ref()
+                .and_then(|m| m.get("lang"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let string_arg = |key: &str| {
+                arguments
+                    .as_ref()
+                    .and_then(|m| m.get(key))
+                    .and_then(|v| v.as_str())
+                    .map(|s| s.to_string())
+            };
+            server
+                .semantic_search_docs(Parameters(SemanticSearchRequest {
+                    query: query.to_string(),
+                    limit,
+                    threshold,
+                    lang,
+                    kind: string_arg("kind"),
+                    path: string_arg("path"),
+                    visibility: string_arg("visibility"),
+                    vectors: string_arg("vectors"),
+                    page_size,
+                    cursor: cursor.clone(),
+                    project: None,
+                }))
+                .await
+        }
+        "semantic_search_with_context" => {
+            let query = arguments
+                .as_ref()
+                .and_then(|m| m.get("query"))
+                .and_then(|v| v.as_str())
+                .expect("required param validated upstream");
+            let limit = arguments
+                .as_ref()
+                .and_then(|m| m.get("limit"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(5) as u32;
+            let threshold = arguments
+                .as_ref()
+                .and_then(|m| m.get("threshold"))
+                .and_then(|v| v.as_f64())
+                .map(|v| v as f32);
+            let lang = arguments
+                .as_ref()
+                .and_then(|m| m.get("lang"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let string_arg = |key: &str| {
+                arguments
+                    .as_ref()
+                    .and_then(|m| m.get(key))
+                    .and_then(|v| v.as_str())
+                    .map(|s| s.to_string())
+            };
+            server
+                .semantic_search_with_context(Parameters(SemanticSearchWithContextRequest {
+                    query: query.to_string(),
+                    limit,
+                    threshold,
+                    lang,
+                    kind: string_arg("kind"),
+                    path: string_arg("path"),
+                    visibility: string_arg("visibility"),
+                    context_lines: arguments
+                        .as_ref()
+                        .and_then(|m| m.get("context_lines"))
+                        .and_then(|v| v.as_u64())
+                        .map(|n| n as u32),
+                    page_size,
+                    cursor: cursor.clone(),
+                    project: None,
+                }))
+                .await
+        }
+        "search_documents" => {
+            use crate::mcp::SearchDocumentsRequest;
+            let query = arguments
+                .as_ref()
+                .and_then(|m| m.get("query"))
+                .and_then(|v| v.as_str())
+                .expect("required param validated upstream")
+                .to_string();
+            let collection = arguments
+                .as_ref()
+                .and_then(|m| m.get("collection"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let limit = arguments
+                .as_ref()
+                .and_then(|m| m.get("limit"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(5) as u32;
+            server
+                .search_documents(Parameters(SearchDocumentsRequest {
+                    query,
+                    collection,
+                    limit,
+                    project: None,
+                }))
+                .await
+        }
+        _ => Ok(rmcp::model::CallToolResult::error(vec![
+            rmcp::model::ContentBlock::text(format!("Unknown tool: {tool}")),
+        ])),
+    }
+}
+
+/// Run a text-mode call on the background server of `server.daemon`,
+/// starting one for the calls that follow when none answers, with the
+/// global arguments `global_args`. The exit code of a call it answered;
+/// None when the call is left to run in process.
+#[cfg(unix)]
+pub async fn run_on_daemon(
+    tool: &str,
+    positional: &[String],
+    args: Option<&str>,
+    config: &Settings,
+    global_args: &[std::ffi::OsString],
+) -> Option<i32> {
+    use crate::mcp::daemon;
+
+    let arguments = tool_arguments(tool, positional, args, false);
+    let socket = daemon::socket_path(&config.index_path);
+    let Some(reply) = daemon::call(&socket, tool, &arguments).await else {
+        if let Err(e) = daemon::spawn(&socket, config.server.daemon_idle_timeout_secs, global_args)
+        {
+            tracing::debug!(target: "mcp", "failed to start the daemon: {e}");
+        }
+        return None;
+    };
+    for text in &reply.text {
+        println!("{text}");
+    }
+    Some(reply.exit_code)
+}
+
+/// Mark the results `relevant` and `irrelevant` of the query of a search
+/// call, before it runs and ranks by the marks (see [`crate::feedback`]).
+/// Exits when the call has no query to mark them for or an id is unknown.
+pub fn record_feedback(
+    tool: &str,
+    positional: &[String],
+    args: Option<&str>,
+    relevant: &[u32],
+    irrelevant: &[u32],
+    json: bool,
+    facade: &IndexFacade,
+) {
+    if !FEEDBACK_TOOLS.contains(&tool) {
+        exit_invalid_args(
+            tool,
+            &format!(
+                "--relevant and --irrelevant mark the results of {}",
+                FEEDBACK_TOOLS.join(" and ")
+            ),
+            tool_param_spec(tool).0,
+            json,
+        );
+    }
+    let arguments = tool_arguments(tool, positional, args, json);
+    let Some(query) = arguments
+        .as_ref()
+        .and_then(|m| m.get("query"))
+        .and_then(|v| v.as_str())
+    else {
+        exit_invalid_args(
+            tool,
+            &missing_param_message(tool),
+            tool_param_spec(tool).0,
+            json,
+        );
+    };
+
+    for (ids, is_relevant) in [(relevant, true), (irrelevant, false)] {
+        if ids.is_empty() {
+            continue;
+        }
+        let ids: Vec<crate::SymbolId> = ids.iter().map(|&id| crate::SymbolId(id)).collect();
+        match facade.record_feedback(query, &ids, is_relevant) {
+            Ok(symbols) => {
+                let names: Vec<&str> = symbols.iter().map(|symbol| &*symbol.name).collect();
+                eprintln!(
+                    "Marked {} {} for \"{query}\"",
+                    names.join(", "),
+                    if is_relevant {
+                        "relevant"
+                    } else {
+                        "irrelevant"
+                    }
+                );
+            }
+            Err(e) => exit_invalid_args(tool, &e.to_string(), tool_param_spec(tool).0, json),
+        }
+    }
+}
+
+/// Run the MCP direct tool invocation command.
+pub async fn run(
+    tool: String,
+    positional: Vec<String>,
+    args: Option<String>,
+    json: bool,
+    fields: Option<Vec<String>>,
+    facade: IndexFacade,
+    config: &Settings,
+) {
+    let arguments = tool_arguments(&tool, &positional, args.as_deref(), json);
+
+    // Text mode runs the server's tool handlers, which record the call
+    if json {
+        crate::queries::record_query(
+            config,
+            crate::queries::QueryCall::new(&tool, arguments.clone().unwrap_or_default()),
+        );
+    }
+
+    // Collect data for find_symbol if JSON output is requested
+    let find_symbol_data = if json && tool == "find_symbol" {
+        let name = arguments
+            .as_ref()
+            .and_then(|m| m.get("name"))
+            .and_then(|v| v.as_str());
+        let language = arguments
+            .as_ref()
+            .and_then(|m| m.get("lang"))
+            .and_then(|v| v.as_str());
+
+        if let Some(symbol_name) = name {
+            // One resolution policy with the MCP handler: the symbol_id:
+            // form resolves by id here exactly as it does in text mode.
+            let symbols = match resolve_find_symbol_target(&facade, symbol_name, language) {
+                FindSymbolTarget::Symbols { symbols, .. } => symbols,
+                // Non-numeric id: nothing to look up; renders the
+                // not_found envelope exactly like an unmatched name.
+                FindSymbolTarget::InvalidId(_) => Vec::new(),
+            };
+            // Same card as MCP: context with callers, or the bare symbol
+            Some(symbol_cards(&facade, symbols))
+        } else {
+            None
+        }
+    } else {
+        None
+    };
+
+    // `depth:` on get_calls/find_callers: 1 (the default) lists direct
+    // edges, more follow them transitively.
+    let call_depth = arguments
+        .as_ref()
+        .and_then(|m| m.get("depth"))
+        .and_then(|v| v.as_u64())
+        .unwrap_or(1)
+        .max(1) as usize;
+
+    // `page_size:` and `cursor:` walk a large result a page at a time;
+    // `default_size` is the page without a `page_size:`.
+    let page_size = arguments
+        .as_ref()
+        .and_then(|m| m.get("page_size"))
+        .and_then(|v| v.as_u64())
+        .map(|n| n as u32);
+    let cursor = arguments
+        .as_ref()
+        .and_then(|m| m.get("cursor"))
+        .map(|v| v.as_str().map_or_else(|| v.to_string(), str::to_string));
+    let requested_page = |default_size: usize| {
+        Page::new(cursor.as_deref(), page_size, default_size)
+            .unwrap_or_else(|e| exit_invalid_args(&tool, &e, tool_param_spec(&tool).0, json))
+    };
+    let mut next_cursor = None;
+
+    // Collect data for get_calls if JSON output is requested.
+    // Resolution goes through the shared service layer: ambiguous names
+    // refuse-and-list (exit 2) exactly like the MCP handler, never aggregate.
+    let get_calls_data = if json && tool == "get_calls" {
+        let symbol_id = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_id"))
+            .and_then(|v| v.as_u64())
+            .map(|id| id as u32);
+        let function_name = arguments
+            .as_ref()
+            .and_then(|m| m.get("function_name"))
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+
+        use crate::symbol::context::ContextIncludes;
+        match resolve_symbol_or_id(&facade, symbol_id, function_name) {
+            SymbolResolution::Resolved { symbol, .. } if call_depth > 1 => Some(
+                facade
+                    .get_transitive_calls(symbol.id, call_depth)
+                    .into_iter()
+                    .map(CallRelation::reached)
+                    .collect(),
+            ),
+            SymbolResolution::Resolved { symbol, .. } => {
+                let mut all_calls = Vec::new();
+                if let Some(ctx) = facade.get_symbol_context(symbol.id, ContextIncludes::CALLS) {
+                    if let Some(calls) = ctx.relationships.calls {
+                        for (called, metadata) in calls {
+                            all_calls.push(CallRelation::direct(called, metadata));
+                        }
+                    }
+                }
+                Some(all_calls)
+            }
+            SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => None,
+            SymbolResolution::Ambiguous { name, candidates } => {
+                exit_ambiguous(EntityType::Calls, &name, candidates)
+            }
+            SymbolResolution::MissingParam => exit_invalid_args(
+                &tool,
+                &missing_param_message(&tool),
+                tool_param_spec(&tool).0,
+                json,
+            ),
+        }
+    } else {
+        None
+    };
+    let get_calls_data = get_calls_data.map(|results| {
+        let (results, next) = requested_page(usize::MAX).take(results);
+        next_cursor = next;
+        results
+    });
+
+    // Collect data for find_callers if JSON output is requested.
+    // Same shared resolution policy as get_calls: refuse-and-list on
+    // ambiguity, never merge callers of unrelated same-named symbols.
+    let find_callers_data = if json && tool == "find_callers" {
+        let symbol_id = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_id"))
+            .and_then(|v| v.as_u64())
+            .map(|id| id as u32);
+        let function_name = arguments
+            .as_ref()
+            .and_then(|m| m.get("function_name"))
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+
+        match resolve_symbol_or_id(&facade, symbol_id, function_name) {
+            SymbolResolution::Resolved { symbol, .. } if call_depth > 1 => Some(
+                facade
+                    .get_transitive_callers(symbol.id, call_depth)
+                    .into_iter()
+                    .map(CallRelation::reached)
+                    .collect(),
+            ),
+            SymbolResolution::Resolved { symbol, .. } => {
+                let callers = facade.get_calling_functions_with_metadata(symbol.id);
+                let all_callers: Vec<_> = callers
+                    .into_iter()
+                    .map(|(caller, metadata)| CallRelation::direct(caller, metadata))
+                    .collect();
+                Some(all_callers)
+            }
+            SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => None,
+            SymbolResolution::Ambiguous { name, candidates } => {
+                exit_ambiguous(EntityType::Callers, &name, candidates)
+            }
+            SymbolResolution::MissingParam => exit_invalid_args(
+                &tool,
+                &missing_param_message(&tool),
+                tool_param_spec(&tool).0,
+                json,
+            ),
+        }
+    } else {
+        None
+    };
+    let find_callers_data = find_callers_data.map(|results| {
+        let (results, next) = requested_page(usize::MAX).take(results);
+        next_cursor = next;
+        results
+    });
+
+    // Collect data for analyze_impact if JSON output is requested
+    let analyze_impact_data = if json && tool == "analyze_impact" {
+        let symbol_id = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_id"))
+            .and_then(|v| v.as_u64())
+            .map(|id| id as u32);
+        let symbol_name = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_name"))
+            .and_then(|v| v.as_str());
+
+        match resolve_symbol_or_id(&facade, symbol_id, symbol_name.map(|s| s.to_string())) {
+            SymbolResolution::Resolved { symbol, .. } => {
+                let max_depth = arguments
+                    .as_ref()
+                    .and_then(|m| m.get("max_depth"))
+                    .and_then(|v| v.as_u64())
+                    .unwrap_or(3) as usize;
+
+                let impacted_ids = facade.get_impact_radius(symbol.id, Some(max_depth));
 
                 // Convert SymbolIds to full Symbols
                 let mut impacted_symbols = Vec::new();
@@ -557,6 +1326,223 @@ pub async fn run(
     } else {
         None
     };
+    let analyze_impact_data = analyze_impact_data.map(|results| {
+        let (results, next) = requested_page(usize::MAX).take(results);
+        next_cursor = next;
+        results
+    });
+
+    // Collect data for get_call_hierarchy if JSON output is requested
+    let call_hierarchy_data = if json && tool == "get_call_hierarchy" {
+        let symbol_id = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_id"))
+            .and_then(|v| v.as_u64())
+            .map(|id| id as u32);
+        let function_name = arguments
+            .as_ref()
+            .and_then(|m| m.get("function_name"))
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+        let depth = arguments
+            .as_ref()
+            .and_then(|m| m.get("depth"))
+            .and_then(|v| v.as_u64())
+            .unwrap_or(3) as usize;
+
+        match resolve_symbol_or_id(&facade, symbol_id, function_name) {
+            SymbolResolution::Resolved { symbol, .. } => {
+                facade.get_call_hierarchy(symbol.id, depth)
+            }
+            SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => None,
+            SymbolResolution::Ambiguous { name, candidates } => {
+                exit_ambiguous(EntityType::CallTree, &name, candidates)
+            }
+            SymbolResolution::MissingParam => exit_invalid_args(
+                &tool,
+                &missing_param_message(&tool),
+                tool_param_spec(&tool).0,
+                json,
+            ),
+        }
+    } else {
+        None
+    };
+
+    // Collect data for get_type_hierarchy if JSON output is requested
+    let type_hierarchy_data = if json && tool == "get_type_hierarchy" {
+        let symbol_id = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_id"))
+            .and_then(|v| v.as_u64())
+            .map(|id| id as u32);
+        let type_name = arguments
+            .as_ref()
+            .and_then(|m| m.get("type_name"))
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+
+        match resolve_symbol_or_id(&facade, symbol_id, type_name) {
+            SymbolResolution::Resolved { symbol, .. } => facade.get_type_hierarchy(symbol.id),
+            SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => None,
+            SymbolResolution::Ambiguous { name, candidates } => {
+                exit_ambiguous(EntityType::Hierarchy, &name, candidates)
+            }
+            SymbolResolution::MissingParam => exit_invalid_args(
+                &tool,
+                &missing_param_message(&tool),
+                tool_param_spec(&tool).0,
+                json,
+            ),
+        }
+    } else {
+        None
+    };
+
+    // Collect data for get_file_outline if JSON output is requested: the
+    // files the path names, with the symbols of the one it names alone
+    let file_outline_data = if json && tool == "get_file_outline" {
+        let path = arguments
+            .as_ref()
+            .and_then(|m| m.get("path"))
+            .and_then(|v| v.as_str())
+            .expect("required param validated upstream");
+        let files = facade.find_indexed_files(path);
+        let symbols = match files.as_slice() {
+            [(file_id, _)] => facade.get_file_outline(*file_id),
+            _ => Vec::new(),
+        };
+        Some((files, symbols))
+    } else {
+        None
+    };
+
+    // Collect data for impact_of_change if JSON output is requested
+    let change_impact_data = if json && tool == "impact_of_change" {
+        let symbol_id = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_id"))
+            .and_then(|v| v.as_u64())
+            .map(|id| id as u32);
+        let symbol_name = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_name"))
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+        let max_depth = arguments
+            .as_ref()
+            .and_then(|m| m.get("max_depth"))
+            .and_then(|v| v.as_u64())
+            .unwrap_or(3) as usize;
+
+        match resolve_symbol_or_id(&facade, symbol_id, symbol_name) {
+            SymbolResolution::Resolved { symbol, .. } => {
+                facade.get_change_impact(symbol.id, max_depth)
+            }
+            SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => None,
+            SymbolResolution::Ambiguous { name, candidates } => {
+                exit_ambiguous(EntityType::ImpactGraph, &name, candidates)
+            }
+            SymbolResolution::MissingParam => exit_invalid_args(
+                &tool,
+                &missing_param_message(&tool),
+                tool_param_spec(&tool).0,
+                json,
+            ),
+        }
+    } else {
+        None
+    };
+
+    // Collect data for find_tests if JSON output is requested
+    let covering_tests_data = if json && tool == "find_tests" {
+        let symbol_id = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_id"))
+            .and_then(|v| v.as_u64())
+            .map(|id| id as u32);
+        let symbol_name = arguments
+            .as_ref()
+            .and_then(|m| m.get("symbol_name"))
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+        let max_depth = arguments
+            .as_ref()
+            .and_then(|m| m.get("max_depth"))
+            .and_then(|v| v.as_u64())
+            .unwrap_or(3) as usize;
+
+        match resolve_symbol_or_id(&facade, symbol_id, symbol_name) {
+            SymbolResolution::Resolved { symbol, .. } => {
+                Some(crate::analysis::tests_for(&facade, symbol.id, max_depth))
+            }
+            SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => None,
+            SymbolResolution::Ambiguous { name, candidates } => {
+                exit_ambiguous(EntityType::Test, &name, candidates)
+            }
+            SymbolResolution::MissingParam => exit_invalid_args(
+                &tool,
+                &missing_param_message(&tool),
+                tool_param_spec(&tool).0,
+                json,
+            ),
+        }
+    } else {
+        None
+    };
+
+    // Collect data for find_unused_symbols if JSON output is requested
+    let unused_symbols_data = if json && tool == "find_unused_symbols" {
+        let kind = arguments
+            .as_ref()
+            .and_then(|m| m.get("kind"))
+            .and_then(|v| v.as_str());
+        let path = arguments
+            .as_ref()
+            .and_then(|m| m.get("path"))
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+        let lang = arguments
+            .as_ref()
+            .and_then(|m| m.get("lang"))
+            .and_then(|v| v.as_str());
+        let include_public = arguments
+            .as_ref()
+            .and_then(|m| m.get("include_public"))
+            .and_then(|v| v.as_bool())
+            .unwrap_or(false);
+
+        match find_unused_symbols(&facade, kind, path, lang, include_public) {
+            Ok(unused) => Some(unused),
+            Err(e) => {
+                use crate::io::envelope::{Envelope, ResultCode};
+                let envelope: Envelope<()> = Envelope::error(ResultCode::InvalidQuery, e)
+                    .with_entity_type(EntityType::Symbol);
+                emit_envelope_and_exit(envelope);
+            }
+        }
+    } else {
+        None
+    };
+
+    // Collect data for find_todos if JSON output is requested
+    let todos_data = if json && tool == "find_todos" {
+        let text = |key: &str| {
+            arguments
+                .as_ref()
+                .and_then(|m| m.get(key))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string())
+        };
+        Some(find_todos(
+            &facade,
+            text("tag").as_deref(),
+            text("path"),
+            text("author"),
+        ))
+    } else {
+        None
+    };
 
     // Collect data for search_symbols if JSON output is requested
     let search_symbols_data = if json && tool == "search_symbols" {
@@ -600,12 +1586,82 @@ pub async fn run(
                 }
             };
 
-            match facade.search(q, limit as usize, kind_filter, module, language) {
-                Ok(results) => Some(results),
-                Err(e) => exit_index_error(EntityType::SearchResult, q, e),
+            let mode = match arguments
+                .as_ref()
+                .and_then(|m| m.get("mode"))
+                .and_then(|v| v.as_str())
+                .map(str::parse::<SearchMode>)
+            {
+                None => SearchMode::default(),
+                Some(Ok(mode)) => mode,
+                Some(Err(e)) => {
+                    use crate::io::envelope::{Envelope, ResultCode};
+                    let envelope: Envelope<()> = Envelope::error(ResultCode::InvalidQuery, e)
+                        .with_entity_type(EntityType::SearchResult)
+                        .with_query(q);
+                    emit_envelope_and_exit(envelope);
+                }
+            };
+
+            let page = requested_page(limit as usize);
+            match facade.search_with_mode(
+                q,
+                mode,
+                page.fetch_limit(),
+                kind_filter,
+                module,
+                language,
+            ) {
+                Ok(results) => {
+                    let (results, next) = page.take(results);
+                    next_cursor = next;
+                    Some(results)
+                }
+                Err(e) => exit_index_error(EntityType::SearchResult, q, e),
+            }
+        } else {
+            None
+        }
+    } else {
+        None
+    };
+
+    // Collect data for query_symbols if JSON output is requested
+    let query_symbols_data = if json && tool == "query_symbols" {
+        use crate::io::envelope::{Envelope, ResultCode};
+        use crate::symbol::dsl::{QueryField, SymbolQuery};
+
+        let query = arguments
+            .as_ref()
+            .and_then(|m| m.get("query"))
+            .and_then(|v| v.as_str())
+            .expect("required param validated upstream");
+        let limit = arguments
+            .as_ref()
+            .and_then(|m| m.get("limit"))
+            .and_then(|v| v.as_u64())
+            .unwrap_or(10) as usize;
+        let framework_kinds = facade.settings().framework_kinds();
+        let parsed = match SymbolQuery::parse_with_kinds(query, &framework_kinds) {
+            Ok(parsed) => parsed,
+            Err(e) => {
+                let envelope: Envelope<()> =
+                    Envelope::error(ResultCode::InvalidQuery, format!("Invalid query: {e}"))
+                        .with_entity_type(EntityType::SearchResult)
+                        .with_query(query)
+                        .with_hint(format!("Filters: {}", QueryField::KEYS));
+                emit_envelope_and_exit(envelope);
+            }
+        };
+
+        let page = requested_page(limit);
+        match facade.query_symbols(&parsed, page.fetch_limit()) {
+            Ok(results) => {
+                let (results, next) = page.take(results);
+                next_cursor = next;
+                Some(results)
             }
-        } else {
-            None
+            Err(e) => exit_index_error(EntityType::SearchResult, query, e),
         }
     } else {
         None
@@ -623,6 +1679,8 @@ pub async fn run(
     struct ContextWithoutSymbol {
         file_path: String,
         relationships: crate::symbol::context::SymbolRelationships,
+        #[serde(skip_serializing_if = "Option::is_none")]
+        source: Option<crate::symbol::snippet::SourceSnippet>,
     }
 
     #[derive(serde::Serialize)]
@@ -655,23 +1713,26 @@ pub async fn run(
                     .and_then(|m| m.get("threshold"))
                     .and_then(|v| v.as_f64())
                     .map(|t| t as f32);
-                let language = arguments
+                let filter = semantic_filter(arguments.as_ref(), q);
+                let vectors = arguments
                     .as_ref()
-                    .and_then(|m| m.get("lang"))
-                    .and_then(|v| v.as_str());
-
-                let results = match threshold {
-                    Some(t) => facade
-                        .semantic_search_docs_with_threshold_and_language(q, limit, t, language),
-                    None => facade.semantic_search_docs_with_language(q, limit, language),
-                };
+                    .and_then(|m| m.get("vectors"))
+                    .and_then(|v| v.as_str())
+                    .map(|v| {
+                        v.parse::<crate::config::SemanticVectors>()
+                            .unwrap_or_else(|e| exit_invalid_query(q, e))
+                    });
 
-                match results {
+                let page = requested_page(limit);
+                match facade.semantic_search_in(q, page.fetch_limit(), &filter, vectors) {
                     Ok(results) => {
                         let semantic_results: Vec<SemanticSearchResult> = results
                             .into_iter()
+                            .filter(|(_, score)| threshold.is_none_or(|t| *score >= t))
                             .map(|(symbol, score)| SemanticSearchResult { symbol, score })
                             .collect();
+                        let (semantic_results, next) = page.take(semantic_results);
+                        next_cursor = next;
                         Some(semantic_results)
                     }
                     Err(e) => exit_index_error(EntityType::SearchResult, q, e),
@@ -705,23 +1766,22 @@ pub async fn run(
                     .and_then(|m| m.get("threshold"))
                     .and_then(|v| v.as_f64())
                     .map(|t| t as f32);
-                let language = arguments
+                let filter = semantic_filter(arguments.as_ref(), q);
+                let context_lines = arguments
                     .as_ref()
-                    .and_then(|m| m.get("lang"))
-                    .and_then(|v| v.as_str());
+                    .and_then(|m| m.get("context_lines"))
+                    .and_then(|v| v.as_u64())
+                    .map(|n| n as usize);
+                let workspace_root = facade.settings().workspace_root.as_deref();
 
-                let search_results = match threshold {
-                    Some(t) => facade.semantic_search_docs_with_threshold_and_language(
-                        q,
-                        limit as usize,
-                        t,
-                        language,
-                    ),
-                    None => facade.semantic_search_docs_with_language(q, limit as usize, language),
-                };
+                let page = requested_page(limit as usize);
+                let search_results =
+                    facade.semantic_search_docs_reranked(q, page.fetch_limit(), threshold, &filter);
 
                 match search_results {
                     Ok(results) => {
+                        let (results, next) = page.take(results);
+                        next_cursor = next;
                         use crate::symbol::context::ContextIncludes;
                         let context_results: Vec<SemanticSearchWithContextResult> = results
                             .into_iter()
@@ -733,12 +1793,19 @@ pub async fn run(
                                 );
 
                                 context.map(|ctx| SemanticSearchWithContextResult {
-                                    symbol,
-                                    score,
                                     context: ContextWithoutSymbol {
                                         file_path: ctx.file_path,
                                         relationships: ctx.relationships,
+                                        source: context_lines.and_then(|lines| {
+                                            crate::symbol::snippet::SourceSnippet::of_symbol(
+                                                &symbol,
+                                                workspace_root,
+                                                lines,
+                                            )
+                                        }),
                                     },
+                                    symbol,
+                                    score,
                                 })
                             })
                             .collect();
@@ -754,6 +1821,57 @@ pub async fn run(
         None
     };
 
+    // Collect data for find_similar_symbols if JSON output is requested
+    let similar_symbols_data = if json && tool == "find_similar_symbols" {
+        if !facade.has_semantic_search() {
+            None // Semantic search not enabled
+        } else {
+            let symbol_id = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_id"))
+                .and_then(|v| v.as_u64())
+                .map(|id| id as u32);
+            let symbol_name = arguments
+                .as_ref()
+                .and_then(|m| m.get("symbol_name"))
+                .and_then(|v| v.as_str())
+                .map(|s| s.to_string());
+            let limit = arguments
+                .as_ref()
+                .and_then(|m| m.get("limit"))
+                .and_then(|v| v.as_u64())
+                .unwrap_or(10) as usize;
+
+            match resolve_symbol_or_id(&facade, symbol_id, symbol_name) {
+                SymbolResolution::Resolved { symbol, .. } => {
+                    let query = symbol.name.to_string();
+                    let filter = semantic_filter(arguments.as_ref(), &query);
+                    match facade.similar_symbols(symbol.id, limit, &filter) {
+                        Ok(results) => Some(
+                            results
+                                .into_iter()
+                                .map(|(symbol, score)| SemanticSearchResult { symbol, score })
+                                .collect::<Vec<_>>(),
+                        ),
+                        Err(e) => exit_index_error(EntityType::SearchResult, &query, e),
+                    }
+                }
+                SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => None,
+                SymbolResolution::Ambiguous { name, candidates } => {
+                    exit_ambiguous(EntityType::Symbol, &name, candidates)
+                }
+                SymbolResolution::MissingParam => exit_invalid_args(
+                    &tool,
+                    &missing_param_message(&tool),
+                    tool_param_spec(&tool).0,
+                    json,
+                ),
+            }
+        }
+    } else {
+        None
+    };
+
     // Check semantic search status before moving indexer
     let has_semantic_search = facade.has_semantic_search();
 
@@ -871,328 +1989,318 @@ pub async fn run(
     let text_exit: i32 = if json {
         0
     } else {
-        match tool.as_str() {
-            "find_symbol" => {
-                let name = arguments
+        text_exit_code(&facade, &tool, &arguments).unwrap_or_else(|| {
+            exit_invalid_args(
+                &tool,
+                &missing_param_message(&tool),
+                tool_param_spec(&tool).0,
+                json,
+            )
+        })
+    };
+
+    // Embedded mode - use already loaded facade directly
+    let server = {
+        let server = crate::mcp::CodeIntelligenceServer::new(facade);
+
+        // Add DocumentStore if documents are enabled and indexed
+        if let Some(store_arc) = document_store {
+            server.with_document_store_arc(store_arc)
+        } else {
+            server
+        }
+    };
+
+    // JSON mode already collected everything above through the shared
+    // service layer — one execution per invocation. The JSON emit arms
+    // below use only pre-collected data; handler dispatch is text-only.
+    let result = if json {
+        Ok(rmcp::model::CallToolResult::success(vec![]))
+    } else {
+        call_tool_text(&server, &tool, &arguments).await
+    };
+
+    // Print result
+    match result {
+        Ok(call_result) => {
+            if json && tool == "get_index_info" {
+                use crate::io::envelope::Envelope;
+                use crate::io::guidance_engine::generate_guidance_from_config;
+
+                if let Some(index_info) = index_info_data {
+                    let mut envelope =
+                        Envelope::success(index_info).with_message("Index statistics");
+
+                    if let Some(hint) =
+                        generate_guidance_from_config(&guidance_config, "get_index_info", None, 1)
+                    {
+                        envelope = envelope.with_hint(hint);
+                    }
+
+                    let output = match &fields {
+                        Some(f) => envelope.to_json_with_fields(f),
+                        None => envelope.to_json(),
+                    };
+                    println!("{}", output.expect("envelope serialization"));
+                    if envelope.exit_code != 0 {
+                        std::process::exit(envelope.exit_code.into());
+                    }
+                }
+            } else if json && tool == "find_symbol" {
+                // Use pre-collected data for JSON output
+                if let Some(symbol_contexts) = find_symbol_data {
+                    use crate::io::envelope::{EntityType, Envelope};
+                    use crate::io::guidance_engine::generate_guidance_from_config;
+
+                    let name = arguments
+                        .as_ref()
+                        .and_then(|m| m.get("name"))
+                        .and_then(|v| v.as_str())
+                        .unwrap_or("unknown");
+                    let language = arguments
+                        .as_ref()
+                        .and_then(|m| m.get("lang"))
+                        .and_then(|v| v.as_str());
+
+                    if symbol_contexts.is_empty() {
+                        let mut envelope: Envelope<()> =
+                            Envelope::not_found(format!("Symbol '{name}' not found"))
+                                .with_entity_type(EntityType::Symbol)
+                                .with_query(name);
+
+                        if let Some(lang) = language {
+                            envelope = envelope.with_lang(lang);
+                        }
+
+                        if let Some(hint) = generate_guidance_from_config(
+                            &guidance_config,
+                            "find_symbol",
+                            Some(name),
+                            0,
+                        ) {
+                            envelope = envelope.with_hint(hint);
+                        }
+
+                        // Envelope serialization is infallible for simple types
+                        emit_envelope_and_exit(envelope);
+                    } else {
+                        let count = symbol_contexts.len();
+                        let mut envelope = Envelope::success(symbol_contexts)
+                            .with_entity_type(EntityType::Symbol)
+                            .with_count(count)
+                            .with_query(name)
+                            .with_message(format!("Found {count} symbol(s)"));
+
+                        if let Some(lang) = language {
+                            envelope = envelope.with_lang(lang);
+                        }
+
+                        if let Some(hint) = generate_guidance_from_config(
+                            &guidance_config,
+                            "find_symbol",
+                            Some(name),
+                            count,
+                        ) {
+                            envelope = envelope.with_hint(hint);
+                        }
+
+                        let output = match &fields {
+                            Some(f) => envelope.to_json_with_fields(f),
+                            None => envelope.to_json(),
+                        };
+                        println!("{}", output.expect("envelope serialization"));
+                    }
+                }
+            } else if json && tool == "get_calls" {
+                use crate::io::envelope::{EntityType, Envelope};
+                use crate::io::guidance_engine::generate_guidance_from_config;
+
+                let identifier = if let Some(id) = arguments
                     .as_ref()
-                    .and_then(|m| m.get("name"))
-                    .and_then(|v| v.as_str())
-                    .expect("required param validated upstream");
-                let lang = arguments
+                    .and_then(|m| m.get("symbol_id"))
+                    .and_then(|v| v.as_u64())
+                {
+                    format!("symbol_id:{id}")
+                } else {
+                    arguments
+                        .as_ref()
+                        .and_then(|m| m.get("function_name"))
+                        .and_then(|v| v.as_str())
+                        .unwrap_or("unknown")
+                        .to_string()
+                };
+                let language = arguments
                     .as_ref()
                     .and_then(|m| m.get("lang"))
                     .and_then(|v| v.as_str());
-                let found = match resolve_find_symbol_target(&facade, name, lang) {
-                    FindSymbolTarget::Symbols { symbols, .. } => !symbols.is_empty(),
-                    FindSymbolTarget::InvalidId(_) => false,
-                };
-                if found { 0 } else { 1 }
-            }
-            "get_calls" | "find_callers" | "analyze_impact" => {
-                let symbol_id = arguments
+
+                if let Some(calls) = get_calls_data {
+                    let count = calls.len();
+                    let mut envelope = Envelope::success(calls)
+                        .with_entity_type(EntityType::Calls)
+                        .with_count(count)
+                        .with_query(&identifier)
+                        .with_message(format!("Calls {count} function(s)"))
+                        .with_next_cursor(next_cursor.clone());
+
+                    if let Some(lang) = language {
+                        envelope = envelope.with_lang(lang);
+                    }
+
+                    if let Some(hint) = generate_guidance_from_config(
+                        &guidance_config,
+                        "get_calls",
+                        Some(&identifier),
+                        count,
+                    ) {
+                        envelope = envelope.with_hint(hint);
+                    }
+
+                    let output = match &fields {
+                        Some(f) => envelope.to_json_with_fields(f),
+                        None => envelope.to_json(),
+                    };
+                    println!("{}", output.expect("envelope serialization"));
+                    if envelope.exit_code != 0 {
+                        std::process::exit(envelope.exit_code.into());
+                    }
+                } else {
+                    let mut envelope: Envelope<()> =
+                        Envelope::not_found(format!("Function '{identifier}' not found"))
+                            .with_entity_type(EntityType::Calls)
+                            .with_query(&identifier);
+
+                    if let Some(lang) = language {
+                        envelope = envelope.with_lang(lang);
+                    }
+
+                    if let Some(hint) = generate_guidance_from_config(
+                        &guidance_config,
+                        "get_calls",
+                        Some(&identifier),
+                        0,
+                    ) {
+                        envelope = envelope.with_hint(hint);
+                    }
+
+                    emit_envelope_and_exit(envelope);
+                }
+            } else if json && tool == "find_callers" {
+                use crate::io::envelope::{EntityType, Envelope};
+                use crate::io::guidance_engine::generate_guidance_from_config;
+
+                let identifier = if let Some(id) = arguments
                     .as_ref()
                     .and_then(|m| m.get("symbol_id"))
                     .and_then(|v| v.as_u64())
-                    .map(|id| id as u32);
-                let name_key = if tool == "analyze_impact" {
-                    "symbol_name"
+                {
+                    format!("symbol_id:{id}")
                 } else {
-                    "function_name"
+                    arguments
+                        .as_ref()
+                        .and_then(|m| m.get("function_name"))
+                        .and_then(|v| v.as_str())
+                        .unwrap_or("unknown")
+                        .to_string()
                 };
-                let symbol_name = arguments
+                let language = arguments
                     .as_ref()
-                    .and_then(|m| m.get(name_key))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
-                match resolve_symbol_or_id(&facade, symbol_id, symbol_name) {
-                    SymbolResolution::Resolved { .. } => 0,
-                    SymbolResolution::NotFoundById(_) | SymbolResolution::NotFoundByName(_) => 1,
-                    SymbolResolution::Ambiguous { .. } => 2,
-                    SymbolResolution::MissingParam => exit_invalid_args(
-                        &tool,
-                        &missing_param_message(&tool),
-                        tool_param_spec(&tool).0,
-                        json,
-                    ),
-                }
-            }
-            _ => 0,
-        }
-    };
+                    .and_then(|m| m.get("lang"))
+                    .and_then(|v| v.as_str());
 
-    // Embedded mode - use already loaded facade directly
-    let server = {
-        let server = crate::mcp::CodeIntelligenceServer::new(facade);
+                if let Some(callers) = find_callers_data {
+                    let count = callers.len();
+                    let mut envelope = Envelope::success(callers)
+                        .with_entity_type(EntityType::Callers)
+                        .with_count(count)
+                        .with_query(&identifier)
+                        .with_message(format!("Called by {count} function(s)"))
+                        .with_next_cursor(next_cursor.clone());
 
-        // Add DocumentStore if documents are enabled and indexed
-        if let Some(store_arc) = document_store {
-            server.with_document_store_arc(store_arc)
-        } else {
-            server
-        }
-    };
+                    if let Some(lang) = language {
+                        envelope = envelope.with_lang(lang);
+                    }
 
-    // Call the tool directly
-    use crate::mcp::*;
-    use rmcp::handler::server::wrapper::Parameters;
+                    if let Some(hint) = generate_guidance_from_config(
+                        &guidance_config,
+                        "find_callers",
+                        Some(&identifier),
+                        count,
+                    ) {
+                        envelope = envelope.with_hint(hint);
+                    }
 
-    // JSON mode already collected everything above through the shared
-    // service layer — one execution per invocation. The JSON emit arms
-    // below use only pre-collected data; handler dispatch is text-only.
-    let result = if json {
-        Ok(rmcp::model::CallToolResult::success(vec![]))
-    } else {
-        match tool.as_str() {
-            "find_symbol" => {
-                let name = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("name"))
-                    .and_then(|v| v.as_str())
-                    .expect("required param validated upstream");
-                let lang = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("lang"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
-                server
-                    .find_symbol(Parameters(FindSymbolRequest {
-                        name: name.to_string(),
-                        lang,
-                    }))
-                    .await
-            }
-            "get_calls" => {
-                let function_name = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("function_name"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
+                    let output = match &fields {
+                        Some(f) => envelope.to_json_with_fields(f),
+                        None => envelope.to_json(),
+                    };
+                    println!("{}", output.expect("envelope serialization"));
+                    if envelope.exit_code != 0 {
+                        std::process::exit(envelope.exit_code.into());
+                    }
+                } else {
+                    let mut envelope: Envelope<()> =
+                        Envelope::not_found(format!("Function '{identifier}' not found"))
+                            .with_entity_type(EntityType::Callers)
+                            .with_query(&identifier);
 
-                let symbol_id = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("symbol_id"))
-                    .and_then(|v| v.as_u64())
-                    .map(|id| id as u32);
-
-                server
-                    .get_calls(Parameters(GetCallsRequest {
-                        function_name,
-                        symbol_id,
-                    }))
-                    .await
-            }
-            "find_callers" => {
-                let function_name = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("function_name"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
+                    if let Some(lang) = language {
+                        envelope = envelope.with_lang(lang);
+                    }
 
-                let symbol_id = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("symbol_id"))
-                    .and_then(|v| v.as_u64())
-                    .map(|id| id as u32);
-
-                server
-                    .find_callers(Parameters(FindCallersRequest {
-                        function_name,
-                        symbol_id,
-                    }))
-                    .await
-            }
-            "analyze_impact" => {
-                let symbol_name = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("symbol_name"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
+                    if let Some(hint) = generate_guidance_from_config(
+                        &guidance_config,
+                        "find_callers",
+                        Some(&identifier),
+                        0,
+                    ) {
+                        envelope = envelope.with_hint(hint);
+                    }
+
+                    emit_envelope_and_exit(envelope);
+                }
+            } else if json && tool == "analyze_impact" {
+                use crate::io::envelope::{EntityType, Envelope};
+                use crate::io::guidance_engine::generate_guidance_from_config;
 
-                let symbol_id = arguments
+                // Get identifier for messages
+                let identifier = if let Some(id) = arguments
                     .as_ref()
                     .and_then(|m| m.get("symbol_id"))
                     .and_then(|v| v.as_u64())
-                    .map(|id| id as u32);
+                {
+                    format!("symbol_id:{id}")
+                } else {
+                    arguments
+                        .as_ref()
+                        .and_then(|m| m.get("symbol_name"))
+                        .and_then(|v| v.as_str())
+                        .unwrap_or("unknown")
+                        .to_string()
+                };
 
                 let max_depth = arguments
                     .as_ref()
                     .and_then(|m| m.get("max_depth"))
                     .and_then(|v| v.as_u64())
                     .unwrap_or(3) as u32;
-                server
-                    .analyze_impact(Parameters(AnalyzeImpactRequest {
-                        symbol_name,
-                        symbol_id,
-                        max_depth,
-                    }))
-                    .await
-            }
-            "get_index_info" => {
-                use crate::mcp::GetIndexInfoRequest;
-                use rmcp::handler::server::wrapper::Parameters;
-                server
-                    .get_index_info(Parameters(GetIndexInfoRequest {}))
-                    .await
-            }
-            "search_symbols" => {
-                let query = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("query"))
-                    .and_then(|v| v.as_str())
-                    .expect("required param validated upstream");
-                let limit = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("limit"))
-                    .and_then(|v| v.as_u64())
-                    .unwrap_or(10) as u32;
-                let kind = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("kind"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
-                let module = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("module"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
-                let lang = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("lang"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
-                server
-                    .search_symbols(Parameters(SearchSymbolsRequest {
-                        query: query.to_string(),
-                        limit,
-                        kind,
-                        module,
-                        lang,
-                    }))
-                    .await
-            }
-            "semantic_search_docs" => {
-                let query = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("query"))
-                    .and_then(|v| v.as_str())
-                    .expect("required param validated upstream");
-                let limit = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("limit"))
-                    .and_then(|v| v.as_u64())
-                    .unwrap_or(10) as u32;
-                let threshold = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("threshold"))
-                    .and_then(|v| v.as_f64())
-                    .map(|v| v as f32);
-                let lang = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("lang"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
-                server
-                    .semantic_search_docs(Parameters(SemanticSearchRequest {
-                        query: query.to_string(),
-                        limit,
-                        threshold,
-                        lang,
-                    }))
-                    .await
-            }
-            "semantic_search_with_context" => {
-                let query = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("query"))
-                    .and_then(|v| v.as_str())
-                    .expect("required param validated upstream");
-                let limit = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("limit"))
-                    .and_then(|v| v.as_u64())
-                    .unwrap_or(5) as u32;
-                let threshold = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("threshold"))
-                    .and_then(|v| v.as_f64())
-                    .map(|v| v as f32);
-                let lang = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("lang"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
-                server
-                    .semantic_search_with_context(Parameters(SemanticSearchWithContextRequest {
-                        query: query.to_string(),
-                        limit,
-                        threshold,
-                        lang,
-                    }))
-                    .await
-            }
-            "search_documents" => {
-                use crate::mcp::SearchDocumentsRequest;
-                let query = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("query"))
-                    .and_then(|v| v.as_str())
-                    .expect("required param validated upstream")
-                    .to_string();
-                let collection = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("collection"))
-                    .and_then(|v| v.as_str())
-                    .map(|s| s.to_string());
-                let limit = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("limit"))
-                    .and_then(|v| v.as_u64())
-                    .unwrap_or(5) as u32;
-                server
-                    .search_documents(Parameters(SearchDocumentsRequest {
-                        query,
-                        collection,
-                        limit,
-                    }))
-                    .await
-            }
-            _ => {
-                if json {
-                    use crate::io::exit_code::ExitCode;
-                    use crate::io::format::JsonResponse;
-                    let response = JsonResponse::error(
-                        ExitCode::GeneralError,
-                        &format!("Unknown tool: {tool}"),
-                        vec![
-                            "Available tools: find_symbol, get_calls, find_callers, analyze_impact, get_index_info, search_symbols, semantic_search_docs, semantic_search_with_context, search_documents",
-                        ],
-                    );
-                    println!("{}", serde_json::to_string_pretty(&response).unwrap());
-                } else {
-                    eprintln!("Unknown tool: {tool}");
-                    eprintln!(
-                        "Available tools: find_symbol, get_calls, find_callers, analyze_impact, get_index_info, search_symbols, semantic_search_docs, semantic_search_with_context, search_documents"
-                    );
-                }
-                std::process::exit(1);
-            }
-        }
-    };
-
-    // Print result
-    match result {
-        Ok(call_result) => {
-            if json && tool == "get_index_info" {
-                use crate::io::envelope::Envelope;
-                use crate::io::guidance_engine::generate_guidance_from_config;
 
-                if let Some(index_info) = index_info_data {
-                    let mut envelope =
-                        Envelope::success(index_info).with_message("Index statistics");
+                if let Some(impacted) = analyze_impact_data {
+                    let count = impacted.len();
+                    let mut envelope = Envelope::success(impacted)
+                        .with_entity_type(EntityType::ImpactGraph)
+                        .with_count(count)
+                        .with_query(&identifier)
+                        .with_depth(max_depth)
+                        .with_message(format!("{count} symbol(s) would be impacted"))
+                        .with_next_cursor(next_cursor.clone());
 
-                    if let Some(hint) =
-                        generate_guidance_from_config(&guidance_config, "get_index_info", None, 1)
-                    {
+                    if let Some(hint) = generate_guidance_from_config(
+                        &guidance_config,
+                        "analyze_impact",
+                        Some(&identifier),
+                        count,
+                    ) {
                         envelope = envelope.with_hint(hint);
                     }
 
@@ -1204,60 +2312,103 @@ pub async fn run(
                     if envelope.exit_code != 0 {
                         std::process::exit(envelope.exit_code.into());
                     }
+                } else {
+                    // Symbol not found
+                    let mut envelope: Envelope<()> =
+                        Envelope::not_found(format!("Symbol '{identifier}' not found"))
+                            .with_entity_type(EntityType::ImpactGraph)
+                            .with_query(&identifier);
+
+                    if let Some(hint) = generate_guidance_from_config(
+                        &guidance_config,
+                        "analyze_impact",
+                        Some(&identifier),
+                        0,
+                    ) {
+                        envelope = envelope.with_hint(hint);
+                    }
+
+                    emit_envelope_and_exit(envelope);
                 }
-            } else if json && tool == "find_symbol" {
-                // Use pre-collected data for JSON output
-                if let Some(symbol_contexts) = find_symbol_data {
-                    use crate::io::envelope::{EntityType, Envelope};
-                    use crate::io::guidance_engine::generate_guidance_from_config;
+            } else if json && tool == "get_call_hierarchy" {
+                use crate::io::envelope::{EntityType, Envelope};
+                use crate::io::guidance_engine::generate_guidance_from_config;
 
-                    let name = arguments
+                let identifier = if let Some(id) = arguments
+                    .as_ref()
+                    .and_then(|m| m.get("symbol_id"))
+                    .and_then(|v| v.as_u64())
+                {
+                    format!("symbol_id:{id}")
+                } else {
+                    arguments
                         .as_ref()
-                        .and_then(|m| m.get("name"))
+                        .and_then(|m| m.get("function_name"))
                         .and_then(|v| v.as_str())
-                        .unwrap_or("unknown");
-                    let language = arguments
-                        .as_ref()
-                        .and_then(|m| m.get("lang"))
-                        .and_then(|v| v.as_str());
+                        .unwrap_or("unknown")
+                        .to_string()
+                };
+
+                if let Some(hierarchy) = call_hierarchy_data {
+                    let count = hierarchy.len();
+                    let depth = hierarchy.depth as u32;
+                    let mut envelope = Envelope::success(hierarchy)
+                        .with_entity_type(EntityType::CallTree)
+                        .with_count(count)
+                        .with_query(&identifier)
+                        .with_depth(depth)
+                        .with_message(format!("{count} caller(s) and callee(s)"));
+
+                    if let Some(hint) = generate_guidance_from_config(
+                        &guidance_config,
+                        "get_call_hierarchy",
+                        Some(&identifier),
+                        count,
+                    ) {
+                        envelope = envelope.with_hint(hint);
+                    }
 
-                    if symbol_contexts.is_empty() {
-                        let mut envelope: Envelope<()> =
-                            Envelope::not_found(format!("Symbol '{name}' not found"))
-                                .with_entity_type(EntityType::Symbol)
-                                .with_query(name);
+                    let output = match &fields {
+                        Some(f) => envelope.to_json_with_fields(f),
+                        None => envelope.to_json(),
+                    };
+                    println!("{}", output.expect("envelope serialization"));
+                    if envelope.exit_code != 0 {
+                        std::process::exit(envelope.exit_code.into());
+                    }
+                } else {
+                    let envelope: Envelope<()> =
+                        Envelope::not_found(format!("Function '{identifier}' not found"))
+                            .with_entity_type(EntityType::CallTree)
+                            .with_query(&identifier);
 
-                        if let Some(lang) = language {
-                            envelope = envelope.with_lang(lang);
-                        }
+                    emit_envelope_and_exit(envelope);
+                }
+            } else if json && tool == "get_file_outline" {
+                use crate::io::envelope::{EntityType, Envelope};
+                use crate::io::guidance_engine::generate_guidance_from_config;
 
-                        if let Some(hint) = generate_guidance_from_config(
-                            &guidance_config,
-                            "find_symbol",
-                            Some(name),
-                            0,
-                        ) {
-                            envelope = envelope.with_hint(hint);
-                        }
+                let path = arguments
+                    .as_ref()
+                    .and_then(|m| m.get("path"))
+                    .and_then(|v| v.as_str())
+                    .unwrap_or("unknown")
+                    .to_string();
+                let (files, symbols) = file_outline_data.unwrap_or_default();
 
-                        // Envelope serialization is infallible for simple types
-                        emit_envelope_and_exit(envelope);
-                    } else {
-                        let count = symbol_contexts.len();
-                        let mut envelope = Envelope::success(symbol_contexts)
+                match files.as_slice() {
+                    [(_, file_path)] => {
+                        let count = symbols.len();
+                        let mut envelope = Envelope::success(symbols)
                             .with_entity_type(EntityType::Symbol)
                             .with_count(count)
-                            .with_query(name)
-                            .with_message(format!("Found {count} symbol(s)"));
-
-                        if let Some(lang) = language {
-                            envelope = envelope.with_lang(lang);
-                        }
+                            .with_query(file_path)
+                            .with_message(format!("{count} symbol(s) in {file_path}"));
 
                         if let Some(hint) = generate_guidance_from_config(
                             &guidance_config,
-                            "find_symbol",
-                            Some(name),
+                            "get_file_outline",
+                            Some(file_path),
                             count,
                         ) {
                             envelope = envelope.with_hint(hint);
@@ -1268,9 +2419,38 @@ pub async fn run(
                             None => envelope.to_json(),
                         };
                         println!("{}", output.expect("envelope serialization"));
+                        if envelope.exit_code != 0 {
+                            std::process::exit(envelope.exit_code.into());
+                        }
+                    }
+                    [] => {
+                        let envelope: Envelope<()> =
+                            Envelope::not_found(format!("File '{path}' not indexed"))
+                                .with_entity_type(EntityType::Symbol)
+                                .with_query(&path);
+
+                        emit_envelope_and_exit(envelope);
+                    }
+                    _ => {
+                        let matches: Vec<&str> = files
+                            .iter()
+                            .map(|(_, file_path)| file_path.as_str())
+                            .collect();
+                        let envelope: Envelope<()> = Envelope::error(
+                            crate::io::envelope::ResultCode::InvalidQuery,
+                            format!(
+                                "'{path}' matches {} indexed files: {}",
+                                matches.len(),
+                                matches.join(", ")
+                            ),
+                        )
+                        .with_entity_type(EntityType::Symbol)
+                        .with_query(&path);
+
+                        emit_envelope_and_exit(envelope);
                     }
                 }
-            } else if json && tool == "get_calls" {
+            } else if json && tool == "get_type_hierarchy" {
                 use crate::io::envelope::{EntityType, Envelope};
                 use crate::io::guidance_engine::generate_guidance_from_config;
 
@@ -1283,31 +2463,23 @@ pub async fn run(
                 } else {
                     arguments
                         .as_ref()
-                        .and_then(|m| m.get("function_name"))
+                        .and_then(|m| m.get("type_name"))
                         .and_then(|v| v.as_str())
                         .unwrap_or("unknown")
                         .to_string()
                 };
-                let language = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("lang"))
-                    .and_then(|v| v.as_str());
 
-                if let Some(calls) = get_calls_data {
-                    let count = calls.len();
-                    let mut envelope = Envelope::success(calls)
-                        .with_entity_type(EntityType::Calls)
+                if let Some(hierarchy) = type_hierarchy_data {
+                    let count = hierarchy.len();
+                    let mut envelope = Envelope::success(hierarchy)
+                        .with_entity_type(EntityType::Hierarchy)
                         .with_count(count)
                         .with_query(&identifier)
-                        .with_message(format!("Calls {count} function(s)"));
-
-                    if let Some(lang) = language {
-                        envelope = envelope.with_lang(lang);
-                    }
+                        .with_message(format!("{count} supertype(s) and subtype(s)"));
 
                     if let Some(hint) = generate_guidance_from_config(
                         &guidance_config,
-                        "get_calls",
+                        "get_type_hierarchy",
                         Some(&identifier),
                         count,
                     ) {
@@ -1323,27 +2495,14 @@ pub async fn run(
                         std::process::exit(envelope.exit_code.into());
                     }
                 } else {
-                    let mut envelope: Envelope<()> =
-                        Envelope::not_found(format!("Function '{identifier}' not found"))
-                            .with_entity_type(EntityType::Calls)
+                    let envelope: Envelope<()> =
+                        Envelope::not_found(format!("Type '{identifier}' not found"))
+                            .with_entity_type(EntityType::Hierarchy)
                             .with_query(&identifier);
 
-                    if let Some(lang) = language {
-                        envelope = envelope.with_lang(lang);
-                    }
-
-                    if let Some(hint) = generate_guidance_from_config(
-                        &guidance_config,
-                        "get_calls",
-                        Some(&identifier),
-                        0,
-                    ) {
-                        envelope = envelope.with_hint(hint);
-                    }
-
                     emit_envelope_and_exit(envelope);
                 }
-            } else if json && tool == "find_callers" {
+            } else if json && tool == "impact_of_change" {
                 use crate::io::envelope::{EntityType, Envelope};
                 use crate::io::guidance_engine::generate_guidance_from_config;
 
@@ -1356,31 +2515,32 @@ pub async fn run(
                 } else {
                     arguments
                         .as_ref()
-                        .and_then(|m| m.get("function_name"))
+                        .and_then(|m| m.get("symbol_name"))
                         .and_then(|v| v.as_str())
                         .unwrap_or("unknown")
                         .to_string()
                 };
-                let language = arguments
+                let max_depth = arguments
                     .as_ref()
-                    .and_then(|m| m.get("lang"))
-                    .and_then(|v| v.as_str());
+                    .and_then(|m| m.get("max_depth"))
+                    .and_then(|v| v.as_u64())
+                    .unwrap_or(3) as u32;
 
-                if let Some(callers) = find_callers_data {
-                    let count = callers.len();
-                    let mut envelope = Envelope::success(callers)
-                        .with_entity_type(EntityType::Callers)
+                if let Some(impact) = change_impact_data {
+                    let count = impact.len();
+                    let files = impact.files().count();
+                    let mut envelope = Envelope::success(impact)
+                        .with_entity_type(EntityType::ImpactGraph)
                         .with_count(count)
                         .with_query(&identifier)
-                        .with_message(format!("Called by {count} function(s)"));
-
-                    if let Some(lang) = language {
-                        envelope = envelope.with_lang(lang);
-                    }
+                        .with_depth(max_depth)
+                        .with_message(format!(
+                            "{count} affected symbol(s) in {files} other file(s)"
+                        ));
 
                     if let Some(hint) = generate_guidance_from_config(
                         &guidance_config,
-                        "find_callers",
+                        "impact_of_change",
                         Some(&identifier),
                         count,
                     ) {
@@ -1396,31 +2556,17 @@ pub async fn run(
                         std::process::exit(envelope.exit_code.into());
                     }
                 } else {
-                    let mut envelope: Envelope<()> =
-                        Envelope::not_found(format!("Function '{identifier}' not found"))
-                            .with_entity_type(EntityType::Callers)
+                    let envelope: Envelope<()> =
+                        Envelope::not_found(format!("Symbol '{identifier}' not found"))
+                            .with_entity_type(EntityType::ImpactGraph)
                             .with_query(&identifier);
 
-                    if let Some(lang) = language {
-                        envelope = envelope.with_lang(lang);
-                    }
-
-                    if let Some(hint) = generate_guidance_from_config(
-                        &guidance_config,
-                        "find_callers",
-                        Some(&identifier),
-                        0,
-                    ) {
-                        envelope = envelope.with_hint(hint);
-                    }
-
                     emit_envelope_and_exit(envelope);
                 }
-            } else if json && tool == "analyze_impact" {
+            } else if json && tool == "find_tests" {
                 use crate::io::envelope::{EntityType, Envelope};
                 use crate::io::guidance_engine::generate_guidance_from_config;
 
-                // Get identifier for messages
                 let identifier = if let Some(id) = arguments
                     .as_ref()
                     .and_then(|m| m.get("symbol_id"))
@@ -1436,24 +2582,17 @@ pub async fn run(
                         .to_string()
                 };
 
-                let max_depth = arguments
-                    .as_ref()
-                    .and_then(|m| m.get("max_depth"))
-                    .and_then(|v| v.as_u64())
-                    .unwrap_or(3) as u32;
-
-                if let Some(impacted) = analyze_impact_data {
-                    let count = impacted.len();
-                    let mut envelope = Envelope::success(impacted)
-                        .with_entity_type(EntityType::ImpactGraph)
+                if let Some(tests) = covering_tests_data {
+                    let count = tests.len();
+                    let mut envelope = Envelope::success(tests)
+                        .with_entity_type(EntityType::Test)
                         .with_count(count)
                         .with_query(&identifier)
-                        .with_depth(max_depth)
-                        .with_message(format!("{count} symbol(s) would be impacted"));
+                        .with_message(format!("Found {count} test(s)"));
 
                     if let Some(hint) = generate_guidance_from_config(
                         &guidance_config,
-                        "analyze_impact",
+                        "find_tests",
                         Some(&identifier),
                         count,
                     ) {
@@ -1469,23 +2608,147 @@ pub async fn run(
                         std::process::exit(envelope.exit_code.into());
                     }
                 } else {
-                    // Symbol not found
-                    let mut envelope: Envelope<()> =
+                    let envelope: Envelope<()> =
                         Envelope::not_found(format!("Symbol '{identifier}' not found"))
-                            .with_entity_type(EntityType::ImpactGraph)
+                            .with_entity_type(EntityType::Test)
                             .with_query(&identifier);
 
+                    emit_envelope_and_exit(envelope);
+                }
+            } else if json && tool == "find_similar_symbols" {
+                use crate::io::envelope::{EntityType, Envelope, ResultCode};
+                use crate::io::guidance_engine::generate_guidance_from_config;
+
+                let identifier = if let Some(id) = arguments
+                    .as_ref()
+                    .and_then(|m| m.get("symbol_id"))
+                    .and_then(|v| v.as_u64())
+                {
+                    format!("symbol_id:{id}")
+                } else {
+                    arguments
+                        .as_ref()
+                        .and_then(|m| m.get("symbol_name"))
+                        .and_then(|v| v.as_str())
+                        .unwrap_or("unknown")
+                        .to_string()
+                };
+
+                if let Some(results) = similar_symbols_data {
+                    let count = results.len();
+
+                    let mut envelope = if count == 0 {
+                        Envelope::<Vec<SemanticSearchResult>>::not_found(format!(
+                            "No symbols similar to '{identifier}' found"
+                        ))
+                        .with_entity_type(EntityType::Symbol)
+                        .with_query(&identifier)
+                    } else {
+                        Envelope::success(results)
+                            .with_entity_type(EntityType::Symbol)
+                            .with_count(count)
+                            .with_query(&identifier)
+                            .with_message(format!("Found {count} similar symbol(s)"))
+                    };
+
                     if let Some(hint) = generate_guidance_from_config(
                         &guidance_config,
-                        "analyze_impact",
+                        "find_similar_symbols",
                         Some(&identifier),
-                        0,
+                        count,
                     ) {
                         envelope = envelope.with_hint(hint);
                     }
 
+                    let output = match &fields {
+                        Some(f) => envelope.to_json_with_fields(f),
+                        None => envelope.to_json(),
+                    };
+                    println!("{}", output.expect("envelope serialization"));
+                    if envelope.exit_code != 0 {
+                        std::process::exit(envelope.exit_code.into());
+                    }
+                } else if !has_semantic_search {
+                    let envelope: Envelope<()> =
+                        Envelope::error(ResultCode::IndexError, "Semantic search is not enabled")
+                            .with_entity_type(EntityType::Symbol)
+                            .with_query(&identifier)
+                            .with_hint(
+                                "Enable semantic search in settings.toml and rebuild the index",
+                            );
+
+                    emit_envelope_and_exit(envelope);
+                } else {
+                    let envelope: Envelope<()> =
+                        Envelope::not_found(format!("Symbol '{identifier}' not found"))
+                            .with_entity_type(EntityType::Symbol)
+                            .with_query(&identifier);
+
                     emit_envelope_and_exit(envelope);
                 }
+            } else if json && tool == "find_unused_symbols" {
+                use crate::io::envelope::{EntityType, Envelope};
+                use crate::io::guidance_engine::generate_guidance_from_config;
+
+                let mut unused = unused_symbols_data.unwrap_or_default();
+                let found = unused.len();
+                let limit = arguments
+                    .as_ref()
+                    .and_then(|m| m.get("limit"))
+                    .and_then(|v| v.as_u64())
+                    .unwrap_or(50) as usize;
+                unused.truncate(limit);
+
+                let mut envelope = Envelope::success(unused)
+                    .with_entity_type(EntityType::Symbol)
+                    .with_count(found)
+                    .with_truncated(found > limit)
+                    .with_message(format!("Found {found} unused symbol(s)"));
+
+                if let Some(hint) = generate_guidance_from_config(
+                    &guidance_config,
+                    "find_unused_symbols",
+                    None,
+                    found,
+                ) {
+                    envelope = envelope.with_hint(hint);
+                }
+
+                let output = match &fields {
+                    Some(f) => envelope.to_json_with_fields(f),
+                    None => envelope.to_json(),
+                };
+                println!("{}", output.expect("envelope serialization"));
+            } else if json && tool == "find_todos" {
+                use crate::io::envelope::{EntityType, Envelope};
+                use crate::io::guidance_engine::generate_guidance_from_config;
+
+                let mut todos = todos_data.unwrap_or_default();
+                let found = todos.len();
+                let limit = arguments
+                    .as_ref()
+                    .and_then(|m| m.get("limit"))
+                    .and_then(|v| v.as_u64())
+                    .unwrap_or(50) as usize;
+                todos.truncate(limit);
+
+                let mut envelope = Envelope::success(todos)
+                    .with_entity_type(EntityType::Todo)
+                    .with_count(found)
+                    .with_truncated(found > limit)
+                    .with_message(format!("Found {found} todo(s)"));
+
+                if let Some(hint) =
+                    generate_guidance_from_config(&guidance_config, "find_todos", None, found)
+                {
+                    envelope = envelope.with_hint(hint);
+                }
+
+                let output = match &fields {
+                    Some(f) => envelope.to_json_with_fields(f),
+                    None => envelope.to_json(),
+                };
+                println!("{}", output.expect("envelope serialization"));
             } else if json && tool == "search_symbols" {
                 use crate::io::envelope::{EntityType, Envelope, ResultCode};
                 use crate::io::guidance_engine::generate_guidance_from_config;
@@ -1518,6 +2781,7 @@ pub async fn run(
                             .with_count(count)
                             .with_query(query)
                             .with_message(format!("Found {count} symbol(s)"))
+                            .with_next_cursor(next_cursor.clone())
                     };
 
                     if let Some(lang) = language {
@@ -1552,6 +2816,46 @@ pub async fn run(
 
                     emit_envelope_and_exit(envelope);
                 }
+            } else if json && tool == "query_symbols" {
+                use crate::io::envelope::{EntityType, Envelope};
+
+                let query = arguments
+                    .as_ref()
+                    .and_then(|m| m.get("query"))
+                    .and_then(|v| v.as_str())
+                    .unwrap_or("unknown");
+                // Collected above, or the process exited with the error
+                let results: Vec<SearchSymbolResult> = query_symbols_data
+                    .unwrap_or_default()
+                    .into_iter()
+                    .map(Into::into)
+                    .collect();
+                let count = results.len();
+
+                let envelope = if count == 0 {
+                    Envelope::<Vec<SearchSymbolResult>>::not_found(format!(
+                        "No symbols match '{query}'"
+                    ))
+                    .with_entity_type(EntityType::SearchResult)
+                    .with_query(query)
+                } else {
+                    Envelope::success(results)
+                        .with_entity_type(EntityType::SearchResult)
+                        .with_count(count)
+                        .with_query(query)
+                        .with_message(format!("Found {count} symbol(s)"))
+                        .with_next_cursor(next_cursor.clone())
+                        .with_hint("Use symbol_id for precise lookup")
+                };
+
+                let output = match &fields {
+                    Some(f) => envelope.to_json_with_fields(f),
+                    None => envelope.to_json(),
+                };
+                println!("{}", output.expect("envelope serialization"));
+                if envelope.exit_code != 0 {
+                    std::process::exit(envelope.exit_code.into());
+                }
             } else if json && tool == "semantic_search_docs" {
                 use crate::io::envelope::{EntityType, Envelope, ResultCode};
                 use crate::io::guidance_engine::generate_guidance_from_config;
@@ -1581,6 +2885,7 @@ pub async fn run(
                             .with_count(count)
                             .with_query(query)
                             .with_message(format!("Found {count} similar symbol(s)"))
+                            .with_next_cursor(next_cursor.clone())
                     };
 
                     if let Some(lang) = language {
@@ -1654,6 +2959,7 @@ pub async fn run(
                             .with_count(count)
                             .with_query(query)
                             .with_message(format!("Found {count} symbol(s) with context"))
+                            .with_next_cursor(next_cursor.clone())
                     };
 
                     if let Some(lang) = language {
@@ -3,14 +3,30 @@
 //! Each command is implemented in its own module.
 //! Commands are progressively migrated from main.rs.
 
+pub mod analyze;
 pub mod benchmark;
+pub mod browse;
+pub mod check;
+pub mod complete;
+pub mod diff;
 pub mod directories;
+pub mod doctor;
 pub mod documents;
+pub mod embed;
+pub mod export;
+pub mod import;
 pub mod index;
 pub mod init;
 pub mod mcp;
+pub mod models;
 pub mod parse;
 pub mod plugin;
 pub mod profile;
+pub mod projects;
+pub mod query;
+pub mod report;
 pub mod retrieve;
 pub mod serve;
+pub mod shards;
+pub mod snapshot;
+pub mod stats;
@@ -0,0 +1,99 @@
+//! Models command - download models for machines without internet access.
+//!
+//! Local embedding and reranker models are downloaded on first use into the
+//! global models cache. `codanna models fetch` downloads them into a
+//! directory of its own instead, in the same layout, so it can be copied to
+//! a machine that cannot download anything and named by
+//! `semantic_search.model_path` there.
+
+use std::path::{Path, PathBuf};
+
+use fastembed::{InitOptions, RerankInitOptions, TextEmbedding, TextRerank};
+
+use crate::cli::ModelAction;
+use crate::config::Settings;
+use crate::io::ExitCode;
+use crate::semantic::parse_reranker_model;
+use crate::vector::{model_to_string, parse_embedding_model};
+
+/// Bundle directory when neither `--output` nor `model_path` names one
+const DEFAULT_BUNDLE_DIR: &str = "codanna-models";
+
+/// Run the models command.
+pub fn run(action: ModelAction, config: &Settings) -> ExitCode {
+    match action {
+        ModelAction::Fetch {
+            model,
+            rerank,
+            output,
+        } => {
+            let semantic = &config.semantic_search;
+            let output = output
+                .or_else(|| semantic.resolved_model_path(config.workspace_root.as_deref()))
+                .unwrap_or_else(|| PathBuf::from(DEFAULT_BUNDLE_DIR));
+            let models = if model.is_empty() {
+                vec![semantic.model.clone()]
+            } else {
+                model
+            };
+            let reranker = (rerank || semantic.rerank).then(|| semantic.rerank_model.as_str());
+
+            if let Err(e) = std::fs::create_dir_all(&output) {
+                eprintln!("Error: failed to create {}: {e}", output.display());
+                return ExitCode::IoError;
+            }
+            for name in &models {
+                if let Err(e) = fetch_embedding_model(name, &output) {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            }
+            if let Some(name) = reranker {
+                if let Err(e) = fetch_reranker(name, &output) {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            }
+
+            println!("\nModels saved to {}", output.display());
+            println!(
+                "Copy the directory to the offline machine and set in .codanna/settings.toml:"
+            );
+            println!("\n  [semantic_search]");
+            println!("  model_path = \"{}\"", output.display());
+            ExitCode::Success
+        }
+    }
+}
+
+/// Download an embedding model into `dir` and check that it loads.
+fn fetch_embedding_model(name: &str, dir: &Path) -> Result<(), String> {
+    let model = parse_embedding_model(name).map_err(|e| format!("Invalid model name: {e}"))?;
+    let model_name = model_to_string(&model);
+    eprintln!("Fetching embedding model '{model_name}'...");
+
+    let mut text_model = TextEmbedding::try_new(
+        InitOptions::new(model)
+            .with_cache_dir(dir.to_path_buf())
+            .with_show_download_progress(true),
+    )
+    .map_err(|e| format!("Failed to download model '{model_name}': {e}"))?;
+    text_model
+        .embed(vec!["test"], None)
+        .map_err(|e| format!("Model '{model_name}' downloaded but failed to embed: {e}"))?;
+    Ok(())
+}
+
+/// Download a reranker into `dir`.
+fn fetch_reranker(name: &str, dir: &Path) -> Result<(), String> {
+    let model = parse_reranker_model(name)?;
+    eprintln!("Fetching reranker '{name}'...");
+
+    TextRerank::try_new(
+        RerankInitOptions::new(model)
+            .with_cache_dir(dir.to_path_buf())
+            .with_show_download_progress(true),
+    )
+    .map_err(|e| format!("Failed to download reranker '{name}': {e}"))?;
+    Ok(())
+}
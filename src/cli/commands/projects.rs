@@ -0,0 +1,177 @@
+//! Projects command - the registry of the projects indexed on this machine.
+//!
+//! `codanna init` registers a project in `~/.codanna/projects.json` and
+//! every save of its index refreshes the counts kept there. `codanna
+//! projects` lists them with where their index is and how large, gives
+//! them aliases, and forgets the ones that are gone; `--project` reaches
+//! one by alias or directory name from any directory.
+
+use crate::cli::ProjectsAction;
+use crate::indexing::pipeline::metrics::format_bytes;
+use crate::init::{ProjectInfo, ProjectRegistry};
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+use crate::mcp::format_relative_time;
+use serde::Serialize;
+use std::path::PathBuf;
+
+use super::index::dir_size;
+
+/// One project as `projects list` shows it
+#[derive(Debug, Serialize)]
+pub struct ProjectEntry {
+    pub id: String,
+    pub name: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub alias: Option<String>,
+    pub path: PathBuf,
+    /// Whether the project directory is still there
+    pub exists: bool,
+    pub index_path: PathBuf,
+    /// Bytes on disk, none without an index
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub index_size: Option<u64>,
+    pub symbols: u32,
+    pub files: u32,
+    /// When the index was last saved, none before the first
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub last_indexed: Option<u64>,
+}
+
+impl ProjectEntry {
+    fn new(id: &str, info: &ProjectInfo) -> Self {
+        let index_path = info.index_dir();
+        Self {
+            id: id.to_string(),
+            name: info.name.clone(),
+            alias: info.alias.clone(),
+            exists: info.path.is_dir(),
+            index_size: index_path
+                .is_dir()
+                .then(|| dir_size(&index_path))
+                .filter(|size| *size > 0),
+            path: info.path.clone(),
+            index_path,
+            symbols: info.symbol_count,
+            files: info.file_count,
+            last_indexed: (info.last_modified > 0).then_some(info.last_modified),
+        }
+    }
+}
+
+/// Run the projects command.
+pub fn run(action: ProjectsAction) -> ExitCode {
+    let mut registry = match ProjectRegistry::load() {
+        Ok(registry) => registry,
+        Err(e) => {
+            eprintln!("Error: {e}");
+            return ExitCode::ConfigError;
+        }
+    };
+    match action {
+        ProjectsAction::List { json } => list(&registry, json),
+        ProjectsAction::Alias { project, alias } => {
+            let result = registry
+                .find(&project)
+                .and_then(|id| {
+                    registry.set_alias(&id, alias.as_deref())?;
+                    Ok(id)
+                })
+                .and_then(|id| registry.save().map(|()| id));
+            match result {
+                Ok(id) => {
+                    let path = registry
+                        .find_project_by_id(&id)
+                        .map(|info| info.path.display().to_string())
+                        .unwrap_or_default();
+                    match alias {
+                        Some(alias) => println!("{path} is now '{alias}': codanna -p {alias} ..."),
+                        None => println!("{path} has no alias now"),
+                    }
+                    ExitCode::Success
+                }
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    ExitCode::GeneralError
+                }
+            }
+        }
+        ProjectsAction::Remove { project } => {
+            let id = match registry.find(&project) {
+                Ok(id) => id,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::NotFound;
+                }
+            };
+            let removed = registry.remove_project(&id);
+            if let Err(e) = registry.save() {
+                eprintln!("Error: {e}");
+                return ExitCode::IoError;
+            }
+            if let Some(info) = removed {
+                println!(
+                    "Forgot {} ({}); its index is left at {}",
+                    info.display_name(),
+                    info.path.display(),
+                    info.index_dir().display()
+                );
+            }
+            ExitCode::Success
+        }
+    }
+}
+
+fn list(registry: &ProjectRegistry, json: bool) -> ExitCode {
+    let entries: Vec<ProjectEntry> = registry
+        .projects()
+        .into_iter()
+        .map(|(id, info)| ProjectEntry::new(id, info))
+        .collect();
+    let count = entries.len();
+
+    if json {
+        let envelope = if entries.is_empty() {
+            Envelope::not_found("No projects registered")
+                .with_entity_type(EntityType::Project)
+                .with_hint("Register one with: codanna init")
+        } else {
+            Envelope::success(entries)
+                .with_entity_type(EntityType::Project)
+                .with_count(count)
+                .with_message(format!(
+                    "{count} project{}",
+                    if count == 1 { "" } else { "s" }
+                ))
+        };
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+    } else if entries.is_empty() {
+        println!("No projects registered. Register one with: codanna init");
+    } else {
+        for entry in &entries {
+            let name = entry.alias.as_deref().unwrap_or(&entry.name);
+            let missing = if entry.exists { "" } else { "  (missing)" };
+            println!("{name:<20} {}{missing}", entry.path.display());
+            let size = entry
+                .index_size
+                .map_or_else(|| "not built".to_string(), format_bytes);
+            let indexed = entry.last_indexed.map_or_else(
+                || "never indexed".to_string(),
+                |at| format!("indexed {}", format_relative_time(at)),
+            );
+            println!(
+                "{:<20} index {} ({size}), {} symbols in {} files, {indexed}",
+                "",
+                entry.index_path.display(),
+                entry.symbols,
+                entry.files
+            );
+        }
+    }
+
+    if count == 0 {
+        ExitCode::NotFound
+    } else {
+        ExitCode::Success
+    }
+}
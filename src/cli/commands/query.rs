@@ -0,0 +1,201 @@
+//! Query command - save searches under a name and run them again.
+//!
+//! `codanna query save` stores an MCP tool call in `.codanna/queries.json`,
+//! `codanna query run` replays it through `codanna mcp`, and the
+//! `run_saved_query` MCP tool runs it from an agent. `codanna retrieve
+//! history` lists the searches run recently, from the same file.
+
+use crate::cli::QueryAction;
+use crate::config::Settings;
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+use crate::mcp::format_relative_time;
+use crate::mcp::service::{accepted_params_line, missing_param_message, tool_param_spec};
+use crate::queries::{QueryCall, QueryStore, SavedQuery, is_valid_query_name, queries_path};
+
+use super::mcp::{KNOWN_TOOLS, add_positional_arguments};
+
+/// Run the save, list and delete actions; `run` needs the index and goes
+/// through [`run_saved`].
+pub fn run(action: QueryAction, config: &Settings) -> ExitCode {
+    let path = queries_path(config);
+    let mut store = QueryStore::load(&path);
+    match action {
+        QueryAction::Save { name, tool, args } => {
+            let call = match build_call(&tool, &args) {
+                Ok(call) => call,
+                Err(message) => {
+                    eprintln!("Error: {message}");
+                    return ExitCode::GeneralError;
+                }
+            };
+            if !is_valid_query_name(&name) {
+                eprintln!(
+                    "Error: invalid query name '{name}'; use letters, digits, '.', '_' and '-'"
+                );
+                return ExitCode::GeneralError;
+            }
+
+            let saved = SavedQuery {
+                call: call.clone(),
+                saved_at: crate::indexing::get_utc_timestamp(),
+            };
+            let replaced = store.saved.insert(name.clone(), saved).is_some();
+            if let Err(e) = store.save(&path) {
+                eprintln!("Error: failed to save {}: {e}", path.display());
+                return ExitCode::IoError;
+            }
+            let verb = if replaced { "Replaced" } else { "Saved" };
+            println!("{verb} '{name}': {call}");
+            println!("Run it with: codanna query run {name}");
+            ExitCode::Success
+        }
+        QueryAction::List { json } => {
+            if json {
+                let count = store.saved.len();
+                let envelope = Envelope::success(store.saved)
+                    .with_entity_type(EntityType::Query)
+                    .with_count(count)
+                    .with_message(format!("{count} saved quer{}", plural_y(count)));
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else if store.saved.is_empty() {
+                println!("No saved queries. Save one with: codanna query save <name> <query>");
+            } else {
+                for (name, saved) in &store.saved {
+                    println!("{name:<20} {}", saved.call);
+                }
+            }
+            ExitCode::Success
+        }
+        QueryAction::Delete { name } => {
+            if store.saved.remove(&name).is_none() {
+                eprintln!("Error: no saved query named '{name}'");
+                return ExitCode::NotFound;
+            }
+            if let Err(e) = store.save(&path) {
+                eprintln!("Error: failed to save {}: {e}", path.display());
+                return ExitCode::IoError;
+            }
+            println!("Deleted '{name}'");
+            ExitCode::Success
+        }
+        QueryAction::Run { .. } => unreachable!("query run is dispatched to run_saved"),
+    }
+}
+
+/// Run the query saved under `name` as `codanna mcp` would
+pub async fn run_saved(
+    name: &str,
+    json: bool,
+    fields: Option<Vec<String>>,
+    facade: IndexFacade,
+    config: &Settings,
+) -> ExitCode {
+    let Some(saved) = saved_query(config, name) else {
+        eprintln!("Error: no saved query named '{name}'");
+        eprintln!("List them with: codanna query list");
+        return ExitCode::NotFound;
+    };
+    let arguments = serde_json::Value::Object(saved.call.arguments).to_string();
+    super::mcp::run(
+        saved.call.tool,
+        Vec::new(),
+        Some(arguments),
+        json,
+        fields,
+        facade,
+        config,
+    )
+    .await;
+    ExitCode::Success
+}
+
+/// The query saved under `name`
+pub fn saved_query(config: &Settings, name: &str) -> Option<SavedQuery> {
+    QueryStore::load(&queries_path(config)).saved.remove(name)
+}
+
+/// List the `limit` most recent searches, newest first
+pub fn history(config: &Settings, limit: usize, json: bool) -> ExitCode {
+    let store = QueryStore::load(&queries_path(config));
+    let entries: Vec<_> = store.recent(limit).cloned().collect();
+    let count = entries.len();
+
+    if json {
+        let envelope = if entries.is_empty() {
+            Envelope::not_found("No searches recorded yet")
+                .with_entity_type(EntityType::Query)
+                .with_hint("Searches of codanna mcp, retrieve search and the MCP server are kept")
+        } else {
+            Envelope::success(entries)
+                .with_entity_type(EntityType::Query)
+                .with_count(count)
+                .with_message(format!("{count} recent quer{}", plural_y(count)))
+                .with_hint("Keep one with: codanna query save <name> --tool <tool> <args>")
+        };
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+    } else if entries.is_empty() {
+        eprintln!("No searches recorded yet");
+    } else {
+        for entry in &entries {
+            println!("{:<16} {}", format_relative_time(entry.at), entry.call);
+        }
+    }
+
+    if count == 0 {
+        ExitCode::NotFound
+    } else {
+        ExitCode::Success
+    }
+}
+
+/// The call of `tool` that `codanna mcp <tool> <args>` makes, checked
+/// against the parameters of the tool
+fn build_call(tool: &str, args: &[String]) -> Result<QueryCall, String> {
+    if !KNOWN_TOOLS.contains(&tool) {
+        return Err(format!(
+            "unknown tool '{tool}'. Available tools: {}",
+            KNOWN_TOOLS.join(", ")
+        ));
+    }
+    let mut arguments = serde_json::Map::new();
+    add_positional_arguments(tool, args, &mut arguments);
+    // find_symbol carries symbol_id:N in its name
+    if tool == "find_symbol" {
+        arguments.remove("symbol_id");
+    }
+
+    let call = QueryCall::new(tool, arguments);
+    check_call(&call)?;
+    Ok(call)
+}
+
+/// Refuse a call of a known tool with parameters it does not take, or
+/// without the ones it needs
+pub fn check_call(call: &QueryCall) -> Result<(), String> {
+    let tool = call.tool.as_str();
+    let (accepted, requires_one_of) = tool_param_spec(tool);
+    if let Some(key) = call
+        .arguments
+        .keys()
+        .find(|key| !accepted.contains(&key.as_str()))
+    {
+        return Err(format!(
+            "unknown parameter '{key}' for {tool}\n{}",
+            accepted_params_line(tool)
+        ));
+    }
+    if !requires_one_of.is_empty()
+        && !requires_one_of
+            .iter()
+            .any(|k| call.arguments.contains_key(*k))
+    {
+        return Err(missing_param_message(tool));
+    }
+    Ok(())
+}
+
+fn plural_y(count: usize) -> &'static str {
+    if count == 1 { "y" } else { "ies" }
+}
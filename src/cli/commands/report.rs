@@ -0,0 +1,87 @@
+//! Report command - what the last indexing run found wrong with its files.
+//!
+//! `codanna index` saves the diagnostics of its files with the index; this
+//! reads them back grouped by file, the files that got no symbols at all
+//! first.
+
+use crate::cli::args::ReportAction;
+use crate::config::Settings;
+use crate::indexing::diagnostics::{FileDiagnostic, ParseReport};
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+
+/// Run the report command.
+pub fn run(action: ReportAction, config: &Settings) -> ExitCode {
+    match action {
+        ReportAction::ParseFailures { failed, json } => parse_failures(failed, json, config),
+    }
+}
+
+fn parse_failures(failed_only: bool, json: bool, config: &Settings) -> ExitCode {
+    let Some(report) = ParseReport::load(&config.index_path) else {
+        let message = "No parse report yet";
+        let hint = "Run 'codanna index' to build one";
+        if json {
+            let envelope = Envelope::<()>::not_found(message).with_hint(hint);
+            println!("{}", envelope.to_json().expect("envelope serialization"));
+        } else {
+            eprintln!("{message}. {hint}.");
+        }
+        return ExitCode::NotFound;
+    };
+
+    let mut files = report.by_file();
+    // Files that got no symbols first, by path within each group
+    files.sort_by_key(|(_, list)| !list.iter().any(|d| d.kind.is_failure()));
+    if failed_only {
+        files.retain(|(_, list)| list.iter().any(|d| d.kind.is_failure()));
+    }
+    let failed = report.failed_files();
+    let message = format!(
+        "{} file(s) with problems, {failed} without symbols, of a {} run indexing {} files",
+        files.len(),
+        report.mode,
+        report.files_indexed
+    );
+
+    if json {
+        let count = files.len();
+        let diagnostics: Vec<FileDiagnostic> = files
+            .iter()
+            .flat_map(|(_, list)| list.iter().map(|d| (*d).clone()))
+            .collect();
+        let shown = ParseReport {
+            indexed_at: report.indexed_at,
+            mode: report.mode.clone(),
+            files_indexed: report.files_indexed,
+            diagnostics,
+        };
+        let envelope = Envelope::success(&shown)
+            .with_entity_type(EntityType::Diagnostic)
+            .with_count(count)
+            .with_message(message);
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+        return ExitCode::Success;
+    }
+
+    if files.is_empty() {
+        println!("Every file of the last {} run parsed cleanly", report.mode);
+        return ExitCode::Success;
+    }
+    for (path, list) in &files {
+        println!("{}", path.display());
+        for diagnostic in list {
+            let line = diagnostic
+                .line
+                .map(|line| format!(" (line {line})"))
+                .unwrap_or_default();
+            println!(
+                "  {}{line}: {}",
+                diagnostic.kind.as_str(),
+                diagnostic.message
+            );
+        }
+    }
+    println!("\n{message}");
+    ExitCode::Success
+}
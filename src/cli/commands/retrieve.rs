@@ -1,10 +1,19 @@
 //! Retrieve command - query symbol information from the index.
 
 use crate::cli::RetrieveQuery;
+use crate::config::Settings;
 use crate::indexing::facade::IndexFacade;
 use crate::io::ExitCode;
 use crate::io::OutputFormat;
+use crate::queries::QueryCall;
 use crate::retrieve;
+use serde::{Deserialize, Serialize};
+use serde_json::{Map, Value};
+use std::io::BufRead;
+use std::path::Path;
+
+use super::mcp::{KNOWN_TOOLS, SEMANTIC_TOOLS};
+use super::query::check_call;
 
 /// Run the retrieve command.
 pub fn run(query: RetrieveQuery, indexer: &IndexFacade) -> ExitCode {
@@ -33,7 +42,12 @@ pub fn run(query: RetrieveQuery, indexer: &IndexFacade) -> ExitCode {
             let format = OutputFormat::from_json_flag(json);
             retrieve::retrieve_symbol(indexer, &final_name, language, format, fields)
         }
-        RetrieveQuery::Callers { args, json, fields } => {
+        RetrieveQuery::Callers {
+            args,
+            depth,
+            json,
+            fields,
+        } => {
             use crate::io::args::parse_positional_args;
 
             // Parse positional arguments for function name and key:value pairs
@@ -54,10 +68,20 @@ pub fn run(query: RetrieveQuery, indexer: &IndexFacade) -> ExitCode {
             // Extract language filter
             let language = params.get("lang").map(|s| s.as_str());
 
+            // Transitive depth (priority: flag > key:value)
+            let depth = depth
+                .or_else(|| params.get("depth").and_then(|s| s.parse::<usize>().ok()))
+                .unwrap_or(1);
+
             let format = OutputFormat::from_json_flag(json);
-            retrieve::retrieve_callers(indexer, &final_function, language, format, fields)
+            retrieve::retrieve_callers(indexer, &final_function, language, depth, format, fields)
         }
-        RetrieveQuery::Calls { args, json, fields } => {
+        RetrieveQuery::Calls {
+            args,
+            depth,
+            json,
+            fields,
+        } => {
             use crate::io::args::parse_positional_args;
 
             // Parse positional arguments for function name and key:value pairs
@@ -78,8 +102,13 @@ pub fn run(query: RetrieveQuery, indexer: &IndexFacade) -> ExitCode {
             // Extract language filter
             let language = params.get("lang").map(|s| s.as_str());
 
+            // Transitive depth (priority: flag > key:value)
+            let depth = depth
+                .or_else(|| params.get("depth").and_then(|s| s.parse::<usize>().ok()))
+                .unwrap_or(1);
+
             let format = OutputFormat::from_json_flag(json);
-            retrieve::retrieve_calls(indexer, &final_function, language, format, fields)
+            retrieve::retrieve_calls(indexer, &final_function, language, depth, format, fields)
         }
         RetrieveQuery::Implementations { args, json, fields } => {
             use crate::io::args::parse_positional_args;
@@ -103,15 +132,330 @@ pub fn run(query: RetrieveQuery, indexer: &IndexFacade) -> ExitCode {
             let format = OutputFormat::from_json_flag(json);
             retrieve::retrieve_implementations(indexer, &final_trait, language, format, fields)
         }
+        RetrieveQuery::Hierarchy { args, json, fields } => {
+            use crate::io::args::parse_positional_args;
+
+            // Parse positional arguments for type name and key:value pairs
+            let (positional_type, params) = parse_positional_args(&args);
+
+            // Determine type name or symbol_id (priority: positional > key:value)
+            let final_type = positional_type
+                .or_else(|| params.get("type").cloned())
+                .or_else(|| params.get("symbol_id").map(|id| format!("symbol_id:{id}")))
+                .unwrap_or_else(|| {
+                    eprintln!("Error: hierarchy requires a type name or symbol_id");
+                    eprintln!("Usage: codanna retrieve hierarchy Parser");
+                    eprintln!("   or: codanna retrieve hierarchy type:Parser");
+                    eprintln!("   or: codanna retrieve hierarchy symbol_id:1771");
+                    std::process::exit(1);
+                });
+
+            // Extract language filter
+            let language = params.get("lang").map(|s| s.as_str());
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_hierarchy(indexer, &final_type, language, format, fields)
+        }
+        RetrieveQuery::Impact {
+            args,
+            depth,
+            json,
+            fields,
+        } => {
+            use crate::io::args::parse_positional_args;
+
+            // Parse positional arguments for symbol name and key:value pairs
+            let (positional_name, params) = parse_positional_args(&args);
+
+            // Determine symbol name or symbol_id (priority: positional > key:value)
+            let final_name = positional_name
+                .or_else(|| params.get("symbol").cloned())
+                .or_else(|| params.get("symbol_id").map(|id| format!("symbol_id:{id}")))
+                .unwrap_or_else(|| {
+                    eprintln!("Error: impact requires a symbol name or symbol_id");
+                    eprintln!("Usage: codanna retrieve impact parse_file");
+                    eprintln!("   or: codanna retrieve impact symbol:parse_file");
+                    eprintln!("   or: codanna retrieve impact symbol_id:1771");
+                    std::process::exit(1);
+                });
+
+            // Extract language filter
+            let language = params.get("lang").map(|s| s.as_str());
+
+            // Distance limit (priority: flag > key:value)
+            let depth = depth
+                .or_else(|| params.get("depth").and_then(|s| s.parse::<usize>().ok()))
+                .unwrap_or(3);
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_impact(indexer, &final_name, language, depth, format, fields)
+        }
+        RetrieveQuery::TestsFor {
+            args,
+            depth,
+            json,
+            fields,
+        } => {
+            use crate::io::args::parse_positional_args;
+
+            // Parse positional arguments for function name and key:value pairs
+            let (positional_function, params) = parse_positional_args(&args);
+
+            // Determine function name or symbol_id (priority: positional > key:value)
+            let final_function = positional_function
+                .or_else(|| params.get("function").cloned())
+                .or_else(|| params.get("symbol_id").map(|id| format!("symbol_id:{id}")))
+                .unwrap_or_else(|| {
+                    eprintln!("Error: tests-for requires a function name or symbol_id");
+                    eprintln!("Usage: codanna retrieve tests-for parse_file");
+                    eprintln!("   or: codanna retrieve tests-for function:parse_file");
+                    eprintln!("   or: codanna retrieve tests-for symbol_id:1771");
+                    std::process::exit(1);
+                });
+
+            // Extract language filter
+            let language = params.get("lang").map(|s| s.as_str());
+
+            // Helper depth (priority: flag > key:value)
+            let depth = depth
+                .or_else(|| params.get("depth").and_then(|s| s.parse::<usize>().ok()))
+                .unwrap_or(3);
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_tests_for(indexer, &final_function, language, depth, format, fields)
+        }
+        RetrieveQuery::References { args, json, fields } => {
+            use crate::io::args::parse_positional_args;
+
+            // Parse positional arguments for symbol name and key:value pairs
+            let (positional_symbol, params) = parse_positional_args(&args);
+
+            // Determine symbol name or symbol_id (priority: positional > key:value)
+            let final_symbol = positional_symbol
+                .or_else(|| params.get("symbol").cloned())
+                .or_else(|| params.get("symbol_id").map(|id| format!("symbol_id:{id}")))
+                .unwrap_or_else(|| {
+                    eprintln!("Error: references requires a symbol name or symbol_id");
+                    eprintln!("Usage: codanna retrieve references Settings::debug");
+                    eprintln!("   or: codanna retrieve references symbol:MAX_RETRIES");
+                    eprintln!("   or: codanna retrieve references symbol_id:1771");
+                    std::process::exit(1);
+                });
+
+            // Extract language filter
+            let language = params.get("lang").map(|s| s.as_str());
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_references(indexer, &final_symbol, language, format, fields)
+        }
+        RetrieveQuery::Api {
+            args,
+            public,
+            json,
+            fields,
+        } => {
+            use crate::io::args::parse_positional_args;
+
+            // Only key:value pairs: the surface has no single subject
+            let (_, params) = parse_positional_args(&args);
+
+            let kinds = match params
+                .get("kind")
+                .map(|kind| kind.parse::<crate::SymbolKind>())
+            {
+                Some(Ok(kind)) => vec![kind],
+                Some(Err(e)) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+                None => Vec::new(),
+            };
+            let filter = crate::export::GraphFilter {
+                path: params.get("path").cloned(),
+                language: params.get("lang").map(|lang| lang.to_lowercase()),
+                kinds,
+                relations: Vec::new(),
+            };
+            // Without --public the internal API is listed too
+            let visibility = if public {
+                crate::Visibility::Public
+            } else {
+                crate::Visibility::Module
+            };
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_api(indexer, &filter, visibility, format, fields)
+        }
+        RetrieveQuery::Todos { args, json, fields } => {
+            use crate::io::args::parse_positional_args;
+
+            // Only key:value pairs, tags comma-separated
+            let (_, params) = parse_positional_args(&args);
+            let filter = crate::analysis::TodoFilter {
+                tags: params
+                    .get("tag")
+                    .map(|tags| tags.split(',').map(|tag| tag.trim().to_string()).collect())
+                    .unwrap_or_default(),
+                path: params.get("path").cloned(),
+                author: params.get("author").cloned(),
+            };
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_todos(indexer, &filter, format, fields)
+        }
+        RetrieveQuery::Strings {
+            pattern,
+            args,
+            json,
+            fields,
+        } => {
+            use crate::io::args::parse_positional_args;
+            use crate::parsing::strings::StringKind;
+
+            // Only key:value pairs, kinds comma-separated
+            let (_, params) = parse_positional_args(&args);
+            let mut kinds = Vec::new();
+            for name in params.get("kind").into_iter().flat_map(|k| k.split(',')) {
+                match StringKind::from_name(name.trim()) {
+                    Some(kind) => kinds.push(kind),
+                    None => {
+                        eprintln!(
+                            "Error: unknown string kind '{}' (expected url, route, format or message)",
+                            name.trim()
+                        );
+                        return ExitCode::GeneralError;
+                    }
+                }
+            }
+            let filter = crate::analysis::StringFilter {
+                pattern: pattern.or_else(|| params.get("pattern").cloned()),
+                kinds,
+                path: params.get("path").cloned(),
+            };
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_strings(indexer, &filter, format, fields)
+        }
+        RetrieveQuery::OwnedBy { args, json, fields } => {
+            use crate::io::args::parse_positional_args;
+
+            let (positional, params) = parse_positional_args(&args);
+            let Some(owner) = positional.or_else(|| params.get("owner").cloned()) else {
+                eprintln!("Error: owned-by requires a team or person from CODEOWNERS");
+                eprintln!("Usage: codanna retrieve owned-by @org/team");
+                return ExitCode::GeneralError;
+            };
+            let kinds = match params
+                .get("kind")
+                .map(|kind| kind.parse::<crate::SymbolKind>())
+            {
+                Some(Ok(kind)) => vec![kind],
+                Some(Err(e)) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+                None => Vec::new(),
+            };
+            let filter = crate::export::GraphFilter {
+                path: params.get("path").cloned(),
+                language: params.get("lang").map(|lang| lang.to_lowercase()),
+                kinds,
+                relations: Vec::new(),
+            };
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_owned_by(indexer, &owner, &filter, format, fields)
+        }
+        RetrieveQuery::Signature { args, json, fields } => {
+            use crate::io::args::parse_positional_args;
+
+            let (positional, params) = parse_positional_args(&args);
+            let Some(query) = positional.or_else(|| params.get("query").cloned()) else {
+                eprintln!("Error: signature requires a signature to search for");
+                eprintln!("Usage: codanna retrieve signature \"fn(&Path) -> Result<Vec<Symbol>>\"");
+                return ExitCode::GeneralError;
+            };
+            let kinds = match params
+                .get("kind")
+                .map(|kind| kind.parse::<crate::SymbolKind>())
+            {
+                Some(Ok(kind)) => vec![kind],
+                Some(Err(e)) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+                None => Vec::new(),
+            };
+            let filter = crate::export::GraphFilter {
+                path: params.get("path").cloned(),
+                language: params.get("lang").map(|lang| lang.to_lowercase()),
+                kinds,
+                relations: Vec::new(),
+            };
+            let limit = params
+                .get("limit")
+                .and_then(|limit| limit.parse::<usize>().ok())
+                .unwrap_or(20);
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_signature(indexer, &query, &filter, limit, format, fields)
+        }
+        RetrieveQuery::History { args, json } => {
+            use crate::io::args::parse_positional_args;
+
+            let (_, params) = parse_positional_args(&args);
+            let limit = params
+                .get("limit")
+                .and_then(|limit| limit.parse::<usize>().ok())
+                .unwrap_or(20);
+            super::query::history(indexer.settings(), limit, json)
+        }
+        RetrieveQuery::Similar { args, json, fields } => {
+            use crate::io::args::parse_positional_args;
+            use crate::semantic::SemanticFilter;
+
+            let (positional_symbol, params) = parse_positional_args(&args);
+            let Some(symbol) = positional_symbol
+                .or_else(|| params.get("symbol").cloned())
+                .or_else(|| params.get("symbol_id").map(|id| format!("symbol_id:{id}")))
+            else {
+                eprintln!("Error: similar requires a symbol name or symbol_id");
+                eprintln!("Usage: codanna retrieve similar parse_config");
+                eprintln!("   or: codanna retrieve similar symbol_id:1771");
+                return ExitCode::GeneralError;
+            };
+            let filter = match SemanticFilter::parse(
+                params.get("lang").map(|s| s.as_str()),
+                params.get("kind").map(|s| s.as_str()),
+                params.get("path").map(|s| s.as_str()),
+                None,
+            ) {
+                Ok(filter) => filter,
+                Err(e) => {
+                    eprintln!("Error: {e}");
+                    return ExitCode::GeneralError;
+                }
+            };
+            let limit = params
+                .get("limit")
+                .and_then(|limit| limit.parse::<usize>().ok())
+                .unwrap_or(10);
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_similar(indexer, &symbol, &filter, limit, format, fields)
+        }
         RetrieveQuery::Search {
             args,
             limit,
             json,
             kind,
             module,
+            mode,
+            context_lines,
             fields,
         } => {
             use crate::io::args::parse_positional_args;
+            use crate::symbol::name_match::SearchMode;
 
             // Parse positional arguments for query and key:value pairs
             let (positional_query, params) = parse_positional_args(&args);
@@ -140,6 +484,37 @@ pub fn run(query: RetrieveQuery, indexer: &IndexFacade) -> ExitCode {
             // Extract language filter
             let language = params.get("lang").map(|s| s.as_str());
 
+            // Unknown modes warn and fall back to full-text search, like kinds
+            let final_mode =
+                mode.or_else(|| params.get("mode").cloned())
+                    .map_or(SearchMode::default(), |m| {
+                        m.parse().unwrap_or_else(|e| {
+                            eprintln!("Warning: {e}, using text");
+                            SearchMode::default()
+                        })
+                    });
+
+            let final_context_lines = context_lines.or_else(|| {
+                params
+                    .get("context_lines")
+                    .and_then(|s| s.parse::<usize>().ok())
+            });
+
+            // Kept in the history as the search_symbols call it equals
+            if let serde_json::Value::Object(arguments) = serde_json::json!({
+                "query": final_query,
+                "limit": final_limit,
+                "kind": final_kind,
+                "module": final_module,
+                "lang": language,
+                "mode": (final_mode != SearchMode::Text).then(|| final_mode.as_str()),
+            }) {
+                crate::queries::record_query(
+                    indexer.settings(),
+                    crate::queries::QueryCall::new("search_symbols", arguments),
+                );
+            }
+
             // Call retrieve function with merged parameters
             let format = OutputFormat::from_json_flag(json);
             retrieve::retrieve_search(
@@ -149,10 +524,44 @@ pub fn run(query: RetrieveQuery, indexer: &IndexFacade) -> ExitCode {
                 final_kind.as_deref(),
                 final_module.as_deref(),
                 language,
+                final_mode,
+                final_context_lines,
                 format,
                 fields,
             )
         }
+        RetrieveQuery::Query {
+            args,
+            limit,
+            json,
+            fields,
+        } => {
+            // Words the shell kept together stay together
+            let query = args
+                .iter()
+                .map(|arg| {
+                    if arg.contains(char::is_whitespace) && !arg.contains('"') {
+                        format!("\"{arg}\"")
+                    } else {
+                        arg.clone()
+                    }
+                })
+                .collect::<Vec<_>>()
+                .join(" ");
+
+            if let serde_json::Value::Object(arguments) = serde_json::json!({
+                "query": query,
+                "limit": limit,
+            }) {
+                crate::queries::record_query(
+                    indexer.settings(),
+                    crate::queries::QueryCall::new("query_symbols", arguments),
+                );
+            }
+
+            let format = OutputFormat::from_json_flag(json);
+            retrieve::retrieve_query(indexer, &query, limit, format, fields)
+        }
         RetrieveQuery::Describe { args, json, fields } => {
             use crate::io::args::parse_positional_args;
 
@@ -177,5 +586,192 @@ pub fn run(query: RetrieveQuery, indexer: &IndexFacade) -> ExitCode {
             let format = OutputFormat::from_json_flag(json);
             retrieve::retrieve_describe(indexer, &final_symbol, language, format, fields)
         }
+        RetrieveQuery::Batch { .. } => unreachable!("retrieve batch is dispatched to run_batch"),
+    }
+}
+
+/// One line of `retrieve batch` input
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields)]
+struct BatchQuery {
+    /// Echoed on the result; the line number when left out
+    #[serde(default)]
+    id: Option<Value>,
+    tool: String,
+    #[serde(default, alias = "args")]
+    arguments: Map<String, Value>,
+}
+
+/// One line of `retrieve batch` output
+#[derive(Debug, Serialize)]
+struct BatchResult {
+    id: Value,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    tool: Option<String>,
+    status: &'static str,
+    result: String,
+}
+
+impl BatchResult {
+    fn error(id: Value, tool: Option<String>, message: impl Into<String>) -> Self {
+        Self {
+            id,
+            tool,
+            status: "error",
+            result: message.into(),
+        }
+    }
+}
+
+/// Run the tool calls of `input`, a JSON Lines file or `-` for stdin, on
+/// one server, printing a result line for each as it completes. Fails when
+/// any query does.
+pub async fn run_batch(input: &Path, mut facade: IndexFacade, config: &Settings) -> ExitCode {
+    let lines = if input == Path::new("-") {
+        std::io::stdin()
+            .lock()
+            .lines()
+            .collect::<Result<Vec<_>, _>>()
+    } else {
+        std::fs::read_to_string(input).map(|text| text.lines().map(str::to_string).collect())
+    };
+    let lines = match lines {
+        Ok(lines) => lines,
+        Err(e) => {
+            eprintln!("Error: failed to read {}: {e}", input.display());
+            return ExitCode::IoError;
+        }
+    };
+
+    // Blank lines and # comments are skipped, keeping line numbers
+    let queries: Vec<Result<(Value, QueryCall), BatchResult>> = lines
+        .iter()
+        .enumerate()
+        .filter(|(_, line)| {
+            let line = line.trim();
+            !line.is_empty() && !line.starts_with('#')
+        })
+        .map(|(index, line)| parse_batch_line(index + 1, line))
+        .collect();
+
+    // The model loads once, and only when a query embeds
+    let embeds = queries
+        .iter()
+        .flatten()
+        .any(|(_, call)| SEMANTIC_TOOLS.contains(&call.tool.as_str()));
+    if embeds && config.semantic_search.enabled && !facade.has_semantic_search() {
+        if let Err(e) = facade.load_semantic_search(&config.index_path.join("semantic")) {
+            eprintln!("Warning: semantic search is unavailable: {e}");
+        }
+    }
+    let needs_documents = queries
+        .iter()
+        .flatten()
+        .any(|(_, call)| call.tool == "search_documents");
+
+    let server = crate::mcp::CodeIntelligenceServer::new(facade);
+    let server = match needs_documents
+        .then(|| crate::documents::load_from_settings(config))
+        .flatten()
+    {
+        Some(store) => server.with_document_store_arc(store),
+        None => server,
+    };
+
+    let mut failed = 0;
+    for query in queries {
+        let result = match query {
+            Ok((id, call)) => match server.run_query_call(&call).await {
+                Ok(result) => {
+                    let text = result
+                        .content
+                        .iter()
+                        .filter_map(|content| match content {
+                            rmcp::model::ContentBlock::Text(text) => Some(text.text.as_str()),
+                            _ => None,
+                        })
+                        .collect::<Vec<_>>()
+                        .join("\n");
+                    if result.is_error == Some(true) {
+                        BatchResult::error(id, Some(call.tool), text)
+                    } else {
+                        BatchResult {
+                            id,
+                            tool: Some(call.tool),
+                            status: "success",
+                            result: text,
+                        }
+                    }
+                }
+                Err(e) => BatchResult::error(id, Some(call.tool), e.message.to_string()),
+            },
+            Err(result) => result,
+        };
+        if result.status == "error" {
+            failed += 1;
+        }
+        println!(
+            "{}",
+            serde_json::to_string(&result).expect("result serialization")
+        );
+    }
+
+    if failed > 0 {
+        eprintln!("{failed} queries failed");
+        ExitCode::GeneralError
+    } else {
+        ExitCode::Success
+    }
+}
+
+/// The id and tool call of input line `number`, or the result saying why
+/// it cannot run
+fn parse_batch_line(number: usize, line: &str) -> Result<(Value, QueryCall), BatchResult> {
+    let query: BatchQuery = serde_json::from_str(line).map_err(|e| {
+        BatchResult::error(
+            Value::from(number),
+            None,
+            format!("line {number} is not a query: {e}"),
+        )
+    })?;
+    let id = query.id.unwrap_or_else(|| Value::from(number));
+    if !KNOWN_TOOLS.contains(&query.tool.as_str()) {
+        let message = format!(
+            "unknown tool '{}'. Available tools: {}",
+            query.tool,
+            KNOWN_TOOLS.join(", ")
+        );
+        return Err(BatchResult::error(id, Some(query.tool), message));
+    }
+    let call = QueryCall::new(&query.tool, query.arguments);
+    match check_call(&call) {
+        Ok(()) => Ok((id, call)),
+        Err(message) => Err(BatchResult::error(id, Some(call.tool), message)),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_batch_line() {
+        let (id, call) = parse_batch_line(
+            1,
+            r#"{"id": "q1", "tool": "find_callers", "arguments": {"function_name": "parse"}}"#,
+        )
+        .unwrap();
+        assert_eq!(id, Value::from("q1"));
+        assert_eq!(call.tool, "find_callers");
+
+        // The line number stands in for a missing id
+        let (id, _) = parse_batch_line(3, r#"{"tool": "get_index_info"}"#).unwrap();
+        assert_eq!(id, Value::from(3));
+
+        let unknown = parse_batch_line(4, r#"{"tool": "find_everything"}"#).unwrap_err();
+        assert!(unknown.result.starts_with("unknown tool"));
+        let missing = parse_batch_line(5, r#"{"id": 7, "tool": "find_symbol"}"#).unwrap_err();
+        assert_eq!((missing.id, missing.status), (Value::from(7), "error"));
+        assert!(parse_batch_line(6, "find_symbol main").is_err());
     }
 }
@@ -1,12 +1,14 @@
-//! Serve command - MCP server modes (stdio, HTTP, HTTPS).
+//! Serve command - MCP server modes (stdio, HTTP, HTTPS, WebSocket, SSE).
 
 use std::fs::OpenOptions;
 use std::io::Write;
 use std::path::{Path, PathBuf};
 use std::sync::Arc;
 
-use crate::config::Settings;
+use crate::cli::TokenAction;
+use crate::config::{ServerToken, Settings};
 use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
 
 /// PID lockfile guard for stdio MCP servers. Prevents two concurrent
 /// `codanna serve` (stdio) processes from racing the tantivy writer on the
@@ -83,7 +85,18 @@ impl ServeLockGuard {
     }
 }
 
-fn read_lock_pid(lock_path: &Path) -> Option<u32> {
+/// The stdio serve lock of the index at `index_path`, None for a read-only
+/// server: it never writes the index, so it runs next to any other
+fn serve_lock(
+    config: &Settings,
+    index_path: &Path,
+) -> Result<Option<ServeLockGuard>, ServeLockError> {
+    (!config.server.read_only)
+        .then(|| ServeLockGuard::acquire(index_path))
+        .transpose()
+}
+
+pub(super) fn read_lock_pid(lock_path: &Path) -> Option<u32> {
     std::fs::read_to_string(lock_path)
         .ok()
         .and_then(|s| s.trim().parse::<u32>().ok())
@@ -95,7 +108,7 @@ impl Drop for ServeLockGuard {
     }
 }
 
-fn pid_is_alive(pid: u32) -> bool {
+pub(super) fn pid_is_alive(pid: u32) -> bool {
     use sysinfo::{Pid, ProcessRefreshKind, ProcessesToUpdate, System};
     let mut sys = System::new();
     let pid = Pid::from_u32(pid);
@@ -107,19 +120,69 @@ fn pid_is_alive(pid: u32) -> bool {
     sys.process(pid).is_some()
 }
 
+/// Held shared by every server of an index while it runs, whatever its
+/// transport and read-only or not, so a command replacing the index finds
+/// them all. `serve.lock` only keeps writing stdio servers apart.
+const SERVERS_LOCK: &str = "servers.lock";
+
+fn open_servers_lock(index_path: &Path) -> std::io::Result<std::fs::File> {
+    std::fs::create_dir_all(index_path)?;
+    OpenOptions::new()
+        .read(true)
+        .write(true)
+        .create(true)
+        .truncate(false)
+        .open(index_path.join(SERVERS_LOCK))
+}
+
+/// Register a server of the index at `index_path` until the file is closed,
+/// or None while `codanna snapshot restore` replaces the index. Not waiting
+/// for it: the file goes with the replaced index.
+pub(super) fn register_server(index_path: &Path) -> std::io::Result<Option<std::fs::File>> {
+    let lock = open_servers_lock(index_path)?;
+    match lock.try_lock_shared() {
+        Ok(()) => Ok(Some(lock)),
+        Err(std::fs::TryLockError::WouldBlock) => Ok(None),
+        Err(std::fs::TryLockError::Error(e)) => Err(e),
+    }
+}
+
+/// Keep servers of the index at `index_path` from starting while the file
+/// is open, or None when one is running
+pub(super) fn exclude_servers(index_path: &Path) -> std::io::Result<Option<std::fs::File>> {
+    let lock = open_servers_lock(index_path)?;
+    match lock.try_lock() {
+        Ok(()) => Ok(Some(lock)),
+        Err(std::fs::TryLockError::WouldBlock) => Ok(None),
+        Err(std::fs::TryLockError::Error(e)) => Err(e),
+    }
+}
+
 /// Arguments for the serve command.
 pub struct ServeArgs {
     pub watch: bool,
     pub watch_interval: u64,
     pub http: bool,
     pub https: bool,
+    /// Also serve MCP over WebSocket, on the HTTP or HTTPS server
+    pub ws: bool,
+    /// Also serve MCP over SSE, on the HTTP or HTTPS server
+    pub sse: bool,
+    /// Other projects to serve, as name=path, next to `[server.projects]`
+    pub projects: Vec<String>,
     pub bind: String,
+    /// Serve MCP on this Unix domain socket instead of stdio
+    pub uds: Option<PathBuf>,
+    /// Seconds without a client after which the socket server exits
+    pub idle_timeout: Option<u64>,
+    /// Never write the index, next to `server.read_only`
+    pub read_only: bool,
 }
 
 /// Run the serve command.
 pub async fn run(
     args: ServeArgs,
-    config: Settings,
+    mut config: Settings,
     settings: Arc<Settings>,
     facade: IndexFacade,
     index_path: PathBuf,
@@ -129,16 +192,51 @@ pub async fn run(
         watch_interval,
         http,
         https,
+        ws,
+        sse,
+        projects,
         bind,
+        uds,
+        idle_timeout,
+        read_only,
     } = args;
 
+    for project in projects {
+        let Some((name, path)) = project.split_once('=') else {
+            eprintln!("Error: --project expects NAME=PATH, got '{project}'");
+            std::process::exit(1);
+        };
+        let path = std::path::absolute(path).unwrap_or_else(|_| PathBuf::from(path));
+        config.server.projects.insert(name.to_string(), path);
+    }
+    config.server.read_only |= read_only;
+
+    // Released when the process exits, process::exit included
+    let _registered = match register_server(&index_path) {
+        Ok(Some(lock)) => lock,
+        Ok(None) => {
+            eprintln!("The index is being restored from a snapshot. Start the server once it is.");
+            std::process::exit(1);
+        }
+        Err(e) => {
+            eprintln!(
+                "Failed to register the server under {}: {e}",
+                index_path.display()
+            );
+            std::process::exit(1);
+        }
+    };
+
     // Determine server mode:
     // 1. CLI --https flag takes highest precedence
-    // 2. CLI --http flag takes second precedence
+    // 2. CLI --http, --ws or --sse flag takes second precedence
     // 3. Otherwise, check config.server.mode
-    let server_mode = if https {
+    // --uds excludes the network flags and serves like stdio
+    let server_mode = if uds.is_some() {
+        "uds"
+    } else if https {
         "https"
-    } else if http || config.server.mode == "http" {
+    } else if http || ws || sse || config.server.mode == "http" {
         "http"
     } else {
         "stdio"
@@ -167,10 +265,10 @@ pub async fn run(
 
     match server_mode {
         "https" => {
-            run_https_server(&config, watch, bind_address).await;
+            run_https_server(&config, watch, bind_address, ws, sse).await;
         }
         "http" => {
-            run_http_server(config, watch, bind_address).await;
+            run_http_server(config, watch, bind_address, ws, sse).await;
         }
         _ => {
             run_stdio_server(
@@ -180,16 +278,24 @@ pub async fn run(
                 index_path,
                 watch,
                 actual_watch_interval,
+                uds,
+                idle_timeout,
             )
             .await;
         }
     }
 }
 
-async fn run_https_server(config: &Settings, watch: bool, bind_address: String) {
+async fn run_https_server(
+    config: &Settings,
+    watch: bool,
+    bind_address: String,
+    ws: bool,
+    sse: bool,
+) {
     // HTTPS mode - secure server with TLS
     tracing::info!(target: "mcp", "starting HTTPS server on {bind_address}");
-    if watch || config.file_watch.enabled {
+    if !config.server.read_only && (watch || config.file_watch.enabled) {
         tracing::debug!(
             target: "mcp",
             "file watching enabled with {}ms debounce",
@@ -201,7 +307,7 @@ async fn run_https_server(config: &Settings, watch: bool, bind_address: String)
     #[cfg(feature = "https-server")]
     {
         use crate::mcp::https_server::serve_https;
-        if let Err(e) = serve_https(config.clone(), watch, bind_address).await {
+        if let Err(e) = serve_https(config.clone(), watch, bind_address, ws, sse).await {
             eprintln!("HTTPS server error: {e}");
             std::process::exit(1);
         }
@@ -215,20 +321,23 @@ async fn run_https_server(config: &Settings, watch: bool, bind_address: String)
     }
 }
 
-async fn run_http_server(config: Settings, watch: bool, bind_address: String) {
+async fn run_http_server(config: Settings, watch: bool, bind_address: String, ws: bool, sse: bool) {
     // HTTP mode - persistent server with event-driven file watching
     eprintln!("Starting MCP server in HTTP mode");
     eprintln!("Bind address: {bind_address}");
-    if watch || config.file_watch.enabled {
+    if !config.server.read_only && (watch || config.file_watch.enabled) {
         eprintln!(
             "File watching: ENABLED (event-driven with {}ms debounce)",
             config.file_watch.debounce_ms
         );
     }
+    if config.server.read_only {
+        eprintln!("Read-only: the index is never written, file watching is off");
+    }
 
     // Use the HTTP server implementation
     use crate::mcp::http_server::serve_http;
-    if let Err(e) = serve_http(config, watch, bind_address).await {
+    if let Err(e) = serve_http(config, watch, bind_address, ws, sse).await {
         eprintln!("HTTP server error: {e}");
         std::process::exit(1);
     }
@@ -241,12 +350,14 @@ async fn run_stdio_server(
     index_path: PathBuf,
     watch: bool,
     actual_watch_interval: u64,
+    uds: Option<PathBuf>,
+    idle_timeout: Option<u64>,
 ) {
     // Acquire the stdio serve lock before doing anything else. Bound at
     // function scope so the guard removes the lockfile on return / unwind.
     // The process::exit arms below must drop it explicitly: exit skips
     // destructors and would leave the lockfile behind.
-    let serve_lock = match ServeLockGuard::acquire(&index_path) {
+    let serve_lock = match serve_lock(&config, &index_path) {
         Ok(guard) => guard,
         Err(ServeLockError::AlreadyRunning { pid, lock_path }) => {
             eprintln!(
@@ -257,7 +368,8 @@ async fn run_stdio_server(
             eprintln!("Subagents and other AI tools may have spawned a duplicate. To run multiple");
             eprintln!("clients against one index, use HTTP mode:");
             eprintln!("  codanna serve --http --watch");
-            eprintln!("HTTP mode supports concurrent clients without lock conflicts.");
+            eprintln!("  codanna serve --uds .codanna/codanna.sock --watch");
+            eprintln!("Both modes support concurrent clients without lock conflicts.");
             eprintln!();
             eprintln!(
                 "If you are sure no other codanna serve is running, remove {} and retry.",
@@ -274,11 +386,24 @@ async fn run_stdio_server(
         }
     };
 
+    // A read-only server follows the index others write instead of
+    // watching the source files itself
+    let crate::mcp::Watching {
+        hot_reload: watch,
+        file_watch,
+    } = crate::mcp::Watching::of(&config, watch);
+
     // stdio mode - current implementation
-    eprintln!("Starting MCP server on stdio transport");
+    match &uds {
+        Some(path) => eprintln!("Starting MCP server on unix socket {}", path.display()),
+        None => eprintln!("Starting MCP server on stdio transport"),
+    }
     if watch {
         eprintln!("Index watching enabled (interval: {actual_watch_interval}s)");
     }
+    if config.server.read_only {
+        eprintln!("Read-only: the index is never written, file watching is off");
+    }
     eprintln!("To test: npx @modelcontextprotocol/inspector cargo run -- serve");
 
     // Create MCP server using the already-loaded facade
@@ -288,7 +413,14 @@ async fn run_stdio_server(
         facade.symbol_count(),
         facade.has_semantic_search()
     );
-    let server = crate::mcp::CodeIntelligenceServer::new(facade);
+    let projects = Arc::new(crate::mcp::projects::Projects::load(&config));
+    let limits = Arc::new(crate::mcp::limits::RequestLimits::from_config(
+        &config.server,
+    ));
+    let server = crate::mcp::CodeIntelligenceServer::new(facade)
+        .with_projects(projects.clone())
+        .with_limits(limits.clone())
+        .with_read_only(config.server.read_only);
 
     // Load document store and attach to server (shared with watcher later)
     let document_store_arc = crate::documents::load_from_settings(&config);
@@ -316,11 +448,13 @@ async fn run_stdio_server(
             watcher.watch().await;
         });
 
+        projects.spawn_hot_reload(Duration::from_secs(actual_watch_interval));
+
         eprintln!("Hot-reload watcher started");
     }
 
     // Start unified file watcher if enabled
-    if watch || config.file_watch.enabled {
+    if file_watch {
         use crate::mcp::notifications::NotificationBroadcaster;
         use crate::watcher::UnifiedWatcher;
         use crate::watcher::handlers::{CodeFileHandler, ConfigFileHandler, DocumentFileHandler};
@@ -342,7 +476,7 @@ async fn run_stdio_server(
             .indexer(facade_arc.clone())
             .index_path(index_path.clone())
             .workspace_root(workspace_root.clone())
-            .debounce_ms(debounce_ms);
+            .file_watch(&config.file_watch);
 
         // Add code file handler
         builder = builder.handler(CodeFileHandler::new(
@@ -351,7 +485,7 @@ async fn run_stdio_server(
         ));
 
         // Add config file handler
-        match ConfigFileHandler::new(settings_path.clone()) {
+        match ConfigFileHandler::new(settings_path.clone(), config.profile.clone()) {
             Ok(config_handler) => {
                 builder = builder.handler(config_handler);
             }
@@ -399,6 +533,32 @@ async fn run_stdio_server(
         }
     }
 
+    // The index a shutdown saves, once the file watcher may have changed it
+    let watched_facade = file_watch.then(|| server.get_facade_arc());
+
+    // Or serve every client that connects to the socket
+    if let Some(path) = uds {
+        #[cfg(unix)]
+        let result = run_uds_server(
+            server,
+            &path,
+            idle_timeout,
+            &config,
+            &limits,
+            watched_facade.as_deref(),
+        )
+        .await;
+        #[cfg(not(unix))]
+        let result: Result<(), String> =
+            Err("Unix domain sockets are not supported on this platform".to_string());
+        if let Err(e) = result {
+            eprintln!("{e}");
+            drop(serve_lock);
+            std::process::exit(1);
+        }
+        return;
+    }
+
     // Start server with stdio transport
     use rmcp::{ServiceExt, transport::stdio};
     let service = match server.serve(stdio()).await {
@@ -410,11 +570,113 @@ async fn run_stdio_server(
         }
     };
 
-    // Wait for server to complete
-    if let Err(e) = service.waiting().await {
-        eprintln!("MCP server error: {e}");
-        drop(serve_lock);
-        std::process::exit(1);
+    // Wait for server to complete, or drain in-flight tool calls on
+    // SIGTERM/Ctrl+C while it keeps answering them
+    let shutdown = async {
+        crate::mcp::shutdown::signal().await;
+        crate::mcp::shutdown::drain(
+            &limits,
+            config.server.shutdown_timeout_secs,
+            watched_facade.as_deref(),
+        )
+        .await;
+    };
+    tokio::select! {
+        result = service.waiting() => {
+            if let Err(e) = result {
+                eprintln!("MCP server error: {e}");
+                drop(serve_lock);
+                std::process::exit(1);
+            }
+        }
+        _ = shutdown => {
+            eprintln!("MCP server shut down gracefully");
+        }
+    }
+}
+
+/// Serve a session of `server` to each connection on the socket at `path`
+/// until SIGTERM/Ctrl+C, then drain them like stdio, or until no client
+/// has been connected for `idle_timeout` seconds
+#[cfg(unix)]
+async fn run_uds_server(
+    server: crate::mcp::CodeIntelligenceServer,
+    path: &Path,
+    idle_timeout: Option<u64>,
+    config: &Settings,
+    limits: &crate::mcp::limits::RequestLimits,
+    watched_facade: Option<&tokio::sync::RwLock<IndexFacade>>,
+) -> Result<(), String> {
+    use crate::mcp::uds_server::{UdsListener, parse_socket_mode};
+    use tokio_util::sync::CancellationToken;
+
+    let mode = parse_socket_mode(&config.server.socket_mode)?;
+    let listener = UdsListener::bind(path, mode)
+        .map_err(|e| format!("Failed to listen on {}: {e}", path.display()))?;
+    eprintln!(
+        "MCP server listening on unix socket {} (mode {mode:o})",
+        path.display()
+    );
+
+    let ct = CancellationToken::new();
+    let shutdown = async {
+        crate::mcp::shutdown::signal().await;
+        crate::mcp::shutdown::drain(limits, config.server.shutdown_timeout_secs, watched_facade)
+            .await;
+    };
+    let idle_timeout = idle_timeout.map(std::time::Duration::from_secs);
+    tokio::select! {
+        _ = listener.serve(|| server.for_new_connection(), ct.clone(), idle_timeout) => {
+            let idle = idle_timeout.unwrap_or_default().as_secs();
+            eprintln!("MCP server stopped after {idle}s without a client");
+        }
+        _ = shutdown => {
+            eprintln!("MCP server shut down gracefully");
+        }
+    }
+    ct.cancel();
+    Ok(())
+}
+
+/// Run `codanna serve token`: tokens go into the settings file as digests,
+/// so the token itself is shown only here.
+pub fn run_token(action: TokenAction, cli_config: Option<&Path>) -> ExitCode {
+    use crate::mcp::auth::{generate_token, token_digest};
+
+    match action {
+        TokenAction::Create { name } => {
+            let config_path = super::directories::resolve_config_path(cli_config);
+            let mut settings = match Settings::load_from(&config_path) {
+                Ok(settings) => settings,
+                Err(e) => {
+                    eprintln!("Error loading configuration: {e}");
+                    return ExitCode::ConfigError;
+                }
+            };
+            if settings.server.tokens.iter().any(|t| t.name == name) {
+                eprintln!("Error: a token named '{name}' already exists");
+                eprintln!("Remove it from {} first", config_path.display());
+                return ExitCode::GeneralError;
+            }
+
+            let token = generate_token();
+            settings.server.tokens.push(ServerToken {
+                name: name.clone(),
+                token: None,
+                sha256: Some(token_digest(&token)),
+            });
+            if let Err(e) = settings.save(&config_path) {
+                eprintln!("Error saving configuration: {e}");
+                return ExitCode::IoError;
+            }
+
+            println!("Created token '{name}' in {}", config_path.display());
+            println!("\n  {token}\n");
+            println!("It is not stored and will not be shown again.");
+            println!("Clients send it as: Authorization: Bearer <token>");
+            println!("Restart a running codanna serve --http/--https to apply it.");
+            ExitCode::Success
+        }
     }
 }
 
@@ -483,6 +745,21 @@ mod serve_lock_tests {
         );
     }
 
+    #[test]
+    fn read_only_server_takes_no_lock() {
+        let dir = TempDir::new().unwrap();
+        let mut config = Settings::default();
+        config.server.read_only = true;
+        assert!(serve_lock(&config, dir.path()).unwrap().is_none());
+        assert!(!dir.path().join("serve.lock").exists());
+
+        // A server that writes takes it
+        config.server.read_only = false;
+        let guard = serve_lock(&config, dir.path()).unwrap();
+        assert!(guard.is_some());
+        assert!(dir.path().join("serve.lock").exists());
+    }
+
     #[test]
     fn second_acquire_blocks_when_first_is_alive() {
         let dir = TempDir::new().unwrap();
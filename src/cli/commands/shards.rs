@@ -0,0 +1,188 @@
+//! Shards command - query the shards of a monorepo together.
+
+use crate::cli::args::ShardsAction;
+use crate::config::Settings;
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+use crate::shard::{ShardLink, ShardSet};
+use crate::symbol::Symbol;
+use serde::Serialize;
+use std::path::PathBuf;
+
+/// A shard as `shards list --json` prints it
+#[derive(Debug, Serialize)]
+struct ListedShard {
+    name: String,
+    path: PathBuf,
+    indexed: bool,
+    symbols: usize,
+    files: u32,
+    /// References the shard resolved no symbol for
+    unresolved: usize,
+}
+
+/// A symbol with the shard holding it, as `shards find --json` prints it
+#[derive(Debug, Serialize)]
+struct ShardSymbol<'a> {
+    shard: &'a str,
+    #[serde(flatten)]
+    symbol: Symbol,
+}
+
+/// Run the shards command.
+pub fn run(action: ShardsAction, config: &Settings) -> ExitCode {
+    if config.indexing.shards.is_empty() {
+        eprintln!("Error: settings.toml has no [indexing.shards]");
+        return ExitCode::ConfigError;
+    }
+    let set = match ShardSet::open(config) {
+        Ok(set) => set,
+        Err(e) => {
+            eprintln!("Error: {e}");
+            return ExitCode::GeneralError;
+        }
+    };
+
+    match action {
+        ShardsAction::List { json } => list(&set, config, json),
+        ShardsAction::Find { name, lang, json } => {
+            warn_unindexed(&set);
+            find(&set, &name, lang.as_deref(), json)
+        }
+        ShardsAction::Links { symbol, json } => {
+            warn_unindexed(&set);
+            links(&set, symbol.as_deref(), json)
+        }
+    }
+}
+
+fn warn_unindexed(set: &ShardSet) {
+    for name in set.unindexed() {
+        eprintln!("Warning: shard '{name}' is not indexed; run 'codanna --shard {name} index'");
+    }
+}
+
+fn list(set: &ShardSet, config: &Settings, json: bool) -> ExitCode {
+    let shards: Vec<ListedShard> = config
+        .indexing
+        .shards
+        .iter()
+        .map(|(name, path)| {
+            let facade = set
+                .shards()
+                .find(|(shard, _)| *shard == name.as_str())
+                .map(|(_, facade)| facade);
+            ListedShard {
+                name: name.clone(),
+                path: path.clone(),
+                indexed: facade.is_some(),
+                symbols: facade.map_or(0, |facade| facade.symbol_count()),
+                files: facade.map_or(0, |facade| facade.file_count()),
+                unresolved: facade.map_or(0, |facade| facade.get_unresolved().len()),
+            }
+        })
+        .collect();
+
+    if json {
+        let envelope = Envelope::success(&shards)
+            .with_entity_type(EntityType::Project)
+            .with_count(shards.len())
+            .with_message(format!("Found {} shard(s)", shards.len()));
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+        return ExitCode::Success;
+    }
+    for shard in &shards {
+        if shard.indexed {
+            println!(
+                "{}  {}  {} symbols, {} files, {} unresolved",
+                shard.name,
+                shard.path.display(),
+                shard.symbols,
+                shard.files,
+                shard.unresolved
+            );
+        } else {
+            println!(
+                "{}  {}  not indexed (run 'codanna --shard {} index')",
+                shard.name,
+                shard.path.display(),
+                shard.name
+            );
+        }
+    }
+    ExitCode::Success
+}
+
+fn find(set: &ShardSet, name: &str, lang: Option<&str>, json: bool) -> ExitCode {
+    let found: Vec<ShardSymbol> = set
+        .find_symbols_by_name(name, lang)
+        .into_iter()
+        .map(|(shard, symbol)| ShardSymbol { shard, symbol })
+        .collect();
+
+    if found.is_empty() {
+        let message = format!("No symbol named '{name}' in the shards");
+        if json {
+            let envelope = Envelope::not_found(message);
+            println!("{}", envelope.to_json().expect("envelope serialization"));
+        } else {
+            println!("{message}");
+        }
+        return ExitCode::NotFound;
+    }
+    if json {
+        let envelope = Envelope::success(&found)
+            .with_entity_type(EntityType::Symbol)
+            .with_count(found.len())
+            .with_query(name)
+            .with_message(format!("Found {} symbol(s)", found.len()));
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+        return ExitCode::Success;
+    }
+    for ShardSymbol { shard, symbol } in &found {
+        println!(
+            "[{shard}] {:?} {}  {}:{}",
+            symbol.kind,
+            symbol.name,
+            symbol.file_path,
+            symbol.range.start_line + 1
+        );
+    }
+    ExitCode::Success
+}
+
+fn links(set: &ShardSet, symbol: Option<&str>, json: bool) -> ExitCode {
+    let links: Vec<ShardLink> = set
+        .links()
+        .into_iter()
+        .filter(|link| symbol.is_none_or(|name| *link.from.name == *name || *link.to.name == *name))
+        .collect();
+
+    if json {
+        let envelope = Envelope::success(&links)
+            .with_entity_type(EntityType::Reference)
+            .with_count(links.len())
+            .with_message(format!("Found {} link(s) between shards", links.len()));
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+        return ExitCode::Success;
+    }
+    if links.is_empty() {
+        println!("No links between shards");
+        return ExitCode::Success;
+    }
+    for link in &links {
+        let kind = format!("{:?}", link.kind).to_lowercase();
+        println!(
+            "[{}] {} {kind} [{}] {}  {}:{} -> {}:{}",
+            link.from_shard,
+            link.from.name,
+            link.to_shard,
+            link.to.name,
+            link.from.file_path,
+            link.line + 1,
+            link.to.file_path,
+            link.to.range.start_line + 1
+        );
+    }
+    ExitCode::Success
+}
@@ -0,0 +1,277 @@
+//! Snapshot command - pack the index into a portable archive and restore it.
+
+use crate::archive::{self, ARCHIVE_EXTENSION, ArchiveManifest};
+use crate::cli::args::SnapshotAction;
+use crate::config::Settings;
+use crate::indexing::pipeline::metrics::format_bytes;
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+use crate::storage::{IndexLease, LeaseError};
+use serde::Serialize;
+use std::path::{Path, PathBuf};
+
+/// An archive as `snapshot list --json` prints it
+#[derive(Debug, Serialize)]
+struct ListedArchive {
+    path: PathBuf,
+    #[serde(flatten)]
+    manifest: ArchiveManifest,
+    /// Whether it fits this codanna and its settings
+    compatible: bool,
+}
+
+/// Run the snapshot command.
+pub fn run(action: SnapshotAction, config: &Settings) -> ExitCode {
+    match action {
+        SnapshotAction::Create { output, json } => create(output, json, config),
+        SnapshotAction::List { dir, json } => list(dir, json, config),
+        SnapshotAction::Restore { archive, force } => restore(&archive, force, config),
+    }
+}
+
+fn create(output: Option<PathBuf>, json: bool, config: &Settings) -> ExitCode {
+    let output =
+        output.unwrap_or_else(|| archive::archives_dir(config).join(archive::archive_name()));
+    let manifest = match archive::create_archive(config, &output) {
+        Ok(manifest) => manifest,
+        Err(e) => {
+            eprintln!("Error: {e}");
+            return ExitCode::GeneralError;
+        }
+    };
+    let size = std::fs::metadata(&output).map(|m| m.len()).unwrap_or(0);
+    let message = format!(
+        "Archived {} symbols across {} files to {} ({})",
+        manifest.symbols,
+        manifest.files,
+        output.display(),
+        format_bytes(size)
+    );
+    if json {
+        let archived = ListedArchive {
+            path: output,
+            manifest,
+            compatible: true,
+        };
+        let envelope = Envelope::success(&archived)
+            .with_entity_type(EntityType::Project)
+            .with_message(message);
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+    } else {
+        println!("{message}");
+    }
+    ExitCode::Success
+}
+
+fn list(dir: Option<PathBuf>, json: bool, config: &Settings) -> ExitCode {
+    let dir = dir.unwrap_or_else(|| archive::archives_dir(config));
+    let archives: Vec<ListedArchive> = archive::list_archives(&dir)
+        .into_iter()
+        .map(|(path, manifest)| ListedArchive {
+            compatible: manifest.problems(config).is_empty(),
+            path,
+            manifest,
+        })
+        .collect();
+
+    if json {
+        let envelope = Envelope::success(&archives)
+            .with_entity_type(EntityType::Project)
+            .with_count(archives.len())
+            .with_message(format!("Found {} archive(s)", archives.len()));
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+        return ExitCode::Success;
+    }
+    if archives.is_empty() {
+        println!("No archives in {}", dir.display());
+        println!("Create one with 'codanna snapshot create'.");
+        return ExitCode::Success;
+    }
+    for listed in &archives {
+        let manifest = &listed.manifest;
+        let created = chrono::DateTime::from_timestamp(manifest.created_at as i64, 0)
+            .map(|date| date.format("%Y-%m-%d %H:%M").to_string())
+            .unwrap_or_default();
+        let name = listed.path.file_name().unwrap_or_default();
+        let fit = if listed.compatible {
+            ""
+        } else {
+            "  (incompatible)"
+        };
+        println!(
+            "{}  {created}  {}  {} symbols, {} files  codanna {}{fit}",
+            name.to_string_lossy(),
+            manifest.short_commit().unwrap_or("-"),
+            manifest.symbols,
+            manifest.files,
+            manifest.codanna_version
+        );
+    }
+    ExitCode::Success
+}
+
+fn restore(archive: &Path, force: bool, config: &Settings) -> ExitCode {
+    let archive = resolve(archive, config);
+    let manifest = match archive::read_manifest(&archive) {
+        Ok(manifest) => manifest,
+        Err(e) => {
+            eprintln!("Error: {e}");
+            return ExitCode::GeneralError;
+        }
+    };
+    let problems = manifest.problems(config);
+    if !problems.is_empty() {
+        for problem in &problems {
+            eprintln!("{}: {problem}", archive.display());
+        }
+        if !force {
+            eprintln!("Error: the archive does not fit this project");
+            eprintln!("Reindex with 'codanna index --force', or restore it anyway with --force.");
+            return ExitCode::ConfigError;
+        }
+    }
+
+    // The index is replaced under whoever has it open
+    super::embed::stop_background(config);
+    let _servers = match super::serve::exclude_servers(&config.index_path) {
+        Ok(Some(lock)) => lock,
+        Ok(None) => {
+            eprintln!("Error: a server is using the index. Stop it first.");
+            return ExitCode::GeneralError;
+        }
+        Err(e) => {
+            eprintln!("Error: {e}");
+            return ExitCode::GeneralError;
+        }
+    };
+    if let Some(user) = in_use(config) {
+        eprintln!("Error: {user}");
+        return ExitCode::GeneralError;
+    }
+
+    // Held across the swap, so no writer starts on the index being
+    // replaced, and released with the generation bumped, so readers
+    // reload even when the restored files keep older modification times.
+    // A writer holding it is refused rather than waited for, like a server.
+    let lease = match IndexLease::try_acquire(&config.index_path, "codanna snapshot restore") {
+        Ok(lease) => lease,
+        Err(e @ LeaseError::Held(_)) => {
+            eprintln!("Error: {e}. Restore once it finishes.");
+            return ExitCode::GeneralError;
+        }
+        Err(e) => {
+            eprintln!("Error: {e}");
+            return ExitCode::GeneralError;
+        }
+    };
+    if let Err(e) = archive::restore_archive(config, &archive) {
+        eprintln!("Error: {e}");
+        return ExitCode::GeneralError;
+    }
+    drop(lease);
+    let built = match manifest.short_commit() {
+        Some(commit) => format!(" of commit {commit}"),
+        None => String::new(),
+    };
+    println!(
+        "Restored the index{built}: {} symbols across {} files",
+        manifest.symbols, manifest.files
+    );
+    eprintln!("Run 'codanna index' to index the changes since it was built.");
+    ExitCode::Success
+}
+
+/// Why the index cannot be replaced now, besides the servers registered in
+/// `servers.lock` and a writer holding the lease: a stdio server of an
+/// older codanna, or the background server of `codanna mcp`
+fn in_use(config: &Settings) -> Option<String> {
+    let index_path = &config.index_path;
+    if let Some(pid) = super::serve::read_lock_pid(&index_path.join("serve.lock")) {
+        if super::serve::pid_is_alive(pid) {
+            return Some(format!(
+                "a server (pid {pid}) is using the index. Stop it first."
+            ));
+        }
+    }
+    #[cfg(unix)]
+    {
+        let socket = crate::mcp::daemon::socket_path(index_path);
+        if std::os::unix::net::UnixStream::connect(&socket).is_ok() {
+            return Some(format!(
+                "the background server on {} is using the index. Stop it first.",
+                socket.display()
+            ));
+        }
+    }
+    None
+}
+
+/// `archive`, or the archive of that name in the archives directory
+fn resolve(archive: &Path, config: &Settings) -> PathBuf {
+    if archive.exists() || archive.components().count() > 1 {
+        return archive.to_path_buf();
+    }
+    let dir = archive::archives_dir(config);
+    let named = dir.join(archive);
+    if named.exists() {
+        return named;
+    }
+    dir.join(format!("{}.{ARCHIVE_EXTENSION}", archive.display()))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::storage::{EMISSION_SEMANTICS_VERSION, IndexMetadata};
+    use std::fs;
+
+    /// Settings of a project at `root` with an index of the files restore
+    /// looks at
+    fn project(root: &Path) -> Settings {
+        let root = root.canonicalize().unwrap();
+        let mut settings = Settings::default();
+        settings.workspace_root = Some(root.clone());
+        settings.index_path = root.join(".codanna/index");
+        fs::create_dir_all(settings.index_path.join("tantivy")).unwrap();
+        fs::write(settings.index_path.join("tantivy/meta.json"), "local").unwrap();
+        let mut metadata = IndexMetadata::new();
+        metadata.update_counts(10, 2);
+        metadata.emission_version = Some(EMISSION_SEMANTICS_VERSION);
+        metadata.save(&settings.index_path).unwrap();
+        settings
+    }
+
+    #[test]
+    fn test_restore_refuses_while_the_index_is_in_use() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = project(dir.path());
+        let index = &settings.index_path;
+        let output = dir.path().join(archive::archive_name());
+        archive::create_archive(&settings, &output).unwrap();
+        let untouched = || fs::read_to_string(index.join("tantivy/meta.json")).unwrap() == "local";
+
+        // A writer
+        let lease = IndexLease::try_acquire(index, "codanna index").unwrap();
+        assert_eq!(restore(&output, false, &settings), ExitCode::GeneralError);
+        drop(lease);
+        assert!(untouched());
+
+        // A server of any transport
+        let server = super::super::serve::register_server(index)
+            .unwrap()
+            .unwrap();
+        assert!(
+            super::super::serve::exclude_servers(index)
+                .unwrap()
+                .is_none()
+        );
+        assert_eq!(restore(&output, false, &settings), ExitCode::GeneralError);
+        drop(server);
+        assert!(untouched());
+
+        assert_eq!(in_use(&settings), None);
+        fs::write(index.join("tantivy/meta.json"), "changed").unwrap();
+        assert_eq!(restore(&output, false, &settings), ExitCode::Success);
+        assert!(untouched());
+    }
+}
@@ -0,0 +1,152 @@
+//! Stats command - index totals, breakdowns and complexity hotspots.
+
+use crate::analysis::breakdown::{is_documentable, is_documented, percentage};
+use crate::analysis::{
+    GroupStats, Grouping, MetricsReport, breakdown, metrics_report, render_breakdown,
+    render_metrics,
+};
+use crate::export::GraphFilter;
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+use crate::io::envelope::{EntityType, Envelope};
+use serde::Serialize;
+use std::collections::BTreeMap;
+
+/// Everything `codanna stats` reports
+#[derive(Debug, Serialize)]
+struct IndexStats {
+    symbols: usize,
+    files: u32,
+    relationships: usize,
+    kinds: BTreeMap<String, usize>,
+    languages: BTreeMap<String, usize>,
+    /// Percentage of the filtered symbols with a doc comment (with --by)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    doc_coverage: Option<f32>,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    breakdowns: Vec<Breakdown>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    metrics: Option<MetricsReport>,
+}
+
+/// The groups of one `--by`
+#[derive(Debug, Serialize)]
+struct Breakdown {
+    by: Grouping,
+    groups: Vec<GroupStats>,
+}
+
+/// Run the stats command.
+#[allow(clippy::too_many_arguments)]
+pub fn run(
+    metrics: bool,
+    by: &[String],
+    depth: Option<usize>,
+    path: Option<String>,
+    lang: Option<String>,
+    limit: usize,
+    json: bool,
+    indexer: &IndexFacade,
+) -> ExitCode {
+    let mut groupings = Vec::new();
+    for name in by {
+        match name.parse::<Grouping>() {
+            Ok(grouping) if !groupings.contains(&grouping) => groupings.push(grouping),
+            Ok(_) => {}
+            Err(e) => {
+                eprintln!("Error: {e}");
+                return ExitCode::GeneralError;
+            }
+        }
+    }
+
+    let (kinds, languages) = indexer.symbol_stats();
+    let filter = GraphFilter {
+        path,
+        language: lang.map(|lang| lang.to_lowercase()),
+        kinds: Vec::new(),
+        relations: Vec::new(),
+    };
+    let symbols = if metrics || !groupings.is_empty() {
+        indexer.get_all_symbols()
+    } else {
+        Vec::new()
+    };
+    let doc_coverage = (!groupings.is_empty()).then(|| {
+        let counted: Vec<_> = symbols
+            .iter()
+            .filter(|symbol| is_documentable(symbol) && filter.accepts(symbol))
+            .collect();
+        let documented = counted
+            .iter()
+            .filter(|symbol| is_documented(symbol))
+            .count();
+        percentage(documented, counted.len())
+    });
+    let breakdowns = groupings
+        .iter()
+        .map(|&grouping| Breakdown {
+            by: grouping,
+            groups: breakdown(&symbols, &filter, grouping, depth),
+        })
+        .collect();
+    let metrics = metrics.then(|| metrics_report(&symbols, &filter, limit));
+    let stats = IndexStats {
+        symbols: indexer.symbol_count(),
+        files: indexer.file_count(),
+        relationships: indexer.relationship_count(),
+        kinds,
+        languages,
+        doc_coverage,
+        breakdowns,
+        metrics,
+    };
+    let unmeasured = stats
+        .metrics
+        .as_ref()
+        .is_some_and(|report| report.functions == 0);
+    let hint = "Set indexing.symbol_metrics = true and re-index with --force to measure functions";
+
+    if json {
+        let mut envelope = Envelope::success(&stats)
+            .with_entity_type(EntityType::Stats)
+            .with_count(stats.symbols)
+            .with_message(format!(
+                "Index contains {} symbols across {} files",
+                stats.symbols, stats.files
+            ));
+        if unmeasured {
+            envelope = envelope.with_hint(hint);
+        }
+        println!("{}", envelope.to_json().expect("envelope serialization"));
+        return ExitCode::Success;
+    }
+
+    println!(
+        "Index contains {} symbols across {} files, {} relationships",
+        stats.symbols, stats.files, stats.relationships
+    );
+    println!("\nSymbol kinds:");
+    for (kind, count) in &stats.kinds {
+        println!("  {kind:<12} {count}");
+    }
+    println!("\nLanguages:");
+    for (language, count) in &stats.languages {
+        println!("  {language:<12} {count}");
+    }
+    if let Some(coverage) = stats.doc_coverage {
+        println!("\nDoc coverage: {coverage:.1}%");
+    }
+    for group in &stats.breakdowns {
+        println!("\nBy {}:", group.by.as_str());
+        print!("{}", render_breakdown(group.by, &group.groups));
+    }
+    if let Some(report) = &stats.metrics {
+        println!();
+        print!("{}", render_metrics(report));
+        if unmeasured {
+            println!("  {hint}");
+        }
+    }
+    ExitCode::Success
+}
@@ -6,4 +6,8 @@
 pub mod args;
 pub mod commands;
 
-pub use args::{Cli, Commands, DocumentAction, PluginAction, RetrieveQuery};
+pub use args::{
+    AnalyzeTarget, Cli, Commands, DocumentAction, ExportTarget, ImportTarget, IndexAction,
+    ModelAction, PluginAction, ProjectsAction, QueryAction, ReportAction, RetrieveQuery,
+    ServeAction, ShardsAction, SnapshotAction, TokenAction,
+};
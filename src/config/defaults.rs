@@ -41,6 +41,15 @@ pub(super) fn default_batch_size() -> usize {
 pub(super) fn default_batches_per_commit() -> usize {
     10 // Commit every 10 batches (~50K symbols)
 }
+pub(super) fn default_max_ast_depth() -> usize {
+    crate::parsing::parser::MAX_AST_DEPTH
+}
+pub(super) fn default_language_plugins() -> Vec<PathBuf> {
+    vec![PathBuf::from(crate::init::local_dir_name()).join("languages")]
+}
+pub(super) fn default_todo_tags() -> Vec<String> {
+    ["TODO", "FIXME", "HACK", "XXX"].map(String::from).to_vec()
+}
 pub(super) fn default_true() -> bool {
     true
 }
@@ -59,6 +68,30 @@ pub(super) fn default_similarity_threshold() -> f32 {
 pub(super) fn default_embedding_threads() -> usize {
     3
 }
+pub(super) fn default_code_weight() -> f32 {
+    0.5
+}
+pub(super) fn default_code_chunk_tokens() -> usize {
+    256
+}
+pub(super) fn default_code_chunk_overlap() -> usize {
+    32
+}
+pub(super) fn default_hybrid_weight() -> f32 {
+    0.5
+}
+pub(super) fn default_rerank_model() -> String {
+    "bge-reranker-base".to_string()
+}
+pub(super) fn default_rerank_candidates() -> usize {
+    50
+}
+pub(super) fn default_remote_batch_size() -> usize {
+    64
+}
+pub(super) fn default_remote_max_retries() -> u32 {
+    3
+}
 pub(super) fn default_debounce_ms() -> u64 {
     500
 }
@@ -68,9 +101,39 @@ pub(super) fn default_server_mode() -> String {
 pub(super) fn default_bind_address() -> String {
     "127.0.0.1:8080".to_string()
 }
+pub(super) fn default_socket_mode() -> String {
+    "0600".to_string()
+}
 pub(super) fn default_watch_interval() -> u64 {
     5
 }
+pub(super) fn default_shutdown_timeout_secs() -> u64 {
+    30
+}
+pub(super) fn default_daemon_idle_timeout_secs() -> u64 {
+    600
+}
+pub(super) fn default_unused_kinds() -> Vec<String> {
+    vec!["function".to_string(), "method".to_string()]
+}
+pub(super) fn default_entry_points() -> Vec<String> {
+    // Program entry points and Python's dunder protocol methods
+    vec!["main".to_string(), "__*__".to_string()]
+}
+pub(super) fn default_test_patterns() -> Vec<String> {
+    [
+        "**/tests/**",
+        "**/test/**",
+        "**/__tests__/**",
+        "**/*_test.*",
+        "**/*.test.*",
+        "**/*.spec.*",
+        "**/test_*.py",
+    ]
+    .iter()
+    .map(|pattern| pattern.to_string())
+    .collect()
+}
 
 pub(super) fn default_guidance_templates() -> IndexMap<String, GuidanceTemplate> {
     let mut templates = IndexMap::new();
@@ -188,6 +251,9 @@ pub(super) fn generate_language_defaults() -> IndexMap<String, LanguageConfig> {
                         parser_options: HashMap::new(),
                         config_files: Vec::new(),
                         projects: Vec::new(),
+                        limits: Default::default(),
+                        scope: Default::default(),
+                        kinds: Vec::new(),
                     },
                 )
             })
@@ -224,6 +290,9 @@ pub(super) fn fallback_minimal_languages() -> IndexMap<String, LanguageConfig> {
             parser_options: HashMap::new(),
             config_files: Vec::new(),
             projects: Vec::new(),
+            limits: Default::default(),
+            scope: Default::default(),
+            kinds: Vec::new(),
         },
     );
 
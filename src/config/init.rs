@@ -90,7 +90,9 @@ impl Settings {
     pub(super) fn add_config_comments(toml: String) -> String {
         let mut result = String::from(
             "# Codanna Configuration File\n\
-             # https://github.com/bartolli/codanna\n\n",
+             # https://github.com/bartolli/codanna\n\
+             # String values may read ${VAR} or ${VAR:-default} from the environment,\n\
+             # and with CODANNA_CONFIG_COMMANDS=1 the output of $(command)\n\n",
         );
 
         let mut in_languages_section = false;
@@ -111,6 +113,18 @@ impl Settings {
                 result.push_str("\n# Path to the index directory (relative to workspace root)\n");
             } else if line.starts_with("workspace_root = ") {
                 result.push_str("\n# Workspace root directory (automatically detected)\n");
+            } else if line == "[workspace]" {
+                result.push_str("\n[workspace]\n");
+                result.push_str(
+                    "# A project spread over several directories, indexed into one index\n",
+                );
+                result.push_str(
+                    "# Relative to the workspace root; kept as written, unlike add-dir paths\n",
+                );
+                prev_line_was_section = true;
+                continue;
+            } else if line.starts_with("roots = ") {
+                result.push_str("# e.g. roots = [\"app\", \"libs/shared\", \"../vendored-sdk\"]\n");
             } else if line == "[indexing]" {
                 result.push_str("\n[indexing]\n");
                 prev_line_was_section = true;
@@ -129,6 +143,9 @@ impl Settings {
                 result.push_str("# Exponential backoff: 100ms, 200ms, 400ms delays\n");
             } else if line.starts_with("ignore_patterns = ") {
                 result.push_str("\n# Additional patterns to ignore during indexing\n");
+            } else if line.starts_with("symlinks = ") {
+                result.push_str("\n# Symbolic links to follow (default: within-root)\n");
+                result.push_str("# within-root: into the workspace, follow: all, skip: none\n");
             } else if line.starts_with("indexed_paths = ") {
                 result.push_str("\n# List of directories to index\n");
                 result.push_str("# Add folders using: codanna add-dir <path>\n");
@@ -138,6 +155,36 @@ impl Settings {
                 result.push_str("\n# Items per batch before flushing to index (default: 5000)\n");
             } else if line.starts_with("batches_per_commit = ") {
                 result.push_str("\n# Number of batches before committing to disk (default: 10)\n");
+            } else if line.starts_with("queue_size = ") {
+                result.push_str("\n# Files queued between pipeline stages (0 = derived)\n");
+                result.push_str("# A full queue pauses the stage feeding it, bounding memory\n");
+                result.push_str("# Also caps symbols waiting for embedding (at least one batch)\n");
+            } else if line.starts_with("max_memory_mb = ") {
+                result.push_str("\n# Memory budget for indexing in MB (default: 0 = unbounded)\n");
+                result.push_str("# Commits more often and spills relationships to disk\n");
+                result.push_str("# Override per run with: codanna index --max-memory <MB>\n");
+            } else if line.starts_with("max_ast_depth = ") {
+                result.push_str("\n# Deepest AST nesting parsed (default: 500)\n");
+                result.push_str("# Deeper subtrees, as in huge generated sources, are skipped\n");
+            } else if line.starts_with("content_cache = ") {
+                result.push_str(
+                    "\n# Reuse parse results and embeddings of files whose content another\n",
+                );
+                result.push_str(
+                    "# index (worktree, branch, snapshot) already saw (default: false)\n",
+                );
+                result.push_str(
+                    "# Cached in ~/.codanna/cache; remove the directory to reclaim space\n",
+                );
+            } else if line.starts_with("deterministic = ") {
+                result.push_str(
+                    "\n# Same sources, same index: stable IDs and commit order (default: false)\n",
+                );
+                result.push_str("# Slower; set SOURCE_DATE_EPOCH to pin recorded timestamps too\n");
+                result
+                    .push_str("# Holds every parsed file in memory until parsing ends, so peak\n");
+                result.push_str("# memory grows with the size of the codebase\n");
+                result.push_str("# Enable per run with: codanna index --deterministic\n");
             } else if line.starts_with("pipeline_tracing = ") {
                 result.push_str("\n# Enable detailed pipeline stage tracing\n");
                 result.push_str("# Shows timing, throughput, and memory for each stage\n");
@@ -145,6 +192,38 @@ impl Settings {
             } else if line.starts_with("show_progress = ") {
                 result.push_str("\n# Show progress bars during indexing (default: true)\n");
                 result.push_str("# Use --no-progress CLI flag to override\n");
+            } else if line.starts_with("embedded_sql = ") {
+                result.push_str("\n# Detect SQL queries in string literals (default: false)\n");
+                result
+                    .push_str("# Links functions to the tables of indexed .sql files they query\n");
+            } else if line.starts_with("rust_macros = ") {
+                result.push_str("\n# Index symbols Rust macros generate (default: false)\n");
+                result
+                    .push_str("# Derived trait impls, thiserror errors, same-file macro_rules!\n");
+            } else if line.starts_with("embedded_languages = ") {
+                result.push_str("\n# Index code embedded in other files (default: false)\n");
+                result.push_str("# HTML scripts, Rust doc examples, SQL CREATE strings\n");
+            } else if line.starts_with("string_literals = ") {
+                result.push_str("\n# Index URLs and messages of strings (default: false)\n");
+                result.push_str("# Also routes and format strings, for retrieve strings\n");
+            } else if line.starts_with("symbol_metrics = ") {
+                result.push_str("\n# Measure complexity and size of functions (default: true)\n");
+                result.push_str("# Shown by retrieve describe and codanna stats --metrics\n");
+            } else if line.starts_with("todo_tags = ") {
+                result.push_str("\n# Comment tags indexed as todos, for retrieve todos\n");
+                result.push_str("# Default: TODO, FIXME, HACK, XXX; an empty list turns it off\n");
+            } else if line.starts_with("git_blame = ") {
+                result.push_str("\n# Record who last changed each symbol (default: false)\n");
+                result.push_str("# Runs git blame per file, which slows indexing\n");
+            } else if line.starts_with("language_plugins = ") {
+                result.push_str("\n# Language plugin folders (default: .codanna/languages)\n");
+                result.push_str("# Each holds plugin.toml, a WASM grammar and tags.scm\n");
+            } else if line == "[indexing.threads]" {
+                result.push_str("\n[indexing.threads]\n");
+                result.push_str("# Worker threads per pipeline stage (default: 0 = derived)\n");
+                result.push_str("# Derived from parallelism: parse 60%, read 20%, discover 10%\n");
+                prev_line_was_section = true;
+                continue;
             } else if line == "[mcp]" {
                 result.push_str("\n[mcp]\n");
                 prev_line_was_section = true;
@@ -176,7 +255,7 @@ impl Settings {
             } else if line.starts_with("model = ") {
                 result.push_str("\n# Model to use for embeddings\n");
                 result.push_str(
-                    "# Note: Changing models requires re-indexing (codanna index --force)\n",
+                    "# Note: After a change, the next `codanna index` re-embeds all doc comments\n",
                 );
                 result.push_str("# - AllMiniLML6V2: English-only, 384 dimensions (default)\n");
                 result.push_str("# - MultilingualE5Small: 94 languages including, 384 dimensions (recommended for multilingual)\n");
@@ -186,10 +265,37 @@ impl Settings {
                 result.push_str(
                     "# - MultilingualE5Large: 94 languages, 1024 dimensions (best quality)\n",
                 );
+                result.push_str(
+                    "#   Multilingual models let English queries find Japanese or German docs;\n",
+                );
+                result.push_str(
+                    "#   `codanna index` warns when an English-only model meets such docs\n",
+                );
                 result.push_str("# - BGESmallZHV15: Chinese-specialized, 512 dimensions\n");
+                result.push_str("# - Short names: all-minilm, bge-small, bge-base, bge-large, multilingual-e5,\n");
+                result.push_str(
+                    "#   multilingual-e5-base, multilingual-e5-large, nomic-embed, jina-code\n",
+                );
                 result.push_str("# - See documentation for full list of available models\n");
             } else if line.starts_with("threshold = ") {
+                result.push_str(
+                    "\n# Models directory for machines without internet access, made with\n",
+                );
+                result.push_str(
+                    "# `codanna models fetch` on a connected one. Models load from it instead of\n",
+                );
+                result
+                    .push_str("# being downloaded; relative paths start at the workspace root.\n");
+                result.push_str("# model_path = \"codanna-models\"\n");
                 result.push_str("\n# Similarity threshold for search results (0.0 to 1.0)\n");
+            } else if line.starts_with("execution_provider = ") {
+                result.push_str(
+                    "\n# Hardware for local embedding inference: \"cpu\" (default), \"auto\",\n",
+                );
+                result.push_str("# \"cuda\", \"coreml\" (alias \"metal\") or \"directml\". GPU providers need the\n");
+                result.push_str(
+                    "# matching cargo feature; an unavailable provider falls back to the CPU.\n",
+                );
             } else if line.starts_with("embedding_threads = ") {
                 result.push_str("\n# Number of parallel embedding model instances (default: 3)\n");
                 result
@@ -201,11 +307,82 @@ impl Settings {
                 result.push_str(
                     "# Uncomment and configure to use a remote server instead of local models.\n",
                 );
+                result.push_str("# provider = \"http\"                     # or \"local\"; unset, remote_url decides\n");
                 result.push_str("# remote_url = \"http://localhost:11434\"  # server base URL\n");
                 result.push_str("# remote_model = \"nomic-embed-text\"     # model name to send\n");
                 result.push_str("# remote_dim = 768                       # output dimension\n");
                 result.push_str("# API key: set CODANNA_EMBED_API_KEY environment variable (not stored in config)\n");
                 result.push_str("# Override any field with env vars: CODANNA_EMBED_URL, CODANNA_EMBED_MODEL, CODANNA_EMBED_DIM\n");
+            } else if line.starts_with("remote_batch_size = ") {
+                result.push_str(
+                    "\n# Remote requests: texts per request, retries of timeouts, 429s and 5xx\n",
+                );
+                result.push_str(
+                    "# (with exponential backoff), and an optional cap on requests per minute\n",
+                );
+                result.push_str("# remote_requests_per_minute = 600\n");
+            } else if line.starts_with("remote_max_retries = ") {
+                // Covered by the remote_batch_size comment
+            } else if line.starts_with("quantization = ") {
+                result.push_str("\n# Embedding storage: \"none\" (float32) or \"int8\", about 4x smaller on disk\n");
+                result.push_str(
+                    "# with a rescoring pass; the next index save rewrites the vector file\n",
+                );
+            } else if line.starts_with("code_embeddings = ") {
+                result.push_str(
+                    "\n# Also embed each symbol's kind, name and signature, so semantic search\n",
+                );
+                result.push_str(
+                    "# finds undocumented code; code_weight is the code share, from 0.0 to 1.0,\n",
+                );
+                result.push_str("# when both embeddings of a symbol are compared\n");
+            } else if line.starts_with("code_weight = ") {
+                // Covered by the code_embeddings comment
+            } else if line.starts_with("code_chunks = ") {
+                result.push_str(
+                    "\n# Embed function and method bodies with their code, a long body as\n",
+                );
+                result.push_str(
+                    "# overlapping chunks of code_chunk_tokens (about 4 characters each)\n",
+                );
+                result.push_str(
+                    "# repeating code_chunk_overlap tokens; a symbol scores its best chunk\n",
+                );
+            } else if line.starts_with("code_chunk_tokens = ")
+                || line.starts_with("code_chunk_overlap = ")
+            {
+                // Covered by the code_chunks comment
+            } else if line.starts_with("hybrid = ") {
+                result.push_str("\n# Hybrid search: fuse the doc comment ranking with full-text (BM25) matches\n");
+                result.push_str("# so exact identifier matches are not buried under fuzzy ones.\n");
+                result.push_str(
+                    "# hybrid_weight is the lexical share, from 0.0 (vector only) to 1.0;\n",
+                );
+                result.push_str(
+                    "# hybrid_fusion is \"weighted\" (scores) or \"rrf\" (reciprocal-rank fusion)\n",
+                );
+            } else if line.starts_with("hybrid_weight = ") || line.starts_with("hybrid_fusion = ") {
+                // Covered by the hybrid comment
+            } else if line.starts_with("rerank = ") {
+                result.push_str(
+                    "\n# Re-rank the top rerank_candidates hits of semantic_search_with_context\n",
+                );
+                result.push_str("# with a cross-encoder: more precise natural-language results, slower queries.\n");
+                result.push_str(
+                    "# rerank_model: bge-reranker-base, bge-reranker-v2-m3 (multilingual),\n",
+                );
+                result.push_str(
+                    "# jina-reranker-v1-turbo-en or jina-reranker-v2-base-multilingual\n",
+                );
+            } else if line.starts_with("rerank_model = ")
+                || line.starts_with("rerank_candidates = ")
+            {
+                // Covered by the rerank comment
+            } else if line.starts_with("background = ") {
+                result.push_str("\n# Return from `codanna index` once symbols and relationships are committed,\n");
+                result.push_str(
+                    "# and compute embeddings in a background process (codanna embed --status)\n",
+                );
             } else if line == "[file_watch]" {
                 result.push_str("\n[file_watch]\n");
                 result.push_str("# Enable automatic file watching for indexed files\n");
@@ -218,7 +395,22 @@ impl Settings {
             } else if line.starts_with("debounce_ms = ") {
                 result.push_str("\n# Debounce interval in milliseconds\n");
                 result.push_str("# How long to wait after a file change before re-indexing\n");
+            } else if line.starts_with("batch_window_ms = ") {
+                result.push_str(
+                    "\n# Milliseconds settled files wait for other pending changes, so a\n",
+                );
+                result.push_str(
+                    "# checkout or formatter run is indexed as one batch (0 = no waiting)\n",
+                );
+            } else if line.starts_with("ignore = ") {
+                result.push_str("\n# Glob patterns of files whose changes are ignored, e.g. [\"generated/**\"]\n");
             } else if line == "[server]" {
+                result.push_str("\n# After each indexed batch, run a command (changed files in\n");
+                result.push_str(
+                    "# CODANNA_CHANGED_FILES, JSON on stdin) or POST the JSON to a URL:\n",
+                );
+                result.push_str("# on_index = \"make invalidate-cache\"\n");
+                result.push_str("# webhook = \"http://localhost:9000/codanna\"\n");
                 result.push_str("\n[server]\n");
                 result.push_str("# Server mode: \"stdio\" (default) or \"http\"\n");
                 result.push_str("# stdio: Lightweight, spawns per request (best for production)\n");
@@ -231,13 +423,100 @@ impl Settings {
                 // mode field - comment already added above
             } else if line.starts_with("bind = ") {
                 result.push_str("\n# HTTP server bind address (only used when mode = \"http\" or --http flag)\n");
+            } else if line.starts_with("socket_mode = ") {
+                result.push_str(
+                    "\n# Permissions of the socket file of \"codanna serve --uds <path>\" (0600 = owner only)\n",
+                );
+                result.push_str(line);
+                result.push('\n');
+                continue;
             } else if line.starts_with("watch_interval = ") {
                 result.push_str("\n# Watch interval for stdio mode in seconds (how often to check for file changes)\n");
+                result.push_str(line);
+                result.push('\n');
+                continue;
+            } else if line.starts_with("shutdown_timeout_secs = ") {
+                result.push_str(
+                    "\n# Seconds a shutdown (SIGTERM or Ctrl+C) waits for tool calls in flight before exiting\n",
+                );
+                result.push_str(line);
+                result.push('\n');
+                continue;
+            } else if line.starts_with("read_only = ") {
+                result.push_str(
+                    "\n# Never write the index, so `codanna index` can run next to the server (also serve --read-only)\n",
+                );
+                result.push_str(line);
+                result.push('\n');
+                continue;
+            } else if line.starts_with("daemon = ") {
+                result.push_str(
+                    "\n# Answer text-mode \"codanna mcp\" calls from a background server that keeps the index and model loaded.\n# --json calls and \"codanna retrieve\" still run in process.\n",
+                );
+                result.push_str(line);
+                result.push('\n');
+                continue;
+            } else if line.starts_with("daemon_idle_timeout_secs = ") {
+                result
+                    .push_str("# Seconds the background server waits for a call before exiting\n");
+                result.push_str(line);
+                result.push('\n');
+                continue;
+            } else if line.starts_with("rate_limit_per_minute = ") {
+                result.push_str(
+                    "\n# Tool calls one client connection may make per minute, in bursts of as many. 0 = no limit.\n",
+                );
+                result.push_str(line);
+                result.push('\n');
+                continue;
+            } else if line.starts_with("max_in_flight = ") {
+                result.push_str(
+                    "\n# Tool calls answered at once across all connections; more are refused. 0 = no limit.\n",
+                );
+                result.push_str(line);
+                result.push('\n');
+                result.push_str(
+                    "\n# Bearer tokens for HTTP/HTTPS serve. None = no token checked (HTTP mode keeps its built-in OAuth flow).\n",
+                );
+                result.push_str(
+                    "# Every MCP request must carry one as \"Authorization: Bearer <token>\".\n",
+                );
+                result.push_str("# Create one with: codanna serve token create <name>\n");
+                result.push_str("# [[server.tokens]]\n");
+                result.push_str("# name = \"ci\"\n");
+                result.push_str(
+                    "# sha256 = \"<hex SHA-256 of the token>\"  # or token = \"<the token>\"\n",
+                );
+                result.push_str(
+                    "\n# Client certificates for HTTPS serve (mutual TLS). None = none asked for.\n",
+                );
+                result.push_str("# [server.mtls]\n");
+                result.push_str(
+                    "# client_ca = \"/etc/codanna/client-ca.pem\"  # CAs of accepted clients\n",
+                );
+                result.push_str("# require_client_cert = true\n");
+                result
+                    .push_str("# [server.mtls.identities]  # certificate CN -> identity in logs\n");
+                result.push_str("# \"build-agent-01\" = \"ci\"\n");
+                result.push_str(
+                    "\n# Other projects for serve to load next to this one; tools pick one with \"project\".\n",
+                );
+                result.push_str("# [server.projects]\n");
+                result.push_str(
+                    "# backend = \"/home/me/src/backend\"  # root holding its .codanna\n",
+                );
+                continue;
             } else if line == "[logging]" {
                 result.push_str("\n[logging]\n");
                 result.push_str("# Logging configuration\n");
                 result.push_str("# Levels: \"error\", \"warn\" (default/quiet), \"info\", \"debug\", \"trace\"\n");
                 result.push_str("# Override with RUST_LOG env var: RUST_LOG=debug codanna index\n");
+                result.push_str(
+                    "# format: \"compact\" lines, or \"json\" objects with fields and spans\n",
+                );
+                result.push_str(
+                    "# otlp_endpoint = \"http://localhost:4318\"  # export spans (otel feature)\n",
+                );
                 prev_line_was_section = true;
                 continue;
             } else if line.starts_with("default = ") && !in_languages_section {
@@ -303,9 +582,80 @@ impl Settings {
                 result.push_str("\n# Collection configuration\n");
                 result.push_str("# paths: directories or files to include\n");
                 result.push_str("# patterns: glob patterns to match (default: [\"**/*.md\"])\n");
+            } else if line == "[analysis.unused]" {
+                result.push_str("\n[analysis.unused]\n");
+                result.push_str("# Unused symbol detection (codanna analyze unused)\n");
+                result.push_str(
+                    "# A symbol is unused when nothing calls, uses, implements, extends or imports it\n",
+                );
+                prev_line_was_section = true;
+                continue;
+            } else if line.starts_with("kinds = ") {
+                result.push_str("# Symbol kinds to check\n");
+            } else if line.starts_with("entry_points = ") {
+                result.push_str("\n# Names of entry points, never reported (* matches any text)\n");
+            } else if line.starts_with("test_patterns = ") {
+                result.push_str(
+                    "\n# Test files, skipped with test modules unless include_tests = true\n",
+                );
+            } else if line.starts_with("include_tests = ") {
+                result.push_str("\n# Report unused test code\n");
+            } else if line.starts_with("include_public = ") {
+                result
+                    .push_str("\n# Report public symbols (code outside the index may use them)\n");
+            } else if line == "[analysis.check]" {
+                result.push_str("\n[analysis.check]\n");
+                result.push_str("# Quality gate (codanna check): exits non-zero past one\n");
+                result.push_str("# Thresholds left out are not checked, flags override them\n");
+                result.push_str("# min_doc_coverage = 60.0\n");
+                result.push_str("# max_cycles = 0\n");
+                result.push_str("# max_undocumented_public = 10\n");
+                prev_line_was_section = true;
+                continue;
+            } else if line == "[profiles]" {
+                result.push_str("\n[profiles]\n");
+                result.push_str(
+                    "# Named sets of settings, laid over the rest with --profile <name>\n",
+                );
+                result.push_str(
+                    "# (or CODANNA_PROFILE), so one file serves CI, local use and agents\n",
+                );
+                result.push_str("# [profiles.ci.indexing]\n");
+                result.push_str("# parallelism = 2\n");
+                result.push_str("# [profiles.ci.semantic_search]\n");
+                result.push_str("# enabled = false\n");
+                result.push_str("# [profiles.agent.file_watch]\n");
+                result.push_str("# enabled = true\n");
+                prev_line_was_section = true;
+                continue;
+            } else if line == "[overrides]" {
+                result.push_str("\n[overrides]\n");
+                result.push_str(
+                    "# Settings of the files under a glob, relative to the workspace root\n",
+                );
+                result.push_str("# Where several globs match a file, the longer one wins\n");
+                result.push_str("# [overrides.\"vendor/**\"]\n");
+                result.push_str("# semantic_search = false\n");
+                result.push_str("# [overrides.\"docs/**\"]\n");
+                result.push_str("# code_weight = 0.2   # weigh doc comments over code\n");
+                prev_line_was_section = true;
+                continue;
             } else if line.starts_with("[languages.") {
                 if !in_languages_section {
                     result.push_str("\n# Language-specific settings\n");
+                    result.push_str(
+                        "# Minified and generated files: past max_file_size or max_line_length\n",
+                    );
+                    result.push_str(
+                        "# (bytes), large_files = \"skip\" | \"symbols-only\" | \"full\" applies\n",
+                    );
+                    result.push_str(
+                        "# include/exclude globs limit a language to part of the workspace:\n",
+                    );
+                    result.push_str("#   include = [\"src/**\"], exclude = [\"**/*.gen.ts\"]\n");
+                    result.push_str(
+                        "# extensions may add one no parser claims (\"mjs\") or take one over (\"h\")\n",
+                    );
                     in_languages_section = true;
                 }
                 result.push('\n');
@@ -429,6 +779,8 @@ impl Settings {
 #
 # This file tells codanna which files to exclude from indexing.
 # Each line specifies a pattern. Patterns follow the same rules as .gitignore.
+# A .codannaignore in a subdirectory applies to the files below it, and
+# `!pattern` re-includes a file that a broader pattern excluded.
 
 # Build artifacts
 target/
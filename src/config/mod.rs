@@ -23,13 +23,19 @@ use figment::{
 use indexmap::IndexMap;
 use serde::{Deserialize, Serialize};
 use std::collections::HashMap;
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
 
 mod defaults;
 mod init;
+mod overrides;
 mod paths;
+mod reload;
+mod substitute;
 
 use defaults::*;
+pub use overrides::PathOverrides;
+pub use reload::SettingsChanges;
+pub use substitute::{COMMANDS_ENV, RawValue};
 
 #[derive(Debug, Deserialize, Serialize, Clone)]
 pub struct Settings {
@@ -45,6 +51,10 @@ pub struct Settings {
     #[serde(skip_serializing_if = "Option::is_none")]
     pub workspace_root: Option<PathBuf>,
 
+    /// Directories of the project beside the workspace root
+    #[serde(default)]
+    pub workspace: WorkspaceConfig,
+
     /// Indexing configuration
     #[serde(default)]
     pub indexing: IndexingConfig,
@@ -53,6 +63,10 @@ pub struct Settings {
     #[serde(skip)]
     pub indexed_paths_cache: Vec<PathBuf>,
 
+    /// Roots of `[workspace]` merged into the indexed paths (not serialized)
+    #[serde(skip)]
+    pub workspace_roots_cache: Vec<PathBuf>,
+
     /// Language-specific settings (IndexMap preserves insertion order)
     #[serde(default)]
     pub languages: IndexMap<String, LanguageConfig>,
@@ -84,6 +98,29 @@ pub struct Settings {
     /// Document embedding settings for RAG
     #[serde(default)]
     pub documents: crate::documents::DocumentsConfig,
+
+    /// Relationship graph analysis settings
+    #[serde(default)]
+    pub analysis: AnalysisConfig,
+
+    /// Settings of the files under a glob, relative to the workspace root
+    /// (`[overrides."vendor/**"]`); where several match, the longer wins
+    #[serde(default)]
+    pub overrides: IndexMap<String, PathOverride>,
+
+    /// Named sets of settings, laid over the others with `--profile <name>`
+    /// (`[profiles.ci]`, `[profiles.ci.indexing]`, ...)
+    #[serde(default)]
+    pub profiles: IndexMap<String, toml::Table>,
+
+    /// The profile these settings were loaded with (not serialized)
+    #[serde(skip)]
+    pub profile: Option<String>,
+
+    /// String values of the file as written before `${VAR}` and
+    /// `$(command)` substitution, to save them back so (not serialized)
+    #[serde(skip)]
+    pub raw_values: Vec<RawValue>,
 }
 
 #[derive(Debug, Deserialize, Serialize, Clone)]
@@ -107,6 +144,12 @@ pub struct IndexingConfig {
     #[serde(default)]
     pub ignore_patterns: Vec<String>,
 
+    /// Symbolic links the walk of the indexed directories follows:
+    /// `within-root` those whose target is in the workspace (default),
+    /// `follow` every one, `skip` none
+    #[serde(default)]
+    pub symlinks: SymlinkPolicy,
+
     /// List of directories to index
     /// This list is managed by the add-dir and remove-dir commands
     #[serde(default)]
@@ -121,6 +164,54 @@ pub struct IndexingConfig {
     #[serde(default = "default_batches_per_commit")]
     pub batches_per_commit: usize,
 
+    /// Files each queue between pipeline stages holds before the stage
+    /// feeding it waits, bounding memory on large repositories; also caps
+    /// the symbols waiting for embedding, at least one batch
+    /// (0 = derived from the stage thread counts)
+    #[serde(default)]
+    pub queue_size: usize,
+
+    /// Worker threads per pipeline stage, overriding the counts derived
+    /// from `parallelism`
+    #[serde(default)]
+    pub threads: StageThreads,
+
+    /// Memory budget of an indexing run in megabytes (0 = unbounded).
+    /// Shrinks the stage queues, batches and Tantivy heap to fit it, commits
+    /// after every batch and spills pending relationships to disk
+    #[serde(default)]
+    pub max_memory_mb: usize,
+
+    /// Deepest AST nesting parsers descend into (default: 500). Deeper
+    /// subtrees are skipped, keeping the symbols above them, and parse
+    /// threads get the stack the budget needs
+    #[serde(default = "default_max_ast_depth")]
+    pub max_ast_depth: usize,
+
+    /// Share parse results and embeddings between indexes of the same
+    /// files (worktrees, branches, snapshots), in a content-addressed cache
+    /// under the global directory
+    #[serde(default)]
+    pub content_cache: bool,
+
+    /// Build indexes reproducibly: IDs assigned in path order, documents
+    /// written by one thread without background merges, no mtimes.
+    /// COLLECT holds every parsed file of the run in memory until PARSE is
+    /// done, so peak memory grows with the size of the codebase.
+    #[serde(default)]
+    pub deterministic: bool,
+
+    /// Address an indexing run listens on for `index --worker` processes,
+    /// set by `index --listen`; never read from settings.toml
+    #[serde(skip)]
+    pub listen: Option<String>,
+
+    /// Keep the relationships to names no symbol of the index has, for
+    /// linking shards to each other; set for shard indexes, never read
+    /// from settings.toml
+    #[serde(skip)]
+    pub keep_unresolved: bool,
+
     /// Enable detailed pipeline stage tracing (timing, memory, throughput)
     /// Set logging.modules.pipeline = "info" to see output
     #[serde(default)]
@@ -129,6 +220,84 @@ pub struct IndexingConfig {
     /// Show progress bars during indexing (default: true)
     #[serde(default = "default_true")]
     pub show_progress: bool,
+
+    /// Scan string literals of every language for SQL queries and link the
+    /// enclosing function to the tables they touch (default: false)
+    #[serde(default)]
+    pub embedded_sql: bool,
+
+    /// Index the impls and functions Rust macros generate: std and serde
+    /// derives, thiserror errors and same-file `macro_rules!` (default: false)
+    #[serde(default)]
+    pub rust_macros: bool,
+
+    /// Index the code embedded in files of another language: scripts of
+    /// HTML pages, examples of Rust doc comments and SQL definitions in
+    /// string literals (default: false)
+    #[serde(default)]
+    pub embedded_languages: bool,
+
+    /// Index the URLs, HTTP routes, format strings and messages of string
+    /// literals, for `codanna retrieve strings` (default: false)
+    #[serde(default)]
+    pub string_literals: bool,
+
+    /// Measure the cyclomatic complexity, lines and parameters of functions
+    /// and methods (default: true)
+    #[serde(default = "default_true")]
+    pub symbol_metrics: bool,
+
+    /// Comment tags indexed as todos, matched at the start of a comment
+    /// line (an empty list turns todo extraction off)
+    #[serde(default = "default_todo_tags")]
+    pub todo_tags: Vec<String>,
+
+    /// Record the last author and commit of each symbol from `git blame`
+    /// (default: false)
+    #[serde(default)]
+    pub git_blame: bool,
+
+    /// Directories of language plugins, each plugin a subdirectory with a
+    /// `plugin.toml` (relative paths are from the workspace root)
+    #[serde(default = "default_language_plugins")]
+    pub language_plugins: Vec<PathBuf>,
+
+    /// Shards of a monorepo: subtrees, by name, indexed into indexes of
+    /// their own and selected with `codanna --shard <name>` (relative paths
+    /// are from the workspace root)
+    #[serde(default, skip_serializing_if = "IndexMap::is_empty")]
+    pub shards: IndexMap<String, PathBuf>,
+
+    /// Webhooks told of each `codanna index` run that changed the index,
+    /// with a summary of what it changed
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub webhooks: Vec<IndexWebhook>,
+}
+
+/// A webhook of `[[indexing.webhooks]]`
+#[derive(Debug, Deserialize, Serialize, Clone, PartialEq, Eq)]
+pub struct IndexWebhook {
+    pub url: String,
+    /// Key of the HMAC-SHA256 of the body sent as `X-Codanna-Signature`;
+    /// `${VAR}` keeps it out of the file
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub secret: Option<String>,
+}
+
+/// Worker threads of the indexing pipeline stages (0 = derived)
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, Default, PartialEq, Eq)]
+pub struct StageThreads {
+    /// Directory walking (derived: 10% of parallelism)
+    #[serde(default)]
+    pub discover: usize,
+
+    /// File reading (derived: 20% of parallelism)
+    #[serde(default)]
+    pub read: usize,
+
+    /// Parsing (derived: 60% of parallelism)
+    #[serde(default)]
+    pub parse: usize,
 }
 
 /// Source layout for project resolution
@@ -145,6 +314,193 @@ pub enum SourceLayout {
     FlatKmp,
 }
 
+/// Which symbolic links indexing follows
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, PartialEq, Eq, Default)]
+#[serde(rename_all = "kebab-case")]
+pub enum SymlinkPolicy {
+    /// Links whose target lies in the workspace root
+    #[default]
+    WithinRoot,
+    /// Every link
+    Follow,
+    /// No link, to a file or a directory
+    Skip,
+}
+
+/// What indexing does with a file over its language's [`FileLimits`]
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, PartialEq, Eq, Default)]
+#[serde(rename_all = "kebab-case")]
+pub enum LargeFilePolicy {
+    /// Leave the file out of the index
+    #[default]
+    Skip,
+    /// Index its symbols, without doc comments, imports or relationships
+    SymbolsOnly,
+    /// Index it like any other file
+    Full,
+}
+
+/// Where embeddings are computed
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, PartialEq, Eq)]
+#[serde(rename_all = "kebab-case")]
+pub enum EmbeddingProvider {
+    /// Local ONNX inference with fastembed
+    Local,
+    /// An Ollama or OpenAI-compatible embeddings endpoint at `remote_url`
+    Http,
+}
+
+/// How embeddings are stored on disk
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, Default, PartialEq, Eq)]
+#[serde(rename_all = "lowercase")]
+pub enum VectorQuantization {
+    /// float32 vectors
+    #[default]
+    None,
+    /// One signed byte per value, about 4x smaller; searches rescore the
+    /// best int8 candidates against the full-precision query
+    Int8,
+}
+
+/// How hybrid search combines the lexical and vector rankings
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, Default, PartialEq, Eq)]
+#[serde(rename_all = "lowercase")]
+pub enum HybridFusion {
+    /// Weighted sum of the cosine similarity and the normalized BM25 score
+    #[default]
+    Weighted,
+    /// Weighted reciprocal-rank fusion, using ranks only
+    Rrf,
+}
+
+/// Which embeddings of a symbol semantic search compares the query with
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, PartialEq, Eq)]
+#[serde(rename_all = "lowercase")]
+pub enum SemanticVectors {
+    /// The doc comment
+    Docs,
+    /// The code: kind, name and signature
+    Code,
+    /// Both, weighted by `code_weight`
+    Both,
+}
+
+impl std::str::FromStr for SemanticVectors {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "docs" => Ok(Self::Docs),
+            "code" => Ok(Self::Code),
+            "both" => Ok(Self::Both),
+            _ => Err(format!(
+                "Unknown embeddings: {s}. Expected docs, code or both"
+            )),
+        }
+    }
+}
+
+/// Hardware that runs local embedding inference. Providers other than
+/// `cpu` need codanna built with the matching cargo feature (`cuda`,
+/// `coreml`, `directml`); one that is unavailable falls back to the CPU.
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, Default, PartialEq, Eq)]
+#[serde(rename_all = "lowercase")]
+pub enum ExecutionProvider {
+    #[default]
+    Cpu,
+    /// The first available of CUDA, CoreML and DirectML
+    Auto,
+    /// NVIDIA GPUs
+    Cuda,
+    /// Apple GPUs and Neural Engine
+    #[serde(alias = "metal")]
+    CoreMl,
+    /// Windows GPUs through DirectX 12
+    DirectMl,
+}
+
+impl ExecutionProvider {
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            Self::Cpu => "cpu",
+            Self::Auto => "auto",
+            Self::Cuda => "cuda",
+            Self::CoreMl => "coreml",
+            Self::DirectMl => "directml",
+        }
+    }
+}
+
+/// Size limits past which a file is handled by its `large_files` policy,
+/// for minified bundles and generated code
+#[derive(Debug, Deserialize, Serialize, Clone, Default, PartialEq, Eq)]
+pub struct FileLimits {
+    /// Largest file, in bytes, indexed in full
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub max_file_size: Option<u64>,
+
+    /// Longest line, in bytes, of a file indexed in full
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub max_line_length: Option<usize>,
+
+    /// What to do with a file over either limit
+    #[serde(default, skip_serializing_if = "is_default_policy")]
+    pub large_files: LargeFilePolicy,
+}
+
+/// Where in the project a language's files are indexed, as globs relative
+/// to the workspace root; a file no `include` glob matches, when there are
+/// some, or that an `exclude` glob matches, is left out
+#[derive(Debug, Deserialize, Serialize, Clone, Default, PartialEq, Eq)]
+pub struct LanguageScope {
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub include: Vec<String>,
+
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub exclude: Vec<String>,
+}
+
+impl LanguageScope {
+    /// Whether the scope takes `relative`, a path relative to the
+    /// workspace root; invalid globs, which loading rejects, match nothing
+    pub fn takes(&self, relative: &Path) -> bool {
+        let matches = |patterns: &[String]| {
+            patterns.iter().any(|pattern| {
+                glob::Pattern::new(pattern).is_ok_and(|pattern| pattern.matches_path(relative))
+            })
+        };
+        (self.include.is_empty() || matches(&self.include)) && !matches(&self.exclude)
+    }
+}
+
+fn is_default_policy(policy: &LargeFilePolicy) -> bool {
+    *policy == LargeFilePolicy::default()
+}
+
+impl FileLimits {
+    /// Why `content` is over the limits, if it is
+    pub fn exceeded_by(&self, content: &str) -> Option<String> {
+        if let Some(max) = self.max_file_size {
+            if content.len() as u64 > max {
+                return Some(format!(
+                    "{} bytes, over max_file_size = {max}",
+                    content.len()
+                ));
+            }
+        }
+        if let Some(max) = self.max_line_length {
+            if let Some(longest) = content.lines().map(str::len).max() {
+                if longest > max {
+                    return Some(format!(
+                        "line of {longest} bytes, over max_line_length = {max}"
+                    ));
+                }
+            }
+        }
+        None
+    }
+}
+
 /// Per-project configuration with explicit source layout
 #[derive(Debug, Deserialize, Serialize, Clone)]
 pub struct ProjectConfig {
@@ -162,7 +518,8 @@ pub struct LanguageConfig {
     #[serde(default = "default_true")]
     pub enabled: bool,
 
-    /// File extensions for this language
+    /// File extensions for this language; one it does not have built in
+    /// (`.mjs`, or `h` under cpp) maps files of that extension to it
     #[serde(default)]
     pub extensions: Vec<String>,
 
@@ -180,6 +537,50 @@ pub struct LanguageConfig {
     /// Use when auto-detection fails (e.g., custom build plugins)
     #[serde(default, skip_serializing_if = "Vec::is_empty")]
     pub projects: Vec<ProjectConfig>,
+
+    /// Size limits for minified and generated files
+    #[serde(flatten)]
+    pub limits: FileLimits,
+
+    /// Where in the project this language's files are indexed
+    #[serde(flatten)]
+    pub scope: LanguageScope,
+
+    /// Kinds of symbols frameworks define, given to the symbols the rules
+    /// match (see [`crate::parsing::kinds`])
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub kinds: Vec<KindRule>,
+}
+
+/// A rule giving the symbols it matches a framework kind, as
+/// `[[languages.<name>.kinds]]`; a symbol matches when every condition set
+/// holds
+#[derive(Debug, Deserialize, Serialize, Clone, Default, PartialEq, Eq)]
+pub struct KindRule {
+    /// Name of the kind, lowercase with underscores (`react_component`)
+    pub kind: String,
+
+    /// Kinds the symbol has to itself (`function`, `method`); any when empty
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub base: Vec<String>,
+
+    /// Regex matching the symbol's name
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub name: Option<String>,
+
+    /// Regex matching the symbol's signature
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub signature: Option<String>,
+
+    /// Regex matching one of the attributes, decorators or annotations
+    /// directly above the symbol, or its first line
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub attribute: Option<String>,
+
+    /// Globs of the symbol's file, relative to the workspace root; any
+    /// file when empty
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub paths: Vec<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize, Clone)]
@@ -207,14 +608,28 @@ pub struct SemanticSearchConfig {
     #[serde(default = "default_embedding_model")]
     pub model: String,
 
+    /// Directory of pre-downloaded models (`codanna models fetch`), used
+    /// instead of the global models cache for machines without internet
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub model_path: Option<PathBuf>,
+
     /// Similarity threshold for search results
     #[serde(default = "default_similarity_threshold")]
     pub threshold: f32,
 
+    /// Hardware for local inference, falling back to the CPU
+    #[serde(default)]
+    pub execution_provider: ExecutionProvider,
+
     /// Number of parallel embedding model instances
     #[serde(default = "default_embedding_threads")]
     pub embedding_threads: usize,
 
+    /// Where embeddings are computed. Unset, `remote_url` decides: set,
+    /// it is `http`, otherwise `local`.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub provider: Option<EmbeddingProvider>,
+
     /// Remote embedding server URL (OpenAI-compatible, e.g. http://host:8100).
     /// When set, local fastembed is bypassed and this endpoint is used instead.
     /// Overrideable via CODANNA_EMBED_URL env var.
@@ -230,6 +645,84 @@ pub struct SemanticSearchConfig {
     /// Required when remote_url is set. Overrideable via CODANNA_EMBED_DIM env var.
     #[serde(default)]
     pub remote_dim: Option<usize>,
+
+    /// Texts sent to the remote server per request
+    #[serde(default = "default_remote_batch_size")]
+    pub remote_batch_size: usize,
+
+    /// Retries of a remote request that timed out, failed to connect or
+    /// got 429 or a 5xx, with exponential backoff
+    #[serde(default = "default_remote_max_retries")]
+    pub remote_max_retries: u32,
+
+    /// Most requests started per minute against the remote server
+    /// (unlimited when unset)
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub remote_requests_per_minute: Option<u32>,
+
+    /// How embeddings are saved: `none` (float32) or `int8`. Takes effect
+    /// on the next save of the index.
+    #[serde(default)]
+    pub quantization: VectorQuantization,
+
+    /// Also embed the code of each symbol with a signature (its kind, name
+    /// and signature), so queries can match code without doc comments.
+    /// Background embedding (`background`) computes doc comments only.
+    #[serde(default)]
+    pub code_embeddings: bool,
+
+    /// Share of the code similarity when a search compares both embeddings
+    /// of a symbol, from 0 (doc comment only) to 1 (code only)
+    #[serde(default = "default_code_weight")]
+    pub code_weight: f32,
+
+    /// Embed the bodies of functions and methods with their code, a body
+    /// longer than `code_chunk_tokens` as overlapping chunks with a vector
+    /// each, so a query matching any part of it finds the symbol. Needs
+    /// `code_embeddings`.
+    #[serde(default)]
+    pub code_chunks: bool,
+
+    /// Most tokens of a chunk, estimated at four characters each; keep it
+    /// within the window of the embedding model
+    #[serde(default = "default_code_chunk_tokens")]
+    pub code_chunk_tokens: usize,
+
+    /// Tokens a chunk repeats of the end of the one before it
+    #[serde(default = "default_code_chunk_overlap")]
+    pub code_chunk_overlap: usize,
+
+    /// Rank semantic search results by doc comment similarity and Tantivy
+    /// BM25 together, so exact identifier matches are not buried
+    #[serde(default)]
+    pub hybrid: bool,
+
+    /// Share of the lexical score in hybrid ranking, from 0 (vector only)
+    /// to 1 (lexical only)
+    #[serde(default = "default_hybrid_weight")]
+    pub hybrid_weight: f32,
+
+    /// How hybrid search fuses the two rankings
+    #[serde(default)]
+    pub hybrid_fusion: HybridFusion,
+
+    /// Re-rank the top hits of `semantic_search_with_context` with a
+    /// cross-encoder, for precision on natural-language queries
+    #[serde(default)]
+    pub rerank: bool,
+
+    /// Cross-encoder for re-ranking (e.g. bge-reranker-base, bge-reranker-v2-m3)
+    #[serde(default = "default_rerank_model")]
+    pub rerank_model: String,
+
+    /// Hits re-ranked per query
+    #[serde(default = "default_rerank_candidates")]
+    pub rerank_candidates: usize,
+
+    /// Commit the structural index right away and compute embeddings in a
+    /// background `codanna embed` process (see `codanna embed --status`)
+    #[serde(default)]
+    pub background: bool,
     // API key: set CODANNA_EMBED_API_KEY environment variable.
     // Intentionally not a config field -- secrets must not live in shared config files.
 }
@@ -243,6 +736,25 @@ pub struct FileWatchConfig {
     /// Debounce interval in milliseconds (default: 500ms)
     #[serde(default = "default_debounce_ms")]
     pub debounce_ms: u64,
+
+    /// Milliseconds settled files wait for other pending changes, so a
+    /// checkout is indexed as one batch (default: 0, no waiting)
+    #[serde(default)]
+    pub batch_window_ms: u64,
+
+    /// Glob patterns of files whose changes are ignored, relative to the
+    /// workspace root
+    #[serde(default)]
+    pub ignore: Vec<String>,
+
+    /// Shell command run after each batch is indexed, with the changed
+    /// files in `CODANNA_CHANGED_FILES` and as JSON on stdin
+    #[serde(default)]
+    pub on_index: Option<String>,
+
+    /// URL the changed files of each indexed batch are POSTed to as JSON
+    #[serde(default)]
+    pub webhook: Option<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize, Clone)]
@@ -255,9 +767,95 @@ pub struct ServerConfig {
     #[serde(default = "default_bind_address")]
     pub bind: String,
 
+    /// Permissions of the socket file of `serve --uds`, in octal. The
+    /// default lets its owner alone connect.
+    #[serde(default = "default_socket_mode")]
+    pub socket_mode: String,
+
     /// Watch interval for stdio mode (seconds)
     #[serde(default = "default_watch_interval")]
     pub watch_interval: u64,
+
+    /// Seconds a shutdown waits for the tool calls being answered before
+    /// exiting; calls made meanwhile are refused
+    #[serde(default = "default_shutdown_timeout_secs")]
+    pub shutdown_timeout_secs: u64,
+
+    /// Serve the index without writing to it: no file watcher, and the
+    /// index is reopened whenever a `codanna index` run elsewhere commits
+    #[serde(default)]
+    pub read_only: bool,
+
+    /// Answer text-mode `codanna mcp` calls from a background server
+    /// holding the index and model, started by the first call and stopped
+    /// once idle. `--json` calls and `codanna retrieve` print output built
+    /// in process, so they still open the index themselves.
+    #[serde(default)]
+    pub daemon: bool,
+
+    /// Seconds the background server of `daemon` waits for another call
+    /// before exiting
+    #[serde(default = "default_daemon_idle_timeout_secs")]
+    pub daemon_idle_timeout_secs: u64,
+
+    /// Tool calls one client connection may make per minute, in bursts of
+    /// up to as many. 0 = no limit.
+    #[serde(default)]
+    pub rate_limit_per_minute: u32,
+
+    /// Tool calls the server answers at once across all connections; calls
+    /// past it are refused until one finishes. 0 = no limit.
+    #[serde(default)]
+    pub max_in_flight: usize,
+
+    /// Serve the plain REST/JSON API under /api/v1 on the HTTP/HTTPS
+    /// server, next to MCP
+    #[serde(default)]
+    pub rest_api: bool,
+
+    /// Bearer tokens accepted by the HTTP/HTTPS server. None = no token
+    /// checked beyond the built-in OAuth flow of HTTP mode.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub tokens: Vec<ServerToken>,
+
+    /// Client certificate authentication for HTTPS serve. None = no client
+    /// certificate asked for.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub mtls: Option<MtlsConfig>,
+
+    /// Other projects served next to this one, by name: the root of each,
+    /// holding its `.codanna` directory. Tools choose one with `project`.
+    #[serde(default, skip_serializing_if = "IndexMap::is_empty")]
+    pub projects: IndexMap<String, PathBuf>,
+}
+
+/// Mutual TLS for HTTPS serve
+#[derive(Debug, Deserialize, Serialize, Clone)]
+pub struct MtlsConfig {
+    /// PEM bundle of the CAs whose client certificates are accepted
+    pub client_ca: PathBuf,
+
+    /// Refuse connections without a client certificate. When false, one is
+    /// still verified if sent.
+    #[serde(default = "default_true")]
+    pub require_client_cert: bool,
+
+    /// Client identity logged for each certificate common name; a common
+    /// name not listed is logged as it is
+    #[serde(default)]
+    pub identities: IndexMap<String, String>,
+}
+
+/// A bearer token of the HTTP/HTTPS server, named for the audit log
+#[derive(Debug, Deserialize, Serialize, Clone, PartialEq, Eq)]
+pub struct ServerToken {
+    pub name: String,
+    /// The token itself
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub token: Option<String>,
+    /// Hex SHA-256 of the token, as `codanna serve token create` stores it
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub sha256: Option<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize, Clone)]
@@ -271,6 +869,28 @@ pub struct LoggingConfig {
     /// Example: { "tantivy" = "warn", "watcher" = "debug" }
     #[serde(default)]
     pub modules: IndexMap<String, String>,
+
+    /// How log lines are written to stderr
+    #[serde(default)]
+    pub format: LogFormat,
+
+    /// OTLP/HTTP collector the spans of indexing runs and tool calls are
+    /// exported to, e.g. "http://localhost:4318" (needs the `otel` feature;
+    /// `OTEL_EXPORTER_OTLP_ENDPOINT` works as well)
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub otlp_endpoint: Option<String>,
+}
+
+/// Format of the log lines on stderr
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, Default, PartialEq, Eq)]
+#[serde(rename_all = "lowercase")]
+pub enum LogFormat {
+    /// A timestamp, level, target and message a line
+    #[default]
+    Compact,
+    /// A JSON object a line, with the fields of the event and the spans it
+    /// happened in
+    Json,
 }
 
 impl Default for LoggingConfig {
@@ -278,6 +898,100 @@ impl Default for LoggingConfig {
         Self {
             default: default_log_level(),
             modules: default_logging_modules(),
+            format: LogFormat::default(),
+            otlp_endpoint: None,
+        }
+    }
+}
+
+/// The roots of a project spread over several directories
+#[derive(Debug, Deserialize, Serialize, Clone, Default)]
+pub struct WorkspaceConfig {
+    /// Directories indexed into the one index with the indexed paths, relative
+    /// to the workspace root, e.g. `["app", "libs/shared", "../vendored-sdk"]`.
+    /// Unlike the paths of add-dir they are kept as written, so the settings
+    /// can be committed.
+    #[serde(default)]
+    pub roots: Vec<PathBuf>,
+}
+
+/// Settings of one `[overrides]` glob; a field left unset keeps the value
+/// of the project
+#[derive(Debug, Deserialize, Serialize, Clone, Copy, Default, PartialEq)]
+pub struct PathOverride {
+    /// Embed the doc comments (and code) of the symbols in these files
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub semantic_search: Option<bool>,
+
+    /// Embed the code text of the symbols in these files
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub code_embeddings: Option<bool>,
+
+    /// Code share of the score of the symbols in these files; lower it to
+    /// weigh their doc comments more
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub code_weight: Option<f32>,
+}
+
+#[derive(Debug, Deserialize, Serialize, Clone, Default)]
+pub struct AnalysisConfig {
+    /// Unused symbol detection (`codanna analyze unused`)
+    #[serde(default)]
+    pub unused: UnusedConfig,
+
+    /// Quality gate thresholds (`codanna check`)
+    #[serde(default)]
+    pub check: CheckConfig,
+}
+
+/// Thresholds `codanna check` holds the index to; a threshold left unset
+/// is not checked
+#[derive(Debug, Deserialize, Serialize, Clone, Default, PartialEq)]
+pub struct CheckConfig {
+    /// Lowest percentage of symbols with a doc comment
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub min_doc_coverage: Option<f32>,
+
+    /// Most module cycles
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub max_cycles: Option<usize>,
+
+    /// Most public symbols without a doc comment
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub max_undocumented_public: Option<usize>,
+}
+
+#[derive(Debug, Deserialize, Serialize, Clone)]
+pub struct UnusedConfig {
+    /// Symbol kinds checked for incoming edges
+    #[serde(default = "default_unused_kinds")]
+    pub kinds: Vec<String>,
+
+    /// Names of entry points, called from outside the code (`*` matches any text)
+    #[serde(default = "default_entry_points")]
+    pub entry_points: Vec<String>,
+
+    /// Glob patterns of test files
+    #[serde(default = "default_test_patterns")]
+    pub test_patterns: Vec<String>,
+
+    /// Report symbols in test code
+    #[serde(default = "default_false")]
+    pub include_tests: bool,
+
+    /// Report public symbols, which code outside the index may use
+    #[serde(default = "default_false")]
+    pub include_public: bool,
+}
+
+impl Default for UnusedConfig {
+    fn default() -> Self {
+        Self {
+            kinds: default_unused_kinds(),
+            entry_points: default_entry_points(),
+            test_patterns: default_test_patterns(),
+            include_tests: false,
+            include_public: false,
         }
     }
 }
@@ -332,8 +1046,10 @@ impl Default for Settings {
             version: default_version(),
             index_path: default_index_path(),
             workspace_root: None,
+            workspace: WorkspaceConfig::default(),
             indexing: IndexingConfig::default(),
             indexed_paths_cache: Vec::new(),
+            workspace_roots_cache: Vec::new(),
             languages: generate_language_defaults(), // Now uses registry
             mcp: McpConfig::default(),
             semantic_search: SemanticSearchConfig::default(),
@@ -342,6 +1058,11 @@ impl Default for Settings {
             logging: LoggingConfig::default(),
             guidance: GuidanceConfig::default(),
             documents: crate::documents::DocumentsConfig::default(),
+            analysis: AnalysisConfig::default(),
+            overrides: IndexMap::new(),
+            profiles: IndexMap::new(),
+            profile: None,
+            raw_values: Vec::new(),
         }
     }
 }
@@ -358,11 +1079,30 @@ impl Default for IndexingConfig {
                 ".git/**".to_string(),
                 "*.generated.*".to_string(),
             ],
+            symlinks: SymlinkPolicy::default(),
             indexed_paths: Vec::new(),
             batch_size: default_batch_size(),
             batches_per_commit: default_batches_per_commit(),
+            queue_size: 0,
+            threads: StageThreads::default(),
+            max_memory_mb: 0,
+            max_ast_depth: default_max_ast_depth(),
+            content_cache: false,
+            deterministic: false,
+            listen: None,
+            keep_unresolved: false,
             pipeline_tracing: false,
             show_progress: true,
+            embedded_sql: false,
+            rust_macros: false,
+            embedded_languages: false,
+            string_literals: false,
+            symbol_metrics: true,
+            todo_tags: default_todo_tags(),
+            git_blame: false,
+            language_plugins: default_language_plugins(),
+            shards: IndexMap::new(),
+            webhooks: Vec::new(),
         }
     }
 }
@@ -382,20 +1122,72 @@ impl Default for SemanticSearchConfig {
         Self {
             enabled: true, // Enabled by default for better code intelligence
             model: default_embedding_model(),
+            model_path: None,
             threshold: default_similarity_threshold(),
+            execution_provider: ExecutionProvider::default(),
             embedding_threads: default_embedding_threads(),
+            provider: None,
             remote_url: None,
             remote_model: None,
             remote_dim: None,
+            remote_batch_size: default_remote_batch_size(),
+            remote_max_retries: default_remote_max_retries(),
+            remote_requests_per_minute: None,
+            quantization: VectorQuantization::default(),
+            code_embeddings: false,
+            code_weight: default_code_weight(),
+            code_chunks: false,
+            code_chunk_tokens: default_code_chunk_tokens(),
+            code_chunk_overlap: default_code_chunk_overlap(),
+            hybrid: false,
+            hybrid_weight: default_hybrid_weight(),
+            hybrid_fusion: HybridFusion::default(),
+            rerank: false,
+            rerank_model: default_rerank_model(),
+            rerank_candidates: default_rerank_candidates(),
+            background: false,
         }
     }
 }
 
+impl SemanticSearchConfig {
+    /// The remote server to embed with, if embeddings are remote. The
+    /// CODANNA_EMBED_URL env var overrides the config.
+    pub fn remote_endpoint(&self) -> Option<String> {
+        if let Ok(url) = std::env::var("CODANNA_EMBED_URL") {
+            return Some(url);
+        }
+        match self.provider {
+            Some(EmbeddingProvider::Local) => None,
+            Some(EmbeddingProvider::Http) | None => self.remote_url.clone(),
+        }
+    }
+
+    /// `model_path` against `workspace_root` when relative
+    pub fn resolved_model_path(&self, workspace_root: Option<&Path>) -> Option<PathBuf> {
+        let path = self.model_path.as_ref()?;
+        Some(match workspace_root {
+            Some(root) => root.join(path),
+            None => path.clone(),
+        })
+    }
+
+    /// Whether embeddings come from a remote server rather than local
+    /// inference
+    pub fn is_remote(&self) -> bool {
+        self.provider == Some(EmbeddingProvider::Http) || self.remote_endpoint().is_some()
+    }
+}
+
 impl Default for FileWatchConfig {
     fn default() -> Self {
         Self {
             enabled: true, // Default to enabled for better user experience
             debounce_ms: default_debounce_ms(),
+            batch_window_ms: 0,
+            ignore: Vec::new(),
+            on_index: None,
+            webhook: None,
         }
     }
 }
@@ -405,7 +1197,18 @@ impl Default for ServerConfig {
         Self {
             mode: default_server_mode(),
             bind: default_bind_address(),
+            socket_mode: default_socket_mode(),
             watch_interval: default_watch_interval(),
+            shutdown_timeout_secs: default_shutdown_timeout_secs(),
+            read_only: false,
+            daemon: false,
+            daemon_idle_timeout_secs: default_daemon_idle_timeout_secs(),
+            rate_limit_per_minute: 0,
+            max_in_flight: 0,
+            rest_api: false,
+            tokens: Vec::new(),
+            mtls: None,
+            projects: IndexMap::new(),
         }
     }
 }
@@ -436,16 +1239,26 @@ impl Settings {
 
     /// Load configuration from all sources
     pub fn load() -> Result<Self, Box<figment::Error>> {
+        Self::load_profile(None)
+    }
+
+    /// Load configuration from all sources, with the `[profiles.<name>]`
+    /// table of the file laid over the rest of it
+    pub fn load_profile(profile: Option<&str>) -> Result<Self, Box<figment::Error>> {
         // Try to find the workspace root by looking for config directory
         let local_dir = crate::init::local_dir_name();
         let config_path = Self::find_workspace_config()
             .unwrap_or_else(|| PathBuf::from(local_dir).join("settings.toml"));
 
-        Figment::new()
+        let (expanded, raw_values) = Self::substitutions(&config_path)?;
+        let figment = Figment::new()
             // Start with defaults
             .merge(Serialized::defaults(Settings::default()))
             // Layer in config file if it exists
             .merge(Toml::file(config_path))
+            // and its ${VAR} and $(command) values, substituted
+            .merge(Serialized::defaults(expanded));
+        Self::select_profile(figment, profile)?
             // Layer in environment variables with CI_ prefix
             // Use double underscore (__) to separate nested levels
             // Single underscore (_) remains as is within field names
@@ -463,9 +1276,105 @@ impl Settings {
                 if settings.workspace_root.is_none() {
                     settings.workspace_root = Self::workspace_root();
                 }
+                let base = settings
+                    .workspace_root
+                    .clone()
+                    .unwrap_or_else(|| PathBuf::from("."));
+                settings.merge_workspace_roots(&base);
                 settings.sync_indexed_path_cache();
+                settings.profile = profile.map(str::to_string);
+                settings.raw_values = raw_values;
                 settings
             })
+            .and_then(Self::checked_globs)
+    }
+
+    /// The settings file at `path` with its `${VAR}` and `$(command)`
+    /// values substituted, and those values as written; empty when it has
+    /// none. A missing or malformed file is left to figment to report.
+    fn substitutions(path: &Path) -> Result<(toml::Table, Vec<RawValue>), Box<figment::Error>> {
+        let Ok(text) = std::fs::read_to_string(path) else {
+            return Ok(Default::default());
+        };
+        if !text.contains('$') {
+            return Ok(Default::default());
+        }
+        let Ok(mut table) = toml::from_str::<toml::Table>(&text) else {
+            return Ok(Default::default());
+        };
+        let raw_values = substitute::expand_table(&mut table)
+            .map_err(|e| Box::new(figment::Error::from(format!("{}: {e}", path.display()))))?;
+        if raw_values.is_empty() {
+            return Ok(Default::default());
+        }
+        Ok((table, raw_values))
+    }
+
+    /// `figment` with the `[profiles.<name>]` table of its settings merged
+    /// over them
+    fn select_profile(
+        figment: Figment,
+        profile: Option<&str>,
+    ) -> Result<Figment, Box<figment::Error>> {
+        let Some(name) = profile else {
+            return Ok(figment);
+        };
+        let selected = figment
+            .find_value(&format!("profiles.{name}"))
+            .map_err(|_| {
+                let defined = figment
+                    .extract_inner::<IndexMap<String, toml::Table>>("profiles")
+                    .map(|profiles| profiles.into_keys().collect::<Vec<_>>())
+                    .unwrap_or_default();
+                let defined = if defined.is_empty() {
+                    "none are defined".to_string()
+                } else {
+                    format!("defined: {}", defined.join(", "))
+                };
+                Box::new(figment::Error::from(format!(
+                    "unknown profile '{name}' ({defined})"
+                )))
+            })?;
+        Ok(figment.merge(Serialized::defaults(selected)))
+    }
+
+    /// The settings, or an error naming an invalid `[overrides]` or
+    /// `[languages]` glob or kind rule
+    fn checked_globs(settings: Settings) -> Result<Self, Box<figment::Error>> {
+        PathOverrides::new(&settings).map_err(|e| Box::new(figment::Error::from(e)))?;
+        for (language, config) in &settings.languages {
+            let scope = &config.scope;
+            for (key, pattern) in scope
+                .include
+                .iter()
+                .map(|pattern| ("include", pattern))
+                .chain(scope.exclude.iter().map(|pattern| ("exclude", pattern)))
+            {
+                if let Err(e) = glob::Pattern::new(pattern) {
+                    return Err(Box::new(figment::Error::from(format!(
+                        "languages.{language}.{key}: invalid pattern '{pattern}': {e}"
+                    ))));
+                }
+            }
+            if let Err(e) = crate::parsing::kinds::KindRules::compile(&config.kinds) {
+                return Err(Box::new(figment::Error::from(format!(
+                    "languages.{language}.kinds: {e}"
+                ))));
+            }
+        }
+        Ok(settings)
+    }
+
+    /// Names of the framework kinds the `[[languages.<name>.kinds]]` rules
+    /// give, once each
+    pub fn framework_kinds(&self) -> Vec<String> {
+        let mut kinds: Vec<String> = Vec::new();
+        for rule in self.languages.values().flat_map(|config| &config.kinds) {
+            if !kinds.contains(&rule.kind) {
+                kinds.push(rule.kind.clone());
+            }
+        }
+        kinds
     }
 
     /// Find the workspace root by looking for .codanna directory
@@ -533,16 +1442,37 @@ impl Settings {
 
     /// Load configuration from a specific file
     pub fn load_from(path: impl AsRef<std::path::Path>) -> Result<Self, Box<figment::Error>> {
-        Figment::new()
+        Self::load_from_profile(path, None)
+    }
+
+    /// Load configuration from a specific file, with its
+    /// `[profiles.<name>]` table laid over the rest of it
+    pub fn load_from_profile(
+        path: impl AsRef<std::path::Path>,
+        profile: Option<&str>,
+    ) -> Result<Self, Box<figment::Error>> {
+        let path = path.as_ref();
+        let (expanded, raw_values) = Self::substitutions(path)?;
+        let figment = Figment::new()
             .merge(Serialized::defaults(Settings::default()))
             .merge(Toml::file(path))
+            .merge(Serialized::defaults(expanded));
+        Self::select_profile(figment, profile)?
             .merge(Env::prefixed("CI_").split("_"))
             .extract()
             .map(|mut settings: Settings| {
+                let base = settings
+                    .workspace_root
+                    .clone()
+                    .unwrap_or_else(|| paths::project_dir_of(path));
+                settings.merge_workspace_roots(&base);
                 settings.sync_indexed_path_cache();
+                settings.profile = profile.map(str::to_string);
+                settings.raw_values = raw_values;
                 settings
             })
             .map_err(Box::new)
+            .and_then(Self::checked_globs)
     }
 
     /// Save current configuration to file
@@ -553,7 +1483,21 @@ impl Settings {
         let parent = path.as_ref().parent().ok_or("Invalid path")?;
         std::fs::create_dir_all(parent)?;
 
-        let toml_string = toml::to_string_pretty(self)?;
+        // Roots stay in [workspace], as written
+        let mut saved = self.clone();
+        saved
+            .indexing
+            .indexed_paths
+            .retain(|path| !self.workspace_roots_cache.contains(path));
+        let toml_string = if self.raw_values.is_empty() {
+            toml::to_string_pretty(&saved)?
+        } else {
+            // Substituted values go back as written, not as the secrets
+            // they expanded to
+            let mut value = toml::Value::try_from(&saved)?;
+            substitute::restore_raw(&mut value, &self.raw_values);
+            toml::to_string_pretty(&value)?
+        };
         let toml_with_comments = Self::add_config_comments(toml_string);
         std::fs::write(path, toml_with_comments)?;
 
@@ -744,6 +1688,8 @@ default = "info"
         let config = FileWatchConfig::default();
         assert!(config.enabled); // Now defaults to true
         assert_eq!(config.debounce_ms, 500);
+        assert_eq!(config.batch_window_ms, 0);
+        assert!(config.ignore.is_empty() && config.on_index.is_none());
 
         println!(
             "  ✓ Default config: enabled={}, debounce_ms={}",
@@ -752,6 +1698,159 @@ default = "info"
         println!("=== TEST PASSED ===");
     }
 
+    #[test]
+    fn test_language_limits_from_toml() {
+        let temp_dir = TempDir::new().unwrap();
+        let config_path = temp_dir.path().join("settings.toml");
+        fs::write(
+            &config_path,
+            r#"
+[languages.javascript]
+enabled = true
+max_file_size = 500000
+max_line_length = 2000
+large_files = "symbols-only"
+"#,
+        )
+        .unwrap();
+
+        let settings: Settings = Figment::new()
+            .merge(Serialized::defaults(Settings::default()))
+            .merge(Toml::file(config_path))
+            .extract()
+            .unwrap();
+
+        let limits = &settings.languages["javascript"].limits;
+        assert_eq!(limits.max_file_size, Some(500_000));
+        assert_eq!(limits.max_line_length, Some(2000));
+        assert_eq!(limits.large_files, LargeFilePolicy::SymbolsOnly);
+        assert_eq!(settings.languages["rust"].limits, FileLimits::default());
+
+        assert!(limits.exceeded_by("short\nlines\n").is_none());
+        let minified = "x".repeat(2001);
+        assert!(limits.exceeded_by(&minified).unwrap().contains("2001"));
+    }
+
+    #[test]
+    fn test_kind_rules_from_toml() {
+        let temp_dir = TempDir::new().unwrap();
+        let config_path = temp_dir.path().join("settings.toml");
+        fs::write(
+            &config_path,
+            r#"
+[[languages.rust.kinds]]
+kind = "actix_handler"
+base = ["function"]
+attribute = '^#\[(get|post)\('
+
+[[languages.python.kinds]]
+kind = "django_view"
+signature = '\(request'
+paths = ["**/views.py"]
+"#,
+        )
+        .unwrap();
+
+        let settings = Settings::load_from(&config_path).unwrap();
+        let rule = &settings.languages["rust"].kinds[0];
+        assert_eq!(rule.kind, "actix_handler");
+        assert_eq!(rule.base, ["function"]);
+        assert_eq!(settings.framework_kinds(), ["django_view", "actix_handler"]);
+
+        fs::write(
+            &config_path,
+            "[[languages.rust.kinds]]\nkind = \"handler\"\nname = \"(unclosed\"\n",
+        )
+        .unwrap();
+        let error = Settings::load_from(&config_path).unwrap_err();
+        assert!(
+            error.to_string().contains("languages.rust.kinds"),
+            "{error}"
+        );
+    }
+
+    #[test]
+    fn test_execution_provider_from_toml() {
+        assert_eq!(
+            Settings::default().semantic_search.execution_provider,
+            ExecutionProvider::Cpu
+        );
+        for (value, expected) in [
+            ("cuda", ExecutionProvider::Cuda),
+            ("metal", ExecutionProvider::CoreMl),
+            ("auto", ExecutionProvider::Auto),
+        ] {
+            let settings: Settings = Figment::new()
+                .merge(Serialized::defaults(Settings::default()))
+                .merge(Toml::string(&format!(
+                    "[semantic_search]\nexecution_provider = \"{value}\"\n"
+                )))
+                .extract()
+                .unwrap();
+            assert_eq!(settings.semantic_search.execution_provider, expected);
+        }
+    }
+
+    #[test]
+    fn test_hybrid_search_from_toml() {
+        let defaults = Settings::default().semantic_search;
+        assert!(!defaults.hybrid);
+        assert_eq!(defaults.hybrid_fusion, HybridFusion::Weighted);
+
+        let settings: Settings = Figment::new()
+            .merge(Serialized::defaults(Settings::default()))
+            .merge(Toml::string(
+                "[semantic_search]\nhybrid = true\nhybrid_weight = 0.3\nhybrid_fusion = \"rrf\"\n",
+            ))
+            .extract()
+            .unwrap();
+        assert!(settings.semantic_search.hybrid);
+        assert_eq!(settings.semantic_search.hybrid_weight, 0.3);
+        assert_eq!(settings.semantic_search.hybrid_fusion, HybridFusion::Rrf);
+    }
+
+    #[test]
+    fn test_code_embeddings_from_toml() {
+        let defaults = Settings::default().semantic_search;
+        assert!(!defaults.code_embeddings);
+        assert_eq!(defaults.code_weight, 0.5);
+
+        let settings: Settings = Figment::new()
+            .merge(Serialized::defaults(Settings::default()))
+            .merge(Toml::string(
+                "[semantic_search]\ncode_embeddings = true\ncode_weight = 0.7\n",
+            ))
+            .extract()
+            .unwrap();
+        assert!(settings.semantic_search.code_embeddings);
+        assert_eq!(settings.semantic_search.code_weight, 0.7);
+
+        assert_eq!("Code".parse::<SemanticVectors>(), Ok(SemanticVectors::Code));
+        assert!("bodies".parse::<SemanticVectors>().is_err());
+    }
+
+    #[test]
+    fn test_model_path_from_toml() {
+        assert!(Settings::default().semantic_search.model_path.is_none());
+
+        let settings: Settings = Figment::new()
+            .merge(Serialized::defaults(Settings::default()))
+            .merge(Toml::string(
+                "[semantic_search]\nmodel_path = \"vendor/codanna-models\"\n",
+            ))
+            .extract()
+            .unwrap();
+        let semantic = settings.semantic_search;
+        assert_eq!(
+            semantic.resolved_model_path(Some(Path::new("/work/project"))),
+            Some(PathBuf::from("/work/project/vendor/codanna-models"))
+        );
+        assert_eq!(
+            semantic.resolved_model_path(None),
+            Some(PathBuf::from("vendor/codanna-models"))
+        );
+    }
+
     #[test]
     fn test_file_watch_config_from_toml() {
         println!("\n=== TEST: FileWatchConfig from TOML ===");
@@ -998,6 +2097,126 @@ indexed_paths = ["{path1_str}", "{path2_str}"]
         assert_eq!(settings.indexing.indexed_paths[1], test_folder2);
     }
 
+    #[test]
+    fn test_workspace_roots_are_indexed_but_saved_as_written() {
+        let temp_dir = TempDir::new().unwrap();
+        let project = temp_dir.path().join("project");
+        let shared = temp_dir.path().join("shared");
+        fs::create_dir_all(project.join(".codanna")).unwrap();
+        fs::create_dir_all(project.join("app")).unwrap();
+        fs::create_dir(&shared).unwrap();
+        let config_path = project.join(".codanna/settings.toml");
+        fs::write(
+            &config_path,
+            "[workspace]\nroots = [\"app\", \"../shared\"]\n",
+        )
+        .unwrap();
+
+        let mut settings = Settings::load_from(&config_path).unwrap();
+        let app = project.join("app").canonicalize().unwrap();
+        let shared = shared.canonicalize().unwrap();
+        assert_eq!(settings.indexing.indexed_paths, vec![app.clone(), shared]);
+        assert_eq!(settings.indexed_paths_cache.len(), 2);
+        assert!(settings.add_indexed_path(app.join(".")).is_err());
+        assert!(settings.remove_indexed_path(&app).is_err());
+
+        settings.save(&config_path).unwrap();
+        let saved = fs::read_to_string(&config_path).unwrap();
+        assert!(saved.contains("indexed_paths = []"), "{saved}");
+        assert!(saved.contains("\"../shared\""));
+    }
+
+    #[test]
+    fn test_substituted_values_are_saved_as_written() {
+        let temp_dir = TempDir::new().unwrap();
+        let config_path = temp_dir.path().join("settings.toml");
+        fs::write(
+            &config_path,
+            "[semantic_search]\nmodel = \"${CODANNA_TEST_UNSET_MODEL:-AllMiniLML6V2}\"\n",
+        )
+        .unwrap();
+
+        let settings = Settings::load_from(&config_path).unwrap();
+        assert_eq!(settings.semantic_search.model, "AllMiniLML6V2");
+        settings.save(&config_path).unwrap();
+        let saved = fs::read_to_string(&config_path).unwrap();
+        assert!(
+            saved.contains("model = \"${CODANNA_TEST_UNSET_MODEL:-AllMiniLML6V2}\""),
+            "{saved}"
+        );
+
+        fs::write(
+            &config_path,
+            "[server]\nbind = \"${CODANNA_TEST_UNSET_BIND}\"\n",
+        )
+        .unwrap();
+        let error = Settings::load_from(&config_path).unwrap_err();
+        assert!(error.to_string().contains("server.bind"), "{error}");
+    }
+
+    #[test]
+    fn test_profile_is_laid_over_the_settings() {
+        let temp_dir = TempDir::new().unwrap();
+        let config_path = temp_dir.path().join("settings.toml");
+        fs::write(
+            &config_path,
+            "[indexing]\nparallelism = 8\ntantivy_heap_mb = 100\n\
+             [semantic_search]\nenabled = true\n\
+             [profiles.ci.indexing]\nparallelism = 2\n\
+             [profiles.ci.semantic_search]\nenabled = false\n\
+             [profiles.agent.file_watch]\nenabled = true\n",
+        )
+        .unwrap();
+
+        let base = Settings::load_from(&config_path).unwrap();
+        assert_eq!(base.indexing.parallelism, 8);
+        assert!(base.semantic_search.enabled);
+        assert_eq!(base.profile, None);
+
+        let ci = Settings::load_from_profile(&config_path, Some("ci")).unwrap();
+        assert_eq!(ci.indexing.parallelism, 2);
+        // Keys the profile leaves out keep their values
+        assert_eq!(ci.indexing.tantivy_heap_mb, 100);
+        assert!(!ci.semantic_search.enabled);
+        assert_eq!(ci.profile.as_deref(), Some("ci"));
+        assert_eq!(ci.profiles.len(), 2);
+
+        let error = Settings::load_from_profile(&config_path, Some("nightly")).unwrap_err();
+        assert!(error.to_string().contains("defined: agent, ci"), "{error}");
+    }
+
+    #[test]
+    fn test_path_overrides_longer_globs_win() {
+        let temp_dir = TempDir::new().unwrap();
+        let config_path = temp_dir.path().join("settings.toml");
+        fs::write(
+            &config_path,
+            "workspace_root = \"/work\"\n\
+             [overrides.\"vendor/**\"]\nsemantic_search = false\ncode_weight = 0.9\n\
+             [overrides.\"vendor/ours/**\"]\nsemantic_search = true\n\
+             [overrides.\"docs/**\"]\ncode_weight = 0.2\n",
+        )
+        .unwrap();
+        let settings = Settings::load_from(&config_path).unwrap();
+        let overrides = PathOverrides::new(&settings).unwrap();
+
+        assert!(!overrides.embeds(Path::new("/work/vendor/lib/a.rs")));
+        let ours = overrides.for_path(Path::new("./vendor/ours/b.rs"));
+        assert_eq!(ours.semantic_search, Some(true));
+        assert_eq!(ours.code_weight, Some(0.9));
+        assert_eq!(
+            overrides.for_path(Path::new("docs/guide.rs")).code_weight,
+            Some(0.2)
+        );
+        assert_eq!(
+            overrides.for_path(Path::new("src/main.rs")),
+            PathOverride::default()
+        );
+
+        fs::write(&config_path, "[overrides.\"[\"]\nsemantic_search = false\n").unwrap();
+        assert!(Settings::load_from(&config_path).is_err());
+    }
+
     #[test]
     fn test_save_indexed_paths_to_toml() {
         let temp_dir = TempDir::new().unwrap();
@@ -1084,4 +2303,31 @@ max_chunk_chars = 2500
         assert_eq!(settings.documents.defaults.overlap_chars, 100);
         assert!(settings.documents.collections.is_empty());
     }
+
+    #[test]
+    fn test_reload_changes_split_live_and_restart_keys() {
+        let current = Settings::default();
+        let mut edited = current.clone();
+        edited.semantic_search.threshold = 0.8;
+        edited.indexing.ignore_patterns = vec!["vendor/**".to_string()];
+        edited.server.bind = "127.0.0.1:9000".to_string();
+        // No tokens were listed at startup, so no token check is running
+        edited.server.tokens.push(ServerToken {
+            name: "ci".to_string(),
+            token: Some("secret".to_string()),
+            sha256: None,
+        });
+
+        let changes = current.reload_changes(&edited);
+        assert_eq!(
+            changes.applied,
+            vec!["indexing.ignore_patterns", "semantic_search.threshold"]
+        );
+        assert_eq!(changes.need_restart, vec!["server.bind", "server.tokens"]);
+
+        let live = current.with_live_values_of(&edited);
+        assert_eq!(live.semantic_search.threshold, 0.8);
+        assert_eq!(live.server.bind, current.server.bind);
+        assert!(current.reload_changes(&current).is_empty());
+    }
 }
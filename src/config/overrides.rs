@@ -0,0 +1,74 @@
+//! Per-directory settings: the `[overrides]` globs matched against files.
+//!
+//! One index covers the whole project, but not every part of it deserves
+//! the same treatment: `vendor/**` can leave semantic search out, and a
+//! `docs/**` tree can weigh its doc comments over its code. The globs are
+//! matched against paths relative to the workspace root, and where several
+//! match a file, the longer glob wins: `vendor/ours/**` over `vendor/**`.
+//! The settings come through figment, which keeps no table order, so the
+//! order the globs are written in cannot decide.
+
+use super::{PathOverride, Settings};
+use std::path::{Path, PathBuf};
+
+/// The `[overrides]` of the settings, compiled
+#[derive(Debug, Clone, Default)]
+pub struct PathOverrides {
+    /// Shortest glob first
+    rules: Vec<(glob::Pattern, PathOverride)>,
+    workspace_root: Option<PathBuf>,
+}
+
+impl PathOverrides {
+    /// Compile the globs of `settings`; an invalid one is an error
+    pub fn new(settings: &Settings) -> Result<Self, String> {
+        let mut rules: Vec<(glob::Pattern, PathOverride)> = settings
+            .overrides
+            .iter()
+            .map(|(pattern, values)| {
+                glob::Pattern::new(pattern)
+                    .map(|compiled| (compiled, *values))
+                    .map_err(|e| format!("overrides: invalid pattern '{pattern}': {e}"))
+            })
+            .collect::<Result<_, _>>()?;
+        rules.sort_by(|(a, _), (b, _)| {
+            a.as_str()
+                .len()
+                .cmp(&b.as_str().len())
+                .then_with(|| a.as_str().cmp(b.as_str()))
+        });
+        Ok(Self {
+            rules,
+            workspace_root: settings.workspace_root.clone(),
+        })
+    }
+
+    /// Whether no glob is set
+    pub fn is_empty(&self) -> bool {
+        self.rules.is_empty()
+    }
+
+    /// The overrides of the globs that match `path`, the longer glob's
+    /// value where two set one
+    pub fn for_path(&self, path: &Path) -> PathOverride {
+        let mut merged = PathOverride::default();
+        if self.rules.is_empty() {
+            return merged;
+        }
+        let relative = super::paths::relative_to(self.workspace_root.as_deref(), path);
+        for (pattern, values) in &self.rules {
+            if !pattern.matches_path(relative) {
+                continue;
+            }
+            merged.semantic_search = values.semantic_search.or(merged.semantic_search);
+            merged.code_embeddings = values.code_embeddings.or(merged.code_embeddings);
+            merged.code_weight = values.code_weight.or(merged.code_weight);
+        }
+        merged
+    }
+
+    /// Whether the symbols of `path` get embeddings at all
+    pub fn embeds(&self, path: &Path) -> bool {
+        self.for_path(path).semantic_search != Some(false)
+    }
+}
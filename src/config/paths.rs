@@ -1,13 +1,70 @@
 //! Indexed-path management: the indexed_paths list and its canonicalized cache.
+//!
+//! The roots of `[workspace]` join the indexed paths when the settings are
+//! loaded, resolved against the workspace root, and are left out again when
+//! they are saved.
 
 use super::Settings;
 use std::path::{Path, PathBuf};
 
+/// The project directory of a settings file: the parent of its
+/// `.codanna` directory, or the directory holding it
+pub(super) fn project_dir_of(config_path: &Path) -> PathBuf {
+    let dir = config_path.parent().unwrap_or(Path::new("."));
+    let local_dir = crate::init::local_dir_name();
+    match dir.parent() {
+        Some(project) if dir.file_name().is_some_and(|name| name == local_dir) => {
+            project.to_path_buf()
+        }
+        _ => dir.to_path_buf(),
+    }
+}
+
+/// `path` relative to `root` when under it, without a leading `./`
+pub(super) fn relative_to<'a>(root: Option<&Path>, path: &'a Path) -> &'a Path {
+    let relative = root
+        .and_then(|root| path.strip_prefix(root).ok())
+        .unwrap_or(path);
+    relative.strip_prefix(".").unwrap_or(relative)
+}
+
 impl Settings {
+    /// `path` relative to the workspace root, as the globs of the settings
+    /// are written
+    pub fn workspace_relative<'a>(&self, path: &'a Path) -> &'a Path {
+        relative_to(self.workspace_root.as_deref(), path)
+    }
+
     pub(super) fn sync_indexed_path_cache(&mut self) {
         self.indexed_paths_cache = self.indexing.indexed_paths.clone();
     }
 
+    /// Add the roots of `[workspace]` to the indexed paths, relative ones
+    /// resolved against `base`. A root an indexed path already covers is
+    /// left out.
+    pub(super) fn merge_workspace_roots(&mut self, base: &Path) {
+        self.workspace_roots_cache.clear();
+        for root in &self.workspace.roots {
+            let path = if root.is_absolute() {
+                root.clone()
+            } else {
+                base.join(root)
+            };
+            // A missing root is kept, so indexing reports it
+            let path = path.canonicalize().unwrap_or(path);
+            if self
+                .indexing
+                .indexed_paths
+                .iter()
+                .any(|existing| path.starts_with(existing))
+            {
+                continue;
+            }
+            self.indexing.indexed_paths.push(path.clone());
+            self.workspace_roots_cache.push(path);
+        }
+    }
+
     /// Add a folder to the list of indexed paths
     pub fn add_indexed_path(&mut self, path: PathBuf) -> Result<(), String> {
         // Canonicalize the path to avoid duplicates
@@ -59,6 +116,12 @@ impl Settings {
         let canonical_path = path
             .canonicalize()
             .map_err(|e| format!("Invalid path: {e}"))?;
+        if self.workspace_roots_cache.contains(&canonical_path) {
+            return Err(format!(
+                "{} is a root of [workspace] in settings.toml; remove it from workspace.roots",
+                path.display()
+            ));
+        }
 
         let original_len = self.indexing.indexed_paths.len();
         self.indexing.indexed_paths.retain(|p| p != &canonical_path);
@@ -0,0 +1,80 @@
+//! Settings reload: which edits to settings.toml a running server takes up.
+
+use super::Settings;
+use std::collections::BTreeSet;
+
+/// Dotted keys (`section.key`) that differ between two settings
+#[derive(Debug, Default, PartialEq, Eq)]
+pub struct SettingsChanges {
+    /// Taken up by the running server
+    pub applied: Vec<String>,
+    /// Read once at startup, so left as they were until a restart
+    pub need_restart: Vec<String>,
+}
+
+impl SettingsChanges {
+    pub fn is_empty(&self) -> bool {
+        self.applied.is_empty() && self.need_restart.is_empty()
+    }
+}
+
+impl Settings {
+    /// These settings with the values of `new` that a running server can
+    /// take up in place: guidance, ignore patterns, indexed paths and
+    /// workspace roots, the threshold and code weight of semantic search, and the bearer tokens
+    /// while both list some (the token check is installed at startup).
+    pub fn with_live_values_of(&self, new: &Settings) -> Settings {
+        let mut live = self.clone();
+        live.guidance = new.guidance.clone();
+        live.indexing.ignore_patterns = new.indexing.ignore_patterns.clone();
+        live.indexing.indexed_paths = new.indexing.indexed_paths.clone();
+        live.workspace = new.workspace.clone();
+        live.workspace_roots_cache = new.workspace_roots_cache.clone();
+        live.sync_indexed_path_cache();
+        live.semantic_search.threshold = new.semantic_search.threshold;
+        live.semantic_search.code_weight = new.semantic_search.code_weight;
+        if !self.server.tokens.is_empty() && !new.server.tokens.is_empty() {
+            live.server.tokens = new.server.tokens.clone();
+        }
+        live
+    }
+
+    /// What reloading `new` over these settings changes
+    pub fn reload_changes(&self, new: &Settings) -> SettingsChanges {
+        let live = self.with_live_values_of(new);
+        SettingsChanges {
+            applied: changed_keys(self, &live),
+            need_restart: changed_keys(&live, new),
+        }
+    }
+}
+
+/// Keys whose values differ, compared a table deep: `guidance.templates`
+/// changes as a whole
+fn changed_keys(old: &Settings, new: &Settings) -> Vec<String> {
+    use serde_json::Value;
+
+    let (Ok(Value::Object(old)), Ok(Value::Object(new))) =
+        (serde_json::to_value(old), serde_json::to_value(new))
+    else {
+        return Vec::new();
+    };
+    let sections: BTreeSet<&String> = old.keys().chain(new.keys()).collect();
+
+    let mut changed = Vec::new();
+    for section in sections {
+        match (old.get(section), new.get(section)) {
+            (Some(Value::Object(old_table)), Some(Value::Object(new_table))) => {
+                let keys: BTreeSet<&String> = old_table.keys().chain(new_table.keys()).collect();
+                changed.extend(
+                    keys.into_iter()
+                        .filter(|key| old_table.get(*key) != new_table.get(*key))
+                        .map(|key| format!("{section}.{key}")),
+                );
+            }
+            (old_value, new_value) if old_value != new_value => changed.push(section.clone()),
+            _ => {}
+        }
+    }
+    changed
+}
@@ -0,0 +1,291 @@
+//! Substitution in settings.toml: `${VAR}` and `$(command)` in string values.
+//!
+//! Tokens, model paths and provider URLs differ from machine to machine, and
+//! secrets have no place in a committed file. A string value can name them
+//! instead: `${VAR}` is the environment variable, `${VAR:-default}` falls
+//! back when it is unset or empty, `$(command)` is the output of a shell
+//! command (a secrets manager, say), and `$$` is a plain `$`.
+//!
+//! A command from a settings file that came with a checkout is a command
+//! from whoever wrote it, so `$(command)` runs only when
+//! `CODANNA_CONFIG_COMMANDS=1` is set. Settings written back to the file
+//! keep the values as written, not what they expanded to.
+
+use std::fmt;
+
+/// Environment variable that lets `$(command)` run
+pub const COMMANDS_ENV: &str = "CODANNA_CONFIG_COMMANDS";
+
+/// One step of the key of a value
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum KeyPart {
+    Key(String),
+    Index(usize),
+}
+
+/// A string value of the settings file that held a substitution
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct RawValue {
+    pub key: Vec<KeyPart>,
+    /// As written in the file
+    pub raw: String,
+    /// As substituted
+    pub expanded: String,
+}
+
+/// A key as written in TOML: `server.bind`, `indexing.ignore_patterns[2]`
+pub struct DisplayKey<'a>(pub &'a [KeyPart]);
+
+impl fmt::Display for DisplayKey<'_> {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        for (i, part) in self.0.iter().enumerate() {
+            match part {
+                KeyPart::Key(key) if i == 0 => write!(f, "{key}")?,
+                KeyPart::Key(key) => write!(f, ".{key}")?,
+                KeyPart::Index(index) => write!(f, "[{index}]")?,
+            }
+        }
+        Ok(())
+    }
+}
+
+/// Substitute the string values of `table`, returning those it changed
+pub(super) fn expand_table(table: &mut toml::Table) -> Result<Vec<RawValue>, String> {
+    let commands = std::env::var(COMMANDS_ENV).is_ok_and(|value| value == "1");
+    let env = |name: &str| std::env::var(name).ok();
+    let mut changed = Vec::new();
+    let mut key = Vec::new();
+    for (name, value) in table.iter_mut() {
+        key.push(KeyPart::Key(name.clone()));
+        expand_value(value, &mut key, &env, commands, &mut changed)?;
+        key.pop();
+    }
+    Ok(changed)
+}
+
+fn expand_value(
+    value: &mut toml::Value,
+    key: &mut Vec<KeyPart>,
+    env: &impl Fn(&str) -> Option<String>,
+    commands: bool,
+    changed: &mut Vec<RawValue>,
+) -> Result<(), String> {
+    match value {
+        toml::Value::String(text) if text.contains('$') => {
+            let expanded = expand_with(text, env, commands)
+                .map_err(|e| format!("{}: {e}", DisplayKey(key)))?;
+            if expanded != *text {
+                changed.push(RawValue {
+                    key: key.clone(),
+                    raw: std::mem::replace(text, expanded.clone()),
+                    expanded,
+                });
+            }
+        }
+        toml::Value::Array(items) => {
+            for (index, item) in items.iter_mut().enumerate() {
+                key.push(KeyPart::Index(index));
+                expand_value(item, key, env, commands, changed)?;
+                key.pop();
+            }
+        }
+        toml::Value::Table(table) => {
+            for (name, item) in table.iter_mut() {
+                key.push(KeyPart::Key(name.clone()));
+                expand_value(item, key, env, commands, changed)?;
+                key.pop();
+            }
+        }
+        _ => {}
+    }
+    Ok(())
+}
+
+/// Put back the values as written where `value` still holds what they
+/// expanded to; one changed since loading is saved as it now is
+pub(super) fn restore_raw(value: &mut toml::Value, raw_values: &[RawValue]) {
+    for raw_value in raw_values {
+        let mut at = Some(&mut *value);
+        for part in &raw_value.key {
+            at = match (at, part) {
+                (Some(toml::Value::Table(table)), KeyPart::Key(key)) => table.get_mut(key),
+                (Some(toml::Value::Array(items)), KeyPart::Index(index)) => items.get_mut(*index),
+                _ => None,
+            };
+        }
+        if let Some(toml::Value::String(text)) = at {
+            if *text == raw_value.expanded {
+                *text = raw_value.raw.clone();
+            }
+        }
+    }
+}
+
+/// `text` with its substitutions made, variables read from `env`
+fn expand_with(
+    text: &str,
+    env: &impl Fn(&str) -> Option<String>,
+    commands: bool,
+) -> Result<String, String> {
+    let mut out = String::with_capacity(text.len());
+    let mut rest = text;
+    while let Some(at) = rest.find('$') {
+        out.push_str(&rest[..at]);
+        let after = &rest[at + 1..];
+        if let Some(after) = after.strip_prefix('$') {
+            out.push('$');
+            rest = after;
+        } else if let Some(inner) = after.strip_prefix('{') {
+            let end = inner
+                .find('}')
+                .ok_or_else(|| format!("unclosed '${{' in '{text}'"))?;
+            out.push_str(&variable(&inner[..end], env)?);
+            rest = &inner[end + 1..];
+        } else if let Some(inner) = after.strip_prefix('(') {
+            let end = closing_paren(inner).ok_or_else(|| format!("unclosed '$(' in '{text}'"))?;
+            if !commands {
+                return Err(format!(
+                    "'$({})' is not run without {COMMANDS_ENV}=1",
+                    &inner[..end]
+                ));
+            }
+            out.push_str(&command(&inner[..end])?);
+            rest = &inner[end + 1..];
+        } else {
+            out.push('$');
+            rest = after;
+        }
+    }
+    out.push_str(rest);
+    Ok(out)
+}
+
+/// `NAME` or `NAME:-default`
+fn variable(spec: &str, env: &impl Fn(&str) -> Option<String>) -> Result<String, String> {
+    let (name, default) = match spec.split_once(":-") {
+        Some((name, default)) => (name, Some(default)),
+        None => (spec, None),
+    };
+    let name = name.trim();
+    if name.is_empty() {
+        return Err("'${}' names no variable".to_string());
+    }
+    match (env(name).filter(|value| !value.is_empty()), default) {
+        (Some(value), _) => Ok(value),
+        (None, Some(default)) => Ok(default.to_string()),
+        (None, None) => Err(format!("environment variable {name} is not set")),
+    }
+}
+
+/// Where the `(` already passed is closed, nested parentheses skipped
+fn closing_paren(text: &str) -> Option<usize> {
+    let mut depth = 0usize;
+    for (at, c) in text.char_indices() {
+        match c {
+            '(' => depth += 1,
+            ')' if depth == 0 => return Some(at),
+            ')' => depth -= 1,
+            _ => {}
+        }
+    }
+    None
+}
+
+/// The output of `command`, without its trailing newline
+fn command(command: &str) -> Result<String, String> {
+    let output = if cfg!(windows) {
+        std::process::Command::new("cmd")
+            .args(["/C", command])
+            .output()
+    } else {
+        std::process::Command::new("sh")
+            .args(["-c", command])
+            .output()
+    }
+    .map_err(|e| format!("'$({command})': {e}"))?;
+    if !output.status.success() {
+        let stderr = String::from_utf8_lossy(&output.stderr);
+        return Err(format!(
+            "'$({command})' exited with {}: {}",
+            output.status,
+            stderr.trim()
+        ));
+    }
+    let stdout = String::from_utf8(output.stdout)
+        .map_err(|_| format!("'$({command})' printed no UTF-8 text"))?;
+    Ok(stdout.trim_end_matches(['\n', '\r']).to_string())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn env(name: &str) -> Option<String> {
+        match name {
+            "TOKEN" => Some("s3cret".to_string()),
+            "EMPTY" => Some(String::new()),
+            _ => None,
+        }
+    }
+
+    #[test]
+    fn test_expand_variables() {
+        let expand = |text| expand_with(text, &env, false);
+        assert_eq!(expand("Bearer ${TOKEN}").unwrap(), "Bearer s3cret");
+        assert_eq!(
+            expand("${MISSING:-http://localhost}").unwrap(),
+            "http://localhost"
+        );
+        assert_eq!(expand("${EMPTY:-fallback}").unwrap(), "fallback");
+        assert_eq!(expand("$$HOME and $5").unwrap(), "$HOME and $5");
+        assert!(
+            expand("${MISSING}")
+                .unwrap_err()
+                .contains("MISSING is not set")
+        );
+        assert!(expand("${TOKEN").is_err());
+        assert!(
+            expand("$(pass show codanna)")
+                .unwrap_err()
+                .contains(COMMANDS_ENV)
+        );
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn test_expand_commands() {
+        assert_eq!(
+            expand_with("key-$(printf '%s\\n' \"$(echo abc)\")", &env, true).unwrap(),
+            "key-abc"
+        );
+        assert!(expand_with("$(exit 2)", &env, true).is_err());
+    }
+
+    #[test]
+    fn test_raw_values_are_restored() {
+        let mut table: toml::Table = toml::from_str(
+            "[server]\nbind = \"${TOKEN}\"\n[indexing]\nignore_patterns = [\"a\", \"${TOKEN}/b\"]\n",
+        )
+        .unwrap();
+        let mut changed = Vec::new();
+        for (name, value) in table.iter_mut() {
+            let mut key = vec![KeyPart::Key(name.clone())];
+            expand_value(value, &mut key, &env, false, &mut changed).unwrap();
+        }
+        assert_eq!(changed.len(), 2);
+        assert_eq!(
+            DisplayKey(&changed[1].key).to_string(),
+            "indexing.ignore_patterns[1]"
+        );
+
+        let mut value = toml::Value::Table(table);
+        assert_eq!(value["server"]["bind"].as_str(), Some("s3cret"));
+        value["indexing"]["ignore_patterns"][1] = toml::Value::from("edited");
+        restore_raw(&mut value, &changed);
+        assert_eq!(value["server"]["bind"].as_str(), Some("${TOKEN}"));
+        assert_eq!(
+            value["indexing"]["ignore_patterns"][1].as_str(),
+            Some("edited")
+        );
+    }
+}
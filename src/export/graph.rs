@@ -0,0 +1,372 @@
+//! Building the exported graph from the index
+
+use super::UnknownExportValue;
+use crate::indexing::facade::IndexFacade;
+use crate::parsing::Import;
+use crate::symbol::ScopeContext;
+use crate::{FileId, Symbol, SymbolId, SymbolKind};
+use std::collections::{BTreeSet, HashMap};
+use std::str::FromStr;
+
+/// Relationship shown as an edge
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, serde::Serialize)]
+#[serde(rename_all = "lowercase")]
+pub enum EdgeKind {
+    Calls,
+    Implements,
+    Extends,
+    Uses,
+    Imports,
+}
+
+impl EdgeKind {
+    /// Relationships exported when none are selected
+    pub const DEFAULT: &[EdgeKind] = &[
+        EdgeKind::Calls,
+        EdgeKind::Implements,
+        EdgeKind::Extends,
+        EdgeKind::Imports,
+    ];
+
+    /// Edge label
+    pub fn as_str(self) -> &'static str {
+        match self {
+            Self::Calls => "calls",
+            Self::Implements => "implements",
+            Self::Extends => "extends",
+            Self::Uses => "uses",
+            Self::Imports => "imports",
+        }
+    }
+}
+
+impl FromStr for EdgeKind {
+    type Err = UnknownExportValue;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "calls" | "call" => Ok(Self::Calls),
+            "implements" | "impl" => Ok(Self::Implements),
+            "extends" => Ok(Self::Extends),
+            "uses" => Ok(Self::Uses),
+            "imports" | "import" => Ok(Self::Imports),
+            _ => Err(UnknownExportValue {
+                option: "relation",
+                input: s.to_string(),
+                expected: "calls, implements, extends, uses, imports",
+            }),
+        }
+    }
+}
+
+/// Which symbols and relationships to export
+#[derive(Debug, Clone, Default)]
+pub struct GraphFilter {
+    /// Only symbols in files under this path
+    pub path: Option<String>,
+    /// Only symbols of this language
+    pub language: Option<String>,
+    /// Only symbols of these kinds (all if empty)
+    pub kinds: Vec<SymbolKind>,
+    /// Relationships to export ([`EdgeKind::DEFAULT`] if empty)
+    pub relations: Vec<EdgeKind>,
+}
+
+impl GraphFilter {
+    /// Whether a symbol can be a node of the graph
+    pub fn accepts(&self, symbol: &Symbol) -> bool {
+        if let Some(path) = &self.path {
+            let prefix = path.trim_start_matches("./");
+            let file = symbol.file_path.trim_start_matches("./");
+            if !file.starts_with(prefix) {
+                return false;
+            }
+        }
+        if let Some(language) = &self.language {
+            if symbol.language_id.map(|id| id.as_str()) != Some(language.as_str()) {
+                return false;
+            }
+        }
+        self.kinds.is_empty() || self.kinds.contains(&symbol.kind)
+    }
+
+    fn relations(&self) -> &[EdgeKind] {
+        if self.relations.is_empty() {
+            EdgeKind::DEFAULT
+        } else {
+            &self.relations
+        }
+    }
+}
+
+/// A node of the exported graph
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct GraphNode {
+    /// Identifier unique in the graph: `s<symbol_id>` or `f<file_id>`
+    pub id: String,
+    pub label: String,
+    /// Symbol kind, or `File` for an importing file
+    pub kind: String,
+    /// File the node is in
+    pub file: String,
+}
+
+/// A directed edge between two nodes, by index
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub struct Edge {
+    pub from: usize,
+    pub to: usize,
+    pub kind: EdgeKind,
+}
+
+/// Nodes and edges of an export, in a stable order
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct SymbolGraph {
+    pub nodes: Vec<GraphNode>,
+    pub edges: Vec<Edge>,
+}
+
+impl SymbolGraph {
+    /// Build the graph of the indexed symbols the filter accepts
+    pub fn build(facade: &IndexFacade, filter: &GraphFilter) -> Self {
+        let symbols: HashMap<SymbolId, Symbol> = facade
+            .get_all_symbols()
+            .into_iter()
+            .filter(|symbol| filter.accepts(symbol))
+            .map(|symbol| (symbol.id, symbol))
+            .collect();
+        let mut ids: Vec<SymbolId> = symbols.keys().copied().collect();
+        ids.sort_by_key(|id| id.value());
+
+        let mut builder = Builder::default();
+        for &kind in filter.relations() {
+            if kind == EdgeKind::Imports {
+                continue;
+            }
+            for id in &ids {
+                let targets = match kind {
+                    EdgeKind::Calls => facade.get_called_functions(*id),
+                    EdgeKind::Implements => facade.get_implemented_traits(*id),
+                    EdgeKind::Extends => facade.get_extends(*id),
+                    EdgeKind::Uses => facade.get_uses(*id),
+                    EdgeKind::Imports => unreachable!("imports are file edges"),
+                };
+                for target in targets {
+                    if let Some(target) = symbols.get(&target.id) {
+                        builder.symbol_edge(&symbols[id], target, kind);
+                    }
+                }
+            }
+        }
+
+        if filter.relations().contains(&EdgeKind::Imports) {
+            let mut files: Vec<FileId> = symbols.values().map(|symbol| symbol.file_id).collect();
+            files.sort_by_key(|id| id.value());
+            files.dedup();
+            for file_id in files {
+                let Some(path) = facade.get_file_path(file_id) else {
+                    continue;
+                };
+                for import in facade.get_imports_for_file(file_id) {
+                    if let Some(target) =
+                        resolve_import(facade, &import).and_then(|target| symbols.get(&target.id))
+                    {
+                        builder.import_edge(file_id, &path, target);
+                    }
+                }
+            }
+        }
+
+        builder.finish()
+    }
+}
+
+/// The indexed symbol an import names: the only symbol of the import's last
+/// segment, or the one whose module path ends with the import's module
+pub(crate) fn resolve_import(facade: &IndexFacade, import: &Import) -> Option<Symbol> {
+    if import.is_glob {
+        return None;
+    }
+    let segments = path_segments(&import.path);
+    let (name, module) = segments.split_last()?;
+    let mut candidates: Vec<Symbol> = facade
+        .find_symbols_by_name(name, None)
+        .into_iter()
+        .filter(|symbol| symbol.file_id != import.file_id)
+        .collect();
+    if candidates.len() <= 1 {
+        return candidates.pop();
+    }
+    candidates.into_iter().find(|symbol| {
+        let module_path = symbol.module_path.as_deref().unwrap_or_default();
+        let mut symbol_module = path_segments(module_path);
+        if symbol_module.last() == Some(name) {
+            symbol_module.pop();
+        }
+        !module.is_empty() && symbol_module.ends_with(module)
+    })
+}
+
+/// Segments of a module or import path, whatever its separators
+pub(crate) fn path_segments(path: &str) -> Vec<&str> {
+    path.split([':', '.', '/', '\\'])
+        .filter(|segment| !segment.is_empty())
+        .collect()
+}
+
+/// Collects the nodes edges touch, each once
+#[derive(Default)]
+struct Builder {
+    nodes: Vec<GraphNode>,
+    index: HashMap<String, usize>,
+    edges: BTreeSet<Edge>,
+}
+
+impl Builder {
+    fn node(&mut self, node: GraphNode) -> usize {
+        if let Some(&index) = self.index.get(&node.id) {
+            return index;
+        }
+        self.index.insert(node.id.clone(), self.nodes.len());
+        self.nodes.push(node);
+        self.nodes.len() - 1
+    }
+
+    fn symbol_node(&mut self, symbol: &Symbol) -> usize {
+        let label = match &symbol.scope_context {
+            Some(ScopeContext::ClassMember {
+                class_name: Some(class),
+            }) => format!("{class}.{}", symbol.name),
+            _ => symbol.name.to_string(),
+        };
+        self.node(GraphNode {
+            id: format!("s{}", symbol.id.value()),
+            label,
+            kind: format!("{:?}", symbol.kind),
+            file: symbol.file_path.to_string(),
+        })
+    }
+
+    fn symbol_edge(&mut self, from: &Symbol, to: &Symbol, kind: EdgeKind) {
+        if from.id == to.id {
+            return;
+        }
+        let from = self.symbol_node(from);
+        let to = self.symbol_node(to);
+        self.edges.insert(Edge { from, to, kind });
+    }
+
+    fn import_edge(&mut self, file_id: FileId, path: &str, to: &Symbol) {
+        let from = self.node(GraphNode {
+            id: format!("f{}", file_id.value()),
+            label: path.to_string(),
+            kind: "File".to_string(),
+            file: path.to_string(),
+        });
+        let to = self.symbol_node(to);
+        self.edges.insert(Edge {
+            from,
+            to,
+            kind: EdgeKind::Imports,
+        });
+    }
+
+    fn finish(self) -> SymbolGraph {
+        SymbolGraph {
+            nodes: self.nodes,
+            edges: self.edges.into_iter().collect(),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::Settings;
+
+    fn symbol(id: u32, name: &str, kind: SymbolKind, file_path: &str) -> Symbol {
+        let mut symbol = Symbol::new(
+            SymbolId::new(id).unwrap(),
+            name,
+            kind,
+            FileId::new(1).unwrap(),
+            crate::Range::new(id, 0, id, 10),
+        );
+        symbol.file_path = file_path.into();
+        symbol
+    }
+
+    #[test]
+    fn test_filter_by_path_and_kind() {
+        let parser = symbol(1, "parse", SymbolKind::Function, "src/parsing/mod.rs");
+        let config = symbol(2, "Settings", SymbolKind::Struct, "src/config/mod.rs");
+
+        let filter = GraphFilter {
+            path: Some("./src/parsing".to_string()),
+            ..Default::default()
+        };
+        assert!(filter.accepts(&parser));
+        assert!(!filter.accepts(&config));
+
+        let filter = GraphFilter {
+            kinds: vec![SymbolKind::Struct],
+            ..Default::default()
+        };
+        assert!(!filter.accepts(&parser));
+        assert!(filter.accepts(&config));
+    }
+
+    #[test]
+    fn test_relation_names() {
+        assert_eq!("calls".parse::<EdgeKind>(), Ok(EdgeKind::Calls));
+        assert_eq!("IMPORTS".parse::<EdgeKind>(), Ok(EdgeKind::Imports));
+        assert!("overrides".parse::<EdgeKind>().is_err());
+        assert_eq!(
+            path_segments("crate::io::Envelope"),
+            ["crate", "io", "Envelope"]
+        );
+        assert_eq!(path_segments("./utils/format"), ["utils", "format"]);
+    }
+
+    #[test]
+    fn test_build_keeps_edges_between_accepted_symbols() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("shapes.py");
+        std::fs::write(
+            &source,
+            "class Shape:\n    pass\n\n\nclass Square(Shape):\n    pass\n\n\ndef area():\n    return make()\n\n\ndef make():\n    return Square()\n",
+        )
+        .unwrap();
+
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+
+        let graph = SymbolGraph::build(&facade, &GraphFilter::default());
+        let edges: Vec<(&str, &str, EdgeKind)> = graph
+            .edges
+            .iter()
+            .map(|edge| {
+                (
+                    graph.nodes[edge.from].label.as_str(),
+                    graph.nodes[edge.to].label.as_str(),
+                    edge.kind,
+                )
+            })
+            .collect();
+        assert!(edges.contains(&("area", "make", EdgeKind::Calls)));
+        assert!(edges.contains(&("Square", "Shape", EdgeKind::Extends)));
+
+        let classes = GraphFilter {
+            kinds: vec![SymbolKind::Class],
+            ..Default::default()
+        };
+        let graph = SymbolGraph::build(&facade, &classes);
+        assert_eq!(graph.nodes.len(), 2, "functions are filtered out");
+        assert_eq!(graph.edges.len(), 1);
+    }
+}
@@ -0,0 +1,602 @@
+//! LSIF dumps, written and read
+//!
+//! `codanna export lsif` writes the definitions, references and hovers of
+//! the index as an [LSIF](https://microsoft.github.io/language-server-protocol/specifications/lsif/0.6.0/specification/)
+//! dump: JSON Lines, a vertex or an edge each, positions in UTF-16 units.
+//!
+//! `codanna import lsif` reads a dump written by a language's own indexer
+//! and stores the references it resolves that the index lacks: from the
+//! symbol around the reference to the symbol defined where it points, as a
+//! call of a function or method, a use of a type, or a reference to a
+//! field, constant or variable. References the index cannot place on its
+//! symbols are counted and left out. Re-indexing a file drops what was
+//! imported for it, so import again after `codanna index`.
+
+use super::GraphFilter;
+use super::scip::{Sources, name_range, relative_path, root_uri};
+use crate::indexing::facade::IndexFacade;
+use crate::relationship::{RelationKind, Relationship, RelationshipEdge, RelationshipMetadata};
+use crate::symbol::ScopeContext;
+use crate::{Symbol, SymbolId, SymbolKind};
+use serde_json::{Value, json};
+use std::collections::{BTreeMap, HashMap, HashSet};
+use std::path::{Path, PathBuf};
+
+/// A written LSIF dump
+#[derive(Debug, Clone, Default)]
+pub struct LsifDump {
+    /// JSON Lines, a vertex or edge each
+    pub text: String,
+    pub documents: usize,
+    pub symbols: usize,
+}
+
+impl LsifDump {
+    /// Build the dump of the symbols the filter accepts. Paths are made
+    /// relative to `project_root`, where the sources are read from to
+    /// place symbol names.
+    pub fn build(facade: &IndexFacade, filter: &GraphFilter, project_root: &Path) -> Self {
+        let symbols: HashMap<SymbolId, Symbol> = facade
+            .get_all_symbols()
+            .into_iter()
+            .filter(|symbol| filter.accepts(symbol))
+            .map(|symbol| (symbol.id, symbol))
+            .collect();
+        let mut ids: Vec<SymbolId> = symbols.keys().copied().collect();
+        ids.sort_by_key(|id| id.value());
+
+        let mut out = Exporter {
+            writer: Writer::default(),
+            sources: Sources::new(project_root),
+            documents: BTreeMap::new(),
+            root: project_root,
+            root_uri: root_uri(project_root),
+        };
+        out.writer.vertex(
+            "metaData",
+            json!({
+                "version": "0.5.0",
+                "projectRoot": out.root_uri,
+                "positionEncoding": "utf-16",
+                "toolInfo": { "name": "codanna", "version": env!("CARGO_PKG_VERSION") },
+            }),
+        );
+
+        for id in &ids {
+            let symbol = &symbols[id];
+            let result_set = out.writer.vertex("resultSet", json!({}));
+            let (definition, document) = out.range(
+                symbol,
+                symbol.range.start_line,
+                symbol.range.start_column,
+                &symbol.name,
+            );
+            out.writer
+                .edge("next", json!({ "outV": definition, "inV": result_set }));
+            let definition_result = out.writer.vertex("definitionResult", json!({}));
+            out.writer.edge(
+                "textDocument/definition",
+                json!({ "outV": result_set, "inV": definition_result }),
+            );
+            out.writer.edge(
+                "item",
+                json!({ "outV": definition_result, "inVs": [definition], "document": document }),
+            );
+
+            let mut contents = Vec::new();
+            if let Some(signature) = &symbol.signature {
+                let language = symbol.language_id.map_or("", |id| id.as_str());
+                contents.push(json!({ "language": language, "value": signature }));
+            }
+            if let Some(doc) = &symbol.doc_comment {
+                contents.push(json!(&**doc));
+            }
+            if !contents.is_empty() {
+                let hover = out
+                    .writer
+                    .vertex("hoverResult", json!({ "result": { "contents": contents } }));
+                out.writer.edge(
+                    "textDocument/hover",
+                    json!({ "outV": result_set, "inV": hover }),
+                );
+            }
+
+            // References from the other exported symbols, by document
+            let mut references: BTreeMap<u64, Vec<u64>> = BTreeMap::new();
+            let callers = facade
+                .get_calling_functions_with_metadata(*id)
+                .into_iter()
+                .chain(facade.get_referencing_symbols_with_metadata(*id));
+            for (from, metadata) in callers {
+                let Some(line) = metadata.as_ref().and_then(|meta| meta.line) else {
+                    continue;
+                };
+                if !symbols.contains_key(&from.id) {
+                    continue;
+                }
+                let column = metadata.and_then(|meta| meta.column).unwrap_or(0);
+                let (range, document) = out.range(&from, line, column, &symbol.name);
+                out.writer
+                    .edge("next", json!({ "outV": range, "inV": result_set }));
+                references.entry(document).or_default().push(range);
+            }
+            if !references.is_empty() {
+                let reference_result = out.writer.vertex("referenceResult", json!({}));
+                out.writer.edge(
+                    "textDocument/references",
+                    json!({ "outV": result_set, "inV": reference_result }),
+                );
+                out.writer.edge(
+                    "item",
+                    json!({
+                        "outV": reference_result,
+                        "inVs": [definition],
+                        "document": document,
+                        "property": "definitions",
+                    }),
+                );
+                for (document, ranges) in references {
+                    out.writer.edge(
+                        "item",
+                        json!({
+                            "outV": reference_result,
+                            "inVs": ranges,
+                            "document": document,
+                            "property": "references",
+                        }),
+                    );
+                }
+            }
+        }
+
+        let documents = std::mem::take(&mut out.documents);
+        for (document, ranges) in documents.values() {
+            out.writer
+                .edge("contains", json!({ "outV": document, "inVs": ranges }));
+        }
+
+        Self {
+            text: out.writer.lines.join("\n") + "\n",
+            documents: documents.len(),
+            symbols: ids.len(),
+        }
+    }
+}
+
+/// State of an export: the documents seen so far and their ranges
+struct Exporter<'a> {
+    writer: Writer,
+    sources: Sources<'a>,
+    /// Relative path to the document vertex and its ranges
+    documents: BTreeMap<String, (u64, Vec<u64>)>,
+    root: &'a Path,
+    root_uri: String,
+}
+
+impl Exporter<'_> {
+    /// A range vertex for `name` at `line` in the file of `symbol`, and the
+    /// document vertex it belongs to
+    fn range(&mut self, symbol: &Symbol, line: u32, column: u16, name: &str) -> (u64, u64) {
+        let path = relative_path(&symbol.file_path, self.root);
+        if !self.documents.contains_key(&path) {
+            let document = self.writer.vertex(
+                "document",
+                json!({
+                    "uri": format!("{}/{path}", self.root_uri.trim_end_matches('/')),
+                    "languageId": symbol.language_id.map_or("", |id| id.as_str()),
+                }),
+            );
+            self.documents.insert(path.clone(), (document, Vec::new()));
+        }
+
+        let lines = self.sources.lines(&symbol.file_path);
+        let bytes = name_range(lines, line, column, name);
+        let text = lines.get(line as usize).map_or("", String::as_str);
+        let utf16 = |byte: u32| {
+            text.get(..byte as usize)
+                .map_or(byte as usize, |prefix| prefix.encode_utf16().count())
+        };
+        let range = self.writer.vertex(
+            "range",
+            json!({
+                "start": { "line": line, "character": utf16(bytes[1]) },
+                "end": { "line": line, "character": utf16(bytes[2]) },
+            }),
+        );
+
+        let (document, ranges) = self.documents.get_mut(&path).expect("document added above");
+        ranges.push(range);
+        (range, *document)
+    }
+}
+
+/// Numbers the vertices and edges of a dump as they are written
+#[derive(Default)]
+struct Writer {
+    lines: Vec<String>,
+    last_id: u64,
+}
+
+impl Writer {
+    fn element(&mut self, element: &str, label: &str, fields: Value) -> u64 {
+        self.last_id += 1;
+        let mut record = json!({ "id": self.last_id, "type": element, "label": label });
+        if let (Value::Object(record), Value::Object(fields)) = (&mut record, fields) {
+            record.extend(fields);
+        }
+        self.lines.push(record.to_string());
+        self.last_id
+    }
+
+    fn vertex(&mut self, label: &str, fields: Value) -> u64 {
+        self.element("vertex", label, fields)
+    }
+
+    fn edge(&mut self, label: &str, fields: Value) {
+        self.element("edge", label, fields);
+    }
+}
+
+/// What an LSIF import found and stored
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct LsifImport {
+    /// References the dump resolves to a definition
+    pub references: usize,
+    /// Of those, the ones stored as new relationships
+    pub added: usize,
+    /// Already in the index
+    pub known: usize,
+    /// Not placed on indexed symbols on both ends
+    pub unmatched: usize,
+}
+
+/// Store the references of the LSIF dump `text` the index lacks. Paths in
+/// the dump are taken relative to its `projectRoot`, else to `project_root`.
+pub fn import(
+    facade: &mut IndexFacade,
+    text: &str,
+    project_root: &Path,
+) -> Result<LsifImport, String> {
+    let dump = Dump::parse(text)?;
+    let dump_root = dump.project_root.as_deref().map(uri_path);
+    let dump_root = dump_root.as_deref().unwrap_or(project_root);
+
+    // Symbols by file, the locals left out: they are no relationship's end
+    let mut files: HashMap<String, Vec<Symbol>> = HashMap::new();
+    for symbol in facade.get_all_symbols() {
+        if matches!(
+            symbol.scope_context,
+            Some(ScopeContext::Local { .. } | ScopeContext::Parameter)
+        ) {
+            continue;
+        }
+        files
+            .entry(relative_path(&symbol.file_path, project_root))
+            .or_default()
+            .push(symbol);
+    }
+    let enclosing = |range: &str| {
+        let (line, _) = *dump.ranges.get(range)?;
+        let document = dump.documents.get(dump.range_documents.get(range)?)?;
+        let path = uri_path(document);
+        let path = relative_path(&path.to_string_lossy(), dump_root);
+        files
+            .get(&path)?
+            .iter()
+            .filter(|symbol| symbol.range.start_line <= line && line <= symbol.range.end_line)
+            .min_by_key(|symbol| symbol.range.end_line - symbol.range.start_line)
+    };
+
+    let mut result = LsifImport::default();
+    let mut found: HashSet<(SymbolId, SymbolId, RelationKind)> = HashSet::new();
+    let mut existing: HashMap<(SymbolId, RelationKind), HashSet<SymbolId>> = HashMap::new();
+    let mut edges = Vec::new();
+
+    let mut ranges: Vec<&String> = dump.ranges.keys().collect();
+    ranges.sort();
+    for range in ranges {
+        let Some(definitions) = dump.definition_ranges(range) else {
+            continue;
+        };
+        if definitions.contains(range) {
+            continue;
+        }
+        for definition in definitions {
+            result.references += 1;
+            let (Some(from), Some(to)) = (enclosing(range), enclosing(definition)) else {
+                result.unmatched += 1;
+                continue;
+            };
+            let Some(kind) = relation_kind(to.kind) else {
+                result.unmatched += 1;
+                continue;
+            };
+            if from.id == to.id || !found.insert((from.id, to.id, kind)) {
+                continue;
+            }
+            let known = existing.entry((from.id, kind)).or_insert_with(|| {
+                facade
+                    .document_index()
+                    .get_relationships_from(from.id, kind)
+                    .unwrap_or_default()
+                    .into_iter()
+                    .map(|(_, to, _)| to)
+                    .collect()
+            });
+            if known.contains(&to.id) {
+                result.known += 1;
+                continue;
+            }
+            let (line, character) = dump.ranges[range];
+            let metadata = RelationshipMetadata::new()
+                .at_position(line, u16::try_from(character).unwrap_or(u16::MAX));
+            edges.push(RelationshipEdge::new(
+                from.id,
+                to.id,
+                Relationship::new(kind).with_metadata(metadata),
+            ));
+        }
+    }
+
+    result.added = edges.len();
+    if !edges.is_empty() {
+        facade
+            .add_relationships(&edges)
+            .map_err(|e| format!("failed to store relationships: {e}"))?;
+    }
+    Ok(result)
+}
+
+/// The relationship a reference to a symbol of `kind` stands for
+fn relation_kind(kind: SymbolKind) -> Option<RelationKind> {
+    match kind {
+        SymbolKind::Function | SymbolKind::Method | SymbolKind::Macro => Some(RelationKind::Calls),
+        SymbolKind::Struct
+        | SymbolKind::Enum
+        | SymbolKind::Trait
+        | SymbolKind::Interface
+        | SymbolKind::Class
+        | SymbolKind::TypeAlias => Some(RelationKind::Uses),
+        SymbolKind::Field | SymbolKind::Constant | SymbolKind::Variable | SymbolKind::Given => {
+            Some(RelationKind::References)
+        }
+        SymbolKind::Module | SymbolKind::Parameter => None,
+    }
+}
+
+/// The path of a `file://` URI
+fn uri_path(uri: &str) -> PathBuf {
+    let path = uri.strip_prefix("file://").unwrap_or(uri);
+    let bytes = path.as_bytes();
+    let mut decoded = Vec::with_capacity(bytes.len());
+    let mut i = 0;
+    while i < bytes.len() {
+        let escaped = (bytes[i] == b'%')
+            .then(|| path.get(i + 1..i + 3))
+            .flatten()
+            .and_then(|hex| u8::from_str_radix(hex, 16).ok());
+        match escaped {
+            Some(byte) => {
+                decoded.push(byte);
+                i += 3;
+            }
+            None => {
+                decoded.push(bytes[i]);
+                i += 1;
+            }
+        }
+    }
+    PathBuf::from(String::from_utf8_lossy(&decoded).into_owned())
+}
+
+/// The parts of an LSIF dump an import follows, by element id
+#[derive(Debug, Default)]
+struct Dump {
+    project_root: Option<String>,
+    /// Document URIs
+    documents: HashMap<String, String>,
+    /// Start line and character of the ranges
+    ranges: HashMap<String, (u32, u32)>,
+    /// Document of each range
+    range_documents: HashMap<String, String>,
+    /// `next` edges, range or result set to result set
+    next: HashMap<String, String>,
+    /// `textDocument/definition` edges, to the definition result
+    definitions: HashMap<String, String>,
+    /// `item` edges, result to ranges
+    items: HashMap<String, Vec<String>>,
+}
+
+impl Dump {
+    /// Read a dump of JSON Lines, or of one JSON array
+    fn parse(text: &str) -> Result<Self, String> {
+        let records: Vec<Value> = if text.trim_start().starts_with('[') {
+            serde_json::from_str(text).map_err(|e| format!("invalid LSIF dump: {e}"))?
+        } else {
+            text.lines()
+                .enumerate()
+                .filter(|(_, line)| !line.trim().is_empty())
+                .map(|(number, line)| {
+                    serde_json::from_str(line)
+                        .map_err(|e| format!("invalid LSIF dump, line {}: {e}", number + 1))
+                })
+                .collect::<Result<_, _>>()?
+        };
+
+        let mut dump = Self::default();
+        for record in &records {
+            let label = record["label"].as_str().unwrap_or_default();
+            let Some(id) = element_id(&record["id"]) else {
+                continue;
+            };
+            match (record["type"].as_str().unwrap_or_default(), label) {
+                ("vertex", "metaData") => {
+                    dump.project_root = record["projectRoot"].as_str().map(str::to_string);
+                }
+                ("vertex", "document") => {
+                    if let Some(uri) = record["uri"].as_str() {
+                        dump.documents.insert(id, uri.to_string());
+                    }
+                }
+                ("vertex", "range") => {
+                    let start = &record["start"];
+                    if let (Some(line), Some(character)) =
+                        (start["line"].as_u64(), start["character"].as_u64())
+                    {
+                        dump.ranges.insert(id, (line as u32, character as u32));
+                    }
+                }
+                ("edge", "contains") => {
+                    if let Some(document) = element_id(&record["outV"]) {
+                        if dump.documents.contains_key(&document) {
+                            for range in in_vertices(record) {
+                                dump.range_documents.insert(range, document.clone());
+                            }
+                        }
+                    }
+                }
+                ("edge", "next") | ("edge", "textDocument/definition") => {
+                    let edges = if label == "next" {
+                        &mut dump.next
+                    } else {
+                        &mut dump.definitions
+                    };
+                    if let Some(from) = element_id(&record["outV"]) {
+                        edges.extend(in_vertices(record).into_iter().map(|to| (from.clone(), to)));
+                    }
+                }
+                ("edge", "item") => {
+                    let Some(from) = element_id(&record["outV"]) else {
+                        continue;
+                    };
+                    let ranges = in_vertices(record);
+                    if let Some(document) = element_id(&record["document"]) {
+                        for range in &ranges {
+                            dump.range_documents
+                                .entry(range.clone())
+                                .or_insert_with(|| document.clone());
+                        }
+                    }
+                    dump.items.entry(from).or_default().extend(ranges);
+                }
+                _ => {}
+            }
+        }
+        Ok(dump)
+    }
+
+    /// The definition ranges `range` resolves to, through its result sets
+    fn definition_ranges(&self, range: &str) -> Option<&Vec<String>> {
+        let mut element = range;
+        // Chains are short; the bound guards against a cyclic dump
+        for _ in 0..16 {
+            if let Some(result) = self.definitions.get(element) {
+                return self.items.get(result);
+            }
+            element = self.next.get(element)?;
+        }
+        None
+    }
+}
+
+/// An element id, a number or a string in LSIF
+fn element_id(value: &Value) -> Option<String> {
+    match value {
+        Value::Number(number) => Some(number.to_string()),
+        Value::String(id) => Some(id.clone()),
+        _ => None,
+    }
+}
+
+/// The `inV` or `inVs` of an edge
+fn in_vertices(edge: &Value) -> Vec<String> {
+    match edge.get("inVs").and_then(Value::as_array) {
+        Some(ids) => ids.iter().filter_map(element_id).collect(),
+        None => element_id(&edge["inV"]).into_iter().collect(),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::Settings;
+    use std::sync::Arc;
+
+    fn python_facade(dir: &Path, source: &str) -> IndexFacade {
+        let settings = Settings {
+            index_path: dir.join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let path = dir.join("shapes.py");
+        std::fs::write(&path, source).unwrap();
+        let mut facade = IndexFacade::new(Arc::new(settings)).unwrap();
+        facade.index_file(&path).unwrap();
+        facade
+    }
+
+    #[test]
+    fn test_export_links_references_to_definitions() {
+        let dir = tempfile::tempdir().unwrap();
+        let facade = python_facade(
+            dir.path(),
+            "def make():\n    pass\n\n\ndef area():\n    return make()\n",
+        );
+
+        let dump = LsifDump::build(&facade, &GraphFilter::default(), dir.path());
+        assert_eq!(dump.documents, 1);
+        assert_eq!(dump.symbols, 2);
+
+        let parsed = Dump::parse(&dump.text).unwrap();
+        assert_eq!(parsed.documents.len(), 1);
+        let call = parsed
+            .ranges
+            .iter()
+            .find(|(_, start)| **start == (5, 11))
+            .map(|(id, _)| id.clone())
+            .expect("range of the call of make");
+        let definition = &parsed.definition_ranges(&call).unwrap()[0];
+        assert_eq!(parsed.ranges[definition], (0, 4));
+    }
+
+    #[test]
+    fn test_import_adds_missing_references() {
+        let dir = tempfile::tempdir().unwrap();
+        let mut facade = python_facade(
+            dir.path(),
+            "def make():\n    pass\n\n\ndef area():\n    return 1\n",
+        );
+        let uri = format!("{}/shapes.py", root_uri(dir.path()));
+        let dump = [
+            json!({"id": 1, "type": "vertex", "label": "document", "uri": uri}),
+            json!({"id": 2, "type": "vertex", "label": "range",
+                   "start": {"line": 0, "character": 4}, "end": {"line": 0, "character": 8}}),
+            json!({"id": 3, "type": "vertex", "label": "range",
+                   "start": {"line": 5, "character": 11}, "end": {"line": 5, "character": 15}}),
+            json!({"id": 4, "type": "vertex", "label": "resultSet"}),
+            json!({"id": 5, "type": "edge", "label": "next", "outV": 2, "inV": 4}),
+            json!({"id": 6, "type": "edge", "label": "next", "outV": 3, "inV": 4}),
+            json!({"id": 7, "type": "vertex", "label": "definitionResult"}),
+            json!({"id": 8, "type": "edge", "label": "textDocument/definition", "outV": 4, "inV": 7}),
+            json!({"id": 9, "type": "edge", "label": "item", "outV": 7, "inVs": [2], "document": 1}),
+            json!({"id": 10, "type": "edge", "label": "contains", "outV": 1, "inVs": [2, 3]}),
+        ]
+        .map(|record| record.to_string())
+        .join("\n");
+
+        let result = import(&mut facade, &dump, dir.path()).unwrap();
+        assert_eq!(result.references, 1);
+        assert_eq!(result.added, 1);
+
+        let area = facade.find_symbols_by_name("area", None).pop().unwrap();
+        let called: Vec<String> = facade
+            .get_called_functions(area.id)
+            .iter()
+            .map(|symbol| symbol.name.to_string())
+            .collect();
+        assert_eq!(called, vec!["make"]);
+
+        let again = import(&mut facade, &dump, dir.path()).unwrap();
+        assert_eq!((again.added, again.known), (0, 1));
+    }
+}
@@ -0,0 +1,114 @@
+//! Export of the symbol relationship graph
+//!
+//! Besides the graph described below, the index is written for other
+//! tools:
+//!
+//! - `codanna export scip`, a SCIP index for code navigation ([`scip`]);
+//! - `codanna export lsif`, an LSIF dump, which `codanna import lsif` reads
+//!   back from other indexers ([`lsif`]);
+//! - `codanna export tags`, a ctags or etags file for editors ([`tags`]);
+//! - `codanna export cypher`, a Cypher script for Neo4j ([`cypher`]);
+//! - `codanna export sqlite`, a SQLite database for ad-hoc SQL ([`sqlite`]),
+//!   when built with the `sqlite-export` feature.
+//!
+//! `codanna export graph` renders the indexed relationships as a diagram
+//! source: Graphviz DOT, Mermaid flowcharts or GraphML. The graph is
+//! built once ([`SymbolGraph`]) and handed to a renderer, so every format
+//! shows the same nodes and edges.
+//!
+//! Nodes are symbols with at least one exported edge, grouped by file, plus
+//! a node per importing file when imports are exported. Imports point from
+//! the file to the symbol they resolve to; imports of code outside the index
+//! are left out.
+
+pub mod cypher;
+pub mod graph;
+pub mod lsif;
+pub mod render;
+pub mod scip;
+#[cfg(feature = "sqlite-export")]
+pub mod sqlite;
+pub mod tags;
+
+pub use cypher::CypherScript;
+pub use graph::{Edge, EdgeKind, GraphFilter, GraphNode, SymbolGraph};
+pub use lsif::{LsifDump, LsifImport};
+pub use scip::ScipIndex;
+pub use tags::{TagsFile, TagsFormat};
+
+use std::fmt;
+use std::str::FromStr;
+
+/// Diagram format of an exported graph
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum GraphFormat {
+    Dot,
+    Mermaid,
+    GraphMl,
+}
+
+impl GraphFormat {
+    /// Render a graph in this format
+    pub fn render(self, graph: &SymbolGraph) -> String {
+        match self {
+            Self::Dot => render::dot(graph),
+            Self::Mermaid => render::mermaid(graph),
+            Self::GraphMl => render::graphml(graph),
+        }
+    }
+}
+
+/// Unknown value passed to an export option
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct UnknownExportValue {
+    pub option: &'static str,
+    pub input: String,
+    pub expected: &'static str,
+}
+
+impl fmt::Display for UnknownExportValue {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(
+            f,
+            "unknown {} '{}'; expected one of: {}",
+            self.option, self.input, self.expected
+        )
+    }
+}
+
+impl std::error::Error for UnknownExportValue {}
+
+impl FromStr for GraphFormat {
+    type Err = UnknownExportValue;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "dot" | "graphviz" => Ok(Self::Dot),
+            "mermaid" | "mmd" => Ok(Self::Mermaid),
+            "graphml" => Ok(Self::GraphMl),
+            _ => Err(UnknownExportValue {
+                option: "format",
+                input: s.to_string(),
+                expected: "dot, mermaid, graphml",
+            }),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_format_names() {
+        assert_eq!("dot".parse::<GraphFormat>(), Ok(GraphFormat::Dot));
+        assert_eq!("Mermaid".parse::<GraphFormat>(), Ok(GraphFormat::Mermaid));
+        assert_eq!("graphml".parse::<GraphFormat>(), Ok(GraphFormat::GraphMl));
+
+        let err = "svg".parse::<GraphFormat>().unwrap_err();
+        assert_eq!(
+            err.to_string(),
+            "unknown format 'svg'; expected one of: dot, mermaid, graphml"
+        );
+    }
+}
@@ -0,0 +1,184 @@
+//! Rendering an exported graph as DOT, Mermaid or GraphML
+
+use super::SymbolGraph;
+use std::collections::BTreeMap;
+use std::fmt::Write;
+
+/// Node indexes grouped by file, files in name order
+fn by_file(graph: &SymbolGraph) -> BTreeMap<&str, Vec<usize>> {
+    let mut files: BTreeMap<&str, Vec<usize>> = BTreeMap::new();
+    for (index, node) in graph.nodes.iter().enumerate() {
+        files.entry(node.file.as_str()).or_default().push(index);
+    }
+    files
+}
+
+fn dot_escape(text: &str) -> String {
+    text.replace('\\', "\\\\").replace('"', "\\\"")
+}
+
+/// Graphviz DOT, a cluster per file
+pub fn dot(graph: &SymbolGraph) -> String {
+    let mut out = String::from("digraph codanna {\n");
+    out.push_str("  rankdir=LR;\n");
+    out.push_str("  node [shape=box];\n");
+    for (cluster, (file, nodes)) in by_file(graph).into_iter().enumerate() {
+        let _ = writeln!(out, "  subgraph \"cluster_{cluster}\" {{");
+        let _ = writeln!(out, "    label=\"{}\";", dot_escape(file));
+        for index in nodes {
+            let node = &graph.nodes[index];
+            let _ = writeln!(
+                out,
+                "    \"{}\" [label=\"{}\\n({})\"];",
+                node.id,
+                dot_escape(&node.label),
+                node.kind
+            );
+        }
+        out.push_str("  }\n");
+    }
+    for edge in &graph.edges {
+        let _ = writeln!(
+            out,
+            "  \"{}\" -> \"{}\" [label=\"{}\"];",
+            graph.nodes[edge.from].id,
+            graph.nodes[edge.to].id,
+            edge.kind.as_str()
+        );
+    }
+    out.push_str("}\n");
+    out
+}
+
+fn mermaid_escape(text: &str) -> String {
+    text.replace('"', "#quot;")
+}
+
+/// Mermaid flowchart, a subgraph per file
+pub fn mermaid(graph: &SymbolGraph) -> String {
+    let mut out = String::from("flowchart LR\n");
+    for (cluster, (file, nodes)) in by_file(graph).into_iter().enumerate() {
+        let _ = writeln!(out, "  subgraph c{cluster}[\"{}\"]", mermaid_escape(file));
+        for index in nodes {
+            let node = &graph.nodes[index];
+            let _ = writeln!(out, "    {}[\"{}\"]", node.id, mermaid_escape(&node.label));
+        }
+        out.push_str("  end\n");
+    }
+    for edge in &graph.edges {
+        let _ = writeln!(
+            out,
+            "  {} -->|{}| {}",
+            graph.nodes[edge.from].id,
+            edge.kind.as_str(),
+            graph.nodes[edge.to].id
+        );
+    }
+    out
+}
+
+fn xml_escape(text: &str) -> String {
+    text.replace('&', "&amp;")
+        .replace('<', "&lt;")
+        .replace('>', "&gt;")
+        .replace('"', "&quot;")
+}
+
+/// GraphML, with the label, kind and file of nodes and the relation of edges
+pub fn graphml(graph: &SymbolGraph) -> String {
+    let mut out = String::from("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n");
+    out.push_str("<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">\n");
+    out.push_str("  <key id=\"label\" for=\"node\" attr.name=\"label\" attr.type=\"string\"/>\n");
+    out.push_str("  <key id=\"kind\" for=\"node\" attr.name=\"kind\" attr.type=\"string\"/>\n");
+    out.push_str("  <key id=\"file\" for=\"node\" attr.name=\"file\" attr.type=\"string\"/>\n");
+    out.push_str(
+        "  <key id=\"relation\" for=\"edge\" attr.name=\"relation\" attr.type=\"string\"/>\n",
+    );
+    out.push_str("  <graph id=\"codanna\" edgedefault=\"directed\">\n");
+    for node in &graph.nodes {
+        let _ = writeln!(out, "    <node id=\"{}\">", node.id);
+        let _ = writeln!(
+            out,
+            "      <data key=\"label\">{}</data>",
+            xml_escape(&node.label)
+        );
+        let _ = writeln!(out, "      <data key=\"kind\">{}</data>", node.kind);
+        let _ = writeln!(
+            out,
+            "      <data key=\"file\">{}</data>",
+            xml_escape(&node.file)
+        );
+        out.push_str("    </node>\n");
+    }
+    for (index, edge) in graph.edges.iter().enumerate() {
+        let _ = writeln!(
+            out,
+            "    <edge id=\"e{index}\" source=\"{}\" target=\"{}\">",
+            graph.nodes[edge.from].id, graph.nodes[edge.to].id
+        );
+        let _ = writeln!(
+            out,
+            "      <data key=\"relation\">{}</data>",
+            edge.kind.as_str()
+        );
+        out.push_str("    </edge>\n");
+    }
+    out.push_str("  </graph>\n");
+    out.push_str("</graphml>\n");
+    out
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::export::{Edge, EdgeKind, GraphNode};
+
+    fn graph() -> SymbolGraph {
+        let node = |id: &str, label: &str, kind: &str, file: &str| GraphNode {
+            id: id.to_string(),
+            label: label.to_string(),
+            kind: kind.to_string(),
+            file: file.to_string(),
+        };
+        SymbolGraph {
+            nodes: vec![
+                node("s1", "main", "Function", "src/main.rs"),
+                node("s2", "Parser<\"T\">", "Struct", "src/parser.rs"),
+            ],
+            edges: vec![Edge {
+                from: 0,
+                to: 1,
+                kind: EdgeKind::Calls,
+            }],
+        }
+    }
+
+    #[test]
+    fn test_dot() {
+        let out = dot(&graph());
+        assert!(out.starts_with("digraph codanna {\n"));
+        assert!(out.contains("    label=\"src/main.rs\";\n"));
+        assert!(out.contains("    \"s2\" [label=\"Parser<\\\"T\\\">\\n(Struct)\"];\n"));
+        assert!(out.contains("  \"s1\" -> \"s2\" [label=\"calls\"];\n"));
+        assert!(out.ends_with("}\n"));
+    }
+
+    #[test]
+    fn test_mermaid() {
+        let out = mermaid(&graph());
+        assert!(out.starts_with("flowchart LR\n"));
+        assert!(out.contains("  subgraph c1[\"src/parser.rs\"]\n"));
+        assert!(out.contains("    s2[\"Parser<#quot;T#quot;>\"]\n"));
+        assert!(out.contains("  s1 -->|calls| s2\n"));
+    }
+
+    #[test]
+    fn test_graphml() {
+        let out = graphml(&graph());
+        assert!(out.contains("<graph id=\"codanna\" edgedefault=\"directed\">"));
+        assert!(out.contains("<data key=\"label\">Parser&lt;&quot;T&quot;&gt;</data>"));
+        assert!(out.contains("<edge id=\"e0\" source=\"s1\" target=\"s2\">"));
+        assert!(out.contains("<data key=\"relation\">calls</data>"));
+        assert!(out.trim_end().ends_with("</graphml>"));
+    }
+}
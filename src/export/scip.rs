@@ -0,0 +1,425 @@
+//! SCIP index of the symbols and their references
+//!
+//! `codanna export scip` writes the index in the format of
+//! [SCIP](https://github.com/sourcegraph/scip), which Sourcegraph and other
+//! code navigation tools read, so they need not index the code again.
+//!
+//! Each indexed file is a document holding a definition occurrence per
+//! symbol, a reference occurrence per call or field access resolved to an
+//! exported symbol, and the symbol's kind, signature, documentation and the
+//! traits or classes it implements or extends. Symbols are named
+//! `codanna . . . <file>/<Type>#<member>`, and locals `local <id>`.
+//!
+//! The protobuf messages of `scip.proto` are encoded here by hand; they are
+//! few and only ever written.
+
+use super::GraphFilter;
+use crate::indexing::facade::IndexFacade;
+use crate::symbol::ScopeContext;
+use crate::{Symbol, SymbolId, SymbolKind};
+use std::collections::{BTreeMap, HashMap};
+use std::path::Path;
+
+/// `SymbolRole.Definition`
+const ROLE_DEFINITION: u64 = 1;
+/// `TextEncoding.UTF8` and `PositionEncoding.UTF8CodeUnitOffsetFromLineStart`:
+/// columns are byte offsets
+const UTF8: u64 = 1;
+
+/// An encoded SCIP index and what went into it
+#[derive(Debug, Clone, Default)]
+pub struct ScipIndex {
+    /// The `Index` message, protobuf-encoded
+    pub bytes: Vec<u8>,
+    pub documents: usize,
+    pub symbols: usize,
+}
+
+impl ScipIndex {
+    /// Build the index of the symbols the filter accepts. Paths are made
+    /// relative to `project_root`, where the sources are read from to
+    /// place symbol names.
+    pub fn build(facade: &IndexFacade, filter: &GraphFilter, project_root: &Path) -> Self {
+        let symbols: HashMap<SymbolId, Symbol> = facade
+            .get_all_symbols()
+            .into_iter()
+            .filter(|symbol| filter.accepts(symbol))
+            .map(|symbol| (symbol.id, symbol))
+            .collect();
+        let mut ids: Vec<SymbolId> = symbols.keys().copied().collect();
+        ids.sort_by_key(|id| id.value());
+
+        let mut sources = Sources::new(project_root);
+        let mut documents: BTreeMap<String, Document> = BTreeMap::new();
+        for id in &ids {
+            let symbol = &symbols[id];
+            let scip_symbol = scip_symbol(symbol, project_root);
+            let lines = sources.lines(&symbol.file_path);
+            let document = documents
+                .entry(relative_path(&symbol.file_path, project_root))
+                .or_insert_with(|| Document::new(symbol));
+
+            let range = name_range(
+                lines,
+                symbol.range.start_line,
+                symbol.range.start_column,
+                &symbol.name,
+            );
+            let mut occurrence = Message::default();
+            occurrence.packed(1, &range);
+            occurrence.string(2, &scip_symbol);
+            occurrence.uint(3, ROLE_DEFINITION);
+            occurrence.packed(
+                7,
+                &[
+                    symbol.range.start_line,
+                    symbol.range.start_column.into(),
+                    symbol.range.end_line,
+                    symbol.range.end_column.into(),
+                ],
+            );
+            document.occurrences.push((range, occurrence));
+
+            let implemented = facade
+                .get_implemented_traits(*id)
+                .into_iter()
+                .chain(facade.get_extends(*id));
+            let mut information = Message::default();
+            information.string(1, &scip_symbol);
+            if let Some(doc) = &symbol.doc_comment {
+                information.string(3, doc);
+            }
+            for target in implemented.filter(|target| symbols.contains_key(&target.id)) {
+                let mut relationship = Message::default();
+                relationship.string(1, &global_symbol(&symbols[&target.id], project_root));
+                relationship.uint(3, 1);
+                information.message(4, &relationship);
+            }
+            information.uint(5, kind_number(symbol.kind));
+            information.string(6, &symbol.name);
+            if let Some(signature) = &symbol.signature {
+                let mut signature_doc = Message::default();
+                if let Some(language) = symbol.language_id {
+                    signature_doc.string(4, language.as_str());
+                }
+                signature_doc.string(5, signature);
+                information.message(7, &signature_doc);
+            }
+            document.symbols.push(information);
+
+            // References from the other exported symbols
+            let callers = facade
+                .get_calling_functions_with_metadata(*id)
+                .into_iter()
+                .chain(facade.get_referencing_symbols_with_metadata(*id));
+            for (from, metadata) in callers {
+                let Some(line) = metadata.as_ref().and_then(|meta| meta.line) else {
+                    continue;
+                };
+                if !symbols.contains_key(&from.id) {
+                    continue;
+                }
+                let column = metadata.and_then(|meta| meta.column).unwrap_or(0);
+                let range = name_range(sources.lines(&from.file_path), line, column, &symbol.name);
+                let mut occurrence = Message::default();
+                occurrence.packed(1, &range);
+                occurrence.string(2, &scip_symbol);
+                documents
+                    .entry(relative_path(&from.file_path, project_root))
+                    .or_insert_with(|| Document::new(&from))
+                    .occurrences
+                    .push((range, occurrence));
+            }
+        }
+
+        let mut tool = Message::default();
+        tool.string(1, "codanna");
+        tool.string(2, env!("CARGO_PKG_VERSION"));
+        let mut metadata = Message::default();
+        metadata.message(2, &tool);
+        metadata.string(3, &root_uri(project_root));
+        metadata.uint(4, UTF8);
+
+        let mut index = Message::default();
+        index.message(1, &metadata);
+        for (path, document) in &mut documents {
+            index.message(2, &document.encode(path));
+        }
+
+        Self {
+            bytes: index.0,
+            documents: documents.len(),
+            symbols: ids.len(),
+        }
+    }
+}
+
+/// The occurrences and symbols of one file, until encoded
+struct Document {
+    language: Option<&'static str>,
+    /// By range, so the occurrences are written in source order
+    occurrences: Vec<(Vec<u32>, Message)>,
+    symbols: Vec<Message>,
+}
+
+impl Document {
+    fn new(symbol: &Symbol) -> Self {
+        Self {
+            language: symbol.language_id.map(|id| id.as_str()),
+            occurrences: Vec::new(),
+            symbols: Vec::new(),
+        }
+    }
+
+    fn encode(&mut self, path: &str) -> Message {
+        self.occurrences.sort_by(|a, b| a.0.cmp(&b.0));
+
+        let mut document = Message::default();
+        document.string(1, path);
+        for (_, occurrence) in &self.occurrences {
+            document.message(2, occurrence);
+        }
+        for symbol in &self.symbols {
+            document.message(3, symbol);
+        }
+        if let Some(language) = self.language {
+            document.string(4, language);
+        }
+        document.uint(6, UTF8);
+        document
+    }
+}
+
+/// Lines of the source files, each read once
+pub(super) struct Sources<'a> {
+    root: &'a Path,
+    files: HashMap<String, Vec<String>>,
+}
+
+impl<'a> Sources<'a> {
+    pub(super) fn new(root: &'a Path) -> Self {
+        Self {
+            root,
+            files: HashMap::new(),
+        }
+    }
+
+    pub(super) fn lines(&mut self, file_path: &str) -> &[String] {
+        self.files.entry(file_path.to_string()).or_insert_with(|| {
+            crate::indexing::encoding::read_to_string(&self.root.join(file_path))
+                .map(|text| text.lines().map(str::to_string).collect())
+                .unwrap_or_default()
+        })
+    }
+}
+
+/// SCIP range of `name` on `line`, first found from `column` on, else at
+/// `column` itself
+pub(super) fn name_range(lines: &[String], line: u32, column: u16, name: &str) -> Vec<u32> {
+    let column = usize::from(column);
+    let start = lines
+        .get(line as usize)
+        .and_then(|text| text.get(column..))
+        .and_then(|rest| rest.find(name))
+        .map_or(column, |offset| column + offset);
+    vec![line, start as u32, (start + name.len()) as u32]
+}
+
+/// `file_path` relative to `root`, with `/` separators
+pub(super) fn relative_path(file_path: &str, root: &Path) -> String {
+    let path = Path::new(file_path);
+    path.strip_prefix(root)
+        .unwrap_or(path)
+        .to_string_lossy()
+        .trim_start_matches("./")
+        .replace('\\', "/")
+}
+
+/// `file://` URI of the project root
+pub(super) fn root_uri(root: &Path) -> String {
+    let root = std::path::absolute(root).unwrap_or_else(|_| root.to_path_buf());
+    let path = root.to_string_lossy().replace('\\', "/");
+    if path.starts_with('/') {
+        format!("file://{path}")
+    } else {
+        format!("file:///{path}")
+    }
+}
+
+/// The SCIP symbol of `symbol`, in a project at `root`
+pub fn scip_symbol(symbol: &Symbol, root: &Path) -> String {
+    match symbol.scope_context {
+        Some(ScopeContext::Local { .. } | ScopeContext::Parameter) => {
+            format!("local {}", symbol.id.value())
+        }
+        _ => global_symbol(symbol, root),
+    }
+}
+
+/// The global SCIP symbol of `symbol`: its file as namespaces, then the
+/// class it is a member of, then itself
+fn global_symbol(symbol: &Symbol, root: &Path) -> String {
+    let mut name = String::from("codanna . . . ");
+    for segment in relative_path(&symbol.file_path, root)
+        .split('/')
+        .filter(|segment| !segment.is_empty())
+    {
+        name.push_str(&escape(segment));
+        name.push('/');
+    }
+    if let Some(ScopeContext::ClassMember {
+        class_name: Some(class),
+    }) = &symbol.scope_context
+    {
+        name.push_str(&escape(class));
+        name.push('#');
+    }
+    let own = escape(&symbol.name);
+    match symbol.kind {
+        SymbolKind::Function | SymbolKind::Method => name.push_str(&format!("{own}().")),
+        SymbolKind::Struct
+        | SymbolKind::Enum
+        | SymbolKind::Trait
+        | SymbolKind::Interface
+        | SymbolKind::Class
+        | SymbolKind::TypeAlias => name.push_str(&format!("{own}#")),
+        SymbolKind::Module => name.push_str(&format!("{own}/")),
+        SymbolKind::Macro => name.push_str(&format!("{own}!")),
+        SymbolKind::Parameter => name.push_str(&format!("({own})")),
+        SymbolKind::Variable | SymbolKind::Constant | SymbolKind::Field | SymbolKind::Given => {
+            name.push_str(&format!("{own}."))
+        }
+    }
+    name
+}
+
+/// A descriptor name as SCIP writes it: backquoted unless it is a plain
+/// identifier
+fn escape(name: &str) -> String {
+    let plain = !name.is_empty()
+        && name
+            .chars()
+            .all(|c| c.is_alphanumeric() || matches!(c, '_' | '+' | '-' | '$'));
+    if plain {
+        name.to_string()
+    } else {
+        format!("`{}`", name.replace('`', "``"))
+    }
+}
+
+/// `SymbolInformation.Kind` of a symbol kind
+fn kind_number(kind: SymbolKind) -> u64 {
+    match kind {
+        SymbolKind::Class => 7,
+        SymbolKind::Constant => 8,
+        SymbolKind::Enum => 11,
+        SymbolKind::Field => 15,
+        SymbolKind::Function => 17,
+        SymbolKind::Interface => 21,
+        SymbolKind::Macro => 25,
+        SymbolKind::Method => 26,
+        SymbolKind::Module => 29,
+        SymbolKind::Parameter => 37,
+        SymbolKind::Struct => 49,
+        SymbolKind::Trait => 53,
+        SymbolKind::TypeAlias => 55,
+        SymbolKind::Variable => 61,
+        SymbolKind::Given => 20,
+    }
+}
+
+/// A protobuf message being encoded
+#[derive(Debug, Clone, Default)]
+struct Message(Vec<u8>);
+
+impl Message {
+    fn varint(&mut self, mut value: u64) {
+        while value >= 0x80 {
+            self.0.push((value as u8) | 0x80);
+            value >>= 7;
+        }
+        self.0.push(value as u8);
+    }
+
+    fn tag(&mut self, field: u32, wire_type: u8) {
+        self.varint((u64::from(field) << 3) | u64::from(wire_type));
+    }
+
+    fn bytes(&mut self, field: u32, bytes: &[u8]) {
+        self.tag(field, 2);
+        self.varint(bytes.len() as u64);
+        self.0.extend_from_slice(bytes);
+    }
+
+    fn uint(&mut self, field: u32, value: u64) {
+        self.tag(field, 0);
+        self.varint(value);
+    }
+
+    fn string(&mut self, field: u32, value: &str) {
+        self.bytes(field, value.as_bytes());
+    }
+
+    fn message(&mut self, field: u32, message: &Message) {
+        self.bytes(field, &message.0);
+    }
+
+    fn packed(&mut self, field: u32, values: &[u32]) {
+        let mut packed = Message::default();
+        for &value in values {
+            packed.varint(u64::from(value));
+        }
+        self.bytes(field, &packed.0);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::Settings;
+    use std::sync::Arc;
+
+    #[test]
+    fn test_varint_and_escape() {
+        let mut message = Message::default();
+        message.uint(1, 300);
+        assert_eq!(message.0, [0x08, 0xac, 0x02]);
+
+        assert_eq!(escape("parse_file"), "parse_file");
+        assert_eq!(escape("mod.rs"), "`mod.rs`");
+        assert_eq!(escape("a`b"), "`a``b`");
+    }
+
+    #[test]
+    fn test_build_names_definitions_and_references() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("shapes.py");
+        std::fs::write(
+            &source,
+            "def make():\n    pass\n\n\ndef area():\n    return make()\n",
+        )
+        .unwrap();
+        let mut facade = IndexFacade::new(Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+
+        let make = facade.find_symbols_by_name("make", None).pop().unwrap();
+        assert_eq!(
+            scip_symbol(&make, dir.path()),
+            "codanna . . . `shapes.py`/make()."
+        );
+
+        let index = ScipIndex::build(&facade, &GraphFilter::default(), dir.path());
+        assert_eq!(index.documents, 1);
+        assert_eq!(index.symbols, 2);
+        let text = String::from_utf8_lossy(&index.bytes);
+        assert!(text.contains("shapes.py"));
+        assert!(text.contains("make()."));
+        // The definition of make and the call in area
+        assert_eq!(text.matches("`shapes.py`/make().").count(), 3);
+    }
+}
@@ -0,0 +1,266 @@
+//! The index as a SQLite database
+//!
+//! `codanna export sqlite <file>` writes the symbol store into four tables
+//! for ad-hoc SQL:
+//!
+//! - `files`: id, path and language of each indexed file;
+//! - `symbols`: name, kind, location, visibility, scope, signature and doc
+//!   of each symbol, with the last author when indexed with git blame;
+//! - `relationships`: calls, extends, implements, uses, defines and
+//!   references between symbols, with where each was found;
+//! - `metrics`: size and complexity of the functions and methods measured
+//!   with `indexing.symbol_metrics`.
+//!
+//! Lines are 1-based, columns 0-based byte offsets. An existing file is
+//! replaced.
+
+use super::GraphFilter;
+use crate::indexing::facade::IndexFacade;
+use crate::symbol::ScopeContext;
+use crate::{RelationKind, Symbol};
+use rusqlite::{Connection, params};
+use std::collections::HashMap;
+use std::path::Path;
+
+const SCHEMA: &str = "
+CREATE TABLE files (
+    id INTEGER PRIMARY KEY,
+    path TEXT NOT NULL,
+    language TEXT
+);
+CREATE TABLE symbols (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    file_id INTEGER NOT NULL REFERENCES files(id),
+    start_line INTEGER NOT NULL,
+    start_column INTEGER NOT NULL,
+    end_line INTEGER NOT NULL,
+    end_column INTEGER NOT NULL,
+    visibility TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    module_path TEXT,
+    signature TEXT,
+    doc TEXT,
+    language TEXT,
+    author TEXT,
+    commit_hash TEXT,
+    authored_at INTEGER
+);
+CREATE TABLE relationships (
+    from_id INTEGER NOT NULL REFERENCES symbols(id),
+    to_id INTEGER NOT NULL REFERENCES symbols(id),
+    kind TEXT NOT NULL,
+    line INTEGER,
+    col INTEGER
+);
+CREATE TABLE metrics (
+    symbol_id INTEGER PRIMARY KEY REFERENCES symbols(id),
+    complexity INTEGER NOT NULL,
+    lines INTEGER NOT NULL,
+    parameters INTEGER NOT NULL
+);
+CREATE INDEX symbols_name ON symbols(name);
+CREATE INDEX symbols_file ON symbols(file_id);
+CREATE INDEX relationships_from ON relationships(from_id, kind);
+CREATE INDEX relationships_to ON relationships(to_id, kind);
+";
+
+/// Relationships written, each in the direction the index stores it
+const RELATIONS: &[RelationKind] = &[
+    RelationKind::Calls,
+    RelationKind::Extends,
+    RelationKind::Implements,
+    RelationKind::Uses,
+    RelationKind::Defines,
+    RelationKind::References,
+];
+
+/// Rows written to each table
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct SqliteExport {
+    pub files: usize,
+    pub symbols: usize,
+    pub relationships: usize,
+    pub metrics: usize,
+}
+
+/// Write the symbols the filter accepts, their files and the relationships
+/// between them to a new database at `path`
+pub fn export(
+    facade: &IndexFacade,
+    filter: &GraphFilter,
+    path: &Path,
+) -> Result<SqliteExport, String> {
+    if path.exists() {
+        std::fs::remove_file(path)
+            .map_err(|e| format!("failed to replace {}: {e}", path.display()))?;
+    }
+    write_tables(facade, filter, path)
+        .map_err(|e| format!("failed to write {}: {e}", path.display()))
+}
+
+fn write_tables(
+    facade: &IndexFacade,
+    filter: &GraphFilter,
+    path: &Path,
+) -> rusqlite::Result<SqliteExport> {
+    let mut connection = Connection::open(path)?;
+    connection.execute_batch(SCHEMA)?;
+
+    let symbols: HashMap<_, Symbol> = facade
+        .get_all_symbols()
+        .into_iter()
+        .filter(|symbol| filter.accepts(symbol))
+        .map(|symbol| (symbol.id, symbol))
+        .collect();
+    // Language of the files of the exported symbols
+    let mut languages = HashMap::new();
+    for symbol in symbols.values() {
+        let language = languages.entry(symbol.file_id).or_insert(None);
+        if language.is_none() {
+            *language = symbol.language_id.map(|id| id.as_str());
+        }
+    }
+
+    let mut written = SqliteExport::default();
+    let transaction = connection.transaction()?;
+    {
+        let mut insert = transaction.prepare("INSERT INTO files VALUES (?1, ?2, ?3)")?;
+        for (file_id, file_path) in facade.get_indexed_files() {
+            let Some(language) = languages.get(&file_id) else {
+                continue;
+            };
+            insert.execute(params![file_id.value(), file_path, language])?;
+            written.files += 1;
+        }
+
+        let mut insert = transaction.prepare(
+            "INSERT INTO symbols VALUES \
+             (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14, ?15, ?16, ?17)",
+        )?;
+        let mut insert_metrics =
+            transaction.prepare("INSERT INTO metrics VALUES (?1, ?2, ?3, ?4)")?;
+        let mut insert_relationship =
+            transaction.prepare("INSERT INTO relationships VALUES (?1, ?2, ?3, ?4, ?5)")?;
+        let mut ids: Vec<_> = symbols.keys().copied().collect();
+        ids.sort_by_key(|id| id.value());
+        for id in ids {
+            let symbol = &symbols[&id];
+            let authorship = symbol.authorship.as_ref();
+            insert.execute(params![
+                id.value(),
+                &*symbol.name,
+                format!("{:?}", symbol.kind),
+                symbol.file_id.value(),
+                symbol.range.start_line + 1,
+                symbol.range.start_column,
+                symbol.range.end_line + 1,
+                symbol.range.end_column,
+                format!("{:?}", symbol.visibility),
+                scope_name(symbol.scope_context.as_ref()),
+                symbol.module_path.as_deref(),
+                symbol.signature.as_deref(),
+                symbol.doc_comment.as_deref(),
+                symbol.language_id.map(|id| id.as_str()),
+                authorship.map(|a| a.author.as_str()),
+                authorship.map(|a| a.commit.as_str()),
+                authorship.map(|a| i64::try_from(a.timestamp).unwrap_or(i64::MAX)),
+            ])?;
+            written.symbols += 1;
+
+            if let Some(metrics) = symbol.metrics {
+                insert_metrics.execute(params![
+                    id.value(),
+                    metrics.complexity,
+                    metrics.lines,
+                    metrics.parameters
+                ])?;
+                written.metrics += 1;
+            }
+
+            for kind in RELATIONS {
+                let relationships = facade
+                    .document_index()
+                    .get_relationships_from(id, *kind)
+                    .unwrap_or_default();
+                for (_, to, relationship) in relationships {
+                    if !symbols.contains_key(&to) {
+                        continue;
+                    }
+                    let metadata = relationship.metadata.as_ref();
+                    insert_relationship.execute(params![
+                        id.value(),
+                        to.value(),
+                        format!("{kind:?}").to_lowercase(),
+                        metadata.and_then(|meta| meta.line).map(|line| line + 1),
+                        metadata.and_then(|meta| meta.column),
+                    ])?;
+                    written.relationships += 1;
+                }
+            }
+        }
+    }
+    transaction.commit()?;
+    Ok(written)
+}
+
+/// Where a symbol is defined, as the `scope` column names it
+fn scope_name(scope: Option<&ScopeContext>) -> &'static str {
+    match scope {
+        Some(ScopeContext::Local { .. }) => "local",
+        Some(ScopeContext::Parameter) => "parameter",
+        Some(ScopeContext::ClassMember { .. }) => "member",
+        Some(ScopeContext::Module) | None => "module",
+        Some(ScopeContext::Package) => "package",
+        Some(ScopeContext::Global) => "global",
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::Settings;
+    use std::sync::Arc;
+
+    #[test]
+    fn test_export_writes_symbols_and_calls() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("shapes.py");
+        std::fs::write(
+            &source,
+            "def make():\n    pass\n\n\ndef area():\n    return make()\n",
+        )
+        .unwrap();
+        let mut facade = IndexFacade::new(Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+
+        let path = dir.path().join("out.db");
+        let written = export(&facade, &GraphFilter::default(), &path).unwrap();
+        assert_eq!((written.files, written.symbols), (1, 2));
+        assert_eq!(written.relationships, 1);
+
+        let connection = Connection::open(&path).unwrap();
+        let call: (String, String, i64) = connection
+            .query_row(
+                "SELECT f.name, t.name, r.line FROM relationships r \
+                 JOIN symbols f ON f.id = r.from_id JOIN symbols t ON t.id = r.to_id \
+                 WHERE r.kind = 'calls'",
+                [],
+                |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?)),
+            )
+            .unwrap();
+        assert_eq!(call, ("area".to_string(), "make".to_string(), 6));
+
+        // Exporting again replaces the file
+        assert_eq!(
+            export(&facade, &GraphFilter::default(), &path).unwrap(),
+            written
+        );
+    }
+}
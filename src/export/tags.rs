@@ -0,0 +1,289 @@
+//! Tags files for editor navigation
+//!
+//! `codanna export tags` writes the symbols of the index as a tags file:
+//! the extended format of universal-ctags, which Vim and most editors
+//! read, or the etags format of Emacs. Locals and parameters are left out,
+//! as ctags leaves them out by default. Paths are relative to the project
+//! root, where the file is meant to be written.
+
+use super::scip::relative_path;
+use super::{GraphFilter, UnknownExportValue};
+use crate::indexing::facade::IndexFacade;
+use crate::symbol::ScopeContext;
+use crate::{Symbol, SymbolKind};
+use std::collections::BTreeMap;
+use std::path::Path;
+use std::str::FromStr;
+
+/// Format of an exported tags file
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum TagsFormat {
+    Ctags,
+    Etags,
+}
+
+impl TagsFormat {
+    /// The name editors look for the file under
+    pub fn default_file(self) -> &'static str {
+        match self {
+            Self::Ctags => "tags",
+            Self::Etags => "TAGS",
+        }
+    }
+}
+
+impl FromStr for TagsFormat {
+    type Err = UnknownExportValue;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "ctags" | "vim" => Ok(Self::Ctags),
+            "etags" | "emacs" => Ok(Self::Etags),
+            _ => Err(UnknownExportValue {
+                option: "format",
+                input: s.to_string(),
+                expected: "ctags, etags",
+            }),
+        }
+    }
+}
+
+/// A written tags file
+#[derive(Debug, Clone, Default)]
+pub struct TagsFile {
+    pub text: String,
+    pub tags: usize,
+    pub files: usize,
+}
+
+impl TagsFile {
+    /// Build the tags of the symbols the filter accepts. Paths are made
+    /// relative to `project_root`, where the sources are read from for the
+    /// search patterns and offsets.
+    pub fn build(
+        facade: &IndexFacade,
+        filter: &GraphFilter,
+        project_root: &Path,
+        format: TagsFormat,
+    ) -> Self {
+        let mut files: BTreeMap<String, Vec<Symbol>> = BTreeMap::new();
+        for symbol in facade.get_all_symbols() {
+            let local = matches!(
+                symbol.scope_context,
+                Some(ScopeContext::Local { .. } | ScopeContext::Parameter)
+            );
+            if local || symbol.kind == SymbolKind::Parameter || !filter.accepts(&symbol) {
+                continue;
+            }
+            files
+                .entry(symbol.file_path.to_string())
+                .or_default()
+                .push(symbol);
+        }
+
+        let file_count = files.len();
+        let mut count = 0;
+        let mut tags = Vec::new();
+        let mut text = String::new();
+        for (file_path, mut symbols) in files {
+            symbols.sort_by_key(|symbol| (symbol.range.start_line, symbol.range.start_column));
+            let source = Source::read(&project_root.join(&file_path));
+            let path = relative_path(&file_path, project_root);
+            count += symbols.len();
+            match format {
+                TagsFormat::Ctags => {
+                    tags.extend(
+                        symbols
+                            .iter()
+                            .map(|symbol| ctags_line(symbol, &path, &source)),
+                    );
+                }
+                TagsFormat::Etags => {
+                    let section: String = symbols
+                        .iter()
+                        .map(|symbol| etags_line(symbol, &source))
+                        .collect();
+                    text.push_str(&format!("\x0c\n{path},{}\n{section}", section.len()));
+                }
+            }
+        }
+
+        if format == TagsFormat::Ctags {
+            // Sorted bytewise, so editors can search the file by halves
+            tags.sort();
+            text = [
+                "!_TAG_FILE_FORMAT\t2\t/extended format/",
+                "!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted, 2=foldcase/",
+                "!_TAG_PROGRAM_NAME\tcodanna\t//",
+                "!_TAG_PROGRAM_URL\thttps://github.com/bartolli/codanna\t//",
+            ]
+            .iter()
+            .map(|header| format!("{header}\n"))
+            .collect();
+            text.push_str(&format!(
+                "!_TAG_PROGRAM_VERSION\t{}\t//\n",
+                env!("CARGO_PKG_VERSION")
+            ));
+            for tag in &tags {
+                text.push_str(tag);
+                text.push('\n');
+            }
+        }
+
+        Self {
+            text,
+            tags: count,
+            files: file_count,
+        }
+    }
+}
+
+/// A source file split into lines, with the byte offset of each
+struct Source {
+    text: String,
+    starts: Vec<usize>,
+}
+
+impl Source {
+    /// An unreadable file reads as empty; its tags fall back to line numbers
+    fn read(path: &Path) -> Self {
+        let text = crate::indexing::encoding::read_to_string(path).unwrap_or_default();
+        let starts = if text.is_empty() {
+            Vec::new()
+        } else {
+            std::iter::once(0)
+                .chain(text.match_indices('\n').map(|(i, _)| i + 1))
+                .collect()
+        };
+        Self { text, starts }
+    }
+
+    /// Line `line`, without its line break
+    fn line(&self, line: u32) -> Option<&str> {
+        let start = *self.starts.get(line as usize)?;
+        let end = self
+            .starts
+            .get(line as usize + 1)
+            .map_or(self.text.len(), |next| next - 1);
+        let text = self.text.get(start..end)?;
+        Some(text.strip_suffix('\r').unwrap_or(text))
+    }
+}
+
+/// The tag of `symbol` in the extended ctags format
+fn ctags_line(symbol: &Symbol, path: &str, source: &Source) -> String {
+    let line = symbol.range.start_line;
+    let address = match source.line(line) {
+        Some(text) => {
+            let pattern = text.replace('\\', "\\\\").replace('/', "\\/");
+            format!("/^{pattern}$/")
+        }
+        None => (line + 1).to_string(),
+    };
+    let mut tag = format!(
+        "{}\t{path}\t{address};\"\t{}\tline:{}",
+        symbol.name,
+        kind_letter(symbol.kind),
+        line + 1
+    );
+    if let Some(ScopeContext::ClassMember {
+        class_name: Some(class),
+    }) = &symbol.scope_context
+    {
+        tag.push_str(&format!("\tclass:{class}"));
+    }
+    if let Some(language) = symbol.language_id {
+        tag.push_str(&format!("\tlanguage:{}", language.as_str()));
+    }
+    tag
+}
+
+/// The tag of `symbol` in an etags section: the line up to the end of the
+/// name, the name, the line number and the byte offset of the line
+fn etags_line(symbol: &Symbol, source: &Source) -> String {
+    let line = symbol.range.start_line;
+    let text = source.line(line).unwrap_or_default();
+    let column = usize::from(symbol.range.start_column);
+    let end = text
+        .get(column..)
+        .and_then(|rest| rest.find(&*symbol.name))
+        .map_or(text.len(), |offset| column + offset + symbol.name.len());
+    let offset = source.starts.get(line as usize).copied().unwrap_or(0);
+    format!(
+        "{}\x7f{}\x01{},{offset}\n",
+        &text[..end],
+        symbol.name,
+        line + 1
+    )
+}
+
+/// The kind letter of ctags, as its Rust parser writes them, with `c` for
+/// classes
+fn kind_letter(kind: SymbolKind) -> char {
+    match kind {
+        SymbolKind::Function => 'f',
+        SymbolKind::Method => 'P',
+        SymbolKind::Struct => 's',
+        SymbolKind::Enum => 'g',
+        SymbolKind::Trait | SymbolKind::Interface => 'i',
+        SymbolKind::Class => 'c',
+        SymbolKind::Module => 'n',
+        SymbolKind::Variable | SymbolKind::Given => 'v',
+        SymbolKind::Constant => 'C',
+        SymbolKind::Field => 'm',
+        SymbolKind::Parameter => 'z',
+        SymbolKind::TypeAlias => 't',
+        SymbolKind::Macro => 'M',
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::Settings;
+    use std::sync::Arc;
+
+    #[test]
+    fn test_ctags_and_etags() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("shapes.py");
+        std::fs::write(
+            &source,
+            "def make():\n    pass\n\n\nclass Shape:\n    pass\n",
+        )
+        .unwrap();
+        let mut facade = IndexFacade::new(Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+        let root = source.parent().unwrap().canonicalize().unwrap();
+
+        let ctags = TagsFile::build(&facade, &GraphFilter::default(), &root, TagsFormat::Ctags);
+        assert_eq!((ctags.tags, ctags.files), (2, 1));
+        let lines: Vec<&str> = ctags.text.lines().filter(|l| !l.starts_with('!')).collect();
+        assert_eq!(
+            lines,
+            vec![
+                "Shape\tshapes.py\t/^class Shape:$/;\"\tc\tline:5\tlanguage:python",
+                "make\tshapes.py\t/^def make():$/;\"\tf\tline:1\tlanguage:python",
+            ]
+        );
+
+        let etags = TagsFile::build(&facade, &GraphFilter::default(), &root, TagsFormat::Etags);
+        let section = "def make\x7fmake\x011,0\nclass Shape\x7fShape\x015,23\n";
+        assert_eq!(
+            etags.text,
+            format!("\x0c\nshapes.py,{}\n{section}", section.len())
+        );
+    }
+
+    #[test]
+    fn test_format_names() {
+        assert_eq!("ctags".parse::<TagsFormat>(), Ok(TagsFormat::Ctags));
+        assert_eq!("Emacs".parse::<TagsFormat>(), Ok(TagsFormat::Etags));
+        assert!("gtags".parse::<TagsFormat>().is_err());
+    }
+}
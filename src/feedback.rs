@@ -0,0 +1,238 @@
+//! Relevance feedback on search results
+//!
+//! A team marks results of a search as relevant or irrelevant, with the
+//! `search_feedback` MCP tool or `codanna mcp <search> --relevant <ids>`,
+//! and the marks are kept in `.codanna/feedback.json` next to the index.
+//! Later semantic searches whose words overlap those of a marked query move
+//! its marked symbols up or down, so the ranking learns the vocabulary of
+//! the domain: "ledger posting" finds `apply_journal_entry` once someone
+//! said so.
+//!
+//! A mark names the symbol by its name and file rather than its id, which
+//! a full reindex renumbers. The boost scales the score of the symbol, as
+//! the scores of vector, hybrid and re-ranked searches are not on one
+//! scale, by the share of words the two queries have in common.
+
+use crate::{IndexError, IndexResult, Settings};
+use serde::{Deserialize, Serialize};
+use std::collections::BTreeSet;
+use std::path::{Path, PathBuf};
+
+/// File holding the marks, next to the index
+const FEEDBACK_FILE: &str = "feedback.json";
+
+/// Share of its score a symbol gains or loses for a mark on the same query
+pub const BOOST: f32 = 0.25;
+
+/// Share of the words two queries must have in common for a mark of one to
+/// count for the other
+pub const MIN_OVERLAP: f32 = 0.5;
+
+/// Most the marks of a symbol move its score, up or down
+const MAX_BOOST: f32 = 2.0 * BOOST;
+
+/// A result marked for a query
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct FeedbackEntry {
+    /// The query, as its words joined by spaces
+    pub query: String,
+    pub symbol: String,
+    pub file: String,
+    pub relevant: bool,
+    /// Seconds since the Unix epoch
+    pub at: u64,
+}
+
+/// The contents of `feedback.json`
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct RelevanceFeedback {
+    /// Oldest first
+    #[serde(default)]
+    pub entries: Vec<FeedbackEntry>,
+}
+
+/// Where the feedback of the index at `settings.index_path` is kept
+pub fn feedback_path(settings: &Settings) -> PathBuf {
+    settings
+        .index_path
+        .parent()
+        .unwrap_or(Path::new("."))
+        .join(FEEDBACK_FILE)
+}
+
+/// The lowercase words of a query, each once
+fn words(query: &str) -> BTreeSet<String> {
+    query
+        .split(|c: char| !c.is_alphanumeric() && c != '_')
+        .filter(|word| !word.is_empty())
+        .map(str::to_lowercase)
+        .collect()
+}
+
+/// Words the queries have in common, as a share of the words of either
+fn overlap(a: &BTreeSet<String>, b: &BTreeSet<String>) -> f32 {
+    let union = a.union(b).count();
+    if union == 0 {
+        0.0
+    } else {
+        a.intersection(b).count() as f32 / union as f32
+    }
+}
+
+impl RelevanceFeedback {
+    /// The feedback at `path`; empty when there is none yet or it cannot
+    /// be read
+    pub fn load(path: &Path) -> Self {
+        std::fs::read_to_string(path)
+            .ok()
+            .and_then(|json| serde_json::from_str(&json).ok())
+            .unwrap_or_default()
+    }
+
+    pub fn save(&self, path: &Path) -> IndexResult<()> {
+        let json = serde_json::to_string_pretty(self)
+            .map_err(|e| IndexError::General(format!("Failed to serialize feedback: {e}")))?;
+        if let Some(dir) = path.parent() {
+            std::fs::create_dir_all(dir)?;
+        }
+        std::fs::write(path, json)?;
+        Ok(())
+    }
+
+    pub fn is_empty(&self) -> bool {
+        self.entries.is_empty()
+    }
+
+    /// Mark `symbol` of `file` for `query`. Marking it again for the same
+    /// words replaces the earlier mark.
+    pub fn record(&mut self, query: &str, symbol: &str, file: &str, relevant: bool, at: u64) {
+        let query = words(query).into_iter().collect::<Vec<_>>().join(" ");
+        self.entries.retain(|entry| {
+            !(entry.query == query && entry.symbol == symbol && entry.file == file)
+        });
+        self.entries.push(FeedbackEntry {
+            query,
+            symbol: symbol.to_string(),
+            file: file.to_string(),
+            relevant,
+            at,
+        });
+    }
+
+    /// Whether a mark counts for `query`
+    pub fn applies_to(&self, query: &str) -> bool {
+        let asked = words(query);
+        self.entries
+            .iter()
+            .any(|entry| overlap(&asked, &words(&entry.query)) >= MIN_OVERLAP)
+    }
+
+    /// Share of its score `symbol` of `file` gains, or loses when negative,
+    /// from the marks of queries like `query`
+    pub fn boost(&self, query: &str, symbol: &str, file: &str) -> f32 {
+        let asked = words(query);
+        let boost: f32 = self
+            .entries
+            .iter()
+            .filter(|entry| entry.symbol == symbol && entry.file == file)
+            .map(|entry| (entry, overlap(&asked, &words(&entry.query))))
+            .filter(|(_, overlap)| *overlap >= MIN_OVERLAP)
+            .map(|(entry, overlap)| {
+                let sign = if entry.relevant { 1.0 } else { -1.0 };
+                sign * BOOST * overlap
+            })
+            .sum();
+        boost.clamp(-MAX_BOOST, MAX_BOOST)
+    }
+
+    /// Move the scores of `results` of `query` by their marks and sort them
+    /// again, best first. `name_and_file` gives the symbol of a result.
+    pub fn rerank<T>(
+        &self,
+        query: &str,
+        results: &mut [(T, f32)],
+        name_and_file: impl Fn(&T) -> (&str, &str),
+    ) {
+        if !self.applies_to(query) {
+            return;
+        }
+        for (result, score) in results.iter_mut() {
+            let (symbol, file) = name_and_file(result);
+            // Scaled by the magnitude, so a mark moves a negative score the
+            // same way it moves a positive one
+            *score += score.abs() * self.boost(query, symbol, file);
+        }
+        results.sort_by(|a, b| b.1.total_cmp(&a.1));
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_marks_boost_similar_queries() {
+        let mut feedback = RelevanceFeedback::default();
+        feedback.record("Ledger posting", "apply_entry", "src/ledger.rs", true, 1);
+        feedback.record("ledger posting", "post_mail", "src/mail.rs", false, 2);
+
+        assert_eq!(
+            feedback.boost("ledger posting", "apply_entry", "src/ledger.rs"),
+            BOOST
+        );
+        assert_eq!(
+            feedback.boost("posting, ledger!", "post_mail", "src/mail.rs"),
+            -BOOST
+        );
+        // Two of three words in common
+        let partial = feedback.boost("ledger posting rules", "apply_entry", "src/ledger.rs");
+        assert!(partial > 0.0 && partial < BOOST, "{partial}");
+        assert_eq!(
+            feedback.boost("user login", "apply_entry", "src/ledger.rs"),
+            0.0
+        );
+        assert_eq!(
+            feedback.boost("ledger posting", "apply_entry", "src/other.rs"),
+            0.0
+        );
+        assert!(!feedback.applies_to("user login"));
+
+        // Marking again replaces the mark
+        feedback.record("posting ledger", "post_mail", "src/mail.rs", true, 3);
+        assert_eq!(feedback.entries.len(), 2);
+        assert_eq!(
+            feedback.boost("ledger posting", "post_mail", "src/mail.rs"),
+            BOOST
+        );
+    }
+
+    #[test]
+    fn test_rerank_moves_marked_results() {
+        let mut feedback = RelevanceFeedback::default();
+        feedback.record("hot path", "slow", "a.rs", true, 1);
+        feedback.record("hot path", "fast", "a.rs", false, 1);
+
+        let mut results = vec![("fast", 0.8), ("slow", 0.7), ("other", 0.75)];
+        feedback.rerank("hot path", &mut results, |name| (*name, "a.rs"));
+        let order: Vec<&str> = results.iter().map(|(name, _)| *name).collect();
+        assert_eq!(order, ["slow", "other", "fast"]);
+
+        // Negative scores of a re-ranker move the same way
+        let mut results = vec![("fast", -1.0), ("slow", -1.1)];
+        feedback.rerank("hot path", &mut results, |name| (*name, "a.rs"));
+        assert_eq!(results[0].0, "slow");
+    }
+
+    #[test]
+    fn test_feedback_round_trip() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join(FEEDBACK_FILE);
+        assert!(RelevanceFeedback::load(&path).is_empty());
+
+        let mut feedback = RelevanceFeedback::default();
+        feedback.record("parse config", "load", "src/config.rs", true, 7);
+        feedback.save(&path).unwrap();
+        assert_eq!(RelevanceFeedback::load(&path), feedback);
+        assert_eq!(feedback.entries[0].query, "config parse");
+    }
+}
@@ -3,8 +3,9 @@
 //! Replaces direct libgit2 usage to honor ~/.ssh/config, credential helpers,
 //! and other git configuration that libgit2 does not support.
 
-use std::path::Path;
-use std::process::Command;
+use std::io::{BufRead, BufReader, Read, Write};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
 use thiserror::Error;
 
 /// Git process exit code with user-friendly Display.
@@ -62,23 +63,30 @@ impl GitError {
 
 pub type GitResult<T> = Result<T, GitError>;
 
-/// Run a git command and return stdout on success, GitError on failure.
-fn run_git(args: &[&str]) -> GitResult<String> {
-    let output = Command::new("git")
+/// A git command isolated from the repository environment of the caller.
+fn git_command(args: &[&str]) -> Command {
+    let mut command = Command::new("git");
+    command
         .args(args)
         .env_remove("GIT_DIR")
         .env_remove("GIT_WORK_TREE")
         .env_remove("GIT_INDEX_FILE")
         .env_remove("GIT_OBJECT_DIRECTORY")
-        .env_remove("GIT_ALTERNATE_OBJECT_DIRECTORIES")
-        .output()
-        .map_err(|e| {
-            if e.kind() == std::io::ErrorKind::NotFound {
-                GitError::GitNotFound
-            } else {
-                GitError::Io(e)
-            }
-        })?;
+        .env_remove("GIT_ALTERNATE_OBJECT_DIRECTORIES");
+    command
+}
+
+fn spawn_error(e: std::io::Error) -> GitError {
+    if e.kind() == std::io::ErrorKind::NotFound {
+        GitError::GitNotFound
+    } else {
+        GitError::Io(e)
+    }
+}
+
+/// Run a git command and return stdout on success, GitError on failure.
+fn run_git(args: &[&str]) -> GitResult<String> {
+    let output = git_command(args).output().map_err(spawn_error)?;
 
     if output.status.success() {
         Ok(String::from_utf8_lossy(&output.stdout).trim().to_string())
@@ -235,6 +243,186 @@ pub fn get_commit_sha(repo_dir: &Path) -> GitResult<String> {
     run_git(&["-C", &dir_str, "rev-parse", "HEAD"])
 }
 
+/// Resolve a branch, tag or commit of a local repository to its full SHA.
+pub fn resolve_commit(repo_dir: &Path, rev: &str) -> GitResult<String> {
+    let dir_str = repo_dir.to_string_lossy();
+    let spec = format!("{rev}^{{commit}}");
+    run_git(&["-C", &dir_str, "rev-parse", "--verify", "--quiet", &spec]).map_err(|e| match e {
+        GitError::CommandFailed { .. } => GitError::ReferenceNotFound {
+            url: repo_dir.display().to_string(),
+            ref_name: rev.to_string(),
+        },
+        other => other,
+    })
+}
+
+/// Files under `dir` that differ between `since` and the work tree, whether
+/// committed, staged or not, plus untracked files git does not ignore.
+/// Paths are relative to `dir`; deleted files are included and renames are
+/// reported as a deletion and an addition.
+pub fn changed_files(dir: &Path, since: &str) -> GitResult<Vec<PathBuf>> {
+    let commit = resolve_commit(dir, since)?;
+    let dir_str = dir.to_string_lossy();
+    let diff = run_git(&[
+        "-C",
+        &dir_str,
+        "diff",
+        "--name-only",
+        "--no-renames",
+        "--relative",
+        "-z",
+        &commit,
+        "--",
+    ])?;
+    let untracked = run_git(&[
+        "-C",
+        &dir_str,
+        "ls-files",
+        "--others",
+        "--exclude-standard",
+        "-z",
+    ])?;
+
+    let mut files: Vec<PathBuf> = diff
+        .split('\0')
+        .chain(untracked.split('\0'))
+        .filter(|path| !path.is_empty())
+        .map(PathBuf::from)
+        .collect();
+    files.sort();
+    files.dedup();
+    Ok(files)
+}
+
+/// Write the files of a commit under `dest`, read from the object store so
+/// the work tree is left alone. Only paths `keep` accepts are written;
+/// symlinks and submodules are skipped. Returns the number of files written.
+pub fn export_tree(
+    repo_dir: &Path,
+    commit: &str,
+    dest: &Path,
+    keep: impl Fn(&str) -> bool,
+) -> GitResult<usize> {
+    let dir_str = repo_dir.to_string_lossy();
+    let listing = run_git(&["-C", &dir_str, "ls-tree", "-r", "-z", "--full-tree", commit])?;
+    // <mode> <type> <object>\t<path>
+    let blobs: Vec<(String, &str)> = listing
+        .split('\0')
+        .filter_map(|entry| {
+            let (meta, path) = entry.split_once('\t')?;
+            let mut fields = meta.split(' ');
+            let (mode, kind, object) = (fields.next()?, fields.next()?, fields.next()?);
+            (kind == "blob" && mode != "120000" && keep(path))
+                .then(|| (format!("{object}\n"), path))
+        })
+        .collect();
+    if blobs.is_empty() {
+        return Ok(0);
+    }
+
+    let mut child = git_command(&["-C", &dir_str, "cat-file", "--batch"])
+        .stdin(Stdio::piped())
+        .stdout(Stdio::piped())
+        .stderr(Stdio::null())
+        .spawn()
+        .map_err(spawn_error)?;
+    let mut stdin = child.stdin.take().expect("cat-file stdin is piped");
+    let objects: String = blobs.iter().map(|(object, _)| object.as_str()).collect();
+    // Fed from a thread so a full stdout pipe cannot stall the request
+    let feeder = std::thread::spawn(move || stdin.write_all(objects.as_bytes()));
+
+    let mut stdout = BufReader::new(child.stdout.take().expect("cat-file stdout is piped"));
+    let mut header = String::new();
+    for (_, path) in &blobs {
+        // <object> blob <size>, the content, then a newline
+        header.clear();
+        stdout.read_line(&mut header)?;
+        let mut fields = header.split_whitespace();
+        let size = match (fields.next(), fields.next(), fields.next()) {
+            (Some(_), Some("blob"), Some(size)) => size.parse::<usize>().ok(),
+            _ => None,
+        }
+        .ok_or_else(|| GitError::CommandFailed {
+            command: "cat-file --batch".to_string(),
+            stderr: format!("unexpected object header: {}", header.trim_end()),
+            exit_code: GitExitCode(None),
+        })?;
+        let mut content = vec![0; size + 1];
+        stdout.read_exact(&mut content)?;
+        content.pop();
+
+        let target = dest.join(path);
+        if let Some(parent) = target.parent() {
+            std::fs::create_dir_all(parent)?;
+        }
+        std::fs::write(target, content)?;
+    }
+    drop(stdout);
+    if let Ok(fed) = feeder.join() {
+        fed?;
+    }
+    child.wait()?;
+    Ok(blobs.len())
+}
+
+/// The commit that last changed a line, as `git blame` reports it
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct BlameLine {
+    pub commit: String,
+    pub author: String,
+    /// Author time, seconds since the Unix epoch
+    pub timestamp: u64,
+}
+
+/// Blame a file of a work tree, one entry per line of its current content.
+/// Lines not committed yet are `None`.
+pub fn blame_file(file: &Path) -> GitResult<Vec<Option<BlameLine>>> {
+    let dir = file
+        .parent()
+        .filter(|dir| !dir.as_os_str().is_empty())
+        .unwrap_or(Path::new("."));
+    let dir_str = dir.to_string_lossy();
+    let name = file
+        .file_name()
+        .map(|name| name.to_string_lossy())
+        .unwrap_or_default();
+    let porcelain = run_git(&["-C", &dir_str, "blame", "--line-porcelain", "--", &name])?;
+    Ok(parse_blame(&porcelain))
+}
+
+/// Parse `git blame --line-porcelain`: a header per line naming its commit,
+/// the commit's details, then the line itself behind a tab.
+fn parse_blame(porcelain: &str) -> Vec<Option<BlameLine>> {
+    let mut lines = Vec::new();
+    let mut current: Option<BlameLine> = None;
+    for row in porcelain.lines() {
+        if row.starts_with('\t') {
+            // The all-zero commit stands for uncommitted changes
+            let line = current
+                .take()
+                .filter(|line| !line.commit.bytes().all(|b| b == b'0'));
+            lines.push(line);
+        } else if let Some(line) = current.as_mut() {
+            if let Some(author) = row.strip_prefix("author ") {
+                line.author = author.to_string();
+            } else if let Some(time) = row.strip_prefix("author-time ") {
+                line.timestamp = time.parse().unwrap_or(0);
+            }
+        } else if let Some(commit) = row
+            .split(' ')
+            .next()
+            .filter(|commit| commit.len() >= 40 && commit.bytes().all(|b| b.is_ascii_hexdigit()))
+        {
+            current = Some(BlameLine {
+                commit: commit.to_string(),
+                author: String::new(),
+                timestamp: 0,
+            });
+        }
+    }
+    lines
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -419,6 +607,156 @@ mod tests {
         );
     }
 
+    #[test]
+    fn test_parse_blame_porcelain() {
+        let porcelain = [
+            "1f2e3d4c5b6a79808f7e6d5c4b3a291807f6e5d4 1 1 2",
+            "author Alice",
+            "author-mail <alice@example.com>",
+            "author-time 1700000000",
+            "summary Add retry",
+            "filename src/net.rs",
+            "\tfn retry() {",
+            "1f2e3d4c5b6a79808f7e6d5c4b3a291807f6e5d4 2 2",
+            "author Alice",
+            "author-time 1700000000",
+            "filename src/net.rs",
+            "\t}",
+            "0000000000000000000000000000000000000000 3 3 1",
+            "author Not Committed Yet",
+            "author-time 1800000000",
+            "filename src/net.rs",
+            "\t// wip",
+        ]
+        .join("\n");
+
+        let lines = parse_blame(&porcelain);
+        assert_eq!(lines.len(), 3);
+        let first = lines[0].as_ref().unwrap();
+        assert_eq!(first.author, "Alice");
+        assert_eq!(first.timestamp, 1_700_000_000);
+        assert_eq!(lines[1], lines[0]);
+        assert_eq!(lines[2], None, "uncommitted lines have no commit");
+    }
+
+    #[test]
+    fn test_blame_file_in_repo() {
+        let dir = tempdir().unwrap();
+        init_test_repo(dir.path());
+        std::fs::write(dir.path().join("README.md"), "test\nuncommitted\n").unwrap();
+
+        let lines = blame_file(&dir.path().join("README.md")).unwrap();
+        assert_eq!(lines.len(), 2);
+        let first = lines[0].as_ref().expect("committed line");
+        assert_eq!(first.author, "Test");
+        assert_eq!(first.commit, get_commit_sha(dir.path()).unwrap());
+        assert_eq!(lines[1], None);
+    }
+
+    #[test]
+    fn test_export_tree_at_earlier_commit() {
+        let dir = tempdir().unwrap();
+        init_test_repo(dir.path());
+        let first = get_commit_sha(dir.path()).unwrap();
+        let run = |args: &[&str]| {
+            let status = Command::new("git")
+                .args(args)
+                .current_dir(dir.path())
+                .status()
+                .unwrap();
+            assert!(status.success(), "git {} failed", args.join(" "));
+        };
+        std::fs::create_dir_all(dir.path().join("src")).unwrap();
+        std::fs::write(dir.path().join("src/lib.rs"), "\n\npub fn later() {}\n").unwrap();
+        std::fs::write(dir.path().join("README.md"), "changed").unwrap();
+        run(&["add", "-A"]);
+        run(&["commit", "-m", "second commit"]);
+
+        assert_eq!(resolve_commit(dir.path(), "HEAD~1").unwrap(), first);
+        assert!(matches!(
+            resolve_commit(dir.path(), "no-such-branch"),
+            Err(GitError::ReferenceNotFound { .. })
+        ));
+
+        let old = tempdir().unwrap();
+        assert_eq!(
+            export_tree(dir.path(), &first, old.path(), |_| true).unwrap(),
+            1
+        );
+        assert_eq!(
+            std::fs::read_to_string(old.path().join("README.md")).unwrap(),
+            "test"
+        );
+        assert!(!old.path().join("src").exists());
+
+        let head = tempdir().unwrap();
+        let written = export_tree(dir.path(), "HEAD", head.path(), |path| {
+            path.ends_with(".rs")
+        });
+        assert_eq!(written.unwrap(), 1);
+        assert_eq!(
+            std::fs::read_to_string(head.path().join("src/lib.rs")).unwrap(),
+            "\n\npub fn later() {}\n",
+            "content is written byte for byte"
+        );
+        assert_eq!(
+            std::fs::read_to_string(dir.path().join("README.md")).unwrap(),
+            "changed",
+            "the work tree is untouched"
+        );
+    }
+
+    #[test]
+    fn test_changed_files_since_commit() {
+        let dir = tempdir().unwrap();
+        init_test_repo(dir.path());
+        let run = |args: &[&str]| {
+            let status = Command::new("git")
+                .args(args)
+                .current_dir(dir.path())
+                .status()
+                .unwrap();
+            assert!(status.success(), "git {} failed", args.join(" "));
+        };
+        std::fs::create_dir_all(dir.path().join("src")).unwrap();
+        std::fs::write(dir.path().join("src/a.rs"), "fn a() {}\n").unwrap();
+        std::fs::write(dir.path().join("src/b.rs"), "fn b() {}\n").unwrap();
+        std::fs::write(dir.path().join(".gitignore"), "*.log\n").unwrap();
+        run(&["add", "-A"]);
+        run(&["commit", "-m", "second commit"]);
+        let since = get_commit_sha(dir.path()).unwrap();
+
+        // Committed, deleted in the work tree, untracked and ignored
+        std::fs::write(dir.path().join("src/a.rs"), "fn a() { b() }\n").unwrap();
+        run(&["commit", "-am", "third commit"]);
+        std::fs::remove_file(dir.path().join("src/b.rs")).unwrap();
+        std::fs::write(dir.path().join("src/c.rs"), "fn c() {}\n").unwrap();
+        std::fs::write(dir.path().join("debug.log"), "noise").unwrap();
+
+        assert_eq!(
+            changed_files(dir.path(), &since).unwrap(),
+            [
+                PathBuf::from("src/a.rs"),
+                PathBuf::from("src/b.rs"),
+                PathBuf::from("src/c.rs")
+            ]
+        );
+        assert_eq!(
+            changed_files(&dir.path().join("src"), &since).unwrap(),
+            [
+                PathBuf::from("a.rs"),
+                PathBuf::from("b.rs"),
+                PathBuf::from("c.rs")
+            ],
+            "paths are relative to the directory asked about"
+        );
+        assert_eq!(changed_files(dir.path(), "HEAD").unwrap().len(), 2);
+        assert!(matches!(
+            changed_files(dir.path(), "no-such-branch"),
+            Err(GitError::ReferenceNotFound { .. })
+        ));
+    }
+
     #[test]
     fn test_error_messages_include_suggestions() {
         let errors: Vec<GitError> = vec![
@@ -0,0 +1,169 @@
+//! What went wrong with the files of an indexing run.
+//!
+//! A file can fail to read, decode or parse, be skipped for its size, or
+//! parse with grammar errors or nesting past `max_ast_depth` and lose the
+//! symbols below them; each of these is a [`FileDiagnostic`]. The
+//! diagnostics of the last `codanna index` run that indexed or failed any
+//! file are saved next to the index as a [`ParseReport`], which
+//! `codanna report parse-failures` prints. An incremental run parses only
+//! what changed, so its report covers those files and the ones that keep
+//! failing: `index --force` reports on every file.
+
+use serde::{Deserialize, Serialize};
+use std::path::{Path, PathBuf};
+
+/// File of the report under the index directory
+pub const REPORT_FILE: &str = "parse_report.json";
+
+/// What kind of problem a file had
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum DiagnosticKind {
+    /// The file could not be read
+    ReadFailed,
+    /// Binary content, or bytes invalid in the encoding it was read in
+    Encoding,
+    /// Over its language's size limits, left out
+    SizeLimit,
+    /// No parser took the file
+    ParseFailed,
+    /// The grammar did not match all of it; symbols inside the broken
+    /// regions may be missing
+    GrammarErrors,
+    /// Nesting past `max_ast_depth`; the symbols below it were skipped
+    DepthLimit,
+}
+
+impl DiagnosticKind {
+    /// Whether the file got no symbols at all
+    pub fn is_failure(self) -> bool {
+        matches!(self, Self::ReadFailed | Self::SizeLimit | Self::ParseFailed)
+    }
+
+    pub fn as_str(self) -> &'static str {
+        match self {
+            Self::ReadFailed => "read_failed",
+            Self::Encoding => "encoding",
+            Self::SizeLimit => "size_limit",
+            Self::ParseFailed => "parse_failed",
+            Self::GrammarErrors => "grammar_errors",
+            Self::DepthLimit => "depth_limit",
+        }
+    }
+}
+
+/// One problem of one file
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct FileDiagnostic {
+    pub path: PathBuf,
+    pub kind: DiagnosticKind,
+    pub message: String,
+    /// 1-based line of the first place it occurs, where there is one
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub line: Option<usize>,
+}
+
+impl FileDiagnostic {
+    pub fn new(path: impl Into<PathBuf>, kind: DiagnosticKind, message: impl Into<String>) -> Self {
+        Self {
+            path: path.into(),
+            kind,
+            message: message.into(),
+            line: None,
+        }
+    }
+
+    pub fn at_line(mut self, line: usize) -> Self {
+        self.line = Some(line);
+        self
+    }
+}
+
+/// The diagnostics of an indexing run, as saved with the index
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct ParseReport {
+    /// Unix timestamp of the run
+    pub indexed_at: u64,
+    /// `full` for `index --force`, `incremental` otherwise
+    pub mode: String,
+    pub files_indexed: usize,
+    /// Sorted by path, then kind
+    pub diagnostics: Vec<FileDiagnostic>,
+}
+
+impl ParseReport {
+    pub fn new(force: bool, files_indexed: usize, mut diagnostics: Vec<FileDiagnostic>) -> Self {
+        diagnostics.sort_by(|a, b| (&a.path, a.kind).cmp(&(&b.path, b.kind)));
+        Self {
+            indexed_at: crate::indexing::get_utc_timestamp(),
+            mode: if force { "full" } else { "incremental" }.to_string(),
+            files_indexed,
+            diagnostics,
+        }
+    }
+
+    /// The report saved in `index_path`, if a run saved one
+    pub fn load(index_path: &Path) -> Option<Self> {
+        let text = std::fs::read_to_string(index_path.join(REPORT_FILE)).ok()?;
+        serde_json::from_str(&text).ok()
+    }
+
+    /// Save the report in `index_path`, replacing the last one
+    pub fn save(&self, index_path: &Path) -> std::io::Result<()> {
+        let json = serde_json::to_string_pretty(self).map_err(std::io::Error::other)?;
+        std::fs::write(index_path.join(REPORT_FILE), json)
+    }
+
+    /// The files with a diagnostic, each with its diagnostics
+    pub fn by_file(&self) -> Vec<(&Path, Vec<&FileDiagnostic>)> {
+        let mut files: Vec<(&Path, Vec<&FileDiagnostic>)> = Vec::new();
+        for diagnostic in &self.diagnostics {
+            match files.last_mut() {
+                Some((path, list)) if *path == diagnostic.path => list.push(diagnostic),
+                _ => files.push((&diagnostic.path, vec![diagnostic])),
+            }
+        }
+        files
+    }
+
+    /// How many files got no symbols at all
+    pub fn failed_files(&self) -> usize {
+        self.by_file()
+            .iter()
+            .filter(|(_, list)| list.iter().any(|d| d.kind.is_failure()))
+            .count()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_report_round_trips_grouped_by_file() {
+        let dir = tempfile::tempdir().unwrap();
+        let report = ParseReport::new(
+            true,
+            3,
+            vec![
+                FileDiagnostic::new("src/b.rs", DiagnosticKind::DepthLimit, "deep").at_line(40),
+                FileDiagnostic::new("src/a.rs", DiagnosticKind::SizeLimit, "too large"),
+                FileDiagnostic::new("src/b.rs", DiagnosticKind::GrammarErrors, "2 errors")
+                    .at_line(3),
+            ],
+        );
+        report.save(dir.path()).unwrap();
+
+        let loaded = ParseReport::load(dir.path()).unwrap();
+        assert_eq!(loaded.mode, "full");
+        let files = loaded.by_file();
+        assert_eq!(files.len(), 2);
+        assert_eq!(files[0].0, Path::new("src/a.rs"));
+        assert_eq!(
+            files[1].1.iter().map(|d| d.kind).collect::<Vec<_>>(),
+            vec![DiagnosticKind::GrammarErrors, DiagnosticKind::DepthLimit]
+        );
+        assert_eq!(loaded.failed_files(), 1);
+        assert!(ParseReport::load(&dir.path().join("missing")).is_none());
+    }
+}
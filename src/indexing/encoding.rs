@@ -0,0 +1,160 @@
+//! Character encodings of source files.
+//!
+//! The parsers take UTF-8, but not every source file is: Windows tools
+//! write UTF-16 behind a byte order mark, and older trees hold Latin-1 or
+//! Windows-1252. A file is decoded by its BOM where it has one, as UTF-8
+//! where it is valid, as UTF-16 where its NUL bytes fall on every other
+//! byte, and otherwise by the guess of `chardetng`. Any other file with a
+//! NUL byte is binary and is not read. Lines and columns of the index
+//! count in the decoded text, so whatever reads a file back for its
+//! symbols decodes it here too.
+
+use encoding_rs::{Encoding, UTF_8, UTF_16BE, UTF_16LE};
+use std::io;
+use std::path::Path;
+
+/// How many leading bytes tell UTF-16 without a BOM from binary
+const SNIFF_LEN: usize = 4096;
+
+/// The text of a file, as UTF-8
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Decoded {
+    pub text: String,
+    /// The encoding the text was transcoded from; none for UTF-8
+    pub encoding: Option<&'static str>,
+    /// Whether bytes invalid in that encoding were replaced with U+FFFD
+    pub malformed: bool,
+}
+
+/// Read the file at `path` and decode it
+pub fn read(path: &Path) -> io::Result<Decoded> {
+    decode(&std::fs::read(path)?)
+}
+
+/// Read the file at `path` as UTF-8, as `fs::read_to_string` would a
+/// UTF-8 file
+pub fn read_to_string(path: &Path) -> io::Result<String> {
+    read(path).map(|decoded| decoded.text)
+}
+
+/// Decode `bytes`; an error if they look binary
+pub fn decode(bytes: &[u8]) -> io::Result<Decoded> {
+    if let Some((encoding, bom)) = Encoding::for_bom(bytes) {
+        return Ok(transcode(encoding, &bytes[bom..]));
+    }
+    if let Ok(text) = std::str::from_utf8(bytes) {
+        if !text.contains('\0') {
+            return Ok(Decoded {
+                text: text.to_string(),
+                encoding: None,
+                malformed: false,
+            });
+        }
+    }
+    if let Some(encoding) = sniff_utf16(bytes) {
+        return Ok(transcode(encoding, bytes));
+    }
+    if bytes.contains(&0) {
+        return Err(io::Error::new(io::ErrorKind::InvalidData, "binary file"));
+    }
+
+    let mut detector = chardetng::EncodingDetector::new();
+    detector.feed(bytes, true);
+    Ok(transcode(detector.guess(None, false), bytes))
+}
+
+fn transcode(encoding: &'static Encoding, bytes: &[u8]) -> Decoded {
+    let (text, malformed) = encoding.decode_without_bom_handling(bytes);
+    Decoded {
+        text: text.into_owned(),
+        encoding: (encoding != UTF_8).then(|| encoding.name()),
+        malformed,
+    }
+}
+
+/// UTF-16 without a BOM: text that is mostly ASCII has a NUL in every
+/// other byte, after each character in little endian and before it in big
+fn sniff_utf16(bytes: &[u8]) -> Option<&'static Encoding> {
+    let head = &bytes[..bytes.len().min(SNIFF_LEN) & !1];
+    if head.is_empty() {
+        return None;
+    }
+    let pairs = head.len() / 2;
+    let (mut even, mut odd) = (0, 0);
+    for pair in head.chunks_exact(2) {
+        even += usize::from(pair[0] == 0);
+        odd += usize::from(pair[1] == 0);
+    }
+    // Most characters ASCII, and no NUL on the other side
+    let mostly = |nuls: usize| nuls * 2 >= pairs;
+    match (even, odd) {
+        (0, odd) if mostly(odd) => Some(UTF_16LE),
+        (even, 0) if mostly(even) => Some(UTF_16BE),
+        _ => None,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn utf16le(text: &str) -> Vec<u8> {
+        text.encode_utf16().flat_map(u16::to_le_bytes).collect()
+    }
+
+    #[test]
+    fn test_utf8_is_read_as_is() {
+        let decoded = decode("fn main() { let café = 1; }".as_bytes()).unwrap();
+        assert_eq!(decoded.text, "fn main() { let café = 1; }");
+        assert_eq!(decoded.encoding, None);
+
+        // A UTF-8 BOM is dropped
+        let decoded = decode(b"\xEF\xBB\xBFfn main() {}").unwrap();
+        assert_eq!(decoded.text, "fn main() {}");
+        assert_eq!(decoded.encoding, None);
+        assert!(!decoded.malformed);
+
+        // Behind a UTF-8 BOM, invalid bytes are replaced
+        let decoded = decode(b"\xEF\xBB\xBFlet x = \"\xFF\";").unwrap();
+        assert_eq!(decoded.text, "let x = \"\u{FFFD}\";");
+        assert!(decoded.malformed);
+    }
+
+    #[test]
+    fn test_utf16_with_and_without_bom() {
+        let source = "def greet():\n    return 'héllo'\n";
+
+        let mut with_bom = vec![0xFF, 0xFE];
+        with_bom.extend(utf16le(source));
+        let decoded = decode(&with_bom).unwrap();
+        assert_eq!(decoded.text, source);
+        assert_eq!(decoded.encoding, Some("UTF-16LE"));
+
+        let decoded = decode(&utf16le(source)).unwrap();
+        assert_eq!(decoded.text, source);
+        assert_eq!(decoded.encoding, Some("UTF-16LE"));
+
+        let big: Vec<u8> = source.encode_utf16().flat_map(u16::to_be_bytes).collect();
+        let decoded = decode(&big).unwrap();
+        assert_eq!(decoded.text, source);
+        assert_eq!(decoded.encoding, Some("UTF-16BE"));
+    }
+
+    #[test]
+    fn test_latin1_is_transcoded() {
+        // "// Résumé of the café menu" in Latin-1
+        let bytes = b"// R\xE9sum\xE9 of the caf\xE9 menu\nint main() { return 0; }\n";
+        let decoded = decode(bytes).unwrap();
+        assert!(decoded.text.contains("Résumé of the café menu"));
+        assert!(decoded.encoding.is_some());
+    }
+
+    #[test]
+    fn test_binary_is_rejected() {
+        let bytes = [
+            0x7F, b'E', b'L', b'F', 2, 1, 1, 0, 0, 0, 0, 0, 0x3E, 0, 0xB7,
+        ];
+        let error = decode(&bytes).unwrap_err();
+        assert_eq!(error.kind(), io::ErrorKind::InvalidData);
+    }
+}
@@ -23,18 +23,25 @@
 //! let symbols = facade.find_symbols_by_name("main")?;  // Uses DocumentIndex
 //! ```
 
-use crate::config::Settings;
+use crate::analysis::CodeOwners;
+use crate::config::{PathOverrides, SemanticVectors, Settings};
+use crate::feedback::{RelevanceFeedback, feedback_path};
 use crate::indexing::pipeline::Pipeline;
 use crate::semantic::remote::run_async;
 use crate::semantic::{
-    EmbeddingBackend, EmbeddingPool, RemoteEmbedder, SemanticSearchError, SimpleSemanticSearch,
+    EmbeddingBackend, EmbeddingPool, RemoteEmbedder, RemoteLimits, Reranker, SemanticFilter,
+    SemanticSearchError, SimpleSemanticSearch,
 };
-use crate::storage::{DocumentIndex, SearchResult};
+use crate::storage::{CompactStats, DocumentIndex, SearchResult};
 use crate::symbol::context::{ContextIncludes, SymbolContext, SymbolRelationships};
-use crate::{FileId, IndexError, RelationKind, Relationship, Symbol, SymbolId, SymbolKind};
+use crate::symbol::dsl::SymbolQuery;
+use crate::symbol::name_match::{NameMatcher, SearchMode};
+use crate::{
+    FileId, IndexError, RelationKind, Relationship, RelationshipEdge, Symbol, SymbolId, SymbolKind,
+};
 use std::collections::{HashMap, HashSet};
 use std::path::{Path, PathBuf};
-use std::sync::{Arc, Mutex};
+use std::sync::{Arc, LazyLock, Mutex, OnceLock, Weak};
 
 /// Result type for facade operations
 pub type FacadeResult<T> = Result<T, IndexError>;
@@ -72,6 +79,149 @@ impl SyncStats {
     }
 }
 
+/// A function reached by a transitive call graph query
+#[derive(Debug, Clone)]
+pub struct CallGraphEntry {
+    /// The function reached
+    pub symbol: Symbol,
+    /// Metadata of the call edge that first reached it
+    pub metadata: Option<crate::relationship::RelationshipMetadata>,
+    /// Call edges between it and the queried function (1 = direct)
+    pub depth: usize,
+    /// The function on the other side of that edge: its caller for
+    /// `get_transitive_calls`, its callee for `get_transitive_callers`
+    pub via: SymbolId,
+}
+
+/// A function in one of the trees of a [`CallHierarchy`]
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct CallNode {
+    pub symbol: Symbol,
+    /// Where the call is made, as `path:line` in the caller's file, when
+    /// the parser recorded it
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub call_site: Option<String>,
+    /// Whether the edge is one of several the call may resolve to
+    pub possible_target: bool,
+    /// The function's own callers (in `incoming`) or callees (in
+    /// `outgoing`)
+    pub children: Vec<CallNode>,
+}
+
+/// The callers and callees of a function, transitively, as trees
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct CallHierarchy {
+    pub symbol: Symbol,
+    /// Call edges followed from the function each way
+    pub depth: usize,
+    /// What calls the function
+    pub incoming: Vec<CallNode>,
+    /// What the function calls
+    pub outgoing: Vec<CallNode>,
+}
+
+impl CallHierarchy {
+    /// Number of functions in both trees
+    pub fn len(&self) -> usize {
+        fn count(nodes: &[CallNode]) -> usize {
+            nodes.iter().map(|node| 1 + count(&node.children)).sum()
+        }
+        count(&self.incoming) + count(&self.outgoing)
+    }
+
+    /// Whether the function has no callers and no callees
+    pub fn is_empty(&self) -> bool {
+        self.incoming.is_empty() && self.outgoing.is_empty()
+    }
+}
+
+/// A type in one of the trees of a [`TypeHierarchy`]
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct HierarchyNode {
+    pub symbol: Symbol,
+    /// How the type relates to its parent in the tree: `Extends` or
+    /// `Implements`, read from subtype to supertype
+    pub relation: RelationKind,
+    /// The type's own supertypes (in `supertypes`) or subtypes (in
+    /// `subtypes`)
+    pub children: Vec<HierarchyNode>,
+}
+
+/// Every supertype and subtype of a type, from its extends/implements
+/// relationships
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct TypeHierarchy {
+    pub symbol: Symbol,
+    /// What the type extends and implements, transitively
+    pub supertypes: Vec<HierarchyNode>,
+    /// What extends and implements the type, transitively
+    pub subtypes: Vec<HierarchyNode>,
+}
+
+impl TypeHierarchy {
+    /// Number of types in both trees
+    pub fn len(&self) -> usize {
+        fn count(nodes: &[HierarchyNode]) -> usize {
+            nodes.iter().map(|node| 1 + count(&node.children)).sum()
+        }
+        count(&self.supertypes) + count(&self.subtypes)
+    }
+
+    /// Whether the type has no supertypes and no subtypes
+    pub fn is_empty(&self) -> bool {
+        self.supertypes.is_empty() && self.subtypes.is_empty()
+    }
+}
+
+/// A symbol affected by a change, in one level of a [`ChangeImpact`]
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct ImpactEntry {
+    pub symbol: Symbol,
+    /// How the symbol depends on `via`: `Calls`, `Implements`, `Extends`
+    /// or `Uses`
+    pub relation: RelationKind,
+    /// The symbol one step closer to the changed one
+    pub via: SymbolId,
+}
+
+/// The symbols and files a change reaches at one distance
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct ImpactLevel {
+    /// Dependency edges between the changed symbol and these (1 = direct)
+    pub distance: usize,
+    pub symbols: Vec<ImpactEntry>,
+    /// Files of `symbols` that no nearer level, nor the changed symbol,
+    /// is in
+    pub files: Vec<String>,
+}
+
+/// Everything that transitively depends on a symbol, grouped by distance
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct ChangeImpact {
+    pub symbol: Symbol,
+    /// Nearest first; empty when nothing depends on the symbol
+    pub levels: Vec<ImpactLevel>,
+}
+
+impl ChangeImpact {
+    /// Number of affected symbols at all distances
+    pub fn len(&self) -> usize {
+        self.levels.iter().map(|level| level.symbols.len()).sum()
+    }
+
+    /// Whether nothing depends on the symbol
+    pub fn is_empty(&self) -> bool {
+        self.levels.is_empty()
+    }
+
+    /// Affected files other than the changed symbol's own, nearest first
+    pub fn files(&self) -> impl Iterator<Item = &str> {
+        self.levels
+            .iter()
+            .flat_map(|level| level.files.iter().map(String::as_str))
+    }
+}
+
 /// IndexFacade - Unified interface for code intelligence operations
 ///
 /// This facade wraps DocumentIndex (for queries) and Pipeline (for indexing),
@@ -98,13 +248,22 @@ pub struct IndexFacade {
     /// Base path for index storage
     index_base: PathBuf,
 
-    /// Set to true when load_semantic_search fails with DimensionMismatch so
-    /// hot-reload and other callers do not retry on every reload cycle.
+    /// Set to true when load_semantic_search fails with DimensionMismatch or
+    /// ModelChanged so hot-reload and other callers do not retry on every
+    /// reload cycle.
     semantic_incompatible: bool,
 
     /// Persisted semantic metadata for status/reporting when semantic search
     /// is not loaded into memory (for example, lite facade loads).
     semantic_metadata_snapshot: Option<crate::semantic::SemanticMetadata>,
+
+    /// Cross-encoder for `semantic_search.rerank`, loaded on first use;
+    /// None inside when it failed to load
+    reranker: OnceLock<Option<Reranker>>,
+
+    /// CODEOWNERS of the workspace, read on first use; None inside when it
+    /// has none
+    code_owners: OnceLock<Option<CodeOwners>>,
 }
 
 impl IndexFacade {
@@ -136,6 +295,8 @@ impl IndexFacade {
             index_base,
             semantic_incompatible: false,
             semantic_metadata_snapshot: None,
+            reranker: OnceLock::new(),
+            code_owners: OnceLock::new(),
         })
     }
 
@@ -162,6 +323,8 @@ impl IndexFacade {
             index_base,
             semantic_incompatible: false,
             semantic_metadata_snapshot: None,
+            reranker: OnceLock::new(),
+            code_owners: OnceLock::new(),
         }
     }
 
@@ -180,6 +343,29 @@ impl IndexFacade {
         &self.settings
     }
 
+    /// Owners the CODEOWNERS of the workspace gives the file at `path`;
+    /// none without a CODEOWNERS or a rule matching the file
+    pub fn owners_of(&self, path: &str) -> Vec<String> {
+        let code_owners = self.code_owners.get_or_init(|| {
+            let root = match &self.settings.workspace_root {
+                Some(root) => root.clone(),
+                None => std::env::current_dir().ok()?,
+            };
+            CodeOwners::load(&root)
+        });
+        let Some(code_owners) = code_owners else {
+            return Vec::new();
+        };
+        let relative = self.settings.workspace_relative(Path::new(path));
+        code_owners.owners_of(&relative.to_string_lossy()).to_vec()
+    }
+
+    /// Take up the values of `new` that apply without a restart (see
+    /// [`Settings::with_live_values_of`]); the rest stay as loaded.
+    pub fn apply_live_settings(&mut self, new: &Settings) {
+        self.settings = Arc::new(self.settings.with_live_values_of(new));
+    }
+
     /// Get the index base path.
     pub fn index_base(&self) -> &Path {
         &self.index_base
@@ -199,8 +385,7 @@ impl IndexFacade {
 
         // In remote mode, skip local fastembed init; use new_empty so the
         // SemanticSearch instance carries the correct dimension from the backend.
-        let is_remote = self.settings.semantic_search.remote_url.is_some()
-            || std::env::var("CODANNA_EMBED_URL").is_ok();
+        let is_remote = self.settings.semantic_search.is_remote();
         let semantic = if is_remote {
             SimpleSemanticSearch::new_empty(
                 backend.dimensions(),
@@ -224,16 +409,70 @@ impl IndexFacade {
     }
 
     /// Returns true if a previous load_semantic_search call failed with
-    /// DimensionMismatch, meaning retrying would always fail until re-indexed.
+    /// DimensionMismatch or ModelChanged, meaning retrying would always fail
+    /// until re-embedded.
     pub fn is_semantic_incompatible(&self) -> bool {
         self.semantic_incompatible
     }
 
+    /// Start semantic search over with the configured model, dropping the
+    /// embeddings of the model the index was built with. Files indexed
+    /// from now on are embedded as usual; `embed_missing_symbols` embeds
+    /// the rest.
+    pub fn reset_semantic_search(&mut self) -> FacadeResult<()> {
+        let semantic_path = self.index_base.join("semantic");
+        if semantic_path.exists() {
+            std::fs::remove_dir_all(&semantic_path)?;
+        }
+        self.semantic_search = None;
+        self.semantic_incompatible = false;
+        self.enable_semantic_search()
+    }
+
+    /// Embed the documented symbols that have no embedding, e.g. after
+    /// `reset_semantic_search`. Returns how many were embedded.
+    pub fn embed_missing_symbols(&mut self) -> FacadeResult<usize> {
+        const BATCH_SIZE: usize = 256;
+
+        let Some(semantic) = self.semantic_search.clone() else {
+            return Ok(0);
+        };
+        self.ensure_embedding_pool()?;
+        let pool = self
+            .embedding_pool
+            .clone()
+            .expect("embedding pool initialized above");
+
+        // Files that [overrides] leave out of semantic search stay out
+        let overrides = PathOverrides::new(&self.settings).unwrap_or_default();
+        let symbols = self
+            .get_all_symbols()
+            .into_iter()
+            .filter(|symbol| overrides.embeds(Path::new(&*symbol.file_path)));
+        let missing = semantic
+            .lock()
+            .map_err(|_| IndexError::lock_error())?
+            .missing_embeddings(symbols);
+        let mut embedded = 0;
+        for batch in missing.chunks(BATCH_SIZE) {
+            let items: Vec<(SymbolId, &str, &str)> = batch
+                .iter()
+                .map(|(id, doc, language)| (*id, doc.as_str(), language.as_str()))
+                .collect();
+            let embeddings = pool.embed_parallel(&items)?;
+            embedded += semantic
+                .lock()
+                .map_err(|_| IndexError::lock_error())?
+                .store_embeddings(embeddings);
+        }
+        Ok(embedded)
+    }
+
     /// Save semantic search data to disk.
     pub fn save_semantic_search(&self, path: &Path) -> FacadeResult<()> {
         if let Some(ref semantic) = self.semantic_search {
             let sem = semantic.lock().map_err(|_| IndexError::lock_error())?;
-            sem.save(path)?;
+            sem.save_with_quantization(path, self.settings.semantic_search.quantization)?;
         }
         Ok(())
     }
@@ -244,8 +483,22 @@ impl IndexFacade {
     /// Embedding pool for generating new embeddings is initialized lazily.
     pub fn load_semantic_search(&mut self, path: &Path) -> FacadeResult<bool> {
         if path.join("metadata.json").exists() {
-            let is_remote = self.settings.semantic_search.remote_url.is_some()
-                || std::env::var("CODANNA_EMBED_URL").is_ok();
+            // Embeddings of another model are not comparable with the
+            // configured one's, whatever their dimension; index runs start
+            // the store over (see `reset_semantic_search`)
+            let index_model = crate::semantic::SemanticMetadata::load(path)?.model_name;
+            let configured = configured_model_name(&self.settings.semantic_search);
+            if index_model != configured {
+                self.semantic_incompatible = true;
+                return Err(IndexError::SemanticSearch(
+                    SemanticSearchError::ModelChanged {
+                        index: index_model,
+                        configured,
+                    },
+                ));
+            }
+
+            let is_remote = self.settings.semantic_search.is_remote();
             let load_result = if is_remote {
                 SimpleSemanticSearch::load_remote(path)
             } else {
@@ -256,8 +509,8 @@ impl IndexFacade {
                     // Restore the embedding backend so query-time remote embedding
                     // works immediately without waiting for a lazy reindex call.
                     if self.embedding_pool.is_none() {
-                        match build_embedding_backend(&self.settings.semantic_search) {
-                            Ok(b) => self.embedding_pool = Some(Arc::new(b)),
+                        match shared_embedding_backend(&self.settings.semantic_search) {
+                            Ok(b) => self.embedding_pool = Some(b),
                             Err(e) => tracing::warn!("Failed to restore embedding backend: {e}"),
                         }
                     }
@@ -276,7 +529,7 @@ impl IndexFacade {
                                     suggestion: format!(
                                         "Index was built with {index_dim}-dimensional embeddings \
                                          but current backend produces {backend_dim}d. \
-                                         Re-index with: codanna index <path> --force"
+                                         Run 'codanna index' to re-embed"
                                     ),
                                 },
                             ));
@@ -357,12 +610,24 @@ impl IndexFacade {
             return Ok(());
         }
 
-        let backend = build_embedding_backend(&self.settings.semantic_search)?;
-        self.embedding_pool = Some(Arc::new(backend));
+        self.embedding_pool = Some(shared_embedding_backend(&self.settings.semantic_search)?);
         tracing::debug!("Initialized embedding backend for incremental updates");
         Ok(())
     }
 
+    /// The backend a directory run embeds with. With
+    /// `semantic_search.background` there is none: the run commits symbols
+    /// without their embeddings, and `codanna embed` computes them after.
+    fn directory_embedding_pool(&mut self) -> Option<Arc<EmbeddingBackend>> {
+        if !self.has_semantic_search() || self.settings.semantic_search.background {
+            return None;
+        }
+        if let Err(e) = self.ensure_embedding_pool() {
+            tracing::warn!("Failed to initialize embedding pool: {e}");
+        }
+        self.embedding_pool.clone()
+    }
+
     /// Get semantic search embedding count.
     pub fn semantic_search_embedding_count(&self) -> usize {
         self.semantic_search
@@ -411,6 +676,7 @@ impl IndexFacade {
     /// Symbol counts by kind and by language in one pass. Both index-info
     /// renderings consume this single assembly; the two maps partition the
     /// same symbol set (languageless legacy rows appear only in kinds).
+    /// Symbols of a framework kind count under it, not their own.
     pub fn symbol_stats(
         &self,
     ) -> (
@@ -420,7 +686,7 @@ impl IndexFacade {
         let mut kinds = std::collections::BTreeMap::new();
         let mut languages = std::collections::BTreeMap::new();
         for symbol in self.get_all_symbols() {
-            *kinds.entry(format!("{:?}", symbol.kind)).or_insert(0usize) += 1;
+            *kinds.entry(symbol.kind_name()).or_insert(0usize) += 1;
             if let Some(lang) = symbol.language_id.as_ref() {
                 *languages.entry(lang.as_str().to_string()).or_insert(0usize) += 1;
             }
@@ -531,6 +797,108 @@ impl IndexFacade {
         results
     }
 
+    /// Get the symbols reading or writing a field or module-level value,
+    /// with the metadata of each reference site.
+    pub fn get_referencing_symbols_with_metadata(
+        &self,
+        symbol_id: SymbolId,
+    ) -> Vec<(Symbol, Option<crate::relationship::RelationshipMetadata>)> {
+        let relationships = self
+            .document_index
+            .get_relationships_to(symbol_id, RelationKind::References)
+            .unwrap_or_default();
+
+        let mut results = Vec::new();
+        for (from_id, _, rel) in relationships {
+            if let Some(symbol) = self.get_symbol(from_id) {
+                results.push((symbol, rel.metadata));
+            }
+        }
+        results
+    }
+
+    /// Get every function a symbol eventually calls, up to `max_depth`
+    /// call edges away, nearest first.
+    pub fn get_transitive_calls(
+        &self,
+        symbol_id: SymbolId,
+        max_depth: usize,
+    ) -> Vec<CallGraphEntry> {
+        self.walk_call_graph(symbol_id, max_depth, |id| {
+            self.get_called_functions_with_metadata(id)
+        })
+    }
+
+    /// Get every function that eventually calls a symbol, up to
+    /// `max_depth` call edges away, nearest first.
+    pub fn get_transitive_callers(
+        &self,
+        symbol_id: SymbolId,
+        max_depth: usize,
+    ) -> Vec<CallGraphEntry> {
+        self.walk_call_graph(symbol_id, max_depth, |id| {
+            self.get_calling_functions_with_metadata(id)
+        })
+    }
+
+    /// Breadth-first walk of call edges. Each function is reported once,
+    /// at the depth it is first reached, so cycles and diamonds terminate.
+    fn walk_call_graph(
+        &self,
+        symbol_id: SymbolId,
+        max_depth: usize,
+        edges: impl Fn(SymbolId) -> Vec<(Symbol, Option<crate::relationship::RelationshipMetadata>)>,
+    ) -> Vec<CallGraphEntry> {
+        let mut visited = HashSet::from([symbol_id]);
+        let mut queue = std::collections::VecDeque::from([(symbol_id, 0usize)]);
+        let mut reached = Vec::new();
+
+        while let Some((current_id, depth)) = queue.pop_front() {
+            if depth >= max_depth {
+                continue;
+            }
+            for (symbol, metadata) in edges(current_id) {
+                if visited.insert(symbol.id) {
+                    queue.push_back((symbol.id, depth + 1));
+                    reached.push(CallGraphEntry {
+                        symbol,
+                        metadata,
+                        depth: depth + 1,
+                        via: current_id,
+                    });
+                }
+            }
+        }
+        reached
+    }
+
+    /// Get the caller and callee trees of a function, `max_depth` call
+    /// edges deep each. A function is placed once, under the first function
+    /// reaching it, as in [`IndexFacade::get_transitive_calls`].
+    pub fn get_call_hierarchy(
+        &self,
+        symbol_id: SymbolId,
+        max_depth: usize,
+    ) -> Option<CallHierarchy> {
+        let symbol = self.get_symbol(symbol_id)?;
+        let incoming = call_tree(
+            &symbol,
+            self.get_transitive_callers(symbol_id, max_depth),
+            false,
+        );
+        let outgoing = call_tree(
+            &symbol,
+            self.get_transitive_calls(symbol_id, max_depth),
+            true,
+        );
+        Some(CallHierarchy {
+            symbol,
+            depth: max_depth,
+            incoming,
+            outgoing,
+        })
+    }
+
     /// Get implementations of a trait/interface.
     pub fn get_implementations(&self, trait_id: SymbolId) -> Vec<Symbol> {
         let relationships = self
@@ -539,7 +907,14 @@ impl IndexFacade {
             .unwrap_or_default();
 
         let mut symbols = Vec::new();
-        for (from_id, _, _) in relationships {
+        for (from_id, _, rel) in relationships {
+            if rel
+                .metadata
+                .as_ref()
+                .is_some_and(|m| m.is_declaration_link())
+            {
+                continue;
+            }
             if let Some(symbol) = self.get_symbol(from_id) {
                 symbols.push(symbol);
             }
@@ -555,7 +930,14 @@ impl IndexFacade {
             .unwrap_or_default();
 
         let mut symbols = Vec::new();
-        for (_, to_id, _) in relationships {
+        for (_, to_id, rel) in relationships {
+            if rel
+                .metadata
+                .as_ref()
+                .is_some_and(|m| m.is_declaration_link())
+            {
+                continue;
+            }
             if let Some(symbol) = self.get_symbol(to_id) {
                 symbols.push(symbol);
             }
@@ -563,6 +945,62 @@ impl IndexFacade {
         symbols
     }
 
+    /// Get the header declarations a C or C++ definition implements.
+    pub fn get_declarations(&self, definition_id: SymbolId) -> Vec<Symbol> {
+        self.document_index
+            .get_relationships_from(definition_id, RelationKind::Implements)
+            .unwrap_or_default()
+            .into_iter()
+            .filter(|(_, _, rel)| {
+                rel.metadata
+                    .as_ref()
+                    .is_some_and(|meta| meta.is_declaration_link())
+            })
+            .filter_map(|(_, to_id, _)| self.get_symbol(to_id))
+            .collect()
+    }
+
+    /// Get the other parts of a C# `partial` type: the `partial` types of
+    /// the same name, kind and module in other files.
+    pub fn get_partial_parts(&self, type_id: SymbolId) -> Vec<Symbol> {
+        use crate::parsing::csharp::parser::is_partial_signature;
+
+        let Some(symbol) = self.get_symbol(type_id) else {
+            return Vec::new();
+        };
+        let is_part = |sym: &Symbol| {
+            sym.language_id.is_some_and(|id| id.as_str() == "csharp")
+                && sym.signature.as_deref().is_some_and(is_partial_signature)
+        };
+        if !is_part(&symbol) {
+            return Vec::new();
+        }
+        self.find_symbols_by_name(&symbol.name, Some("csharp"))
+            .into_iter()
+            .filter(|part| {
+                part.id != symbol.id
+                    && part.kind == symbol.kind
+                    && part.module_path == symbol.module_path
+                    && is_part(part)
+            })
+            .collect()
+    }
+
+    /// Get the definitions of a C or C++ header declaration.
+    pub fn get_definitions(&self, declaration_id: SymbolId) -> Vec<Symbol> {
+        self.document_index
+            .get_relationships_to(declaration_id, RelationKind::Implements)
+            .unwrap_or_default()
+            .into_iter()
+            .filter(|(_, _, rel)| {
+                rel.metadata
+                    .as_ref()
+                    .is_some_and(|meta| meta.is_declaration_link())
+            })
+            .filter_map(|(from_id, _, _)| self.get_symbol(from_id))
+            .collect()
+    }
+
     /// Get classes/types extended by a class.
     pub fn get_extends(&self, class_id: SymbolId) -> Vec<Symbol> {
         let relationships = self
@@ -595,6 +1033,58 @@ impl IndexFacade {
         symbols
     }
 
+    /// Get the supertype and subtype trees of a type.
+    pub fn get_type_hierarchy(&self, type_id: SymbolId) -> Option<TypeHierarchy> {
+        let symbol = self.get_symbol(type_id)?;
+        let supertypes = self.hierarchy_branches(type_id, true, &mut HashSet::from([type_id]));
+        let subtypes = self.hierarchy_branches(type_id, false, &mut HashSet::from([type_id]));
+        Some(TypeHierarchy {
+            symbol,
+            supertypes,
+            subtypes,
+        })
+    }
+
+    /// Supertypes or subtypes of a type, each with its own. A type is
+    /// placed once, under the first type reaching it, so diamonds and
+    /// cyclic relationships terminate.
+    fn hierarchy_branches(
+        &self,
+        type_id: SymbolId,
+        supertypes: bool,
+        visited: &mut HashSet<SymbolId>,
+    ) -> Vec<HierarchyNode> {
+        let related = if supertypes {
+            [
+                (RelationKind::Extends, self.get_extends(type_id)),
+                (
+                    RelationKind::Implements,
+                    self.get_implemented_traits(type_id),
+                ),
+            ]
+        } else {
+            [
+                (RelationKind::Extends, self.get_extended_by(type_id)),
+                (RelationKind::Implements, self.get_implementations(type_id)),
+            ]
+        };
+
+        let mut nodes: Vec<HierarchyNode> = related
+            .into_iter()
+            .flat_map(|(relation, symbols)| symbols.into_iter().map(move |s| (relation, s)))
+            .filter(|(_, symbol)| visited.insert(symbol.id))
+            .map(|(relation, symbol)| HierarchyNode {
+                symbol,
+                relation,
+                children: Vec::new(),
+            })
+            .collect();
+        for node in &mut nodes {
+            node.children = self.hierarchy_branches(node.symbol.id, supertypes, visited);
+        }
+        nodes
+    }
+
     /// Get types/symbols used by a symbol.
     pub fn get_uses(&self, symbol_id: SymbolId) -> Vec<Symbol> {
         let relationships = self
@@ -693,6 +1183,18 @@ impl IndexFacade {
             if !implemented.is_empty() {
                 relationships.implements = Some(implemented);
             }
+            let declarations = self.get_declarations(symbol_id);
+            if !declarations.is_empty() {
+                relationships.declarations = Some(declarations);
+            }
+            let definitions = self.get_definitions(symbol_id);
+            if !definitions.is_empty() {
+                relationships.definitions = Some(definitions);
+            }
+            let parts = self.get_partial_parts(symbol_id);
+            if !parts.is_empty() {
+                relationships.parts = Some(parts);
+            }
         }
 
         if include.contains(ContextIncludes::DEFINITIONS) {
@@ -746,10 +1248,13 @@ impl IndexFacade {
             }
         }
 
+        let owners = self.owners_of(&symbol.file_path);
         Some(SymbolContext {
             symbol,
             file_path,
             relationships,
+            owners,
+            source: None,
         })
     }
 
@@ -804,7 +1309,27 @@ impl IndexFacade {
         deps
     }
 
-    /// Get impact radius (BFS traversal of dependents).
+    /// Whether another symbol calls, uses, implements, extends or references
+    /// this one. Recursive calls don't count.
+    pub fn is_referenced(&self, symbol_id: SymbolId) -> bool {
+        [
+            RelationKind::Calls,
+            RelationKind::Uses,
+            RelationKind::Implements,
+            RelationKind::Extends,
+            RelationKind::References,
+        ]
+        .iter()
+        .any(|kind| {
+            self.document_index
+                .get_relationships_to(symbol_id, *kind)
+                .unwrap_or_default()
+                .iter()
+                .any(|(from_id, _, _)| *from_id != symbol_id)
+        })
+    }
+
+    /// Get impact radius (BFS traversal of dependents), ordered by symbol id.
     pub fn get_impact_radius(
         &self,
         symbol_id: SymbolId,
@@ -839,9 +1364,71 @@ impl IndexFacade {
             }
         }
 
-        // Remove the initial symbol from results
+        // Remove the initial symbol from results, and order the rest by id
+        // so that every call lists them alike
         visited.remove(&symbol_id);
-        visited.into_iter().collect()
+        let mut impacted: Vec<SymbolId> = visited.into_iter().collect();
+        impacted.sort_by_key(|id| id.0);
+        impacted
+    }
+
+    /// Get what a change to a symbol affects: its callers, implementors,
+    /// subtypes and users, transitively up to `max_depth` edges away,
+    /// grouped by distance with the files they are in. Each symbol is
+    /// reported once, at the distance it is first reached.
+    pub fn get_change_impact(&self, symbol_id: SymbolId, max_depth: usize) -> Option<ChangeImpact> {
+        let symbol = self.get_symbol(symbol_id)?;
+        let mut visited = HashSet::from([symbol_id]);
+        let mut seen_files = HashSet::from([symbol.file_path.to_string()]);
+        let mut frontier = vec![symbol_id];
+        let mut levels = Vec::new();
+
+        for distance in 1..=max_depth {
+            let mut symbols = Vec::new();
+            for &current_id in &frontier {
+                for relation in [
+                    RelationKind::Calls,
+                    RelationKind::Implements,
+                    RelationKind::Extends,
+                    RelationKind::Uses,
+                ] {
+                    let relationships = self
+                        .document_index
+                        .get_relationships_to(current_id, relation)
+                        .unwrap_or_default();
+                    for (from_id, _, _) in relationships {
+                        if !visited.insert(from_id) {
+                            continue;
+                        }
+                        if let Some(dependent) = self.get_symbol(from_id) {
+                            symbols.push(ImpactEntry {
+                                symbol: dependent,
+                                relation,
+                                via: current_id,
+                            });
+                        }
+                    }
+                }
+            }
+            if symbols.is_empty() {
+                break;
+            }
+
+            let mut files: Vec<String> = symbols
+                .iter()
+                .map(|entry| entry.symbol.file_path.to_string())
+                .filter(|file| seen_files.insert(file.clone()))
+                .collect();
+            files.sort();
+            frontier = symbols.iter().map(|entry| entry.symbol.id).collect();
+            levels.push(ImpactLevel {
+                distance,
+                symbols,
+                files,
+            });
+        }
+
+        Some(ChangeImpact { symbol, levels })
     }
 
     // =========================================================================
@@ -862,6 +1449,91 @@ impl IndexFacade {
             .map_err(Into::into)
     }
 
+    /// Search in `mode`: full-text search, or fuzzy or regex matching of
+    /// symbol names, best match first.
+    pub fn search_with_mode(
+        &self,
+        query: &str,
+        mode: SearchMode,
+        limit: usize,
+        kind_filter: Option<SymbolKind>,
+        module_filter: Option<&str>,
+        language_filter: Option<&str>,
+    ) -> FacadeResult<Vec<SearchResult>> {
+        let matcher = match mode {
+            SearchMode::Text => {
+                return self.search(query, limit, kind_filter, module_filter, language_filter);
+            }
+            SearchMode::Fuzzy => NameMatcher::fuzzy(query),
+            SearchMode::Regex => NameMatcher::regex(query)
+                .map_err(|e| IndexError::General(format!("Invalid regex '{query}': {e}")))?,
+        };
+
+        let mut scored: Vec<(Symbol, f32)> = self
+            .get_all_symbols()
+            .into_iter()
+            .filter(|symbol| {
+                kind_filter.is_none_or(|kind| symbol.kind == kind)
+                    && module_filter.is_none_or(|module| {
+                        symbol
+                            .module_path
+                            .as_deref()
+                            .is_some_and(|path| path.starts_with(module))
+                    })
+                    && language_filter.is_none_or(|language| {
+                        symbol.language_id.map(|id| id.as_str()) == Some(language)
+                    })
+            })
+            .filter_map(|symbol| {
+                let score = matcher.score(&symbol.name)?;
+                Some((symbol, score))
+            })
+            .collect();
+        scored.sort_by(|(a, a_score), (b, b_score)| {
+            b_score
+                .total_cmp(a_score)
+                .then(a.name.len().cmp(&b.name.len()))
+                .then_with(|| a.name.cmp(&b.name))
+                .then(a.id.0.cmp(&b.id.0))
+        });
+        scored.truncate(limit);
+
+        Ok(scored
+            .into_iter()
+            .map(|(symbol, score)| SearchResult {
+                symbol_id: symbol.id,
+                name: symbol.name.to_string(),
+                kind: symbol.kind,
+                file_path: symbol.file_path.to_string(),
+                line: symbol.range.start_line + 1,
+                column: symbol.range.start_column,
+                doc_comment: symbol.doc_comment.as_deref().map(str::to_string),
+                signature: symbol.signature.as_deref().map(str::to_string),
+                module_path: symbol
+                    .module_path
+                    .as_deref()
+                    .unwrap_or_default()
+                    .to_string(),
+                language_id: symbol.language_id.map(|id| id.as_str().to_string()),
+                score,
+                highlights: Vec::new(),
+                context: None,
+            })
+            .collect())
+    }
+
+    /// Symbols matching every term of a parsed query language query; see
+    /// `crate::symbol::dsl`.
+    pub fn query_symbols(
+        &self,
+        query: &SymbolQuery,
+        limit: usize,
+    ) -> FacadeResult<Vec<SearchResult>> {
+        self.document_index
+            .query_symbols(query, limit)
+            .map_err(Into::into)
+    }
+
     /// Semantic search using doc comment embeddings.
     pub fn semantic_search_docs(
         &self,
@@ -871,36 +1543,83 @@ impl IndexFacade {
         self.semantic_search_docs_with_language(query, limit, None)
     }
 
-    /// Semantic search with language filter.
+    /// Semantic search with language filter. With `semantic_search.hybrid`,
+    /// the doc comment ranking is fused with a full-text search.
     pub fn semantic_search_docs_with_language(
         &self,
         query: &str,
         limit: usize,
         language_filter: Option<&str>,
     ) -> FacadeResult<Vec<(Symbol, f32)>> {
-        let semantic = self
-            .semantic_search
-            .as_ref()
-            .ok_or(IndexError::SemanticSearchNotEnabled)?;
-
-        let sem = semantic.lock().map_err(|_| IndexError::lock_error())?;
+        self.semantic_search_in(
+            query,
+            limit,
+            &SemanticFilter::language(language_filter),
+            None,
+        )
+    }
 
-        // When the semantic search has no local model (built with remote embeddings),
-        // generate the query vector via the embedding backend regardless of whether
-        // the backend is currently remote or local — the pool just needs to produce
-        // a vector of the right dimension.
-        let results = if sem.has_local_model() {
-            sem.search_with_language(query, limit, language_filter)?
+    /// Semantic search over the doc comment embeddings, the code embeddings
+    /// or both, ranking only the symbols `filter` accepts. `None` compares
+    /// both when `semantic_search.code_embeddings` is on, and the doc
+    /// comments otherwise.
+    pub fn semantic_search_in(
+        &self,
+        query: &str,
+        limit: usize,
+        filter: &SemanticFilter,
+        vectors: Option<SemanticVectors>,
+    ) -> FacadeResult<Vec<(Symbol, f32)>> {
+        let cfg = &self.settings.semantic_search;
+        let language_filter = filter.language.as_deref();
+        // Marked results from just past the limit can move into it
+        let feedback = self.relevance_feedback();
+        let wanted = limit;
+        let limit = if feedback.applies_to(query) {
+            limit.saturating_mul(2)
         } else {
-            let pool = self.embedding_pool.as_ref().ok_or_else(|| {
-                IndexError::General(
-                    "Remote-mode index requires an embedding backend for queries. \
-                     Set CODANNA_EMBED_URL or re-index with a local model."
-                        .to_string(),
-                )
-            })?;
-            let query_vec = pool.embed_one(query)?;
-            sem.search_with_embedding_and_language(&query_vec, limit, language_filter)?
+            limit
+        };
+        // The semantic index only knows languages; the other filters pick
+        // the symbols to rank from the document index
+        let allowed: Option<HashSet<SymbolId>> = filter.needs_symbols().then(|| {
+            self.get_all_symbols()
+                .into_iter()
+                .filter(|symbol| filter.accepts(symbol))
+                .map(|symbol| symbol.id)
+                .collect()
+        });
+        let allowed = allowed.as_ref();
+        let vectors = vectors.unwrap_or(if cfg.code_embeddings {
+            SemanticVectors::Both
+        } else {
+            SemanticVectors::Docs
+        });
+        let results = if cfg.hybrid {
+            // Draw from deeper in both rankings, as fusion reorders them
+            let candidates = limit.saturating_mul(3);
+            let vector =
+                self.vector_search(query, candidates, language_filter, allowed, vectors)?;
+            let lexical: Vec<(SymbolId, f32)> = self
+                .search(query, candidates, filter.kind, None, language_filter)
+                .unwrap_or_else(|e| {
+                    tracing::warn!(target: "facade", "hybrid search lexical side failed: {e}");
+                    Vec::new()
+                })
+                .into_iter()
+                .filter(|result| allowed.is_none_or(|allowed| allowed.contains(&result.symbol_id)))
+                .map(|result| (result.symbol_id, result.score))
+                .collect();
+            let mut fused = crate::semantic::hybrid::fuse(
+                &vector,
+                &lexical,
+                cfg.hybrid_fusion,
+                cfg.hybrid_weight,
+            );
+            fused.truncate(limit);
+            fused
+        } else {
+            self.vector_search(query, limit, language_filter, allowed, vectors)?
         };
 
         let mut symbols = Vec::new();
@@ -909,29 +1628,229 @@ impl IndexFacade {
                 symbols.push((symbol, score));
             }
         }
+        feedback.rerank(query, &mut symbols, |symbol| {
+            (&*symbol.name, &*symbol.file_path)
+        });
+        symbols.truncate(wanted);
 
         Ok(symbols)
     }
 
-    /// Semantic search with score threshold.
-    pub fn semantic_search_docs_with_threshold(
+    /// The relevance feedback of the index, see [`crate::feedback`].
+    pub fn relevance_feedback(&self) -> RelevanceFeedback {
+        RelevanceFeedback::load(&feedback_path(&self.settings))
+    }
+
+    /// Mark the symbols `symbol_ids` as relevant or irrelevant results of
+    /// `query`, for later searches to rank by. Returns the marked symbols.
+    pub fn record_feedback(
         &self,
         query: &str,
-        limit: usize,
-        threshold: f32,
-    ) -> FacadeResult<Vec<(Symbol, f32)>> {
-        self.semantic_search_docs_with_threshold_and_language(query, limit, threshold, None)
+        symbol_ids: &[SymbolId],
+        relevant: bool,
+    ) -> FacadeResult<Vec<Symbol>> {
+        let symbols = symbol_ids
+            .iter()
+            .map(|&id| {
+                self.get_symbol(id)
+                    .ok_or_else(|| IndexError::SymbolNotFound {
+                        name: format!("symbol_id:{}", id.value()),
+                    })
+            })
+            .collect::<Result<Vec<_>, _>>()?;
+        let path = feedback_path(&self.settings);
+        let mut feedback = RelevanceFeedback::load(&path);
+        let at = crate::indexing::get_utc_timestamp();
+        for symbol in &symbols {
+            feedback.record(query, &symbol.name, &symbol.file_path, relevant, at);
+        }
+        feedback.save(&path)?;
+        Ok(symbols)
     }
 
-    /// Semantic search with threshold and language filter.
-    pub fn semantic_search_docs_with_threshold_and_language(
+    /// Embeddings of `vectors` most similar to `query`, best first.
+    fn vector_search(
         &self,
         query: &str,
         limit: usize,
-        threshold: f32,
         language_filter: Option<&str>,
-    ) -> FacadeResult<Vec<(Symbol, f32)>> {
-        let results = self.semantic_search_docs_with_language(query, limit, language_filter)?;
+        allowed: Option<&HashSet<SymbolId>>,
+        vectors: SemanticVectors,
+    ) -> FacadeResult<Vec<(SymbolId, f32)>> {
+        let semantic = self
+            .semantic_search
+            .as_ref()
+            .ok_or(IndexError::SemanticSearchNotEnabled)?;
+
+        let sem = semantic.lock().map_err(|_| IndexError::lock_error())?;
+        let query_vec = self.embed_query_with(&sem, query)?;
+
+        Ok(sem.search_vectors_weighted(
+            &query_vec,
+            limit,
+            language_filter,
+            allowed,
+            vectors,
+            self.code_weight_of(),
+        )?)
+    }
+
+    /// The code share of the score of a symbol: the code weight of semantic
+    /// search, unless an `[overrides]` glob of its file sets another
+    fn code_weight_of(&self) -> impl Fn(SymbolId) -> f32 + '_ {
+        let code_weight = self.settings.semantic_search.code_weight;
+        // Symbols are looked up only when a glob sets a code weight
+        let overrides = self
+            .settings
+            .overrides
+            .values()
+            .any(|values| values.code_weight.is_some())
+            .then(|| PathOverrides::new(&self.settings).unwrap_or_default());
+        move |id| {
+            overrides
+                .as_ref()
+                .and_then(|overrides| {
+                    let symbol = self.get_symbol(id)?;
+                    overrides
+                        .for_path(Path::new(&*symbol.file_path))
+                        .code_weight
+                })
+                .unwrap_or(code_weight)
+        }
+    }
+
+    /// Embed `query` for a search of `sem`.
+    fn embed_query_with(&self, sem: &SimpleSemanticSearch, query: &str) -> FacadeResult<Vec<f32>> {
+        // When the semantic search has no local model (built with remote embeddings),
+        // generate the query vector via the embedding backend regardless of whether
+        // the backend is currently remote or local — the pool just needs to produce
+        // a vector of the right dimension.
+        if sem.has_local_model() {
+            Ok(sem.embed_query(query)?)
+        } else {
+            let pool = self.embedding_pool.as_ref().ok_or_else(|| {
+                IndexError::General(
+                    "Remote-mode index requires an embedding backend for queries. \
+                     Set CODANNA_EMBED_URL or re-index with a local model."
+                        .to_string(),
+                )
+            })?;
+            Ok(pool.embed_one(query)?)
+        }
+    }
+
+    /// Symbols most similar to `symbol_id`, best first, without it: its
+    /// doc comment compared with theirs or, when it has none, its code
+    /// (kind, name and signature). The stored embedding of the symbol is
+    /// used, or one computed when it has none.
+    pub fn similar_symbols(
+        &self,
+        symbol_id: SymbolId,
+        limit: usize,
+        filter: &SemanticFilter,
+    ) -> FacadeResult<Vec<(Symbol, f32)>> {
+        let symbol = self
+            .get_symbol(symbol_id)
+            .ok_or_else(|| IndexError::SymbolNotFound {
+                name: format!("symbol_id:{}", symbol_id.value()),
+            })?;
+        let cfg = &self.settings.semantic_search;
+        let semantic = self
+            .semantic_search
+            .as_ref()
+            .ok_or(IndexError::SemanticSearchNotEnabled)?;
+        let sem = semantic.lock().map_err(|_| IndexError::lock_error())?;
+
+        let (query_vec, vectors) = if let Some(doc) = symbol.doc_comment.as_deref() {
+            let query_vec = match sem.embedding_of(symbol_id, false) {
+                Some(embedding) => embedding,
+                None => self.embed_query_with(&sem, doc)?,
+            };
+            let vectors = if cfg.code_embeddings {
+                SemanticVectors::Both
+            } else {
+                SemanticVectors::Docs
+            };
+            (query_vec, vectors)
+        } else if let Some(signature) = symbol.signature.as_deref() {
+            let query_vec = match sem.embedding_of(symbol_id, true) {
+                Some(embedding) => embedding,
+                // Same text as the code embeddings of indexing
+                None => self.embed_query_with(
+                    &sem,
+                    &format!("{:?} {}\n{signature}", symbol.kind, symbol.name),
+                )?,
+            };
+            let vectors = if sem.code_embedding_count() > 0 {
+                SemanticVectors::Code
+            } else {
+                SemanticVectors::Docs
+            };
+            (query_vec, vectors)
+        } else {
+            return Err(IndexError::General(format!(
+                "'{}' has no doc comment or signature to compare",
+                symbol.name
+            )));
+        };
+
+        let allowed: Option<HashSet<SymbolId>> = filter.needs_symbols().then(|| {
+            self.get_all_symbols()
+                .into_iter()
+                .filter(|candidate| filter.accepts(candidate))
+                .map(|candidate| candidate.id)
+                .collect()
+        });
+        let ranked = sem.search_vectors_weighted(
+            &query_vec,
+            limit.saturating_add(1),
+            filter.language.as_deref(),
+            allowed.as_ref(),
+            vectors,
+            self.code_weight_of(),
+        )?;
+        drop(sem);
+
+        Ok(ranked
+            .into_iter()
+            .filter(|(id, _)| *id != symbol_id)
+            .take(limit)
+            .filter_map(|(id, score)| Some((self.get_symbol(id)?, score)))
+            .collect())
+    }
+
+    /// Every doc comment embedding, with its symbol.
+    pub fn semantic_embeddings(&self) -> FacadeResult<Vec<(SymbolId, Vec<f32>)>> {
+        let semantic = self
+            .semantic_search
+            .as_ref()
+            .ok_or(IndexError::SemanticSearchNotEnabled)?;
+        let sem = semantic.lock().map_err(|_| IndexError::lock_error())?;
+        Ok(sem
+            .embeddings()
+            .map(|(id, embedding)| (id, embedding.to_vec()))
+            .collect())
+    }
+
+    /// Semantic search with score threshold.
+    pub fn semantic_search_docs_with_threshold(
+        &self,
+        query: &str,
+        limit: usize,
+        threshold: f32,
+    ) -> FacadeResult<Vec<(Symbol, f32)>> {
+        self.semantic_search_docs_with_threshold_and_language(query, limit, threshold, None)
+    }
+
+    /// Semantic search with threshold and language filter.
+    pub fn semantic_search_docs_with_threshold_and_language(
+        &self,
+        query: &str,
+        limit: usize,
+        threshold: f32,
+        language_filter: Option<&str>,
+    ) -> FacadeResult<Vec<(Symbol, f32)>> {
+        let results = self.semantic_search_docs_with_language(query, limit, language_filter)?;
 
         Ok(results
             .into_iter()
@@ -939,6 +1858,78 @@ impl IndexFacade {
             .collect())
     }
 
+    /// Semantic search for `semantic_search_with_context`, over the symbols
+    /// `filter` accepts. With `semantic_search.rerank`, the top
+    /// `rerank_candidates` hits above `threshold` are re-ranked by a
+    /// cross-encoder, and scored by it.
+    pub fn semantic_search_docs_reranked(
+        &self,
+        query: &str,
+        limit: usize,
+        threshold: Option<f32>,
+        filter: &SemanticFilter,
+    ) -> FacadeResult<Vec<(Symbol, f32)>> {
+        let cfg = &self.settings.semantic_search;
+        let reranker = if cfg.rerank {
+            self.reranker
+                .get_or_init(|| match Reranker::new(&cfg.rerank_model) {
+                    Ok(reranker) => Some(reranker),
+                    Err(e) => {
+                        tracing::warn!(target: "facade", "re-ranking disabled: {e}");
+                        None
+                    }
+                })
+                .as_ref()
+        } else {
+            None
+        };
+        let candidates = match reranker {
+            Some(_) => cfg.rerank_candidates.max(limit),
+            None => limit,
+        };
+
+        let mut results = self.semantic_search_in(query, candidates, filter, None)?;
+        if let Some(threshold) = threshold {
+            results.retain(|(_, score)| *score >= threshold);
+        }
+        let Some(reranker) = reranker else {
+            return Ok(results);
+        };
+
+        let documents: Vec<String> = results
+            .iter()
+            .map(|(symbol, _)| {
+                let mut text = symbol.name.to_string();
+                if let Some(signature) = &symbol.signature {
+                    text.push('\n');
+                    text.push_str(signature);
+                }
+                if let Some(doc) = &symbol.doc_comment {
+                    text.push('\n');
+                    text.push_str(doc);
+                }
+                text
+            })
+            .collect();
+        let documents: Vec<&str> = documents.iter().map(String::as_str).collect();
+        match reranker.scores(query, &documents) {
+            Ok(scores) => {
+                for ((_, score), rerank) in results.iter_mut().zip(scores) {
+                    *score = rerank;
+                }
+                results.sort_by(|a, b| b.1.total_cmp(&a.1));
+                // The cross-encoder scores replaced the boosted ones
+                self.relevance_feedback()
+                    .rerank(query, &mut results, |symbol| {
+                        (&*symbol.name, &*symbol.file_path)
+                    });
+            }
+            Err(e) => tracing::warn!(target: "facade", "re-ranking failed: {e}"),
+        }
+        results.truncate(limit);
+        Ok(results)
+    }
+
     // =========================================================================
     // File Operations
     // =========================================================================
@@ -963,6 +1954,38 @@ impl IndexFacade {
             .map(|p| self.document_index.to_portable_file_path(&p).unwrap_or(p))
     }
 
+    /// Get the imports recorded for a file, unresolved.
+    pub fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.document_index
+            .get_imports_for_file(file_id)
+            .unwrap_or_default()
+    }
+
+    /// Get every indexed todo comment, in file and line order.
+    pub fn get_todos(&self) -> Vec<crate::parsing::todo::Todo> {
+        self.document_index.get_todos().unwrap_or_else(|e| {
+            tracing::warn!(target: "facade", "get_todos error: {e}");
+            Vec::new()
+        })
+    }
+
+    /// Get every indexed string literal, in file and position order.
+    pub fn get_strings(&self) -> Vec<crate::parsing::strings::IndexedString> {
+        self.document_index.get_strings().unwrap_or_else(|e| {
+            tracing::warn!(target: "facade", "get_strings error: {e}");
+            Vec::new()
+        })
+    }
+
+    /// Get every kept reference to a name no symbol of the index has, in
+    /// file and position order (kept by shard indexes only).
+    pub fn get_unresolved(&self) -> Vec<crate::relationship::UnresolvedReference> {
+        self.document_index.get_unresolved().unwrap_or_else(|e| {
+            tracing::warn!(target: "facade", "get_unresolved error: {e}");
+            Vec::new()
+        })
+    }
+
     /// Get all indexed file paths.
     pub fn get_all_indexed_paths(&self) -> Vec<PathBuf> {
         self.document_index
@@ -970,6 +1993,63 @@ impl IndexFacade {
             .unwrap_or_default()
     }
 
+    /// Get every indexed file with its path in the emitted contract shape,
+    /// sorted by that path.
+    pub fn get_indexed_files(&self) -> Vec<(FileId, String)> {
+        let mut files: Vec<(FileId, String)> = self
+            .get_all_indexed_paths()
+            .iter()
+            .filter_map(|path| {
+                let stored = path.to_string_lossy();
+                let file_id = self.get_file_id_for_path(&stored)?;
+                let shown = self
+                    .document_index
+                    .to_portable_file_path(&stored)
+                    .unwrap_or_else(|| stored.into_owned());
+                Some((file_id, shown))
+            })
+            .collect();
+        files.sort_by(|a, b| a.1.cmp(&b.1));
+        files
+    }
+
+    /// Get the indexed files `path` names: the one with exactly that path,
+    /// stored or emitted, or else every file whose emitted path ends with
+    /// it, from a `/` on.
+    pub fn find_indexed_files(&self, path: &str) -> Vec<(FileId, String)> {
+        let path = path.trim_start_matches("./");
+        let files = self.get_indexed_files();
+        if let Some(file) = files.iter().find(|(file_id, shown)| {
+            shown == path
+                || self
+                    .document_index
+                    .get_file_path(*file_id)
+                    .ok()
+                    .flatten()
+                    .is_some_and(|stored| stored == path)
+        }) {
+            return vec![file.clone()];
+        }
+        let suffix = format!("/{path}");
+        files
+            .into_iter()
+            .filter(|(_, shown)| shown.ends_with(&suffix))
+            .collect()
+    }
+
+    /// Get the symbols of a file in the order they appear in it.
+    pub fn get_file_outline(&self, file_id: FileId) -> Vec<Symbol> {
+        let mut symbols = self.get_symbols_by_file(file_id);
+        symbols.sort_by_key(|symbol| {
+            (
+                symbol.range.start_line,
+                symbol.range.start_column,
+                std::cmp::Reverse(symbol.range.end_line),
+            )
+        });
+        symbols
+    }
+
     // =========================================================================
     // Statistics Methods
     // =========================================================================
@@ -994,6 +2074,25 @@ impl IndexFacade {
         self.document_index.document_count().map_err(Into::into)
     }
 
+    /// Compact the document index, then drop the embeddings whose symbol
+    /// is gone. Returns what the index dropped and the number of
+    /// embeddings removed; saving the facade rewrites the vector file.
+    pub fn compact(&self) -> FacadeResult<(CompactStats, usize)> {
+        let stats = self.document_index.compact()?;
+        let Some(semantic) = self.semantic_search.as_ref() else {
+            return Ok((stats, 0));
+        };
+        let mut sem = semantic.lock().map_err(|_| IndexError::lock_error())?;
+        let mut stale = Vec::new();
+        for (id, _) in sem.embeddings() {
+            if self.document_index.find_symbol_by_id(id)?.is_none() {
+                stale.push(id);
+            }
+        }
+        sem.remove_embeddings(&stale);
+        Ok((stats, stale.len()))
+    }
+
     // =========================================================================
     // Directory Tracking
     // =========================================================================
@@ -1035,7 +2134,8 @@ impl IndexFacade {
 
     /// Index a single file using the parallel pipeline.
     ///
-    /// Returns `IndexingResult::Indexed` with the file ID on success.
+    /// Returns `IndexingResult::Indexed` with the file ID on success, or
+    /// `Cached` when the file is unchanged since it was indexed.
     /// File records key off path text: an uncanonical root or file path
     /// (`./src`, `x/../x`) addresses a key space disjoint from the
     /// registered indexed_paths walks, re-indexing every file as new and
@@ -1063,6 +2163,9 @@ impl IndexFacade {
             self.embedding_pool.clone(),
         )?;
 
+        if stats.cached {
+            return Ok(crate::IndexingResult::Cached(stats.file_id));
+        }
         Ok(crate::IndexingResult::Indexed(stats.file_id))
     }
 
@@ -1086,6 +2189,23 @@ impl IndexFacade {
         self.index_file(path)
     }
 
+    /// Store relationships resolved outside the pipeline, such as those
+    /// imported from an LSIF dump, in one batch.
+    pub fn add_relationships(&mut self, edges: &[RelationshipEdge]) -> FacadeResult<()> {
+        self.document_index.start_batch()?;
+        for edge in edges {
+            if let Err(e) =
+                self.document_index
+                    .store_relationship(edge.source, edge.target, &edge.relationship)
+            {
+                let _ = self.document_index.rollback_batch();
+                return Err(e.into());
+            }
+        }
+        self.document_index.commit_batch()?;
+        Ok(())
+    }
+
     /// Remove a file from the index.
     ///
     /// Uses the Pipeline's cleanup stage to remove symbols and embeddings.
@@ -1110,16 +2230,12 @@ impl IndexFacade {
     /// This is the primary indexing entry point using Pipeline.
     pub fn index_directory(&mut self, path: &Path, force: bool) -> FacadeResult<IndexingStats> {
         let path = &Self::canonical_or_raw(path);
-        if self.has_semantic_search() {
-            if let Err(e) = self.ensure_embedding_pool() {
-                tracing::warn!("Failed to initialize embedding pool: {e}");
-            }
-        }
+        let pool = self.directory_embedding_pool();
         let stats = self.pipeline.index_incremental(
             path,
             Arc::clone(&self.document_index),
             self.semantic_search.clone(),
-            self.embedding_pool.clone(),
+            pool,
             force,
         )?;
 
@@ -1153,6 +2269,14 @@ impl IndexFacade {
         use crate::indexing::progress::IndexStats;
 
         let dir = &Self::canonical_or_raw(dir.as_ref());
+        let _span = tracing::info_span!(
+            target: "pipeline",
+            "index_directory",
+            path = %dir.display(),
+            force,
+            dry_run
+        )
+        .entered();
         let walker = FileWalker::new(Arc::clone(&self.settings));
         let files: Vec<_> = walker.walk(dir).collect();
 
@@ -1185,11 +2309,7 @@ impl IndexFacade {
         // Auto-force mode for empty indexes (clean index behaves like --force)
         let force = force || self.document_count().unwrap_or(0) == 0;
 
-        if self.has_semantic_search() {
-            if let Err(e) = self.ensure_embedding_pool() {
-                tracing::warn!("Failed to initialize embedding pool: {e}");
-            }
-        }
+        let pool = self.directory_embedding_pool();
 
         // Use Pipeline for indexing with progress flag
         // The pipeline manages progress bars internally for clean sequential display
@@ -1197,7 +2317,7 @@ impl IndexFacade {
             dir,
             Arc::clone(&self.document_index),
             self.semantic_search.clone(),
-            self.embedding_pool.clone(),
+            pool,
             force,
             progress && total_files > 0,
             total_files,
@@ -1206,15 +2326,64 @@ impl IndexFacade {
         // Update tracked paths
         self.add_indexed_path(dir);
 
-        // Convert to IndexStats format using pipeline's actual timing
-        let mut stats = IndexStats::default();
+        Ok(Self::incremental_index_stats(&pipeline_stats))
+    }
+
+    /// Index only what changed in a directory since a git revision.
+    ///
+    /// Git lists the changed files instead of a walk and hash of the whole
+    /// directory. An empty index has nothing to compare against, so it is
+    /// indexed in full.
+    pub fn index_directory_since(
+        &mut self,
+        dir: impl AsRef<Path>,
+        since: &str,
+        progress: bool,
+    ) -> crate::IndexResult<crate::indexing::progress::IndexStats> {
+        let dir = &Self::canonical_or_raw(dir.as_ref());
+        if self.document_count().unwrap_or(0) == 0 {
+            return self.index_directory_with_options(dir, progress, false, true, None);
+        }
+        let _span = tracing::info_span!(
+            target: "pipeline",
+            "index_since",
+            path = %dir.display(),
+            since
+        )
+        .entered();
+
+        let pool = self.directory_embedding_pool();
+
+        let pipeline_stats = self.pipeline.index_changed_since(
+            dir,
+            since,
+            Arc::clone(&self.document_index),
+            self.semantic_search.clone(),
+            pool,
+            progress,
+        )?;
+
+        self.add_indexed_path(dir);
+
+        Ok(Self::incremental_index_stats(&pipeline_stats))
+    }
+
+    /// Convert pipeline stats to IndexStats format, using the pipeline's
+    /// actual timing
+    fn incremental_index_stats(
+        pipeline_stats: &crate::indexing::pipeline::IncrementalStats,
+    ) -> crate::indexing::progress::IndexStats {
+        let mut stats = crate::indexing::progress::IndexStats::default();
         stats.files_indexed = pipeline_stats.new_files + pipeline_stats.modified_files;
+        stats.files_failed = pipeline_stats.index_stats.files_failed;
+        stats.files_skipped = pipeline_stats.index_stats.files_skipped.clone();
+        stats.diagnostics = pipeline_stats.index_stats.diagnostics.clone();
+        stats.foreign_doc_files = pipeline_stats.index_stats.foreign_doc_files.clone();
         stats.symbols_found = pipeline_stats.index_stats.symbols_found;
         stats.files_removed = pipeline_stats.deleted_files;
         stats.symbols_removed = pipeline_stats.deleted_symbols;
         stats.elapsed = pipeline_stats.elapsed;
-
-        Ok(stats)
+        stats
     }
 
     /// Sync with configuration (compare stored vs config paths).
@@ -1236,11 +2405,11 @@ impl IndexFacade {
 
         let mut stats = SyncStats::default();
 
-        if self.has_semantic_search() && !to_add.is_empty() {
-            if let Err(e) = self.ensure_embedding_pool() {
-                tracing::warn!("Failed to initialize embedding pool: {e}");
-            }
-        }
+        let pool = if to_add.is_empty() {
+            None
+        } else {
+            self.directory_embedding_pool()
+        };
 
         // Index new directories with progress if enabled
         // Use force=true since these are new directories being indexed for the first time
@@ -1262,7 +2431,7 @@ impl IndexFacade {
                 path,
                 Arc::clone(&self.document_index),
                 self.semantic_search.clone(),
-                self.embedding_pool.clone(),
+                pool.clone(),
                 true, // force: new directories should be fully indexed
                 progress,
                 file_count,
@@ -1305,9 +2474,21 @@ pub fn resolve_remote_model_name(cfg: &crate::config::SemanticSearchConfig) -> S
         .unwrap_or_else(|| "text-embedding-ada-002".to_string())
 }
 
+/// The name the configured model is recorded under in semantic metadata:
+/// the remote model name, or the canonical name of the local one.
+pub fn configured_model_name(cfg: &crate::config::SemanticSearchConfig) -> String {
+    let is_remote = cfg.is_remote();
+    if is_remote {
+        return resolve_remote_model_name(cfg);
+    }
+    crate::vector::parse_embedding_model(&cfg.model)
+        .map(|model| crate::vector::model_to_string(&model))
+        .unwrap_or_else(|_| cfg.model.clone())
+}
+
 /// Format a human-readable semantic search status line for CLI output.
 pub fn format_semantic_status(cfg: &crate::config::SemanticSearchConfig) -> String {
-    let is_remote = std::env::var("CODANNA_EMBED_URL").is_ok() || cfg.remote_url.is_some();
+    let is_remote = cfg.is_remote();
     let threshold = cfg.threshold;
 
     if is_remote {
@@ -1319,15 +2500,40 @@ pub fn format_semantic_status(cfg: &crate::config::SemanticSearchConfig) -> Stri
     }
 }
 
+/// Embedding backends of the loaded indexes, by their semantic search settings
+static EMBEDDING_BACKENDS: LazyLock<Mutex<HashMap<String, Weak<EmbeddingBackend>>>> =
+    LazyLock::new(|| Mutex::new(HashMap::new()));
+
+/// The backend `cfg` configures, built unless a facade loaded earlier with
+/// the same settings still holds one. A server of several projects
+/// embeds with one backend per model instead of one per project.
+fn shared_embedding_backend(
+    cfg: &crate::config::SemanticSearchConfig,
+) -> FacadeResult<Arc<EmbeddingBackend>> {
+    let key = serde_json::to_string(cfg).unwrap_or_default();
+    let mut backends = EMBEDDING_BACKENDS
+        .lock()
+        .map_err(|_| IndexError::lock_error())?;
+    if let Some(backend) = backends.get(&key).and_then(Weak::upgrade) {
+        return Ok(backend);
+    }
+    let backend = Arc::new(build_embedding_backend(cfg)?);
+    backends.insert(key, Arc::downgrade(&backend));
+    Ok(backend)
+}
+
 pub fn build_embedding_backend(
     cfg: &crate::config::SemanticSearchConfig,
 ) -> FacadeResult<EmbeddingBackend> {
-    // Env vars override config file
-    let remote_url = std::env::var("CODANNA_EMBED_URL")
-        .ok()
-        .or_else(|| cfg.remote_url.clone());
-
-    if let Some(url) = remote_url {
+    if cfg.is_remote() {
+        // Env vars override config file
+        let url = cfg.remote_endpoint().ok_or_else(|| {
+            IndexError::General(
+                "semantic_search.provider = \"http\" needs semantic_search.remote_url \
+                 (e.g. http://localhost:11434 for Ollama)"
+                    .to_string(),
+            )
+        })?;
         let model = resolve_remote_model_name(cfg);
 
         let dim: Option<usize> = match std::env::var("CODANNA_EMBED_DIM") {
@@ -1356,13 +2562,17 @@ pub fn build_embedding_backend(
             if api_key.is_some() { "bearer" } else { "none" }
         );
 
+        let limits = RemoteLimits {
+            batch_size: cfg.remote_batch_size,
+            max_retries: cfg.remote_max_retries,
+            requests_per_minute: cfg.remote_requests_per_minute,
+        };
         let url_owned = url.clone();
         let model_owned = model.clone();
-        let embedder =
-            run_async(
-                async move { RemoteEmbedder::new(&url_owned, &model_owned, dim, api_key).await },
-            )
-            .map_err(|e| IndexError::General(format!("Remote embedder init failed: {e}")))?;
+        let embedder = run_async(async move {
+            RemoteEmbedder::new(&url_owned, &model_owned, dim, api_key, limits).await
+        })
+        .map_err(|e| IndexError::General(format!("Remote embedder init failed: {e}")))?;
 
         return Ok(EmbeddingBackend::Remote(Arc::new(embedder)));
     }
@@ -1371,12 +2581,56 @@ pub fn build_embedding_backend(
     let pool_size = cfg.embedding_threads;
     let embedding_model = crate::vector::parse_embedding_model(&cfg.model)
         .map_err(|e| IndexError::General(format!("Failed to parse embedding model: {e}")))?;
-    let pool = EmbeddingPool::new(pool_size, embedding_model)
-        .map_err(|e| IndexError::General(format!("Local embedding pool init failed: {e}")))?;
+    let pool =
+        EmbeddingPool::with_execution_provider(pool_size, embedding_model, cfg.execution_provider)
+            .map_err(|e| IndexError::General(format!("Local embedding pool init failed: {e}")))?;
 
     Ok(EmbeddingBackend::Local(pool))
 }
 
+/// The tree `entries` of a call graph walk from `root` form. In `outgoing`
+/// trees a node is called by its parent, otherwise it calls its parent.
+fn call_tree(root: &Symbol, entries: Vec<CallGraphEntry>, outgoing: bool) -> Vec<CallNode> {
+    let mut by_via: HashMap<SymbolId, Vec<CallGraphEntry>> = HashMap::new();
+    for entry in entries {
+        by_via.entry(entry.via).or_default().push(entry);
+    }
+
+    fn nodes(
+        parent: &Symbol,
+        by_via: &mut HashMap<SymbolId, Vec<CallGraphEntry>>,
+        outgoing: bool,
+    ) -> Vec<CallNode> {
+        let Some(entries) = by_via.remove(&parent.id) else {
+            return Vec::new();
+        };
+        entries
+            .into_iter()
+            .map(|entry| {
+                let caller = if outgoing { parent } else { &entry.symbol };
+                let call_site = entry
+                    .metadata
+                    .as_ref()
+                    .and_then(|m| m.line)
+                    .map(|line| format!("{}:{}", caller.file_path, line + 1));
+                let possible_target = entry
+                    .metadata
+                    .as_ref()
+                    .is_some_and(|m| m.is_possible_target());
+                let children = nodes(&entry.symbol, by_via, outgoing);
+                CallNode {
+                    symbol: entry.symbol,
+                    call_site,
+                    possible_target,
+                    children,
+                }
+            })
+            .collect()
+    }
+
+    nodes(root, &mut by_via, outgoing)
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -1401,6 +2655,34 @@ mod tests {
         assert!(result.is_err());
     }
 
+    #[test]
+    fn load_semantic_search_refuses_another_model() {
+        let dir = tempfile::tempdir().unwrap();
+        let semantic_path = dir.path().join("semantic");
+        SimpleSemanticSearch::new_empty(3, "other-model")
+            .save(&semantic_path)
+            .unwrap();
+
+        let settings = Settings {
+            index_path: dir.path().to_path_buf(),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        match facade.load_semantic_search(&semantic_path) {
+            Err(IndexError::SemanticSearch(SemanticSearchError::ModelChanged {
+                index,
+                configured,
+            })) => {
+                assert_eq!(index, "other-model");
+                assert_eq!(configured, "AllMiniLML6V2");
+            }
+            other => panic!("expected ModelChanged, got {other:?}"),
+        }
+        assert!(facade.is_semantic_incompatible());
+        assert!(!facade.has_semantic_search());
+    }
+
     // Regression: file records key off the walk root's textual form. An
     // uncanonical root used to address a key space disjoint from the
     // canonical indexed_paths walk, re-indexing every file as new and
@@ -1497,6 +2779,51 @@ mod tests {
         );
     }
 
+    #[test]
+    fn partial_parts_link_across_files() {
+        use crate::symbol::context::ContextIncludes;
+
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let form = dir.path().join("Form1.cs");
+        let designer = dir.path().join("Form1.Designer.cs");
+        let other = dir.path().join("Other.cs");
+        std::fs::write(
+            &form,
+            "namespace App {\n    public partial class Form1 {\n        void Load() { }\n    }\n}\n",
+        )
+        .unwrap();
+        std::fs::write(
+            &designer,
+            "namespace App {\n    partial class Form1 {\n        void InitializeComponent() { }\n    }\n}\n",
+        )
+        .unwrap();
+        std::fs::write(&other, "namespace App {\n    public class Form2 { }\n}\n").unwrap();
+
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        for file in [&form, &designer, &other] {
+            facade.index_file(file).unwrap();
+        }
+
+        let parts = facade.find_symbols_by_name("Form1", Some("csharp"));
+        assert_eq!(parts.len(), 2, "one symbol per file: {parts:?}");
+        let linked = facade.get_partial_parts(parts[0].id);
+        assert_eq!(linked.len(), 1);
+        assert_eq!(linked[0].id, parts[1].id);
+
+        let ctx = facade
+            .get_symbol_context(parts[1].id, ContextIncludes::IMPLEMENTATIONS)
+            .unwrap();
+        assert_eq!(ctx.relationships.parts.unwrap()[0].id, parts[0].id);
+
+        let form2 = facade.find_symbols_by_name("Form2", None).pop().unwrap();
+        assert!(facade.get_partial_parts(form2.id).is_empty());
+    }
+
     // Regression: get_all_symbols sampled the first 10k symbol docs and
     // consumers (get_index_info kind counts) presented the sample as
     // totals.
@@ -1599,4 +2926,274 @@ mod tests {
         let result = facade.index_file_with_force(&source, true);
         assert!(result.is_ok(), "force on unindexed file: {result:?}");
     }
+
+    #[test]
+    fn transitive_call_queries_stop_at_depth_and_cycles() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+
+        let source = dir.path().join("chain.py");
+        std::fs::write(
+            &source,
+            "def entry():\n    middle()\n\n\ndef middle():\n    leaf()\n\n\ndef leaf():\n    entry()\n",
+        )
+        .unwrap();
+
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+        let id = |name: &str| {
+            facade
+                .find_symbols_by_name(name, None)
+                .pop()
+                .expect("function indexed")
+                .id
+        };
+        let reached = |entries: Vec<CallGraphEntry>| -> Vec<(String, usize)> {
+            entries
+                .into_iter()
+                .map(|entry| (entry.symbol.name.to_string(), entry.depth))
+                .collect()
+        };
+
+        assert_eq!(
+            reached(facade.get_transitive_calls(id("entry"), 1)),
+            vec![("middle".to_string(), 1)]
+        );
+        // The cycle back to entry is not reported
+        assert_eq!(
+            reached(facade.get_transitive_calls(id("entry"), 5)),
+            vec![("middle".to_string(), 1), ("leaf".to_string(), 2)]
+        );
+        let callers = facade.get_transitive_callers(id("leaf"), 2);
+        assert_eq!(
+            reached(callers.clone()),
+            vec![("middle".to_string(), 1), ("entry".to_string(), 2)]
+        );
+        assert_eq!(callers[1].via, id("middle"));
+    }
+
+    #[test]
+    fn call_hierarchy_nests_both_directions() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+
+        let source = dir.path().join("chain.py");
+        std::fs::write(
+            &source,
+            "def entry():\n    middle()\n\n\ndef middle():\n    leaf()\n\n\ndef leaf():\n    pass\n",
+        )
+        .unwrap();
+
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+        let id = |name: &str| {
+            facade
+                .find_symbols_by_name(name, None)
+                .pop()
+                .expect("function indexed")
+                .id
+        };
+
+        let entry = facade.get_call_hierarchy(id("entry"), 3).unwrap();
+        assert!(entry.incoming.is_empty());
+        assert_eq!(entry.outgoing[0].symbol.name.as_ref(), "middle");
+        assert_eq!(
+            entry.outgoing[0].children[0].symbol.name.as_ref(),
+            "leaf",
+            "callees are nested under their caller"
+        );
+        assert_eq!(entry.len(), 2);
+
+        let middle = facade.get_call_hierarchy(id("middle"), 1).unwrap();
+        assert_eq!(middle.incoming[0].symbol.name.as_ref(), "entry");
+        assert_eq!(middle.outgoing[0].symbol.name.as_ref(), "leaf");
+        assert!(middle.outgoing[0].children.is_empty());
+    }
+
+    #[test]
+    fn type_hierarchy_follows_extends_both_ways() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+
+        let source = dir.path().join("shapes.py");
+        std::fs::write(
+            &source,
+            "class Shape:\n    pass\n\n\nclass Polygon(Shape):\n    pass\n\n\nclass Square(Polygon):\n    pass\n",
+        )
+        .unwrap();
+
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+        let id = |name: &str| {
+            facade
+                .find_symbols_by_name(name, None)
+                .pop()
+                .expect("class indexed")
+                .id
+        };
+
+        let polygon = facade.get_type_hierarchy(id("Polygon")).unwrap();
+        assert_eq!(polygon.supertypes.len(), 1);
+        assert_eq!(polygon.supertypes[0].symbol.name.as_ref(), "Shape");
+        assert_eq!(polygon.supertypes[0].relation, RelationKind::Extends);
+        assert_eq!(polygon.subtypes.len(), 1);
+        assert_eq!(polygon.subtypes[0].symbol.name.as_ref(), "Square");
+
+        let square = facade.get_type_hierarchy(id("Square")).unwrap();
+        assert_eq!(square.len(), 2);
+        assert_eq!(
+            square.supertypes[0].children[0].symbol.name.as_ref(),
+            "Shape",
+            "supertypes are transitive"
+        );
+        assert!(square.subtypes.is_empty());
+    }
+
+    #[test]
+    fn change_impact_groups_dependents_by_distance() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+
+        let source = dir.path().join("impact.py");
+        std::fs::write(
+            &source,
+            "class Shape:\n    pass\n\n\nclass Square(Shape):\n    pass\n\n\ndef base():\n    pass\n\n\ndef middle():\n    base()\n\n\ndef top():\n    middle()\n    base()\n",
+        )
+        .unwrap();
+
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+        let id = |name: &str| {
+            facade
+                .find_symbols_by_name(name, None)
+                .pop()
+                .expect("symbol indexed")
+                .id
+        };
+        let levels = |impact: &ChangeImpact| -> Vec<Vec<String>> {
+            impact
+                .levels
+                .iter()
+                .map(|level| {
+                    let mut names: Vec<String> = level
+                        .symbols
+                        .iter()
+                        .map(|entry| entry.symbol.name.to_string())
+                        .collect();
+                    names.sort();
+                    names
+                })
+                .collect()
+        };
+
+        let impact = facade.get_change_impact(id("middle"), 5).unwrap();
+        assert_eq!(levels(&impact), vec![vec!["top".to_string()]]);
+        assert_eq!(impact.levels[0].symbols[0].relation, RelationKind::Calls);
+        assert_eq!(impact.levels[0].symbols[0].via, id("middle"));
+        assert_eq!(impact.files().count(), 0, "callers share the file");
+
+        // top calls base directly, so it is not reported again through middle
+        let impact = facade.get_change_impact(id("base"), 5).unwrap();
+        assert_eq!(impact.len(), 2);
+        assert_eq!(
+            levels(&impact),
+            vec![vec!["middle".to_string(), "top".to_string()]]
+        );
+
+        let impact = facade.get_change_impact(id("Shape"), 1).unwrap();
+        assert_eq!(levels(&impact), vec![vec!["Square".to_string()]]);
+        assert_eq!(impact.levels[0].symbols[0].relation, RelationKind::Extends);
+        assert!(facade.get_change_impact(id("top"), 3).unwrap().is_empty());
+    }
+
+    #[test]
+    fn query_symbols_intersects_fields_and_relationships() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("shapes.py");
+        std::fs::write(
+            &source,
+            "def make():\n    pass\n\n\ndef area():\n    return make()\n\n\nclass Shape:\n    pass\n",
+        )
+        .unwrap();
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+
+        let names = |query: &str| -> Vec<String> {
+            let query = SymbolQuery::parse(query).unwrap();
+            facade
+                .query_symbols(&query, 10)
+                .unwrap()
+                .into_iter()
+                .map(|result| result.name)
+                .collect()
+        };
+        assert_eq!(names("kind:function calls:make"), vec!["area"]);
+        assert_eq!(names("kind:function -calls:make"), vec!["make"]);
+        assert_eq!(names("called_by:area"), vec!["make"]);
+        assert_eq!(
+            names("lang:python kind:function,class"),
+            vec!["make", "area", "Shape"]
+        );
+        assert_eq!(
+            names("path:shapes.py name:*a*"),
+            vec!["make", "area", "Shape"]
+        );
+        assert_eq!(names("path:**/*.py kind:class"), vec!["Shape"]);
+        assert!(names("kind:function calls:missing").is_empty());
+        assert!(names("path:src/** kind:function").is_empty());
+    }
+
+    #[test]
+    fn query_symbols_by_directive_tag() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("legacy.py");
+        std::fs::write(
+            &source,
+            "# codanna:tag=deprecated\ndef old():\n    pass\n\n\n# codanna:ignore-next-symbol\ndef generated():\n    pass\n\n\ndef new():\n    pass\n",
+        )
+        .unwrap();
+        let mut facade = IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+
+        let query = SymbolQuery::parse("tag:Deprecated").unwrap();
+        let tagged: Vec<String> = facade
+            .query_symbols(&query, 10)
+            .unwrap()
+            .into_iter()
+            .map(|result| result.name)
+            .collect();
+        assert_eq!(tagged, vec!["old"]);
+        assert_eq!(
+            facade.find_symbols_by_name("old", None)[0].tags,
+            vec!["deprecated"]
+        );
+        assert!(facade.find_symbols_by_name("generated", None).is_empty());
+        assert_eq!(facade.find_symbols_by_name("new", None).len(), 1);
+    }
 }
@@ -4,7 +4,6 @@
 //! efficient incremental updates.
 
 use crate::FileId;
-use chrono::Utc;
 use sha2::{Digest, Sha256};
 use std::path::{Path, PathBuf};
 
@@ -58,10 +57,10 @@ pub fn calculate_hash(content: &str) -> String {
     hex::encode(hasher.finalize())
 }
 
-/// Get current UTC timestamp in seconds since UNIX_EPOCH TEST 5
+/// Get current UTC timestamp in seconds since UNIX_EPOCH, or
+/// `SOURCE_DATE_EPOCH` when set
 pub fn get_utc_timestamp() -> u64 {
-    // Use chrono for accurate cross-platform timestamp
-    Utc::now().timestamp() as u64
+    crate::utils::get_utc_timestamp()
 }
 
 #[cfg(test)]
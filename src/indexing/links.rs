@@ -0,0 +1,172 @@
+//! Symbolic and hard links met walking the indexed directories.
+//!
+//! `[indexing] symlinks` decides which links the walk follows: `skip`
+//! leaves them out, `follow` follows every one and `within-root`, the
+//! default, those whose target lies in the workspace, as a package vendored
+//! by linking it in from elsewhere in the repository. The walker reports a
+//! link back into its own ancestors as an error, which the walk skips, so a
+//! cycle ends there. A file reached by several paths, through links or as
+//! hard links of one another, is indexed once per walk; where its target
+//! lies in the walked directory, under the path it has there, so which link
+//! the walk meets first does not decide where its symbols are found.
+
+use crate::config::SymlinkPolicy;
+use ignore::{DirEntry, WalkBuilder};
+use std::collections::HashSet;
+use std::path::{Path, PathBuf};
+use std::sync::{Arc, Mutex, PoisonError};
+
+/// What tells two paths to the same file apart from two files
+#[cfg(unix)]
+type FileKey = (u64, u64);
+#[cfg(not(unix))]
+type FileKey = PathBuf;
+
+/// The link policy of one walk, and the files it admitted
+#[derive(Debug)]
+pub struct LinkFilter {
+    policy: SymlinkPolicy,
+    walk_root: PathBuf,
+    canonical_walk_root: Option<PathBuf>,
+    canonical_project_root: Option<PathBuf>,
+    seen: Mutex<HashSet<FileKey>>,
+}
+
+impl LinkFilter {
+    /// A filter for the walk of `walk_root`, in the workspace at
+    /// `project_root` (the walked directory itself when none)
+    pub fn new(policy: SymlinkPolicy, walk_root: &Path, project_root: Option<&Path>) -> Arc<Self> {
+        let canonical_walk_root = walk_root.canonicalize().ok();
+        let canonical_project_root = match project_root {
+            Some(root) => root.canonicalize().ok(),
+            None => canonical_walk_root.clone(),
+        };
+        Arc::new(Self {
+            policy,
+            walk_root: walk_root.to_path_buf(),
+            canonical_walk_root,
+            canonical_project_root,
+            seen: Mutex::new(HashSet::new()),
+        })
+    }
+
+    /// Set `builder` to follow the links the policy follows, and no other
+    pub fn configure(self: &Arc<Self>, builder: &mut WalkBuilder) {
+        builder.follow_links(self.policy != SymlinkPolicy::Skip);
+        if self.policy != SymlinkPolicy::Follow {
+            let filter = Arc::clone(self);
+            builder.filter_entry(move |entry| filter.allows(entry));
+        }
+    }
+
+    /// Whether the walk takes `entry`, a link only where the policy follows it
+    fn allows(&self, entry: &DirEntry) -> bool {
+        if entry.depth() == 0 || !entry.path_is_symlink() {
+            return true;
+        }
+        match self.policy {
+            SymlinkPolicy::Follow => true,
+            SymlinkPolicy::Skip => false,
+            SymlinkPolicy::WithinRoot => {
+                match (entry.path().canonicalize(), &self.canonical_project_root) {
+                    (Ok(target), Some(root)) => target.starts_with(root),
+                    _ => false,
+                }
+            }
+        }
+    }
+
+    /// The path to index the file at `path` under; none for a dangling
+    /// link or a file this walk already admitted by another path
+    pub fn admit(&self, path: &Path) -> Option<PathBuf> {
+        let canonical = path.canonicalize().ok()?;
+        let key = file_key(&canonical)?;
+        let first = self
+            .seen
+            .lock()
+            .unwrap_or_else(PoisonError::into_inner)
+            .insert(key);
+        first.then(|| self.indexed_path(path, &canonical))
+    }
+
+    /// `path`, or the path in the walked directory of the file it leads to
+    fn indexed_path(&self, path: &Path, canonical: &Path) -> PathBuf {
+        let Some(root) = &self.canonical_walk_root else {
+            return path.to_path_buf();
+        };
+        match canonical.strip_prefix(root) {
+            Ok(relative) => self.walk_root.join(relative),
+            Err(_) => path.to_path_buf(),
+        }
+    }
+}
+
+#[cfg(unix)]
+fn file_key(canonical: &Path) -> Option<FileKey> {
+    use std::os::unix::fs::MetadataExt;
+    let metadata = std::fs::metadata(canonical).ok()?;
+    Some((metadata.dev(), metadata.ino()))
+}
+
+#[cfg(not(unix))]
+fn file_key(canonical: &Path) -> Option<FileKey> {
+    Some(canonical.to_path_buf())
+}
+
+#[cfg(all(test, unix))]
+mod tests {
+    use super::*;
+    use std::fs;
+    use std::os::unix::fs::symlink;
+
+    fn walk(root: &Path, policy: SymlinkPolicy) -> Vec<PathBuf> {
+        let links = LinkFilter::new(policy, root, None);
+        let mut builder = WalkBuilder::new(root);
+        builder.hidden(false);
+        links.configure(&mut builder);
+        let mut files: Vec<PathBuf> = builder
+            .build()
+            .flatten()
+            .filter(|entry| !entry.file_type().is_some_and(|ft| ft.is_dir()))
+            .filter_map(|entry| links.admit(entry.path()))
+            .map(|path| path.strip_prefix(root).unwrap().to_path_buf())
+            .collect();
+        files.sort();
+        files
+    }
+
+    #[test]
+    fn test_links_by_policy() {
+        let outside = tempfile::tempdir().unwrap();
+        fs::write(outside.path().join("ext.rs"), "fn ext() {}").unwrap();
+
+        let project = tempfile::tempdir().unwrap();
+        let root = project.path();
+        fs::create_dir_all(root.join("packages/util")).unwrap();
+        fs::write(root.join("packages/util/lib.rs"), "fn util() {}").unwrap();
+        fs::create_dir(root.join("app")).unwrap();
+        // Vendored by a link, a second path to the same files
+        symlink(root.join("packages/util"), root.join("app/util")).unwrap();
+        symlink(outside.path(), root.join("app/external")).unwrap();
+        // A link back up would walk forever
+        symlink(root, root.join("app/loop")).unwrap();
+
+        let util = PathBuf::from("packages/util/lib.rs");
+        assert_eq!(walk(root, SymlinkPolicy::Skip), vec![util.clone()]);
+        assert_eq!(walk(root, SymlinkPolicy::WithinRoot), vec![util.clone()]);
+        assert_eq!(
+            walk(root, SymlinkPolicy::Follow),
+            vec![PathBuf::from("app/external/ext.rs"), util]
+        );
+    }
+
+    #[test]
+    fn test_hard_links_are_indexed_once() {
+        let project = tempfile::tempdir().unwrap();
+        let root = project.path();
+        fs::write(root.join("a.rs"), "fn a() {}").unwrap();
+        fs::hard_link(root.join("a.rs"), root.join("b.rs")).unwrap();
+
+        assert_eq!(walk(root, SymlinkPolicy::WithinRoot).len(), 1);
+    }
+}
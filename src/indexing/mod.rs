@@ -1,7 +1,11 @@
+pub mod diagnostics;
+pub mod encoding;
 pub mod facade;
 pub mod file_info;
+pub mod links;
 pub mod progress;
 pub mod walker;
+pub mod webhooks;
 
 // Parallel pipeline for high-performance indexing
 pub mod pipeline;
@@ -15,4 +19,7 @@ pub use walker::FileWalker;
 pub use pipeline::{Pipeline, PipelineConfig};
 
 // Facade - primary API for indexing operations
-pub use facade::{FacadeResult, IndexFacade, IndexingStats, SyncStats};
+pub use facade::{
+    CallGraphEntry, CallHierarchy, CallNode, ChangeImpact, FacadeResult, HierarchyNode,
+    ImpactEntry, ImpactLevel, IndexFacade, IndexingStats, SyncStats, TypeHierarchy,
+};
@@ -0,0 +1,218 @@
+//! Content-addressed cache of parse results and embeddings
+//!
+//! Worktrees, branches and snapshots of one project mostly hold the same
+//! files, and each of their indexes would parse and embed them again. With
+//! `indexing.content_cache` on, PARSE keeps what it makes of each file
+//! under the hash of its content, and EMBED the vector of each text under
+//! the hash of the text and the model. Any index then reads them back
+//! instead of parsing or embedding the same content.
+//!
+//! Keys also cover the codanna version, the path and module path of the
+//! file and the settings that shape a parse, so an entry is only read where
+//! the parse would turn out the same. Entries are written once, by rename,
+//! so indexes running at the same time share the cache safely. Nothing is
+//! ever evicted: remove the directory to reclaim the space.
+
+use super::types::ParsedFile;
+use crate::Settings;
+use crate::indexing::file_info::calculate_hash;
+use crate::parsing::LanguageId;
+use std::collections::HashMap;
+use std::path::{Path, PathBuf};
+
+/// Parse results and embeddings shared between indexes
+pub struct ContentCache {
+    dir: PathBuf,
+    /// Hash of the version and parse settings, per language
+    fingerprints: HashMap<String, String>,
+    /// For languages without settings of their own
+    fingerprint: String,
+}
+
+impl ContentCache {
+    /// A cache in `dir` for indexes parsing with `settings`
+    pub fn new(dir: PathBuf, settings: &Settings) -> Self {
+        let indexing = &settings.indexing;
+        let semantic = &settings.semantic_search;
+        let chunks = (semantic.enabled && semantic.code_chunks)
+            .then_some((semantic.code_chunk_tokens, semantic.code_chunk_overlap));
+        let shared = format!(
+            "{}\0{:?}\0{}\0{}\0{}\0{}\0{}\0{}\0{:?}",
+            env!("CARGO_PKG_VERSION"),
+            indexing.todo_tags,
+            indexing.embedded_sql,
+            indexing.rust_macros,
+            indexing.embedded_languages,
+            indexing.string_literals,
+            indexing.symbol_metrics,
+            indexing.max_ast_depth,
+            chunks
+        );
+        let fingerprints = settings
+            .languages
+            .iter()
+            .map(|(name, config)| {
+                // Sorted, as the options are a HashMap
+                let options: std::collections::BTreeMap<_, _> =
+                    config.parser_options.iter().collect();
+                let language = serde_json::to_string(&(
+                    &config.extensions,
+                    options,
+                    &config.config_files,
+                    &config.projects,
+                    &config.limits,
+                    &config.kinds,
+                ))
+                .unwrap_or_default();
+                (
+                    name.clone(),
+                    calculate_hash(&format!("{shared}\0{language}")),
+                )
+            })
+            .collect();
+        Self {
+            dir,
+            fingerprints,
+            fingerprint: calculate_hash(&shared),
+        }
+    }
+
+    /// The cache `indexing.content_cache` enables, under the global
+    /// directory. Authorship comes from git history rather than content,
+    /// so `indexing.git_blame` leaves it off.
+    pub fn from_settings(settings: &Settings) -> Option<Self> {
+        if !settings.indexing.content_cache || settings.indexing.git_blame {
+            return None;
+        }
+        Some(Self::new(crate::init::global_dir().join("cache"), settings))
+    }
+
+    /// Key of the parse of a file
+    pub fn parse_key(
+        &self,
+        path: &Path,
+        content_hash: &str,
+        language_id: LanguageId,
+        module_path: Option<&str>,
+    ) -> String {
+        let fingerprint = self
+            .fingerprints
+            .get(language_id.as_str())
+            .unwrap_or(&self.fingerprint);
+        calculate_hash(&format!(
+            "{fingerprint}\0{language_id}\0{}\0{content_hash}\0{}",
+            path.display(),
+            module_path.unwrap_or_default()
+        ))
+    }
+
+    /// Key of the embedding of `text` by `model`
+    pub fn embedding_key(model: &str, dimensions: usize, text: &str) -> String {
+        calculate_hash(&format!("{model}\0{dimensions}\0{text}"))
+    }
+
+    fn entry(&self, kind: &str, key: &str) -> PathBuf {
+        self.dir.join(kind).join(&key[..2]).join(key)
+    }
+
+    pub fn get_parsed(&self, key: &str) -> Option<ParsedFile> {
+        let bytes = std::fs::read(self.entry("parse", key)).ok()?;
+        serde_json::from_slice(&bytes).ok()
+    }
+
+    pub fn put_parsed(&self, key: &str, parsed: &ParsedFile) {
+        if let Ok(bytes) = serde_json::to_vec(parsed) {
+            self.write(&self.entry("parse", key), &bytes);
+        }
+    }
+
+    /// The cached embedding, if it has `dimensions` values
+    pub fn get_embedding(&self, key: &str, dimensions: usize) -> Option<Vec<f32>> {
+        let bytes = std::fs::read(self.entry("embeddings", key)).ok()?;
+        if bytes.len() != dimensions * 4 {
+            return None;
+        }
+        Some(
+            bytes
+                .chunks_exact(4)
+                .map(|value| f32::from_le_bytes([value[0], value[1], value[2], value[3]]))
+                .collect(),
+        )
+    }
+
+    pub fn put_embedding(&self, key: &str, embedding: &[f32]) {
+        let bytes: Vec<u8> = embedding.iter().flat_map(|v| v.to_le_bytes()).collect();
+        self.write(&self.entry("embeddings", key), &bytes);
+    }
+
+    /// Write an entry in one rename; the cache only saves work, so a
+    /// failure is logged and forgotten
+    fn write(&self, path: &Path, bytes: &[u8]) {
+        if path.exists() {
+            return;
+        }
+        let result = (|| {
+            let dir = path.parent().expect("entries live in a shard directory");
+            std::fs::create_dir_all(dir)?;
+            let mut staged = tempfile::NamedTempFile::new_in(dir)?;
+            std::io::Write::write_all(&mut staged, bytes)?;
+            staged.persist(path).map_err(|e| e.error)?;
+            Ok::<_, std::io::Error>(())
+        })();
+        if let Err(e) = result {
+            tracing::debug!(target: "pipeline", "content cache write failed for {}: {e}", path.display());
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::SymbolKind;
+    use crate::indexing::pipeline::types::RawSymbol;
+    use crate::types::Range;
+
+    #[test]
+    fn test_entries_round_trip_under_their_keys() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings::default();
+        let cache = ContentCache::new(dir.path().to_path_buf(), &settings);
+        let rust = LanguageId::new("rust");
+
+        let key = cache.parse_key(Path::new("src/lib.rs"), "abc", rust, Some("crate"));
+        assert!(cache.get_parsed(&key).is_none());
+        let mut parsed = ParsedFile::new("src/lib.rs".into(), "abc".to_string(), rust);
+        parsed.raw_symbols.push(RawSymbol::new(
+            "serve",
+            SymbolKind::Function,
+            Range::new(0, 0, 0, 10),
+        ));
+        cache.put_parsed(&key, &parsed);
+        let cached = cache.get_parsed(&key).unwrap();
+        assert_eq!(&*cached.raw_symbols[0].name, "serve");
+        assert_eq!(cached.language_id, rust);
+
+        // Another path, module path or parse setting is another entry
+        assert_ne!(
+            key,
+            cache.parse_key(Path::new("src/main.rs"), "abc", rust, Some("crate"))
+        );
+        assert_ne!(
+            key,
+            cache.parse_key(Path::new("src/lib.rs"), "abc", rust, None)
+        );
+        let mut other = Settings::default();
+        other.indexing.embedded_sql = !other.indexing.embedded_sql;
+        let other = ContentCache::new(dir.path().to_path_buf(), &other);
+        assert!(
+            other
+                .get_parsed(&other.parse_key(Path::new("src/lib.rs"), "abc", rust, Some("crate")))
+                .is_none()
+        );
+
+        let key = ContentCache::embedding_key("model", 3, "Serve requests");
+        cache.put_embedding(&key, &[0.5, -1.0, 2.0]);
+        assert_eq!(cache.get_embedding(&key, 3), Some(vec![0.5, -1.0, 2.0]));
+        assert!(cache.get_embedding(&key, 4).is_none());
+    }
+}
@@ -0,0 +1,270 @@
+//! Checkpoints of interrupted indexing runs
+//!
+//! Relationships are resolved once every file of a run is indexed. A run
+//! that dies in between leaves files committed without their relationships,
+//! and the next run would skip them as unchanged. So the INDEX stage
+//! journals the pending relationships and typed bindings of each batch to
+//! a checkpoint in the index directory, synced before every commit. The
+//! next run over the index picks up the entries of the files that were
+//! committed, resolves them with its own, and removes the checkpoint once
+//! resolution is done; the files that were not committed are simply new to
+//! it. `codanna index` rebuilds instead unless given `--resume`.
+//!
+//! Embeddings are saved when a run ends, so the files an interrupted run
+//! committed stay without embeddings until they change or are re-indexed
+//! with `--force`.
+
+use super::spill::RelationshipRecord;
+use super::types::{
+    FileBindings, PipelineError, PipelineResult, UnresolvedRelationship, VariableBinding,
+};
+use crate::parsing::get_registry;
+use crate::storage::DocumentIndex;
+use crate::types::FileId;
+use serde::{Deserialize, Serialize};
+use std::collections::HashMap;
+use std::fs::{File, OpenOptions};
+use std::io::{BufRead, BufReader, BufWriter, Write};
+use std::path::{Path, PathBuf};
+
+/// Checkpoint file, in the Tantivy directory of the index
+pub const CHECKPOINT_FILE: &str = "checkpoint.jsonl";
+
+/// One line of the checkpoint
+#[derive(Serialize, Deserialize)]
+#[serde(tag = "entry", rename_all = "snake_case")]
+enum Entry {
+    Relationship(RelationshipRecord<String>),
+    Bindings {
+        file_id: FileId,
+        bindings: Vec<VariableBinding>,
+    },
+}
+
+fn checkpoint_error(e: impl std::fmt::Display) -> PipelineError {
+    PipelineError::Index(crate::IndexError::General(format!(
+        "Failed to write indexing checkpoint: {e}"
+    )))
+}
+
+/// The journal of the run in progress
+pub struct Checkpoint {
+    writer: BufWriter<File>,
+}
+
+impl Checkpoint {
+    /// The checkpoint of the index in `index_dir`
+    pub fn path(index_dir: &Path) -> PathBuf {
+        index_dir.join(CHECKPOINT_FILE)
+    }
+
+    /// Start the journal of a run with the entries it carries over from
+    /// the run it resumes. The file is replaced in one rename, so an
+    /// interruption here loses nothing.
+    pub fn create(
+        path: &Path,
+        relationships: &[UnresolvedRelationship],
+        bindings: &FileBindings,
+    ) -> PipelineResult<Self> {
+        let staged = path.with_extension("jsonl.tmp");
+        let mut writer = BufWriter::new(File::create(&staged).map_err(checkpoint_error)?);
+        write_entries(&mut writer, relationships, bindings)?;
+        let file = writer
+            .into_inner()
+            .map_err(|e| checkpoint_error(e.error()))?;
+        file.sync_data().map_err(checkpoint_error)?;
+        drop(file);
+        std::fs::rename(&staged, path).map_err(checkpoint_error)?;
+
+        let file = OpenOptions::new()
+            .append(true)
+            .open(path)
+            .map_err(checkpoint_error)?;
+        Ok(Self {
+            writer: BufWriter::new(file),
+        })
+    }
+
+    /// Journal the relationships and bindings of one batch
+    pub fn record(
+        &mut self,
+        relationships: &[UnresolvedRelationship],
+        bindings: &FileBindings,
+    ) -> PipelineResult<()> {
+        write_entries(&mut self.writer, relationships, bindings)
+    }
+
+    /// Make everything recorded durable; called before each commit, so
+    /// the entries of every committed file survive
+    pub fn sync(&mut self) -> PipelineResult<()> {
+        self.writer.flush().map_err(checkpoint_error)?;
+        self.writer.get_ref().sync_data().map_err(checkpoint_error)
+    }
+
+    /// The relationships and bindings journaled for the files `index` has
+    /// committed; empty without a checkpoint
+    pub fn load(
+        path: &Path,
+        index: &DocumentIndex,
+    ) -> PipelineResult<(Vec<UnresolvedRelationship>, FileBindings)> {
+        let mut relationships = Vec::new();
+        let mut bindings = FileBindings::new();
+        let file = match File::open(path) {
+            Ok(file) => file,
+            Err(e) if e.kind() == std::io::ErrorKind::NotFound => {
+                return Ok((relationships, bindings));
+            }
+            Err(e) => return Err(checkpoint_error(e)),
+        };
+
+        let mut committed: HashMap<FileId, bool> = HashMap::new();
+        let mut is_committed = |file_id: FileId| -> PipelineResult<bool> {
+            if let Some(&known) = committed.get(&file_id) {
+                return Ok(known);
+            }
+            let known = index.get_file_path(file_id)?.is_some();
+            committed.insert(file_id, known);
+            Ok(known)
+        };
+        let registry = get_registry().lock().ok();
+        let language = |name: String| registry.as_ref()?.find_language_id(&name);
+        for line in BufReader::new(file).lines() {
+            let line = line.map_err(checkpoint_error)?;
+            // Only the tail written after the last sync can be torn, and
+            // it belongs to files that were never committed
+            let Ok(entry) = serde_json::from_str::<Entry>(&line) else {
+                continue;
+            };
+            match entry {
+                Entry::Relationship(rel) => {
+                    if is_committed(rel.file_id)? {
+                        relationships.push(rel.into_relationship(&language));
+                    }
+                }
+                Entry::Bindings {
+                    file_id,
+                    bindings: file_bindings,
+                } => {
+                    if is_committed(file_id)? {
+                        bindings.entry(file_id).or_default().extend(file_bindings);
+                    }
+                }
+            }
+        }
+        Ok((relationships, bindings))
+    }
+
+    /// Remove the checkpoint once a run's relationships are resolved
+    pub fn remove(path: &Path) -> PipelineResult<()> {
+        match std::fs::remove_file(path) {
+            Err(e) if e.kind() != std::io::ErrorKind::NotFound => Err(checkpoint_error(e)),
+            _ => Ok(()),
+        }
+    }
+}
+
+fn write_entries(
+    writer: &mut BufWriter<File>,
+    relationships: &[UnresolvedRelationship],
+    bindings: &FileBindings,
+) -> PipelineResult<()> {
+    let mut write = |entry: &Entry| -> PipelineResult<()> {
+        serde_json::to_writer(&mut *writer, entry).map_err(checkpoint_error)?;
+        writer.write_all(b"\n").map_err(checkpoint_error)
+    };
+    for rel in relationships {
+        let record = RelationshipRecord::new(rel.clone(), |language| language.to_string());
+        write(&Entry::Relationship(record))?;
+    }
+    for (file_id, file_bindings) in bindings {
+        write(&Entry::Bindings {
+            file_id: *file_id,
+            bindings: file_bindings.clone(),
+        })?;
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::RelationKind;
+    use crate::Settings;
+    use crate::indexing::pipeline::types::FileRegistration;
+    use crate::parsing::LanguageId;
+    use crate::types::Range;
+    use tempfile::TempDir;
+
+    fn relationship(file_id: u32, to: &str) -> UnresolvedRelationship {
+        UnresolvedRelationship {
+            from_id: None,
+            from_name: "caller".into(),
+            to_name: to.into(),
+            file_id: FileId::new(file_id).unwrap(),
+            kind: RelationKind::Calls,
+            metadata: None,
+            to_range: None,
+            target_language: Some(LanguageId::new("rust")),
+        }
+    }
+
+    fn register(index: &DocumentIndex, file_id: u32) {
+        index.start_batch().unwrap();
+        index
+            .store_file_registration(&FileRegistration {
+                path: PathBuf::from(format!("src/file_{file_id}.rs")),
+                file_id: FileId::new(file_id).unwrap(),
+                content_hash: "abc".to_string(),
+                language_id: LanguageId::new("rust"),
+                timestamp: 1700000000,
+                mtime: 1700000000,
+                encoding: None,
+            })
+            .unwrap();
+        index.commit_batch().unwrap();
+    }
+
+    #[test]
+    fn test_load_keeps_entries_of_committed_files() {
+        let dir = TempDir::new().unwrap();
+        let index = DocumentIndex::new(dir.path(), &Settings::default()).unwrap();
+        let path = Checkpoint::path(dir.path());
+
+        let mut checkpoint = Checkpoint::create(&path, &[], &FileBindings::new()).unwrap();
+        let bindings = FileBindings::from([(
+            FileId::new(1).unwrap(),
+            vec![VariableBinding {
+                name: "client".to_string(),
+                type_name: "Client".to_string(),
+                range: Range::new(3, 4, 3, 20),
+            }],
+        )]);
+        checkpoint
+            .record(&[relationship(1, "connect")], &bindings)
+            .unwrap();
+        checkpoint.sync().unwrap();
+        register(&index, 1);
+        // File 2 was sent to INDEX but the run died before committing it
+        checkpoint
+            .record(&[relationship(2, "send")], &FileBindings::new())
+            .unwrap();
+        drop(checkpoint);
+        let mut torn = OpenOptions::new().append(true).open(&path).unwrap();
+        torn.write_all(b"{\"entry\":\"relation").unwrap();
+
+        let (relationships, bindings) = Checkpoint::load(&path, &index).unwrap();
+        assert_eq!(relationships.len(), 1);
+        assert_eq!(&*relationships[0].to_name, "connect");
+        assert_eq!(bindings[&FileId::new(1).unwrap()][0].type_name, "Client");
+
+        // A resumed run carries them into its own checkpoint
+        Checkpoint::create(&path, &relationships, &bindings).unwrap();
+        let (carried, _) = Checkpoint::load(&path, &index).unwrap();
+        assert_eq!(carried.len(), 1);
+
+        Checkpoint::remove(&path).unwrap();
+        assert!(!path.exists());
+        assert!(Checkpoint::load(&path, &index).unwrap().0.is_empty());
+        Checkpoint::remove(&path).unwrap();
+    }
+}
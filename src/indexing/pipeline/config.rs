@@ -5,6 +5,9 @@
 
 use crate::Settings;
 
+/// Rough size of one pending relationship in memory, names included
+const RELATIONSHIP_BYTES: usize = 256;
+
 /// Configuration for the parallel indexing pipeline.
 #[derive(Debug, Clone)]
 pub struct PipelineConfig {
@@ -32,11 +35,18 @@ pub struct PipelineConfig {
     /// Channel capacity for index batches (COLLECT → INDEX)
     pub batch_channel_size: usize,
 
+    /// Channel capacity for embedding batches (COLLECT → EMBED)
+    pub embed_channel_size: usize,
+
     /// Number of batches between Tantivy commits
     pub batches_per_commit: usize,
 
     /// Enable detailed stage tracing (timing, memory, throughput)
     pub pipeline_tracing: bool,
+
+    /// Pending relationships INDEX keeps in memory before spilling them
+    /// to disk (0 = never spill)
+    pub spill_limit: usize,
 }
 
 impl Default for PipelineConfig {
@@ -53,8 +63,10 @@ impl Default for PipelineConfig {
             content_channel_size: 100,
             parsed_channel_size: 1000,
             batch_channel_size: 20,
+            embed_channel_size: 20,
             batches_per_commit: 10,
             pipeline_tracing: false,
+            spill_limit: 0,
         }
     }
 }
@@ -62,32 +74,52 @@ impl Default for PipelineConfig {
 impl PipelineConfig {
     /// Create config from Settings.
     ///
-    /// Derives thread counts from `indexing.parallelism`:
+    /// Derives thread counts from `indexing.parallelism` (0 = all cores),
+    /// unless `indexing.threads` sets them:
     /// - parse_threads: 60% of parallelism (CPU-bound parsing)
     /// - read_threads: 20% of parallelism (I/O-bound file reading)
     /// - discover_threads: 10% of parallelism (filesystem walking)
     ///
     /// Also reads:
+    /// - `indexing.queue_size` -> upper bound of the file channels, and of
+    ///   the symbols waiting for EMBED
     /// - `indexing.batch_size` -> batch_size
     /// - `indexing.batches_per_commit` -> batches_per_commit
     /// - `indexing.pipeline_tracing` -> pipeline_tracing
+    /// - `indexing.max_memory_mb` -> see [`Self::with_max_memory`]
     pub fn from_settings(settings: &Settings) -> Self {
         let indexing = &settings.indexing;
-        let parallelism = indexing.parallelism;
+        let parallelism = match indexing.parallelism {
+            0 => num_cpus::get(),
+            parallelism => parallelism,
+        };
+        let threads = indexing.threads;
+        let or_derived = |set: usize, derived: usize| if set > 0 { set } else { derived };
 
         // Derive thread counts from single parallelism value
         // 60% for CPU-heavy parsing, 20% for I/O, 10% for discovery
-        let parse_threads = (parallelism * 60 / 100).max(2);
-        let read_threads = (parallelism * 20 / 100).max(1);
-        let discover_threads = (parallelism * 10 / 100).max(1);
-
-        // Channel sizes scale with derived thread counts
-        let path_channel_size = parallelism * 100;
-        let content_channel_size = read_threads * 50;
-        let parsed_channel_size = parse_threads * 100;
+        let parse_threads = or_derived(threads.parse, (parallelism * 60 / 100).max(2));
+        let read_threads = or_derived(threads.read, (parallelism * 20 / 100).max(1));
+        let discover_threads = or_derived(threads.discover, (parallelism * 10 / 100).max(1));
+
+        // Channel sizes scale with thread counts; a full channel blocks the
+        // stage sending to it, so the queue size caps the files in flight
+        let bounded = |size: usize| match indexing.queue_size {
+            0 => size,
+            queue_size => size.min(queue_size),
+        };
+        let path_channel_size = bounded(parallelism * 100);
+        let content_channel_size = bounded(read_threads * 50);
+        let parsed_channel_size = bounded(parse_threads * 100);
         let batch_channel_size = 20;
-
-        Self {
+        // EMBED is the slowest stage; at least one batch of up to
+        // `batch_size` symbols waits for it
+        let embed_channel_size = match indexing.queue_size {
+            0 => batch_channel_size,
+            queue_size => (queue_size / indexing.batch_size.max(1)).clamp(1, batch_channel_size),
+        };
+
+        let config = Self {
             parse_threads,
             read_threads,
             discover_threads,
@@ -96,8 +128,14 @@ impl PipelineConfig {
             content_channel_size,
             parsed_channel_size,
             batch_channel_size,
+            embed_channel_size,
             batches_per_commit: indexing.batches_per_commit,
             pipeline_tracing: indexing.pipeline_tracing,
+            spill_limit: 0,
+        };
+        match indexing.max_memory_mb {
+            0 => config,
+            budget => config.with_max_memory(budget),
         }
     }
 
@@ -112,8 +150,10 @@ impl PipelineConfig {
             content_channel_size: 50,
             parsed_channel_size: 500,
             batch_channel_size: 10,
+            embed_channel_size: 10,
             batches_per_commit: 5,
             pipeline_tracing: false,
+            spill_limit: 0,
         }
     }
 
@@ -129,8 +169,10 @@ impl PipelineConfig {
             content_channel_size: 200,
             parsed_channel_size: 2000,
             batch_channel_size: 50,
+            embed_channel_size: 50,
             batches_per_commit: 20,
             pipeline_tracing: false,
+            spill_limit: 0,
         }
     }
 
@@ -152,6 +194,31 @@ impl PipelineConfig {
         self
     }
 
+    /// Fit the pipeline into a memory budget of `mb` megabytes.
+    ///
+    /// The stage queues get a quarter of it, and pending relationships
+    /// another quarter before they spill to disk. Batches shrink and each
+    /// one is committed, so Tantivy flushes its segments early. The writer
+    /// heap is capped separately, where the index is opened.
+    pub fn with_max_memory(mut self, mb: usize) -> Self {
+        let share = (mb / 4).max(1);
+        self.batch_size = self.batch_size.min(1000);
+        self.batches_per_commit = 1;
+        self.batch_channel_size = self.batch_channel_size.min(4);
+        self.embed_channel_size = self.embed_channel_size.min(4);
+        while self.estimated_memory_mb() > share
+            && (self.path_channel_size > 1
+                || self.content_channel_size > 1
+                || self.parsed_channel_size > 1)
+        {
+            self.path_channel_size = (self.path_channel_size / 2).max(1);
+            self.content_channel_size = (self.content_channel_size / 2).max(1);
+            self.parsed_channel_size = (self.parsed_channel_size / 2).max(1);
+        }
+        self.spill_limit = share * 1_000_000 / RELATIONSHIP_BYTES;
+        self
+    }
+
     /// Calculate total channel buffer memory (approximate)
     pub fn estimated_memory_mb(&self) -> usize {
         // Rough estimates:
@@ -159,12 +226,14 @@ impl PipelineConfig {
         // - Content: 10KB avg
         // - Parsed: 50KB avg
         // - Batch: 500KB avg
+        // - Embed batch: 500KB avg
         let path_mem = self.path_channel_size * 100;
         let content_mem = self.content_channel_size * 10_000;
         let parsed_mem = self.parsed_channel_size * 50_000;
         let batch_mem = self.batch_channel_size * 500_000;
+        let embed_mem = self.embed_channel_size * 500_000;
 
-        (path_mem + content_mem + parsed_mem + batch_mem) / 1_000_000
+        (path_mem + content_mem + parsed_mem + batch_mem + embed_mem) / 1_000_000
     }
 }
 
@@ -216,6 +285,50 @@ mod tests {
         println!("  batches_per_commit: {}", config.batches_per_commit);
     }
 
+    #[test]
+    fn test_stage_threads_and_queue_size() {
+        let mut settings = Settings::default();
+        settings.indexing.parallelism = 10;
+        settings.indexing.threads.parse = 3;
+        settings.indexing.queue_size = 250;
+        let config = PipelineConfig::from_settings(&settings);
+
+        assert_eq!(config.parse_threads, 3);
+        assert_eq!(config.read_threads, 2, "derived when not set");
+        assert_eq!(config.path_channel_size, 250);
+        assert_eq!(config.content_channel_size, 100, "already below the cap");
+        assert_eq!(config.parsed_channel_size, 250);
+        assert_eq!(config.embed_channel_size, 1, "one batch below the cap");
+
+        settings.indexing.batch_size = 100;
+        let config = PipelineConfig::from_settings(&settings);
+        assert_eq!(config.embed_channel_size, 2);
+
+        settings.indexing.parallelism = 0;
+        let config = PipelineConfig::from_settings(&settings);
+        assert!(config.discover_threads >= 1);
+        assert!(config.path_channel_size > 0);
+    }
+
+    #[test]
+    fn test_max_memory_fits_budget() {
+        let mut settings = Settings::default();
+        settings.indexing.parallelism = 16;
+        let unbounded = PipelineConfig::from_settings(&settings);
+        assert_eq!(unbounded.spill_limit, 0);
+
+        settings.indexing.max_memory_mb = 128;
+        let config = PipelineConfig::from_settings(&settings);
+
+        assert!(config.estimated_memory_mb() <= 32);
+        assert!(config.parsed_channel_size < unbounded.parsed_channel_size);
+        assert_eq!(config.batches_per_commit, 1);
+        assert!(config.embed_channel_size <= 4);
+        assert!(config.batch_size <= 1000);
+        assert_eq!(config.spill_limit, 32 * 1_000_000 / RELATIONSHIP_BYTES);
+        assert_eq!(config.parse_threads, unbounded.parse_threads);
+    }
+
     #[test]
     fn test_memory_estimate() {
         let config = PipelineConfig::default();
@@ -26,7 +26,9 @@ impl Pipeline {
 
         // Phase 2: Resolve relationships
         let symbol_cache = Arc::new(symbol_cache);
-        let phase2_stats = self.run_phase2(unresolved, bindings, symbol_cache, index)?;
+        let phase2_stats =
+            self.run_phase2(unresolved, bindings, symbol_cache, Arc::clone(&index))?;
+        self.clear_checkpoint(&index)?;
 
         Ok((index_stats, phase2_stats))
     }
@@ -75,6 +77,7 @@ impl Pipeline {
             Arc::clone(&index),
             show_progress,
         )?;
+        self.clear_checkpoint(&index)?;
 
         // Save embeddings
         self.persist_embeddings(semantic.as_ref(), semantic_path)?;
@@ -1,16 +1,20 @@
 //! Change-driven indexing: incremental runs, single-file reindex, config sync.
 
-use super::stages::{CleanupStage, CollectStage, DiscoverStage, IndexStage, ReadStage};
+use super::stages::{
+    CleanupStage, CollectStage, DiscoverStage, IndexStage, ReadStage, SemanticEmbedStage,
+};
 use super::{
-    CleanupStats, EmbedOptions, FileSource, IncrementalStats, ParseStage, Phase1Options,
-    Phase2Stats, Pipeline, PipelineError, PipelineResult, ProgressSink, SingleFileStats,
-    SymbolLookupCache, SyncStats, init_parser_cache,
+    CleanupStats, DiscoverResult, EmbedOptions, FileSource, IncrementalStats, ParseStage,
+    Phase1Options, Phase2Stats, Pipeline, PipelineError, PipelineResult, ProgressSink,
+    SingleFileStats, SymbolLookupCache, SyncStats, init_parser_cache,
 };
 use crate::FileId;
+use crate::config::PathOverrides;
 use crate::indexing::IndexStats;
 use crate::io::status_line::DualProgressBar;
 use crate::semantic::SimpleSemanticSearch;
 use crate::storage::DocumentIndex;
+use std::collections::HashSet;
 use std::path::{Path, PathBuf};
 use std::sync::{Arc, Mutex};
 use std::time::Instant;
@@ -111,7 +115,9 @@ impl Pipeline {
         let parsed = parse_stage.parse(file_content)?;
 
         // Collect into a batch (now includes embedding candidates)
-        let collect_stage = CollectStage::new(self.config.batch_size);
+        let collect_stage = CollectStage::new(self.config.batch_size)
+            .with_code_embeddings(self.settings.semantic_search.code_embeddings)
+            .with_overrides(PathOverrides::new(&self.settings).unwrap_or_default());
         let (mut batch, unresolved, embed_batch) =
             collect_stage.process_single(parsed, Arc::clone(&index))?;
         let variable_bindings = std::mem::take(&mut batch.variable_bindings);
@@ -157,35 +163,18 @@ impl Pipeline {
 
         // Generate embeddings for symbols with doc_comments
         if let (Some(pool), Some(sem)) = (&embedding_pool, &semantic) {
-            if !embed_batch.candidates.is_empty() {
+            if !embed_batch.is_empty() {
                 tracing::info!(
                     target: "pipeline",
                     "Generating {} embeddings for {}",
-                    embed_batch.candidates.len(),
+                    embed_batch.len(),
                     path.display()
                 );
 
-                // Convert to the format expected by embed_parallel
-                let items: Vec<_> = embed_batch
-                    .candidates
-                    .iter()
-                    .map(|(id, doc, lang)| (*id, doc.as_ref(), lang.as_ref()))
-                    .collect();
-
-                // Generate embeddings
-                let embeddings = pool
-                    .embed_parallel(&items)
-                    .map_err(|e| PipelineError::Parse {
-                        path: path.to_path_buf(),
-                        reason: format!("Embedding generation failed: {e}"),
-                    })?;
-
-                // store_embeddings warns internally on any dropped embeddings.
-                if !embeddings.is_empty() {
-                    if let Ok(mut guard) = sem.lock() {
-                        guard.store_embeddings(embeddings);
-                    }
-                }
+                // Symbols whose text did not change take their retired
+                // embeddings back; the rest are embedded
+                SemanticEmbedStage::new(Arc::clone(pool), Arc::clone(sem))
+                    .process_batch(&embed_batch)?;
             }
         }
 
@@ -261,165 +250,71 @@ impl Pipeline {
         let start = Instant::now();
         let semantic_path = self.settings.index_path.join("semantic");
 
-        // Progress bar options shared between phases
-        let bar_options = ProgressBarOptions::default()
-            .with_style(ProgressBarStyle::VerticalSolid)
-            .with_width(28);
-
-        // Run Phase 1 indexing with appropriate progress bar
-        let (
-            index_stats,
-            unresolved,
-            variable_bindings,
-            symbol_cache,
-            cleanup_stats,
-            deleted_symbols,
-            discover_counts,
-        ) = if force {
-            // Force mode: use DualProgressBar for semantic+embedding, else single bar
-            let has_embedding = semantic.is_some() && embedding_pool.is_some();
-
-            if has_embedding {
-                // Dual progress: EMBED and INDEX running in parallel
-                // Estimate embedding candidates = total_files (actual will vary based on symbols per file)
-                let dual_bar = Arc::new(DualProgressBar::new(
-                    "EMBED",
-                    total_files as u64, // Estimated embedding candidates
-                    "embedded",
-                    "INDEX",
-                    total_files as u64,
-                    "files",
-                ));
-                let dual_status = StatusLine::new(Arc::clone(&dual_bar));
-
-                let (stats, unresolved, bindings, cache, metrics) = self.run_phase1(
-                    FileSource::Walk(root.to_path_buf()),
-                    Arc::clone(&index),
-                    Phase1Options {
-                        progress: ProgressSink::Dual(dual_bar.clone()),
-                        embed: Some(EmbedOptions {
-                            pool: embedding_pool
-                                .clone()
-                                .expect("has_embedding checked pool.is_some()"),
-                            semantic: Arc::clone(
-                                semantic
-                                    .as_ref()
-                                    .expect("has_embedding checked semantic.is_some()"),
-                            ),
-                        }),
-                    },
-                )?;
-
-                // Drop StatusLine BEFORE logging to avoid stderr race condition
-                drop(dual_status);
-                if let Some(m) = metrics {
-                    m.log();
-                }
-                eprintln!("{dual_bar}");
-
-                let files_indexed = stats.files_indexed;
-                (
-                    stats,
-                    unresolved,
-                    bindings,
-                    cache,
-                    CleanupStats::default(),
-                    0,
-                    (files_indexed, 0, 0),
-                )
-            } else {
-                // Single progress bar (no embedding or no semantic)
-                let phase1_bar = Arc::new(ProgressBar::with_4_labels(
-                    total_files as u64,
-                    "files",
-                    "indexed",
-                    "failed",
-                    "embedded",
-                    bar_options,
-                ));
-                let phase1_status = StatusLine::new(Arc::clone(&phase1_bar));
-
-                // has_embedding is false here, so semantic and pool are never
-                // both present: the embed stage cannot run in this arm.
-                let (stats, unresolved, bindings, cache, metrics) = self.run_phase1(
-                    FileSource::Walk(root.to_path_buf()),
-                    Arc::clone(&index),
-                    Phase1Options {
-                        progress: ProgressSink::Bar(phase1_bar.clone()),
-                        embed: None,
-                    },
-                )?;
-
-                // Drop StatusLine BEFORE logging to avoid stderr race condition
-                drop(phase1_status);
-                if let Some(m) = metrics {
-                    m.log();
-                }
-                eprintln!("{phase1_bar}");
-
-                let files_indexed = stats.files_indexed;
-                (
-                    stats,
-                    unresolved,
-                    bindings,
-                    cache,
-                    CleanupStats::default(),
-                    0,
-                    (files_indexed, 0, 0),
-                )
-            }
-        } else {
+        if !force {
             // Incremental mode: discover first, then create bar with actual count
             let discover_stage = DiscoverStage::new(root, self.config.discover_threads)
                 .with_index(Arc::clone(&index))
-                .with_workspace_root(self.settings.workspace_root.clone());
+                .with_workspace_root(self.settings.workspace_root.clone())
+                .with_settings(Arc::clone(&self.settings));
             let discover_result = discover_stage.run_incremental()?;
+            return self.index_discovered(
+                discover_result,
+                index,
+                semantic,
+                embedding_pool,
+                true,
+                start,
+            );
+        }
 
-            if discover_result.is_empty() {
-                return Ok(IncrementalStats {
-                    new_files: 0,
-                    modified_files: 0,
-                    deleted_files: 0,
-                    deleted_symbols: 0,
-                    index_stats: IndexStats::new(),
-                    cleanup_stats: CleanupStats::default(),
-                    phase2_stats: Phase2Stats::default(),
-                    elapsed: start.elapsed(),
-                });
-            }
+        // Force mode: use DualProgressBar for semantic+embedding, else single bar
+        let has_embedding = semantic.is_some() && embedding_pool.is_some();
+        let (index_stats, unresolved, variable_bindings, symbol_cache) = if has_embedding {
+            // Dual progress: EMBED and INDEX running in parallel
+            // Estimate embedding candidates = total_files (actual will vary based on symbols per file)
+            let dual_bar = Arc::new(DualProgressBar::new(
+                "EMBED",
+                total_files as u64, // Estimated embedding candidates
+                "embedded",
+                "INDEX",
+                total_files as u64,
+                "files",
+            ));
+            let dual_status = StatusLine::new(Arc::clone(&dual_bar));
 
-            // Cleanup
-            let cleanup_stage = if let Some(ref sem) = semantic {
-                CleanupStage::new(Arc::clone(&index), &semantic_path).with_semantic(Arc::clone(sem))
-            } else {
-                CleanupStage::new(Arc::clone(&index), &semantic_path)
-            };
+            let (stats, unresolved, bindings, cache, metrics) = self.run_phase1(
+                FileSource::Walk(root.to_path_buf()),
+                Arc::clone(&index),
+                Phase1Options {
+                    progress: ProgressSink::Dual(dual_bar.clone()),
+                    embed: Some(EmbedOptions {
+                        pool: embedding_pool
+                            .clone()
+                            .expect("has_embedding checked pool.is_some()"),
+                        semantic: Arc::clone(
+                            semantic
+                                .as_ref()
+                                .expect("has_embedding checked semantic.is_some()"),
+                        ),
+                    }),
+                },
+            )?;
 
-            let mut cleanup_stats = CleanupStats::default();
-            let mut deleted_symbols = 0;
-            if !discover_result.deleted_files.is_empty() {
-                let stats = cleanup_stage.cleanup_files(&discover_result.deleted_files)?;
-                cleanup_stats.files_cleaned += stats.files_cleaned;
-                cleanup_stats.symbols_removed += stats.symbols_removed;
-                deleted_symbols = stats.symbols_removed;
-            }
-            if !discover_result.modified_files.is_empty() {
-                let stats = cleanup_stage.cleanup_files(&discover_result.modified_files)?;
-                cleanup_stats.files_cleaned += stats.files_cleaned;
-                cleanup_stats.symbols_removed += stats.symbols_removed;
+            // Drop StatusLine BEFORE logging to avoid stderr race condition
+            drop(dual_status);
+            if let Some(m) = metrics {
+                m.log();
             }
+            eprintln!("{dual_bar}");
 
-            let files_to_index: Vec<PathBuf> = discover_result
-                .new_files
-                .iter()
-                .chain(discover_result.modified_files.iter())
-                .cloned()
-                .collect();
-
-            // Create Phase 1 bar with actual files to index count
-            // Labels: files, indexed, failed, embedded (for embedding visibility)
+            (stats, unresolved, bindings, cache)
+        } else {
+            // Single progress bar (no embedding or no semantic)
+            let bar_options = ProgressBarOptions::default()
+                .with_style(ProgressBarStyle::VerticalSolid)
+                .with_width(28);
             let phase1_bar = Arc::new(ProgressBar::with_4_labels(
-                files_to_index.len() as u64,
+                total_files as u64,
                 "files",
                 "indexed",
                 "failed",
@@ -428,19 +323,14 @@ impl Pipeline {
             ));
             let phase1_status = StatusLine::new(Arc::clone(&phase1_bar));
 
-            let embed = match (&semantic, &embedding_pool) {
-                (Some(sem), Some(pool)) => Some(EmbedOptions {
-                    pool: Arc::clone(pool),
-                    semantic: Arc::clone(sem),
-                }),
-                _ => None,
-            };
-            let (stats, unresolved, bindings, _run_cache, metrics) = self.run_phase1(
-                FileSource::List(files_to_index),
+            // has_embedding is false here, so semantic and pool are never
+            // both present: the embed stage cannot run in this arm.
+            let (stats, unresolved, bindings, cache, metrics) = self.run_phase1(
+                FileSource::Walk(root.to_path_buf()),
                 Arc::clone(&index),
                 Phase1Options {
                     progress: ProgressSink::Bar(phase1_bar.clone()),
-                    embed,
+                    embed: None,
                 },
             )?;
 
@@ -451,44 +341,207 @@ impl Pipeline {
             }
             eprintln!("{phase1_bar}");
 
-            // Seed Phase 2 from the persisted index: the run-scoped cache
-            // holds only this run's files, hiding unchanged files' symbols
-            // and re-export aliases from resolution.
-            let cache = SymbolLookupCache::from_index(&index)?;
-
-            let counts = (
-                discover_result.new_files.len(),
-                discover_result.modified_files.len(),
-                discover_result.deleted_files.len(),
-            );
-            (
-                stats,
-                unresolved,
-                bindings,
-                cache,
-                cleanup_stats,
-                deleted_symbols,
-                counts,
-            )
+            (stats, unresolved, bindings, cache)
         };
 
         // Run Phase 2 with separate progress bar
-        let symbol_cache = Arc::new(symbol_cache);
         let phase2_stats = self.run_phase2_maybe_bar(
             unresolved,
             variable_bindings,
-            symbol_cache,
+            Arc::new(symbol_cache),
             Arc::clone(&index),
             true,
         )?;
+        self.clear_checkpoint(&index)?;
 
         // Save embeddings
         self.persist_embeddings(semantic.as_ref(), &semantic_path)?;
 
         Ok(IncrementalStats {
-            new_files: discover_counts.0,
-            modified_files: discover_counts.1,
-            deleted_files: discover_counts.2,
+            new_files: index_stats.files_indexed,
+            modified_files: 0,
+            deleted_files: 0,
+            deleted_symbols: 0,
+            index_stats,
+            cleanup_stats: CleanupStats::default(),
+            phase2_stats,
+            elapsed: start.elapsed(),
+        })
+    }
+
+    /// Index only the files changed since a git revision (`index --since`).
+    ///
+    /// Git reports the changes, committed or not, against `since`; the rest
+    /// of the tree is neither walked nor hashed. Files whose relationships
+    /// point into a changed file are re-indexed with it, so their edges are
+    /// resolved again.
+    pub fn index_changed_since(
+        &self,
+        root: &Path,
+        since: &str,
+        index: Arc<DocumentIndex>,
+        semantic: Option<Arc<Mutex<SimpleSemanticSearch>>>,
+        embedding_pool: Option<Arc<crate::semantic::EmbeddingBackend>>,
+        show_progress: bool,
+    ) -> PipelineResult<IncrementalStats> {
+        let start = Instant::now();
+        let changed = crate::git::changed_files(root, since).map_err(|e| PipelineError::Parse {
+            path: root.to_path_buf(),
+            reason: format!(
+                "Failed to list files changed since {since}: {}",
+                e.message()
+            ),
+        })?;
+
+        let discover_stage = DiscoverStage::new(root, self.config.discover_threads)
+            .with_index(Arc::clone(&index))
+            .with_workspace_root(self.settings.workspace_root.clone())
+            .with_settings(Arc::clone(&self.settings));
+        let discover_result = discover_stage.run_changed(&changed)?;
+
+        tracing::info!(
+            target: "pipeline",
+            "Changes since {since}: {} reported by git, {} new, {} modified, {} deleted",
+            changed.len(),
+            discover_result.new_files.len(),
+            discover_result.modified_files.len(),
+            discover_result.deleted_files.len()
+        );
+
+        self.index_discovered(
+            discover_result,
+            index,
+            semantic,
+            embedding_pool,
+            show_progress,
+            start,
+        )
+    }
+
+    /// Clean up, re-index and resolve the files of a discovery, with a
+    /// Phase 1 bar sized to them when `show_progress` is set.
+    fn index_discovered(
+        &self,
+        discover_result: DiscoverResult,
+        index: Arc<DocumentIndex>,
+        semantic: Option<Arc<Mutex<SimpleSemanticSearch>>>,
+        embedding_pool: Option<Arc<crate::semantic::EmbeddingBackend>>,
+        show_progress: bool,
+        start: Instant,
+    ) -> PipelineResult<IncrementalStats> {
+        use crate::io::status_line::{
+            ProgressBar, ProgressBarOptions, ProgressBarStyle, StatusLine,
+        };
+
+        let semantic_path = self.settings.index_path.join("semantic");
+
+        if discover_result.is_empty() && !self.has_checkpoint(&index) {
+            return Ok(IncrementalStats {
+                new_files: 0,
+                modified_files: 0,
+                deleted_files: 0,
+                deleted_symbols: 0,
+                index_stats: IndexStats::new(),
+                cleanup_stats: CleanupStats::default(),
+                phase2_stats: Phase2Stats::default(),
+                elapsed: start.elapsed(),
+            });
+        }
+
+        // Cleanup
+        let cleanup_stage = if let Some(ref sem) = semantic {
+            CleanupStage::new(Arc::clone(&index), &semantic_path).with_semantic(Arc::clone(sem))
+        } else {
+            CleanupStage::new(Arc::clone(&index), &semantic_path)
+        };
+
+        let mut cleanup_stats = CleanupStats::default();
+        let mut deleted_symbols = 0;
+        if !discover_result.deleted_files.is_empty() {
+            let stats = cleanup_stage.cleanup_files(&discover_result.deleted_files)?;
+            cleanup_stats.files_cleaned += stats.files_cleaned;
+            cleanup_stats.symbols_removed += stats.symbols_removed;
+            deleted_symbols = stats.symbols_removed;
+        }
+        if !discover_result.modified_files.is_empty() {
+            let stats = cleanup_stage.cleanup_files(&discover_result.modified_files)?;
+            cleanup_stats.files_cleaned += stats.files_cleaned;
+            cleanup_stats.symbols_removed += stats.symbols_removed;
+        }
+
+        let files_to_index: Vec<PathBuf> = discover_result
+            .new_files
+            .iter()
+            .chain(discover_result.modified_files.iter())
+            .cloned()
+            .collect();
+
+        // Create Phase 1 bar with actual files to index count
+        // Labels: files, indexed, failed, embedded (for embedding visibility)
+        let phase1_bar = show_progress.then(|| {
+            Arc::new(ProgressBar::with_4_labels(
+                files_to_index.len() as u64,
+                "files",
+                "indexed",
+                "failed",
+                "embedded",
+                ProgressBarOptions::default()
+                    .with_style(ProgressBarStyle::VerticalSolid)
+                    .with_width(28),
+            ))
+        });
+        let phase1_status = phase1_bar
+            .as_ref()
+            .map(|bar| StatusLine::new(Arc::clone(bar)));
+
+        let embed = match (&semantic, &embedding_pool) {
+            (Some(sem), Some(pool)) => Some(EmbedOptions {
+                pool: Arc::clone(pool),
+                semantic: Arc::clone(sem),
+            }),
+            _ => None,
+        };
+        let (index_stats, unresolved, variable_bindings, _run_cache, metrics) = self.run_phase1(
+            FileSource::List(files_to_index),
+            Arc::clone(&index),
+            Phase1Options {
+                progress: phase1_bar
+                    .clone()
+                    .map_or(ProgressSink::Silent, ProgressSink::Bar),
+                embed,
+            },
+        )?;
+
+        // Drop StatusLine BEFORE logging to avoid stderr race condition
+        drop(phase1_status);
+        if let Some(m) = metrics {
+            m.log();
+        }
+        if let Some(bar) = &phase1_bar {
+            eprintln!("{bar}");
+        }
+
+        // Seed Phase 2 from the persisted index: the run-scoped cache
+        // holds only this run's files, hiding unchanged files' symbols
+        // and re-export aliases from resolution.
+        let symbol_cache = Arc::new(SymbolLookupCache::from_index(&index)?);
+        let phase2_stats = self.run_phase2_maybe_bar(
+            unresolved,
+            variable_bindings,
+            symbol_cache,
+            Arc::clone(&index),
+            show_progress,
+        )?;
+        self.clear_checkpoint(&index)?;
+
+        // Save embeddings
+        self.persist_embeddings(semantic.as_ref(), &semantic_path)?;
+
+        let (new_files, modified_files) = indexed_counts(&discover_result, &index_stats);
+        Ok(IncrementalStats {
+            new_files,
+            modified_files,
+            deleted_files: discover_result.deleted_files.len(),
             deleted_symbols,
             index_stats,
             cleanup_stats,
@@ -525,7 +578,8 @@ impl Pipeline {
         // Incremental mode: detect changes
         let discover_stage = DiscoverStage::new(root, self.config.discover_threads)
             .with_index(Arc::clone(&index))
-            .with_workspace_root(self.settings.workspace_root.clone());
+            .with_workspace_root(self.settings.workspace_root.clone())
+            .with_settings(Arc::clone(&self.settings));
         let discover_result = discover_stage.run_incremental()?;
 
         tracing::info!(
@@ -536,7 +590,7 @@ impl Pipeline {
             discover_result.deleted_files.len()
         );
 
-        if discover_result.is_empty() {
+        if discover_result.is_empty() && !self.has_checkpoint(&index) {
             return Ok(IncrementalStats {
                 new_files: 0,
                 modified_files: 0,
@@ -618,13 +672,15 @@ impl Pipeline {
             Arc::clone(&index),
             show_progress,
         )?;
+        self.clear_checkpoint(&index)?;
 
         // Save embeddings
         self.persist_embeddings(semantic.as_ref(), &semantic_path)?;
 
+        let (new_files, modified_files) = indexed_counts(&discover_result, &index_stats);
         Ok(IncrementalStats {
-            new_files: discover_result.new_files.len(),
-            modified_files: discover_result.modified_files.len(),
+            new_files,
+            modified_files,
             deleted_files: discover_result.deleted_files.len(),
             deleted_symbols,
             index_stats,
@@ -660,8 +716,6 @@ impl Pipeline {
         embedding_pool: Option<Arc<crate::semantic::EmbeddingBackend>>,
         _progress: bool,
     ) -> PipelineResult<SyncStats> {
-        use std::collections::HashSet;
-
         let start = Instant::now();
         let semantic_path = self.settings.index_path.join("semantic");
 
@@ -815,6 +869,26 @@ impl Pipeline {
     }
 }
 
+/// The discovered new and modified files that were indexed, leaving out
+/// the ones skipped for their size
+fn indexed_counts(discover_result: &DiscoverResult, index_stats: &IndexStats) -> (usize, usize) {
+    let skipped: HashSet<&Path> = index_stats
+        .files_skipped
+        .iter()
+        .map(|(path, _)| path.as_path())
+        .collect();
+    let count = |files: &[PathBuf]| {
+        files
+            .iter()
+            .filter(|path| !skipped.contains(path.as_path()))
+            .count()
+    };
+    (
+        count(&discover_result.new_files),
+        count(&discover_result.modified_files),
+    )
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -939,4 +1013,92 @@ mod tests {
             "incremental pass must keep the edge through the unchanged re-export"
         );
     }
+
+    // Cleanup of a modified file drops the relationships into its symbols,
+    // so `index --since` must re-resolve the unchanged files holding them.
+    #[test]
+    fn changed_since_reindexes_dependents_of_changed_files() {
+        let dir = tempfile::tempdir().unwrap();
+        let root = dir.path().join("fixture");
+        let pkg = root.join("pkg");
+        std::fs::create_dir_all(&pkg).unwrap();
+        std::fs::write(pkg.join("a.py"), "def helper(x):\n    return x\n").unwrap();
+        std::fs::write(
+            pkg.join("c.py"),
+            "from pkg.a import helper\n\n\ndef caller(x):\n    return helper(x)\n",
+        )
+        .unwrap();
+        std::fs::write(pkg.join("d.py"), "def unrelated():\n    pass\n").unwrap();
+        let git = |args: &[&str]| {
+            let status = std::process::Command::new("git")
+                .args(args)
+                .current_dir(&root)
+                .status()
+                .unwrap();
+            assert!(status.success(), "git {} failed", args.join(" "));
+        };
+        git(&["init", "-q"]);
+        git(&["-c", "user.email=t@t", "-c", "user.name=T", "add", "-A"]);
+        git(&[
+            "-c",
+            "user.email=t@t",
+            "-c",
+            "user.name=T",
+            "commit",
+            "-qm",
+            "init",
+        ]);
+
+        let settings = Arc::new(Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        });
+        let index =
+            Arc::new(DocumentIndex::new(settings.index_path.join("tantivy"), &settings).unwrap());
+        let pipeline = Pipeline::with_settings(Arc::clone(&settings));
+        pipeline
+            .index_incremental(&root, Arc::clone(&index), None, None, false)
+            .unwrap();
+        assert!(calls_edge_exists(&index, "caller", "helper"));
+
+        std::fs::write(pkg.join("a.py"), "def helper(x):\n    return x + 1\n").unwrap();
+        // Same second-granularity mtime race as above
+        std::fs::File::options()
+            .write(true)
+            .open(pkg.join("a.py"))
+            .unwrap()
+            .set_modified(std::time::SystemTime::now() + std::time::Duration::from_secs(5))
+            .unwrap();
+        std::fs::write(pkg.join("e.py"), "def added():\n    pass\n").unwrap();
+        let stats = pipeline
+            .index_changed_since(&root, "HEAD", Arc::clone(&index), None, None, false)
+            .unwrap();
+        assert_eq!(
+            (stats.new_files, stats.modified_files, stats.deleted_files),
+            (1, 2, 0),
+            "a.py changed, c.py depends on it, e.py is untracked"
+        );
+        assert_eq!(index.find_symbols_by_name("added", None).unwrap().len(), 1);
+        assert!(
+            calls_edge_exists(&index, "caller", "helper"),
+            "the dependent's edge into the changed file must be resolved again"
+        );
+
+        std::fs::remove_file(pkg.join("e.py")).unwrap();
+        let stats = pipeline
+            .index_changed_since(&root, "HEAD", Arc::clone(&index), None, None, false)
+            .unwrap();
+        assert_eq!(
+            (stats.new_files, stats.modified_files, stats.deleted_files),
+            (0, 0, 1),
+            "a.py is already up to date and e.py is gone"
+        );
+        assert!(
+            index
+                .find_symbols_by_name("added", None)
+                .unwrap()
+                .is_empty()
+        );
+    }
 }
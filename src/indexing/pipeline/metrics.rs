@@ -172,7 +172,7 @@ impl MemorySnapshot {
 }
 
 /// Format bytes as human-readable string.
-fn format_bytes(bytes: u64) -> String {
+pub(crate) fn format_bytes(bytes: u64) -> String {
     const KB: u64 = 1024;
     const MB: u64 = KB * 1024;
     const GB: u64 = MB * 1024;
@@ -28,19 +28,26 @@
 //! let pipeline = Pipeline::new(settings, config);
 //! let stats = pipeline.index_directory(path, &index)?;
 //! ```
+mod cache;
+mod checkpoint;
 pub mod config;
 mod full;
 mod incremental;
 pub mod metrics;
 mod phase1;
 mod phase2;
+mod remote;
+mod spill;
 pub mod stages;
 mod stats;
 pub mod types;
 mod workers;
 
+pub use cache::ContentCache;
+pub use checkpoint::Checkpoint;
 pub use config::PipelineConfig;
 pub use metrics::{PipelineMetrics, StageMetrics, StageTracker};
+pub use remote::run_worker;
 pub use stages::cleanup::{CleanupStage, CleanupStats};
 pub use stages::context::{ContextStage, ContextStats};
 pub use stages::embed::{EmbedStage, EmbedStats};
@@ -53,7 +60,7 @@ pub use types::{
     DiscoverResult, EmbedOptions, EmbeddingBatch, FileBindings, FileContent, FileRegistration,
     FileSource, IndexBatch, ParsedFile, Phase1Options, PipelineError, PipelineResult, ProgressSink,
     RawImport, RawRelationship, RawSymbol, ResolutionContext, ResolvedBatch, ResolvedRelationship,
-    SingleFileStats, SymbolLookupCache, UnresolvedRelationship, VariableBinding,
+    SingleFileStats, Subtypes, SymbolLookupCache, UnresolvedRelationship, VariableBinding,
 };
 
 use crate::Settings;
@@ -125,6 +132,17 @@ impl Pipeline {
         Ok(())
     }
 
+    /// Whether an interrupted run left a checkpoint in `index`; its
+    /// relationships need resolving even when no file has changed.
+    fn has_checkpoint(&self, index: &DocumentIndex) -> bool {
+        Checkpoint::path(index.path()).exists()
+    }
+
+    /// Remove the checkpoint once the run's relationships are resolved.
+    fn clear_checkpoint(&self, index: &DocumentIndex) -> PipelineResult<()> {
+        Checkpoint::remove(&Checkpoint::path(index.path()))
+    }
+
     /// Save embeddings to disk.
     ///
     /// One policy for every composition path: log at error and propagate.
@@ -137,10 +155,13 @@ impl Pipeline {
         let Some(sem) = semantic else {
             return Ok(());
         };
-        let guard = sem.lock().map_err(|_| PipelineError::Parse {
+        let mut guard = sem.lock().map_err(|_| PipelineError::Parse {
             path: PathBuf::new(),
             reason: "Failed to lock semantic search".to_string(),
         })?;
+        // The run is over: no symbol takes the embeddings retired by
+        // cleanup any more
+        guard.discard_retired();
         guard.save(semantic_path).map_err(|e| {
             tracing::error!(target: "pipeline", "Failed to save embeddings: {e}");
             PipelineError::Parse {
@@ -1,17 +1,22 @@
 //! Phase 1 orchestration: the source -> READ -> PARSE -> COLLECT -> INDEX (+ EMBED) skeleton.
 
+use super::remote::{Coordinator, worker_token};
 use super::stages::{CollectStage, DiscoverStage, IndexStage, ReadStage};
 use super::{
-    EmbedOptions, FileBindings, FileSource, ParseStage, Phase1Options, Pipeline, PipelineError,
-    PipelineMetrics, PipelineResult, ProgressSink, SemanticEmbedStage, StageMetrics, StageTracker,
-    SymbolLookupCache, UnresolvedRelationship, init_parser_cache,
+    Checkpoint, ContentCache, EmbedOptions, FileBindings, FileSource, ParseStage, Phase1Options,
+    Pipeline, PipelineError, PipelineMetrics, PipelineResult, ProgressSink, SemanticEmbedStage,
+    StageMetrics, StageTracker, SymbolLookupCache, UnresolvedRelationship, init_parser_cache,
 };
+use crate::config::PathOverrides;
 use crate::indexing::IndexStats;
+use crate::indexing::diagnostics::{DiagnosticKind, FileDiagnostic};
+use crate::semantic::DocLanguage;
 use crate::storage::DocumentIndex;
 use crossbeam_channel::bounded;
 use std::collections::HashMap;
 use std::path::Path;
 use std::sync::Arc;
+use std::sync::atomic::Ordering;
 use std::thread;
 use std::time::{Duration, Instant};
 
@@ -24,6 +29,11 @@ type Phase1Result = (
     Option<Arc<PipelineMetrics>>,
 );
 
+/// The span of a stage under `parent`, entered by the thread running it
+fn stage_span(parent: &tracing::Span, stage: &'static str) -> tracing::Span {
+    tracing::info_span!(target: "pipeline", parent: parent, "stage", stage, otel.name = stage)
+}
+
 impl Pipeline {
     /// Index a directory using the parallel pipeline (Phase 1).
     ///
@@ -76,9 +86,10 @@ impl Pipeline {
         index: Arc<DocumentIndex>,
         opts: Phase1Options,
     ) -> PipelineResult<Phase1Result> {
-        // Empty file list short-circuits: no counters read, no threads spawned.
+        // Empty file list short-circuits: no counters read, no threads spawned,
+        // unless an interrupted run's relationships are waiting for resolution.
         if let FileSource::List(files) = &source {
-            if files.is_empty() {
+            if files.is_empty() && !self.has_checkpoint(&index) {
                 return Ok((
                     IndexStats::new(),
                     Vec::new(),
@@ -91,6 +102,14 @@ impl Pipeline {
 
         let start = Instant::now();
         let Phase1Options { progress, embed } = opts;
+        // Parent of the spans of the stages, which run on threads of their own
+        let phase_span = tracing::info_span!(
+            target: "pipeline",
+            "phase1",
+            files = tracing::field::Empty,
+            symbols = tracing::field::Empty
+        );
+        let _phase = phase_span.enter();
 
         // Create metrics collector if tracing is enabled
         let metrics = if self.config.pipeline_tracing {
@@ -105,6 +124,10 @@ impl Pipeline {
 
         // Query existing ID counters BEFORE spawning threads
         let (start_file_counter, start_symbol_counter) = self.get_start_counters(&index)?;
+        let coordinator = match &self.settings.indexing.listen {
+            Some(addr) => Some(Coordinator::bind(addr, worker_token())?),
+            None => None,
+        };
 
         // Create bounded channels with backpressure
         let (path_tx, path_rx) = bounded(self.config.path_channel_size);
@@ -112,7 +135,7 @@ impl Pipeline {
         let (parsed_tx, parsed_rx) = bounded(self.config.parsed_channel_size);
         let (batch_tx, batch_rx) = bounded(self.config.batch_channel_size);
         // Embed channel for parallel EMBED stage
-        let (embed_tx, embed_rx) = bounded(self.config.batch_channel_size);
+        let (embed_tx, embed_rx) = bounded(self.config.embed_channel_size);
         let embed_sender = if embed.is_some() {
             Some(embed_tx)
         } else {
@@ -135,18 +158,26 @@ impl Pipeline {
         let batch_size = self.config.batch_size;
         let batches_per_commit = self.config.batches_per_commit;
         let tracing_enabled = self.config.pipeline_tracing;
+        let deterministic = settings.indexing.deterministic;
+        let code_embeddings = settings.semantic_search.code_embeddings;
+        // Settings::load rejects an invalid glob
+        let overrides = PathOverrides::new(&settings).unwrap_or_default();
 
         // Stage 1: SOURCE - directory walk or explicit file list
+        let discover_settings = Arc::clone(&settings);
+        let source_span = stage_span(&phase_span, "discover");
         type SourceJoinHandle = thread::JoinHandle<(PipelineResult<usize>, Option<StageMetrics>)>;
         let source_handle: SourceJoinHandle = match source {
             FileSource::Walk(root) => thread::spawn(move || {
+                let _span = source_span.entered();
                 let tracker = if tracing_enabled {
                     Some(StageTracker::new("DISCOVER", discover_threads))
                 } else {
                     None
                 };
 
-                let stage = DiscoverStage::new(root, discover_threads);
+                let stage =
+                    DiscoverStage::new(root, discover_threads).with_settings(discover_settings);
                 let result = stage.run(path_tx);
 
                 // Record metrics
@@ -157,6 +188,7 @@ impl Pipeline {
                 (result, tracker.map(|t| t.finalize()))
             }),
             FileSource::List(files) => thread::spawn(move || {
+                let _span = source_span.entered();
                 let mut sent = 0;
                 for path in files {
                     if path_tx.send(path).is_err() {
@@ -175,71 +207,106 @@ impl Pipeline {
                 let rx = path_rx.clone();
                 let tx = content_tx.clone();
                 let workspace_root = workspace_root.clone();
+                let span = stage_span(&phase_span, "read");
                 thread::spawn(move || {
+                    let _span = span.entered();
                     let stage = ReadStage::with_workspace_root(1, workspace_root);
                     stage.run(rx, tx)
                 })
             })
             .collect();
+
+        // Workers from `index --worker` take batches of paths alongside READ
+        let coordinator = coordinator.map(|coordinator| {
+            let stop = coordinator.stop_handle();
+            let handle =
+                coordinator.spawn(path_rx.clone(), parsed_tx.clone(), Arc::clone(&settings));
+            (stop, handle)
+        });
         drop(path_rx); // Close original receiver
         drop(content_tx); // Close original sender after cloning
 
         // Stage 3: PARSE - parallel parsing with thread-local parsers (with wait tracking)
-        let parse_handles: Vec<_> = (0..parse_threads)
+        // Stacks sized for the depth budget, so deep nesting is cut off by
+        // the budget rather than overflowing a worker
+        let parse_stack =
+            crate::parsing::parser::parser_stack_size(settings.indexing.max_ast_depth);
+        let mut parse_handles: Vec<_> = (0..parse_threads)
             .map(|_| {
                 let rx = content_rx.clone();
                 let tx = parsed_tx.clone();
                 let settings = Arc::clone(&settings);
                 let module_root = module_root.clone();
-                thread::spawn(move || {
-                    let start = Instant::now();
-                    // Initialize thread-local parser cache
-                    init_parser_cache(settings.clone());
-
-                    let stage = ParseStage::new(settings).with_module_root(module_root);
-                    let mut parsed_count = 0;
-                    let mut error_count = 0;
-                    let mut symbol_count = 0;
-                    let mut input_wait = std::time::Duration::ZERO;
-                    let mut output_wait = std::time::Duration::ZERO;
-
-                    loop {
-                        // Track input wait (time blocked on recv)
-                        let recv_start = Instant::now();
-                        let content = match rx.recv() {
-                            Ok(c) => c,
-                            Err(_) => break, // Channel closed
-                        };
-                        input_wait += recv_start.elapsed();
-
-                        match stage.parse(content) {
-                            Ok(parsed) => {
-                                parsed_count += 1;
-                                symbol_count += parsed.raw_symbols.len();
-
-                                // Track output wait (time blocked on send)
-                                let send_start = Instant::now();
-                                if tx.send(parsed).is_err() {
-                                    break; // Channel closed
+                let span = stage_span(&phase_span, "parse");
+                thread::Builder::new()
+                    .stack_size(parse_stack)
+                    .spawn(move || {
+                        let _span = span.entered();
+                        let start = Instant::now();
+                        // Initialize thread-local parser cache
+                        init_parser_cache(settings.clone());
+
+                        let stage = ParseStage::new(settings).with_module_root(module_root);
+                        let mut parsed_count = 0;
+                        let mut error_count = 0;
+                        let mut diagnostics = Vec::new();
+                        let mut symbol_count = 0;
+                        let mut input_wait = std::time::Duration::ZERO;
+                        let mut output_wait = std::time::Duration::ZERO;
+
+                        loop {
+                            // Track input wait (time blocked on recv)
+                            let recv_start = Instant::now();
+                            let content = match rx.recv() {
+                                Ok(c) => c,
+                                Err(_) => break, // Channel closed
+                            };
+                            input_wait += recv_start.elapsed();
+
+                            let path = content.path.clone();
+                            match stage.parse(content) {
+                                Ok(parsed) => {
+                                    parsed_count += 1;
+                                    symbol_count += parsed.raw_symbols.len();
+                                    diagnostics.extend(parsed.diagnostics.iter().cloned());
+
+                                    // Track output wait (time blocked on send)
+                                    let send_start = Instant::now();
+                                    if tx.send(parsed).is_err() {
+                                        break; // Channel closed
+                                    }
+                                    output_wait += send_start.elapsed();
+                                }
+                                Err(PipelineError::LargeFileSkipped { path, reason }) => {
+                                    diagnostics.push(FileDiagnostic::new(
+                                        path,
+                                        DiagnosticKind::SizeLimit,
+                                        reason,
+                                    ));
+                                }
+                                Err(e) => {
+                                    error_count += 1;
+                                    // Continue on parse errors - don't fail the whole batch
+                                    diagnostics.push(FileDiagnostic::new(
+                                        path,
+                                        DiagnosticKind::ParseFailed,
+                                        e.to_string(),
+                                    ));
                                 }
-                                output_wait += send_start.elapsed();
-                            }
-                            Err(_e) => {
-                                error_count += 1;
-                                // Continue on parse errors - don't fail the whole batch
                             }
                         }
-                    }
 
-                    (
-                        parsed_count,
-                        error_count,
-                        symbol_count,
-                        input_wait,
-                        output_wait,
-                        start.elapsed(),
-                    )
-                })
+                        (
+                            parsed_count,
+                            error_count,
+                            diagnostics,
+                            symbol_count,
+                            input_wait,
+                            output_wait,
+                            start.elapsed(),
+                        )
+                    })
+                    .expect("failed to spawn PARSE worker")
             })
             .collect();
         drop(content_rx);
@@ -255,7 +322,9 @@ impl Pipeline {
             }
             _ => None,
         };
+        let collect_span = stage_span(&phase_span, "collect");
         let collect_handle = thread::spawn(move || {
+            let _span = collect_span.entered();
             let tracker = if tracing_enabled {
                 Some(StageTracker::new("COLLECT", 1).with_secondary("batches"))
             } else {
@@ -263,7 +332,10 @@ impl Pipeline {
             };
 
             let stage = CollectStage::new(batch_size)
-                .with_start_counters(start_file_counter, start_symbol_counter);
+                .with_start_counters(start_file_counter, start_symbol_counter)
+                .with_deterministic(deterministic)
+                .with_code_embeddings(code_embeddings)
+                .with_overrides(overrides);
             let result = stage.run(parsed_rx, batch_tx, embed_sender, embed_total_callback);
 
             // Record items and wait times before finalizing
@@ -279,6 +351,7 @@ impl Pipeline {
         });
 
         // Stage 5a: EMBED (parallel with INDEX) - iff embedding options provided
+        let english_only = embed.as_ref().is_some_and(|e| e.pool.is_english_only());
         let embed_handle = if let Some(EmbedOptions { pool, semantic }) = embed {
             let embed_callback = match &progress {
                 ProgressSink::Dual(dp) => {
@@ -294,8 +367,12 @@ impl Pipeline {
                 _ => None,
             };
 
+            let embed_settings = Arc::clone(&settings);
+            let span = stage_span(&phase_span, "embed");
             Some(thread::spawn(move || {
-                let mut stage = SemanticEmbedStage::new(pool, semantic);
+                let _span = span.entered();
+                let mut stage = SemanticEmbedStage::new(pool, semantic)
+                    .with_cache(ContentCache::from_settings(&embed_settings));
                 if let Some(callback) = embed_callback {
                     stage = stage.with_progress(callback);
                 }
@@ -314,6 +391,7 @@ impl Pipeline {
         // Stage 5b: INDEX (parallel with EMBED) - single-threaded Tantivy writes
         // Clone index Arc for metadata update after pipeline completes
         let index_for_metadata = Arc::clone(&index);
+        let checkpoint = Checkpoint::path(index.path());
         // Completion callback to freeze timer when INDEX finishes
         let index_complete = match &progress {
             ProgressSink::Dual(dp) => Some(Arc::clone(dp)),
@@ -321,7 +399,9 @@ impl Pipeline {
         };
         let index_handle = {
             let mut index_stage = IndexStage::new(index, batches_per_commit)
-                .with_counter_floor(start_file_counter, start_symbol_counter);
+                .with_counter_floor(start_file_counter, start_symbol_counter)
+                .with_spill_limit(self.config.spill_limit)
+                .with_checkpoint(checkpoint);
             match &progress {
                 ProgressSink::Silent => {}
                 ProgressSink::Bar(bar) => {
@@ -335,7 +415,9 @@ impl Pipeline {
                 }
             }
 
+            let span = stage_span(&phase_span, "index");
             thread::spawn(move || {
+                let _span = span.entered();
                 let tracker = if tracing_enabled {
                     Some(StageTracker::new("INDEX", 1).with_secondary("commits"))
                 } else {
@@ -363,11 +445,27 @@ impl Pipeline {
         // bars complete on error paths too. Channel closure cascades shutdown,
         // so all joins terminate regardless of individual stage failures.
         let source_join = source_handle.join();
-        let (read_files, read_errors, read_input_wait, read_output_wait, read_wall_time) =
-            self.join_read_workers(read_handles);
+        let (
+            read_files,
+            read_errors,
+            read_failures,
+            read_input_wait,
+            read_output_wait,
+            read_wall_time,
+        ) = self.join_read_workers(read_handles);
+        // Once READ is done every path is taken: stop accepting workers and
+        // join the connections to them with the PARSE workers
+        if let Some((stop, handle)) = coordinator {
+            stop.store(true, Ordering::Relaxed);
+            match handle.join() {
+                Ok(handles) => parse_handles.extend(handles),
+                Err(_) => tracing::error!(target: "pipeline", "Coordinator thread panicked"),
+            }
+        }
         let (
             parsed_files,
             parse_errors,
+            parse_diagnostics,
             total_symbols,
             parse_input_wait,
             parse_output_wait,
@@ -468,6 +566,19 @@ impl Pipeline {
                         stats.embeddings_failed = failed;
                     }
 
+                    // English queries miss the other doc comments under an
+                    // English-only model
+                    if english_only {
+                        let mut foreign: Vec<(DocLanguage, usize)> = embed_stats
+                            .doc_languages
+                            .iter()
+                            .filter(|(language, _)| **language != DocLanguage::English)
+                            .map(|(language, count)| (*language, *count))
+                            .collect();
+                        foreign.sort_by(|a, b| b.1.cmp(&a.1).then(a.0.cmp(&b.0)));
+                        stats.foreign_doc_files = foreign;
+                    }
+
                     // Add EMBED metrics to pipeline report
                     if let Some(m) = &metrics {
                         m.add_stage(StageMetrics {
@@ -512,6 +623,15 @@ impl Pipeline {
         // Update stats with timing and error counts
         stats.elapsed = start.elapsed();
         stats.files_failed = read_errors + parse_errors;
+        stats.files_skipped = parse_diagnostics
+            .iter()
+            .filter(|d| d.kind == DiagnosticKind::SizeLimit)
+            .map(|d| (d.path.clone(), d.message.clone()))
+            .collect();
+        stats.diagnostics = read_failures;
+        stats.diagnostics.extend(parse_diagnostics);
+        phase_span.record("files", stats.files_indexed);
+        phase_span.record("symbols", stats.symbols_found);
 
         // Finalize metrics but don't log (caller logs after StatusLine drop)
         if let Some(ref m) = metrics {
@@ -1,11 +1,13 @@
 //! Phase 2 orchestration: two-pass relationship resolution.
 
+use super::types::defining_module;
 use super::{
     ContextStage, FileBindings, Phase2Stats, Pipeline, PipelineError, PipelineResult, ResolveStage,
     SymbolLookupCache, UnresolvedRelationship, WriteStage,
 };
 use crate::RelationKind;
-use crate::parsing::ParserFactory;
+use crate::parsing::{Import, LanguageId, ParserFactory};
+use crate::relationship::UnresolvedReference;
 use crate::storage::DocumentIndex;
 use std::collections::HashMap;
 use std::sync::Arc;
@@ -47,6 +49,12 @@ impl Pipeline {
     ) -> PipelineResult<Phase2Stats> {
         let start = Instant::now();
         let total_relationships = unresolved.len();
+        let _span = tracing::info_span!(
+            target: "pipeline",
+            "phase2",
+            relationships = total_relationships
+        )
+        .entered();
 
         if unresolved.is_empty() {
             return Ok(Phase2Stats {
@@ -59,10 +67,6 @@ impl Pipeline {
             });
         }
 
-        // Pre-pass: register re-export aliases before any context is built,
-        // so both context-time import bindings and Tier 2 matching see them.
-        populate_reexport_aliases(&symbol_cache, &index);
-
         // Create stages
         let factory = Arc::new(ParserFactory::new(Arc::clone(&self.settings)));
         let context_stage = ContextStage::new(
@@ -71,6 +75,10 @@ impl Pipeline {
             factory,
             Arc::clone(&self.settings),
         );
+
+        // Pre-pass: register re-export aliases before any context is built,
+        // so both context-time import bindings and Tier 2 matching see them.
+        populate_reexport_aliases(&symbol_cache, &index, &context_stage);
         let mut write_stage = WriteStage::new(Arc::clone(&index));
 
         // Split relationships by kind
@@ -126,17 +134,23 @@ impl Pipeline {
             // Extends relationships BEFORE build_contexts(others) consumes the vec
             // and BEFORE any Calls resolution in this pass fires resolve_static_call.
             let inheritance_resolvers = context_stage.build_inheritance_resolvers(&others);
+            let subtypes = context_stage.build_subtypes(&others);
             let contexts = context_stage.build_contexts(others, &variable_bindings);
             let behaviors = context_stage.behaviors();
             let resolve_stage = ResolveStage::new(Arc::clone(&symbol_cache), behaviors)
-                .with_inheritance_resolvers(inheritance_resolvers);
+                .with_inheritance_resolvers(inheritance_resolvers)
+                .with_subtypes(subtypes);
 
             for ctx in contexts {
                 let rel_count = ctx.unresolved_rels.len() as u64;
-                let (batch, resolve_stats) = resolve_stage.resolve(&ctx);
+                let (mut batch, resolve_stats) = resolve_stage.resolve(&ctx);
                 stats.calls_resolved += resolve_stats.calls_resolved;
                 stats.other_resolved += resolve_stats.resolved - resolve_stats.calls_resolved;
+                let unmatched = std::mem::take(&mut batch.unmatched);
                 write_stage.write(batch);
+                if self.settings.indexing.keep_unresolved {
+                    store_unmatched(&index, &symbol_cache, &unmatched);
+                }
 
                 // Update progress bar
                 if let Some(ref prog) = progress {
@@ -222,46 +236,111 @@ impl Pipeline {
     }
 }
 
-/// Register re-export aliases from python module namespaces.
+/// Register re-export aliases from module namespaces.
 ///
 /// An import binds its name in the importing module's namespace, so
 /// `pkg/__init__.py: from pkg.a import helper` exposes `pkg.helper`
 /// (`__init__` maps to the package via module_path stripping; plain modules
-/// re-export the same way). Alias resolution itself is language-agnostic in
-/// the cache; only collection is python-gated here because python is the
-/// language whose canonical public API surface is re-export-shaped. Read
-/// failures degrade to fewer aliases, never an error: a missing alias means
-/// an unresolved edge, same as before the pre-pass existed.
-fn populate_reexport_aliases(cache: &SymbolLookupCache, index: &DocumentIndex) {
-    let python = crate::parsing::LanguageId::new("python");
-
-    let mut entries: Vec<(String, Vec<crate::parsing::Import>)> = Vec::new();
+/// re-export the same way), as do TypeScript barrels
+/// (`index.ts: export { helper } from './a'`) and Rust `pub use`. Each
+/// language's behavior makes the paths absolute
+/// (`LanguageBehavior::reexport_import_path`); languages whose imports
+/// re-export nothing are skipped. Read failures degrade to fewer aliases,
+/// never an error: a missing alias means an unresolved edge, same as before
+/// the pre-pass existed.
+fn populate_reexport_aliases(
+    cache: &SymbolLookupCache,
+    index: &DocumentIndex,
+    context_stage: &ContextStage,
+) {
+    let mut by_language: HashMap<LanguageId, Vec<(String, Vec<Import>)>> = HashMap::new();
     for file_id in cache.file_ids() {
         let symbol_ids = cache.symbols_in_file(file_id);
         let Some(first) = symbol_ids.first().and_then(|&id| cache.get_ref(id)) else {
             continue;
         };
-        if first.language_id.as_ref() != Some(&python) {
+        let Some(language) = first.language_id else {
             continue;
-        }
-        let Some(module_path) = first.module_path.as_deref().map(String::from) else {
+        };
+        let behavior = context_stage.get_behavior(language);
+        let Some(module_path) = defining_module(&first, behavior.as_ref()).map(String::from) else {
             continue;
         };
+        let file_path = std::path::PathBuf::from(first.file_path.as_ref());
         drop(first);
 
-        let imports = index.get_imports_for_file(file_id).unwrap_or_default();
+        let imports: Vec<Import> = index
+            .get_imports_for_file(file_id)
+            .unwrap_or_default()
+            .into_iter()
+            .filter_map(|import| {
+                let path = behavior.reexport_import_path(&import, &module_path, &file_path)?;
+                Some(Import { path, ..import })
+            })
+            .collect();
         if !imports.is_empty() {
-            entries.push((module_path, imports));
+            by_language
+                .entry(language)
+                .or_default()
+                .push((module_path, imports));
         }
     }
 
-    if entries.is_empty() {
-        return;
+    for (language, entries) in by_language {
+        cache.populate_module_aliases(&entries, context_stage.get_behavior(language).as_ref());
+        tracing::debug!(
+            target: "pipeline",
+            "Re-export pre-pass: {} {} modules with imports scanned",
+            entries.len(),
+            language.as_str()
+        );
+    }
+}
+
+/// Keep the relationships to names no symbol of the index has, for shard
+/// indexes to be linked to each other (`crate::shard::ShardSet`).
+///
+/// A method called on a value is left out: its target depends on the
+/// value's type, which a name looked up in another shard does not give.
+/// Write failures are logged and skipped, like those of relationships.
+fn store_unmatched(
+    index: &DocumentIndex,
+    cache: &SymbolLookupCache,
+    unmatched: &[UnresolvedRelationship],
+) {
+    for rel in unmatched {
+        let Some(from_id) = rel.from_id else {
+            continue;
+        };
+        let on_value = rel
+            .metadata
+            .as_ref()
+            .is_some_and(|metadata| metadata.receiver.is_some() && !metadata.static_call);
+        if on_value {
+            continue;
+        }
+        let Some(file_path) = cache.get_ref(from_id).map(|sym| sym.file_path.to_string()) else {
+            continue;
+        };
+        let (line, column) = rel
+            .to_range
+            .map(|range| (range.start_line, range.start_column))
+            .unwrap_or_default();
+        let reference = UnresolvedReference {
+            from_id,
+            to_name: rel.to_name.to_string(),
+            kind: rel.kind,
+            file_id: rel.file_id,
+            file_path,
+            line,
+            column,
+        };
+        if let Err(e) = index.store_unresolved(&reference) {
+            tracing::warn!(
+                target: "pipeline",
+                "Failed to store unresolved reference to {}: {e}",
+                rel.to_name
+            );
+        }
     }
-    cache.populate_module_aliases(&entries, &python);
-    tracing::debug!(
-        target: "pipeline",
-        "Re-export pre-pass: {} python modules with imports scanned",
-        entries.len()
-    );
 }
@@ -0,0 +1,685 @@
+//! Distributed parsing: a coordinator and its workers
+//!
+//! `codanna index --listen <addr>` makes a run the coordinator. Besides its
+//! own READ and PARSE threads, it hands batches of the files to index to the
+//! workers that connect: `codanna index --worker <addr>` on other machines,
+//! each over a checkout of the same commit. A worker reads and parses its
+//! batches and sends the parsed files back. COLLECT and INDEX stay on the
+//! coordinator, which alone assigns IDs and writes the index, and computes
+//! the embeddings of the symbols it receives.
+//!
+//! Messages are lines of JSON over TCP. Paths travel relative to the
+//! workspace root, so each checkout may live anywhere; a worker at another
+//! commit or of another version is turned away. The files of a worker that
+//! fails or goes silent are parsed by the coordinator instead.
+//!
+//! A coordinator listening beyond loopback requires a shared secret, set as
+//! `CODANNA_WORKER_TOKEN` for it and its workers: a worker sends it in its
+//! hello, which is compared by digest in constant time, and one without it
+//! is turned away. The connection itself is not encrypted, so the network
+//! between them should be trusted.
+//!
+//! Workers do not compute embeddings: they ship parsed files, not vectors,
+//! and the embedding model stays loaded on the coordinator alone.
+
+use super::stages::ReadStage;
+use super::types::{ParsedFile, PipelineError, PipelineResult};
+use super::workers::{ParseJoinHandle, ParseTotals};
+use super::{ParseStage, PipelineConfig, init_parser_cache};
+use crate::Settings;
+use crate::indexing::diagnostics::{DiagnosticKind, FileDiagnostic};
+use crate::parsing::parser::parser_stack_size;
+use crossbeam_channel::{Receiver, Sender};
+use serde::{Deserialize, Serialize};
+use std::io::{BufRead, BufReader, BufWriter, Write};
+use std::net::{TcpListener, TcpStream};
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::thread;
+use std::time::{Duration, Instant};
+
+/// Files handed to a worker at a time
+const WORKER_BATCH: usize = 32;
+
+/// How long a worker may take over a batch before its files are parsed by
+/// the coordinator
+const BATCH_TIMEOUT: Duration = Duration::from_secs(300);
+
+/// How long a worker keeps trying to reach a coordinator that is not
+/// listening yet
+const CONNECT_WAIT: Duration = Duration::from_secs(60);
+
+/// Env var holding the secret workers present to the coordinator
+const TOKEN_ENV: &str = "CODANNA_WORKER_TOKEN";
+
+/// One line of the protocol
+#[derive(Serialize, Deserialize)]
+#[serde(tag = "message", rename_all = "snake_case")]
+enum Message {
+    /// Worker to coordinator, on connecting
+    Hello {
+        version: String,
+        commit: Option<String>,
+        /// The secret of `CODANNA_WORKER_TOKEN`, if set
+        #[serde(default, skip_serializing_if = "Option::is_none")]
+        token: Option<String>,
+    },
+    /// Coordinator to worker: it is turned away
+    Rejected { reason: String },
+    /// Coordinator to worker: files to read and parse
+    Batch { paths: Vec<PathBuf> },
+    /// Worker to coordinator: the files of the last batch
+    Parsed {
+        files: Vec<ParsedFile>,
+        skipped: Vec<(PathBuf, String)>,
+        errors: usize,
+    },
+    /// Coordinator to worker: no files left
+    Done,
+}
+
+fn remote_error(e: impl std::fmt::Display) -> PipelineError {
+    PipelineError::Index(crate::IndexError::General(format!(
+        "Distributed indexing failed: {e}"
+    )))
+}
+
+struct Connection {
+    reader: BufReader<TcpStream>,
+    writer: BufWriter<TcpStream>,
+    line: String,
+}
+
+impl Connection {
+    fn new(stream: TcpStream) -> std::io::Result<Self> {
+        Ok(Self {
+            reader: BufReader::new(stream.try_clone()?),
+            writer: BufWriter::new(stream),
+            line: String::new(),
+        })
+    }
+
+    fn send(&mut self, message: &Message) -> std::io::Result<()> {
+        serde_json::to_writer(&mut self.writer, message)?;
+        self.writer.write_all(b"\n")?;
+        self.writer.flush()
+    }
+
+    /// The next message, `None` once the peer closed the connection
+    fn receive(&mut self) -> std::io::Result<Option<Message>> {
+        self.line.clear();
+        if self.reader.read_line(&mut self.line)? == 0 {
+            return Ok(None);
+        }
+        Ok(Some(serde_json::from_str(&self.line)?))
+    }
+}
+
+/// The secret of `CODANNA_WORKER_TOKEN`, None when unset or empty
+pub(super) fn worker_token() -> Option<String> {
+    std::env::var(TOKEN_ENV)
+        .ok()
+        .filter(|token| !token.is_empty())
+}
+
+/// Whether a worker presented the token `expected`. Compared by digest, in
+/// constant time, so the time taken says nothing of how close it came.
+fn token_matches(presented: Option<&str>, expected: &str) -> bool {
+    use sha2::{Digest, Sha256};
+    let presented = Sha256::digest(presented.unwrap_or_default().as_bytes());
+    let expected = Sha256::digest(expected.as_bytes());
+    let diff = presented
+        .iter()
+        .zip(expected.iter())
+        .fold(0u8, |diff, (x, y)| diff | (x ^ y));
+    std::hint::black_box(diff) == 0
+}
+
+/// The commit checked out at `root`, if it is a git repository
+fn checkout_commit(root: &Path) -> Option<String> {
+    crate::git::get_commit_sha(root).ok()
+}
+
+/// A path as it travels: relative to the workspace root when under it
+fn wire_path(root: Option<&Path>, path: &Path) -> PathBuf {
+    root.and_then(|root| path.strip_prefix(root).ok())
+        .unwrap_or(path)
+        .to_path_buf()
+}
+
+/// Read and parse one file with its path as the coordinator knows it
+fn read_and_parse(
+    stage: &ParseStage,
+    read: &ReadStage,
+    path: PathBuf,
+    file: &Path,
+) -> PipelineResult<ParsedFile> {
+    let mut content = read.read_single(&file.to_path_buf())?;
+    content.path = path;
+    stage.parse(content)
+}
+
+/// Workers connected to a run, served while it has files to hand out
+pub(super) struct Coordinator {
+    listener: TcpListener,
+    stop: Arc<AtomicBool>,
+    /// The secret workers must present
+    token: Option<String>,
+}
+
+impl Coordinator {
+    /// Listen on `addr` for workers presenting `token`, which an address
+    /// beyond loopback requires
+    pub(super) fn bind(addr: &str, token: Option<String>) -> PipelineResult<Self> {
+        let listener = TcpListener::bind(addr)
+            .and_then(|listener| {
+                listener.set_nonblocking(true)?;
+                Ok(listener)
+            })
+            .map_err(|e| remote_error(format!("cannot listen on {addr}: {e}")))?;
+        let loopback = listener
+            .local_addr()
+            .is_ok_and(|local| local.ip().is_loopback());
+        if !loopback && token.is_none() {
+            return Err(remote_error(format!(
+                "refusing to listen on {addr} without a token; set {TOKEN_ENV} for the coordinator and its workers"
+            )));
+        }
+        tracing::info!(
+            target: "pipeline",
+            "Listening for indexing workers on {}",
+            listener.local_addr().map_or_else(|_| addr.to_string(), |a| a.to_string())
+        );
+        Ok(Self {
+            listener,
+            stop: Arc::new(AtomicBool::new(false)),
+            token,
+        })
+    }
+
+    /// Stops accepting workers; call once every path has been taken
+    pub(super) fn stop_handle(&self) -> Arc<AtomicBool> {
+        Arc::clone(&self.stop)
+    }
+
+    /// Accept workers until stopped, each served from a thread that takes
+    /// batches from `paths` and sends what comes back to `parsed`. The
+    /// handles of those threads join like PARSE workers.
+    pub(super) fn spawn(
+        self,
+        paths: Receiver<PathBuf>,
+        parsed: Sender<ParsedFile>,
+        settings: Arc<Settings>,
+    ) -> thread::JoinHandle<Vec<ParseJoinHandle>> {
+        thread::spawn(move || {
+            let root = settings.workspace_root.clone();
+            let commit = root.as_deref().and_then(checkout_commit);
+            let stack_size = parser_stack_size(settings.indexing.max_ast_depth);
+            let mut handles = Vec::new();
+            while !self.stop.load(Ordering::Relaxed) {
+                let (stream, peer) = match self.listener.accept() {
+                    Ok(accepted) => accepted,
+                    Err(e) if e.kind() == std::io::ErrorKind::WouldBlock => {
+                        thread::sleep(Duration::from_millis(50));
+                        continue;
+                    }
+                    Err(e) => {
+                        tracing::warn!(target: "pipeline", "Failed to accept a worker: {e}");
+                        thread::sleep(Duration::from_millis(50));
+                        continue;
+                    }
+                };
+                let serve = ServeWorker {
+                    paths: paths.clone(),
+                    parsed: parsed.clone(),
+                    settings: Arc::clone(&settings),
+                    root: root.clone(),
+                    commit: commit.clone(),
+                    token: self.token.clone(),
+                };
+                let handle = thread::Builder::new()
+                    .stack_size(stack_size)
+                    .spawn(move || serve.run(stream, peer.to_string()));
+                match handle {
+                    Ok(handle) => handles.push(handle),
+                    Err(e) => {
+                        tracing::warn!(target: "pipeline", "Failed to serve worker {peer}: {e}")
+                    }
+                }
+            }
+            handles
+        })
+    }
+}
+
+/// The coordinator's side of one worker connection
+struct ServeWorker {
+    paths: Receiver<PathBuf>,
+    parsed: Sender<ParsedFile>,
+    settings: Arc<Settings>,
+    root: Option<PathBuf>,
+    commit: Option<String>,
+    token: Option<String>,
+}
+
+impl ServeWorker {
+    fn run(self, stream: TcpStream, peer: String) -> ParseTotals {
+        let start = Instant::now();
+        let mut files = 0;
+        let mut errors = 0;
+        let mut diagnostics = Vec::new();
+        let mut symbols = 0;
+        let mut input_wait = Duration::ZERO;
+        let mut output_wait = Duration::ZERO;
+
+        let mut connection = match self.welcome(stream) {
+            Ok(connection) => Some(connection),
+            Err(e) => {
+                tracing::warn!(target: "pipeline", "Worker {peer} turned away: {e}");
+                return (
+                    0,
+                    0,
+                    diagnostics,
+                    0,
+                    input_wait,
+                    output_wait,
+                    start.elapsed(),
+                );
+            }
+        };
+        tracing::info!(target: "pipeline", "Worker {peer} connected");
+
+        loop {
+            let recv_start = Instant::now();
+            let Ok(first) = self.paths.recv() else {
+                break; // Every path is taken
+            };
+            input_wait += recv_start.elapsed();
+            let mut batch = vec![first];
+            batch.extend(self.paths.try_iter().take(WORKER_BATCH - 1));
+
+            let received = match self.exchange(&mut connection, &batch) {
+                Ok(received) => received,
+                Err(e) => {
+                    tracing::warn!(
+                        target: "pipeline",
+                        "Worker {peer} failed ({e}); parsing its {} files here",
+                        batch.len()
+                    );
+                    self.parse_here(batch)
+                }
+            };
+            let (batch_files, batch_skipped, batch_errors) = received;
+            errors += batch_errors;
+            diagnostics.extend(batch_skipped.into_iter().map(|(path, reason)| {
+                FileDiagnostic::new(path, DiagnosticKind::SizeLimit, reason)
+            }));
+            for file in batch_files {
+                files += 1;
+                symbols += file.raw_symbols.len();
+                diagnostics.extend(file.diagnostics.iter().cloned());
+                let send_start = Instant::now();
+                if self.parsed.send(file).is_err() {
+                    return (
+                        files,
+                        errors,
+                        diagnostics,
+                        symbols,
+                        input_wait,
+                        output_wait,
+                        start.elapsed(),
+                    );
+                }
+                output_wait += send_start.elapsed();
+            }
+            if connection.is_none() {
+                break;
+            }
+        }
+        if let Some(connection) = connection.as_mut() {
+            let _ = connection.send(&Message::Done);
+        }
+
+        (
+            files,
+            errors,
+            diagnostics,
+            symbols,
+            input_wait,
+            output_wait,
+            start.elapsed(),
+        )
+    }
+
+    /// Check the worker's hello; a worker without the token, or that may
+    /// parse differently, is told why and dropped
+    fn welcome(&self, stream: TcpStream) -> std::io::Result<Connection> {
+        stream.set_nonblocking(false)?;
+        stream.set_read_timeout(Some(BATCH_TIMEOUT))?;
+        let mut connection = Connection::new(stream)?;
+        let reason = match connection.receive()? {
+            Some(Message::Hello {
+                version,
+                commit,
+                token,
+            }) => {
+                let expected = self.token.as_deref();
+                if expected.is_some_and(|expected| !token_matches(token.as_deref(), expected)) {
+                    Some(format!("worker presented no or a wrong {TOKEN_ENV}"))
+                } else if version != env!("CARGO_PKG_VERSION") {
+                    Some(format!(
+                        "worker runs codanna {version}, coordinator {}",
+                        env!("CARGO_PKG_VERSION")
+                    ))
+                } else {
+                    match (&commit, &self.commit) {
+                        (Some(theirs), Some(ours)) if theirs != ours => Some(format!(
+                            "worker is at commit {theirs}, coordinator at {ours}"
+                        )),
+                        _ => None,
+                    }
+                }
+            }
+            _ => Some("expected a hello".to_string()),
+        };
+        match reason {
+            None => Ok(connection),
+            Some(reason) => {
+                let _ = connection.send(&Message::Rejected {
+                    reason: reason.clone(),
+                });
+                Err(std::io::Error::other(reason))
+            }
+        }
+    }
+
+    /// Send a batch and wait for its files; the connection is dropped on
+    /// any failure
+    fn exchange(
+        &self,
+        connection: &mut Option<Connection>,
+        batch: &[PathBuf],
+    ) -> std::io::Result<(Vec<ParsedFile>, Vec<(PathBuf, String)>, usize)> {
+        let Some(open) = connection.as_mut() else {
+            return Err(std::io::Error::other("connection closed"));
+        };
+        let paths = batch
+            .iter()
+            .map(|path| wire_path(self.root.as_deref(), path))
+            .collect();
+        let reply = open
+            .send(&Message::Batch { paths })
+            .and_then(|()| open.receive());
+        match reply {
+            Ok(Some(Message::Parsed {
+                files,
+                skipped,
+                errors,
+            })) => Ok((files, skipped, errors)),
+            other => {
+                *connection = None;
+                match other {
+                    Err(e) => Err(e),
+                    Ok(None) => Err(std::io::Error::other("connection closed")),
+                    Ok(Some(_)) => Err(std::io::Error::other("unexpected message")),
+                }
+            }
+        }
+    }
+
+    /// Parse the files of a failed batch on the coordinator
+    fn parse_here(&self, batch: Vec<PathBuf>) -> (Vec<ParsedFile>, Vec<(PathBuf, String)>, usize) {
+        init_parser_cache(Arc::clone(&self.settings));
+        let stage = ParseStage::new(Arc::clone(&self.settings));
+        let read = ReadStage::new(1);
+        let mut files = Vec::new();
+        let mut skipped = Vec::new();
+        let mut errors = 0;
+        for file in batch {
+            let path = wire_path(self.root.as_deref(), &file);
+            match read_and_parse(&stage, &read, path, &file) {
+                Ok(parsed) => files.push(parsed),
+                Err(PipelineError::LargeFileSkipped { path, reason }) => {
+                    skipped.push((path, reason))
+                }
+                Err(_) => errors += 1,
+            }
+        }
+        (files, skipped, errors)
+    }
+}
+
+/// Work for the coordinator at `addr` until it runs out of files: one
+/// connection per parse thread, each reading and parsing the batches it is
+/// handed from the checkout at the workspace root. Returns the number of
+/// files parsed.
+pub fn run_worker(addr: &str, settings: Arc<Settings>) -> PipelineResult<usize> {
+    let threads = PipelineConfig::from_settings(&settings).parse_threads;
+    let stack_size = parser_stack_size(settings.indexing.max_ast_depth);
+    let root = match settings.workspace_root.clone() {
+        Some(root) => root,
+        None => std::env::current_dir().map_err(remote_error)?,
+    };
+    let commit = checkout_commit(&root);
+    let token = worker_token();
+
+    let handles: Vec<_> = (0..threads)
+        .map(|_| {
+            let addr = addr.to_string();
+            let settings = Arc::clone(&settings);
+            let root = root.clone();
+            let commit = commit.clone();
+            let token = token.clone();
+            thread::Builder::new()
+                .stack_size(stack_size)
+                .spawn(move || work(&addr, settings, &root, commit, token))
+                .map_err(remote_error)
+        })
+        .collect::<PipelineResult<_>>()?;
+
+    let mut parsed = 0;
+    let mut failure = None;
+    for handle in handles {
+        match handle.join() {
+            Ok(Ok(files)) => parsed += files,
+            Ok(Err(e)) => failure = failure.or(Some(e)),
+            Err(_) => failure = failure.or(Some(remote_error("worker thread panicked"))),
+        }
+    }
+    match failure {
+        Some(e) if parsed == 0 => Err(e),
+        Some(e) => {
+            tracing::warn!(target: "pipeline", "{e}");
+            Ok(parsed)
+        }
+        None => Ok(parsed),
+    }
+}
+
+/// One connection of a worker
+fn work(
+    addr: &str,
+    settings: Arc<Settings>,
+    root: &Path,
+    commit: Option<String>,
+    token: Option<String>,
+) -> PipelineResult<usize> {
+    let waiting = Instant::now();
+    let stream = loop {
+        match TcpStream::connect(addr) {
+            Ok(stream) => break stream,
+            Err(e) if waiting.elapsed() >= CONNECT_WAIT => {
+                return Err(remote_error(format!(
+                    "cannot reach coordinator at {addr}: {e}"
+                )));
+            }
+            Err(_) => thread::sleep(Duration::from_secs(1)),
+        }
+    };
+    let mut connection = Connection::new(stream).map_err(remote_error)?;
+    connection
+        .send(&Message::Hello {
+            version: env!("CARGO_PKG_VERSION").to_string(),
+            commit,
+            token,
+        })
+        .map_err(remote_error)?;
+
+    init_parser_cache(Arc::clone(&settings));
+    let stage = ParseStage::new(settings);
+    let read = ReadStage::new(1);
+    let mut parsed = 0;
+    loop {
+        let paths = match connection.receive().map_err(remote_error)? {
+            Some(Message::Batch { paths }) => paths,
+            Some(Message::Rejected { reason }) => {
+                return Err(remote_error(format!("coordinator refused: {reason}")));
+            }
+            Some(Message::Done) | None => return Ok(parsed),
+            Some(_) => return Err(remote_error("unexpected message from coordinator")),
+        };
+
+        let mut files = Vec::with_capacity(paths.len());
+        let mut skipped = Vec::new();
+        let mut errors = 0;
+        for path in paths {
+            let file = root.join(&path);
+            match read_and_parse(&stage, &read, path, &file) {
+                Ok(file) => files.push(file),
+                Err(PipelineError::LargeFileSkipped { path, reason }) => {
+                    skipped.push((path, reason))
+                }
+                Err(e) => {
+                    tracing::debug!(target: "pipeline", "{e}");
+                    errors += 1;
+                }
+            }
+        }
+        parsed += files.len();
+        connection
+            .send(&Message::Parsed {
+                files,
+                skipped,
+                errors,
+            })
+            .map_err(remote_error)?;
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crossbeam_channel::unbounded;
+
+    #[test]
+    fn test_worker_parses_batches_for_coordinator() {
+        let dir = tempfile::tempdir().unwrap();
+        let root = dir.path().canonicalize().unwrap();
+        std::fs::write(root.join("a.rs"), "pub fn alpha() {}\n").unwrap();
+        std::fs::write(root.join("b.rs"), "pub fn beta() {}\npub fn gamma() {}\n").unwrap();
+        let settings = Arc::new(Settings {
+            workspace_root: Some(root.clone()),
+            ..Default::default()
+        });
+
+        let coordinator = Coordinator::bind("127.0.0.1:0", None).unwrap();
+        let addr = coordinator.listener.local_addr().unwrap().to_string();
+        let stop = coordinator.stop_handle();
+        let (path_tx, path_rx) = unbounded();
+        let (parsed_tx, parsed_rx) = unbounded();
+        path_tx.send(root.join("a.rs")).unwrap();
+        path_tx.send(root.join("b.rs")).unwrap();
+        drop(path_tx);
+        let accept = coordinator.spawn(path_rx, parsed_tx, Arc::clone(&settings));
+
+        // The worker ends once the coordinator has no paths left
+        assert_eq!(work(&addr, settings, &root, None, None).unwrap(), 2);
+        stop.store(true, Ordering::Relaxed);
+        let totals: Vec<ParseTotals> = accept
+            .join()
+            .unwrap()
+            .into_iter()
+            .map(|handle| handle.join().unwrap())
+            .collect();
+        assert_eq!(totals.len(), 1);
+        assert_eq!((totals[0].0, totals[0].1, totals[0].3), (2, 0, 3));
+
+        let mut received: Vec<ParsedFile> = parsed_rx.try_iter().collect();
+        received.sort_by(|a, b| a.path.cmp(&b.path));
+        assert_eq!(received[0].path, PathBuf::from("a.rs"));
+        assert_eq!(received[1].raw_symbols.len(), 2);
+        assert_eq!(received[1].language_id.as_str(), "rust");
+    }
+
+    /// Whether the coordinator turned away a worker at `commit` presenting
+    /// `token`, and what the worker was told
+    fn welcome_worker(
+        coordinator_token: Option<&str>,
+        commit: &str,
+        token: Option<&str>,
+    ) -> (bool, PipelineResult<usize>) {
+        let dir = tempfile::tempdir().unwrap();
+        let coordinator = Coordinator::bind("127.0.0.1:0", None).unwrap();
+        let addr = coordinator.listener.local_addr().unwrap().to_string();
+        let (_path_tx, path_rx) = unbounded();
+        let (parsed_tx, _parsed_rx) = unbounded();
+        let settings = Arc::new(Settings::default());
+        let serve = ServeWorker {
+            paths: path_rx,
+            parsed: parsed_tx,
+            settings: Arc::clone(&settings),
+            root: None,
+            commit: Some("abc".to_string()),
+            token: coordinator_token.map(str::to_string),
+        };
+        let accept = thread::spawn(move || {
+            loop {
+                match coordinator.listener.accept() {
+                    Ok((stream, _)) => return serve.welcome(stream).is_err(),
+                    Err(_) => thread::sleep(Duration::from_millis(10)),
+                }
+            }
+        });
+
+        let worked = work(
+            &addr,
+            settings,
+            dir.path(),
+            Some(commit.to_string()),
+            token.map(str::to_string),
+        );
+        (accept.join().unwrap(), worked)
+    }
+
+    #[test]
+    fn test_worker_at_another_commit_is_turned_away() {
+        let (refused, worked) = welcome_worker(None, "def", None);
+        assert!(refused);
+        let message = worked.unwrap_err().to_string();
+        assert!(message.contains("commit def"), "{message}");
+    }
+
+    #[test]
+    fn test_worker_without_the_token_is_turned_away() {
+        for token in [None, Some("guess")] {
+            let (refused, worked) = welcome_worker(Some("secret"), "abc", token);
+            assert!(refused);
+            let message = worked.unwrap_err().to_string();
+            assert!(message.contains(TOKEN_ENV), "{message}");
+        }
+        let (refused, _) = welcome_worker(Some("secret"), "abc", Some("secret"));
+        assert!(!refused);
+
+        assert!(token_matches(Some("secret"), "secret"));
+        assert!(!token_matches(Some("secret!"), "secret"));
+        assert!(!token_matches(None, "secret"));
+    }
+
+    #[test]
+    fn test_coordinator_beyond_loopback_requires_a_token() {
+        let error = Coordinator::bind("0.0.0.0:0", None).err().unwrap();
+        assert!(error.to_string().contains(TOKEN_ENV), "{error}");
+        assert!(Coordinator::bind("0.0.0.0:0", Some("secret".to_string())).is_ok());
+    }
+}
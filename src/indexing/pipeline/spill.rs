@@ -0,0 +1,203 @@
+//! Spilling pending relationships to disk
+//!
+//! Relationships wait in the INDEX stage until every symbol is indexed, so
+//! they are the part of Phase 1 memory that grows with the whole repository.
+//! Under `indexing.max_memory_mb` the stage writes them to a temporary file
+//! whenever more than a limit are pending, and reads them back once Phase 1
+//! is done and the read and parse buffers are gone.
+
+use super::types::{PipelineError, PipelineResult, UnresolvedRelationship};
+use crate::parsing::LanguageId;
+use crate::relationship::{RelationKind, RelationshipMetadata};
+use crate::types::{FileId, Range, SymbolId};
+use serde::{Deserialize, Serialize};
+use std::fs::File;
+use std::io::{BufRead, BufReader, BufWriter, Seek, SeekFrom, Write};
+use std::sync::Arc;
+
+/// A relationship as a line of a file on disk, with its target language
+/// written as `L`
+#[derive(Serialize, Deserialize)]
+pub(super) struct RelationshipRecord<L> {
+    from_id: Option<SymbolId>,
+    from_name: Arc<str>,
+    to_name: Arc<str>,
+    pub(super) file_id: FileId,
+    kind: RelationKind,
+    metadata: Option<RelationshipMetadata>,
+    to_range: Option<Range>,
+    target_language: Option<L>,
+}
+
+impl<L> RelationshipRecord<L> {
+    pub(super) fn new(rel: UnresolvedRelationship, language: impl FnOnce(LanguageId) -> L) -> Self {
+        Self {
+            from_id: rel.from_id,
+            from_name: rel.from_name,
+            to_name: rel.to_name,
+            file_id: rel.file_id,
+            kind: rel.kind,
+            metadata: rel.metadata,
+            to_range: rel.to_range,
+            target_language: rel.target_language.map(language),
+        }
+    }
+
+    pub(super) fn into_relationship(
+        self,
+        language: impl FnOnce(L) -> Option<LanguageId>,
+    ) -> UnresolvedRelationship {
+        UnresolvedRelationship {
+            from_id: self.from_id,
+            from_name: self.from_name,
+            to_name: self.to_name,
+            file_id: self.file_id,
+            kind: self.kind,
+            metadata: self.metadata,
+            to_range: self.to_range,
+            target_language: self.target_language.and_then(language),
+        }
+    }
+}
+
+/// Pending relationships past `limit`, kept in an anonymous temporary file
+/// the OS removes when it is dropped
+pub struct RelationshipSpill {
+    limit: usize,
+    file: Option<BufWriter<File>>,
+    spilled: usize,
+    languages: Vec<LanguageId>,
+}
+
+fn spill_error(e: impl std::fmt::Display) -> PipelineError {
+    PipelineError::Index(crate::IndexError::General(format!(
+        "Failed to spill relationships to disk: {e}"
+    )))
+}
+
+impl RelationshipSpill {
+    /// Spill whenever more than `limit` relationships are pending
+    /// (0 = keep them all in memory)
+    pub fn new(limit: usize) -> Self {
+        Self {
+            limit,
+            file: None,
+            spilled: 0,
+            languages: Vec::new(),
+        }
+    }
+
+    /// Relationships written to disk so far
+    pub fn spilled(&self) -> usize {
+        self.spilled
+    }
+
+    /// Move `pending` to disk if it holds more than the limit
+    pub fn offer(&mut self, pending: &mut Vec<UnresolvedRelationship>) -> PipelineResult<()> {
+        if self.limit == 0 || pending.len() <= self.limit {
+            return Ok(());
+        }
+        if self.file.is_none() {
+            self.file = Some(BufWriter::new(tempfile::tempfile().map_err(spill_error)?));
+        }
+        for rel in pending.drain(..) {
+            // Languages are written as indexes into `languages`: reading a
+            // plugin language back by name would leak it per relationship
+            let languages = &mut self.languages;
+            let line = RelationshipRecord::new(rel, |language| {
+                match languages.iter().position(|known| *known == language) {
+                    Some(index) => index,
+                    None => {
+                        languages.push(language);
+                        languages.len() - 1
+                    }
+                }
+            });
+            let writer = self.file.as_mut().expect("spill file opened above");
+            serde_json::to_writer(&mut *writer, &line).map_err(spill_error)?;
+            writer.write_all(b"\n").map_err(spill_error)?;
+            self.spilled += 1;
+        }
+        // Give the buffer back to the allocator, not just its contents
+        pending.shrink_to(self.limit);
+        Ok(())
+    }
+
+    /// Every spilled relationship followed by `pending`, in the order they
+    /// were offered
+    pub fn finish(
+        self,
+        pending: Vec<UnresolvedRelationship>,
+    ) -> PipelineResult<Vec<UnresolvedRelationship>> {
+        let Some(writer) = self.file else {
+            return Ok(pending);
+        };
+        let mut file = writer.into_inner().map_err(|e| spill_error(e.error()))?;
+        file.seek(SeekFrom::Start(0)).map_err(spill_error)?;
+
+        let mut relationships = Vec::with_capacity(self.spilled + pending.len());
+        for line in BufReader::new(file).lines() {
+            let line = line.map_err(spill_error)?;
+            let rel: RelationshipRecord<usize> =
+                serde_json::from_str(&line).map_err(spill_error)?;
+            relationships.push(rel.into_relationship(|index| self.languages.get(index).copied()));
+        }
+        relationships.extend(pending);
+        Ok(relationships)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn relationship(n: u32) -> UnresolvedRelationship {
+        UnresolvedRelationship {
+            from_id: SymbolId::new(n),
+            from_name: format!("caller_{n}").into(),
+            to_name: "callee".into(),
+            file_id: FileId::new(1).unwrap(),
+            kind: RelationKind::Calls,
+            metadata: None,
+            to_range: Some(Range::new(n, 4, n, 10)),
+            target_language: (n % 2 == 0).then(|| LanguageId::new("rust")),
+        }
+    }
+
+    #[test]
+    fn test_spill_round_trip_keeps_order() {
+        let mut spill = RelationshipSpill::new(2);
+        let mut pending = Vec::new();
+        for n in 1..=7 {
+            pending.push(relationship(n));
+            spill.offer(&mut pending).unwrap();
+        }
+        assert_eq!(spill.spilled(), 6);
+        assert_eq!(pending.len(), 1);
+
+        let relationships = spill.finish(pending).unwrap();
+        let callers: Vec<_> = relationships.iter().map(|r| &*r.from_name).collect();
+        assert_eq!(
+            callers,
+            [
+                "caller_1", "caller_2", "caller_3", "caller_4", "caller_5", "caller_6", "caller_7"
+            ]
+        );
+        assert_eq!(
+            relationships[1].target_language,
+            Some(LanguageId::new("rust"))
+        );
+        assert_eq!(relationships[2].target_language, None);
+        assert_eq!(relationships[3].to_range, Some(Range::new(4, 4, 4, 10)));
+    }
+
+    #[test]
+    fn test_no_limit_never_spills() {
+        let mut spill = RelationshipSpill::new(0);
+        let mut pending: Vec<_> = (1..=100).map(relationship).collect();
+        spill.offer(&mut pending).unwrap();
+
+        assert_eq!(spill.spilled(), 0);
+        assert_eq!(spill.finish(pending).unwrap().len(), 100);
+    }
+}
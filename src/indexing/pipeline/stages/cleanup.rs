@@ -6,7 +6,8 @@
 //!
 //! The cleanup order is critical for embedding sync:
 //! 1. Get symbols for file
-//! 2. Remove embeddings for those symbols
+//! 2. Retire embeddings for those symbols (re-indexed symbols with unchanged
+//!    text take them back, see `SimpleSemanticSearch::retire_embeddings`)
 //! 3. Save embeddings to disk (prevents desync on crash)
 //! 4. Remove file documents from Tantivy
 
@@ -108,7 +109,7 @@ impl CleanupStage {
                 reason: "Failed to lock semantic search".to_string(),
             })?;
 
-            semantic_guard.remove_embeddings(&pending_embedding_removals);
+            semantic_guard.retire_embeddings(&pending_embedding_removals);
             stats.embeddings_removed = pending_embedding_removals.len();
 
             semantic_guard
@@ -5,14 +5,26 @@
 //! - Converts RawSymbol -> Symbol
 //! - Converts RawImport -> Import
 //! - Converts RawRelationship -> UnresolvedRelationship (resolving from_id)
+//! - Converts TodoComment -> Todo (attaching the symbol it is about)
 //! - Batches output for efficient Tantivy writes
-
+//!
+//! Files arrive in the order the PARSE workers finish them, which varies
+//! run to run. In deterministic mode (`indexing.deterministic`) the stage
+//! waits for every file and takes them in path order, so the same sources
+//! always get the same IDs and the same batches. Holding them means the
+//! symbols, relationships and strings of the whole run are in memory at
+//! once, where the streaming mode keeps a batch.
+
+use crate::config::PathOverrides;
 use crate::indexing::pipeline::types::{
     EmbeddingBatch, FileRegistration, IndexBatch, ParsedFile, PipelineResult, RawRelationship,
     RawSymbol, UnresolvedRelationship,
 };
+use crate::parsing::strings::{self, IndexedString};
+use crate::parsing::todo::{self, Todo};
+use crate::semantic::detect_doc_language;
 use crate::symbol::Symbol;
-use crate::types::{FileId, Range, SymbolId};
+use crate::types::{CompactString, FileId, Range, SymbolId};
 use crate::utils::get_utc_timestamp;
 use crossbeam_channel::{Receiver, Sender};
 use std::collections::HashMap;
@@ -29,6 +41,13 @@ pub struct CollectStage {
     start_file_counter: u32,
     /// Starting symbol counter (for continuing from existing index)
     start_symbol_counter: u32,
+    /// Assign IDs in path order rather than arrival order
+    deterministic: bool,
+    /// Also send the code text of symbols with a signature to EMBED
+    code_embeddings: bool,
+    /// `[overrides]` that leave files out of EMBED or change their code
+    /// embeddings
+    overrides: PathOverrides,
 }
 
 type NameInFileCandidates = HashMap<(Arc<str>, FileId), Vec<(Range, SymbolId)>>;
@@ -165,6 +184,9 @@ impl CollectStage {
             batch_size: batch_size.max(1),
             start_file_counter: 0,
             start_symbol_counter: 0,
+            deterministic: false,
+            code_embeddings: false,
+            overrides: PathOverrides::default(),
         }
     }
 
@@ -178,6 +200,26 @@ impl CollectStage {
         self
     }
 
+    /// Take files in path order instead of arrival order, holding every
+    /// parsed file until PARSE is done.
+    pub fn with_deterministic(mut self, deterministic: bool) -> Self {
+        self.deterministic = deterministic;
+        self
+    }
+
+    /// Send the code text (kind, name and signature) of symbols with a
+    /// signature to EMBED as well as their doc comments.
+    pub fn with_code_embeddings(mut self, code_embeddings: bool) -> Self {
+        self.code_embeddings = code_embeddings;
+        self
+    }
+
+    /// Embed the files under `[overrides]` globs as the globs say.
+    pub fn with_overrides(mut self, overrides: PathOverrides) -> Self {
+        self.overrides = overrides;
+        self
+    }
+
     /// Create with default batch size (5000 symbols).
     pub fn default_batch_size() -> Self {
         Self::new(5000)
@@ -240,14 +282,32 @@ impl CollectStage {
         let mut output_wait = Duration::ZERO;
         let mut total_embed_candidates = 0u32;
 
-        loop {
-            // Track input wait (time blocked on recv)
+        let mut in_path_order = if self.deterministic {
             let recv_start = Instant::now();
-            let parsed = match receiver.recv() {
-                Ok(p) => p,
-                Err(_) => break, // Channel closed
-            };
+            let mut files: Vec<ParsedFile> = receiver.iter().collect();
+            files.sort_by(|a, b| a.path.cmp(&b.path));
             input_wait += recv_start.elapsed();
+            Some(files.into_iter())
+        } else {
+            None
+        };
+
+        loop {
+            let parsed = if let Some(files) = &mut in_path_order {
+                match files.next() {
+                    Some(p) => p,
+                    None => break,
+                }
+            } else {
+                // Track input wait (time blocked on recv)
+                let recv_start = Instant::now();
+                let parsed = match receiver.recv() {
+                    Ok(p) => p,
+                    Err(_) => break, // Channel closed
+                };
+                input_wait += recv_start.elapsed();
+                parsed
+            };
 
             self.process_file(&mut state, parsed);
 
@@ -325,12 +385,20 @@ impl CollectStage {
 
         // Set current language for embedding metadata
         state.current_language = parsed.language_id.as_str().into();
+        let overrides = self.overrides.for_path(&parsed.path);
+        let embed_docs = overrides.semantic_search != Some(false);
+        let embed_code = embed_docs && overrides.code_embeddings.unwrap_or(self.code_embeddings);
 
         // Cache path -> FileId for Phase 2 resolution (per decisions.md)
         state.caches.insert_file(parsed.path.clone(), file_id);
 
-        // Register file
-        let mtime = crate::indexing::file_info::get_file_mtime(&parsed.path).unwrap_or(0);
+        // Register file. Checkouts of the same sources differ in mtimes;
+        // an unknown mtime only makes change detection compare hashes.
+        let mtime = if self.deterministic {
+            0
+        } else {
+            crate::indexing::file_info::get_file_mtime(&parsed.path).unwrap_or(0)
+        };
         state
             .current_batch
             .file_registrations
@@ -341,11 +409,16 @@ impl CollectStage {
                 language_id: parsed.language_id,
                 timestamp: get_utc_timestamp(),
                 mtime,
+                encoding: parsed.encoding,
             });
 
         // Process symbols
+        let ranges: Vec<Range> = parsed.raw_symbols.iter().map(|raw| raw.range).collect();
+        let mut symbol_ids = Vec::with_capacity(ranges.len());
+        let mut docs = String::new();
         for raw_sym in parsed.raw_symbols {
             let symbol_id = state.next_symbol_id();
+            symbol_ids.push(symbol_id);
 
             // Cache for relationship resolution
             let name: Arc<str> = raw_sym.name.clone();
@@ -355,11 +428,31 @@ impl CollectStage {
 
             // Extract embedding candidate if symbol has doc_comment
             if let Some(ref doc) = raw_sym.doc_comment {
-                state.current_embed_batch.candidates.push((
-                    symbol_id,
-                    doc.clone(),
-                    state.current_language.clone(),
-                ));
+                docs.push_str(doc);
+                docs.push('\n');
+                if embed_docs {
+                    state.current_embed_batch.candidates.push((
+                        symbol_id,
+                        doc.clone(),
+                        state.current_language.clone(),
+                    ));
+                }
+            }
+            if embed_code {
+                if let Some(text) = code_text(&raw_sym) {
+                    state.current_embed_batch.code_candidates.push((
+                        symbol_id,
+                        text,
+                        state.current_language.clone(),
+                    ));
+                }
+                for chunk in raw_sym.body_chunks.iter().skip(1) {
+                    state.current_embed_batch.chunk_candidates.push((
+                        symbol_id,
+                        chunk_text(&raw_sym, chunk),
+                        state.current_language.clone(),
+                    ));
+                }
             }
 
             // Create Symbol
@@ -374,6 +467,9 @@ impl CollectStage {
 
             state.current_batch.symbols.push(symbol);
         }
+        if let Some(language) = detect_doc_language(&docs) {
+            state.current_embed_batch.doc_languages.push(language);
+        }
 
         // Process imports
         for raw_import in parsed.raw_imports {
@@ -381,6 +477,35 @@ impl CollectStage {
             state.current_batch.imports.push(import);
         }
 
+        // Process todos, each attached to the symbol it is about
+        for comment in parsed.todos {
+            let symbol_id = todo::owner(&comment, &ranges).map(|index| symbol_ids[index]);
+            state.current_batch.todos.push(Todo {
+                tag: comment.tag,
+                author: comment.author,
+                text: comment.text,
+                file_id,
+                file_path: file_path.to_string(),
+                line: comment.line,
+                column: comment.column,
+                symbol_id,
+            });
+        }
+
+        // Process string literals, each attached to the symbol holding it
+        for literal in parsed.strings {
+            let symbol_id = strings::owner(&literal, &ranges).map(|index| symbol_ids[index]);
+            state.current_batch.strings.push(IndexedString {
+                kind: literal.kind,
+                text: literal.text,
+                file_id,
+                file_path: file_path.to_string(),
+                line: literal.line,
+                column: literal.column,
+                symbol_id,
+            });
+        }
+
         // Process relationships
         for raw_rel in parsed.raw_relationships {
             let unresolved = create_unresolved_relationship(&state.caches, raw_rel, file_id);
@@ -392,6 +517,20 @@ impl CollectStage {
     }
 }
 
+/// Text embedded for the code of a symbol: its kind, name and signature,
+/// or the first chunk of its body where PARSE chunked it. Symbols without
+/// a signature have no code worth embedding.
+fn code_text(raw: &RawSymbol) -> Option<CompactString> {
+    let signature = raw.signature.as_deref()?;
+    let code = raw.body_chunks.first().map_or(signature, |chunk| &**chunk);
+    Some(chunk_text(raw, code))
+}
+
+/// A chunk of the code of a symbol, headed by its kind and name
+fn chunk_text(raw: &RawSymbol, code: &str) -> CompactString {
+    CompactString::from(format!("{:?} {}\n{code}", raw.kind, raw.name))
+}
+
 /// Create a Symbol from RawSymbol.
 fn create_symbol(
     id: SymbolId,
@@ -404,7 +543,7 @@ fn create_symbol(
     let mut symbol = Symbol::new(id, raw.name, raw.kind, file_id, raw.range)
         .with_file_path(file_path)
         .with_visibility(raw.visibility)
-        .with_language_id(language_id);
+        .with_language_id(raw.language_id.unwrap_or(language_id));
 
     if let Some(sig) = raw.signature {
         symbol = symbol.with_signature(sig);
@@ -418,6 +557,18 @@ fn create_symbol(
     if let Some(scope) = raw.scope_context {
         symbol = symbol.with_scope(scope);
     }
+    if let Some(metrics) = raw.metrics {
+        symbol = symbol.with_metrics(metrics);
+    }
+    if let Some(authorship) = raw.authorship {
+        symbol = symbol.with_authorship(authorship);
+    }
+    if !raw.tags.is_empty() {
+        symbol = symbol.with_tags(raw.tags);
+    }
+    if let Some(kind) = raw.framework_kind {
+        symbol = symbol.with_framework_kind(kind);
+    }
 
     symbol
 }
@@ -442,6 +593,7 @@ fn create_unresolved_relationship(
         kind: raw.kind,
         metadata: raw.metadata,
         to_range: Some(raw.to_range),
+        target_language: raw.target_language,
     }
 }
 
@@ -467,6 +619,10 @@ mod tests {
             raw_imports: Vec::new(),
             raw_relationships: Vec::new(),
             variable_bindings: Vec::new(),
+            todos: Vec::new(),
+            strings: Vec::new(),
+            encoding: None,
+            diagnostics: Vec::new(),
         }
     }
 
@@ -526,6 +682,124 @@ mod tests {
         assert_eq!(all_ids, vec![1, 2, 3], "IDs should be sequential 1, 2, 3");
     }
 
+    #[test]
+    fn test_deterministic_collect_ignores_arrival_order() {
+        let run = |order: &[&str]| {
+            let (parsed_tx, parsed_rx) = bounded(100);
+            let (batch_tx, batch_rx) = bounded(100);
+            for name in order {
+                let symbol = make_raw_symbol(&name.replace(".rs", ""), SymbolKind::Function, 1);
+                parsed_tx
+                    .send(make_parsed_file(name, vec![symbol]))
+                    .unwrap();
+            }
+            drop(parsed_tx);
+            CollectStage::new(1)
+                .with_deterministic(true)
+                .run(parsed_rx, batch_tx, None, None)
+                .unwrap();
+            batch_rx
+                .iter()
+                .flat_map(|batch| batch.symbols)
+                .map(|symbol| {
+                    (
+                        symbol.name.to_string(),
+                        symbol.id.value(),
+                        symbol.file_id.value(),
+                    )
+                })
+                .collect::<Vec<_>>()
+        };
+
+        let sorted = run(&["a.rs", "b.rs", "c.rs"]);
+        assert_eq!(sorted, run(&["c.rs", "a.rs", "b.rs"]));
+        assert_eq!(
+            sorted,
+            vec![
+                ("a".to_string(), 1, 1),
+                ("b".to_string(), 2, 2),
+                ("c".to_string(), 3, 3)
+            ]
+        );
+    }
+
+    #[test]
+    fn test_collect_attaches_todos_to_symbols() {
+        let (parsed_tx, parsed_rx) = bounded(100);
+        let (batch_tx, batch_rx) = bounded(100);
+
+        let todo = |line: u32| crate::parsing::todo::TodoComment {
+            tag: "TODO".to_string(),
+            author: None,
+            text: format!("line {line}"),
+            line,
+            column: 0,
+            end_line: line,
+        };
+        let mut parsed = make_parsed_file(
+            "src/lib.rs",
+            vec![
+                make_raw_symbol("foo", SymbolKind::Function, 2),
+                make_raw_symbol("bar", SymbolKind::Function, 5),
+            ],
+        );
+        parsed.todos = vec![todo(1), todo(5), todo(20)];
+        parsed_tx.send(parsed).unwrap();
+        drop(parsed_tx);
+
+        let stage = CollectStage::new(100);
+        stage.run(parsed_rx, batch_tx, None, None).unwrap();
+
+        let todos: Vec<(String, Option<u32>)> = batch_rx
+            .iter()
+            .flat_map(|batch| batch.todos)
+            .map(|todo| (todo.text, todo.symbol_id.map(|id| id.value())))
+            .collect();
+        assert_eq!(
+            todos,
+            [
+                ("line 1".to_string(), Some(1)),
+                ("line 5".to_string(), Some(2)),
+                ("line 20".to_string(), None),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_collect_attaches_strings_to_symbols() {
+        use crate::parsing::strings::{StringKind, StringLiteral};
+
+        let (parsed_tx, parsed_rx) = bounded(100);
+        let (batch_tx, batch_rx) = bounded(100);
+
+        let literal = |line: u32, column: u16| StringLiteral {
+            kind: StringKind::Url,
+            text: format!("https://example.com/{line}"),
+            line,
+            column,
+        };
+        let mut parsed = make_parsed_file(
+            "src/lib.rs",
+            vec![
+                make_raw_symbol("foo", SymbolKind::Function, 2),
+                make_raw_symbol("bar", SymbolKind::Function, 5),
+            ],
+        );
+        parsed.strings = vec![literal(2, 4), literal(5, 8), literal(9, 0)];
+        parsed_tx.send(parsed).unwrap();
+        drop(parsed_tx);
+
+        let stage = CollectStage::new(100);
+        stage.run(parsed_rx, batch_tx, None, None).unwrap();
+
+        let strings: Vec<(u32, Option<u32>)> = batch_rx
+            .iter()
+            .flat_map(|batch| batch.strings)
+            .map(|literal| (literal.line, literal.symbol_id.map(|id| id.value())))
+            .collect();
+        assert_eq!(strings, [(2, Some(1)), (5, Some(2)), (9, None)]);
+    }
+
     #[test]
     fn test_collect_batches_by_symbol_count() {
         let (parsed_tx, parsed_rx) = bounded(100);
@@ -862,6 +1136,10 @@ mod tests {
             raw_imports: Vec::new(),
             raw_relationships: Vec::new(),
             variable_bindings: Vec::new(),
+            todos: Vec::new(),
+            strings: Vec::new(),
+            encoding: None,
+            diagnostics: Vec::new(),
         };
 
         parsed_tx.send(parsed).unwrap();
@@ -915,4 +1193,76 @@ mod tests {
             );
         }
     }
+
+    #[test]
+    fn test_collect_sends_code_text_of_symbols_with_signatures() {
+        let (parsed_tx, parsed_rx) = bounded(100);
+        let (batch_tx, batch_rx) = bounded(100);
+        let (embed_tx, embed_rx) = bounded(100);
+
+        let documented = RawSymbol::new("serve", SymbolKind::Function, Range::new(1, 0, 5, 1))
+            .with_signature("fn serve(port: u16)")
+            .with_doc_comment("Serve requests");
+        let undocumented = RawSymbol::new("parse", SymbolKind::Function, Range::new(10, 0, 12, 1))
+            .with_signature("fn parse(input: &str) -> Ast");
+        let bare = RawSymbol::new("LIMIT", SymbolKind::Constant, Range::new(20, 0, 20, 9));
+
+        let mut parsed = ParsedFile::new(
+            PathBuf::from("src/lib.rs"),
+            "abc".to_string(),
+            LanguageId::new("rust"),
+        );
+        parsed.raw_symbols = vec![documented, undocumented, bare];
+        parsed_tx.send(parsed).unwrap();
+        drop(parsed_tx);
+
+        CollectStage::new(100)
+            .with_code_embeddings(true)
+            .run(parsed_rx, batch_tx, Some(embed_tx), None)
+            .unwrap();
+        drop(batch_rx);
+
+        let batches: Vec<_> = embed_rx.iter().collect();
+        let docs: Vec<_> = batches.iter().flat_map(|b| &b.candidates).collect();
+        let code: Vec<_> = batches.iter().flat_map(|b| &b.code_candidates).collect();
+        assert_eq!(docs.len(), 1);
+        assert_eq!(code.len(), 2);
+        assert_eq!(code[0].0.value(), 1);
+        assert_eq!(&*code[1].1, "Function parse\nfn parse(input: &str) -> Ast");
+        assert_eq!(&*code[1].2, "rust");
+    }
+
+    #[test]
+    fn test_collect_sends_body_chunks_after_the_first() {
+        let (parsed_tx, parsed_rx) = bounded(100);
+        let (batch_tx, batch_rx) = bounded(100);
+        let (embed_tx, embed_rx) = bounded(100);
+
+        let mut long = RawSymbol::new("run", SymbolKind::Function, Range::new(1, 0, 90, 1))
+            .with_signature("fn run()");
+        long.body_chunks = vec!["fn run() {\n    a();".into(), "    b();\n}".into()];
+        let mut parsed = ParsedFile::new(
+            PathBuf::from("src/lib.rs"),
+            "abc".to_string(),
+            LanguageId::new("rust"),
+        );
+        parsed.raw_symbols = vec![long];
+        parsed_tx.send(parsed).unwrap();
+        drop(parsed_tx);
+
+        CollectStage::new(100)
+            .with_code_embeddings(true)
+            .run(parsed_rx, batch_tx, Some(embed_tx), None)
+            .unwrap();
+        drop(batch_rx);
+
+        let batches: Vec<_> = embed_rx.iter().collect();
+        let code: Vec<_> = batches.iter().flat_map(|b| &b.code_candidates).collect();
+        let chunks: Vec<_> = batches.iter().flat_map(|b| &b.chunk_candidates).collect();
+        assert_eq!(code.len(), 1);
+        assert_eq!(&*code[0].1, "Function run\nfn run() {\n    a();");
+        assert_eq!(chunks.len(), 1);
+        assert_eq!(chunks[0].0, code[0].0);
+        assert_eq!(&*chunks[0].1, "Function run\n    b();\n}");
+    }
 }
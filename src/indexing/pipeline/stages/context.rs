@@ -12,7 +12,7 @@
 use crate::RelationKind;
 use crate::config::Settings;
 use crate::indexing::pipeline::types::{
-    ResolutionContext, SymbolLookupCache, UnresolvedRelationship, VariableBinding,
+    ResolutionContext, Subtypes, SymbolLookupCache, UnresolvedRelationship, VariableBinding,
 };
 use crate::parsing::resolution::InheritanceResolver;
 use crate::parsing::{LanguageBehavior, LanguageId, ParserFactory};
@@ -101,7 +101,10 @@ impl ContextStage {
             by_file.entry(rel.file_id).or_default().push(rel);
         }
 
-        // Build context for each file
+        // Build context for each file, in FileId order so relationships
+        // are written in the same order every run
+        let mut by_file: Vec<_> = by_file.into_iter().collect();
+        by_file.sort_unstable_by_key(|(file_id, _)| file_id.value());
         let mut contexts = Vec::with_capacity(by_file.len());
 
         for (file_id, rels) in by_file {
@@ -212,6 +215,45 @@ impl ContextStage {
             .collect()
     }
 
+    /// Build the per-language [`Subtypes`] from `Implements` and `Extends`
+    /// `UnresolvedRelationship`s across all files.
+    ///
+    /// Like inheritance, implementation is a cross-file axis. Consumed by
+    /// `ResolveStage` via `with_subtypes` to reach the implementations of a
+    /// bound a call through a generic parameter may dispatch to. Trait and
+    /// type names are reduced to their last path segment.
+    pub fn build_subtypes(&self, unresolved: &[UnresolvedRelationship]) -> Subtypes {
+        let mut subtypes = Subtypes::new();
+        let mut file_lang: HashMap<FileId, LanguageId> = HashMap::new();
+        let bare = |name: &str| -> Box<str> {
+            let name = name.split('<').next().unwrap_or(name).trim();
+            let name = name.rsplit("::").next().unwrap_or(name);
+            name.rsplit('.').next().unwrap_or(name).into()
+        };
+
+        for rel in unresolved {
+            if !matches!(rel.kind, RelationKind::Implements | RelationKind::Extends) {
+                continue;
+            }
+            let Some(language_id) = self.language_for_rel(rel, &mut file_lang) else {
+                continue;
+            };
+            let (parent, child) = (bare(&rel.to_name), bare(&rel.from_name));
+            if parent == child {
+                continue;
+            }
+            let children = subtypes
+                .entry(language_id)
+                .or_default()
+                .entry(parent)
+                .or_default();
+            if !children.contains(&child) {
+                children.push(child);
+            }
+        }
+        subtypes
+    }
+
     /// Resolve the language for a relationship via `from_id` first, falling
     /// back to any symbol on `file_id`. Cached per file.
     fn language_for_rel(
@@ -296,6 +338,7 @@ mod tests {
             kind: RelationKind::Calls,
             metadata: None,
             to_range: Some(Range::new(5, 4, 5, 20)),
+            target_language: None,
         }
     }
 
@@ -412,6 +455,7 @@ mod tests {
             kind: RelationKind::Calls,
             metadata: None,
             to_range: Some(to_range),
+            target_language: None,
         };
 
         let stage = ContextStage::new(cache, index, factory, settings);
@@ -455,6 +499,7 @@ mod tests {
             kind: RelationKind::Extends,
             metadata: None,
             to_range: Some(Range::new(0, 0, 0, 0)),
+            target_language: None,
         };
 
         let stage = ContextStage::new(cache, index, factory, settings);
@@ -470,6 +515,43 @@ mod tests {
         );
     }
 
+    #[test]
+    fn test_build_subtypes_from_implements_and_extends() {
+        let temp_dir = TempDir::new().unwrap();
+        let settings = Arc::new(Settings::default());
+        let index = Arc::new(DocumentIndex::new(temp_dir.path(), &settings).unwrap());
+        let factory = make_factory();
+
+        let rust = LanguageId::new("rust");
+        let cache = Arc::new(SymbolLookupCache::new());
+        cache.insert(make_test_symbol(1, "Circle", 1, rust));
+        cache.insert(make_test_symbol(2, "Square", 2, rust));
+
+        let relationship =
+            |from_id: u32, from: &str, to: &str, kind: RelationKind| UnresolvedRelationship {
+                from_id: Some(SymbolId::new(from_id).unwrap()),
+                from_name: StdArc::from(from),
+                to_name: StdArc::from(to),
+                file_id: FileId::new(from_id).unwrap(),
+                kind,
+                metadata: None,
+                to_range: Some(Range::new(0, 0, 0, 0)),
+                target_language: None,
+            };
+
+        let stage = ContextStage::new(cache, index, factory, settings);
+        let subtypes = stage.build_subtypes(&[
+            relationship(1, "Circle", "shapes::Shape", RelationKind::Implements),
+            relationship(2, "Square", "Shape", RelationKind::Implements),
+            relationship(2, "Square", "Shape", RelationKind::Implements),
+            relationship(1, "Circle", "area", RelationKind::Calls),
+        ]);
+
+        let children: Vec<&str> = subtypes[&rust]["Shape"].iter().map(|c| &**c).collect();
+        assert_eq!(children, ["Circle", "Square"]);
+        assert_eq!(subtypes[&rust].len(), 1, "calls are not subtyping");
+    }
+
     #[test]
     fn test_context_caches_behaviors_per_language() {
         let temp_dir = TempDir::new().unwrap();
@@ -1,20 +1,25 @@
 //! Discover stage - parallel file system walk
 //!
 //! Uses the `ignore` crate's parallel walker for high-performance
-//! file discovery. Filters by supported extensions.
+//! file discovery. Filters by supported extensions or, given the settings,
+//! by the extensions and globs of their `[languages]`.
 //!
-//! Supports two modes:
+//! Supports three modes:
 //! - Full: Discovers all files (for initial indexing or force re-index)
 //! - Incremental: Compares disk state to index, returns new/modified/deleted
+//! - Changed: Categorizes a list of changed files from git, plus dependents
 
+use crate::Settings;
+use crate::indexing::encoding;
 use crate::indexing::file_info::calculate_hash;
+use crate::indexing::links::LinkFilter;
 use crate::indexing::pipeline::types::{DiscoverResult, PipelineError, PipelineResult};
 use crate::parsing::get_registry;
 use crate::storage::DocumentIndex;
 use crossbeam_channel::Sender;
 use ignore::WalkBuilder;
-use std::collections::HashSet;
-use std::fs;
+use ignore::gitignore::Gitignore;
+use std::collections::{HashMap, HashSet};
 use std::path::{Path, PathBuf};
 use std::sync::Arc;
 use std::sync::atomic::{AtomicUsize, Ordering};
@@ -27,6 +32,8 @@ pub struct DiscoverStage {
     index: Option<Arc<DocumentIndex>>,
     /// Workspace root for path normalization.
     workspace_root: Option<PathBuf>,
+    /// Settings whose `[languages]` pick the files, when given.
+    settings: Option<Arc<Settings>>,
 }
 
 impl DiscoverStage {
@@ -37,6 +44,7 @@ impl DiscoverStage {
             threads: threads.max(1),
             index: None,
             workspace_root: None,
+            settings: None,
         }
     }
 
@@ -52,6 +60,28 @@ impl DiscoverStage {
         self
     }
 
+    /// Pick files by the extension mappings and include/exclude globs of
+    /// the `[languages]` of `settings`.
+    pub fn with_settings(mut self, settings: Arc<Settings>) -> Self {
+        self.settings = Some(settings);
+        self
+    }
+
+    /// The link policy of a walk of the root.
+    fn link_filter(&self) -> Arc<LinkFilter> {
+        let policy = self
+            .settings
+            .as_ref()
+            .map(|settings| settings.indexing.symlinks)
+            .unwrap_or_default();
+        let project_root = self.workspace_root.as_deref().or_else(|| {
+            self.settings
+                .as_ref()
+                .and_then(|settings| settings.workspace_root.as_deref())
+        });
+        LinkFilter::new(policy, &self.root, project_root)
+    }
+
     /// Normalize a path relative to workspace_root.
     fn normalize_path(&self, path: &Path) -> PathBuf {
         if path.is_absolute() {
@@ -80,9 +110,10 @@ impl DiscoverStage {
             .git_ignore(true) // Respect .gitignore
             .git_global(true) // Respect global gitignore
             .git_exclude(true) // Respect .git/info/exclude
-            .follow_links(false) // Don't follow symlinks
             .require_git(false) // Allow gitignore to work in non-git directories
             .threads(self.threads);
+        let links = self.link_filter();
+        links.configure(&mut builder);
 
         // Support .codannaignore files (matches FileWalker behavior)
         builder.add_custom_ignore_filename(".codannaignore");
@@ -95,7 +126,9 @@ impl DiscoverStage {
         walker.run(|| {
             let sender = sender.clone();
             let extensions = extensions.clone();
+            let settings = self.settings.clone();
             let count = count_clone.clone();
+            let links = links.clone();
 
             Box::new(move |entry| {
                 let entry = match entry {
@@ -120,13 +153,21 @@ impl DiscoverStage {
                 }
 
                 // Filter by extension
-                if !has_supported_extension(path, &extensions) {
+                if !is_source_file(path, &extensions, settings.as_deref()) {
+                    return ignore::WalkState::Continue;
+                }
+
+                // Once per file, under its own path where a link led here
+                let Some(path) = links.admit(path) else {
+                    return ignore::WalkState::Continue;
+                };
+                if !is_source_file(&path, &extensions, settings.as_deref()) {
                     return ignore::WalkState::Continue;
                 }
 
                 // Send path to channel
                 count.fetch_add(1, Ordering::Relaxed);
-                if sender.send(path.to_path_buf()).is_err() {
+                if sender.send(path).is_err() {
                     // Channel closed, stop walking
                     return ignore::WalkState::Quit;
                 }
@@ -208,6 +249,88 @@ impl DiscoverStage {
         Ok(result)
     }
 
+    /// Categorize only the files git reports as changed (`index --since`),
+    /// without walking or hashing the rest of the tree.
+    ///
+    /// `changed` is relative to the root. Unchanged files with resolved
+    /// relationships into a modified or deleted file come back as modified
+    /// too: cleanup drops those relationships along with the file's symbols,
+    /// so their sources must be resolved again. New files are checked
+    /// against the `.codannaignore` files as the walk would; git already
+    /// applied the gitignore rules.
+    pub fn run_changed(&self, changed: &[PathBuf]) -> PipelineResult<DiscoverResult> {
+        let index = self.index.as_ref().ok_or_else(|| PipelineError::Parse {
+            path: self.root.clone(),
+            reason: "Incremental mode requires an index".to_string(),
+        })?;
+        let extensions = get_supported_extensions()?;
+        let mut ignores = HashMap::new();
+
+        let mut result = DiscoverResult::default();
+        let mut stale = HashSet::new();
+        for relative in changed {
+            let path = self.root.join(relative);
+            let normalized = self.normalize_path(&path);
+            let stored = index.get_file_info(&normalized.to_string_lossy())?;
+
+            match stored {
+                Some((file_id, _, _)) if !path.is_file() => {
+                    stale.insert(file_id);
+                    result.deleted_files.push(normalized);
+                }
+                Some((file_id, _, _)) => {
+                    if self.is_modified(&normalized, index)? {
+                        stale.insert(file_id);
+                        result.modified_files.push(normalized);
+                    }
+                }
+                None => {
+                    let hidden = path
+                        .file_name()
+                        .and_then(|name| name.to_str())
+                        .is_some_and(|name| name.starts_with('.'));
+                    if path.is_file()
+                        && !hidden
+                        && is_source_file(&path, &extensions, self.settings.as_deref())
+                        && !is_codannaignored(&self.root, &path, &mut ignores)
+                    {
+                        result.new_files.push(normalized);
+                    }
+                }
+            }
+        }
+
+        let mut dependents = HashSet::new();
+        for file_id in &stale {
+            for symbol in index.find_symbols_by_file(*file_id)? {
+                for source in index.get_relationship_sources(symbol.id)? {
+                    if let Some(source) = index.find_symbol_by_id(source)? {
+                        if !stale.contains(&source.file_id) {
+                            dependents.insert(source.file_id);
+                        }
+                    }
+                }
+            }
+        }
+        let dependent_count = dependents.len();
+        for file_id in dependents {
+            if let Some(path) = index.get_file_path(file_id)? {
+                result.modified_files.push(PathBuf::from(path));
+            }
+        }
+
+        tracing::debug!(
+            target: "pipeline",
+            "changed result: new={}, modified={} ({} dependents), deleted={}",
+            result.new_files.len(),
+            result.modified_files.len(),
+            dependent_count,
+            result.deleted_files.len()
+        );
+
+        Ok(result)
+    }
+
     /// Collect all files on disk (synchronous, for incremental comparison).
     fn collect_all_files(&self) -> PipelineResult<Vec<PathBuf>> {
         let extensions = get_supported_extensions()?;
@@ -220,8 +343,9 @@ impl DiscoverStage {
             .git_ignore(true) // Respect .gitignore
             .git_global(true) // Respect global gitignore
             .git_exclude(true) // Respect .git/info/exclude
-            .follow_links(false) // Don't follow symlinks
             .require_git(false); // Allow gitignore to work in non-git directories
+        let links = self.link_filter();
+        links.configure(&mut builder);
 
         // Support .codannaignore files (matches FileWalker behavior)
         builder.add_custom_ignore_filename(".codannaignore");
@@ -244,8 +368,13 @@ impl DiscoverStage {
                 }
             }
 
-            if has_supported_extension(path, &extensions) {
-                files.push(path.to_path_buf());
+            if !is_source_file(path, &extensions, self.settings.as_deref()) {
+                continue;
+            }
+            if let Some(path) = links.admit(path) {
+                if is_source_file(&path, &extensions, self.settings.as_deref()) {
+                    files.push(path);
+                }
             }
         }
 
@@ -273,7 +402,7 @@ impl DiscoverStage {
         }
 
         // mtime changed or unknown - verify with hash (requires file read)
-        let content = fs::read_to_string(path).map_err(|e| PipelineError::FileRead {
+        let content = encoding::read_to_string(path).map_err(|e| PipelineError::FileRead {
             path: path.to_path_buf(),
             source: e,
         })?;
@@ -312,6 +441,17 @@ fn get_supported_extensions() -> PipelineResult<HashSet<&'static str>> {
     Ok(extensions)
 }
 
+/// Whether `path` is a file to index: of a language the settings map and
+/// scope it to, or without settings, of a supported extension.
+fn is_source_file(path: &Path, extensions: &HashSet<&str>, settings: Option<&Settings>) -> bool {
+    let Some(settings) = settings else {
+        return has_supported_extension(path, extensions);
+    };
+    get_registry()
+        .lock()
+        .is_ok_and(|registry| registry.language_for_path(path, settings).is_some())
+}
+
 /// Check if a path has a supported extension.
 fn has_supported_extension(path: &Path, extensions: &HashSet<&str>) -> bool {
     path.extension()
@@ -320,10 +460,47 @@ fn has_supported_extension(path: &Path, extensions: &HashSet<&str>) -> bool {
         .unwrap_or(false)
 }
 
+/// Whether the `.codannaignore` files between `root` and `path` exclude it,
+/// as the walker would.
+///
+/// Gitignore semantics: each file governs its own directory and everything
+/// below it, and the deepest one with a matching pattern decides, so a
+/// nested `!pattern` re-includes what a file higher up excluded. As the
+/// walker never enters an excluded directory, nothing below one can be
+/// re-included. `ignores` caches the parsed files by directory.
+fn is_codannaignored(root: &Path, path: &Path, ignores: &mut HashMap<PathBuf, Gitignore>) -> bool {
+    let Ok(relative) = path.strip_prefix(root) else {
+        return false;
+    };
+    let depth = relative.components().count();
+    let mut entry = root.to_path_buf();
+    for (i, component) in relative.components().enumerate() {
+        entry.push(component);
+        let is_dir = i + 1 < depth;
+        for dir in entry.ancestors().skip(1) {
+            if !dir.starts_with(root) {
+                break;
+            }
+            let ignore = ignores
+                .entry(dir.to_path_buf())
+                .or_insert_with(|| Gitignore::new(dir.join(".codannaignore")).0);
+            let matched = ignore.matched(&entry, is_dir);
+            if matched.is_ignore() {
+                return true;
+            }
+            if matched.is_whitelist() {
+                break;
+            }
+        }
+    }
+    false
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
     use crossbeam_channel::bounded;
+    use std::fs;
 
     #[test]
     fn test_discover_examples_directory() {
@@ -380,6 +557,64 @@ mod tests {
         }
     }
 
+    #[test]
+    fn test_discover_respects_nested_codannaignore() {
+        let root = tempfile::tempdir().unwrap();
+        let root = root.path();
+        for dir in ["src", "gen/keep", "web/generated"] {
+            fs::create_dir_all(root.join(dir)).unwrap();
+        }
+        for file in [
+            "src/lib.rs",
+            "src/types.pb.rs",
+            "gen/keep/api.rs",
+            "web/app.rs",
+            "web/schema.pb.rs",
+            "web/generated/hand_written.rs",
+        ] {
+            fs::write(root.join(file), "pub fn f() {}\n").unwrap();
+        }
+        fs::write(root.join(".codannaignore"), "*.pb.rs\ngen/\n!gen/keep/\n").unwrap();
+        // Lives next to what it excludes, and re-includes what the root
+        // file excluded
+        fs::write(
+            root.join("web/.codannaignore"),
+            "generated/\n!generated/hand_written.rs\n!schema.pb.rs\n",
+        )
+        .unwrap();
+
+        let (sender, receiver) = bounded(100);
+        DiscoverStage::new(root, 2).run(sender).unwrap();
+        let mut found: Vec<PathBuf> = receiver
+            .iter()
+            .map(|path| path.strip_prefix(root).unwrap().to_path_buf())
+            .collect();
+        found.sort();
+        // Nothing below an excluded directory comes back
+        assert_eq!(
+            found,
+            ["src/lib.rs", "web/app.rs", "web/schema.pb.rs"].map(PathBuf::from)
+        );
+
+        // Files git reports are matched the same way
+        let mut ignores = HashMap::new();
+        for file in [
+            "src/lib.rs",
+            "src/types.pb.rs",
+            "gen/keep/api.rs",
+            "web/app.rs",
+            "web/schema.pb.rs",
+            "web/generated/hand_written.rs",
+        ] {
+            let path = root.join(file);
+            assert_eq!(
+                is_codannaignored(root, &path, &mut ignores),
+                !found.contains(&PathBuf::from(file)),
+                "{file}"
+            );
+        }
+    }
+
     #[test]
     fn test_get_supported_extensions() {
         let extensions = get_supported_extensions().unwrap();
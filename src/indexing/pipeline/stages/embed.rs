@@ -123,6 +123,7 @@ impl<G: EmbeddingGenerator> EmbedStage<G> {
             crate::types::SymbolKind::Class => "class",
             crate::types::SymbolKind::Field => "field",
             crate::types::SymbolKind::Parameter => "parameter",
+            crate::types::SymbolKind::Given => "given",
         };
 
         let mut text = format!("{kind_str} {name}");
@@ -4,14 +4,18 @@
 //!
 //! Parallel stage that:
 //! - Receives IndexBatch from COLLECT stage
-//! - Writes symbols, imports, file registrations to Tantivy (parallel via RwLock)
-//! - Accumulates UnresolvedRelationships for Phase 2
+//! - Writes symbols, imports, todos, strings, file registrations to Tantivy (parallel via RwLock)
+//! - Accumulates UnresolvedRelationships for Phase 2, spilling them to disk
+//!   past a limit when memory is bounded
 //! - Builds SymbolLookupCache for O(1) Phase 2 resolution (concurrent DashMap)
 //! - Commits every N batches for efficient I/O
+//! - Journals pending relationships to the run's checkpoint before each commit
 //!
 //! Note: Embedding generation moved to separate EMBED stage (parallel with INDEX).
 
 use crate::indexing::IndexStats;
+use crate::indexing::pipeline::checkpoint::Checkpoint;
+use crate::indexing::pipeline::spill::RelationshipSpill;
 use crate::indexing::pipeline::types::{
     IndexBatch, PipelineError, PipelineResult, SymbolLookupCache, UnresolvedRelationship,
 };
@@ -45,6 +49,12 @@ pub struct IndexStage {
     /// mark so a partial run never persists counters below ids consumed
     /// by earlier generations.
     counter_floor: (u32, u32),
+    /// Pending relationships kept in memory before spilling to disk
+    /// (0 = never spill)
+    spill_limit: usize,
+    /// Checkpoint to journal the run to, carrying over the one an
+    /// interrupted run left
+    checkpoint: Option<PathBuf>,
 }
 
 impl IndexStage {
@@ -59,9 +69,25 @@ impl IndexStage {
             progress: None,
             progress_callback: None,
             counter_floor: (0, 0),
+            spill_limit: 0,
+            checkpoint: None,
         }
     }
 
+    /// Journal the run to the checkpoint at `path`, first picking up the
+    /// entries an interrupted run left there for the files it committed.
+    pub fn with_checkpoint(mut self, path: PathBuf) -> Self {
+        self.checkpoint = Some(path);
+        self
+    }
+
+    /// Spill pending relationships to disk whenever more than `limit` wait
+    /// in memory (0 = never).
+    pub fn with_spill_limit(mut self, limit: usize) -> Self {
+        self.spill_limit = limit;
+        self
+    }
+
     /// Seed the durable-counter high-water mark with the run's starting
     /// counters (from `get_start_counters`).
     pub fn with_counter_floor(mut self, file: u32, symbol: u32) -> Self {
@@ -99,6 +125,7 @@ impl IndexStage {
 
         let mut stats = IndexStats::new();
         let mut pending_relationships: Vec<UnresolvedRelationship> = Vec::new();
+        let mut spill = RelationshipSpill::new(self.spill_limit);
         let mut pending_bindings = super::super::FileBindings::new();
         let mut batch_count = 0;
         let mut failed_files_total = 0usize;
@@ -110,6 +137,31 @@ impl IndexStage {
         // Start initial batch - StorageError converts to PipelineError via #[from]
         self.index.start_batch()?;
 
+        // Before this run commits anything, only the files an interrupted
+        // run committed are registered. Their relationships are resolved
+        // again with this run's, so drop any it had already written.
+        let mut checkpoint = match &self.checkpoint {
+            Some(path) => {
+                let (carried, carried_bindings) = Checkpoint::load(path, &self.index)?;
+                let sources: HashSet<_> = carried.iter().filter_map(|rel| rel.from_id).collect();
+                for id in sources {
+                    self.index.delete_relationships_from_symbol(id)?;
+                }
+                let checkpoint = Checkpoint::create(path, &carried, &carried_bindings)?;
+                if !carried.is_empty() {
+                    tracing::info!(
+                        target: "pipeline",
+                        "INDEX: resuming {} relationships of an interrupted run",
+                        carried.len()
+                    );
+                }
+                pending_relationships = carried;
+                pending_bindings = carried_bindings;
+                Some(checkpoint)
+            }
+            None => None,
+        };
+
         let (mut file_high_water, mut symbol_high_water) = self.counter_floor;
 
         loop {
@@ -121,8 +173,13 @@ impl IndexStage {
             };
             input_wait += recv_start.elapsed();
 
+            if let Some(checkpoint) = &mut checkpoint {
+                checkpoint.record(&batch.unresolved_relationships, &batch.variable_bindings)?;
+            }
+
             // Accumulate relationships for Phase 2
             pending_relationships.extend(std::mem::take(&mut batch.unresolved_relationships));
+            spill.offer(&mut pending_relationships)?;
             pending_bindings.extend(std::mem::take(&mut batch.variable_bindings));
 
             for registration in &batch.file_registrations {
@@ -138,15 +195,30 @@ impl IndexStage {
 
             // Commit every N batches
             if batch_count % self.batches_per_commit == 0 {
+                if let Some(checkpoint) = &mut checkpoint {
+                    checkpoint.sync()?;
+                }
                 self.persist_counters(file_high_water, symbol_high_water)?;
                 self.commit_and_restart()?;
             }
         }
 
         // Final commit
+        if let Some(checkpoint) = &mut checkpoint {
+            checkpoint.sync()?;
+        }
         self.persist_counters(file_high_water, symbol_high_water)?;
         self.index.commit_batch()?;
 
+        if spill.spilled() > 0 {
+            tracing::info!(
+                target: "pipeline",
+                "INDEX: reading back {} relationships spilled to disk",
+                spill.spilled()
+            );
+        }
+        let pending_relationships = spill.finish(pending_relationships)?;
+
         // Clean work is committed and durable; failed files are unregistered
         // (no content hash) so the next run re-visits them. The stage still
         // fails so the run does not report success over a known gap.
@@ -244,6 +316,28 @@ impl IndexStage {
             }
         });
 
+        batch.todos.par_iter().for_each(|todo| {
+            if let Err(e) = self.index.store_todo(todo) {
+                tracing::warn!(
+                    target: "pipeline",
+                    "Failed to store todo at {}:{}: {e}",
+                    todo.file_path,
+                    todo.line + 1
+                );
+            }
+        });
+
+        batch.strings.par_iter().for_each(|literal| {
+            if let Err(e) = self.index.store_string(literal) {
+                tracing::warn!(
+                    target: "pipeline",
+                    "Failed to store string at {}:{}: {e}",
+                    literal.file_path,
+                    literal.line + 1
+                );
+            }
+        });
+
         // Update progress AFTER all work is complete
         // This ensures 100% only shows when files are truly fully processed
         if let Some(ref progress) = self.progress {
@@ -339,6 +433,7 @@ mod tests {
             language_id: LanguageId::new("rust"),
             timestamp: 1700000000,
             mtime: 1700000000,
+            encoding: None,
         });
 
         for i in 0..symbol_count {
@@ -486,6 +581,7 @@ mod tests {
             kind: RelationKind::Calls,
             metadata: None,
             to_range: None,
+            target_language: None,
         });
 
         batch_tx.send(batch).unwrap();
@@ -510,6 +606,94 @@ mod tests {
         assert_eq!(symbol_cache.len(), 2);
     }
 
+    #[test]
+    fn test_index_stage_spills_relationships_past_limit() {
+        use crate::RelationKind;
+
+        let temp_dir = TempDir::new().unwrap();
+        let settings = Settings::default();
+        let index = Arc::new(DocumentIndex::new(temp_dir.path(), &settings).unwrap());
+
+        let (batch_tx, batch_rx) = bounded(10);
+        for file_id in 1..=3 {
+            let mut batch = make_test_batch(file_id, 1);
+            for target in ["a", "b"] {
+                batch.unresolved_relationships.push(UnresolvedRelationship {
+                    from_id: SymbolId::new(file_id),
+                    from_name: Arc::from(format!("sym_{file_id}")),
+                    to_name: Arc::from(target),
+                    file_id: FileId::new(file_id).unwrap(),
+                    kind: RelationKind::Calls,
+                    metadata: None,
+                    to_range: None,
+                    target_language: None,
+                });
+            }
+            batch_tx.send(batch).unwrap();
+        }
+        drop(batch_tx);
+
+        let stage = IndexStage::new(Arc::clone(&index), 1).with_spill_limit(1);
+        let (stats, rels, _, _, _) = stage.run(batch_rx).unwrap();
+
+        assert_eq!(stats.files_indexed, 3);
+        let names: Vec<_> = rels
+            .iter()
+            .map(|rel| format!("{}->{}", rel.from_name, rel.to_name))
+            .collect();
+        assert_eq!(
+            names,
+            [
+                "sym_1->a", "sym_1->b", "sym_2->a", "sym_2->b", "sym_3->a", "sym_3->b"
+            ]
+        );
+    }
+
+    #[test]
+    fn test_index_stage_resumes_from_checkpoint() {
+        use crate::RelationKind;
+        use crate::indexing::pipeline::Checkpoint;
+
+        let temp_dir = TempDir::new().unwrap();
+        let settings = Settings::default();
+        let index = Arc::new(DocumentIndex::new(temp_dir.path(), &settings).unwrap());
+        let checkpoint = Checkpoint::path(temp_dir.path());
+
+        // An interrupted run: file 1 committed, Phase 2 never ran
+        let (batch_tx, batch_rx) = bounded(10);
+        let mut batch = make_test_batch(1, 1);
+        batch.unresolved_relationships.push(UnresolvedRelationship {
+            from_id: SymbolId::new(1),
+            from_name: Arc::from("sym_1"),
+            to_name: Arc::from("callee"),
+            file_id: FileId::new(1).unwrap(),
+            kind: RelationKind::Calls,
+            metadata: None,
+            to_range: None,
+            target_language: None,
+        });
+        batch_tx.send(batch).unwrap();
+        drop(batch_tx);
+        IndexStage::new(Arc::clone(&index), 10)
+            .with_checkpoint(checkpoint.clone())
+            .run(batch_rx)
+            .unwrap();
+        assert!(checkpoint.exists());
+
+        // The next run indexes file 2 and carries file 1's relationship
+        let (batch_tx, batch_rx) = bounded(10);
+        batch_tx.send(make_test_batch(2, 1)).unwrap();
+        drop(batch_tx);
+        let (stats, rels, _, _, _) = IndexStage::new(Arc::clone(&index), 10)
+            .with_checkpoint(checkpoint.clone())
+            .run(batch_rx)
+            .unwrap();
+
+        assert_eq!(stats.files_indexed, 1);
+        assert_eq!(rels.len(), 1);
+        assert_eq!(rels[0].to_name.as_ref(), "callee");
+    }
+
     #[test]
     fn test_symbol_cache_lookup_by_name() {
         let temp_dir = TempDir::new().unwrap();
@@ -527,6 +711,7 @@ mod tests {
             language_id: LanguageId::new("rust"),
             timestamp: 1700000000,
             mtime: 1700000000,
+            encoding: None,
         });
 
         // Add symbols with known names
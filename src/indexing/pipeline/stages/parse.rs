@@ -4,14 +4,21 @@
 //! Uses thread-local parsers to avoid contention.
 
 use crate::Settings;
+use crate::config::LargeFilePolicy;
+use crate::indexing::diagnostics::{DiagnosticKind, FileDiagnostic};
+use crate::indexing::pipeline::cache::ContentCache;
 use crate::indexing::pipeline::types::{
     FileContent, ParsedFile, PipelineError, PipelineResult, RawImport, RawRelationship, RawSymbol,
 };
+use crate::parsing::directives::{self, Directive, DirectiveComment};
+use crate::parsing::parser::{
+    max_ast_depth, set_max_ast_depth, take_depth_exceeded, take_grammar_errors,
+};
 use crate::parsing::{
     LanguageBehavior, LanguageId, LanguageParser, get_registry, normalize_for_module_path,
 };
-use crate::types::{FileId, SymbolCounter};
-use std::cell::RefCell;
+use crate::types::{FileId, Range, SymbolCounter};
+use std::cell::{OnceCell, RefCell};
 use std::collections::HashMap;
 use std::path::{Path, PathBuf};
 use std::sync::Arc;
@@ -51,6 +58,7 @@ thread_local! {
 
 /// Initialize thread-local parser cache for current thread.
 pub fn init_parser_cache(settings: Arc<Settings>) {
+    set_max_ast_depth(settings.indexing.max_ast_depth);
     PARSER_CACHE.with(|cache| {
         *cache.borrow_mut() = Some(ParserCache::new(settings));
     });
@@ -75,10 +83,9 @@ fn create_parser(
         })
 }
 
-/// Detect language from file extension.
-fn detect_language(path: &Path) -> PipelineResult<LanguageId> {
-    let extension = path.extension().and_then(|ext| ext.to_str()).unwrap_or("");
-
+/// Detect language from file extension, as mapped and scoped by the
+/// `[languages]` of the settings.
+fn detect_language(path: &Path, settings: &Settings) -> PipelineResult<LanguageId> {
     let registry = get_registry();
     let registry = registry.lock().map_err(|e| PipelineError::Parse {
         path: path.to_path_buf(),
@@ -86,8 +93,7 @@ fn detect_language(path: &Path) -> PipelineResult<LanguageId> {
     })?;
 
     registry
-        .get_by_extension(extension)
-        .map(|def| def.id())
+        .language_for_path(path, settings)
         .ok_or_else(|| PipelineError::UnsupportedFileType {
             path: path.to_path_buf(),
         })
@@ -100,11 +106,14 @@ pub struct ParseStage {
     /// Root of the tree being indexed, for module-path computation when the
     /// file lies outside `settings.workspace_root` (out-of-tree indexing).
     module_root: Option<PathBuf>,
+    /// Parse results shared with other indexes (`indexing.content_cache`)
+    cache: Option<ContentCache>,
 }
 
 impl ParseStage {
     pub fn new(settings: Arc<Settings>) -> Self {
         Self {
+            cache: ContentCache::from_settings(&settings),
             settings,
             module_root: None,
         }
@@ -120,9 +129,32 @@ impl ParseStage {
         &self.settings
     }
 
-    /// Parse a file using this stage's settings.
+    /// Parse a file using this stage's settings, or read back the parse
+    /// of the same content from the content cache.
     pub fn parse(&self, content: FileContent) -> PipelineResult<ParsedFile> {
-        parse_file_with_root(content, &self.settings, self.module_root.as_deref())
+        let module_root = self.module_root.as_deref();
+        let Some(cache) = &self.cache else {
+            return parse_file_with_root(content, &self.settings, module_root);
+        };
+
+        // The module path depends on the project layout rather than the
+        // content, so it is part of the key
+        let language_id = detect_language(&content.path, &self.settings)?;
+        let module_path = create_behavior(language_id)
+            .as_deref()
+            .and_then(|b| compute_module_path(b, &content.path, &self.settings, module_root));
+        let key = cache.parse_key(
+            &content.path,
+            &content.hash,
+            language_id,
+            module_path.as_deref(),
+        );
+        if let Some(parsed) = cache.get_parsed(&key) {
+            return Ok(parsed);
+        }
+        let parsed = parse_file_with_root(content, &self.settings, module_root)?;
+        cache.put_parsed(&key, &parsed);
+        Ok(parsed)
     }
 }
 
@@ -143,7 +175,31 @@ pub fn parse_file_with_root(
     settings: &Settings,
     module_root: Option<&Path>,
 ) -> PipelineResult<ParsedFile> {
-    let language_id = detect_language(&content.path)?;
+    let language_id = detect_language(&content.path, settings)?;
+
+    let limits = settings
+        .languages
+        .get(language_id.as_str())
+        .map(|config| &config.limits);
+    let mut symbols_only = false;
+    if let Some(limits) = limits {
+        if limits.large_files != LargeFilePolicy::Full {
+            if let Some(reason) = limits.exceeded_by(&content.content) {
+                if limits.large_files == LargeFilePolicy::Skip {
+                    return Err(PipelineError::LargeFileSkipped {
+                        path: content.path,
+                        reason,
+                    });
+                }
+                tracing::debug!(
+                    target: "pipeline",
+                    "indexing symbols only of {}: {reason}",
+                    content.path.display()
+                );
+                symbols_only = true;
+            }
+        }
+    }
 
     PARSER_CACHE.with(|cache| {
         let mut cache_ref = cache.borrow_mut();
@@ -153,10 +209,110 @@ pub fn parse_file_with_root(
 
         let parser = parser_cache.get_or_create(language_id)?;
 
-        parse_with_parser(content, language_id, parser, settings, module_root)
+        let path = content.path.clone();
+        let malformed = content.malformed.then(|| content.encoding.unwrap_or("UTF-8"));
+        take_depth_exceeded();
+        take_grammar_errors();
+        let mut parsed = if symbols_only {
+            parse_symbols_only(content, language_id, parser, settings, module_root)
+        } else {
+            parse_with_parser(content, language_id, parser, settings, module_root)
+        };
+        let depth_exceeded = take_depth_exceeded();
+        if let Some((line, column)) = depth_exceeded {
+            tracing::warn!(
+                target: "pipeline",
+                "{}: nesting deeper than max_ast_depth ({}) from line {line}:{column}; symbols below it were skipped",
+                path.display(),
+                max_ast_depth()
+            );
+        }
+        let grammar_errors = take_grammar_errors();
+        if let Ok(parsed) = &mut parsed {
+            let diagnostics = &mut parsed.diagnostics;
+            if let Some(encoding) = malformed {
+                diagnostics.push(FileDiagnostic::new(
+                    path.clone(),
+                    DiagnosticKind::Encoding,
+                    format!("bytes invalid in {encoding} were replaced"),
+                ));
+            }
+            if let Some(errors) = grammar_errors {
+                diagnostics.push(
+                    FileDiagnostic::new(
+                        path.clone(),
+                        DiagnosticKind::GrammarErrors,
+                        format!(
+                            "{} syntax error(s); symbols inside them may be missing",
+                            errors.count
+                        ),
+                    )
+                    .at_line(errors.first.0),
+                );
+            }
+            if let Some((line, _)) = depth_exceeded {
+                diagnostics.push(
+                    FileDiagnostic::new(
+                        path,
+                        DiagnosticKind::DepthLimit,
+                        format!(
+                            "nesting deeper than max_ast_depth ({}); symbols below it were skipped",
+                            max_ast_depth()
+                        ),
+                    )
+                    .at_line(line),
+                );
+            }
+        }
+        parsed
     })
 }
 
+/// Parse only the symbols of a large file: no doc comments, so nothing to
+/// embed, and no imports, relationships or bindings to resolve.
+fn parse_symbols_only(
+    content: FileContent,
+    language_id: LanguageId,
+    parser: &mut dyn LanguageParser,
+    settings: &Settings,
+    module_root: Option<&Path>,
+) -> PipelineResult<ParsedFile> {
+    let dummy_file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    let behavior = create_behavior(language_id);
+    let module_path = behavior
+        .as_deref()
+        .and_then(|b| compute_module_path(b, &content.path, settings, module_root));
+    let file_tree = behavior
+        .as_deref()
+        .map(|b| FileTree::new(b, &content.content));
+    let directives = file_tree.as_ref().map_or_else(Vec::new, extract_directives);
+    let mut parsed = ParsedFile::new(content.path, content.hash, language_id);
+    parsed.module_path = module_path;
+    parsed.encoding = content.encoding.map(str::to_string);
+    if ignores_file(&directives) {
+        return Ok(parsed);
+    }
+    parsed.raw_symbols = parser
+        .parse(&content.content, dummy_file_id, &mut counter)
+        .into_iter()
+        .map(|sym| {
+            let mut raw = RawSymbol::new(sym.name, sym.kind, sym.range);
+            if let Some(sig) = sym.signature {
+                raw = raw.with_signature(sig);
+            }
+            raw = raw.with_visibility(sym.visibility);
+            if let Some(ctx) = sym.scope_context {
+                raw = raw.with_scope_context(ctx);
+            }
+            raw
+        })
+        .collect();
+    mark_deprecated(&content.content, &mut parsed.raw_symbols);
+    apply_directives(&directives, &mut parsed);
+    Ok(parsed)
+}
+
 /// Parse content using provided parser.
 fn parse_with_parser(
     content: FileContent,
@@ -173,17 +329,29 @@ fn parse_with_parser(
     // One behavior instance serves module_path computation and import
     // normalization below
     let behavior = create_behavior(language_id);
+    let file_tree = behavior
+        .as_deref()
+        .map(|b| FileTree::new(b, &content.content));
 
     // Compute module_path using the language behavior
     let module_path = behavior
         .as_deref()
         .and_then(|b| compute_module_path(b, &content.path, settings, module_root));
 
+    // A file can leave itself out of the index
+    let directives = file_tree.as_ref().map_or_else(Vec::new, extract_directives);
+    if ignores_file(&directives) {
+        let mut parsed = ParsedFile::new(content.path, content.hash, language_id);
+        parsed.module_path = module_path;
+        parsed.encoding = content.encoding.map(str::to_string);
+        return Ok(parsed);
+    }
+
     // Parse symbols
     let symbols = parser.parse(&content.content, dummy_file_id, &mut counter);
 
     // Convert to RawSymbols (strip the dummy ID)
-    let raw_symbols: Vec<RawSymbol> = symbols
+    let mut raw_symbols: Vec<RawSymbol> = symbols
         .into_iter()
         .map(|sym| {
             let mut raw = RawSymbol::new(sym.name, sym.kind, sym.range);
@@ -201,6 +369,12 @@ fn parse_with_parser(
         })
         .collect();
 
+    if settings.indexing.symbol_metrics && measures_functions(language_id) {
+        if let Some(tree) = &file_tree {
+            attach_metrics(tree, &mut raw_symbols);
+        }
+    }
+
     // Extract imports (without FileId), normalized to canonical form
     // (e.g. Python relative imports resolved against the file's module path)
     let imports = parser.find_imports(&content.content, dummy_file_id);
@@ -228,7 +402,78 @@ fn parse_with_parser(
         .collect();
 
     // Extract relationships
-    let raw_relationships = extract_relationships(parser, &content.content);
+    let mut raw_relationships = extract_relationships(parser, &content.content);
+    if settings.indexing.rust_macros && language_id == crate::parsing::rust::RustLanguage::ID {
+        if let Some(tree) = &file_tree {
+            let (symbols, relationships) = extract_rust_macros(tree);
+            raw_symbols.extend(symbols);
+            raw_relationships.extend(relationships);
+        }
+    }
+    if settings.indexing.embedded_sql && language_id != crate::parsing::sql::SqlLanguage::ID {
+        if let Some(tree) = &file_tree {
+            raw_relationships.extend(extract_embedded_sql(tree, &raw_symbols));
+        }
+    }
+
+    if language_id == crate::parsing::markdown::MarkdownLanguage::ID {
+        if let Some(tree) = &file_tree {
+            raw_relationships.extend(extract_doc_references(tree, &raw_symbols));
+        }
+    }
+
+    if names_styles(language_id) {
+        raw_relationships.extend(extract_style_references(&content.content, &raw_symbols));
+    }
+
+    if let Some(behavior) = behavior.as_deref() {
+        raw_relationships.extend(extract_cross_language_targets(behavior, &raw_symbols));
+    }
+
+    if let Some(behavior) = behavior.as_deref() {
+        let headers = behavior.header_extensions();
+        if !headers.is_empty()
+            && !crate::parsing::headers::is_header(&content.path.to_string_lossy(), headers)
+        {
+            raw_relationships.extend(extract_declaration_links(&raw_symbols));
+        }
+    }
+
+    if settings.indexing.embedded_languages {
+        if let Some(tree) = &file_tree {
+            let (symbols, relationships) = extract_embedded_code(tree, settings);
+            raw_symbols.extend(symbols);
+            raw_relationships.extend(relationships);
+        }
+    }
+
+    mark_deprecated(&content.content, &mut raw_symbols);
+    assign_framework_kinds(
+        settings,
+        language_id,
+        &content.path,
+        &content.content,
+        &mut raw_symbols,
+    );
+    attach_body_chunks(settings, language_id, &content.content, &mut raw_symbols);
+
+    if settings.indexing.git_blame {
+        attach_authorship(&content.path, &mut raw_symbols);
+    }
+
+    let todos = if settings.indexing.todo_tags.is_empty() {
+        Vec::new()
+    } else {
+        file_tree.as_ref().map_or_else(Vec::new, |tree| {
+            extract_todos(tree, &settings.indexing.todo_tags)
+        })
+    };
+
+    let strings = if settings.indexing.string_literals {
+        file_tree.as_ref().map_or_else(Vec::new, extract_strings)
+    } else {
+        Vec::new()
+    };
 
     // Typed local bindings feed receiver-type inference in Phase 2
     let variable_bindings = parser
@@ -243,7 +488,7 @@ fn parse_with_parser(
         )
         .collect();
 
-    Ok(ParsedFile {
+    let mut parsed = ParsedFile {
         path: content.path,
         content_hash: content.hash,
         language_id,
@@ -252,7 +497,13 @@ fn parse_with_parser(
         raw_imports,
         raw_relationships,
         variable_bindings,
-    })
+        todos,
+        strings,
+        encoding: content.encoding.map(str::to_string),
+        diagnostics: Vec::new(),
+    };
+    apply_directives(&directives, &mut parsed);
+    Ok(parsed)
 }
 
 /// Create the language behavior for a registered language.
@@ -434,6 +685,35 @@ fn extract_relationships(parser: &mut dyn LanguageParser, content: &str) -> Vec<
             crate::RelationKind::Uses,
         ));
     }
+    for (context, used_name, usage_range) in parser.find_uses_owned(content) {
+        relationships.push(RawRelationship::new(
+            context,
+            usage_range,
+            used_name,
+            usage_range,
+            crate::RelationKind::Uses,
+        ));
+    }
+
+    // Field and module-level value reads/writes - the access is the context
+    for reference in parser.find_value_references(content) {
+        let mut meta = crate::relationship::RelationshipMetadata::new()
+            .at_position(reference.range.start_line, reference.range.start_column)
+            .with_context(reference.access.as_str());
+        if let Some(ref receiver) = reference.receiver {
+            meta = meta.with_receiver(receiver.as_str());
+        }
+        relationships.push(
+            RawRelationship::new(
+                reference.context,
+                reference.range, // from_range = reference site (triggers fallback)
+                reference.name,
+                reference.range, // to_range = reference site
+                crate::RelationKind::References,
+            )
+            .with_metadata(meta),
+        );
+    }
 
     // Method definitions (Defines relationships)
     for (definer, method, def_range) in parser.find_defines(content) {
@@ -449,6 +729,661 @@ fn extract_relationships(parser: &mut dyn LanguageParser, content: &str) -> Vec<
     relationships
 }
 
+/// Methods, functions and trait impls Rust macros generate: derives,
+/// thiserror errors and invocations of same-file `macro_rules!`.
+///
+/// Files naming neither a derive nor a `macro_rules!` leave the tree
+/// alone. A generated method is defined by its type like any impl method,
+/// and its doc comment tells what generated it.
+fn extract_rust_macros(tree: &FileTree) -> (Vec<RawSymbol>, Vec<RawRelationship>) {
+    use crate::parsing::rust::macros;
+
+    let mut symbols = Vec::new();
+    let mut relationships = Vec::new();
+    if !macros::may_generate(tree.content) {
+        return (symbols, relationships);
+    }
+    let Some(root) = tree.root() else {
+        return (symbols, relationships);
+    };
+
+    let expansion = macros::expand(root, tree.content);
+    for generated in expansion.symbols {
+        let scope = match &generated.owner {
+            Some(owner) => {
+                relationships.push(RawRelationship::new(
+                    owner.as_str(),
+                    generated.range, // from_range = generation site (triggers fallback)
+                    generated.name.as_str(),
+                    generated.range,
+                    crate::RelationKind::Defines,
+                ));
+                crate::ScopeContext::ClassMember {
+                    class_name: Some(owner.as_str().into()),
+                }
+            }
+            None => crate::ScopeContext::Module,
+        };
+        symbols.push(
+            RawSymbol::new(generated.name, generated.kind, generated.range)
+                .with_signature(generated.signature)
+                .with_doc_comment(format!("Generated by `{}`", generated.origin))
+                .with_visibility(generated.visibility)
+                .with_scope_context(scope),
+        );
+    }
+    for generated in expansion.impls {
+        relationships.push(RawRelationship::new(
+            generated.type_name,
+            generated.range,
+            generated.trait_name,
+            generated.range,
+            crate::RelationKind::Implements,
+        ));
+    }
+    (symbols, relationships)
+}
+
+/// Whether the file's grammar parses the whole file, so tree positions are
+/// the symbols' own. Component and notebook formats parse their code blocks
+/// separately, and Markdown has no functions.
+fn measures_functions(language_id: LanguageId) -> bool {
+    ![
+        crate::parsing::markdown::MarkdownLanguage::ID,
+        crate::parsing::vue::VueLanguage::ID,
+        crate::parsing::svelte::SvelteLanguage::ID,
+        crate::parsing::jupyter::JupyterLanguage::ID,
+    ]
+    .contains(&language_id)
+}
+
+/// The file's syntax tree in the grammar of its behavior, shared by the
+/// passes after symbol extraction.
+///
+/// Language parsers keep their trees to themselves, so the file is parsed
+/// once more, by the first pass that needs the tree, and every later pass
+/// takes the same one. Passes with a cheap pre-filter leave files it rules
+/// out unparsed.
+struct FileTree<'a> {
+    behavior: &'a dyn LanguageBehavior,
+    content: &'a str,
+    tree: OnceCell<Option<tree_sitter::Tree>>,
+}
+
+impl<'a> FileTree<'a> {
+    fn new(behavior: &'a dyn LanguageBehavior, content: &'a str) -> Self {
+        Self {
+            behavior,
+            content,
+            tree: OnceCell::new(),
+        }
+    }
+
+    /// The root of the tree; none when the grammar cannot parse the file
+    fn root(&self) -> Option<tree_sitter::Node<'_>> {
+        self.tree
+            .get_or_init(|| {
+                let mut parser = tree_sitter::Parser::new();
+                parser.set_language(&self.behavior.get_language()).ok()?;
+                parser.parse(self.content, None)
+            })
+            .as_ref()
+            .map(|tree| tree.root_node())
+    }
+}
+
+/// Complexity, lines and parameters of each function and method, measured
+/// on the shared tree.
+fn attach_metrics(tree: &FileTree, symbols: &mut [RawSymbol]) {
+    use crate::parsing::metrics;
+
+    let functions: Vec<usize> = symbols
+        .iter()
+        .enumerate()
+        .filter(|(_, symbol)| {
+            matches!(
+                symbol.kind,
+                crate::SymbolKind::Function | crate::SymbolKind::Method
+            )
+        })
+        .map(|(index, _)| index)
+        .collect();
+    if functions.is_empty() {
+        return;
+    }
+    let Some(root) = tree.root() else {
+        return;
+    };
+
+    let ranges: Vec<crate::Range> = functions
+        .iter()
+        .map(|&index| symbols[index].range)
+        .collect();
+    for (index, measured) in functions.into_iter().zip(metrics::measure(root, &ranges)) {
+        symbols[index].metrics = Some(measured);
+    }
+}
+
+/// The last commit touching the lines of each symbol, from `git blame` of
+/// the file. Files outside a repository, or git missing, leave them unset.
+fn attach_authorship(path: &Path, symbols: &mut [RawSymbol]) {
+    let Ok(lines) = crate::git::blame_file(path) else {
+        return;
+    };
+    for symbol in symbols {
+        let start = symbol.range.start_line as usize;
+        let end = (symbol.range.end_line as usize + 1).min(lines.len());
+        symbol.authorship = lines
+            .get(start..end)
+            .unwrap_or_default()
+            .iter()
+            .flatten()
+            .max_by_key(|line| line.timestamp)
+            .map(|line| crate::symbol::Authorship {
+                author: line.author.clone(),
+                commit: line.commit.clone(),
+                timestamp: line.timestamp,
+            });
+    }
+}
+
+/// TODO, FIXME and the other configured tags in comments; files without a
+/// tag leave the tree alone.
+fn extract_todos(tree: &FileTree, tags: &[String]) -> Vec<crate::parsing::todo::TodoComment> {
+    if !tags.iter().any(|tag| tree.content.contains(tag.as_str())) {
+        return Vec::new();
+    }
+    let Some(root) = tree.root() else {
+        return Vec::new();
+    };
+    crate::parsing::todo::extract_todos(root, tree.content, tags)
+}
+
+/// The URLs, routes, format strings and messages of string literals.
+fn extract_strings(tree: &FileTree) -> Vec<crate::parsing::strings::StringLiteral> {
+    let Some(root) = tree.root() else {
+        return Vec::new();
+    };
+    crate::parsing::strings::extract_strings(root, tree.content)
+}
+
+/// The `codanna:` directives in comments; files without a directive leave
+/// the tree alone.
+fn extract_directives(tree: &FileTree) -> Vec<DirectiveComment> {
+    if !directives::may_contain_directive(tree.content) {
+        return Vec::new();
+    }
+    let Some(root) = tree.root() else {
+        return Vec::new();
+    };
+    directives::extract_directives(root, tree.content)
+}
+
+/// Tag the symbols their language marks deprecated (see
+/// [`crate::parsing::deprecation`]).
+fn mark_deprecated(content: &str, symbols: &mut [RawSymbol]) {
+    use crate::parsing::deprecation::{DEPRECATED_TAG, is_deprecated};
+
+    let lines: Vec<&str> = content.lines().collect();
+    for symbol in symbols {
+        if is_deprecated(&lines, &symbol.range, symbol.doc_comment.as_deref())
+            && !symbol.tags.iter().any(|tag| tag == DEPRECATED_TAG)
+        {
+            symbol.tags.push(DEPRECATED_TAG.to_string());
+        }
+    }
+}
+
+/// Give the symbols the framework kind of the first
+/// `[[languages.<name>.kinds]]` rule of their language matching them (see
+/// [`crate::parsing::kinds`]); embedded code takes its own language's.
+fn assign_framework_kinds(
+    settings: &Settings,
+    language_id: LanguageId,
+    path: &Path,
+    content: &str,
+    symbols: &mut [RawSymbol],
+) {
+    use crate::parsing::kinds::{KindCandidate, KindRules};
+
+    if settings
+        .languages
+        .values()
+        .all(|config| config.kinds.is_empty())
+    {
+        return;
+    }
+    // Loading rejects invalid rules, so compiling does not fail here
+    let mut rules: HashMap<LanguageId, KindRules> = HashMap::new();
+    let lines: Vec<&str> = content.lines().collect();
+    let relative = settings.workspace_relative(path);
+    for symbol in symbols {
+        let language = symbol.language_id.unwrap_or(language_id);
+        let language_rules = rules.entry(language).or_insert_with(|| {
+            settings
+                .languages
+                .get(language.as_str())
+                .and_then(|config| KindRules::compile(&config.kinds).ok())
+                .unwrap_or_default()
+        });
+        if language_rules.is_empty() {
+            continue;
+        }
+        let candidate = KindCandidate {
+            name: &symbol.name,
+            kind: symbol.kind,
+            range: symbol.range,
+            signature: symbol.signature.as_deref(),
+        };
+        symbol.framework_kind = language_rules
+            .kind_of(&candidate, &lines, relative)
+            .map(Into::into);
+    }
+}
+
+/// Split the bodies of functions and methods into the chunks their code
+/// is embedded in, with `semantic_search.code_chunks` (see
+/// [`crate::semantic::chunking`]); embedded code is chunked by its own
+/// language's syntax.
+fn attach_body_chunks(
+    settings: &Settings,
+    language_id: LanguageId,
+    content: &str,
+    symbols: &mut [RawSymbol],
+) {
+    use crate::semantic::chunking::{ChunkLimits, chunk_lines};
+
+    let semantic = &settings.semantic_search;
+    if !semantic.enabled || !semantic.code_chunks {
+        return;
+    }
+    let limits = ChunkLimits::from_tokens(semantic.code_chunk_tokens, semantic.code_chunk_overlap);
+    let lines: Vec<&str> = content.lines().collect();
+    for symbol in symbols {
+        let is_function = matches!(
+            symbol.kind,
+            crate::SymbolKind::Function | crate::SymbolKind::Method
+        );
+        let start = symbol.range.start_line as usize;
+        let end = (symbol.range.end_line as usize + 1).min(lines.len());
+        if !is_function || symbol.signature.is_none() || start >= end {
+            continue;
+        }
+        let language = symbol.language_id.unwrap_or(language_id);
+        symbol.body_chunks = chunk_lines(&lines[start..end], language.as_str(), limits)
+            .into_iter()
+            .map(Into::into)
+            .collect();
+    }
+}
+
+fn ignores_file(directives: &[DirectiveComment]) -> bool {
+    directives
+        .iter()
+        .any(|found| found.directive == Directive::IgnoreFile)
+}
+
+/// Tag the symbols of `codanna:tag=` directives, and leave out those of
+/// `codanna:ignore-next-symbol` with everything within them: members,
+/// relationships from them, bindings, todos and string literals.
+fn apply_directives(found: &[DirectiveComment], parsed: &mut ParsedFile) {
+    let ranges: Vec<Range> = parsed.raw_symbols.iter().map(|sym| sym.range).collect();
+    let mut ignored: Vec<Range> = Vec::new();
+    for directive in found {
+        let Some(index) = directives::next_symbol(directive, &ranges) else {
+            continue;
+        };
+        match &directive.directive {
+            Directive::IgnoreNextSymbol => ignored.push(ranges[index]),
+            Directive::Tag(tags) => {
+                let symbol = &mut parsed.raw_symbols[index];
+                for tag in tags {
+                    if !symbol.tags.contains(tag) {
+                        symbol.tags.push(tag.clone());
+                    }
+                }
+            }
+            Directive::IgnoreFile => {}
+        }
+    }
+    if ignored.is_empty() {
+        return;
+    }
+
+    let inside = |range: &Range| ignored.iter().any(|outer| directives::within(range, outer));
+    let on_ignored_line = |line: u32| {
+        ignored
+            .iter()
+            .any(|outer| outer.start_line <= line && line <= outer.end_line)
+    };
+    parsed.raw_symbols.retain(|sym| !inside(&sym.range));
+    parsed
+        .raw_relationships
+        .retain(|rel| !inside(&rel.from_range));
+    parsed
+        .variable_bindings
+        .retain(|binding| !inside(&binding.range));
+    parsed.todos.retain(|todo| !on_ignored_line(todo.line));
+    parsed
+        .strings
+        .retain(|literal| !on_ignored_line(literal.line));
+}
+
+/// Table references of SQL queries in string literals, from the innermost
+/// function or method holding each query.
+///
+/// Files without a query keyword leave the tree alone. Targets belong to
+/// SQL files, so the relationships resolve in that language.
+fn extract_embedded_sql(tree: &FileTree, symbols: &[RawSymbol]) -> Vec<RawRelationship> {
+    use crate::parsing::sql::{SqlLanguage, embedded};
+
+    if !embedded::may_contain_query(tree.content) {
+        return Vec::new();
+    }
+    let Some(root) = tree.root() else {
+        return Vec::new();
+    };
+
+    embedded::find_embedded_references(root, tree.content)
+        .into_iter()
+        .filter_map(|reference| {
+            let site = reference.range;
+            let owner = symbols
+                .iter()
+                .filter(|sym| {
+                    matches!(
+                        sym.kind,
+                        crate::SymbolKind::Function | crate::SymbolKind::Method
+                    ) && sym.range.contains(site.start_line, site.start_column)
+                })
+                .max_by_key(|sym| (sym.range.start_line, sym.range.start_column))?;
+            let meta = crate::relationship::RelationshipMetadata::new()
+                .at_position(site.start_line, site.start_column)
+                .with_context(reference.query);
+            Some(
+                RawRelationship::new(
+                    owner.name.clone(),
+                    owner.range,
+                    reference.name,
+                    site,
+                    reference.kind,
+                )
+                .with_metadata(meta)
+                .in_language(SqlLanguage::ID),
+            )
+        })
+        .collect()
+}
+
+/// Symbols and relationships of the code embedded in the file: scripts of
+/// an HTML page, examples of Rust doc comments and SQL definitions in
+/// string literals (see [`crate::parsing::embedded`]).
+///
+/// Each block is parsed by its language's parser, on the file's text with
+/// everything else blanked, so positions are the file's own. The symbols
+/// keep their language, and their relationships resolve in it. The
+/// placeholder module a parser names the whole text with stands for the
+/// file, which has a symbol of its own.
+fn extract_embedded_code(
+    tree: &FileTree,
+    settings: &Settings,
+) -> (Vec<RawSymbol>, Vec<RawRelationship>) {
+    use crate::parsing::embedded;
+    use crate::parsing::parser::keeping_parse_diagnostics;
+
+    let content = tree.content;
+    let language_id = tree.behavior.language_id();
+    let mut blocks = Vec::new();
+    if language_id == crate::parsing::html::HtmlLanguage::ID {
+        blocks.extend(embedded::html_scripts(content));
+    }
+    if language_id == crate::parsing::rust::RustLanguage::ID {
+        blocks.extend(embedded::rust_doc_examples(content));
+    }
+    if language_id != crate::parsing::sql::SqlLanguage::ID
+        && embedded::may_contain_sql_definition(content)
+    {
+        if let Some(root) = tree.root() {
+            blocks.extend(embedded::sql_definitions(root, content));
+        }
+    }
+
+    let mut symbols = Vec::new();
+    let mut relationships = Vec::new();
+    if blocks.is_empty() {
+        return (symbols, relationships);
+    }
+    let dummy_file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    let mut parsers: HashMap<LanguageId, Box<dyn LanguageParser>> = HashMap::new();
+    keeping_parse_diagnostics(|| {
+        for block in blocks {
+            let parser = match parsers.entry(block.language) {
+                std::collections::hash_map::Entry::Occupied(entry) => entry.into_mut(),
+                std::collections::hash_map::Entry::Vacant(entry) => {
+                    // A language turned off in the settings has no parser
+                    match create_parser(block.language, settings) {
+                        Ok(parser) => entry.insert(parser),
+                        Err(_) => continue,
+                    }
+                }
+            };
+            let code = block.mask(content);
+            for sym in parser.parse(&code, dummy_file_id, &mut counter) {
+                if sym.kind == crate::SymbolKind::Module && sym.name.starts_with('<') {
+                    continue;
+                }
+                let mut raw = RawSymbol::new(sym.name, sym.kind, sym.range)
+                    .with_visibility(sym.visibility)
+                    .in_language(block.language);
+                if let Some(sig) = sym.signature {
+                    raw = raw.with_signature(sig);
+                }
+                if let Some(doc) = sym.doc_comment {
+                    raw = raw.with_doc_comment(doc);
+                }
+                if let Some(ctx) = sym.scope_context {
+                    raw = raw.with_scope_context(ctx);
+                }
+                symbols.push(raw);
+            }
+            relationships.extend(
+                extract_relationships(parser.as_mut(), &code)
+                    .into_iter()
+                    .map(|rel| rel.in_language(block.language)),
+            );
+        }
+    });
+    (symbols, relationships)
+}
+
+/// Code symbols named in the inline code of a Markdown document, used by
+/// the innermost section holding each name; a section names each symbol
+/// once.
+///
+/// The qualifier of a name (`SessionStore` in `SessionStore::open`) is
+/// kept as its receiver, and the references resolve in whichever language
+/// holds their symbol (see `LanguageBehavior::references_other_languages`).
+fn extract_doc_references(tree: &FileTree, symbols: &[RawSymbol]) -> Vec<RawRelationship> {
+    use crate::parsing::markdown::references;
+
+    let Some(root) = tree.root() else {
+        return Vec::new();
+    };
+
+    let mut seen = std::collections::HashSet::new();
+    references::find_references(root, tree.content)
+        .into_iter()
+        .filter_map(|reference| {
+            let site = reference.range;
+            let section = symbols
+                .iter()
+                .filter(|sym| {
+                    sym.kind == crate::SymbolKind::Module
+                        && sym.range.contains(site.start_line, site.start_column)
+                })
+                .max_by_key(|sym| (sym.range.start_line, sym.range.start_column))?;
+            if !seen.insert((section.range, reference.text)) {
+                return None;
+            }
+            let mut meta = crate::relationship::RelationshipMetadata::new()
+                .at_position(site.start_line, site.start_column)
+                .with_context(reference.text);
+            if let Some(receiver) = reference.receiver {
+                meta = meta.with_receiver(receiver);
+            }
+            Some(
+                RawRelationship::new(
+                    section.name.clone(),
+                    section.range,
+                    reference.name,
+                    site,
+                    crate::RelationKind::Uses,
+                )
+                .with_metadata(meta),
+            )
+        })
+        .collect()
+}
+
+/// Whether files of the language name stylesheet classes, ids and custom
+/// properties: markup, components and the stylesheets themselves.
+fn names_styles(language_id: LanguageId) -> bool {
+    [
+        LanguageId::new("javascript"),
+        LanguageId::new("typescript"),
+        crate::parsing::vue::VueLanguage::ID,
+        crate::parsing::svelte::SvelteLanguage::ID,
+        crate::parsing::html::HtmlLanguage::ID,
+        crate::parsing::css::CssLanguage::ID,
+    ]
+    .contains(&language_id)
+}
+
+/// Stylesheet classes, ids and custom properties named in the file, used
+/// by the innermost symbol holding each name; a symbol names each once.
+///
+/// Usages are found in the text (see [`crate::parsing::css::usages`]), so
+/// no second parse is needed. Definitions belong to stylesheets, so the
+/// relationships resolve in that language.
+fn extract_style_references(content: &str, symbols: &[RawSymbol]) -> Vec<RawRelationship> {
+    use crate::parsing::css::{CssLanguage, usages};
+
+    let mut seen = std::collections::HashSet::new();
+    usages::find_usages(content)
+        .into_iter()
+        .filter_map(|usage| {
+            let site = usage.range;
+            let owner = symbols
+                .iter()
+                .filter(|sym| {
+                    sym.kind != crate::SymbolKind::Parameter
+                        && sym.range.contains(site.start_line, site.start_column)
+                })
+                .max_by_key(|sym| (sym.range.start_line, sym.range.start_column))?;
+            // `--gap: var(--gap)` falls back to the inherited value
+            if owner.name.as_ref() == usage.name {
+                return None;
+            }
+            if !seen.insert((owner.range, usage.name.clone())) {
+                return None;
+            }
+            let meta = crate::relationship::RelationshipMetadata::new()
+                .at_position(site.start_line, site.start_column)
+                .with_context(usage.via);
+            Some(
+                RawRelationship::new(
+                    owner.name.clone(),
+                    owner.range,
+                    usage.name,
+                    site,
+                    crate::RelationKind::References,
+                )
+                .with_metadata(meta)
+                .in_language(CssLanguage::ID),
+            )
+        })
+        .collect()
+}
+
+/// Links from the functions and methods a source file defines to their
+/// declarations, each named after its definition; they resolve among the
+/// headers of the language (see [`crate::parsing::headers`]). Forward
+/// declarations precede their definition in the file and link nothing.
+fn extract_declaration_links(symbols: &[RawSymbol]) -> Vec<RawRelationship> {
+    use crate::symbol::ScopeContext;
+
+    let defined = |sym: &RawSymbol| {
+        matches!(
+            sym.kind,
+            crate::SymbolKind::Function | crate::SymbolKind::Method
+        ) && !matches!(
+            sym.scope_context,
+            Some(ScopeContext::Local { .. } | ScopeContext::Parameter)
+        )
+    };
+    symbols
+        .iter()
+        .enumerate()
+        .filter(|(_, sym)| defined(sym))
+        .filter(|(at, sym)| {
+            !symbols[at + 1..].iter().any(|later| {
+                later.name == sym.name
+                    && later.kind == sym.kind
+                    && later.scope_context == sym.scope_context
+            })
+        })
+        .map(|(_, sym)| {
+            let meta = crate::relationship::RelationshipMetadata::new()
+                .at_position(sym.range.start_line, sym.range.start_column)
+                .with_context(crate::relationship::RelationshipMetadata::DECLARATION);
+            RawRelationship::new(
+                sym.name.clone(),
+                sym.range,
+                sym.name.clone(),
+                sym.range,
+                crate::RelationKind::Implements,
+            )
+            .with_metadata(meta)
+        })
+        .collect()
+}
+
+/// Calls from symbols to their implementations in other languages (RPC
+/// handlers, GraphQL resolvers) or other modules (Terraform module
+/// inputs), as named by the behavior; each resolves among the symbols of
+/// its target language.
+fn extract_cross_language_targets(
+    behavior: &dyn LanguageBehavior,
+    symbols: &[RawSymbol],
+) -> Vec<RawRelationship> {
+    let mut relationships = Vec::new();
+    for symbol in symbols {
+        let targets = behavior.cross_language_targets(
+            &symbol.name,
+            symbol.kind,
+            symbol.signature.as_deref(),
+            symbol.scope_context.as_ref(),
+        );
+        for (language, target) in targets {
+            let meta = crate::relationship::RelationshipMetadata::new()
+                .at_position(symbol.range.start_line, symbol.range.start_column);
+            relationships.push(
+                RawRelationship::new(
+                    symbol.name.clone(),
+                    symbol.range,
+                    target,
+                    symbol.range,
+                    crate::RelationKind::Calls,
+                )
+                .with_metadata(meta)
+                .in_language(language),
+            );
+        }
+    }
+    relationships
+}
+
 /// Compute content hash using FNV-1a.
 pub fn compute_hash(content: &[u8]) -> u64 {
     const FNV_OFFSET_BASIS: u64 = 0xcbf29ce484222325;
@@ -527,7 +1462,7 @@ mod tests {
     #[test]
     fn test_detect_language_rust() {
         let path = Path::new("test.rs");
-        let result = detect_language(path);
+        let result = detect_language(path, &Settings::default());
         assert!(result.is_ok());
         assert_eq!(result.unwrap().as_str(), "rust");
     }
@@ -535,7 +1470,7 @@ mod tests {
     #[test]
     fn test_detect_language_typescript() {
         let path = Path::new("app.ts");
-        let result = detect_language(path);
+        let result = detect_language(path, &Settings::default());
         assert!(result.is_ok());
         assert_eq!(result.unwrap().as_str(), "typescript");
     }
@@ -543,10 +1478,33 @@ mod tests {
     #[test]
     fn test_detect_language_unknown() {
         let path = Path::new("file.xyz");
-        let result = detect_language(path);
+        let result = detect_language(path, &Settings::default());
         assert!(result.is_err());
     }
 
+    #[test]
+    fn test_detect_language_mapped_and_scoped() {
+        let mut settings = Settings::default();
+        let cpp = settings.languages.get_mut("cpp").unwrap();
+        cpp.extensions.push("h".to_string());
+        let typescript = settings.languages.get_mut("typescript").unwrap();
+        typescript.scope.include = vec!["src/**".to_string()];
+        typescript.scope.exclude = vec!["**/*.gen.ts".to_string()];
+
+        let language = |path: &str| {
+            detect_language(Path::new(path), &settings)
+                .ok()
+                .map(|id| id.as_str())
+        };
+        assert_eq!(language("include/api.h"), Some("cpp"));
+        assert_eq!(language("src/app.ts"), Some("typescript"));
+        assert_eq!(language("./src/app.ts"), Some("typescript"));
+        assert_eq!(language("scripts/build.ts"), None);
+        assert_eq!(language("src/api.gen.ts"), None);
+        // Python keeps its extensions and has no globs
+        assert_eq!(language("scripts/build.py"), Some("python"));
+    }
+
     #[test]
     fn test_parse_file_rust() {
         let settings = Arc::new(Settings::default());
@@ -590,6 +1548,60 @@ pub struct Foo {
         assert_eq!(sym.name.as_ref(), "test");
     }
 
+    #[test]
+    fn test_large_file_policy() {
+        use crate::config::FileLimits;
+
+        let source = "/// Builds it\npub fn build() { helper(); }\nfn helper() {}\n";
+        let content = || FileContent::new("gen.rs".into(), source.to_string(), "h".to_string());
+        let with_limits = |limits: FileLimits| {
+            let mut settings = Settings::default();
+            settings.languages.get_mut("rust").unwrap().limits = limits;
+            let settings = Arc::new(settings);
+            init_parser_cache(settings.clone());
+            settings
+        };
+
+        let settings = with_limits(FileLimits {
+            max_line_length: Some(20),
+            ..Default::default()
+        });
+        match parse_file(content(), &settings) {
+            Err(PipelineError::LargeFileSkipped { path, reason }) => {
+                assert_eq!(path, PathBuf::from("gen.rs"));
+                assert!(reason.contains("max_line_length = 20"), "{reason}");
+            }
+            other => panic!("expected the file to be skipped, got {other:?}"),
+        }
+
+        let settings = with_limits(FileLimits {
+            max_file_size: Some(10),
+            large_files: LargeFilePolicy::SymbolsOnly,
+            ..Default::default()
+        });
+        let parsed = parse_file(content(), &settings).unwrap();
+        assert_eq!(parsed.symbol_count(), 2);
+        assert!(parsed.raw_symbols.iter().all(|s| s.doc_comment.is_none()));
+        assert!(parsed.raw_relationships.is_empty());
+
+        // Within the limits, or with the full policy, nothing changes
+        for limits in [
+            FileLimits {
+                max_file_size: Some(1024),
+                max_line_length: Some(80),
+                ..Default::default()
+            },
+            FileLimits {
+                max_file_size: Some(10),
+                large_files: LargeFilePolicy::Full,
+                ..Default::default()
+            },
+        ] {
+            let parsed = parse_file(content(), &with_limits(limits)).unwrap();
+            assert!(!parsed.raw_relationships.is_empty());
+        }
+    }
+
     #[test]
     fn test_method_call_metadata_static_rust() {
         let settings = Arc::new(Settings::default());
@@ -744,4 +1756,578 @@ impl Foo {
             ),
         }
     }
+
+    #[test]
+    fn test_embedded_sql_links_enclosing_function() {
+        let content = r#"
+QUERY = "SELECT * FROM ignored_at_module_level"
+
+def load_user(db, user_id):
+    return db.execute("SELECT * FROM users WHERE id = %s", (user_id,))
+"#;
+        let parse = |embedded_sql: bool| {
+            let mut settings = Settings::default();
+            settings.indexing.embedded_sql = embedded_sql;
+            let settings = Arc::new(settings);
+            init_parser_cache(settings.clone());
+            let file = FileContent::new(
+                "repo.py".into(),
+                content.to_string(),
+                "embedded_sql_hash".to_string(),
+            );
+            parse_file(file, &settings).unwrap()
+        };
+
+        let parsed = parse(true);
+        let uses: Vec<_> = parsed
+            .raw_relationships
+            .iter()
+            .filter(|r| r.target_language == Some(LanguageId::new("sql")))
+            .collect();
+        assert_eq!(uses.len(), 1, "got {uses:?}");
+        assert_eq!(uses[0].from_name.as_ref(), "load_user");
+        assert_eq!(uses[0].to_name.as_ref(), "users");
+        assert_eq!(uses[0].kind, crate::RelationKind::Uses);
+        assert_eq!(
+            uses[0].metadata.as_ref().and_then(|m| m.context.as_deref()),
+            Some("SELECT * FROM users WHERE id = %s")
+        );
+
+        // Off by default
+        let parsed = parse(false);
+        assert!(
+            parsed
+                .raw_relationships
+                .iter()
+                .all(|r| r.target_language.is_none())
+        );
+    }
+
+    #[test]
+    fn test_post_passes_share_one_tree() {
+        let behavior = create_behavior(crate::parsing::rust::RustLanguage::ID).unwrap();
+        let content = "// TODO: split\nfn run() { let q = \"SELECT id FROM users\"; }\n";
+        let tree = FileTree::new(behavior.as_ref(), content);
+        let first = tree.root().unwrap();
+        assert_eq!(first.id(), tree.root().unwrap().id(), "parsed once");
+
+        let todos = extract_todos(&tree, &["TODO".to_string()]);
+        assert_eq!(todos.len(), 1);
+        assert_eq!(first.id(), tree.root().unwrap().id());
+    }
+
+    #[test]
+    fn test_rust_macros_add_generated_symbols() {
+        let content = r#"
+#[derive(Clone, PartialEq)]
+pub struct Config {
+    retries: u32,
+}
+"#;
+        let parse = |rust_macros: bool| {
+            let mut settings = Settings::default();
+            settings.indexing.rust_macros = rust_macros;
+            let settings = Arc::new(settings);
+            init_parser_cache(settings.clone());
+            let file = FileContent::new(
+                "config.rs".into(),
+                content.to_string(),
+                "rust_macros_hash".to_string(),
+            );
+            parse_file(file, &settings).unwrap()
+        };
+
+        let parsed = parse(true);
+        let clone = parsed
+            .raw_symbols
+            .iter()
+            .find(|s| s.name.as_ref() == "clone")
+            .expect("derived clone");
+        assert_eq!(clone.kind, crate::SymbolKind::Method);
+        assert_eq!(clone.signature.as_deref(), Some("fn clone(&self) -> Self"));
+        assert_eq!(
+            clone.doc_comment.as_deref(),
+            Some("Generated by `#[derive(Clone)]`")
+        );
+        assert_eq!(
+            clone.scope_context,
+            Some(crate::ScopeContext::ClassMember {
+                class_name: Some("Config".into())
+            })
+        );
+
+        let implemented: Vec<&str> = parsed
+            .raw_relationships
+            .iter()
+            .filter(|r| r.kind == crate::RelationKind::Implements)
+            .map(|r| r.to_name.as_ref())
+            .collect();
+        assert_eq!(implemented, ["Clone", "PartialEq"]);
+        assert!(parsed.raw_relationships.iter().any(|r| {
+            r.kind == crate::RelationKind::Defines
+                && r.from_name.as_ref() == "Config"
+                && r.to_name.as_ref() == "eq"
+        }));
+
+        // Off by default
+        let parsed = parse(false);
+        assert!(
+            parsed
+                .raw_symbols
+                .iter()
+                .all(|s| s.name.as_ref() != "clone")
+        );
+    }
+
+    #[test]
+    fn test_embedded_code_indexed_in_its_host() {
+        let content = r#"<html>
+<body>
+  <button id="save">Save</button>
+  <script>
+    function save() { persist(); }
+    function persist() {}
+  </script>
+</body>
+</html>
+"#;
+        let parse = |embedded_languages: bool| {
+            let mut settings = Settings::default();
+            settings.indexing.embedded_languages = embedded_languages;
+            let settings = Arc::new(settings);
+            init_parser_cache(settings.clone());
+            let file = FileContent::new(
+                "page.html".into(),
+                content.to_string(),
+                "embedded_hash".to_string(),
+            );
+            parse_file(file, &settings).unwrap()
+        };
+
+        let parsed = parse(true);
+        let save = parsed
+            .raw_symbols
+            .iter()
+            .find(|s| s.name.as_ref() == "save")
+            .expect("script function");
+        assert_eq!(save.kind, crate::SymbolKind::Function);
+        assert_eq!(save.range.start_line, 4, "at its line in the page");
+        assert_eq!(
+            save.language_id,
+            Some(crate::parsing::LanguageId::new("javascript"))
+        );
+        let call = parsed
+            .raw_relationships
+            .iter()
+            .find(|r| r.kind == crate::RelationKind::Calls && r.to_name.as_ref() == "persist")
+            .expect("call in the script");
+        assert_eq!(call.from_name.as_ref(), "save");
+        assert_eq!(call.target_language, save.language_id);
+
+        // The page's own symbols keep its language
+        let button = parsed
+            .raw_symbols
+            .iter()
+            .find(|s| s.name.as_ref() == "#save")
+            .unwrap();
+        assert_eq!(button.language_id, None);
+
+        // Off by default
+        let parsed = parse(false);
+        assert!(parsed.raw_symbols.iter().all(|s| s.name.as_ref() != "save"));
+    }
+
+    #[test]
+    fn test_rust_doc_examples_indexed() {
+        let content = r#"/// Opens the store.
+///
+/// ```
+/// # use store::open;
+/// struct Backup;
+/// fn restore() { open(); }
+/// ```
+pub fn open() {}
+"#;
+        let mut settings = Settings::default();
+        settings.indexing.embedded_languages = true;
+        let settings = Arc::new(settings);
+        init_parser_cache(settings.clone());
+        let file = FileContent::new(
+            "store.rs".into(),
+            content.to_string(),
+            "doc_examples_hash".to_string(),
+        );
+        let parsed = parse_file(file, &settings).unwrap();
+
+        let lines: Vec<(&str, u32)> = parsed
+            .raw_symbols
+            .iter()
+            .map(|s| (s.name.as_ref(), s.range.start_line))
+            .collect();
+        assert!(lines.contains(&("open", 7)));
+        assert!(lines.contains(&("Backup", 4)));
+        assert!(lines.contains(&("restore", 5)));
+        assert!(
+            parsed.diagnostics.is_empty(),
+            "the example's parse reports nothing of the file"
+        );
+    }
+
+    #[test]
+    fn test_symbol_metrics_measure_functions() {
+        let content = r#"
+pub struct Retry;
+
+impl Retry {
+    pub fn delay(&self, attempt: u32) -> u32 {
+        if attempt > 3 || attempt == 0 { 0 } else { attempt * 100 }
+    }
+}
+"#;
+        let parse = |symbol_metrics: bool| {
+            let mut settings = Settings::default();
+            settings.indexing.symbol_metrics = symbol_metrics;
+            let settings = Arc::new(settings);
+            init_parser_cache(settings.clone());
+            let file = FileContent::new(
+                "retry.rs".into(),
+                content.to_string(),
+                "symbol_metrics_hash".to_string(),
+            );
+            parse_file(file, &settings).unwrap()
+        };
+        let metrics_of = |parsed: &ParsedFile, name: &str| {
+            parsed
+                .raw_symbols
+                .iter()
+                .find(|s| s.name.as_ref() == name)
+                .and_then(|s| s.metrics)
+        };
+
+        let parsed = parse(true);
+        assert_eq!(
+            metrics_of(&parsed, "delay"),
+            Some(crate::symbol::SymbolMetrics {
+                complexity: 3,
+                lines: 3,
+                parameters: 2,
+            })
+        );
+        assert_eq!(metrics_of(&parsed, "Retry"), None, "only functions");
+
+        let parsed = parse(false);
+        assert_eq!(metrics_of(&parsed, "delay"), None);
+    }
+
+    #[test]
+    fn test_todo_comments_extracted_for_configured_tags() {
+        let content = r#"
+// FIXME(alice): cap the backoff
+fn delay(attempt: u32) -> u32 {
+    // NOTE: not a default tag
+    attempt * 100
+}
+"#;
+        let parse = |tags: &[&str]| {
+            let mut settings = Settings::default();
+            settings.indexing.todo_tags = tags.iter().map(|tag| tag.to_string()).collect();
+            let settings = Arc::new(settings);
+            init_parser_cache(settings.clone());
+            let file = FileContent::new(
+                "delay.rs".into(),
+                content.to_string(),
+                "todo_comments_hash".to_string(),
+            );
+            parse_file(file, &settings).unwrap()
+        };
+
+        let parsed = parse(&["TODO", "FIXME"]);
+        assert_eq!(parsed.todos.len(), 1);
+        assert_eq!(parsed.todos[0].tag, "FIXME");
+        assert_eq!(parsed.todos[0].author.as_deref(), Some("alice"));
+        assert_eq!(parsed.todos[0].line, 1);
+
+        let parsed = parse(&["NOTE"]);
+        assert_eq!(parsed.todos[0].text, "not a default tag");
+        assert!(parse(&[]).todos.is_empty(), "no tags, no extraction");
+    }
+
+    #[test]
+    fn test_framework_kinds_of_language_rules() {
+        let content = r#"
+#[get("/users")]
+async fn list_users() -> impl Responder {
+    HttpResponse::Ok()
+}
+
+fn helper() {}
+"#;
+        let mut settings = Settings::default();
+        if let Some(rust) = settings.languages.get_mut("rust") {
+            rust.kinds.push(crate::config::KindRule {
+                kind: "actix_handler".to_string(),
+                base: vec!["function".to_string()],
+                attribute: Some(r"^#\[(get|post)\(".to_string()),
+                ..Default::default()
+            });
+        }
+        let settings = Arc::new(settings);
+        init_parser_cache(settings.clone());
+        let file = FileContent::new(
+            "routes.rs".into(),
+            content.to_string(),
+            "framework_kinds_hash".to_string(),
+        );
+        let parsed = parse_file(file, &settings).unwrap();
+
+        let kinds: Vec<(&str, Option<&str>)> = parsed
+            .raw_symbols
+            .iter()
+            .map(|sym| (&*sym.name, sym.framework_kind.as_deref()))
+            .collect();
+        assert!(
+            kinds.contains(&("list_users", Some("actix_handler"))),
+            "{kinds:?}"
+        );
+        assert!(kinds.contains(&("helper", None)), "{kinds:?}");
+    }
+
+    #[test]
+    fn test_body_chunks_of_long_functions() {
+        let steps: String = (0..60)
+            .map(|i| format!("    let step_{i} = process(input, {i});\n"))
+            .collect();
+        let content = format!("fn long(input: &str) {{\n{steps}}}\n\nfn short() {{}}\n");
+        let mut settings = Settings::default();
+        settings.semantic_search.code_chunks = true;
+        settings.semantic_search.code_chunk_tokens = 128;
+        let settings = Arc::new(settings);
+        init_parser_cache(settings.clone());
+        let file = FileContent::new("chunks.rs".into(), content, "body_chunks_hash".to_string());
+        let parsed = parse_file(file, &settings).unwrap();
+
+        let chunks = |name: &str| {
+            parsed
+                .raw_symbols
+                .iter()
+                .find(|sym| &*sym.name == name)
+                .map(|sym| sym.body_chunks.clone())
+                .unwrap()
+        };
+        let long = chunks("long");
+        assert!(long.len() > 1, "{long:?}");
+        assert!(long[0].starts_with("fn long(input: &str) {"));
+        assert!(long.iter().all(|chunk| chunk.len() <= 512));
+        assert!(long.iter().any(|chunk| chunk.contains("step_59")));
+        assert_eq!(chunks("short"), vec![Box::<str>::from("fn short() {}")]);
+    }
+
+    #[test]
+    fn test_string_literals_extracted_when_enabled() {
+        use crate::parsing::strings::StringKind;
+
+        let content = r#"
+fn fetch(id: u32) -> Result<String, Error> {
+    let url = format!("https://api.example.com/items/{id}");
+    get(&url).map_err(|_| Error::new("Request failed after retries"))
+}
+
+// codanna:ignore-next-symbol generated
+fn generated() {
+    println!("https://generated.example.com");
+}
+"#;
+        let parse = |enabled: bool| {
+            let mut settings = Settings::default();
+            settings.indexing.string_literals = enabled;
+            let settings = Arc::new(settings);
+            init_parser_cache(settings.clone());
+            let file = FileContent::new(
+                "fetch.rs".into(),
+                content.to_string(),
+                "string_literals_hash".to_string(),
+            );
+            parse_file(file, &settings).unwrap()
+        };
+
+        let parsed = parse(true);
+        let found: Vec<(StringKind, &str)> = parsed
+            .strings
+            .iter()
+            .map(|literal| (literal.kind, literal.text.as_str()))
+            .collect();
+        assert_eq!(
+            found,
+            [
+                (StringKind::Url, "https://api.example.com/items/{id}"),
+                (StringKind::Message, "Request failed after retries"),
+            ]
+        );
+        assert!(parse(false).strings.is_empty(), "off by default");
+    }
+
+    #[test]
+    fn test_proto_rpcs_call_handlers_in_each_language() {
+        let settings = Arc::new(Settings::default());
+        init_parser_cache(settings.clone());
+
+        let content = FileContent::new(
+            "users.proto".into(),
+            r#"syntax = "proto3";
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+}
+"#
+            .to_string(),
+            "proto_handlers_hash".to_string(),
+        );
+
+        let parsed = parse_file(content, &settings).unwrap();
+        let handlers: Vec<(&str, &str)> = parsed
+            .raw_relationships
+            .iter()
+            .filter(|r| r.kind == crate::RelationKind::Calls && r.from_name.as_ref() == "GetUser")
+            .filter_map(|r| Some((r.target_language?.as_str(), r.to_name.as_ref())))
+            .collect();
+        assert_eq!(
+            handlers,
+            vec![
+                ("rust", "get_user"),
+                ("go", "GetUser"),
+                ("typescript", "getUser")
+            ]
+        );
+    }
+
+    #[test]
+    fn test_style_references_from_components() {
+        let settings = Arc::new(Settings::default());
+        init_parser_cache(settings.clone());
+
+        let content = FileContent::new(
+            "Button.tsx".into(),
+            r#"export function Button({ label }: { label: string }) {
+  return (
+    <button className="btn btn--ghost" id="cta" style={{ color: "var(--accent)" }}>
+      <span className="btn">{label}</span>
+    </button>
+  );
+}
+"#
+            .to_string(),
+            "style_references_hash".to_string(),
+        );
+
+        let parsed = parse_file(content, &settings).unwrap();
+        let styles: Vec<(&str, &str)> = parsed
+            .raw_relationships
+            .iter()
+            .filter(|r| r.target_language == Some(crate::parsing::css::CssLanguage::ID))
+            .map(|r| (r.from_name.as_ref(), r.to_name.as_ref()))
+            .collect();
+        // The component names `.btn` once
+        assert_eq!(
+            styles,
+            vec![
+                ("Button", ".btn"),
+                ("Button", ".btn--ghost"),
+                ("Button", "#cta"),
+                ("Button", "--accent"),
+            ]
+        );
+        assert!(
+            parsed
+                .raw_relationships
+                .iter()
+                .filter(|r| r.target_language == Some(crate::parsing::css::CssLanguage::ID))
+                .all(|r| r.kind == crate::RelationKind::References)
+        );
+    }
+
+    #[test]
+    fn test_declaration_links_from_sources_only() {
+        let settings = Arc::new(Settings::default());
+        init_parser_cache(settings.clone());
+
+        let declaration_links = |path: &str, code: &str| -> Vec<String> {
+            let content = FileContent::new(
+                path.into(),
+                code.to_string(),
+                format!("declaration_links_{path}"),
+            );
+            let parsed = parse_file(content, &settings).unwrap();
+            parsed
+                .raw_relationships
+                .iter()
+                .filter(|r| r.metadata.as_ref().is_some_and(|m| m.is_declaration_link()))
+                .inspect(|r| {
+                    assert_eq!(r.kind, crate::RelationKind::Implements);
+                    assert_eq!(r.from_name, r.to_name);
+                })
+                .map(|r| r.to_name.to_string())
+                .collect()
+        };
+
+        let source = r#"#include "widget.hpp"
+
+void tick(int);
+
+void Widget::resize(int w) {
+    tick(w);
+}
+
+void tick(int n) {}
+"#;
+        // The forward declaration of `tick` links nothing
+        assert_eq!(
+            declaration_links("widget.cpp", source),
+            vec!["resize", "tick"]
+        );
+        assert!(declaration_links("widget.hpp", "int area(int w);\n").is_empty());
+    }
+
+    #[test]
+    fn test_directives_tag_and_leave_out_symbols() {
+        let settings = Arc::new(Settings::default());
+        init_parser_cache(settings.clone());
+
+        let code = r#"// codanna:tag=deprecated,legacy
+pub fn start() {
+    helper();
+}
+
+// codanna:ignore-next-symbol generated by build.rs
+pub mod generated {
+    pub fn table() {
+        helper();
+    }
+}
+
+pub fn helper() {}
+"#;
+        let content = FileContent::new("src/lib.rs".into(), code.to_string(), "directives".into());
+        let parsed = parse_file(content, &settings).unwrap();
+
+        let names: Vec<&str> = parsed.raw_symbols.iter().map(|s| &*s.name).collect();
+        assert_eq!(names, vec!["start", "helper"]);
+        assert_eq!(parsed.raw_symbols[0].tags, vec!["deprecated", "legacy"]);
+        assert!(parsed.raw_symbols[1].tags.is_empty());
+        assert!(
+            parsed
+                .raw_relationships
+                .iter()
+                .all(|rel| rel.from_name.as_ref() != "table"),
+            "relationships of left-out symbols go with them"
+        );
+
+        let ignored = FileContent::new(
+            "src/gen.rs".into(),
+            format!("// codanna:ignore-file\n{code}"),
+            "directives_file".into(),
+        );
+        let parsed = parse_file(ignored, &settings).unwrap();
+        assert!(parsed.raw_symbols.is_empty());
+        assert!(parsed.raw_relationships.is_empty());
+    }
 }
@@ -3,11 +3,12 @@
 //! Reads file contents and computes content hashes.
 //! Runs with multiple threads to saturate I/O.
 
+use crate::indexing::diagnostics::{DiagnosticKind, FileDiagnostic};
+use crate::indexing::encoding;
 use crate::indexing::file_info::calculate_hash;
 use crate::indexing::pipeline::types::{FileContent, PipelineError, PipelineResult};
 use crossbeam_channel::{Receiver, Sender};
-use std::fs;
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
 use std::sync::Arc;
 use std::sync::atomic::{AtomicU64, AtomicUsize, Ordering};
 use std::thread;
@@ -43,14 +44,14 @@ impl ReadStage {
 
     /// Run the read stage, reading from path channel and sending to content channel.
     ///
-    /// Returns (files_read, files_failed, input_wait, output_wait, wall_time).
+    /// Returns (files_read, failures, input_wait, output_wait, wall_time).
     pub fn run(
         &self,
         receiver: Receiver<PathBuf>,
         sender: Sender<FileContent>,
     ) -> PipelineResult<(
         usize,
-        usize,
+        Vec<FileDiagnostic>,
         std::time::Duration,
         std::time::Duration,
         std::time::Duration,
@@ -59,7 +60,6 @@ impl ReadStage {
 
         let start = Instant::now();
         let read_count = Arc::new(AtomicUsize::new(0));
-        let error_count = Arc::new(AtomicUsize::new(0));
         let input_wait_ns = Arc::new(AtomicU64::new(0));
         let output_wait_ns = Arc::new(AtomicU64::new(0));
 
@@ -71,12 +71,12 @@ impl ReadStage {
                 let receiver = receiver.clone();
                 let sender = sender.clone();
                 let read_count = read_count.clone();
-                let error_count = error_count.clone();
                 let input_wait_ns = input_wait_ns.clone();
                 let output_wait_ns = output_wait_ns.clone();
                 let workspace_root = workspace_root.clone();
 
                 thread::spawn(move || {
+                    let mut failures = Vec::new();
                     loop {
                         // Track input wait (time blocked on recv)
                         let recv_start = Instant::now();
@@ -108,11 +108,16 @@ impl ReadStage {
                                     Ordering::Relaxed,
                                 );
                             }
-                            Err(_) => {
-                                error_count.fetch_add(1, Ordering::Relaxed);
+                            Err(e) => {
+                                let path = match *workspace_root {
+                                    Some(ref root) => path.strip_prefix(root).unwrap_or(&path),
+                                    None => path.as_path(),
+                                };
+                                failures.push(read_failure(path, &e));
                             }
                         }
                     }
+                    failures
                 })
             })
             .collect();
@@ -121,10 +126,14 @@ impl ReadStage {
         // reached PARSE; returning Ok would report success on a silently
         // incomplete index (same convention as join_read_workers).
         let mut panicked_workers = 0usize;
+        let mut failures = Vec::new();
         for handle in handles {
-            if handle.join().is_err() {
-                tracing::error!(target: "pipeline", "READ worker panicked");
-                panicked_workers += 1;
+            match handle.join() {
+                Ok(worker_failures) => failures.extend(worker_failures),
+                Err(_) => {
+                    tracing::error!(target: "pipeline", "READ worker panicked");
+                    panicked_workers += 1;
+                }
             }
         }
 
@@ -139,7 +148,7 @@ impl ReadStage {
 
         Ok((
             read_count.load(Ordering::Relaxed),
-            error_count.load(Ordering::Relaxed),
+            failures,
             Duration::from_nanos(input_wait_ns.load(Ordering::Relaxed)),
             Duration::from_nanos(output_wait_ns.load(Ordering::Relaxed)),
             start.elapsed(),
@@ -147,22 +156,41 @@ impl ReadStage {
     }
 }
 
-/// Read a single file and compute its SHA256 hash.
+/// Read a single file, transcoded to UTF-8, and compute its SHA256 hash.
 fn read_file(path: &PathBuf) -> PipelineResult<FileContent> {
-    let content = fs::read_to_string(path).map_err(|e| PipelineError::FileRead {
+    let decoded = encoding::read(path).map_err(|e| PipelineError::FileRead {
         path: path.clone(),
         source: e,
     })?;
 
-    let hash = calculate_hash(&content);
+    let hash = calculate_hash(&decoded.text);
+
+    Ok(FileContent::new(path.clone(), decoded.text, hash)
+        .with_encoding(decoded.encoding, decoded.malformed))
+}
 
-    Ok(FileContent::new(path.clone(), content, hash))
+/// The diagnostic of a file that could not be read
+fn read_failure(path: &Path, error: &PipelineError) -> FileDiagnostic {
+    let kind = match error {
+        PipelineError::FileRead { source, .. }
+            if source.kind() == std::io::ErrorKind::InvalidData =>
+        {
+            DiagnosticKind::Encoding
+        }
+        _ => DiagnosticKind::ReadFailed,
+    };
+    let message = match error {
+        PipelineError::FileRead { source, .. } => source.to_string(),
+        other => other.to_string(),
+    };
+    FileDiagnostic::new(path, kind, message)
 }
 
 #[cfg(test)]
 mod tests {
     use super::*;
     use crossbeam_channel::bounded;
+    use std::fs;
     use tempfile::TempDir;
 
     #[test]
@@ -224,7 +252,7 @@ mod tests {
         // Collect results
         let contents: Vec<_> = content_rx.iter().collect();
 
-        println!("Read {read} files, {failed} failed:");
+        println!("Read {read} files, {} failed:", failed.len());
         println!(
             "  Input wait: {input_wait:?}, Output wait: {output_wait:?}, Wall time: {wall_time:?}"
         );
@@ -238,7 +266,7 @@ mod tests {
         }
 
         assert_eq!(read, 5, "Should read all 5 files");
-        assert_eq!(failed, 0, "No files should fail");
+        assert_eq!(failed.len(), 0, "No files should fail");
         assert_eq!(contents.len(), 5, "Should have 5 FileContent items");
     }
 
@@ -264,10 +292,11 @@ mod tests {
 
         let contents: Vec<_> = content_rx.iter().collect();
 
-        println!("Read {read} files, {failed} failed");
+        println!("Read {read} files, {} failed", failed.len());
 
         assert_eq!(read, 0, "No files should be read");
-        assert_eq!(failed, 2, "Both files should fail");
+        assert_eq!(failed.len(), 2, "Both files should fail");
+        assert!(failed.iter().all(|d| d.kind == DiagnosticKind::ReadFailed));
         assert!(contents.is_empty(), "No content should be produced");
     }
 
@@ -16,10 +16,11 @@
 //! - Pass 2: Resolve Calls (can reference Defines from Pass 1)
 
 use crate::indexing::pipeline::types::{
-    CallerContext, ResolutionContext, ResolvedBatch, ResolvedRelationship, SymbolLookupCache,
-    UnresolvedRelationship,
+    CallerContext, ResolutionContext, ResolvedBatch, ResolvedRelationship, Subtypes,
+    SymbolLookupCache, UnresolvedRelationship, defining_module,
 };
 use crate::parsing::resolution::{GenericInheritanceResolver, InheritanceResolver};
+use crate::parsing::rust::attributes::BindingHost;
 use crate::parsing::{Import, LanguageBehavior, LanguageId};
 use crate::types::{FileId, SymbolId};
 use crate::{RelationKind, Symbol};
@@ -37,6 +38,10 @@ pub struct ResolveStage {
     /// `UnresolvedRelationship`s by CONTEXT stage; absent ⇒ fall back to
     /// empty `GenericInheritanceResolver` (`parent_of` yields `None`).
     inheritance_resolvers: HashMap<LanguageId, Arc<dyn InheritanceResolver>>,
+    /// Per-language subtypes populated from `Implements`/`Extends`
+    /// `UnresolvedRelationship`s by CONTEXT stage; absent ⇒ calls through
+    /// generic bounds reach the bound's own methods only.
+    subtypes: Subtypes,
 }
 
 /// Statistics from resolution.
@@ -54,6 +59,9 @@ pub struct ResolveStats {
     pub defines_resolved: usize,
     /// Calls resolved
     pub calls_resolved: usize,
+    /// Edges written for calls through generic bounds, each to one
+    /// possible target (counted once per call in `calls_resolved`)
+    pub possible_targets: usize,
 }
 
 impl ResolveStage {
@@ -68,6 +76,7 @@ impl ResolveStage {
             symbol_cache,
             behaviors,
             inheritance_resolvers: HashMap::new(),
+            subtypes: Subtypes::new(),
         }
     }
 
@@ -82,6 +91,14 @@ impl ResolveStage {
         self
     }
 
+    /// Install the per-language [`Subtypes`] (built by CONTEXT stage from
+    /// `Implements`/`Extends` `UnresolvedRelationship`s). Consumed by
+    /// `resolve_bounded_call`.
+    pub fn with_subtypes(mut self, subtypes: Subtypes) -> Self {
+        self.subtypes = subtypes;
+        self
+    }
+
     /// Get behavior for a language, if available.
     fn get_behavior(&self, language_id: &LanguageId) -> Option<&Arc<dyn LanguageBehavior>> {
         self.behaviors.get(language_id)
@@ -97,7 +114,24 @@ impl ResolveStage {
         for unresolved in &context.unresolved_rels {
             stats.total_processed += 1;
 
-            if let Some(resolved) = self.resolve_one(unresolved, context) {
+            let possible = self.resolve_bounded_call(unresolved, context);
+            if !possible.is_empty() {
+                stats.resolved += 1;
+                stats.calls_resolved += 1;
+                stats.possible_targets += possible.len();
+                for resolved in possible {
+                    batch.push(resolved);
+                }
+                continue;
+            }
+
+            if let Some(mut resolved) = self.resolve_one(unresolved, context) {
+                // Calls of a header declaration reach its definition
+                if resolved.kind == RelationKind::Calls {
+                    if let Some(definition) = self.definition_of(resolved.to_id) {
+                        resolved.to_id = definition;
+                    }
+                }
                 match resolved.kind {
                     RelationKind::Defines => stats.defines_resolved += 1,
                     RelationKind::Calls => stats.calls_resolved += 1,
@@ -111,6 +145,7 @@ impl ResolveStage {
                     stats.unresolved_ambiguous += 1;
                 } else {
                     stats.unresolved_no_candidates += 1;
+                    batch.unmatched.push(unresolved.clone());
                 }
             }
         }
@@ -150,6 +185,39 @@ impl ResolveStage {
         let from_kind = caller_symbol.as_deref().map(|sym| sym.kind);
         drop(caller_symbol);
 
+        // A definition's link to its header declaration names the
+        // definition itself, which every lookup below finds first.
+        if unresolved
+            .metadata
+            .as_ref()
+            .is_some_and(|meta| meta.is_declaration_link())
+        {
+            return self.resolve_declaration(from_id, unresolved, context);
+        }
+
+        // Targeted references (a SQL table named in a query string, the
+        // input variable of a called Terraform module) name a symbol no
+        // scope of the caller's file or imports can hold, and so do the
+        // code references of documentation, in whatever language.
+        let references_other_languages = self
+            .get_behavior(&caller.language_id)
+            .is_some_and(|behavior| behavior.references_other_languages());
+        if unresolved.target_language.is_some() || references_other_languages {
+            return self.resolve_in_language(
+                from_id,
+                from_kind,
+                unresolved,
+                &caller,
+                unresolved.target_language,
+            );
+        }
+
+        // Field and module-level value references name a value, never the
+        // same-name method or function the scope lookup below may hold.
+        if unresolved.kind == RelationKind::References {
+            return self.resolve_value_reference(from_id, from_kind, unresolved, &caller, context);
+        }
+
         // `super()` receivers name a target the index already holds:
         // enclosing class -> Extends -> parent member. Handled before the
         // scope lookup, which would surface the same-name override (the
@@ -190,7 +258,9 @@ impl ResolveStage {
         // before consulting any non-local match. Bypass tier logic and filter
         // candidates by receiver-compat directly.
         if Self::is_qualified_static_call(unresolved) {
-            return self.resolve_static_call(from_id, from_kind, unresolved, &caller, context);
+            return self
+                .resolve_static_call(from_id, from_kind, unresolved, &caller, context)
+                .or_else(|| self.resolve_binding(from_id, from_kind, unresolved, &caller));
         }
 
         let result = self.symbol_cache.resolve(
@@ -232,10 +302,579 @@ impl ResolveStage {
                 let to_id = self.disambiguate(&candidates, unresolved, &caller, context, false)?;
                 self.accept_unwitnessed_pick(from_id, to_id, unresolved)
             }
-            ResolveResult::NotFound => {
-                self.resolve_typed_receiver_global(from_id, from_kind, unresolved, &caller, context)
+            ResolveResult::NotFound => self
+                .resolve_typed_receiver_global(from_id, from_kind, unresolved, &caller, context)
+                .or_else(|| self.resolve_binding(from_id, from_kind, unresolved, &caller)),
+        }
+    }
+
+    /// The header declaration of a C or C++ definition: the same-name
+    /// function, or method of the same class, of a header of the
+    /// definition's module (`widget.h` for `widget.cpp`), else of the one
+    /// header its file includes declaring it. Overloads fail closed.
+    fn resolve_declaration(
+        &self,
+        from_id: SymbolId,
+        unresolved: &UnresolvedRelationship,
+        context: &ResolutionContext,
+    ) -> Option<ResolvedRelationship> {
+        let definition = self.symbol_cache.get(from_id)?;
+        let behavior = self.get_behavior(&definition.language_id?)?;
+        let declarations: Vec<Symbol> = self
+            .symbol_cache
+            .lookup_candidates(&unresolved.to_name)
+            .into_iter()
+            .filter(|&id| id != from_id)
+            .filter_map(|id| self.symbol_cache.get(id))
+            .filter(|sym| self.declares(sym, &definition))
+            .collect();
+
+        let module = defining_module(&definition, behavior.as_ref());
+        let same_module: Vec<&Symbol> = declarations
+            .iter()
+            .filter(|sym| defining_module(sym, behavior.as_ref()) == module)
+            .collect();
+        let to_id = if same_module.is_empty() {
+            let included: Vec<&Symbol> = declarations
+                .iter()
+                .filter(|sym| self.is_imported(sym, &context.imports, context))
+                .collect();
+            match included.as_slice() {
+                [only] => only.id,
+                _ => return None,
+            }
+        } else {
+            match same_module.as_slice() {
+                [only] => only.id,
+                _ => return None,
+            }
+        };
+        Some(ResolvedRelationship {
+            from_id,
+            to_id,
+            kind: unresolved.kind,
+            metadata: unresolved.metadata.clone(),
+        })
+    }
+
+    /// The definition of a header declaration, for calls to reach the body
+    /// rather than the prototype: the one of the declaration's module, else
+    /// the only one indexed. `None` for symbols that are not declarations.
+    fn definition_of(&self, to_id: SymbolId) -> Option<SymbolId> {
+        let declaration = {
+            let symbol = self.symbol_cache.get_ref(to_id)?;
+            if !self.is_header_symbol(&symbol) {
+                return None;
+            }
+            symbol.value().clone()
+        };
+        let behavior = self.get_behavior(&declaration.language_id?)?;
+        let definitions: Vec<Symbol> = self
+            .symbol_cache
+            .lookup_candidates(&declaration.name)
+            .into_iter()
+            .filter(|&id| id != to_id)
+            .filter_map(|id| self.symbol_cache.get(id))
+            .filter(|sym| self.declares(&declaration, sym))
+            .collect();
+
+        let module = defining_module(&declaration, behavior.as_ref());
+        let same_module: Vec<&Symbol> = definitions
+            .iter()
+            .filter(|sym| defining_module(sym, behavior.as_ref()) == module)
+            .collect();
+        match (same_module.as_slice(), definitions.as_slice()) {
+            ([only], _) => Some(only.id),
+            ([], [only]) => Some(only.id),
+            _ => None,
+        }
+    }
+
+    /// Candidates less the header declarations whose definition is among
+    /// them, so a declaration and its definition are one candidate
+    fn without_declarations(&self, candidates: Vec<SymbolId>) -> Vec<SymbolId> {
+        if candidates.len() < 2 {
+            return candidates;
+        }
+        candidates
+            .iter()
+            .copied()
+            .filter(|&id| {
+                !self
+                    .definition_of(id)
+                    .is_some_and(|definition| candidates.contains(&definition))
+            })
+            .collect()
+    }
+
+    /// Whether `declaration` may be the header declaration of `definition`:
+    /// functions, or methods of the same class, one in a header and the
+    /// other in a source file of a language with headers.
+    fn declares(&self, declaration: &Symbol, definition: &Symbol) -> bool {
+        use crate::symbol::ScopeContext;
+
+        let class_of = |sym: &Symbol| match &sym.scope_context {
+            Some(ScopeContext::ClassMember { class_name }) => class_name.clone(),
+            _ => None,
+        };
+        declaration.kind == definition.kind
+            && class_of(declaration) == class_of(definition)
+            && self.is_header_symbol(declaration)
+            && !self.header_extensions(definition).is_empty()
+            && !self.is_header_symbol(definition)
+    }
+
+    /// Whether the symbol is a function or method of a header
+    fn is_header_symbol(&self, symbol: &Symbol) -> bool {
+        matches!(
+            symbol.kind,
+            crate::SymbolKind::Function | crate::SymbolKind::Method
+        ) && crate::parsing::headers::is_header(&symbol.file_path, self.header_extensions(symbol))
+    }
+
+    /// Header extensions of the symbol's language
+    fn header_extensions(&self, symbol: &Symbol) -> &'static [&'static str] {
+        symbol
+            .language_id
+            .as_ref()
+            .and_then(|id| self.get_behavior(id))
+            .map(|behavior| behavior.header_extensions())
+            .unwrap_or_default()
+    }
+
+    /// Calls on a parameter of generic type (`fn draw<T: Shape>(s: &T)`,
+    /// `s: impl Shape`, `<T extends Shape>(s: T)`) dispatch to whatever
+    /// type the caller is instantiated with, so no single target is right.
+    /// Best effort: the same-name method of each bound and of every known
+    /// subtype of it, each edge's context flagged
+    /// [`POSSIBLE_TARGET`](crate::relationship::RelationshipMetadata::POSSIBLE_TARGET).
+    /// Empty when the receiver is not a bounded parameter of the caller or
+    /// a local binding rebinds it before the call.
+    fn resolve_bounded_call(
+        &self,
+        unresolved: &UnresolvedRelationship,
+        context: &ResolutionContext,
+    ) -> Vec<ResolvedRelationship> {
+        use crate::relationship::RelationshipMetadata;
+
+        if unresolved.kind != RelationKind::Calls {
+            return Vec::new();
+        }
+        let Some(metadata) = unresolved.metadata.as_ref() else {
+            return Vec::new();
+        };
+        let (Some(receiver), false) = (metadata.receiver.as_deref(), metadata.static_call) else {
+            return Vec::new();
+        };
+        let Some(from_id) = unresolved.from_id else {
+            return Vec::new();
+        };
+        let Some(caller) = self.symbol_cache.get_ref(from_id) else {
+            return Vec::new();
+        };
+        let language_id = caller.language_id.unwrap_or(context.language_id);
+        let Some(behavior) = self.get_behavior(&language_id) else {
+            return Vec::new();
+        };
+        if behavior.self_receiver_aliases().contains(&receiver) {
+            return Vec::new();
+        }
+        let Some(signature) = caller.signature.as_deref() else {
+            return Vec::new();
+        };
+        let bounds = behavior.parameter_type_bounds(signature, receiver);
+        if bounds.is_empty()
+            || self
+                .binding_type_at_call_site(unresolved, receiver, &caller, context)
+                .is_some()
+        {
+            return Vec::new();
+        }
+
+        // The bounds, then their subtypes breadth-first (capped, so a
+        // subtype cycle or a huge hierarchy cannot run away)
+        let mut owners: Vec<Box<str>> = bounds.into_iter().map(Into::into).collect();
+        if let Some(subtypes) = self.subtypes.get(&language_id) {
+            let mut next = 0;
+            while next < owners.len() && owners.len() < 64 {
+                let owner = owners[next].clone();
+                for child in subtypes.get(&owner).into_iter().flatten() {
+                    if !owners.contains(child) {
+                        owners.push(child.clone());
+                    }
+                }
+                next += 1;
+            }
+        }
+
+        let mut targets: Vec<SymbolId> = Vec::new();
+        for id in self.symbol_cache.lookup_candidates(&unresolved.to_name) {
+            let Some(sym) = self.symbol_cache.get_ref(id) else {
+                continue;
+            };
+            if sym.kind == crate::SymbolKind::Method
+                && sym.language_id.as_ref() == Some(&language_id)
+                && owners
+                    .iter()
+                    .any(|owner| self.is_member_of(&sym, owner, &caller, behavior.as_ref()))
+            {
+                targets.push(id);
+            }
+        }
+        let (from_kind, file_id) = (caller.kind, caller.file_id);
+        drop(caller);
+
+        let metadata = metadata
+            .clone()
+            .with_context(RelationshipMetadata::POSSIBLE_TARGET);
+        targets
+            .into_iter()
+            .filter(|&to_id| {
+                self.is_compatible(
+                    Some(from_kind),
+                    to_id,
+                    RelationKind::Calls,
+                    file_id,
+                    &language_id,
+                )
+            })
+            .map(|to_id| {
+                ResolvedRelationship::new(from_id, to_id, RelationKind::Calls)
+                    .with_metadata(metadata.clone())
+            })
+            .collect()
+    }
+
+    /// Whether `member` belongs to the type named `owner`: by the
+    /// language's receiver match or, for a member whose parser leaves its
+    /// class unnamed (Rust trait methods), by lying inside a same-file type
+    /// of that name.
+    fn is_member_of(
+        &self,
+        member: &Symbol,
+        owner: &str,
+        caller: &Symbol,
+        behavior: &dyn LanguageBehavior,
+    ) -> bool {
+        use crate::symbol::ScopeContext;
+
+        if behavior.is_receiver_compatible(member, owner, Some(caller)) {
+            return true;
+        }
+        if !matches!(
+            member.scope_context,
+            Some(ScopeContext::ClassMember { class_name: None }) | None
+        ) {
+            return false;
+        }
+        self.symbol_cache
+            .lookup_candidates(owner)
+            .into_iter()
+            .any(|id| {
+                self.symbol_cache.get_ref(id).is_some_and(|ty| {
+                    ty.file_id == member.file_id
+                        && matches!(
+                            ty.kind,
+                            crate::SymbolKind::Trait
+                                | crate::SymbolKind::Interface
+                                | crate::SymbolKind::Class
+                                | crate::SymbolKind::Struct
+                        )
+                        && range_contains(&ty.range, &member.range)
+                })
+            })
+    }
+
+    /// Field, constant and global lookup for value references, among the
+    /// caller language's fields, constants and non-local variables. A bare
+    /// name (`MAX_RETRIES`) takes the scope lookup's pick when it is a
+    /// non-member value. A receiver narrows candidates to its type: the
+    /// caller's own for a self alias, the named type or module for a
+    /// qualified one (`Config::DEFAULT`, `Config { timeout }`,
+    /// `config.MAX_RETRIES`), the inferred binding type for a variable;
+    /// a receiver none of them judges (`self.config.timeout`) leaves the
+    /// members of that name. Exactly one survivor resolves, anything else
+    /// fails closed.
+    fn resolve_value_reference(
+        &self,
+        from_id: SymbolId,
+        from_kind: Option<crate::SymbolKind>,
+        unresolved: &UnresolvedRelationship,
+        caller: &CallerContext,
+        context: &ResolutionContext,
+    ) -> Option<ResolvedRelationship> {
+        use crate::parsing::{PipelineSymbolCache, ResolveResult};
+        use crate::symbol::ScopeContext;
+
+        let behavior = self.get_behavior(&caller.language_id)?;
+        let candidates: Vec<SymbolId> = self
+            .symbol_cache
+            .lookup_candidates(&unresolved.to_name)
+            .into_iter()
+            .filter(|&id| {
+                self.symbol_cache.get_ref(id).is_some_and(|sym| {
+                    sym.language_id == Some(caller.language_id) && is_value_target(&sym)
+                }) && self.is_compatible(
+                    from_kind,
+                    id,
+                    unresolved.kind,
+                    caller.file_id,
+                    &caller.language_id,
+                )
+            })
+            .collect();
+        let is_member = |id: SymbolId| {
+            self.symbol_cache.get_ref(id).is_some_and(|sym| {
+                sym.kind == crate::SymbolKind::Field
+                    || matches!(sym.scope_context, Some(ScopeContext::ClassMember { .. }))
+            })
+        };
+        let members_of = |type_name: &str, caller_sym: Option<&Symbol>| -> Vec<SymbolId> {
+            candidates
+                .iter()
+                .copied()
+                .filter(|&id| {
+                    self.symbol_cache.get_ref(id).is_some_and(|sym| {
+                        behavior.is_receiver_compatible(&sym, type_name, caller_sym)
+                    })
+                })
+                .collect()
+        };
+
+        let receiver = unresolved
+            .metadata
+            .as_ref()
+            .and_then(|meta| meta.receiver.as_deref());
+        let survivors: Vec<SymbolId> = match receiver {
+            None => {
+                let values: Vec<SymbolId> = candidates
+                    .iter()
+                    .copied()
+                    .filter(|&id| !is_member(id))
+                    .collect();
+                match self.symbol_cache.resolve(
+                    &unresolved.to_name,
+                    caller,
+                    unresolved.to_range.as_ref(),
+                    &context.imports,
+                ) {
+                    ResolveResult::Found(id) => values.into_iter().filter(|&v| v == id).collect(),
+                    ResolveResult::Ambiguous(picks) => {
+                        values.into_iter().filter(|v| picks.contains(v)).collect()
+                    }
+                    ResolveResult::NotFound => values,
+                }
+            }
+            Some(receiver) if behavior.self_receiver_aliases().contains(&receiver) => {
+                let caller_sym = self.symbol_cache.get_ref(from_id)?;
+                let Some(ScopeContext::ClassMember {
+                    class_name: Some(enclosing),
+                }) = caller_sym.scope_context.clone()
+                else {
+                    return None;
+                };
+                drop(caller_sym);
+                candidates
+                    .iter()
+                    .copied()
+                    .filter(|&id| {
+                        self.symbol_cache.get_ref(id).is_some_and(|sym| {
+                            matches!(
+                                &sym.scope_context,
+                                Some(ScopeContext::ClassMember { class_name: Some(class) })
+                                    if *class == enclosing
+                            )
+                        })
+                    })
+                    .collect()
+            }
+            Some(receiver) => {
+                let separator = behavior.module_separator();
+                let caller_sym = self.symbol_cache.get(from_id);
+                let qualifier = receiver
+                    .rsplit(separator)
+                    .next()
+                    .and_then(|tail| tail.rsplit('.').next())
+                    .unwrap_or(receiver);
+                let self_rooted = behavior
+                    .self_receiver_aliases()
+                    .iter()
+                    .any(|alias| receiver.starts_with(&format!("{alias}.")));
+                let module_suffix = format!("{separator}{receiver}");
+                let mut qualified: Vec<SymbolId> = if self_rooted {
+                    Vec::new()
+                } else {
+                    members_of(qualifier, caller_sym.as_ref())
+                };
+                for id in candidates.iter().copied() {
+                    let in_module = !is_member(id)
+                        && self.symbol_cache.get_ref(id).is_some_and(|sym| {
+                            defining_module(&sym, &**behavior).is_some_and(|module| {
+                                module == receiver || module.ends_with(&module_suffix)
+                            })
+                        });
+                    if in_module && !qualified.contains(&id) {
+                        qualified.push(id);
+                    }
+                }
+                if !qualified.is_empty() {
+                    qualified
+                } else if let Some((type_name, caller_sym)) =
+                    self.infer_receiver_type(unresolved, &caller.language_id, context)
+                {
+                    let type_name = type_name.split('<').next().unwrap_or_default();
+                    let type_name = type_name.rsplit(separator).next().unwrap_or(type_name);
+                    members_of(type_name, Some(&*caller_sym))
+                } else {
+                    candidates
+                        .iter()
+                        .copied()
+                        .filter(|&id| is_member(id))
+                        .collect()
+                }
             }
+        };
+
+        let [to_id] = survivors[..] else {
+            return None;
+        };
+        Some(ResolvedRelationship {
+            from_id,
+            to_id,
+            kind: unresolved.kind,
+            metadata: unresolved.metadata.clone(),
+        })
+    }
+
+    /// FFI binding lookup for calls the caller's language defines nothing
+    /// for: a Python call reaching a PyO3 `#[pyfunction]`, a JavaScript or
+    /// TypeScript call reaching a napi-rs `#[napi]` function, by the name
+    /// the binding exposes (`computeSum` for `fn compute_sum`). A bound
+    /// method needs a receiver; a self-form receiver names the caller's own
+    /// type, never the extension. Exactly one binding resolves, anything
+    /// else fails closed.
+    fn resolve_binding(
+        &self,
+        from_id: SymbolId,
+        from_kind: Option<crate::SymbolKind>,
+        unresolved: &UnresolvedRelationship,
+        caller: &CallerContext,
+    ) -> Option<ResolvedRelationship> {
+        if unresolved.kind != RelationKind::Calls
+            || self.is_self_form_instance_call(unresolved, &caller.language_id)
+        {
+            return None;
         }
+        let host = BindingHost::of_language(caller.language_id.as_str())?;
+        let has_receiver = unresolved
+            .metadata
+            .as_ref()
+            .is_some_and(|meta| meta.receiver.is_some());
+        let mut survivors = self
+            .symbol_cache
+            .lookup_bindings(host, &unresolved.to_name)
+            .into_iter()
+            .filter(|&id| {
+                let is_method = self
+                    .symbol_cache
+                    .get_ref(id)
+                    .is_some_and(|sym| sym.kind == crate::SymbolKind::Method);
+                (has_receiver || !is_method)
+                    && self.is_compatible(
+                        from_kind,
+                        id,
+                        unresolved.kind,
+                        caller.file_id,
+                        &caller.language_id,
+                    )
+            });
+        let to_id = survivors.next()?;
+        if survivors.next().is_some() {
+            return None;
+        }
+        Some(ResolvedRelationship {
+            from_id,
+            to_id,
+            kind: unresolved.kind,
+            metadata: unresolved.metadata.clone(),
+        })
+    }
+
+    /// Name lookup among the symbols of `target_language`, or of every
+    /// language but the caller's when `None`, for references that cross
+    /// languages. A receiver (`AuthService` in `AuthService::login`) must
+    /// name the candidate's container, as judged by the candidate's
+    /// language. The caller's language still judges the edge (a function
+    /// may use a table, not a column) and ranks what survives
+    /// (`LanguageBehavior::cross_language_target_rank`); exactly one
+    /// best-ranked survivor resolves, anything else fails closed.
+    fn resolve_in_language(
+        &self,
+        from_id: SymbolId,
+        from_kind: Option<crate::SymbolKind>,
+        unresolved: &UnresolvedRelationship,
+        caller: &CallerContext,
+        target_language: Option<LanguageId>,
+    ) -> Option<ResolvedRelationship> {
+        let behavior = self.get_behavior(&caller.language_id);
+        let caller_sym = self.symbol_cache.get(from_id);
+        let receiver = unresolved
+            .metadata
+            .as_ref()
+            .and_then(|meta| meta.receiver.as_deref());
+        let mut ranked: Vec<(SymbolId, u8)> = Vec::new();
+        for id in self.symbol_cache.lookup_candidates(&unresolved.to_name) {
+            let in_language = self.symbol_cache.get_ref(id).is_some_and(|sym| {
+                let of_language = match target_language {
+                    Some(target_language) => sym.language_id == Some(target_language),
+                    None => sym
+                        .language_id
+                        .is_some_and(|language_id| language_id != caller.language_id),
+                };
+                of_language
+                    && receiver.is_none_or(|receiver| {
+                        sym.language_id
+                            .and_then(|language_id| self.get_behavior(&language_id))
+                            .is_some_and(|candidate_behavior| {
+                                candidate_behavior.is_receiver_compatible(&sym, receiver, None)
+                            })
+                    })
+            });
+            if !in_language
+                || !self.is_compatible(
+                    from_kind,
+                    id,
+                    unresolved.kind,
+                    caller.file_id,
+                    &caller.language_id,
+                )
+            {
+                continue;
+            }
+            let rank = match (behavior, caller_sym.as_ref()) {
+                (Some(behavior), Some(caller_sym)) => self
+                    .symbol_cache
+                    .get_ref(id)
+                    .and_then(|sym| behavior.cross_language_target_rank(caller_sym, &sym)),
+                _ => Some(0),
+            };
+            if let Some(rank) = rank {
+                ranked.push((id, rank));
+            }
+        }
+
+        let best = ranked.iter().map(|&(_, rank)| rank).min()?;
+        let mut survivors = ranked.into_iter().filter(|&(_, rank)| rank == best);
+        let (to_id, _) = survivors.next()?;
+        if survivors.next().is_some() {
+            return None;
+        }
+        Some(ResolvedRelationship {
+            from_id,
+            to_id,
+            kind: unresolved.kind,
+            metadata: unresolved.metadata.clone(),
+        })
     }
 
     /// Type-directed member lookup for instance calls whose receiver type is
@@ -1224,6 +1863,7 @@ impl ResolveStage {
             .copied()
             .filter(|&id| self.is_compatible(from_kind, id, unresolved.kind, file_id, language_id))
             .collect();
+        let filtered = self.without_declarations(filtered);
         if filtered.is_empty() {
             return None;
         }
@@ -1487,6 +2127,20 @@ impl ResolveStage {
     }
 }
 
+/// Whether a symbol holds a value a field or global reference can name:
+/// a field, a constant or a variable outside any function body
+fn is_value_target(sym: &Symbol) -> bool {
+    use crate::symbol::ScopeContext;
+    match sym.kind {
+        crate::SymbolKind::Field | crate::SymbolKind::Constant => true,
+        crate::SymbolKind::Variable => !matches!(
+            sym.scope_context,
+            Some(ScopeContext::Local { .. } | ScopeContext::Parameter)
+        ),
+        _ => false,
+    }
+}
+
 fn starts_before(a: &crate::Range, b: &crate::Range) -> bool {
     (a.start_line, a.start_column) < (b.start_line, b.start_column)
 }
@@ -1570,6 +2224,7 @@ mod tests {
             kind,
             metadata: None,
             to_range: Some(Range::new(5, 4, 5, 20)),
+            target_language: None,
         }
     }
 
@@ -2491,6 +3146,7 @@ mod tests {
             kind: RelationKind::Extends,
             metadata: None,
             to_range: None,
+            target_language: None,
         }
     }
 
@@ -2813,6 +3469,7 @@ mod tests {
             kind: RelationKind::Calls,
             metadata: None,
             to_range: None,
+            target_language: None,
         };
 
         let context = make_context(1, LanguageId::new("rust"), vec![], vec![unresolved]);
@@ -2876,6 +3533,7 @@ mod tests {
             kind: RelationKind::Calls,
             metadata: None,
             to_range: Some(Range::new(12, 4, 12, 20)), // Call at line 12
+            target_language: None,
         };
 
         // Call at line 25 - should resolve to helper2 (defined at line 15, closer to call)
@@ -2887,6 +3545,7 @@ mod tests {
             kind: RelationKind::Calls,
             metadata: None,
             to_range: Some(Range::new(25, 4, 25, 20)), // Call at line 25
+            target_language: None,
         };
 
         let context = make_context(
@@ -2943,4 +3602,194 @@ mod tests {
         assert_eq!(stats.calls_resolved, 1);
         assert_eq!(batch.len(), 2);
     }
+
+    #[test]
+    fn ffi_bindings_resolve_calls_across_languages() {
+        let rust = LanguageId::new("rust");
+        let cache = Arc::new(SymbolLookupCache::new());
+        cache.insert(make_symbol(1, "from_python", 1, LanguageId::new("python")));
+        cache.insert(make_symbol(2, "fromNode", 2, LanguageId::new("javascript")));
+        cache.insert(
+            make_symbol(3, "compute_sum", 3, rust)
+                .with_signature("#[pyfunction]\n#[napi]\npub fn compute_sum(a: i64) -> i64"),
+        );
+        cache.insert(make_symbol(4, "helper", 3, rust).with_signature("pub fn helper()"));
+        let stage = make_stage(cache);
+
+        let resolved =
+            |file_id: u32, language: &'static str, unresolved: UnresolvedRelationship| {
+                let from_id = unresolved.from_id.unwrap();
+                let context = make_context(
+                    file_id,
+                    LanguageId::new(language),
+                    vec![from_id],
+                    vec![unresolved],
+                );
+                let (batch, _) = stage.resolve(&context);
+                batch.relationships.first().map(|rel| rel.to_id.value())
+            };
+
+        assert_eq!(
+            resolved(
+                1,
+                "python",
+                make_instance_call(1, "compute_sum", 1, "native")
+            ),
+            Some(3)
+        );
+        assert_eq!(
+            resolved(
+                2,
+                "javascript",
+                make_unresolved(2, "computeSum", 2, RelationKind::Calls)
+            ),
+            Some(3),
+            "napi-rs exposes the camelCase name"
+        );
+        assert_eq!(
+            resolved(
+                2,
+                "javascript",
+                make_unresolved(2, "compute_sum", 2, RelationKind::Calls)
+            ),
+            None
+        );
+        assert_eq!(
+            resolved(1, "python", make_instance_call(1, "compute_sum", 1, "self")),
+            None,
+            "self names the caller's own type"
+        );
+        assert_eq!(
+            resolved(
+                1,
+                "python",
+                make_unresolved(1, "helper", 1, RelationKind::Calls)
+            ),
+            None,
+            "only bound functions cross the boundary"
+        );
+    }
+
+    #[test]
+    fn value_references_resolve_fields_and_constants() {
+        use crate::symbol::ScopeContext;
+
+        let rust = LanguageId::new("rust");
+        let cache = Arc::new(SymbolLookupCache::new());
+        let member = |id: u32, name: &str, file_id: u32, kind: SymbolKind, class: &str| {
+            let mut sym = make_symbol(id, name, file_id, rust);
+            sym.kind = kind;
+            sym.scope_context = Some(ScopeContext::ClassMember {
+                class_name: Some(class.into()),
+            });
+            sym
+        };
+        cache.insert(member(1, "configure", 1, SymbolKind::Method, "Server"));
+        cache.insert(member(2, "timeout", 1, SymbolKind::Field, "Server"));
+        cache.insert(member(3, "timeout", 2, SymbolKind::Field, "Client"));
+        cache.insert(member(4, "timeout", 1, SymbolKind::Method, "Server"));
+        let mut constant = make_symbol(5, "MAX_RETRIES", 3, rust);
+        constant.kind = SymbolKind::Constant;
+        constant.module_path = Some("crate::limits::MAX_RETRIES".into());
+        cache.insert(constant);
+
+        let behaviors: HashMap<LanguageId, StdArc<dyn LanguageBehavior>> = HashMap::from([(
+            rust,
+            StdArc::new(crate::parsing::rust::RustBehavior::new()) as StdArc<dyn LanguageBehavior>,
+        )]);
+        let stage = ResolveStage::new(cache, behaviors);
+        let resolved = |to_name: &str, receiver: Option<&str>| {
+            let mut unresolved = make_unresolved(1, to_name, 1, RelationKind::References);
+            let mut meta = crate::relationship::RelationshipMetadata::new().with_context("read");
+            if let Some(receiver) = receiver {
+                meta = meta.with_receiver(receiver);
+            }
+            unresolved.metadata = Some(meta);
+            let context = make_context(1, rust, vec![SymbolId::new(1).unwrap()], vec![unresolved]);
+            let (batch, _) = stage.resolve(&context);
+            batch.relationships.first().map(|rel| rel.to_id.value())
+        };
+
+        assert_eq!(
+            resolved("timeout", Some("self")),
+            Some(2),
+            "self names the caller's own type, and a field is never the method"
+        );
+        assert_eq!(resolved("timeout", Some("Client")), Some(3));
+        assert_eq!(
+            resolved("timeout", Some("cfg")),
+            None,
+            "an unknown receiver with two fields of the name fails closed"
+        );
+        assert_eq!(resolved("MAX_RETRIES", None), Some(5));
+        assert_eq!(resolved("MAX_RETRIES", Some("limits")), Some(5));
+        assert_eq!(resolved("MAX_RETRIES", Some("config")), None);
+        assert_eq!(resolved("timeout", None), None, "fields need a receiver");
+    }
+
+    #[test]
+    fn bounded_calls_link_every_possible_target() {
+        use crate::symbol::ScopeContext;
+
+        let rust = LanguageId::new("rust");
+        let cache = Arc::new(SymbolLookupCache::new());
+        let mut caller = make_symbol(1, "render", 1, rust);
+        caller.signature = Some("fn render<T: Shape>(shape: &T)".into());
+        cache.insert(caller);
+        let mut shape = make_symbol(2, "Shape", 2, rust);
+        shape.kind = SymbolKind::Trait;
+        shape.range = Range::new(0, 0, 5, 1);
+        cache.insert(shape);
+        let method = |id: u32, file_id: u32, class: Option<&str>| {
+            let mut sym = make_member(id, "area", file_id, rust, 1, 1);
+            sym.scope_context = Some(ScopeContext::ClassMember {
+                class_name: class.map(Into::into),
+            });
+            sym
+        };
+        cache.insert(method(3, 2, None));
+        cache.insert(method(4, 3, Some("Circle")));
+        cache.insert(method(5, 4, Some("Square")));
+        cache.insert(method(6, 5, Some("Room")));
+
+        let behaviors: HashMap<LanguageId, StdArc<dyn LanguageBehavior>> = HashMap::from([(
+            rust,
+            StdArc::new(crate::parsing::rust::RustBehavior::new()) as StdArc<dyn LanguageBehavior>,
+        )]);
+        let subtypes = Subtypes::from([(
+            rust,
+            HashMap::from([("Shape".into(), vec!["Circle".into(), "Square".into()])]),
+        )]);
+        let stage = ResolveStage::new(Arc::clone(&cache), behaviors).with_subtypes(subtypes);
+
+        let call = make_instance_call(1, "area", 1, "shape");
+        let context = make_context(1, rust, vec![SymbolId::new(1).unwrap()], vec![call]);
+        let (batch, stats) = stage.resolve(&context);
+
+        let mut targets: Vec<u32> = batch
+            .relationships
+            .iter()
+            .map(|rel| rel.to_id.value())
+            .collect();
+        targets.sort_unstable();
+        assert_eq!(
+            targets,
+            [3, 4, 5],
+            "the trait's own method and both implementations, not Room::area"
+        );
+        assert!(batch.relationships.iter().all(|rel| {
+            rel.metadata
+                .as_ref()
+                .is_some_and(|meta| meta.is_possible_target())
+        }));
+        assert_eq!((stats.calls_resolved, stats.possible_targets), (1, 3));
+
+        // A concrete parameter type is not a bound
+        let mut caller = make_symbol(1, "render", 1, rust);
+        caller.signature = Some("fn render(shape: &Circle)".into());
+        cache.insert(caller);
+        let call = make_instance_call(1, "area", 1, "shape");
+        let context = make_context(1, rust, vec![SymbolId::new(1).unwrap()], vec![]);
+        assert!(stage.resolve_bounded_call(&call, &context).is_empty());
+    }
 }
@@ -5,10 +5,17 @@
 //!
 //! Receives EmbeddingBatch from COLLECT, generates embeddings using EmbeddingBackend,
 //! stores them in SimpleSemanticSearch. Runs in parallel with INDEX stage.
+//! Doc comments and code texts are stored as separate embeddings, and the
+//! further chunks of a long body as more code embeddings of the symbol. A
+//! symbol of a re-indexed file whose text did not change takes the
+//! embedding cleanup retired instead of being embedded again.
 
+use crate::indexing::pipeline::cache::ContentCache;
 use crate::indexing::pipeline::types::{EmbeddingBatch, PipelineError, PipelineResult};
-use crate::semantic::{EmbeddingBackend, SimpleSemanticSearch};
+use crate::semantic::{DocLanguage, EmbeddingBackend, SimpleSemanticSearch, text_hash};
+use crate::types::{CompactString, SymbolId};
 use crossbeam_channel::Receiver;
+use std::collections::HashMap;
 use std::sync::{Arc, Mutex};
 use std::time::{Duration, Instant};
 
@@ -19,8 +26,12 @@ pub struct SemanticEmbedStats {
     pub received: usize,
     /// Successfully embedded
     pub embedded: usize,
+    /// Of those, taken from the retired embeddings of unchanged texts
+    pub reused: usize,
     /// Skipped (empty doc, dimension mismatch)
     pub skipped: usize,
+    /// Files by the language of their doc comments
+    pub doc_languages: HashMap<DocLanguage, usize>,
     /// Time waiting on input channel
     pub input_wait: Duration,
     /// Total processing time
@@ -34,6 +45,34 @@ impl SemanticEmbedStats {
     }
 }
 
+/// An embedding candidate: symbol, text and language
+type Candidate = (SymbolId, CompactString, Box<str>);
+
+/// The retired embeddings `semantic` holds for the texts of `candidates`,
+/// with their candidates, and the candidates left to embed
+fn take_retired<'a>(
+    semantic: &SimpleSemanticSearch,
+    candidates: &'a [Candidate],
+    code: bool,
+) -> (Vec<(&'a Candidate, Vec<f32>)>, Vec<&'a Candidate>) {
+    let mut reused = Vec::new();
+    let mut remaining = Vec::with_capacity(candidates.len());
+    for candidate in candidates {
+        match semantic.take_retired(&candidate.1, code) {
+            Some(embedding) => reused.push((candidate, embedding)),
+            None => remaining.push(candidate),
+        }
+    }
+    (reused, remaining)
+}
+
+/// A retired embedding as the doc and code stores take it
+fn with_language(
+    ((id, _, lang), embedding): (&Candidate, Vec<f32>),
+) -> (SymbolId, Vec<f32>, String) {
+    (*id, embedding, lang.to_string())
+}
+
 /// Progress callback type for EMBED stage.
 pub type EmbedProgressCallback = Arc<dyn Fn(u64) + Send + Sync>;
 
@@ -45,6 +84,8 @@ pub struct SemanticEmbedStage {
     pool: Arc<EmbeddingBackend>,
     semantic: Arc<Mutex<SimpleSemanticSearch>>,
     progress_callback: Option<EmbedProgressCallback>,
+    /// Embeddings shared with other indexes (`indexing.content_cache`)
+    cache: Option<ContentCache>,
 }
 
 impl SemanticEmbedStage {
@@ -54,9 +95,16 @@ impl SemanticEmbedStage {
             pool,
             semantic,
             progress_callback: None,
+            cache: None,
         }
     }
 
+    /// Reuse the embeddings of texts already in `cache`, and add the new ones.
+    pub fn with_cache(mut self, cache: Option<ContentCache>) -> Self {
+        self.cache = cache;
+        self
+    }
+
     /// Add a progress callback that receives the count of embeddings processed per batch.
     pub fn with_progress(mut self, callback: EmbedProgressCallback) -> Self {
         self.progress_callback = Some(callback);
@@ -84,8 +132,11 @@ impl SemanticEmbedStage {
                     batches_received += 1;
                     stats.input_wait += recv_start.elapsed();
 
-                    let candidate_count = batch.candidates.len();
+                    let candidate_count = batch.len();
                     stats.received += candidate_count;
+                    for language in &batch.doc_languages {
+                        *stats.doc_languages.entry(*language).or_default() += 1;
+                    }
 
                     tracing::debug!(
                         target: "semantic",
@@ -94,9 +145,10 @@ impl SemanticEmbedStage {
                         candidate_count
                     );
 
-                    if !batch.candidates.is_empty() {
-                        let count = self.process_batch(&batch)?;
+                    if !batch.is_empty() {
+                        let (count, reused) = self.process_batch(&batch)?;
                         stats.embedded += count;
+                        stats.reused += reused;
                         stats.skipped += candidate_count - count;
 
                         // Report progress
@@ -122,9 +174,10 @@ impl SemanticEmbedStage {
 
         tracing::info!(
             target: "semantic",
-            "EMBED complete: {}/{} embedded in {:?} ({} batches)",
+            "EMBED complete: {}/{} embedded ({} unchanged) in {:?} ({} batches)",
             stats.embedded,
             stats.received,
+            stats.reused,
             stats.elapsed,
             batches_received
         );
@@ -132,37 +185,123 @@ impl SemanticEmbedStage {
         Ok(stats)
     }
 
-    /// Process a batch of embedding candidates.
-    fn process_batch(&self, batch: &EmbeddingBatch) -> PipelineResult<usize> {
-        // Convert to the format expected by embed_parallel
-        let items: Vec<_> = batch
-            .candidates
-            .iter()
-            .map(|(id, doc, lang)| (*id, doc.as_ref(), lang.as_ref()))
-            .collect();
+    /// Process a batch of embedding candidates, returning how many
+    /// embeddings were stored and how many of those were retired ones.
+    pub(crate) fn process_batch(&self, batch: &EmbeddingBatch) -> PipelineResult<(usize, usize)> {
+        let lock_error = || PipelineError::Parse {
+            path: std::path::PathBuf::new(),
+            reason: "Failed to lock semantic search".to_string(),
+        };
 
-        // Generate embeddings in parallel using pool
-        let embeddings = self
-            .pool
-            .embed_parallel(&items)
-            .map_err(|e| PipelineError::Parse {
-                path: std::path::PathBuf::new(),
-                reason: format!("Embedding generation failed: {e}"),
-            })?;
+        let (docs, code, chunks) = {
+            let semantic = self.semantic.lock().map_err(|_| lock_error())?;
+            (
+                take_retired(&semantic, &batch.candidates, false),
+                take_retired(&semantic, &batch.code_candidates, true),
+                take_retired(&semantic, &batch.chunk_candidates, true),
+            )
+        };
+        let reused = docs.0.len() + code.0.len() + chunks.0.len();
+        let mut embeddings: Vec<_> = docs.0.into_iter().map(with_language).collect();
+        embeddings.extend(self.embed(&docs.1)?);
+        let mut code_embeddings: Vec<_> = code.0.into_iter().map(with_language).collect();
+        code_embeddings.extend(self.embed(&code.1)?);
+        let mut chunk_embeddings: Vec<_> = chunks
+            .0
+            .into_iter()
+            .map(|((id, text, _), embedding)| (*id, embedding, text_hash(text)))
+            .collect();
+        chunk_embeddings.extend(self.embed_chunks(&chunks.1)?);
+        if embeddings.is_empty() && code_embeddings.is_empty() && chunk_embeddings.is_empty() {
+            return Ok((0, 0));
+        }
 
         // Store in semantic search; use the returned count which excludes any
         // embeddings dropped due to dimension mismatch (store_embeddings warns).
-        let stored = if !embeddings.is_empty() {
-            let mut semantic = self.semantic.lock().map_err(|_| PipelineError::Parse {
-                path: std::path::PathBuf::new(),
-                reason: "Failed to lock semantic search".to_string(),
-            })?;
-            semantic.store_embeddings(embeddings)
+        let mut semantic = self.semantic.lock().map_err(|_| lock_error())?;
+        let count = semantic.store_embeddings(embeddings)
+            + semantic.store_code_embeddings(code_embeddings)
+            + semantic.store_chunk_embeddings(chunk_embeddings);
+        for (candidates, code) in [(&batch.candidates, false), (&batch.code_candidates, true)] {
+            semantic.record_texts(candidates.iter().map(|(id, text, _)| (*id, &**text)), code);
+        }
+        Ok((count, reused))
+    }
+
+    /// Embed body chunks as (symbol, embedding, text hash). The chunks of
+    /// a symbol share its ID, so they are embedded under their position
+    /// and paired back with their symbol after.
+    fn embed_chunks(
+        &self,
+        candidates: &[&Candidate],
+    ) -> PipelineResult<Vec<(SymbolId, Vec<f32>, u64)>> {
+        let owned: Vec<Candidate> = candidates
+            .iter()
+            .zip(1u32..)
+            .filter_map(|((_, text, lang), n)| {
+                Some((SymbolId::new(n)?, text.clone(), lang.clone()))
+            })
+            .collect();
+        let numbered: Vec<&Candidate> = owned.iter().collect();
+        Ok(self
+            .embed(&numbered)?
+            .into_iter()
+            .filter_map(|(n, embedding, _)| {
+                let (id, text, _) = candidates.get(n.to_u32() as usize - 1)?;
+                Some((*id, embedding, text_hash(text)))
+            })
+            .collect())
+    }
+
+    /// Embed `(id, text, language)` candidates, reading the texts the content
+    /// cache already holds instead of embedding them again
+    fn embed(
+        &self,
+        candidates: &[&Candidate],
+    ) -> PipelineResult<Vec<(SymbolId, Vec<f32>, String)>> {
+        if candidates.is_empty() {
+            return Ok(Vec::new());
+        }
+
+        // Convert to the format expected by embed_parallel, leaving out the
+        // texts the content cache already holds
+        let dimensions = self.pool.dimensions();
+        let model = self.pool.model_id();
+        let mut cached = Vec::new();
+        let mut keys: HashMap<SymbolId, String> = HashMap::new();
+        let mut items = Vec::with_capacity(candidates.len());
+        for (id, text, lang) in candidates {
+            if let Some(cache) = &self.cache {
+                let key = ContentCache::embedding_key(&model, dimensions, text);
+                if let Some(embedding) = cache.get_embedding(&key, dimensions) {
+                    cached.push((*id, embedding, lang.to_string()));
+                    continue;
+                }
+                keys.insert(*id, key);
+            }
+            items.push((*id, text.as_ref(), lang.as_ref()));
+        }
+
+        // Generate embeddings in parallel using pool
+        let mut embeddings = if items.is_empty() {
+            Vec::new()
         } else {
-            0
+            self.pool
+                .embed_parallel(&items)
+                .map_err(|e| PipelineError::Parse {
+                    path: std::path::PathBuf::new(),
+                    reason: format!("Embedding generation failed: {e}"),
+                })?
         };
-
-        Ok(stored)
+        if let Some(cache) = &self.cache {
+            for (id, embedding, _) in &embeddings {
+                if let Some(key) = keys.get(id) {
+                    cache.put_embedding(key, embedding);
+                }
+            }
+        }
+        embeddings.extend(cached);
+        Ok(embeddings)
     }
 }
 
@@ -4,11 +4,18 @@
 //! Key design principle: Parse stage produces "raw" types without IDs,
 //! Collect stage assigns IDs and produces final types.
 
+use crate::indexing::diagnostics::FileDiagnostic;
+use crate::parsing::registry::{deserialize_registered, deserialize_registered_opt};
+use crate::parsing::rust::attributes::{BindingHost, exported_names};
+use crate::parsing::strings::{IndexedString, StringLiteral};
+use crate::parsing::todo::{Todo, TodoComment};
 use crate::parsing::{Import, LanguageId, PipelineSymbolCache, ResolveResult};
 use crate::relationship::RelationshipMetadata;
-use crate::symbol::ScopeContext;
+use crate::semantic::DocLanguage;
+use crate::symbol::{Authorship, ScopeContext, SymbolMetrics};
 use crate::types::{CompactString, FileId, Range, SymbolId};
 use crate::{RelationKind, Symbol, SymbolKind, Visibility};
+use serde::{Deserialize, Serialize};
 use std::collections::HashMap;
 use std::path::PathBuf;
 use std::sync::{Arc, Mutex};
@@ -20,7 +27,7 @@ use std::sync::{Arc, Mutex};
 /// Symbol extracted from parsing, before ID assignment.
 ///
 /// The COLLECT stage converts this to a full `Symbol` with ID.
-#[derive(Debug, Clone)]
+#[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct RawSymbol {
     pub name: CompactString,
     pub kind: SymbolKind,
@@ -29,6 +36,22 @@ pub struct RawSymbol {
     pub doc_comment: Option<CompactString>,
     pub visibility: Visibility,
     pub scope_context: Option<ScopeContext>,
+    pub metrics: Option<SymbolMetrics>,
+    pub authorship: Option<Authorship>,
+    /// Labels of `codanna:tag=` directives
+    #[serde(default)]
+    pub tags: Vec<String>,
+    /// Kind of a `[[languages.<name>.kinds]]` rule matching the symbol
+    #[serde(default)]
+    pub framework_kind: Option<Box<str>>,
+    /// Language of code embedded in the file (a script of an HTML page);
+    /// `None` for the file's own
+    #[serde(default, deserialize_with = "deserialize_registered_opt")]
+    pub language_id: Option<LanguageId>,
+    /// The body of a function or method in the chunks its code is embedded
+    /// in, with `semantic_search.code_chunks`
+    #[serde(default)]
+    pub body_chunks: Vec<Box<str>>,
 }
 
 impl RawSymbol {
@@ -41,6 +64,12 @@ impl RawSymbol {
             doc_comment: None,
             visibility: Visibility::Public,
             scope_context: None,
+            metrics: None,
+            authorship: None,
+            tags: Vec::new(),
+            framework_kind: None,
+            language_id: None,
+            body_chunks: Vec::new(),
         }
     }
 
@@ -63,12 +92,17 @@ impl RawSymbol {
         self.scope_context = Some(ctx);
         self
     }
+
+    pub fn in_language(mut self, language_id: LanguageId) -> Self {
+        self.language_id = Some(language_id);
+        self
+    }
 }
 
 /// Import extracted from parsing, before FileId assignment.
 ///
 /// The COLLECT stage converts this to a full `Import` with FileId.
-#[derive(Debug, Clone)]
+#[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct RawImport {
     pub path: String,
     pub alias: Option<String>,
@@ -118,9 +152,9 @@ impl RawImport {
 ///
 /// In-memory only: rides `ParsedFile` -> `IndexBatch` -> `ResolutionContext`
 /// so receiver-type inference can consult constructor assignments and
-/// annotated locals. Never persisted; the stored edge metadata keeps the
-/// syntactic receiver.
-#[derive(Debug, Clone)]
+/// annotated locals. Never written to the index, only to the checkpoint of
+/// a run; the stored edge metadata keeps the syntactic receiver.
+#[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct VariableBinding {
     pub name: String,
     pub type_name: String,
@@ -132,12 +166,18 @@ pub struct VariableBinding {
 /// Per-file typed-local bindings: the in-memory lane from Phase 1 to Phase 2.
 pub type FileBindings = HashMap<FileId, Vec<VariableBinding>>;
 
+/// Direct subtypes of each type name, per language: the types implementing
+/// a trait or interface and the classes extending a class, from the
+/// `Implements` and `Extends` relationships of the files being resolved.
+/// Names are bare (`Display` for `fmt::Display`).
+pub type Subtypes = HashMap<LanguageId, HashMap<Box<str>, Vec<Box<str>>>>;
+
 /// Relationship extracted from parsing, before resolution.
 ///
 /// Contains ranges for disambiguation when multiple symbols share the same name:
 /// - `from_range`: Position of the calling symbol (maps to from_id in COLLECT)
 /// - `to_range`: Position of the reference/call site (helps Phase 2 resolution)
-#[derive(Debug, Clone)]
+#[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct RawRelationship {
     pub from_name: Arc<str>,
     pub from_range: Range,
@@ -145,6 +185,11 @@ pub struct RawRelationship {
     pub to_range: Range,
     pub kind: RelationKind,
     pub metadata: Option<RelationshipMetadata>,
+    /// Language of the target when it differs from the caller's (a SQL
+    /// table named in a Python query string); `None` resolves within the
+    /// caller's language
+    #[serde(deserialize_with = "deserialize_registered_opt")]
+    pub target_language: Option<LanguageId>,
 }
 
 impl RawRelationship {
@@ -162,6 +207,7 @@ impl RawRelationship {
             to_range,
             kind,
             metadata: None,
+            target_language: None,
         }
     }
 
@@ -169,23 +215,41 @@ impl RawRelationship {
         self.metadata = Some(metadata);
         self
     }
+
+    pub fn in_language(mut self, language_id: LanguageId) -> Self {
+        self.target_language = Some(language_id);
+        self
+    }
 }
 
 /// Complete output from parsing a single file.
 ///
 /// Contains all extracted data without any IDs assigned.
 /// The COLLECT stage processes this to assign FileId and SymbolIds.
-#[derive(Debug)]
+/// Serializable, as distributed workers send it to the coordinator.
+#[derive(Debug, Serialize, Deserialize)]
 pub struct ParsedFile {
     pub path: PathBuf,
     /// SHA256 hash of file content for change detection (compatible with Tantivy)
     pub content_hash: String,
+    #[serde(deserialize_with = "deserialize_registered")]
     pub language_id: LanguageId,
     pub module_path: Option<String>,
     pub raw_symbols: Vec<RawSymbol>,
     pub raw_imports: Vec<RawImport>,
     pub raw_relationships: Vec<RawRelationship>,
     pub variable_bindings: Vec<VariableBinding>,
+    /// Tagged comments, owners resolved in COLLECT
+    pub todos: Vec<TodoComment>,
+    /// Notable string literals, owners resolved in COLLECT
+    #[serde(default)]
+    pub strings: Vec<StringLiteral>,
+    /// The encoding the file was transcoded from; none for UTF-8
+    #[serde(default)]
+    pub encoding: Option<String>,
+    /// Problems met reading and parsing it
+    #[serde(default)]
+    pub diagnostics: Vec<FileDiagnostic>,
 }
 
 impl ParsedFile {
@@ -199,6 +263,10 @@ impl ParsedFile {
             raw_imports: Vec::new(),
             raw_relationships: Vec::new(),
             variable_bindings: Vec::new(),
+            todos: Vec::new(),
+            strings: Vec::new(),
+            encoding: None,
+            diagnostics: Vec::new(),
         }
     }
 
@@ -231,6 +299,7 @@ impl ParsedFile {
 /// - Change detection: content_hash
 /// - Parser selection: language_id
 /// - Incremental indexing: timestamp
+/// - Source encoding: encoding
 #[derive(Debug, Clone)]
 pub struct FileRegistration {
     pub path: PathBuf,
@@ -242,6 +311,8 @@ pub struct FileRegistration {
     pub timestamp: u64,
     /// File modification time (seconds since UNIX_EPOCH)
     pub mtime: u64,
+    /// The encoding the file was transcoded from; none for UTF-8
+    pub encoding: Option<String>,
 }
 
 /// Unresolved relationship with from_id populated.
@@ -257,6 +328,8 @@ pub struct UnresolvedRelationship {
     pub kind: RelationKind,
     pub metadata: Option<RelationshipMetadata>,
     pub to_range: Option<Range>,
+    /// Carried over from `RawRelationship::target_language`
+    pub target_language: Option<LanguageId>,
 }
 
 /// A batch of data ready to be written to Tantivy.
@@ -275,6 +348,10 @@ pub struct IndexBatch {
     /// Per-file typed local bindings for receiver-type inference (in-memory
     /// lane to Phase 2; never written to the index)
     pub variable_bindings: FileBindings,
+    /// Tagged comments ready to store
+    pub todos: Vec<Todo>,
+    /// Notable string literals ready to store
+    pub strings: Vec<IndexedString>,
 }
 
 impl IndexBatch {
@@ -285,6 +362,8 @@ impl IndexBatch {
             unresolved_relationships: Vec::new(),
             file_registrations: Vec::new(),
             variable_bindings: HashMap::new(),
+            todos: Vec::new(),
+            strings: Vec::new(),
         }
     }
 
@@ -295,6 +374,8 @@ impl IndexBatch {
             unresolved_relationships: Vec::with_capacity(rels),
             file_registrations: Vec::new(),
             variable_bindings: HashMap::new(),
+            todos: Vec::new(),
+            strings: Vec::new(),
         }
     }
 
@@ -313,6 +394,8 @@ impl IndexBatch {
         self.unresolved_relationships
             .extend(other.unresolved_relationships);
         self.file_registrations.extend(other.file_registrations);
+        self.todos.extend(other.todos);
+        self.strings.extend(other.strings);
     }
 }
 
@@ -325,32 +408,50 @@ impl Default for IndexBatch {
 /// A batch of embedding candidates for the EMBED stage.
 ///
 /// Sent from COLLECT to EMBED in parallel with IndexBatch to INDEX.
-/// Contains symbols that have doc_comments suitable for embedding.
+/// Contains symbols that have doc_comments suitable for embedding, and the
+/// code text of symbols with a signature when `semantic_search.code_embeddings`
+/// is on, with the further chunks of long bodies when
+/// `semantic_search.code_chunks` is too.
 #[derive(Debug)]
 pub struct EmbeddingBatch {
     /// Embedding candidates: (symbol_id, doc_comment, language)
     pub candidates: Vec<(SymbolId, CompactString, Box<str>)>,
+    /// Code embedding candidates: (symbol_id, code text, language)
+    pub code_candidates: Vec<(SymbolId, CompactString, Box<str>)>,
+    /// Body chunks after the first, which the code text holds:
+    /// (symbol_id, chunk text, language), several per symbol
+    pub chunk_candidates: Vec<(SymbolId, CompactString, Box<str>)>,
+    /// Language of the doc comments of each file with any, where told
+    pub doc_languages: Vec<DocLanguage>,
 }
 
 impl EmbeddingBatch {
     pub fn new() -> Self {
         Self {
             candidates: Vec::new(),
+            code_candidates: Vec::new(),
+            chunk_candidates: Vec::new(),
+            doc_languages: Vec::new(),
         }
     }
 
     pub fn with_capacity(size: usize) -> Self {
         Self {
             candidates: Vec::with_capacity(size),
+            code_candidates: Vec::new(),
+            chunk_candidates: Vec::new(),
+            doc_languages: Vec::new(),
         }
     }
 
     pub fn is_empty(&self) -> bool {
         self.candidates.is_empty()
+            && self.code_candidates.is_empty()
+            && self.chunk_candidates.is_empty()
     }
 
     pub fn len(&self) -> usize {
-        self.candidates.len()
+        self.candidates.len() + self.code_candidates.len() + self.chunk_candidates.len()
     }
 }
 
@@ -371,6 +472,10 @@ pub struct FileContent {
     pub content: String,
     /// SHA256 hash of file content for change detection (compatible with Tantivy)
     pub hash: String,
+    /// The encoding the content was transcoded from; none for UTF-8
+    pub encoding: Option<&'static str>,
+    /// Whether bytes invalid in its encoding were replaced
+    pub malformed: bool,
 }
 
 impl FileContent {
@@ -379,8 +484,16 @@ impl FileContent {
             path,
             content,
             hash,
+            encoding: None,
+            malformed: false,
         }
     }
+
+    pub fn with_encoding(mut self, encoding: Option<&'static str>, malformed: bool) -> Self {
+        self.encoding = encoding;
+        self.malformed = malformed;
+        self
+    }
 }
 
 // ═══════════════════════════════════════════════════════════════════════════
@@ -404,7 +517,7 @@ pub use crate::parsing::CallerContext;
 /// `Ord` compares file_path, then start_line, then id — the id arm only
 /// breaks ties within one run; the identity prefix is what holds across
 /// runs (ids are session-scoped).
-#[derive(Debug, PartialEq, Eq)]
+#[derive(Debug, Clone, PartialEq, Eq)]
 struct NameCandidate {
     file_path: Box<str>,
     start_line: u32,
@@ -443,6 +556,9 @@ pub struct SymbolLookupCache {
     /// Re-exported paths: "pkg.helper" -> the symbol defined at "pkg.a.helper"
     /// when pkg's namespace imports it. Populated by the Phase 2 pre-pass.
     module_aliases: dashmap::DashMap<Box<str>, crate::types::SymbolId>,
+    /// Rust functions by the name an FFI binding exposes them under:
+    /// (Node, "computeSum") -> `#[napi] fn compute_sum`, identity-sorted
+    bindings: dashmap::DashMap<(BindingHost, Box<str>), Vec<NameCandidate>>,
 }
 
 impl Default for SymbolLookupCache {
@@ -459,6 +575,7 @@ impl SymbolLookupCache {
             by_name: dashmap::DashMap::new(),
             by_file_id: dashmap::DashMap::new(),
             module_aliases: dashmap::DashMap::new(),
+            bindings: dashmap::DashMap::new(),
         }
     }
 
@@ -469,6 +586,7 @@ impl SymbolLookupCache {
             by_name: dashmap::DashMap::with_capacity(symbols / 10), // Fewer unique names
             by_file_id: dashmap::DashMap::with_capacity(symbols / 50), // ~50 symbols/file avg
             module_aliases: dashmap::DashMap::new(),
+            bindings: dashmap::DashMap::new(),
         }
     }
 
@@ -484,6 +602,22 @@ impl SymbolLookupCache {
             id,
         };
 
+        // Insert into bindings under each exposed name
+        if symbol
+            .language_id
+            .is_some_and(|language| language.as_str() == "rust")
+        {
+            if let Some(signature) = symbol.signature.as_deref() {
+                for (host, exported) in exported_names(&symbol.name, signature) {
+                    let mut entry = self.bindings.entry((host, exported.into())).or_default();
+                    let pos = entry
+                        .binary_search(&candidate)
+                        .unwrap_or_else(|insert_at| insert_at);
+                    entry.insert(pos, candidate.clone());
+                }
+            }
+        }
+
         // Insert into by_id
         self.by_id.insert(id, symbol);
 
@@ -528,6 +662,15 @@ impl SymbolLookupCache {
             .unwrap_or_default()
     }
 
+    /// Rust symbols an FFI binding exposes to `host` as `name`, in identity
+    /// order.
+    pub fn lookup_bindings(&self, host: BindingHost, name: &str) -> Vec<crate::types::SymbolId> {
+        self.bindings
+            .get(&(host, name.into()))
+            .map(|r| r.value().iter().map(|c| c.id).collect())
+            .unwrap_or_default()
+    }
+
     /// Whether any candidate exists for `name` (O(1), no clone).
     pub fn has_candidates(&self, name: &str) -> bool {
         self.by_name.contains_key(name)
@@ -585,17 +728,23 @@ impl SymbolLookupCache {
     /// `pkg.helper` (module_path stripping already maps `__init__` to the
     /// package itself; plain modules re-export the same way). Glob imports
     /// (`from pkg.a import *`) expose the source module's public
-    /// module-level names. Entries are (module_path, imports) pairs with
-    /// paths already normalized to absolute form at parse time. Iterates so
-    /// re-export chains converge.
+    /// module-level names. TypeScript barrels (`export { a as b } from
+    /// './a'`) and Rust `pub use` re-export the same way, in their own
+    /// module separator. Entries are (module_path, imports) pairs with
+    /// paths already normalized to absolute form
+    /// (`LanguageBehavior::reexport_import_path`). Iterates so re-export
+    /// chains converge.
     pub fn populate_module_aliases(
         &self,
         entries: &[(String, Vec<Import>)],
-        language: &LanguageId,
+        behavior: &dyn crate::parsing::LanguageBehavior,
     ) {
         // Chains are shallow in practice; the cap only guards degenerate cycles.
         const MAX_ROUNDS: usize = 8;
 
+        let language = behavior.language_id();
+        let separator = behavior.module_separator();
+
         for _ in 0..MAX_ROUNDS {
             let mut progressed = false;
 
@@ -604,12 +753,11 @@ impl SymbolLookupCache {
                     if import.is_glob || import.path.starts_with('.') {
                         continue;
                     }
-                    let (module_part, name) = match import.path.rfind('.') {
-                        Some(pos) => (&import.path[..pos], &import.path[pos + 1..]),
-                        None => continue,
+                    let Some((module_part, name)) = import.path.rsplit_once(separator) else {
+                        continue;
                     };
                     let local = import.alias.as_deref().unwrap_or(name);
-                    let alias_key = format!("{module}.{local}");
+                    let alias_key = format!("{module}{separator}{local}");
                     if alias_key == import.path {
                         continue;
                     }
@@ -618,16 +766,21 @@ impl SymbolLookupCache {
                     }
 
                     // The imported path may itself be a re-export (chain hop).
-                    let target = self.resolve_module_alias(&import.path).or_else(|| {
-                        self.lookup_candidates(name).into_iter().find(|&id| {
-                            self.by_id.get(&id).is_some_and(|sym| {
-                                sym.language_id.as_ref() == Some(language)
-                                    && (sym.module_path.as_deref() == Some(module_part)
-                                        || (sym.kind == crate::types::SymbolKind::Module
-                                            && sym.module_path.as_deref() == Some(&import.path)))
+                    let defined_at = behavior.format_module_path(module_part, name);
+                    let target = self
+                        .resolve_module_alias(&import.path)
+                        .filter(|&id| self.is_of_language(id, language))
+                        .or_else(|| {
+                            self.lookup_candidates(name).into_iter().find(|&id| {
+                                self.by_id.get(&id).is_some_and(|sym| {
+                                    sym.language_id == Some(language)
+                                        && (sym.module_path.as_deref() == Some(&defined_at)
+                                            || (sym.kind == crate::types::SymbolKind::Module
+                                                && sym.module_path.as_deref()
+                                                    == Some(&import.path)))
+                                })
                             })
-                        })
-                    });
+                        });
 
                     if let Some(id) = target {
                         self.module_aliases.insert(alias_key.into(), id);
@@ -636,7 +789,7 @@ impl SymbolLookupCache {
                 }
             }
 
-            progressed |= self.expand_glob_reexports(entries, language);
+            progressed |= self.expand_glob_reexports(entries, behavior);
 
             if !progressed {
                 break;
@@ -644,6 +797,13 @@ impl SymbolLookupCache {
         }
     }
 
+    /// Whether a cached symbol is of `language`
+    fn is_of_language(&self, id: crate::types::SymbolId, language: LanguageId) -> bool {
+        self.by_id
+            .get(&id)
+            .is_some_and(|sym| sym.language_id == Some(language))
+    }
+
     /// Expand `from module import *` re-exports.
     ///
     /// Exposes each public module-level name of the glob source under the
@@ -653,10 +813,13 @@ impl SymbolLookupCache {
     fn expand_glob_reexports(
         &self,
         entries: &[(String, Vec<Import>)],
-        language: &LanguageId,
+        behavior: &dyn crate::parsing::LanguageBehavior,
     ) -> bool {
         use std::collections::HashMap;
 
+        let language = behavior.language_id();
+        let separator = behavior.module_separator();
+
         // Glob source module -> importing modules
         let mut globs: HashMap<&str, Vec<&str>> = HashMap::new();
         for (module, imports) in entries {
@@ -681,7 +844,7 @@ impl SymbolLookupCache {
         // actually imports).
         for entry in self.by_id.iter() {
             let sym = entry.value();
-            if sym.language_id.as_ref() != Some(language)
+            if sym.language_id != Some(language)
                 || sym.kind == crate::types::SymbolKind::Module
                 || sym.name.starts_with('_')
                 || !matches!(
@@ -692,11 +855,11 @@ impl SymbolLookupCache {
             {
                 continue;
             }
-            let Some(targets) = sym.module_path.as_deref().and_then(|mp| globs.get(mp)) else {
+            let Some(targets) = defining_module(sym, behavior).and_then(|mp| globs.get(mp)) else {
                 continue;
             };
             for module in targets {
-                let key = format!("{module}.{}", sym.name);
+                let key = format!("{module}{separator}{}", sym.name);
                 if !self.module_aliases.contains_key(key.as_str()) {
                     self.module_aliases.insert(key.into(), sym.id);
                     progressed = true;
@@ -711,16 +874,17 @@ impl SymbolLookupCache {
             .map(|e| (e.key().clone(), *e.value()))
             .collect();
         for (key, id) in existing {
-            let Some(pos) = key.rfind('.') else { continue };
-            let (src, name) = (&key[..pos], &key[pos + 1..]);
-            if name.starts_with('_') {
+            let Some((src, name)) = key.rsplit_once(separator) else {
+                continue;
+            };
+            if name.starts_with('_') || !self.is_of_language(id, language) {
                 continue;
             }
             let Some(targets) = globs.get(src) else {
                 continue;
             };
             for module in targets {
-                let new_key = format!("{module}.{name}");
+                let new_key = format!("{module}{separator}{name}");
                 if !self.module_aliases.contains_key(new_key.as_str()) {
                     self.module_aliases.insert(new_key.into(), id);
                     progressed = true;
@@ -732,6 +896,17 @@ impl SymbolLookupCache {
     }
 }
 
+/// The module a symbol is defined in: its module path, less the symbol's
+/// own name in languages whose module paths name symbols
+/// (`crate::util::parse` for Rust's `parse`)
+pub(crate) fn defining_module<'s>(
+    symbol: &'s Symbol,
+    behavior: &dyn crate::parsing::LanguageBehavior,
+) -> Option<&'s str> {
+    let module_path = symbol.module_path.as_deref()?;
+    module_path.strip_suffix(behavior.format_module_path("", &symbol.name).as_str())
+}
+
 impl PipelineSymbolCache for SymbolLookupCache {
     fn resolve(
         &self,
@@ -740,10 +915,14 @@ impl PipelineSymbolCache for SymbolLookupCache {
         to_range: Option<&Range>,
         imports: &[Import],
     ) -> ResolveResult {
-        // Get all candidates by name first
+        // Get all candidates by name first. With none, only an import of a
+        // re-export alias can bind the name (`pub use a::parse as p`).
         let candidates = self.lookup_candidates(name);
         if candidates.is_empty() {
-            return ResolveResult::NotFound;
+            return match self.resolve_import_tier(name, caller, imports) {
+                Some(id) => ResolveResult::Found(id),
+                None => ResolveResult::NotFound,
+            };
         }
 
         // Tier 1: Local - Same file + defined before to_range
@@ -776,26 +955,8 @@ impl PipelineSymbolCache for SymbolLookupCache {
         }
 
         // Tier 2: Import - Name matches import alias or last segment
-        for import in imports {
-            // Check if name matches import alias
-            if import.alias.as_deref() == Some(name) {
-                // Look for symbol matching import path
-                if let Some(id) = self.find_by_import_path(&import.path, caller.language_id) {
-                    return ResolveResult::Found(id);
-                }
-            }
-            // Check if name matches last segment of import path
-            let last_segment = import
-                .path
-                .rsplit("::")
-                .next()
-                .or_else(|| import.path.rsplit('.').next())
-                .or_else(|| import.path.rsplit('/').next());
-            if last_segment == Some(name) {
-                if let Some(id) = self.find_by_import_path(&import.path, caller.language_id) {
-                    return ResolveResult::Found(id);
-                }
-            }
+        if let Some(id) = self.resolve_import_tier(name, caller, imports) {
+            return ResolveResult::Found(id);
         }
 
         // Tier 3: Same language with three-level visibility check
@@ -884,11 +1045,36 @@ impl SymbolLookupCache {
         Ok(cache)
     }
 
+    /// The symbol an import binding `name` names: `name` is the import's
+    /// alias or the last segment of its path.
+    fn resolve_import_tier(
+        &self,
+        name: &str,
+        caller: &CallerContext,
+        imports: &[Import],
+    ) -> Option<SymbolId> {
+        imports.iter().find_map(|import| {
+            // Check if name matches last segment of import path
+            let last_segment = import
+                .path
+                .rsplit("::")
+                .next()
+                .or_else(|| import.path.rsplit('.').next())
+                .or_else(|| import.path.rsplit('/').next());
+            (import.alias.as_deref() == Some(name) || last_segment == Some(name))
+                .then(|| self.find_by_import_path(&import.path, caller.language_id))
+                .flatten()
+        })
+    }
+
     /// Find symbol by import path and language.
     fn find_by_import_path(&self, path: &str, language_id: LanguageId) -> Option<SymbolId> {
         // Re-exported path registered by the Phase 2 pre-pass - exact match
         // ahead of the approximate module comparison below.
-        if let Some(id) = self.resolve_module_alias(path) {
+        if let Some(id) = self
+            .resolve_module_alias(path)
+            .filter(|&id| self.is_of_language(id, language_id))
+        {
             return Some(id);
         }
 
@@ -1021,18 +1207,19 @@ impl ResolvedRelationship {
 #[derive(Debug, Default)]
 pub struct ResolvedBatch {
     pub relationships: Vec<ResolvedRelationship>,
+    /// Relationships to names no symbol of the index has
+    pub unmatched: Vec<UnresolvedRelationship>,
 }
 
 impl ResolvedBatch {
     pub fn new() -> Self {
-        Self {
-            relationships: Vec::new(),
-        }
+        Self::default()
     }
 
     pub fn with_capacity(cap: usize) -> Self {
         Self {
             relationships: Vec::with_capacity(cap),
+            unmatched: Vec::new(),
         }
     }
 
@@ -1050,6 +1237,7 @@ impl ResolvedBatch {
 
     pub fn merge(&mut self, other: ResolvedBatch) {
         self.relationships.extend(other.relationships);
+        self.unmatched.extend(other.unmatched);
     }
 }
 
@@ -1144,6 +1332,10 @@ pub enum PipelineError {
     #[error("Unsupported file type: {path}")]
     UnsupportedFileType { path: PathBuf },
 
+    /// Over its language's size limits, with the `skip` policy
+    #[error("Skipped large file {path}: {reason}")]
+    LargeFileSkipped { path: PathBuf, reason: String },
+
     #[error("Channel send error: {0}")]
     ChannelSend(String),
 
@@ -1272,8 +1464,8 @@ mod tests {
         }
     }
 
-    fn python_id() -> LanguageId {
-        LanguageId::new("python")
+    fn python() -> crate::parsing::python::PythonBehavior {
+        crate::parsing::python::PythonBehavior::new()
     }
 
     #[test]
@@ -1283,7 +1475,7 @@ mod tests {
 
         // pkg/__init__.py: from pkg.a import helper  => exposes pkg.helper
         let entries = vec![("pkg".to_string(), vec![import("pkg.a.helper", None)])];
-        cache.populate_module_aliases(&entries, &python_id());
+        cache.populate_module_aliases(&entries, &python());
 
         assert_eq!(
             cache.resolve_module_alias("pkg.helper"),
@@ -1309,7 +1501,7 @@ mod tests {
                 vec![import("pkg.inner.a.helper", None)],
             ),
         ];
-        cache.populate_module_aliases(&entries, &python_id());
+        cache.populate_module_aliases(&entries, &python());
 
         let id = SymbolId::new(1).unwrap();
         assert_eq!(cache.resolve_module_alias("pkg.inner.helper"), Some(id));
@@ -1331,7 +1523,7 @@ mod tests {
         let mut glob = import("pkg.main", None);
         glob.is_glob = true;
         let entries = vec![("pkg".to_string(), vec![glob])];
-        cache.populate_module_aliases(&entries, &python_id());
+        cache.populate_module_aliases(&entries, &python());
 
         assert_eq!(
             cache.resolve_module_alias("pkg.BaseModel"),
@@ -1358,7 +1550,7 @@ mod tests {
             ("pkg".to_string(), vec![outer_glob]),
             ("pkg.sub".to_string(), vec![inner_glob]),
         ];
-        cache.populate_module_aliases(&entries, &python_id());
+        cache.populate_module_aliases(&entries, &python());
 
         let id = SymbolId::new(1).unwrap();
         assert_eq!(cache.resolve_module_alias("pkg.sub.thing"), Some(id));
@@ -1374,7 +1566,7 @@ mod tests {
             "pkg".to_string(),
             vec![import("os.path", None), import("pkg.b.missing", None)],
         )];
-        cache.populate_module_aliases(&entries, &python_id());
+        cache.populate_module_aliases(&entries, &python());
 
         assert_eq!(cache.resolve_module_alias("pkg.path"), None);
         assert_eq!(cache.resolve_module_alias("pkg.missing"), None);
@@ -1393,6 +1585,40 @@ mod tests {
         sym
     }
 
+    #[test]
+    fn test_module_alias_rust_pub_use() {
+        let cache = SymbolLookupCache::new();
+        cache.insert(rust_symbol(1, "parse", "crate::inner::parse"));
+        cache.insert(rust_symbol(2, "render", "crate::inner::render"));
+
+        // src/api.rs: pub use crate::inner::parse as p;
+        //             pub use crate::inner::*;
+        let mut glob = import("crate::inner", None);
+        glob.is_glob = true;
+        let entries = vec![(
+            "crate::api".to_string(),
+            vec![import("crate::inner::parse", Some("p")), glob],
+        )];
+        cache.populate_module_aliases(&entries, &crate::parsing::rust::RustBehavior::new());
+
+        assert_eq!(
+            cache.resolve_module_alias("crate::api::p"),
+            Some(SymbolId::new(1).unwrap())
+        );
+        assert_eq!(
+            cache.resolve_module_alias("crate::api::render"),
+            Some(SymbolId::new(2).unwrap())
+        );
+
+        // `use crate::api::p;` binds a name nothing is declared under
+        let caller = CallerContext::from_file(FileId::new(2).unwrap(), LanguageId::new("rust"));
+        let imports = [import("crate::api::p", None)];
+        assert!(matches!(
+            cache.resolve("p", &caller, None, &imports),
+            ResolveResult::Found(id) if id == SymbolId::new(1).unwrap()
+        ));
+    }
+
     #[test]
     fn find_by_import_path_matches_at_segment_boundaries_only() {
         let rust = LanguageId::new("rust");
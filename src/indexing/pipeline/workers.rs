@@ -2,29 +2,53 @@
 
 use super::Pipeline;
 use super::types::PipelineError;
+use crate::indexing::diagnostics::FileDiagnostic;
 use std::thread;
 use std::time::Duration;
 
 /// Thread join handle type for READ workers.
-/// Returns (files, errors, input_wait, output_wait, wall_time).
-type ReadJoinHandle =
-    thread::JoinHandle<Result<(usize, usize, Duration, Duration, Duration), PipelineError>>;
+/// Returns (files, failures, input_wait, output_wait, wall_time).
+type ReadJoinHandle = thread::JoinHandle<
+    Result<(usize, Vec<FileDiagnostic>, Duration, Duration, Duration), PipelineError>,
+>;
+
+/// What a READ pool returns:
+/// (files, errors, failures, input_wait, output_wait, wall_time).
+pub(super) type ReadTotals = (
+    usize,
+    usize,
+    Vec<FileDiagnostic>,
+    Duration,
+    Duration,
+    Duration,
+);
+
+/// What a PARSE worker returns: (files, errors, diagnostics, symbols,
+/// input_wait, output_wait, wall_time). The diagnostics are those of the
+/// files it parsed and of the ones it failed or skipped.
+pub(super) type ParseTotals = (
+    usize,
+    usize,
+    Vec<FileDiagnostic>,
+    usize,
+    Duration,
+    Duration,
+    Duration,
+);
 
 /// Thread join handle type for PARSE workers (with timing).
-/// Returns (files, errors, symbols, input_wait, output_wait, wall_time).
-type ParseJoinHandle = thread::JoinHandle<(usize, usize, usize, Duration, Duration, Duration)>;
+pub(super) type ParseJoinHandle = thread::JoinHandle<ParseTotals>;
 
 impl Pipeline {
     /// Join READ worker threads and aggregate results.
     ///
-    /// Returns (files_read, errors, total_input_wait, total_output_wait).
+    /// Returns (files_read, errors, failures, total_input_wait,
+    /// total_output_wait, max_wall_time).
     /// Panicked threads are logged and counted as errors.
-    pub(super) fn join_read_workers(
-        &self,
-        handles: Vec<ReadJoinHandle>,
-    ) -> (usize, usize, Duration, Duration, Duration) {
+    pub(super) fn join_read_workers(&self, handles: Vec<ReadJoinHandle>) -> ReadTotals {
         let mut files = 0;
         let mut errors = 0;
+        let mut failures = Vec::new();
         let mut input_wait = Duration::ZERO;
         let mut output_wait = Duration::ZERO;
         let mut max_wall_time = Duration::ZERO;
@@ -33,7 +57,8 @@ impl Pipeline {
             match handle.join() {
                 Ok(Ok((f, e, i, o, w))) => {
                     files += f;
-                    errors += e;
+                    errors += e.len();
+                    failures.extend(e);
                     input_wait += i;
                     output_wait += o;
                     // Use max wall_time (when last thread finished)
@@ -52,19 +77,25 @@ impl Pipeline {
             }
         }
 
-        (files, errors, input_wait, output_wait, max_wall_time)
+        (
+            files,
+            errors,
+            failures,
+            input_wait,
+            output_wait,
+            max_wall_time,
+        )
     }
 
     /// Join PARSE worker threads and aggregate results.
     ///
-    /// Returns (files_parsed, errors, symbols, total_input_wait, total_output_wait, max_wall_time).
+    /// Returns (files_parsed, errors, diagnostics, symbols, total_input_wait,
+    /// total_output_wait, max_wall_time).
     /// Panicked threads are logged and counted as errors.
-    pub(super) fn join_parse_workers(
-        &self,
-        handles: Vec<ParseJoinHandle>,
-    ) -> (usize, usize, usize, Duration, Duration, Duration) {
+    pub(super) fn join_parse_workers(&self, handles: Vec<ParseJoinHandle>) -> ParseTotals {
         let mut files = 0;
         let mut errors = 0;
+        let mut diagnostics = Vec::new();
         let mut symbols = 0;
         let mut input_wait = Duration::ZERO;
         let mut output_wait = Duration::ZERO;
@@ -72,9 +103,10 @@ impl Pipeline {
 
         for handle in handles {
             match handle.join() {
-                Ok((f, e, s, i, o, w)) => {
+                Ok((f, e, d, s, i, o, w)) => {
                     files += f;
                     errors += e;
+                    diagnostics.extend(d);
                     symbols += s;
                     input_wait += i;
                     output_wait += o;
@@ -93,6 +125,7 @@ impl Pipeline {
         (
             files,
             errors,
+            diagnostics,
             symbols,
             input_wait,
             output_wait,
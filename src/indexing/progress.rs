@@ -1,5 +1,7 @@
 //! Progress reporting for indexing operations
 
+use crate::indexing::diagnostics::FileDiagnostic;
+use crate::semantic::DocLanguage;
 use std::path::PathBuf;
 use std::time::{Duration, Instant};
 
@@ -15,9 +17,20 @@ pub struct IndexStats {
     /// Total number of symbols found
     pub symbols_found: usize,
 
+    /// Files left out for being over their language's size limits, with
+    /// the reason
+    pub files_skipped: Vec<(PathBuf, String)>,
+
+    /// Problems of the files read, failed or skipped, by file
+    pub diagnostics: Vec<FileDiagnostic>,
+
     /// Number of symbols that failed embedding generation
     pub embeddings_failed: usize,
 
+    /// Files with doc comments in another language than English, by
+    /// language, when the embedding model is English-only
+    pub foreign_doc_files: Vec<(DocLanguage, usize)>,
+
     /// Files removed by deleted-file cleanup
     pub files_removed: usize,
 
@@ -59,6 +72,26 @@ impl IndexStats {
         self.files_failed += 1;
     }
 
+    /// Warning that the embedding model ranks the doc comments of
+    /// `foreign_doc_files` poorly, if there are any
+    pub fn doc_language_warning(&self) -> Option<String> {
+        if self.foreign_doc_files.is_empty() {
+            return None;
+        }
+        let files: usize = self.foreign_doc_files.iter().map(|(_, count)| count).sum();
+        let languages: Vec<String> = self
+            .foreign_doc_files
+            .iter()
+            .map(|(language, count)| format!("{language}: {count}"))
+            .collect();
+        Some(format!(
+            "Warning: doc comments of {files} file(s) are not in English ({}).\n  \
+             The embedding model is English-only, so semantic search ranks them poorly.\n  \
+             Set semantic_search.model = \"multilingual-e5\" and run: codanna index --force",
+            languages.join(", ")
+        ))
+    }
+
     /// Display the statistics in a human-readable format
     pub fn display(&self) {
         println!("\nIndexing Complete:");
@@ -75,6 +108,19 @@ impl IndexStats {
             println!("  Average symbols/file: {symbols_per_file:.1}");
         }
 
+        if !self.files_skipped.is_empty() {
+            println!(
+                "\nSkipped {} files over their language's size limits:",
+                self.files_skipped.len()
+            );
+            for (path, reason) in self.files_skipped.iter().take(5) {
+                println!("  {}: {}", path.display(), reason);
+            }
+            if self.files_skipped.len() > 5 {
+                println!("  ... and {} more", self.files_skipped.len() - 5);
+            }
+        }
+
         if self.embeddings_failed > 0 {
             println!(
                 "\nWarning: {} symbols failed embedding generation. Semantic search may be incomplete.",
@@ -83,6 +129,10 @@ impl IndexStats {
             println!("  Run with --force to regenerate embeddings.");
         }
 
+        if let Some(warning) = self.doc_language_warning() {
+            println!("\n{warning}");
+        }
+
         if !self.errors.is_empty() {
             println!("\nErrors (showing first {}):", self.errors.len().min(5));
             for (path, error) in &self.errors[..5.min(self.errors.len())] {
@@ -111,6 +161,18 @@ mod tests {
         stats.display();
     }
 
+    #[test]
+    fn test_doc_language_warning() {
+        let mut stats = IndexStats::new();
+        assert!(stats.doc_language_warning().is_none());
+
+        stats.foreign_doc_files = vec![(DocLanguage::Japanese, 8), (DocLanguage::German, 4)];
+        let warning = stats.doc_language_warning().unwrap();
+        assert!(warning.contains("12 file(s)"));
+        assert!(warning.contains("Japanese: 8, German: 4"));
+        assert!(warning.contains("multilingual-e5"));
+    }
+
     #[test]
     fn test_error_limiting() {
         let mut stats = IndexStats::new();
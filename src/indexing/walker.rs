@@ -5,8 +5,10 @@
 //! - Custom ignore patterns from configuration
 //! - Language filtering
 //! - Hidden file handling
+//! - Symbolic and hard links (see [`crate::indexing::links`])
 
 use crate::Settings;
+use crate::indexing::links::LinkFilter;
 use crate::parsing::get_registry;
 use ignore::WalkBuilder;
 use std::path::{Path, PathBuf};
@@ -34,10 +36,17 @@ impl FileWalker {
             .git_ignore(true) // Respect .gitignore files
             .git_global(true) // Respect global gitignore
             .git_exclude(true) // Respect .git/info/exclude
-            .follow_links(false) // Don't follow symlinks by default
             .max_depth(None) // No depth limit
             .require_git(false); // Allow gitignore to work in non-git directories
 
+        // Follow the links `[indexing] symlinks` allows, each file once
+        let links = LinkFilter::new(
+            self.settings.indexing.symlinks,
+            root,
+            self.settings.workspace_root.as_deref(),
+        );
+        links.configure(&mut builder);
+
         // Always support .codannaignore files for custom ignore patterns (follows .gitignore pattern)
         builder.add_custom_ignore_filename(".codannaignore");
 
@@ -73,7 +82,7 @@ impl FileWalker {
                 if let Some(extension) = path.extension() {
                     if let Some(ext_str) = extension.to_str() {
                         if enabled_extensions.iter().any(|ext| ext == ext_str) {
-                            return Some(path.to_path_buf());
+                            return links.admit(path);
                         }
                     }
                 }
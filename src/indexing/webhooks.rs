@@ -0,0 +1,181 @@
+//! Webhooks fired when `codanna index` completes.
+//!
+//! Each `[[indexing.webhooks]]` URL is POSTed a summary of a run that
+//! changed the index: the files indexed and removed, the change in symbols
+//! and how long it took, so a doc generator or a CI job can react to it.
+//! With a `secret`, the body is signed with HMAC-SHA256 in
+//! `X-Codanna-Signature: sha256=<hex>`, as GitHub signs its webhooks. The
+//! index is already saved when they fire; a webhook that fails is reported
+//! and does not fail the run.
+
+use std::time::Duration;
+
+use serde::Serialize;
+use sha2::{Digest, Sha256};
+
+use crate::config::IndexWebhook;
+use crate::indexing::IndexStats;
+
+/// How long a webhook may take to answer
+const WEBHOOK_TIMEOUT: Duration = Duration::from_secs(10);
+
+/// Header holding the signature of the body
+pub const SIGNATURE_HEADER: &str = "X-Codanna-Signature";
+
+/// What an indexing run changed, as the webhooks are sent it
+#[derive(Debug, Clone, Default, Serialize)]
+pub struct IndexSummary {
+    pub event: &'static str,
+    /// `full` for `index --force`, `incremental` otherwise
+    pub mode: &'static str,
+    pub files_indexed: usize,
+    pub files_removed: usize,
+    pub files_failed: usize,
+    /// Net change in the symbols of the index
+    pub symbols_added: usize,
+    pub symbols_removed: usize,
+    pub total_symbols: usize,
+    pub duration_ms: u64,
+}
+
+impl IndexSummary {
+    /// A summary of a run, `force` for a full one
+    pub fn new(force: bool) -> Self {
+        Self {
+            event: "index_completed",
+            mode: if force { "full" } else { "incremental" },
+            ..Self::default()
+        }
+    }
+
+    /// Count the files of one indexed directory
+    pub fn add(&mut self, stats: &IndexStats) {
+        self.files_indexed += stats.files_indexed;
+        self.files_removed += stats.files_removed;
+        self.files_failed += stats.files_failed;
+    }
+
+    /// Whether the run changed any file of the index
+    pub fn changed(&self) -> bool {
+        self.files_indexed > 0 || self.files_removed > 0
+    }
+
+    /// Set the symbols the index held before and after the run
+    pub fn set_symbols(&mut self, before: usize, after: usize) {
+        self.symbols_added = after.saturating_sub(before);
+        self.symbols_removed = before.saturating_sub(after);
+        self.total_symbols = after;
+    }
+}
+
+/// POST `summary` to each of `webhooks`, waiting for their answers
+pub fn notify(webhooks: &[IndexWebhook], summary: &IndexSummary) {
+    if webhooks.is_empty() {
+        return;
+    }
+    let body = serde_json::to_string(summary).expect("summary serialization");
+    let webhooks = webhooks.to_vec();
+    let failures = crate::semantic::remote::run_async(async move {
+        let client = reqwest::Client::new();
+        let mut failures = Vec::new();
+        for webhook in &webhooks {
+            if let Err(e) = post(&client, webhook, &body).await {
+                failures.push(format!("{}: {e}", webhook.url));
+            }
+        }
+        failures
+    });
+    for failure in failures {
+        eprintln!("Warning: index webhook {failure}");
+    }
+}
+
+async fn post(client: &reqwest::Client, webhook: &IndexWebhook, body: &str) -> Result<(), String> {
+    let mut request = client
+        .post(&webhook.url)
+        .timeout(WEBHOOK_TIMEOUT)
+        .header(reqwest::header::CONTENT_TYPE, "application/json")
+        .header("X-Codanna-Event", "index_completed");
+    if let Some(secret) = webhook
+        .secret
+        .as_deref()
+        .filter(|secret| !secret.is_empty())
+    {
+        request = request.header(SIGNATURE_HEADER, signature(secret, body.as_bytes()));
+    }
+    let response = request
+        .body(body.to_string())
+        .send()
+        .await
+        .map_err(|e| e.to_string())?;
+    if response.status().is_success() {
+        crate::debug_event!("indexing", "webhook done", "{}", webhook.url);
+        Ok(())
+    } else {
+        Err(format!("answered {}", response.status()))
+    }
+}
+
+/// `sha256=` and the hex HMAC-SHA256 of `body` under `secret`
+pub fn signature(secret: &str, body: &[u8]) -> String {
+    format!(
+        "sha256={}",
+        hex::encode(hmac_sha256(secret.as_bytes(), body))
+    )
+}
+
+/// HMAC (RFC 2104) over SHA-256
+fn hmac_sha256(key: &[u8], message: &[u8]) -> [u8; 32] {
+    const BLOCK: usize = 64;
+    let mut block = [0u8; BLOCK];
+    if key.len() > BLOCK {
+        block[..32].copy_from_slice(&Sha256::digest(key));
+    } else {
+        block[..key.len()].copy_from_slice(key);
+    }
+
+    let mut inner = Sha256::new();
+    inner.update(block.map(|byte| byte ^ 0x36));
+    inner.update(message);
+    let mut outer = Sha256::new();
+    outer.update(block.map(|byte| byte ^ 0x5c));
+    outer.update(inner.finalize());
+    outer.finalize().into()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_signature_matches_rfc_4231() {
+        // Test case 2 of RFC 4231
+        assert_eq!(
+            signature("Jefe", b"what do ya want for nothing?"),
+            "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+        );
+        // Test case 6: a key longer than the block is hashed first
+        assert_eq!(
+            hex::encode(hmac_sha256(
+                &[0xaa; 131],
+                b"Test Using Larger Than Block-Size Key - Hash Key First"
+            )),
+            "60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54"
+        );
+    }
+
+    #[test]
+    fn test_summary_counts_net_symbol_change() {
+        let mut summary = IndexSummary::new(false);
+        assert!(!summary.changed());
+        summary.files_indexed = 2;
+        summary.set_symbols(120, 100);
+        assert!(summary.changed());
+        assert_eq!((summary.symbols_added, summary.symbols_removed), (0, 20));
+
+        let json = serde_json::to_value(&summary).unwrap();
+        assert_eq!(json["event"], "index_completed");
+        assert_eq!(json["mode"], "incremental");
+        assert_eq!(json["total_symbols"], 100);
+    }
+}
@@ -9,7 +9,7 @@ use crate::error::IndexError;
 use serde::{Deserialize, Serialize};
 use std::collections::HashMap;
 use std::path::{Path, PathBuf};
-use std::sync::OnceLock;
+use std::sync::{OnceLock, RwLock};
 
 // Configurable directory names for testing
 // Change these to production values when ready
@@ -30,6 +30,9 @@ const FASTEMBED_CACHE_NAME: &str = ".fastembed_cache";
 // Global directory cache
 static GLOBAL_DIR: OnceLock<PathBuf> = OnceLock::new();
 
+// Pre-downloaded models (`semantic_search.model_path`), replacing models_dir()
+static MODEL_PATH: RwLock<Option<PathBuf>> = RwLock::new(None);
+
 /// Get the global Codanna directory
 /// Returns ~/.codanna-dev (or test variant) on Unix-like systems
 pub fn global_dir() -> PathBuf {
@@ -48,6 +51,38 @@ pub fn models_dir() -> PathBuf {
     global_dir().join("models")
 }
 
+/// Load models from `path`, a `codanna models fetch` bundle, instead of
+/// the models cache
+pub fn set_model_path(path: PathBuf) {
+    if let Ok(mut model_path) = MODEL_PATH.write() {
+        *model_path = Some(path);
+    }
+}
+
+/// The configured model bundle, if any
+pub fn model_path() -> Option<PathBuf> {
+    MODEL_PATH.read().ok().and_then(|path| path.clone())
+}
+
+/// Get the directory embedding and reranker models load from
+/// Returns the model bundle when configured, models_dir() otherwise
+pub fn model_cache_dir() -> PathBuf {
+    model_path().unwrap_or_else(models_dir)
+}
+
+/// What to do about a model that failed to load from the model bundle;
+/// empty without one
+pub fn model_path_hint(model_name: &str) -> String {
+    match model_path() {
+        Some(path) => format!(
+            ". Add '{model_name}' to the model bundle at {} with \
+             'codanna models fetch' on a machine with internet access",
+            path.display()
+        ),
+        None => String::new(),
+    }
+}
+
 /// Get the projects registry file
 /// Returns ~/.codanna-dev/projects.json
 pub fn projects_file() -> PathBuf {
@@ -249,6 +284,26 @@ pub struct ProjectInfo {
     pub last_modified: u64,
     /// Number of documents in index
     pub doc_count: u64,
+    /// Name to reach the project by with `--project`
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub alias: Option<String>,
+    /// Absolute path to the index, as last saved
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub index_path: Option<PathBuf>,
+}
+
+impl ProjectInfo {
+    /// The alias of the project, or else its directory name
+    pub fn display_name(&self) -> &str {
+        self.alias.as_deref().unwrap_or(&self.name)
+    }
+
+    /// Where the index is: as last saved, or the default under the project
+    pub fn index_dir(&self) -> PathBuf {
+        self.index_path
+            .clone()
+            .unwrap_or_else(|| self.path.join(local_dir_name()).join("index"))
+    }
 }
 
 /// Registry schema for all indexed projects
@@ -334,9 +389,12 @@ impl ProjectRegistry {
             .unwrap_or_else(|_| project_path.to_path_buf());
 
         // Check if project already exists by path
-        if let Some((existing_id, _)) = registry.find_project_by_path(&canonical_input) {
-            // Update the existing project info (in case metadata changed)
-            let updated_info = Self::create_project_info(project_path);
+        if let Some((existing_id, existing)) = registry.find_project_by_path(&canonical_input) {
+            // Update the existing project info (in case metadata changed),
+            // keeping the alias it was given
+            let mut updated_info = Self::create_project_info(project_path);
+            updated_info.alias = existing.alias.clone();
+            updated_info.index_path = existing.index_path.clone();
             registry.add_project(&existing_id, updated_info);
             registry.save()?;
             Ok(existing_id)
@@ -370,6 +428,8 @@ impl ProjectRegistry {
             file_count: 0,
             last_modified: 0,
             doc_count: 0,
+            alias: None,
+            index_path: None,
         }
     }
 
@@ -394,6 +454,106 @@ impl ProjectRegistry {
         })
     }
 
+    /// The registered projects, by alias or directory name
+    pub fn projects(&self) -> Vec<(&str, &ProjectInfo)> {
+        let mut projects: Vec<_> = self
+            .projects
+            .iter()
+            .map(|(id, info)| (id.as_str(), info))
+            .collect();
+        projects.sort_by(|(_, a), (_, b)| {
+            a.display_name()
+                .cmp(b.display_name())
+                .then_with(|| a.path.cmp(&b.path))
+        });
+        projects
+    }
+
+    /// The project `name` refers to: its alias, its ID, or the name of its
+    /// directory when no other project has one of that name
+    pub fn resolve(&self, name: &str) -> Result<(&str, &ProjectInfo), IndexError> {
+        if let Some((id, info)) = self
+            .projects
+            .iter()
+            .find(|(_, info)| info.alias.as_deref() == Some(name))
+        {
+            return Ok((id.as_str(), info));
+        }
+        if let Some((id, info)) = self.projects.get_key_value(name) {
+            return Ok((id.as_str(), info));
+        }
+
+        let named: Vec<_> = self
+            .projects
+            .iter()
+            .filter(|(_, info)| info.name == name)
+            .collect();
+        match named.as_slice() {
+            [(id, info)] => Ok((id.as_str(), info)),
+            [] => Err(IndexError::General(format!(
+                "No project named '{name}'\nSuggestion: Run 'codanna projects list' to see the registered projects"
+            ))),
+            _ => {
+                let paths: Vec<String> = named
+                    .iter()
+                    .map(|(_, info)| info.path.display().to_string())
+                    .collect();
+                Err(IndexError::General(format!(
+                    "Several projects are named '{name}': {}\nSuggestion: Give one an alias with 'codanna projects alias <path> <alias>'",
+                    paths.join(", ")
+                )))
+            }
+        }
+    }
+
+    /// The ID of the project at the directory `project`, or else the one
+    /// it names as [`resolve`](Self::resolve) takes it
+    pub fn find(&self, project: &str) -> Result<String, IndexError> {
+        let path = Path::new(project);
+        if path.is_dir() {
+            if let Some((id, _)) = self.find_project_by_path(path) {
+                return Ok(id);
+            }
+        }
+        self.resolve(project).map(|(id, _)| id.to_string())
+    }
+
+    /// Give the project `project_id` an alias, or take it away with `None`;
+    /// an alias another project has is refused
+    pub fn set_alias(&mut self, project_id: &str, alias: Option<&str>) -> Result<(), IndexError> {
+        if let Some(alias) = alias {
+            if !is_valid_alias(alias) {
+                return Err(IndexError::General(format!(
+                    "Invalid alias '{alias}'\nSuggestion: Use letters, digits, '.', '_' and '-'"
+                )));
+            }
+            if let Some((_, other)) = self
+                .projects
+                .iter()
+                .find(|(id, info)| *id != project_id && info.alias.as_deref() == Some(alias))
+            {
+                return Err(IndexError::General(format!(
+                    "Alias '{alias}' is taken by {}\nSuggestion: Pick another, or remove it there with 'codanna projects alias {}'",
+                    other.path.display(),
+                    other.path.display()
+                )));
+            }
+        }
+        let project = self
+            .find_project_by_id_mut(project_id)
+            .ok_or_else(|| IndexError::General(format!("Project {project_id} not found")))?;
+        project.alias = alias.map(str::to_string);
+        Ok(())
+    }
+
+    /// Forget the project `project_id`; its index stays where it is
+    pub fn remove_project(&mut self, project_id: &str) -> Option<ProjectInfo> {
+        if self.default_project.as_deref() == Some(project_id) {
+            self.default_project = None;
+        }
+        self.projects.remove(project_id)
+    }
+
     /// Find a project by its UUID
     pub fn find_project_by_id(&self, project_id: &str) -> Option<&ProjectInfo> {
         self.projects.get(project_id)
@@ -427,6 +587,14 @@ impl ProjectRegistry {
     }
 }
 
+/// Whether `alias` can name a project on the command line
+fn is_valid_alias(alias: &str) -> bool {
+    !alias.is_empty()
+        && alias
+            .chars()
+            .all(|c| c.is_ascii_alphanumeric() || matches!(c, '.' | '_' | '-'))
+}
+
 impl Default for ProjectRegistry {
     fn default() -> Self {
         Self::new()
@@ -499,4 +667,36 @@ mod tests {
         let projects = projects_file();
         assert!(projects.ends_with(format!("{GLOBAL_DIR_NAME}/projects.json")));
     }
+
+    #[test]
+    fn test_resolve_projects_by_alias_and_name() {
+        let mut registry = ProjectRegistry::new();
+        for (id, path) in [("a1", "/work/api"), ("b2", "/old/api"), ("c3", "/work/web")] {
+            let mut info = ProjectRegistry::create_project_info(Path::new(path));
+            info.path = PathBuf::from(path);
+            registry.add_project(id, info);
+        }
+
+        assert_eq!(registry.resolve("web").unwrap().0, "c3");
+        assert_eq!(registry.resolve("b2").unwrap().0, "b2");
+        let ambiguous = registry.resolve("api").unwrap_err().to_string();
+        assert!(ambiguous.contains("/work/api") && ambiguous.contains("/old/api"));
+        assert!(registry.resolve("mobile").is_err());
+
+        registry.set_alias("a1", Some("backend")).unwrap();
+        assert_eq!(registry.resolve("backend").unwrap().0, "a1");
+        assert!(registry.set_alias("b2", Some("backend")).is_err());
+        assert!(registry.set_alias("b2", Some("not an alias")).is_err());
+        let names: Vec<&str> = registry
+            .projects()
+            .iter()
+            .map(|(_, info)| info.display_name())
+            .collect();
+        assert_eq!(names, ["api", "backend", "web"]);
+
+        registry.set_alias("a1", None).unwrap();
+        assert!(registry.resolve("backend").is_err());
+        assert!(registry.remove_project("b2").is_some());
+        assert_eq!(registry.resolve("api").unwrap().0, "a1");
+    }
 }
@@ -4,12 +4,44 @@
 //!
 //! This envelope provides consistent JSON output across all commands,
 //! designed for Unix piping, AI integration, and future streaming.
+//!
+//! # JSON Lines
+//!
+//! With `--jsonl` an envelope prints as one compact JSON object per line,
+//! each discriminated by `type`:
+//!
+//! - `result`: `{"type", "entity_type", "data"}`, one per item when `data`
+//!   is an array, else one holding the whole of `data`; none when it is null.
+//! - `summary`: the envelope without `data` (`status`, `code`, `exit_code`,
+//!   `message`, `hint`, `meta`), always the last line of a result.
+//! - `error`: the envelope without `data`, the only line of an error.
+//!
+//! # Templates
+//!
+//! With `--format '<template>'` an envelope prints just its data items, a
+//! line each through the [template](crate::io::template). The message of an
+//! envelope without items goes to stderr.
 
+use crate::io::template::{OutputTemplate, output_template};
 use serde::{Deserialize, Serialize};
+use std::sync::atomic::{AtomicBool, Ordering};
 
 /// Schema version for this envelope format.
 pub const SCHEMA_VERSION: &str = "1.0.0";
 
+/// Whether envelopes print as JSON Lines, set once from `--jsonl`
+static JSON_LINES: AtomicBool = AtomicBool::new(false);
+
+/// Print every envelope as JSON Lines from now on.
+pub fn set_json_lines(enabled: bool) {
+    JSON_LINES.store(enabled, Ordering::Relaxed);
+}
+
+/// Whether `--jsonl` was given.
+pub fn json_lines() -> bool {
+    JSON_LINES.load(Ordering::Relaxed)
+}
+
 /// Message type for stream discrimination.
 ///
 /// Today: only `Result` and `Error` are used.
@@ -80,6 +112,22 @@ pub enum EntityType {
     Document,
     Callers,
     Calls,
+    Hierarchy,
+    Cycle,
+    Dependency,
+    Duplicate,
+    Test,
+    Reference,
+    Api,
+    Stats,
+    Todo,
+    StringLiteral,
+    Diff,
+    Benchmark,
+    Embeddings,
+    Query,
+    Diagnostic,
+    Project,
 }
 
 /// Unified JSON output envelope.
@@ -163,6 +211,10 @@ pub struct Meta {
     /// Traversal depth for tree/graph results
     #[serde(skip_serializing_if = "Option::is_none")]
     pub depth: Option<u32>,
+
+    /// Cursor of the next page of a paginated result
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub next_cursor: Option<String>,
 }
 
 impl Default for Meta {
@@ -176,6 +228,7 @@ impl Default for Meta {
             duration_ms: None,
             truncated: None,
             depth: None,
+            next_cursor: None,
         }
     }
 }
@@ -286,11 +339,24 @@ impl<T> Envelope<T> {
         self
     }
 
-    /// Serialize to JSON string.
+    /// Set the cursor of the next page, if there is one.
+    pub fn with_next_cursor(mut self, cursor: Option<String>) -> Self {
+        self.meta.next_cursor = cursor;
+        self
+    }
+
+    /// Serialize to JSON string, as JSON Lines under `--jsonl`, through the
+    /// template under `--format`.
     pub fn to_json(&self) -> Result<String, serde_json::Error>
     where
         T: Serialize,
     {
+        if let Some(template) = output_template() {
+            return self.to_template(template);
+        }
+        if json_lines() {
+            return self.to_json_lines(None);
+        }
         serde_json::to_string_pretty(self)
     }
 
@@ -311,37 +377,102 @@ impl<T> Envelope<T> {
     where
         T: Serialize,
     {
+        if let Some(template) = output_template() {
+            return self.to_template(template);
+        }
+        if json_lines() {
+            return self.to_json_lines(Some(fields));
+        }
+
         // Serialize to Value first
         let mut value = serde_json::to_value(self)?;
+        filter_data_fields(&mut value, fields);
+        serde_json::to_string_pretty(&value)
+    }
 
-        // Helper to filter object fields
-        fn filter_object(obj: &mut serde_json::Map<String, serde_json::Value>, fields: &[String]) {
-            let keys_to_remove: Vec<String> = obj
-                .keys()
-                .filter(|k| !fields.contains(k))
-                .cloned()
-                .collect();
-            for key in keys_to_remove {
-                obj.remove(&key);
+    /// Serialize to JSON Lines, the records the module docs describe, with
+    /// the data items cut down to `fields` when given.
+    pub fn to_json_lines(&self, fields: Option<&[String]>) -> Result<String, serde_json::Error>
+    where
+        T: Serialize,
+    {
+        use serde_json::Value;
+
+        let mut value = serde_json::to_value(self)?;
+        if let Some(fields) = fields {
+            filter_data_fields(&mut value, fields);
+        }
+        let Value::Object(mut record) = value else {
+            return serde_json::to_string(&value);
+        };
+        let data = record.get_mut("data").map(Value::take).unwrap_or_default();
+        record.retain(|key, _| key != "data");
+
+        let mut lines = Vec::new();
+        if self.message_type != MessageType::Error {
+            let items = match data {
+                Value::Null => Vec::new(),
+                Value::Array(items) => items,
+                item => vec![item],
+            };
+            for item in items {
+                lines.push(serde_json::to_string(&serde_json::json!({
+                    "type": MessageType::Result,
+                    "entity_type": self.meta.entity_type,
+                    "data": item,
+                }))?);
             }
+            let summary = serde_json::to_value(MessageType::Summary)?;
+            record.insert("type".to_string(), summary);
+        }
+        lines.push(serde_json::to_string(&record)?);
+        Ok(lines.join("\n"))
+    }
+
+    /// The data items rendered through `template`, a line each.
+    pub fn to_template(&self, template: &OutputTemplate) -> Result<String, serde_json::Error>
+    where
+        T: Serialize,
+    {
+        let data = match &self.data {
+            Some(data) if self.message_type != MessageType::Error => serde_json::to_value(data)?,
+            _ => serde_json::Value::Null,
+        };
+        let lines = template.render_data(&data);
+        if lines.is_empty() {
+            eprintln!("{}", self.message);
+        }
+        Ok(lines)
+    }
+}
+
+/// Keep only `fields` of the data items (or the data object) of `value`.
+fn filter_data_fields(value: &mut serde_json::Value, fields: &[String]) {
+    // Helper to filter object fields
+    fn filter_object(obj: &mut serde_json::Map<String, serde_json::Value>, fields: &[String]) {
+        let keys_to_remove: Vec<String> = obj
+            .keys()
+            .filter(|k| !fields.contains(k))
+            .cloned()
+            .collect();
+        for key in keys_to_remove {
+            obj.remove(&key);
         }
+    }
 
-        // Filter fields in data (array or single object)
-        if let Some(data) = value.get_mut("data") {
-            if let Some(arr) = data.as_array_mut() {
-                // Handle array: filter each item
-                for item in arr.iter_mut() {
-                    if let Some(obj) = item.as_object_mut() {
-                        filter_object(obj, fields);
-                    }
+    // Filter fields in data (array or single object)
+    if let Some(data) = value.get_mut("data") {
+        if let Some(arr) = data.as_array_mut() {
+            // Handle array: filter each item
+            for item in arr.iter_mut() {
+                if let Some(obj) = item.as_object_mut() {
+                    filter_object(obj, fields);
                 }
-            } else if let Some(obj) = data.as_object_mut() {
-                // Handle single object
-                filter_object(obj, fields);
             }
+        } else if let Some(obj) = data.as_object_mut() {
+            // Handle single object
+            filter_object(obj, fields);
         }
-
-        serde_json::to_string_pretty(&value)
     }
 }
 
@@ -403,4 +534,35 @@ mod tests {
         assert!(json.contains("\"status\": \"success\""));
         assert!(json.contains("\"schema_version\": \"1.0.0\""));
     }
+
+    #[test]
+    fn test_json_lines_records() {
+        let fields = vec!["name".to_string()];
+        let envelope = Envelope::success(serde_json::json!([
+            {"name": "a", "kind": "Function"},
+            {"name": "b", "kind": "Struct"},
+        ]))
+        .with_entity_type(EntityType::Symbol)
+        .with_count(2);
+
+        let lines = envelope.to_json_lines(Some(&fields)).unwrap();
+        let records: Vec<serde_json::Value> = lines
+            .lines()
+            .map(|line| serde_json::from_str(line).unwrap())
+            .collect();
+        assert_eq!(records.len(), 3);
+        assert_eq!(records[0]["type"], "result");
+        assert_eq!(records[0]["entity_type"], "symbol");
+        assert_eq!(records[1]["data"], serde_json::json!({"name": "b"}));
+        assert_eq!(records[2]["type"], "summary");
+        assert_eq!(records[2]["meta"]["count"], 2);
+        assert!(records[2].get("data").is_none());
+
+        let error: Envelope<()> = Envelope::error(ResultCode::InvalidQuery, "bad");
+        let lines = error.to_json_lines(None).unwrap();
+        assert_eq!(lines.lines().count(), 1);
+        let record: serde_json::Value = serde_json::from_str(&lines).unwrap();
+        assert_eq!(record["type"], "error");
+        assert_eq!(record["code"], "INVALID_QUERY");
+    }
 }
@@ -1,7 +1,7 @@
 //! Input/Output handling for CLI and tool integration.
 //!
 //! This module provides:
-//! - Unified output formatting (text, JSON)
+//! - Unified output formatting (text, JSON, templates)
 //! - Consistent error handling and exit codes
 //! - Future: JSON-RPC 2.0 support for IDE integration
 
@@ -16,6 +16,7 @@ pub mod output;
 pub mod parse;
 pub mod schema;
 pub mod status_line;
+pub mod template;
 #[cfg(test)]
 mod test;
 
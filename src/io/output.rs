@@ -436,6 +436,8 @@ mod tests {
                 symbol,
                 file_path: format!("src/{name}.rs:11"),
                 relationships: SymbolRelationships::default(),
+                owners: Vec::new(),
+                source: None,
             }
         }
 
@@ -483,6 +485,8 @@ mod tests {
             symbol,
             file_path: "src/test.rs:43".to_string(),
             relationships: SymbolRelationships::default(),
+            owners: Vec::new(),
+            source: None,
         };
 
         let stdout = Vec::new();
@@ -517,6 +521,8 @@ mod tests {
             symbol,
             file_path: "test.rs:1".to_string(),
             relationships: SymbolRelationships::default(),
+            owners: Vec::new(),
+            source: None,
         };
 
         // Test with broken pipe on stdout
@@ -53,6 +53,11 @@ pub enum ParseError {
         source: tree_sitter::LanguageError,
     },
 
+    #[error(
+        "Failed to set up the language plugin parser: {reason}\nSuggestion: Check the plugin's grammar"
+    )]
+    PluginSetupError { reason: String },
+
     #[error(
         "Failed to parse file\nSuggestion: Check if the file has valid syntax for the detected language"
     )]
@@ -77,6 +82,7 @@ impl ParseError {
             ParseError::OutputCreateError { .. } => ExitCode::IoError,
             ParseError::OutputWriteError { .. } => ExitCode::IoError,
             ParseError::LanguageSetupError { .. } => ExitCode::ParseError,
+            ParseError::PluginSetupError { .. } => ExitCode::ParseError,
             ParseError::ParseFailure => ExitCode::ParseError,
             ParseError::SerializationError { .. } => ExitCode::GeneralError,
         }
@@ -245,7 +251,7 @@ pub fn execute_parse(
         path: file_path.display().to_string(),
         source: e,
     })?;
-    let code = String::from_utf8_lossy(&bytes).into_owned();
+    let mut code = String::from_utf8_lossy(&bytes).into_owned();
 
     // Create tree-sitter parser for the language
     let mut parser = tree_sitter::Parser::new();
@@ -264,13 +270,58 @@ pub fn execute_parse(
         Language::Java => tree_sitter_java::LANGUAGE.into(),
         Language::Kotlin => tree_sitter_kotlin::language(),
         Language::Lua => tree_sitter_lua::LANGUAGE.into(),
+        Language::Ruby => tree_sitter_ruby::LANGUAGE.into(),
+        Language::Scala => tree_sitter_scala::LANGUAGE.into(),
         Language::Swift => tree_sitter_swift::LANGUAGE.into(),
+        Language::Zig => tree_sitter_zig::LANGUAGE.into(),
+        Language::Dart => tree_sitter_dart::LANGUAGE.into(),
+        Language::Elixir => tree_sitter_elixir::LANGUAGE.into(),
+        Language::Bash => tree_sitter_bash::LANGUAGE.into(),
+        Language::Sql => tree_sitter_sequel::LANGUAGE.into(),
+        Language::Protobuf => tree_sitter_proto::LANGUAGE.into(),
+        Language::GraphQL => tree_sitter_graphql::LANGUAGE.into(),
+        Language::Hcl => tree_sitter_hcl::LANGUAGE.into(),
+        Language::Vue => tree_sitter_typescript::LANGUAGE_TSX.into(),
+        Language::Svelte => tree_sitter_typescript::LANGUAGE_TSX.into(),
+        Language::Markdown => tree_sitter_md::LANGUAGE.into(),
+        Language::Jupyter => tree_sitter_python::LANGUAGE.into(),
+        Language::Css => tree_sitter_css::LANGUAGE.into(),
+        Language::Html => tree_sitter_html::LANGUAGE.into(),
+        Language::Plugin(id) => {
+            let grammar = crate::parsing::plugin::grammar(id).ok_or_else(|| {
+                ParseError::UnsupportedLanguage {
+                    extension: extension.to_string(),
+                }
+            })?;
+            parser = grammar
+                .parser()
+                .map_err(|reason| ParseError::PluginSetupError { reason })?;
+            grammar.language().clone()
+        }
     };
 
     parser
         .set_language(&ts_language)
         .map_err(|e| ParseError::LanguageSetupError { source: e })?;
 
+    // A component file's AST is that of its script blocks, at their
+    // positions in the file
+    if matches!(language, Language::Vue | Language::Svelte) {
+        let scripts: Vec<_> = crate::parsing::sfc::split(&code)
+            .into_iter()
+            .filter(|block| block.tag == "script")
+            .map(|block| block.content)
+            .collect();
+        code = crate::parsing::sfc::mask(&code, &scripts);
+    }
+
+    // A notebook's AST is that of its code cells, joined into one script
+    if language == Language::Jupyter {
+        code = crate::parsing::jupyter::notebook::Notebook::read(&code)
+            .map(|notebook| notebook.script)
+            .unwrap_or_default();
+    }
+
     // Parse the code
     let tree = parser.parse(&code, None).ok_or(ParseError::ParseFailure)?;
 
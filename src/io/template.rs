@@ -0,0 +1,220 @@
+//! Output templates for `--format '<template>'`.
+//!
+//! A template prints each result item of an envelope on a line of its own,
+//! with `{field}` replaced by the field of the item, so results go straight
+//! into editors, quickfix lists and scripts:
+//!
+//! ```text
+//! codanna retrieve search parse --format '{file}:{line}:{column} {kind} {name}'
+//! ```
+//!
+//! Fields that name the same thing across result types:
+//!
+//! - `{name}`, `{kind}`, `{signature}`, `{doc}`, `{module}`, `{id}`;
+//! - `{file}`, the file path, and `{line}` (1-based) and `{column}`
+//!   (0-based) of the symbol's start;
+//! - `{doc_summary}`, the first line of the doc comment.
+//!
+//! Any other `{key}`, or dotted `{key.nested}`, is a field of the item's
+//! JSON (as `--json` prints it), looked up on the item and then on its
+//! `symbol`. A field the item lacks prints as nothing. `{{` and `}}` print
+//! braces, `\t` and `\n` a tab and a line break.
+
+use serde_json::Value;
+use std::sync::OnceLock;
+
+/// The template given with `--format`, set once at startup
+static OUTPUT_TEMPLATE: OnceLock<OutputTemplate> = OnceLock::new();
+
+/// Print every envelope through `template` from now on.
+pub fn set_output_template(template: OutputTemplate) {
+    let _ = OUTPUT_TEMPLATE.set(template);
+}
+
+/// The template given with `--format`, if any.
+pub fn output_template() -> Option<&'static OutputTemplate> {
+    OUTPUT_TEMPLATE.get()
+}
+
+#[derive(Debug, Clone, PartialEq, Eq)]
+enum Part {
+    Text(String),
+    Field(String),
+}
+
+/// A parsed output template
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct OutputTemplate {
+    parts: Vec<Part>,
+}
+
+impl OutputTemplate {
+    /// Parse `template`, refusing unbalanced braces.
+    pub fn parse(template: &str) -> Result<Self, String> {
+        let mut parts = Vec::new();
+        let mut text = String::new();
+        let mut chars = template.chars().peekable();
+        while let Some(c) = chars.next() {
+            match c {
+                '{' if chars.peek() == Some(&'{') => {
+                    chars.next();
+                    text.push('{');
+                }
+                '}' if chars.peek() == Some(&'}') => {
+                    chars.next();
+                    text.push('}');
+                }
+                '{' => {
+                    let mut field = String::new();
+                    loop {
+                        match chars.next() {
+                            Some('}') => break,
+                            Some('{') | None => {
+                                return Err(format!(
+                                    "Invalid --format template: unclosed '{{{field}'"
+                                ));
+                            }
+                            Some(c) => field.push(c),
+                        }
+                    }
+                    let field = field.trim();
+                    if field.is_empty() {
+                        return Err("Invalid --format template: empty '{}'".to_string());
+                    }
+                    if !text.is_empty() {
+                        parts.push(Part::Text(std::mem::take(&mut text)));
+                    }
+                    parts.push(Part::Field(field.to_string()));
+                }
+                '}' => {
+                    return Err(
+                        "Invalid --format template: unmatched '}' (write '}}' for a brace)"
+                            .to_string(),
+                    );
+                }
+                '\\' if matches!(chars.peek(), Some('t' | 'n')) => {
+                    text.push(if chars.next() == Some('t') {
+                        '\t'
+                    } else {
+                        '\n'
+                    });
+                }
+                c => text.push(c),
+            }
+        }
+        if !text.is_empty() {
+            parts.push(Part::Text(text));
+        }
+        Ok(Self { parts })
+    }
+
+    /// The line of one result item.
+    pub fn render(&self, item: &Value) -> String {
+        let mut line = String::new();
+        for part in &self.parts {
+            match part {
+                Part::Text(text) => line.push_str(text),
+                Part::Field(field) => {
+                    if let Some(value) = field_value(item, field) {
+                        line.push_str(&value);
+                    }
+                }
+            }
+        }
+        line
+    }
+
+    /// The lines of the items of an envelope's `data`: each element of an
+    /// array, else `data` itself, none when it is null.
+    pub fn render_data(&self, data: &Value) -> String {
+        let items = match data {
+            Value::Null => &[][..],
+            Value::Array(items) => items.as_slice(),
+            item => std::slice::from_ref(item),
+        };
+        items
+            .iter()
+            .map(|item| self.render(item))
+            .collect::<Vec<_>>()
+            .join("\n")
+    }
+}
+
+/// `field` of `item` as printed, with the names shared across result types
+fn field_value(item: &Value, field: &str) -> Option<String> {
+    // The first of `keys` the item holds
+    let first = |keys: &[&str]| {
+        keys.iter()
+            .find_map(|key| lookup(item, key).filter(|value| !value.is_null()))
+    };
+    let value = match field {
+        "file" => first(&["file_path", "file", "path"])?,
+        "line" => {
+            if let Some(line) = first(&["line"]) {
+                line
+            } else {
+                let start = first(&["range.start_line"])?.as_u64()?;
+                return Some((start + 1).to_string());
+            }
+        }
+        "column" => first(&["column", "range.start_column"])?,
+        "id" => first(&["symbol_id", "id"])?,
+        "doc" => first(&["doc_comment", "doc"])?,
+        "module" => first(&["module_path", "module"])?,
+        "doc_summary" => {
+            let doc = first(&["doc_comment", "doc"])?;
+            return crate::mcp::service::doc_summary(doc.as_str()?).map(str::to_string);
+        }
+        _ => first(&[field])?,
+    };
+    Some(match value {
+        Value::String(text) => text.clone(),
+        other => other.to_string(),
+    })
+}
+
+/// The value at the dotted `path` of `item`, else of its `symbol`
+fn lookup<'a>(item: &'a Value, path: &str) -> Option<&'a Value> {
+    let at = |root: &'a Value| path.split('.').try_fold(root, |value, key| value.get(key));
+    at(item).or_else(|| item.get("symbol").and_then(at))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    #[test]
+    fn test_render_shared_and_nested_fields() {
+        let template =
+            OutputTemplate::parse("{file}:{line} {kind} {name} — {doc_summary}").unwrap();
+        let context = json!({
+            "symbol": {
+                "name": "parse",
+                "kind": "Function",
+                "range": { "start_line": 9, "start_column": 4 },
+                "doc_comment": "Parse a file.\n\nMore detail.",
+            },
+            "file_path": "src/parse.rs",
+        });
+        assert_eq!(
+            template.render(&context),
+            "src/parse.rs:10 Function parse — Parse a file."
+        );
+
+        let search = json!({ "name": "parse", "file_path": "src/parse.rs", "line": 10 });
+        let template = OutputTemplate::parse("{{{name}}}\\t{line}{missing}").unwrap();
+        assert_eq!(template.render(&search), "{parse}\t10");
+        assert_eq!(
+            template.render_data(&json!([search, search])),
+            "{parse}\t10\n{parse}\t10"
+        );
+    }
+
+    #[test]
+    fn test_parse_refuses_unbalanced_braces() {
+        assert!(OutputTemplate::parse("{name").is_err());
+        assert!(OutputTemplate::parse("name}").is_err());
+        assert!(OutputTemplate::parse("{}").is_err());
+    }
+}
@@ -4,24 +4,32 @@
 // extern crate tree_sitter_kotlin;
 extern crate tree_sitter_kotlin_codanna as tree_sitter_kotlin;
 
+pub mod analysis;
+pub mod archive;
 pub mod cli;
 pub mod config;
 pub mod display;
 pub mod documents;
 pub mod error;
+pub mod export;
+pub mod feedback;
 pub mod git;
 pub mod indexing;
 pub mod init;
 pub mod io;
 pub mod logging;
+pub mod lsp;
 pub mod mcp;
 pub mod parsing;
 pub mod plugins;
 pub mod profiles;
 pub mod project_resolver;
+pub mod queries;
 pub mod relationship;
 pub mod retrieve;
 pub mod semantic;
+pub mod shard;
+pub mod snapshot;
 pub mod storage;
 pub mod symbol;
 pub mod types;
@@ -20,6 +20,19 @@
 //! RUST_LOG=debug codanna index
 //! RUST_LOG=cli=debug,indexer=trace codanna mcp
 //! ```
+//!
+//! # Structured logs and tracing
+//!
+//! `format = "json"` writes every event as a JSON object with its fields and
+//! the spans it happened in. Built with the `otel` feature, the spans of
+//! indexing runs (each pipeline phase and stage) and of MCP tool calls are
+//! exported over OTLP/HTTP when a collector is named:
+//!
+//! ```toml
+//! [logging]
+//! format = "json"
+//! otlp_endpoint = "http://localhost:4318"
+//! ```
 
 use std::sync::Once;
 use tracing_subscriber::fmt::time::FormatTime;
@@ -27,7 +40,7 @@ use tracing_subscriber::layer::SubscriberExt;
 use tracing_subscriber::util::SubscriberInitExt;
 use tracing_subscriber::{EnvFilter, Layer};
 
-use crate::config::LoggingConfig;
+use crate::config::{LogFormat, LoggingConfig};
 
 static INIT: Once = Once::new();
 
@@ -79,17 +92,133 @@ pub fn init_with_config(config: &LoggingConfig) {
             EnvFilter::new(&filter_str)
         };
 
-        let fmt_layer = tracing_subscriber::fmt::layer()
-            .with_writer(std::io::stderr)
-            .with_target(true) // Show target for filtering visibility
-            .with_timer(CompactTime)
-            .with_level(true)
-            .with_filter(filter);
+        let fmt_layer = match config.format {
+            LogFormat::Compact => tracing_subscriber::fmt::layer()
+                .with_writer(std::io::stderr)
+                .with_target(true) // Show target for filtering visibility
+                .with_timer(CompactTime)
+                .with_level(true)
+                .with_filter(filter)
+                .boxed(),
+            LogFormat::Json => tracing_subscriber::fmt::layer()
+                .json()
+                .with_writer(std::io::stderr)
+                .with_target(true)
+                .with_current_span(true)
+                .with_span_list(true)
+                .with_filter(filter)
+                .boxed(),
+        };
 
-        tracing_subscriber::registry().with(fmt_layer).init();
+        let registry = tracing_subscriber::registry().with(fmt_layer);
+        #[cfg(feature = "otel")]
+        let registry = registry.with(otel::layer(config));
+        registry.init();
     });
 }
 
+/// Export the spans not sent yet; call before the process exits
+pub fn shutdown() {
+    #[cfg(feature = "otel")]
+    otel::shutdown();
+}
+
+/// Span export over OTLP
+#[cfg(feature = "otel")]
+mod otel {
+    use std::sync::OnceLock;
+
+    use opentelemetry::trace::TracerProvider as _;
+    use opentelemetry_otlp::WithExportConfig;
+    use opentelemetry_sdk::trace::SdkTracerProvider;
+    use tracing_subscriber::registry::LookupSpan;
+    use tracing_subscriber::{EnvFilter, Layer};
+
+    use crate::config::LoggingConfig;
+
+    /// Spans exported: those of codanna, whatever the log level
+    const SPAN_FILTER: &str = "warn,codanna=info,pipeline=info";
+
+    static PROVIDER: OnceLock<SdkTracerProvider> = OnceLock::new();
+
+    /// The layer exporting spans, none without a collector to send them to
+    pub(super) fn layer<S>(config: &LoggingConfig) -> Option<impl Layer<S> + Send + Sync + use<S>>
+    where
+        S: tracing::Subscriber + for<'a> LookupSpan<'a>,
+    {
+        let endpoint = config
+            .otlp_endpoint
+            .as_deref()
+            .filter(|endpoint| !endpoint.is_empty());
+        if endpoint.is_none() && std::env::var_os("OTEL_EXPORTER_OTLP_ENDPOINT").is_none() {
+            return None;
+        }
+        let mut exporter = opentelemetry_otlp::SpanExporter::builder().with_http();
+        if let Some(endpoint) = endpoint {
+            exporter = exporter.with_endpoint(traces_url(endpoint));
+        }
+        let exporter = match exporter.build() {
+            Ok(exporter) => exporter,
+            Err(e) => {
+                eprintln!("Warning: spans are not exported: {e}");
+                return None;
+            }
+        };
+
+        let mut resource = opentelemetry_sdk::Resource::builder();
+        if std::env::var_os("OTEL_SERVICE_NAME").is_none() {
+            resource = resource.with_service_name("codanna");
+        }
+        let provider = SdkTracerProvider::builder()
+            .with_batch_exporter(exporter)
+            .with_resource(resource.build())
+            .build();
+        let tracer = provider.tracer("codanna");
+        let _ = PROVIDER.set(provider);
+        Some(
+            tracing_opentelemetry::layer()
+                .with_tracer(tracer)
+                .with_filter(EnvFilter::new(SPAN_FILTER)),
+        )
+    }
+
+    pub(super) fn shutdown() {
+        if let Some(provider) = PROVIDER.get() {
+            if let Err(e) = provider.shutdown() {
+                eprintln!("Warning: spans may not all be exported: {e}");
+            }
+        }
+    }
+
+    /// The traces URL of a collector: set in code, the endpoint is taken as
+    /// is, where the environment variable gets `/v1/traces` appended
+    pub(super) fn traces_url(endpoint: &str) -> String {
+        let endpoint = endpoint.trim_end_matches('/');
+        if endpoint.ends_with("/v1/traces") {
+            endpoint.to_string()
+        } else {
+            format!("{endpoint}/v1/traces")
+        }
+    }
+
+    #[cfg(test)]
+    mod tests {
+        use super::*;
+
+        #[test]
+        fn test_traces_url() {
+            assert_eq!(
+                traces_url("http://localhost:4318/"),
+                "http://localhost:4318/v1/traces"
+            );
+            assert_eq!(
+                traces_url("https://otel.example.com/v1/traces"),
+                "https://otel.example.com/v1/traces"
+            );
+        }
+    }
+}
+
 /// Initialize logging to stderr (for MCP stdio mode).
 ///
 /// Deprecated: All logging now goes to stderr by default.
@@ -0,0 +1,30 @@
+//! LSP (Language Server Protocol) server over the index
+//!
+//! `codanna lsp` gives editors definition, references, hover with doc
+//! comments, workspace symbols and call hierarchy for every language the
+//! index covers, from the same index the MCP tools read. It speaks the
+//! protocol on stdin and stdout; logs go to stderr.
+
+pub mod protocol;
+pub mod server;
+
+pub use server::LspServer;
+
+use crate::config::Settings;
+use crate::indexing::facade::IndexFacade;
+use crate::io::ExitCode;
+use std::sync::Arc;
+
+/// Serve `facade` over stdio until the client exits
+pub fn run_stdio(facade: IndexFacade, settings: Arc<Settings>) -> ExitCode {
+    let mut server = LspServer::new(facade, settings);
+    match server.run(std::io::stdin().lock(), std::io::stdout().lock()) {
+        Ok(true) => ExitCode::Success,
+        // Exit without shutdown, or the client went away
+        Ok(false) => ExitCode::GeneralError,
+        Err(e) => {
+            eprintln!("Error: LSP connection failed: {e}");
+            ExitCode::IoError
+        }
+    }
+}
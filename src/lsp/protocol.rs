@@ -0,0 +1,229 @@
+//! The base protocol and the few LSP structures the server speaks.
+//!
+//! Messages are JSON bodies behind a `Content-Length` header. Positions,
+//! ranges, locations and symbol kinds are built as JSON directly: the
+//! server answers a handful of requests and needs no crate of LSP types
+//! for them. Lines and characters are zero-based, as in the index; the
+//! characters of a line are counted as `char`s.
+
+use crate::{Range, SymbolKind};
+use serde_json::{Value, json};
+use std::io::{self, BufRead, Write};
+use std::path::{Path, PathBuf};
+
+/// JSON-RPC and LSP error codes
+pub const PARSE_ERROR: i64 = -32700;
+pub const INVALID_REQUEST: i64 = -32600;
+pub const METHOD_NOT_FOUND: i64 = -32601;
+pub const INVALID_PARAMS: i64 = -32602;
+pub const SERVER_NOT_INITIALIZED: i64 = -32002;
+
+/// The body of the next message on `reader`, none at the end of the input
+pub fn read_frame(reader: &mut impl BufRead) -> io::Result<Option<Vec<u8>>> {
+    let mut length = None;
+    let mut line = String::new();
+    loop {
+        line.clear();
+        if reader.read_line(&mut line)? == 0 {
+            return Ok(None);
+        }
+        let header = line.trim_end();
+        if header.is_empty() {
+            if length.is_some() {
+                break;
+            }
+            continue;
+        }
+        if let Some((name, value)) = header.split_once(':') {
+            if name.eq_ignore_ascii_case("content-length") {
+                length = value.trim().parse::<usize>().ok();
+            }
+        }
+    }
+    let mut body = vec![0; length.unwrap_or_default()];
+    reader.read_exact(&mut body)?;
+    Ok(Some(body))
+}
+
+/// Write `message` to `writer` behind its header
+pub fn write_message(writer: &mut impl Write, message: &Value) -> io::Result<()> {
+    let body = message.to_string();
+    write!(writer, "Content-Length: {}\r\n\r\n{body}", body.len())?;
+    writer.flush()
+}
+
+/// An LSP position
+pub fn position(line: u32, character: u32) -> Value {
+    json!({ "line": line, "character": character })
+}
+
+/// An LSP range from `start` to `end`, each a line and a character
+pub fn span(start: (u32, u32), end: (u32, u32)) -> Value {
+    json!({ "start": position(start.0, start.1), "end": position(end.0, end.1) })
+}
+
+/// The LSP range of a range of the index
+pub fn range(range: &Range) -> Value {
+    span(
+        (range.start_line, u32::from(range.start_column)),
+        (range.end_line, u32::from(range.end_column)),
+    )
+}
+
+/// An LSP location
+pub fn location(path: &Path, range: Value) -> Value {
+    json!({ "uri": path_to_uri(path), "range": range })
+}
+
+/// The line and character of an LSP position
+pub fn parse_position(position: &Value) -> Option<(u32, u32)> {
+    let line = position.get("line")?.as_u64()?;
+    let character = position.get("character")?.as_u64()?;
+    Some((u32::try_from(line).ok()?, u32::try_from(character).ok()?))
+}
+
+/// The LSP symbol kind closest to `kind`
+pub fn symbol_kind(kind: SymbolKind) -> u32 {
+    match kind {
+        SymbolKind::Module => 2,
+        SymbolKind::Class => 5,
+        SymbolKind::Method => 6,
+        SymbolKind::Field => 8,
+        SymbolKind::Enum => 10,
+        SymbolKind::Trait | SymbolKind::Interface => 11,
+        SymbolKind::Function | SymbolKind::Macro => 12,
+        SymbolKind::Variable | SymbolKind::Parameter => 13,
+        SymbolKind::Constant => 14,
+        SymbolKind::Given => 19,
+        SymbolKind::Struct => 23,
+        SymbolKind::TypeAlias => 26,
+    }
+}
+
+/// The path of a `file://` URI; none for another scheme or a remote host
+pub fn uri_to_path(uri: &str) -> Option<PathBuf> {
+    let rest = uri.strip_prefix("file://")?;
+    let rest = rest.strip_prefix("localhost").unwrap_or(rest);
+    if !rest.starts_with('/') {
+        return None;
+    }
+    let decoded = percent_decode(rest)?;
+    // file:///C:/src/main.rs
+    let drive = decoded.as_bytes().get(2) == Some(&b':');
+    if cfg!(windows) && drive {
+        return Some(PathBuf::from(&decoded[1..]));
+    }
+    Some(PathBuf::from(decoded))
+}
+
+/// The `file://` URI of an absolute path
+pub fn path_to_uri(path: &Path) -> String {
+    let text = path.to_string_lossy().replace('\\', "/");
+    let mut uri = String::from("file://");
+    if !text.starts_with('/') {
+        uri.push('/');
+    }
+    for byte in text.bytes() {
+        match byte {
+            b'A'..=b'Z' | b'a'..=b'z' | b'0'..=b'9' | b'-' | b'.' | b'_' | b'~' | b'/' => {
+                uri.push(char::from(byte));
+            }
+            _ => uri.push_str(&format!("%{byte:02X}")),
+        }
+    }
+    uri
+}
+
+fn percent_decode(text: &str) -> Option<String> {
+    let bytes = text.as_bytes();
+    let mut out = Vec::with_capacity(bytes.len());
+    let mut at = 0;
+    while at < bytes.len() {
+        if bytes[at] == b'%' {
+            let hex = text.get(at + 1..at + 3)?;
+            out.push(u8::from_str_radix(hex, 16).ok()?);
+            at += 3;
+        } else {
+            out.push(bytes[at]);
+            at += 1;
+        }
+    }
+    String::from_utf8(out).ok()
+}
+
+/// The identifier at `character` of `line`, or just before it, with the
+/// characters it starts and ends at
+pub fn word_at(line: &str, character: usize) -> Option<(usize, usize, String)> {
+    let chars: Vec<char> = line.chars().collect();
+    let is_word = |c: char| c.is_alphanumeric() || c == '_' || c == '$';
+    let mut at = character.min(chars.len());
+    if at == chars.len() || !is_word(chars[at]) {
+        if at > 0 && is_word(chars[at - 1]) {
+            at -= 1;
+        } else {
+            return None;
+        }
+    }
+    let start = (0..=at).rev().take_while(|&i| is_word(chars[i])).last()?;
+    let end = (at..chars.len())
+        .take_while(|&i| is_word(chars[i]))
+        .last()?
+        + 1;
+    let word: String = chars[start..end].iter().collect();
+    if word.starts_with(|c: char| c.is_ascii_digit()) {
+        return None;
+    }
+    Some((start, end, word))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_frames_round_trip() {
+        let mut out = Vec::new();
+        write_message(&mut out, &json!({ "jsonrpc": "2.0", "id": 1 })).unwrap();
+        write_message(&mut out, &json!({ "jsonrpc": "2.0", "method": "exit" })).unwrap();
+
+        let mut input = io::Cursor::new(out);
+        let first = read_frame(&mut input).unwrap().unwrap();
+        assert_eq!(
+            serde_json::from_slice::<Value>(&first).unwrap()["id"],
+            json!(1)
+        );
+        let second = read_frame(&mut input).unwrap().unwrap();
+        assert_eq!(
+            serde_json::from_slice::<Value>(&second).unwrap()["method"],
+            "exit"
+        );
+        assert!(read_frame(&mut input).unwrap().is_none());
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn test_uris_and_paths() {
+        let path = Path::new("/work/my project/src/lib.rs");
+        let uri = path_to_uri(path);
+        assert_eq!(uri, "file:///work/my%20project/src/lib.rs");
+        assert_eq!(uri_to_path(&uri).unwrap(), path);
+        assert_eq!(
+            uri_to_path("file://localhost/a/b.rs").unwrap(),
+            Path::new("/a/b.rs")
+        );
+        assert!(uri_to_path("untitled:Untitled-1").is_none());
+    }
+
+    #[test]
+    fn test_word_at() {
+        let line = "    let total = compute_sum(a, 42);";
+        assert_eq!(word_at(line, 18).unwrap().2, "compute_sum");
+        assert_eq!(
+            word_at(line, 26).unwrap(),
+            (16, 27, "compute_sum".to_string())
+        );
+        assert_eq!(word_at(line, 8).unwrap().2, "total");
+        assert!(word_at(line, 31).is_none());
+        assert!(word_at(line, 2).is_none());
+    }
+}
@@ -0,0 +1,579 @@
+//! The LSP server: editor requests answered from the index.
+//!
+//! The identifier under the cursor is matched to the symbols of the index
+//! in three steps, most precise first: a definition of that name at that
+//! line of the file, a call of that name the enclosing symbol makes on
+//! that line, and the symbols of that name the index knows, those of the
+//! same file or language before the rest. The index is the one the MCP
+//! agents use; a newer one saved by `codanna index` or the watcher is
+//! picked up between requests.
+
+use super::protocol::{
+    INVALID_PARAMS, INVALID_REQUEST, METHOD_NOT_FOUND, PARSE_ERROR, SERVER_NOT_INITIALIZED,
+    location, parse_position, path_to_uri, range, read_frame, span, symbol_kind, uri_to_path,
+    word_at, write_message,
+};
+use crate::analysis::breakdown::is_documentable;
+use crate::config::Settings;
+use crate::indexing::facade::IndexFacade;
+use crate::relationship::RelationshipMetadata;
+use crate::storage::{IndexLease, IndexMetadata, IndexPersistence};
+use crate::symbol::name_match::SearchMode;
+use crate::{FileId, Symbol, SymbolId};
+use serde_json::{Value, json};
+use std::collections::HashMap;
+use std::io::{self, BufRead, Write};
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+use std::time::{Duration, Instant};
+
+/// How often the index on disk is looked at for a newer save
+const RELOAD_CHECK: Duration = Duration::from_secs(2);
+
+/// Workspace symbols returned for a query
+const WORKSPACE_SYMBOLS: usize = 100;
+
+/// A request that failed: an error code and its message
+type RequestError = (i64, String);
+
+/// The state of one LSP session
+pub struct LspServer {
+    facade: IndexFacade,
+    settings: Arc<Settings>,
+    workspace_root: PathBuf,
+    /// The text of the open documents, by URI
+    documents: HashMap<String, String>,
+    /// When the loaded index was saved
+    loaded_at: u64,
+    checked_at: Instant,
+    initialized: bool,
+    shutdown: bool,
+}
+
+impl LspServer {
+    pub fn new(facade: IndexFacade, settings: Arc<Settings>) -> Self {
+        let workspace_root = settings
+            .workspace_root
+            .clone()
+            .or_else(|| std::env::current_dir().ok())
+            .unwrap_or_default();
+        let loaded_at = IndexMetadata::load(&settings.index_path)
+            .map(|metadata| metadata.last_modified)
+            .unwrap_or_default();
+        Self {
+            facade,
+            settings,
+            workspace_root,
+            documents: HashMap::new(),
+            loaded_at,
+            checked_at: Instant::now(),
+            initialized: false,
+            shutdown: false,
+        }
+    }
+
+    /// Serve the messages of `input` until `exit`; whether the client shut
+    /// the server down first, as it should
+    pub fn run(&mut self, mut input: impl BufRead, mut output: impl Write) -> io::Result<bool> {
+        while let Some(body) = read_frame(&mut input)? {
+            let message: Value = match serde_json::from_slice(&body) {
+                Ok(message) => message,
+                Err(e) => {
+                    let error = error_response(Value::Null, (PARSE_ERROR, e.to_string()));
+                    write_message(&mut output, &error)?;
+                    continue;
+                }
+            };
+            let method = message.get("method").and_then(Value::as_str);
+            let params = message.get("params").cloned().unwrap_or(Value::Null);
+            match (method, message.get("id").cloned()) {
+                (Some("exit"), _) => return Ok(self.shutdown),
+                (Some(method), Some(id)) => {
+                    let response = match self.request(method, params) {
+                        Ok(result) => json!({ "jsonrpc": "2.0", "id": id, "result": result }),
+                        Err(error) => error_response(id, error),
+                    };
+                    write_message(&mut output, &response)?;
+                }
+                (Some(method), None) => self.notification(method, params),
+                // A response to a request the server never sends
+                (None, _) => {}
+            }
+        }
+        Ok(false)
+    }
+
+    fn request(&mut self, method: &str, params: Value) -> Result<Value, RequestError> {
+        if method == "initialize" {
+            self.initialized = true;
+            return Ok(capabilities());
+        }
+        if !self.initialized {
+            return Err((SERVER_NOT_INITIALIZED, "initialize first".to_string()));
+        }
+        if self.shutdown {
+            return Err((INVALID_REQUEST, "the server is shutting down".to_string()));
+        }
+        self.reload_if_newer();
+        match method {
+            "shutdown" => {
+                self.shutdown = true;
+                Ok(Value::Null)
+            }
+            "textDocument/definition" => self.definition(&params),
+            "textDocument/references" => self.references(&params),
+            "textDocument/hover" => self.hover(&params),
+            "workspace/symbol" => self.workspace_symbols(&params),
+            "textDocument/prepareCallHierarchy" => self.prepare_call_hierarchy(&params),
+            "callHierarchy/incomingCalls" => self.incoming_calls(&params),
+            "callHierarchy/outgoingCalls" => self.outgoing_calls(&params),
+            _ => Err((METHOD_NOT_FOUND, format!("unsupported method '{method}'"))),
+        }
+    }
+
+    fn notification(&mut self, method: &str, params: Value) {
+        let uri = params["textDocument"]["uri"].as_str().map(str::to_string);
+        match (method, uri) {
+            ("textDocument/didOpen", Some(uri)) => {
+                if let Some(text) = params["textDocument"]["text"].as_str() {
+                    self.documents.insert(uri, text.to_string());
+                }
+            }
+            // Full sync: the last change is the whole text
+            ("textDocument/didChange", Some(uri)) => {
+                let changes = params["contentChanges"].as_array();
+                if let Some(text) = changes
+                    .and_then(|changes| changes.last())
+                    .and_then(|change| change["text"].as_str())
+                {
+                    self.documents.insert(uri, text.to_string());
+                }
+            }
+            ("textDocument/didClose", Some(uri)) => {
+                self.documents.remove(&uri);
+            }
+            _ => {}
+        }
+    }
+
+    /// Swap in the index on disk when it was saved after the loaded one
+    fn reload_if_newer(&mut self) {
+        if self.checked_at.elapsed() < RELOAD_CHECK {
+            return;
+        }
+        self.checked_at = Instant::now();
+        // Not halfway through another process's write
+        if IndexLease::is_held(&self.settings.index_path) {
+            return;
+        }
+        let Ok(metadata) = IndexMetadata::load(&self.settings.index_path) else {
+            return;
+        };
+        if metadata.last_modified <= self.loaded_at {
+            return;
+        }
+        let persistence = IndexPersistence::new(self.settings.index_path.clone());
+        match persistence.load_facade_lite(Arc::clone(&self.settings)) {
+            Ok(facade) => {
+                self.facade = facade;
+                self.loaded_at = metadata.last_modified;
+                crate::debug_event!(
+                    "lsp",
+                    "reloaded index",
+                    "{} symbols",
+                    self.facade.symbol_count()
+                );
+            }
+            Err(e) => tracing::warn!("[lsp] could not reload the index: {e}"),
+        }
+    }
+
+    fn definition(&self, params: &Value) -> Result<Value, RequestError> {
+        let locations: Vec<Value> = self
+            .symbols_at(params)?
+            .iter()
+            .map(|symbol| self.symbol_location(symbol))
+            .collect();
+        Ok(Value::from(locations))
+    }
+
+    fn references(&self, params: &Value) -> Result<Value, RequestError> {
+        let declarations = params["context"]["includeDeclaration"]
+            .as_bool()
+            .unwrap_or(true);
+        let mut locations = Vec::new();
+        for symbol in self.symbols_at(params)? {
+            if declarations {
+                locations.push(self.symbol_location(&symbol));
+            }
+            let sites = self
+                .facade
+                .get_calling_functions_with_metadata(symbol.id)
+                .into_iter()
+                .chain(self.facade.get_referencing_symbols_with_metadata(symbol.id));
+            for (from, metadata) in sites {
+                let range = site_range(&from, metadata.as_ref(), &symbol.name);
+                locations.push(location(&self.absolute(&from.file_path), range));
+            }
+        }
+        locations.dedup();
+        Ok(Value::from(locations))
+    }
+
+    fn hover(&self, params: &Value) -> Result<Value, RequestError> {
+        let symbols = self.symbols_at(params)?;
+        let Some(symbol) = symbols.first() else {
+            return Ok(Value::Null);
+        };
+        let language = symbol.language_id.map_or("", |id| id.as_str());
+        let header = symbol.signature.as_deref().map_or_else(
+            || format!("{:?} {}", symbol.kind, symbol.name),
+            str::to_string,
+        );
+        let mut value = format!("```{language}\n{header}\n```\n");
+        if let Some(doc) = symbol.doc_comment.as_deref() {
+            value.push_str(&format!("\n{doc}\n"));
+        }
+        value.push_str(&format!(
+            "\n*{}:{}*",
+            symbol.file_path,
+            symbol.range.start_line + 1
+        ));
+        if symbols.len() > 1 {
+            value.push_str(&format!(" (and {} more of this name)", symbols.len() - 1));
+        }
+        Ok(json!({ "contents": { "kind": "markdown", "value": value } }))
+    }
+
+    fn workspace_symbols(&self, params: &Value) -> Result<Value, RequestError> {
+        let query = params["query"].as_str().unwrap_or_default().trim();
+        if query.is_empty() {
+            return Ok(json!([]));
+        }
+        let results = self
+            .facade
+            .search_with_mode(
+                query,
+                SearchMode::Fuzzy,
+                WORKSPACE_SYMBOLS,
+                None,
+                None,
+                None,
+            )
+            .map_err(|e| (INVALID_PARAMS, e.to_string()))?;
+        let symbols: Vec<Value> = results
+            .iter()
+            .filter_map(|result| self.facade.get_symbol(result.symbol_id))
+            .filter(is_documentable)
+            .map(|symbol| {
+                json!({
+                    "name": &*symbol.name,
+                    "kind": symbol_kind(symbol.kind),
+                    "location": self.symbol_location(&symbol),
+                    "containerName": symbol.module_path.as_deref().unwrap_or_default(),
+                })
+            })
+            .collect();
+        Ok(Value::from(symbols))
+    }
+
+    fn prepare_call_hierarchy(&self, params: &Value) -> Result<Value, RequestError> {
+        let items: Vec<Value> = self
+            .symbols_at(params)?
+            .iter()
+            .map(|symbol| self.call_hierarchy_item(symbol))
+            .collect();
+        Ok(Value::from(items))
+    }
+
+    fn incoming_calls(&self, params: &Value) -> Result<Value, RequestError> {
+        let symbol = self.item_symbol(params)?;
+        let calls = group_sites(
+            self.facade.get_calling_functions_with_metadata(symbol.id),
+            |caller, metadata| site_range(caller, metadata, &symbol.name),
+        );
+        let calls: Vec<Value> = calls
+            .into_iter()
+            .map(|(caller, ranges)| {
+                json!({ "from": self.call_hierarchy_item(&caller), "fromRanges": ranges })
+            })
+            .collect();
+        Ok(Value::from(calls))
+    }
+
+    fn outgoing_calls(&self, params: &Value) -> Result<Value, RequestError> {
+        let symbol = self.item_symbol(params)?;
+        // The ranges are of the calls in the caller
+        let calls = group_sites(
+            self.facade.get_called_functions_with_metadata(symbol.id),
+            |callee, metadata| site_range(&symbol, metadata, &callee.name),
+        );
+        let calls: Vec<Value> = calls
+            .into_iter()
+            .map(|(callee, ranges)| {
+                json!({ "to": self.call_hierarchy_item(&callee), "fromRanges": ranges })
+            })
+            .collect();
+        Ok(Value::from(calls))
+    }
+
+    fn call_hierarchy_item(&self, symbol: &Symbol) -> Value {
+        json!({
+            "name": &*symbol.name,
+            "kind": symbol_kind(symbol.kind),
+            "detail": symbol.signature.as_deref().unwrap_or_default(),
+            "uri": path_to_uri(&self.absolute(&symbol.file_path)),
+            "range": range(&symbol.range),
+            "selectionRange": self.name_range(symbol),
+            "data": { "symbol_id": symbol.id.value() },
+        })
+    }
+
+    /// The symbol of the call hierarchy item of `params`
+    fn item_symbol(&self, params: &Value) -> Result<Symbol, RequestError> {
+        params["item"]["data"]["symbol_id"]
+            .as_u64()
+            .and_then(|id| u32::try_from(id).ok())
+            .and_then(SymbolId::new)
+            .and_then(|id| self.facade.get_symbol(id))
+            .ok_or_else(|| (INVALID_PARAMS, "unknown call hierarchy item".to_string()))
+    }
+
+    /// The symbols the identifier at the position of `params` refers to
+    fn symbols_at(&self, params: &Value) -> Result<Vec<Symbol>, RequestError> {
+        let uri = params["textDocument"]["uri"]
+            .as_str()
+            .ok_or_else(|| (INVALID_PARAMS, "textDocument.uri is missing".to_string()))?;
+        let (line, character) = parse_position(&params["position"])
+            .ok_or_else(|| (INVALID_PARAMS, "position is missing".to_string()))?;
+        let Some(path) = uri_to_path(uri) else {
+            return Ok(Vec::new());
+        };
+        let Some((_, _, word)) = self
+            .line_of(uri, &path, line)
+            .and_then(|text| word_at(&text, character as usize))
+        else {
+            return Ok(Vec::new());
+        };
+
+        let outline = self
+            .file_id(&path)
+            .map(|file_id| self.facade.get_file_outline(file_id))
+            .unwrap_or_default();
+        if let Some(defined) = outline
+            .iter()
+            .find(|symbol| *symbol.name == *word && symbol.range.start_line == line)
+        {
+            return Ok(vec![defined.clone()]);
+        }
+
+        // The innermost symbol around the cursor, and what it calls there
+        let enclosing = outline.iter().rev().find(|symbol| {
+            let range = &symbol.range;
+            let start = (range.start_line, u32::from(range.start_column));
+            let end = (range.end_line, u32::from(range.end_column));
+            start <= (line, character) && (line, character) <= end
+        });
+        if let Some(enclosing) = enclosing {
+            let mut called: Vec<Symbol> = self
+                .facade
+                .get_called_functions_with_metadata(enclosing.id)
+                .into_iter()
+                .filter(|(callee, metadata)| {
+                    *callee.name == *word && metadata.as_ref().and_then(|m| m.line) == Some(line)
+                })
+                .map(|(callee, _)| callee)
+                .collect();
+            called.dedup_by_key(|symbol| symbol.id);
+            if !called.is_empty() {
+                return Ok(called);
+            }
+        }
+
+        let candidates: Vec<Symbol> = self
+            .facade
+            .find_symbols_by_name(&word, None)
+            .into_iter()
+            .filter(|symbol| is_documentable(symbol) || outline.iter().any(|s| s.id == symbol.id))
+            .collect();
+        let language = outline.first().and_then(|symbol| symbol.language_id);
+        let in_file: Vec<Symbol> = candidates
+            .iter()
+            .filter(|symbol| outline.iter().any(|s| s.id == symbol.id))
+            .cloned()
+            .collect();
+        if !in_file.is_empty() {
+            return Ok(in_file);
+        }
+        let in_language: Vec<Symbol> = candidates
+            .iter()
+            .filter(|symbol| language.is_some() && symbol.language_id == language)
+            .cloned()
+            .collect();
+        if !in_language.is_empty() {
+            return Ok(in_language);
+        }
+        Ok(candidates)
+    }
+
+    /// The indexed file at `path`
+    fn file_id(&self, path: &Path) -> Option<FileId> {
+        if let Some(file_id) = self.facade.get_file_id_for_path(&path.to_string_lossy()) {
+            return Some(file_id);
+        }
+        let relative = path.strip_prefix(&self.workspace_root).ok()?;
+        match self
+            .facade
+            .find_indexed_files(&relative.to_string_lossy())
+            .as_slice()
+        {
+            [(file_id, _)] => Some(*file_id),
+            _ => None,
+        }
+    }
+
+    /// Line `line` of the document, as the editor has it or else on disk
+    fn line_of(&self, uri: &str, path: &Path, line: u32) -> Option<String> {
+        let text = match self.documents.get(uri) {
+            Some(text) => text.clone(),
+            None => crate::indexing::encoding::read_to_string(path).ok()?,
+        };
+        text.lines().nth(line as usize).map(str::to_string)
+    }
+
+    /// The path of a file of the index, made absolute against the workspace
+    fn absolute(&self, file_path: &str) -> PathBuf {
+        let path = Path::new(file_path);
+        if path.is_absolute() {
+            path.to_path_buf()
+        } else {
+            self.workspace_root.join(path)
+        }
+    }
+
+    fn symbol_location(&self, symbol: &Symbol) -> Value {
+        location(&self.absolute(&symbol.file_path), self.name_range(symbol))
+    }
+
+    /// The range of the name of `symbol` where it is defined, found on the
+    /// first line of its definition; its start when the name is not there
+    fn name_range(&self, symbol: &Symbol) -> Value {
+        let path = self.absolute(&symbol.file_path);
+        let line = symbol.range.start_line;
+        let start = u32::from(symbol.range.start_column);
+        let column = self
+            .line_of(&path_to_uri(&path), &path, line)
+            .and_then(|text| {
+                let chars: Vec<char> = text.chars().collect();
+                let name: Vec<char> = symbol.name.chars().collect();
+                (start as usize..chars.len())
+                    .find(|&at| chars[at..].starts_with(&name))
+                    .and_then(|at| u32::try_from(at).ok())
+            });
+        match column {
+            Some(column) => {
+                let end = column + symbol.name.chars().count() as u32;
+                span((line, column), (line, end))
+            }
+            None => span((line, start), (line, start)),
+        }
+    }
+}
+
+/// The range of the reference to `name` a metadata site points at in the
+/// file of `from`; the start of `from` when the index has no site
+fn site_range(from: &Symbol, metadata: Option<&RelationshipMetadata>, name: &str) -> Value {
+    let line = metadata.and_then(|metadata| metadata.line);
+    match line {
+        Some(line) => {
+            let column = metadata
+                .and_then(|metadata| metadata.column)
+                .map_or(0, u32::from);
+            let end = column + name.chars().count() as u32;
+            span((line, column), (line, end))
+        }
+        None => {
+            let start = (from.range.start_line, u32::from(from.range.start_column));
+            span(start, start)
+        }
+    }
+}
+
+/// The sites of `edges` grouped by the symbol at their other end, in the
+/// order the symbols first appear
+fn group_sites(
+    edges: Vec<(Symbol, Option<RelationshipMetadata>)>,
+    range: impl Fn(&Symbol, Option<&RelationshipMetadata>) -> Value,
+) -> Vec<(Symbol, Vec<Value>)> {
+    let mut groups: Vec<(Symbol, Vec<Value>)> = Vec::new();
+    for (symbol, metadata) in edges {
+        let site = range(&symbol, metadata.as_ref());
+        match groups.iter_mut().find(|(known, _)| known.id == symbol.id) {
+            Some((_, ranges)) => ranges.push(site),
+            None => groups.push((symbol, vec![site])),
+        }
+    }
+    groups
+}
+
+fn error_response(id: Value, (code, message): RequestError) -> Value {
+    json!({ "jsonrpc": "2.0", "id": id, "error": { "code": code, "message": message } })
+}
+
+fn capabilities() -> Value {
+    json!({
+        "capabilities": {
+            // Full text on open and change
+            "textDocumentSync": { "openClose": true, "change": 1 },
+            "definitionProvider": true,
+            "referencesProvider": true,
+            "hoverProvider": true,
+            "workspaceSymbolProvider": true,
+            "callHierarchyProvider": true,
+        },
+        "serverInfo": { "name": "codanna", "version": env!("CARGO_PKG_VERSION") },
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::{Range, SymbolKind};
+
+    fn symbol(id: u32, name: &str) -> Symbol {
+        Symbol::new(
+            SymbolId::new(id).unwrap(),
+            name,
+            SymbolKind::Function,
+            FileId::new(1).unwrap(),
+            Range::new(id, 0, id + 2, 1),
+        )
+        .with_file_path("src/lib.rs")
+    }
+
+    fn site(line: u32, column: u16) -> Option<RelationshipMetadata> {
+        Some(RelationshipMetadata {
+            line: Some(line),
+            column: Some(column),
+            context: None,
+            receiver: None,
+            static_call: false,
+        })
+    }
+
+    #[test]
+    fn test_sites_grouped_by_caller() {
+        let edges = vec![
+            (symbol(1, "parse"), site(4, 8)),
+            (symbol(2, "load"), None),
+            (symbol(1, "parse"), site(9, 12)),
+        ];
+        let groups = group_sites(edges, |from, metadata| site_range(from, metadata, "read"));
+
+        assert_eq!(groups.len(), 2);
+        assert_eq!(&*groups[0].0.name, "parse");
+        assert_eq!(groups[0].1, [span((4, 8), (4, 12)), span((9, 12), (9, 16))]);
+        // No site recorded: the start of the caller
+        assert_eq!(groups[1].1, [span((2, 0), (2, 0))]);
+    }
+}
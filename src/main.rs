@@ -4,7 +4,9 @@
 //! Uses the cli module for argument parsing and command definitions.
 
 use clap::Parser;
-use codanna::cli::{Cli, Commands, RetrieveQuery};
+use codanna::cli::{
+    AnalyzeTarget, Cli, Commands, IndexAction, QueryAction, RetrieveQuery, ServeAction,
+};
 use codanna::indexing::facade::{IndexFacade, format_semantic_status};
 use codanna::project_resolver::{
     providers::{
@@ -14,9 +16,10 @@ use codanna::project_resolver::{
     },
     registry::SimpleProviderRegistry,
 };
-use codanna::storage::{EMISSION_SEMANTICS_VERSION, IndexMetadata};
+use codanna::storage::lease::WRITER_WAIT;
+use codanna::storage::{EMISSION_SEMANTICS_VERSION, IndexLease, IndexMetadata};
 use codanna::{IndexPersistence, Settings};
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
 use std::sync::Arc;
 
 /// Create and populate the provider registry with all language providers.
@@ -203,13 +206,66 @@ fn create_facade_or_exit(settings: Arc<Settings>) -> IndexFacade {
     })
 }
 
+/// Take the writer lease of the index, waiting for the process holding it;
+/// exits naming that process if it keeps it
+fn writer_lease_or_exit(index_path: &Path, command: &str) -> IndexLease {
+    let waiting = |held: &codanna::storage::LeaseError| {
+        eprintln!("{held}; waiting up to {}s", WRITER_WAIT.as_secs());
+    };
+    IndexLease::acquire(index_path, command, WRITER_WAIT, waiting).unwrap_or_else(|e| {
+        eprintln!("Error: {e}");
+        eprintln!("Run the command again once it finishes");
+        std::process::exit(codanna::io::ExitCode::GeneralError as i32);
+    })
+}
+
 /// Entry point with tokio async runtime.
 ///
 /// Handles config initialization, index loading/creation, and command dispatch.
 /// Auto-initializes config for index command. Persists index after modifications.
 #[tokio::main]
 async fn main() {
-    let cli = Cli::parse();
+    let mut cli = Cli::parse();
+
+    // --jsonl streams the --json envelope of the commands that print one
+    if cli.jsonl {
+        if !cli.command.enable_json() {
+            eprintln!("Error: --jsonl is supported by retrieve, analyze and mcp");
+            std::process::exit(codanna::io::ExitCode::GeneralError as i32);
+        }
+        codanna::io::envelope::set_json_lines(true);
+    }
+
+    // --format prints the items of the same envelope through a template
+    if let Some(template) = cli.command.output_template() {
+        let template = match codanna::io::template::OutputTemplate::parse(template) {
+            Ok(template) => template,
+            Err(e) => {
+                eprintln!("Error: {e}");
+                std::process::exit(codanna::io::ExitCode::GeneralError as i32);
+            }
+        };
+        cli.command.enable_json();
+        codanna::io::template::set_output_template(template);
+    }
+
+    // --project runs the command in a project of the registry, as if from
+    // its directory
+    if let Some(name) = &cli.project {
+        let root = codanna::init::ProjectRegistry::load()
+            .and_then(|registry| registry.resolve(name).map(|(_, info)| info.path.clone()))
+            .unwrap_or_else(|e| {
+                eprintln!("Error: {e}");
+                std::process::exit(codanna::io::ExitCode::NotFound as i32);
+            });
+        if let Err(e) = std::env::set_current_dir(&root) {
+            eprintln!(
+                "Error: cannot enter project '{name}' at {}: {e}",
+                root.display()
+            );
+            std::process::exit(codanna::io::ExitCode::NotFound as i32);
+        }
+    }
 
     // For index command, auto-initialize if needed (but not when using --config)
     if matches!(cli.command, Commands::Index { .. }) && cli.config.is_none() {
@@ -226,7 +282,14 @@ async fn main() {
                 }
             }
         }
-    } else if !matches!(cli.command, Commands::Init { .. }) && cli.config.is_none() {
+    } else if !matches!(
+        cli.command,
+        Commands::Init { .. }
+            | Commands::Doctor { .. }
+            | Commands::Complete { .. }
+            | Commands::Projects { .. }
+    ) && cli.config.is_none()
+    {
         // For other commands without --config flag, just warn
         if let Err(warning) = Settings::check_init() {
             eprintln!("Warning: {warning}");
@@ -235,8 +298,9 @@ async fn main() {
     }
 
     // Load configuration
+    let profile = cli.profile.as_deref();
     let mut config = if let Some(config_path) = &cli.config {
-        Settings::load_from(config_path).unwrap_or_else(|e| {
+        Settings::load_from_profile(config_path, profile).unwrap_or_else(|e| {
             eprintln!(
                 "Configuration error loading from {}: {}",
                 config_path.display(),
@@ -245,24 +309,57 @@ async fn main() {
             std::process::exit(1);
         })
     } else {
-        Settings::load().unwrap_or_else(|e| {
+        Settings::load_profile(profile).unwrap_or_else(|e| {
             eprintln!("Configuration error: {e}");
+            // A profile asked for is not quietly left out
+            if profile.is_some() {
+                std::process::exit(1);
+            }
             Settings::default()
         })
     };
 
+    // Embedding and reranker models load from the bundle when one is set
+    if let Some(path) = config
+        .semantic_search
+        .resolved_model_path(config.workspace_root.as_deref())
+    {
+        codanna::init::set_model_path(path);
+    }
+
     // Initialize logging with config (supports RUST_LOG env var override)
     // All logging goes to stderr to avoid polluting stdout (JSON output, piping)
     codanna::logging::init_with_config(&config.logging);
 
+    // Register language plugins before any file is matched to a language
+    for error in codanna::parsing::plugin::load_language_plugins(&config) {
+        eprintln!("Warning: {error}");
+    }
+
     // Determine resource requirements based on command type
     // Commands are categorized by what infrastructure they need:
-    // - Thin: No index, no providers (Parse, McpTest, Benchmark)
+    // - Thin: No index, no providers (Parse, McpTest, Benchmark, Models)
     // - Config-only: Settings but no index (Init, Config, AddDir, RemoveDir, ListDirs, Plugin, Profile, Documents)
     // - Full: Index + providers (Retrieve, Mcp, Serve, Index)
     let needs_providers = !matches!(
         &cli.command,
-        Commands::Parse { .. } | Commands::McpTest { .. } | Commands::Benchmark { .. }
+        Commands::Parse { .. }
+            | Commands::McpTest { .. }
+            | Commands::Benchmark { project: false, .. }
+            | Commands::Embed { .. }
+            | Commands::Models { .. }
+            | Commands::Doctor { .. }
+            | Commands::Report { .. }
+            | Commands::Snapshot { .. }
+            | Commands::Shards { .. }
+            | Commands::Completions { .. }
+            | Commands::Complete { .. }
+            | Commands::Projects { .. }
+            | Commands::Lsp
+            | Commands::Serve {
+                action: Some(_),
+                ..
+            }
     );
 
     let needs_indexer = !matches!(
@@ -272,12 +369,39 @@ async fn main() {
             | Commands::Parse { .. }
             | Commands::McpTest { .. }
             | Commands::Benchmark { .. }
+            // Loads the index afresh on each pass over the queue
+            | Commands::Embed { .. }
+            | Commands::Models { .. }
             | Commands::AddDir { .. }
             | Commands::RemoveDir { .. }
             | Commands::ListDirs
             | Commands::Plugin { .. }
             | Commands::Documents { .. }
             | Commands::Profile { .. }
+            | Commands::Projects { .. }
+            // Read the files of the index, not the index
+            | Commands::Report { .. }
+            | Commands::Snapshot { .. }
+            // Opens the index of every shard
+            | Commands::Shards { .. }
+            // Open the index themselves: a broken one is reported, or
+            // completes nothing
+            | Commands::Doctor { .. }
+            | Commands::Completions { .. }
+            | Commands::Complete { .. }
+            | Commands::Serve {
+                action: Some(_),
+                ..
+            }
+            | Commands::Query {
+                action: QueryAction::Save { .. }
+                    | QueryAction::List { .. }
+                    | QueryAction::Delete { .. }
+            }
+            // Snapshots get an index of their own
+            | Commands::Index { rev: Some(_), .. }
+            // Workers parse for the coordinator's index
+            | Commands::Index { worker: Some(_), .. }
     );
 
     // Initialize project resolution providers (only if needed)
@@ -310,16 +434,76 @@ async fn main() {
     {
         config.indexing.parallelism = *t;
     }
+    if let Commands::Index {
+        max_memory: Some(mb),
+        ..
+    } = &cli.command
+    {
+        config.indexing.max_memory_mb = *mb;
+    }
+    if let Commands::Index {
+        listen: Some(addr), ..
+    } = &cli.command
+    {
+        config.indexing.listen = Some(addr.clone());
+    }
+    if let Commands::Index {
+        deterministic: true,
+        ..
+    } = &cli.command
+    {
+        config.indexing.deterministic = true;
+    }
+    if let Commands::Index {
+        background_embed: true,
+        ..
+    } = &cli.command
+    {
+        config.semantic_search.background = true;
+    }
 
     // Set up persistence based on config
     // Use global path resolution that handles --config properly
-    let index_path = codanna::init::resolve_index_path(&config, cli.config.as_deref());
+    let mut index_path = codanna::init::resolve_index_path(&config, cli.config.as_deref());
 
     // Update the config with the resolved index_path so SimpleIndexer uses the correct path
     config.index_path = index_path.clone();
 
+    // A shard is indexed and queried like the main index, from an index
+    // and indexed paths of its own
+    if let Some(name) = &cli.shard {
+        if matches!(&cli.command, Commands::Index { paths, .. } if !paths.is_empty()) {
+            eprintln!("Error: a shard indexes the subtree it is configured with; drop the paths");
+            std::process::exit(1);
+        }
+        if matches!(&cli.command, Commands::Shards { .. }) {
+            eprintln!("Error: 'shards' reads every shard; drop --shard");
+            std::process::exit(1);
+        }
+        config = codanna::shard::shard_settings(&config, name).unwrap_or_else(|e| {
+            eprintln!("Error: {e}");
+            std::process::exit(1);
+        });
+        index_path = config.index_path.clone();
+    }
+
     let persistence = IndexPersistence::new(index_path.clone());
 
+    // An index run is the only writer of the semantic store while it runs;
+    // it starts the background embedding again once it has saved
+    if matches!(
+        cli.command,
+        Commands::Index {
+            dry_run: false,
+            action: None,
+            rev: None,
+            worker: None,
+            ..
+        }
+    ) {
+        codanna::cli::commands::embed::stop_background(&config);
+    }
+
     // Determine if we need full trait resolver initialization
     // Only needed for trait-related commands: implementations, trait analysis, etc.
     let needs_trait_resolver = matches!(
@@ -333,12 +517,22 @@ async fn main() {
 
     // Determine if we need semantic search (ML model loading)
     // Retrieve commands use Tantivy text search only - no ML model needed
+    // Only these MCP tools need semantic search
+    use codanna::cli::commands::mcp::SEMANTIC_TOOLS;
     let needs_semantic_search = match &cli.command {
-        Commands::Mcp { tool, .. } => {
-            // Only these MCP tools need semantic search
-            ["semantic_search_docs", "semantic_search_with_context"].contains(&tool.as_str())
-        }
+        Commands::Mcp { tool, .. } => SEMANTIC_TOOLS.contains(&tool.as_str()),
+        Commands::Query {
+            action: QueryAction::Run { name, .. },
+        } => codanna::cli::commands::query::saved_query(&config, name)
+            .is_some_and(|saved| SEMANTIC_TOOLS.contains(&saved.call.tool.as_str())),
         Commands::Index { .. } | Commands::Serve { .. } => true,
+        // Compares the stored embeddings
+        Commands::Analyze {
+            analysis: AnalyzeTarget::Duplicates { .. },
+        } => true,
+        Commands::Retrieve {
+            query: RetrieveQuery::Similar { .. },
+        } => true,
         _ => false,
     };
 
@@ -359,7 +553,14 @@ async fn main() {
         if stored != Some(EMISSION_SEMANTICS_VERSION) {
             let stored_txt = stored.map_or_else(|| "none".to_string(), |v| format!("v{v}"));
             let current = EMISSION_SEMANTICS_VERSION;
-            if matches!(cli.command, Commands::Index { dry_run: false, .. }) {
+            if matches!(
+                cli.command,
+                Commands::Index {
+                    dry_run: false,
+                    action: None,
+                    ..
+                }
+            ) {
                 eprintln!(
                     "Index emission semantics changed (index: {stored_txt}, binary: v{current}). Rebuilding from scratch."
                 );
@@ -382,6 +583,8 @@ async fn main() {
                     Commands::Serve {
                         http: false,
                         https: false,
+                        ws: false,
+                        sse: false,
                         ..
                     }
                 ) && config.server.mode != "http"
@@ -393,6 +596,81 @@ async fn main() {
         }
     }
 
+    // A run interrupted after committing files leaves a checkpoint of
+    // their unresolved relationships, which the next run resolves. That is
+    // what `--resume` asks for; any other index run rebuilds, as the run
+    // died before it finished.
+    let mut checkpoint_heal = false;
+    if let Commands::Index {
+        resume,
+        force: false,
+        dry_run: false,
+        rev: None,
+        action: None,
+        worker: None,
+        ..
+    } = &cli.command
+    {
+        let interrupted = persistence.exists() && persistence.has_checkpoint();
+        if interrupted && !resume && !emission_heal {
+            eprintln!(
+                "The last indexing run was interrupted. Rebuilding from scratch (use --resume to continue it)."
+            );
+            checkpoint_heal = true;
+        } else if interrupted && *resume {
+            eprintln!("Resuming the interrupted indexing run.");
+        } else if *resume {
+            eprintln!("No interrupted indexing run to resume.");
+        }
+    }
+
+    // A text-mode mcp call goes to the warm background server when one is
+    // asked for, before this process opens the index itself
+    #[cfg(unix)]
+    if let Commands::Mcp {
+        tool,
+        positional,
+        args,
+        json: false,
+        fields: None,
+        watch: false,
+        relevant,
+        irrelevant,
+        ..
+    } = &cli.command
+    {
+        // Marking results writes the project's feedback, so runs here
+        let marks = !relevant.is_empty() || !irrelevant.is_empty();
+        if config.server.daemon && persistence.exists() && !marks {
+            let mut global_args: Vec<std::ffi::OsString> = Vec::new();
+            if let Some(path) = &cli.config {
+                global_args.extend(["--config".into(), path.into()]);
+            }
+            if let Some(profile) = &cli.profile {
+                global_args.extend(["--profile".into(), profile.into()]);
+            }
+            if let Some(shard) = &cli.shard {
+                global_args.extend(["--shard".into(), shard.into()]);
+            }
+            if let Some(exit_code) = codanna::cli::commands::mcp::run_on_daemon(
+                tool,
+                positional,
+                args.as_deref(),
+                &config,
+                &global_args,
+            )
+            .await
+            {
+                std::process::exit(exit_code);
+            }
+        }
+    }
+
+    // `codanna index` holds the writer lease from loading the index to
+    // saving it; other commands take it only to sync below
+    let index_lease = (needs_indexer && matches!(cli.command, Commands::Index { .. }))
+        .then(|| writer_lease_or_exit(&config.index_path, "codanna index"));
+
     // Load existing index or create new one (only if command needs it)
     let settings = Arc::new(config.clone());
     let mut indexer: Option<IndexFacade> = if !needs_indexer {
@@ -400,8 +678,9 @@ async fn main() {
     } else {
         Some({
             // Force flag always means fresh index, regardless of path source (CLI or settings.toml)
-            let force_recreate_index =
-                matches!(cli.command, Commands::Index { force: true, .. }) || emission_heal;
+            let force_recreate_index = matches!(cli.command, Commands::Index { force: true, .. })
+                || emission_heal
+                || checkpoint_heal;
             if persistence.exists() && !force_recreate_index {
                 tracing::debug!(target: "cli", "found existing index at {}", config.index_path.display());
                 // Use lazy loading for simple commands to improve startup time
@@ -435,7 +714,11 @@ async fn main() {
                     }
                 }
             } else {
-                if force_recreate_index && persistence.exists() && !emission_heal {
+                if force_recreate_index
+                    && persistence.exists()
+                    && !emission_heal
+                    && !checkpoint_heal
+                {
                     eprintln!("Force re-indexing requested, creating new index");
                 } else if !persistence.exists() {
                     tracing::debug!(
@@ -469,6 +752,34 @@ async fn main() {
         None
     };
 
+    // Embeddings of a model other than the configured one are dropped, and
+    // the index run embeds every doc comment again with the configured model
+    let mut reembed = false;
+    if let Some(ref mut idx) = indexer {
+        if config.semantic_search.enabled
+            && idx.is_semantic_incompatible()
+            && matches!(
+                cli.command,
+                Commands::Index {
+                    dry_run: false,
+                    action: None,
+                    ..
+                }
+            )
+        {
+            match idx.reset_semantic_search() {
+                Ok(()) => {
+                    eprintln!(
+                        "Embedding model changed: re-embedding doc comments with {}",
+                        codanna::indexing::facade::configured_model_name(&config.semantic_search)
+                    );
+                    reembed = true;
+                }
+                Err(e) => eprintln!("Warning: Failed to reset semantic search: {e}"),
+            }
+        }
+    }
+
     if let Some(ref mut idx) = indexer {
         // Only enable semantic search for commands that need it
         if needs_semantic_search
@@ -488,8 +799,9 @@ async fn main() {
     // Sync indexed paths with config - auto-index new directories
     // This handles changes made while the index was not in use (e.g., add-dir command)
     // Skip sync if force flag is present (force means fresh start, not incremental)
-    let is_force_index =
-        matches!(cli.command, Commands::Index { force: true, .. }) || emission_heal;
+    let is_force_index = matches!(cli.command, Commands::Index { force: true, .. })
+        || emission_heal
+        || checkpoint_heal;
 
     // Progress is enabled by default from settings, can be disabled with --no-progress
     let no_progress_flag = matches!(
@@ -590,8 +902,25 @@ async fn main() {
     // Track whether sync made changes (for later check); None means sync did not run
     let mut sync_made_changes: Option<bool> = None;
 
+    // A command other than `codanna index` syncs only when no other process
+    // is writing the index; that writer keeps it current
+    let sync_lease = if index_lease.is_none() && indexer.is_some() && persistence.exists() {
+        match IndexLease::try_acquire(&config.index_path, "settings sync") {
+            Ok(lease) => Some(lease),
+            Err(e) => {
+                tracing::info!(target: "sync", "skipping sync: {e}");
+                None
+            }
+        }
+    } else {
+        None
+    };
+
     if let Some(ref mut idx) = indexer {
-        if persistence.exists() && !is_force_index {
+        if persistence.exists()
+            && !is_force_index
+            && (index_lease.is_some() || sync_lease.is_some())
+        {
             // Load stored indexed_paths from metadata
             match IndexMetadata::load(&config.index_path) {
                 Ok(metadata) => {
@@ -675,6 +1004,11 @@ async fn main() {
             }
         }
     }
+    if let Some(lease) = sync_lease {
+        if sync_made_changes != Some(true) {
+            lease.release_unchanged();
+        }
+    }
 
     match cli.command {
         Commands::Init { force } => {
@@ -721,10 +1055,25 @@ async fn main() {
         }
 
         Commands::Serve {
+            action: Some(ServeAction::Token { action }),
+            ..
+        } => {
+            let exit_code = codanna::cli::commands::serve::run_token(action, cli.config.as_deref());
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Serve {
+            action: None,
             watch,
             watch_interval,
             http,
             https,
+            ws,
+            sse,
+            uds,
+            idle_timeout,
+            read_only,
+            projects,
             bind,
         } => {
             use codanna::cli::commands::serve::{ServeArgs, run as run_serve};
@@ -734,6 +1083,12 @@ async fn main() {
                     watch_interval,
                     http,
                     https,
+                    ws,
+                    sse,
+                    uds,
+                    idle_timeout,
+                    read_only,
+                    projects,
                     bind,
                 },
                 config,
@@ -744,12 +1099,41 @@ async fn main() {
             .await;
         }
 
+        Commands::Index {
+            action: Some(IndexAction::Compact),
+            ..
+        } => {
+            codanna::cli::commands::index::run_compact(
+                indexer.as_ref().expect("index compact requires indexer"),
+                &persistence,
+                &config,
+            );
+        }
+
+        Commands::Index {
+            worker: Some(addr), ..
+        } => {
+            codanna::cli::commands::index::run_worker(&addr, &config);
+        }
+
+        Commands::Index {
+            no_progress,
+            rev: Some(rev),
+            name,
+            ..
+        } => {
+            let progress = config.indexing.show_progress && !no_progress;
+            codanna::cli::commands::index::run_rev(&rev, name, progress, &config);
+        }
+
         Commands::Index {
             paths,
             force,
             no_progress,
             dry_run,
             max_files,
+            since,
+            json,
             ..
         } => {
             use codanna::cli::commands::index::{IndexArgs, run as run_index};
@@ -762,7 +1146,11 @@ async fn main() {
                     progress,
                     dry_run,
                     max_files,
+                    since,
+                    json,
                     cli_config: cli.config.clone(),
+                    shard: cli.shard.clone(),
+                    reembed,
                 },
                 &mut config,
                 indexer.as_mut().expect("index requires indexer"),
@@ -771,6 +1159,35 @@ async fn main() {
             );
         }
 
+        Commands::Embed { status, json } => {
+            let exit_code = codanna::cli::commands::embed::run(status, json, &config);
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Models { action } => {
+            let exit_code = codanna::cli::commands::models::run(action, &config);
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Query {
+            action: QueryAction::Run { name, json, fields },
+        } => {
+            let exit_code = codanna::cli::commands::query::run_saved(
+                &name,
+                json,
+                fields,
+                indexer.expect("query run requires indexer"),
+                &config,
+            )
+            .await;
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Query { action } => {
+            let exit_code = codanna::cli::commands::query::run(action, &config);
+            std::process::exit(exit_code as i32);
+        }
+
         Commands::AddDir { path } => {
             codanna::cli::commands::directories::run_add_dir(path, cli.config.as_deref());
         }
@@ -783,7 +1200,19 @@ async fn main() {
             codanna::cli::commands::directories::run_list_dirs(&config);
         }
 
-        Commands::Retrieve { query } => {
+        Commands::Retrieve {
+            query: RetrieveQuery::Batch { input },
+        } => {
+            let exit_code = codanna::cli::commands::retrieve::run_batch(
+                &input,
+                indexer.expect("retrieve batch requires indexer"),
+                &config,
+            )
+            .await;
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Retrieve { query, .. } => {
             let exit_code = codanna::cli::commands::retrieve::run(
                 query,
                 indexer.as_ref().expect("retrieve requires indexer"),
@@ -791,6 +1220,135 @@ async fn main() {
             std::process::exit(exit_code as i32);
         }
 
+        Commands::Analyze { analysis } => {
+            let exit_code = codanna::cli::commands::analyze::run(
+                analysis,
+                indexer.as_ref().expect("analyze requires indexer"),
+            );
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Export { target } => {
+            let exit_code = codanna::cli::commands::export::run(
+                target,
+                indexer.as_ref().expect("export requires indexer"),
+            );
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Import { target } => {
+            let mut indexer = indexer.expect("import requires indexer");
+            let exit_code = codanna::cli::commands::import::run(target, &mut indexer, &persistence);
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Stats {
+            metrics,
+            by,
+            depth,
+            path,
+            lang,
+            limit,
+            json,
+        } => {
+            let exit_code = codanna::cli::commands::stats::run(
+                metrics,
+                &by,
+                depth,
+                path,
+                lang,
+                limit,
+                json,
+                indexer.as_ref().expect("stats requires indexer"),
+            );
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Check {
+            min_doc_coverage,
+            max_cycles,
+            max_undocumented_public,
+            path,
+            lang,
+            json,
+        } => {
+            let flags = codanna::config::CheckConfig {
+                min_doc_coverage,
+                max_cycles,
+                max_undocumented_public,
+            };
+            let exit_code = codanna::cli::commands::check::run(
+                flags,
+                &config.analysis.check,
+                path,
+                lang,
+                json,
+                indexer.as_ref().expect("check requires indexer"),
+            );
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Completions { shell } => {
+            let exit_code = codanna::cli::commands::complete::run_script(&shell);
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Complete { words } => {
+            let exit_code = codanna::cli::commands::complete::run(&words, &config);
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Browse { query, lang } => {
+            let exit_code = codanna::cli::commands::browse::run(
+                query,
+                lang,
+                indexer.as_ref().expect("browse requires indexer"),
+            );
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Snapshot { action } => {
+            let exit_code = codanna::cli::commands::snapshot::run(action, &config);
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Shards { action } => {
+            let exit_code = codanna::cli::commands::shards::run(action, &config);
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Report { action } => {
+            let exit_code = codanna::cli::commands::report::run(action, &config);
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Doctor { json } => {
+            let exit_code =
+                codanna::cli::commands::doctor::run(&config, cli.config.as_deref(), json);
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Diff {
+            from,
+            to,
+            relations,
+            path,
+            lang,
+            json,
+        } => {
+            let exit_code = codanna::cli::commands::diff::run(
+                from,
+                to,
+                relations,
+                path,
+                lang,
+                json,
+                &config,
+                indexer.as_ref().expect("diff requires indexer"),
+            );
+            std::process::exit(exit_code as i32);
+        }
+
         Commands::Mcp {
             tool,
             positional,
@@ -798,13 +1356,24 @@ async fn main() {
             json,
             fields,
             watch,
+            relevant,
+            irrelevant,
+            ..
         } => {
             let mut indexer = indexer.expect("mcp requires indexer");
 
             // If --watch is enabled, check for file changes and reindex
             if watch {
                 let paths = config.get_indexed_paths();
-                if !paths.is_empty() {
+                let lease = if paths.is_empty() {
+                    None
+                } else {
+                    Some(writer_lease_or_exit(
+                        &config.index_path,
+                        "codanna mcp --watch",
+                    ))
+                };
+                if let Some(lease) = lease {
                     let mut total_indexed = 0usize;
                     for path in &paths {
                         if path.is_dir() {
@@ -827,17 +1396,40 @@ async fn main() {
                         if let Err(e) = persistence.save_facade(&indexer) {
                             tracing::warn!(target: "mcp", "failed to save index after watch reindex: {e}");
                         }
+                    } else {
+                        lease.release_unchanged();
                     }
                 }
             }
 
+            if !relevant.is_empty() || !irrelevant.is_empty() {
+                codanna::cli::commands::mcp::record_feedback(
+                    &tool,
+                    &positional,
+                    args.as_deref(),
+                    &relevant,
+                    &irrelevant,
+                    json,
+                    &indexer,
+                );
+            }
+
             codanna::cli::commands::mcp::run(
                 tool, positional, args, json, fields, indexer, &config,
             )
             .await;
         }
 
-        Commands::Benchmark { language, file } => {
+        Commands::Benchmark {
+            language,
+            file,
+            project,
+            json,
+        } => {
+            if project {
+                let exit_code = codanna::cli::commands::benchmark::run_project(&config, json);
+                std::process::exit(exit_code as i32);
+            }
             codanna::cli::commands::benchmark::run(&language, file);
         }
 
@@ -852,7 +1444,27 @@ async fn main() {
         Commands::Profile { action } => {
             codanna::cli::commands::profile::run(action);
         }
+
+        Commands::Lsp => {
+            let indexer = indexer.expect("lsp requires indexer");
+            if !persistence.exists() {
+                eprintln!(
+                    "Warning: no index at {}; run 'codanna index' to build it",
+                    config.index_path.display()
+                );
+            }
+            let exit_code = codanna::lsp::run_stdio(indexer, settings.clone());
+            std::process::exit(exit_code as i32);
+        }
+
+        Commands::Projects { action } => {
+            let exit_code = codanna::cli::commands::projects::run(action);
+            std::process::exit(exit_code as i32);
+        }
     }
+
+    // Spans of the run still batched for export
+    codanna::logging::shutdown();
 }
 
 #[cfg(test)]
@@ -0,0 +1,209 @@
+//! Bearer-token authentication for the HTTP and HTTPS servers
+//!
+//! Tokens are listed under `[[server.tokens]]` in settings, each with a name
+//! and either the token or its SHA-256. Once any is listed, every MCP
+//! request must carry one of them as `Authorization: Bearer <token>`, on
+//! `/mcp` as on the WebSocket and SSE endpoints; the built-in OAuth flow of
+//! HTTP mode no longer grants access. Tokens are compared by digest, in
+//! constant time, and each request is logged with the name of its token.
+//! Edits to the list apply as settings.toml is saved; listing the first
+//! token or removing the last takes a restart.
+
+use sha2::{Digest, Sha256};
+
+use crate::config::ServerToken;
+
+/// Start of every token `codanna serve token create` makes
+pub const TOKEN_PREFIX: &str = "cdn_";
+
+/// A new random token
+pub fn generate_token() -> String {
+    use rand::RngExt;
+    let bytes: [u8; 32] = rand::rng().random();
+    format!("{TOKEN_PREFIX}{}", hex::encode(bytes))
+}
+
+/// Hex SHA-256 of `token`, as `sha256` in settings holds it
+pub fn token_digest(token: &str) -> String {
+    hex::encode(Sha256::digest(token.as_bytes()))
+}
+
+fn digest(token: &str) -> [u8; 32] {
+    let mut digest = [0u8; 32];
+    digest.copy_from_slice(&Sha256::digest(token.as_bytes()));
+    digest
+}
+
+/// The tokens the server accepts
+#[derive(Debug, Default)]
+pub struct TokenVerifier {
+    tokens: std::sync::RwLock<Vec<(String, [u8; 32])>>,
+}
+
+impl TokenVerifier {
+    /// Verifier of the tokens in settings. Entries with neither a token nor
+    /// a valid digest are left out with a warning.
+    pub fn from_config(tokens: &[ServerToken]) -> Self {
+        Self {
+            tokens: std::sync::RwLock::new(expected_digests(tokens)),
+        }
+    }
+
+    /// Accept the tokens in settings from now on, in place of the ones before
+    pub fn reload(&self, tokens: &[ServerToken]) {
+        *self.tokens.write().unwrap() = expected_digests(tokens);
+    }
+
+    /// Whether any token is required
+    pub fn is_enabled(&self) -> bool {
+        !self.tokens.read().unwrap().is_empty()
+    }
+
+    /// Name of the token `presented` is, None when it is none of them.
+    /// Every token is compared, so the time taken says nothing about which
+    /// one matched or how closely.
+    pub fn verify(&self, presented: &str) -> Option<String> {
+        let presented = digest(presented);
+        let mut matched = None;
+        for (name, expected) in self.tokens.read().unwrap().iter() {
+            if constant_time_eq(&presented, expected) && matched.is_none() {
+                matched = Some(name.clone());
+            }
+        }
+        matched
+    }
+}
+
+/// Keep `verifier` to the tokens of the settings `facade` holds as the
+/// watcher reloads them, once any were listed at startup
+#[cfg(feature = "http-server")]
+pub fn spawn_reload(
+    verifier: std::sync::Arc<TokenVerifier>,
+    facade: std::sync::Arc<tokio::sync::RwLock<crate::indexing::facade::IndexFacade>>,
+    mut events: tokio::sync::broadcast::Receiver<crate::mcp::notifications::FileChangeEvent>,
+) {
+    use crate::mcp::notifications::FileChangeEvent;
+    use tokio::sync::broadcast::error::RecvError;
+
+    if !verifier.is_enabled() {
+        return;
+    }
+    tokio::spawn(async move {
+        loop {
+            match events.recv().await {
+                Ok(FileChangeEvent::SettingsReloaded) => {
+                    let tokens = facade.read().await.settings().server.tokens.clone();
+                    verifier.reload(&tokens);
+                    crate::debug_event!("auth", "reloaded", "{} token(s)", tokens.len());
+                }
+                Ok(_) | Err(RecvError::Lagged(_)) => {}
+                Err(RecvError::Closed) => break,
+            }
+        }
+    });
+}
+
+fn expected_digests(tokens: &[ServerToken]) -> Vec<(String, [u8; 32])> {
+    let mut digests = Vec::new();
+    for entry in tokens {
+        let expected = match (&entry.token, &entry.sha256) {
+            (Some(token), _) => Some(digest(token)),
+            (None, Some(sha256)) => hex::decode(sha256.trim())
+                .ok()
+                .and_then(|bytes| <[u8; 32]>::try_from(bytes).ok()),
+            (None, None) => None,
+        };
+        match expected {
+            Some(expected) => digests.push((entry.name.clone(), expected)),
+            None => tracing::warn!(
+                "[auth] server token '{}' has no token or valid sha256, ignored",
+                entry.name
+            ),
+        }
+    }
+    digests
+}
+
+fn constant_time_eq(a: &[u8; 32], b: &[u8; 32]) -> bool {
+    let diff = a.iter().zip(b).fold(0u8, |diff, (x, y)| diff | (x ^ y));
+    std::hint::black_box(diff) == 0
+}
+
+/// Require a listed token on every request of `router`, when any is listed
+#[cfg(feature = "http-server")]
+pub fn protect(router: axum::Router, verifier: &std::sync::Arc<TokenVerifier>) -> axum::Router {
+    if verifier.is_enabled() {
+        router.layer(axum::middleware::from_fn_with_state(
+            verifier.clone(),
+            require_token,
+        ))
+    } else {
+        router
+    }
+}
+
+#[cfg(feature = "http-server")]
+async fn require_token(
+    axum::extract::State(verifier): axum::extract::State<std::sync::Arc<TokenVerifier>>,
+    req: axum::extract::Request,
+    next: axum::middleware::Next,
+) -> Result<axum::response::Response, axum::http::StatusCode> {
+    // CORS preflight requests never carry credentials
+    if req.method() == axum::http::Method::OPTIONS {
+        return Ok(next.run(req).await);
+    }
+
+    let presented = req
+        .headers()
+        .get(axum::http::header::AUTHORIZATION)
+        .and_then(|value| value.to_str().ok())
+        .and_then(|value| value.strip_prefix("Bearer "));
+    let method = req.method().clone();
+    let path = req.uri().path().to_string();
+    match presented.and_then(|token| verifier.verify(token.trim())) {
+        Some(name) => {
+            crate::log_event!("auth", "authorized", "token '{name}': {method} {path}");
+            Ok(next.run(req).await)
+        }
+        None => {
+            let reason = if presented.is_some() {
+                "unknown token"
+            } else {
+                "missing token"
+            };
+            tracing::warn!("[auth] rejected {method} {path}: {reason}");
+            Err(axum::http::StatusCode::UNAUTHORIZED)
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn entry(name: &str, token: Option<&str>, sha256: Option<&str>) -> ServerToken {
+        ServerToken {
+            name: name.to_string(),
+            token: token.map(str::to_string),
+            sha256: sha256.map(str::to_string),
+        }
+    }
+
+    #[test]
+    fn test_verify_names_the_token() {
+        let created = generate_token();
+        assert!(created.starts_with(TOKEN_PREFIX));
+        let verifier = TokenVerifier::from_config(&[
+            entry("laptop", Some("s3cret"), None),
+            entry("ci", None, Some(&token_digest(&created))),
+            entry("broken", None, Some("not hex")),
+        ]);
+        assert!(verifier.is_enabled());
+        assert_eq!(verifier.verify("s3cret").as_deref(), Some("laptop"));
+        assert_eq!(verifier.verify(&created).as_deref(), Some("ci"));
+        assert_eq!(verifier.verify("s3cre"), None);
+        assert_eq!(verifier.verify(""), None);
+
+        assert!(!TokenVerifier::from_config(&[]).is_enabled());
+    }
+}
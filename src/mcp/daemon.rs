@@ -0,0 +1,199 @@
+//! Background server for `codanna mcp` calls
+//!
+//! Each `codanna mcp <tool>` call opens the index, and a semantic tool
+//! loads the embedding model, before it answers. With `server.daemon` on,
+//! a call in text mode goes instead to a `codanna serve --uds --read-only`
+//! in the background, which keeps both loaded between calls and answers it
+//! as the command would, text and exit code alike.
+//!
+//! The first call finds no server on the socket of the index, starts one
+//! and answers itself; the server exits once no call has reached it for
+//! `server.daemon_idle_timeout_secs`. Being read-only, it reopens the index
+//! whenever `codanna index` commits. A server of another codanna version is
+//! not asked, and left to idle out.
+//!
+//! `--json` calls and `codanna retrieve` are not proxied: they build their
+//! output in the command, printing and exiting as they go, where text mode
+//! prints what the tool handlers of the server return.
+
+use std::ffi::OsString;
+use std::path::{Path, PathBuf};
+use std::time::{Duration, SystemTime};
+
+use rmcp::ServiceExt;
+use rmcp::model::{ClientRequest, CustomRequest, ServerResult};
+use serde::Deserialize;
+use serde_json::{Map, Value};
+
+/// Method of a text-mode call, answered with what the command prints
+pub const CLI_CALL_METHOD: &str = "requests/codanna/cli-call";
+
+/// Socket of the background server, in the index directory
+pub const SOCKET_NAME: &str = "daemon.sock";
+
+/// Left by the call that starts a server, so the calls made while it
+/// loads the index do not start more
+const STARTING_NAME: &str = "daemon.starting";
+
+/// How long a server may take to load before another is started
+const STARTING_GRACE: Duration = Duration::from_secs(60);
+
+/// How long connecting may take before the call runs in process
+const CONNECT_TIMEOUT: Duration = Duration::from_secs(2);
+
+/// The socket of the background server of the index at `index_path`
+pub fn socket_path(index_path: &Path) -> PathBuf {
+    index_path.join(SOCKET_NAME)
+}
+
+/// A call as the background server answered it
+#[derive(Debug, Clone, PartialEq, Eq, Deserialize)]
+pub struct CliReply {
+    /// The text blocks of the result, printed a line each
+    pub text: Vec<String>,
+    pub exit_code: i32,
+}
+
+/// Run `tool` with `arguments` on the server listening on `socket`. None
+/// when no server of this version answers there.
+pub async fn call(
+    socket: &Path,
+    tool: &str,
+    arguments: &Option<Map<String, Value>>,
+) -> Option<CliReply> {
+    let connect = async {
+        let stream = tokio::net::UnixStream::connect(socket).await.ok()?;
+        ().serve(tokio::io::split(stream)).await.ok()
+    };
+    let client = tokio::time::timeout(CONNECT_TIMEOUT, connect)
+        .await
+        .ok()
+        .flatten()?;
+
+    let version = client
+        .peer_info()
+        .map(|info| info.server_info.version.as_str());
+    let reply = if version == Some(env!("CARGO_PKG_VERSION")) {
+        let request = ClientRequest::CustomRequest(CustomRequest::new(
+            CLI_CALL_METHOD,
+            Some(serde_json::json!({ "tool": tool, "arguments": arguments })),
+        ));
+        match client.peer().send_request(request).await {
+            Ok(ServerResult::CustomResult(result)) => serde_json::from_value(result.0).ok(),
+            Ok(_) => None,
+            Err(e) => {
+                tracing::debug!(target: "mcp", "daemon call failed: {e}");
+                None
+            }
+        }
+    } else {
+        tracing::debug!(target: "mcp", "daemon runs codanna {version:?}, not asking it");
+        None
+    };
+    let _ = client.cancel().await;
+    reply
+}
+
+/// Start a background server on `socket`, running codanna with the
+/// global arguments `global_args`, unless one was started moments ago and
+/// may still be loading
+pub fn spawn(
+    socket: &Path,
+    idle_timeout_secs: u64,
+    global_args: &[OsString],
+) -> std::io::Result<()> {
+    use std::os::unix::process::CommandExt;
+    use std::process::{Command, Stdio};
+
+    let starting = socket.with_file_name(STARTING_NAME);
+    let loading = std::fs::metadata(&starting)
+        .and_then(|meta| meta.modified())
+        .ok()
+        .and_then(|started| SystemTime::now().duration_since(started).ok())
+        .is_some_and(|age| age < STARTING_GRACE);
+    if loading {
+        return Ok(());
+    }
+    std::fs::write(&starting, std::process::id().to_string())?;
+
+    Command::new(std::env::current_exe()?)
+        .args(global_args)
+        .arg("serve")
+        .arg("--read-only")
+        .arg("--uds")
+        .arg(socket)
+        .arg("--idle-timeout")
+        .arg(idle_timeout_secs.to_string())
+        .stdin(Stdio::null())
+        .stdout(Stdio::null())
+        .stderr(Stdio::null())
+        // Out of the terminal's process group, so Ctrl+C there spares it
+        .process_group(0)
+        .spawn()
+        .map(drop)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[tokio::test]
+    async fn test_call_without_a_server() {
+        let dir = tempfile::tempdir().unwrap();
+        let socket = socket_path(dir.path());
+        assert!(call(&socket, "get_index_info", &None).await.is_none());
+
+        // A socket file nothing listens on
+        drop(std::os::unix::net::UnixListener::bind(&socket).unwrap());
+        assert!(call(&socket, "get_index_info", &None).await.is_none());
+    }
+
+    #[tokio::test]
+    async fn test_call_answered_by_a_server() {
+        use crate::mcp::CodeIntelligenceServer;
+        use crate::mcp::uds_server::UdsListener;
+        use tokio_util::sync::CancellationToken;
+
+        let dir = tempfile::tempdir().unwrap();
+        let settings = crate::config::Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("tool.py");
+        std::fs::write(&source, "def load():\n    pass\n").unwrap();
+        let mut facade =
+            crate::indexing::facade::IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+
+        let server = CodeIntelligenceServer::new(facade);
+        let socket = socket_path(dir.path());
+        let listener = UdsListener::bind(&socket, 0o600).unwrap();
+        let ct = CancellationToken::new();
+        let serve_ct = ct.clone();
+        let serving = tokio::spawn(async move {
+            listener
+                .serve(|| server.for_new_connection(), serve_ct, None)
+                .await;
+        });
+
+        let arguments = |name: &str| {
+            let mut map = Map::new();
+            map.insert("name".to_string(), Value::String(name.to_string()));
+            Some(map)
+        };
+        let found = call(&socket, "find_symbol", &arguments("load"))
+            .await
+            .unwrap();
+        assert_eq!(found.exit_code, 0);
+        assert!(found.text.concat().contains("load"), "{found:?}");
+
+        let missing = call(&socket, "find_symbol", &arguments("absent"))
+            .await
+            .unwrap();
+        assert_eq!(missing.exit_code, 1);
+
+        ct.cancel();
+        serving.await.unwrap();
+    }
+}
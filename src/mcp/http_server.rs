@@ -1,10 +1,18 @@
 //! HTTP server implementation for MCP
 //!
 //! Provides a persistent HTTP server with streamable HTTP transport
-//! for multiple concurrent clients and real-time updates.
+//! for multiple concurrent clients and real-time updates. With `ws` set it
+//! also serves MCP over WebSocket at `/ws`, and with `sse` over the
+//! HTTP+SSE transport at `/sse`.
 
 #[cfg(feature = "http-server")]
-pub async fn serve_http(config: crate::Settings, watch: bool, bind: String) -> anyhow::Result<()> {
+pub async fn serve_http(
+    config: crate::Settings,
+    watch: bool,
+    bind: String,
+    ws: bool,
+    sse: bool,
+) -> anyhow::Result<()> {
     use crate::IndexPersistence;
     use crate::indexing::facade::IndexFacade;
     use crate::mcp::{CodeIntelligenceServer, notifications::NotificationBroadcaster};
@@ -24,6 +32,11 @@ pub async fn serve_http(config: crate::Settings, watch: bool, bind: String) -> a
 
     crate::log_event!("http", "starting", "MCP server on {bind}");
 
+    let crate::mcp::Watching {
+        hot_reload: watch,
+        file_watch,
+    } = crate::mcp::Watching::of(&config, watch);
+
     // Create notification broadcaster for file change events
     let broadcaster = Arc::new(NotificationBroadcaster::new(100));
 
@@ -90,8 +103,14 @@ pub async fn serve_http(config: crate::Settings, watch: bool, bind: String) -> a
         tracing::debug!(target: "mcp", "document store loaded for MCP server");
     }
 
+    // Other projects the tools can answer for
+    let projects = Arc::new(crate::mcp::projects::Projects::load(&config));
+    if watch {
+        projects.spawn_hot_reload(Duration::from_secs(5));
+    }
+
     // Start unified file watcher if enabled
-    if watch || config.file_watch.enabled {
+    if file_watch {
         use crate::watcher::UnifiedWatcher;
         use crate::watcher::handlers::{CodeFileHandler, ConfigFileHandler, DocumentFileHandler};
 
@@ -109,7 +128,7 @@ pub async fn serve_http(config: crate::Settings, watch: bool, bind: String) -> a
             .indexer(indexer.clone())
             .index_path(config.index_path.clone())
             .workspace_root(workspace_root.clone())
-            .debounce_ms(debounce_ms);
+            .file_watch(&config.file_watch);
 
         // Add code file handler
         builder = builder.handler(CodeFileHandler::new(
@@ -118,7 +137,7 @@ pub async fn serve_http(config: crate::Settings, watch: bool, bind: String) -> a
         ));
 
         // Add config file handler
-        match ConfigFileHandler::new(settings_path.clone()) {
+        match ConfigFileHandler::new(settings_path.clone(), config.profile.clone()) {
             Ok(config_handler) => {
                 builder = builder.handler(config_handler);
             }
@@ -175,45 +194,60 @@ pub async fn serve_http(config: crate::Settings, watch: bool, bind: String) -> a
     let broadcaster_for_service = broadcaster.clone();
     let ct_for_service = ct.clone();
     let document_store_for_service = document_store_arc.clone();
+    let projects_for_service = projects.clone();
+    let limits = Arc::new(crate::mcp::limits::RequestLimits::from_config(
+        &config.server,
+    ));
+    let shutdown_limits = limits.clone();
+    let read_only = config.server.read_only;
+
+    // One server per MCP session, over streamable HTTP or WebSocket
+    let make_server: crate::mcp::ws_server::ServerFactory = Arc::new(move || {
+        crate::debug_event!("mcp", "creating server instance");
+        let server = CodeIntelligenceServer::new_with_facade(
+            indexer_for_service.clone(),
+            config_for_service.clone(),
+        )
+        .with_projects(projects_for_service.clone())
+        .with_limits(limits.clone())
+        .with_read_only(read_only);
 
-    let mcp_service = StreamableHttpService::new(
-        move || {
-            crate::debug_event!("mcp", "creating server instance");
-            let server = CodeIntelligenceServer::new_with_facade(
-                indexer_for_service.clone(),
-                config_for_service.clone(),
-            );
-
-            // Attach document store if available
-            let server = if let Some(ref store_arc) = document_store_for_service {
-                server.with_document_store_arc(store_arc.clone())
-            } else {
-                server
-            };
+        // Attach document store if available
+        let server = if let Some(ref store_arc) = document_store_for_service {
+            server.with_document_store_arc(store_arc.clone())
+        } else {
+            server
+        };
+
+        // Start notification listener for this connection
+        // Note: We need to wait for initialize() to be called first
+        let server_clone = server.clone();
+        let receiver = broadcaster_for_service.subscribe();
+        let listener_ct = ct_for_service.clone();
+        crate::debug_event!("mcp", "subscribing to broadcaster");
+        tokio::spawn(async move {
+            // Wait a bit for the MCP handshake to complete
+            tokio::time::sleep(tokio::time::Duration::from_secs(1)).await;
+            crate::debug_event!("mcp", "notification listener started");
 
-            // Start notification listener for this connection
-            // Note: We need to wait for initialize() to be called first
-            let server_clone = server.clone();
-            let receiver = broadcaster_for_service.subscribe();
-            let listener_ct = ct_for_service.clone();
-            crate::debug_event!("mcp", "subscribing to broadcaster");
-            tokio::spawn(async move {
-                // Wait a bit for the MCP handshake to complete
-                tokio::time::sleep(tokio::time::Duration::from_secs(1)).await;
-                crate::debug_event!("mcp", "notification listener started");
-
-                // Run listener until cancelled
-                tokio::select! {
-                    _ = server_clone.start_notification_listener(receiver) => {
-                        crate::debug_event!("mcp", "notification listener ended");
-                    }
-                    _ = listener_ct.cancelled() => {
-                        crate::debug_event!("mcp", "notification listener stopped");
-                    }
+            // Run listener until cancelled
+            tokio::select! {
+                _ = server_clone.start_notification_listener(receiver) => {
+                    crate::debug_event!("mcp", "notification listener ended");
+                }
+                _ = listener_ct.cancelled() => {
+                    crate::debug_event!("mcp", "notification listener stopped");
                 }
-            });
+            }
+        });
 
-            Ok(server)
+        server
+    });
+
+    let mcp_service = StreamableHttpService::new(
+        {
+            let make_server = make_server.clone();
+            move || Ok(make_server())
         },
         LocalSessionManager::default().into(),
         {
@@ -400,14 +434,6 @@ pub async fn serve_http(config: crate::Settings, watch: bool, bind: String) -> a
         axum::response::Html(html)
     }
 
-    // Helper function for shutdown signal with cancellation token
-    async fn shutdown_signal() {
-        tokio::signal::ctrl_c()
-            .await
-            .expect("failed to listen for ctrl+c");
-        eprintln!("Received shutdown signal");
-    }
-
     // Bearer token validation middleware - only for MCP endpoints
     async fn validate_bearer_token(
         req: axum::http::Request<axum::body::Body>,
@@ -434,9 +460,17 @@ pub async fn serve_http(config: crate::Settings, watch: bool, bind: String) -> a
     }
 
     // Create protected MCP router with Bearer token validation
-    let protected_mcp_router = Router::new()
-        .nest_service("/mcp", mcp_service)
-        .layer(axum::middleware::from_fn(validate_bearer_token));
+    // Tokens listed in settings replace the OAuth dummy token
+    let verifier = Arc::new(crate::mcp::auth::TokenVerifier::from_config(
+        &config.server.tokens,
+    ));
+    crate::mcp::auth::spawn_reload(verifier.clone(), indexer.clone(), broadcaster.subscribe());
+    let protected_mcp_router = Router::new().nest_service("/mcp", mcp_service);
+    let protected_mcp_router = if verifier.is_enabled() {
+        crate::mcp::auth::protect(protected_mcp_router, &verifier)
+    } else {
+        protected_mcp_router.layer(axum::middleware::from_fn(validate_bearer_token))
+    };
 
     // Create main router - OAuth endpoints FIRST (no auth), then MCP endpoints (with auth)
     let router = Router::new()
@@ -453,23 +487,92 @@ pub async fn serve_http(config: crate::Settings, watch: bool, bind: String) -> a
         // MCP endpoint - Bearer token authentication required
         .merge(protected_mcp_router);
 
+    // WebSocket and SSE endpoints - origin checked, and the OAuth dummy
+    // token not required, as browsers cannot send it on an upgrade or from
+    // an EventSource. Tokens listed in settings are required all the same.
+    let router = if ws {
+        router.merge(crate::mcp::auth::protect(
+            crate::mcp::ws_server::router(
+                make_server.clone(),
+                config.mcp.allowed_origins.clone(),
+                ct.child_token(),
+            ),
+            &verifier,
+        ))
+    } else {
+        router
+    };
+    let router = if sse {
+        router.merge(crate::mcp::auth::protect(
+            crate::mcp::sse_server::router(
+                make_server,
+                config.mcp.allowed_origins.clone(),
+                ct.child_token(),
+            ),
+            &verifier,
+        ))
+    } else {
+        router
+    };
+
+    // Prometheus metrics, behind the tokens listed in settings like /mcp
+    let router = router.merge(crate::mcp::auth::protect(
+        crate::mcp::metrics::router(indexer.clone(), projects.clone()),
+        &verifier,
+    ));
+
+    // REST API under /api/v1, when `server.rest_api` is set
+    let rest_api = config.server.rest_api;
+    let router = if rest_api {
+        router.merge(crate::mcp::auth::protect(
+            crate::mcp::rest::router(indexer.clone(), projects),
+            &verifier,
+        ))
+    } else {
+        router
+    };
+
     // Bind and serve
     let listener = tokio::net::TcpListener::bind(&bind).await?;
     eprintln!("HTTP MCP server listening on http://{bind}");
     eprintln!("MCP endpoint: http://{bind}/mcp");
+    if ws {
+        eprintln!("WebSocket endpoint: ws://{bind}/ws");
+    }
+    if sse {
+        eprintln!("SSE endpoint: http://{bind}/sse");
+    }
     eprintln!("Health check: http://{bind}/health");
+    eprintln!("Metrics: http://{bind}/metrics");
+    if rest_api {
+        eprintln!("REST API: http://{bind}/api/v1 (schema at /api/v1/openapi.json)");
+    }
+    if verifier.is_enabled() {
+        eprintln!("Authentication: bearer tokens from [[server.tokens]]");
+    }
     eprintln!("Press Ctrl+C to stop the server");
 
     // Create server future
     let server = axum::serve(listener, router);
 
-    // Handle graceful shutdown with tokio::select!
+    // Handle graceful shutdown with tokio::select!, serving until in-flight
+    // tool calls are drained
+    let flush_index = file_watch.then_some(&*indexer);
+    let shutdown = async {
+        crate::mcp::shutdown::signal().await;
+        eprintln!("Shutting down HTTP server...");
+        crate::mcp::shutdown::drain(
+            &shutdown_limits,
+            config.server.shutdown_timeout_secs,
+            flush_index,
+        )
+        .await;
+    };
     tokio::select! {
         result = server => {
             result?;
         }
-        _ = shutdown_signal() => {
-            eprintln!("Shutting down HTTP server...");
+        _ = shutdown => {
             ct.cancel();
         }
     }
@@ -483,6 +586,8 @@ pub async fn serve_http(
     _config: crate::Settings,
     _watch: bool,
     _bind: String,
+    _ws: bool,
+    _sse: bool,
 ) -> anyhow::Result<()> {
     eprintln!("HTTP server support is not compiled in.");
     eprintln!("Please rebuild with: cargo build --features http-server");
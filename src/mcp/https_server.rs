@@ -1,10 +1,18 @@
 //! HTTPS server implementation for MCP using streamable HTTP transport with TLS
 //!
 //! Provides a secure HTTPS server with TLS support for MCP communication.
-//! Uses streamable HTTP transport which is compatible with Claude Code.
+//! Uses streamable HTTP transport which is compatible with Claude Code. With
+//! `ws` set it also serves MCP over secure WebSocket at `/ws`, and with `sse`
+//! over the HTTP+SSE transport at `/sse`.
 
 #[cfg(feature = "https-server")]
-pub async fn serve_https(config: crate::Settings, watch: bool, bind: String) -> anyhow::Result<()> {
+pub async fn serve_https(
+    config: crate::Settings,
+    watch: bool,
+    bind: String,
+    ws: bool,
+    sse: bool,
+) -> anyhow::Result<()> {
     use crate::IndexPersistence;
     use crate::indexing::facade::IndexFacade;
     use crate::mcp::{CodeIntelligenceServer, notifications::NotificationBroadcaster};
@@ -27,6 +35,11 @@ pub async fn serve_https(config: crate::Settings, watch: bool, bind: String) ->
 
     crate::log_event!("https", "starting", "MCP server on {bind}");
 
+    let crate::mcp::Watching {
+        hot_reload: watch,
+        file_watch,
+    } = crate::mcp::Watching::of(&config, watch);
+
     // Create notification broadcaster for file change events
     let broadcaster = Arc::new(NotificationBroadcaster::new(100));
 
@@ -62,8 +75,14 @@ pub async fn serve_https(config: crate::Settings, watch: bool, bind: String) ->
         tracing::debug!(target: "mcp", "document store loaded for MCP server");
     }
 
+    // Other projects the tools can answer for
+    let projects = Arc::new(crate::mcp::projects::Projects::load(&config));
+    if watch {
+        projects.spawn_hot_reload(Duration::from_secs(5));
+    }
+
     // Start unified file watcher if enabled
-    if watch || config.file_watch.enabled {
+    if file_watch {
         use crate::watcher::UnifiedWatcher;
         use crate::watcher::handlers::{CodeFileHandler, ConfigFileHandler, DocumentFileHandler};
 
@@ -81,7 +100,7 @@ pub async fn serve_https(config: crate::Settings, watch: bool, bind: String) ->
             .indexer(indexer.clone())
             .index_path(config.index_path.clone())
             .workspace_root(workspace_root.clone())
-            .debounce_ms(debounce_ms);
+            .file_watch(&config.file_watch);
 
         // Add code file handler
         builder = builder.handler(CodeFileHandler::new(
@@ -90,7 +109,7 @@ pub async fn serve_https(config: crate::Settings, watch: bool, bind: String) ->
         ));
 
         // Add config file handler
-        match ConfigFileHandler::new(settings_path.clone()) {
+        match ConfigFileHandler::new(settings_path.clone(), config.profile.clone()) {
             Ok(config_handler) => {
                 builder = builder.handler(config_handler);
             }
@@ -180,8 +199,14 @@ pub async fn serve_https(config: crate::Settings, watch: bool, bind: String) ->
     let config_for_service = Arc::new(config.clone());
 
     // Create a shared service instance that all connections will use
+    let limits = Arc::new(crate::mcp::limits::RequestLimits::from_config(
+        &config.server,
+    ));
     let shared_service =
-        CodeIntelligenceServer::new_with_facade(indexer_for_service, config_for_service);
+        CodeIntelligenceServer::new_with_facade(indexer_for_service, config_for_service)
+            .with_projects(projects.clone())
+            .with_limits(limits.clone())
+            .with_read_only(config.server.read_only);
 
     // Attach document store if available
     let shared_service = if let Some(store_arc) = document_store_arc {
@@ -200,12 +225,14 @@ pub async fn serve_https(config: crate::Settings, watch: bool, bind: String) ->
             .await;
     });
 
+    let service_for_http = shared_service.clone();
     let mcp_service = StreamableHttpService::new(
         move || {
             // Return a clone of the shared service
             // Since CodeIntelligenceServer derives Clone and the indexer is Arc<RwLock<_>>,
-            // all clones will share the same underlying indexer
-            Ok(shared_service.clone())
+            // all clones will share the same underlying indexer; each session
+            // gets its own rate limit
+            Ok(service_for_http.for_new_connection())
         },
         LocalSessionManager::default().into(),
         {
@@ -263,10 +290,18 @@ pub async fn serve_https(config: crate::Settings, watch: bool, bind: String) ->
         Ok(next.run(req).await)
     }
 
-    // Create MCP router with logging middleware
-    let mcp_router_with_logging = Router::new()
-        .nest_service("/mcp", mcp_service)
-        .layer(axum::middleware::from_fn(log_requests));
+    // Create MCP router with logging middleware, behind the bearer tokens
+    // once settings list any
+    let verifier = Arc::new(crate::mcp::auth::TokenVerifier::from_config(
+        &config.server.tokens,
+    ));
+    crate::mcp::auth::spawn_reload(verifier.clone(), indexer.clone(), broadcaster.subscribe());
+    let mcp_router_with_logging = crate::mcp::auth::protect(
+        Router::new()
+            .nest_service("/mcp", mcp_service)
+            .layer(axum::middleware::from_fn(log_requests)),
+        &verifier,
+    );
 
     // Create main router - OAuth endpoints available but optional for HTTPS
     let router = Router::new()
@@ -283,38 +318,134 @@ pub async fn serve_https(config: crate::Settings, watch: bool, bind: String) ->
         // MCP endpoint - No authentication required (TLS provides transport security)
         .merge(mcp_router_with_logging);
 
+    let router = if ws {
+        let ws_service = shared_service.clone();
+        router.merge(crate::mcp::auth::protect(
+            crate::mcp::ws_server::router(
+                Arc::new(move || ws_service.for_new_connection()),
+                config.mcp.allowed_origins.clone(),
+                ct.child_token(),
+            ),
+            &verifier,
+        ))
+    } else {
+        router
+    };
+    let router = if sse {
+        let sse_service = shared_service.clone();
+        router.merge(crate::mcp::auth::protect(
+            crate::mcp::sse_server::router(
+                Arc::new(move || sse_service.for_new_connection()),
+                config.mcp.allowed_origins.clone(),
+                ct.child_token(),
+            ),
+            &verifier,
+        ))
+    } else {
+        router
+    };
+
+    // Prometheus metrics, behind the bearer tokens like /mcp
+    let router = router.merge(crate::mcp::auth::protect(
+        crate::mcp::metrics::router(indexer.clone(), projects.clone()),
+        &verifier,
+    ));
+
+    // REST API under /api/v1, when `server.rest_api` is set
+    let rest_api = config.server.rest_api;
+    let router = if rest_api {
+        router.merge(crate::mcp::auth::protect(
+            crate::mcp::rest::router(indexer.clone(), projects),
+            &verifier,
+        ))
+    } else {
+        router
+    };
+
     // Get or create TLS certificates
     let (cert_pem, key_pem) = get_or_create_certificate(&bind)
         .await
         .context("Failed to get or create TLS certificate")?;
 
-    // Configure TLS
-    let tls_config = RustlsConfig::from_pem(cert_pem, key_pem)
-        .await
-        .context("Failed to configure TLS")?;
+    // Configure TLS, asking for client certificates when [server.mtls] is set
+    let mtls = config.server.mtls.clone();
+    let tls_config = match &mtls {
+        Some(mtls) => crate::mcp::mtls::server_config(&cert_pem, &key_pem, mtls)
+            .context("Failed to configure mutual TLS")?,
+        None => RustlsConfig::from_pem(cert_pem, key_pem)
+            .await
+            .context("Failed to configure TLS")?,
+    };
 
     // Parse bind address
     let addr: SocketAddr = bind.parse().context("Failed to parse bind address")?;
 
     eprintln!("HTTPS MCP server listening on https://{bind}");
     eprintln!("MCP endpoint: https://{bind}/mcp");
+    if ws {
+        eprintln!("WebSocket endpoint: wss://{bind}/ws");
+    }
+    if sse {
+        eprintln!("SSE endpoint: https://{bind}/sse");
+    }
     eprintln!("Health check: https://{bind}/health");
+    eprintln!("Metrics: https://{bind}/metrics");
+    if rest_api {
+        eprintln!("REST API: https://{bind}/api/v1 (schema at /api/v1/openapi.json)");
+    }
+    if verifier.is_enabled() {
+        eprintln!("Authentication: bearer tokens from [[server.tokens]]");
+    }
+    if let Some(mtls) = &mtls {
+        eprintln!(
+            "Client certificates: {} by {}",
+            if mtls.require_client_cert {
+                "required"
+            } else {
+                "verified when sent"
+            },
+            mtls.client_ca.display()
+        );
+    }
     eprintln!();
     eprintln!("Using self-signed certificate. Clients will show security warnings.");
     eprintln!("To trust the certificate, visit https://{bind} in your browser first");
     eprintln!();
     eprintln!("Press Ctrl+C to stop the server");
 
-    // Serve with TLS
-    let server = axum_server::bind_rustls(addr, tls_config).serve(router.into_make_service());
+    // Serve with TLS; with mutual TLS, requests carry the client identity
+    let server = async {
+        match &mtls {
+            Some(mtls) => {
+                let router = router.layer(axum::middleware::from_fn(
+                    crate::mcp::mtls::log_client_identity,
+                ));
+                axum_server::bind(addr)
+                    .acceptor(crate::mcp::mtls::IdentityAcceptor::new(tls_config, mtls))
+                    .serve(router.into_make_service())
+                    .await
+            }
+            None => {
+                axum_server::bind_rustls(addr, tls_config)
+                    .serve(router.into_make_service())
+                    .await
+            }
+        }
+    };
 
-    // Handle graceful shutdown
+    // Handle graceful shutdown, serving until in-flight tool calls are drained
+    let flush_index = file_watch.then_some(&*indexer);
+    let shutdown = async {
+        crate::mcp::shutdown::signal().await;
+        eprintln!("Shutting down HTTPS server...");
+        crate::mcp::shutdown::drain(&limits, config.server.shutdown_timeout_secs, flush_index)
+            .await;
+    };
     tokio::select! {
         result = server => {
             result?;
         }
-        _ = shutdown_signal() => {
-            eprintln!("Shutting down HTTPS server...");
+        _ = shutdown => {
             ct.cancel();
         }
     }
@@ -496,15 +627,6 @@ async fn oauth_authorize(
     axum::response::Html(html)
 }
 
-/// Helper function for shutdown signal
-#[cfg(feature = "https-server")]
-async fn shutdown_signal() {
-    tokio::signal::ctrl_c()
-        .await
-        .expect("failed to listen for ctrl+c");
-    eprintln!("Received shutdown signal");
-}
-
 /// Get or create self-signed certificate for HTTPS
 #[cfg(feature = "https-server")]
 async fn get_or_create_certificate(bind: &str) -> anyhow::Result<(Vec<u8>, Vec<u8>)> {
@@ -631,6 +753,8 @@ pub async fn serve_https(
     _config: crate::Settings,
     _watch: bool,
     _bind: String,
+    _ws: bool,
+    _sse: bool,
 ) -> anyhow::Result<()> {
     eprintln!("HTTPS server support is not compiled in.");
     eprintln!("Please rebuild with: cargo build --features https-server");
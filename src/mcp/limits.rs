@@ -0,0 +1,259 @@
+//! Rate limits and the in-flight cap of the MCP server
+//!
+//! `server.rate_limit_per_minute` caps the tool calls of each client
+//! connection, in bursts of up to as many; `server.max_in_flight` caps the
+//! calls answered at once across all of them. A call past either gets a
+//! JSON-RPC error with code [`LIMIT_EXCEEDED`] saying which limit it hit,
+//! and in `data.retry_after_ms` when to try again.
+//!
+//! On shutdown the server drains: calls already admitted run to their end,
+//! for up to `server.shutdown_timeout_secs`, and new ones are refused with
+//! [`SHUTTING_DOWN`].
+
+use std::sync::atomic::{AtomicBool, AtomicUsize, Ordering};
+use std::sync::{Arc, Mutex};
+use std::time::{Duration, Instant};
+
+use rmcp::model::{ErrorCode, ErrorData as McpError};
+use tokio::sync::{Notify, OwnedSemaphorePermit, Semaphore};
+
+use crate::config::ServerConfig;
+
+/// Error code of a call refused by a limit, from the range JSON-RPC keeps
+/// for servers
+pub const LIMIT_EXCEEDED: ErrorCode = ErrorCode(-32029);
+
+/// Error code of a call refused because the server is shutting down
+pub const SHUTTING_DOWN: ErrorCode = ErrorCode(-32030);
+
+/// How long a client refused for the in-flight cap is asked to wait
+const BUSY_RETRY: Duration = Duration::from_millis(500);
+
+/// The tool calls one connection has left, as a token bucket refilled at
+/// its rate
+#[derive(Debug)]
+pub struct RateLimiter {
+    per_minute: u32,
+    available: f64,
+    updated: Instant,
+}
+
+impl RateLimiter {
+    /// Limiter of `per_minute` calls, none when 0
+    pub fn new(per_minute: u32) -> Self {
+        Self {
+            per_minute,
+            available: f64::from(per_minute),
+            updated: Instant::now(),
+        }
+    }
+
+    /// Take a call made at `now`; Err with the time until the next one is
+    /// allowed
+    fn take(&mut self, now: Instant) -> Result<(), Duration> {
+        if self.per_minute == 0 {
+            return Ok(());
+        }
+        let per_second = f64::from(self.per_minute) / 60.0;
+        let elapsed = now.saturating_duration_since(self.updated).as_secs_f64();
+        self.available = (self.available + elapsed * per_second).min(f64::from(self.per_minute));
+        self.updated = now;
+        if self.available >= 1.0 {
+            self.available -= 1.0;
+            Ok(())
+        } else {
+            Err(Duration::from_secs_f64((1.0 - self.available) / per_second))
+        }
+    }
+}
+
+impl Default for RateLimiter {
+    fn default() -> Self {
+        Self::new(0)
+    }
+}
+
+/// The limits of a server, shared by all its connections
+#[derive(Debug, Default)]
+pub struct RequestLimits {
+    rate_limit_per_minute: u32,
+    max_in_flight: usize,
+    in_flight: Option<Arc<Semaphore>>,
+    draining: AtomicBool,
+    active: Arc<ActiveCalls>,
+}
+
+/// Count of the calls being answered, with a wakeup when it falls to 0
+#[derive(Debug, Default)]
+struct ActiveCalls {
+    count: AtomicUsize,
+    idle: Notify,
+}
+
+/// A call being answered, counted until dropped
+#[derive(Debug)]
+pub struct ActiveCall(Arc<ActiveCalls>);
+
+impl Drop for ActiveCall {
+    fn drop(&mut self) {
+        if self.0.count.fetch_sub(1, Ordering::SeqCst) == 1 {
+            self.0.idle.notify_waiters();
+        }
+    }
+}
+
+impl RequestLimits {
+    pub fn from_config(config: &ServerConfig) -> Self {
+        Self {
+            rate_limit_per_minute: config.rate_limit_per_minute,
+            max_in_flight: config.max_in_flight,
+            in_flight: (config.max_in_flight > 0)
+                .then(|| Arc::new(Semaphore::new(config.max_in_flight))),
+            ..Default::default()
+        }
+    }
+
+    /// Count a call of `tool` as being answered until the guard is dropped,
+    /// or refuse it once the server drains
+    pub fn begin_call(&self, tool: &str) -> Result<ActiveCall, McpError> {
+        // Counted before the check, so a drain never misses a call it let in
+        self.active.count.fetch_add(1, Ordering::SeqCst);
+        let call = ActiveCall(self.active.clone());
+        if self.draining.load(Ordering::SeqCst) {
+            crate::debug_event!("limits", "shutting down", "{tool}");
+            return Err(McpError::new(
+                SHUTTING_DOWN,
+                "Server shutting down: no new tool calls are accepted. Retry on another instance.",
+                None,
+            ));
+        }
+        Ok(call)
+    }
+
+    /// Refuse new calls and wait up to `deadline` for the ones being
+    /// answered. Returns how many were still running at the deadline.
+    pub async fn drain(&self, deadline: Duration) -> usize {
+        self.draining.store(true, Ordering::SeqCst);
+        let idle = async {
+            loop {
+                // Registered before the check, so the last drop cannot slip by
+                let notified = self.active.idle.notified();
+                if self.active.count.load(Ordering::SeqCst) == 0 {
+                    break;
+                }
+                notified.await;
+            }
+        };
+        match tokio::time::timeout(deadline, idle).await {
+            Ok(()) => 0,
+            Err(_) => self.active.count.load(Ordering::SeqCst),
+        }
+    }
+
+    /// Rate limiter of a new connection
+    pub fn new_connection(&self) -> Arc<Mutex<RateLimiter>> {
+        Arc::new(Mutex::new(RateLimiter::new(self.rate_limit_per_minute)))
+    }
+
+    /// Admit a call of `tool` on the connection `rate` limits: the permit
+    /// to hold while answering it, or the error to answer it with
+    pub fn admit(
+        &self,
+        rate: &Mutex<RateLimiter>,
+        tool: &str,
+    ) -> Result<Option<OwnedSemaphorePermit>, McpError> {
+        if let Err(wait) = rate.lock().unwrap().take(Instant::now()) {
+            crate::debug_event!("limits", "rate limited", "{tool}");
+            return Err(limit_error(
+                format!(
+                    "Rate limit exceeded: {} tool calls per minute per connection. Retry in {:.1}s.",
+                    self.rate_limit_per_minute,
+                    wait.as_secs_f64()
+                ),
+                wait,
+            ));
+        }
+        let Some(in_flight) = &self.in_flight else {
+            return Ok(None);
+        };
+        match in_flight.clone().try_acquire_owned() {
+            Ok(permit) => Ok(Some(permit)),
+            Err(_) => {
+                crate::debug_event!("limits", "server busy", "{tool}");
+                Err(limit_error(
+                    format!(
+                        "Server busy: {} tool calls already in flight. Retry shortly.",
+                        self.max_in_flight
+                    ),
+                    BUSY_RETRY,
+                ))
+            }
+        }
+    }
+}
+
+fn limit_error(message: String, retry_after: Duration) -> McpError {
+    McpError::new(
+        LIMIT_EXCEEDED,
+        message,
+        Some(serde_json::json!({ "retry_after_ms": retry_after.as_millis() as u64 })),
+    )
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_rate_limiter_refills_at_its_rate() {
+        let mut limiter = RateLimiter::new(60);
+        let start = limiter.updated;
+        for _ in 0..60 {
+            assert!(limiter.take(start).is_ok());
+        }
+        let wait = limiter.take(start).unwrap_err();
+        assert!(wait > Duration::from_millis(900) && wait <= Duration::from_secs(1));
+
+        assert!(limiter.take(start + Duration::from_secs(1)).is_ok());
+        assert!(limiter.take(start + Duration::from_secs(1)).is_err());
+
+        let mut unlimited = RateLimiter::new(0);
+        assert!((0..1000).all(|_| unlimited.take(start).is_ok()));
+    }
+
+    #[test]
+    fn test_in_flight_cap_refuses_past_it() {
+        let config = ServerConfig {
+            max_in_flight: 1,
+            ..Default::default()
+        };
+        let limits = RequestLimits::from_config(&config);
+        let rate = limits.new_connection();
+
+        let permit = limits.admit(&rate, "search_symbols").unwrap();
+        assert!(permit.is_some());
+        let error = limits.admit(&rate, "search_symbols").unwrap_err();
+        assert_eq!(error.code, LIMIT_EXCEEDED);
+        drop(permit);
+        assert!(limits.admit(&rate, "search_symbols").is_ok());
+    }
+
+    #[tokio::test]
+    async fn test_drain_waits_for_active_calls_and_refuses_new_ones() {
+        let limits = Arc::new(RequestLimits::default());
+        let call = limits.begin_call("get_calls").unwrap();
+
+        let draining = limits.clone();
+        let drained = tokio::spawn(async move { draining.drain(Duration::from_secs(5)).await });
+        tokio::task::yield_now().await;
+        let error = limits.begin_call("get_calls").unwrap_err();
+        assert_eq!(error.code, SHUTTING_DOWN);
+
+        drop(call);
+        assert_eq!(drained.await.unwrap(), 0);
+
+        let stuck = RequestLimits::default();
+        let _call = stuck.begin_call("find_symbol").unwrap();
+        assert_eq!(stuck.drain(Duration::from_millis(10)).await, 1);
+    }
+}
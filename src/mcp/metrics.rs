@@ -0,0 +1,230 @@
+//! Prometheus metrics for the HTTP and HTTPS servers
+//!
+//! `GET /metrics` serves, in the Prometheus text format, the tool calls
+//! answered so far with their latency, the size of each project's index
+//! and how long ago it last changed, and the memory the server holds.
+//! Bearer tokens, when listed, are required on it as on `/mcp`.
+
+use std::collections::BTreeMap;
+use std::fmt::Write;
+use std::sync::{LazyLock, Mutex};
+use std::time::{Duration, SystemTime};
+
+use crate::indexing::facade::IndexFacade;
+
+/// Upper bounds, in seconds, of the tool latency histogram buckets
+const LATENCY_BUCKETS: [f64; 11] = [
+    0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0,
+];
+
+/// Calls of one tool
+#[derive(Debug, Default)]
+struct ToolStats {
+    calls: u64,
+    errors: u64,
+    /// Calls that took at most each bucket's bound, cumulative
+    buckets: [u64; LATENCY_BUCKETS.len()],
+    seconds: f64,
+}
+
+static TOOL_CALLS: LazyLock<Mutex<BTreeMap<String, ToolStats>>> = LazyLock::new(Mutex::default);
+
+/// Count a call of `tool` that took `elapsed`
+pub fn record_tool_call(tool: &str, elapsed: Duration, failed: bool) {
+    let seconds = elapsed.as_secs_f64();
+    let mut calls = TOOL_CALLS.lock().unwrap();
+    let stats = calls.entry(tool.to_string()).or_default();
+    stats.calls += 1;
+    stats.errors += u64::from(failed);
+    stats.seconds += seconds;
+    for (count, bound) in stats.buckets.iter_mut().zip(LATENCY_BUCKETS) {
+        if seconds <= bound {
+            *count += 1;
+        }
+    }
+}
+
+/// The metrics of the tool calls so far and of the index of each of
+/// `projects`, in the Prometheus text format
+pub fn render(projects: &[(&str, &IndexFacade)]) -> String {
+    let mut out = String::new();
+    {
+        let calls = TOOL_CALLS.lock().unwrap();
+
+        out.push_str("# HELP codanna_tool_calls_total Tool calls answered.\n");
+        out.push_str("# TYPE codanna_tool_calls_total counter\n");
+        for (tool, stats) in calls.iter() {
+            let _ = writeln!(
+                out,
+                "codanna_tool_calls_total{{tool=\"{}\"}} {}",
+                escape(tool),
+                stats.calls
+            );
+        }
+
+        out.push_str("# HELP codanna_tool_errors_total Tool calls that failed.\n");
+        out.push_str("# TYPE codanna_tool_errors_total counter\n");
+        for (tool, stats) in calls.iter() {
+            let _ = writeln!(
+                out,
+                "codanna_tool_errors_total{{tool=\"{}\"}} {}",
+                escape(tool),
+                stats.errors
+            );
+        }
+
+        out.push_str("# HELP codanna_tool_duration_seconds Time taken to answer a tool call.\n");
+        out.push_str("# TYPE codanna_tool_duration_seconds histogram\n");
+        for (tool, stats) in calls.iter() {
+            let tool = escape(tool);
+            for (count, bound) in stats.buckets.iter().zip(LATENCY_BUCKETS) {
+                let _ = writeln!(
+                    out,
+                    "codanna_tool_duration_seconds_bucket{{tool=\"{tool}\",le=\"{bound}\"}} {count}"
+                );
+            }
+            let _ = writeln!(
+                out,
+                "codanna_tool_duration_seconds_bucket{{tool=\"{tool}\",le=\"+Inf\"}} {}",
+                stats.calls
+            );
+            let _ = writeln!(
+                out,
+                "codanna_tool_duration_seconds_sum{{tool=\"{tool}\"}} {}",
+                stats.seconds
+            );
+            let _ = writeln!(
+                out,
+                "codanna_tool_duration_seconds_count{{tool=\"{tool}\"}} {}",
+                stats.calls
+            );
+        }
+    }
+
+    let gauges: [(&str, &str, fn(&IndexFacade) -> Option<f64>); 4] = [
+        ("codanna_index_symbols", "Symbols in the index.", |facade| {
+            Some(facade.symbol_count() as f64)
+        }),
+        ("codanna_index_files", "Files in the index.", |facade| {
+            Some(facade.file_count() as f64)
+        }),
+        (
+            "codanna_index_relationships",
+            "Relationships in the index.",
+            |facade| Some(facade.relationship_count() as f64),
+        ),
+        (
+            "codanna_index_age_seconds",
+            "Time since the index last changed on disk.",
+            index_age,
+        ),
+    ];
+    for (name, help, value) in gauges {
+        let _ = writeln!(out, "# HELP {name} {help}");
+        let _ = writeln!(out, "# TYPE {name} gauge");
+        for (project, facade) in projects {
+            if let Some(value) = value(facade) {
+                let _ = writeln!(out, "{name}{{project=\"{}\"}} {value}", escape(project));
+            }
+        }
+    }
+
+    let memory = crate::indexing::pipeline::metrics::MemorySnapshot::current();
+    out.push_str("# HELP codanna_process_resident_memory_bytes Resident memory of the server.\n");
+    out.push_str("# TYPE codanna_process_resident_memory_bytes gauge\n");
+    let _ = writeln!(out, "codanna_process_resident_memory_bytes {}", memory.rss);
+    out.push_str("# HELP codanna_process_virtual_memory_bytes Virtual memory of the server.\n");
+    out.push_str("# TYPE codanna_process_virtual_memory_bytes gauge\n");
+    let _ = writeln!(
+        out,
+        "codanna_process_virtual_memory_bytes {}",
+        memory.virtual_mem
+    );
+    out
+}
+
+/// Seconds since the index of `facade` was last written, as the hot
+/// reload watcher sees it
+fn index_age(facade: &IndexFacade) -> Option<f64> {
+    let meta = facade
+        .settings()
+        .index_path
+        .join("tantivy")
+        .join("meta.json");
+    let modified = std::fs::metadata(meta).ok()?.modified().ok()?;
+    Some(
+        SystemTime::now()
+            .duration_since(modified)
+            .unwrap_or_default()
+            .as_secs_f64(),
+    )
+}
+
+/// `value` as a label value
+fn escape(value: &str) -> String {
+    value
+        .replace('\\', "\\\\")
+        .replace('"', "\\\"")
+        .replace('\n', "\\n")
+}
+
+/// Router serving the metrics of the index of `facade` and of `projects`
+/// at `/metrics`
+#[cfg(feature = "http-server")]
+pub fn router(
+    facade: std::sync::Arc<tokio::sync::RwLock<IndexFacade>>,
+    projects: std::sync::Arc<crate::mcp::projects::Projects>,
+) -> axum::Router {
+    axum::Router::new()
+        .route("/metrics", axum::routing::get(serve_metrics))
+        .with_state((facade, projects))
+}
+
+#[cfg(feature = "http-server")]
+async fn serve_metrics(
+    axum::extract::State((facade, projects)): axum::extract::State<(
+        std::sync::Arc<tokio::sync::RwLock<IndexFacade>>,
+        std::sync::Arc<crate::mcp::projects::Projects>,
+    )>,
+) -> impl axum::response::IntoResponse {
+    let current = facade.read().await;
+    let mut others = Vec::new();
+    for (name, project) in &projects.others {
+        others.push((name.as_str(), project.facade.read().await));
+    }
+    let mut indexes = vec![(projects.current.as_str(), &*current)];
+    indexes.extend(others.iter().map(|(name, facade)| (*name, &**facade)));
+
+    (
+        [(
+            axum::http::header::CONTENT_TYPE,
+            "text/plain; version=0.0.4; charset=utf-8",
+        )],
+        render(&indexes),
+    )
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_tool_calls_fill_the_histogram() {
+        record_tool_call("metrics_test_tool", Duration::from_millis(30), false);
+        record_tool_call("metrics_test_tool", Duration::from_secs(20), true);
+
+        let out = render(&[]);
+        assert!(out.contains("codanna_tool_calls_total{tool=\"metrics_test_tool\"} 2\n"));
+        assert!(out.contains("codanna_tool_errors_total{tool=\"metrics_test_tool\"} 1\n"));
+        assert!(out.contains(
+            "codanna_tool_duration_seconds_bucket{tool=\"metrics_test_tool\",le=\"0.025\"} 0\n"
+        ));
+        assert!(out.contains(
+            "codanna_tool_duration_seconds_bucket{tool=\"metrics_test_tool\",le=\"0.05\"} 1\n"
+        ));
+        assert!(out.contains(
+            "codanna_tool_duration_seconds_bucket{tool=\"metrics_test_tool\",le=\"+Inf\"} 2\n"
+        ));
+        assert!(out.contains("# TYPE codanna_process_resident_memory_bytes gauge\n"));
+    }
+}
@@ -17,16 +17,36 @@
 //!    - Direct access to already-loaded index
 //!    - Most memory efficient for CLI operations
 
+pub mod auth;
 pub mod client;
+#[cfg(unix)]
+pub mod daemon;
 pub mod http_server;
 pub mod https_server;
+pub mod limits;
+pub mod metrics;
+#[cfg(feature = "https-server")]
+pub mod mtls;
 pub mod notifications;
+pub mod pagination;
+pub mod projects;
+pub mod prompts;
 pub mod requests;
+pub mod resources;
+#[cfg(feature = "http-server")]
+pub mod rest;
 pub mod server;
 pub mod service;
+pub mod shutdown;
+#[cfg(feature = "http-server")]
+pub mod sse_server;
 pub mod stale_server;
 pub mod tools;
+#[cfg(unix)]
+pub mod uds_server;
+#[cfg(feature = "http-server")]
+pub mod ws_server;
 
 pub use requests::*;
-pub use server::{CodeIntelligenceServer, format_relative_time};
+pub use server::{CodeIntelligenceServer, Watching, format_relative_time};
 pub use stale_server::StaleIndexServer;
@@ -0,0 +1,210 @@
+//! Mutual TLS for the HTTPS server
+//!
+//! With `[server.mtls]` in settings, HTTPS serve asks every client for a
+//! certificate and verifies it against the CA bundle `client_ca` names.
+//! The common name of a verified certificate, or the identity
+//! `[server.mtls.identities]` maps it to, is logged with each request.
+//! Bearer tokens, when listed too, are checked on top.
+
+use std::sync::Arc;
+
+use anyhow::Context;
+use axum::Extension;
+use axum_server::accept::Accept;
+use axum_server::tls_rustls::{RustlsAcceptor, RustlsConfig};
+use indexmap::IndexMap;
+use rustls::RootCertStore;
+use rustls::pki_types::pem::PemObject;
+use rustls::pki_types::{CertificateDer, PrivateKeyDer};
+use rustls::server::WebPkiClientVerifier;
+use tokio::io::{AsyncRead, AsyncWrite};
+use tokio_rustls::server::TlsStream;
+use tower::Layer;
+
+use crate::config::MtlsConfig;
+
+/// Who a client certificate says the client is
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ClientIdentity {
+    /// Identity logged for the client
+    pub name: String,
+    /// Common name of the certificate, empty when it has none
+    pub common_name: String,
+}
+
+impl ClientIdentity {
+    /// Identity of the client that sent the DER certificate `cert`
+    pub fn from_certificate(cert: &[u8], identities: &IndexMap<String, String>) -> Self {
+        let common_name = x509_parser::parse_x509_certificate(cert)
+            .ok()
+            .and_then(|(_, cert)| {
+                cert.subject()
+                    .iter_common_name()
+                    .next()
+                    .and_then(|cn| cn.as_str().ok().map(str::to_string))
+            })
+            .unwrap_or_default();
+        let name = identities
+            .get(&common_name)
+            .cloned()
+            .unwrap_or_else(|| common_name.clone());
+        Self { name, common_name }
+    }
+}
+
+/// TLS settings serving the certificate `cert_pem` with its key, and
+/// verifying client certificates as `mtls` says
+pub fn server_config(
+    cert_pem: &[u8],
+    key_pem: &[u8],
+    mtls: &MtlsConfig,
+) -> anyhow::Result<RustlsConfig> {
+    let certs = CertificateDer::pem_slice_iter(cert_pem)
+        .collect::<Result<Vec<_>, _>>()
+        .context("Failed to parse server certificate")?;
+    let key = PrivateKeyDer::from_pem_slice(key_pem).context("Failed to parse server key")?;
+
+    let mut roots = RootCertStore::empty();
+    let ca_pem = std::fs::read(&mtls.client_ca)
+        .with_context(|| format!("Failed to read client CA {}", mtls.client_ca.display()))?;
+    for ca in CertificateDer::pem_slice_iter(&ca_pem) {
+        let ca =
+            ca.with_context(|| format!("Failed to parse client CA {}", mtls.client_ca.display()))?;
+        roots
+            .add(ca)
+            .context("Client CA bundle holds an invalid certificate")?;
+    }
+    if roots.is_empty() {
+        anyhow::bail!(
+            "Client CA {} holds no certificate",
+            mtls.client_ca.display()
+        );
+    }
+
+    let verifier = WebPkiClientVerifier::builder(Arc::new(roots));
+    let verifier = if mtls.require_client_cert {
+        verifier
+    } else {
+        verifier.allow_unauthenticated()
+    };
+    let verifier = verifier
+        .build()
+        .context("Failed to build client certificate verifier")?;
+
+    let mut config = rustls::ServerConfig::builder()
+        .with_client_cert_verifier(verifier)
+        .with_single_cert(certs, key)
+        .context("Failed to configure TLS")?;
+    config.alpn_protocols = vec![b"h2".to_vec(), b"http/1.1".to_vec()];
+    Ok(RustlsConfig::from_config(Arc::new(config)))
+}
+
+/// TLS acceptor that hands the [`ClientIdentity`] of each connection to
+/// its requests, as an `Option<ClientIdentity>` extension
+#[derive(Clone)]
+pub struct IdentityAcceptor {
+    inner: RustlsAcceptor,
+    identities: Arc<IndexMap<String, String>>,
+}
+
+impl IdentityAcceptor {
+    pub fn new(config: RustlsConfig, mtls: &MtlsConfig) -> Self {
+        Self {
+            inner: RustlsAcceptor::new(config),
+            identities: Arc::new(mtls.identities.clone()),
+        }
+    }
+}
+
+impl<I, S> Accept<I, S> for IdentityAcceptor
+where
+    I: AsyncRead + AsyncWrite + Unpin + Send + 'static,
+    S: Send + 'static,
+{
+    type Stream = TlsStream<I>;
+    type Service = axum::middleware::AddExtension<S, Option<ClientIdentity>>;
+    type Future = std::pin::Pin<
+        Box<dyn Future<Output = std::io::Result<(Self::Stream, Self::Service)>> + Send>,
+    >;
+
+    fn accept(&self, stream: I, service: S) -> Self::Future {
+        let inner = self.inner.clone();
+        let identities = self.identities.clone();
+        Box::pin(async move {
+            let (stream, service) = inner.accept(stream, service).await?;
+            let identity = stream
+                .get_ref()
+                .1
+                .peer_certificates()
+                .and_then(|certs| certs.first())
+                .map(|cert| ClientIdentity::from_certificate(cert, &identities));
+            Ok((stream, Extension(identity).layer(service)))
+        })
+    }
+}
+
+/// Log each request with the identity of its client certificate
+pub async fn log_client_identity(
+    req: axum::extract::Request,
+    next: axum::middleware::Next,
+) -> axum::response::Response {
+    let identity = req
+        .extensions()
+        .get::<Option<ClientIdentity>>()
+        .cloned()
+        .flatten();
+    match identity {
+        Some(identity) if identity.name != identity.common_name => crate::log_event!(
+            "mtls",
+            "request",
+            "client '{}' (CN={}): {} {}",
+            identity.name,
+            identity.common_name,
+            req.method(),
+            req.uri().path()
+        ),
+        Some(identity) => crate::log_event!(
+            "mtls",
+            "request",
+            "client '{}': {} {}",
+            identity.name,
+            req.method(),
+            req.uri().path()
+        ),
+        None => crate::log_event!(
+            "mtls",
+            "request",
+            "no client certificate: {} {}",
+            req.method(),
+            req.uri().path()
+        ),
+    }
+    next.run(req).await
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn certificate(common_name: &str) -> Vec<u8> {
+        let mut params = rcgen::CertificateParams::new(vec!["localhost".to_string()]).unwrap();
+        params
+            .distinguished_name
+            .push(rcgen::DnType::CommonName, common_name);
+        let key = rcgen::KeyPair::generate().unwrap();
+        params.self_signed(&key).unwrap().der().to_vec()
+    }
+
+    #[test]
+    fn test_identity_maps_common_name() {
+        let identities = IndexMap::from([("build-agent-01".to_string(), "ci".to_string())]);
+
+        let identity =
+            ClientIdentity::from_certificate(&certificate("build-agent-01"), &identities);
+        assert_eq!(identity.name, "ci");
+        assert_eq!(identity.common_name, "build-agent-01");
+
+        let identity = ClientIdentity::from_certificate(&certificate("laptop"), &identities);
+        assert_eq!(identity.name, "laptop");
+    }
+}
@@ -12,6 +12,10 @@ pub enum FileChangeEvent {
     FileCreated { path: PathBuf },
     FileDeleted { path: PathBuf },
     IndexReloaded, // Entire index was reloaded from disk
+    // Files one batch of watcher changes re-indexed or removed, sent after
+    // the per-file events of the batch
+    IndexUpdated { files: Vec<PathBuf> },
+    SettingsReloaded, // settings.toml changed; the facade holds the values applied
 }
 
 /// Manages notification broadcasting to multiple MCP server instances
@@ -68,8 +72,16 @@ impl super::CodeIntelligenceServer {
                 Ok(event) => {
                     crate::debug_event!("mcp-notify", "received", "{event:?}");
 
+                    let subscribed = self.subscribed_resources_changed_by(&event).await;
                     let peer_guard = self.peer.lock().await;
                     if let Some(peer) = peer_guard.as_ref() {
+                        // Resources the client subscribed to
+                        for uri in subscribed {
+                            let _ = peer
+                                .notify_resource_updated(ResourceUpdatedNotificationParam::new(uri))
+                                .await;
+                        }
+
                         match event {
                             FileChangeEvent::FileReindexed { path } => {
                                 let path_str = path.display().to_string();
@@ -175,6 +187,34 @@ impl super::CodeIntelligenceServer {
 
                                 crate::debug_event!("mcp-notify", "sent", "IndexReloaded");
                             }
+                            // Read by the servers themselves, nothing to tell clients
+                            FileChangeEvent::SettingsReloaded => {}
+                            FileChangeEvent::IndexUpdated { files } => {
+                                let files: Vec<String> = files
+                                    .iter()
+                                    .map(|path| path.display().to_string())
+                                    .collect();
+
+                                // One notification per batch, so clients can drop
+                                // cached answers about these files at once
+                                let _ = peer
+                                    .send_notification(ServerNotification::CustomNotification(
+                                        CustomNotification::new(
+                                            "notifications/codanna/index-updated",
+                                            Some(serde_json::json!({
+                                                "files": files
+                                            })),
+                                        ),
+                                    ))
+                                    .await;
+
+                                crate::debug_event!(
+                                    "mcp-notify",
+                                    "sent",
+                                    "IndexUpdated {} file(s)",
+                                    files.len()
+                                );
+                            }
                         }
                     } else {
                         crate::debug_event!("mcp-notify", "dropped", "no peer");
@@ -0,0 +1,121 @@
+//! Cursor pagination of large tool results
+//!
+//! Search and relationship tools take `page_size` and `cursor`. A page
+//! with more results after it names the cursor of the next one; passed
+//! back with the same arguments, it returns the results that follow.
+//! Results come in the same order on every call against the same index,
+//! so walking the cursors visits each result once.
+
+/// Most results one page holds
+pub const MAX_PAGE_SIZE: usize = 1000;
+
+/// One page of a result list
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct Page {
+    /// Results before the page
+    pub offset: usize,
+    /// Results on the page
+    pub size: usize,
+}
+
+impl Page {
+    /// The page `cursor` starts, `page_size` results long, or
+    /// `default_size` long when no size is given.
+    pub fn new(
+        cursor: Option<&str>,
+        page_size: Option<u32>,
+        default_size: usize,
+    ) -> Result<Self, String> {
+        let offset = match cursor {
+            Some(cursor) => decode_cursor(cursor).ok_or_else(|| {
+                format!("Invalid cursor '{cursor}': pass the cursor a previous page returned")
+            })?,
+            None => 0,
+        };
+        let size = match page_size {
+            Some(0) => return Err("page_size must be at least 1".to_string()),
+            Some(size) => (size as usize).min(MAX_PAGE_SIZE),
+            None => default_size,
+        };
+        Ok(Self { offset, size })
+    }
+
+    /// Results to ask for: all of them up to the end of the page, and one
+    /// more to tell whether another page follows
+    pub fn fetch_limit(&self) -> usize {
+        self.offset.saturating_add(self.size).saturating_add(1)
+    }
+
+    /// This page of `results`, which start at the first result, and the
+    /// cursor of the next page when there is one
+    pub fn slice<'a, T>(&self, results: &'a [T]) -> (&'a [T], Option<String>) {
+        let start = self.offset.min(results.len());
+        let end = self.offset.saturating_add(self.size).min(results.len());
+        let next = (end < results.len()).then(|| encode_cursor(end));
+        (&results[start..end], next)
+    }
+
+    /// [`Page::slice`] of an owned list
+    pub fn take<T>(&self, mut results: Vec<T>) -> (Vec<T>, Option<String>) {
+        let (page, next) = self.slice(&results);
+        let len = page.len();
+        results.drain(..self.offset.min(results.len()));
+        results.truncate(len);
+        (results, next)
+    }
+}
+
+/// Closing line of a text page with more results after it
+pub fn next_page_line(cursor: &str) -> String {
+    format!("More results: call again with cursor \"{cursor}\" for the next page\n")
+}
+
+// A cursor is the offset of its page in hex, behind a letter so that the
+// CLI never reads it as a number
+fn encode_cursor(offset: usize) -> String {
+    format!("c{offset:x}")
+}
+
+fn decode_cursor(cursor: &str) -> Option<usize> {
+    usize::from_str_radix(cursor.strip_prefix('c')?, 16).ok()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_cursors_walk_every_result_once() {
+        let results: Vec<u32> = (0..7).collect();
+        let mut walked = Vec::new();
+        let mut cursor = None;
+        loop {
+            let page = Page::new(cursor.as_deref(), Some(3), 10).unwrap();
+            let (items, next) = page.take(results[..page.fetch_limit().min(7)].to_vec());
+            walked.extend(items);
+            match next {
+                Some(next) => cursor = Some(next),
+                None => break,
+            }
+        }
+        assert_eq!(walked, results);
+    }
+
+    #[test]
+    fn test_invalid_pages_are_rejected() {
+        assert!(Page::new(Some("12"), None, 10).is_err());
+        assert!(Page::new(Some("cxyz"), None, 10).is_err());
+        assert!(Page::new(None, Some(0), 10).is_err());
+        assert_eq!(
+            Page::new(None, Some(100_000), 10).unwrap().size,
+            MAX_PAGE_SIZE
+        );
+        assert_eq!(
+            Page::new(None, None, 10).unwrap(),
+            Page {
+                offset: 0,
+                size: 10
+            }
+        );
+    }
+}
@@ -0,0 +1,160 @@
+//! Serving several projects from one MCP server
+//!
+//! `[server.projects]` in settings, and `codanna serve --project
+//! <name>=<path>`, name other projects for the server to load next to the
+//! one it runs in. Every tool takes an optional `project` naming the index
+//! to answer from, this project's when left out, and `list_projects` lists
+//! them. Indexes embedded with the same model share one loaded model.
+
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+use std::time::Duration;
+
+use anyhow::Context;
+use indexmap::IndexMap;
+use tokio::sync::RwLock;
+
+use crate::documents::DocumentStore;
+use crate::indexing::facade::IndexFacade;
+use crate::{IndexPersistence, Settings};
+
+/// Index and documents of one project
+#[derive(Clone)]
+pub struct Project {
+    pub facade: Arc<RwLock<IndexFacade>>,
+    pub document_store: Option<Arc<RwLock<DocumentStore>>>,
+}
+
+impl Project {
+    /// Load the project whose `.codanna` directory is under `root`
+    pub fn load(root: &Path) -> anyhow::Result<Self> {
+        let config_path = root
+            .join(crate::init::local_dir_name())
+            .join("settings.toml");
+        if !config_path.exists() {
+            anyhow::bail!(
+                "{} not found; run 'codanna init' and 'codanna index' there",
+                config_path.display()
+            );
+        }
+        let mut settings = Settings::load_from(&config_path)
+            .with_context(|| format!("Failed to load {}", config_path.display()))?;
+        if settings.workspace_root.is_none() {
+            settings.workspace_root = Some(root.to_path_buf());
+        }
+        settings.index_path = crate::init::resolve_index_path(&settings, Some(&config_path));
+        let settings = Arc::new(settings);
+
+        let facade = IndexPersistence::new(settings.index_path.clone())
+            .load_facade(settings.clone())
+            .with_context(|| {
+                format!(
+                    "Failed to load the index at {}",
+                    settings.index_path.display()
+                )
+            })?;
+        let document_store = crate::documents::load_from_settings(&settings);
+        Ok(Self {
+            facade: Arc::new(RwLock::new(facade)),
+            document_store,
+        })
+    }
+}
+
+/// The projects a server answers for besides the one it runs in
+#[derive(Clone, Default)]
+pub struct Projects {
+    /// Name of the project the server runs in
+    pub current: String,
+    pub others: IndexMap<String, Project>,
+}
+
+impl Projects {
+    /// Load the projects of `[server.projects]`, the current one named after
+    /// the workspace root of `config`. Relative roots are taken from that
+    /// workspace root. A project that fails to load is left out with a
+    /// warning.
+    pub fn load(config: &Settings) -> Self {
+        let current = config
+            .workspace_root
+            .as_deref()
+            .and_then(Path::file_name)
+            .map(|name| name.to_string_lossy().into_owned())
+            .unwrap_or_else(|| "default".to_string());
+
+        let mut others = IndexMap::new();
+        for (name, root) in &config.server.projects {
+            let root = match &config.workspace_root {
+                Some(workspace) => workspace.join(root),
+                None => root.clone(),
+            };
+            if *name == current {
+                tracing::warn!(
+                    "[projects] '{name}' names the current project, {} left out",
+                    root.display()
+                );
+                continue;
+            }
+            match Project::load(&root) {
+                Ok(project) => {
+                    crate::log_event!("projects", "loaded", "'{name}' from {}", root.display());
+                    others.insert(name.clone(), project);
+                }
+                Err(e) => tracing::warn!("[projects] project '{name}' left out: {e:#}"),
+            }
+        }
+        Self { current, others }
+    }
+
+    /// Whether the server answers for other projects too
+    pub fn is_empty(&self) -> bool {
+        self.others.is_empty()
+    }
+
+    /// Names of all projects, the current one first
+    pub fn names(&self) -> Vec<&str> {
+        std::iter::once(self.current.as_str())
+            .chain(self.others.keys().map(String::as_str))
+            .collect()
+    }
+
+    /// Reload the index of each other project when it changes on disk
+    pub fn spawn_hot_reload(&self, check_interval: Duration) {
+        for (name, project) in &self.others {
+            let facade = project.facade.clone();
+            tokio::spawn(async move {
+                let settings = facade.read().await.settings().clone();
+                crate::watcher::HotReloadWatcher::new(facade, settings, check_interval)
+                    .watch()
+                    .await
+            });
+            crate::debug_event!("projects", "hot-reload started", "'{name}'");
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_missing_projects_are_left_out() {
+        let mut config = Settings {
+            workspace_root: Some(PathBuf::from("/src/frontend")),
+            ..Default::default()
+        };
+        config
+            .server
+            .projects
+            .insert("frontend".to_string(), PathBuf::from("/src/frontend"));
+        config
+            .server
+            .projects
+            .insert("backend".to_string(), PathBuf::from("/nonexistent/backend"));
+
+        let projects = Projects::load(&config);
+        assert_eq!(projects.current, "frontend");
+        assert!(projects.is_empty());
+        assert_eq!(projects.names(), vec!["frontend"]);
+    }
+}
@@ -0,0 +1,205 @@
+//! Built-in prompts: explain_symbol, trace_call_path, blast_radius.
+//!
+//! Each prompt runs the tools its workflow starts with and hands their
+//! output to the model along with what to do next, so a client that only
+//! fills in prompts gets the same workflow an agent chaining the tools
+//! would follow.
+
+use rmcp::model::ErrorData as McpError;
+use rmcp::model::*;
+use rmcp::{handler::server::wrapper::Parameters, prompt, prompt_router, schemars};
+use serde::Deserialize;
+use serde_json::json;
+
+use crate::mcp::server::CodeIntelligenceServer;
+use crate::queries::QueryCall;
+
+#[derive(Debug, Deserialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct ExplainSymbolArgs {
+    /// Name of the symbol to explain
+    pub symbol: String,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct TraceCallPathArgs {
+    /// Function the path starts at
+    pub from: String,
+    /// Function the path ends at
+    pub to: String,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct BlastRadiusArgs {
+    /// Name of the symbol about to be edited
+    pub symbol: String,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    pub project: Option<String>,
+}
+
+/// Levels of calls trace_call_path follows from each end
+const TRACE_DEPTH: u32 = 3;
+
+#[prompt_router(router = prompts_router, vis = "pub(crate)")]
+impl CodeIntelligenceServer {
+    #[prompt(
+        name = "explain_symbol",
+        description = "Explain the role of a symbol: what it is, what it calls and who calls it"
+    )]
+    pub async fn explain_symbol(
+        &self,
+        Parameters(ExplainSymbolArgs { symbol, project }): Parameters<ExplainSymbolArgs>,
+    ) -> Result<GetPromptResult, McpError> {
+        let mut prompt = format!(
+            "Explain the role of `{symbol}` in this codebase: what it is for, what it relies on \
+            and what relies on it. The index results below are the starting point; read the \
+            source at the locations they give before describing behaviour, and say so when \
+            they name more than one `{symbol}`.\n\n"
+        );
+        for (tool, arguments) in [
+            ("find_symbol", json!({ "name": symbol })),
+            ("get_calls", json!({ "function_name": symbol })),
+            ("find_callers", json!({ "function_name": symbol })),
+        ] {
+            prompt.push_str(&self.tool_section(tool, arguments, project.as_deref()).await);
+        }
+        Ok(user_prompt(format!("Explain the role of {symbol}"), prompt))
+    }
+
+    #[prompt(
+        name = "trace_call_path",
+        description = "Trace the calls that lead from one function to another"
+    )]
+    pub async fn trace_call_path(
+        &self,
+        Parameters(TraceCallPathArgs { from, to, project }): Parameters<TraceCallPathArgs>,
+    ) -> Result<GetPromptResult, McpError> {
+        let mut prompt = format!(
+            "Trace how `{from}` reaches `{to}`. Below are the calls `{from}` makes and the \
+            callers of `{to}`, {TRACE_DEPTH} levels deep each. Find where they meet and give the \
+            path as a chain of calls with the file and line of each step. If they do not meet, \
+            say so and follow get_calls and find_callers further from the nearest ends.\n\n"
+        );
+        for (tool, arguments) in [
+            (
+                "get_calls",
+                json!({ "function_name": from, "depth": TRACE_DEPTH }),
+            ),
+            (
+                "find_callers",
+                json!({ "function_name": to, "depth": TRACE_DEPTH }),
+            ),
+        ] {
+            prompt.push_str(&self.tool_section(tool, arguments, project.as_deref()).await);
+        }
+        Ok(user_prompt(format!("Trace {from} to {to}"), prompt))
+    }
+
+    #[prompt(
+        name = "blast_radius",
+        description = "Assess what editing a symbol could break and which tests cover it"
+    )]
+    pub async fn blast_radius(
+        &self,
+        Parameters(BlastRadiusArgs { symbol, project }): Parameters<BlastRadiusArgs>,
+    ) -> Result<GetPromptResult, McpError> {
+        let mut prompt = format!(
+            "Assess the blast radius of editing `{symbol}`. Below is everything that depends on \
+            it, grouped by distance, and the tests that exercise it. Name the dependents most \
+            likely to break and why, the files to review with the change, and the tests to run; \
+            call out dependents no test reaches.\n\n"
+        );
+        for (tool, arguments) in [
+            ("impact_of_change", json!({ "symbol_name": symbol })),
+            ("find_tests", json!({ "symbol_name": symbol })),
+        ] {
+            prompt.push_str(&self.tool_section(tool, arguments, project.as_deref()).await);
+        }
+        Ok(user_prompt(
+            format!("Blast radius of editing {symbol}"),
+            prompt,
+        ))
+    }
+}
+
+impl CodeIntelligenceServer {
+    /// The output of `tool` called with `arguments` on `project`, under a
+    /// heading naming the call
+    async fn tool_section(
+        &self,
+        tool: &str,
+        mut arguments: serde_json::Value,
+        project: Option<&str>,
+    ) -> String {
+        arguments["project"] = json!(project);
+        let serde_json::Value::Object(arguments) = arguments else {
+            unreachable!("tool arguments are an object");
+        };
+        let call = QueryCall::new(tool, arguments);
+        let output = match self.run_query_call(&call).await {
+            Ok(result) => result
+                .content
+                .iter()
+                .filter_map(|content| match content {
+                    ContentBlock::Text(text) => Some(text.text.as_str()),
+                    _ => None,
+                })
+                .collect::<Vec<_>>()
+                .join("\n"),
+            Err(e) => format!("Failed: {}", e.message),
+        };
+        format!("## {call}\n\n{}\n\n", output.trim_end())
+    }
+}
+
+fn user_prompt(description: String, text: String) -> GetPromptResult {
+    GetPromptResult::new(vec![PromptMessage::new_text(PromptMessageRole::User, text)])
+        .with_description(description)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::Settings;
+    use crate::indexing::facade::IndexFacade;
+    use std::sync::Arc;
+
+    #[tokio::test]
+    async fn test_blast_radius_embeds_tool_output() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("shapes.py");
+        std::fs::write(
+            &source,
+            "def area():\n    return make()\n\n\ndef make():\n    return 1\n",
+        )
+        .unwrap();
+        let mut facade = IndexFacade::new(Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+        let server = CodeIntelligenceServer::new(facade);
+
+        let result = server
+            .blast_radius(Parameters(BlastRadiusArgs {
+                symbol: "make".to_string(),
+                project: None,
+            }))
+            .await
+            .unwrap();
+        let PromptMessageContent::Text { text, .. } = &result.messages[0].content else {
+            panic!("expected a text message");
+        };
+        assert!(text.contains("## impact_of_change symbol_name:make"));
+        assert!(text.contains("## find_tests symbol_name:make"));
+        assert!(text.contains("area"), "the caller is among the dependents");
+    }
+}
@@ -11,6 +11,9 @@ pub struct FindSymbolRequest {
     /// Filter by programming language (e.g., "rust", "python", "typescript", "php")
     #[serde(skip_serializing_if = "Option::is_none")]
     pub lang: Option<String>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
@@ -22,6 +25,18 @@ pub struct GetCallsRequest {
     /// Symbol ID for direct lookup (recommended to avoid ambiguity)
     #[serde(skip_serializing_if = "Option::is_none")]
     pub symbol_id: Option<u32>,
+    /// Follow calls transitively up to this many edges (default: 1, direct calls only)
+    #[serde(default = "default_call_depth")]
+    pub depth: u32,
+    /// Results per page, the rest reached with `cursor` (default: all of them)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub page_size: Option<u32>,
+    /// Cursor of the page to return, as the previous page named it
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub cursor: Option<String>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
@@ -33,6 +48,35 @@ pub struct FindCallersRequest {
     /// Symbol ID for direct lookup (recommended to avoid ambiguity)
     #[serde(skip_serializing_if = "Option::is_none")]
     pub symbol_id: Option<u32>,
+    /// Follow callers transitively up to this many edges (default: 1, direct callers only)
+    #[serde(default = "default_call_depth")]
+    pub depth: u32,
+    /// Results per page, the rest reached with `cursor` (default: all of them)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub page_size: Option<u32>,
+    /// Cursor of the page to return, as the previous page named it
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub cursor: Option<String>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct GetCallHierarchyRequest {
+    /// Name of the function to analyze (use symbol_id for unambiguous lookup)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub function_name: Option<String>,
+    /// Symbol ID for direct lookup (recommended to avoid ambiguity)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_id: Option<u32>,
+    /// Call edges to follow each way, callers and callees (default: 3)
+    #[serde(default = "default_depth")]
+    pub depth: u32,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
@@ -47,6 +91,73 @@ pub struct AnalyzeImpactRequest {
     /// Maximum depth to search (default: 3)
     #[serde(default = "default_depth", alias = "depth")]
     pub max_depth: u32,
+    /// Results per page, the rest reached with `cursor` (default: all of them)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub page_size: Option<u32>,
+    /// Cursor of the page to return, as the previous page named it
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub cursor: Option<String>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct GetTypeHierarchyRequest {
+    /// Name of the struct, trait, class or interface (use symbol_id for unambiguous lookup)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub type_name: Option<String>,
+    /// Symbol ID for direct lookup (recommended to avoid ambiguity)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_id: Option<u32>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct GetFileOutlineRequest {
+    /// Path of the file, as indexed or its trailing part (e.g., "src/lib.rs")
+    pub path: String,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct ImpactOfChangeRequest {
+    /// Name of the symbol about to change (use symbol_id for unambiguous lookup)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_name: Option<String>,
+    /// Symbol ID for direct lookup (recommended to avoid ambiguity)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_id: Option<u32>,
+    /// Maximum distance to follow dependents (default: 3)
+    #[serde(default = "default_depth")]
+    pub max_depth: u32,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct FindTestsRequest {
+    /// Name of the function to find tests for (use symbol_id for unambiguous lookup)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_name: Option<String>,
+    /// Symbol ID for direct lookup (recommended to avoid ambiguity)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_id: Option<u32>,
+    /// Follow calls through test helpers up to this many edges (default: 3)
+    #[serde(default = "default_depth")]
+    pub max_depth: u32,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
@@ -66,6 +177,41 @@ pub struct SearchSymbolsRequest {
     /// Filter by programming language (e.g., "rust", "python", "typescript", "php")
     #[serde(skip_serializing_if = "Option::is_none")]
     pub lang: Option<String>,
+    /// How to match the query: "text" (full-text, default), "fuzzy"
+    /// (typo-tolerant names, any case or separators) or "regex" (names)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub mode: Option<String>,
+    /// Results per page, the rest reached with `cursor` (default: limit)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub page_size: Option<u32>,
+    /// Cursor of the page to return, as the previous page named it
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub cursor: Option<String>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct QuerySymbolsRequest {
+    /// Filters that all must hold, e.g. `kind:function lang:rust path:src/parsing/**
+    /// calls:parse_file doc:"utf-8"`. Keys: kind, lang, name, module, path, author,
+    /// doc, sig, calls, called_by, implements, extends, uses; `-key:value` negates,
+    /// `a,b` takes either value, bare words are searched for
+    pub query: String,
+    /// Maximum number of results (default: 10)
+    #[serde(default = "default_limit")]
+    pub limit: u32,
+    /// Results per page, the rest reached with `cursor` (default: limit)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub page_size: Option<u32>,
+    /// Cursor of the page to return, as the previous page named it
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub cursor: Option<String>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
@@ -82,6 +228,54 @@ pub struct SemanticSearchRequest {
     /// Filter by programming language (e.g., "rust", "python", "typescript", "php")
     #[serde(skip_serializing_if = "Option::is_none")]
     pub lang: Option<String>,
+    /// Filter by symbol kind (e.g., "Function", "Method", "Class")
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub kind: Option<String>,
+    /// Only symbols in files matching this glob (e.g., "**/services/**") or under this path
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub path: Option<String>,
+    /// Filter by visibility: "public", "crate", "module" or "private"
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub visibility: Option<String>,
+    /// Embeddings to compare: "docs" (doc comments), "code" (signatures) or "both"
+    /// (default: both when code embeddings are indexed, else docs)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub vectors: Option<String>,
+    /// Results per page, the rest reached with `cursor` (default: limit)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub page_size: Option<u32>,
+    /// Cursor of the page to return, as the previous page named it
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub cursor: Option<String>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct FindSimilarSymbolsRequest {
+    /// Name of the symbol to compare with (use symbol_id for unambiguous lookup)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_name: Option<String>,
+    /// Symbol ID for direct lookup (recommended to avoid ambiguity)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub symbol_id: Option<u32>,
+    /// Maximum number of results (default: 10)
+    #[serde(default = "default_limit")]
+    pub limit: u32,
+    /// Filter by programming language (e.g., "rust", "python", "typescript", "php")
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub lang: Option<String>,
+    /// Filter by symbol kind (e.g., "Function", "Method", "Class")
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub kind: Option<String>,
+    /// Only symbols in files matching this glob (e.g., "**/services/**") or under this path
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub path: Option<String>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
@@ -98,19 +292,65 @@ pub struct SemanticSearchWithContextRequest {
     /// Filter by programming language (e.g., "rust", "python", "typescript", "php")
     #[serde(skip_serializing_if = "Option::is_none")]
     pub lang: Option<String>,
+    /// Filter by symbol kind (e.g., "Function", "Method", "Class")
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub kind: Option<String>,
+    /// Only symbols in files matching this glob (e.g., "**/services/**") or under this path
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub path: Option<String>,
+    /// Filter by visibility: "public", "crate", "module" or "private"
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub visibility: Option<String>,
+    /// Include the source of each symbol with this many lines around it, and
+    /// its full documentation (capped at 50 lines and 8 KiB per result)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub context_lines: Option<u32>,
+    /// Results per page, the rest reached with `cursor` (default: limit)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub page_size: Option<u32>,
+    /// Cursor of the page to return, as the previous page named it
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub cursor: Option<String>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct SearchFeedbackRequest {
+    /// The query the results were returned for
+    pub query: String,
+    /// Symbol IDs of results that answer the query well
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub relevant: Option<Vec<u32>>,
+    /// Symbol IDs of results that do not belong in its answer
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub irrelevant: Option<Vec<u32>>,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct GetIndexInfoRequest {
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
 }
 
 #[derive(Debug, Deserialize, Serialize)]
 #[serde(deny_unknown_fields)]
-pub struct GetIndexInfoRequest {}
+pub struct ListProjectsRequest {}
 
-impl schemars::JsonSchema for GetIndexInfoRequest {
+impl schemars::JsonSchema for ListProjectsRequest {
     fn schema_name() -> std::borrow::Cow<'static, str> {
-        std::borrow::Cow::Borrowed("GetIndexInfoRequest")
+        std::borrow::Cow::Borrowed("ListProjectsRequest")
     }
 
     fn schema_id() -> std::borrow::Cow<'static, str> {
-        std::borrow::Cow::Borrowed(concat!(module_path!(), "::GetIndexInfoRequest"))
+        std::borrow::Cow::Borrowed(concat!(module_path!(), "::ListProjectsRequest"))
     }
 
     fn json_schema(_generator: &mut schemars::SchemaGenerator) -> schemars::Schema {
@@ -142,12 +382,72 @@ pub struct SearchDocumentsRequest {
     /// Maximum number of results (default: 5)
     #[serde(default = "default_context_limit")]
     pub limit: u32,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct RunSavedQueryRequest {
+    /// Name the query was saved under with `codanna query save`
+    pub name: String,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct FindUnusedSymbolsRequest {
+    /// Filter by symbol kind (e.g., "Function", "Method"; default: analysis.unused.kinds)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub kind: Option<String>,
+    /// Only symbols in files under this path
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub path: Option<String>,
+    /// Filter by programming language (e.g., "rust", "python", "typescript", "php")
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub lang: Option<String>,
+    /// Also report public symbols, which code outside the index may use (default: false)
+    #[serde(default)]
+    pub include_public: bool,
+    /// Maximum number of results (default: 50)
+    #[serde(default = "default_unused_limit")]
+    pub limit: u32,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
+}
+
+#[derive(Debug, Deserialize, Serialize, schemars::JsonSchema)]
+#[serde(deny_unknown_fields)]
+pub struct FindTodosRequest {
+    /// Only these tags, comma-separated (e.g., "FIXME" or "TODO,HACK"; default: all)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub tag: Option<String>,
+    /// Only todos in files under this path
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub path: Option<String>,
+    /// Only todos naming this author, as in TODO(alice)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub author: Option<String>,
+    /// Maximum number of results (default: 50)
+    #[serde(default = "default_todo_limit")]
+    pub limit: u32,
+    /// Project to answer from, as list_projects names it (default: the server's own)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub project: Option<String>,
 }
 
 fn default_depth() -> u32 {
     3
 }
 
+fn default_call_depth() -> u32 {
+    1
+}
+
 fn default_limit() -> u32 {
     10
 }
@@ -156,6 +456,14 @@ fn default_context_limit() -> u32 {
     5
 }
 
+fn default_unused_limit() -> u32 {
+    50
+}
+
+fn default_todo_limit() -> u32 {
+    50
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -190,6 +498,20 @@ mod tests {
             )
             .is_err()
         );
+        assert!(
+            serde_json::from_value::<GetTypeHierarchyRequest>(json!({"type": "Parser"})).is_err()
+        );
+        assert!(
+            serde_json::from_value::<ImpactOfChangeRequest>(json!({"depth": 2, "symbol_id": 1}))
+                .is_err()
+        );
+        assert!(
+            serde_json::from_value::<FindTestsRequest>(json!({"function_name": "add"})).is_err()
+        );
+        assert!(
+            serde_json::from_value::<FindUnusedSymbolsRequest>(json!({"public": true})).is_err()
+        );
+        assert!(serde_json::from_value::<FindTodosRequest>(json!({"tags": "TODO"})).is_err());
         assert!(serde_json::from_value::<GetIndexInfoRequest>(json!({"bogus": 1})).is_err());
         assert!(
             serde_json::from_value::<SearchDocumentsRequest>(
@@ -209,6 +531,16 @@ mod tests {
         assert_eq!(req.max_depth, 3);
     }
 
+    #[test]
+    fn call_depth_defaults_to_direct_calls() {
+        let req: GetCallsRequest =
+            serde_json::from_value(json!({"function_name": "x"})).expect("default applies");
+        assert_eq!(req.depth, 1);
+        let req: FindCallersRequest =
+            serde_json::from_value(json!({"symbol_id": 7, "depth": 4})).expect("depth accepted");
+        assert_eq!(req.depth, 4);
+    }
+
     #[test]
     fn rejection_names_the_field_and_accepted_keys() {
         let err = serde_json::from_value::<GetCallsRequest>(json!({"bogus": 1})).unwrap_err();
@@ -0,0 +1,279 @@
+//! The index as MCP resources
+//!
+//! Next to its tools the server publishes, for the project it runs in:
+//!
+//! - `codanna://files`, the indexed files;
+//! - `codanna://outline/{path}`, the symbols of one file in line order;
+//! - `codanna://stats`, the size of the index.
+//!
+//! All three are JSON. A client that subscribes to one is told when it
+//! changes: an outline when its file is re-indexed, the file list when
+//! files come and go, the stats on any of these.
+
+use rmcp::model::ErrorData as McpError;
+use rmcp::model::*;
+use serde_json::json;
+
+use crate::indexing::facade::IndexFacade;
+use crate::mcp::notifications::FileChangeEvent;
+use crate::mcp::pagination::{MAX_PAGE_SIZE, Page};
+use crate::mcp::server::CodeIntelligenceServer;
+use crate::mcp::service::doc_summary;
+
+pub const FILES_URI: &str = "codanna://files";
+pub const STATS_URI: &str = "codanna://stats";
+const OUTLINE_PREFIX: &str = "codanna://outline/";
+const JSON: &str = "application/json";
+
+/// URI of the outline of the indexed file `path`
+pub fn outline_uri(path: &str) -> String {
+    format!("{OUTLINE_PREFIX}{path}")
+}
+
+/// Symbol and file counts of `indexer`, with its semantic search
+pub(crate) fn index_stats(indexer: &IndexFacade) -> serde_json::Value {
+    let semantic = if let Some(metadata) = indexer.get_semantic_metadata() {
+        let live_count = indexer.semantic_search_embedding_count();
+        json!({
+            "enabled": true,
+            "model": metadata.model_name,
+            "embeddings": live_count,
+            "dimensions": metadata.dimension
+        })
+    } else {
+        json!({
+            "enabled": false
+        })
+    };
+
+    json!({
+        "symbols": indexer.symbol_count(),
+        "files": indexer.file_count(),
+        "relationships": indexer.relationship_count(),
+        "semantic": semantic
+    })
+}
+
+/// The outline of the indexed file `path`, None when it is not indexed
+fn outline(indexer: &IndexFacade, path: &str) -> Option<serde_json::Value> {
+    let (file_id, path) = indexer
+        .get_indexed_files()
+        .into_iter()
+        .find(|(_, shown)| shown == path)?;
+    let symbols: Vec<_> = indexer
+        .get_file_outline(file_id)
+        .iter()
+        .map(|symbol| {
+            json!({
+                "name": &*symbol.name,
+                "kind": format!("{:?}", symbol.kind),
+                "line": symbol.range.start_line + 1,
+                "end_line": symbol.range.end_line + 1,
+                "signature": symbol.signature.as_deref(),
+                "doc": symbol.doc_comment.as_deref().and_then(doc_summary),
+            })
+        })
+        .collect();
+    Some(json!({ "path": path, "symbols": symbols }))
+}
+
+fn json_contents(uri: &str, value: &serde_json::Value) -> ResourceContents {
+    ResourceContents::text(serde_json::to_string_pretty(value).unwrap_or_default(), uri)
+        .with_mime_type(JSON)
+}
+
+impl CodeIntelligenceServer {
+    /// The file list and stats, then the outline of every indexed file,
+    /// [`MAX_PAGE_SIZE`] resources to a page
+    pub(crate) async fn list_index_resources(
+        &self,
+        cursor: Option<&str>,
+    ) -> Result<ListResourcesResult, McpError> {
+        let page = Page::new(cursor, None, MAX_PAGE_SIZE)
+            .map_err(|message| McpError::invalid_params(message, None))?;
+        let indexer = self.facade.read().await;
+
+        let mut resources = vec![
+            RawResource::new(FILES_URI, "files")
+                .with_description("Indexed files")
+                .with_mime_type(JSON)
+                .no_annotation(),
+            RawResource::new(STATS_URI, "stats")
+                .with_description("Symbols, files and relationships in the index")
+                .with_mime_type(JSON)
+                .no_annotation(),
+        ];
+        resources.extend(indexer.get_indexed_files().into_iter().map(|(_, path)| {
+            RawResource::new(outline_uri(&path), path)
+                .with_description("Symbols of the file in line order")
+                .with_mime_type(JSON)
+                .no_annotation()
+        }));
+
+        let (resources, next_cursor) = page.take(resources);
+        let mut result = ListResourcesResult::with_all_items(resources);
+        result.next_cursor = next_cursor;
+        Ok(result)
+    }
+
+    /// The template of the outline URIs
+    pub(crate) fn index_resource_templates(&self) -> ListResourceTemplatesResult {
+        ListResourceTemplatesResult::with_all_items(vec![
+            RawResourceTemplate::new(format!("{OUTLINE_PREFIX}{{path}}"), "outline")
+                .with_description("Symbols of an indexed file in line order")
+                .with_mime_type(JSON)
+                .no_annotation(),
+        ])
+    }
+
+    /// The contents of the resource `uri`
+    pub(crate) async fn read_index_resource(
+        &self,
+        uri: &str,
+    ) -> Result<ReadResourceResult, McpError> {
+        let indexer = self.facade.read().await;
+        let value = match uri {
+            FILES_URI => {
+                let files: Vec<String> = indexer
+                    .get_indexed_files()
+                    .into_iter()
+                    .map(|(_, path)| path)
+                    .collect();
+                json!({ "files": files })
+            }
+            STATS_URI => index_stats(&indexer),
+            _ => uri
+                .strip_prefix(OUTLINE_PREFIX)
+                .and_then(|path| outline(&indexer, path))
+                .ok_or_else(|| {
+                    McpError::resource_not_found(
+                        format!("Unknown resource '{uri}'. List resources for the URIs served"),
+                        None,
+                    )
+                })?,
+        };
+        Ok(ReadResourceResult::new(vec![json_contents(uri, &value)]))
+    }
+
+    /// Start telling this connection when the resource `uri` changes
+    pub(crate) fn subscribe_resource(&self, uri: String) {
+        crate::debug_event!("resources", "subscribed", "{uri}");
+        self.subscriptions.lock().unwrap().insert(uri);
+    }
+
+    pub(crate) fn unsubscribe_resource(&self, uri: &str) {
+        self.subscriptions.lock().unwrap().remove(uri);
+    }
+
+    /// The resources this connection subscribed to that `event` changes
+    pub(crate) async fn subscribed_resources_changed_by(
+        &self,
+        event: &FileChangeEvent,
+    ) -> Vec<String> {
+        let subscriptions = self.subscriptions.lock().unwrap().clone();
+        if subscriptions.is_empty() {
+            return Vec::new();
+        }
+        let mut changed = vec![STATS_URI.to_string()];
+        match event {
+            FileChangeEvent::FileReindexed { path } => {
+                let indexer = self.facade.read().await;
+                if let Some(file_id) = indexer.get_file_id_for_path(&path.to_string_lossy()) {
+                    changed.extend(
+                        indexer
+                            .get_file_path(file_id)
+                            .map(|path| outline_uri(&path)),
+                    );
+                }
+            }
+            FileChangeEvent::FileCreated { .. } | FileChangeEvent::FileDeleted { .. } => {
+                changed.push(FILES_URI.to_string());
+            }
+            // Any outline may have changed with the whole index
+            FileChangeEvent::IndexReloaded => return subscriptions.into_iter().collect(),
+            // The per-file events of a batch already named its changes, and
+            // no resource shows settings
+            FileChangeEvent::IndexUpdated { .. } | FileChangeEvent::SettingsReloaded => {
+                return Vec::new();
+            }
+        }
+        changed.retain(|uri| subscriptions.contains(uri));
+        changed
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::Settings;
+    use std::sync::Arc;
+
+    fn text(result: &ReadResourceResult) -> serde_json::Value {
+        match &result.contents[0] {
+            ResourceContents::TextResourceContents { text, .. } => {
+                serde_json::from_str(text).unwrap()
+            }
+            other => panic!("expected text contents, got {other:?}"),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_outline_lists_symbols_in_line_order() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let source = dir.path().join("shapes.py");
+        std::fs::write(
+            &source,
+            "def make():\n    pass\n\n\nclass Shape:\n    pass\n",
+        )
+        .unwrap();
+        let mut facade = IndexFacade::new(Arc::new(settings)).unwrap();
+        facade.index_file(&source).unwrap();
+        let server = CodeIntelligenceServer::new(facade);
+
+        let files = text(&server.read_index_resource(FILES_URI).await.unwrap());
+        let path = files["files"][0].as_str().unwrap().to_string();
+        assert!(path.ends_with("shapes.py"));
+
+        let outline = text(
+            &server
+                .read_index_resource(&outline_uri(&path))
+                .await
+                .unwrap(),
+        );
+        let names: Vec<&str> = outline["symbols"]
+            .as_array()
+            .unwrap()
+            .iter()
+            .map(|symbol| symbol["name"].as_str().unwrap())
+            .collect();
+        assert_eq!(names, vec!["make", "Shape"]);
+        assert_eq!(outline["symbols"][1]["line"], 5);
+
+        assert!(
+            server
+                .read_index_resource(&outline_uri("missing.py"))
+                .await
+                .is_err()
+        );
+
+        let reindexed = FileChangeEvent::FileReindexed {
+            path: source.clone(),
+        };
+        assert!(
+            server
+                .subscribed_resources_changed_by(&reindexed)
+                .await
+                .is_empty()
+        );
+        server.subscribe_resource(outline_uri(&path));
+        assert_eq!(
+            server.subscribed_resources_changed_by(&reindexed).await,
+            vec![outline_uri(&path)]
+        );
+    }
+}
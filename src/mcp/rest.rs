@@ -0,0 +1,423 @@
+//! Plain REST/JSON API for the HTTP and HTTPS servers
+//!
+//! With `server.rest_api = true`, dashboards and scripts that speak no MCP
+//! can query the index with a GET: `/api/v1/symbols`, `/api/v1/search` and
+//! `/api/v1/callers/{id}`, described by the OpenAPI document at
+//! `/api/v1/openapi.json`. They resolve symbols with the same policy as the
+//! MCP tools and answer with the envelopes of `codanna mcp --json`, their
+//! HTTP status following the envelope's code. Bearer tokens, when listed,
+//! are required on them as on `/mcp`.
+
+use std::sync::Arc;
+
+use axum::Router;
+use axum::extract::{Path, Query, State};
+use axum::http::StatusCode;
+use axum::response::{IntoResponse, Response};
+use serde::{Deserialize, Serialize};
+use serde_json::{Value, json};
+use tokio::sync::RwLock;
+
+use crate::indexing::facade::IndexFacade;
+use crate::io::envelope::{EntityType, Envelope, ResultCode};
+use crate::mcp::projects::Projects;
+use crate::mcp::service::{
+    CallRelation, FindSymbolTarget, SearchSymbolResult, SymbolResolution,
+    resolve_find_symbol_target, resolve_symbol_or_id, symbol_cards,
+};
+use crate::symbol::name_match::SearchMode;
+
+pub const SYMBOLS_PATH: &str = "/api/v1/symbols";
+pub const SEARCH_PATH: &str = "/api/v1/search";
+pub const CALLERS_PATH: &str = "/api/v1/callers/{id}";
+pub const OPENAPI_PATH: &str = "/api/v1/openapi.json";
+
+/// Results a search returns at most, whatever `limit` asks for
+const MAX_SEARCH_LIMIT: usize = 100;
+
+type ApiState = (Arc<RwLock<IndexFacade>>, Arc<Projects>);
+
+/// The routes of the API, answering from `facade` or one of `projects`
+pub fn router(facade: Arc<RwLock<IndexFacade>>, projects: Arc<Projects>) -> Router {
+    Router::new()
+        .route(SYMBOLS_PATH, axum::routing::get(find_symbols))
+        .route(SEARCH_PATH, axum::routing::get(search_symbols))
+        .route(CALLERS_PATH, axum::routing::get(find_callers))
+        .route(OPENAPI_PATH, axum::routing::get(serve_openapi))
+        .with_state((facade, projects))
+}
+
+#[derive(Debug, Deserialize)]
+struct SymbolsQuery {
+    name: Option<String>,
+    lang: Option<String>,
+    project: Option<String>,
+}
+
+#[derive(Debug, Deserialize)]
+struct SearchQuery {
+    q: Option<String>,
+    limit: Option<usize>,
+    kind: Option<String>,
+    module: Option<String>,
+    lang: Option<String>,
+    mode: Option<String>,
+    project: Option<String>,
+}
+
+#[derive(Debug, Deserialize)]
+struct CallersQuery {
+    depth: Option<usize>,
+    project: Option<String>,
+}
+
+async fn find_symbols(
+    State(state): State<ApiState>,
+    Query(query): Query<SymbolsQuery>,
+) -> Response {
+    let Some(name) = query.name.filter(|name| !name.is_empty()) else {
+        return invalid(EntityType::Symbol, "", "Missing query parameter 'name'");
+    };
+    let facade = match facade_of(&state, query.project.as_deref()) {
+        Ok(facade) => facade,
+        Err(response) => return response,
+    };
+    let facade = facade.read().await;
+
+    let symbols = match resolve_find_symbol_target(&facade, &name, query.lang.as_deref()) {
+        FindSymbolTarget::Symbols { symbols, .. } => symbols,
+        FindSymbolTarget::InvalidId(id) => {
+            return invalid(
+                EntityType::Symbol,
+                &name,
+                format!("Invalid symbol_id '{id}'"),
+            );
+        }
+    };
+    let cards = symbol_cards(&facade, symbols);
+    let count = cards.len();
+    let envelope = if count == 0 {
+        Envelope::not_found(format!("Symbol '{name}' not found"))
+    } else {
+        Envelope::success(cards)
+            .with_count(count)
+            .with_message(format!("Found {count} symbol(s)"))
+    };
+    let envelope = envelope
+        .with_entity_type(EntityType::Symbol)
+        .with_query(&name);
+    respond(match query.lang {
+        Some(lang) => envelope.with_lang(lang),
+        None => envelope,
+    })
+}
+
+async fn search_symbols(
+    State(state): State<ApiState>,
+    Query(query): Query<SearchQuery>,
+) -> Response {
+    let Some(q) = query.q.filter(|q| !q.trim().is_empty()) else {
+        return invalid(EntityType::SearchResult, "", "Missing query parameter 'q'");
+    };
+    // One kind and mode vocabulary with the MCP tool; an unknown one is an
+    // error, not an unfiltered search
+    let kind = match query.kind.as_deref().map(str::parse::<crate::SymbolKind>) {
+        None => None,
+        Some(Ok(kind)) => Some(kind),
+        Some(Err(e)) => return invalid(EntityType::SearchResult, &q, e.to_string()),
+    };
+    let mode = match query.mode.as_deref().map(str::parse::<SearchMode>) {
+        None => SearchMode::default(),
+        Some(Ok(mode)) => mode,
+        Some(Err(e)) => return invalid(EntityType::SearchResult, &q, e),
+    };
+    let limit = query.limit.unwrap_or(10).clamp(1, MAX_SEARCH_LIMIT);
+    let facade = match facade_of(&state, query.project.as_deref()) {
+        Ok(facade) => facade,
+        Err(response) => return response,
+    };
+    let facade = facade.read().await;
+
+    let results = match facade.search_with_mode(
+        &q,
+        mode,
+        limit,
+        kind,
+        query.module.as_deref(),
+        query.lang.as_deref(),
+    ) {
+        Ok(results) => results,
+        Err(e) => {
+            let envelope: Envelope<()> =
+                Envelope::error(ResultCode::IndexError, format!("Index query failed: {e}"))
+                    .with_entity_type(EntityType::SearchResult)
+                    .with_query(&q);
+            return respond(envelope);
+        }
+    };
+    let results: Vec<SearchSymbolResult> = results.into_iter().map(Into::into).collect();
+    let count = results.len();
+    let envelope = if count == 0 {
+        Envelope::not_found(format!("No symbols found for '{q}'"))
+    } else {
+        Envelope::success(results)
+            .with_count(count)
+            .with_message(format!("Found {count} symbol(s)"))
+    };
+    respond(
+        envelope
+            .with_entity_type(EntityType::SearchResult)
+            .with_query(&q),
+    )
+}
+
+async fn find_callers(
+    State(state): State<ApiState>,
+    Path(id): Path<u32>,
+    Query(query): Query<CallersQuery>,
+) -> Response {
+    let identifier = format!("symbol_id:{id}");
+    let depth = query.depth.unwrap_or(1).max(1);
+    let facade = match facade_of(&state, query.project.as_deref()) {
+        Ok(facade) => facade,
+        Err(response) => return response,
+    };
+    let facade = facade.read().await;
+
+    let symbol = match resolve_symbol_or_id(&facade, Some(id), None) {
+        SymbolResolution::Resolved { symbol, .. } => symbol,
+        _ => {
+            let envelope: Envelope<()> = Envelope::not_found(format!("No symbol with id {id}"))
+                .with_entity_type(EntityType::Callers)
+                .with_query(&identifier)
+                .with_hint("Find the id of a symbol with /api/v1/symbols?name=<name>");
+            return respond(envelope);
+        }
+    };
+    // Same edges as find_callers: direct with their call sites, or every
+    // function reaching the symbol within `depth` calls
+    let callers: Vec<CallRelation> = if depth > 1 {
+        facade
+            .get_transitive_callers(symbol.id, depth)
+            .into_iter()
+            .map(CallRelation::reached)
+            .collect()
+    } else {
+        facade
+            .get_calling_functions_with_metadata(symbol.id)
+            .into_iter()
+            .map(|(caller, metadata)| CallRelation::direct(caller, metadata))
+            .collect()
+    };
+    let count = callers.len();
+    let envelope = if count == 0 {
+        Envelope::not_found(format!("No callers of '{}'", symbol.name))
+    } else {
+        Envelope::success(callers)
+            .with_count(count)
+            .with_message(format!("Found {count} caller(s) of '{}'", symbol.name))
+    };
+    respond(
+        envelope
+            .with_entity_type(EntityType::Callers)
+            .with_query(&identifier)
+            .with_depth(depth as u32),
+    )
+}
+
+async fn serve_openapi() -> axum::Json<Value> {
+    axum::Json(openapi())
+}
+
+/// The facade of the project named `project`, the current one by default
+fn facade_of(
+    state: &ApiState,
+    project: Option<&str>,
+) -> Result<Arc<RwLock<IndexFacade>>, Response> {
+    let (facade, projects) = state;
+    match project {
+        Some(name) if name != projects.current => match projects.others.get(name) {
+            Some(project) => Ok(project.facade.clone()),
+            None => {
+                let envelope: Envelope<()> =
+                    Envelope::not_found(format!("Unknown project '{name}'"))
+                        .with_entity_type(EntityType::Project)
+                        .with_query(name)
+                        .with_hint(format!("Projects: {}", projects.names().join(", ")));
+                Err(respond(envelope))
+            }
+        },
+        _ => Ok(facade.clone()),
+    }
+}
+
+fn invalid(entity: EntityType, query: &str, message: impl Into<String>) -> Response {
+    let envelope: Envelope<()> = Envelope::error(ResultCode::InvalidQuery, message)
+        .with_entity_type(entity)
+        .with_query(query);
+    respond(envelope)
+}
+
+/// `envelope` as JSON, under the HTTP status of its code
+fn respond<T: Serialize>(envelope: Envelope<T>) -> Response {
+    (status_of(&envelope.code), axum::Json(envelope)).into_response()
+}
+
+fn status_of(code: &ResultCode) -> StatusCode {
+    match code {
+        ResultCode::Ok => StatusCode::OK,
+        ResultCode::NotFound => StatusCode::NOT_FOUND,
+        ResultCode::ParseError | ResultCode::InvalidQuery => StatusCode::BAD_REQUEST,
+        ResultCode::IndexError | ResultCode::InternalError => StatusCode::INTERNAL_SERVER_ERROR,
+    }
+}
+
+/// The OpenAPI 3.1 document of the API
+pub fn openapi() -> Value {
+    let string = |name: &str, description: &str| {
+        json!({
+            "name": name,
+            "in": "query",
+            "required": false,
+            "description": description,
+            "schema": { "type": "string" },
+        })
+    };
+    let integer = |name: &str, description: &str| {
+        json!({
+            "name": name,
+            "in": "query",
+            "required": false,
+            "description": description,
+            "schema": { "type": "integer", "minimum": 1 },
+        })
+    };
+    let project = string(
+        "project",
+        "Project to query, by its name in [server.projects]; the server's own by default",
+    );
+    let responses = |found: &str| {
+        json!({
+            "200": { "description": found, "content": envelope_content() },
+            "400": { "description": "Invalid parameters", "content": envelope_content() },
+            "404": { "description": "Nothing found", "content": envelope_content() },
+            "500": { "description": "The index could not be queried", "content": envelope_content() },
+        })
+    };
+
+    let mut name = string("name", "Symbol name, or symbol_id:<id>");
+    name["required"] = json!(true);
+    let mut q = string("q", "Search text");
+    q["required"] = json!(true);
+
+    json!({
+        "openapi": "3.1.0",
+        "info": {
+            "title": "Codanna REST API",
+            "version": env!("CARGO_PKG_VERSION"),
+            "description": "Read-only queries of the code index. Answers are the envelopes of `codanna mcp --json`.",
+        },
+        "paths": {
+            SYMBOLS_PATH: {
+                "get": {
+                    "operationId": "findSymbol",
+                    "summary": "Symbols by name, with their location, signature and callers",
+                    "parameters": [name, string("lang", "Language to keep, e.g. rust"), project],
+                    "responses": responses("The matching symbols"),
+                }
+            },
+            SEARCH_PATH: {
+                "get": {
+                    "operationId": "searchSymbols",
+                    "summary": "Full-text search of symbol names, docs and signatures",
+                    "parameters": [
+                        q,
+                        integer("limit", "Results at most, up to 100; 10 by default"),
+                        string("kind", "Symbol kind to keep, e.g. function or struct"),
+                        string("module", "Module path to keep"),
+                        string("lang", "Language to keep"),
+                        string("mode", "Matching: text (default), fuzzy or regex"),
+                        project,
+                    ],
+                    "responses": responses("The symbols found, best first"),
+                }
+            },
+            CALLERS_PATH: {
+                "get": {
+                    "operationId": "findCallers",
+                    "summary": "Functions that call a symbol",
+                    "parameters": [
+                        {
+                            "name": "id",
+                            "in": "path",
+                            "required": true,
+                            "description": "Symbol id, as /api/v1/symbols gives it",
+                            "schema": { "type": "integer", "minimum": 0 },
+                        },
+                        integer("depth", "Call edges to follow; 1 (direct callers) by default"),
+                        project,
+                    ],
+                    "responses": responses("The callers"),
+                }
+            },
+        },
+        "components": {
+            "schemas": {
+                "Envelope": {
+                    "type": "object",
+                    "required": ["type", "status", "code", "exit_code", "message"],
+                    "properties": {
+                        "type": { "type": "string" },
+                        "status": { "type": "string", "enum": ["success", "not_found", "partial_success", "error"] },
+                        "code": {
+                            "type": "string",
+                            "enum": ["OK", "NOT_FOUND", "PARSE_ERROR", "INDEX_ERROR", "INVALID_QUERY", "INTERNAL_ERROR"],
+                        },
+                        "exit_code": { "type": "integer" },
+                        "message": { "type": "string" },
+                        "hint": { "type": "string" },
+                        "data": {},
+                        "meta": { "type": "object" },
+                    },
+                }
+            }
+        },
+    })
+}
+
+fn envelope_content() -> Value {
+    json!({
+        "application/json": { "schema": { "$ref": "#/components/schemas/Envelope" } }
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_openapi_describes_every_route() {
+        let document = openapi();
+        let paths = document["paths"].as_object().unwrap();
+        for path in [SYMBOLS_PATH, SEARCH_PATH, CALLERS_PATH] {
+            assert!(paths[path]["get"]["operationId"].is_string(), "{path}");
+            assert!(paths[path]["get"]["responses"]["200"].is_object(), "{path}");
+        }
+        assert_eq!(paths.len(), 3);
+        assert!(document["openapi"].as_str().unwrap().starts_with("3."));
+    }
+
+    #[test]
+    fn test_status_follows_envelope_code() {
+        assert_eq!(status_of(&ResultCode::Ok), StatusCode::OK);
+        assert_eq!(status_of(&ResultCode::NotFound), StatusCode::NOT_FOUND);
+        assert_eq!(
+            status_of(&ResultCode::InvalidQuery),
+            StatusCode::BAD_REQUEST
+        );
+        assert_eq!(
+            status_of(&ResultCode::IndexError),
+            StatusCode::INTERNAL_SERVER_ERROR
+        );
+    }
+}
@@ -4,16 +4,23 @@ use rmcp::model::ErrorData as McpError;
 use rmcp::model::*;
 use rmcp::{
     ServerHandler,
-    handler::server::router::tool::ToolRouter,
+    handler::server::{
+        prompt::PromptContext,
+        router::{prompt::PromptRouter, tool::ToolRouter},
+        tool::ToolCallContext,
+    },
     service::{Peer, RequestContext, RoleServer, ServiceError},
-    tool_handler,
 };
+use std::collections::HashSet;
 use std::sync::Arc;
 use tokio::sync::{Mutex, RwLock};
+use tracing::Instrument;
 
 use crate::Settings;
 use crate::documents::DocumentStore;
 use crate::indexing::facade::IndexFacade;
+use crate::mcp::limits::{RateLimiter, RequestLimits};
+use crate::mcp::projects::{Project, Projects};
 
 /// Generate guidance for MCP tool responses
 pub(crate) fn generate_mcp_guidance(
@@ -51,12 +58,51 @@ pub fn format_relative_time(timestamp: u64) -> String {
     }
 }
 
+/// Tools that write the project, refused by a read-only server
+pub const WRITE_TOOLS: &[&str] = &["search_feedback"];
+
+/// Custom requests that write the index, refused by a read-only server
+const WRITE_REQUESTS: &[&str] = &["requests/codanna/force-reindex"];
+
+/// What a server watches, given `--watch` and its settings
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct Watching {
+    /// Reload the index when another process writes it
+    pub hot_reload: bool,
+    /// Index the source files that change
+    pub file_watch: bool,
+}
+
+impl Watching {
+    /// Read-only: reload the index `codanna index` writes elsewhere, and
+    /// never index source changes here
+    pub fn of(settings: &Settings, watch: bool) -> Self {
+        let read_only = settings.server.read_only;
+        let hot_reload = watch || read_only;
+        Self {
+            hot_reload,
+            file_watch: !read_only && (hot_reload || settings.file_watch.enabled),
+        }
+    }
+}
+
 #[derive(Clone)]
 pub struct CodeIntelligenceServer {
     pub facade: Arc<RwLock<IndexFacade>>,
     pub document_store: Option<Arc<RwLock<DocumentStore>>>,
+    /// Other projects the tools can answer for
+    pub(crate) projects: Arc<Projects>,
+    /// Limits shared with the other connections of the server
+    limits: Arc<RequestLimits>,
+    /// Tool calls this connection has left
+    rate: Arc<std::sync::Mutex<RateLimiter>>,
+    /// Resources this connection subscribed to
+    pub(super) subscriptions: Arc<std::sync::Mutex<HashSet<String>>>,
     tool_router: ToolRouter<Self>,
+    prompt_router: PromptRouter<Self>,
     pub(super) peer: Arc<Mutex<Option<Peer<RoleServer>>>>,
+    /// Refuse requests that write the index
+    read_only: bool,
 }
 
 impl CodeIntelligenceServer {
@@ -64,8 +110,14 @@ impl CodeIntelligenceServer {
         Self {
             facade: Arc::new(RwLock::new(facade)),
             document_store: None,
-            tool_router: Self::symbols_router() + Self::search_router(),
+            projects: Arc::default(),
+            limits: Arc::default(),
+            rate: Arc::default(),
+            subscriptions: Arc::default(),
+            tool_router: Self::symbols_router() + Self::search_router() + Self::analysis_router(),
+            prompt_router: Self::prompts_router(),
             peer: Arc::new(Mutex::new(None)),
+            read_only: false,
         }
     }
 
@@ -74,8 +126,14 @@ impl CodeIntelligenceServer {
         Self {
             facade,
             document_store: None,
-            tool_router: Self::symbols_router() + Self::search_router(),
+            projects: Arc::default(),
+            limits: Arc::default(),
+            rate: Arc::default(),
+            subscriptions: Arc::default(),
+            tool_router: Self::symbols_router() + Self::search_router() + Self::analysis_router(),
+            prompt_router: Self::prompts_router(),
             peer: Arc::new(Mutex::new(None)),
+            read_only: false,
         }
     }
 
@@ -84,8 +142,14 @@ impl CodeIntelligenceServer {
         Self {
             facade,
             document_store: None,
-            tool_router: Self::symbols_router() + Self::search_router(),
+            projects: Arc::default(),
+            limits: Arc::default(),
+            rate: Arc::default(),
+            subscriptions: Arc::default(),
+            tool_router: Self::symbols_router() + Self::search_router() + Self::analysis_router(),
+            prompt_router: Self::prompts_router(),
             peer: Arc::new(Mutex::new(None)),
+            read_only: false,
         }
     }
 
@@ -101,11 +165,82 @@ impl CodeIntelligenceServer {
         self
     }
 
+    /// Answer for the other projects of `projects` too
+    pub fn with_projects(mut self, projects: Arc<Projects>) -> Self {
+        self.projects = projects;
+        self
+    }
+
+    /// Refuse requests that write the index, when `read_only`
+    pub fn with_read_only(mut self, read_only: bool) -> Self {
+        self.read_only = read_only;
+        self
+    }
+
+    /// Refuse the tool or custom request `name` when it writes and the
+    /// server is read-only
+    fn check_writable(&self, name: &str) -> Result<(), McpError> {
+        if self.read_only && (WRITE_TOOLS.contains(&name) || WRITE_REQUESTS.contains(&name)) {
+            return Err(McpError::invalid_request(
+                "The server is read-only: run codanna index to update the index",
+                None,
+            ));
+        }
+        Ok(())
+    }
+
+    /// Limit tool calls as `limits` says
+    pub fn with_limits(mut self, limits: Arc<RequestLimits>) -> Self {
+        self.rate = limits.new_connection();
+        self.limits = limits;
+        self
+    }
+
+    /// This server for another client connection, with its own rate limit
+    /// and resource subscriptions
+    pub fn for_new_connection(&self) -> Self {
+        Self {
+            rate: self.limits.new_connection(),
+            subscriptions: Arc::default(),
+            ..self.clone()
+        }
+    }
+
+    /// The project `name` picks, this server's own when None
+    pub(crate) fn project(&self, name: Option<&str>) -> Result<Project, McpError> {
+        match name {
+            Some(name) if name != self.projects.current => {
+                self.projects.others.get(name).cloned().ok_or_else(|| {
+                    McpError::invalid_params(
+                        format!(
+                            "Unknown project '{name}'. Projects: {}",
+                            self.projects.names().join(", ")
+                        ),
+                        None,
+                    )
+                })
+            }
+            _ => Ok(Project {
+                facade: self.facade.clone(),
+                document_store: self.document_store.clone(),
+            }),
+        }
+    }
+
     /// Get a reference to the facade Arc for external management (e.g., hot-reload)
     pub fn get_facade_arc(&self) -> Arc<RwLock<IndexFacade>> {
         self.facade.clone()
     }
 
+    /// Remember a search in the query history of the index
+    pub(crate) async fn record_query(&self, tool: &str, request: &impl serde::Serialize) {
+        let indexer = self.facade.read().await;
+        crate::queries::record_query(
+            indexer.settings(),
+            crate::queries::QueryCall::from_request(tool, request),
+        );
+    }
+
     /// Send a notification when a file is re-indexed
     pub async fn notify_file_reindexed(&self, file_path: &str) {
         let peer_guard = self.peer.lock().await;
@@ -136,12 +271,15 @@ impl CodeIntelligenceServer {
     }
 }
 
-#[tool_handler(router = self.tool_router)]
 impl ServerHandler for CodeIntelligenceServer {
     fn get_info(&self) -> ServerInfo {
         ServerInfo::new(
             ServerCapabilities::builder()
                 .enable_tools()
+                .enable_prompts()
+                .enable_resources()
+                .enable_resources_subscribe()
+                .enable_resources_list_changed()
                 .build(),
         )
         .with_server_info(
@@ -153,9 +291,22 @@ impl ServerHandler for CodeIntelligenceServer {
             "This server provides code intelligence tools for analyzing this codebase. \
             WORKFLOW: Start with 'semantic_search_with_context' or 'semantic_search_docs' to anchor on the right files and APIs - they provide the highest-quality context. \
             Then use 'find_symbol' and 'search_symbols' to lock onto exact files and kinds. \
+            For a compound filter (kind, language, path, relationships, doc words at once), use 'query_symbols' instead of intersecting several calls. \
             Treat 'get_calls', 'find_callers', and 'analyze_impact' as hints; confirm with code reading or tighter queries (unique names, kind filters). \
+            Use 'get_call_hierarchy' for both the callers and the callees of a function, as nested trees, in one call. \
+            Use 'get_type_hierarchy' for the supertypes and subtypes of a struct, trait, class or interface. \
+            Before editing a file, use 'get_file_outline' for the skeleton of its symbols. \
+            Before changing a symbol, use 'impact_of_change' for everything that depends on it, grouped by distance, in one call. \
+            Use 'find_tests' for the tests that exercise a function. \
+            Before writing a helper, use 'find_similar_symbols' for an existing one that does the same. \
+            Use 'find_unused_symbols' to find dead code candidates; confirm with 'find_callers' before proposing removals. \
+            Use 'find_todos' for the TODO and FIXME comments of the code you are about to edit. \
             Use 'search_documents' to find relevant project documentation (markdown files). \
-            Use 'get_index_info' to understand what's indexed.",
+            Use 'run_saved_query' to rerun a search the user saved by name. \
+            After a semantic search, use 'search_feedback' to mark the results that did and did not answer it, when the user or your reading settles that. \
+            Use 'list_projects' for the projects this server answers for; every tool takes one as 'project'. \
+            The prompts 'explain_symbol', 'trace_call_path' and 'blast_radius' run these workflows in one step. \
+            Use 'get_index_info' to understand what's indexed; the file list, per-file outlines and index stats are also resources.",
         )
     }
 
@@ -177,14 +328,118 @@ impl ServerHandler for CodeIntelligenceServer {
         Ok(self.get_info())
     }
 
+    async fn list_tools(
+        &self,
+        _request: Option<PaginatedRequestParams>,
+        _context: RequestContext<RoleServer>,
+    ) -> Result<ListToolsResult, McpError> {
+        Ok(ListToolsResult::with_all_items(self.tool_router.list_all()))
+    }
+
+    /// Answer a tool call within the limits of the server, counting it and
+    /// its latency for `/metrics`
+    async fn call_tool(
+        &self,
+        request: CallToolRequestParams,
+        context: RequestContext<RoleServer>,
+    ) -> Result<CallToolResult, McpError> {
+        let tool = request.name.to_string();
+        self.check_writable(&tool)?;
+        let _call = self.limits.begin_call(&tool)?;
+        let _permit = self.limits.admit(&self.rate, &tool)?;
+        let started = std::time::Instant::now();
+        let span = tracing::info_span!("tool_call", tool = %tool, failed = tracing::field::Empty);
+        let result = self
+            .tool_router
+            .call(ToolCallContext::new(self, request, context))
+            .instrument(span.clone())
+            .await;
+        let failed = !result
+            .as_ref()
+            .is_ok_and(|result| result.is_error != Some(true));
+        span.record("failed", failed);
+        crate::mcp::metrics::record_tool_call(&tool, started.elapsed(), failed);
+        result
+    }
+
+    async fn list_prompts(
+        &self,
+        _request: Option<PaginatedRequestParams>,
+        _context: RequestContext<RoleServer>,
+    ) -> Result<ListPromptsResult, McpError> {
+        Ok(ListPromptsResult::with_all_items(
+            self.prompt_router.list_all(),
+        ))
+    }
+
+    async fn get_prompt(
+        &self,
+        request: GetPromptRequestParams,
+        context: RequestContext<RoleServer>,
+    ) -> Result<GetPromptResult, McpError> {
+        self.prompt_router
+            .get_prompt(PromptContext::new(
+                self,
+                request.name,
+                request.arguments,
+                context,
+            ))
+            .await
+    }
+
+    async fn list_resources(
+        &self,
+        request: Option<PaginatedRequestParams>,
+        _context: RequestContext<RoleServer>,
+    ) -> Result<ListResourcesResult, McpError> {
+        let cursor = request.and_then(|request| request.cursor);
+        self.list_index_resources(cursor.as_deref()).await
+    }
+
+    async fn list_resource_templates(
+        &self,
+        _request: Option<PaginatedRequestParams>,
+        _context: RequestContext<RoleServer>,
+    ) -> Result<ListResourceTemplatesResult, McpError> {
+        Ok(self.index_resource_templates())
+    }
+
+    async fn read_resource(
+        &self,
+        request: ReadResourceRequestParams,
+        _context: RequestContext<RoleServer>,
+    ) -> Result<ReadResourceResult, McpError> {
+        self.read_index_resource(&request.uri).await
+    }
+
+    async fn subscribe(
+        &self,
+        request: SubscribeRequestParams,
+        _context: RequestContext<RoleServer>,
+    ) -> Result<(), McpError> {
+        self.subscribe_resource(request.uri);
+        Ok(())
+    }
+
+    async fn unsubscribe(
+        &self,
+        request: UnsubscribeRequestParams,
+        _context: RequestContext<RoleServer>,
+    ) -> Result<(), McpError> {
+        self.unsubscribe_resource(&request.uri);
+        Ok(())
+    }
+
     async fn on_custom_request(
         &self,
         request: CustomRequest,
         _context: RequestContext<RoleServer>,
     ) -> Result<CustomResult, McpError> {
+        self.check_writable(&request.method)?;
         match request.method.as_str() {
             "requests/codanna/force-reindex" => self.handle_force_reindex(request).await,
-            "requests/codanna/index-stats" => self.handle_index_stats().await,
+            "requests/codanna/index-stats" => self.handle_index_stats(request).await,
+            "requests/codanna/cli-call" => self.handle_cli_call(request).await,
             _ => Err(McpError::new(
                 ErrorCode::METHOD_NOT_FOUND,
                 format!("Unknown method: {}", request.method),
@@ -194,6 +449,15 @@ impl ServerHandler for CodeIntelligenceServer {
     }
 }
 
+/// The optional `project` parameter of a custom request
+fn request_project(request: &CustomRequest) -> Option<&str> {
+    request
+        .params
+        .as_ref()
+        .and_then(|p| p.get("project"))
+        .and_then(|v| v.as_str())
+}
+
 // Custom request handlers
 impl CodeIntelligenceServer {
     /// Handle force-reindex request
@@ -209,7 +473,8 @@ impl CodeIntelligenceServer {
             .and_then(|p| p.get("paths"))
             .and_then(|v| serde_json::from_value(v.clone()).ok());
 
-        let mut indexer = self.facade.write().await;
+        let project = self.project(request_project(&request))?;
+        let mut indexer = project.facade.write().await;
 
         let (reindexed, symbols) = if let Some(paths) = paths {
             // Reindex specific paths
@@ -262,28 +527,58 @@ impl CodeIntelligenceServer {
     }
 
     /// Handle index-stats request
-    async fn handle_index_stats(&self) -> Result<CustomResult, McpError> {
-        let indexer = self.facade.read().await;
+    async fn handle_index_stats(&self, request: CustomRequest) -> Result<CustomResult, McpError> {
+        let project = self.project(request_project(&request))?;
+        let indexer = project.facade.read().await;
+        Ok(CustomResult(crate::mcp::resources::index_stats(&indexer)))
+    }
 
-        let semantic = if let Some(metadata) = indexer.get_semantic_metadata() {
-            let live_count = indexer.semantic_search_embedding_count();
-            serde_json::json!({
-                "enabled": true,
-                "model": metadata.model_name,
-                "embeddings": live_count,
-                "dimensions": metadata.dimension
-            })
+    /// Handle cli-call request: a `codanna mcp` call in text mode, answered
+    /// with the text and exit code the command would print and exit with
+    async fn handle_cli_call(&self, request: CustomRequest) -> Result<CustomResult, McpError> {
+        use crate::cli::commands::mcp::{KNOWN_TOOLS, call_tool_text, text_exit_code};
+        use crate::mcp::service::{missing_param_message, tool_param_spec};
+
+        let params = request.params.unwrap_or_default();
+        let tool = params.get("tool").and_then(|v| v.as_str()).unwrap_or("");
+        if !KNOWN_TOOLS.contains(&tool) {
+            return Err(McpError::invalid_params(
+                format!("Unknown tool: {tool}"),
+                None,
+            ));
+        }
+        let arguments = params
+            .get("arguments")
+            .and_then(|v| v.as_object())
+            .filter(|map| !map.is_empty())
+            .cloned();
+
+        // The command checked them; a call naming no target would panic
+        let (_, requires_one_of) = tool_param_spec(tool);
+        let named = requires_one_of.is_empty()
+            || arguments
+                .as_ref()
+                .is_some_and(|map| requires_one_of.iter().any(|k| map.contains_key(*k)));
+        let exit_code = if named {
+            let indexer = self.facade.read().await;
+            text_exit_code(&indexer, tool, &arguments)
         } else {
-            serde_json::json!({
-                "enabled": false
+            None
+        }
+        .ok_or_else(|| McpError::invalid_params(missing_param_message(tool), None))?;
+
+        let result = call_tool_text(self, tool, &arguments).await?;
+        let text: Vec<&str> = result
+            .content
+            .iter()
+            .filter_map(|content| match content {
+                rmcp::model::ContentBlock::Text(text) => Some(text.text.as_str()),
+                _ => None,
             })
-        };
-
+            .collect();
         Ok(CustomResult(serde_json::json!({
-            "symbols": indexer.symbol_count(),
-            "files": indexer.file_count(),
-            "relationships": indexer.relationship_count(),
-            "semantic": semantic
+            "text": text,
+            "exit_code": exit_code,
         })))
     }
 
@@ -303,3 +598,70 @@ impl CodeIntelligenceServer {
         Ok(())
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_read_only_refuses_every_write() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: dir.path().join("index"),
+            ..Default::default()
+        };
+        let facade = IndexFacade::new(Arc::new(settings)).unwrap();
+        let writable = CodeIntelligenceServer::new(facade);
+        let read_only = writable.clone().with_read_only(true);
+
+        let tools: Vec<String> = writable
+            .tool_router
+            .list_all()
+            .into_iter()
+            .map(|tool| tool.name.to_string())
+            .collect();
+        for name in WRITE_TOOLS.iter().chain(WRITE_REQUESTS) {
+            assert!(read_only.check_writable(name).is_err(), "{name}");
+            assert!(writable.check_writable(name).is_ok(), "{name}");
+        }
+        for tool in WRITE_TOOLS {
+            assert!(tools.iter().any(|listed| listed == tool), "{tool}");
+        }
+        // Everything else only reads
+        for tool in tools
+            .iter()
+            .filter(|tool| !WRITE_TOOLS.contains(&tool.as_str()))
+        {
+            assert!(read_only.check_writable(tool).is_ok(), "{tool}");
+        }
+        assert!(
+            read_only
+                .check_writable("requests/codanna/index-stats")
+                .is_ok()
+        );
+    }
+
+    #[test]
+    fn test_read_only_watches_no_files() {
+        let mut settings = Settings::default();
+        settings.file_watch.enabled = true;
+        assert_eq!(
+            Watching::of(&settings, false),
+            Watching {
+                hot_reload: false,
+                file_watch: true
+            }
+        );
+
+        settings.server.read_only = true;
+        for watch in [false, true] {
+            assert_eq!(
+                Watching::of(&settings, watch),
+                Watching {
+                    hot_reload: true,
+                    file_watch: false
+                }
+            );
+        }
+    }
+}
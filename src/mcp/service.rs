@@ -6,7 +6,11 @@
 //! same-named but unrelated symbols must not merge into one result.
 
 use crate::Symbol;
-use crate::indexing::facade::IndexFacade;
+use crate::analysis::{TodoFilter, TodoItem, UnusedRules, find_unused, list_todos};
+use crate::export::GraphFilter;
+use crate::indexing::facade::{
+    CallHierarchy, CallNode, ChangeImpact, HierarchyNode, IndexFacade, TypeHierarchy,
+};
 
 /// Outcome of resolving a tool's target symbol from `symbol_id` or name.
 pub enum SymbolResolution {
@@ -111,19 +115,83 @@ pub fn tool_param_spec(tool: &str) -> (&'static [&'static str], &'static [&'stat
     match tool {
         "find_symbol" => (&["name", "symbol_id", "lang"], &["name"]),
         "get_calls" | "find_callers" => (
-            &["function_name", "symbol_id"],
+            &["function_name", "symbol_id", "depth", "page_size", "cursor"],
             &["function_name", "symbol_id"],
         ),
         "analyze_impact" => (
-            &["symbol_name", "symbol_id", "max_depth", "depth"],
+            &[
+                "symbol_name",
+                "symbol_id",
+                "max_depth",
+                "depth",
+                "page_size",
+                "cursor",
+            ],
+            &["symbol_name", "symbol_id"],
+        ),
+        "get_call_hierarchy" => (
+            &["function_name", "symbol_id", "depth"],
+            &["function_name", "symbol_id"],
+        ),
+        "get_type_hierarchy" => (&["type_name", "symbol_id"], &["type_name", "symbol_id"]),
+        "get_file_outline" => (&["path"], &["path"]),
+        "impact_of_change" | "find_tests" => (
+            &["symbol_name", "symbol_id", "max_depth"],
             &["symbol_name", "symbol_id"],
         ),
+        "find_unused_symbols" => (&["kind", "path", "lang", "include_public", "limit"], &[]),
+        "find_todos" => (&["tag", "path", "author", "limit"], &[]),
         "get_index_info" => (&[], &[]),
-        "search_symbols" => (&["query", "limit", "kind", "module", "lang"], &["query"]),
-        "semantic_search_docs" | "semantic_search_with_context" => {
-            (&["query", "limit", "threshold", "lang"], &["query"])
-        }
+        "search_symbols" => (
+            &[
+                "query",
+                "limit",
+                "kind",
+                "module",
+                "lang",
+                "mode",
+                "page_size",
+                "cursor",
+            ],
+            &["query"],
+        ),
+        "query_symbols" => (&["query", "limit", "page_size", "cursor"], &["query"]),
+        "semantic_search_docs" => (
+            &[
+                "query",
+                "limit",
+                "threshold",
+                "lang",
+                "kind",
+                "path",
+                "visibility",
+                "vectors",
+                "page_size",
+                "cursor",
+            ],
+            &["query"],
+        ),
+        "semantic_search_with_context" => (
+            &[
+                "query",
+                "limit",
+                "threshold",
+                "lang",
+                "kind",
+                "path",
+                "visibility",
+                "context_lines",
+                "page_size",
+                "cursor",
+            ],
+            &["query"],
+        ),
+        "find_similar_symbols" => (
+            &["symbol_name", "symbol_id", "limit", "lang", "kind", "path"],
+            &["symbol_name", "symbol_id"],
+        ),
         "search_documents" => (&["query", "collection", "limit"], &["query"]),
+        "run_saved_query" => (&["name"], &["name"]),
         _ => (&[], &[]),
     }
 }
@@ -152,14 +220,21 @@ pub fn accepted_params_line(tool: &str) -> String {
     }
 }
 
-/// Class-scoped fallback for dotted queries: "Class.method" resolves the
-/// method within the named type when no symbol matches the literal name.
-/// Uniform across languages; `find` supplies name candidates (typically a
-/// `find_symbols_by_name` closure so language filters carry through).
+/// Class-scoped fallback for dotted queries: "Class.method" (or
+/// "Type::field") resolves the member within the named type when no symbol
+/// matches the literal name. Uniform across languages; `find` supplies name
+/// candidates (typically a `find_symbols_by_name` closure so language
+/// filters carry through).
 pub fn find_dotted_members(name: &str, find: impl Fn(&str) -> Vec<Symbol>) -> Vec<Symbol> {
-    let Some((class, member)) = name.rsplit_once('.') else {
+    // The rightmost separator splits off the member
+    let split = match (name.rsplit_once("::"), name.rsplit_once('.')) {
+        (Some(path), Some(dotted)) if dotted.0.len() > path.0.len() => Some(dotted),
+        (path, dotted) => path.or(dotted),
+    };
+    let Some((class, member)) = split else {
         return Vec::new();
     };
+    let class = class.rsplit("::").next().unwrap_or(class);
     if class.is_empty() || member.is_empty() {
         return Vec::new();
     }
@@ -219,6 +294,370 @@ pub fn render_ambiguity(tool: &str, name: &str, candidates: &[Symbol]) -> String
     msg
 }
 
+/// Text rendering of a type hierarchy, one indented row per type: `->`
+/// rows are supertypes, `<-` rows subtypes. Shared by `get_type_hierarchy`
+/// and `retrieve hierarchy`.
+pub fn render_hierarchy(hierarchy: &TypeHierarchy) -> String {
+    fn rows(out: &mut String, nodes: &[HierarchyNode], arrow: &str, indent: usize) {
+        for node in nodes {
+            let symbol = &node.symbol;
+            let relation = match node.relation {
+                crate::RelationKind::Implements => "implements",
+                _ => "extends",
+            };
+            let location = format!(
+                "{:?} {} at {}:{}",
+                symbol.kind,
+                symbol.name,
+                symbol.file_path,
+                symbol.range.start_line + 1
+            );
+            let row = if arrow == "->" {
+                format!("{relation} {location}")
+            } else {
+                format!("{location} ({relation})")
+            };
+            out.push_str(&format!("{:indent$}{arrow} {row}\n", ""));
+            rows(out, &node.children, arrow, indent + 2);
+        }
+    }
+
+    let symbol = &hierarchy.symbol;
+    let mut out = format!(
+        "{:?} {} at {}:{}\n",
+        symbol.kind,
+        symbol.name,
+        symbol.file_path,
+        symbol.range.start_line + 1
+    );
+    for (title, nodes, arrow) in [
+        ("Supertypes", &hierarchy.supertypes, "->"),
+        ("Subtypes", &hierarchy.subtypes, "<-"),
+    ] {
+        out.push_str(&format!("{title}:\n"));
+        if nodes.is_empty() {
+            out.push_str("  (none)\n");
+        }
+        rows(&mut out, nodes, arrow, 2);
+    }
+    out
+}
+
+/// Text rendering of a change impact, one section per distance. Each row
+/// names the symbol it depends on; a section ends with the files first
+/// reached there. Shared by `impact_of_change` and `retrieve impact`.
+pub fn render_change_impact(impact: &ChangeImpact) -> String {
+    let mut names: std::collections::HashMap<crate::SymbolId, &str> = impact
+        .levels
+        .iter()
+        .flat_map(|level| &level.symbols)
+        .map(|entry| (entry.symbol.id, entry.symbol.name.as_ref()))
+        .collect();
+    names.insert(impact.symbol.id, impact.symbol.name.as_ref());
+
+    let symbol = &impact.symbol;
+    let mut out = format!(
+        "{:?} {} at {}:{}\n",
+        symbol.kind,
+        symbol.name,
+        symbol.file_path,
+        symbol.range.start_line + 1
+    );
+    if impact.is_empty() {
+        out.push_str("Nothing calls, implements, extends or uses it\n");
+        return out;
+    }
+    out.push_str(&format!(
+        "Affects {} symbol(s) in {} other file(s)\n",
+        impact.len(),
+        impact.files().count()
+    ));
+    for level in &impact.levels {
+        out.push_str(&format!("\nDistance {}:\n", level.distance));
+        for entry in &level.symbols {
+            let relation = match entry.relation {
+                crate::RelationKind::Calls => "calls",
+                crate::RelationKind::Implements => "implements",
+                crate::RelationKind::Extends => "extends",
+                _ => "uses",
+            };
+            out.push_str(&format!(
+                "  {:?} {} at {}:{} [symbol_id:{}] ({relation} {})\n",
+                entry.symbol.kind,
+                entry.symbol.name,
+                entry.symbol.file_path,
+                entry.symbol.range.start_line + 1,
+                entry.symbol.id.value(),
+                names.get(&entry.via).copied().unwrap_or("?")
+            ));
+        }
+        if !level.files.is_empty() {
+            out.push_str(&format!("  Files: {}\n", level.files.join(", ")));
+        }
+    }
+    out
+}
+
+/// Unused symbols for `find_unused_symbols`, both renderings: the rules of
+/// `[analysis.unused]`, public symbols included on request, filtered by
+/// kind, path and language. `Err` carries the message of an unknown kind or
+/// invalid setting.
+pub fn find_unused_symbols(
+    facade: &IndexFacade,
+    kind: Option<&str>,
+    path: Option<String>,
+    lang: Option<&str>,
+    include_public: bool,
+) -> Result<Vec<Symbol>, String> {
+    let mut config = facade.settings().analysis.unused.clone();
+    config.include_public |= include_public;
+    let rules = UnusedRules::new(&config)?;
+    let kinds = match kind {
+        Some(kind) => vec![
+            kind.parse::<crate::SymbolKind>()
+                .map_err(|e| e.to_string())?,
+        ],
+        None => Vec::new(),
+    };
+    let filter = GraphFilter {
+        path,
+        language: lang.map(str::to_lowercase),
+        kinds,
+        relations: Vec::new(),
+    };
+    Ok(find_unused(facade, &rules, &filter))
+}
+
+/// Todos for `find_todos`, both renderings: `tag` is a comma-separated
+/// list, matched like `path` and `author` by [`TodoFilter`].
+pub fn find_todos(
+    facade: &IndexFacade,
+    tag: Option<&str>,
+    path: Option<String>,
+    author: Option<String>,
+) -> Vec<TodoItem> {
+    let filter = TodoFilter {
+        tags: tag
+            .map(|tags| tags.split(',').map(|tag| tag.trim().to_string()).collect())
+            .unwrap_or_default(),
+        path,
+        author,
+    };
+    list_todos(facade.get_todos(), &filter, |id| {
+        facade.get_symbol(id).map(|symbol| symbol.name.to_string())
+    })
+}
+
+/// The symbol card of each of `symbols`: its context with callers and
+/// location, or just the symbol and its file where the index has no
+/// context for it. The `find_symbol` JSON data.
+pub fn symbol_cards(
+    facade: &IndexFacade,
+    symbols: Vec<Symbol>,
+) -> Vec<crate::symbol::context::SymbolContext> {
+    use crate::symbol::context::{ContextIncludes, SymbolContext};
+    symbols
+        .into_iter()
+        .map(|symbol| {
+            facade
+                .get_symbol_context(symbol.id, ContextIncludes::SYMBOL_CARD)
+                .unwrap_or_else(|| SymbolContext {
+                    file_path: facade
+                        .get_file_path(symbol.file_id)
+                        .unwrap_or_else(|| "unknown".to_string()),
+                    owners: facade.owners_of(&symbol.file_path),
+                    symbol,
+                    relationships: Default::default(),
+                    source: None,
+                })
+        })
+        .collect()
+}
+
+/// Flattened call/caller info combining symbol with call site metadata.
+/// Avoids tuple waste like `[[symbol, null], ...]` in JSON output.
+#[derive(Debug, serde::Serialize)]
+pub struct CallRelation {
+    #[serde(flatten)]
+    symbol: Symbol,
+    /// Line number of the call site (1-indexed)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    call_line: Option<u32>,
+    /// Column of the call site
+    #[serde(skip_serializing_if = "Option::is_none")]
+    call_column: Option<u16>,
+    /// Call edges from the queried function, in transitive mode (`depth:` > 1)
+    #[serde(skip_serializing_if = "Option::is_none")]
+    depth: Option<usize>,
+    /// Function on the other side of the edge that reached this one, in
+    /// transitive mode
+    #[serde(skip_serializing_if = "Option::is_none")]
+    via: Option<crate::types::SymbolId>,
+}
+
+impl CallRelation {
+    /// A direct call edge
+    pub fn direct(
+        symbol: Symbol,
+        metadata: Option<crate::relationship::RelationshipMetadata>,
+    ) -> Self {
+        Self {
+            symbol,
+            call_line: metadata.as_ref().and_then(|m| m.line).map(|l| l + 1),
+            call_column: metadata.as_ref().and_then(|m| m.column),
+            depth: None,
+            via: None,
+        }
+    }
+
+    /// A function reached by a transitive query
+    pub fn reached(entry: crate::indexing::CallGraphEntry) -> Self {
+        Self {
+            depth: Some(entry.depth),
+            via: Some(entry.via),
+            ..Self::direct(entry.symbol, entry.metadata)
+        }
+    }
+}
+
+/// Symbol info extracted from search result for consistent JSON shape.
+/// Matches the nested `symbol: {...}` pattern used by semantic_search_docs.
+#[derive(Debug, serde::Serialize)]
+pub struct SymbolInfo {
+    id: crate::types::SymbolId,
+    name: String,
+    kind: crate::types::SymbolKind,
+    file_path: String,
+    line: u32,
+    column: u16,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    doc_comment: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    signature: Option<String>,
+    module_path: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    language_id: Option<String>,
+}
+
+/// Search result with nested symbol for consistent JSON output.
+/// Standardizes on `symbol: {...}` rather than flat `symbol_id: ...`.
+#[derive(Debug, serde::Serialize)]
+pub struct SearchSymbolResult {
+    symbol: SymbolInfo,
+    score: f32,
+    highlights: Vec<crate::storage::tantivy::TextHighlight>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    context: Option<String>,
+}
+
+impl From<crate::storage::tantivy::SearchResult> for SearchSymbolResult {
+    fn from(sr: crate::storage::tantivy::SearchResult) -> Self {
+        Self {
+            symbol: SymbolInfo {
+                id: sr.symbol_id,
+                name: sr.name,
+                kind: sr.kind,
+                file_path: sr.file_path,
+                line: sr.line,
+                column: sr.column,
+                doc_comment: sr.doc_comment,
+                signature: sr.signature,
+                module_path: sr.module_path,
+                language_id: sr.language_id,
+            },
+            score: sr.score,
+            highlights: sr.highlights,
+            context: sr.context,
+        }
+    }
+}
+
+/// Nested JSON rendering of a call hierarchy, one object per function with
+/// its location and its own callers (`incoming`) or callees (`outgoing`)
+/// under `children`. The `get_call_hierarchy` tool output.
+pub fn render_call_hierarchy(hierarchy: &CallHierarchy) -> serde_json::Value {
+    fn function(symbol: &Symbol) -> serde_json::Map<String, serde_json::Value> {
+        let mut object = serde_json::Map::new();
+        object.insert("symbol_id".into(), symbol.id.value().into());
+        object.insert("name".into(), symbol.name.to_string().into());
+        object.insert("kind".into(), format!("{:?}", symbol.kind).into());
+        object.insert(
+            "location".into(),
+            format!("{}:{}", symbol.file_path, symbol.range.start_line + 1).into(),
+        );
+        if let Some(signature) = &symbol.signature {
+            object.insert("signature".into(), signature.as_ref().into());
+        }
+        object
+    }
+    fn nodes(nodes: &[CallNode]) -> serde_json::Value {
+        nodes
+            .iter()
+            .map(|node| {
+                let mut object = function(&node.symbol);
+                if let Some(call_site) = &node.call_site {
+                    object.insert("call_site".into(), call_site.as_str().into());
+                }
+                if node.possible_target {
+                    object.insert("possible_target".into(), true.into());
+                }
+                object.insert("children".into(), nodes(&node.children));
+                serde_json::Value::Object(object)
+            })
+            .collect()
+    }
+
+    serde_json::json!({
+        "symbol": function(&hierarchy.symbol),
+        "depth": hierarchy.depth,
+        "incoming": nodes(&hierarchy.incoming),
+        "outgoing": nodes(&hierarchy.outgoing),
+    })
+}
+
+/// First non-empty line of a doc comment, trimmed
+pub fn doc_summary(doc: &str) -> Option<&str> {
+    doc.lines().map(str::trim).find(|line| !line.is_empty())
+}
+
+/// Text outline of the `symbols` of the file `path`, in the order
+/// [`IndexFacade::get_file_outline`] gives them: one row per symbol with its
+/// line range, indented under the symbol whose range holds it. The
+/// `get_file_outline` tool output.
+pub fn render_file_outline(path: &str, symbols: &[Symbol]) -> String {
+    let mut out = format!("{path}: {} symbol(s)\n", symbols.len());
+    // End lines of the symbols holding the current one
+    let mut enclosing: Vec<u32> = Vec::new();
+    for symbol in symbols {
+        while enclosing
+            .last()
+            .is_some_and(|&end| symbol.range.start_line > end)
+        {
+            enclosing.pop();
+        }
+        let indent = 2 * (enclosing.len() + 1);
+        out.push_str(&format!(
+            "{:indent$}{}-{} {:?} {} [symbol_id:{}]\n",
+            "",
+            symbol.range.start_line + 1,
+            symbol.range.end_line + 1,
+            symbol.kind,
+            symbol.name,
+            symbol.id.value()
+        ));
+        if let Some(signature) = &symbol.signature {
+            out.push_str(&format!("{:indent$}  Signature: {signature}\n", ""));
+        }
+        if let Some(doc) = symbol.doc_comment.as_deref().and_then(doc_summary) {
+            out.push_str(&format!("{:indent$}  Doc: {doc}\n", ""));
+        }
+        if symbol.range.end_line > symbol.range.start_line {
+            enclosing.push(symbol.range.end_line);
+        }
+    }
+    out
+}
+
 /// Parse the `receiver:{r},static:{s}` relationship context written by the
 /// parsers. Returns `None` when the context lacks the pattern or the
 /// receiver is empty.
@@ -338,6 +777,21 @@ mod tests {
         assert!(found.is_empty());
     }
 
+    #[test]
+    fn dotted_lookup_accepts_path_separator() {
+        // "Settings::debug" names a field the way Rust code spells it
+        let mut sym = method_symbol(1, "debug", Some("Settings"), "crate::config::debug");
+        sym.kind = crate::SymbolKind::Field;
+        let found = find_dotted_members("config::Settings::debug", |n| {
+            if n == "debug" {
+                vec![sym.clone()]
+            } else {
+                vec![]
+            }
+        });
+        assert_eq!(found.len(), 1);
+    }
+
     #[test]
     fn dotted_lookup_ignores_undotted_and_empty_segments() {
         assert!(find_dotted_members("plain", |_| unreachable!("no dot, no lookup")).is_empty());
@@ -345,6 +799,113 @@ mod tests {
         assert!(find_dotted_members("x.", |_| Vec::new()).is_empty());
     }
 
+    #[test]
+    fn call_hierarchy_rendering_nests_children() {
+        let symbol = |id: u32, name: &str| {
+            Symbol::new(
+                crate::SymbolId::new(id).unwrap(),
+                name,
+                crate::SymbolKind::Function,
+                crate::FileId::new(1).unwrap(),
+                crate::Range::new(id, 0, id, 10),
+            )
+        };
+        let node = |id: u32, name: &str, children| CallNode {
+            symbol: symbol(id, name),
+            call_site: None,
+            possible_target: false,
+            children,
+        };
+        let hierarchy = CallHierarchy {
+            symbol: symbol(2, "middle"),
+            depth: 3,
+            incoming: vec![node(1, "entry", Vec::new())],
+            outgoing: vec![node(3, "leaf", vec![node(4, "sink", Vec::new())])],
+        };
+
+        let json = render_call_hierarchy(&hierarchy);
+        assert_eq!(json["symbol"]["name"], "middle");
+        assert_eq!(json["incoming"][0]["name"], "entry");
+        assert_eq!(json["outgoing"][0]["children"][0]["name"], "sink");
+        assert_eq!(
+            json["outgoing"][0]["children"][0]["children"],
+            serde_json::json!([])
+        );
+        assert!(json["outgoing"][0].get("call_site").is_none());
+    }
+
+    #[test]
+    fn file_outline_indents_members() {
+        let symbol = |id: u32, name: &str, kind, start: u32, end: u32| {
+            Symbol::new(
+                crate::SymbolId::new(id).unwrap(),
+                name,
+                kind,
+                crate::FileId::new(1).unwrap(),
+                crate::Range::new(start, 0, end, 1),
+            )
+        };
+        let mut shape = symbol(1, "Shape", crate::SymbolKind::Class, 0, 5);
+        shape.doc_comment = Some("\n  A shape.\n  More.".into());
+        let symbols = [
+            shape,
+            symbol(2, "area", crate::SymbolKind::Method, 2, 4),
+            symbol(3, "make", crate::SymbolKind::Function, 7, 8),
+        ];
+
+        let text = render_file_outline("shapes.py", &symbols);
+        let lines: Vec<&str> = text.lines().collect();
+        assert_eq!(
+            lines,
+            [
+                "shapes.py: 3 symbol(s)",
+                "  1-6 Class Shape [symbol_id:1]",
+                "    Doc: A shape.",
+                "    3-5 Method area [symbol_id:2]",
+                "  8-9 Function make [symbol_id:3]",
+            ]
+        );
+    }
+
+    #[test]
+    fn hierarchy_rendering_nests_both_trees() {
+        let node = |id: u32, name: &str, relation, children| HierarchyNode {
+            symbol: Symbol::new(
+                crate::SymbolId::new(id).unwrap(),
+                name,
+                crate::SymbolKind::Class,
+                crate::FileId::new(1).unwrap(),
+                crate::Range::new(id, 0, id, 10),
+            ),
+            relation,
+            children,
+        };
+        let polygon = node(2, "Polygon", crate::RelationKind::Extends, Vec::new());
+        let hierarchy = TypeHierarchy {
+            symbol: polygon.symbol,
+            supertypes: vec![node(
+                1,
+                "Shape",
+                crate::RelationKind::Extends,
+                vec![node(
+                    4,
+                    "Drawable",
+                    crate::RelationKind::Implements,
+                    Vec::new(),
+                )],
+            )],
+            subtypes: Vec::new(),
+        };
+
+        let text = render_hierarchy(&hierarchy);
+        let lines: Vec<&str> = text.lines().collect();
+        assert!(lines[0].starts_with("Class Polygon at "));
+        assert_eq!(lines[1], "Supertypes:");
+        assert!(lines[2].starts_with("  -> extends Class Shape at "));
+        assert!(lines[3].starts_with("    -> implements Class Drawable at "));
+        assert_eq!(&lines[4..], ["Subtypes:", "  (none)"]);
+    }
+
     #[test]
     fn param_vocabulary_messages_are_stable() {
         assert_eq!(
@@ -357,7 +918,7 @@ mod tests {
         );
         assert_eq!(
             accepted_params_line("get_calls"),
-            "Accepted parameters for get_calls: function_name, symbol_id"
+            "Accepted parameters for get_calls: function_name, symbol_id, depth, page_size, cursor"
         );
         assert_eq!(
             accepted_params_line("get_index_info"),
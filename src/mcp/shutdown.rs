@@ -0,0 +1,65 @@
+//! Graceful shutdown of the MCP servers
+//!
+//! On SIGTERM or Ctrl+C a server stops taking tool calls, lets the ones it
+//! is answering finish for up to `server.shutdown_timeout_secs`, saves the
+//! index the file watcher may have changed, and exits. The transport keeps
+//! running meanwhile, so the answers in flight still reach their clients.
+
+use std::time::Duration;
+
+use tokio::sync::RwLock;
+
+use crate::IndexPersistence;
+use crate::indexing::facade::IndexFacade;
+use crate::mcp::limits::RequestLimits;
+
+/// Resolve on SIGTERM or Ctrl+C
+pub async fn signal() {
+    let ctrl_c = async {
+        tokio::signal::ctrl_c()
+            .await
+            .expect("failed to listen for ctrl+c");
+    };
+
+    #[cfg(unix)]
+    let terminate = async {
+        tokio::signal::unix::signal(tokio::signal::unix::SignalKind::terminate())
+            .expect("failed to listen for SIGTERM")
+            .recv()
+            .await;
+    };
+    #[cfg(not(unix))]
+    let terminate = std::future::pending::<()>();
+
+    tokio::select! {
+        _ = ctrl_c => {}
+        _ = terminate => {}
+    }
+    eprintln!("Received shutdown signal");
+}
+
+/// Refuse new tool calls, wait up to `timeout_secs` for the ones `limits`
+/// counts, then save the index of `facade` when given one
+pub async fn drain(
+    limits: &RequestLimits,
+    timeout_secs: u64,
+    facade: Option<&RwLock<IndexFacade>>,
+) {
+    crate::log_event!("shutdown", "draining", "up to {timeout_secs}s");
+    let unfinished = limits.drain(Duration::from_secs(timeout_secs)).await;
+    if unfinished > 0 {
+        tracing::warn!("[shutdown] {unfinished} tool call(s) still running at the deadline");
+    } else {
+        crate::log_event!("shutdown", "drained");
+    }
+
+    if let Some(facade) = facade {
+        // The read lock waits for a re-index in progress
+        let facade = facade.read().await;
+        let persistence = IndexPersistence::new(facade.settings().index_path.clone());
+        match persistence.save_facade(&facade) {
+            Ok(()) => crate::log_event!("shutdown", "index saved"),
+            Err(e) => tracing::error!("[shutdown] failed to save index: {e}"),
+        }
+    }
+}
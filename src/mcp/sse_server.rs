@@ -0,0 +1,385 @@
+//! Server-Sent Events transport for MCP
+//!
+//! `codanna serve --sse` adds the HTTP+SSE transport of MCP to the HTTP or
+//! HTTPS server, for clients that predate streamable HTTP. `GET /sse` opens
+//! a session: its first event names the endpoint to POST messages to, and
+//! every message of the server follows as a `message` event.
+//!
+//! Each event id names its session and its place in it. The server keeps
+//! the latest events of a session for [`RESUME_GRACE`] after its stream
+//! drops, so a client that reconnects with `Last-Event-ID`, as
+//! `EventSource` does by itself, gets the events it missed instead of
+//! running its query again.
+
+use std::collections::{HashMap, VecDeque};
+use std::convert::Infallible;
+use std::sync::{Arc, Mutex, Weak};
+use std::time::{Duration, Instant};
+
+use axum::Router;
+use axum::extract::{Query, State};
+use axum::http::{HeaderMap, StatusCode};
+use axum::response::sse::{Event, KeepAlive, Sse};
+use axum::response::{IntoResponse, Response};
+use futures::Stream;
+use serde::Deserialize;
+use tokio::io::{AsyncBufReadExt, AsyncWriteExt, BufReader, DuplexStream, WriteHalf};
+use tokio::sync::Notify;
+use tokio_util::sync::CancellationToken;
+
+use super::ws_server::{
+    RESUME_GRACE, ServerFactory, new_session_id, origin_allowed, serve_on_pipe, to_line,
+};
+
+/// How often an idle stream gets a keepalive comment
+const KEEP_ALIVE_INTERVAL: Duration = Duration::from_secs(15);
+
+/// Bytes of events kept per session for clients that reconnect; the oldest
+/// go first
+const EVENT_LOG_BYTES: usize = 8 * 1024 * 1024;
+
+/// The latest messages of a server, numbered from the first it sent
+#[derive(Debug, Default)]
+struct EventLog {
+    /// Number of the first event in `events`
+    first: u64,
+    events: VecDeque<String>,
+    bytes: usize,
+    /// Set once the server has ended
+    closed: bool,
+}
+
+impl EventLog {
+    fn push(&mut self, message: String) {
+        self.bytes += message.len();
+        self.events.push_back(message);
+        while self.bytes > EVENT_LOG_BYTES && self.events.len() > 1 {
+            if let Some(old) = self.events.pop_front() {
+                self.bytes -= old.len();
+                self.first += 1;
+            }
+        }
+    }
+
+    /// Number the next event will get
+    fn end(&self) -> u64 {
+        self.first + self.events.len() as u64
+    }
+
+    /// Event `n`: Ok(None) when it has not been sent yet, Err when it is no
+    /// longer kept
+    fn get(&self, n: u64) -> Result<Option<&str>, ()> {
+        if n < self.first {
+            return Err(());
+        }
+        Ok(self
+            .events
+            .get((n - self.first) as usize)
+            .map(String::as_str))
+    }
+}
+
+/// One MCP session, whether or not a stream is open on it
+struct SseSession {
+    id: String,
+    to_server: tokio::sync::Mutex<WriteHalf<DuplexStream>>,
+    log: Mutex<EventLog>,
+    new_event: Notify,
+    /// Open streams, and when the last one closed
+    streams: Mutex<(usize, Instant)>,
+    /// Stops the server of the session
+    ct: CancellationToken,
+}
+
+impl Drop for SseSession {
+    fn drop(&mut self) {
+        self.ct.cancel();
+    }
+}
+
+type Sessions = Arc<Mutex<HashMap<String, Arc<SseSession>>>>;
+
+#[derive(Clone)]
+struct SseState {
+    factory: ServerFactory,
+    sessions: Sessions,
+    allowed_origins: Option<Arc<[String]>>,
+    ct: CancellationToken,
+}
+
+#[derive(Deserialize)]
+struct SessionParams {
+    #[serde(rename = "sessionId")]
+    session_id: Option<String>,
+}
+
+/// Router serving MCP over SSE at `/sse`, with messages posted to
+/// `/message`. Browsers may connect only from `allowed_origins` when set,
+/// and from loopback origins otherwise.
+pub fn router(
+    factory: ServerFactory,
+    allowed_origins: Option<Vec<String>>,
+    ct: CancellationToken,
+) -> Router {
+    let sessions: Sessions = Arc::default();
+
+    // Drop sessions that ended or that nobody came back for
+    let reaper = sessions.clone();
+    let reaper_ct = ct.clone();
+    tokio::spawn(async move {
+        let mut tick = tokio::time::interval(KEEP_ALIVE_INTERVAL);
+        loop {
+            tokio::select! {
+                _ = tick.tick() => {
+                    reaper.lock().unwrap().retain(|id, session| {
+                        let (open, since) = *session.streams.lock().unwrap();
+                        let keep = open > 0 || since.elapsed() < RESUME_GRACE;
+                        if !keep {
+                            crate::debug_event!("sse", "session expired", "{id}");
+                        }
+                        keep
+                    });
+                }
+                _ = reaper_ct.cancelled() => break,
+            }
+        }
+    });
+
+    let state = SseState {
+        factory,
+        sessions,
+        allowed_origins: allowed_origins.map(Into::into),
+        ct,
+    };
+    Router::new()
+        .route("/sse", axum::routing::get(open_stream))
+        .route("/message", axum::routing::post(post_message))
+        .with_state(state)
+}
+
+fn check_origin(headers: &HeaderMap, state: &SseState) -> Result<(), Response> {
+    let origin = headers
+        .get(axum::http::header::ORIGIN)
+        .and_then(|value| value.to_str().ok());
+    match origin {
+        Some(origin) if !origin_allowed(origin, state.allowed_origins.as_deref()) => {
+            tracing::warn!("[sse] rejected connection from origin {origin}");
+            Err(StatusCode::FORBIDDEN.into_response())
+        }
+        _ => Ok(()),
+    }
+}
+
+/// `<session>-<n>`: event `n` of a session
+fn event_id(session: &str, n: u64) -> String {
+    format!("{session}-{n}")
+}
+
+fn parse_event_id(id: &str) -> Option<(&str, u64)> {
+    let (session, n) = id.rsplit_once('-')?;
+    Some((session, n.parse().ok()?))
+}
+
+async fn open_stream(headers: HeaderMap, State(state): State<SseState>) -> Response {
+    if let Err(response) = check_origin(&headers, &state) {
+        return response;
+    }
+
+    let last_event = headers
+        .get("Last-Event-ID")
+        .and_then(|value| value.to_str().ok());
+    let (session, next) = match last_event {
+        Some(last_event) => {
+            let Some((id, n)) = parse_event_id(last_event) else {
+                return (StatusCode::BAD_REQUEST, "malformed Last-Event-ID").into_response();
+            };
+            let Some(session) = state.sessions.lock().unwrap().get(id).cloned() else {
+                return (StatusCode::NOT_FOUND, "unknown or expired session").into_response();
+            };
+            if session.log.lock().unwrap().get(n + 1).is_err() {
+                return (
+                    StatusCode::GONE,
+                    "the events after Last-Event-ID are no longer kept",
+                )
+                    .into_response();
+            }
+            crate::debug_event!("sse", "session resumed", "{id} after event {n}");
+            (session, n + 1)
+        }
+        None => (start_session(&state), 0),
+    };
+
+    Sse::new(events(session, next))
+        .keep_alive(KeepAlive::new().interval(KEEP_ALIVE_INTERVAL))
+        .into_response()
+}
+
+/// Start an MCP server for a new session, and copy its messages to the
+/// session's event log as they come
+fn start_session(state: &SseState) -> Arc<SseSession> {
+    let id = new_session_id();
+    let ct = state.ct.child_token();
+    let pipe = serve_on_pipe((state.factory)(), &id, ct.clone());
+    let (read, write) = tokio::io::split(pipe);
+    let session = Arc::new(SseSession {
+        id: id.clone(),
+        to_server: tokio::sync::Mutex::new(write),
+        log: Mutex::default(),
+        new_event: Notify::new(),
+        streams: Mutex::new((0, Instant::now())),
+        ct,
+    });
+
+    // Only a weak reference, so that expiring the session drops it and
+    // stops the server
+    let log_session: Weak<SseSession> = Arc::downgrade(&session);
+    tokio::spawn(async move {
+        let mut lines = BufReader::new(read).lines();
+        while let Ok(Some(message)) = lines.next_line().await {
+            let Some(session) = log_session.upgrade() else {
+                return;
+            };
+            session.log.lock().unwrap().push(message);
+            session.new_event.notify_waiters();
+        }
+        if let Some(session) = log_session.upgrade() {
+            session.log.lock().unwrap().closed = true;
+            session.new_event.notify_waiters();
+        }
+    });
+
+    state
+        .sessions
+        .lock()
+        .unwrap()
+        .insert(id.clone(), session.clone());
+    crate::debug_event!("sse", "session started", "{id}");
+    session
+}
+
+/// Counts a stream as open on its session for as long as it lives
+struct StreamGuard(Arc<SseSession>);
+
+impl StreamGuard {
+    fn new(session: Arc<SseSession>) -> Self {
+        session.streams.lock().unwrap().0 += 1;
+        Self(session)
+    }
+}
+
+impl Drop for StreamGuard {
+    fn drop(&mut self) {
+        let mut streams = self.0.streams.lock().unwrap();
+        *streams = (streams.0 - 1, Instant::now());
+    }
+}
+
+/// The events of `session` from event `next` on: the endpoint to post to,
+/// then the messages of the server as they come. Ends with the server, or
+/// when the stream falls so far behind that the log no longer has its next
+/// event.
+fn events(session: Arc<SseSession>, next: u64) -> impl Stream<Item = Result<Event, Infallible>> {
+    let endpoint = Event::default()
+        .event("endpoint")
+        .data(format!("/message?sessionId={}", session.id));
+    let messages = futures::stream::unfold(
+        (StreamGuard::new(session), next),
+        |(guard, next)| async move {
+            let session = &guard.0;
+            loop {
+                let notified = session.new_event.notified();
+                tokio::pin!(notified);
+                notified.as_mut().enable();
+                {
+                    let log = session.log.lock().unwrap();
+                    match log.get(next) {
+                        Ok(Some(message)) => {
+                            let event = Event::default()
+                                .event("message")
+                                .id(event_id(&session.id, next))
+                                .data(message);
+                            drop(log);
+                            return Some((Ok(event), (guard, next + 1)));
+                        }
+                        Ok(None) if log.closed => return None,
+                        Ok(None) => {}
+                        Err(()) => {
+                            tracing::warn!(
+                                "[sse] stream of session {} fell behind at event {next} of {}",
+                                session.id,
+                                log.end()
+                            );
+                            return None;
+                        }
+                    }
+                }
+                notified.await;
+            }
+        },
+    );
+    futures::StreamExt::chain(futures::stream::once(async { Ok(endpoint) }), messages)
+}
+
+async fn post_message(
+    headers: HeaderMap,
+    Query(params): Query<SessionParams>,
+    State(state): State<SseState>,
+    body: String,
+) -> Response {
+    if let Err(response) = check_origin(&headers, &state) {
+        return response;
+    }
+    let Some(id) = params.session_id else {
+        return (StatusCode::BAD_REQUEST, "missing sessionId").into_response();
+    };
+    let Some(session) = state.sessions.lock().unwrap().get(&id).cloned() else {
+        return (StatusCode::NOT_FOUND, "unknown or expired session").into_response();
+    };
+    let Some(line) = to_line(&body) else {
+        return (StatusCode::BAD_REQUEST, "body is not JSON").into_response();
+    };
+    if session
+        .to_server
+        .lock()
+        .await
+        .write_all(line.as_bytes())
+        .await
+        .is_err()
+    {
+        return (StatusCode::GONE, "session ended").into_response();
+    }
+    StatusCode::ACCEPTED.into_response()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_event_log_keeps_the_latest_events() {
+        let mut log = EventLog::default();
+        log.push("a".to_string());
+        log.push("b".to_string());
+        assert_eq!(log.get(1), Ok(Some("b")));
+        assert_eq!(log.get(2), Ok(None));
+
+        let big = "x".repeat(EVENT_LOG_BYTES);
+        log.push(big.clone());
+        assert_eq!(log.get(0), Err(()));
+        assert_eq!(log.get(2), Ok(Some(big.as_str())));
+        assert_eq!(log.end(), 3);
+
+        // The latest event is kept whatever its size
+        log.push(big.clone());
+        assert_eq!(log.first, 3);
+        assert_eq!(log.get(3), Ok(Some(big.as_str())));
+    }
+
+    #[test]
+    fn test_event_ids_name_the_session() {
+        let id = event_id("3f2a", 17);
+        assert_eq!(parse_event_id(&id), Some(("3f2a", 17)));
+        assert_eq!(parse_event_id("17"), None);
+        assert_eq!(parse_event_id("3f2a-x"), None);
+    }
+}
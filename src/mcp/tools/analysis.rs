@@ -0,0 +1,178 @@
+//! Codebase analysis tools: find_unused_symbols, find_tests, find_todos.
+
+use rmcp::model::ErrorData as McpError;
+use rmcp::model::*;
+use rmcp::{handler::server::wrapper::Parameters, tool, tool_router};
+
+use crate::analysis::{render_tests, render_todos, render_unused, tests_for};
+use crate::mcp::requests::{FindTestsRequest, FindTodosRequest, FindUnusedSymbolsRequest};
+use crate::mcp::server::{CodeIntelligenceServer, generate_mcp_guidance};
+use crate::mcp::service::{self, SymbolResolution, render_ambiguity};
+
+#[tool_router(router = analysis_router, vis = "pub(crate)")]
+impl CodeIntelligenceServer {
+    #[tool(
+        description = "Find symbols nothing calls, uses, implements, extends or imports: candidates for dead code removal. Entry points (main), test code and public API are skipped, as configured in [analysis.unused] of the settings.\n\nConfirm with find_callers and a text search before removing: calls made by reflection, macros or code outside the index are not seen."
+    )]
+    pub async fn find_unused_symbols(
+        &self,
+        Parameters(FindUnusedSymbolsRequest {
+            kind,
+            path,
+            lang,
+            include_public,
+            limit,
+            project,
+        }): Parameters<FindUnusedSymbolsRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        // One kind vocabulary (SymbolKind::from_str); unknown kinds error
+        // instead of silently returning unfiltered results.
+        let mut unused = match service::find_unused_symbols(
+            &indexer,
+            kind.as_deref(),
+            path,
+            lang.as_deref(),
+            include_public,
+        ) {
+            Ok(unused) => unused,
+            Err(e) => {
+                return Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                    "Error: {e}"
+                ))]));
+            }
+        };
+        let found = unused.len();
+        unused.truncate(limit as usize);
+
+        let mut result = if found == 0 {
+            "No unused symbols found\n".to_string()
+        } else {
+            let mut result = format!("Found {found} unused symbol(s):\n");
+            result.push_str(&render_unused(&unused));
+            if unused.len() < found {
+                result.push_str(&format!(
+                    "  ... {} more (raise limit to see them)\n",
+                    found - unused.len()
+                ));
+            }
+            result
+        };
+
+        if let Some(guidance) =
+            generate_mcp_guidance(indexer.settings(), "find_unused_symbols", found)
+        {
+            result.push_str("\n---\nGuidance: ");
+            result.push_str(&guidance);
+            result.push('\n');
+        }
+
+        Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+    }
+
+    #[tool(
+        description = "Find the tests that exercise a function: Rust #[test] functions, pytest test_* functions, Go TestXxx functions and Jest/Vitest/Mocha it/test blocks that call it, directly or through test helpers.\n\nShows: each test with how many calls away it reaches the function.\nDoes NOT show: Tests that only reach it through code outside the index."
+    )]
+    pub async fn find_tests(
+        &self,
+        Parameters(FindTestsRequest {
+            symbol_name,
+            symbol_id,
+            max_depth,
+            project,
+        }): Parameters<FindTestsRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        // Shared resolution policy; see service.rs.
+        let symbol = match service::resolve_symbol_or_id(&indexer, symbol_id, symbol_name) {
+            SymbolResolution::Resolved { symbol, .. } => symbol,
+            SymbolResolution::NotFoundById(id) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Symbol not found: symbol_id:{id}"
+                ))]));
+            }
+            SymbolResolution::NotFoundByName(name) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Symbol not found: {name}"
+                ))]));
+            }
+            SymbolResolution::Ambiguous { name, candidates } => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(
+                    render_ambiguity("find_tests", &name, &candidates),
+                )]));
+            }
+            SymbolResolution::MissingParam => {
+                return Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                    "{}\n{}",
+                    service::missing_param_message("find_tests"),
+                    service::accepted_params_line("find_tests"),
+                ))]));
+            }
+        };
+
+        let tests = tests_for(&indexer, symbol.id, max_depth as usize);
+        let mut result = if tests.is_empty() {
+            format!("No tests call {} within {max_depth} call(s)\n", symbol.name)
+        } else {
+            let mut result = format!("Found {} test(s) for {}:\n", tests.len(), symbol.name);
+            result.push_str(&render_tests(&tests));
+            result
+        };
+
+        if let Some(guidance) = generate_mcp_guidance(indexer.settings(), "find_tests", tests.len())
+        {
+            result.push_str("\n---\nGuidance: ");
+            result.push_str(&guidance);
+            result.push('\n');
+        }
+
+        Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+    }
+
+    #[tool(
+        description = "Find TODO, FIXME, HACK and other tagged comments (the tags of indexing.todo_tags), each with the symbol it is about: the one just below the comment or the one holding it.\n\nFilter by tag, by path to the code you are editing, or by the author of TODO(author) comments."
+    )]
+    pub async fn find_todos(
+        &self,
+        Parameters(FindTodosRequest {
+            tag,
+            path,
+            author,
+            limit,
+            project,
+        }): Parameters<FindTodosRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        let mut todos = service::find_todos(&indexer, tag.as_deref(), path, author);
+        let found = todos.len();
+        todos.truncate(limit as usize);
+
+        let mut result = if found == 0 {
+            "No todos found\n".to_string()
+        } else {
+            let mut result = format!("Found {found} todo(s):\n");
+            result.push_str(&render_todos(&todos));
+            if todos.len() < found {
+                result.push_str(&format!(
+                    "  ... {} more (raise limit to see them)\n",
+                    found - todos.len()
+                ));
+            }
+            result
+        };
+
+        if let Some(guidance) = generate_mcp_guidance(indexer.settings(), "find_todos", found) {
+            result.push_str("\n---\nGuidance: ");
+            result.push_str(&guidance);
+            result.push('\n');
+        }
+
+        Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+    }
+}
@@ -1,4 +1,5 @@
 //! MCP tool handlers, split by concern; routers combine in server.rs.
 
+pub mod analysis;
 pub mod search;
 pub mod symbols;
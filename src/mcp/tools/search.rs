@@ -1,26 +1,39 @@
-//! Search and info tools: get_index_info, semantic_search_docs,
-//! semantic_search_with_context, search_symbols, search_documents.
+//! Search and info tools: get_index_info, list_projects,
+//! semantic_search_docs, semantic_search_with_context, search_feedback,
+//! find_similar_symbols, search_symbols, query_symbols, search_documents,
+//! run_saved_query.
 
 use rmcp::model::ErrorData as McpError;
 use rmcp::model::*;
 use rmcp::{handler::server::wrapper::Parameters, tool, tool_router};
 
+use crate::config::SemanticVectors;
 use crate::documents::SearchQuery as DocSearchQuery;
+use crate::semantic::SemanticFilter;
+use crate::storage::IndexLease;
+use crate::symbol::dsl::{QueryField, SymbolQuery};
+use crate::symbol::name_match::SearchMode;
+use crate::symbol::snippet::SourceSnippet;
 
+use crate::mcp::pagination::{Page, next_page_line};
 use crate::mcp::requests::{
-    GetIndexInfoRequest, SearchDocumentsRequest, SearchSymbolsRequest, SemanticSearchRequest,
-    SemanticSearchWithContextRequest,
+    FindSimilarSymbolsRequest, GetIndexInfoRequest, ListProjectsRequest, QuerySymbolsRequest,
+    RunSavedQueryRequest, SearchDocumentsRequest, SearchFeedbackRequest, SearchSymbolsRequest,
+    SemanticSearchRequest, SemanticSearchWithContextRequest,
 };
 use crate::mcp::server::{CodeIntelligenceServer, format_relative_time, generate_mcp_guidance};
+use crate::mcp::service::{self, SymbolResolution, render_ambiguity};
+use crate::queries::{QueryCall, QueryStore, queries_path};
 
 #[tool_router(router = search_router, vis = "pub(crate)")]
 impl CodeIntelligenceServer {
     #[tool(description = "Get information about the indexed codebase")]
     pub async fn get_index_info(
         &self,
-        Parameters(_params): Parameters<GetIndexInfoRequest>,
+        Parameters(GetIndexInfoRequest { project }): Parameters<GetIndexInfoRequest>,
     ) -> Result<CallToolResult, McpError> {
-        let indexer = self.facade.read().await;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
         let symbol_count = indexer.symbol_count();
         let file_count = indexer.file_count();
         let relationship_count = indexer.relationship_count();
@@ -54,24 +67,78 @@ impl CodeIntelligenceServer {
             "\n\nSemantic Search:\n  - Status: Disabled".to_string()
         };
 
+        // A process writing the index, whose changes load once it finishes
+        let writer_info = IndexLease::holder(&indexer.settings().index_path)
+            .map(|holder| format!("\n\nIndex Writer:\n  - {holder}"))
+            .unwrap_or_default();
+
         let result = format!(
-            "Index contains {symbol_count} symbols across {file_count} files.\n\nBreakdown:\n  - Symbols: {symbol_count}\n  - Relationships: {relationship_count}\n\nSymbol Kinds:{kinds_display}\n\nLanguages:{languages_display}{semantic_info}"
+            "Index contains {symbol_count} symbols across {file_count} files.\n\nBreakdown:\n  - Symbols: {symbol_count}\n  - Relationships: {relationship_count}\n\nSymbol Kinds:{kinds_display}\n\nLanguages:{languages_display}{semantic_info}{writer_info}"
         );
 
         Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
     }
 
+    #[tool(
+        description = "List the projects this server answers for. Pass one's name as `project` to any tool to search its index instead of the default project's."
+    )]
+    pub async fn list_projects(
+        &self,
+        Parameters(_params): Parameters<ListProjectsRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let names = self.projects.names();
+        let mut result = format!(
+            "{} project{}:",
+            names.len(),
+            if names.len() == 1 { "" } else { "s" }
+        );
+        for name in names {
+            let project = self.project(Some(name))?;
+            let indexer = project.facade.read().await;
+            let default = if name == self.projects.current {
+                " (default)"
+            } else {
+                ""
+            };
+            let semantic = if indexer.has_semantic_search() {
+                "semantic search enabled"
+            } else {
+                "semantic search disabled"
+            };
+            result.push_str(&format!(
+                "\n  - {name}{default}: {} symbols in {} files, {semantic}",
+                indexer.symbol_count(),
+                indexer.file_count()
+            ));
+            if let Some(root) = &indexer.settings().workspace_root {
+                result.push_str(&format!("\n    {}", root.display()));
+            }
+        }
+
+        Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+    }
+
     #[tool(description = "Search documentation using natural language semantic search")]
     pub async fn semantic_search_docs(
         &self,
-        Parameters(SemanticSearchRequest {
+        Parameters(request): Parameters<SemanticSearchRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        self.record_query("semantic_search_docs", &request).await;
+        let SemanticSearchRequest {
             query,
             limit,
             threshold,
             lang,
-        }): Parameters<SemanticSearchRequest>,
-    ) -> Result<CallToolResult, McpError> {
-        let indexer = self.facade.read().await;
+            kind,
+            path,
+            visibility,
+            vectors,
+            page_size,
+            cursor,
+            project,
+        } = request;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
 
         tracing::debug!(
             target: "mcp",
@@ -102,20 +169,38 @@ impl CodeIntelligenceServer {
             ))]));
         }
 
-        let results = match threshold {
-            Some(t) => indexer.semantic_search_docs_with_threshold_and_language(
-                &query,
-                limit as usize,
-                t,
-                lang.as_deref(),
-            ),
-            None => {
-                indexer.semantic_search_docs_with_language(&query, limit as usize, lang.as_deref())
+        let filter = SemanticFilter::parse(
+            lang.as_deref(),
+            kind.as_deref(),
+            path.as_deref(),
+            visibility.as_deref(),
+        );
+        let vectors = vectors
+            .as_deref()
+            .map(str::parse::<SemanticVectors>)
+            .transpose();
+        let (filter, vectors) = match (filter, vectors) {
+            (Ok(filter), Ok(vectors)) => (filter, vectors),
+            (Err(e), _) | (_, Err(e)) => {
+                return Ok(CallToolResult::error(vec![ContentBlock::text(e)]));
             }
         };
+        let page = match Page::new(cursor.as_deref(), page_size, limit as usize) {
+            Ok(page) => page,
+            Err(e) => return Ok(CallToolResult::error(vec![ContentBlock::text(e)])),
+        };
+        let results = indexer
+            .semantic_search_in(&query, page.fetch_limit(), &filter, vectors)
+            .map(|results| {
+                results
+                    .into_iter()
+                    .filter(|(_, score)| threshold.is_none_or(|t| *score >= t))
+                    .collect::<Vec<_>>()
+            });
 
         match results {
             Ok(results) => {
+                let (results, next_cursor) = page.take(results);
                 if results.is_empty() {
                     let mut output =
                         format!("No semantically similar documentation found for: {query}");
@@ -139,7 +224,7 @@ impl CodeIntelligenceServer {
                 for (i, (symbol, score)) in results.iter().enumerate() {
                     result.push_str(&format!(
                         "{}. {} ({:?}) - Similarity: {:.3}\n",
-                        i + 1,
+                        page.offset + i + 1,
                         symbol.name,
                         symbol.kind,
                         score
@@ -165,8 +250,20 @@ impl CodeIntelligenceServer {
                         result.push_str(&format!("   Signature: {sig}\n"));
                     }
 
+                    if let Some(ref authorship) = symbol.authorship {
+                        result.push_str(&format!("   Last changed: {}\n", authorship.summary()));
+                    }
+
+                    let owners = indexer.owners_of(&symbol.file_path);
+                    if !owners.is_empty() {
+                        result.push_str(&format!("   Owners: {}\n", owners.join(" ")));
+                    }
+
                     result.push('\n');
                 }
+                if let Some(cursor) = &next_cursor {
+                    result.push_str(&next_page_line(cursor));
+                }
 
                 // Add system guidance
                 if let Some(guidance) =
@@ -186,18 +283,29 @@ impl CodeIntelligenceServer {
     }
 
     #[tool(
-        description = "Search by natural language and get full context: documentation, dependencies, callers, impact.\n\nReturns symbols with:\n- Their documentation\n- What calls them\n- What they call\n- Complete impact graph (includes ALL relationships: calls, type usage, composition)\n\nUse this when: You want to find and understand symbols with their complete usage context."
+        description = "Search by natural language and get full context: documentation, dependencies, callers, impact.\n\nReturns symbols with:\n- Their documentation\n- What calls them\n- What they call\n- Complete impact graph (includes ALL relationships: calls, type usage, composition)\n\nUse this when: You want to find and understand symbols with their complete usage context. Set context_lines to read each symbol's source without opening its file."
     )]
     pub async fn semantic_search_with_context(
         &self,
-        Parameters(SemanticSearchWithContextRequest {
+        Parameters(request): Parameters<SemanticSearchWithContextRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        self.record_query("semantic_search_with_context", &request)
+            .await;
+        let SemanticSearchWithContextRequest {
             query,
             limit,
             threshold,
             lang,
-        }): Parameters<SemanticSearchWithContextRequest>,
-    ) -> Result<CallToolResult, McpError> {
-        let indexer = self.facade.read().await;
+            kind,
+            path,
+            visibility,
+            context_lines,
+            page_size,
+            cursor,
+            project,
+        } = request;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
 
         if !indexer.has_semantic_search() {
             tracing::debug!(
@@ -221,21 +329,27 @@ impl CodeIntelligenceServer {
             ))]));
         }
 
-        // First, perform semantic search
-        let search_results = match threshold {
-            Some(t) => indexer.semantic_search_docs_with_threshold_and_language(
-                &query,
-                limit as usize,
-                t,
-                lang.as_deref(),
-            ),
-            None => {
-                indexer.semantic_search_docs_with_language(&query, limit as usize, lang.as_deref())
-            }
+        let filter = match SemanticFilter::parse(
+            lang.as_deref(),
+            kind.as_deref(),
+            path.as_deref(),
+            visibility.as_deref(),
+        ) {
+            Ok(filter) => filter,
+            Err(e) => return Ok(CallToolResult::error(vec![ContentBlock::text(e)])),
         };
+        let page = match Page::new(cursor.as_deref(), page_size, limit as usize) {
+            Ok(page) => page,
+            Err(e) => return Ok(CallToolResult::error(vec![ContentBlock::text(e)])),
+        };
+
+        // First, perform semantic search
+        let search_results =
+            indexer.semantic_search_docs_reranked(&query, page.fetch_limit(), threshold, &filter);
 
         match search_results {
             Ok(results) => {
+                let (results, next_cursor) = page.take(results);
                 if results.is_empty() {
                     let mut output = format!("No documentation found matching query: {query}");
                     // Add guidance for no results
@@ -261,7 +375,7 @@ impl CodeIntelligenceServer {
                     // Basic symbol information - matching find_symbol format
                     output.push_str(&format!(
                         "{}. {} - {:?} at {} [symbol_id:{}]\n",
-                        idx + 1,
+                        page.offset + idx + 1,
                         symbol.name,
                         symbol.kind,
                         crate::symbol::context::SymbolContext::symbol_location(symbol),
@@ -269,13 +383,14 @@ impl CodeIntelligenceServer {
                     ));
                     output.push_str(&format!("   Similarity Score: {score:.3}\n"));
 
-                    // Documentation
+                    // Documentation, in full when the source is asked for
                     if let Some(ref doc) = symbol.doc_comment {
+                        let shown = context_lines.map_or(5, |_| usize::MAX);
                         output.push_str("   Documentation:\n");
-                        for line in doc.lines().take(5) {
+                        for line in doc.lines().take(shown) {
                             output.push_str(&format!("     {line}\n"));
                         }
-                        if doc.lines().count() > 5 {
+                        if doc.lines().count() > shown {
                             output.push_str("     ...\n");
                         }
                     }
@@ -285,6 +400,33 @@ impl CodeIntelligenceServer {
                         output.push_str(&format!("   Signature: {sig}\n"));
                     }
 
+                    if let Some(lines) = context_lines {
+                        if let Some(source) = SourceSnippet::of_symbol(
+                            symbol,
+                            indexer.settings().workspace_root.as_deref(),
+                            lines as usize,
+                        ) {
+                            output.push_str(&format!(
+                                "   Source ({}-{}{}):\n",
+                                source.start_line,
+                                source.end_line,
+                                if source.truncated { ", truncated" } else { "" }
+                            ));
+                            for line in source.code.lines() {
+                                output.push_str(&format!("     {line}\n"));
+                            }
+                        }
+                    }
+
+                    if let Some(ref authorship) = symbol.authorship {
+                        output.push_str(&format!("   Last changed: {}\n", authorship.summary()));
+                    }
+
+                    let owners = indexer.owners_of(&symbol.file_path);
+                    if !owners.is_empty() {
+                        output.push_str(&format!("   Owners: {}\n", owners.join(" ")));
+                    }
+
                     // Only gather additional context for functions/methods
                     if matches!(
                         symbol.kind,
@@ -697,6 +839,9 @@ impl CodeIntelligenceServer {
 
                     output.push('\n');
                 }
+                if let Some(cursor) = &next_cursor {
+                    output.push_str(&next_page_line(cursor));
+                }
 
                 // Add system guidance
                 if let Some(guidance) = generate_mcp_guidance(
@@ -717,18 +862,195 @@ impl CodeIntelligenceServer {
         }
     }
 
-    #[tool(description = "Search for symbols using full-text search with fuzzy matching")]
+    #[tool(
+        description = "Mark results of a semantic search as relevant or irrelevant for its query, by symbol_id. The marks are kept with the project, and later semantic searches with similar wording rank the relevant symbols higher and the irrelevant ones lower, so search learns the project's vocabulary."
+    )]
+    pub async fn search_feedback(
+        &self,
+        Parameters(SearchFeedbackRequest {
+            query,
+            relevant,
+            irrelevant,
+            project,
+        }): Parameters<SearchFeedbackRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let relevant = relevant.unwrap_or_default();
+        let irrelevant = irrelevant.unwrap_or_default();
+        if relevant.is_empty() && irrelevant.is_empty() {
+            return Ok(CallToolResult::error(vec![ContentBlock::text(
+                "search_feedback requires 'relevant' or 'irrelevant' symbol IDs",
+            )]));
+        }
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        let mut result = String::new();
+        for (ids, is_relevant) in [(relevant, true), (irrelevant, false)] {
+            if ids.is_empty() {
+                continue;
+            }
+            let ids: Vec<crate::SymbolId> = ids.into_iter().map(crate::SymbolId).collect();
+            match indexer.record_feedback(&query, &ids, is_relevant) {
+                Ok(symbols) => {
+                    let label = if is_relevant {
+                        "relevant"
+                    } else {
+                        "irrelevant"
+                    };
+                    result.push_str(&format!("Marked {label} for \"{query}\":\n"));
+                    for symbol in symbols {
+                        result.push_str(&format!(
+                            "  - {} ({:?}) at {} [symbol_id:{}]\n",
+                            symbol.name,
+                            symbol.kind,
+                            symbol.file_path,
+                            symbol.id.value()
+                        ));
+                    }
+                }
+                Err(e) => {
+                    return Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                        "Failed to record feedback: {e}"
+                    ))]));
+                }
+            }
+        }
+
+        Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+    }
+
+    #[tool(
+        description = "Find the symbols most similar in meaning to a given one, by comparing its embedding with the others: its doc comment, or its signature when it has none.\n\nUse it before writing a helper, to find the existing one to reuse, and to spot reimplementations of the same logic."
+    )]
+    pub async fn find_similar_symbols(
+        &self,
+        Parameters(request): Parameters<FindSimilarSymbolsRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        self.record_query("find_similar_symbols", &request).await;
+        let FindSimilarSymbolsRequest {
+            symbol_name,
+            symbol_id,
+            limit,
+            lang,
+            kind,
+            path,
+            project,
+        } = request;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        if !indexer.has_semantic_search() {
+            return Ok(CallToolResult::error(vec![ContentBlock::text(
+                "Semantic search is not enabled. Enable it in settings.toml and rebuild the index.",
+            )]));
+        }
+
+        // Shared resolution policy; see service.rs.
+        let symbol = match service::resolve_symbol_or_id(&indexer, symbol_id, symbol_name) {
+            SymbolResolution::Resolved { symbol, .. } => symbol,
+            SymbolResolution::NotFoundById(id) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Symbol not found: symbol_id:{id}"
+                ))]));
+            }
+            SymbolResolution::NotFoundByName(name) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Symbol not found: {name}"
+                ))]));
+            }
+            SymbolResolution::Ambiguous { name, candidates } => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(
+                    render_ambiguity("find_similar_symbols", &name, &candidates),
+                )]));
+            }
+            SymbolResolution::MissingParam => {
+                return Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                    "{}\n{}",
+                    service::missing_param_message("find_similar_symbols"),
+                    service::accepted_params_line("find_similar_symbols"),
+                ))]));
+            }
+        };
+
+        let filter =
+            match SemanticFilter::parse(lang.as_deref(), kind.as_deref(), path.as_deref(), None) {
+                Ok(filter) => filter,
+                Err(e) => return Ok(CallToolResult::error(vec![ContentBlock::text(e)])),
+            };
+
+        match indexer.similar_symbols(symbol.id, limit as usize, &filter) {
+            Ok(results) => {
+                let mut result = if results.is_empty() {
+                    format!("No symbols similar to {} found\n", symbol.name)
+                } else {
+                    format!(
+                        "Found {} symbol(s) similar to {} ({:?}):\n\n",
+                        results.len(),
+                        symbol.name,
+                        symbol.kind
+                    )
+                };
+
+                for (i, (similar, score)) in results.iter().enumerate() {
+                    result.push_str(&format!(
+                        "{}. {} ({:?}) - Similarity: {:.3} [symbol_id:{}]\n",
+                        i + 1,
+                        similar.name,
+                        similar.kind,
+                        score,
+                        similar.id.value()
+                    ));
+                    result.push_str(&format!(
+                        "   File: {}:{}\n",
+                        similar.file_path,
+                        similar.range.start_line + 1
+                    ));
+                    if let Some(ref doc) = similar.doc_comment {
+                        let first_line = doc.lines().next().unwrap_or("");
+                        result.push_str(&format!("   Doc: {first_line}\n"));
+                    } else if let Some(ref sig) = similar.signature {
+                        result.push_str(&format!("   Signature: {sig}\n"));
+                    }
+                    result.push('\n');
+                }
+
+                if let Some(guidance) =
+                    generate_mcp_guidance(indexer.settings(), "find_similar_symbols", results.len())
+                {
+                    result.push_str("\n---\nGuidance: ");
+                    result.push_str(&guidance);
+                    result.push('\n');
+                }
+
+                Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+            }
+            Err(e) => Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                "Similarity search failed: {e}"
+            ))])),
+        }
+    }
+
+    #[tool(
+        description = "Search for symbols using full-text search with fuzzy matching. Set mode to \"fuzzy\" to rank names despite typos, case and separators (parseFile finds parse_file), or to \"regex\" to match names with a regular expression."
+    )]
     pub async fn search_symbols(
         &self,
-        Parameters(SearchSymbolsRequest {
+        Parameters(request): Parameters<SearchSymbolsRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        self.record_query("search_symbols", &request).await;
+        let SearchSymbolsRequest {
             query,
             limit,
             kind,
             module,
             lang,
-        }): Parameters<SearchSymbolsRequest>,
-    ) -> Result<CallToolResult, McpError> {
-        let indexer = self.facade.read().await;
+            mode,
+            page_size,
+            cursor,
+            project,
+        } = request;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
 
         // One kind vocabulary (SymbolKind::from_str); unknown kinds error
         // instead of silently returning unfiltered results.
@@ -742,14 +1064,31 @@ impl CodeIntelligenceServer {
             }
         };
 
-        match indexer.search(
+        let mode = match mode.as_deref().map(str::parse::<SearchMode>) {
+            None => SearchMode::default(),
+            Some(Ok(mode)) => mode,
+            Some(Err(e)) => {
+                return Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                    "Error: {e}"
+                ))]));
+            }
+        };
+
+        let page = match Page::new(cursor.as_deref(), page_size, limit as usize) {
+            Ok(page) => page,
+            Err(e) => return Ok(CallToolResult::error(vec![ContentBlock::text(e)])),
+        };
+
+        match indexer.search_with_mode(
             &query,
-            limit as usize,
+            mode,
+            page.fetch_limit(),
             kind_filter,
             module.as_deref(),
             lang.as_deref(),
         ) {
             Ok(results) => {
+                let (results, next_cursor) = page.take(results);
                 if results.is_empty() {
                     let mut output = format!("No results found for query: {query}");
                     // Add guidance for no results
@@ -772,7 +1111,7 @@ impl CodeIntelligenceServer {
                 for (i, search_result) in results.iter().enumerate() {
                     result.push_str(&format!(
                         "{}. {} ({:?})\n",
-                        i + 1,
+                        page.offset + i + 1,
                         search_result.name,
                         search_result.kind
                     ));
@@ -798,6 +1137,9 @@ impl CodeIntelligenceServer {
                     result.push_str(&format!("   Score: {:.2}\n", search_result.score));
                     result.push('\n');
                 }
+                if let Some(cursor) = &next_cursor {
+                    result.push_str(&next_page_line(cursor));
+                }
 
                 // Add system guidance
                 if let Some(guidance) =
@@ -816,18 +1158,99 @@ impl CodeIntelligenceServer {
         }
     }
 
+    #[tool(
+        description = "Find the symbols matching a compound filter in one call, e.g. `kind:function lang:rust path:src/parsing/** calls:parse_file doc:\"utf-8\"`.\n\nKeys: kind, lang, name (a * makes it a glob), module, path (glob, or a directory), author, doc and sig (words, in order), and the relationships calls, called_by, implements, extends and uses, which take a symbol name. Every term must hold; `-key:value` negates one, `a,b` takes either value, and bare words are searched for like search_symbols does. Results come in file order unless bare words rank them."
+    )]
+    pub async fn query_symbols(
+        &self,
+        Parameters(request): Parameters<QuerySymbolsRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        self.record_query("query_symbols", &request).await;
+        let QuerySymbolsRequest {
+            query,
+            limit,
+            page_size,
+            cursor,
+            project,
+        } = request;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        let framework_kinds = indexer.settings().framework_kinds();
+        let parsed = match SymbolQuery::parse_with_kinds(&query, &framework_kinds) {
+            Ok(parsed) => parsed,
+            Err(e) => {
+                return Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                    "Invalid query: {e}\nFilters: {}",
+                    QueryField::KEYS
+                ))]));
+            }
+        };
+        let page = match Page::new(cursor.as_deref(), page_size, limit as usize) {
+            Ok(page) => page,
+            Err(e) => return Ok(CallToolResult::error(vec![ContentBlock::text(e)])),
+        };
+
+        match indexer.query_symbols(&parsed, page.fetch_limit()) {
+            Ok(results) => {
+                let (results, next_cursor) = page.take(results);
+                if results.is_empty() {
+                    return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                        "No symbols match: {query}"
+                    ))]));
+                }
+
+                let mut result =
+                    format!("Found {} symbol(s) matching '{query}':\n\n", results.len());
+                for (i, search_result) in results.iter().enumerate() {
+                    result.push_str(&format!(
+                        "{}. {} ({:?}) [symbol_id:{}]\n",
+                        page.offset + i + 1,
+                        search_result.name,
+                        search_result.kind,
+                        search_result.symbol_id.value()
+                    ));
+                    result.push_str(&format!(
+                        "   File: {}:{}\n",
+                        search_result.file_path, search_result.line
+                    ));
+                    if let Some(ref doc) = search_result.doc_comment {
+                        let first_line = doc.lines().next().unwrap_or("");
+                        result.push_str(&format!("   Doc: {first_line}\n"));
+                    }
+                    if let Some(ref sig) = search_result.signature {
+                        result.push_str(&format!("   Signature: {sig}\n"));
+                    }
+                    result.push('\n');
+                }
+                if let Some(cursor) = &next_cursor {
+                    result.push_str(&next_page_line(cursor));
+                }
+
+                Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+            }
+            Err(e) => Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                "Query failed: {e}"
+            ))])),
+        }
+    }
+
     #[tool(
         description = "Search indexed documents (markdown, text files) using natural language queries. Returns relevant chunks with context and highlighted keywords."
     )]
     pub async fn search_documents(
         &self,
-        Parameters(SearchDocumentsRequest {
+        Parameters(request): Parameters<SearchDocumentsRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        self.record_query("search_documents", &request).await;
+        let SearchDocumentsRequest {
             query,
             collection,
             limit,
-        }): Parameters<SearchDocumentsRequest>,
-    ) -> Result<CallToolResult, McpError> {
-        let store = match &self.document_store {
+            project,
+        } = request;
+        let project = self.project(project.as_deref())?;
+        let store = match &project.document_store {
             Some(s) => s,
             None => {
                 return Ok(CallToolResult::error(vec![ContentBlock::text(
@@ -841,7 +1264,7 @@ impl CodeIntelligenceServer {
         };
 
         let mut store = store.write().await;
-        let indexer = self.facade.read().await;
+        let indexer = project.facade.read().await;
 
         // Auto-sync: check for file changes in all collections before searching
         let settings = indexer.settings();
@@ -899,4 +1322,92 @@ impl CodeIntelligenceServer {
             ))])),
         }
     }
+
+    #[tool(
+        description = "Run a query saved with `codanna query save` by its name, so a recurring search or audit does not need to be spelled out again. An unknown name lists the saved queries."
+    )]
+    pub async fn run_saved_query(
+        &self,
+        Parameters(RunSavedQueryRequest { name, project }): Parameters<RunSavedQueryRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let path = queries_path(
+            self.project(project.as_deref())?
+                .facade
+                .read()
+                .await
+                .settings(),
+        );
+        let store = QueryStore::load(&path);
+        let Some(saved) = store.saved.get(&name) else {
+            let message = if store.saved.is_empty() {
+                format!(
+                    "No saved query named '{name}'. No queries are saved yet; save one with: codanna query save <name> <query>"
+                )
+            } else {
+                let names: Vec<&str> = store.saved.keys().map(String::as_str).collect();
+                format!(
+                    "No saved query named '{name}'. Saved queries: {}",
+                    names.join(", ")
+                )
+            };
+            return Ok(CallToolResult::error(vec![ContentBlock::text(message)]));
+        };
+        // A query saved in a project runs on that project
+        let mut call = saved.call.clone();
+        if let Some(project) = project {
+            call.arguments
+                .entry("project")
+                .or_insert(serde_json::Value::String(project));
+        }
+        self.run_query_call(&call).await
+    }
+}
+
+impl CodeIntelligenceServer {
+    /// Run a tool call read back from `queries.json`
+    pub(crate) async fn run_query_call(
+        &self,
+        call: &QueryCall,
+    ) -> Result<CallToolResult, McpError> {
+        fn request<T: serde::de::DeserializeOwned>(call: &QueryCall) -> Result<T, CallToolResult> {
+            serde_json::from_value(serde_json::Value::Object(call.arguments.clone())).map_err(|e| {
+                CallToolResult::error(vec![ContentBlock::text(format!(
+                    "Saved query `{call}` no longer matches the {} tool: {e}",
+                    call.tool
+                ))])
+            })
+        }
+        macro_rules! run {
+            ($method:ident) => {
+                match request(call) {
+                    Ok(request) => self.$method(Parameters(request)).await,
+                    Err(error) => Ok(error),
+                }
+            };
+        }
+
+        match call.tool.as_str() {
+            "find_symbol" => run!(find_symbol),
+            "get_calls" => run!(get_calls),
+            "find_callers" => run!(find_callers),
+            "get_call_hierarchy" => run!(get_call_hierarchy),
+            "analyze_impact" => run!(analyze_impact),
+            "get_type_hierarchy" => run!(get_type_hierarchy),
+            "get_file_outline" => run!(get_file_outline),
+            "impact_of_change" => run!(impact_of_change),
+            "find_tests" => run!(find_tests),
+            "find_similar_symbols" => run!(find_similar_symbols),
+            "find_unused_symbols" => run!(find_unused_symbols),
+            "find_todos" => run!(find_todos),
+            "get_index_info" => run!(get_index_info),
+            "search_symbols" => run!(search_symbols),
+            "query_symbols" => run!(query_symbols),
+            "semantic_search_docs" => run!(semantic_search_docs),
+            "semantic_search_with_context" => run!(semantic_search_with_context),
+            "search_documents" => run!(search_documents),
+            other => Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                "Saved query runs unknown tool '{other}'"
+            ))])),
+        }
+    }
 }
@@ -1,16 +1,22 @@
-//! Symbol-target tools: find_symbol, get_calls, find_callers, analyze_impact.
+//! Symbol-target tools: find_symbol, get_calls, find_callers,
+//! get_call_hierarchy, analyze_impact, get_type_hierarchy, get_file_outline,
+//! impact_of_change.
 
 use rmcp::model::ErrorData as McpError;
 use rmcp::model::*;
 use rmcp::{handler::server::wrapper::Parameters, tool, tool_router};
 
 use crate::Symbol;
+use crate::indexing::CallGraphEntry;
+use crate::mcp::pagination::{Page, next_page_line};
 use crate::mcp::requests::{
-    AnalyzeImpactRequest, FindCallersRequest, FindSymbolRequest, GetCallsRequest,
+    AnalyzeImpactRequest, FindCallersRequest, FindSymbolRequest, GetCallHierarchyRequest,
+    GetCallsRequest, GetFileOutlineRequest, GetTypeHierarchyRequest, ImpactOfChangeRequest,
 };
 use crate::mcp::server::{CodeIntelligenceServer, generate_mcp_guidance};
 use crate::mcp::service::{
     self, SymbolResolution, parse_receiver_context, qualified_call, render_ambiguity,
+    render_call_hierarchy, render_change_impact, render_file_outline, render_hierarchy,
 };
 
 #[tool_router(router = symbols_router, vis = "pub(crate)")]
@@ -18,11 +24,16 @@ impl CodeIntelligenceServer {
     #[tool(description = "Find a symbol by name in the indexed codebase")]
     pub async fn find_symbol(
         &self,
-        Parameters(FindSymbolRequest { name, lang }): Parameters<FindSymbolRequest>,
+        Parameters(FindSymbolRequest {
+            name,
+            lang,
+            project,
+        }): Parameters<FindSymbolRequest>,
     ) -> Result<CallToolResult, McpError> {
         use crate::symbol::context::ContextIncludes;
 
-        let indexer = self.facade.read().await;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
 
         // symbol_id:XXX (from semantic search results and ambiguity hints)
         // resolves by direct id lookup; policy shared with the CLI JSON path.
@@ -124,6 +135,25 @@ impl CodeIntelligenceServer {
                     }
                 }
 
+                // Header declaration and definition (C/C++), partial parts (C#)
+                for (label, arrow, symbols) in [
+                    ("Declared at", "->", &ctx.relationships.declarations),
+                    ("Defined at", "<-", &ctx.relationships.definitions),
+                    ("Other parts", "+", &ctx.relationships.parts),
+                ] {
+                    if let Some(symbols) = symbols.as_ref().filter(|s| !s.is_empty()) {
+                        result.push_str(&format!("{label}:\n"));
+                        for sym in symbols.iter().take(5) {
+                            result.push_str(&format!(
+                                "  {arrow} {} at {}\n",
+                                sym.name,
+                                crate::symbol::context::SymbolContext::symbol_location(sym)
+                            ));
+                        }
+                        has_relationships = true;
+                    }
+                }
+
                 if let Some(defines) = &ctx.relationships.defines {
                     if !defines.is_empty() {
                         let methods = defines
@@ -247,16 +277,25 @@ impl CodeIntelligenceServer {
     }
 
     #[tool(
-        description = "Get functions that a given function CALLS (invokes with parentheses).\n\nShows: function_name() → what it calls\nDoes NOT show: Type usage, component rendering, or who calls this function.\n\nUse analyze_impact for: Type dependencies, component usage (JSX), or reverse lookups."
+        description = "Get functions that a given function CALLS (invokes with parentheses).\n\nShows: function_name() → what it calls (set depth > 1 to follow calls transitively)\nDoes NOT show: Type usage, component rendering, or who calls this function.\n\nUse analyze_impact for: Type dependencies, component usage (JSX), or reverse lookups."
     )]
     pub async fn get_calls(
         &self,
         Parameters(GetCallsRequest {
             function_name,
             symbol_id,
+            depth,
+            page_size,
+            cursor,
+            project,
         }): Parameters<GetCallsRequest>,
     ) -> Result<CallToolResult, McpError> {
-        let indexer = self.facade.read().await;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+        let page = match Page::new(cursor.as_deref(), page_size, usize::MAX) {
+            Ok(page) => page,
+            Err(e) => return Ok(CallToolResult::error(vec![ContentBlock::text(e)])),
+        };
 
         // Resolution policy is shared with the CLI JSON path via the
         // service layer; text renderings stay byte-identical.
@@ -287,6 +326,32 @@ impl CodeIntelligenceServer {
                 }
             };
 
+        // Transitive mode. A function reaching nothing transitively makes no
+        // direct calls either, so the empty case falls through to the
+        // direct listing's message.
+        if depth > 1 {
+            let reached = indexer.get_transitive_calls(symbol.id, depth as usize);
+            if !reached.is_empty() {
+                let result_count = reached.len();
+                let (shown, next_cursor) = page.slice(&reached);
+                let mut result = format!(
+                    "{identifier} calls {result_count} function(s) within {depth} call(s):\n"
+                );
+                result.push_str(&render_call_graph(&symbol, &reached, shown, "->"));
+                if let Some(cursor) = &next_cursor {
+                    result.push_str(&next_page_line(cursor));
+                }
+                if let Some(guidance) =
+                    generate_mcp_guidance(indexer.settings(), "get_calls", result_count)
+                {
+                    result.push_str("\n---\nGuidance: ");
+                    result.push_str(&guidance);
+                    result.push('\n');
+                }
+                return Ok(CallToolResult::success(vec![ContentBlock::text(result)]));
+            }
+        }
+
         // Get calls for this specific symbol
         let all_called_with_metadata = indexer.get_called_functions_with_metadata(symbol.id);
 
@@ -302,6 +367,7 @@ impl CodeIntelligenceServer {
         }
 
         let result_count = all_called_with_metadata.len();
+        let (all_called_with_metadata, next_cursor) = page.take(all_called_with_metadata);
         let mut result = format!("{identifier} calls {result_count} function(s):\n");
         for (callee, metadata) in all_called_with_metadata {
             // Parse metadata to extract receiver info and call site location
@@ -330,11 +396,17 @@ impl CodeIntelligenceServer {
                     call_line + 1
                 ));
             }
+            if metadata.as_ref().is_some_and(|m| m.is_possible_target()) {
+                result.push_str(" [possible target]");
+            }
             result.push('\n');
             if let Some(ref sig) = callee.signature {
                 result.push_str(&format!("     Signature: {sig}\n"));
             }
         }
+        if let Some(cursor) = &next_cursor {
+            result.push_str(&next_page_line(cursor));
+        }
 
         // Add system guidance
         if let Some(guidance) = generate_mcp_guidance(indexer.settings(), "get_calls", result_count)
@@ -348,16 +420,25 @@ impl CodeIntelligenceServer {
     }
 
     #[tool(
-        description = "Find functions that CALL a given function (invoke it with parentheses).\n\nShows: what calls → function_name()\nDoes NOT show: Type references, component rendering, or what this function calls.\n\nUse analyze_impact for: Complete dependency graph including type usage and composition."
+        description = "Find functions that CALL a given function (invoke it with parentheses).\n\nShows: what calls → function_name() (set depth > 1 to follow callers transitively)\nDoes NOT show: Type references, component rendering, or what this function calls.\n\nUse analyze_impact for: Complete dependency graph including type usage and composition."
     )]
     pub async fn find_callers(
         &self,
         Parameters(FindCallersRequest {
             function_name,
             symbol_id,
+            depth,
+            page_size,
+            cursor,
+            project,
         }): Parameters<FindCallersRequest>,
     ) -> Result<CallToolResult, McpError> {
-        let indexer = self.facade.read().await;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+        let page = match Page::new(cursor.as_deref(), page_size, usize::MAX) {
+            Ok(page) => page,
+            Err(e) => return Ok(CallToolResult::error(vec![ContentBlock::text(e)])),
+        };
 
         // Shared resolution policy; see service.rs.
         let (symbol, identifier) =
@@ -387,6 +468,30 @@ impl CodeIntelligenceServer {
                 }
             };
 
+        // Transitive mode; the empty case falls through as in get_calls
+        if depth > 1 {
+            let reached = indexer.get_transitive_callers(symbol.id, depth as usize);
+            if !reached.is_empty() {
+                let result_count = reached.len();
+                let (shown, next_cursor) = page.slice(&reached);
+                let mut result = format!(
+                    "{result_count} function(s) call {identifier} within {depth} call(s):\n"
+                );
+                result.push_str(&render_call_graph(&symbol, &reached, shown, "<-"));
+                if let Some(cursor) = &next_cursor {
+                    result.push_str(&next_page_line(cursor));
+                }
+                if let Some(guidance) =
+                    generate_mcp_guidance(indexer.settings(), "find_callers", result_count)
+                {
+                    result.push_str("\n---\nGuidance: ");
+                    result.push_str(&guidance);
+                    result.push('\n');
+                }
+                return Ok(CallToolResult::success(vec![ContentBlock::text(result)]));
+            }
+        }
+
         // Get callers for THIS SPECIFIC symbol only (no aggregation)
         let all_callers_with_metadata = indexer.get_calling_functions_with_metadata(symbol.id);
 
@@ -403,6 +508,7 @@ impl CodeIntelligenceServer {
 
         // Build structured text response with rich metadata
         let result_count = all_callers_with_metadata.len();
+        let (all_callers_with_metadata, next_cursor) = page.take(all_callers_with_metadata);
         let mut result = format!("{result_count} function(s) call {identifier}:\n");
 
         for (caller, metadata) in all_callers_with_metadata {
@@ -430,8 +536,13 @@ impl CodeIntelligenceServer {
                 (String::new(), caller.range.start_line + 1)
             };
 
+            let possible = if metadata.as_ref().is_some_and(|m| m.is_possible_target()) {
+                " [possible target]"
+            } else {
+                ""
+            };
             result.push_str(&format!(
-                "  <- {:?} {} at {}:{}{}\n",
+                "  <- {:?} {} at {}:{}{}{possible}\n",
                 caller.kind, caller.name, caller.file_path, call_line, call_info
             ));
 
@@ -439,6 +550,9 @@ impl CodeIntelligenceServer {
                 result.push_str(&format!("     Signature: {sig}\n"));
             }
         }
+        if let Some(cursor) = &next_cursor {
+            result.push_str(&next_page_line(cursor));
+        }
 
         // Add system guidance
         if let Some(guidance) =
@@ -452,6 +566,61 @@ impl CodeIntelligenceServer {
         Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
     }
 
+    #[tool(
+        description = "Get the call hierarchy of a function in one call: the functions calling it and the functions it calls, transitively up to depth edges each way, as nested JSON trees.\n\nShows: incoming (callers, each with its own callers) and outgoing (callees, each with its own callees)\nDoes NOT show: Type usage or composition.\n\nUse analyze_impact for: Everything that depends on a symbol."
+    )]
+    pub async fn get_call_hierarchy(
+        &self,
+        Parameters(GetCallHierarchyRequest {
+            function_name,
+            symbol_id,
+            depth,
+            project,
+        }): Parameters<GetCallHierarchyRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        // Shared resolution policy; see service.rs.
+        let symbol = match service::resolve_symbol_or_id(&indexer, symbol_id, function_name) {
+            SymbolResolution::Resolved { symbol, .. } => symbol,
+            SymbolResolution::NotFoundById(id) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Symbol not found: symbol_id:{id}"
+                ))]));
+            }
+            SymbolResolution::NotFoundByName(name) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Function not found: {name}"
+                ))]));
+            }
+            SymbolResolution::Ambiguous { name, candidates } => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(
+                    render_ambiguity("get_call_hierarchy", &name, &candidates),
+                )]));
+            }
+            SymbolResolution::MissingParam => {
+                return Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                    "{}\n{}",
+                    service::missing_param_message("get_call_hierarchy"),
+                    service::accepted_params_line("get_call_hierarchy"),
+                ))]));
+            }
+        };
+
+        let Some(hierarchy) = indexer.get_call_hierarchy(symbol.id, depth as usize) else {
+            return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                "Symbol not found: symbol_id:{}",
+                symbol.id.value()
+            ))]));
+        };
+
+        // Plain JSON, without guidance, so clients can parse it whole
+        let result =
+            serde_json::to_string_pretty(&render_call_hierarchy(&hierarchy)).unwrap_or_default();
+        Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+    }
+
     #[tool(
         description = "Analyze complete impact of changing a symbol. Shows ALL relationships: function calls, type usage, composition.\n\nShows:\n- What CALLS this function\n- What USES this as a type (fields, parameters, returns)\n- What RENDERS/COMPOSES this (JSX: <Component>, Rust: struct fields, etc.)\n- Full dependency graph across files\n\nUse this when: You need to see everything that depends on a symbol."
     )]
@@ -461,11 +630,19 @@ impl CodeIntelligenceServer {
             symbol_name,
             symbol_id,
             max_depth,
+            page_size,
+            cursor,
+            project,
         }): Parameters<AnalyzeImpactRequest>,
     ) -> Result<CallToolResult, McpError> {
         use crate::symbol::context::ContextIncludes;
 
-        let indexer = self.facade.read().await;
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+        let page = match Page::new(cursor.as_deref(), page_size, usize::MAX) {
+            Ok(page) => page,
+            Err(e) => return Ok(CallToolResult::error(vec![ContentBlock::text(e)])),
+        };
 
         // Shared resolution policy; see service.rs.
         let (symbol, identifier) =
@@ -583,9 +760,10 @@ impl CodeIntelligenceServer {
             "Total impact: {impact_count} symbol(s) would be affected (max depth: {max_depth})\n"
         ));
 
-        // Group by symbol kind
-        let mut by_kind: std::collections::HashMap<crate::SymbolKind, Vec<Symbol>> =
-            std::collections::HashMap::new();
+        // Group by symbol kind, in the order the page first has each
+        let (impacted, next_cursor) = page.take(impacted);
+        let mut by_kind: indexmap::IndexMap<crate::SymbolKind, Vec<Symbol>> =
+            indexmap::IndexMap::new();
 
         for id in impacted {
             if let Some(sym) = indexer.get_symbol(id) {
@@ -605,6 +783,9 @@ impl CodeIntelligenceServer {
                 ));
             }
         }
+        if let Some(cursor) = &next_cursor {
+            result.push_str(&next_page_line(cursor));
+        }
 
         // Add system guidance
         if let Some(guidance) =
@@ -617,4 +798,212 @@ impl CodeIntelligenceServer {
 
         Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
     }
+
+    #[tool(
+        description = "Get the type hierarchy of a struct, trait, class or interface: every supertype it extends or implements and every subtype extending or implementing it, transitively, as trees.\n\nShows: -> supertypes, <- subtypes\nDoes NOT show: Type usage or calls.\n\nUse analyze_impact for: Everything that depends on a type."
+    )]
+    pub async fn get_type_hierarchy(
+        &self,
+        Parameters(GetTypeHierarchyRequest {
+            type_name,
+            symbol_id,
+            project,
+        }): Parameters<GetTypeHierarchyRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        // Shared resolution policy; see service.rs.
+        let symbol = match service::resolve_symbol_or_id(&indexer, symbol_id, type_name) {
+            SymbolResolution::Resolved { symbol, .. } => symbol,
+            SymbolResolution::NotFoundById(id) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Symbol not found: symbol_id:{id}"
+                ))]));
+            }
+            SymbolResolution::NotFoundByName(name) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Type not found: {name}"
+                ))]));
+            }
+            SymbolResolution::Ambiguous { name, candidates } => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(
+                    render_ambiguity("get_type_hierarchy", &name, &candidates),
+                )]));
+            }
+            SymbolResolution::MissingParam => {
+                return Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                    "{}\n{}",
+                    service::missing_param_message("get_type_hierarchy"),
+                    service::accepted_params_line("get_type_hierarchy"),
+                ))]));
+            }
+        };
+
+        let Some(hierarchy) = indexer.get_type_hierarchy(symbol.id) else {
+            return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                "Symbol not found: symbol_id:{}",
+                symbol.id.value()
+            ))]));
+        };
+
+        let mut result = render_hierarchy(&hierarchy);
+        if let Some(guidance) =
+            generate_mcp_guidance(indexer.settings(), "get_type_hierarchy", hierarchy.len())
+        {
+            result.push_str("\n---\nGuidance: ");
+            result.push_str(&guidance);
+            result.push('\n');
+        }
+
+        Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+    }
+
+    #[tool(
+        description = "Get the outline of a file before editing it: every symbol in it with its kind, line range, signature and doc summary, in the order they appear, members indented under their type.\n\nTakes the path as indexed or its trailing part (e.g., src/lib.rs).\n\nUse find_symbol for: Where a symbol is defined."
+    )]
+    pub async fn get_file_outline(
+        &self,
+        Parameters(GetFileOutlineRequest { path, project }): Parameters<GetFileOutlineRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        let mut files = indexer.find_indexed_files(&path);
+        let (file_id, file_path) = match files.len() {
+            0 => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "File not indexed: {path}"
+                ))]));
+            }
+            1 => files.remove(0),
+            count => {
+                let mut message =
+                    format!("'{path}' matches {count} indexed files; pass one of them:\n");
+                for (_, file_path) in &files {
+                    message.push_str(&format!("  {file_path}\n"));
+                }
+                return Ok(CallToolResult::success(vec![ContentBlock::text(message)]));
+            }
+        };
+
+        let symbols = indexer.get_file_outline(file_id);
+        let mut result = render_file_outline(&file_path, &symbols);
+        if let Some(guidance) =
+            generate_mcp_guidance(indexer.settings(), "get_file_outline", symbols.len())
+        {
+            result.push_str("\n---\nGuidance: ");
+            result.push_str(&guidance);
+            result.push('\n');
+        }
+
+        Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+    }
+
+    #[tool(
+        description = "Before changing a symbol, list everything the change can break: the callers, implementors, subtypes and users that depend on it, transitively, grouped by distance with the files each distance adds.\n\nShows: Distance 1 (direct dependents), distance 2 (their dependents), ...\nDoes NOT show: What the symbol itself calls.\n\nUse find_callers for: Call sites only."
+    )]
+    pub async fn impact_of_change(
+        &self,
+        Parameters(ImpactOfChangeRequest {
+            symbol_name,
+            symbol_id,
+            max_depth,
+            project,
+        }): Parameters<ImpactOfChangeRequest>,
+    ) -> Result<CallToolResult, McpError> {
+        let project = self.project(project.as_deref())?;
+        let indexer = project.facade.read().await;
+
+        // Shared resolution policy; see service.rs.
+        let symbol = match service::resolve_symbol_or_id(&indexer, symbol_id, symbol_name) {
+            SymbolResolution::Resolved { symbol, .. } => symbol,
+            SymbolResolution::NotFoundById(id) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Symbol not found: symbol_id:{id}"
+                ))]));
+            }
+            SymbolResolution::NotFoundByName(name) => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                    "Symbol not found: {name}"
+                ))]));
+            }
+            SymbolResolution::Ambiguous { name, candidates } => {
+                return Ok(CallToolResult::success(vec![ContentBlock::text(
+                    render_ambiguity("impact_of_change", &name, &candidates),
+                )]));
+            }
+            SymbolResolution::MissingParam => {
+                return Ok(CallToolResult::error(vec![ContentBlock::text(format!(
+                    "{}\n{}",
+                    service::missing_param_message("impact_of_change"),
+                    service::accepted_params_line("impact_of_change"),
+                ))]));
+            }
+        };
+
+        let Some(impact) = indexer.get_change_impact(symbol.id, max_depth as usize) else {
+            return Ok(CallToolResult::success(vec![ContentBlock::text(format!(
+                "Symbol not found: symbol_id:{}",
+                symbol.id.value()
+            ))]));
+        };
+
+        let mut result = render_change_impact(&impact);
+        if let Some(guidance) =
+            generate_mcp_guidance(indexer.settings(), "impact_of_change", impact.len())
+        {
+            result.push_str("\n---\nGuidance: ");
+            result.push_str(&guidance);
+            result.push('\n');
+        }
+
+        Ok(CallToolResult::success(vec![ContentBlock::text(result)]))
+    }
+}
+
+/// Rows of a transitive call graph listing for the `shown` entries of
+/// `reached`, nearest first. Rows past the first level name the function
+/// they were reached through.
+fn render_call_graph(
+    root: &Symbol,
+    reached: &[CallGraphEntry],
+    shown: &[CallGraphEntry],
+    arrow: &str,
+) -> String {
+    let mut names: std::collections::HashMap<crate::SymbolId, &str> = reached
+        .iter()
+        .map(|entry| (entry.symbol.id, entry.symbol.name.as_ref()))
+        .collect();
+    names.insert(root.id, root.name.as_ref());
+
+    let mut rows = String::new();
+    for entry in shown {
+        let symbol = &entry.symbol;
+        rows.push_str(&format!(
+            "  {arrow} [depth {}] {:?} {} at {}:{}",
+            entry.depth,
+            symbol.kind,
+            symbol.name,
+            symbol.file_path,
+            symbol.range.start_line + 1
+        ));
+        if entry.depth > 1 {
+            if let Some(via) = names.get(&entry.via) {
+                rows.push_str(&format!(" (via {via})"));
+            }
+        }
+        if entry
+            .metadata
+            .as_ref()
+            .is_some_and(|m| m.is_possible_target())
+        {
+            rows.push_str(" [possible target]");
+        }
+        rows.push('\n');
+        if let Some(ref sig) = symbol.signature {
+            rows.push_str(&format!("     Signature: {sig}\n"));
+        }
+    }
+    rows
 }
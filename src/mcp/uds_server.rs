@@ -0,0 +1,213 @@
+//! Unix domain socket transport for MCP
+//!
+//! `codanna serve --uds <path>` listens on a socket file instead of stdio
+//! or a TCP port. Each connection is an MCP session of its own, framed as
+//! newline-delimited JSON-RPC like stdio, so several local agents can share
+//! the one server of a project.
+//!
+//! Access is the socket file's permissions: `server.socket_mode`, by
+//! default `0600`, the owner alone. The socket is bound in a private
+//! directory and moved into place once its mode is set, so no connection
+//! gets in under looser permissions. A socket left by a server that died
+//! is replaced; one a live server answers on is not.
+//!
+//! With an idle timeout the server stops once no session has been open
+//! for that long, as the background server of `server.daemon` does.
+
+use std::os::unix::fs::{DirBuilderExt, PermissionsExt};
+use std::path::{Path, PathBuf};
+use std::sync::atomic::{AtomicUsize, Ordering};
+use std::sync::{Arc, Mutex};
+use std::time::{Duration, Instant};
+
+use rmcp::ServiceExt;
+use tokio::net::UnixListener;
+use tokio_util::sync::CancellationToken;
+
+use crate::mcp::CodeIntelligenceServer;
+
+/// The mode `mode` names in octal, as `server.socket_mode` holds it
+pub fn parse_socket_mode(mode: &str) -> Result<u32, String> {
+    let digits = mode.trim().trim_start_matches("0o");
+    match u32::from_str_radix(digits, 8) {
+        Ok(bits) if bits <= 0o777 => Ok(bits),
+        _ => Err(format!(
+            "Invalid server.socket_mode '{mode}': expected octal permissions such as 0600"
+        )),
+    }
+}
+
+/// A listening socket, removed from the filesystem when dropped
+pub struct UdsListener {
+    listener: UnixListener,
+    path: PathBuf,
+}
+
+impl UdsListener {
+    /// Listen on `path` with permissions `mode`
+    pub fn bind(path: &Path, mode: u32) -> std::io::Result<Self> {
+        if path.exists() {
+            if std::os::unix::net::UnixStream::connect(path).is_ok() {
+                return Err(std::io::Error::new(
+                    std::io::ErrorKind::AddrInUse,
+                    format!("a codanna server already listens on {}", path.display()),
+                ));
+            }
+            // Left by a server that died
+            std::fs::remove_file(path)?;
+        }
+
+        let parent = path
+            .parent()
+            .filter(|parent| !parent.as_os_str().is_empty())
+            .unwrap_or(Path::new("."));
+        let private = parent.join(format!(".codanna-sock-{}", std::process::id()));
+        std::fs::DirBuilder::new().mode(0o700).create(&private)?;
+        let staged = private.join("sock");
+        let bound = UnixListener::bind(&staged).and_then(|listener| {
+            std::fs::set_permissions(&staged, std::fs::Permissions::from_mode(mode))?;
+            std::fs::rename(&staged, path)?;
+            Ok(listener)
+        });
+        let _ = std::fs::remove_file(&staged);
+        let _ = std::fs::remove_dir(&private);
+
+        Ok(Self {
+            listener: bound?,
+            path: path.to_path_buf(),
+        })
+    }
+
+    /// Serve a server from `make_server` on each connection until `ct` is
+    /// cancelled, or until no session has been open for `idle_timeout`
+    pub async fn serve(
+        &self,
+        make_server: impl Fn() -> CodeIntelligenceServer,
+        ct: CancellationToken,
+        idle_timeout: Option<Duration>,
+    ) {
+        let open = Arc::new(AtomicUsize::new(0));
+        let last_closed = Arc::new(Mutex::new(Instant::now()));
+        let mut idle_check = tokio::time::interval(Duration::from_secs(1));
+        loop {
+            tokio::select! {
+                accepted = self.listener.accept() => match accepted {
+                    Ok((stream, _)) => {
+                        let server = make_server();
+                        let session_ct = ct.child_token();
+                        let session = Session::open(&open, &last_closed);
+                        tokio::spawn(async move {
+                            let _session = session;
+                            crate::debug_event!("uds", "connected");
+                            let service = match server.serve(tokio::io::split(stream)).await {
+                                Ok(service) => service,
+                                Err(e) => {
+                                    tracing::warn!("[uds] session failed to initialize: {e}");
+                                    return;
+                                }
+                            };
+                            tokio::select! {
+                                _ = service.waiting() => {}
+                                _ = session_ct.cancelled() => {}
+                            }
+                            crate::debug_event!("uds", "disconnected");
+                        });
+                    }
+                    Err(e) => tracing::warn!("[uds] failed to accept connection: {e}"),
+                },
+                _ = idle_check.tick(), if idle_timeout.is_some() => {
+                    let idle = open.load(Ordering::SeqCst) == 0
+                        && idle_timeout.is_some_and(|timeout| {
+                            last_closed.lock().unwrap().elapsed() >= timeout
+                        });
+                    if idle {
+                        crate::debug_event!("uds", "idle");
+                        break;
+                    }
+                }
+                _ = ct.cancelled() => break,
+            }
+        }
+    }
+}
+
+/// An open session, counted until it is dropped
+struct Session {
+    open: Arc<AtomicUsize>,
+    last_closed: Arc<Mutex<Instant>>,
+}
+
+impl Session {
+    fn open(open: &Arc<AtomicUsize>, last_closed: &Arc<Mutex<Instant>>) -> Self {
+        open.fetch_add(1, Ordering::SeqCst);
+        Self {
+            open: open.clone(),
+            last_closed: last_closed.clone(),
+        }
+    }
+}
+
+impl Drop for Session {
+    fn drop(&mut self) {
+        *self.last_closed.lock().unwrap() = Instant::now();
+        self.open.fetch_sub(1, Ordering::SeqCst);
+    }
+}
+
+impl Drop for UdsListener {
+    fn drop(&mut self) {
+        let _ = std::fs::remove_file(&self.path);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_socket_mode() {
+        assert_eq!(parse_socket_mode("0600"), Ok(0o600));
+        assert_eq!(parse_socket_mode("660"), Ok(0o660));
+        assert_eq!(parse_socket_mode("0o640"), Ok(0o640));
+        assert!(parse_socket_mode("0800").is_err());
+        assert!(parse_socket_mode("1777").is_err());
+    }
+
+    #[tokio::test]
+    async fn test_bind_sets_mode_and_refuses_a_live_socket() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join("codanna.sock");
+
+        let listener = UdsListener::bind(&path, 0o600).unwrap();
+        let mode = std::fs::metadata(&path).unwrap().permissions().mode();
+        assert_eq!(mode & 0o777, 0o600);
+
+        let error = UdsListener::bind(&path, 0o600).err().unwrap();
+        assert_eq!(error.kind(), std::io::ErrorKind::AddrInUse);
+
+        drop(listener);
+        assert!(!path.exists());
+    }
+
+    #[tokio::test]
+    async fn test_serve_stops_when_idle() {
+        let dir = tempfile::tempdir().unwrap();
+        let settings = crate::config::Settings {
+            index_path: dir.path().join("index"),
+            ..Default::default()
+        };
+        let facade =
+            crate::indexing::facade::IndexFacade::new(std::sync::Arc::new(settings)).unwrap();
+        let server = CodeIntelligenceServer::new(facade);
+        let listener = UdsListener::bind(&dir.path().join("codanna.sock"), 0o600).unwrap();
+
+        let served = listener.serve(
+            || server.for_new_connection(),
+            CancellationToken::new(),
+            Some(Duration::from_millis(10)),
+        );
+        tokio::time::timeout(Duration::from_secs(5), served)
+            .await
+            .expect("an idle server stops");
+    }
+}
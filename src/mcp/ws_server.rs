@@ -0,0 +1,366 @@
+//! WebSocket transport for MCP
+//!
+//! `codanna serve --ws` adds a `/ws` endpoint to the HTTP or HTTPS server
+//! for clients that only speak WebSocket. Each text frame carries one
+//! JSON-RPC message; rmcp sees the newline-delimited stream it serves over
+//! stdio, through an in-memory pipe.
+//!
+//! The server pings every [`PING_INTERVAL`] and drops a connection that
+//! stays silent for two of them. A dropped connection does not end its MCP
+//! session: the session id comes back in the `Mcp-Session-Id` header of the
+//! upgrade response, and connecting to `/ws?session=<id>` within
+//! [`RESUME_GRACE`] picks the session up where it left off, with the
+//! messages sent meanwhile. A close frame with the normal code ends the
+//! session at once.
+
+use std::collections::HashMap;
+use std::sync::{Arc, Mutex};
+use std::time::{Duration, Instant};
+
+use axum::Router;
+use axum::extract::ws::{CloseFrame, Message, WebSocket, WebSocketUpgrade, close_code};
+use axum::extract::{Query, State};
+use axum::http::{HeaderMap, HeaderValue, StatusCode};
+use axum::response::{IntoResponse, Response};
+use rmcp::ServiceExt;
+use serde::Deserialize;
+use tokio::io::{
+    AsyncBufReadExt, AsyncWriteExt, BufReader, DuplexStream, Lines, ReadHalf, WriteHalf,
+};
+use tokio_util::sync::CancellationToken;
+
+use crate::mcp::CodeIntelligenceServer;
+
+/// How often the server pings a connection
+pub const PING_INTERVAL: Duration = Duration::from_secs(15);
+
+/// How long a dropped connection's session waits to be resumed
+pub const RESUME_GRACE: Duration = Duration::from_secs(120);
+
+/// Bytes of server output held for a session while it has no connection;
+/// the server waits for a reconnect once they are full
+const PIPE_CAPACITY: usize = 1024 * 1024;
+
+/// Header naming the session of a connection
+const SESSION_HEADER: &str = "Mcp-Session-Id";
+
+/// Makes the MCP server of a new session
+pub type ServerFactory = Arc<dyn Fn() -> CodeIntelligenceServer + Send + Sync>;
+
+/// Client side of the pipe to the MCP server of one session
+struct Session {
+    id: String,
+    from_server: Lines<BufReader<ReadHalf<DuplexStream>>>,
+    to_server: WriteHalf<DuplexStream>,
+    /// A message that was read from the server but never reached the client
+    pending: Option<String>,
+}
+
+/// Sessions whose connection dropped, with the time it did
+type Detached = Arc<Mutex<HashMap<String, (Session, Instant)>>>;
+
+#[derive(Clone)]
+struct WsState {
+    factory: ServerFactory,
+    detached: Detached,
+    allowed_origins: Option<Arc<[String]>>,
+    ct: CancellationToken,
+}
+
+#[derive(Deserialize)]
+struct ConnectParams {
+    session: Option<String>,
+}
+
+/// Router serving MCP over WebSocket at `/ws`. Browsers may connect only
+/// from `allowed_origins` when set, and from loopback origins otherwise.
+pub fn router(
+    factory: ServerFactory,
+    allowed_origins: Option<Vec<String>>,
+    ct: CancellationToken,
+) -> Router {
+    let detached: Detached = Arc::default();
+
+    // Drop sessions nobody came back for
+    let reaper = detached.clone();
+    let reaper_ct = ct.clone();
+    tokio::spawn(async move {
+        let mut tick = tokio::time::interval(PING_INTERVAL);
+        loop {
+            tokio::select! {
+                _ = tick.tick() => {
+                    let mut detached = reaper.lock().unwrap();
+                    detached.retain(|id, (_, since)| {
+                        let keep = since.elapsed() < RESUME_GRACE;
+                        if !keep {
+                            crate::debug_event!("ws", "session expired", "{id}");
+                        }
+                        keep
+                    });
+                }
+                _ = reaper_ct.cancelled() => break,
+            }
+        }
+    });
+
+    let state = WsState {
+        factory,
+        detached,
+        allowed_origins: allowed_origins.map(Into::into),
+        ct,
+    };
+    Router::new()
+        .route("/ws", axum::routing::get(connect))
+        .with_state(state)
+}
+
+async fn connect(
+    ws: WebSocketUpgrade,
+    headers: HeaderMap,
+    Query(params): Query<ConnectParams>,
+    State(state): State<WsState>,
+) -> Response {
+    let origin = headers
+        .get(axum::http::header::ORIGIN)
+        .and_then(|value| value.to_str().ok());
+    if let Some(origin) = origin {
+        if !origin_allowed(origin, state.allowed_origins.as_deref()) {
+            tracing::warn!("[ws] rejected connection from origin {origin}");
+            return StatusCode::FORBIDDEN.into_response();
+        }
+    }
+
+    let session = match params.session {
+        Some(id) => match state.detached.lock().unwrap().remove(&id) {
+            Some((session, _)) => {
+                crate::debug_event!("ws", "session resumed", "{id}");
+                session
+            }
+            None => {
+                return (StatusCode::NOT_FOUND, "unknown or expired session").into_response();
+            }
+        },
+        None => start_session(&state),
+    };
+
+    let id = HeaderValue::from_str(&session.id).expect("hex session id");
+    let mut response = ws
+        .protocols(["mcp"])
+        .on_upgrade(move |socket| pump(socket, session, state));
+    response.headers_mut().insert(SESSION_HEADER, id);
+    response
+}
+
+/// Id of a new session
+pub(super) fn new_session_id() -> String {
+    use rand::RngExt;
+    hex::encode(rand::rng().random::<[u8; 16]>())
+}
+
+/// Run `server` on the far end of a new pipe, until the returned end is
+/// dropped or `ct` is cancelled
+pub(super) fn serve_on_pipe(
+    server: CodeIntelligenceServer,
+    session_id: &str,
+    ct: CancellationToken,
+) -> DuplexStream {
+    let (client, server_side) = tokio::io::duplex(PIPE_CAPACITY);
+    let session_id = session_id.to_string();
+    tokio::spawn(async move {
+        let service = match server.serve(tokio::io::split(server_side)).await {
+            Ok(service) => service,
+            Err(e) => {
+                tracing::warn!("[mcp] session {session_id} failed to initialize: {e}");
+                return;
+            }
+        };
+        tokio::select! {
+            _ = service.waiting() => {}
+            _ = ct.cancelled() => {}
+        }
+        crate::debug_event!("mcp", "session ended", "{session_id}");
+    });
+    client
+}
+
+/// Start an MCP server for a new session
+fn start_session(state: &WsState) -> Session {
+    let id = new_session_id();
+    let client = serve_on_pipe((state.factory)(), &id, state.ct.child_token());
+    crate::debug_event!("ws", "session started", "{id}");
+    let (read, write) = tokio::io::split(client);
+    Session {
+        id,
+        from_server: BufReader::new(read).lines(),
+        to_server: write,
+        pending: None,
+    }
+}
+
+/// How a connection ended
+enum Disconnect {
+    /// The connection dropped; the session can be resumed
+    Dropped,
+    /// The client or the server ended the session
+    Ended,
+}
+
+/// Carry messages between `socket` and the session until either side goes
+async fn pump(mut socket: WebSocket, mut session: Session, state: WsState) {
+    let disconnect = exchange(&mut socket, &mut session).await;
+    match disconnect {
+        Disconnect::Dropped => {
+            crate::debug_event!("ws", "connection dropped", "{}", session.id);
+            let id = session.id.clone();
+            state
+                .detached
+                .lock()
+                .unwrap()
+                .insert(id, (session, Instant::now()));
+        }
+        Disconnect::Ended => {
+            let _ = socket
+                .send(Message::Close(Some(CloseFrame {
+                    code: close_code::NORMAL,
+                    reason: "session ended".into(),
+                })))
+                .await;
+        }
+    }
+}
+
+async fn exchange(socket: &mut WebSocket, session: &mut Session) -> Disconnect {
+    if let Some(message) = session.pending.take() {
+        if socket
+            .send(Message::Text(message.clone().into()))
+            .await
+            .is_err()
+        {
+            session.pending = Some(message);
+            return Disconnect::Dropped;
+        }
+    }
+
+    let mut ping = tokio::time::interval(PING_INTERVAL);
+    ping.tick().await;
+    let mut last_heard = Instant::now();
+
+    loop {
+        tokio::select! {
+            frame = socket.recv() => {
+                let text = match frame {
+                    Some(Ok(Message::Text(text))) => text.as_str().to_owned(),
+                    Some(Ok(Message::Binary(bytes))) => match String::from_utf8(bytes.to_vec()) {
+                        Ok(text) => text,
+                        Err(_) => {
+                            let _ = socket.send(parse_error()).await;
+                            continue;
+                        }
+                    },
+                    Some(Ok(Message::Ping(_) | Message::Pong(_))) => {
+                        last_heard = Instant::now();
+                        continue;
+                    }
+                    Some(Ok(Message::Close(frame))) => {
+                        return match frame {
+                            Some(frame) if frame.code == close_code::NORMAL => Disconnect::Ended,
+                            _ => Disconnect::Dropped,
+                        };
+                    }
+                    None | Some(Err(_)) => return Disconnect::Dropped,
+                };
+                last_heard = Instant::now();
+
+                // rmcp reads one message per line; a pretty-printed message
+                // would span several
+                let Some(line) = to_line(&text) else {
+                    let _ = socket.send(parse_error()).await;
+                    continue;
+                };
+                if session.to_server.write_all(line.as_bytes()).await.is_err() {
+                    return Disconnect::Ended;
+                }
+            }
+            line = session.from_server.next_line() => match line {
+                Ok(Some(message)) => {
+                    if socket.send(Message::Text(message.clone().into())).await.is_err() {
+                        session.pending = Some(message);
+                        return Disconnect::Dropped;
+                    }
+                }
+                Ok(None) | Err(_) => return Disconnect::Ended,
+            },
+            _ = ping.tick() => {
+                if last_heard.elapsed() > PING_INTERVAL * 2 {
+                    return Disconnect::Dropped;
+                }
+                if socket.send(Message::Ping(Default::default())).await.is_err() {
+                    return Disconnect::Dropped;
+                }
+            }
+        }
+    }
+}
+
+/// `text` as one line of JSON, None when it is not JSON
+pub(super) fn to_line(text: &str) -> Option<String> {
+    let value: serde_json::Value = serde_json::from_str(text).ok()?;
+    Some(format!("{value}\n"))
+}
+
+/// JSON-RPC error for a frame that is not JSON
+fn parse_error() -> Message {
+    let error = serde_json::json!({
+        "jsonrpc": "2.0",
+        "id": null,
+        "error": {"code": -32700, "message": "Parse error"}
+    });
+    Message::Text(error.to_string().into())
+}
+
+/// Whether a browser page at `origin` may connect: one of `allowed` when
+/// given, a loopback host otherwise
+pub(super) fn origin_allowed(origin: &str, allowed: Option<&[String]>) -> bool {
+    if let Some(allowed) = allowed {
+        return allowed.iter().any(|a| a.eq_ignore_ascii_case(origin));
+    }
+    let Some((_, rest)) = origin.split_once("://") else {
+        return false;
+    };
+    let host = match rest.strip_prefix('[') {
+        Some(ipv6) => ipv6.split(']').next().unwrap_or_default(),
+        None => rest.split(':').next().unwrap_or_default(),
+    };
+    host.eq_ignore_ascii_case("localhost")
+        || host
+            .parse::<std::net::IpAddr>()
+            .is_ok_and(|ip| ip.is_loopback())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_origin_check() {
+        assert!(origin_allowed("http://localhost:3000", None));
+        assert!(origin_allowed("http://127.0.0.1", None));
+        assert!(origin_allowed("https://[::1]:8443", None));
+        assert!(!origin_allowed("https://evil.example.com", None));
+        assert!(!origin_allowed("http://localhost.evil.example.com", None));
+        assert!(!origin_allowed("null", None));
+
+        let allowed = vec!["https://app.example.com".to_string()];
+        assert!(origin_allowed("https://app.example.com", Some(&allowed)));
+        assert!(!origin_allowed("http://localhost:3000", Some(&allowed)));
+    }
+
+    #[test]
+    fn test_frames_become_single_lines() {
+        assert_eq!(
+            to_line("{\n  \"jsonrpc\": \"2.0\",\n  \"method\": \"ping\"\n}").as_deref(),
+            Some("{\"jsonrpc\":\"2.0\",\"method\":\"ping\"}\n")
+        );
+        assert_eq!(to_line("not json"), None);
+    }
+}
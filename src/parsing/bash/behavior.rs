@@ -0,0 +1,272 @@
+//! Bash-specific language behavior implementation
+//!
+//! Scripts have no module system: a script's module path is its path from
+//! the project root without the extension, `scripts/lib/common.sh` being
+//! `scripts/lib/common`. `source` names a file, usually relative to the
+//! sourcing script through `$(dirname "$0")`, `${BASH_SOURCE%/*}` or a
+//! variable holding the script directory. That leading expansion is read
+//! as the script's own directory.
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Bash language behavior implementation
+#[derive(Clone)]
+pub struct BashBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl BashBehavior {
+    /// Create a new Bash behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_bash::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for BashBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for BashBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+/// Length of a leading `$VAR`, `${...}` or `$(...)` expansion
+fn leading_expansion_len(path: &str) -> Option<usize> {
+    let rest = path.strip_prefix('$')?;
+    let (open, close) = match rest.chars().next()? {
+        '{' => ('{', '}'),
+        '(' => ('(', ')'),
+        _ => {
+            let name_len = rest
+                .find(|c: char| !(c.is_alphanumeric() || c == '_'))
+                .unwrap_or(rest.len());
+            return Some(1 + name_len);
+        }
+    };
+
+    let mut depth = 0;
+    for (i, c) in rest.char_indices() {
+        if c == open {
+            depth += 1;
+        } else if c == close {
+            depth -= 1;
+            if depth == 0 {
+                return Some(1 + i + 1);
+            }
+        }
+    }
+    None
+}
+
+impl LanguageBehavior for BashBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("bash")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// Functions and variables are visible to every script sourcing them
+    fn parse_visibility(&self, _signature: &str) -> Visibility {
+        Visibility::Public
+    }
+
+    /// The synthetic `<module>` symbol takes the script's name
+    fn configure_symbol(&self, symbol: &mut crate::Symbol, module_path: Option<&str>) {
+        if let Some(path) = module_path {
+            symbol.module_path = Some(path.to_string().into());
+            if symbol.kind == crate::types::SymbolKind::Module {
+                let short = path.rsplit('/').next().unwrap_or(path);
+                symbol.name = crate::types::compact_string(short);
+            }
+        }
+    }
+
+    fn normalize_caller_name(&self, name: &str, file_id: FileId) -> String {
+        if name == "<module>" {
+            self.get_module_path_for_file(file_id)
+                .map(|path| path.rsplit('/').next().unwrap_or(&path).to_string())
+                .unwrap_or_else(|| name.to_string())
+        } else {
+            name.to_string()
+        }
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "/"
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &[]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("/"))
+        }
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::BashResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    /// `$(dirname "$0")/lib/common.sh` sourced from `scripts/deploy` becomes
+    /// `scripts/lib/common`
+    ///
+    /// Absolute paths and `~` point outside the project and are dropped.
+    fn normalize_import_path(
+        &self,
+        import_path: &str,
+        importing_module: Option<&str>,
+        _file_path: &Path,
+    ) -> Option<String> {
+        let path = match leading_expansion_len(import_path) {
+            Some(len) => import_path[len..].trim_start_matches('/'),
+            None if import_path.starts_with('$') => return None,
+            None => import_path,
+        };
+        if path.starts_with('/') || path.starts_with('~') || path.contains('$') {
+            return None;
+        }
+        let path = [".sh", ".bash", ".zsh"]
+            .iter()
+            .find_map(|ext| path.strip_suffix(ext))
+            .unwrap_or(path);
+
+        let mut segments: Vec<&str> = importing_module
+            .map(|module| module.split('/').collect())
+            .unwrap_or_default();
+        // The sourcing script itself is the last segment
+        segments.pop();
+
+        for part in path.split('/') {
+            match part {
+                "" | "." => {}
+                ".." => {
+                    segments.pop();
+                }
+                other => segments.push(other),
+            }
+        }
+
+        (!segments.is_empty()).then(|| segments.join("/"))
+    }
+
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        import_path == symbol_module_path
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_module_path_from_file() {
+        let behavior = BashBehavior::new();
+        let root = Path::new("/project");
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/scripts/lib/common.sh"),
+                root,
+                &["sh", "bash", "zsh"]
+            ),
+            Some("scripts/lib/common".to_string())
+        );
+    }
+
+    #[test]
+    fn test_normalize_import_path() {
+        let behavior = BashBehavior::new();
+        let file = Path::new("scripts/deploy.sh");
+        let module = Some("scripts/deploy");
+
+        assert_eq!(
+            behavior.normalize_import_path("./lib/logging.sh", module, file),
+            Some("scripts/lib/logging".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("$(dirname $0)/lib/common.sh", module, file),
+            Some("scripts/lib/common".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("${BASH_SOURCE%/*}/../env.sh", module, file),
+            Some("env".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("$SCRIPT_DIR/lib/common.sh", module, file),
+            Some("scripts/lib/common".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("/etc/profile", module, file),
+            None
+        );
+        assert_eq!(
+            behavior.normalize_import_path("~/.bashrc", module, file),
+            None
+        );
+    }
+
+    #[test]
+    fn test_module_symbol_takes_script_name() {
+        let behavior = BashBehavior::new();
+        let mut symbol = crate::Symbol::new(
+            crate::SymbolId::new(1).unwrap(),
+            "<module>",
+            crate::SymbolKind::Module,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 10, 0),
+        );
+
+        behavior.configure_symbol(&mut symbol, Some("scripts/deploy"));
+        assert_eq!(symbol.name.as_ref(), "deploy");
+        assert_eq!(symbol.module_path.as_deref(), Some("scripts/deploy"));
+    }
+}
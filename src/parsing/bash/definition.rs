@@ -0,0 +1,89 @@
+//! Bash language definition for the registry
+//!
+//! Provides the Bash language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{BashBehavior, BashParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Bash language definition
+pub struct BashLanguage;
+
+impl BashLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("bash");
+}
+
+impl LanguageDefinition for BashLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Bash"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["sh", "bash", "zsh"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = BashParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(BashBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Bash is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Bash is enabled by default
+    }
+}
+
+/// Register Bash language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(BashLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_bash_definition() {
+        let bash = BashLanguage;
+
+        assert_eq!(bash.id(), LanguageId::new("bash"));
+        assert_eq!(bash.name(), "Bash");
+        assert!(bash.extensions().contains(&"sh"));
+    }
+
+    #[test]
+    fn test_bash_enabled_by_default() {
+        let bash = BashLanguage;
+        let settings = Settings::default();
+
+        assert!(bash.default_enabled());
+        assert!(bash.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_bash_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("bash")));
+    }
+}
@@ -0,0 +1,20 @@
+//! Bash language parser implementation
+//!
+//! Indexes shell functions and top-level variables of bash and zsh
+//! scripts. `source` directives link scripts to the files they load, and
+//! commands are recorded as calls so build and deploy scripts can be
+//! traced from entry point to the functions and tools they run.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+pub mod resolution;
+
+pub use behavior::BashBehavior;
+pub use definition::BashLanguage;
+pub use parser::BashParser;
+pub use resolution::BashResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
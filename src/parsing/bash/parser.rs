@@ -0,0 +1,612 @@
+//! Bash language parser implementation
+//!
+//! Extracts symbols and relationships from bash and zsh scripts using
+//! tree-sitter-bash.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | script file | Module (`<module>`, renamed to the script name) |
+//! | `name() { ... }` / `function name { ... }` | Function |
+//! | top-level `NAME=value` / `export NAME=value` | Variable |
+//! | `readonly NAME=value` / `declare -r NAME=value` | Constant |
+//!
+//! Functions are global once defined, so they carry a global scope, as do
+//! exported variables. Variables assigned inside functions are not indexed.
+//!
+//! ## Relationships
+//!
+//! - `source file.sh` and `. file.sh` are imports of the sourced script
+//! - Every command invocation is a call from the enclosing function, or from
+//!   the script itself at top level. Calls to functions of the script or of
+//!   sourced scripts resolve; external commands (`docker`, `kubectl`) are
+//!   kept as unresolved references.
+//!
+//! ## Documentation
+//!
+//! The `#` comment block directly above a function or variable is its doc
+//! comment; the block at the top of the file (after the shebang) documents
+//! the script.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, NodeTracker, NodeTrackingState, ParserContext,
+    ScopeType,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Caller name for commands run at the top level of a script
+const MODULE_SCOPE: &str = "<module>";
+
+/// Commands that run another command and are not interesting as callees
+const BASH_COMMAND_WRAPPERS: &[&str] = &["source", ".", "exec", "command", "builtin", "eval"];
+
+/// Bash-specific parsing errors
+#[derive(Error, Debug)]
+pub enum BashParseError {
+    #[error(
+        "Failed to initialize Bash parser: {reason}\nSuggestion: Ensure tree-sitter-bash is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// Bash language parser
+pub struct BashParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for BashParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("BashParser")
+            .field("language", &"Bash")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Name of the command a `command` node runs, when it is spelled literally
+fn command_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    let name = node.child_by_field_name("name")?;
+    let word = name.named_child(0).filter(|w| w.kind() == "word")?;
+    Some(&code[word.byte_range()])
+}
+
+/// `"$DIR/lib.sh"` / `'lib.sh'` -> `$DIR/lib.sh` / `lib.sh`
+fn unquote(text: &str) -> String {
+    text.chars().filter(|c| !matches!(c, '"' | '\'')).collect()
+}
+
+/// First line of a statement, used as its signature
+fn first_line<'a>(node: &Node, code: &'a str) -> &'a str {
+    code[node.byte_range()].lines().next().unwrap_or("").trim()
+}
+
+impl BashParser {
+    /// Create a new Bash parser instance
+    pub fn new() -> Result<Self, BashParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_bash::LANGUAGE.into())
+            .map_err(|e| BashParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn create_symbol(
+        &self,
+        counter: &mut SymbolCounter,
+        name: &str,
+        kind: SymbolKind,
+        file_id: FileId,
+        range: Range,
+        signature: String,
+        doc_comment: Option<String>,
+        scope: Option<ScopeContext>,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(counter.next_id(), name, kind, file_id, range)
+            .with_signature(signature)
+            .with_visibility(Visibility::Public);
+        if let Some(doc) = doc_comment {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(scope.unwrap_or_else(|| self.context.current_scope_context()));
+        symbol
+    }
+
+    /// Extract symbols from AST node recursively
+    fn extract_symbols_from_node(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let top_level = !self.context.is_in_function();
+        match node.kind() {
+            "function_definition" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_function(node, code, file_id, counter, symbols, depth);
+            }
+            "variable_assignment" if top_level => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_assignment(
+                    node,
+                    node,
+                    SymbolKind::Variable,
+                    None,
+                    code,
+                    file_id,
+                    counter,
+                    symbols,
+                );
+            }
+            "declaration_command" if top_level => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_declaration(node, code, file_id, counter, symbols);
+            }
+            // `FOO=1 make` sets FOO for one command only
+            "command" => {}
+            _ => {
+                self.extract_children(node, code, file_id, counter, symbols, depth);
+            }
+        }
+    }
+
+    fn extract_children(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            self.extract_symbols_from_node(child, code, file_id, counter, symbols, depth + 1);
+        }
+    }
+
+    fn process_function(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(name_node) = node.child_by_field_name("name") else {
+            return;
+        };
+        let name = &code[name_node.byte_range()];
+        let body = node.child_by_field_name("body");
+
+        // `deploy()` / `function deploy`
+        let header_end = body.map(|b| b.start_byte()).unwrap_or(node.end_byte());
+        let signature = code[node.start_byte()..header_end].trim().to_string();
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            SymbolKind::Function,
+            file_id,
+            range_from_node(&node),
+            signature,
+            self.extract_doc_comment(&node, code),
+            Some(ScopeContext::Global),
+        );
+        symbols.push(symbol);
+
+        // Nested function definitions become global when the outer one runs
+        if let Some(body) = body {
+            let saved_function = self.context.current_function().map(|s| s.to_string());
+            self.context.enter_scope(ScopeType::function());
+            self.context.set_current_function(Some(name.to_string()));
+
+            self.extract_children(body, code, file_id, counter, symbols, depth);
+
+            self.context.exit_scope();
+            self.context.set_current_function(saved_function);
+        }
+    }
+
+    /// `export NAME=value`, `readonly NAME=value`, `declare -r NAME=value`
+    fn process_declaration(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let keyword = node
+            .child(0)
+            .map(|child| &code[child.byte_range()])
+            .unwrap_or("");
+        if keyword == "local" {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        let children: Vec<Node> = node.named_children(&mut cursor).collect();
+        let has_flag = |flag: char| {
+            children.iter().any(|child| {
+                let text = &code[child.byte_range()];
+                child.kind() == "word" && text.starts_with('-') && text.contains(flag)
+            })
+        };
+
+        let kind = if keyword == "readonly" || has_flag('r') {
+            SymbolKind::Constant
+        } else {
+            SymbolKind::Variable
+        };
+        let scope = (keyword == "export" || has_flag('x')).then_some(ScopeContext::Global);
+
+        // `export PATH` without a value re-exports a variable assigned elsewhere
+        for child in children
+            .iter()
+            .filter(|child| child.kind() == "variable_assignment")
+        {
+            self.process_assignment(
+                *child,
+                node,
+                kind,
+                scope.clone(),
+                code,
+                file_id,
+                counter,
+                symbols,
+            );
+        }
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn process_assignment(
+        &mut self,
+        assignment: Node,
+        statement: Node,
+        kind: SymbolKind,
+        scope: Option<ScopeContext>,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(name_node) = assignment.child_by_field_name("name") else {
+            return;
+        };
+        let name = &code[name_node.byte_range()];
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&statement),
+            first_line(&statement, code).to_string(),
+            self.extract_doc_comment(&statement, code),
+            scope,
+        );
+        symbols.push(symbol);
+    }
+
+    /// Doc comment of the script: the comment block opening the file
+    fn script_doc_comment(root: &Node, code: &str) -> Option<String> {
+        let mut lines = Vec::new();
+        let mut last_row = None;
+        let mut cursor = root.walk();
+        for child in root.named_children(&mut cursor) {
+            if child.kind() != "comment" {
+                break;
+            }
+            // A blank line ends the block
+            if last_row.is_some_and(|row| child.start_position().row > row + 1) {
+                break;
+            }
+            last_row = Some(child.end_position().row);
+
+            let text = &code[child.byte_range()];
+            if text.starts_with("#!") {
+                continue;
+            }
+            lines.push(text.trim_start_matches('#').trim().to_string());
+        }
+
+        let doc = lines.join("\n").trim().to_string();
+        (!doc.is_empty()).then_some(doc)
+    }
+
+    /// Walk commands, tracking the enclosing function
+    ///
+    /// `visit` receives each literally named command with its caller.
+    fn walk_commands<'a>(
+        node: Node,
+        code: &'a str,
+        caller: &'a str,
+        visit: &mut impl FnMut(Node, &'a str, &'a str),
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut caller = caller;
+        match node.kind() {
+            "function_definition" => {
+                if let Some(name) = node.child_by_field_name("name") {
+                    caller = &code[name.byte_range()];
+                }
+            }
+            "command" => {
+                if let Some(name) = command_name(&node, code) {
+                    visit(node, name, caller);
+                }
+            }
+            _ => {}
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::walk_commands(child, code, caller, visit, depth + 1);
+        }
+    }
+}
+
+impl LanguageParser for BashParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+
+        let mut symbols = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            let root = tree.root_node();
+
+            // The script itself owns top-level commands. BashBehavior renames
+            // it to the script's name during indexing.
+            let mut module_symbol = Symbol::new(
+                symbol_counter.next_id(),
+                MODULE_SCOPE,
+                SymbolKind::Module,
+                file_id,
+                range_from_node(&root),
+            );
+            if let Some(doc) = Self::script_doc_comment(&root, code) {
+                module_symbol = module_symbol.with_doc(doc);
+            }
+            module_symbol.scope_context = Some(ScopeContext::Module);
+            symbols.push(module_symbol);
+
+            self.extract_symbols_from_node(root, code, file_id, symbol_counter, &mut symbols, 0);
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// `#` lines directly above the definition, without a blank line between
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let mut lines = Vec::new();
+        let mut next_row = node.start_position().row;
+        let mut current = node.prev_named_sibling();
+        while let Some(prev) = current {
+            if prev.kind() != "comment" || prev.end_position().row + 1 != next_row {
+                break;
+            }
+            let text = &code[prev.byte_range()];
+            if text.starts_with("#!") {
+                break;
+            }
+            lines.push(text.trim_start_matches('#').trim().to_string());
+            next_row = prev.start_position().row;
+            current = prev.prev_named_sibling();
+        }
+
+        if lines.is_empty() {
+            return None;
+        }
+        lines.reverse();
+        Some(lines.join("\n"))
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::walk_commands(
+                tree.root_node(),
+                code,
+                MODULE_SCOPE,
+                &mut |command, name, caller| {
+                    if !BASH_COMMAND_WRAPPERS.contains(&name) {
+                        calls.push((caller, name, range_from_node(&command)));
+                    }
+                },
+                0,
+            );
+        }
+        calls
+    }
+
+    fn find_implementations<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        // Shell has no types to implement
+        Vec::new()
+    }
+
+    fn find_uses<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_defines<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    /// `source path` and `. path`, with quotes removed
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let mut imports = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::walk_commands(
+                tree.root_node(),
+                code,
+                MODULE_SCOPE,
+                &mut |command, name, _| {
+                    if !matches!(name, "source" | ".") {
+                        return;
+                    }
+                    let Some(argument) = command.child_by_field_name("argument") else {
+                        return;
+                    };
+                    imports.push(Import {
+                        path: unquote(&code[argument.byte_range()]),
+                        alias: None,
+                        file_id,
+                        // Everything the sourced script defines becomes visible
+                        is_glob: true,
+                        is_type_only: false,
+                    });
+                },
+                0,
+            );
+        }
+        imports
+    }
+
+    fn language(&self) -> Language {
+        Language::Bash
+    }
+}
+
+impl NodeTracker for BashParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = BashParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(BashParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_function_forms() {
+        let symbols = parse("greet() { echo hi; }\nfunction farewell { echo bye; }\n");
+
+        let greet = find(&symbols, "greet");
+        assert_eq!(greet.kind, SymbolKind::Function);
+        assert_eq!(greet.signature.as_deref(), Some("greet()"));
+        assert_eq!(greet.scope_context, Some(ScopeContext::Global));
+
+        let farewell = find(&symbols, "farewell");
+        assert_eq!(farewell.signature.as_deref(), Some("function farewell"));
+    }
+
+    #[test]
+    fn test_script_module_symbol() {
+        let symbols = parse("#!/bin/sh\n# Cleans build output\n\nrm -rf build\n");
+
+        let module = find(&symbols, MODULE_SCOPE);
+        assert_eq!(module.kind, SymbolKind::Module);
+        assert_eq!(module.doc_comment.as_deref(), Some("Cleans build output"));
+    }
+
+    #[test]
+    fn test_declarations() {
+        let code = r#"
+readonly VERSION=1.2
+declare -r MAX=3
+export TARGET=prod
+COUNT=0
+run() {
+    local tmp=1
+    inner=2
+}
+"#;
+        let symbols = parse(code);
+
+        assert_eq!(find(&symbols, "VERSION").kind, SymbolKind::Constant);
+        assert_eq!(find(&symbols, "MAX").kind, SymbolKind::Constant);
+
+        let target = find(&symbols, "TARGET");
+        assert_eq!(target.kind, SymbolKind::Variable);
+        assert_eq!(target.scope_context, Some(ScopeContext::Global));
+
+        let count = find(&symbols, "COUNT");
+        assert_eq!(count.scope_context, Some(ScopeContext::Module));
+
+        // Assignments inside functions are not indexed
+        assert!(!symbols.iter().any(|s| s.name.as_ref() == "tmp"));
+        assert!(!symbols.iter().any(|s| s.name.as_ref() == "inner"));
+    }
+
+    #[test]
+    fn test_source_is_not_a_call() {
+        let mut parser = BashParser::new().unwrap();
+        let code = "source ./env.sh\nsetup\n";
+
+        let calls = parser.find_calls(code);
+        assert_eq!(calls.len(), 1);
+        assert_eq!(calls[0].0, MODULE_SCOPE);
+        assert_eq!(calls[0].1, "setup");
+    }
+}
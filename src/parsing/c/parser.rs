@@ -165,6 +165,20 @@ impl CParser {
         }
     }
 
+    /// Name of the function a declarator prototypes (`area(struct shape *s)`,
+    /// `*make(void)`); function pointers (`(*handler)(int)`) are variables
+    fn prototype_name_node(declarator: Node) -> Option<Node> {
+        match declarator.kind() {
+            "function_declarator" => declarator
+                .child_by_field_name("declarator")
+                .filter(|inner| inner.kind() == "identifier"),
+            "pointer_declarator" => declarator
+                .child_by_field_name("declarator")
+                .and_then(Self::prototype_name_node),
+            _ => None,
+        }
+    }
+
     /// Helper to find declarator name for variables and parameters
     fn find_declarator_name(node: Node) -> Option<Node> {
         match node.kind() {
@@ -381,8 +395,29 @@ impl CParser {
             }
             "declaration" => {
                 self.register_handled_node("declaration", node.kind_id());
+                // File-level prototypes, as headers declare the functions
+                // source files define
+                let file_level = matches!(
+                    self.context.current_scope_context(),
+                    crate::symbol::ScopeContext::Module
+                );
                 // Handle variable declarations
                 for child in node.children(&mut node.walk()) {
+                    if file_level {
+                        if let Some(name_node) = Self::prototype_name_node(child) {
+                            if let Some(symbol) = self.create_symbol(
+                                counter,
+                                node,
+                                name_node,
+                                SymbolKind::Function,
+                                file_id,
+                                code,
+                            ) {
+                                symbols.push(symbol);
+                            }
+                            continue;
+                        }
+                    }
                     if child.kind() == "init_declarator" {
                         if let Some(name_node) = Self::find_declarator_name(child) {
                             if let Some(symbol) = self.create_symbol(
@@ -184,7 +184,17 @@ impl LanguageBehavior for CppBehavior {
         symbol_module_path: &str,
         _importing_module: Option<&str>,
     ) -> bool {
-        // Simple exact match for C++
+        // An include imports what its header declares and what the source
+        // file of the same name defines
         import_path == symbol_module_path
+            || crate::parsing::headers::include_matches_module(
+                import_path,
+                symbol_module_path,
+                self.module_separator(),
+            )
+    }
+
+    fn header_extensions(&self) -> &'static [&'static str] {
+        crate::parsing::headers::CPP_HEADERS
     }
 }
@@ -4,6 +4,7 @@ use crate::parsing::context::ParserContext;
 use crate::parsing::method_call::MethodCall;
 use crate::parsing::parser::check_recursion_depth;
 use crate::parsing::{Import, Language, LanguageParser, NodeTracker, NodeTrackingState};
+use crate::symbol::ScopeContext;
 use crate::types::{Range, SymbolCounter};
 use crate::{FileId, Symbol, SymbolKind, Visibility};
 use std::any::Any;
@@ -130,35 +131,21 @@ impl CppParser {
             "function_definition" => {
                 self.register_handled_node(node.kind(), node.kind_id());
                 if let Some(declarator) = node.child_by_field_name("declarator") {
-                    // Check for qualified_identifier (ClassName::methodName)
-                    let mut is_method = self.context.current_class().is_some();
-                    let mut method_name = String::new();
-
-                    for i in 0..declarator.child_count() {
-                        if let Some(child) = declarator.child(i as u32) {
-                            if child.kind() == "qualified_identifier" {
-                                // This is a method implementation (Class::method)
-                                is_method = true;
-                                // Extract method name from qualified_identifier
-                                for j in 0..child.child_count() {
-                                    if let Some(id_node) = child.child(j as u32) {
-                                        if id_node.kind() == "identifier" {
-                                            method_name = code[id_node.byte_range()].to_string();
-                                            break;
-                                        }
-                                    }
-                                }
-                                break;
-                            }
-                        }
-                    }
-
-                    // Fallback: try to get declarator field
-                    if method_name.is_empty() {
-                        if let Some(name_node) = declarator.child_by_field_name("declarator") {
-                            method_name = code[name_node.byte_range()].to_string();
-                        }
-                    }
+                    // Qualified names (Class::method) are method implementations
+                    let declared = Self::function_declarator(declarator)
+                        .and_then(|function| Self::declared_name(function, code));
+                    let (class_name, method_name) = match declared {
+                        Some((class_name, name)) => (class_name, name.to_string()),
+                        // Fallback: try to get declarator field
+                        None => (
+                            None,
+                            declarator
+                                .child_by_field_name("declarator")
+                                .map(|name_node| code[name_node.byte_range()].to_string())
+                                .unwrap_or_default(),
+                        ),
+                    };
+                    let is_method = self.context.current_class().is_some() || class_name.is_some();
 
                     if !method_name.is_empty() {
                         let symbol_id = counter.next_id();
@@ -176,7 +163,7 @@ impl CppParser {
                             SymbolKind::Function
                         };
 
-                        let symbol = self.create_symbol(
+                        let mut symbol = self.create_symbol(
                             symbol_id,
                             method_name,
                             kind,
@@ -187,11 +174,51 @@ impl CppParser {
                             "", // module_path
                             Visibility::Public,
                         );
+                        // Out-of-line members are defined outside their class
+                        if let Some(class_name) = class_name {
+                            symbol.scope_context = Some(ScopeContext::ClassMember {
+                                class_name: Some(class_name.into()),
+                            });
+                        }
 
                         symbols.push(symbol);
                     }
                 }
             }
+            "declaration" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                // File-level prototypes (`int area(const Widget& w);`), as
+                // headers declare the functions source files define
+                let prototype = node
+                    .child_by_field_name("declarator")
+                    .filter(|_| Self::is_file_level(node))
+                    .and_then(Self::function_declarator)
+                    .and_then(|function| Self::declared_name(function, code));
+                if let Some((None, name)) = prototype {
+                    let symbol_id = counter.next_id();
+                    let doc_comment = self.extract_doc_comment(&node, code);
+                    let range = Range::new(
+                        node.start_position().row as u32,
+                        node.start_position().column as u16,
+                        node.end_position().row as u32,
+                        node.end_position().column as u16,
+                    );
+
+                    let symbol = self.create_symbol(
+                        symbol_id,
+                        name.to_string(),
+                        SymbolKind::Function,
+                        file_id,
+                        range,
+                        None, // signature
+                        doc_comment,
+                        "", // module_path
+                        Visibility::Public,
+                    );
+
+                    symbols.push(symbol);
+                }
+            }
             "class_specifier" => {
                 self.register_handled_node(node.kind(), node.kind_id());
                 if let Some(name_node) = node.child_by_field_name("name") {
@@ -370,6 +397,61 @@ impl CppParser {
         }
     }
 
+    /// The `function_declarator` of a declarator, through the pointers and
+    /// references of the return type (`Widget* make()`, `const Name& name()`).
+    fn function_declarator(declarator: Node) -> Option<Node> {
+        match declarator.kind() {
+            "function_declarator" => Some(declarator),
+            "pointer_declarator" | "reference_declarator" => {
+                let mut cursor = declarator.walk();
+                declarator
+                    .named_children(&mut cursor)
+                    .find_map(Self::function_declarator)
+            }
+            _ => None,
+        }
+    }
+
+    /// Name a `function_declarator` declares and, for an out-of-line
+    /// member (`Widget::resize`, `ui::Widget::resize`), its class. `None`
+    /// for function pointers and other declarators naming no function.
+    fn declared_name<'a>(function: Node, code: &'a str) -> Option<(Option<&'a str>, &'a str)> {
+        let mut name = function.child_by_field_name("declarator")?;
+        let mut class_name = None;
+        while name.kind() == "qualified_identifier" {
+            class_name = name.child_by_field_name("scope").map(|scope| {
+                // `Stack<T>::push` is a member of `Stack`
+                let scope = match scope.kind() {
+                    "template_type" => scope.child_by_field_name("name").unwrap_or(scope),
+                    _ => scope,
+                };
+                &code[scope.byte_range()]
+            });
+            name = name.child_by_field_name("name")?;
+        }
+        matches!(
+            name.kind(),
+            "identifier" | "field_identifier" | "operator_name" | "destructor_name"
+        )
+        .then(|| (class_name, &code[name.byte_range()]))
+    }
+
+    /// Whether a declaration is outside every function body, class body
+    /// and parameter list (namespaces and templates included)
+    fn is_file_level(node: Node) -> bool {
+        let mut parent = node.parent();
+        while let Some(current) = parent {
+            if matches!(
+                current.kind(),
+                "compound_statement" | "field_declaration_list" | "parameter_list"
+            ) {
+                return false;
+            }
+            parent = current.parent();
+        }
+        true
+    }
+
     /// Unqualified function name of a `function_definition` node, if any.
     ///
     /// `Class::method` impls yield the unqualified `method` to match the
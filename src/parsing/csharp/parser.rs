@@ -4,6 +4,8 @@
 //! - Symbol extraction (classes, interfaces, structs, enums, methods, properties, fields, events)
 //! - Method call detection with proper caller context tracking
 //! - Interface implementation tracking
+//! - Type usage (find_uses) and containment (find_defines) relationships
+//! - Extension methods attributed to their receiver type (find_inherent_methods)
 //! - Using directive (import) tracking
 //! - Visibility modifier handling (public, private, internal, protected)
 //! - Signature extraction for methods and types
@@ -20,8 +22,10 @@
 //!
 //! # Limitations
 //!
-//! - Type usage tracking (find_uses) is not yet implemented
-//! - Define relationships (containment) are not yet implemented
+//! - Type uses cover base lists, member signatures, fields and properties;
+//!   body-expression types are not tracked
+//! - `partial` parts of a type in one file share one symbol; parts in other
+//!   files keep their own, linked by name (`IndexFacade::get_partial_parts`)
 //! - External framework references (e.g., System.Console) require special handling
 
 use crate::parsing::Import;
@@ -36,6 +40,14 @@ use std::any::Any;
 use std::collections::HashSet;
 use tree_sitter::{Language, Node, Parser};
 
+/// Whether the signature of a type declares it `partial`
+pub fn is_partial_signature(signature: &str) -> bool {
+    signature
+        .split_whitespace()
+        .take_while(|word| !matches!(*word, "class" | "struct" | "interface" | "record"))
+        .any(|word| word == "partial")
+}
+
 /// C# language parser using tree-sitter
 ///
 /// This parser traverses C# Abstract Syntax Trees (AST) to extract symbols,
@@ -188,7 +200,9 @@ impl CSharpParser {
 
                 if let Some(symbol) = self.process_class(node, code, file_id, counter, module_path)
                 {
-                    symbols.push(symbol);
+                    if !Self::is_later_part(node, code, &symbol, symbols) {
+                        symbols.push(symbol);
+                    }
 
                     // Enter class scope for processing members
                     self.context.enter_scope(ScopeType::Class);
@@ -219,7 +233,9 @@ impl CSharpParser {
                 if let Some(symbol) =
                     self.process_interface(node, code, file_id, counter, module_path)
                 {
-                    symbols.push(symbol);
+                    if !Self::is_later_part(node, code, &symbol, symbols) {
+                        symbols.push(symbol);
+                    }
 
                     // Process interface members
                     self.context.enter_scope(ScopeType::Class);
@@ -243,7 +259,9 @@ impl CSharpParser {
 
                 if let Some(symbol) = self.process_struct(node, code, file_id, counter, module_path)
                 {
-                    symbols.push(symbol);
+                    if !Self::is_later_part(node, code, &symbol, symbols) {
+                        symbols.push(symbol);
+                    }
 
                     // Process struct members
                     self.context.enter_scope(ScopeType::Class);
@@ -280,7 +298,9 @@ impl CSharpParser {
 
                 if let Some(symbol) = self.process_record(node, code, file_id, counter, module_path)
                 {
-                    symbols.push(symbol);
+                    if !Self::is_later_part(node, code, &symbol, symbols) {
+                        symbols.push(symbol);
+                    }
 
                     // Process record members
                     self.context.enter_scope(ScopeType::Class);
@@ -405,6 +425,27 @@ impl CSharpParser {
         }
     }
 
+    /// Whether `node` is a `partial` part of a type an earlier part in the
+    /// file declared already. That part's symbol stands for both, so the
+    /// members of the later one join it.
+    fn is_later_part(node: Node, code: &str, symbol: &Symbol, symbols: &[Symbol]) -> bool {
+        let mut cursor = node.walk();
+        let partial = node
+            .children(&mut cursor)
+            .any(|child| child.kind() == "modifier" && &code[child.byte_range()] == "partial");
+        partial
+            && symbols.iter().any(|earlier| {
+                earlier.name == symbol.name
+                    && earlier.kind == symbol.kind
+                    && earlier.module_path == symbol.module_path
+                    && earlier.scope_context == symbol.scope_context
+                    && earlier
+                        .signature
+                        .as_deref()
+                        .is_some_and(is_partial_signature)
+            })
+    }
+
     /// Extract namespace name from namespace declaration
     fn extract_namespace_name(&self, node: Node, code: &str) -> Option<String> {
         if let Some(name_node) = node.child_by_field_name("name") {
@@ -953,6 +994,276 @@ impl CSharpParser {
         }
     }
 
+    /// Name of a type declaration (class, struct, record, interface, enum)
+    fn type_declaration_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+        if matches!(
+            node.kind(),
+            "class_declaration"
+                | "struct_declaration"
+                | "record_declaration"
+                | "interface_declaration"
+                | "enum_declaration"
+        ) {
+            node.child_by_field_name("name")
+                .map(|name| &code[name.byte_range()])
+        } else {
+            None
+        }
+    }
+
+    /// Reduce a type node to the user-defined type name it references
+    ///
+    /// Unwraps `T?` and `T[]`, strips generics and qualification. Returns
+    /// `None` for `predefined_type` (`int`, `string`, ...) and implicit `var`.
+    fn referenced_type_name<'a>(type_node: &Node, code: &'a str) -> Option<&'a str> {
+        match type_node.kind() {
+            "identifier" | "generic_name" | "qualified_name" => {
+                let name = Self::simple_type_name(type_node, code);
+                (name != "var" && !name.is_empty()).then_some(name)
+            }
+            "nullable_type" | "array_type" | "pointer_type" | "ref_type" => type_node
+                .child_by_field_name("type")
+                .and_then(|inner| Self::referenced_type_name(&inner, code)),
+            _ => None,
+        }
+    }
+
+    /// Static twin of `extract_simple_type_name` for the associated-fn walkers
+    fn simple_type_name<'a>(type_node: &Node, code: &'a str) -> &'a str {
+        match type_node.kind() {
+            "generic_name" => type_node
+                .named_child(0)
+                .map(|ident| &code[ident.byte_range()])
+                .unwrap_or(&code[type_node.byte_range()]),
+            "qualified_name" => type_node
+                .child_by_field_name("name")
+                .map(|name| Self::simple_type_name(&name, code))
+                .unwrap_or(&code[type_node.byte_range()]),
+            _ => &code[type_node.byte_range()],
+        }
+    }
+
+    /// Receiver type of an extension method, if `node` is one
+    ///
+    /// C# extension methods are static methods whose first parameter carries
+    /// the `this` modifier: `static int WordCount(this string s)`. The
+    /// receiver is the first parameter's type.
+    fn extension_receiver<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+        if node.kind() != "method_declaration" {
+            return None;
+        }
+        let params = node.child_by_field_name("parameters")?;
+        let mut cursor = params.walk();
+        let first = params
+            .named_children(&mut cursor)
+            .find(|child| child.kind() == "parameter")?;
+
+        let mut param_cursor = first.walk();
+        let is_extension = first
+            .children(&mut param_cursor)
+            .any(|child| child.kind() == "modifier" && &code[child.byte_range()] == "this");
+        if !is_extension {
+            return None;
+        }
+
+        let type_node = first.child_by_field_name("type")?;
+        Self::referenced_type_name(&type_node, code).or_else(|| Some(&code[type_node.byte_range()]))
+    }
+
+    /// Extract type usages (base-list, parameter, return, field, property and
+    /// event types)
+    ///
+    /// Member signatures attribute to the member (`DoWork` uses `Helper`);
+    /// base types, field and event types attribute to the enclosing type.
+    fn extract_uses_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        current_type: Option<&'a str>,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        let current_type = Self::type_declaration_name(&node, code).or(current_type);
+
+        match node.kind() {
+            "class_declaration"
+            | "struct_declaration"
+            | "record_declaration"
+            | "interface_declaration" => {
+                let mut cursor = node.walk();
+                let base_list = node
+                    .children(&mut cursor)
+                    .find(|child| child.kind() == "base_list");
+                if let (Some(context), Some(base_list)) = (current_type, base_list) {
+                    let mut base_cursor = base_list.walk();
+                    for base in base_list.named_children(&mut base_cursor) {
+                        // `record B(int X) : A(X)` names its base with arguments
+                        let base_type = if base.kind() == "primary_constructor_base_type" {
+                            base.child_by_field_name("type")
+                        } else {
+                            Some(base)
+                        };
+                        if let Some(base_type) = base_type {
+                            Self::push_type_use(context, &base_type, code, uses);
+                        }
+                    }
+                }
+            }
+            "method_declaration" | "constructor_declaration" | "local_function_statement" => {
+                let Some(member) = node
+                    .child_by_field_name("name")
+                    .map(|name| &code[name.byte_range()])
+                else {
+                    return;
+                };
+                let returns = node
+                    .child_by_field_name("returns")
+                    .or_else(|| node.child_by_field_name("type"));
+                if let Some(returns) = returns {
+                    Self::push_type_use(member, &returns, code, uses);
+                }
+                if let Some(params) = node.child_by_field_name("parameters") {
+                    let mut cursor = params.walk();
+                    for param in params.named_children(&mut cursor) {
+                        if let Some(param_type) = param.child_by_field_name("type") {
+                            Self::push_type_use(member, &param_type, code, uses);
+                        }
+                    }
+                }
+            }
+            "property_declaration" | "event_declaration" => {
+                if let (Some(context), Some(prop_type)) =
+                    (current_type, node.child_by_field_name("type"))
+                {
+                    Self::push_type_use(context, &prop_type, code, uses);
+                }
+            }
+            "field_declaration" | "event_field_declaration" => {
+                let mut cursor = node.walk();
+                let declaration = node
+                    .children(&mut cursor)
+                    .find(|child| child.kind() == "variable_declaration");
+                if let (Some(context), Some(field_type)) = (
+                    current_type,
+                    declaration.and_then(|decl| decl.child_by_field_name("type")),
+                ) {
+                    Self::push_type_use(context, &field_type, code, uses);
+                }
+            }
+            _ => {}
+        }
+
+        let mut cursor = node.walk();
+        for child in node.children(&mut cursor) {
+            Self::extract_uses_from_node(child, code, current_type, uses);
+        }
+    }
+
+    fn push_type_use<'a>(
+        context: &'a str,
+        type_node: &Node,
+        code: &'a str,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if let Some(used) = Self::referenced_type_name(type_node, code) {
+            let range = Range::new(
+                type_node.start_position().row as u32,
+                type_node.start_position().column as u16,
+                type_node.end_position().row as u32,
+                type_node.end_position().column as u16,
+            );
+            uses.push((context, used, range));
+        }
+    }
+
+    /// Extract containment: each type defines its methods, properties,
+    /// constructors, events and fields
+    ///
+    /// `partial` declarations each contribute their own members, so a type
+    /// split across files collects the union of its Defines edges.
+    fn extract_defines_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        defines: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if let (Some(type_name), Some(body)) = (
+            Self::type_declaration_name(&node, code),
+            node.child_by_field_name("body"),
+        ) {
+            let mut cursor = body.walk();
+            for member in body.children(&mut cursor) {
+                let range = Range::new(
+                    member.start_position().row as u32,
+                    member.start_position().column as u16,
+                    member.end_position().row as u32,
+                    member.end_position().column as u16,
+                );
+                match member.kind() {
+                    "method_declaration"
+                    | "constructor_declaration"
+                    | "property_declaration"
+                    | "event_declaration" => {
+                        if let Some(name) = member.child_by_field_name("name") {
+                            defines.push((type_name, &code[name.byte_range()], range));
+                        }
+                    }
+                    "field_declaration" | "event_field_declaration" => {
+                        let mut member_cursor = member.walk();
+                        for child in member.children(&mut member_cursor) {
+                            if child.kind() != "variable_declaration" {
+                                continue;
+                            }
+                            let mut decl_cursor = child.walk();
+                            for declarator in child.children(&mut decl_cursor) {
+                                if declarator.kind() != "variable_declarator" {
+                                    continue;
+                                }
+                                let name = declarator
+                                    .child_by_field_name("name")
+                                    .or_else(|| declarator.named_child(0));
+                                if let Some(name) = name {
+                                    defines.push((type_name, &code[name.byte_range()], range));
+                                }
+                            }
+                        }
+                    }
+                    _ => {}
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.children(&mut cursor) {
+            Self::extract_defines_from_node(child, code, defines);
+        }
+    }
+
+    /// Extract extension methods as (receiver_type, method_name, range)
+    fn extract_extension_methods_from_node(
+        node: Node,
+        code: &str,
+        methods: &mut Vec<(String, String, Range)>,
+    ) {
+        if let Some(receiver) = Self::extension_receiver(&node, code) {
+            if let Some(name) = node.child_by_field_name("name") {
+                let range = Range::new(
+                    node.start_position().row as u32,
+                    node.start_position().column as u16,
+                    node.end_position().row as u32,
+                    node.end_position().column as u16,
+                );
+                methods.push((
+                    receiver.to_string(),
+                    code[name.byte_range()].to_string(),
+                    range,
+                ));
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.children(&mut cursor) {
+            Self::extract_extension_methods_from_node(child, code, methods);
+        }
+    }
+
     /// Extract imports from a node tree
     fn extract_imports_from_node(
         node: Node,
@@ -1842,42 +2153,50 @@ impl LanguageParser for CSharpParser {
         implementations
     }
 
-    fn find_uses<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
-        // TODO: Implement proper type usage tracking for C#
-        //
-        // This should track where types are referenced/used, for example:
-        // - Method parameter types: `void DoWork(Helper helper)`
-        // - Return types: `Helper GetHelper()`
-        // - Field/property types: `private Helper _helper;`
-        // - Base classes/interfaces: `class Foo : IBar`
-        //
-        // Previously disabled because it was creating invalid relationships with "use" as context
-        // instead of proper semantic relationships.
-        //
-        // Implementation approach:
-        // 1. Traverse AST looking for type references
-        // 2. Extract (user_symbol, used_type, range) tuples
-        // 3. Filter out primitive types (int, string, etc.)
-        // 4. Ensure proper context (method names, not node kinds)
-        Vec::new()
-    }
-
-    fn find_defines<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
-        // TODO: Implement proper defines tracking for C#
-        //
-        // This should track definition relationships, for example:
-        // - Variable definitions: `var x = 5;` → (containing_method, "x", range)
-        // - Field definitions: `private int _count;` → (containing_class, "_count", range)
-        // - Property definitions: `public string Name { get; set; }` → (containing_class, "Name", range)
-        //
-        // Previously disabled because it was using node.kind() instead of actual definer names,
-        // creating relationships like "variable_declaration defines x" instead of "Method defines x".
-        //
-        // Implementation approach:
-        // 1. Track current scope context (class, method, etc.)
-        // 2. For each definition, extract (definer_name, defined_symbol, range)
-        // 3. Ensure definer_name is the actual symbol name, not AST node type
-        Vec::new()
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut uses = Vec::new();
+
+        match self.parser.parse(code, None) {
+            Some(tree) => {
+                Self::extract_uses_from_node(tree.root_node(), code, None, &mut uses);
+            }
+            None => {
+                eprintln!("Failed to parse C# file for type uses");
+            }
+        }
+
+        uses
+    }
+
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut defines = Vec::new();
+
+        match self.parser.parse(code, None) {
+            Some(tree) => {
+                Self::extract_defines_from_node(tree.root_node(), code, &mut defines);
+            }
+            None => {
+                eprintln!("Failed to parse C# file for defines");
+            }
+        }
+
+        defines
+    }
+
+    /// Extension methods attributed to their receiver type
+    ///
+    /// `static class StringExt { static int WordCount(this string s) }`
+    /// yields `("string", "WordCount", range)`, so `text.WordCount()` can be
+    /// resolved against the receiver's type rather than `StringExt`.
+    fn find_inherent_methods(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let tree = match self.parser.parse(code, None) {
+            Some(tree) => tree,
+            None => return Vec::new(),
+        };
+
+        let mut methods = Vec::new();
+        Self::extract_extension_methods_from_node(tree.root_node(), code, &mut methods);
+        methods
     }
 
     /// Extract variable type bindings from C# code
@@ -2134,4 +2453,138 @@ mod tests {
         );
         assert!(imports.iter().any(|i| i.path == "MyApp.Services"));
     }
+
+    #[test]
+    fn test_csharp_find_defines_type_members() {
+        let mut parser = CSharpParser::new().unwrap();
+        let code = r#"
+            namespace App.Services {
+                public partial class UserService {
+                    private readonly Repository _repo, _backup;
+                    public string Name { get; set; }
+                    public UserService(Repository repo) { _repo = repo; }
+                    public User Find(int id) { return _repo.Get(id); }
+                }
+            }
+        "#;
+
+        let defines = parser.find_defines(code);
+        for member in ["_repo", "_backup", "Name", "UserService", "Find"] {
+            assert!(
+                defines
+                    .iter()
+                    .any(|(definer, defined, _)| *definer == "UserService" && *defined == member),
+                "UserService should define {member}. Found: {defines:?}"
+            );
+        }
+    }
+
+    #[test]
+    fn test_csharp_find_uses_skips_predefined_types() {
+        let mut parser = CSharpParser::new().unwrap();
+        let code = r#"
+            public class OrderService {
+                private Repository<Order> _orders;
+                public Customer? Owner { get; set; }
+                public Invoice[] Bill(Order order, int count, string note) { return null; }
+            }
+        "#;
+
+        let uses = parser.find_uses(code);
+        let has = |ctx: &str, ty: &str| uses.iter().any(|(c, t, _)| *c == ctx && *t == ty);
+
+        assert!(has("OrderService", "Repository"), "Found: {uses:?}");
+        assert!(has("OrderService", "Customer"), "Found: {uses:?}");
+        assert!(has("Bill", "Invoice"), "Found: {uses:?}");
+        assert!(has("Bill", "Order"), "Found: {uses:?}");
+        assert!(
+            !uses.iter().any(|(_, t, _)| *t == "int" || *t == "string"),
+            "Predefined types must be filtered. Found: {uses:?}"
+        );
+    }
+
+    #[test]
+    fn test_csharp_partial_parts_in_a_file_share_one_symbol() {
+        let mut parser = CSharpParser::new().unwrap();
+        let code = r#"
+            namespace App {
+                public partial class Form1 {
+                    public void Load() { }
+                }
+                public partial class Form1 {
+                    private Button ok;
+                    public void InitializeComponent() { }
+                }
+                public class Other { }
+            }
+            namespace Tools {
+                public partial class Form1 { }
+            }
+        "#;
+        let mut counter = SymbolCounter::new();
+        let symbols = parser.parse(code, FileId::new(1).unwrap(), &mut counter);
+
+        let forms: Vec<_> = symbols
+            .iter()
+            .filter(|sym| sym.kind == SymbolKind::Class && &*sym.name == "Form1")
+            .collect();
+        assert_eq!(forms.len(), 2, "one per namespace: {forms:?}");
+        assert!(is_partial_signature(forms[0].signature.as_deref().unwrap()));
+        for member in ["Load", "ok", "InitializeComponent"] {
+            assert!(
+                symbols.iter().any(|sym| &*sym.name == member),
+                "{member} should be extracted"
+            );
+        }
+        assert!(!is_partial_signature("public class Partial"));
+    }
+
+    #[test]
+    fn test_csharp_find_uses_records_base_list_types() {
+        let mut parser = CSharpParser::new().unwrap();
+        let code = r#"
+            public class OrderService : ServiceBase<Order>, IDisposable, Events.IPublisher { }
+            public record Paid(decimal Amount) : OrderEvent(Amount);
+            public interface IRepository : IReadOnly { }
+        "#;
+
+        let uses = parser.find_uses(code);
+        let has = |ctx: &str, ty: &str| uses.iter().any(|(c, t, _)| *c == ctx && *t == ty);
+
+        assert!(has("OrderService", "ServiceBase"), "Found: {uses:?}");
+        assert!(has("OrderService", "IDisposable"), "Found: {uses:?}");
+        assert!(has("OrderService", "IPublisher"), "Found: {uses:?}");
+        assert!(has("Paid", "OrderEvent"), "Found: {uses:?}");
+        assert!(has("IRepository", "IReadOnly"), "Found: {uses:?}");
+    }
+
+    #[test]
+    fn test_csharp_extension_methods_attributed_to_receiver() {
+        let mut parser = CSharpParser::new().unwrap();
+        let code = r#"
+            public static class OrderExtensions {
+                public static decimal Total(this Order order) { return 0; }
+                public static int WordCount(this string text) { return 0; }
+                public static void Helper(Order order) { }
+            }
+        "#;
+
+        let methods = parser.find_inherent_methods(code);
+        assert!(
+            methods
+                .iter()
+                .any(|(recv, name, _)| recv == "Order" && name == "Total"),
+            "Found: {methods:?}"
+        );
+        assert!(
+            methods
+                .iter()
+                .any(|(recv, name, _)| recv == "string" && name == "WordCount"),
+            "Found: {methods:?}"
+        );
+        assert!(
+            !methods.iter().any(|(_, name, _)| name == "Helper"),
+            "Non-extension statics must not be attributed. Found: {methods:?}"
+        );
+    }
 }
@@ -0,0 +1,156 @@
+//! CSS-specific language behavior implementation
+//!
+//! A stylesheet's module path is its path from the project root without
+//! the extension (`src/styles/button.css` is `src/styles/button`).
+//! Stylesheets import nothing the index follows: their names are global
+//! to the page, so the usages of a class or custom property resolve among
+//! the symbols of every stylesheet (see [`super::usages`]).
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// CSS language behavior implementation
+#[derive(Clone)]
+pub struct CssBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl CssBehavior {
+    /// Create a new CSS behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_css::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for CssBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for CssBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for CssBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("css")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// Every selector applies document-wide
+    fn parse_visibility(&self, _signature: &str) -> Visibility {
+        Visibility::Public
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "/"
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &[]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("/"))
+        }
+    }
+
+    fn module_path_from_file(
+        &self,
+        file_path: &Path,
+        workspace_root: &Path,
+        extensions: &[&str],
+    ) -> Option<String> {
+        let relative_path = file_path.strip_prefix(workspace_root).ok()?;
+        let path = relative_path.to_str()?;
+        let path = extensions
+            .iter()
+            .chain(["css", "scss"].iter())
+            .find_map(|extension| path.strip_suffix(&format!(".{extension}")))
+            .unwrap_or(path);
+        let components: Vec<&str> = path
+            .split(std::path::MAIN_SEPARATOR)
+            .filter(|s| !s.is_empty())
+            .collect();
+        self.format_path_as_module(&components)
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(crate::parsing::GenericResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    fn import_matches_symbol(
+        &self,
+        _import_path: &str,
+        _symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        false
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_module_path_drops_extension() {
+        let behavior = CssBehavior::new();
+        let root = Path::new("/project");
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/src/styles/button.scss"),
+                root,
+                &["css", "scss"]
+            ),
+            Some("src/styles/button".to_string())
+        );
+        assert_eq!(
+            behavior.module_path_from_file(Path::new("/project/main.css"), root, &[]),
+            Some("main".to_string())
+        );
+    }
+}
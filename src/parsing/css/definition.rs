@@ -0,0 +1,90 @@
+//! CSS language definition for the registry
+//!
+//! Provides the CSS language implementation, covering `.css` stylesheets
+//! and `.scss` sources read with the same grammar, that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{CssBehavior, CssParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// CSS language definition
+pub struct CssLanguage;
+
+impl CssLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("css");
+}
+
+impl LanguageDefinition for CssLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "CSS"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["css", "scss"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = CssParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(CssBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // CSS is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // CSS is enabled by default
+    }
+}
+
+/// Register CSS language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(CssLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_css_definition() {
+        let css = CssLanguage;
+
+        assert_eq!(css.id(), LanguageId::new("css"));
+        assert_eq!(css.name(), "CSS");
+        assert!(css.extensions().contains(&"scss"));
+    }
+
+    #[test]
+    fn test_css_enabled_by_default() {
+        let css = CssLanguage;
+        let settings = Settings::default();
+
+        assert!(css.default_enabled());
+        assert!(css.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_css_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("css")));
+    }
+}
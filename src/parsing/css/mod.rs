@@ -0,0 +1,19 @@
+//! CSS language parser implementation
+//!
+//! Indexes the classes, ids and custom properties of CSS and SCSS
+//! stylesheets as symbols, and links them to the markup, components and
+//! stylesheets naming them (see [`usages`]), so style refactors can find
+//! every element a rule applies to.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+pub mod usages;
+
+pub use behavior::CssBehavior;
+pub use definition::CssLanguage;
+pub use parser::CssParser;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
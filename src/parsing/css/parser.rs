@@ -0,0 +1,405 @@
+//! CSS stylesheet parser implementation
+//!
+//! Indexes the names a stylesheet styles using tree-sitter-css, which also
+//! reads the nesting of SCSS (`.card { &.active { ... } }`).
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | Name | SymbolKind |
+//! |-----------|------|------------|
+//! | class selector `.btn` | `.btn` | Class |
+//! | id selector `#sidebar` | `#sidebar` | Constant |
+//! | custom property `--accent: #0af` | `--accent` | Variable |
+//!
+//! A stylesheet defines each name once, at the first rule selecting it or
+//! the first declaration setting it; later rules (`.btn:hover`, media
+//! queries, theme overrides) style the same symbol. A selector symbol spans
+//! its rule, and its signature is the rule's selector list. Classes and ids
+//! inside pseudo-class arguments (`:not(.disabled)`) are not selected by
+//! the rule and are left out, as are the variables and mixins of SCSS,
+//! which the grammar does not read.
+//!
+//! ## Relationships
+//!
+//! Usages of these names are found in the text of markup, components and
+//! stylesheets (see [`super::usages`]) and resolved among the symbols of
+//! all stylesheets by the indexing pipeline rather than returned by this
+//! parser: `class="btn"` in a template references `.btn`, and
+//! `var(--accent)` in a rule references `--accent`.
+//!
+//! ## Documentation
+//!
+//! A `/* ... */` comment directly above a rule or declaration is its doc
+//! comment.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, NodeTracker, NodeTrackingState,
+    truncate_for_display,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Longest selector list kept as a signature
+const MAX_SIGNATURE_LEN: usize = 200;
+
+/// CSS-specific parsing errors
+#[derive(Error, Debug)]
+pub enum CssParseError {
+    #[error(
+        "Failed to initialize CSS parser: {reason}\nSuggestion: Ensure tree-sitter-css is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// CSS language parser
+pub struct CssParser {
+    parser: Parser,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for CssParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("CssParser")
+            .field("language", &"CSS")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// `.card >\n  .title` -> `.card > .title`
+fn one_line(text: &str) -> String {
+    text.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+/// The child of `node` of kind `kind`
+fn child_of_kind<'t>(node: &Node<'t>, kind: &str) -> Option<Node<'t>> {
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find(|child| child.kind() == kind)
+}
+
+impl CssParser {
+    /// Create a new CSS parser instance
+    pub fn new() -> Result<Self, CssParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_css::LANGUAGE.into())
+            .map_err(|e| CssParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    /// Symbols of the rules and declarations under `node`, in document
+    /// order; `seen` holds the names already defined
+    #[allow(clippy::too_many_arguments)]
+    fn walk(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        seen: &mut HashSet<String>,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        match node.kind() {
+            "rule_set" => {
+                if let Some(selectors) = child_of_kind(&node, "selectors") {
+                    let mut names = Vec::new();
+                    self.selected_names(selectors, code, &mut names, depth + 1);
+                    let signature = truncate_for_display(
+                        &one_line(&code[selectors.byte_range()]),
+                        MAX_SIGNATURE_LEN,
+                    );
+                    for (name, kind) in names {
+                        if seen.insert(name.clone()) {
+                            let symbol = self.symbol(
+                                &node,
+                                code,
+                                &name,
+                                kind,
+                                signature.clone(),
+                                file_id,
+                                counter,
+                            );
+                            symbols.push(symbol);
+                        }
+                    }
+                }
+            }
+            "declaration" => {
+                if let Some(property) = child_of_kind(&node, "property_name") {
+                    let name = &code[property.byte_range()];
+                    if name.starts_with("--") && seen.insert(name.to_string()) {
+                        self.register_handled_node(node.kind(), node.kind_id());
+                        let declaration = code[node.byte_range()].trim_end_matches(';');
+                        let signature =
+                            truncate_for_display(&one_line(declaration), MAX_SIGNATURE_LEN);
+                        let symbol = self.symbol(
+                            &node,
+                            code,
+                            name,
+                            SymbolKind::Variable,
+                            signature,
+                            file_id,
+                            counter,
+                        );
+                        symbols.push(symbol);
+                    }
+                }
+                return;
+            }
+            _ => {}
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            self.walk(child, code, file_id, counter, seen, symbols, depth + 1);
+        }
+    }
+
+    /// Classes and ids a selector list selects, in the order written
+    fn selected_names(
+        &mut self,
+        node: Node,
+        code: &str,
+        names: &mut Vec<(String, SymbolKind)>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            // `:not(.disabled)` selects elements without the class
+            if !matches!(child.kind(), "arguments" | "pseudo_class_arguments") {
+                self.selected_names(child, code, names, depth + 1);
+            }
+        }
+
+        let (name_kind, sigil, kind) = match node.kind() {
+            "class_selector" => ("class_name", '.', SymbolKind::Class),
+            "id_selector" => ("id_name", '#', SymbolKind::Constant),
+            _ => return,
+        };
+        if let Some(name) = child_of_kind(&node, name_kind) {
+            self.register_handled_node(node.kind(), node.kind_id());
+            names.push((format!("{sigil}{}", &code[name.byte_range()]), kind));
+        }
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn symbol(
+        &self,
+        node: &Node,
+        code: &str,
+        name: &str,
+        kind: SymbolKind,
+        signature: String,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(
+            counter.next_id(),
+            name,
+            kind,
+            file_id,
+            range_from_node(node),
+        )
+        .with_signature(signature)
+        .with_visibility(Visibility::Public);
+        if let Some(doc) = self.extract_doc_comment(node, code) {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(ScopeContext::Module);
+        symbol
+    }
+}
+
+impl LanguageParser for CssParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        let Some(tree) = self.parser.parse(code, None) else {
+            return Vec::new();
+        };
+
+        let mut symbols = Vec::new();
+        let mut seen = HashSet::new();
+        self.walk(
+            tree.root_node(),
+            code,
+            file_id,
+            symbol_counter,
+            &mut seen,
+            &mut symbols,
+            0,
+        );
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// The `/* ... */` comment ending on the line above the node
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let comment = node
+            .prev_sibling()
+            .filter(|prev| prev.kind() == "comment")?;
+        if comment.end_position().row + 1 != node.start_position().row {
+            return None;
+        }
+        let text = code[comment.byte_range()]
+            .trim_start_matches("/*")
+            .trim_end_matches("*/");
+        let lines: Vec<&str> = text
+            .lines()
+            .map(|line| line.trim().trim_start_matches('*').trim())
+            .filter(|line| !line.is_empty())
+            .collect();
+        (!lines.is_empty()).then(|| lines.join("\n"))
+    }
+
+    fn find_calls<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_implementations<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    /// Custom property reads resolve across stylesheets; see
+    /// [`super::usages::find_usages`]
+    fn find_uses<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_defines<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_imports(&mut self, _code: &str, _file_id: FileId) -> Vec<Import> {
+        Vec::new()
+    }
+
+    fn language(&self) -> Language {
+        Language::Css
+    }
+}
+
+impl NodeTracker for CssParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = CssParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(CssParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_selectors_and_custom_properties() {
+        let code = r#":root {
+  --accent: #0af;
+}
+
+/* Primary call to action */
+.btn, #cta {
+  color: var(--accent);
+}
+
+.btn:hover:not(.disabled) {
+  --accent: #08c;
+}
+
+@media (max-width: 600px) {
+  nav.menu > .menu__item { display: block; }
+}
+"#;
+        let symbols = parse(code);
+        let names: Vec<&str> = symbols.iter().map(|s| s.name.as_ref()).collect();
+        assert_eq!(
+            names,
+            vec!["--accent", ".btn", "#cta", ".menu", ".menu__item"]
+        );
+
+        let accent = find(&symbols, "--accent");
+        assert_eq!(accent.kind, SymbolKind::Variable);
+        assert_eq!(accent.signature.as_deref(), Some("--accent: #0af"));
+        assert_eq!(accent.range.start_line, 1);
+
+        let btn = find(&symbols, ".btn");
+        assert_eq!(btn.kind, SymbolKind::Class);
+        assert_eq!(btn.signature.as_deref(), Some(".btn, #cta"));
+        assert_eq!(btn.doc_comment.as_deref(), Some("Primary call to action"));
+        assert_eq!((btn.range.start_line, btn.range.end_line), (5, 7));
+
+        assert_eq!(find(&symbols, "#cta").kind, SymbolKind::Constant);
+        assert_eq!(
+            find(&symbols, ".menu__item").signature.as_deref(),
+            Some("nav.menu > .menu__item")
+        );
+    }
+
+    #[test]
+    fn test_nested_rules() {
+        let code = ".card {\n  padding: 1rem;\n  &.active { color: red; }\n  .title { font-weight: bold; }\n}\n";
+        let symbols = parse(code);
+        let names: Vec<&str> = symbols.iter().map(|s| s.name.as_ref()).collect();
+        assert_eq!(names, vec![".card", ".active", ".title"]);
+        assert_eq!(find(&symbols, ".title").range.start_line, 3);
+    }
+}
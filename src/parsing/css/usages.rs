@@ -0,0 +1,241 @@
+//! Style names used outside the rules defining them
+//!
+//! Markup names the classes and ids of stylesheets in its attributes:
+//! `class="btn btn-primary"` in HTML, Vue and Svelte templates,
+//! `className="btn"` or `className={'btn'}` in JSX, `id="sidebar"` in any
+//! of them, and `class:active={on}` directives in Svelte. Custom
+//! properties are read with `var(--accent)`, in stylesheets and inline
+//! styles alike.
+//!
+//! Usages are found in the text, so markup written inside strings counts
+//! too. An attribute is only read when `=` follows its name directly, as
+//! formatters write markup and rarely code (`id = 'main'`), and bound
+//! attributes (`:class="..."`, `className={styles.btn}`) are expressions
+//! and are left out. Only names that are CSS identifiers are kept, which
+//! drops the interpolations of templates (`btn-{size}`).
+
+/// Attributes naming classes or an id, longest first
+const ATTRIBUTES: &[&str] = &["className", "class", "id"];
+
+/// A class, id or custom property named in a file
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct StyleUsage {
+    /// The name as its stylesheet symbol: `.btn`, `#sidebar`, `--accent`
+    pub name: String,
+    /// What names it: `class`, `className`, `id` or `var`
+    pub via: &'static str,
+    /// Position of the name as written
+    pub range: crate::Range,
+}
+
+/// The classes, ids and custom properties `code` names, in file order
+pub fn find_usages(code: &str) -> Vec<StyleUsage> {
+    let mut found: Vec<(&'static str, String, usize, usize)> = Vec::new();
+
+    for (at, _) in code.match_indices("var(") {
+        if code[..at].ends_with(is_name_char) {
+            continue;
+        }
+        let start = at + 4 + leading_whitespace(&code[at + 4..]);
+        if code[start..].starts_with("--") {
+            let end = start + 2 + name_len(&code[start + 2..]);
+            if end > start + 2 {
+                found.push(("var", code[start..end].to_string(), start, end));
+            }
+        }
+    }
+
+    for attribute in ATTRIBUTES {
+        for (at, _) in code.match_indices(attribute) {
+            // After the tag name or another attribute; `data-id`, `:class`
+            // and `myClass` are other names
+            if !code[..at].ends_with(|c: char| c.is_whitespace()) {
+                continue;
+            }
+            let after = at + attribute.len();
+            let rest = &code[after..];
+            if *attribute == "class" {
+                if let Some(directive) = rest.strip_prefix(':') {
+                    let len = name_len(directive);
+                    if len > 0 {
+                        let start = after + 1;
+                        let name = format!(".{}", &code[start..start + len]);
+                        found.push(("class", name, start, start + len));
+                    }
+                    continue;
+                }
+            }
+            let Some(value) = attribute_value(code, after) else {
+                continue;
+            };
+            let sigil = if *attribute == "id" { '#' } else { '.' };
+            for (offset, token) in tokens(&code[value.clone()]) {
+                if name_len(token) != token.len() || token.starts_with(|c: char| c.is_ascii_digit())
+                {
+                    continue;
+                }
+                let start = value.start + offset;
+                found.push((
+                    *attribute,
+                    format!("{sigil}{token}"),
+                    start,
+                    start + token.len(),
+                ));
+                if sigil == '#' {
+                    break; // An element has one id
+                }
+            }
+        }
+    }
+
+    found.sort_by_key(|(_, _, start, _)| *start);
+    let lines = LineStarts::new(code);
+    found
+        .into_iter()
+        .map(|(via, name, start, end)| {
+            let (start_line, start_column) = lines.position(start);
+            let (end_line, end_column) = lines.position(end);
+            StyleUsage {
+                name,
+                via,
+                range: crate::Range::new(start_line, start_column, end_line, end_column),
+            }
+        })
+        .collect()
+}
+
+/// Byte range of the literal value of the attribute whose name ends at
+/// `after`: `="..."`, `='...'`, or a JSX expression that is only a string
+/// (`={"..."}`, `` ={`...`} `` without substitutions)
+fn attribute_value(code: &str, after: usize) -> Option<std::ops::Range<usize>> {
+    let rest = code[after..].strip_prefix('=')?;
+    let (mut start, braced) = match rest.strip_prefix('{') {
+        Some(inner) => (after + 2 + leading_whitespace(inner), true),
+        None => (after + 1, false),
+    };
+    let quote = code[start..].chars().next()?;
+    if !matches!(quote, '"' | '\'') && !(braced && quote == '`') {
+        return None;
+    }
+    start += 1;
+    let end = start + code[start..].find(quote)?;
+    if braced {
+        let close = &code[end + 1..];
+        if !close[leading_whitespace(close)..].starts_with('}') {
+            return None;
+        }
+        if quote == '`' && code[start..end].contains("${") {
+            return None;
+        }
+    }
+    Some(start..end)
+}
+
+/// Whitespace-separated words of `value` with their byte offsets
+fn tokens(value: &str) -> impl Iterator<Item = (usize, &str)> {
+    value
+        .split(|c: char| c.is_ascii_whitespace())
+        .scan(0, |offset, token| {
+            let at = *offset;
+            *offset += token.len() + 1;
+            Some((at, token))
+        })
+        .filter(|(_, token)| !token.is_empty())
+}
+
+fn is_name_char(c: char) -> bool {
+    c.is_ascii_alphanumeric() || c == '-' || c == '_' || !c.is_ascii()
+}
+
+/// Length of the CSS identifier `text` starts with
+fn name_len(text: &str) -> usize {
+    text.find(|c: char| !is_name_char(c)).unwrap_or(text.len())
+}
+
+fn leading_whitespace(text: &str) -> usize {
+    text.len() - text.trim_start().len()
+}
+
+/// Row and column of byte offsets, by the offsets lines start at
+struct LineStarts(Vec<usize>);
+
+impl LineStarts {
+    fn new(code: &str) -> Self {
+        let starts = std::iter::once(0)
+            .chain(code.match_indices('\n').map(|(at, _)| at + 1))
+            .collect();
+        Self(starts)
+    }
+
+    fn position(&self, offset: usize) -> (u32, u16) {
+        let row = self.0.partition_point(|&start| start <= offset) - 1;
+        (row as u32, (offset - self.0[row]) as u16)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn names(code: &str) -> Vec<(String, &'static str)> {
+        find_usages(code)
+            .into_iter()
+            .map(|usage| (usage.name, usage.via))
+            .collect()
+    }
+
+    #[test]
+    fn test_markup_attributes() {
+        let code = r#"<nav id="sidebar" class="menu menu--open" data-id="x">
+  <a class='link' :class="{ active: isActive }">Home</a>
+</nav>"#;
+        assert_eq!(
+            names(code),
+            vec![
+                ("#sidebar".to_string(), "id"),
+                (".menu".to_string(), "class"),
+                (".menu--open".to_string(), "class"),
+                (".link".to_string(), "class"),
+            ]
+        );
+
+        let usages = find_usages(code);
+        let link = usages.iter().find(|usage| usage.name == ".link").unwrap();
+        assert_eq!(link.range, crate::Range::new(1, 12, 1, 16));
+    }
+
+    #[test]
+    fn test_jsx_and_svelte_attributes() {
+        let code = r#"export function Button({ primary }) {
+  const id = 'main';
+  return <button className={"btn"} id={`cta`}>
+    <span className={styles.label} class:active={primary}>Go</span>
+  </button>;
+}"#;
+        assert_eq!(
+            names(code),
+            vec![
+                (".btn".to_string(), "className"),
+                ("#cta".to_string(), "id"),
+                (".active".to_string(), "class"),
+            ]
+        );
+
+        // Interpolated names are not identifiers
+        assert!(names(r#"<div class="btn-{size} {extra}">"#).is_empty());
+        assert!(names("<div className={`btn ${size}`}>").is_empty());
+    }
+
+    #[test]
+    fn test_custom_properties() {
+        let code = ".btn {\n  color: var(--accent);\n  margin: var( --space-2, 4px);\n}\n";
+        assert_eq!(
+            names(code),
+            vec![
+                ("--accent".to_string(), "var"),
+                ("--space-2".to_string(), "var"),
+            ]
+        );
+        assert!(names("color: myvar(--x); width: var(calc)").is_empty());
+    }
+}
@@ -0,0 +1,253 @@
+//! Dart-specific language behavior implementation
+//!
+//! Module paths follow the pub package layout: a file's path below `lib`
+//! (or `bin`/`test`) becomes a dotted path, so `lib/src/widgets/counter.dart`
+//! becomes `src.widgets.counter`. Imports name files, either relative to the
+//! importing file or as `package:name/path.dart` below the package's `lib`,
+//! and are rewritten to the same dotted form at parse time. `dart:` SDK
+//! libraries are left untouched.
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Dart language behavior implementation
+#[derive(Clone)]
+pub struct DartBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl DartBehavior {
+    /// Create a new Dart behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_dart::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for DartBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for DartBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for DartBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("dart")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// Privacy is spelled in the name: `_count`, `_helper()`
+    fn parse_visibility(&self, signature: &str) -> Visibility {
+        let name = signature
+            .split('(')
+            .next()
+            .and_then(|head| head.split_whitespace().last())
+            .unwrap_or("");
+        if name.rsplit('.').next().unwrap_or(name).starts_with('_') {
+            Visibility::Private
+        } else {
+            Visibility::Public
+        }
+    }
+
+    fn configure_symbol(&self, symbol: &mut crate::Symbol, module_path: Option<&str>) {
+        // The parser derives visibility from the symbol name, which the
+        // signature alone cannot always recover; keep what it assigned.
+        if let Some(path) = module_path {
+            symbol.module_path = Some(path.to_string().into());
+        }
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "."
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &["lib", "bin", "test"]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("."))
+        }
+    }
+
+    fn supports_traits(&self) -> bool {
+        true // Mixins
+    }
+
+    fn supports_inherent_methods(&self) -> bool {
+        true // Extension members
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::DartResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    /// `package:app/src/counter.dart` becomes `src.counter`; `counter.dart`
+    /// imported from `src.widgets.home` becomes `src.widgets.counter`
+    fn normalize_import_path(
+        &self,
+        import_path: &str,
+        importing_module: Option<&str>,
+        _file_path: &Path,
+    ) -> Option<String> {
+        if import_path.starts_with("dart:") {
+            return None;
+        }
+
+        if let Some(package_path) = import_path.strip_prefix("package:") {
+            // The package name is the first segment; the rest lives below `lib`
+            let (_, path) = package_path.split_once('/')?;
+            let path = path.strip_suffix(".dart").unwrap_or(path);
+            return Some(path.replace('/', "."));
+        }
+
+        let path = import_path.strip_suffix(".dart")?;
+        let mut segments: Vec<&str> = importing_module
+            .map(|module| module.split('.').collect())
+            .unwrap_or_default();
+        // The importing file itself is the last segment
+        segments.pop();
+
+        for part in path.split('/') {
+            match part {
+                "" | "." => {}
+                ".." => {
+                    segments.pop();
+                }
+                other => segments.push(other),
+            }
+        }
+
+        Some(segments.join("."))
+    }
+
+    /// Dart imports name a single library file
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        import_path == symbol_module_path
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+
+    /// `_private` names are visible throughout their library, which in
+    /// practice is the file (`part` files aside)
+    fn is_symbol_visible_from_file(&self, symbol: &crate::Symbol, from_file: FileId) -> bool {
+        symbol.file_id == from_file || symbol.visibility != Visibility::Private
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_module_path_from_file() {
+        let behavior = DartBehavior::new();
+        let root = Path::new("/project");
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/lib/src/widgets/counter.dart"),
+                root,
+                &["dart"]
+            ),
+            Some("src.widgets.counter".to_string())
+        );
+    }
+
+    #[test]
+    fn test_normalize_import_path() {
+        let behavior = DartBehavior::new();
+        let file = Path::new("lib/src/widgets/home.dart");
+        let module = Some("src.widgets.home");
+
+        assert_eq!(
+            behavior.normalize_import_path("package:app/src/models/user.dart", module, file),
+            Some("src.models.user".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("counter.dart", module, file),
+            Some("src.widgets.counter".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("../models/user.dart", module, file),
+            Some("src.models.user".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("dart:async", module, file),
+            None
+        );
+    }
+
+    #[test]
+    fn test_import_matches_symbol() {
+        let behavior = DartBehavior::new();
+
+        assert!(behavior.import_matches_symbol("src.models.user", "src.models.user", None));
+        assert!(!behavior.import_matches_symbol("src.models", "src.models.user", None));
+    }
+
+    #[test]
+    fn test_parse_visibility() {
+        let behavior = DartBehavior::new();
+        assert_eq!(
+            behavior.parse_visibility("void _increment()"),
+            Visibility::Private
+        );
+        assert_eq!(
+            behavior.parse_visibility("Widget build(BuildContext context)"),
+            Visibility::Public
+        );
+        assert_eq!(
+            behavior.parse_visibility("Point._internal(this.x)"),
+            Visibility::Private
+        );
+    }
+}
@@ -0,0 +1,89 @@
+//! Dart language definition for the registry
+//!
+//! Provides the Dart language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{DartBehavior, DartParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Dart language definition
+pub struct DartLanguage;
+
+impl DartLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("dart");
+}
+
+impl LanguageDefinition for DartLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Dart"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["dart"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = DartParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(DartBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Dart is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Dart is enabled by default
+    }
+}
+
+/// Register Dart language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(DartLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_dart_definition() {
+        let dart = DartLanguage;
+
+        assert_eq!(dart.id(), LanguageId::new("dart"));
+        assert_eq!(dart.name(), "Dart");
+        assert!(dart.extensions().contains(&"dart"));
+    }
+
+    #[test]
+    fn test_dart_enabled_by_default() {
+        let dart = DartLanguage;
+        let settings = Settings::default();
+
+        assert!(dart.default_enabled());
+        assert!(dart.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_dart_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("dart")));
+    }
+}
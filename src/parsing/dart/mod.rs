@@ -0,0 +1,21 @@
+//! Dart language parser implementation
+//!
+//! Indexes classes, mixins, enums, extensions, typedefs, functions and
+//! class members for Dart and Flutter code. Extension members are
+//! attributed to the type they extend, async bodies are marked in the
+//! signature, and `///` doc comments are kept so widgets are reachable
+//! through semantic search.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+pub mod resolution;
+
+pub use behavior::DartBehavior;
+pub use definition::DartLanguage;
+pub use parser::DartParser;
+pub use resolution::DartResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
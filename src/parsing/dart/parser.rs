@@ -0,0 +1,1419 @@
+//! Dart language parser implementation
+//!
+//! Extracts symbols and relationships from Dart and Flutter source using
+//! tree-sitter-dart.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | class / abstract class | Class |
+//! | mixin | Trait |
+//! | enum | Enum (values are Constant) |
+//! | method, getter, setter, operator | Method |
+//! | constructor / factory constructor | Method |
+//! | top-level function | Function |
+//! | field (`static const` fields are Constant) | Field |
+//! | top-level `const` / `final` | Constant |
+//! | top-level `var` / typed variable | Variable |
+//! | typedef | TypeAlias |
+//!
+//! Members of `extension StringX on String { ... }` are attributed to the
+//! extended type, as Swift extensions are, and reported through
+//! `find_inherent_methods`. The extension itself is not a symbol.
+//!
+//! Class members are a signature followed by a sibling `function_body`; the
+//! body's `async`, `async*` or `sync*` modifier is appended to the
+//! signature so asynchronous APIs are searchable as such.
+//!
+//! ## Calls
+//!
+//! The grammar has no call node: `a.b(c)` is a primary followed by
+//! selectors, and a call is a selector holding an `argument_part`. Calls are
+//! read from those selector chains. Flutter widgets are instantiated without
+//! `new`, so `Text('hi')` inside `build` is a call to `Text` and a widget's
+//! `build` method links to the widgets it composes.
+//!
+//! ## Relationships
+//!
+//! - `extends` is reported by `find_extends`
+//! - `implements` and `with` (mixins) are reported by `find_implementations`
+//! - Classes, mixins and enums define their members (`find_defines`)
+//! - `import 'uri' as prefix show A, B` yields one import per directive
+//!
+//! ## Visibility
+//!
+//! Identifiers starting with `_` are private to their library; everything
+//! else is public.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, MethodCall, NodeTracker, NodeTrackingState,
+    ParserContext, ScopeType,
+};
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Core types filtered from type usage tracking
+const DART_BUILTIN_TYPES: &[&str] = &[
+    "int", "double", "num", "bool", "String", "void", "dynamic", "Object", "Null", "Never",
+    "Function", "var",
+];
+
+/// Dart-specific parsing errors
+#[derive(Error, Debug)]
+pub enum DartParseError {
+    #[error(
+        "Failed to initialize Dart parser: {reason}\nSuggestion: Ensure tree-sitter-dart is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// Dart language parser
+pub struct DartParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for DartParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("DartParser")
+            .field("language", &"Dart")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Range spanning from the start of `first` to the end of `last`
+fn range_between(first: &Node, last: &Node) -> Range {
+    let start = first.start_position();
+    let end = last.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Signature nodes that name a function or member
+fn is_signature(kind: &str) -> bool {
+    matches!(
+        kind,
+        "method_signature"
+            | "function_signature"
+            | "getter_signature"
+            | "setter_signature"
+            | "operator_signature"
+            | "constructor_signature"
+            | "constant_constructor_signature"
+            | "factory_constructor_signature"
+            | "redirecting_factory_constructor_signature"
+    )
+}
+
+fn is_constructor(kind: &str) -> bool {
+    matches!(
+        kind,
+        "constructor_signature"
+            | "constant_constructor_signature"
+            | "factory_constructor_signature"
+            | "redirecting_factory_constructor_signature"
+    )
+}
+
+/// Type declarations whose body members belong to them
+fn is_type_definition(kind: &str) -> bool {
+    matches!(
+        kind,
+        "class_definition" | "mixin_declaration" | "enum_declaration"
+    )
+}
+
+fn is_doc_comment(text: &str) -> bool {
+    text.starts_with("///") || (text.starts_with("/**") && !text.starts_with("/**/"))
+}
+
+/// `_name` and `Point._internal` are library-private
+fn visibility_of_name(name: &str) -> Visibility {
+    let last = name.rsplit('.').next().unwrap_or(name);
+    if last.starts_with('_') {
+        Visibility::Private
+    } else {
+        Visibility::Public
+    }
+}
+
+/// The concrete signature inside a `method_signature` wrapper
+fn inner_signature<'a>(node: &Node<'a>) -> Node<'a> {
+    if node.kind() != "method_signature" {
+        return *node;
+    }
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find(|child| is_signature(child.kind()))
+        .unwrap_or(*node)
+}
+
+/// Name of a function, member or constructor signature
+///
+/// Constructors are named as written: `Point` or `Point.origin`. Operators
+/// are named `operator ==`.
+fn signature_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    let inner = inner_signature(node);
+    if let Some(name) = inner.child_by_field_name("name") {
+        if !is_constructor(inner.kind()) {
+            return Some(&code[name.byte_range()]);
+        }
+    }
+
+    let mut cursor = inner.walk();
+    let children: Vec<Node> = inner.children(&mut cursor).collect();
+
+    if inner.kind() == "operator_signature" {
+        let keyword = children.iter().find(|child| child.kind() == "operator")?;
+        let params = children
+            .iter()
+            .find(|child| child.kind() == "formal_parameter_list")?;
+        return Some(code[keyword.start_byte()..params.start_byte()].trim());
+    }
+
+    // `Point.origin(...)`: the identifiers before the parameter list
+    let mut identifiers = children
+        .iter()
+        .take_while(|child| child.kind() != "formal_parameter_list")
+        .filter(|child| child.kind() == "identifier");
+    let first = identifiers.next()?;
+    let last = identifiers.last().unwrap_or(first);
+    Some(&code[first.start_byte()..last.end_byte()])
+}
+
+/// Body following a signature: members are `signature function_body` pairs
+fn function_body_of<'a>(signature: &Node<'a>) -> Option<Node<'a>> {
+    let mut next = signature.next_named_sibling();
+    while let Some(node) = next {
+        match node.kind() {
+            "function_body" => return Some(node),
+            // Constructor initializer list sits between signature and body
+            "initializers" | "redirection" | "comment" | "documentation_comment" => {
+                next = node.next_named_sibling();
+            }
+            _ => return None,
+        }
+    }
+    None
+}
+
+/// `async`, `async*` or `sync*` from the start of a function body
+fn async_modifier<'a>(body: &Node, code: &'a str) -> Option<&'a str> {
+    let text = &code[body.byte_range()];
+    ["async*", "async", "sync*"]
+        .into_iter()
+        .find(|modifier| text.starts_with(modifier))
+}
+
+/// Name declared by a class, mixin, enum or typedef
+fn type_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    if let Some(name) = node.child_by_field_name("name") {
+        return Some(&code[name.byte_range()]);
+    }
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find(|child| matches!(child.kind(), "identifier" | "type_identifier"))
+        .map(|child| &code[child.byte_range()])
+}
+
+fn type_body<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    node.child_by_field_name("body").or_else(|| {
+        let mut cursor = node.walk();
+        node.named_children(&mut cursor)
+            .find(|child| matches!(child.kind(), "class_body" | "enum_body" | "extension_body"))
+    })
+}
+
+/// `StringX on String` -> `String`
+fn extension_target<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    if let Some(target) = node
+        .child_by_field_name("class")
+        .filter(|target| target.kind() == "type_identifier")
+    {
+        return Some(&code[target.byte_range()]);
+    }
+    let mut cursor = node.walk();
+    node.children(&mut cursor)
+        .skip_while(|child| child.kind() != "on")
+        .find(|child| child.kind() == "type_identifier")
+        .map(|child| &code[child.byte_range()])
+}
+
+/// Type names directly listed by a heritage clause (`extends`, `with`, `implements`)
+fn listed_types<'a>(clause: &Node, code: &'a str) -> Vec<(&'a str, Range)> {
+    let mut cursor = clause.walk();
+    clause
+        .named_children(&mut cursor)
+        .filter(|child| child.kind() == "type_identifier")
+        .map(|child| (&code[child.byte_range()], range_from_node(&child)))
+        .collect()
+}
+
+/// Find a named child of the given kind
+fn child_of_kind<'a>(node: &Node<'a>, kind: &str) -> Option<Node<'a>> {
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find(|child| child.kind() == kind)
+}
+
+/// Names declared by a variable or field list
+fn declared_names<'a>(list: &Node<'a>) -> Vec<Node<'a>> {
+    let mut cursor = list.walk();
+    match list.kind() {
+        "identifier_list" => list
+            .named_children(&mut cursor)
+            .filter(|child| child.kind() == "identifier")
+            .collect(),
+        _ => list
+            .named_children(&mut cursor)
+            .filter_map(|declarator| {
+                declarator
+                    .child_by_field_name("name")
+                    .or_else(|| child_of_kind(&declarator, "identifier"))
+            })
+            .collect(),
+    }
+}
+
+fn is_declaration_list(kind: &str) -> bool {
+    matches!(
+        kind,
+        "static_final_declaration_list" | "initialized_identifier_list" | "identifier_list"
+    )
+}
+
+/// Start of the modifiers and type written before a top-level variable list
+///
+/// Top-level variables are not wrapped in a declaration node:
+/// `const int maxItems = 64;` is `const_builtin`, the type and the list as
+/// siblings under `program`.
+fn declaration_prefix<'a>(list: &Node<'a>) -> Node<'a> {
+    let mut start = *list;
+    let mut prev = list.prev_sibling();
+    while let Some(node) = prev {
+        let is_prefix = matches!(
+            node.kind(),
+            "final_builtin"
+                | "const_builtin"
+                | "inferred_type"
+                | "type_identifier"
+                | "type_arguments"
+                | "nullable_type"
+                | "void_type"
+                | "function_type"
+                | "late"
+                | "static"
+                | "external"
+                | "covariant"
+        );
+        if !is_prefix {
+            break;
+        }
+        start = node;
+        prev = node.prev_sibling();
+    }
+    start
+}
+
+/// A call found in a selector chain
+struct ChainCall<'a> {
+    callee: &'a str,
+    receiver: Option<&'a str>,
+    range: Range,
+}
+
+/// Calls among the children of a node
+///
+/// `foo(x)`, `obj.method(x)` and `Foo.named()` are a primary followed by
+/// selectors; each `argument_part` selector calls the name before it.
+/// `new Foo()` and `const Text('x')` call the type.
+fn chain_calls<'a>(node: &Node, code: &'a str) -> Vec<ChainCall<'a>> {
+    let mut calls = Vec::new();
+
+    if matches!(node.kind(), "new_expression" | "const_object_expression") {
+        if let Some(ty) = child_of_kind(node, "type_identifier") {
+            calls.push(ChainCall {
+                callee: &code[ty.byte_range()],
+                receiver: None,
+                range: range_from_node(node),
+            });
+        }
+        return calls;
+    }
+
+    let mut chain_start: Option<Node> = None;
+    let mut callee: Option<Node> = None;
+    let mut receiver_end: Option<usize> = None;
+
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        match child.kind() {
+            "identifier" => {
+                chain_start = Some(child);
+                callee = Some(child);
+                receiver_end = None;
+            }
+            "this" | "super" => {
+                chain_start = Some(child);
+                callee = None;
+                receiver_end = None;
+            }
+            "selector" if chain_start.is_some() => {
+                let mut selector_cursor = child.walk();
+                let part = child.named_children(&mut selector_cursor).next();
+                match part.map(|part| part.kind()) {
+                    Some("argument_part") => {
+                        if let (Some(start), Some(name)) = (chain_start, callee) {
+                            let receiver = receiver_end
+                                .map(|end| code[start.start_byte()..end].trim())
+                                .filter(|receiver| !receiver.is_empty());
+                            calls.push(ChainCall {
+                                callee: &code[name.byte_range()],
+                                receiver,
+                                range: range_between(&start, &child),
+                            });
+                        }
+                        // The call result is not a name
+                        callee = None;
+                        receiver_end = None;
+                    }
+                    Some(
+                        "unconditional_assignable_selector" | "conditional_assignable_selector",
+                    ) => {
+                        callee = part.and_then(|part| child_of_kind(&part, "identifier"));
+                        receiver_end = Some(child.start_byte());
+                    }
+                    _ => {
+                        callee = None;
+                        receiver_end = None;
+                    }
+                }
+            }
+            _ => {
+                chain_start = None;
+                callee = None;
+                receiver_end = None;
+            }
+        }
+    }
+
+    calls
+}
+
+impl DartParser {
+    /// Create a new Dart parser instance
+    pub fn new() -> Result<Self, DartParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_dart::LANGUAGE.into())
+            .map_err(|e| DartParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn create_symbol(
+        &self,
+        counter: &mut SymbolCounter,
+        name: &str,
+        kind: SymbolKind,
+        file_id: FileId,
+        range: Range,
+        signature: String,
+        doc_comment: Option<String>,
+        visibility: Visibility,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(counter.next_id(), name, kind, file_id, range)
+            .with_signature(signature)
+            .with_visibility(visibility);
+        if let Some(doc) = doc_comment {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(self.context.current_scope_context());
+        symbol
+    }
+
+    /// Extract symbols from AST node recursively
+    fn extract_symbols_from_node(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        match node.kind() {
+            "class_definition" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_type(
+                    node,
+                    SymbolKind::Class,
+                    code,
+                    file_id,
+                    counter,
+                    symbols,
+                    depth,
+                );
+            }
+            "mixin_declaration" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_type(
+                    node,
+                    SymbolKind::Trait,
+                    code,
+                    file_id,
+                    counter,
+                    symbols,
+                    depth,
+                );
+            }
+            "enum_declaration" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_type(
+                    node,
+                    SymbolKind::Enum,
+                    code,
+                    file_id,
+                    counter,
+                    symbols,
+                    depth,
+                );
+            }
+            "extension_declaration" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_extension(node, code, file_id, counter, symbols, depth);
+            }
+            "type_alias" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_type_alias(node, code, file_id, counter, symbols);
+            }
+            "enum_constant" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_enum_constant(node, code, file_id, counter, symbols);
+            }
+            kind if is_signature(kind) => {
+                let inner = inner_signature(&node);
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.register_handled_node(inner.kind(), inner.kind_id());
+                self.process_function(node, code, file_id, counter, symbols);
+            }
+            "declaration" => {
+                // Fields, abstract members and bodiless constructors
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_declaration(node, code, file_id, counter, symbols, depth);
+            }
+            kind if is_declaration_list(kind) => {
+                // Top-level variables; class fields go through `declaration`
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_top_level_variables(node, code, file_id, counter, symbols);
+            }
+            // Bodies hold no declarations worth indexing: locals stay out
+            "function_body" | "import_or_export" | "part_directive" | "part_of_directive" => {}
+            _ => {
+                self.extract_children(node, code, file_id, counter, symbols, depth);
+            }
+        }
+    }
+
+    fn extract_children(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            self.extract_symbols_from_node(child, code, file_id, counter, symbols, depth + 1);
+        }
+    }
+
+    /// Walk a type or extension body with `owner` as the enclosing class
+    #[allow(clippy::too_many_arguments)]
+    fn process_members(
+        &mut self,
+        body: Node,
+        owner: &str,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        self.register_handled_node(body.kind(), body.kind_id());
+
+        let saved_class = self.context.current_class().map(|s| s.to_string());
+        self.context.enter_scope(ScopeType::Class);
+        self.context.set_current_class(Some(owner.to_string()));
+
+        self.extract_children(body, code, file_id, counter, symbols, depth);
+
+        self.context.exit_scope();
+        self.context.set_current_class(saved_class);
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn process_type(
+        &mut self,
+        node: Node,
+        kind: SymbolKind,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(name) = type_name(&node, code) else {
+            return;
+        };
+        let body = type_body(&node);
+
+        // `abstract class Shape extends Base with Mixin implements Api`
+        let header_end = body.map(|b| b.start_byte()).unwrap_or(node.end_byte());
+        let signature = code[node.start_byte()..header_end].trim().to_string();
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            signature,
+            self.extract_doc_comment(&node, code),
+            visibility_of_name(name),
+        );
+        symbols.push(symbol);
+
+        if let Some(body) = body {
+            self.process_members(body, name, code, file_id, counter, symbols, depth);
+        }
+    }
+
+    fn process_extension(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let (Some(target), Some(body)) = (extension_target(&node, code), type_body(&node)) else {
+            return;
+        };
+        self.process_members(body, target, code, file_id, counter, symbols, depth);
+    }
+
+    fn process_type_alias(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(name) = type_name(&node, code) else {
+            return;
+        };
+        let signature = code[node.byte_range()]
+            .trim_end_matches(';')
+            .trim()
+            .to_string();
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            SymbolKind::TypeAlias,
+            file_id,
+            range_from_node(&node),
+            signature,
+            self.extract_doc_comment(&node, code),
+            visibility_of_name(name),
+        );
+        symbols.push(symbol);
+    }
+
+    fn process_enum_constant(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(name) = type_name(&node, code) else {
+            return;
+        };
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            SymbolKind::Constant,
+            file_id,
+            range_from_node(&node),
+            code[node.byte_range()].trim().to_string(),
+            self.extract_doc_comment(&node, code),
+            visibility_of_name(name),
+        );
+        symbols.push(symbol);
+    }
+
+    /// Functions, methods, accessors, operators and constructors
+    fn process_function(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(name) = signature_name(&node, code) else {
+            return;
+        };
+
+        let kind = if self.context.is_in_class() || is_constructor(inner_signature(&node).kind()) {
+            SymbolKind::Method
+        } else {
+            SymbolKind::Function
+        };
+
+        let mut signature = code[node.byte_range()].trim().to_string();
+        if let Some(modifier) = function_body_of(&node).and_then(|body| async_modifier(&body, code))
+        {
+            signature.push(' ');
+            signature.push_str(modifier);
+        }
+
+        // Doc comments and annotations precede the outermost node of the member
+        let doc_node = match node.parent() {
+            Some(parent) if parent.kind() == "declaration" => parent,
+            _ => node,
+        };
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            signature,
+            self.extract_doc_comment(&doc_node, code),
+            visibility_of_name(name),
+        );
+        symbols.push(symbol);
+    }
+
+    /// Class-level `declaration`: fields, abstract members, bodiless constructors
+    fn process_declaration(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let is_const = child_of_kind(&node, "const_builtin").is_some();
+        let is_static = node
+            .children(&mut node.walk())
+            .any(|child| child.kind() == "static");
+        let kind = if is_const && is_static {
+            SymbolKind::Constant
+        } else {
+            SymbolKind::Field
+        };
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            if is_signature(child.kind()) {
+                self.extract_symbols_from_node(child, code, file_id, counter, symbols, depth + 1);
+                continue;
+            }
+            if !is_declaration_list(child.kind()) {
+                continue;
+            }
+            self.register_handled_node(child.kind(), child.kind_id());
+
+            // `final int count` up to the declared names
+            let prefix = code[node.start_byte()..child.start_byte()].trim();
+            for name_node in declared_names(&child) {
+                let name = &code[name_node.byte_range()];
+                let symbol = self.create_symbol(
+                    counter,
+                    name,
+                    kind,
+                    file_id,
+                    range_from_node(&node),
+                    format!("{prefix} {name}").trim().to_string(),
+                    self.extract_doc_comment(&node, code),
+                    visibility_of_name(name),
+                );
+                symbols.push(symbol);
+            }
+        }
+    }
+
+    fn process_top_level_variables(
+        &mut self,
+        list: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let prefix_node = declaration_prefix(&list);
+        let prefix = code[prefix_node.start_byte()..list.start_byte()].trim();
+        let kind = if list.kind() == "static_final_declaration_list"
+            || prefix.starts_with("const")
+            || prefix.starts_with("final")
+        {
+            SymbolKind::Constant
+        } else {
+            SymbolKind::Variable
+        };
+        let doc_comment = self.extract_doc_comment(&prefix_node, code);
+
+        for name_node in declared_names(&list) {
+            let name = &code[name_node.byte_range()];
+            let symbol = self.create_symbol(
+                counter,
+                name,
+                kind,
+                file_id,
+                range_between(&prefix_node, &list),
+                format!("{prefix} {name}").trim().to_string(),
+                doc_comment.clone(),
+                visibility_of_name(name),
+            );
+            symbols.push(symbol);
+        }
+    }
+
+    /// Walk children, attributing each `function_body` to the signature before it
+    fn walk_calls<'a>(
+        node: Node,
+        code: &'a str,
+        caller: Option<&'a str>,
+        visit: &mut impl FnMut(ChainCall<'a>, Option<&'a str>),
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        for call in chain_calls(&node, code) {
+            visit(call, caller);
+        }
+
+        let mut pending: Option<&'a str> = None;
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            if is_signature(child.kind()) {
+                pending = signature_name(&child, code);
+                continue;
+            }
+            let child_caller = match child.kind() {
+                // Field initializers and constructor initializer lists run
+                // as part of their member
+                "function_body" | "initializers" => pending.or(caller),
+                _ => caller,
+            };
+            if child.kind() == "function_body" {
+                pending = None;
+            }
+            Self::walk_calls(child, code, child_caller, visit, depth + 1);
+        }
+    }
+
+    fn extract_defines_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        defines: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if is_type_definition(node.kind()) {
+            if let (Some(owner), Some(body)) = (type_name(&node, code), type_body(&node)) {
+                let mut cursor = body.walk();
+                for member in body.named_children(&mut cursor) {
+                    match member.kind() {
+                        kind if is_signature(kind) => {
+                            if let Some(name) = signature_name(&member, code) {
+                                defines.push((owner, name, range_from_node(&member)));
+                            }
+                        }
+                        "enum_constant" => {
+                            if let Some(name) = type_name(&member, code) {
+                                defines.push((owner, name, range_from_node(&member)));
+                            }
+                        }
+                        "declaration" => {
+                            let mut member_cursor = member.walk();
+                            for part in member.named_children(&mut member_cursor) {
+                                if is_signature(part.kind()) {
+                                    if let Some(name) = signature_name(&part, code) {
+                                        defines.push((owner, name, range_from_node(&member)));
+                                    }
+                                } else if is_declaration_list(part.kind()) {
+                                    for name in declared_names(&part) {
+                                        defines.push((
+                                            owner,
+                                            &code[name.byte_range()],
+                                            range_from_node(&member),
+                                        ));
+                                    }
+                                }
+                            }
+                        }
+                        _ => {}
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_defines_from_node(child, code, defines);
+        }
+    }
+
+    /// Heritage pairs from one clause kind: `superclass`, `mixins` or `interfaces`
+    fn extract_heritage_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        clause_kinds: &[&str],
+        heritage: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if is_type_definition(node.kind()) {
+            if let Some(derived) = type_name(&node, code) {
+                let mut clauses = Vec::new();
+                let mut cursor = node.walk();
+                for child in node.named_children(&mut cursor) {
+                    // `extends Base with Mixin`: the mixins nest in the superclass
+                    if child.kind() == "superclass" {
+                        if let Some(mixins) = child_of_kind(&child, "mixins") {
+                            clauses.push(mixins);
+                        }
+                    }
+                    clauses.push(child);
+                }
+                for clause in clauses {
+                    if clause_kinds.contains(&clause.kind()) {
+                        for (base, range) in listed_types(&clause, code) {
+                            heritage.push((derived, base, range));
+                        }
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_heritage_from_node(child, code, clause_kinds, heritage);
+        }
+    }
+
+    /// Parameter and return types referenced by functions and methods
+    fn extract_uses_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if is_signature(node.kind()) && node.kind() != "method_signature" {
+            if let Some(user) = signature_name(&node, code) {
+                Self::collect_type_identifiers(node, code, user, uses);
+            }
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_uses_from_node(child, code, uses);
+        }
+    }
+
+    fn collect_type_identifiers<'a>(
+        node: Node,
+        code: &'a str,
+        user: &'a str,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if node.kind() == "type_identifier" {
+            let type_name = &code[node.byte_range()];
+            if !DART_BUILTIN_TYPES.contains(&type_name) {
+                uses.push((user, type_name, range_from_node(&node)));
+            }
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::collect_type_identifiers(child, code, user, uses);
+        }
+    }
+
+    /// `final Counter c = ...`, `final c = Counter(...)` and `var c = const Counter()`
+    fn extract_variable_types_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        bindings: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if matches!(
+            node.kind(),
+            "initialized_variable_definition"
+                | "initialized_identifier"
+                | "static_final_declaration"
+        ) {
+            let name = node
+                .child_by_field_name("name")
+                .or_else(|| child_of_kind(&node, "identifier"));
+            if let Some(name) = name {
+                // Local definitions carry their type; list entries inherit it
+                // from the siblings before the list
+                let declared = child_of_kind(&node, "type_identifier").or_else(|| {
+                    node.parent()
+                        .map(|list| declaration_prefix(&list))
+                        .filter(|prefix| prefix.kind() == "type_identifier")
+                });
+                let declared = declared
+                    .map(|ty| &code[ty.byte_range()])
+                    .filter(|ty| !DART_BUILTIN_TYPES.contains(ty));
+
+                // The initializer chain sits among the definition's children;
+                // `new Counter()` / `const Counter()` are nested expressions
+                let mut initializers = vec![node];
+                let mut cursor = node.walk();
+                initializers.extend(node.named_children(&mut cursor).filter(|child| {
+                    matches!(child.kind(), "new_expression" | "const_object_expression")
+                }));
+                let inferred = initializers
+                    .iter()
+                    .flat_map(|initializer| chain_calls(initializer, code))
+                    .next()
+                    .filter(|call| call.receiver.is_none())
+                    .map(|call| call.callee)
+                    .filter(|callee| callee.starts_with(|c: char| c.is_uppercase()));
+
+                if let Some(type_name) = declared.or(inferred) {
+                    bindings.push((&code[name.byte_range()], type_name, range_from_node(&node)));
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_variable_types_from_node(child, code, bindings);
+        }
+    }
+
+    /// Extension members reported against the extended type
+    fn extract_extension_methods_from_node(
+        node: Node,
+        code: &str,
+        methods: &mut Vec<(String, String, Range)>,
+    ) {
+        if node.kind() == "extension_declaration" {
+            if let (Some(target), Some(body)) = (extension_target(&node, code), type_body(&node)) {
+                let mut cursor = body.walk();
+                for member in body.named_children(&mut cursor) {
+                    if is_signature(member.kind()) {
+                        if let Some(name) = signature_name(&member, code) {
+                            methods.push((
+                                target.to_string(),
+                                name.to_string(),
+                                range_from_node(&member),
+                            ));
+                        }
+                    }
+                }
+            }
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_extension_methods_from_node(child, code, methods);
+        }
+    }
+
+    fn extract_imports_from_node(
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        imports: &mut Vec<Import>,
+    ) {
+        if node.kind() == "import_specification" {
+            let uri = child_of_kind(&node, "configurable_uri")
+                .or_else(|| child_of_kind(&node, "uri"))
+                .map(|uri| &code[uri.byte_range()]);
+            let path = uri.and_then(|uri| {
+                let start = uri.find(['\'', '"'])? + 1;
+                let end = start + uri[start..].find(['\'', '"'])?;
+                Some(&uri[start..end])
+            });
+
+            if let Some(path) = path {
+                // `as prefix` is the only direct identifier child
+                let alias = child_of_kind(&node, "identifier")
+                    .map(|alias| code[alias.byte_range()].to_string());
+                let has_show = node.named_children(&mut node.walk()).any(|child| {
+                    child.kind() == "combinator" && code[child.byte_range()].starts_with("show")
+                });
+
+                imports.push(Import {
+                    path: path.to_string(),
+                    // Unprefixed imports expose the whole library namespace
+                    is_glob: alias.is_none() && !has_show,
+                    alias,
+                    file_id,
+                    is_type_only: false,
+                });
+            }
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_imports_from_node(child, code, file_id, imports);
+        }
+    }
+}
+
+impl LanguageParser for DartParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+
+        let mut symbols = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            self.extract_symbols_from_node(
+                tree.root_node(),
+                code,
+                file_id,
+                symbol_counter,
+                &mut symbols,
+                0,
+            );
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// `///` lines or a `/** ... */` block above the declaration
+    ///
+    /// Annotations such as `@override` may sit between the comment and
+    /// the member.
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let mut lines = Vec::new();
+        let mut current = node.prev_sibling();
+        while let Some(prev) = current {
+            match prev.kind() {
+                "annotation" | "marker_annotation" => {}
+                "comment" | "documentation_comment" => {
+                    let text = &code[prev.byte_range()];
+                    if !is_doc_comment(text) {
+                        break;
+                    }
+                    if let Some(line) = text.strip_prefix("///") {
+                        lines.push(line.trim().to_string());
+                    } else {
+                        let inner = text.trim_start_matches("/**").trim_end_matches("*/");
+                        for line in inner.lines().rev() {
+                            let line = line.trim().trim_start_matches('*').trim();
+                            if !line.is_empty() {
+                                lines.push(line.to_string());
+                            }
+                        }
+                    }
+                }
+                _ => break,
+            }
+            current = prev.prev_sibling();
+        }
+
+        if lines.is_empty() {
+            return None;
+        }
+        lines.reverse();
+        Some(lines.join("\n"))
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::walk_calls(
+                tree.root_node(),
+                code,
+                None,
+                &mut |call, caller| {
+                    if let (Some(caller), None) = (caller, call.receiver) {
+                        calls.push((caller, call.callee, call.range));
+                    }
+                },
+                0,
+            );
+        }
+        calls
+    }
+
+    fn find_method_calls(&mut self, code: &str) -> Vec<MethodCall> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::walk_calls(
+                tree.root_node(),
+                code,
+                None,
+                &mut |call, caller| {
+                    let (Some(caller), Some(receiver)) = (caller, call.receiver) else {
+                        return;
+                    };
+                    let mut method_call =
+                        MethodCall::new(caller, call.callee, call.range).with_receiver(receiver);
+                    // `Navigator.of(context)`, `Point.origin()`: types are TitleCase
+                    if !receiver.contains('.') && receiver.starts_with(|c: char| c.is_uppercase()) {
+                        method_call = method_call.static_method();
+                    }
+                    calls.push(method_call);
+                },
+                0,
+            );
+        }
+        calls
+    }
+
+    /// `implements` interfaces and `with` mixins
+    fn find_implementations<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut heritage = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_heritage_from_node(
+                tree.root_node(),
+                code,
+                &["interfaces", "mixins"],
+                &mut heritage,
+            );
+        }
+        heritage
+    }
+
+    fn find_extends<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut heritage = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_heritage_from_node(
+                tree.root_node(),
+                code,
+                &["superclass"],
+                &mut heritage,
+            );
+        }
+        heritage
+    }
+
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut uses = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_uses_from_node(tree.root_node(), code, &mut uses);
+        }
+        uses
+    }
+
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut defines = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_defines_from_node(tree.root_node(), code, &mut defines);
+        }
+        defines
+    }
+
+    fn find_variable_types<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut bindings = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_variable_types_from_node(tree.root_node(), code, &mut bindings);
+        }
+        bindings
+    }
+
+    /// Extension members attributed to the type they extend
+    fn find_inherent_methods(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let mut methods = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_extension_methods_from_node(tree.root_node(), code, &mut methods);
+        }
+        methods
+    }
+
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let mut imports = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_imports_from_node(tree.root_node(), code, file_id, &mut imports);
+        }
+        imports
+    }
+
+    fn language(&self) -> Language {
+        Language::Dart
+    }
+}
+
+impl NodeTracker for DartParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = DartParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find '{name}'"))
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(DartParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_class_members() {
+        let code = r#"
+/// A 2D point
+class Point {
+  static const origin = Point(0, 0);
+  final double x;
+  final double y;
+
+  const Point(this.x, this.y);
+  Point._internal() : x = 0, y = 0;
+  factory Point.polar(double r) => Point(r, 0);
+
+  double get length => x + y;
+  Point operator +(Point other) => Point(x + other.x, y + other.y);
+}
+"#;
+        let symbols = parse(code);
+
+        let point = find(&symbols, "Point");
+        assert_eq!(point.kind, SymbolKind::Class);
+        assert_eq!(point.doc_comment.as_deref(), Some("A 2D point"));
+
+        assert_eq!(find(&symbols, "origin").kind, SymbolKind::Constant);
+        assert_eq!(find(&symbols, "x").kind, SymbolKind::Field);
+        assert_eq!(find(&symbols, "length").kind, SymbolKind::Method);
+        assert_eq!(find(&symbols, "operator +").kind, SymbolKind::Method);
+
+        let internal = find(&symbols, "Point._internal");
+        assert_eq!(internal.kind, SymbolKind::Method);
+        assert_eq!(internal.visibility, Visibility::Private);
+        assert_eq!(find(&symbols, "Point.polar").visibility, Visibility::Public);
+    }
+
+    #[test]
+    fn test_top_level_declarations() {
+        let code = r#"
+const maxItems = 64;
+final String greeting = 'hi';
+var counter = 0;
+int _hidden = 1;
+
+Future<void> load() async {}
+"#;
+        let symbols = parse(code);
+        assert_eq!(find(&symbols, "maxItems").kind, SymbolKind::Constant);
+        assert_eq!(find(&symbols, "greeting").kind, SymbolKind::Constant);
+        assert_eq!(find(&symbols, "counter").kind, SymbolKind::Variable);
+        assert_eq!(find(&symbols, "_hidden").visibility, Visibility::Private);
+
+        let load = find(&symbols, "load");
+        assert_eq!(load.kind, SymbolKind::Function);
+        assert_eq!(load.signature.as_deref(), Some("Future<void> load() async"));
+    }
+
+    #[test]
+    fn test_locals_are_not_symbols() {
+        let code = r#"
+void main() {
+  final local = 1;
+  print(local);
+}
+"#;
+        let symbols = parse(code);
+        assert_eq!(find(&symbols, "main").kind, SymbolKind::Function);
+        assert!(!symbols.iter().any(|s| s.name.as_ref() == "local"));
+    }
+
+    #[test]
+    fn test_variable_types() {
+        let code = r#"
+void main() {
+  final Counter a = makeCounter();
+  final b = Counter(1);
+  var c = const Counter(2);
+}
+"#;
+        let mut parser = DartParser::new().unwrap();
+        let bindings = parser.find_variable_types(code);
+        for name in ["a", "b", "c"] {
+            assert!(
+                bindings
+                    .iter()
+                    .any(|(var, ty, _)| *var == name && *ty == "Counter"),
+                "{name} should be a Counter, got {bindings:?}"
+            );
+        }
+    }
+}
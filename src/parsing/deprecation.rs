@@ -0,0 +1,185 @@
+//! Deprecation markers
+//!
+//! A symbol is deprecated when the lines right above it, its first line or
+//! its doc comment carry one of the markers its language writes:
+//!
+//! | Marker | Languages |
+//! |--------|-----------|
+//! | `#[deprecated]`, `#[\Deprecated]` | Rust, PHP |
+//! | `@Deprecated`, `@deprecated` | Java, Kotlin, Scala, Dart, Python decorators, JSDoc, PHPDoc, Elixir |
+//! | `[Obsolete]` | C# |
+//! | `[[deprecated]]`, `__attribute__((deprecated))` | C, C++ |
+//! | `@available(*, deprecated)` | Swift |
+//! | `Deprecated:` opening a comment line | Go |
+//! | `.. deprecated::` | Python docstrings |
+//!
+//! The lines above are the attributes, annotations, decorators and comments
+//! directly above the symbol, without a blank line in between. A deprecated
+//! symbol carries the [`DEPRECATED_TAG`], as a `codanna:tag=deprecated`
+//! directive would tag it.
+
+use crate::Range;
+
+/// Tag of deprecated symbols
+pub const DEPRECATED_TAG: &str = "deprecated";
+
+/// Attribute and annotation markers, anywhere in a line
+const ATTRIBUTE_MARKERS: &[&str] = &[
+    "#[deprecated",
+    "#[\\Deprecated",
+    "#[Deprecated",
+    "@Deprecated",
+    "@deprecated",
+    "[Obsolete",
+    "[System.Obsolete",
+    "[[deprecated",
+    "__attribute__((deprecated",
+    "__declspec(deprecated",
+];
+
+/// Markers opening the text of a comment line
+const COMMENT_MARKERS: &[&str] = &["Deprecated:", ".. deprecated::"];
+
+/// Most lines read above a symbol
+const MAX_LINES_ABOVE: usize = 50;
+
+/// Whether a line carries an attribute or annotation marker
+fn has_attribute_marker(line: &str) -> bool {
+    ATTRIBUTE_MARKERS.iter().any(|marker| line.contains(marker))
+        || (line.contains("@available(") && line.contains("deprecated"))
+}
+
+/// Whether the text of a comment line opens with a deprecation marker
+fn has_comment_marker(line: &str) -> bool {
+    let text = line
+        .trim_start()
+        .trim_start_matches(['/', '*', '!', '#', '-'])
+        .trim_start();
+    COMMENT_MARKERS
+        .iter()
+        .any(|marker| text.starts_with(marker))
+}
+
+/// `line` without the text of its string literals
+fn without_strings(line: &str) -> String {
+    let mut code = String::with_capacity(line.len());
+    let mut quote = None;
+    for c in line.chars() {
+        match quote {
+            Some(open) if c == open => quote = None,
+            Some(_) => {}
+            None if matches!(c, '"' | '\'' | '`') => quote = Some(c),
+            None => code.push(c),
+        }
+    }
+    code
+}
+
+/// Whether a line above a symbol belongs to it: an attribute, annotation,
+/// decorator or comment
+fn is_preamble(line: &str) -> bool {
+    let line = line.trim_start();
+    ["#", "@", "[", "//", "/*", "*", "--"]
+        .iter()
+        .any(|start| line.starts_with(start))
+}
+
+/// The lines directly above the symbol at `range` that belong to it,
+/// nearest first: its attributes, annotations, decorators and comments
+pub fn preamble<'a>(lines: &[&'a str], range: &Range) -> impl Iterator<Item = &'a str> {
+    let start = (range.start_line as usize).min(lines.len());
+    lines[..start]
+        .iter()
+        .rev()
+        .take(MAX_LINES_ABOVE)
+        .take_while(|line| is_preamble(line))
+        .copied()
+}
+
+/// Whether the symbol at `range` of the file with `lines`, documented by
+/// `doc`, is marked deprecated
+pub fn is_deprecated(lines: &[&str], range: &Range, doc: Option<&str>) -> bool {
+    if doc.is_some_and(|doc| {
+        doc.lines()
+            .any(|line| has_comment_marker(line) || line.contains("@deprecated"))
+    }) {
+        return true;
+    }
+
+    let start = range.start_line as usize;
+    // A marker in a string of the first line is text, not a marker
+    if lines
+        .get(start)
+        .is_some_and(|first| has_attribute_marker(&without_strings(first)))
+    {
+        return true;
+    }
+    preamble(lines, range).any(|line| has_attribute_marker(line) || has_comment_marker(line))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn deprecated(code: &str, line: u32) -> bool {
+        let lines: Vec<&str> = code.lines().collect();
+        is_deprecated(&lines, &Range::new(line, 0, line, 1), None)
+    }
+
+    #[test]
+    fn test_attributes_above() {
+        let rust =
+            "#[deprecated(since = \"2.0\", note = \"use open\")]\n#[inline]\npub fn start() {}\n";
+        assert!(deprecated(rust, 2));
+
+        let python = "@deprecated(\"use load\")\ndef fetch():\n    pass\n";
+        assert!(deprecated(python, 1));
+
+        let csharp = "    [Obsolete(\"Use Save\")]\n    public void Store() {}\n";
+        assert!(deprecated(csharp, 1));
+
+        let swift = "@available(*, deprecated, message: \"use run\")\nfunc start() {}\n";
+        assert!(deprecated(swift, 1));
+    }
+
+    #[test]
+    fn test_comments_above() {
+        let jsdoc =
+            "/**\n * Loads it.\n * @deprecated Use load instead.\n */\nfunction fetch() {}\n";
+        assert!(deprecated(jsdoc, 4));
+
+        let go = "// Fetch loads it.\n//\n// Deprecated: use Load.\nfunc Fetch() {}\n";
+        assert!(deprecated(go, 3));
+    }
+
+    #[test]
+    fn test_markers_on_the_first_line() {
+        assert!(deprecated("@Deprecated public void store() {}\n", 0));
+        assert!(deprecated(
+            "void store(void) __attribute__((deprecated));\n",
+            0
+        ));
+        assert!(!deprecated(
+            "const store = () => log(\"@deprecated\");\n",
+            0
+        ));
+    }
+
+    #[test]
+    fn test_markers_of_other_symbols() {
+        // A blank line or code ends what is above a symbol
+        let code = "#[deprecated]\nfn old() {}\n\nfn new() {}\n#[allow(deprecated)]\nfn uses_old() { old(); }\n";
+        assert!(deprecated(code, 1));
+        assert!(!deprecated(code, 3));
+        assert!(!deprecated(code, 5));
+    }
+
+    #[test]
+    fn test_doc_comments() {
+        let range = Range::new(0, 0, 0, 1);
+        let deprecated = |doc: &str| is_deprecated(&["def fetch():"], &range, Some(doc));
+        assert!(deprecated("Fetch it.\n\n.. deprecated:: 2.0\n   Use load."));
+        assert!(deprecated("Loads it.\n@deprecated use load"));
+        assert!(!deprecated("Replaces the deprecated fetch."));
+    }
+}
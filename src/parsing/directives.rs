@@ -0,0 +1,228 @@
+//! `codanna:` directives in comments
+//!
+//! A comment line starting with a directive changes what the file indexes,
+//! without touching the settings:
+//!
+//! | Directive | Effect |
+//! |-----------|--------|
+//! | `codanna:ignore-file` | the file indexes no symbols, imports or relationships |
+//! | `codanna:ignore-next-symbol` | the next symbol is left out, with its members |
+//! | `codanna:tag=deprecated,internal` | the next symbol carries the tags |
+//!
+//! The next symbol is the first to start below the comment, the outermost
+//! where several start on one line, and never one past the end of a
+//! symbol holding the comment. Text after the directive is free to explain
+//! it: `// codanna:ignore-next-symbol generated by protoc`. Tags are
+//! separated by commas without spaces, kept in lowercase, and searched
+//! with `tag:` in `codanna retrieve query`.
+//!
+//! Comments are found as todos are, see [`crate::parsing::todo`].
+
+use crate::Range;
+use crate::parsing::todo::{CLOSERS, MARKERS, is_comment, run_end};
+use tree_sitter::Node;
+
+/// What every directive starts with
+pub const PREFIX: &str = "codanna:";
+
+/// A directive, as written after [`PREFIX`]
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum Directive {
+    IgnoreFile,
+    IgnoreNextSymbol,
+    /// Tags for the next symbol, lowercase
+    Tag(Vec<String>),
+}
+
+/// A directive as found in the source
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct DirectiveComment {
+    pub directive: Directive,
+    /// Zero-based, like symbol ranges
+    pub line: u32,
+    /// Last line of the run of comments holding it
+    pub end_line: u32,
+}
+
+/// Whether `code` may hold a directive, before parsing it for one
+pub fn may_contain_directive(code: &str) -> bool {
+    code.contains(PREFIX)
+}
+
+/// The directive a comment line starts with
+fn parse_line(line: &str) -> Option<Directive> {
+    let body = line.trim_start().trim_start_matches(MARKERS).trim_start();
+    let mut rest = body.strip_prefix(PREFIX)?;
+    for closer in CLOSERS {
+        rest = rest.strip_suffix(closer).unwrap_or(rest);
+    }
+    let word = rest.split_whitespace().next()?;
+    match word.split_once('=') {
+        Some(("tag", tags)) => {
+            let tags: Vec<String> = tags
+                .split(',')
+                .map(|tag| tag.trim().to_lowercase())
+                .filter(|tag| !tag.is_empty())
+                .collect();
+            (!tags.is_empty()).then_some(Directive::Tag(tags))
+        }
+        Some(_) => None,
+        None => match word {
+            "ignore-file" => Some(Directive::IgnoreFile),
+            "ignore-next-symbol" => Some(Directive::IgnoreNextSymbol),
+            _ => None,
+        },
+    }
+}
+
+/// The directives of the tree's comments, in source order
+pub fn extract_directives(root: Node, code: &str) -> Vec<DirectiveComment> {
+    let mut directives = Vec::new();
+    let mut cursor = root.walk();
+    let mut stack = vec![root];
+    while let Some(node) = stack.pop() {
+        if !is_comment(node) {
+            stack.extend(node.children(&mut cursor));
+            continue;
+        }
+        let Some(comment) = code.get(node.byte_range()) else {
+            continue;
+        };
+        let start = node.start_position().row;
+        let end_line = run_end(node);
+        for (offset, line) in comment.lines().enumerate() {
+            if let Some(directive) = parse_line(line) {
+                directives.push(DirectiveComment {
+                    directive,
+                    line: (start + offset) as u32,
+                    end_line,
+                });
+            }
+        }
+    }
+    directives.sort_by_key(|directive| directive.line);
+    directives
+}
+
+/// Which of the symbols at `ranges` the directive is about: the first
+/// starting below its comment, the outermost of those starting together,
+/// within the innermost symbol holding the comment
+pub fn next_symbol(directive: &DirectiveComment, ranges: &[Range]) -> Option<usize> {
+    let holds = |range: &Range, line: u32| range.start_line <= line && line <= range.end_line;
+    let container = ranges
+        .iter()
+        .filter(|range| holds(range, directive.line))
+        .min_by_key(|range| range.end_line.saturating_sub(range.start_line));
+    ranges
+        .iter()
+        .enumerate()
+        .filter(|(_, range)| {
+            range.start_line > directive.end_line
+                && container.is_none_or(|container| holds(container, range.start_line))
+        })
+        .min_by_key(|(_, range)| (range.start_line, std::cmp::Reverse(range.end_line)))
+        .map(|(index, _)| index)
+}
+
+/// Whether `inner` lies within `outer`
+pub fn within(inner: &Range, outer: &Range) -> bool {
+    (inner.start_line, inner.start_column) >= (outer.start_line, outer.start_column)
+        && (inner.end_line, inner.end_column) <= (outer.end_line, outer.end_column)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn extract(language: tree_sitter::Language, code: &str) -> Vec<DirectiveComment> {
+        let mut parser = tree_sitter::Parser::new();
+        parser.set_language(&language).unwrap();
+        let tree = parser.parse(code, None).unwrap();
+        extract_directives(tree.root_node(), code)
+    }
+
+    #[test]
+    fn test_directives_in_comments() {
+        let directives = extract(
+            tree_sitter_rust::LANGUAGE.into(),
+            r#"// codanna:ignore-file
+/// Old entry point
+// codanna:tag=Deprecated,internal
+fn start() {
+    let text = "// codanna:ignore-next-symbol";
+    /* codanna:ignore-next-symbol generated */
+}
+// codanna:tag=
+// codanna:unknown
+"#,
+        );
+
+        let found: Vec<(&Directive, u32, u32)> = directives
+            .iter()
+            .map(|d| (&d.directive, d.line, d.end_line))
+            .collect();
+        assert_eq!(
+            found,
+            [
+                (&Directive::IgnoreFile, 0, 2),
+                (
+                    &Directive::Tag(vec!["deprecated".to_string(), "internal".to_string()]),
+                    2,
+                    2
+                ),
+                (&Directive::IgnoreNextSymbol, 5, 5),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_python_hash_comments() {
+        let directives = extract(
+            tree_sitter_python::LANGUAGE.into(),
+            "# codanna:ignore-next-symbol\ndef generated():\n    pass\n",
+        );
+        assert_eq!(directives.len(), 1);
+        assert_eq!(directives[0].directive, Directive::IgnoreNextSymbol);
+    }
+
+    #[test]
+    fn test_next_symbol() {
+        let directive = |line: u32| DirectiveComment {
+            directive: Directive::IgnoreNextSymbol,
+            line,
+            end_line: line,
+        };
+        let ranges = [
+            Range::new(5, 0, 30, 1),  // impl
+            Range::new(5, 5, 5, 9),   // its name
+            Range::new(10, 4, 20, 5), // method
+            Range::new(40, 0, 50, 1), // function
+        ];
+
+        assert_eq!(
+            next_symbol(&directive(3), &ranges),
+            Some(0),
+            "the outermost"
+        );
+        assert_eq!(next_symbol(&directive(8), &ranges), Some(2));
+        assert_eq!(
+            next_symbol(&directive(25), &ranges),
+            None,
+            "not past its end"
+        );
+        assert_eq!(
+            next_symbol(&directive(32), &ranges),
+            Some(3),
+            "below a blank"
+        );
+        assert_eq!(next_symbol(&directive(55), &ranges), None);
+    }
+
+    #[test]
+    fn test_within() {
+        let outer = Range::new(5, 0, 30, 1);
+        assert!(within(&Range::new(10, 4, 20, 5), &outer));
+        assert!(within(&outer, &outer));
+        assert!(!within(&Range::new(28, 0, 31, 1), &outer));
+    }
+}
@@ -0,0 +1,215 @@
+//! Elixir-specific language behavior implementation
+//!
+//! Module names are declared by `defmodule`, but Mix projects mirror them
+//! in the file layout: `lib/my_app/accounts/user.ex` holds
+//! `MyApp.Accounts.User`. File paths below `lib` (or `test`) are camelized
+//! into that form so imports, which always name modules, match the files
+//! defining them.
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Elixir language behavior implementation
+#[derive(Clone)]
+pub struct ElixirBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl ElixirBehavior {
+    /// Create a new Elixir behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_elixir::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for ElixirBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for ElixirBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+/// `my_app` -> `MyApp`
+fn camelize(component: &str) -> String {
+    component
+        .split('_')
+        .map(|part| {
+            let mut chars = part.chars();
+            match chars.next() {
+                Some(first) => first.to_uppercase().chain(chars).collect(),
+                None => String::new(),
+            }
+        })
+        .collect()
+}
+
+impl LanguageBehavior for ElixirBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("elixir")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// `defp`, `defmacrop` and `defguardp` are private to their module
+    fn parse_visibility(&self, signature: &str) -> Visibility {
+        let keyword = signature.split_whitespace().next().unwrap_or("");
+        if matches!(keyword, "defp" | "defmacrop" | "defguardp") {
+            Visibility::Private
+        } else {
+            Visibility::Public
+        }
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "."
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &["lib", "test"]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(
+                components
+                    .iter()
+                    .map(|component| camelize(component))
+                    .collect::<Vec<_>>()
+                    .join("."),
+            )
+        }
+    }
+
+    fn supports_traits(&self) -> bool {
+        true // Protocols
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::ElixirResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    /// Imports already name modules; Erlang modules (`:ets`) are left out
+    fn normalize_import_path(
+        &self,
+        import_path: &str,
+        _importing_module: Option<&str>,
+        _file_path: &Path,
+    ) -> Option<String> {
+        if import_path.starts_with(':') {
+            return None;
+        }
+        Some(import_path.to_string())
+    }
+
+    /// `alias MyApp.Repo` names exactly one module
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        import_path == symbol_module_path
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+
+    /// Private definitions are only callable inside their own module,
+    /// which in practice is the file
+    fn is_symbol_visible_from_file(&self, symbol: &crate::Symbol, from_file: FileId) -> bool {
+        symbol.file_id == from_file || symbol.visibility != Visibility::Private
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_module_path_from_file() {
+        let behavior = ElixirBehavior::new();
+        let root = Path::new("/project");
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/lib/my_app/accounts/user.ex"),
+                root,
+                &["ex", "exs"]
+            ),
+            Some("MyApp.Accounts.User".to_string())
+        );
+    }
+
+    #[test]
+    fn test_normalize_import_path() {
+        let behavior = ElixirBehavior::new();
+        let file = Path::new("lib/my_app/user.ex");
+
+        assert_eq!(
+            behavior.normalize_import_path("MyApp.Repo", None, file),
+            Some("MyApp.Repo".to_string())
+        );
+        assert_eq!(behavior.normalize_import_path(":ets", None, file), None);
+    }
+
+    #[test]
+    fn test_parse_visibility() {
+        let behavior = ElixirBehavior::new();
+
+        assert_eq!(
+            behavior.parse_visibility("defp normalize(value)"),
+            Visibility::Private
+        );
+        assert_eq!(
+            behavior.parse_visibility("defmacrop build(ast)"),
+            Visibility::Private
+        );
+        assert_eq!(
+            behavior.parse_visibility("def fetch(id)"),
+            Visibility::Public
+        );
+        assert_eq!(
+            behavior.parse_visibility("defmodule MyApp.User"),
+            Visibility::Public
+        );
+    }
+}
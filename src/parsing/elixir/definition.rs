@@ -0,0 +1,89 @@
+//! Elixir language definition for the registry
+//!
+//! Provides the Elixir language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{ElixirBehavior, ElixirParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Elixir language definition
+pub struct ElixirLanguage;
+
+impl ElixirLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("elixir");
+}
+
+impl LanguageDefinition for ElixirLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Elixir"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["ex", "exs"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = ElixirParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(ElixirBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Elixir is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Elixir is enabled by default
+    }
+}
+
+/// Register Elixir language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(ElixirLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_elixir_definition() {
+        let elixir = ElixirLanguage;
+
+        assert_eq!(elixir.id(), LanguageId::new("elixir"));
+        assert_eq!(elixir.name(), "Elixir");
+        assert!(elixir.extensions().contains(&"ex"));
+    }
+
+    #[test]
+    fn test_elixir_enabled_by_default() {
+        let elixir = ElixirLanguage;
+        let settings = Settings::default();
+
+        assert!(elixir.default_enabled());
+        assert!(elixir.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_elixir_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("elixir")));
+    }
+}
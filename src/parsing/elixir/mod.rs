@@ -0,0 +1,20 @@
+//! Elixir language parser implementation
+//!
+//! Indexes modules, protocols, implementations, functions, macros and
+//! struct fields. `defimpl` records which protocols a type implements, and
+//! `use` is recorded as a relationship so modules built from `use`
+//! macros stay connected to the module providing them.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+pub mod resolution;
+
+pub use behavior::ElixirBehavior;
+pub use definition::ElixirLanguage;
+pub use parser::ElixirParser;
+pub use resolution::ElixirResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
@@ -0,0 +1,1167 @@
+//! Elixir language parser implementation
+//!
+//! Extracts symbols and relationships from Elixir source using
+//! tree-sitter-elixir.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | defmodule | Module |
+//! | defprotocol | Interface |
+//! | defimpl | Module (named `Protocol.Type`) |
+//! | def / defdelegate | Function (public) |
+//! | defp | Function (private) |
+//! | defmacro / defguard | Macro (`defmacrop` / `defguardp` are private) |
+//! | defstruct / defexception fields | Field |
+//!
+//! The grammar has no declaration nodes: every definition is a `call` whose
+//! target is the defining macro (`def`, `defmodule`, ...). Definitions are
+//! recognised by that target. A function with several clauses is indexed
+//! once per name and arity, from its first clause.
+//!
+//! Modules are named as written: `defmodule Inner` nested in `Outer` is
+//! indexed as `Inner`, matching how relationships name it. Definitions
+//! inside a module are attributed to it as class members.
+//!
+//! ## Relationships
+//!
+//! - `defimpl Proto, for: Type` implements `Proto` for `Type`; without
+//!   `for:` the enclosing module is the implementing type
+//! - `use Mod` is a uses relationship from the enclosing module. The
+//!   functions `use` generates through `__using__` are not visible to the
+//!   parser; the edge is what remains of them.
+//! - Modules and protocols define their functions and macros
+//! - `alias`, `import`, `require` and `use` are imports
+//!
+//! ## Calls
+//!
+//! `helper(x)` is a local call and `Repo.get(id)` a remote call on the
+//! `Repo` receiver. Pipes are followed, so `x |> normalize` calls
+//! `normalize` even without parentheses.
+//!
+//! ## Documentation
+//!
+//! `@moduledoc` and `@doc` strings are the doc comments of the module or
+//! the definition that follows them. `@doc false` hides a definition and
+//! leaves it undocumented.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, MethodCall, NodeTracker, NodeTrackingState,
+    ParserContext, ScopeType,
+};
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Kernel special forms and control flow that look like calls
+const ELIXIR_SPECIAL_FORMS: &[&str] = &[
+    "if",
+    "unless",
+    "case",
+    "cond",
+    "with",
+    "for",
+    "receive",
+    "try",
+    "quote",
+    "unquote",
+    "unquote_splicing",
+    "super",
+];
+
+/// Directives that bring other modules into scope
+const ELIXIR_DIRECTIVES: &[&str] = &["alias", "import", "require", "use"];
+
+/// Elixir-specific parsing errors
+#[derive(Error, Debug)]
+pub enum ElixirParseError {
+    #[error(
+        "Failed to initialize Elixir parser: {reason}\nSuggestion: Ensure tree-sitter-elixir is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// Elixir language parser
+pub struct ElixirParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+    /// `Module.name/arity` of functions already indexed from an earlier clause
+    seen_clauses: HashSet<String>,
+}
+
+impl std::fmt::Debug for ElixirParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("ElixirParser")
+            .field("language", &"Elixir")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Kind and visibility of a function-like definition macro
+fn definition_kind(keyword: &str) -> Option<(SymbolKind, Visibility)> {
+    match keyword {
+        "def" | "defdelegate" => Some((SymbolKind::Function, Visibility::Public)),
+        "defp" => Some((SymbolKind::Function, Visibility::Private)),
+        "defmacro" | "defguard" => Some((SymbolKind::Macro, Visibility::Public)),
+        "defmacrop" | "defguardp" => Some((SymbolKind::Macro, Visibility::Private)),
+        _ => None,
+    }
+}
+
+/// Target identifier of a plain call: `def` in `def foo`, `helper` in `helper(x)`
+fn call_keyword<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    if node.kind() != "call" {
+        return None;
+    }
+    let target = node.child_by_field_name("target")?;
+    (target.kind() == "identifier").then(|| &code[target.byte_range()])
+}
+
+fn child_of_kind<'a>(node: &Node<'a>, kind: &str) -> Option<Node<'a>> {
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find(|child| child.kind() == kind)
+}
+
+fn first_argument<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    child_of_kind(node, "arguments")?.named_child(0)
+}
+
+/// Value of `key:` in a call's trailing keyword list
+fn keyword_argument<'a>(node: &Node<'a>, key: &str, code: &str) -> Option<Node<'a>> {
+    let keywords = child_of_kind(&child_of_kind(node, "arguments")?, "keywords")?;
+    let mut cursor = keywords.walk();
+    keywords
+        .named_children(&mut cursor)
+        .filter(|pair| pair.kind() == "pair")
+        .find(|pair| {
+            pair.child_by_field_name("key")
+                .is_some_and(|k| keyword_text(&k, code) == key)
+        })
+        .and_then(|pair| pair.child_by_field_name("value"))
+}
+
+/// `for: ` -> `for`
+fn keyword_text<'a>(keyword: &Node, code: &'a str) -> &'a str {
+    code[keyword.byte_range()].trim().trim_end_matches(':')
+}
+
+/// Module name of `defmodule Name`, `defprotocol Name` or `alias Name`
+fn alias_argument<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    let first = first_argument(node)?;
+    (first.kind() == "alias").then(|| &code[first.byte_range()])
+}
+
+/// Module names in `for: Type` or `for: [A, B]`
+fn alias_list<'a>(node: &Node, code: &'a str) -> Vec<&'a str> {
+    match node.kind() {
+        "alias" => vec![&code[node.byte_range()]],
+        "list" => {
+            let mut cursor = node.walk();
+            node.named_children(&mut cursor)
+                .filter(|child| child.kind() == "alias")
+                .map(|child| &code[child.byte_range()])
+                .collect()
+        }
+        _ => Vec::new(),
+    }
+}
+
+/// The head of `def name(args) when guard`, without the guard
+fn function_head<'a>(argument: Node<'a>, code: &str) -> Node<'a> {
+    if argument.kind() == "binary_operator"
+        && argument
+            .child_by_field_name("operator")
+            .is_some_and(|op| &code[op.byte_range()] == "when")
+    {
+        if let Some(left) = argument.child_by_field_name("left") {
+            return left;
+        }
+    }
+    argument
+}
+
+/// Name and arity of a function head: `fetch(id)` or a bare `name`
+fn head_name_and_arity<'a>(head: &Node, code: &'a str) -> Option<(&'a str, usize)> {
+    match head.kind() {
+        "identifier" => Some((&code[head.byte_range()], 0)),
+        "call" => {
+            let name = call_keyword(head, code)?;
+            if name.starts_with("unquote") {
+                // Names computed at compile time
+                return None;
+            }
+            let arity = child_of_kind(head, "arguments")
+                .map(|args| args.named_child_count())
+                .unwrap_or(0);
+            Some((name, arity))
+        }
+        _ => None,
+    }
+}
+
+/// `@name value`: the attribute name and its value
+fn module_attribute<'a, 'b>(node: &Node<'b>, code: &'a str) -> Option<(&'a str, Option<Node<'b>>)> {
+    if node.kind() != "unary_operator" {
+        return None;
+    }
+    let operator = node.child_by_field_name("operator")?;
+    if &code[operator.byte_range()] != "@" {
+        return None;
+    }
+    let operand = node.child_by_field_name("operand")?;
+    match operand.kind() {
+        "identifier" => Some((&code[operand.byte_range()], None)),
+        "call" => Some((call_keyword(&operand, code)?, first_argument(&operand))),
+        _ => None,
+    }
+}
+
+/// Text of a `@doc` / `@moduledoc` string, heredoc or `~S` sigil
+///
+/// `@doc false` yields `None`.
+fn doc_text(value: &Node, code: &str) -> Option<String> {
+    if !matches!(value.kind(), "string" | "sigil") {
+        return None;
+    }
+    let mut content = String::new();
+    let mut cursor = value.walk();
+    for part in value.named_children(&mut cursor) {
+        if part.kind() == "quoted_content" {
+            content.push_str(&code[part.byte_range()]);
+        }
+    }
+
+    let lines: Vec<&str> = content.lines().map(str::trim).collect();
+    let doc = lines.join("\n").trim().to_string();
+    (!doc.is_empty()).then_some(doc)
+}
+
+/// Fields of `defstruct [:a, b: 1]` or `defstruct a: nil`: atoms and pairs
+fn struct_field_nodes<'a>(node: &Node<'a>, fields: &mut Vec<Node<'a>>) {
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        match child.kind() {
+            "atom" | "pair" => fields.push(child),
+            "list" | "keywords" => struct_field_nodes(&child, fields),
+            _ => {}
+        }
+    }
+}
+
+/// A call found in a function body
+struct ElixirCall<'a> {
+    callee: &'a str,
+    receiver: Option<&'a str>,
+    is_static: bool,
+    range: Range,
+}
+
+impl ElixirParser {
+    /// Create a new Elixir parser instance
+    pub fn new() -> Result<Self, ElixirParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_elixir::LANGUAGE.into())
+            .map_err(|e| ElixirParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+            seen_clauses: HashSet::new(),
+        })
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn create_symbol(
+        &self,
+        counter: &mut SymbolCounter,
+        name: &str,
+        kind: SymbolKind,
+        file_id: FileId,
+        range: Range,
+        signature: String,
+        doc_comment: Option<String>,
+        visibility: Visibility,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(counter.next_id(), name, kind, file_id, range)
+            .with_signature(signature)
+            .with_visibility(visibility);
+        if let Some(doc) = doc_comment {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(self.context.current_scope_context());
+        symbol
+    }
+
+    /// Extract symbols from AST node recursively
+    fn extract_symbols_from_node(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        if node.kind() == "call" {
+            match call_keyword(&node, code) {
+                Some("defmodule") => {
+                    self.register_handled_node(node.kind(), node.kind_id());
+                    self.process_module(
+                        node,
+                        SymbolKind::Module,
+                        code,
+                        file_id,
+                        counter,
+                        symbols,
+                        depth,
+                    );
+                    return;
+                }
+                Some("defprotocol") => {
+                    self.register_handled_node(node.kind(), node.kind_id());
+                    self.process_module(
+                        node,
+                        SymbolKind::Interface,
+                        code,
+                        file_id,
+                        counter,
+                        symbols,
+                        depth,
+                    );
+                    return;
+                }
+                Some("defimpl") => {
+                    self.register_handled_node(node.kind(), node.kind_id());
+                    self.process_impl(node, code, file_id, counter, symbols, depth);
+                    return;
+                }
+                Some("defstruct" | "defexception") => {
+                    self.register_handled_node(node.kind(), node.kind_id());
+                    self.process_struct(node, code, file_id, counter, symbols);
+                    return;
+                }
+                Some(keyword) => {
+                    if let Some((kind, visibility)) = definition_kind(keyword) {
+                        self.register_handled_node(node.kind(), node.kind_id());
+                        self.process_definition(
+                            node, keyword, kind, visibility, code, file_id, counter, symbols,
+                        );
+                        return;
+                    }
+                }
+                None => {}
+            }
+        }
+
+        match node.kind() {
+            // Attributes are read as documentation of what follows them
+            "unary_operator" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+            }
+            "comment" => {}
+            _ => {
+                self.extract_children(node, code, file_id, counter, symbols, depth);
+            }
+        }
+    }
+
+    fn extract_children(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            self.extract_symbols_from_node(child, code, file_id, counter, symbols, depth + 1);
+        }
+    }
+
+    /// Walk a module's `do` block with `owner` as the enclosing module
+    #[allow(clippy::too_many_arguments)]
+    fn process_body(
+        &mut self,
+        node: Node,
+        owner: &str,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(body) = child_of_kind(&node, "do_block") else {
+            return;
+        };
+        self.register_handled_node(body.kind(), body.kind_id());
+
+        let saved_class = self.context.current_class().map(|s| s.to_string());
+        self.context.enter_scope(ScopeType::Class);
+        self.context.set_current_class(Some(owner.to_string()));
+
+        self.extract_children(body, code, file_id, counter, symbols, depth);
+
+        self.context.exit_scope();
+        self.context.set_current_class(saved_class);
+    }
+
+    /// Source of a definition up to its `do` block: `defmodule MyApp.User`
+    fn header_signature(node: &Node, code: &str) -> String {
+        let end = child_of_kind(node, "do_block")
+            .map(|body| body.start_byte())
+            .unwrap_or(node.end_byte());
+        code[node.start_byte()..end].trim().to_string()
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn process_module(
+        &mut self,
+        node: Node,
+        kind: SymbolKind,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(name) = alias_argument(&node, code) else {
+            return;
+        };
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            Self::header_signature(&node, code),
+            self.extract_doc_comment(&node, code),
+            Visibility::Public,
+        );
+        symbols.push(symbol);
+
+        self.process_body(node, name, code, file_id, counter, symbols, depth);
+    }
+
+    /// `defimpl Proto, for: Type` defines the module `Proto.Type`
+    fn process_impl(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(protocol) = alias_argument(&node, code) else {
+            return;
+        };
+        let target = keyword_argument(&node, "for", code)
+            .and_then(|value| alias_list(&value, code).first().map(|t| t.to_string()))
+            .or_else(|| self.context.current_class().map(|s| s.to_string()));
+        let name = match target {
+            Some(target) => format!("{protocol}.{target}"),
+            None => protocol.to_string(),
+        };
+
+        let symbol = self.create_symbol(
+            counter,
+            &name,
+            SymbolKind::Module,
+            file_id,
+            range_from_node(&node),
+            Self::header_signature(&node, code),
+            self.extract_doc_comment(&node, code),
+            Visibility::Public,
+        );
+        symbols.push(symbol);
+
+        self.process_body(node, &name, code, file_id, counter, symbols, depth);
+    }
+
+    fn process_struct(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(arguments) = child_of_kind(&node, "arguments") else {
+            return;
+        };
+        let mut fields = Vec::new();
+        struct_field_nodes(&arguments, &mut fields);
+
+        for field in fields {
+            let name = match field.child_by_field_name("key") {
+                Some(key) => keyword_text(&key, code),
+                None => code[field.byte_range()].trim_start_matches(':'),
+            };
+            if name.is_empty() {
+                continue;
+            }
+            let symbol = self.create_symbol(
+                counter,
+                name,
+                SymbolKind::Field,
+                file_id,
+                range_from_node(&field),
+                code[field.byte_range()].to_string(),
+                None,
+                Visibility::Public,
+            );
+            symbols.push(symbol);
+        }
+    }
+
+    /// `def`, `defp`, `defmacro`, `defguard` and `defdelegate`
+    #[allow(clippy::too_many_arguments)]
+    fn process_definition(
+        &mut self,
+        node: Node,
+        keyword: &str,
+        kind: SymbolKind,
+        visibility: Visibility,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(argument) = first_argument(&node) else {
+            return;
+        };
+        let head = function_head(argument, code);
+        let Some((name, arity)) = head_name_and_arity(&head, code) else {
+            return;
+        };
+
+        // Later clauses of `fetch/1` extend the first one
+        let owner = self.context.current_class().unwrap_or_default();
+        if !self.seen_clauses.insert(format!("{owner}.{name}/{arity}")) {
+            return;
+        }
+
+        let signature = format!("{keyword} {}", &code[argument.byte_range()]);
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            signature,
+            self.extract_doc_comment(&node, code),
+            visibility,
+        );
+        symbols.push(symbol);
+    }
+
+    /// Walk calls made by definitions
+    ///
+    /// `visit` receives each call and the name of the definition making it.
+    fn walk_calls<'a>(
+        node: Node,
+        code: &'a str,
+        caller: Option<&'a str>,
+        visit: &mut impl FnMut(ElixirCall<'a>, Option<&'a str>),
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        match node.kind() {
+            // `@spec name(t) :: t` is not a call
+            "unary_operator" if module_attribute(&node, code).is_some() => return,
+            "call" => {
+                if let Some(keyword) = call_keyword(&node, code) {
+                    if ELIXIR_DIRECTIVES.contains(&keyword) || keyword == "defdelegate" {
+                        return;
+                    }
+                    if definition_kind(keyword).is_some() {
+                        Self::walk_definition_calls(node, code, visit, depth);
+                        return;
+                    }
+                    if let Some(caller) = caller {
+                        if !ELIXIR_SPECIAL_FORMS.contains(&keyword) {
+                            visit(
+                                ElixirCall {
+                                    callee: keyword,
+                                    receiver: None,
+                                    is_static: false,
+                                    range: range_from_node(&node),
+                                },
+                                Some(caller),
+                            );
+                        }
+                    }
+                } else if let Some(call) = Self::remote_call(&node, code) {
+                    if caller.is_some() {
+                        visit(call, caller);
+                    }
+                }
+            }
+            "binary_operator" => {
+                // `value |> normalize`: a pipe into a bare name is a call
+                let is_pipe = node
+                    .child_by_field_name("operator")
+                    .is_some_and(|op| &code[op.byte_range()] == "|>");
+                if let (true, Some(caller), Some(right)) =
+                    (is_pipe, caller, node.child_by_field_name("right"))
+                {
+                    if right.kind() == "identifier" {
+                        visit(
+                            ElixirCall {
+                                callee: &code[right.byte_range()],
+                                receiver: None,
+                                is_static: false,
+                                range: range_from_node(&right),
+                            },
+                            Some(caller),
+                        );
+                    }
+                }
+            }
+            _ => {}
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::walk_calls(child, code, caller, visit, depth + 1);
+        }
+    }
+
+    /// Calls in a definition's guard and body; the head itself is skipped
+    fn walk_definition_calls<'a>(
+        node: Node,
+        code: &'a str,
+        visit: &mut impl FnMut(ElixirCall<'a>, Option<&'a str>),
+        depth: usize,
+    ) {
+        let Some(arguments) = child_of_kind(&node, "arguments") else {
+            return;
+        };
+        let Some(argument) = arguments.named_child(0) else {
+            return;
+        };
+        let head = function_head(argument, code);
+        let Some((name, _)) = head_name_and_arity(&head, code) else {
+            return;
+        };
+
+        if head.id() != argument.id() {
+            // `when is_integer(id)`
+            if let Some(guard) = argument.child_by_field_name("right") {
+                Self::walk_calls(guard, code, Some(name), visit, depth + 1);
+            }
+        }
+
+        // `do: expr` keyword bodies follow the head
+        let mut cursor = arguments.walk();
+        for rest in arguments.named_children(&mut cursor).skip(1) {
+            Self::walk_calls(rest, code, Some(name), visit, depth + 1);
+        }
+        if let Some(body) = child_of_kind(&node, "do_block") {
+            Self::walk_calls(body, code, Some(name), visit, depth + 1);
+        }
+    }
+
+    /// `Repo.get(id)`, `:ets.new(...)`, `__MODULE__.helper()`
+    fn remote_call<'a>(node: &Node, code: &'a str) -> Option<ElixirCall<'a>> {
+        let target = node.child_by_field_name("target")?;
+        if target.kind() != "dot" {
+            return None;
+        }
+        let left = target.child_by_field_name("left")?;
+        let right = target.child_by_field_name("right")?;
+        if right.kind() != "identifier" {
+            return None;
+        }
+        // `user.name` without parentheses is a field access
+        if left.kind() != "alias" && child_of_kind(node, "arguments").is_none() {
+            return None;
+        }
+
+        let receiver = &code[left.byte_range()];
+        Some(ElixirCall {
+            callee: &code[right.byte_range()],
+            receiver: Some(receiver),
+            is_static: matches!(left.kind(), "alias" | "atom") || receiver == "__MODULE__",
+            range: range_from_node(node),
+        })
+    }
+
+    /// Walk module definitions, tracking the enclosing module name
+    ///
+    /// `visit` receives each `call` node inside a module body together with
+    /// the innermost module's name.
+    fn walk_modules<'a>(
+        node: Node<'a>,
+        code: &'a str,
+        module: Option<&'a str>,
+        visit: &mut impl FnMut(Node<'a>, Option<&'a str>),
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut module = module;
+        if node.kind() == "call" {
+            visit(node, module);
+            match call_keyword(&node, code) {
+                Some("defmodule" | "defprotocol") => {
+                    module = alias_argument(&node, code).or(module);
+                }
+                // `Proto.Type` is not spelled out in the source
+                Some("defimpl") => module = None,
+                Some(keyword) if definition_kind(keyword).is_some() => return,
+                _ => {}
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::walk_modules(child, code, module, visit, depth + 1);
+        }
+    }
+
+    fn extract_imports_from_node(
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        imports: &mut Vec<Import>,
+    ) {
+        Self::walk_modules(
+            node,
+            code,
+            None,
+            &mut |call, _| {
+                let Some(directive) = call_keyword(&call, code) else {
+                    return;
+                };
+                if !ELIXIR_DIRECTIVES.contains(&directive) {
+                    return;
+                }
+                let Some(argument) = first_argument(&call) else {
+                    return;
+                };
+                let explicit_alias = keyword_argument(&call, "as", code)
+                    .filter(|value| value.kind() == "alias")
+                    .map(|value| code[value.byte_range()].to_string());
+
+                let paths: Vec<String> = match argument.kind() {
+                    "alias" => vec![code[argument.byte_range()].to_string()],
+                    // `alias MyApp.{Repo, Mailer}`
+                    "dot" => {
+                        let (Some(left), Some(right)) = (
+                            argument.child_by_field_name("left"),
+                            argument.child_by_field_name("right"),
+                        ) else {
+                            return;
+                        };
+                        if right.kind() != "tuple" {
+                            return;
+                        }
+                        let base = &code[left.byte_range()];
+                        let mut cursor = right.walk();
+                        right
+                            .named_children(&mut cursor)
+                            .filter(|child| child.kind() == "alias")
+                            .map(|child| format!("{base}.{}", &code[child.byte_range()]))
+                            .collect()
+                    }
+                    _ => return,
+                };
+
+                let multiple = paths.len() > 1;
+                for path in paths {
+                    let alias = match directive {
+                        // `alias A.B` binds `B` unless renamed
+                        "alias" if !multiple => explicit_alias
+                            .clone()
+                            .or_else(|| path.rsplit('.').next().map(str::to_string)),
+                        "alias" => path.rsplit('.').next().map(str::to_string),
+                        "require" => explicit_alias.clone(),
+                        _ => None,
+                    };
+                    let is_glob = match directive {
+                        "import" => keyword_argument(&call, "only", code).is_none(),
+                        "use" => true,
+                        _ => false,
+                    };
+                    imports.push(Import {
+                        path,
+                        alias,
+                        file_id,
+                        is_glob,
+                        is_type_only: false,
+                    });
+                }
+            },
+            0,
+        );
+    }
+}
+
+impl LanguageParser for ElixirParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+        self.seen_clauses.clear();
+
+        let mut symbols = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            self.extract_symbols_from_node(
+                tree.root_node(),
+                code,
+                file_id,
+                symbol_counter,
+                &mut symbols,
+                0,
+            );
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// `@moduledoc` inside a module, or the `@doc` before a definition
+    ///
+    /// Other attributes such as `@spec` and `@impl` may sit between the
+    /// `@doc` and the definition.
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        if matches!(
+            call_keyword(node, code),
+            Some("defmodule" | "defprotocol" | "defimpl")
+        ) {
+            let body = child_of_kind(node, "do_block")?;
+            let mut cursor = body.walk();
+            return body
+                .named_children(&mut cursor)
+                .filter_map(|child| module_attribute(&child, code))
+                .find(|(name, _)| *name == "moduledoc")
+                .and_then(|(_, value)| doc_text(&value?, code));
+        }
+
+        let mut current = node.prev_named_sibling();
+        while let Some(prev) = current {
+            match module_attribute(&prev, code) {
+                Some(("doc", value)) => return doc_text(&value?, code),
+                Some(_) => {}
+                None if prev.kind() == "comment" => {}
+                None => break,
+            }
+            current = prev.prev_named_sibling();
+        }
+        None
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::walk_calls(
+                tree.root_node(),
+                code,
+                None,
+                &mut |call, caller| {
+                    if let (Some(caller), None) = (caller, call.receiver) {
+                        calls.push((caller, call.callee, call.range));
+                    }
+                },
+                0,
+            );
+        }
+        calls
+    }
+
+    fn find_method_calls(&mut self, code: &str) -> Vec<MethodCall> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::walk_calls(
+                tree.root_node(),
+                code,
+                None,
+                &mut |call, caller| {
+                    let (Some(caller), Some(receiver)) = (caller, call.receiver) else {
+                        return;
+                    };
+                    let mut method_call =
+                        MethodCall::new(caller, call.callee, call.range).with_receiver(receiver);
+                    if call.is_static {
+                        method_call = method_call.static_method();
+                    }
+                    calls.push(method_call);
+                },
+                0,
+            );
+        }
+        calls
+    }
+
+    /// `defimpl Proto, for: Type` as (Type, Proto)
+    fn find_implementations<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut implementations = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::walk_modules(
+                tree.root_node(),
+                code,
+                None,
+                &mut |call, module| {
+                    if call_keyword(&call, code) != Some("defimpl") {
+                        return;
+                    }
+                    let Some(protocol) = alias_argument(&call, code) else {
+                        return;
+                    };
+                    let targets = match keyword_argument(&call, "for", code) {
+                        Some(value) => alias_list(&value, code),
+                        None => module.into_iter().collect(),
+                    };
+                    for target in targets {
+                        implementations.push((target, protocol, range_from_node(&call)));
+                    }
+                },
+                0,
+            );
+        }
+        implementations
+    }
+
+    /// `use Mod` inside a module
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut uses = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::walk_modules(
+                tree.root_node(),
+                code,
+                None,
+                &mut |call, module| {
+                    if call_keyword(&call, code) != Some("use") {
+                        return;
+                    }
+                    if let (Some(module), Some(used)) = (module, alias_argument(&call, code)) {
+                        uses.push((module, used, range_from_node(&call)));
+                    }
+                },
+                0,
+            );
+        }
+        uses
+    }
+
+    /// Modules and protocols define their functions and macros
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut defines: Vec<(&'a str, &'a str, Range)> = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::walk_modules(
+                tree.root_node(),
+                code,
+                None,
+                &mut |call, module| {
+                    let Some(module) = module else {
+                        return;
+                    };
+                    if !call_keyword(&call, code).is_some_and(|k| definition_kind(k).is_some()) {
+                        return;
+                    }
+                    let Some(argument) = first_argument(&call) else {
+                        return;
+                    };
+                    let head = function_head(argument, code);
+                    let Some((name, _)) = head_name_and_arity(&head, code) else {
+                        return;
+                    };
+                    // One edge per function, not per clause
+                    if !defines
+                        .iter()
+                        .any(|(from, to, _)| *from == module && *to == name)
+                    {
+                        defines.push((module, name, range_from_node(&call)));
+                    }
+                },
+                0,
+            );
+        }
+        defines
+    }
+
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let mut imports = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_imports_from_node(tree.root_node(), code, file_id, &mut imports);
+        }
+        imports
+    }
+
+    fn language(&self) -> Language {
+        Language::Elixir
+    }
+}
+
+impl NodeTracker for ElixirParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = ElixirParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(ElixirParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_module_functions() {
+        let code = r#"
+defmodule Math do
+  def add(a, b), do: a + b
+  defp check(x) when x > 0, do: x
+  defmacro twice(expr), do: expr
+end
+"#;
+        let symbols = parse(code);
+
+        assert_eq!(find(&symbols, "Math").kind, SymbolKind::Module);
+
+        let add = find(&symbols, "add");
+        assert_eq!(add.kind, SymbolKind::Function);
+        assert_eq!(add.visibility, Visibility::Public);
+        assert_eq!(add.signature.as_deref(), Some("def add(a, b)"));
+
+        let check = find(&symbols, "check");
+        assert_eq!(check.visibility, Visibility::Private);
+        assert_eq!(check.signature.as_deref(), Some("defp check(x) when x > 0"));
+
+        assert_eq!(find(&symbols, "twice").kind, SymbolKind::Macro);
+    }
+
+    #[test]
+    fn test_clauses_are_indexed_once() {
+        let code = r#"
+defmodule Fact do
+  def of(0), do: 1
+  def of(n), do: n * of(n - 1)
+  def of(n, acc), do: n * acc
+end
+"#;
+        let symbols = parse(code);
+        let clauses = symbols.iter().filter(|s| s.name.as_ref() == "of").count();
+        // `of/1` once, `of/2` once
+        assert_eq!(clauses, 2);
+    }
+
+    #[test]
+    fn test_doc_false_hides_documentation() {
+        let code = r#"
+defmodule Docs do
+  @doc false
+  def internal, do: :ok
+
+  @doc "Visible"
+  @spec visible() :: :ok
+  def visible, do: :ok
+end
+"#;
+        let symbols = parse(code);
+
+        assert_eq!(find(&symbols, "internal").doc_comment, None);
+        assert_eq!(
+            find(&symbols, "visible").doc_comment.as_deref(),
+            Some("Visible")
+        );
+    }
+
+    #[test]
+    fn test_struct_fields() {
+        let symbols = parse("defmodule Point do\n  defstruct [:x, y: 0]\nend\n");
+
+        for field in ["x", "y"] {
+            let symbol = find(&symbols, field);
+            assert_eq!(symbol.kind, SymbolKind::Field);
+        }
+    }
+
+    #[test]
+    fn test_pipe_into_bare_name_is_a_call() {
+        let code = r#"
+defmodule Pipeline do
+  def run(input) do
+    input |> trim |> String.upcase()
+  end
+end
+"#;
+        let mut parser = ElixirParser::new().unwrap();
+
+        let calls = parser.find_calls(code);
+        assert!(
+            calls
+                .iter()
+                .any(|(caller, callee, _)| *caller == "run" && *callee == "trim")
+        );
+
+        let method_calls = parser.find_method_calls(code);
+        let upcase = method_calls
+            .iter()
+            .find(|c| c.method_name == "upcase")
+            .expect("String.upcase should be a remote call");
+        assert_eq!(upcase.receiver.as_deref(), Some("String"));
+        assert!(upcase.is_static);
+    }
+}
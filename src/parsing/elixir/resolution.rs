@@ -0,0 +1,267 @@
+//! Elixir-specific symbol resolution
+//!
+//! Elixir resolves names through:
+//! - Variables and parameters of the current function clause
+//! - Functions and macros of the enclosing module
+//! - Functions brought in by `import` and modules renamed by `alias`
+
+use crate::parsing::resolution::{ImportBinding, ImportOrigin, default_compatible_relationship};
+use crate::parsing::{Import, ResolutionScope, ScopeLevel, ScopeType};
+use crate::{FileId, RelationKind, SymbolId};
+use std::collections::HashMap;
+
+/// Elixir resolution context
+///
+/// Tracks local, module-level and imported scopes. Remote references
+/// (`Repo.get`) fall back to their last segment.
+pub struct ElixirResolutionContext {
+    #[allow(dead_code)]
+    file_id: FileId,
+
+    /// Function locals and parameters
+    local_scope: HashMap<String, SymbolId>,
+
+    /// Modules and module functions in this file
+    module_scope: HashMap<String, SymbolId>,
+
+    /// Symbols made available by `import` and `use`
+    imported_scope: HashMap<String, SymbolId>,
+
+    /// Scope stack for nested contexts
+    scope_stack: Vec<ScopeType>,
+
+    /// Import bindings keyed by visible name
+    import_bindings: HashMap<String, ImportBinding>,
+}
+
+impl ElixirResolutionContext {
+    pub fn new(file_id: FileId) -> Self {
+        Self {
+            file_id,
+            local_scope: HashMap::new(),
+            module_scope: HashMap::new(),
+            imported_scope: HashMap::new(),
+            scope_stack: Vec::new(),
+            import_bindings: HashMap::new(),
+        }
+    }
+}
+
+impl ResolutionScope for ElixirResolutionContext {
+    fn as_any_mut(&mut self) -> &mut dyn std::any::Any {
+        self
+    }
+
+    fn resolve(&self, name: &str) -> Option<SymbolId> {
+        if let Some(&id) = self.local_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.module_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.imported_scope.get(name) {
+            return Some(id);
+        }
+
+        // `Repo.get` / `MyApp.Accounts.User` -> last segment
+        if let Some((_, last)) = name.rsplit_once('.') {
+            if !last.is_empty() {
+                return self.resolve(last);
+            }
+        }
+
+        None
+    }
+
+    fn add_symbol(&mut self, name: String, symbol_id: SymbolId, scope_level: ScopeLevel) {
+        match scope_level {
+            ScopeLevel::Local => {
+                self.local_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Module | ScopeLevel::Global => {
+                self.module_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Package => {
+                self.imported_scope.insert(name, symbol_id);
+            }
+        }
+    }
+
+    fn enter_scope(&mut self, scope_type: ScopeType) {
+        self.scope_stack.push(scope_type);
+    }
+
+    fn exit_scope(&mut self) {
+        if let Some(scope) = self.scope_stack.pop() {
+            if matches!(scope, ScopeType::Function { .. }) {
+                self.clear_local_scope();
+            }
+        }
+    }
+
+    fn clear_local_scope(&mut self) {
+        self.local_scope.clear();
+    }
+
+    fn symbols_in_scope(&self) -> Vec<(String, SymbolId, ScopeLevel)> {
+        let mut symbols = Vec::new();
+
+        for (name, &id) in &self.local_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Local));
+        }
+        for (name, &id) in &self.module_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Module));
+        }
+        for (name, &id) in &self.imported_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Package));
+        }
+
+        symbols
+    }
+
+    fn resolve_relationship(
+        &self,
+        _from_name: &str,
+        to_name: &str,
+        _kind: RelationKind,
+        _from_file: FileId,
+    ) -> Option<SymbolId> {
+        self.resolve(to_name)
+    }
+
+    fn populate_imports(&mut self, imports: &[Import]) {
+        for import in imports {
+            // `alias` binds the short module name; `import` and `use` expose
+            // functions, resolved via the index
+            let Some(alias) = import.alias.clone() else {
+                continue;
+            };
+            self.import_bindings.insert(
+                alias.clone(),
+                ImportBinding {
+                    import: import.clone(),
+                    exposed_name: alias,
+                    origin: ImportOrigin::Unknown,
+                    resolved_symbol: None,
+                },
+            );
+        }
+    }
+
+    fn register_import_binding(&mut self, binding: ImportBinding) {
+        self.import_bindings
+            .insert(binding.exposed_name.clone(), binding);
+    }
+
+    fn import_binding(&self, name: &str) -> Option<ImportBinding> {
+        self.import_bindings.get(name).cloned()
+    }
+
+    /// Protocols are implemented by modules (`defimpl ..., for: Module`)
+    /// and `use` links two modules.
+    fn is_compatible_relationship(
+        &self,
+        from_kind: crate::SymbolKind,
+        to_kind: crate::SymbolKind,
+        rel_kind: crate::RelationKind,
+    ) -> bool {
+        use crate::RelationKind::*;
+        use crate::SymbolKind::*;
+
+        match rel_kind {
+            Implements => matches!(from_kind, Module | Struct) && to_kind == Interface,
+            ImplementedBy => from_kind == Interface && matches!(to_kind, Module | Struct),
+            Uses if from_kind == Module && to_kind == Module => true,
+            UsedBy if from_kind == Module && to_kind == Module => true,
+            Defines => {
+                let container = matches!(from_kind, Module | Interface);
+                let member = matches!(to_kind, Function | Macro | Field);
+                container && member
+            }
+            _ => default_compatible_relationship(from_kind, to_kind, rel_kind),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::SymbolKind;
+
+    #[test]
+    fn test_scope_resolution_order() {
+        let mut context = ElixirResolutionContext::new(FileId::new(1).unwrap());
+
+        let local_id = SymbolId::new(1).unwrap();
+        let module_id = SymbolId::new(2).unwrap();
+        let import_id = SymbolId::new(3).unwrap();
+
+        context.add_symbol("x".to_string(), import_id, ScopeLevel::Package);
+        context.add_symbol("x".to_string(), module_id, ScopeLevel::Module);
+        context.add_symbol("x".to_string(), local_id, ScopeLevel::Local);
+
+        assert_eq!(context.resolve("x"), Some(local_id));
+        context.clear_local_scope();
+        assert_eq!(context.resolve("x"), Some(module_id));
+    }
+
+    #[test]
+    fn test_remote_name_falls_back_to_last_segment() {
+        let mut context = ElixirResolutionContext::new(FileId::new(1).unwrap());
+        let id = SymbolId::new(7).unwrap();
+        context.add_symbol("get".to_string(), id, ScopeLevel::Module);
+
+        assert_eq!(context.resolve("Repo.get"), Some(id));
+    }
+
+    #[test]
+    fn test_only_aliases_bind_names() {
+        let mut context = ElixirResolutionContext::new(FileId::new(1).unwrap());
+        let file_id = FileId::new(1).unwrap();
+        context.populate_imports(&[
+            Import {
+                path: "MyApp.Repo".to_string(),
+                alias: Some("Repo".to_string()),
+                file_id,
+                is_glob: false,
+                is_type_only: false,
+            },
+            Import {
+                path: "Ecto.Changeset".to_string(),
+                alias: None,
+                file_id,
+                is_glob: true,
+                is_type_only: false,
+            },
+        ]);
+
+        assert!(context.import_binding("Repo").is_some());
+        assert!(context.import_binding("Changeset").is_none());
+    }
+
+    #[test]
+    fn test_modules_implement_protocols_and_use_modules() {
+        let context = ElixirResolutionContext::new(FileId::new(1).unwrap());
+
+        assert!(context.is_compatible_relationship(
+            SymbolKind::Module,
+            SymbolKind::Interface,
+            RelationKind::Implements
+        ));
+        assert!(!context.is_compatible_relationship(
+            SymbolKind::Module,
+            SymbolKind::Module,
+            RelationKind::Implements
+        ));
+        assert!(context.is_compatible_relationship(
+            SymbolKind::Module,
+            SymbolKind::Module,
+            RelationKind::Uses
+        ));
+        assert!(context.is_compatible_relationship(
+            SymbolKind::Function,
+            SymbolKind::Macro,
+            RelationKind::Calls
+        ));
+    }
+}
@@ -0,0 +1,429 @@
+//! Code of one language embedded in a file of another
+//!
+//! | Host | Embedded | Found in |
+//! |------|----------|----------|
+//! | HTML | JavaScript, TypeScript | `<script>` elements without `src` |
+//! | Rust | Rust | fenced examples of `///` and `//!` doc comments |
+//! | any but SQL | SQL | string literals starting with `CREATE` |
+//!
+//! A block is found as the byte ranges of its code in the host file.
+//! [`mask`](crate::parsing::sfc::mask) blanks everything else while keeping
+//! line breaks, so the embedded language's parser reports positions in the
+//! host file: the symbols of a block are indexed in the host file, at the
+//! lines they are written on, under the embedded language. The indexer
+//! looks for blocks when `indexing.embedded_languages` is set.
+//!
+//! Doc examples are read as rustdoc reads them: a fence without a language
+//! or with rustdoc attributes only is Rust, `compile_fail` examples are
+//! skipped, and the hidden lines (`# use std::fmt;`) are part of the code.
+//! Other embeddings, YAML in Helm templates among them, have no grammar in
+//! the index to parse them.
+
+use crate::parsing::LanguageId;
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::sfc::{self, Block};
+use crate::parsing::sql::embedded::{definition_start, is_string_literal};
+use std::ops::Range;
+use tree_sitter::Node;
+
+/// Info string words of a rustdoc fence that keep it Rust
+const RUSTDOC_ATTRIBUTES: &[&str] = &[
+    "rust",
+    "ignore",
+    "should_panic",
+    "no_run",
+    "test_harness",
+    "standalone_crate",
+    "edition2015",
+    "edition2018",
+    "edition2021",
+    "edition2024",
+];
+
+/// Words a SQL definition names after `CREATE`
+const DEFINED_OBJECTS: &[&str] = &[
+    "table",
+    "view",
+    "index",
+    "unique",
+    "function",
+    "procedure",
+    "trigger",
+    "type",
+    "schema",
+    "sequence",
+    "materialized",
+    "temp",
+    "temporary",
+    "or",
+];
+
+/// Code of another language in a host file
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct EmbeddedBlock {
+    pub language: LanguageId,
+    /// Byte ranges of the code in the host file, in order
+    pub segments: Vec<Range<usize>>,
+}
+
+impl EmbeddedBlock {
+    /// The host file's text with everything but the block blanked
+    pub fn mask(&self, code: &str) -> String {
+        sfc::mask(code, &self.segments)
+    }
+}
+
+/// Language of a script element, None for one with a `src` or of a type
+/// that is not code (`application/json`, `importmap`, templates)
+fn script_language(block: &Block) -> Option<LanguageId> {
+    if block.attribute("src").is_some() {
+        return None;
+    }
+    if let Some(lang) = block.attribute("lang") {
+        return match lang.to_ascii_lowercase().as_str() {
+            "ts" | "typescript" => Some(LanguageId::new("typescript")),
+            "js" | "javascript" => Some(LanguageId::new("javascript")),
+            _ => None,
+        };
+    }
+    match block
+        .attribute("type")
+        .map(|kind| kind.trim().to_ascii_lowercase())
+        .as_deref()
+    {
+        None | Some("" | "module" | "text/javascript" | "application/javascript") => {
+            Some(LanguageId::new("javascript"))
+        }
+        Some("text/typescript" | "application/typescript") => Some(LanguageId::new("typescript")),
+        Some(_) => None,
+    }
+}
+
+/// The scripts of an HTML document, in document order
+pub fn html_scripts(code: &str) -> Vec<EmbeddedBlock> {
+    let mut blocks = Vec::new();
+    let mut cursor = 0;
+
+    while let Some(found) = code[cursor..].find('<') {
+        let start = cursor + found;
+        if code[start..].starts_with("<!--") {
+            cursor = code[start..]
+                .find("-->")
+                .map_or(code.len(), |end| start + end + 3);
+            continue;
+        }
+        if !sfc::is_tag_at(code, start + 1, "script") {
+            cursor = start + 1;
+            continue;
+        }
+        let Some(open_end) = sfc::tag_end(code, start) else {
+            break;
+        };
+        let open_tag = &code[start..open_end];
+        if open_tag.ends_with("/>") {
+            cursor = open_end;
+            continue;
+        }
+        let (content_end, end) =
+            sfc::closing_tag(code, open_end, "script").unwrap_or((code.len(), code.len()));
+        let block = Block {
+            tag: "script".to_string(),
+            open_tag,
+            content: open_end..content_end,
+            span: start..end,
+        };
+        if let Some(language) = script_language(&block) {
+            if !code[block.content.clone()].trim().is_empty() {
+                blocks.push(EmbeddedBlock {
+                    language,
+                    segments: vec![block.content],
+                });
+            }
+        }
+        cursor = end;
+    }
+
+    blocks
+}
+
+/// The doc comment prefix a line starts with, and the offset of its text
+fn doc_line(line: &str) -> Option<(&'static str, usize)> {
+    let indent = line.len() - line.trim_start().len();
+    let rest = &line[indent..];
+    let prefix = ["///", "//!"]
+        .into_iter()
+        .find(|prefix| rest.starts_with(prefix))?;
+    // `////` is a plain comment
+    if rest[prefix.len()..].starts_with('/') {
+        return None;
+    }
+    Some((prefix, indent + prefix.len()))
+}
+
+/// Whether the info string of a doc comment fence makes it a Rust example
+fn is_rust_example(info: &str) -> bool {
+    let words: Vec<&str> = info
+        .split([',', ' ', '\t'])
+        .filter(|word| !word.is_empty())
+        .collect();
+    !words.contains(&"compile_fail")
+        && words.iter().all(|word| {
+            RUSTDOC_ATTRIBUTES.contains(word)
+                || word.starts_with("ignore-")
+                || word.starts_with('{')
+        })
+}
+
+/// A fenced block of doc comment lines being read
+struct Fence {
+    /// `///` or `//!`: the example ends with its comment
+    prefix: &'static str,
+    /// The opening fence, which closes it
+    fence: &'static str,
+    is_rust: bool,
+    segments: Vec<Range<usize>>,
+}
+
+impl Fence {
+    fn into_block(self) -> Option<EmbeddedBlock> {
+        (self.is_rust && !self.segments.is_empty()).then(|| EmbeddedBlock {
+            language: crate::parsing::rust::RustLanguage::ID,
+            segments: self.segments,
+        })
+    }
+}
+
+/// The examples of a Rust file's doc comments, in source order
+pub fn rust_doc_examples(code: &str) -> Vec<EmbeddedBlock> {
+    let mut blocks = Vec::new();
+    let mut open: Option<Fence> = None;
+    let mut offset = 0;
+
+    for line in code.split_inclusive('\n') {
+        let start = offset;
+        offset += line.len();
+        let line = line.trim_end_matches(['\n', '\r']);
+        let doc = doc_line(line);
+
+        // An example ends with its fence or, left open, with its comment
+        if let Some(fence) = open.take() {
+            match doc {
+                Some((prefix, text_start)) if prefix == fence.prefix => {
+                    let text = &line[text_start..];
+                    let trimmed = text.trim_start();
+                    if trimmed.starts_with(fence.fence) {
+                        blocks.extend(fence.into_block());
+                        continue;
+                    }
+                    let mut fence = fence;
+                    let body = match trimmed.strip_prefix('#') {
+                        // A hidden line of the example
+                        Some("") => None,
+                        Some(rest) if rest.starts_with(' ') => Some(text.len() - rest.len() + 1),
+                        _ => Some(0),
+                    };
+                    if let Some(body) = body {
+                        let from = start + text_start + body;
+                        let to = start + line.len();
+                        if from < to {
+                            fence.segments.push(from..to);
+                        }
+                    }
+                    open = Some(fence);
+                    continue;
+                }
+                _ => blocks.extend(fence.into_block()),
+            }
+        }
+
+        let Some((prefix, text_start)) = doc else {
+            continue;
+        };
+        let trimmed = line[text_start..].trim_start();
+        if let Some(fence) = ["```", "~~~"]
+            .into_iter()
+            .find(|fence| trimmed.starts_with(fence))
+        {
+            let info = trimmed.trim_start_matches(['`', '~']).trim();
+            open = Some(Fence {
+                prefix,
+                fence,
+                is_rust: is_rust_example(info),
+                segments: Vec::new(),
+            });
+        }
+    }
+    blocks.extend(open.and_then(Fence::into_block));
+
+    blocks
+}
+
+/// Cheap check whether a file may hold a SQL definition in a string, to
+/// skip the literal walk for most files
+pub fn may_contain_sql_definition(code: &str) -> bool {
+    let lower = code.to_ascii_lowercase();
+    lower.match_indices("create ").any(|(at, phrase)| {
+        let object = lower[at + phrase.len()..]
+            .trim_start()
+            .split(|c: char| !c.is_ascii_alphabetic())
+            .next()
+            .unwrap_or("");
+        DEFINED_OBJECTS.contains(&object)
+    })
+}
+
+/// The SQL definitions of `root`'s string literals, each literal a block;
+/// interpolations (`${table}`) are left out of it
+pub fn sql_definitions(root: Node, code: &str) -> Vec<EmbeddedBlock> {
+    let mut blocks = Vec::new();
+    walk_literals(root, code, &mut blocks, 0);
+    blocks
+}
+
+fn walk_literals(node: Node, code: &str, blocks: &mut Vec<EmbeddedBlock>, depth: usize) {
+    if !check_recursion_depth(depth, node) {
+        return;
+    }
+
+    if is_string_literal(node.kind()) {
+        let text = &code[node.byte_range()];
+        if let Some(start) = definition_start(text) {
+            let body = text.trim_end_matches(['"', '\'', '`', '#']);
+            let mut from = node.start_byte() + start;
+            let end = node.start_byte() + body.len();
+            let mut segments = Vec::new();
+            let mut cursor = node.walk();
+            for child in node.named_children(&mut cursor) {
+                let kind = child.kind();
+                if (kind.contains("substitution") || kind.contains("interpolation"))
+                    && child.start_byte() >= from
+                {
+                    segments.push(from..child.start_byte());
+                    from = child.end_byte();
+                }
+            }
+            if from < end {
+                segments.push(from..end);
+            }
+            segments.retain(|segment| !segment.is_empty());
+            blocks.push(EmbeddedBlock {
+                language: crate::parsing::sql::SqlLanguage::ID,
+                segments,
+            });
+        }
+        return;
+    }
+
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        walk_literals(child, code, blocks, depth + 1);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn texts(code: &str, blocks: &[EmbeddedBlock]) -> Vec<String> {
+        blocks
+            .iter()
+            .map(|block| {
+                block
+                    .segments
+                    .iter()
+                    .map(|segment| &code[segment.clone()])
+                    .collect::<Vec<_>>()
+                    .join("|")
+            })
+            .collect()
+    }
+
+    #[test]
+    fn test_html_scripts() {
+        let code = r#"<html>
+<head>
+  <script src="/vendor.js"></script>
+  <script type="application/json">{"debug": true}</script>
+  <!-- <script>disabled()</script> -->
+  <script>
+    function start() { render(); }
+  </script>
+  <script lang="ts">const count: number = 1;</script>
+</head>
+</html>
+"#;
+        let blocks = html_scripts(code);
+        let languages: Vec<&str> = blocks.iter().map(|b| b.language.as_str()).collect();
+        assert_eq!(languages, vec!["javascript", "typescript"]);
+        assert_eq!(
+            texts(code, &blocks)[0].trim(),
+            "function start() { render(); }"
+        );
+
+        // The mask keeps the script where it is
+        let masked = blocks[0].mask(code);
+        assert_eq!(masked.len(), code.len());
+        assert_eq!(masked.lines().nth(6), code.lines().nth(6));
+        assert!(masked.lines().nth(2).unwrap().trim().is_empty());
+    }
+
+    #[test]
+    fn test_rust_doc_examples() {
+        let code = r#"//! ```
+//! let parser = Parser::new();
+//! ```
+
+/// Adds one.
+///
+/// ```rust,no_run
+/// # use demo::add_one;
+/// #
+/// fn check() { assert_eq!(add_one(1), 2); }
+/// ```
+///
+/// ```text
+/// not code
+/// ```
+///
+/// ```compile_fail
+/// add_one("one");
+/// ```
+pub fn add_one(x: i32) -> i32 {
+    x + 1
+}
+
+/// ```
+/// struct Unclosed;
+fn after() {}
+"#;
+        let blocks = rust_doc_examples(code);
+        assert_eq!(
+            texts(code, &blocks),
+            vec![
+                " let parser = Parser::new();".to_string(),
+                "use demo::add_one;| fn check() { assert_eq!(add_one(1), 2); }".to_string(),
+                " struct Unclosed;".to_string(),
+            ]
+        );
+        assert!(blocks.iter().all(|b| b.language.as_str() == "rust"));
+    }
+
+    #[test]
+    fn test_sql_definitions() {
+        let code = "const schema = `\n  CREATE TABLE ${prefix}users (id INT);\n`;\nconst query = \"SELECT * FROM users\";\n";
+        assert!(may_contain_sql_definition(code));
+        assert!(!may_contain_sql_definition("// create a new user\n"));
+
+        let mut parser = tree_sitter::Parser::new();
+        parser
+            .set_language(&tree_sitter_javascript::LANGUAGE.into())
+            .unwrap();
+        let tree = parser.parse(code, None).unwrap();
+        let blocks = sql_definitions(tree.root_node(), code);
+        assert_eq!(
+            texts(code, &blocks),
+            vec!["CREATE TABLE |users (id INT);\n".to_string()]
+        );
+        assert_eq!(blocks[0].language.as_str(), "sql");
+    }
+}
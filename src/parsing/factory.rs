@@ -4,12 +4,17 @@
 //! Validates language enablement and provides discovery of supported languages.
 
 use super::{
-    CBehavior, CParser, CSharpBehavior, CSharpParser, ClojureBehavior, ClojureParser, CppBehavior,
-    CppParser, GdscriptBehavior, GdscriptParser, GoBehavior, GoParser, JavaBehavior, JavaParser,
-    JavaScriptBehavior, JavaScriptParser, KotlinBehavior, KotlinParser, Language, LanguageBehavior,
-    LanguageId, LanguageParser, LuaBehavior, LuaParser, PhpBehavior, PhpParser, PythonBehavior,
-    PythonParser, RustBehavior, RustParser, SwiftBehavior, SwiftParser, TypeScriptBehavior,
-    TypeScriptParser, get_registry,
+    BashBehavior, BashParser, CBehavior, CParser, CSharpBehavior, CSharpParser, ClojureBehavior,
+    ClojureParser, CppBehavior, CppParser, CssBehavior, CssParser, DartBehavior, DartParser,
+    ElixirBehavior, ElixirParser, GdscriptBehavior, GdscriptParser, GoBehavior, GoParser,
+    GraphQLBehavior, GraphQLParser, HclBehavior, HclParser, HtmlBehavior, HtmlParser, JavaBehavior,
+    JavaParser, JavaScriptBehavior, JavaScriptParser, JupyterBehavior, JupyterParser,
+    KotlinBehavior, KotlinParser, Language, LanguageBehavior, LanguageId, LanguageParser,
+    LuaBehavior, LuaParser, MarkdownBehavior, MarkdownParser, PhpBehavior, PhpParser,
+    ProtobufBehavior, ProtobufParser, PythonBehavior, PythonParser, RubyBehavior, RubyParser,
+    RustBehavior, RustParser, ScalaBehavior, ScalaParser, SqlBehavior, SqlParser, SvelteBehavior,
+    SvelteParser, SwiftBehavior, SwiftParser, TypeScriptBehavior, TypeScriptParser, VueBehavior,
+    VueParser, ZigBehavior, ZigParser, get_registry,
 };
 use crate::{IndexError, IndexResult, Settings};
 use std::sync::Arc;
@@ -186,10 +191,79 @@ impl ParserFactory {
                 let parser = LuaParser::new().map_err(|e| IndexError::General(e.to_string()))?;
                 Ok(Box::new(parser))
             }
+            Language::Ruby => {
+                let parser = RubyParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Scala => {
+                let parser = ScalaParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
             Language::Swift => {
                 let parser = SwiftParser::new().map_err(|e| IndexError::General(e.to_string()))?;
                 Ok(Box::new(parser))
             }
+            Language::Zig => {
+                let parser = ZigParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Dart => {
+                let parser = DartParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Elixir => {
+                let parser = ElixirParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Bash => {
+                let parser = BashParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Sql => {
+                let parser = SqlParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Protobuf => {
+                let parser =
+                    ProtobufParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::GraphQL => {
+                let parser =
+                    GraphQLParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Hcl => {
+                let parser = HclParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Vue => {
+                let parser = VueParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Svelte => {
+                let parser = SvelteParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Markdown => {
+                let parser =
+                    MarkdownParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Jupyter => {
+                let parser =
+                    JupyterParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Css => {
+                let parser = CssParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Html => {
+                let parser = HtmlParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                Ok(Box::new(parser))
+            }
+            Language::Plugin(id) => self.create_parser_from_registry(id),
         }
     }
 
@@ -329,6 +403,20 @@ impl ParserFactory {
                     behavior: Box::new(LuaBehavior::new()),
                 }
             }
+            Language::Ruby => {
+                let parser = RubyParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(RubyBehavior::new()),
+                }
+            }
+            Language::Scala => {
+                let parser = ScalaParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(ScalaBehavior::new()),
+                }
+            }
             Language::Swift => {
                 let parser = SwiftParser::new().map_err(|e| IndexError::General(e.to_string()))?;
                 ParserWithBehavior {
@@ -336,6 +424,109 @@ impl ParserFactory {
                     behavior: Box::new(SwiftBehavior::new()),
                 }
             }
+            Language::Zig => {
+                let parser = ZigParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(ZigBehavior::new()),
+                }
+            }
+            Language::Dart => {
+                let parser = DartParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(DartBehavior::new()),
+                }
+            }
+            Language::Elixir => {
+                let parser = ElixirParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(ElixirBehavior::new()),
+                }
+            }
+            Language::Bash => {
+                let parser = BashParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(BashBehavior::new()),
+                }
+            }
+            Language::Sql => {
+                let parser = SqlParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(SqlBehavior::new()),
+                }
+            }
+            Language::Protobuf => {
+                let parser =
+                    ProtobufParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(ProtobufBehavior::new()),
+                }
+            }
+            Language::GraphQL => {
+                let parser =
+                    GraphQLParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(GraphQLBehavior::new()),
+                }
+            }
+            Language::Hcl => {
+                let parser = HclParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(HclBehavior::new()),
+                }
+            }
+            Language::Vue => {
+                let parser = VueParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(VueBehavior::new()),
+                }
+            }
+            Language::Svelte => {
+                let parser = SvelteParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(SvelteBehavior::new()),
+                }
+            }
+            Language::Markdown => {
+                let parser =
+                    MarkdownParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(MarkdownBehavior::new()),
+                }
+            }
+            Language::Jupyter => {
+                let parser =
+                    JupyterParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(JupyterBehavior::new()),
+                }
+            }
+            Language::Css => {
+                let parser = CssParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(CssBehavior::new()),
+                }
+            }
+            Language::Html => {
+                let parser = HtmlParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+                ParserWithBehavior {
+                    parser: Box::new(parser),
+                    behavior: Box::new(HtmlBehavior::new()),
+                }
+            }
+            Language::Plugin(id) => self.create_parser_with_behavior_from_registry(id)?,
         };
 
         Ok(result)
@@ -363,21 +554,37 @@ impl ParserFactory {
     /// Filters all supported languages against settings.languages map.
     pub fn enabled_languages(&self) -> Vec<Language> {
         vec![
+            Language::Bash,
             Language::C,
             Language::Clojure,
             Language::Cpp,
             Language::CSharp,
+            Language::Css,
+            Language::Dart,
+            Language::Elixir,
             Language::Gdscript,
             Language::Go,
+            Language::GraphQL,
+            Language::Hcl,
+            Language::Html,
             Language::Java,
             Language::JavaScript,
+            Language::Jupyter,
             Language::Kotlin,
             Language::Lua,
+            Language::Markdown,
             Language::Php,
+            Language::Protobuf,
             Language::Python,
+            Language::Ruby,
             Language::Rust,
+            Language::Scala,
+            Language::Sql,
+            Language::Svelte,
             Language::Swift,
             Language::TypeScript,
+            Language::Vue,
+            Language::Zig,
         ]
         .into_iter()
         .filter(|&lang| self.is_language_enabled(lang))
@@ -505,6 +712,9 @@ mod tests {
                 parser_options: HashMap::new(),
                 config_files: Vec::new(),
                 projects: Vec::new(),
+                limits: Default::default(),
+                scope: Default::default(),
+                kinds: Vec::new(),
             },
         );
 
@@ -517,6 +727,9 @@ mod tests {
                 parser_options: HashMap::new(),
                 config_files: Vec::new(),
                 projects: Vec::new(),
+                limits: Default::default(),
+                scope: Default::default(),
+                kinds: Vec::new(),
             },
         );
 
@@ -529,6 +742,9 @@ mod tests {
                 parser_options: HashMap::new(),
                 config_files: Vec::new(),
                 projects: Vec::new(),
+                limits: Default::default(),
+                scope: Default::default(),
+                kinds: Vec::new(),
             },
         );
 
@@ -541,6 +757,9 @@ mod tests {
                 parser_options: HashMap::new(),
                 config_files: Vec::new(),
                 projects: Vec::new(),
+                limits: Default::default(),
+                scope: Default::default(),
+                kinds: Vec::new(),
             },
         );
 
@@ -658,6 +877,9 @@ mod tests {
                 parser_options: HashMap::new(),
                 config_files: Vec::new(),
                 projects: Vec::new(),
+                limits: Default::default(),
+                scope: Default::default(),
+                kinds: Vec::new(),
             },
         );
         settings.languages = languages;
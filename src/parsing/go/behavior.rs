@@ -655,6 +655,10 @@ mod tests {
             visibility: Visibility::Private, // Will be updated by configure_symbol
             scope_context: None,
             language_id: Some(LanguageId::new("go")),
+            metrics: None,
+            authorship: None,
+            tags: Vec::new(),
+            framework_kind: None,
         };
 
         behavior.configure_symbol(&mut symbol, Some("pkg/utils"));
@@ -684,6 +688,10 @@ mod tests {
             visibility: Visibility::Public, // Will be updated by configure_symbol
             scope_context: None,
             language_id: Some(LanguageId::new("go")),
+            metrics: None,
+            authorship: None,
+            tags: Vec::new(),
+            framework_kind: None,
         };
 
         behavior.configure_symbol(&mut symbol, None);
@@ -0,0 +1,285 @@
+//! GraphQL-specific language behavior implementation
+//!
+//! A schema has no modules: a document's module path is its path from the
+//! project root without the extension. `#import "./fragments.graphql"`
+//! comments (graphql-import, the webpack and Vite loaders) name a file
+//! relative to the importing document.
+
+use super::resolvers;
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::symbol::ScopeContext;
+use crate::{FileId, Symbol, SymbolKind, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// GraphQL language behavior implementation
+#[derive(Clone)]
+pub struct GraphQLBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl GraphQLBehavior {
+    /// Create a new GraphQL behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_graphql::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for GraphQLBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for GraphQLBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+fn class_name(scope: Option<&ScopeContext>) -> Option<&str> {
+    match scope? {
+        ScopeContext::ClassMember {
+            class_name: Some(class),
+        } => Some(class),
+        _ => None,
+    }
+}
+
+impl LanguageBehavior for GraphQLBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("graphql")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// Everything in a schema is visible to every document
+    fn parse_visibility(&self, _signature: &str) -> Visibility {
+        Visibility::Public
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "/"
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &[]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("/"))
+        }
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::GraphQLResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    /// `./fragments/user.graphql` imported by `src/queries/users` becomes
+    /// `src/queries/fragments/user`
+    fn normalize_import_path(
+        &self,
+        import_path: &str,
+        importing_module: Option<&str>,
+        _file_path: &Path,
+    ) -> Option<String> {
+        let path = [".graphql", ".gql"]
+            .iter()
+            .find_map(|ext| import_path.strip_suffix(ext))
+            .unwrap_or(import_path);
+
+        let mut segments: Vec<&str> = importing_module
+            .map(|module| module.split('/').collect())
+            .unwrap_or_default();
+        // The importing document itself is the last segment
+        segments.pop();
+
+        for part in path.split('/') {
+            match part {
+                "" | "." => {}
+                ".." => {
+                    segments.pop();
+                }
+                other => segments.push(other),
+            }
+        }
+
+        (!segments.is_empty()).then(|| segments.join("/"))
+    }
+
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        import_path == symbol_module_path
+    }
+
+    /// Fields of object types and root operation fields are answered by
+    /// resolvers named after them (see [`resolvers`])
+    fn cross_language_targets(
+        &self,
+        name: &str,
+        kind: SymbolKind,
+        _signature: Option<&str>,
+        scope: Option<&ScopeContext>,
+    ) -> Vec<(crate::parsing::LanguageId, String)> {
+        if matches!(kind, SymbolKind::Field | SymbolKind::Method) && class_name(scope).is_some() {
+            resolvers::resolver_names(name)
+        } else {
+            Vec::new()
+        }
+    }
+
+    /// A resolver in a class named after the field's type wins. Root
+    /// operation fields (Method symbols) also accept free functions and,
+    /// last, methods of other classes, as in Apollo resolver maps and
+    /// Spring controllers; other fields only link to their type's
+    /// resolver class, their names being too common to match on alone.
+    fn cross_language_target_rank(&self, caller: &Symbol, candidate: &Symbol) -> Option<u8> {
+        let type_name = class_name(caller.scope_context.as_ref())?;
+        match class_name(candidate.scope_context.as_ref()) {
+            Some(class) if resolvers::is_resolver_class(class, type_name) => Some(0),
+            _ if caller.kind != SymbolKind::Method => None,
+            None => Some(1),
+            Some(_) => Some(2),
+        }
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn member(name: &str, kind: SymbolKind, class: Option<&str>) -> Symbol {
+        let mut symbol = Symbol::new(
+            crate::SymbolId::new(1).unwrap(),
+            name,
+            kind,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 1, 0),
+        );
+        symbol.scope_context = Some(match class {
+            Some(class) => ScopeContext::ClassMember {
+                class_name: Some(class.into()),
+            },
+            None => ScopeContext::Module,
+        });
+        symbol
+    }
+
+    #[test]
+    fn test_normalize_import_path() {
+        let behavior = GraphQLBehavior::new();
+        let file = Path::new("src/queries/users.graphql");
+        let module = Some("src/queries/users");
+
+        assert_eq!(
+            behavior.normalize_import_path("./fragments/user.graphql", module, file),
+            Some("src/queries/fragments/user".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("../schema.gql", module, file),
+            Some("src/schema".to_string())
+        );
+    }
+
+    #[test]
+    fn test_fields_have_resolver_targets() {
+        let behavior = GraphQLBehavior::new();
+        let query = ScopeContext::ClassMember {
+            class_name: Some("Query".into()),
+        };
+
+        assert!(
+            !behavior
+                .cross_language_targets("user", SymbolKind::Method, None, Some(&query))
+                .is_empty()
+        );
+        assert!(
+            behavior
+                .cross_language_targets(
+                    "GetUser",
+                    SymbolKind::Function,
+                    None,
+                    Some(&ScopeContext::Global)
+                )
+                .is_empty()
+        );
+    }
+
+    #[test]
+    fn test_resolver_ranking() {
+        let behavior = GraphQLBehavior::new();
+        let root_field = member("user", SymbolKind::Method, Some("Query"));
+        let type_field = member("posts", SymbolKind::Field, Some("User"));
+
+        let in_resolver = member("user", SymbolKind::Method, Some("QueryResolver"));
+        let free = member("user", SymbolKind::Function, None);
+        let elsewhere = member("user", SymbolKind::Method, Some("UserController"));
+        assert_eq!(
+            behavior.cross_language_target_rank(&root_field, &in_resolver),
+            Some(0)
+        );
+        assert_eq!(
+            behavior.cross_language_target_rank(&root_field, &free),
+            Some(1)
+        );
+        assert_eq!(
+            behavior.cross_language_target_rank(&root_field, &elsewhere),
+            Some(2)
+        );
+
+        let posts = member("posts", SymbolKind::Method, Some("userResolver"));
+        let unrelated = member("posts", SymbolKind::Method, Some("Blog"));
+        assert_eq!(
+            behavior.cross_language_target_rank(&type_field, &posts),
+            Some(0)
+        );
+        assert_eq!(
+            behavior.cross_language_target_rank(&type_field, &unrelated),
+            None
+        );
+        assert_eq!(
+            behavior.cross_language_target_rank(&type_field, &free),
+            None
+        );
+    }
+}
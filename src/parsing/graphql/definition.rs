@@ -0,0 +1,89 @@
+//! GraphQL language definition for the registry
+//!
+//! Provides the GraphQL language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{GraphQLBehavior, GraphQLParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// GraphQL language definition
+pub struct GraphQLLanguage;
+
+impl GraphQLLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("graphql");
+}
+
+impl LanguageDefinition for GraphQLLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "GraphQL"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["graphql", "gql"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = GraphQLParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(GraphQLBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // GraphQL is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // GraphQL is enabled by default
+    }
+}
+
+/// Register GraphQL language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(GraphQLLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_graphql_definition() {
+        let graphql = GraphQLLanguage;
+
+        assert_eq!(graphql.id(), LanguageId::new("graphql"));
+        assert_eq!(graphql.name(), "GraphQL");
+        assert!(graphql.extensions().contains(&"gql"));
+    }
+
+    #[test]
+    fn test_graphql_enabled_by_default() {
+        let graphql = GraphQLLanguage;
+        let settings = Settings::default();
+
+        assert!(graphql.default_enabled());
+        assert!(graphql.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_graphql_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("graphql")));
+    }
+}
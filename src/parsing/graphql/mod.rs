@@ -0,0 +1,21 @@
+//! GraphQL language parser implementation
+//!
+//! Indexes the types, fields, operations and fragments of `.graphql` and
+//! `.gql` documents, and links schema fields to the resolvers answering
+//! them in application code (see [`resolvers`]), so relationship queries
+//! span the API layer.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+pub mod resolution;
+pub mod resolvers;
+
+pub use behavior::GraphQLBehavior;
+pub use definition::GraphQLLanguage;
+pub use parser::GraphQLParser;
+pub use resolution::GraphQLResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
@@ -0,0 +1,962 @@
+//! GraphQL language parser implementation
+//!
+//! Extracts schema types and executable documents using
+//! tree-sitter-graphql.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | `type`, `input` | Struct |
+//! | `interface` | Interface |
+//! | `enum` | Enum |
+//! | enum values | Constant |
+//! | `union`, `scalar` | TypeAlias |
+//! | fields of `Query`, `Mutation`, `Subscription` | Method |
+//! | other fields | Field |
+//! | named `query`, `mutation`, `subscription` | Function |
+//! | `fragment` | Function |
+//!
+//! Root operation types are `Query`, `Mutation` and `Subscription` unless
+//! the document's `schema { ... }` block names others. Fields added by
+//! `extend type` are members of the extended type. Anonymous operations
+//! have no name to index.
+//!
+//! ## Relationships
+//!
+//! Types use the types of their fields and arguments, unions their
+//! members, and `implements` records implementations. Operations call the
+//! root fields they select (with the root type as receiver) and the
+//! fragments they spread, and use their variable types; fragments use
+//! their type condition. `#import "file.graphql"` comments are imports.
+//! Links from fields to their resolvers in application code are added
+//! during indexing (see [`super::resolvers`]).
+//!
+//! ## Documentation
+//!
+//! Descriptions (`"""..."""` or `"..."`) are doc comments, as are `#`
+//! comments directly above a definition without one.
+
+use super::resolvers::ROOT_TYPES;
+use crate::parsing::method_call::MethodCall;
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, NodeTracker, NodeTrackingState, ParserContext,
+    ScopeType,
+};
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Nodes wrapping the definitions of a document
+const WRAPPER_KINDS: &[&str] = &[
+    "document",
+    "definition",
+    "executable_definition",
+    "type_system_definition",
+    "type_definition",
+    "type_system_extension",
+    "type_extension",
+];
+
+/// GraphQL-specific parsing errors
+#[derive(Error, Debug)]
+pub enum GraphQLParseError {
+    #[error(
+        "Failed to initialize GraphQL parser: {reason}\nSuggestion: Ensure tree-sitter-graphql is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// A call from an operation: a root field or a fragment spread
+struct Selection<'a> {
+    caller: &'a str,
+    target: &'a str,
+    range: Range,
+    receiver: Option<String>,
+}
+
+/// GraphQL language parser
+pub struct GraphQLParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for GraphQLParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("GraphQLParser")
+            .field("language", &"GraphQL")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Whitespace-collapsed text
+fn collapse(text: &str) -> String {
+    text.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+fn child_of_kind<'t>(node: &Node<'t>, kind: &str) -> Option<Node<'t>> {
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find(|child| child.kind() == kind)
+}
+
+/// Name of a definition, field or fragment
+fn name_of<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    let name = match node.kind() {
+        "fragment_definition" | "fragment_spread" => {
+            child_of_kind(&child_of_kind(node, "fragment_name")?, "name")?
+        }
+        "enum_value_definition" => child_of_kind(&child_of_kind(node, "enum_value")?, "name")?,
+        _ => child_of_kind(node, "name")?,
+    };
+    Some(&code[name.byte_range()])
+}
+
+/// The named type inside `[User!]!`
+fn named_type<'t>(node: Node<'t>) -> Option<Node<'t>> {
+    if node.kind() == "named_type" {
+        return child_of_kind(&node, "name");
+    }
+    let mut cursor = node.walk();
+    let inner = node.named_children(&mut cursor).find(|child| {
+        matches!(
+            child.kind(),
+            "type" | "named_type" | "list_type" | "non_null_type"
+        )
+    });
+    inner.and_then(named_type)
+}
+
+/// Definition text from its keyword or name, without description and body:
+/// `type User implements Node`, `user(id: ID!): User`
+fn header(node: &Node, code: &str) -> String {
+    let start = child_of_kind(node, "description")
+        .map(|description| description.end_byte())
+        .unwrap_or(node.start_byte());
+    let end = [
+        "fields_definition",
+        "enum_values_definition",
+        "input_fields_definition",
+        "selection_set",
+    ]
+    .iter()
+    .find_map(|kind| child_of_kind(node, kind))
+    .map(|body| body.start_byte())
+    .unwrap_or(node.end_byte());
+    collapse(&code[start..end])
+}
+
+/// `"""Text"""` / `"Text"` -> `Text`
+fn description_text(node: &Node, code: &str) -> Option<String> {
+    let description = child_of_kind(node, "description")?;
+    let text = code[description.byte_range()].trim();
+    let text = text
+        .strip_prefix("\"\"\"")
+        .and_then(|rest| rest.strip_suffix("\"\"\""))
+        .or_else(|| {
+            text.strip_prefix('"')
+                .and_then(|rest| rest.strip_suffix('"'))
+        })
+        .unwrap_or(text);
+    let doc = text
+        .lines()
+        .map(str::trim)
+        .collect::<Vec<_>>()
+        .join("\n")
+        .trim()
+        .to_string();
+    (!doc.is_empty()).then_some(doc)
+}
+
+impl GraphQLParser {
+    /// Create a new GraphQL parser instance
+    pub fn new() -> Result<Self, GraphQLParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_graphql::LANGUAGE.into())
+            .map_err(|e| GraphQLParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn create_symbol(
+        &self,
+        counter: &mut SymbolCounter,
+        name: &str,
+        kind: SymbolKind,
+        file_id: FileId,
+        node: &Node,
+        signature: String,
+        code: &str,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(
+            counter.next_id(),
+            name,
+            kind,
+            file_id,
+            range_from_node(node),
+        )
+        .with_signature(signature)
+        .with_visibility(Visibility::Public);
+        if let Some(doc) = self.extract_doc_comment(node, code) {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(self.context.current_scope_context());
+        symbol
+    }
+
+    /// Top-level definitions, with wrapper nodes removed
+    fn collect_definitions<'t>(node: Node<'t>, definitions: &mut Vec<Node<'t>>, depth: usize) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            if WRAPPER_KINDS.contains(&child.kind()) {
+                Self::collect_definitions(child, definitions, depth + 1);
+            } else if child.kind() != "comment" {
+                definitions.push(child);
+            }
+        }
+    }
+
+    /// Root types named by the document's `schema { ... }` block, as
+    /// (operation type, type name); the defaults otherwise
+    fn root_types<'a>(definitions: &[Node], code: &'a str) -> Vec<(&'a str, &'a str)> {
+        let mut roots: Vec<(&str, &str)> = vec![
+            ("query", ROOT_TYPES[0]),
+            ("mutation", ROOT_TYPES[1]),
+            ("subscription", ROOT_TYPES[2]),
+        ];
+        for schema in definitions
+            .iter()
+            .filter(|node| matches!(node.kind(), "schema_definition" | "schema_extension"))
+        {
+            let mut cursor = schema.walk();
+            for root in schema
+                .named_children(&mut cursor)
+                .filter(|child| child.kind() == "root_operation_type_definition")
+            {
+                let (Some(operation), Some(type_name)) = (
+                    child_of_kind(&root, "operation_type"),
+                    child_of_kind(&root, "named_type").and_then(named_type),
+                ) else {
+                    continue;
+                };
+                let operation = &code[operation.byte_range()];
+                if let Some(entry) = roots.iter_mut().find(|(op, _)| *op == operation) {
+                    entry.1 = &code[type_name.byte_range()];
+                }
+            }
+        }
+        roots
+    }
+
+    /// Extract symbols from the definitions of a document
+    fn extract_definitions(
+        &mut self,
+        root: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let mut definitions = Vec::new();
+        Self::collect_definitions(root, &mut definitions, 0);
+        let roots = Self::root_types(&definitions, code);
+
+        for definition in definitions {
+            let kind = match definition.kind() {
+                "object_type_definition" | "input_object_type_definition" => SymbolKind::Struct,
+                "interface_type_definition" => SymbolKind::Interface,
+                "enum_type_definition" => SymbolKind::Enum,
+                "union_type_definition" | "scalar_type_definition" => SymbolKind::TypeAlias,
+                "operation_definition" | "fragment_definition" => SymbolKind::Function,
+                // `extend type Query { ... }` adds members to an existing type
+                kind if kind.ends_with("_type_extension") => {
+                    if let Some(name) = name_of(&definition, code) {
+                        let is_root = roots.iter().any(|(_, root)| *root == name);
+                        self.extract_members(
+                            definition, name, is_root, code, file_id, counter, symbols,
+                        );
+                    }
+                    continue;
+                }
+                _ => continue,
+            };
+            let Some(name) = name_of(&definition, code) else {
+                continue;
+            };
+            self.register_handled_node(definition.kind(), definition.kind_id());
+
+            let symbol = self.create_symbol(
+                counter,
+                name,
+                kind,
+                file_id,
+                &definition,
+                header(&definition, code),
+                code,
+            );
+            symbols.push(symbol);
+
+            if kind != SymbolKind::Function {
+                let is_root = roots.iter().any(|(_, root)| *root == name);
+                self.extract_members(definition, name, is_root, code, file_id, counter, symbols);
+            }
+        }
+    }
+
+    /// Fields and enum values of a type
+    #[allow(clippy::too_many_arguments)]
+    fn extract_members(
+        &mut self,
+        definition: Node,
+        type_name: &str,
+        is_root: bool,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(body) = [
+            "fields_definition",
+            "enum_values_definition",
+            "input_fields_definition",
+        ]
+        .iter()
+        .find_map(|kind| child_of_kind(&definition, kind)) else {
+            return;
+        };
+
+        self.context.enter_scope(ScopeType::Class);
+        self.context.set_current_class(Some(type_name.to_string()));
+
+        let mut cursor = body.walk();
+        for member in body.named_children(&mut cursor) {
+            let kind = match member.kind() {
+                "field_definition" if is_root => SymbolKind::Method,
+                "field_definition" | "input_value_definition" => SymbolKind::Field,
+                "enum_value_definition" => SymbolKind::Constant,
+                _ => continue,
+            };
+            let Some(name) = name_of(&member, code) else {
+                continue;
+            };
+            self.register_handled_node(member.kind(), member.kind_id());
+            let symbol = self.create_symbol(
+                counter,
+                name,
+                kind,
+                file_id,
+                &member,
+                header(&member, code),
+                code,
+            );
+            symbols.push(symbol);
+        }
+
+        self.context.exit_scope();
+        self.context.set_current_class(None);
+    }
+
+    /// Root fields selected by operations and fragments spread anywhere in
+    /// them
+    fn collect_selections<'a>(&mut self, code: &'a str) -> Vec<Selection<'a>> {
+        let mut selections = Vec::new();
+        let Some(tree) = self.parser.parse(code, None) else {
+            return selections;
+        };
+        let mut definitions = Vec::new();
+        Self::collect_definitions(tree.root_node(), &mut definitions, 0);
+        let roots = Self::root_types(&definitions, code);
+
+        for definition in definitions {
+            if !matches!(
+                definition.kind(),
+                "operation_definition" | "fragment_definition"
+            ) {
+                continue;
+            }
+            let Some(caller) = name_of(&definition, code) else {
+                continue;
+            };
+            let Some(selection_set) = child_of_kind(&definition, "selection_set") else {
+                continue;
+            };
+
+            if definition.kind() == "operation_definition" {
+                let operation = child_of_kind(&definition, "operation_type")
+                    .map(|op| &code[op.byte_range()])
+                    .unwrap_or("query");
+                let root = roots
+                    .iter()
+                    .find(|(op, _)| *op == operation)
+                    .map(|(_, root)| *root);
+                let mut cursor = selection_set.walk();
+                for selection in selection_set.named_children(&mut cursor) {
+                    let field = if selection.kind() == "field" {
+                        Some(selection)
+                    } else {
+                        child_of_kind(&selection, "field")
+                    };
+                    let Some(name) = field.and_then(|field| child_of_kind(&field, "name")) else {
+                        continue;
+                    };
+                    selections.push(Selection {
+                        caller,
+                        target: &code[name.byte_range()],
+                        range: range_from_node(&name),
+                        receiver: root.map(str::to_string),
+                    });
+                }
+            }
+            Self::collect_spreads(selection_set, code, caller, &mut selections, 0);
+        }
+        selections
+    }
+
+    fn collect_spreads<'a>(
+        node: Node,
+        code: &'a str,
+        caller: &'a str,
+        selections: &mut Vec<Selection<'a>>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            if child.kind() == "fragment_spread" {
+                if let Some(fragment) = child_of_kind(&child, "fragment_name") {
+                    selections.push(Selection {
+                        caller,
+                        target: &code[fragment.byte_range()],
+                        range: range_from_node(&fragment),
+                        receiver: None,
+                    });
+                }
+            } else {
+                Self::collect_spreads(child, code, caller, selections, depth + 1);
+            }
+        }
+    }
+
+    /// Named types referenced under `node`, owned by `owner`
+    fn collect_type_uses<'a>(
+        node: Node,
+        code: &'a str,
+        owner: &'a str,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            match child.kind() {
+                "named_type" => {
+                    if let Some(name) = named_type(child) {
+                        uses.push((owner, &code[name.byte_range()], range_from_node(&name)));
+                    }
+                }
+                // Interfaces are implementations, not uses
+                "implements_interfaces" | "description" | "directives" | "comment" => {}
+                _ => Self::collect_type_uses(child, code, owner, uses, depth + 1),
+            }
+        }
+    }
+
+    /// `# text` -> `text`
+    fn comment_text(node: &Node, code: &str) -> String {
+        code[node.byte_range()]
+            .trim_start_matches('#')
+            .trim()
+            .to_string()
+    }
+}
+
+impl LanguageParser for GraphQLParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+
+        let mut symbols = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            self.extract_definitions(
+                tree.root_node(),
+                code,
+                file_id,
+                symbol_counter,
+                &mut symbols,
+            );
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// The description, else `#` comment lines directly above the
+    /// definition
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        if let Some(doc) = description_text(node, code) {
+            return Some(doc);
+        }
+
+        // Top-level definitions sit in wrapper nodes; comments are their
+        // siblings
+        let mut anchor = *node;
+        while anchor.prev_named_sibling().is_none() {
+            match anchor.parent() {
+                Some(parent) if WRAPPER_KINDS.contains(&parent.kind()) => anchor = parent,
+                _ => break,
+            }
+        }
+
+        let mut lines = Vec::new();
+        let mut next_row = node.start_position().row;
+        let mut current = anchor.prev_named_sibling();
+        while let Some(prev) = current {
+            if prev.kind() != "comment" || prev.end_position().row + 1 != next_row {
+                break;
+            }
+            // `#import` lines are directives, not documentation
+            if code[prev.byte_range()].starts_with("#import") {
+                break;
+            }
+            lines.push(Self::comment_text(&prev, code));
+            next_row = prev.start_position().row;
+            current = prev.prev_named_sibling();
+        }
+
+        if lines.is_empty() {
+            return None;
+        }
+        lines.reverse();
+        let doc = lines.join("\n").trim().to_string();
+        (!doc.is_empty()).then_some(doc)
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        self.collect_selections(code)
+            .into_iter()
+            .map(|selection| (selection.caller, selection.target, selection.range))
+            .collect()
+    }
+
+    /// Root field selections carry their root type (`Query`) as a static
+    /// receiver, so `user` resolves to `Query.user` rather than any field
+    /// named `user`
+    fn find_method_calls(&mut self, code: &str) -> Vec<MethodCall> {
+        self.collect_selections(code)
+            .into_iter()
+            .map(|selection| {
+                let call = MethodCall::new(selection.caller, selection.target, selection.range);
+                match selection.receiver {
+                    Some(receiver) => call.with_receiver(&receiver).static_method(),
+                    None => call,
+                }
+            })
+            .collect()
+    }
+
+    /// `type User implements Node & Entity`
+    fn find_implementations<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut implementations = Vec::new();
+        let Some(tree) = self.parser.parse(code, None) else {
+            return implementations;
+        };
+        let mut definitions = Vec::new();
+        Self::collect_definitions(tree.root_node(), &mut definitions, 0);
+
+        for definition in definitions {
+            let (Some(name), Some(interfaces)) = (
+                name_of(&definition, code),
+                child_of_kind(&definition, "implements_interfaces"),
+            ) else {
+                continue;
+            };
+            let mut stack = vec![interfaces];
+            while let Some(node) = stack.pop() {
+                let mut cursor = node.walk();
+                for child in node.named_children(&mut cursor) {
+                    match child.kind() {
+                        "named_type" => {
+                            if let Some(interface) = named_type(child) {
+                                implementations.push((
+                                    name,
+                                    &code[interface.byte_range()],
+                                    range_from_node(&interface),
+                                ));
+                            }
+                        }
+                        // Left-recursive `implements A & B`
+                        "implements_interfaces" => stack.push(child),
+                        _ => {}
+                    }
+                }
+            }
+        }
+        implementations
+    }
+
+    /// Field and argument types of types, members of unions, variable types
+    /// of operations and type conditions of fragments
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut uses = Vec::new();
+        let Some(tree) = self.parser.parse(code, None) else {
+            return uses;
+        };
+        let mut definitions = Vec::new();
+        Self::collect_definitions(tree.root_node(), &mut definitions, 0);
+
+        for definition in definitions {
+            let Some(owner) = name_of(&definition, code) else {
+                continue;
+            };
+            match definition.kind() {
+                "operation_definition" => {
+                    if let Some(variables) = child_of_kind(&definition, "variable_definitions") {
+                        Self::collect_type_uses(variables, code, owner, &mut uses, 0);
+                    }
+                }
+                "fragment_definition" => {
+                    if let Some(condition) = child_of_kind(&definition, "type_condition") {
+                        Self::collect_type_uses(condition, code, owner, &mut uses, 0);
+                    }
+                }
+                "schema_definition" | "directive_definition" => {}
+                _ => Self::collect_type_uses(definition, code, owner, &mut uses, 0),
+            }
+        }
+        uses
+    }
+
+    /// Root types define their operation fields
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut defines = Vec::new();
+        let Some(tree) = self.parser.parse(code, None) else {
+            return defines;
+        };
+        let mut definitions = Vec::new();
+        Self::collect_definitions(tree.root_node(), &mut definitions, 0);
+        let roots = Self::root_types(&definitions, code);
+
+        for definition in definitions {
+            let Some(name) = name_of(&definition, code) else {
+                continue;
+            };
+            if !roots.iter().any(|(_, root)| *root == name) {
+                continue;
+            }
+            let Some(fields) = child_of_kind(&definition, "fields_definition") else {
+                continue;
+            };
+            let mut cursor = fields.walk();
+            for field in fields
+                .named_children(&mut cursor)
+                .filter(|child| child.kind() == "field_definition")
+            {
+                if let Some(field_name) = name_of(&field, code) {
+                    defines.push((name, field_name, range_from_node(&field)));
+                }
+            }
+        }
+        defines
+    }
+
+    /// `#import "./fragments.graphql"` comments
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        code.lines()
+            .filter_map(|line| {
+                let rest = line.trim().strip_prefix("#import")?.trim();
+                let path = rest.strip_prefix(['"', '\''])?.split(['"', '\'']).next()?;
+                (!path.is_empty()).then(|| Import {
+                    path: path.to_string(),
+                    alias: None,
+                    file_id,
+                    // Every fragment of the imported file becomes visible
+                    is_glob: true,
+                    is_type_only: false,
+                })
+            })
+            .collect()
+    }
+
+    fn language(&self) -> Language {
+        Language::GraphQL
+    }
+}
+
+impl NodeTracker for GraphQLParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::symbol::ScopeContext;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = GraphQLParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+    }
+
+    fn member_of(symbol: &Symbol) -> Option<&str> {
+        match symbol.scope_context.as_ref()? {
+            ScopeContext::ClassMember {
+                class_name: Some(class),
+            } => Some(class),
+            _ => None,
+        }
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(GraphQLParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_types_and_fields() {
+        let code = r#"""A registered account"""
+type User implements Node {
+  id: ID!
+  "Posts, newest first"
+  posts(first: Int = 10): [Post!]!
+}
+
+# Publication state
+enum Status {
+  DRAFT
+  PUBLISHED
+}
+
+input NewUser {
+  email: String!
+}
+
+union SearchResult = User | Post
+"#;
+        let symbols = parse(code);
+
+        let user = find(&symbols, "User");
+        assert_eq!(user.kind, SymbolKind::Struct);
+        assert_eq!(user.signature.as_deref(), Some("type User implements Node"));
+        assert_eq!(user.doc_comment.as_deref(), Some("A registered account"));
+        assert_eq!(user.scope_context, Some(ScopeContext::Module));
+
+        let posts = find(&symbols, "posts");
+        assert_eq!(posts.kind, SymbolKind::Field);
+        assert_eq!(member_of(posts), Some("User"));
+        assert_eq!(
+            posts.signature.as_deref(),
+            Some("posts(first: Int = 10): [Post!]!")
+        );
+        assert_eq!(posts.doc_comment.as_deref(), Some("Posts, newest first"));
+
+        let status = find(&symbols, "Status");
+        assert_eq!(status.kind, SymbolKind::Enum);
+        assert_eq!(status.doc_comment.as_deref(), Some("Publication state"));
+        assert_eq!(find(&symbols, "DRAFT").kind, SymbolKind::Constant);
+        assert_eq!(member_of(find(&symbols, "DRAFT")), Some("Status"));
+
+        assert_eq!(find(&symbols, "NewUser").kind, SymbolKind::Struct);
+        assert_eq!(member_of(find(&symbols, "email")), Some("NewUser"));
+        assert_eq!(find(&symbols, "SearchResult").kind, SymbolKind::TypeAlias);
+    }
+
+    #[test]
+    fn test_root_fields_are_methods() {
+        let code = r#"schema {
+  query: RootQuery
+}
+
+type RootQuery {
+  user(id: ID!): User
+}
+
+extend type Mutation {
+  createUser(input: NewUser!): User
+}
+"#;
+        let symbols = parse(code);
+
+        let user = find(&symbols, "user");
+        assert_eq!(user.kind, SymbolKind::Method);
+        assert_eq!(member_of(user), Some("RootQuery"));
+
+        let create = find(&symbols, "createUser");
+        assert_eq!(create.kind, SymbolKind::Method);
+        assert_eq!(member_of(create), Some("Mutation"));
+        assert!(
+            !symbols.iter().any(|s| s.name.as_ref() == "Mutation"),
+            "extensions do not define a new type"
+        );
+    }
+
+    #[test]
+    fn test_operations_and_fragments() {
+        let code = r#"query GetUser($id: ID!) {
+  user(id: $id) {
+    ...UserFields
+  }
+}
+
+fragment UserFields on User {
+  id
+}
+
+{
+  anonymous
+}
+"#;
+        let symbols = parse(code);
+
+        let query = find(&symbols, "GetUser");
+        assert_eq!(query.kind, SymbolKind::Function);
+        assert_eq!(query.signature.as_deref(), Some("query GetUser($id: ID!)"));
+
+        let fragment = find(&symbols, "UserFields");
+        assert_eq!(fragment.kind, SymbolKind::Function);
+        assert_eq!(
+            fragment.signature.as_deref(),
+            Some("fragment UserFields on User")
+        );
+        assert_eq!(symbols.len(), 2, "anonymous operations are not indexed");
+    }
+
+    #[test]
+    fn test_operation_calls() {
+        let code = r#"mutation AddUser($input: NewUser!) {
+  createUser(input: $input) {
+    ...UserFields
+  }
+}
+"#;
+        let mut parser = GraphQLParser::new().unwrap();
+        let calls = parser.find_method_calls(code);
+
+        let create = calls
+            .iter()
+            .find(|call| call.method_name == "createUser")
+            .expect("Should find root field call");
+        assert_eq!(create.caller, "AddUser");
+        assert_eq!(create.receiver.as_deref(), Some("Mutation"));
+        assert!(create.is_static);
+
+        let spread = calls
+            .iter()
+            .find(|call| call.method_name == "UserFields")
+            .expect("Should find fragment spread");
+        assert_eq!(spread.receiver, None);
+
+        let uses = parser.find_uses(code);
+        assert!(
+            uses.iter()
+                .any(|(from, to, _)| *from == "AddUser" && *to == "NewUser")
+        );
+    }
+
+    #[test]
+    fn test_implementations_uses_and_defines() {
+        let code = r#"type User implements Node & Entity {
+  posts: [Post!]!
+  status: Status
+}
+
+union Feed = Post | Ad
+
+type Query {
+  me: User
+}
+"#;
+        let mut parser = GraphQLParser::new().unwrap();
+
+        let implementations = parser.find_implementations(code);
+        let interfaces: Vec<_> = implementations.iter().map(|(_, to, _)| *to).collect();
+        assert!(interfaces.contains(&"Node"));
+        assert!(interfaces.contains(&"Entity"));
+
+        let uses = parser.find_uses(code);
+        for (from, to) in [("User", "Post"), ("User", "Status"), ("Feed", "Ad")] {
+            assert!(
+                uses.iter().any(|(f, t, _)| *f == from && *t == to),
+                "{from} -> {to}"
+            );
+        }
+        assert!(!uses.iter().any(|(_, to, _)| *to == "Node"));
+
+        let defines = parser.find_defines(code);
+        assert_eq!(defines.len(), 1);
+        assert_eq!((defines[0].0, defines[0].1), ("Query", "me"));
+    }
+
+    #[test]
+    fn test_imports() {
+        let code = r#"#import "./fragments/user.graphql"
+# Regular comment
+
+query Me {
+  me {
+    ...UserFields
+  }
+}
+"#;
+        let mut parser = GraphQLParser::new().unwrap();
+        let imports = parser.find_imports(code, FileId::new(1).unwrap());
+
+        assert_eq!(imports.len(), 1);
+        assert_eq!(imports[0].path, "./fragments/user.graphql");
+        assert!(imports[0].is_glob);
+    }
+}
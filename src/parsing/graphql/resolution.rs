@@ -0,0 +1,212 @@
+//! GraphQL-specific symbol resolution
+//!
+//! A schema is one namespace spread over any number of files: types,
+//! fields and fragments are referenced by name from every document.
+//! Resolution checks:
+//! - Arguments and variables of the current field or operation
+//! - Types and fragments declared in the file itself
+//! - Types and fragments declared in other files
+
+use crate::parsing::resolution::{ImportBinding, default_compatible_relationship};
+use crate::parsing::{Import, ResolutionScope, ScopeLevel, ScopeType};
+use crate::{FileId, RelationKind, SymbolId};
+use std::collections::HashMap;
+
+/// GraphQL resolution context
+///
+/// Tracks argument, file-level and project-level scopes.
+pub struct GraphQLResolutionContext {
+    #[allow(dead_code)]
+    file_id: FileId,
+
+    /// Arguments and variables
+    local_scope: HashMap<String, SymbolId>,
+
+    /// Types and fragments declared in this file
+    module_scope: HashMap<String, SymbolId>,
+
+    /// Types and fragments declared in other files
+    imported_scope: HashMap<String, SymbolId>,
+
+    /// Scope stack for nested contexts
+    scope_stack: Vec<ScopeType>,
+
+    /// Import bindings keyed by visible name
+    import_bindings: HashMap<String, ImportBinding>,
+}
+
+impl GraphQLResolutionContext {
+    pub fn new(file_id: FileId) -> Self {
+        Self {
+            file_id,
+            local_scope: HashMap::new(),
+            module_scope: HashMap::new(),
+            imported_scope: HashMap::new(),
+            scope_stack: Vec::new(),
+            import_bindings: HashMap::new(),
+        }
+    }
+}
+
+impl ResolutionScope for GraphQLResolutionContext {
+    fn as_any_mut(&mut self) -> &mut dyn std::any::Any {
+        self
+    }
+
+    fn resolve(&self, name: &str) -> Option<SymbolId> {
+        if let Some(&id) = self.local_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.module_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.imported_scope.get(name) {
+            return Some(id);
+        }
+
+        None
+    }
+
+    fn add_symbol(&mut self, name: String, symbol_id: SymbolId, scope_level: ScopeLevel) {
+        match scope_level {
+            ScopeLevel::Local => {
+                self.local_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Module | ScopeLevel::Global => {
+                self.module_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Package => {
+                self.imported_scope.insert(name, symbol_id);
+            }
+        }
+    }
+
+    fn enter_scope(&mut self, scope_type: ScopeType) {
+        self.scope_stack.push(scope_type);
+    }
+
+    fn exit_scope(&mut self) {
+        if let Some(scope) = self.scope_stack.pop() {
+            if matches!(scope, ScopeType::Function { .. }) {
+                self.clear_local_scope();
+            }
+        }
+    }
+
+    fn clear_local_scope(&mut self) {
+        self.local_scope.clear();
+    }
+
+    fn symbols_in_scope(&self) -> Vec<(String, SymbolId, ScopeLevel)> {
+        let mut symbols = Vec::new();
+
+        for (name, &id) in &self.local_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Local));
+        }
+        for (name, &id) in &self.module_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Module));
+        }
+        for (name, &id) in &self.imported_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Package));
+        }
+
+        symbols
+    }
+
+    fn resolve_relationship(
+        &self,
+        _from_name: &str,
+        to_name: &str,
+        _kind: RelationKind,
+        _from_file: FileId,
+    ) -> Option<SymbolId> {
+        self.resolve(to_name)
+    }
+
+    fn populate_imports(&mut self, _imports: &[Import]) {
+        // `#import` pulls in a file of fragments, resolved through the
+        // index
+    }
+
+    fn register_import_binding(&mut self, binding: ImportBinding) {
+        self.import_bindings
+            .insert(binding.exposed_name.clone(), binding);
+    }
+
+    fn import_binding(&self, name: &str) -> Option<ImportBinding> {
+        self.import_bindings.get(name).cloned()
+    }
+
+    /// Fields call the resolvers implementing them in application code
+    fn is_compatible_relationship(
+        &self,
+        from_kind: crate::SymbolKind,
+        to_kind: crate::SymbolKind,
+        rel_kind: crate::RelationKind,
+    ) -> bool {
+        use crate::RelationKind::*;
+        use crate::SymbolKind::*;
+
+        match rel_kind {
+            Calls if from_kind == Field => matches!(to_kind, Function | Method),
+            CalledBy if to_kind == Field => matches!(from_kind, Function | Method),
+            _ => default_compatible_relationship(from_kind, to_kind, rel_kind),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_scope_resolution_order() {
+        let mut context = GraphQLResolutionContext::new(FileId::new(1).unwrap());
+
+        let local_id = SymbolId::new(1).unwrap();
+        let module_id = SymbolId::new(2).unwrap();
+        let import_id = SymbolId::new(3).unwrap();
+
+        context.add_symbol("x".to_string(), import_id, ScopeLevel::Package);
+        context.add_symbol("x".to_string(), module_id, ScopeLevel::Module);
+        context.add_symbol("x".to_string(), local_id, ScopeLevel::Local);
+
+        assert_eq!(context.resolve("x"), Some(local_id));
+        context.clear_local_scope();
+        assert_eq!(context.resolve("x"), Some(module_id));
+    }
+
+    #[test]
+    fn test_arguments_end_with_field() {
+        let mut context = GraphQLResolutionContext::new(FileId::new(1).unwrap());
+        let id = SymbolId::new(4).unwrap();
+
+        context.enter_scope(ScopeType::function());
+        context.add_symbol("id".to_string(), id, ScopeLevel::Local);
+        assert_eq!(context.resolve("id"), Some(id));
+
+        context.exit_scope();
+        assert_eq!(context.resolve("id"), None);
+    }
+
+    #[test]
+    fn test_fields_call_resolvers() {
+        let context = GraphQLResolutionContext::new(FileId::new(1).unwrap());
+
+        assert!(context.is_compatible_relationship(
+            crate::SymbolKind::Field,
+            crate::SymbolKind::Function,
+            RelationKind::Calls
+        ));
+        assert!(context.is_compatible_relationship(
+            crate::SymbolKind::Field,
+            crate::SymbolKind::Method,
+            RelationKind::Calls
+        ));
+        assert!(!context.is_compatible_relationship(
+            crate::SymbolKind::Field,
+            crate::SymbolKind::Field,
+            RelationKind::Calls
+        ));
+    }
+}
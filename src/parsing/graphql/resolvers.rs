@@ -0,0 +1,139 @@
+//! Resolver naming across GraphQL server libraries
+//!
+//! Servers answer each schema field with a resolver function named after
+//! the field in the implementing language's convention:
+//!
+//! | Language | Libraries | `userById` resolver |
+//! |----------|-----------|---------------------|
+//! | JavaScript, TypeScript | Apollo, graphql-js, NestJS | `userById` |
+//! | Python | Graphene, Ariadne | `resolve_user_by_id` |
+//! | Python | Strawberry | `user_by_id` |
+//! | Go | gqlgen | `UserByID`, `UserById` |
+//! | Rust | async-graphql, Juniper | `user_by_id` |
+//! | Ruby | graphql-ruby | `user_by_id` |
+//! | Java, Kotlin | Spring GraphQL, DGS | `userById` |
+//!
+//! Resolvers usually live in a class named after the field's type
+//! (`QueryResolver`, `queryResolver`, `Types::QueryType`, `UserResolvers`),
+//! which is how a field resolver is told apart from an unrelated method of
+//! the same name.
+
+use crate::parsing::LanguageId;
+use crate::parsing::protobuf::handlers::to_snake_case;
+
+/// Root operation types of a schema without a `schema { ... }` block
+pub const ROOT_TYPES: &[&str] = &["Query", "Mutation", "Subscription"];
+
+/// Suffixes of classes holding the resolvers of a type
+const RESOLVER_CLASS_SUFFIXES: &[&str] = &[
+    "",
+    "resolver",
+    "resolvers",
+    "type",
+    "root",
+    "object",
+    "fields",
+    "datafetcher",
+    "controller",
+];
+
+/// `userById` -> `UserById`
+fn to_pascal_case(name: &str) -> String {
+    let mut chars = name.chars();
+    match chars.next() {
+        Some(first) => first.to_uppercase().chain(chars).collect(),
+        None => String::new(),
+    }
+}
+
+/// gqlgen capitalizes the common initialisms golint knows, so `userById`
+/// becomes `UserByID`
+fn to_go_name(name: &str) -> String {
+    const INITIALISMS: &[&str] = &["Id", "Url", "Uri", "Api", "Http", "Json", "Sql", "Uuid"];
+    let pascal = to_pascal_case(name);
+    INITIALISMS
+        .iter()
+        .find(|initialism| pascal.ends_with(*initialism) && pascal.len() > initialism.len())
+        .map(|initialism| {
+            let stem = &pascal[..pascal.len() - initialism.len()];
+            format!("{stem}{}", initialism.to_ascii_uppercase())
+        })
+        .unwrap_or(pascal)
+}
+
+/// Resolver names of `field` in each language with GraphQL servers
+pub fn resolver_names(field: &str) -> Vec<(LanguageId, String)> {
+    let snake = to_snake_case(field);
+    let mut names = vec![
+        (LanguageId::new("javascript"), field.to_string()),
+        (LanguageId::new("typescript"), field.to_string()),
+        (LanguageId::new("python"), format!("resolve_{snake}")),
+        (LanguageId::new("python"), snake.clone()),
+        (LanguageId::new("go"), to_go_name(field)),
+        (LanguageId::new("rust"), snake.clone()),
+        (LanguageId::new("ruby"), snake),
+        (LanguageId::new("java"), field.to_string()),
+        (LanguageId::new("kotlin"), field.to_string()),
+    ];
+    let pascal = to_pascal_case(field);
+    if names[4].1 != pascal {
+        names.insert(5, (LanguageId::new("go"), pascal));
+    }
+    names
+}
+
+/// Whether `class` holds the resolvers of the GraphQL type `type_name`:
+/// `QueryResolver`, `queryResolver`, `Types::QueryType` and `Query` do for
+/// `Query`
+pub fn is_resolver_class(class: &str, type_name: &str) -> bool {
+    let class = class.rsplit(['.', ':']).next().unwrap_or(class);
+    let class = class.to_ascii_lowercase();
+    let type_name = type_name.to_ascii_lowercase();
+    class
+        .strip_prefix(type_name.as_str())
+        .is_some_and(|suffix| RESOLVER_CLASS_SUFFIXES.contains(&suffix))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn names_in(field: &str, language: &str) -> Vec<String> {
+        resolver_names(field)
+            .into_iter()
+            .filter(|(id, _)| id.as_str() == language)
+            .map(|(_, name)| name)
+            .collect()
+    }
+
+    #[test]
+    fn test_resolver_names() {
+        assert_eq!(names_in("userById", "typescript"), vec!["userById"]);
+        assert_eq!(
+            names_in("userById", "python"),
+            vec!["resolve_user_by_id", "user_by_id"]
+        );
+        assert_eq!(names_in("userById", "go"), vec!["UserByID", "UserById"]);
+        assert_eq!(names_in("createPost", "go"), vec!["CreatePost"]);
+        assert_eq!(names_in("createPost", "rust"), vec!["create_post"]);
+        assert_eq!(names_in("createPost", "ruby"), vec!["create_post"]);
+    }
+
+    #[test]
+    fn test_resolver_classes() {
+        for class in [
+            "Query",
+            "QueryResolver",
+            "queryResolver",
+            "QueryResolvers",
+            "Types::QueryType",
+            "QueryRoot",
+        ] {
+            assert!(is_resolver_class(class, "Query"), "{class}");
+        }
+        assert!(is_resolver_class("UserResolver", "User"));
+        for class in ["UserService", "Session", "MutationResolver"] {
+            assert!(!is_resolver_class(class, "Query"), "{class}");
+        }
+    }
+}
@@ -0,0 +1,269 @@
+//! HCL-specific language behavior implementation
+//!
+//! Terraform treats a directory as one module, whatever its files are
+//! called, so a file's module path is its directory from the project root
+//! (`.` for the root module): `modules/vpc/main.tf` and
+//! `modules/vpc/variables.tf` are both `modules/vpc`, and their addresses
+//! resolve against each other.
+
+use super::modules;
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::symbol::ScopeContext;
+use crate::{FileId, Symbol, SymbolKind, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// HCL language behavior implementation
+#[derive(Clone)]
+pub struct HclBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl HclBehavior {
+    /// Create a new HCL behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_hcl::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for HclBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for HclBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for HclBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("hcl")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// Every address is visible throughout its module
+    fn parse_visibility(&self, _signature: &str) -> Visibility {
+        Visibility::Public
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "/"
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &[]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            Some(".".to_string())
+        } else {
+            Some(components.join("/"))
+        }
+    }
+
+    /// The file's directory, not the file itself, is the module
+    fn module_path_from_file(
+        &self,
+        file_path: &Path,
+        workspace_root: &Path,
+        _extensions: &[&str],
+    ) -> Option<String> {
+        let relative_path = file_path.strip_prefix(workspace_root).ok()?;
+        let directory = relative_path.parent()?.to_str()?;
+        let components: Vec<&str> = directory
+            .split(std::path::MAIN_SEPARATOR)
+            .filter(|s| !s.is_empty())
+            .collect();
+        self.format_path_as_module(&components)
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::HclResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    /// Local module sources become the module path of their directory
+    /// (`./modules/vpc` called from `.` is `modules/vpc`); registry and git
+    /// sources are kept as written
+    fn normalize_import_path(
+        &self,
+        import_path: &str,
+        importing_module: Option<&str>,
+        _file_path: &Path,
+    ) -> Option<String> {
+        modules::local_module_dir(import_path, importing_module.unwrap_or("."))
+            .or_else(|| Some(import_path.to_string()))
+    }
+
+    /// A called module's addresses are private to it: `var.region` in the
+    /// caller never means the child's `var.region`. The call reaches the
+    /// child through its inputs instead (see [`Self::cross_language_targets`]).
+    fn import_matches_symbol(
+        &self,
+        _import_path: &str,
+        _symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        false
+    }
+
+    /// A module call sets the `var.*` inputs of the module it instantiates
+    fn cross_language_targets(
+        &self,
+        _name: &str,
+        kind: SymbolKind,
+        signature: Option<&str>,
+        _scope: Option<&ScopeContext>,
+    ) -> Vec<(crate::parsing::LanguageId, String)> {
+        if kind != SymbolKind::Module {
+            return Vec::new();
+        }
+        let Some((Some(_), inputs)) = signature.and_then(modules::parse_module_signature) else {
+            return Vec::new();
+        };
+        inputs
+            .into_iter()
+            .map(|input| (self.language_id(), format!("var.{input}")))
+            .collect()
+    }
+
+    /// Only the variable declared in the module's `source` directory is an
+    /// input of the call
+    fn cross_language_target_rank(&self, caller: &Symbol, candidate: &Symbol) -> Option<u8> {
+        let (source, _) = modules::parse_module_signature(caller.signature.as_deref()?)?;
+        let child = modules::local_module_dir(source?, caller.module_path.as_deref()?)?;
+        (candidate.module_path.as_deref() == Some(child.as_str())).then_some(0)
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn variable(name: &str, module_path: &str) -> Symbol {
+        let mut symbol = Symbol::new(
+            crate::SymbolId::new(2).unwrap(),
+            name,
+            SymbolKind::Variable,
+            FileId::new(2).unwrap(),
+            crate::Range::new(0, 0, 3, 1),
+        );
+        symbol.module_path = Some(module_path.into());
+        symbol
+    }
+
+    fn module_call(signature: &str) -> Symbol {
+        let mut symbol = Symbol::new(
+            crate::SymbolId::new(1).unwrap(),
+            "module.vpc",
+            SymbolKind::Module,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 4, 1),
+        )
+        .with_signature(signature);
+        symbol.module_path = Some("envs/prod".into());
+        symbol
+    }
+
+    #[test]
+    fn test_module_path_is_directory() {
+        let behavior = HclBehavior::new();
+        let root = Path::new("/project");
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/modules/vpc/main.tf"),
+                root,
+                &["tf"]
+            ),
+            Some("modules/vpc".to_string())
+        );
+        assert_eq!(
+            behavior.module_path_from_file(Path::new("/project/main.tf"), root, &["tf"]),
+            Some(".".to_string())
+        );
+    }
+
+    #[test]
+    fn test_normalize_import_path() {
+        let behavior = HclBehavior::new();
+        let file = Path::new("envs/prod/main.tf");
+
+        assert_eq!(
+            behavior.normalize_import_path("../../modules/vpc", Some("envs/prod"), file),
+            Some("modules/vpc".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("terraform-aws-modules/vpc/aws", Some("."), file),
+            Some("terraform-aws-modules/vpc/aws".to_string())
+        );
+    }
+
+    #[test]
+    fn test_module_inputs_target_child_variables() {
+        let behavior = HclBehavior::new();
+        let call = module_call(r#"module "vpc" { source = "../../modules/vpc", cidr_block }"#);
+
+        assert_eq!(
+            behavior.cross_language_targets(
+                "module.vpc",
+                SymbolKind::Module,
+                call.signature.as_deref(),
+                None
+            ),
+            vec![(behavior.language_id(), "var.cidr_block".to_string())]
+        );
+        assert_eq!(
+            behavior.cross_language_target_rank(&call, &variable("var.cidr_block", "modules/vpc")),
+            Some(0)
+        );
+        assert_eq!(
+            behavior.cross_language_target_rank(&call, &variable("var.cidr_block", "envs/prod")),
+            None
+        );
+
+        let remote =
+            module_call(r#"module "vpc" { source = "terraform-aws-modules/vpc/aws", cidr }"#);
+        assert_eq!(
+            behavior.cross_language_target_rank(&remote, &variable("var.cidr", "modules/vpc")),
+            None
+        );
+    }
+}
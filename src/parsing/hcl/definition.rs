@@ -0,0 +1,89 @@
+//! HCL language definition for the registry
+//!
+//! Provides the HCL language implementation, covering Terraform
+//! configurations, that self-registers with the global registry.
+
+use std::sync::Arc;
+
+use super::{HclBehavior, HclParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// HCL language definition
+pub struct HclLanguage;
+
+impl HclLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("hcl");
+}
+
+impl LanguageDefinition for HclLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "HCL"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["tf", "hcl"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = HclParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(HclBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // HCL is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // HCL is enabled by default
+    }
+}
+
+/// Register HCL language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(HclLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_hcl_definition() {
+        let hcl = HclLanguage;
+
+        assert_eq!(hcl.id(), LanguageId::new("hcl"));
+        assert_eq!(hcl.name(), "HCL");
+        assert!(hcl.extensions().contains(&"tf"));
+    }
+
+    #[test]
+    fn test_hcl_enabled_by_default() {
+        let hcl = HclLanguage;
+        let settings = Settings::default();
+
+        assert!(hcl.default_enabled());
+        assert!(hcl.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_hcl_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("hcl")));
+    }
+}
@@ -0,0 +1,22 @@
+//! HCL language parser implementation
+//!
+//! Indexes the resources, data sources, modules, variables, outputs and
+//! locals of Terraform configurations under their Terraform addresses, and
+//! links each module call to the module its `source` names (see
+//! [`modules`]), so infrastructure code can be navigated like application
+//! code.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod modules;
+pub mod parser;
+pub mod resolution;
+
+pub use behavior::HclBehavior;
+pub use definition::HclLanguage;
+pub use parser::HclParser;
+pub use resolution::HclResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
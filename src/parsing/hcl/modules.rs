@@ -0,0 +1,135 @@
+//! Terraform module calls
+//!
+//! A `module` block instantiates the module in its `source` directory and
+//! sets that module's input variables from its other arguments:
+//!
+//! ```hcl
+//! module "vpc" {
+//!   source     = "./modules/vpc"
+//!   cidr_block = var.cidr
+//! }
+//! ```
+//!
+//! The parser records the call in the block's signature,
+//! `module "vpc" { source = "./modules/vpc", cidr_block }`, from which the
+//! behavior links `module.vpc` to `var.cidr_block` of `modules/vpc`. Only
+//! local sources (`./`, `../`) name a directory of the project; registry
+//! and git sources are left unlinked.
+
+/// Arguments of a `module` block that configure the call itself rather
+/// than set an input variable
+pub const META_ARGUMENTS: &[&str] = &[
+    "source",
+    "version",
+    "providers",
+    "count",
+    "for_each",
+    "depends_on",
+];
+
+/// `module "vpc" { source = "./modules/vpc", cidr_block, azs }`
+pub fn module_signature(name: &str, source: Option<&str>, inputs: &[&str]) -> String {
+    let mut arguments: Vec<String> = Vec::new();
+    if let Some(source) = source {
+        arguments.push(format!("source = \"{source}\""));
+    }
+    arguments.extend(inputs.iter().map(|input| input.to_string()));
+
+    if arguments.is_empty() {
+        format!("module \"{name}\"")
+    } else {
+        format!("module \"{name}\" {{ {} }}", arguments.join(", "))
+    }
+}
+
+/// Source and input names of a module call signature built by
+/// [`module_signature`]
+pub fn parse_module_signature(signature: &str) -> Option<(Option<&str>, Vec<&str>)> {
+    let body = signature
+        .strip_prefix("module ")?
+        .split_once(" { ")?
+        .1
+        .strip_suffix(" }")?;
+
+    let mut source = None;
+    let mut inputs = Vec::new();
+    for argument in body.split(", ") {
+        match argument.strip_prefix("source = ") {
+            Some(quoted) => source = quoted.strip_prefix('"')?.strip_suffix('"'),
+            None => inputs.push(argument),
+        }
+    }
+    Some((source, inputs))
+}
+
+/// Module path of the directory a local `source` names, relative to the
+/// calling module's directory: `../network` called from `envs/prod` is
+/// `envs/network`. `None` for registry, git and other remote sources.
+pub fn local_module_dir(source: &str, caller_dir: &str) -> Option<String> {
+    if !(source.starts_with("./") || source.starts_with("../")) {
+        return None;
+    }
+
+    let mut segments: Vec<&str> = caller_dir
+        .split('/')
+        .filter(|segment| !segment.is_empty() && *segment != ".")
+        .collect();
+    for part in source.split('/') {
+        match part {
+            "" | "." => {}
+            ".." => {
+                segments.pop()?;
+            }
+            other => segments.push(other),
+        }
+    }
+
+    if segments.is_empty() {
+        Some(".".to_string())
+    } else {
+        Some(segments.join("/"))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_signature_round_trip() {
+        let signature = module_signature("vpc", Some("./modules/vpc"), &["cidr_block", "azs"]);
+        assert_eq!(
+            signature,
+            r#"module "vpc" { source = "./modules/vpc", cidr_block, azs }"#
+        );
+        assert_eq!(
+            parse_module_signature(&signature),
+            Some((Some("./modules/vpc"), vec!["cidr_block", "azs"]))
+        );
+
+        assert_eq!(module_signature("empty", None, &[]), r#"module "empty""#);
+        assert_eq!(parse_module_signature(r#"module "empty""#), None);
+    }
+
+    #[test]
+    fn test_local_module_dir() {
+        assert_eq!(
+            local_module_dir("./modules/vpc", "."),
+            Some("modules/vpc".to_string())
+        );
+        assert_eq!(
+            local_module_dir("../network", "envs/prod"),
+            Some("envs/network".to_string())
+        );
+        assert_eq!(
+            local_module_dir("../..", "envs/prod"),
+            Some(".".to_string())
+        );
+        assert_eq!(local_module_dir("../outside", "."), None);
+        assert_eq!(local_module_dir("terraform-aws-modules/vpc/aws", "."), None);
+        assert_eq!(
+            local_module_dir("git::https://example.com/vpc.git", "."),
+            None
+        );
+    }
+}
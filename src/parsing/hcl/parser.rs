@@ -0,0 +1,656 @@
+//! HCL language parser implementation
+//!
+//! Extracts the top-level blocks of Terraform configurations (and other
+//! HCL files) using tree-sitter-hcl. Symbols are named by the address
+//! Terraform uses to reference them.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | Name | SymbolKind |
+//! |-----------|------|------------|
+//! | `resource "aws_instance" "web"` | `aws_instance.web` | Struct |
+//! | `data "aws_ami" "ubuntu"` | `data.aws_ami.ubuntu` | Struct |
+//! | `module "vpc"` | `module.vpc` | Module |
+//! | `variable "region"` | `var.region` | Variable |
+//! | `output "vpc_id"` | `output.vpc_id` | Variable |
+//! | `name = ...` in `locals` | `local.name` | Variable |
+//! | other labelled blocks (`provider "aws"`, `job "api"`) | `provider.aws` | Struct |
+//!
+//! ## Relationships
+//!
+//! Every symbol uses the addresses its arguments reference
+//! (`var.region`, `local.tags`, `aws_instance.web.id`,
+//! `module.vpc.vpc_id`, `depends_on = [...]`). A module's `source` is an
+//! import, and its other arguments set the variables of the module it
+//! calls (see [`super::modules`]).
+//!
+//! ## Documentation
+//!
+//! The `description` argument of variables and outputs is their doc
+//! comment; other blocks use the `#`, `//` or `/* */` comments directly
+//! above them.
+
+use super::modules;
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, NodeTracker, NodeTrackingState, ParserContext,
+};
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Roots of references that name no block: `each.value`, `count.index`,
+/// `path.module`
+const BUILTIN_ROOTS: &[&str] = &["each", "count", "path", "terraform", "self"];
+
+/// HCL-specific parsing errors
+#[derive(Error, Debug)]
+pub enum HclParseError {
+    #[error(
+        "Failed to initialize HCL parser: {reason}\nSuggestion: Ensure tree-sitter-hcl is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// HCL language parser
+pub struct HclParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for HclParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("HclParser")
+            .field("language", &"HCL")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Whitespace-collapsed text
+fn collapse(text: &str) -> String {
+    text.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+fn child_of_kind<'t>(node: &Node<'t>, kind: &str) -> Option<Node<'t>> {
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find(|child| child.kind() == kind)
+}
+
+/// `"text"` -> `text`, for strings without interpolation
+fn string_value<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    let text = code[node.byte_range()].trim();
+    let inner = text.strip_prefix('"')?.strip_suffix('"')?;
+    (!inner.contains("${")).then_some(inner)
+}
+
+/// A block's type and labels: `resource "aws_instance" "web"` ->
+/// (`resource`, [`aws_instance`, `web`])
+fn block_header<'a>(block: &Node, code: &'a str) -> Option<(&'a str, Vec<&'a str>)> {
+    let mut cursor = block.walk();
+    let mut parts = block
+        .named_children(&mut cursor)
+        .take_while(|child| child.kind() != "block_start" && child.kind() != "body");
+    let block_type = parts.next().filter(|node| node.kind() == "identifier")?;
+    let labels = parts
+        .filter_map(|label| match label.kind() {
+            "string_lit" => string_value(&label, code),
+            "identifier" => Some(&code[label.byte_range()]),
+            _ => None,
+        })
+        .collect();
+    Some((&code[block_type.byte_range()], labels))
+}
+
+/// Arguments of a block body, as (name, attribute node)
+fn attributes<'a, 't>(body: &Node<'t>, code: &'a str) -> Vec<(&'a str, Node<'t>)> {
+    let mut cursor = body.walk();
+    body.named_children(&mut cursor)
+        .filter(|child| child.kind() == "attribute")
+        .filter_map(|attribute| {
+            let name = child_of_kind(&attribute, "identifier")?;
+            Some((&code[name.byte_range()], attribute))
+        })
+        .collect()
+}
+
+/// Value of an argument: `source = "./vpc"` -> `"./vpc"` node
+fn attribute_value<'t>(body: &Node<'t>, name: &str, code: &str) -> Option<Node<'t>> {
+    attributes(body, code)
+        .into_iter()
+        .find(|(attribute, _)| *attribute == name)
+        .and_then(|(_, attribute)| child_of_kind(&attribute, "expression"))
+}
+
+/// Name and kind a top-level block is indexed under
+fn address(block_type: &str, labels: &[&str]) -> Option<(String, SymbolKind)> {
+    match (block_type, labels) {
+        ("resource", [resource_type, name]) => {
+            Some((format!("{resource_type}.{name}"), SymbolKind::Struct))
+        }
+        ("data", [data_type, name]) => {
+            Some((format!("data.{data_type}.{name}"), SymbolKind::Struct))
+        }
+        ("module", [name]) => Some((format!("module.{name}"), SymbolKind::Module)),
+        ("variable", [name]) => Some((format!("var.{name}"), SymbolKind::Variable)),
+        ("output", [name]) => Some((format!("output.{name}"), SymbolKind::Variable)),
+        (_, []) => None,
+        (block_type, labels) => Some((
+            format!("{block_type}.{}", labels.join(".")),
+            SymbolKind::Struct,
+        )),
+    }
+}
+
+impl HclParser {
+    /// Create a new HCL parser instance
+    pub fn new() -> Result<Self, HclParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_hcl::LANGUAGE.into())
+            .map_err(|e| HclParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    /// Top-level blocks of a configuration
+    fn top_level<'t>(root: Node<'t>) -> Vec<Node<'t>> {
+        let Some(body) = child_of_kind(&root, "body") else {
+            return Vec::new();
+        };
+        let mut cursor = body.walk();
+        body.named_children(&mut cursor)
+            .filter(|child| child.kind() == "block")
+            .collect()
+    }
+
+    /// Indexed symbols of a configuration as (name, kind, node, signature),
+    /// `locals` expanded to one entry per value
+    fn definitions<'t>(root: Node<'t>, code: &str) -> Vec<(String, SymbolKind, Node<'t>, String)> {
+        let mut definitions = Vec::new();
+        for block in Self::top_level(root) {
+            let Some((block_type, labels)) = block_header(&block, code) else {
+                continue;
+            };
+            let body = child_of_kind(&block, "body");
+
+            if block_type == "locals" {
+                let Some(body) = body else { continue };
+                for (name, attribute) in attributes(&body, code) {
+                    let signature = code[attribute.byte_range()]
+                        .lines()
+                        .next()
+                        .unwrap_or_default()
+                        .trim()
+                        .to_string();
+                    definitions.push((
+                        format!("local.{name}"),
+                        SymbolKind::Variable,
+                        attribute,
+                        signature,
+                    ));
+                }
+                continue;
+            }
+
+            let Some((name, kind)) = address(block_type, &labels) else {
+                continue;
+            };
+            let header_end = child_of_kind(&block, "block_start")
+                .map(|start| start.start_byte())
+                .unwrap_or(block.end_byte());
+            let header = collapse(&code[block.start_byte()..header_end]);
+
+            let signature = match (block_type, body) {
+                ("module", Some(body)) => {
+                    let source = attribute_value(&body, "source", code)
+                        .and_then(|value| string_value(&value, code));
+                    let inputs: Vec<&str> = attributes(&body, code)
+                        .into_iter()
+                        .map(|(argument, _)| argument)
+                        .filter(|argument| !modules::META_ARGUMENTS.contains(argument))
+                        .collect();
+                    modules::module_signature(labels[0], source, &inputs)
+                }
+                ("variable", Some(body)) => match attribute_value(&body, "type", code) {
+                    Some(value) => format!(
+                        "{header} {{ type = {} }}",
+                        collapse(&code[value.byte_range()])
+                    ),
+                    None => header,
+                },
+                _ => header,
+            };
+            definitions.push((name, kind, block, signature));
+        }
+        definitions
+    }
+
+    /// Addresses referenced under `node`: `var.region`,
+    /// `aws_instance.web` (from `aws_instance.web.id`),
+    /// `data.aws_ami.ubuntu`
+    fn collect_references(
+        node: Node,
+        code: &str,
+        references: &mut Vec<(String, Range)>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            if child.kind() != "variable_expr" {
+                Self::collect_references(child, code, references, depth + 1);
+                continue;
+            }
+
+            let root = &code[child.byte_range()];
+            // `var.region.name` is `variable_expr` followed by `get_attr`
+            // siblings
+            let mut segments = vec![root];
+            let mut end = child;
+            let mut next = child.next_named_sibling();
+            while let Some(attr) = next.filter(|n| n.kind() == "get_attr") {
+                if let Some(identifier) = child_of_kind(&attr, "identifier") {
+                    segments.push(&code[identifier.byte_range()]);
+                }
+                end = attr;
+                next = attr.next_named_sibling();
+            }
+
+            let length = match root {
+                _ if BUILTIN_ROOTS.contains(&root) => continue,
+                "data" => 3,
+                _ => 2,
+            };
+            if segments.len() < length {
+                continue;
+            }
+            let start = child.start_position();
+            let finish = end.end_position();
+            references.push((
+                segments[..length].join("."),
+                Range::new(
+                    start.row as u32,
+                    start.column as u16,
+                    finish.row as u32,
+                    finish.column as u16,
+                ),
+            ));
+        }
+    }
+
+    /// `# text`, `// text`, `/* text */` -> `text`
+    fn comment_text(node: &Node, code: &str) -> String {
+        let text = code[node.byte_range()].trim();
+        if let Some(block) = text.strip_prefix("/*") {
+            return block
+                .trim_end_matches("*/")
+                .lines()
+                .map(|line| line.trim().trim_start_matches('*').trim())
+                .collect::<Vec<_>>()
+                .join("\n")
+                .trim()
+                .to_string();
+        }
+        text.trim_start_matches('#')
+            .trim_start_matches("//")
+            .trim()
+            .to_string()
+    }
+}
+
+impl LanguageParser for HclParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+
+        let Some(tree) = self.parser.parse(code, None) else {
+            return Vec::new();
+        };
+
+        let mut symbols = Vec::new();
+        for (name, kind, node, signature) in Self::definitions(tree.root_node(), code) {
+            self.register_handled_node(node.kind(), node.kind_id());
+
+            let mut symbol = Symbol::new(
+                symbol_counter.next_id(),
+                name,
+                kind,
+                file_id,
+                range_from_node(&node),
+            )
+            .with_signature(signature)
+            .with_visibility(Visibility::Public);
+            if let Some(doc) = self.extract_doc_comment(&node, code) {
+                symbol = symbol.with_doc(doc);
+            }
+            symbol.scope_context = Some(self.context.current_scope_context());
+            symbols.push(symbol);
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// The `description` argument, else comment lines directly above
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        if node.kind() == "block" {
+            let description = child_of_kind(node, "body")
+                .and_then(|body| attribute_value(&body, "description", code))
+                .and_then(|value| string_value(&value, code));
+            if let Some(description) = description.filter(|text| !text.trim().is_empty()) {
+                return Some(description.trim().to_string());
+            }
+        }
+
+        let mut lines = Vec::new();
+        let mut next_row = node.start_position().row;
+        let mut current = node.prev_named_sibling();
+        while let Some(prev) = current {
+            if prev.kind() != "comment" || prev.end_position().row + 1 != next_row {
+                break;
+            }
+            lines.push(Self::comment_text(&prev, code));
+            next_row = prev.start_position().row;
+            current = prev.prev_named_sibling();
+        }
+
+        if lines.is_empty() {
+            return None;
+        }
+        lines.reverse();
+        let doc = lines.join("\n").trim().to_string();
+        (!doc.is_empty()).then_some(doc)
+    }
+
+    /// Function calls are to Terraform's built-ins, which are not indexed
+    fn find_calls<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_implementations<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    /// References use addresses, which are built from block labels; see
+    /// [`Self::find_uses_owned`]
+    fn find_uses<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    /// Addresses referenced by each block, local value and output
+    fn find_uses_owned(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let mut uses = Vec::new();
+        let Some(tree) = self.parser.parse(code, None) else {
+            return uses;
+        };
+
+        for (name, _, node, _) in Self::definitions(tree.root_node(), code) {
+            let mut references = Vec::new();
+            Self::collect_references(node, code, &mut references, 0);
+
+            let mut seen = HashSet::new();
+            for (target, range) in references {
+                if target != name && seen.insert(target.clone()) {
+                    uses.push((name.clone(), target, range));
+                }
+            }
+        }
+        uses
+    }
+
+    fn find_defines<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    /// `source` of each `module` block, aliased by the module's name
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let Some(tree) = self.parser.parse(code, None) else {
+            return Vec::new();
+        };
+
+        Self::top_level(tree.root_node())
+            .into_iter()
+            .filter_map(|block| {
+                let (block_type, labels) = block_header(&block, code)?;
+                if block_type != "module" || labels.len() != 1 {
+                    return None;
+                }
+                let body = child_of_kind(&block, "body")?;
+                let source = attribute_value(&body, "source", code)
+                    .and_then(|value| string_value(&value, code))?;
+                Some(Import {
+                    path: source.to_string(),
+                    alias: Some(labels[0].to_string()),
+                    file_id,
+                    is_glob: false,
+                    is_type_only: false,
+                })
+            })
+            .collect()
+    }
+
+    fn language(&self) -> Language {
+        Language::Hcl
+    }
+}
+
+impl NodeTracker for HclParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::symbol::ScopeContext;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = HclParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(HclParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_blocks_are_addressed() {
+        let code = r#"
+variable "region" {
+  type        = string
+  description = "AWS region to deploy into"
+}
+
+# Web frontend
+resource "aws_instance" "web" {
+  ami = data.aws_ami.ubuntu.id
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+output "web_ip" {
+  value = aws_instance.web.public_ip
+}
+
+provider "aws" {
+  region = var.region
+}
+
+terraform {
+  required_version = ">= 1.5"
+}
+"#;
+        let symbols = parse(code);
+
+        let region = find(&symbols, "var.region");
+        assert_eq!(region.kind, SymbolKind::Variable);
+        assert_eq!(
+            region.signature.as_deref(),
+            Some(r#"variable "region" { type = string }"#)
+        );
+        assert_eq!(
+            region.doc_comment.as_deref(),
+            Some("AWS region to deploy into")
+        );
+        assert_eq!(region.scope_context, Some(ScopeContext::Module));
+
+        let web = find(&symbols, "aws_instance.web");
+        assert_eq!(web.kind, SymbolKind::Struct);
+        assert_eq!(
+            web.signature.as_deref(),
+            Some(r#"resource "aws_instance" "web""#)
+        );
+        assert_eq!(web.doc_comment.as_deref(), Some("Web frontend"));
+
+        assert_eq!(
+            find(&symbols, "data.aws_ami.ubuntu").kind,
+            SymbolKind::Struct
+        );
+        assert_eq!(find(&symbols, "output.web_ip").kind, SymbolKind::Variable);
+        assert_eq!(find(&symbols, "provider.aws").kind, SymbolKind::Struct);
+        assert_eq!(symbols.len(), 5, "unlabelled blocks are not indexed");
+    }
+
+    #[test]
+    fn test_locals_and_modules() {
+        let code = r#"
+locals {
+  name = "web"
+  tags = {
+    Name = local.name
+  }
+}
+
+module "vpc" {
+  source     = "./modules/vpc"
+  version    = "1.0.0"
+  cidr_block = var.cidr
+  azs        = ["a", "b"]
+}
+"#;
+        let symbols = parse(code);
+
+        let name = find(&symbols, "local.name");
+        assert_eq!(name.kind, SymbolKind::Variable);
+        assert_eq!(name.signature.as_deref(), Some(r#"name = "web""#));
+        assert_eq!(
+            find(&symbols, "local.tags").signature.as_deref(),
+            Some("tags = {")
+        );
+
+        let vpc = find(&symbols, "module.vpc");
+        assert_eq!(vpc.kind, SymbolKind::Module);
+        assert_eq!(
+            vpc.signature.as_deref(),
+            Some(r#"module "vpc" { source = "./modules/vpc", cidr_block, azs }"#)
+        );
+
+        let mut parser = HclParser::new().unwrap();
+        let imports = parser.find_imports(code, FileId::new(1).unwrap());
+        assert_eq!(imports.len(), 1);
+        assert_eq!(imports[0].path, "./modules/vpc");
+        assert_eq!(imports[0].alias.as_deref(), Some("vpc"));
+    }
+
+    #[test]
+    fn test_references() {
+        let code = r#"
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  subnet_id     = module.vpc.public_subnets[0]
+  tags          = merge(local.tags, { Name = "web-${var.env}" })
+  count         = var.instances
+  depends_on    = [aws_security_group.web]
+
+  dynamic "ebs_block_device" {
+    for_each = var.volumes
+    content {
+      device_name = ebs_block_device.value.name
+    }
+  }
+
+  lifecycle {
+    ignore_changes = [tags]
+  }
+}
+
+output "ip" {
+  value = aws_instance.web[0].public_ip
+  path  = path.module
+}
+"#;
+        let mut parser = HclParser::new().unwrap();
+        let uses = parser.find_uses_owned(code);
+        let targets_of = |owner: &str| -> Vec<String> {
+            uses.iter()
+                .filter(|(from, _, _)| from == owner)
+                .map(|(_, to, _)| to.clone())
+                .collect()
+        };
+
+        let web = targets_of("aws_instance.web");
+        for target in [
+            "data.aws_ami.ubuntu",
+            "module.vpc",
+            "local.tags",
+            "var.env",
+            "var.instances",
+            "aws_security_group.web",
+            "var.volumes",
+        ] {
+            assert!(web.contains(&target.to_string()), "{target} in {web:?}");
+        }
+        assert!(!web.iter().any(|target| target.starts_with("count.")));
+
+        assert_eq!(targets_of("output.ip"), vec!["aws_instance.web"]);
+    }
+}
@@ -0,0 +1,215 @@
+//! HCL-specific symbol resolution
+//!
+//! A Terraform module is a directory: every `.tf` file in it shares one
+//! namespace of addresses (`var.region`, `local.name`, `aws_instance.web`,
+//! `module.vpc`). Resolution checks:
+//! - Iterators of the current `for` expression or `dynamic` block
+//! - Addresses declared in the file itself
+//! - Addresses declared in the other files of the module
+
+use crate::parsing::resolution::{ImportBinding, default_compatible_relationship};
+use crate::parsing::{Import, ResolutionScope, ScopeLevel, ScopeType};
+use crate::{FileId, RelationKind, SymbolId};
+use std::collections::HashMap;
+
+/// HCL resolution context
+///
+/// Tracks iterator, file-level and module-level scopes.
+pub struct HclResolutionContext {
+    #[allow(dead_code)]
+    file_id: FileId,
+
+    /// `for` and `dynamic` iterators
+    local_scope: HashMap<String, SymbolId>,
+
+    /// Addresses declared in this file
+    module_scope: HashMap<String, SymbolId>,
+
+    /// Addresses declared in the other files of the module
+    imported_scope: HashMap<String, SymbolId>,
+
+    /// Scope stack for nested contexts
+    scope_stack: Vec<ScopeType>,
+
+    /// Import bindings keyed by visible name
+    import_bindings: HashMap<String, ImportBinding>,
+}
+
+impl HclResolutionContext {
+    pub fn new(file_id: FileId) -> Self {
+        Self {
+            file_id,
+            local_scope: HashMap::new(),
+            module_scope: HashMap::new(),
+            imported_scope: HashMap::new(),
+            scope_stack: Vec::new(),
+            import_bindings: HashMap::new(),
+        }
+    }
+}
+
+impl ResolutionScope for HclResolutionContext {
+    fn as_any_mut(&mut self) -> &mut dyn std::any::Any {
+        self
+    }
+
+    fn resolve(&self, name: &str) -> Option<SymbolId> {
+        if let Some(&id) = self.local_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.module_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.imported_scope.get(name) {
+            return Some(id);
+        }
+
+        None
+    }
+
+    fn add_symbol(&mut self, name: String, symbol_id: SymbolId, scope_level: ScopeLevel) {
+        match scope_level {
+            ScopeLevel::Local => {
+                self.local_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Module | ScopeLevel::Global => {
+                self.module_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Package => {
+                self.imported_scope.insert(name, symbol_id);
+            }
+        }
+    }
+
+    fn enter_scope(&mut self, scope_type: ScopeType) {
+        self.scope_stack.push(scope_type);
+    }
+
+    fn exit_scope(&mut self) {
+        if let Some(scope) = self.scope_stack.pop() {
+            if matches!(scope, ScopeType::Block) {
+                self.clear_local_scope();
+            }
+        }
+    }
+
+    fn clear_local_scope(&mut self) {
+        self.local_scope.clear();
+    }
+
+    fn symbols_in_scope(&self) -> Vec<(String, SymbolId, ScopeLevel)> {
+        let mut symbols = Vec::new();
+
+        for (name, &id) in &self.local_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Local));
+        }
+        for (name, &id) in &self.module_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Module));
+        }
+        for (name, &id) in &self.imported_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Package));
+        }
+
+        symbols
+    }
+
+    fn resolve_relationship(
+        &self,
+        _from_name: &str,
+        to_name: &str,
+        _kind: RelationKind,
+        _from_file: FileId,
+    ) -> Option<SymbolId> {
+        self.resolve(to_name)
+    }
+
+    fn populate_imports(&mut self, _imports: &[Import]) {
+        // A module `source` names a directory whose addresses are private
+        // to it; its inputs are linked through the module call
+    }
+
+    fn register_import_binding(&mut self, binding: ImportBinding) {
+        self.import_bindings
+            .insert(binding.exposed_name.clone(), binding);
+    }
+
+    fn import_binding(&self, name: &str) -> Option<ImportBinding> {
+        self.import_bindings.get(name).cloned()
+    }
+
+    /// Blocks, locals and outputs reference each other by address, and
+    /// module calls set the variables of the module they instantiate
+    fn is_compatible_relationship(
+        &self,
+        from_kind: crate::SymbolKind,
+        to_kind: crate::SymbolKind,
+        rel_kind: crate::RelationKind,
+    ) -> bool {
+        use crate::RelationKind::*;
+        use crate::SymbolKind::*;
+
+        let is_address = |kind| matches!(kind, Struct | Module | Variable);
+        match rel_kind {
+            Uses | UsedBy => is_address(from_kind) && is_address(to_kind),
+            Calls if from_kind == Module => to_kind == Variable,
+            CalledBy if to_kind == Module => from_kind == Variable,
+            _ => default_compatible_relationship(from_kind, to_kind, rel_kind),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_scope_resolution_order() {
+        let mut context = HclResolutionContext::new(FileId::new(1).unwrap());
+
+        let local_id = SymbolId::new(1).unwrap();
+        let module_id = SymbolId::new(2).unwrap();
+        let import_id = SymbolId::new(3).unwrap();
+
+        context.add_symbol("x".to_string(), import_id, ScopeLevel::Package);
+        context.add_symbol("x".to_string(), module_id, ScopeLevel::Module);
+        context.add_symbol("x".to_string(), local_id, ScopeLevel::Local);
+
+        assert_eq!(context.resolve("x"), Some(local_id));
+        context.clear_local_scope();
+        assert_eq!(context.resolve("x"), Some(module_id));
+    }
+
+    #[test]
+    fn test_iterators_end_with_block() {
+        let mut context = HclResolutionContext::new(FileId::new(1).unwrap());
+        let id = SymbolId::new(4).unwrap();
+
+        context.enter_scope(ScopeType::Block);
+        context.add_symbol("rule".to_string(), id, ScopeLevel::Local);
+        assert_eq!(context.resolve("rule"), Some(id));
+
+        context.exit_scope();
+        assert_eq!(context.resolve("rule"), None);
+    }
+
+    #[test]
+    fn test_addresses_and_module_inputs() {
+        let context = HclResolutionContext::new(FileId::new(1).unwrap());
+
+        assert!(context.is_compatible_relationship(
+            crate::SymbolKind::Variable,
+            crate::SymbolKind::Struct,
+            RelationKind::Uses
+        ));
+        assert!(context.is_compatible_relationship(
+            crate::SymbolKind::Module,
+            crate::SymbolKind::Variable,
+            RelationKind::Calls
+        ));
+        assert!(!context.is_compatible_relationship(
+            crate::SymbolKind::Struct,
+            crate::SymbolKind::Variable,
+            RelationKind::Calls
+        ));
+    }
+}
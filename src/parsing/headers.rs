@@ -0,0 +1,102 @@
+//! Headers of C and C++ and the includes naming them
+//!
+//! A header declares what its source file defines: `widget.h` declares
+//! `int area(const Widget *w);`, `widget.c` defines it. Both files share a
+//! module path (`widget`), as module paths drop the extension, so an
+//! `#include "widget.h"` imports the symbols of either. Declarations and
+//! definitions are paired by the indexing pipeline, which links each
+//! definition to its declaration and sends calls of a declaration to the
+//! definition.
+
+use std::path::Path;
+
+/// Extensions of C headers
+pub const C_HEADERS: &[&str] = &["h"];
+
+/// Extensions of C++ headers
+pub const CPP_HEADERS: &[&str] = &["h", "hh", "hpp", "hxx", "h++"];
+
+/// Whether the file at `path` is a header, by its extension
+pub fn is_header(path: &str, headers: &[&str]) -> bool {
+    Path::new(path)
+        .extension()
+        .and_then(|ext| ext.to_str())
+        .is_some_and(|ext| {
+            headers
+                .iter()
+                .any(|header| header.eq_ignore_ascii_case(ext))
+        })
+}
+
+/// Whether the include `#include "<include>"` names the file defining the
+/// symbol at `symbol_module_path` (`widget::area`).
+///
+/// The include is read relative to the including file or an include
+/// directory, neither of which the module path records, so its segments
+/// (without `./`, `../` and the extension) need only end the symbol's
+/// module: `ui/widget.h` and `widget.h` both name `app::ui::widget`.
+/// Segments are separated by `/` as written or `sep` once normalized.
+pub fn include_matches_module(include: &str, symbol_module_path: &str, sep: &str) -> bool {
+    let include = include.replace(sep, "/");
+    let include = match include.rsplit_once('.') {
+        Some((stem, ext)) if !stem.is_empty() && !ext.contains('/') => stem,
+        _ => include.as_str(),
+    };
+    let segments: Vec<&str> = include
+        .split('/')
+        .filter(|segment| !matches!(*segment, "" | "." | ".."))
+        .collect();
+    if segments.is_empty() {
+        return false;
+    }
+    let included = segments.join(sep);
+
+    let Some((module, _)) = symbol_module_path.rsplit_once(sep) else {
+        return false;
+    };
+    module == included
+        || module
+            .strip_suffix(included.as_str())
+            .is_some_and(|prefix| prefix.ends_with(sep))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_is_header() {
+        assert!(is_header("src/widget.h", C_HEADERS));
+        assert!(is_header("include/Widget.HPP", CPP_HEADERS));
+        assert!(!is_header("src/widget.cpp", CPP_HEADERS));
+        assert!(!is_header("src/widget.hpp", C_HEADERS));
+        assert!(!is_header("Makefile", CPP_HEADERS));
+    }
+
+    #[test]
+    fn test_include_matches_module() {
+        assert!(include_matches_module("widget.h", "widget::area", "::"));
+        assert!(include_matches_module(
+            "ui/widget.h",
+            "app::ui::widget::area",
+            "::"
+        ));
+        assert!(include_matches_module(
+            "../ui/widget.hpp",
+            "ui::widget::resize",
+            "::"
+        ));
+        assert!(include_matches_module(
+            "ui::widget.h",
+            "ui::widget::resize",
+            "::"
+        ));
+        assert!(include_matches_module("./widget.h", "widget::area", "::"));
+
+        // The file, not a same-suffix name or the symbol itself
+        assert!(!include_matches_module("widget.h", "mywidget::area", "::"));
+        assert!(!include_matches_module("area.h", "widget::area", "::"));
+        assert!(!include_matches_module("ui/widget.h", "widget::area", "::"));
+        assert!(!include_matches_module("../", "widget::area", "::"));
+    }
+}
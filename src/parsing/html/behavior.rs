@@ -0,0 +1,189 @@
+//! HTML-specific language behavior implementation
+//!
+//! A document's module path is its path from the project root without the
+//! extension (`templates/index.html` is `templates/index`), and the
+//! document takes it as its name. Documents import nothing the index
+//! follows; the classes and ids of their elements reference the rules of
+//! stylesheets (see [`crate::parsing::css::usages`]).
+
+use super::parser::DOCUMENT_PLACEHOLDER;
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// HTML language behavior implementation
+#[derive(Clone)]
+pub struct HtmlBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl HtmlBehavior {
+    /// Create a new HTML behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_html::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for HtmlBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for HtmlBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for HtmlBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("html")
+    }
+
+    /// The document takes its module path as its name
+    fn configure_symbol(&self, symbol: &mut crate::Symbol, module_path: Option<&str>) {
+        let Some(path) = module_path else {
+            return;
+        };
+        symbol.module_path = Some(path.to_string().into());
+        if symbol.name.as_ref() == DOCUMENT_PLACEHOLDER {
+            symbol.name = crate::types::compact_string(path);
+        }
+    }
+
+    fn normalize_caller_name(&self, name: &str, file_id: FileId) -> String {
+        if name == DOCUMENT_PLACEHOLDER {
+            self.get_module_path_for_file(file_id)
+                .unwrap_or_else(|| name.to_string())
+        } else {
+            name.to_string()
+        }
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// Every element can be linked to
+    fn parse_visibility(&self, _signature: &str) -> Visibility {
+        Visibility::Public
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "/"
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &[]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("/"))
+        }
+    }
+
+    fn module_path_from_file(
+        &self,
+        file_path: &Path,
+        workspace_root: &Path,
+        extensions: &[&str],
+    ) -> Option<String> {
+        let relative_path = file_path.strip_prefix(workspace_root).ok()?;
+        let path = relative_path.to_str()?;
+        let path = extensions
+            .iter()
+            .chain(["html", "htm"].iter())
+            .find_map(|extension| path.strip_suffix(&format!(".{extension}")))
+            .unwrap_or(path);
+        let components: Vec<&str> = path
+            .split(std::path::MAIN_SEPARATOR)
+            .filter(|s| !s.is_empty())
+            .collect();
+        self.format_path_as_module(&components)
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(crate::parsing::GenericResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    fn import_matches_symbol(
+        &self,
+        _import_path: &str,
+        _symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        false
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_document_takes_module_path() {
+        let behavior = HtmlBehavior::new();
+        let root = Path::new("/project");
+        let module_path = behavior
+            .module_path_from_file(Path::new("/project/templates/index.html"), root, &[])
+            .unwrap();
+        assert_eq!(module_path, "templates/index");
+
+        let mut document = crate::Symbol::new(
+            crate::SymbolId::new(1).unwrap(),
+            DOCUMENT_PLACEHOLDER,
+            crate::SymbolKind::Module,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 20, 0),
+        );
+        let mut sidebar = crate::Symbol::new(
+            crate::SymbolId::new(2).unwrap(),
+            "#sidebar",
+            crate::SymbolKind::Constant,
+            FileId::new(1).unwrap(),
+            crate::Range::new(3, 2, 8, 8),
+        );
+        behavior.configure_symbol(&mut document, Some(&module_path));
+        behavior.configure_symbol(&mut sidebar, Some(&module_path));
+
+        assert_eq!(document.name.as_ref(), "templates/index");
+        assert_eq!(sidebar.name.as_ref(), "#sidebar");
+        assert_eq!(sidebar.module_path.as_deref(), Some("templates/index"));
+    }
+}
@@ -0,0 +1,89 @@
+//! HTML language definition for the registry
+//!
+//! Provides the HTML language implementation, covering `.html` and `.htm`
+//! documents, that self-registers with the global registry.
+
+use std::sync::Arc;
+
+use super::{HtmlBehavior, HtmlParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// HTML language definition
+pub struct HtmlLanguage;
+
+impl HtmlLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("html");
+}
+
+impl LanguageDefinition for HtmlLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "HTML"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["html", "htm"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = HtmlParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(HtmlBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // HTML is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // HTML is enabled by default
+    }
+}
+
+/// Register HTML language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(HtmlLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_html_definition() {
+        let html = HtmlLanguage;
+
+        assert_eq!(html.id(), LanguageId::new("html"));
+        assert_eq!(html.name(), "HTML");
+        assert!(html.extensions().contains(&"htm"));
+    }
+
+    #[test]
+    fn test_html_enabled_by_default() {
+        let html = HtmlLanguage;
+        let settings = Settings::default();
+
+        assert!(html.default_enabled());
+        assert!(html.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_html_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("html")));
+    }
+}
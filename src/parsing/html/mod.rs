@@ -0,0 +1,17 @@
+//! HTML language parser implementation
+//!
+//! Indexes HTML documents and the elements they identify as symbols, and
+//! links the classes and ids of their elements to the stylesheet rules
+//! styling them (see [`crate::parsing::css::usages`]).
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+
+pub use behavior::HtmlBehavior;
+pub use definition::HtmlLanguage;
+pub use parser::HtmlParser;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
@@ -0,0 +1,378 @@
+//! HTML document parser implementation
+//!
+//! Indexes HTML documents and the elements they identify using
+//! tree-sitter-html.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | Name | SymbolKind |
+//! |-----------|------|------------|
+//! | the document | its module path (`templates/index`) | Module |
+//! | `<nav id="sidebar">` | `#sidebar` | Constant |
+//!
+//! The document is named [`DOCUMENT_PLACEHOLDER`] until the behavior
+//! names it after its file, and its signature is its `<title>`. An element
+//! with an id spans its content and its signature is its opening tag; a
+//! document identifies each id once, at its first element.
+//!
+//! ## Relationships
+//!
+//! The classes and ids of elements reference the rules of stylesheets
+//! styling them (`class="btn"` references `.btn`, `id="sidebar"`
+//! references `#sidebar`), and inline styles the custom properties they
+//! read. These are found in the text (see [`crate::parsing::css::usages`])
+//! and resolved among the symbols of all stylesheets by the indexing
+//! pipeline rather than returned by this parser. The scripts of `<script>`
+//! elements are indexed by the pipeline too, when
+//! `indexing.embedded_languages` is set (see [`crate::parsing::embedded`]);
+//! the styles of `<style>` elements are not indexed.
+//!
+//! ## Documentation
+//!
+//! An `<!-- ... -->` comment directly above an element is its doc comment.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, NodeTracker, NodeTrackingState,
+    truncate_for_display,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Name of a document until the behavior names it after its file
+pub const DOCUMENT_PLACEHOLDER: &str = "<document>";
+
+/// Longest opening tag kept as a signature
+const MAX_SIGNATURE_LEN: usize = 200;
+
+/// HTML-specific parsing errors
+#[derive(Error, Debug)]
+pub enum HtmlParseError {
+    #[error(
+        "Failed to initialize HTML parser: {reason}\nSuggestion: Ensure tree-sitter-html is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// HTML language parser
+pub struct HtmlParser {
+    parser: Parser,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for HtmlParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("HtmlParser")
+            .field("language", &"HTML")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// The opening tag of an element, `<br />` when it closes itself
+fn opening_tag<'t>(element: &Node<'t>) -> Option<Node<'t>> {
+    let mut cursor = element.walk();
+    element
+        .named_children(&mut cursor)
+        .find(|child| matches!(child.kind(), "start_tag" | "self_closing_tag"))
+}
+
+/// The value of the attribute `name` of a tag, unquoted
+fn attribute<'c>(tag: &Node, code: &'c str, name: &str) -> Option<&'c str> {
+    let mut cursor = tag.walk();
+    let found = tag.named_children(&mut cursor).find(|child| {
+        child.kind() == "attribute"
+            && child
+                .named_child(0)
+                .is_some_and(|key| code[key.byte_range()].eq_ignore_ascii_case(name))
+    })?;
+    let value = found.named_child(1)?;
+    let value = match value.kind() {
+        "quoted_attribute_value" => value.named_child(0)?,
+        _ => value,
+    };
+    Some(&code[value.byte_range()])
+}
+
+/// The text of the document's first `<title>`
+fn title(node: Node, code: &str, depth: usize) -> Option<String> {
+    if !check_recursion_depth(depth, node) {
+        return None;
+    }
+    if node.kind() == "element" {
+        let tag = opening_tag(&node)?;
+        let is_title = tag
+            .named_child(0)
+            .is_some_and(|name| code[name.byte_range()].eq_ignore_ascii_case("title"));
+        if is_title {
+            let mut cursor = node.walk();
+            let text: Vec<&str> = node
+                .named_children(&mut cursor)
+                .filter(|child| child.kind() == "text")
+                .map(|child| code[child.byte_range()].trim())
+                .collect();
+            let text = text
+                .join(" ")
+                .split_whitespace()
+                .collect::<Vec<_>>()
+                .join(" ");
+            return (!text.is_empty()).then_some(text);
+        }
+    }
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find_map(|child| title(child, code, depth + 1))
+}
+
+impl HtmlParser {
+    /// Create a new HTML parser instance
+    pub fn new() -> Result<Self, HtmlParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_html::LANGUAGE.into())
+            .map_err(|e| HtmlParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    /// Elements with an id under `node`, in document order; `seen` holds
+    /// the ids already indexed
+    #[allow(clippy::too_many_arguments)]
+    fn identified(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        seen: &mut HashSet<String>,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        if node.kind() == "element" {
+            if let Some(tag) = opening_tag(&node) {
+                let id = attribute(&tag, code, "id").map(str::trim);
+                if let Some(id) =
+                    id.filter(|id| !id.is_empty() && !id.contains(char::is_whitespace))
+                {
+                    let name = format!("#{id}");
+                    if seen.insert(name.clone()) {
+                        self.register_handled_node(node.kind(), node.kind_id());
+                        let signature = code[tag.byte_range()]
+                            .split_whitespace()
+                            .collect::<Vec<_>>()
+                            .join(" ");
+                        let mut symbol = Symbol::new(
+                            counter.next_id(),
+                            name.as_str(),
+                            SymbolKind::Constant,
+                            file_id,
+                            range_from_node(&node),
+                        )
+                        .with_signature(truncate_for_display(&signature, MAX_SIGNATURE_LEN))
+                        .with_visibility(Visibility::Public);
+                        if let Some(doc) = self.extract_doc_comment(&node, code) {
+                            symbol = symbol.with_doc(doc);
+                        }
+                        symbol.scope_context = Some(ScopeContext::Module);
+                        symbols.push(symbol);
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            if child.kind() == "element" {
+                self.identified(child, code, file_id, counter, seen, symbols, depth + 1);
+            }
+        }
+    }
+}
+
+impl LanguageParser for HtmlParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        let Some(tree) = self.parser.parse(code, None) else {
+            return Vec::new();
+        };
+        let root = tree.root_node();
+        self.register_handled_node(root.kind(), root.kind_id());
+
+        let mut document = Symbol::new(
+            symbol_counter.next_id(),
+            DOCUMENT_PLACEHOLDER,
+            SymbolKind::Module,
+            file_id,
+            range_from_node(&root),
+        )
+        .with_visibility(Visibility::Public);
+        if let Some(title) = title(root, code, 0) {
+            document = document.with_signature(format!("<title>{title}</title>"));
+        }
+        document.scope_context = Some(ScopeContext::Module);
+
+        let mut symbols = vec![document];
+        let mut seen = HashSet::new();
+        self.identified(
+            root,
+            code,
+            file_id,
+            symbol_counter,
+            &mut seen,
+            &mut symbols,
+            0,
+        );
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// The `<!-- ... -->` comment ending on the line above the element
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let comment = node
+            .prev_named_sibling()
+            .filter(|prev| prev.kind() == "comment")?;
+        if comment.end_position().row + 1 != node.start_position().row {
+            return None;
+        }
+        let text = code[comment.byte_range()]
+            .trim_start_matches("<!--")
+            .trim_end_matches("-->");
+        let lines: Vec<&str> = text
+            .lines()
+            .map(str::trim)
+            .filter(|line| !line.is_empty())
+            .collect();
+        (!lines.is_empty()).then(|| lines.join("\n"))
+    }
+
+    fn find_calls<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_implementations<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    /// Style references resolve among stylesheets; see
+    /// [`crate::parsing::css::usages::find_usages`]
+    fn find_uses<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_defines<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_imports(&mut self, _code: &str, _file_id: FileId) -> Vec<Import> {
+        Vec::new()
+    }
+
+    fn language(&self) -> Language {
+        Language::Html
+    }
+}
+
+impl NodeTracker for HtmlParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = HtmlParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(HtmlParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_document_and_ids() {
+        let code = r#"<!DOCTYPE html>
+<html>
+  <head><title>
+    Team dashboard
+  </title></head>
+  <body>
+    <!-- Site navigation -->
+    <nav id="sidebar" class="menu">
+      <a class="link" href="/">Home</a>
+    </nav>
+    <input id='search' type="text" />
+    <div id="sidebar"></div>
+  </body>
+</html>
+"#;
+        let symbols = parse(code);
+        let names: Vec<&str> = symbols.iter().map(|s| s.name.as_ref()).collect();
+        assert_eq!(names, vec![DOCUMENT_PLACEHOLDER, "#sidebar", "#search"]);
+
+        let document = &symbols[0];
+        assert_eq!(document.kind, SymbolKind::Module);
+        assert_eq!(
+            document.signature.as_deref(),
+            Some("<title>Team dashboard</title>")
+        );
+
+        let sidebar = find(&symbols, "#sidebar");
+        assert_eq!(sidebar.kind, SymbolKind::Constant);
+        assert_eq!(
+            sidebar.signature.as_deref(),
+            Some(r#"<nav id="sidebar" class="menu">"#)
+        );
+        assert_eq!(sidebar.doc_comment.as_deref(), Some("Site navigation"));
+        assert_eq!((sidebar.range.start_line, sidebar.range.end_line), (7, 9));
+
+        assert_eq!(find(&symbols, "#search").range.start_line, 10);
+    }
+}
@@ -23,6 +23,13 @@ const FILE_SCOPE: &str = "<file>";
 const NODE_CLASS_DECLARATION: &str = "class_declaration";
 const NODE_INTERFACE_DECLARATION: &str = "interface_declaration";
 const NODE_ENUM_DECLARATION: &str = "enum_declaration";
+const NODE_RECORD_DECLARATION: &str = "record_declaration";
+const NODE_ENUM_CONSTANT: &str = "enum_constant";
+const NODE_ANNOTATION: &str = "annotation";
+const NODE_MARKER_ANNOTATION: &str = "marker_annotation";
+const NODE_ANNOTATION_ELEMENT_DECLARATION: &str = "annotation_type_element_declaration";
+const NODE_LOCAL_VARIABLE_DECLARATION: &str = "local_variable_declaration";
+const NODE_OBJECT_CREATION_EXPRESSION: &str = "object_creation_expression";
 const NODE_METHOD_DECLARATION: &str = "method_declaration";
 const NODE_CONSTRUCTOR_DECLARATION: &str = "constructor_declaration";
 const NODE_FIELD_DECLARATION: &str = "field_declaration";
@@ -245,6 +252,54 @@ impl JavaParser {
         Visibility::Crate
     }
 
+    /// Find the `modifiers` child of a declaration
+    ///
+    /// tree-sitter-java does not expose modifiers as a field on every
+    /// declaration kind, so scan the direct children instead.
+    fn modifiers_node<'a>(&self, node: Node<'a>) -> Option<Node<'a>> {
+        let mut cursor = node.walk();
+        let found = node
+            .children(&mut cursor)
+            .find(|child| child.kind() == NODE_MODIFIERS);
+        found
+    }
+
+    /// Check that every keyword in `required` appears in the declaration's modifiers
+    fn has_modifiers(&self, node: Node, code: &str, required: &[&str]) -> bool {
+        let Some(modifiers) = self.modifiers_node(node) else {
+            return false;
+        };
+        let mut cursor = modifiers.walk();
+        let keywords: Vec<&str> = modifiers
+            .children(&mut cursor)
+            .map(|child| self.text_for_node(code, child).trim())
+            .collect();
+        required.iter().all(|kw| keywords.contains(kw))
+    }
+
+    /// Annotation names applied to a declaration (`@Override`, `@Service("x")`)
+    ///
+    /// Returns the simple name; `@javax.inject.Inject` yields `Inject`.
+    fn annotation_names<'a>(&self, node: Node, code: &'a str) -> Vec<(&'a str, Range)> {
+        let mut names = Vec::new();
+        let Some(modifiers) = self.modifiers_node(node) else {
+            return names;
+        };
+        let mut cursor = modifiers.walk();
+        for child in modifiers.children(&mut cursor) {
+            if child.kind() == NODE_ANNOTATION || child.kind() == NODE_MARKER_ANNOTATION {
+                if let Some(name_node) = child.child_by_field_name("name") {
+                    let full = self.text_for_node(code, name_node).trim();
+                    let simple = full.rsplit('.').next().unwrap_or(full);
+                    if !simple.is_empty() {
+                        names.push((simple, self.node_to_range(child)));
+                    }
+                }
+            }
+        }
+        names
+    }
+
     // =========================================================================
     // HELPER METHODS - Signature Extraction
     // =========================================================================
@@ -333,6 +388,7 @@ impl JavaParser {
         let symbol_kind = match node.kind() {
             NODE_INTERFACE_DECLARATION => crate::SymbolKind::Interface,
             NODE_ENUM_DECLARATION => crate::SymbolKind::Enum,
+            NODE_RECORD_DECLARATION => crate::SymbolKind::Struct,
             _ => crate::SymbolKind::Class,
         };
 
@@ -530,6 +586,23 @@ impl JavaParser {
         let visibility = self.determine_visibility(node, code);
         let doc_comment = self.doc_comment_for(&node, code);
 
+        // `static final` fields are constants; everything else is a plain field
+        let field_kind = if self.has_modifiers(node, code, &["static", "final"]) {
+            crate::SymbolKind::Constant
+        } else {
+            crate::SymbolKind::Field
+        };
+
+        // Signature without initializer: "private final Map<String, User> cache"
+        let mut signature = String::with_capacity(64);
+        if let Some(modifiers) = self.modifiers_node(node) {
+            signature.push_str(self.text_for_node(code, modifiers).trim());
+            signature.push(' ');
+        }
+        if let Some(type_node) = node.child_by_field_name("type") {
+            signature.push_str(self.text_for_node(code, type_node).trim());
+        }
+
         // Field declarations can have multiple variable_declarator children
         let mut cursor = node.walk();
         for child in node.children(&mut cursor) {
@@ -542,14 +615,10 @@ impl JavaParser {
                         let symbol_id = counter.next_id();
                         let range = self.node_to_range(child);
 
-                        let mut symbol = Symbol::new(
-                            symbol_id,
-                            field_name.as_str(),
-                            crate::SymbolKind::Variable,
-                            file_id,
-                            range,
-                        );
+                        let mut symbol =
+                            Symbol::new(symbol_id, field_name.as_str(), field_kind, file_id, range);
                         symbol.visibility = visibility;
+                        symbol.signature = Some(format!("{signature} {field_name}").into());
                         if let Some(doc) = &doc_comment {
                             symbol.doc_comment = Some(doc.as_str().into());
                         }
@@ -567,6 +636,45 @@ impl JavaParser {
         }
     }
 
+    fn handle_enum_constant(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        symbols: &mut Vec<Symbol>,
+        counter: &mut SymbolCounter,
+        context: &ParserContext,
+    ) {
+        self.register_node_recursively(node);
+
+        let Some(name_node) = node.child_by_field_name("name") else {
+            return;
+        };
+        let constant_name = self.text_for_node(code, name_node).trim();
+        if constant_name.is_empty() {
+            return;
+        }
+
+        let mut symbol = Symbol::new(
+            counter.next_id(),
+            constant_name,
+            crate::SymbolKind::Constant,
+            file_id,
+            self.node_to_range(node),
+        );
+        // Enum constants are implicitly public static final
+        symbol.visibility = Visibility::Public;
+        symbol.signature = Some(constant_name.into());
+        if let Some(doc) = self.doc_comment_for(&node, code) {
+            symbol.doc_comment = Some(doc.into());
+        }
+        symbol.scope_context = Some(crate::symbol::ScopeContext::ClassMember {
+            class_name: context.current_class().map(|name| name.to_string().into()),
+        });
+
+        symbols.push(symbol);
+    }
+
     fn handle_annotation_type_declaration(
         &mut self,
         node: Node,
@@ -697,7 +805,10 @@ impl JavaParser {
         }
 
         match node.kind() {
-            NODE_CLASS_DECLARATION | NODE_INTERFACE_DECLARATION | NODE_ENUM_DECLARATION => {
+            NODE_CLASS_DECLARATION
+            | NODE_INTERFACE_DECLARATION
+            | NODE_ENUM_DECLARATION
+            | NODE_RECORD_DECLARATION => {
                 self.handle_class_declaration(
                     node,
                     code,
@@ -718,6 +829,13 @@ impl JavaParser {
             NODE_FIELD_DECLARATION => {
                 self.handle_field_declaration(node, code, file_id, symbols, counter, context);
             }
+            NODE_ENUM_CONSTANT => {
+                self.handle_enum_constant(node, code, file_id, symbols, counter, context);
+            }
+            NODE_ANNOTATION_ELEMENT_DECLARATION => {
+                // `String value() default "";` inside @interface bodies
+                self.handle_method_declaration(node, code, file_id, symbols, counter, context);
+            }
             NODE_PACKAGE_DECLARATION | NODE_IMPORT_DECLARATION => {
                 // Register recursively to track scoped_identifier chains
                 self.register_node_recursively(node);
@@ -933,7 +1051,10 @@ impl JavaParser {
         implements: &mut Vec<(&'a str, &'a str, Range)>,
     ) {
         // Check for class/enum with super_interfaces field
-        if node.kind() == NODE_CLASS_DECLARATION || node.kind() == NODE_ENUM_DECLARATION {
+        if matches!(
+            node.kind(),
+            NODE_CLASS_DECLARATION | NODE_ENUM_DECLARATION | NODE_RECORD_DECLARATION
+        ) {
             if let Some(name_node) = node.child_by_field_name("name") {
                 let class_name = self.text_for_node(code, name_node).trim();
 
@@ -1046,7 +1167,10 @@ impl JavaParser {
 
         // Track context: class name or method name
         let new_context = match node.kind() {
-            NODE_CLASS_DECLARATION | NODE_INTERFACE_DECLARATION | NODE_ENUM_DECLARATION => {
+            NODE_CLASS_DECLARATION
+            | NODE_INTERFACE_DECLARATION
+            | NODE_ENUM_DECLARATION
+            | NODE_RECORD_DECLARATION => {
                 // Extract class name
                 node.child_by_field_name("name")
                     .map(|n| self.text_for_node(code, n).trim())
@@ -1061,6 +1185,26 @@ impl JavaParser {
 
         let context = new_context.or(current_context);
 
+        // Annotations applied to a declaration are type uses of the annotation
+        // (`@Service class UserService` -> UserService uses Service). Field
+        // annotations attribute to the enclosing class.
+        if matches!(
+            node.kind(),
+            NODE_CLASS_DECLARATION
+                | NODE_INTERFACE_DECLARATION
+                | NODE_ENUM_DECLARATION
+                | NODE_RECORD_DECLARATION
+                | NODE_METHOD_DECLARATION
+                | NODE_CONSTRUCTOR_DECLARATION
+                | NODE_FIELD_DECLARATION
+        ) {
+            if let Some(ctx) = context {
+                for (annotation, range) in self.annotation_names(node, code) {
+                    uses.push((ctx, annotation, range));
+                }
+            }
+        }
+
         // Collect type references from field declarations
         if node.kind() == NODE_FIELD_DECLARATION {
             if let Some(ctx) = context {
@@ -1158,26 +1302,114 @@ impl JavaParser {
     }
 
     /// Collect method definitions recursively
-    /// TODO: Implement after tree-sitter exploration
+    ///
+    /// Emits (type_name, method_name) for every method, constructor, and
+    /// annotation element declared directly in a class, interface, enum,
+    /// record, or @interface body. Nested types report their own members.
     fn collect_method_defines<'a>(
         &self,
-        _node: Node,
-        _code: &'a str,
-        _defines: &mut Vec<(&'a str, &'a str, Range)>,
+        node: Node,
+        code: &'a str,
+        defines: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if matches!(
+            node.kind(),
+            NODE_CLASS_DECLARATION
+                | NODE_INTERFACE_DECLARATION
+                | NODE_ENUM_DECLARATION
+                | NODE_RECORD_DECLARATION
+                | "annotation_type_declaration"
+        ) {
+            if let (Some(name_node), Some(body)) = (
+                node.child_by_field_name("name"),
+                node.child_by_field_name("body"),
+            ) {
+                let type_name = self.text_for_node(code, name_node).trim();
+                self.collect_body_defines(body, code, type_name, defines);
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.children(&mut cursor) {
+            self.collect_method_defines(child, code, defines);
+        }
+    }
+
+    /// Emit defines for the member declarations of one type body
+    fn collect_body_defines<'a>(
+        &self,
+        body: Node,
+        code: &'a str,
+        type_name: &'a str,
+        defines: &mut Vec<(&'a str, &'a str, Range)>,
     ) {
-        // TODO: Find methods in classes/interfaces
+        let mut cursor = body.walk();
+        for member in body.children(&mut cursor) {
+            match member.kind() {
+                NODE_METHOD_DECLARATION
+                | NODE_CONSTRUCTOR_DECLARATION
+                | NODE_ANNOTATION_ELEMENT_DECLARATION => {
+                    if let Some(name_node) = member.child_by_field_name("name") {
+                        let method_name = self.text_for_node(code, name_node).trim();
+                        if !method_name.is_empty() {
+                            defines.push((type_name, method_name, self.node_to_range(member)));
+                        }
+                    }
+                }
+                // Enum methods live one level down, after the constant list
+                "enum_body_declarations" => {
+                    self.collect_body_defines(member, code, type_name, defines);
+                }
+                _ => {}
+            }
+        }
     }
 
     /// Collect variable type declarations
-    /// TODO: Implement after tree-sitter exploration
+    ///
+    /// Handles explicitly typed locals (`UserService svc = ...`) and
+    /// `var` locals whose initializer is a constructor call
+    /// (`var svc = new UserService()`). Generic arguments are stripped so
+    /// the binding names the class, matching field-type extraction.
     fn collect_variable_types<'a>(
         &self,
-        _node: Node,
-        _code: &'a str,
-        _var_types: &mut Vec<(&'a str, &'a str, Range)>,
+        node: Node,
+        code: &'a str,
+        var_types: &mut Vec<(&'a str, &'a str, Range)>,
     ) {
-        // TODO: Find local_variable_declaration
-        // TODO: Extract variable name and type
+        if node.kind() == NODE_LOCAL_VARIABLE_DECLARATION {
+            let declared_type = node
+                .child_by_field_name("type")
+                .filter(|t| self.text_for_node(code, *t).trim() != "var")
+                .and_then(|t| self.extract_type_name(t, code))
+                .filter(|t| !get_primitive_types().contains(t));
+
+            let mut cursor = node.walk();
+            for declarator in node.children_by_field_name("declarator", &mut cursor) {
+                let Some(name_node) = declarator.child_by_field_name("name") else {
+                    continue;
+                };
+                let var_name = self.text_for_node(code, name_node).trim();
+
+                // Prefer the declared type; fall back to `new T()` for `var`
+                let type_name = declared_type.or_else(|| {
+                    declarator
+                        .child_by_field_name("value")
+                        .filter(|v| v.kind() == NODE_OBJECT_CREATION_EXPRESSION)
+                        .and_then(|v| v.child_by_field_name("type"))
+                        .and_then(|t| self.extract_type_name(t, code))
+                });
+
+                if let Some(type_name) = type_name {
+                    var_types.push((var_name, type_name, self.node_to_range(declarator)));
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.children(&mut cursor) {
+            self.collect_variable_types(child, code, var_types);
+        }
     }
 
     /// Register a node and all its children recursively for audit tracking
@@ -1358,4 +1590,123 @@ mod tests {
         assert_eq!(call.receiver.as_deref(), Some("Integer"));
         assert!(call.is_static);
     }
+
+    fn parse_symbols(code: &str) -> Vec<Symbol> {
+        let mut parser = JavaParser::new().unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, FileId::new(1).unwrap(), &mut counter)
+    }
+
+    #[test]
+    fn test_java_fields_emit_field_and_constant_kinds() {
+        let code = r#"
+            public class Config {
+                private String name;
+                public static final int MAX = 10;
+            }
+        "#;
+        let symbols = parse_symbols(code);
+        let name = symbols.iter().find(|s| &*s.name == "name").unwrap();
+        assert_eq!(name.kind, crate::SymbolKind::Field);
+        assert_eq!(name.signature.as_deref(), Some("private String name"));
+        let max = symbols.iter().find(|s| &*s.name == "MAX").unwrap();
+        assert_eq!(max.kind, crate::SymbolKind::Constant);
+    }
+
+    #[test]
+    fn test_java_enum_constants_and_records() {
+        let code = r#"
+            enum Color { RED, GREEN; int rgb() { return 0; } }
+            record Point(int x, int y) implements Shape {}
+        "#;
+        let symbols = parse_symbols(code);
+        let red = symbols.iter().find(|s| &*s.name == "RED").unwrap();
+        assert_eq!(red.kind, crate::SymbolKind::Constant);
+        assert!(symbols.iter().any(|s| &*s.name == "rgb"));
+        let point = symbols.iter().find(|s| &*s.name == "Point").unwrap();
+        assert_eq!(point.kind, crate::SymbolKind::Struct);
+
+        let mut parser = JavaParser::new().unwrap();
+        let implements = parser.find_implementations(code);
+        assert!(
+            implements
+                .iter()
+                .any(|(t, i, _)| *t == "Point" && *i == "Shape")
+        );
+    }
+
+    #[test]
+    fn test_java_annotations_emit_uses() {
+        let code = r#"
+            @Service
+            public class UserService {
+                @Override
+                public String toString() { return ""; }
+            }
+        "#;
+        let mut parser = JavaParser::new().unwrap();
+        let uses = parser.find_uses(code);
+        assert!(
+            uses.iter()
+                .any(|(ctx, ty, _)| *ctx == "UserService" && *ty == "Service"),
+            "class annotation should be a use, got {uses:?}"
+        );
+        assert!(
+            uses.iter()
+                .any(|(ctx, ty, _)| *ctx == "toString" && *ty == "Override"),
+            "method annotation should be a use, got {uses:?}"
+        );
+    }
+
+    #[test]
+    fn test_java_find_defines() {
+        let code = r#"
+            interface Repo { void save(); }
+            class UserRepo implements Repo {
+                UserRepo() {}
+                public void save() {}
+            }
+        "#;
+        let mut parser = JavaParser::new().unwrap();
+        let defines = parser.find_defines(code);
+        assert!(defines.iter().any(|(t, m, _)| *t == "Repo" && *m == "save"));
+        assert!(
+            defines
+                .iter()
+                .any(|(t, m, _)| *t == "UserRepo" && *m == "save")
+        );
+        assert!(
+            defines
+                .iter()
+                .any(|(t, m, _)| *t == "UserRepo" && *m == "UserRepo")
+        );
+    }
+
+    #[test]
+    fn test_java_find_variable_types() {
+        let code = r#"
+            class Demo {
+                void run() {
+                    UserService svc = factory();
+                    var repo = new UserRepo();
+                    List<String> names = List.of();
+                    int count = 0;
+                }
+            }
+        "#;
+        let mut parser = JavaParser::new().unwrap();
+        let types = parser.find_variable_types(code);
+        assert!(
+            types
+                .iter()
+                .any(|(v, t, _)| *v == "svc" && *t == "UserService")
+        );
+        assert!(
+            types
+                .iter()
+                .any(|(v, t, _)| *v == "repo" && *t == "UserRepo")
+        );
+        assert!(types.iter().any(|(v, t, _)| *v == "names" && *t == "List"));
+        assert!(!types.iter().any(|(v, _, _)| *v == "count"));
+    }
 }
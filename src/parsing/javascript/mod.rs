@@ -6,6 +6,7 @@ pub mod definition;
 pub mod jsconfig;
 pub mod parser;
 pub mod resolution;
+pub(crate) mod test_blocks;
 
 pub use behavior::JavaScriptBehavior;
 pub use definition::JavaScriptLanguage;
@@ -7,6 +7,7 @@
 //! TypeScript-specific features like interfaces, type aliases, type annotations, abstract classes, etc.
 
 use crate::parsing::Import;
+use crate::parsing::javascript::test_blocks::TestCall;
 use crate::parsing::parser::check_recursion_depth;
 use crate::parsing::{
     LanguageParser, MethodCall, NodeTracker, NodeTrackingState, ParserContext, ScopeType,
@@ -440,6 +441,49 @@ impl JavaScriptParser {
                     );
                 }
             }
+            "call_expression" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                let test = TestCall::of(node, code);
+                if let Some(test) = test.filter(|test| !test.suite) {
+                    symbols.push(self.process_test_block(
+                        &test,
+                        node,
+                        file_id,
+                        counter,
+                        module_path,
+                    ));
+                }
+
+                let mut cursor = node.walk();
+                for child in node.children(&mut cursor) {
+                    self.extract_symbols_from_node(
+                        child,
+                        code,
+                        file_id,
+                        counter,
+                        symbols,
+                        module_path,
+                        depth + 1,
+                    );
+                }
+                // Anonymous arrow callbacks are not walked for symbols; those
+                // of tests and suites are, for the tests and helpers they hold
+                // (function expressions already are)
+                if let Some(body) = test
+                    .filter(|test| test.callback.kind() == "arrow_function")
+                    .and_then(|test| test.callback.child_by_field_name("body"))
+                {
+                    self.extract_symbols_from_node(
+                        body,
+                        code,
+                        file_id,
+                        counter,
+                        symbols,
+                        module_path,
+                        depth + 1,
+                    );
+                }
+            }
             _ => {
                 // Track all nodes we encounter, even if not extracting symbols
                 self.register_handled_node(node.kind(), node.kind_id());
@@ -745,6 +789,34 @@ impl JavaScriptParser {
         }
     }
 
+    /// Process a test block (`it("adds", () => ..)`) as a function named by
+    /// its description
+    fn process_test_block(
+        &mut self,
+        test: &TestCall,
+        node: Node,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        module_path: &str,
+    ) -> Symbol {
+        self.create_symbol(
+            counter.next_id(),
+            test.description.to_string(),
+            SymbolKind::Function,
+            file_id,
+            Range::new(
+                node.start_position().row as u32,
+                node.start_position().column as u16,
+                node.end_position().row as u32,
+                node.end_position().column as u16,
+            ),
+            Some(test.signature()),
+            None,
+            module_path,
+            Visibility::Private,
+        )
+    }
+
     /// Process arrow functions
     fn process_arrow_function(
         &mut self,
@@ -1196,7 +1268,10 @@ impl JavaScriptParser {
         }
         // Handle function context - track which function we're inside
         // CRITICAL: Only set NEW context when entering a function, otherwise INHERIT current context
-        let function_context = if node.kind() == "function_declaration"
+        let function_context = if let Some(test) = TestCall::described_by_callback(*node, code) {
+            // A test block's callback runs as the test it describes
+            Some(test)
+        } else if node.kind() == "function_declaration"
             || node.kind() == "generator_function_declaration"
             || node.kind() == "method_definition"
             || node.kind() == "arrow_function"
@@ -1439,7 +1514,9 @@ impl JavaScriptParser {
     ) {
         // Track function context - SAME FIX as extract_calls_recursive
         // Only set NEW context when entering a function, otherwise INHERIT
-        let function_context = if node.kind() == "function_declaration"
+        let function_context = if let Some(test) = TestCall::described_by_callback(*node, code) {
+            Some(test)
+        } else if node.kind() == "function_declaration"
             || node.kind() == "generator_function_declaration"
             || node.kind() == "method_definition"
             || node.kind() == "arrow_function"
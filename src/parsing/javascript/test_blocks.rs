@@ -0,0 +1,155 @@
+//! Test framework blocks shared by the JavaScript and TypeScript parsers
+//!
+//! Jest, Vitest and Mocha tests are anonymous callbacks passed to `it` or
+//! `test`: `it("adds numbers", () => { .. })`. The parsers index each block
+//! as a function named by its description, so the calls inside have a
+//! caller and a test can be traced to the code it exercises.
+
+use tree_sitter::Node;
+
+/// Callees defining one test
+const TEST_CALLEES: &[&str] = &["it", "test"];
+
+/// Callees grouping tests
+const SUITE_CALLEES: &[&str] = &["describe", "suite"];
+
+/// A call to a test framework: a test or a suite of tests
+#[derive(Debug, Clone, Copy)]
+pub(crate) struct TestCall<'tree, 'a> {
+    /// Whether the call groups tests (`describe`) rather than defining one
+    pub suite: bool,
+    /// The callee as written, modifiers included: `it`, `test.only`
+    pub callee: &'a str,
+    /// The description, without quotes
+    pub description: &'a str,
+    /// The function holding the test or the suite's tests
+    pub callback: Node<'tree>,
+}
+
+impl<'tree, 'a> TestCall<'tree, 'a> {
+    /// The test framework call of a `call_expression`, if it is one:
+    /// `it("adds", () => ..)`, `test.skip('adds', function () {..})` or
+    /// `describe("math", () => ..)`. Descriptions built at runtime
+    /// (template substitutions, `test.each`) are not recognized.
+    pub fn of(call: Node<'tree>, code: &'a str) -> Option<Self> {
+        if call.kind() != "call_expression" {
+            return None;
+        }
+        let function = call.child_by_field_name("function")?;
+        let base = match function.kind() {
+            "identifier" => function,
+            "member_expression" => function
+                .child_by_field_name("object")
+                .filter(|object| object.kind() == "identifier")?,
+            _ => return None,
+        };
+        let base = &code[base.byte_range()];
+        let suite = SUITE_CALLEES.contains(&base);
+        if !suite && !TEST_CALLEES.contains(&base) {
+            return None;
+        }
+
+        let arguments = call.child_by_field_name("arguments")?;
+        let mut cursor = arguments.walk();
+        let mut named = arguments.named_children(&mut cursor);
+        let description = named.next()?;
+        let callback = named
+            .last()
+            .filter(|arg| matches!(arg.kind(), "arrow_function" | "function_expression"))?;
+        let literal = match description.kind() {
+            "string" => &code[description.byte_range()],
+            "template_string" => {
+                let mut cursor = description.walk();
+                if description
+                    .named_children(&mut cursor)
+                    .any(|part| part.kind() == "template_substitution")
+                {
+                    return None;
+                }
+                &code[description.byte_range()]
+            }
+            _ => return None,
+        };
+        if literal.len() < 2 {
+            return None;
+        }
+
+        Some(Self {
+            suite,
+            callee: &code[function.byte_range()],
+            description: &literal[1..literal.len() - 1],
+            callback,
+        })
+    }
+
+    /// The description of the test a callback defines: `Some` for the
+    /// callback of an `it` or `test` call, `None` for any other function
+    pub fn described_by_callback(callback: Node<'tree>, code: &'a str) -> Option<&'a str> {
+        let arguments = callback.parent().filter(|p| p.kind() == "arguments")?;
+        let test = Self::of(arguments.parent()?, code)?;
+        (!test.suite && test.callback.id() == callback.id()).then_some(test.description)
+    }
+
+    /// Signature of the test's symbol: `it("adds numbers")`
+    pub fn signature(&self) -> String {
+        format!("{}(\"{}\")", self.callee, self.description)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn calls(code: &str) -> Vec<(bool, String, String)> {
+        let mut parser = tree_sitter::Parser::new();
+        parser
+            .set_language(&tree_sitter_javascript::LANGUAGE.into())
+            .unwrap();
+        let tree = parser.parse(code, None).unwrap();
+
+        let mut found = Vec::new();
+        let mut stack = vec![tree.root_node()];
+        while let Some(node) = stack.pop() {
+            if let Some(test) = TestCall::of(node, code) {
+                found.push((test.suite, test.signature(), test.description.to_string()));
+            }
+            let mut cursor = node.walk();
+            stack.extend(node.children(&mut cursor));
+        }
+        found.sort();
+        found
+    }
+
+    #[test]
+    fn test_calls_are_recognized_with_modifiers() {
+        let code = r#"
+describe("math", () => {
+    it("adds", () => expect(add(1, 2)).toBe(3));
+    test.only('subtracts', function () {});
+    test(`multiplies`, async () => {});
+});
+"#;
+        let found = calls(code);
+        assert_eq!(
+            found,
+            [
+                (false, "it(\"adds\")".into(), "adds".into()),
+                (false, "test(\"multiplies\")".into(), "multiplies".into()),
+                (false, "test.only(\"subtracts\")".into(), "subtracts".into()),
+                (true, "describe(\"math\")".into(), "math".into()),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_other_calls_are_ignored() {
+        let code = r#"
+it(name, () => {});
+test(`adds ${n}`, () => {});
+test.each([1, 2])("adds %d", (n) => {});
+it("pending");
+run("adds", () => {});
+"#;
+        assert!(calls(code).is_empty(), "{:?}", calls(code));
+    }
+}
@@ -0,0 +1,237 @@
+//! Jupyter-specific language behavior implementation
+//!
+//! A notebook's code is Python, so module paths, imports, visibility and
+//! resolution follow Python: `notebooks/sales.ipynb` is `notebooks.sales`,
+//! and `from utils.io import load` in a cell resolves as it would in a
+//! `.py` file. The notebook's module symbol takes the file's name, as a
+//! Python module's does; the symbols of its cells keep theirs.
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::PythonBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, SymbolKind, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Jupyter language behavior implementation
+#[derive(Clone)]
+pub struct JupyterBehavior {
+    language: Language,
+    /// Shared with `script`, so the Python rules see the notebooks' files
+    /// and imports
+    state: BehaviorState,
+    /// Module path, import and resolution rules of the code cells
+    script: PythonBehavior,
+}
+
+impl JupyterBehavior {
+    /// Create a new Jupyter behavior instance
+    pub fn new() -> Self {
+        let script = PythonBehavior::new();
+        Self {
+            language: tree_sitter_python::LANGUAGE.into(),
+            state: script.state().clone(),
+            script,
+        }
+    }
+}
+
+impl StatefulBehavior for JupyterBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for JupyterBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for JupyterBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("jupyter")
+    }
+
+    /// Cells keep their names; everything else is configured as in Python
+    fn configure_symbol(&self, symbol: &mut crate::Symbol, module_path: Option<&str>) {
+        if symbol.kind == SymbolKind::Module && symbol.name.as_ref() != "<module>" {
+            if let Some(path) = module_path {
+                symbol.module_path = Some(path.to_string().into());
+            }
+            return;
+        }
+        self.script.configure_symbol(symbol, module_path);
+    }
+
+    fn normalize_caller_name(&self, name: &str, file_id: FileId) -> String {
+        self.script.normalize_caller_name(name, file_id)
+    }
+
+    fn format_module_path(&self, base_path: &str, symbol_name: &str) -> String {
+        self.script.format_module_path(base_path, symbol_name)
+    }
+
+    fn self_receiver_aliases(&self) -> &'static [&'static str] {
+        self.script.self_receiver_aliases()
+    }
+
+    fn extract_parameter_type(&self, signature: &str, var_name: &str) -> Option<String> {
+        self.script.extract_parameter_type(signature, var_name)
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn module_separator(&self) -> &'static str {
+        self.script.module_separator()
+    }
+
+    fn supports_traits(&self) -> bool {
+        self.script.supports_traits()
+    }
+
+    fn supports_inherent_methods(&self) -> bool {
+        self.script.supports_inherent_methods()
+    }
+
+    fn module_path_from_file(
+        &self,
+        file_path: &Path,
+        project_root: &Path,
+        extensions: &[&str],
+    ) -> Option<String> {
+        self.script
+            .module_path_from_file(file_path, project_root, extensions)
+    }
+
+    fn parse_visibility(&self, signature: &str) -> Visibility {
+        self.script.parse_visibility(signature)
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        self.script.format_path_as_module(components)
+    }
+
+    fn normalize_import_path(
+        &self,
+        import_path: &str,
+        importing_module: Option<&str>,
+        file_path: &Path,
+    ) -> Option<String> {
+        self.script
+            .normalize_import_path(import_path, importing_module, file_path)
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        self.script.create_resolution_context(file_id)
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        self.script.create_inheritance_resolver()
+    }
+
+    fn build_resolution_context_with_pipeline_cache(
+        &self,
+        file_id: FileId,
+        imports: &[crate::parsing::Import],
+        cache: &dyn crate::parsing::PipelineSymbolCache,
+        extensions: &[&str],
+    ) -> (
+        Box<dyn crate::parsing::ResolutionScope>,
+        Vec<crate::parsing::Import>,
+    ) {
+        self.script
+            .build_resolution_context_with_pipeline_cache(file_id, imports, cache, extensions)
+    }
+
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        importing_module: Option<&str>,
+    ) -> bool {
+        self.script
+            .import_matches_symbol(import_path, symbol_module_path, importing_module)
+    }
+
+    fn is_resolvable_symbol(&self, symbol: &crate::Symbol) -> bool {
+        self.script.is_resolvable_symbol(symbol)
+    }
+
+    fn is_symbol_visible_from_file(&self, symbol: &crate::Symbol, from_file: FileId) -> bool {
+        self.script.is_symbol_visible_from_file(symbol, from_file)
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::symbol::ScopeContext;
+
+    fn symbol(name: &str, kind: SymbolKind) -> crate::Symbol {
+        let mut symbol = crate::Symbol::new(
+            crate::SymbolId::new(1).unwrap(),
+            name,
+            kind,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 20, 0),
+        );
+        symbol.scope_context = Some(ScopeContext::Module);
+        symbol
+    }
+
+    #[test]
+    fn test_notebook_module_takes_file_name() {
+        let behavior = JupyterBehavior::new();
+        let mut module = symbol("<module>", SymbolKind::Module);
+        let mut cell = symbol("Cell 2", SymbolKind::Module);
+        let mut helper = symbol("_clean", SymbolKind::Function).with_signature("def _clean(df):");
+
+        behavior.configure_symbol(&mut module, Some("notebooks.sales"));
+        behavior.configure_symbol(&mut cell, Some("notebooks.sales"));
+        behavior.configure_symbol(&mut helper, Some("notebooks.sales"));
+
+        assert_eq!(module.name.as_ref(), "sales");
+        assert_eq!(cell.name.as_ref(), "Cell 2");
+        assert_eq!(cell.module_path.as_deref(), Some("notebooks.sales"));
+        assert_eq!(helper.visibility, Visibility::Module);
+    }
+
+    #[test]
+    fn test_state_is_shared_with_python_rules() {
+        let behavior = JupyterBehavior::new();
+        let file_id = FileId::new(7).unwrap();
+        behavior.register_file(
+            PathBuf::from("notebooks/sales.ipynb"),
+            file_id,
+            "notebooks.sales".to_string(),
+        );
+
+        assert_eq!(behavior.normalize_caller_name("<module>", file_id), "sales");
+        assert!(behavior.import_matches_symbol(
+            "utils.io.load",
+            "utils.io",
+            Some("notebooks.sales")
+        ));
+    }
+}
@@ -0,0 +1,89 @@
+//! Jupyter language definition for the registry
+//!
+//! Provides the Jupyter language implementation, covering `.ipynb`
+//! notebooks, that self-registers with the global registry.
+
+use std::sync::Arc;
+
+use super::{JupyterBehavior, JupyterParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Jupyter language definition
+pub struct JupyterLanguage;
+
+impl JupyterLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("jupyter");
+}
+
+impl LanguageDefinition for JupyterLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Jupyter"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["ipynb"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = JupyterParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(JupyterBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Jupyter is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Jupyter is enabled by default
+    }
+}
+
+/// Register Jupyter language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(JupyterLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_jupyter_definition() {
+        let jupyter = JupyterLanguage;
+
+        assert_eq!(jupyter.id(), LanguageId::new("jupyter"));
+        assert_eq!(jupyter.name(), "Jupyter");
+        assert!(jupyter.extensions().contains(&"ipynb"));
+    }
+
+    #[test]
+    fn test_jupyter_enabled_by_default() {
+        let jupyter = JupyterLanguage;
+        let settings = Settings::default();
+
+        assert!(jupyter.default_enabled());
+        assert!(jupyter.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_jupyter_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("jupyter")));
+    }
+}
@@ -0,0 +1,19 @@
+//! Jupyter notebook parser implementation
+//!
+//! Indexes the code cells of `.ipynb` notebooks with the Python parser and
+//! their markdown cells as documented symbols for semantic search, with
+//! every position mapped back to the cell it came from in the notebook
+//! file (see [`notebook`]).
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod notebook;
+pub mod parser;
+
+pub use behavior::JupyterBehavior;
+pub use definition::JupyterLanguage;
+pub use parser::JupyterParser;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
@@ -0,0 +1,386 @@
+//! Jupyter notebook documents
+//!
+//! A notebook is a JSON document whose `cells` array holds code, markdown
+//! and raw cells, each with its text as a `source` string or an array of
+//! lines:
+//!
+//! ```json
+//! {
+//!  "cells": [
+//!   {
+//!    "cell_type": "code",
+//!    "source": [
+//!     "def load(path):\n",
+//!     "    return pd.read_csv(path)"
+//!    ]
+//!   }
+//!  ],
+//!  "metadata": { "kernelspec": { "language": "python" } }
+//! }
+//! ```
+//!
+//! [`Notebook::read`] decodes the cells and joins the code cells into one
+//! Python script, a blank line after each, remembering where every script
+//! line starts in the notebook file so positions in the script can be
+//! mapped back with [`Notebook::to_file`]. IPython magics (`%matplotlib
+//! inline`, `!pip install`, `df.head?`) are not Python; their lines become
+//! `pass` at the same indentation, and cells run by a non-Python cell magic
+//! (`%%bash`, `%%sql`) are left out.
+
+use crate::Range;
+use tree_sitter::{Node, Parser};
+
+/// Cell magics whose body is still Python
+const PYTHON_CELL_MAGICS: &[&str] = &["time", "timeit", "capture", "prun", "debug"];
+
+/// What a cell holds
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum CellKind {
+    Code,
+    Markdown,
+    Raw,
+}
+
+/// A cell of a notebook
+#[derive(Debug, Clone)]
+pub struct Cell {
+    /// Position in the notebook, from 1, as Jupyter numbers cells
+    pub number: usize,
+    pub kind: CellKind,
+    /// The cell's text
+    pub source: String,
+    /// The cell object in the notebook file
+    pub range: Range,
+}
+
+/// A notebook's cells and the script of its code cells
+#[derive(Debug, Clone, Default)]
+pub struct Notebook {
+    pub cells: Vec<Cell>,
+    /// Whether the kernel runs Python; notebooks that don't say are assumed
+    /// to
+    pub python: bool,
+    /// The code cells joined into one Python script
+    pub script: String,
+    /// Position in the notebook file of each script line's start
+    origins: Vec<(u32, u16)>,
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Value of `key` in a JSON object
+fn field<'t>(object: Node<'t>, key: &str, code: &str) -> Option<Node<'t>> {
+    if object.kind() != "object" {
+        return None;
+    }
+    let mut cursor = object.walk();
+    object
+        .named_children(&mut cursor)
+        .filter(|pair| pair.kind() == "pair")
+        .find(|pair| {
+            pair.child_by_field_name("key")
+                .and_then(|name| string(name, code))
+                .is_some_and(|name| name == key)
+        })
+        .and_then(|pair| pair.child_by_field_name("value"))
+}
+
+/// A JSON string, decoded
+fn string(node: Node, code: &str) -> Option<String> {
+    (node.kind() == "string")
+        .then(|| serde_json::from_str(&code[node.byte_range()]).ok())
+        .flatten()
+}
+
+/// Decoded lines of a JSON string and the file position each starts at
+///
+/// Columns count the string as written, so escapes before a position
+/// (`\"`, `\t`) shift it a little to the right of the decoded text.
+fn string_lines(node: Node, code: &str) -> Option<Vec<(String, (u32, u16))>> {
+    let text = string(node, code)?;
+    let raw = &code[node.byte_range()];
+    let start = node.start_position();
+
+    // Every line break of the decoded text is a `\n` escape
+    let mut starts = vec![1];
+    let bytes = raw.as_bytes();
+    let mut i = 1;
+    while i < bytes.len() {
+        if bytes[i] == b'\\' {
+            if bytes.get(i + 1) == Some(&b'n') {
+                starts.push(i + 2);
+            }
+            i += 2;
+        } else {
+            i += 1;
+        }
+    }
+
+    let lines: Vec<&str> = text.split('\n').collect();
+    Some(
+        lines
+            .iter()
+            .enumerate()
+            .map(|(index, line)| {
+                let offset = starts.get(index).copied().unwrap_or(1);
+                (
+                    line.to_string(),
+                    (start.row as u32, (start.column + offset) as u16),
+                )
+            })
+            .collect(),
+    )
+}
+
+/// Lines of a cell's `source`, a string or an array of strings, and the
+/// file position each starts at
+fn source_lines(source: Node, code: &str) -> Vec<(String, (u32, u16))> {
+    let strings: Vec<Node> = match source.kind() {
+        "string" => vec![source],
+        "array" => {
+            let mut cursor = source.walk();
+            source
+                .named_children(&mut cursor)
+                .filter(|element| element.kind() == "string")
+                .collect()
+        }
+        _ => Vec::new(),
+    };
+
+    // An element not ending in a line break continues on the next one
+    let mut lines: Vec<(String, (u32, u16))> = Vec::new();
+    let mut open = false;
+    for element in strings {
+        let Some(pieces) = string_lines(element, code) else {
+            continue;
+        };
+        for (index, (piece, origin)) in pieces.into_iter().enumerate() {
+            match lines.last_mut() {
+                Some((line, _)) if index == 0 && open => line.push_str(&piece),
+                _ => lines.push((piece, origin)),
+            }
+        }
+        open = true;
+    }
+    if lines.last().is_some_and(|(line, _)| line.is_empty()) {
+        lines.pop();
+    }
+    lines
+}
+
+/// Whether a line is an IPython line magic, shell escape or help request
+fn is_magic(line: &str) -> bool {
+    let line = line.trim();
+    line.starts_with(['%', '!']) || (line.ends_with('?') && !line.starts_with('#'))
+}
+
+/// The lines of a code cell as Python, or `None` for a cell run by a
+/// non-Python cell magic
+fn python_lines(lines: Vec<(String, (u32, u16))>) -> Option<Vec<(String, (u32, u16))>> {
+    if let Some((first, _)) = lines.first() {
+        if let Some(magic) = first.trim_start().strip_prefix("%%") {
+            let name = magic.split_whitespace().next().unwrap_or("");
+            if !PYTHON_CELL_MAGICS.contains(&name) {
+                return None;
+            }
+        }
+    }
+
+    Some(
+        lines
+            .into_iter()
+            .map(|(line, origin)| {
+                if is_magic(&line) {
+                    let indent = &line[..line.len() - line.trim_start().len()];
+                    (format!("{indent}pass"), origin)
+                } else {
+                    (line, origin)
+                }
+            })
+            .collect(),
+    )
+}
+
+impl Notebook {
+    /// Read a notebook file, or `None` if it is not notebook JSON
+    pub fn read(code: &str) -> Option<Self> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_json::LANGUAGE.into())
+            .ok()?;
+        let tree = parser.parse(code, None)?;
+        let mut cursor = tree.root_node().walk();
+        let root = tree
+            .root_node()
+            .named_children(&mut cursor)
+            .find(|node| node.kind() == "object")?;
+        let cells = field(root, "cells", code).filter(|cells| cells.kind() == "array")?;
+
+        let metadata = field(root, "metadata", code);
+        let language = metadata
+            .and_then(|metadata| field(metadata, "kernelspec", code))
+            .and_then(|kernelspec| field(kernelspec, "language", code))
+            .or_else(|| {
+                metadata
+                    .and_then(|metadata| field(metadata, "language_info", code))
+                    .and_then(|info| field(info, "name", code))
+            })
+            .and_then(|language| string(language, code));
+        let python = language.is_none_or(|language| language.eq_ignore_ascii_case("python"));
+
+        let mut notebook = Notebook {
+            python,
+            ..Self::default()
+        };
+        let mut cursor = cells.walk();
+        for (index, cell) in cells
+            .named_children(&mut cursor)
+            .filter(|cell| cell.kind() == "object")
+            .enumerate()
+        {
+            let kind = match field(cell, "cell_type", code)
+                .and_then(|kind| string(kind, code))
+                .as_deref()
+            {
+                Some("code") => CellKind::Code,
+                Some("markdown") => CellKind::Markdown,
+                _ => CellKind::Raw,
+            };
+            let lines = field(cell, "source", code)
+                .map(|source| source_lines(source, code))
+                .unwrap_or_default();
+            let source = lines
+                .iter()
+                .map(|(line, _)| line.as_str())
+                .collect::<Vec<_>>()
+                .join("\n");
+
+            if kind == CellKind::Code && python {
+                if let Some(lines) = python_lines(lines) {
+                    let end = range_from_node(&cell);
+                    for (line, origin) in lines {
+                        notebook.script.push_str(&line);
+                        notebook.script.push('\n');
+                        notebook.origins.push(origin);
+                    }
+                    notebook.script.push('\n');
+                    notebook.origins.push((end.end_line, end.end_column));
+                }
+            }
+
+            notebook.cells.push(Cell {
+                number: index + 1,
+                kind,
+                source,
+                range: range_from_node(&cell),
+            });
+        }
+
+        Some(notebook)
+    }
+
+    /// Position in the notebook file of a range of [`Self::script`]
+    pub fn to_file(&self, range: Range) -> Range {
+        let position = |line: u32, column: u16| match self
+            .origins
+            .get(line as usize)
+            .or(self.origins.last())
+        {
+            Some(&(row, start)) => (row, start.saturating_add(column)),
+            None => (line, column),
+        };
+        let (start_line, start_column) = position(range.start_line, range.start_column);
+        let (end_line, end_column) = position(range.end_line, range.end_column);
+        Range::new(start_line, start_column, end_line, end_column)
+    }
+
+    /// The cell at a line of the notebook file
+    pub fn cell_at(&self, line: u32) -> Option<&Cell> {
+        self.cells
+            .iter()
+            .find(|cell| cell.range.start_line <= line && line <= cell.range.end_line)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const NOTEBOOK: &str = r##"{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": ["# Sales\n", "Monthly totals."]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {},
+   "outputs": [],
+   "source": [
+    "%matplotlib inline\n",
+    "def total(rows):\n",
+    "    return sum(r[\"amount\"] for r in rows)"
+   ]
+  },
+  {
+   "cell_type": "code",
+   "metadata": {},
+   "source": "%%bash\necho hi"
+  }
+ ],
+ "metadata": {"kernelspec": {"language": "python", "name": "python3"}},
+ "nbformat": 4
+}"##;
+
+    #[test]
+    fn test_cells_and_script() {
+        let notebook = Notebook::read(NOTEBOOK).unwrap();
+        assert!(notebook.python);
+        assert_eq!(notebook.cells.len(), 3);
+
+        let markdown = &notebook.cells[0];
+        assert_eq!(markdown.number, 1);
+        assert_eq!(markdown.kind, CellKind::Markdown);
+        assert_eq!(markdown.source, "# Sales\nMonthly totals.");
+
+        assert_eq!(notebook.cells[2].source, "%%bash\necho hi");
+        assert_eq!(
+            notebook.script,
+            "pass\ndef total(rows):\n    return sum(r[\"amount\"] for r in rows)\n\n"
+        );
+    }
+
+    #[test]
+    fn test_script_positions_are_file_positions() {
+        let notebook = Notebook::read(NOTEBOOK).unwrap();
+
+        // `total` on the second script line
+        let range = notebook.to_file(Range::new(1, 4, 1, 9));
+        assert_eq!(range, Range::new(14, 9, 14, 14));
+        assert_eq!(&NOTEBOOK.lines().nth(14).unwrap()[9..14], "total");
+        assert_eq!(notebook.cell_at(range.start_line).unwrap().number, 2);
+    }
+
+    #[test]
+    fn test_other_kernels_and_other_json() {
+        let code = r#"{"cells": [{"cell_type": "code", "source": "x <- 1"}],
+ "metadata": {"kernelspec": {"language": "R"}}}"#;
+        let notebook = Notebook::read(code).unwrap();
+        assert!(!notebook.python);
+        assert!(notebook.script.is_empty());
+        assert_eq!(notebook.cells[0].source, "x <- 1");
+
+        assert!(Notebook::read(r#"{"name": "package.json"}"#).is_none());
+    }
+}
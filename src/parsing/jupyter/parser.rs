@@ -0,0 +1,394 @@
+//! Jupyter notebook parser implementation
+//!
+//! The code cells of a Python notebook are joined into one script (see
+//! [`super::notebook`]) and parsed by the Python parser, so a function
+//! defined in one cell and called in another is one call graph. Every
+//! position reported is mapped back to the notebook file, where it falls
+//! inside the cell the code came from.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | Name | SymbolKind |
+//! |-----------|------|------------|
+//! | the notebook | `<module>`, renamed to the file name | Module |
+//! | declarations of code cells | as in Python | as in Python |
+//! | code cell | `Cell 3` | Module |
+//! | markdown cell | its first heading, else `Cell 2` | Module |
+//!
+//! Notebooks of other kernels (R, Julia) index their cells only.
+//!
+//! ## Relationships
+//!
+//! Calls, imports and inheritance are those of the Python script.
+//!
+//! ## Documentation
+//!
+//! Docstrings document Python symbols, and a markdown cell's text documents
+//! the markdown cell, so semantic search covers a notebook's narrative.
+
+use super::notebook::{CellKind, Notebook};
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, MethodCall, NodeTracker, PythonParser,
+    ValueReference, truncate_for_display,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use tree_sitter::Node;
+
+/// Name of module scope in the relationships of the Python parser
+const MODULE_SCOPE: &str = "<module>";
+
+/// Longest markdown cell text kept as its doc comment
+const MAX_DOC_LEN: usize = 2000;
+
+/// Longest first line kept as a cell's signature
+const MAX_SIGNATURE_LEN: usize = 120;
+
+/// Jupyter notebook parser
+pub struct JupyterParser {
+    python: PythonParser,
+}
+
+impl std::fmt::Debug for JupyterParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("JupyterParser")
+            .field("language", &"Jupyter")
+            .finish()
+    }
+}
+
+/// `Training` for a markdown cell whose first heading is `## Training`
+fn heading_title(source: &str) -> Option<String> {
+    source.lines().find_map(|line| {
+        let rest = line.trim_start().trim_start_matches('#');
+        let level = line.trim_start().len() - rest.len();
+        if !(1..=6).contains(&level) || !rest.starts_with([' ', '\t']) {
+            return None;
+        }
+        let title = rest
+            .trim()
+            .trim_end_matches('#')
+            .replace('`', "")
+            .split_whitespace()
+            .collect::<Vec<_>>()
+            .join(" ");
+        (!title.is_empty()).then_some(title)
+    })
+}
+
+/// `text`, a name the Python parser found in the script, as text of the
+/// notebook file
+///
+/// Names are identifiers and appear in the notebook as written.
+fn in_file<'a>(code: &'a str, text: &str) -> Option<&'a str> {
+    if text == MODULE_SCOPE {
+        return Some(MODULE_SCOPE);
+    }
+    code.find(text)
+        .map(|start| &code[start..start + text.len()])
+}
+
+/// [`in_file`] and [`Notebook::to_file`] for the relationships the Python
+/// parser found in the script
+fn remap_all<'a>(
+    notebook: &Notebook,
+    code: &'a str,
+    found: Vec<(&str, &str, Range)>,
+) -> Vec<(&'a str, &'a str, Range)> {
+    found
+        .into_iter()
+        .filter_map(|(from, to, range)| {
+            Some((
+                in_file(code, from)?,
+                in_file(code, to)?,
+                notebook.to_file(range),
+            ))
+        })
+        .collect()
+}
+
+impl JupyterParser {
+    /// Create a new Jupyter parser instance
+    pub fn new() -> Result<Self, String> {
+        Ok(Self {
+            python: PythonParser::new().map_err(|e| e.to_string())?,
+        })
+    }
+
+    /// The Python script of a notebook, if it has one
+    fn script(code: &str) -> Option<Notebook> {
+        Notebook::read(code).filter(|notebook| notebook.python)
+    }
+
+    /// A symbol for each code and markdown cell
+    fn cell_symbols(
+        notebook: &Notebook,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        let mut symbols = Vec::new();
+        for cell in &notebook.cells {
+            let title = match cell.kind {
+                CellKind::Code => None,
+                CellKind::Markdown => heading_title(&cell.source),
+                CellKind::Raw => continue,
+            };
+            let name = title.unwrap_or_else(|| format!("Cell {}", cell.number));
+
+            let mut symbol = Symbol::new(
+                counter.next_id(),
+                name.as_str(),
+                SymbolKind::Module,
+                file_id,
+                cell.range,
+            )
+            .with_visibility(Visibility::Public);
+            if let Some(first) = cell.source.lines().map(str::trim).find(|l| !l.is_empty()) {
+                symbol = symbol.with_signature(truncate_for_display(first, MAX_SIGNATURE_LEN));
+            }
+            if cell.kind == CellKind::Markdown && !cell.source.trim().is_empty() {
+                symbol = symbol.with_doc(truncate_for_display(cell.source.trim(), MAX_DOC_LEN));
+            }
+            symbol.scope_context = Some(ScopeContext::Module);
+            symbols.push(symbol);
+        }
+        symbols
+    }
+}
+
+impl NodeTracker for JupyterParser {
+    /// The Python nodes of the code cells
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.python.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.python.register_handled_node(node_kind, node_id);
+    }
+}
+
+impl LanguageParser for JupyterParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        let Some(notebook) = Notebook::read(code) else {
+            return Vec::new();
+        };
+
+        let mut symbols = Vec::new();
+        if notebook.python {
+            for mut symbol in self.python.parse(&notebook.script, file_id, symbol_counter) {
+                symbol.range = notebook.to_file(symbol.range);
+                symbols.push(symbol);
+            }
+        }
+        symbols.extend(Self::cell_symbols(&notebook, file_id, symbol_counter));
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// Docstrings, as in Python
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        self.python.extract_doc_comment(node, code)
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let Some(notebook) = Self::script(code) else {
+            return Vec::new();
+        };
+        let calls = self.python.find_calls(&notebook.script);
+        remap_all(&notebook, code, calls)
+    }
+
+    fn find_method_calls(&mut self, code: &str) -> Vec<MethodCall> {
+        let Some(notebook) = Self::script(code) else {
+            return Vec::new();
+        };
+        self.python
+            .find_method_calls(&notebook.script)
+            .into_iter()
+            .map(|mut call| {
+                call.range = notebook.to_file(call.range);
+                call.caller_range = call.caller_range.map(|range| notebook.to_file(range));
+                call
+            })
+            .collect()
+    }
+
+    fn find_implementations<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_value_references(&mut self, code: &str) -> Vec<ValueReference> {
+        let Some(notebook) = Self::script(code) else {
+            return Vec::new();
+        };
+        self.python
+            .find_value_references(&notebook.script)
+            .into_iter()
+            .map(|mut reference| {
+                reference.range = notebook.to_file(reference.range);
+                reference
+            })
+            .collect()
+    }
+
+    fn find_extends<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let Some(notebook) = Self::script(code) else {
+            return Vec::new();
+        };
+        let extends = self.python.find_extends(&notebook.script);
+        remap_all(&notebook, code, extends)
+    }
+
+    fn find_uses<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let Some(notebook) = Self::script(code) else {
+            return Vec::new();
+        };
+        let defines = self.python.find_defines(&notebook.script);
+        remap_all(&notebook, code, defines)
+    }
+
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let Some(notebook) = Self::script(code) else {
+            return Vec::new();
+        };
+        self.python.find_imports(&notebook.script, file_id)
+    }
+
+    fn language(&self) -> Language {
+        Language::Jupyter
+    }
+
+    fn find_variable_types<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let Some(notebook) = Self::script(code) else {
+            return Vec::new();
+        };
+        let bindings = self.python.find_variable_types(&notebook.script);
+        remap_all(&notebook, code, bindings)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const NOTEBOOK: &str = r##"{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": ["# Monthly sales\n", "\n", "Totals per region."]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {},
+   "outputs": [],
+   "source": [
+    "from pandas import read_csv\n",
+    "\n",
+    "def load(path):\n",
+    "    \"\"\"Read the sales export.\"\"\"\n",
+    "    return read_csv(path)"
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 2,
+   "metadata": {},
+   "outputs": [],
+   "source": ["sales = load(\"sales.csv\")"]
+  }
+ ],
+ "metadata": {"kernelspec": {"language": "python", "name": "python3"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}"##;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = JupyterParser::new().unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, FileId::new(1).unwrap(), &mut counter)
+    }
+
+    fn find<'s>(symbols: &'s [Symbol], name: &str) -> &'s Symbol {
+        symbols
+            .iter()
+            .find(|symbol| symbol.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("symbol {name} not found"))
+    }
+
+    #[test]
+    fn test_code_cells_are_python() {
+        let symbols = parse(NOTEBOOK);
+
+        let load = find(&symbols, "load");
+        assert_eq!(load.kind, SymbolKind::Function);
+        assert_eq!(load.doc_comment.as_deref(), Some("Read the sales export."));
+        assert_eq!(load.range.start_line, 15, "Line of the notebook file");
+
+        let cell = find(&symbols, "Cell 2");
+        assert_eq!(cell.kind, SymbolKind::Module);
+        assert_eq!(
+            cell.signature.as_deref(),
+            Some("from pandas import read_csv")
+        );
+        assert!(cell.range.start_line <= 15 && 17 <= cell.range.end_line);
+    }
+
+    #[test]
+    fn test_markdown_cells_are_documented() {
+        let symbols = parse(NOTEBOOK);
+
+        let intro = find(&symbols, "Monthly sales");
+        assert_eq!(intro.kind, SymbolKind::Module);
+        assert_eq!(
+            intro.doc_comment.as_deref(),
+            Some("# Monthly sales\n\nTotals per region.")
+        );
+    }
+
+    #[test]
+    fn test_calls_across_cells() {
+        let mut parser = JupyterParser::new().unwrap();
+
+        let calls = parser.find_calls(NOTEBOOK);
+        let call = calls
+            .iter()
+            .find(|(from, to, _)| *from == MODULE_SCOPE && *to == "load")
+            .expect("module-level call to load");
+        assert_eq!(call.2.start_line, 25);
+
+        let imports = parser.find_imports(NOTEBOOK, FileId::new(1).unwrap());
+        assert_eq!(imports.len(), 1);
+        assert_eq!(imports[0].path, "pandas.read_csv");
+    }
+
+    #[test]
+    fn test_other_kernels_index_cells_only() {
+        let code = r##"{"cells": [
+  {"cell_type": "markdown", "source": "# Setup"},
+  {"cell_type": "code", "source": "library(dplyr)"}
+ ],
+ "metadata": {"kernelspec": {"language": "R"}}}"##;
+        let symbols = parse(code);
+
+        let names: Vec<&str> = symbols.iter().map(|s| s.name.as_ref()).collect();
+        assert_eq!(names, vec!["Setup", "Cell 2"]);
+    }
+}
@@ -0,0 +1,283 @@
+//! Framework symbol kinds
+//!
+//! A language's kinds say what a symbol is to its compiler: a React
+//! component, a Django view and an Actix handler are all functions. The
+//! `[[languages.<name>.kinds]]` rules of the settings name the kinds
+//! frameworks add on top, and the symbols matching a rule carry its kind
+//! as their framework kind:
+//!
+//! ```toml
+//! [[languages.rust.kinds]]
+//! kind = "actix_handler"
+//! base = ["function"]
+//! attribute = '^#\[(get|post|put|delete|patch|route)\('
+//!
+//! [[languages.typescript.kinds]]
+//! kind = "react_component"
+//! base = ["function"]
+//! name = '^[A-Z]'
+//! paths = ["src/components/**"]
+//! ```
+//!
+//! A rule matches a symbol when each condition it sets holds: one of its
+//! `base` kinds, `name` and `signature` patterns on the name and signature,
+//! `attribute` on a line of the preamble (see
+//! [`crate::parsing::deprecation::preamble`]) or the first line, and one of
+//! the `paths` globs on the file. The first rule matching wins. The symbol
+//! keeps its own kind, so `kind:function` still finds a component while
+//! `kind:react_component` finds only those, and stats count it under the
+//! framework kind.
+
+use crate::config::KindRule;
+use crate::parsing::deprecation::preamble;
+use crate::{Range, SymbolKind};
+use regex::Regex;
+use std::path::Path;
+
+/// A rule with its patterns compiled
+#[derive(Debug, Clone)]
+struct CompiledRule {
+    kind: String,
+    base: Vec<SymbolKind>,
+    name: Option<Regex>,
+    signature: Option<Regex>,
+    attribute: Option<Regex>,
+    paths: Vec<glob::Pattern>,
+}
+
+/// The kind rules of a language, ready to match
+#[derive(Debug, Clone, Default)]
+pub struct KindRules {
+    rules: Vec<CompiledRule>,
+}
+
+/// The symbol a rule is matched against
+#[derive(Debug, Clone, Copy)]
+pub struct KindCandidate<'a> {
+    pub name: &'a str,
+    pub kind: SymbolKind,
+    pub range: Range,
+    pub signature: Option<&'a str>,
+}
+
+/// Whether `name` can name a framework kind: lowercase words joined by
+/// underscores, not a kind the languages have
+fn valid_kind_name(name: &str) -> Result<(), String> {
+    let well_formed = name.starts_with(|c: char| c.is_ascii_lowercase())
+        && name
+            .chars()
+            .all(|c| c.is_ascii_lowercase() || c.is_ascii_digit() || c == '_');
+    if !well_formed {
+        return Err(format!(
+            "kind '{name}' must be lowercase letters, digits and underscores"
+        ));
+    }
+    if name.parse::<SymbolKind>().is_ok() {
+        return Err(format!("kind '{name}' is a built-in kind"));
+    }
+    Ok(())
+}
+
+impl KindRules {
+    /// Compile `rules`, naming the kind of the first invalid one
+    pub fn compile(rules: &[KindRule]) -> Result<Self, String> {
+        let regex = |kind: &str, key: &str, pattern: &Option<String>| {
+            pattern
+                .as_deref()
+                .map(Regex::new)
+                .transpose()
+                .map_err(|e| format!("{kind}: invalid {key} pattern: {e}"))
+        };
+
+        let mut compiled = Vec::with_capacity(rules.len());
+        for rule in rules {
+            let kind = rule.kind.as_str();
+            valid_kind_name(kind)?;
+            let base = rule
+                .base
+                .iter()
+                .map(|base| base.parse::<SymbolKind>())
+                .collect::<Result<Vec<_>, _>>()
+                .map_err(|e| format!("{kind}: base: {e}"))?;
+            let paths = rule
+                .paths
+                .iter()
+                .map(|pattern| {
+                    glob::Pattern::new(pattern)
+                        .map_err(|e| format!("{kind}: invalid path '{pattern}': {e}"))
+                })
+                .collect::<Result<Vec<_>, _>>()?;
+            compiled.push(CompiledRule {
+                kind: rule.kind.clone(),
+                base,
+                name: regex(kind, "name", &rule.name)?,
+                signature: regex(kind, "signature", &rule.signature)?,
+                attribute: regex(kind, "attribute", &rule.attribute)?,
+                paths,
+            });
+        }
+        Ok(Self { rules: compiled })
+    }
+
+    pub fn is_empty(&self) -> bool {
+        self.rules.is_empty()
+    }
+
+    /// The framework kind of the first rule matching `symbol` of the file
+    /// at `relative`, a path relative to the workspace root, with `lines`
+    pub fn kind_of(&self, symbol: &KindCandidate, lines: &[&str], relative: &Path) -> Option<&str> {
+        self.rules
+            .iter()
+            .find(|rule| rule.matches(symbol, lines, relative))
+            .map(|rule| rule.kind.as_str())
+    }
+}
+
+impl CompiledRule {
+    fn matches(&self, symbol: &KindCandidate, lines: &[&str], relative: &Path) -> bool {
+        if !self.base.is_empty() && !self.base.contains(&symbol.kind) {
+            return false;
+        }
+        if !self.paths.is_empty()
+            && !self
+                .paths
+                .iter()
+                .any(|pattern| pattern.matches_path(relative))
+        {
+            return false;
+        }
+        if self
+            .name
+            .as_ref()
+            .is_some_and(|name| !name.is_match(symbol.name))
+        {
+            return false;
+        }
+        if self.signature.as_ref().is_some_and(|signature| {
+            !symbol
+                .signature
+                .is_some_and(|text| signature.is_match(text))
+        }) {
+            return false;
+        }
+        self.attribute.as_ref().is_none_or(|attribute| {
+            let first = lines.get(symbol.range.start_line as usize).copied();
+            first
+                .into_iter()
+                .chain(preamble(lines, &symbol.range))
+                .any(|line| attribute.is_match(line.trim()))
+        })
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn rule(kind: &str) -> KindRule {
+        KindRule {
+            kind: kind.to_string(),
+            ..KindRule::default()
+        }
+    }
+
+    fn candidate<'a>(name: &'a str, line: u32, signature: Option<&'a str>) -> KindCandidate<'a> {
+        KindCandidate {
+            name,
+            kind: SymbolKind::Function,
+            range: Range::new(line, 0, line + 2, 1),
+            signature,
+        }
+    }
+
+    #[test]
+    fn test_attribute_rule_of_actix_handlers() {
+        let rules = KindRules::compile(&[KindRule {
+            base: vec!["function".to_string()],
+            attribute: Some(r"^#\[(get|post)\(".to_string()),
+            ..rule("actix_handler")
+        }])
+        .unwrap();
+        let code = "#[get(\"/users\")]\n/// Lists users\nasync fn list() {}\n\nfn helper() {}\n";
+        let lines: Vec<&str> = code.lines().collect();
+        let path = Path::new("src/routes.rs");
+
+        assert_eq!(
+            rules.kind_of(&candidate("list", 2, None), &lines, path),
+            Some("actix_handler")
+        );
+        assert_eq!(
+            rules.kind_of(&candidate("helper", 4, None), &lines, path),
+            None
+        );
+
+        let method = KindCandidate {
+            kind: SymbolKind::Method,
+            ..candidate("list", 2, None)
+        };
+        assert_eq!(
+            rules.kind_of(&method, &lines, path),
+            None,
+            "not a base kind"
+        );
+    }
+
+    #[test]
+    fn test_every_condition_holds_and_first_rule_wins() {
+        let rules = KindRules::compile(&[
+            KindRule {
+                name: Some("^[A-Z]".to_string()),
+                signature: Some(r"JSX\.Element".to_string()),
+                paths: vec!["src/components/**".to_string()],
+                ..rule("react_component")
+            },
+            KindRule {
+                name: Some("^use[A-Z]".to_string()),
+                ..rule("react_hook")
+            },
+        ])
+        .unwrap();
+        let components = Path::new("src/components/Button.tsx");
+        let kind = |name, signature, path| {
+            rules
+                .kind_of(&candidate(name, 0, signature), &[], path)
+                .map(str::to_string)
+        };
+
+        let element = Some("function Button(): JSX.Element");
+        assert_eq!(
+            kind("Button", element, components).as_deref(),
+            Some("react_component")
+        );
+        assert_eq!(kind("Button", element, Path::new("src/app.tsx")), None);
+        assert_eq!(kind("button", element, components), None);
+        assert_eq!(kind("Button", None, components), None, "no signature");
+        assert_eq!(
+            kind("useTheme", None, components).as_deref(),
+            Some("react_hook")
+        );
+    }
+
+    #[test]
+    fn test_invalid_rules() {
+        let error = |rule: KindRule| KindRules::compile(&[rule]).unwrap_err();
+
+        assert!(error(rule("function")).contains("built-in"));
+        assert!(error(rule("React Component")).contains("lowercase"));
+        assert!(
+            error(KindRule {
+                name: Some("(".to_string()),
+                ..rule("view")
+            })
+            .contains("view: invalid name pattern")
+        );
+        assert!(
+            error(KindRule {
+                base: vec!["routine".to_string()],
+                ..rule("view")
+            })
+            .contains("view: base")
+        );
+        assert!(KindRules::compile(&[]).unwrap().is_empty());
+    }
+}
@@ -297,6 +297,10 @@ impl LanguageBehavior for KotlinBehavior {
         true // Kotlin has interfaces
     }
 
+    fn supports_inherent_methods(&self) -> bool {
+        true // Extension functions attach methods to existing types
+    }
+
     // Override import tracking methods to use state
     fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
         self.register_file_with_state(path, file_id, module_path);
@@ -19,6 +19,7 @@ use tree_sitter::{Node, Parser};
 const FILE_SCOPE: &str = "<file>";
 const NODE_CLASS_DECLARATION: &str = "class_declaration";
 const NODE_OBJECT_DECLARATION: &str = "object_declaration";
+const NODE_COMPANION_OBJECT: &str = "companion_object";
 const NODE_FUNCTION_DECLARATION: &str = "function_declaration";
 const NODE_PROPERTY_DECLARATION: &str = "property_declaration";
 const NODE_SECONDARY_CONSTRUCTOR: &str = "secondary_constructor";
@@ -887,6 +888,10 @@ impl KotlinParser {
                 );
                 return;
             }
+            NODE_COMPANION_OBJECT => {
+                self.handle_companion_object(node, code, file_id, symbols, counter, context, depth);
+                return;
+            }
             NODE_FUNCTION_DECLARATION => {
                 self.handle_function_declaration(
                     node, code, file_id, symbols, counter, context, depth,
@@ -1136,6 +1141,69 @@ impl KotlinParser {
         context.set_current_class(saved_class);
     }
 
+    /// Companion objects are emitted as a nested Class (`Companion` unless named),
+    /// but their members stay attributed to the enclosing class: `Foo.create()`
+    /// is how callers reach them, so `class_name` must remain `Foo`.
+    fn handle_companion_object(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        symbols: &mut Vec<Symbol>,
+        counter: &mut SymbolCounter,
+        context: &mut ParserContext,
+        depth: usize,
+    ) {
+        self.register_node_recursively(node);
+
+        let mut companion_name = "Companion".to_string();
+        let mut cursor = node.walk();
+        for child in node.children(&mut cursor) {
+            if child.kind() == NODE_TYPE_IDENTIFIER {
+                companion_name = self.text_for_node(code, child).trim().to_string();
+                break;
+            }
+        }
+
+        let symbol_id = counter.next_id();
+        let range = self.node_to_range(node);
+        let mut symbol = Symbol::new(
+            symbol_id,
+            companion_name.as_str(),
+            SymbolKind::Class,
+            file_id,
+            range,
+        );
+        symbol.visibility = self.determine_visibility(node, code);
+        symbol.signature = Some(self.extract_signature(node, code).into());
+        if let Some(doc) = self.doc_comment_for(&node, code) {
+            symbol.doc_comment = Some(doc.into());
+        }
+        symbol.scope_context = Some(crate::symbol::ScopeContext::ClassMember {
+            class_name: context.current_class().map(|c| c.to_string().into()),
+        });
+        symbols.push(symbol);
+
+        let mut cursor = node.walk();
+        for child in node.children(&mut cursor) {
+            if child.kind() == NODE_CLASS_BODY {
+                let mut body_cursor = child.walk();
+                for body_child in child.children(&mut body_cursor) {
+                    self.extract_symbols_from_node(
+                        body_child,
+                        code,
+                        file_id,
+                        symbols,
+                        counter,
+                        context,
+                        depth + 1,
+                    );
+                }
+                break;
+            }
+        }
+    }
+
     /// Try to extract a function from context receiver pattern
     /// Pattern: context(View, Database) fun save() { }
     /// AST: infix_expression > call_expression("context") + simple_identifier("fun") + call_expression(name + lambda)
@@ -2012,6 +2080,37 @@ impl KotlinParser {
         }
     }
 
+    /// Collect (receiver_type, function_name, range) for extension functions
+    fn collect_extension_functions(
+        &self,
+        node: Node,
+        code: &str,
+        methods: &mut Vec<(String, String, Range)>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        if node.kind() == NODE_FUNCTION_DECLARATION {
+            let (func_name, receiver_type, _, _, _) = self.extract_function_info(node, code);
+            if let (Some(name), Some(receiver)) = (func_name, receiver_type) {
+                // `Money.Companion` -> `Money`, `List<T>?` -> `List`
+                let receiver = receiver.trim_end_matches('?');
+                let receiver = receiver.split('<').next().unwrap_or(receiver).trim();
+                let receiver = receiver.strip_suffix(".Companion").unwrap_or(receiver);
+                if !receiver.is_empty() {
+                    methods.push((receiver.to_string(), name, self.node_to_range(node)));
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.children(&mut cursor) {
+            self.collect_extension_functions(child, code, methods, depth + 1);
+        }
+    }
+
     /// Access handled nodes for audit tooling
     pub fn get_handled_nodes(&self) -> &std::collections::HashSet<HandledNode> {
         self.node_tracker.get_handled_nodes()
@@ -2127,6 +2226,22 @@ impl LanguageParser for KotlinParser {
         defines
     }
 
+    /// Extension functions attributed to their receiver type
+    ///
+    /// `fun List<Order>.total(): Int` yields `("List", "total", range)` and
+    /// `fun Money.Companion.zero()` yields `("Money", "zero", range)`, so
+    /// method-on-type queries find extensions next to the members.
+    fn find_inherent_methods(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let tree = match self.parser.parse(code, None) {
+            Some(tree) => tree,
+            None => return Vec::new(),
+        };
+
+        let mut methods = Vec::new();
+        self.collect_extension_functions(tree.root_node(), code, &mut methods, 0);
+        methods
+    }
+
     fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
         let tree = match self.parser.parse(code, None) {
             Some(tree) => tree,
@@ -22,7 +22,25 @@ pub enum Language {
     Java,
     Kotlin,
     Lua,
+    Ruby,
+    Scala,
     Swift,
+    Zig,
+    Dart,
+    Elixir,
+    Bash,
+    Sql,
+    Protobuf,
+    GraphQL,
+    Hcl,
+    Vue,
+    Svelte,
+    Markdown,
+    Jupyter,
+    Css,
+    Html,
+    /// A language added by a plugin (see [`super::plugin`])
+    Plugin(super::LanguageId),
 }
 
 impl Language {
@@ -47,7 +65,24 @@ impl Language {
             Language::Java => super::LanguageId::new("java"),
             Language::Kotlin => super::LanguageId::new("kotlin"),
             Language::Lua => super::LanguageId::new("lua"),
+            Language::Ruby => super::LanguageId::new("ruby"),
+            Language::Scala => super::LanguageId::new("scala"),
             Language::Swift => super::LanguageId::new("swift"),
+            Language::Zig => super::LanguageId::new("zig"),
+            Language::Dart => super::LanguageId::new("dart"),
+            Language::Elixir => super::LanguageId::new("elixir"),
+            Language::Bash => super::LanguageId::new("bash"),
+            Language::Sql => super::LanguageId::new("sql"),
+            Language::Protobuf => super::LanguageId::new("protobuf"),
+            Language::GraphQL => super::LanguageId::new("graphql"),
+            Language::Hcl => super::LanguageId::new("hcl"),
+            Language::Vue => super::LanguageId::new("vue"),
+            Language::Svelte => super::LanguageId::new("svelte"),
+            Language::Markdown => super::LanguageId::new("markdown"),
+            Language::Jupyter => super::LanguageId::new("jupyter"),
+            Language::Css => super::LanguageId::new("css"),
+            Language::Html => super::LanguageId::new("html"),
+            Language::Plugin(id) => *id,
         }
     }
 
@@ -71,7 +106,23 @@ impl Language {
             "java" => Some(Language::Java),
             "kotlin" => Some(Language::Kotlin),
             "lua" => Some(Language::Lua),
+            "ruby" => Some(Language::Ruby),
+            "scala" => Some(Language::Scala),
             "swift" => Some(Language::Swift),
+            "zig" => Some(Language::Zig),
+            "dart" => Some(Language::Dart),
+            "elixir" => Some(Language::Elixir),
+            "bash" => Some(Language::Bash),
+            "sql" => Some(Language::Sql),
+            "protobuf" => Some(Language::Protobuf),
+            "graphql" => Some(Language::GraphQL),
+            "hcl" => Some(Language::Hcl),
+            "vue" => Some(Language::Vue),
+            "svelte" => Some(Language::Svelte),
+            "markdown" => Some(Language::Markdown),
+            "jupyter" => Some(Language::Jupyter),
+            "css" => Some(Language::Css),
+            "html" => Some(Language::Html),
             _ => None,
         }
     }
@@ -87,7 +138,7 @@ impl Language {
         let registry = super::get_registry();
         if let Ok(registry) = registry.lock() {
             if let Some(def) = registry.get_by_extension(&ext_lower) {
-                return Self::from_language_id(def.id());
+                return Self::from_language_id(def.id()).or(Some(Language::Plugin(def.id())));
             }
         }
 
@@ -110,7 +161,23 @@ impl Language {
             "java" => Some(Language::Java),
             "kt" | "kts" => Some(Language::Kotlin),
             "lua" => Some(Language::Lua),
+            "rb" | "rake" | "gemspec" | "ru" => Some(Language::Ruby),
+            "scala" | "sc" => Some(Language::Scala),
             "swift" => Some(Language::Swift),
+            "zig" => Some(Language::Zig),
+            "dart" => Some(Language::Dart),
+            "ex" | "exs" => Some(Language::Elixir),
+            "sh" | "bash" | "zsh" => Some(Language::Bash),
+            "sql" => Some(Language::Sql),
+            "proto" => Some(Language::Protobuf),
+            "graphql" | "gql" => Some(Language::GraphQL),
+            "tf" | "hcl" => Some(Language::Hcl),
+            "vue" => Some(Language::Vue),
+            "svelte" => Some(Language::Svelte),
+            "md" | "markdown" => Some(Language::Markdown),
+            "ipynb" => Some(Language::Jupyter),
+            "css" | "scss" => Some(Language::Css),
+            "html" | "htm" => Some(Language::Html),
             _ => None,
         }
     }
@@ -141,7 +208,28 @@ impl Language {
             Language::Java => &["java"],
             Language::Kotlin => &["kt", "kts"],
             Language::Lua => &["lua"],
+            Language::Ruby => &["rb", "rake", "gemspec", "ru"],
+            Language::Scala => &["scala", "sc"],
             Language::Swift => &["swift"],
+            Language::Zig => &["zig"],
+            Language::Dart => &["dart"],
+            Language::Elixir => &["ex", "exs"],
+            Language::Bash => &["sh", "bash", "zsh"],
+            Language::Sql => &["sql"],
+            Language::Protobuf => &["proto"],
+            Language::GraphQL => &["graphql", "gql"],
+            Language::Hcl => &["tf", "hcl"],
+            Language::Vue => &["vue"],
+            Language::Svelte => &["svelte"],
+            Language::Markdown => &["md", "markdown"],
+            Language::Jupyter => &["ipynb"],
+            Language::Css => &["css", "scss"],
+            Language::Html => &["html", "htm"],
+            Language::Plugin(id) => super::get_registry()
+                .lock()
+                .ok()
+                .and_then(|registry| registry.get(*id).map(|def| def.extensions()))
+                .unwrap_or(&[]),
         }
     }
 
@@ -162,7 +250,24 @@ impl Language {
             Language::Java => "java",
             Language::Kotlin => "kotlin",
             Language::Lua => "lua",
+            Language::Ruby => "ruby",
+            Language::Scala => "scala",
             Language::Swift => "swift",
+            Language::Zig => "zig",
+            Language::Dart => "dart",
+            Language::Elixir => "elixir",
+            Language::Bash => "bash",
+            Language::Sql => "sql",
+            Language::Protobuf => "protobuf",
+            Language::GraphQL => "graphql",
+            Language::Hcl => "hcl",
+            Language::Vue => "vue",
+            Language::Svelte => "svelte",
+            Language::Markdown => "markdown",
+            Language::Jupyter => "jupyter",
+            Language::Css => "css",
+            Language::Html => "html",
+            Language::Plugin(id) => id.as_str(),
         }
     }
 
@@ -183,7 +288,24 @@ impl Language {
             Language::Java => "Java",
             Language::Kotlin => "Kotlin",
             Language::Lua => "Lua",
+            Language::Ruby => "Ruby",
+            Language::Scala => "Scala",
             Language::Swift => "Swift",
+            Language::Zig => "Zig",
+            Language::Dart => "Dart",
+            Language::Elixir => "Elixir",
+            Language::Bash => "Bash",
+            Language::Sql => "SQL",
+            Language::Protobuf => "Protocol Buffers",
+            Language::GraphQL => "GraphQL",
+            Language::Hcl => "HCL",
+            Language::Vue => "Vue",
+            Language::Svelte => "Svelte",
+            Language::Markdown => "Markdown",
+            Language::Jupyter => "Jupyter",
+            Language::Css => "CSS",
+            Language::Html => "HTML",
+            Language::Plugin(id) => id.as_str(),
         }
     }
 }
@@ -219,6 +341,28 @@ mod tests {
         assert_eq!(Language::from_extension("gd"), Some(Language::Gdscript));
         assert_eq!(Language::from_extension("lua"), Some(Language::Lua));
         assert_eq!(Language::from_extension("LUA"), Some(Language::Lua));
+        assert_eq!(Language::from_extension("rb"), Some(Language::Ruby));
+        assert_eq!(Language::from_extension("rake"), Some(Language::Ruby));
+        assert_eq!(Language::from_extension("scala"), Some(Language::Scala));
+        assert_eq!(Language::from_extension("sc"), Some(Language::Scala));
+        assert_eq!(Language::from_extension("zig"), Some(Language::Zig));
+        assert_eq!(Language::from_extension("dart"), Some(Language::Dart));
+        assert_eq!(Language::from_extension("ex"), Some(Language::Elixir));
+        assert_eq!(Language::from_extension("exs"), Some(Language::Elixir));
+        assert_eq!(Language::from_extension("sh"), Some(Language::Bash));
+        assert_eq!(Language::from_extension("zsh"), Some(Language::Bash));
+        assert_eq!(Language::from_extension("sql"), Some(Language::Sql));
+        assert_eq!(Language::from_extension("proto"), Some(Language::Protobuf));
+        assert_eq!(Language::from_extension("graphql"), Some(Language::GraphQL));
+        assert_eq!(Language::from_extension("gql"), Some(Language::GraphQL));
+        assert_eq!(Language::from_extension("tf"), Some(Language::Hcl));
+        assert_eq!(Language::from_extension("hcl"), Some(Language::Hcl));
+        assert_eq!(Language::from_extension("vue"), Some(Language::Vue));
+        assert_eq!(Language::from_extension("svelte"), Some(Language::Svelte));
+        assert_eq!(Language::from_extension("md"), Some(Language::Markdown));
+        assert_eq!(Language::from_extension("ipynb"), Some(Language::Jupyter));
+        assert_eq!(Language::from_extension("scss"), Some(Language::Css));
+        assert_eq!(Language::from_extension("htm"), Some(Language::Html));
         assert_eq!(Language::from_extension("txt"), None);
     }
 
@@ -277,7 +421,11 @@ mod tests {
             Language::from_path(Path::new("script.lua")),
             Some(Language::Lua)
         );
-        assert_eq!(Language::from_path(Path::new("README.md")), None);
+        assert_eq!(
+            Language::from_path(Path::new("README.md")),
+            Some(Language::Markdown)
+        );
+        assert_eq!(Language::from_path(Path::new("notes.txt")), None);
     }
 
     #[test]
@@ -294,5 +442,22 @@ mod tests {
         assert!(Language::Go.extensions().contains(&"go.sum"));
         assert!(Language::Gdscript.extensions().contains(&"gd"));
         assert!(Language::Lua.extensions().contains(&"lua"));
+        assert!(Language::Ruby.extensions().contains(&"rb"));
+        assert!(Language::Ruby.extensions().contains(&"gemspec"));
+        assert!(Language::Scala.extensions().contains(&"scala"));
+        assert!(Language::Zig.extensions().contains(&"zig"));
+        assert!(Language::Dart.extensions().contains(&"dart"));
+        assert!(Language::Elixir.extensions().contains(&"exs"));
+        assert!(Language::Bash.extensions().contains(&"bash"));
+        assert!(Language::Sql.extensions().contains(&"sql"));
+        assert!(Language::Protobuf.extensions().contains(&"proto"));
+        assert!(Language::GraphQL.extensions().contains(&"gql"));
+        assert!(Language::Hcl.extensions().contains(&"tf"));
+        assert!(Language::Vue.extensions().contains(&"vue"));
+        assert!(Language::Svelte.extensions().contains(&"svelte"));
+        assert!(Language::Markdown.extensions().contains(&"markdown"));
+        assert!(Language::Jupyter.extensions().contains(&"ipynb"));
+        assert!(Language::Css.extensions().contains(&"scss"));
+        assert!(Language::Html.extensions().contains(&"html"));
     }
 }
@@ -327,6 +327,16 @@ pub trait LanguageBehavior: Send + Sync {
         None
     }
 
+    /// Trait or interface bounds on the type of parameter `var_name` in
+    /// `signature` when that type is generic: the bounds of its type
+    /// parameter (`<T: Shape + Clone>`, `where T: Shape`,
+    /// `<T extends Shape>`) or of an `impl`/`dyn` type. Consumed by
+    /// `ResolveStage::resolve_bounded_call`, which links calls on the
+    /// parameter to every possible target. Default empty.
+    fn parameter_type_bounds(&self, _signature: &str, _var_name: &str) -> Vec<String> {
+        Vec::new()
+    }
+
     /// Receiver tokens the resolve stage treats as the calling instance
     /// (self-form receivers pass the receiver gate without type inference).
     ///
@@ -479,7 +489,8 @@ pub trait LanguageBehavior: Send + Sync {
                                 &import.path,
                                 module_path.as_ref(),
                                 importing_module.as_deref(),
-                            ) {
+                            ) || cache.resolve_module_alias(&import.path) == Some(id)
+                            {
                                 ImportOrigin::Internal
                             } else {
                                 ImportOrigin::External
@@ -627,6 +638,25 @@ pub trait LanguageBehavior: Send + Sync {
         None
     }
 
+    /// The absolute path an import re-exports under the importing module's
+    /// namespace, for the Phase 2 re-export pre-pass.
+    ///
+    /// `module` is the importing file's module path. The path is in the
+    /// form of symbol module paths, with this language's separator, so
+    /// `pkg/__init__.py: from pkg.a import helper` yields `pkg.a.helper`
+    /// and a later `from pkg import helper` resolves to the definition.
+    ///
+    /// Returns `None` (the default) for languages whose imports re-export
+    /// nothing.
+    fn reexport_import_path(
+        &self,
+        _import: &crate::parsing::Import,
+        _module: &str,
+        _file_path: &Path,
+    ) -> Option<String> {
+        None
+    }
+
     /// Check if an import path matches a symbol's module path
     ///
     /// This allows each language to implement custom matching rules.
@@ -803,6 +833,51 @@ pub trait LanguageBehavior: Send + Sync {
             .is_some_and(|path| path.ends_with(&suffix))
     }
 
+    /// Names a symbol is implemented under in other languages, each to be
+    /// resolved among that language's symbols: the handlers of an RPC, the
+    /// resolvers of a GraphQL field, the inputs of a Terraform module call.
+    /// Called for every symbol the parser extracts; the default links
+    /// nothing.
+    fn cross_language_targets(
+        &self,
+        _name: &str,
+        _kind: SymbolKind,
+        _signature: Option<&str>,
+        _scope: Option<&crate::symbol::ScopeContext>,
+    ) -> Vec<(crate::parsing::registry::LanguageId, String)> {
+        Vec::new()
+    }
+
+    /// Rank of `candidate`, a symbol of the target language, as the target
+    /// of a cross-language reference from `caller`; lower ranks win and
+    /// `None` rejects the candidate.
+    ///
+    /// Consulted for relationships carrying a target language (embedded
+    /// SQL, [`Self::cross_language_targets`]) and for every relationship of
+    /// a language that [`Self::references_other_languages`], after kind
+    /// compatibility. The default ranks every candidate equally, so the
+    /// reference resolves only when one candidate is left.
+    fn cross_language_target_rank(&self, _caller: &Symbol, _candidate: &Symbol) -> Option<u8> {
+        Some(0)
+    }
+
+    /// Whether every reference of this language names a symbol of another
+    /// language, as the code names of documentation do. Such references
+    /// resolve among the symbols of all other languages instead of the
+    /// caller's scopes; the default keeps them in the caller's language.
+    fn references_other_languages(&self) -> bool {
+        false
+    }
+
+    /// Extensions of the files declaring what other files of the language
+    /// define (C and C++ headers, see [`crate::parsing::headers`]). The
+    /// indexing pipeline links each definition of a source file to its
+    /// declaration in a header and resolves calls of the declaration to
+    /// the definition. Default empty: declarations are definitions.
+    fn header_extensions(&self) -> &'static [&'static str] {
+        &[]
+    }
+
     // ========== Relationship Resolution Methods ==========
 
     /// Disambiguate when multiple symbols share the same name
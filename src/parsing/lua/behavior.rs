@@ -242,7 +242,11 @@ impl LanguageBehavior for LuaBehavior {
             return true;
         }
 
-        false
+        // package.path tries `?/init.lua` after `?.lua`:
+        // require("app.db") loads app/db/init.lua, module path "app.db.init"
+        symbol_module_path
+            .strip_suffix(".init")
+            .is_some_and(|package| package == normalized_import)
     }
 }
 
@@ -344,4 +348,14 @@ mod tests {
         assert!(behavior.import_matches_symbol("mymodule/utils", "mymodule.utils", None));
         assert!(!behavior.import_matches_symbol("mymodule.utils", "other.module", None));
     }
+
+    #[test]
+    fn test_import_matches_init_module() {
+        let behavior = LuaBehavior::new();
+
+        assert!(behavior.import_matches_symbol("app.db", "app.db.init", None));
+        assert!(behavior.import_matches_symbol("app/db", "app.db.init", None));
+        assert!(!behavior.import_matches_symbol("app", "app.db.init", None));
+        assert!(!behavior.import_matches_symbol("app.db.pool", "app.db.init", None));
+    }
 }
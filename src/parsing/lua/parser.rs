@@ -7,6 +7,7 @@ use crate::parsing::{
     HandledNode, Import, LanguageParser, MethodCall, NodeTracker, NodeTrackingState, ParserContext,
     ScopeType,
 };
+use crate::symbol::ScopeContext;
 use crate::types::SymbolCounter;
 use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
 use std::any::Any;
@@ -30,6 +31,51 @@ fn range_from_node(node: &Node) -> Range {
     )
 }
 
+/// Function name of a table-method target: `M.foo` in `M.foo = function`
+fn table_function_name<'a>(target: &Node, code: &'a str) -> Option<&'a str> {
+    if target.kind() != "dot_index_expression" {
+        return None;
+    }
+    target
+        .child_by_field_name("field")
+        .map(|field| &code[field.byte_range()])
+}
+
+/// Values of an assignment with the target each is bound to
+///
+/// `a, b = x, y` -> `[(Some(a), x), (Some(b), y)]`; surplus values get `None`.
+fn assignment_values<'tree>(node: &Node<'tree>) -> Vec<(Option<Node<'tree>>, Node<'tree>)> {
+    let mut targets = Vec::new();
+    let mut values = Vec::new();
+    for child in node.children(&mut node.walk()) {
+        match child.kind() {
+            "variable_list" => targets.extend(child.named_children(&mut child.walk())),
+            "expression_list" => values.extend(child.named_children(&mut child.walk())),
+            _ => {}
+        }
+    }
+    values
+        .into_iter()
+        .enumerate()
+        .map(|(i, value)| (targets.get(i).copied(), value))
+        .collect()
+}
+
+/// `foo` when the value is a function assigned to `M.foo`
+///
+/// Plain `local f = function()` values stay anonymous: their calls belong
+/// to the enclosing function, as for any other closure.
+fn table_function_binding<'a>(
+    target: Option<Node>,
+    value: &Node,
+    code: &'a str,
+) -> Option<&'a str> {
+    if value.kind() != "function_definition" {
+        return None;
+    }
+    table_function_name(&target?, code)
+}
+
 impl LuaParser {
     /// Parse Lua source code and extract all symbols
     ///
@@ -152,35 +198,16 @@ impl LuaParser {
                     let func_name = symbol.name.to_string();
                     symbols.push(symbol);
 
-                    self.context.enter_scope(ScopeType::hoisting_function());
-                    let saved_function = self.context.current_function().map(|s| s.to_string());
-                    self.context.set_current_function(Some(func_name));
-
-                    if let Some(params) = node.child_by_field_name("parameters") {
-                        self.process_parameters(
-                            params,
-                            code,
-                            file_id,
-                            counter,
-                            symbols,
-                            module_path,
-                        );
-                    }
-
-                    if let Some(body) = node.child_by_field_name("body") {
-                        self.extract_symbols_from_node(
-                            body,
-                            code,
-                            file_id,
-                            counter,
-                            symbols,
-                            module_path,
-                            depth + 1,
-                        );
-                    }
-
-                    self.context.exit_scope();
-                    self.context.set_current_function(saved_function);
+                    self.process_function_body(
+                        node,
+                        &func_name,
+                        code,
+                        file_id,
+                        counter,
+                        symbols,
+                        module_path,
+                        depth,
+                    );
                 }
 
                 if entered_class_scope {
@@ -334,6 +361,47 @@ impl LuaParser {
         }
     }
 
+    /// Walk the parameters and body of a named function
+    ///
+    /// Shared by `function M.name()` declarations and `M.name = function()`
+    /// assignments, whose `function_definition` value has the same fields.
+    #[allow(clippy::too_many_arguments)]
+    fn process_function_body(
+        &mut self,
+        node: Node,
+        func_name: &str,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        module_path: &str,
+        depth: usize,
+    ) {
+        self.context.enter_scope(ScopeType::hoisting_function());
+        let saved_function = self.context.current_function().map(|s| s.to_string());
+        self.context
+            .set_current_function(Some(func_name.to_string()));
+
+        if let Some(params) = node.child_by_field_name("parameters") {
+            self.process_parameters(params, code, file_id, counter, symbols, module_path);
+        }
+
+        if let Some(body) = node.child_by_field_name("body") {
+            self.extract_symbols_from_node(
+                body,
+                code,
+                file_id,
+                counter,
+                symbols,
+                module_path,
+                depth + 1,
+            );
+        }
+
+        self.context.exit_scope();
+        self.context.set_current_function(saved_function);
+    }
+
     fn process_function_declaration(
         &mut self,
         node: Node,
@@ -384,7 +452,7 @@ impl LuaParser {
         };
         let doc_comment = self.extract_lua_doc_comment(&node, code);
 
-        Some(self.create_symbol(
+        let mut symbol = self.create_symbol(
             counter.next_id(),
             name,
             kind,
@@ -394,7 +462,12 @@ impl LuaParser {
             doc_comment,
             module_path,
             visibility,
-        ))
+        );
+        // `function name()` without `local` assigns a global, wherever it appears
+        if !is_local && !name_text.contains([':', '.']) {
+            symbol.scope_context = Some(ScopeContext::Global);
+        }
+        Some(symbol)
     }
 
     fn process_variable_declaration(
@@ -409,7 +482,7 @@ impl LuaParser {
         for child in node.children(&mut node.walk()) {
             if child.kind() == "assignment_statement" {
                 let mut var_names = Vec::new();
-                let mut expr_kinds = Vec::new();
+                let mut values = Vec::new();
 
                 for assign_child in child.children(&mut child.walk()) {
                     if assign_child.kind() == "variable_list" {
@@ -419,16 +492,17 @@ impl LuaParser {
                             }
                         }
                     } else if assign_child.kind() == "expression_list" {
-                        for expr_child in assign_child.children(&mut assign_child.walk()) {
-                            expr_kinds.push(expr_child.kind() == "function_definition");
-                        }
+                        // Named children only, so `local a, b = x, y` stays aligned
+                        values.extend(assign_child.named_children(&mut assign_child.walk()));
                     }
                 }
 
                 for (i, var_node) in var_names.iter().enumerate() {
                     let name = code[var_node.byte_range()].to_string();
                     let range = range_from_node(var_node);
-                    let is_function = expr_kinds.get(i).copied().unwrap_or(false);
+                    let is_function = values
+                        .get(i)
+                        .is_some_and(|value| value.kind() == "function_definition");
 
                     let kind = if is_function {
                         SymbolKind::Function
@@ -464,6 +538,7 @@ impl LuaParser {
         }
     }
 
+    #[allow(clippy::too_many_arguments)]
     fn process_assignment(
         &mut self,
         node: Node,
@@ -474,15 +549,19 @@ impl LuaParser {
         module_path: &str,
         depth: usize,
     ) {
-        // Build position-aligned Vec<bool> for each expression value
-        let mut function_value_flags = Vec::new();
+        // Position-aligned with the variable_list children (commas included)
+        let mut values = Vec::new();
         for child in node.children(&mut node.walk()) {
             if child.kind() == "expression_list" {
-                for expr_child in child.children(&mut child.walk()) {
-                    function_value_flags.push(expr_child.kind() == "function_definition");
-                }
+                values.extend(child.children(&mut child.walk()));
             }
         }
+        let function_value = |index: usize| {
+            values
+                .get(index)
+                .copied()
+                .filter(|value| value.kind() == "function_definition")
+        };
 
         for child in node.children(&mut node.walk()) {
             if child.kind() == "variable_list" {
@@ -496,9 +575,8 @@ impl LuaParser {
                             }
 
                             let range = range_from_node(&var_child);
-                            let is_function =
-                                function_value_flags.get(index).copied().unwrap_or(false);
-                            let kind = if is_function {
+                            let function = function_value(index);
+                            let kind = if function.is_some() {
                                 SymbolKind::Function
                             } else if name.chars().all(|c| c.is_uppercase() || c == '_')
                                 && name.contains('_')
@@ -516,7 +594,7 @@ impl LuaParser {
 
                             let doc_comment = self.extract_lua_doc_comment(&node, code);
 
-                            let symbol = self.create_symbol(
+                            let mut symbol = self.create_symbol(
                                 counter.next_id(),
                                 name.clone(),
                                 kind,
@@ -527,11 +605,11 @@ impl LuaParser {
                                 module_path,
                                 visibility,
                             );
+                            // Assignment without `local` creates a global
+                            symbol.scope_context = Some(ScopeContext::Global);
                             symbols.push(symbol);
                         }
                         "dot_index_expression" => {
-                            let is_function =
-                                function_value_flags.get(index).copied().unwrap_or(false);
                             self.process_dot_index_assignment(
                                 var_child,
                                 node,
@@ -540,7 +618,8 @@ impl LuaParser {
                                 counter,
                                 symbols,
                                 module_path,
-                                is_function,
+                                function_value(index),
+                                depth,
                             );
                         }
                         _ => {}
@@ -549,25 +628,26 @@ impl LuaParser {
             }
         }
 
-        for child in node.children(&mut node.walk()) {
-            if child.kind() == "expression_list" {
-                for expr_child in child.children(&mut child.walk()) {
-                    if expr_child.kind() == "table_constructor" {
-                        self.extract_symbols_from_node(
-                            expr_child,
-                            code,
-                            file_id,
-                            counter,
-                            symbols,
-                            module_path,
-                            depth + 1,
-                        );
-                    }
-                }
+        for value in &values {
+            if value.kind() == "table_constructor" {
+                self.extract_symbols_from_node(
+                    *value,
+                    code,
+                    file_id,
+                    counter,
+                    symbols,
+                    module_path,
+                    depth + 1,
+                );
             }
         }
     }
 
+    /// `M.VERSION = "1.0"`, `M.helper = function(...) end`
+    ///
+    /// Function values are members of the indexed table, the same as
+    /// `function M.helper(...)`, and their bodies are walked.
+    #[allow(clippy::too_many_arguments)]
     fn process_dot_index_assignment(
         &mut self,
         node: Node,
@@ -577,13 +657,14 @@ impl LuaParser {
         counter: &mut SymbolCounter,
         symbols: &mut Vec<Symbol>,
         module_path: &str,
-        is_function: bool,
+        function: Option<Node>,
+        depth: usize,
     ) {
         if let Some(field_node) = node.child_by_field_name("field") {
             let field_name = code[field_node.byte_range()].to_string();
             let range = range_from_node(&node);
 
-            let kind = if is_function {
+            let kind = if function.is_some() {
                 SymbolKind::Function
             } else if field_name.chars().all(|c| c.is_uppercase() || c == '_')
                 && field_name.contains('_')
@@ -599,12 +680,42 @@ impl LuaParser {
                 Visibility::Public
             };
 
-            let signature = code[node.byte_range()].to_string();
+            let target = &code[node.byte_range()];
+            let signature = match function.and_then(|f| f.child_by_field_name("parameters")) {
+                Some(params) => format!("{target} = function{}", &code[params.byte_range()]),
+                None => target.to_string(),
+            };
             let doc_comment = self.extract_lua_doc_comment(&parent_node, code);
 
+            let Some(function) = function else {
+                let symbol = self.create_symbol(
+                    counter.next_id(),
+                    field_name,
+                    kind,
+                    file_id,
+                    range,
+                    Some(signature),
+                    doc_comment,
+                    module_path,
+                    visibility,
+                );
+                symbols.push(symbol);
+                return;
+            };
+
+            // `M.sub.helper = function` belongs to `M.sub`, like `function M.sub.helper()`
+            let table_name = node
+                .child_by_field_name("table")
+                .map(|table| code[table.byte_range()].to_string());
+            let saved_class = self.context.current_class().map(|s| s.to_string());
+            if let Some(table_name) = &table_name {
+                self.context.enter_scope(ScopeType::Class);
+                self.context.set_current_class(Some(table_name.clone()));
+            }
+
             let symbol = self.create_symbol(
                 counter.next_id(),
-                field_name,
+                field_name.clone(),
                 kind,
                 file_id,
                 range,
@@ -614,6 +725,22 @@ impl LuaParser {
                 visibility,
             );
             symbols.push(symbol);
+
+            self.process_function_body(
+                function,
+                &field_name,
+                code,
+                file_id,
+                counter,
+                symbols,
+                module_path,
+                depth,
+            );
+
+            if table_name.is_some() {
+                self.context.exit_scope();
+            }
+            self.context.set_current_class(saved_class);
         }
     }
 
@@ -779,6 +906,19 @@ fn extract_method_calls_recursive<'a>(
         }
     }
 
+    // `M.foo = function() ... end`: calls in the body come from `foo`
+    if node.kind() == "assignment_statement" {
+        for child in node.children(&mut node.walk()) {
+            if child.kind() == "variable_list" {
+                extract_method_calls_recursive(&child, code, enclosing, calls);
+            }
+        }
+        for (target, value) in assignment_values(node) {
+            let name = table_function_binding(target, &value, code);
+            extract_method_calls_recursive(&value, code, name.or(enclosing), calls);
+        }
+        return;
+    }
     if node.kind() == "function_call" {
         if let Some(name_node) = node.child_by_field_name("name") {
             // Colon form `tbl:method()` and dot form `tbl.fn()` differ in AST kind only;
@@ -821,38 +961,15 @@ fn extract_imports_recursive(node: &Node, code: &str, file_id: FileId, imports:
     while let Some(current_node) = stack.pop() {
         let mut found_import = false;
 
-        // Look for variable_declaration containing require() calls
-        // Pattern: local foo = require("module")
-        if current_node.kind() == "variable_declaration" {
-            let mut alias: Option<String> = None;
-            let mut require_call: Option<Node> = None;
-
-            for child in current_node.children(&mut current_node.walk()) {
-                if child.kind() == "assignment_statement" {
-                    for assign_child in child.children(&mut child.walk()) {
-                        if assign_child.kind() == "variable_list" {
-                            // Get the variable name (alias)
-                            for var_child in assign_child.children(&mut assign_child.walk()) {
-                                if var_child.kind() == "identifier" {
-                                    alias = Some(code[var_child.byte_range()].to_string());
-                                    break;
-                                }
-                            }
-                        } else if assign_child.kind() == "expression_list" {
-                            // Check if value is a require() call
-                            for expr_child in assign_child.children(&mut assign_child.walk()) {
-                                if expr_child.kind() == "function_call" {
-                                    require_call = Some(expr_child);
-                                    break;
-                                }
-                            }
-                        }
-                    }
-                }
-            }
-
-            if let Some(call_node) = require_call {
-                if let Some(import) = try_extract_require_call(&call_node, code, file_id, alias) {
+        // Assignments of require() calls, local or global:
+        // `local foo = require("module")`, `json = require("json")`,
+        // `local a, b = require("a"), require("b")`
+        if current_node.kind() == "assignment_statement" {
+            for (target, value) in assignment_values(&current_node) {
+                let alias = target
+                    .filter(|target| target.kind() == "identifier")
+                    .map(|target| code[target.byte_range()].to_string());
+                if let Some(import) = try_extract_require_call(&value, code, file_id, alias) {
                     imports.push(import);
                     found_import = true;
                 }
@@ -972,6 +1089,10 @@ impl LuaParser {
                 self.register_handled_node(node.kind(), node.kind_id());
                 self.process_children_for_calls(node, code, calls, current_function);
             }
+            "assignment_statement" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_assignment_for_calls(node, code, calls, current_function);
+            }
             _ => {
                 self.process_children_for_calls(node, code, calls, current_function);
             }
@@ -1009,6 +1130,33 @@ impl LuaParser {
         }
     }
 
+    /// Attribute calls inside `M.name = function() ... end` values to `name`
+    fn process_assignment_for_calls<'a>(
+        &mut self,
+        node: Node,
+        code: &'a str,
+        calls: &mut Vec<(&'a str, &'a str, Range)>,
+        current_function: &mut Option<&'a str>,
+    ) {
+        for child in node.children(&mut node.walk()) {
+            if child.kind() == "variable_list" {
+                self.find_calls_in_node(child, code, calls, current_function);
+            }
+        }
+
+        for (target, value) in assignment_values(&node) {
+            match table_function_binding(target, &value, code) {
+                Some(name) => {
+                    let old_function = *current_function;
+                    *current_function = Some(name);
+                    self.find_calls_in_node(value, code, calls, current_function);
+                    *current_function = old_function;
+                }
+                None => self.find_calls_in_node(value, code, calls, current_function),
+            }
+        }
+    }
+
     fn process_call<'a>(
         &mut self,
         node: Node,
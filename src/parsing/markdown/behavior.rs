@@ -0,0 +1,228 @@
+//! Markdown-specific language behavior implementation
+//!
+//! A document's module path is its path from the project root without the
+//! extension (`docs/architecture.md` is `docs/architecture`). Documents
+//! import nothing; the code names in their backticks reference symbols of
+//! the other indexed languages instead (see
+//! [`LanguageBehavior::references_other_languages`]).
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::symbol::ScopeContext;
+use crate::{FileId, Symbol, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Markdown language behavior implementation
+#[derive(Clone)]
+pub struct MarkdownBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl MarkdownBehavior {
+    /// Create a new Markdown behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_md::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for MarkdownBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for MarkdownBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for MarkdownBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("markdown")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// Every heading can be linked to
+    fn parse_visibility(&self, _signature: &str) -> Visibility {
+        Visibility::Public
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "/"
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &[]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("/"))
+        }
+    }
+
+    fn module_path_from_file(
+        &self,
+        file_path: &Path,
+        workspace_root: &Path,
+        extensions: &[&str],
+    ) -> Option<String> {
+        let relative_path = file_path.strip_prefix(workspace_root).ok()?;
+        let path = relative_path.to_str()?;
+        let path = extensions
+            .iter()
+            .chain(["md", "markdown"].iter())
+            .find_map(|extension| path.strip_suffix(&format!(".{extension}")))
+            .unwrap_or(path);
+        let components: Vec<&str> = path
+            .split(std::path::MAIN_SEPARATOR)
+            .filter(|s| !s.is_empty())
+            .collect();
+        self.format_path_as_module(&components)
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(crate::parsing::GenericResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    fn import_matches_symbol(
+        &self,
+        _import_path: &str,
+        _symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        false
+    }
+
+    /// Backticked names are code, never other documents' headings
+    fn references_other_languages(&self) -> bool {
+        true
+    }
+
+    /// Documentation names what a project exposes: public symbols outrank
+    /// private ones, and locals and parameters are never meant
+    fn cross_language_target_rank(&self, _caller: &Symbol, candidate: &Symbol) -> Option<u8> {
+        match (&candidate.scope_context, candidate.visibility) {
+            (Some(ScopeContext::Local { .. } | ScopeContext::Parameter), _) => None,
+            (_, Visibility::Public) => Some(0),
+            _ => Some(1),
+        }
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::SymbolKind;
+
+    fn function(visibility: Visibility, scope: ScopeContext) -> Symbol {
+        let mut symbol = Symbol::new(
+            crate::SymbolId::new(2).unwrap(),
+            "authenticate_user",
+            SymbolKind::Function,
+            FileId::new(2).unwrap(),
+            crate::Range::new(0, 0, 10, 1),
+        )
+        .with_visibility(visibility);
+        symbol.scope_context = Some(scope);
+        symbol
+    }
+
+    #[test]
+    fn test_module_path_drops_extension() {
+        let behavior = MarkdownBehavior::new();
+        let root = Path::new("/project");
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/docs/architecture.md"),
+                root,
+                &["md"]
+            ),
+            Some("docs/architecture".to_string())
+        );
+        assert_eq!(
+            behavior.module_path_from_file(Path::new("/project/README.md"), root, &[]),
+            Some("README".to_string())
+        );
+    }
+
+    #[test]
+    fn test_public_symbols_outrank_private_ones() {
+        let behavior = MarkdownBehavior::new();
+        let section = Symbol::new(
+            crate::SymbolId::new(1).unwrap(),
+            "Authentication",
+            SymbolKind::Module,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 20, 0),
+        );
+
+        assert!(behavior.references_other_languages());
+        assert_eq!(
+            behavior.cross_language_target_rank(
+                &section,
+                &function(Visibility::Public, ScopeContext::Module)
+            ),
+            Some(0)
+        );
+        assert_eq!(
+            behavior.cross_language_target_rank(
+                &section,
+                &function(Visibility::Private, ScopeContext::Module)
+            ),
+            Some(1)
+        );
+        assert_eq!(
+            behavior.cross_language_target_rank(
+                &section,
+                &function(
+                    Visibility::Private,
+                    ScopeContext::Local {
+                        hoisted: false,
+                        parent_name: None,
+                        parent_kind: None,
+                    }
+                )
+            ),
+            None
+        );
+    }
+}
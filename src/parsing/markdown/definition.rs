@@ -0,0 +1,89 @@
+//! Markdown language definition for the registry
+//!
+//! Provides the Markdown language implementation, covering `.md` and
+//! `.markdown` documents, that self-registers with the global registry.
+
+use std::sync::Arc;
+
+use super::{MarkdownBehavior, MarkdownParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Markdown language definition
+pub struct MarkdownLanguage;
+
+impl MarkdownLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("markdown");
+}
+
+impl LanguageDefinition for MarkdownLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Markdown"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["md", "markdown"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = MarkdownParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(MarkdownBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Markdown is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Markdown is enabled by default
+    }
+}
+
+/// Register Markdown language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(MarkdownLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_markdown_definition() {
+        let markdown = MarkdownLanguage;
+
+        assert_eq!(markdown.id(), LanguageId::new("markdown"));
+        assert_eq!(markdown.name(), "Markdown");
+        assert!(markdown.extensions().contains(&"md"));
+    }
+
+    #[test]
+    fn test_markdown_enabled_by_default() {
+        let markdown = MarkdownLanguage;
+        let settings = Settings::default();
+
+        assert!(markdown.default_enabled());
+        assert!(markdown.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_markdown_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("markdown")));
+    }
+}
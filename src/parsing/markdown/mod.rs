@@ -0,0 +1,19 @@
+//! Markdown language parser implementation
+//!
+//! Indexes the headings of Markdown documents as symbols documented by
+//! their sections, and the code names in their backticks (see
+//! [`references`]) as references to symbols of the other indexed
+//! languages, so documentation and code can be navigated together.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+pub mod references;
+
+pub use behavior::MarkdownBehavior;
+pub use definition::MarkdownLanguage;
+pub use parser::MarkdownParser;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
@@ -0,0 +1,341 @@
+//! Markdown document parser implementation
+//!
+//! Indexes the outline of Markdown documents using the block grammar of
+//! tree-sitter-md: every heading is a symbol spanning the section it opens,
+//! documented by the prose and code blocks of that section, so semantic
+//! search reaches design docs and READMEs.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | Name | SymbolKind |
+//! |-----------|------|------------|
+//! | `## Session handling` (ATX) | `Session handling` | Module |
+//! | `Session handling` underlined by `===` or `---` (setext) | `Session handling` | Module |
+//!
+//! ## Relationships
+//!
+//! Sections use the code symbols their inline code spans name
+//! (`` `authenticate_user` ``, `` `SessionStore::open` ``); see
+//! [`super::references`]. Those symbols belong to other languages, so the
+//! references are extracted and resolved across languages by the indexing
+//! pipeline rather than returned by this parser.
+//!
+//! ## Documentation
+//!
+//! A section's doc comment is its own text up to its first subsection:
+//! paragraphs, lists, quotes and fenced code blocks as written.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, NodeTracker, NodeTrackingState,
+    truncate_for_display,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Longest section text kept as a heading's doc comment
+const MAX_DOC_LEN: usize = 2000;
+
+/// Markdown-specific parsing errors
+#[derive(Error, Debug)]
+pub enum MarkdownParseError {
+    #[error(
+        "Failed to initialize Markdown parser: {reason}\nSuggestion: Ensure tree-sitter-md is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// Markdown language parser
+pub struct MarkdownParser {
+    parser: Parser,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for MarkdownParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("MarkdownParser")
+            .field("language", &"Markdown")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// A heading and the section it opens
+struct Section<'t> {
+    node: Node<'t>,
+    heading: Node<'t>,
+    level: usize,
+    title: String,
+}
+
+/// `1` for `#` and `===`, up to `6` for `######`
+fn heading_level(heading: &Node) -> usize {
+    let mut cursor = heading.walk();
+    heading
+        .children(&mut cursor)
+        .find_map(|child| match child.kind() {
+            "setext_h1_underline" => Some(1),
+            "setext_h2_underline" => Some(2),
+            kind => kind
+                .strip_prefix("atx_h")
+                .and_then(|rest| rest.strip_suffix("_marker"))
+                .and_then(|level| level.parse().ok()),
+        })
+        .unwrap_or(1)
+}
+
+/// `` ## The `login` flow ## `` -> `The login flow`
+fn heading_title(heading: &Node, code: &str) -> Option<String> {
+    let content = heading.child_by_field_name("heading_content")?;
+    let mut text = code[content.byte_range()].trim();
+    if heading.kind() == "atx_heading" {
+        // A closing sequence of `#`s follows a space
+        let open = text.trim_end_matches('#');
+        if open.is_empty() || open.ends_with([' ', '\t']) {
+            text = open.trim_end();
+        }
+    }
+    let title = text
+        .replace('`', "")
+        .split_whitespace()
+        .collect::<Vec<_>>()
+        .join(" ");
+    (!title.is_empty()).then_some(title)
+}
+
+impl MarkdownParser {
+    /// Create a new Markdown parser instance
+    pub fn new() -> Result<Self, MarkdownParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_md::LANGUAGE.into())
+            .map_err(|e| MarkdownParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    /// The heading opening a section, if any; text before a document's
+    /// first heading is a section without one
+    fn heading_of<'t>(section: &Node<'t>) -> Option<Node<'t>> {
+        section
+            .named_child(0)
+            .filter(|child| matches!(child.kind(), "atx_heading" | "setext_heading"))
+    }
+
+    /// Sections opened by a heading, in document order
+    fn sections<'t>(node: Node<'t>, code: &str, sections: &mut Vec<Section<'t>>, depth: usize) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        if node.kind() == "section" {
+            if let Some(heading) = Self::heading_of(&node) {
+                if let Some(title) = heading_title(&heading, code) {
+                    sections.push(Section {
+                        node,
+                        heading,
+                        level: heading_level(&heading),
+                        title,
+                    });
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            if child.kind() == "section" {
+                Self::sections(child, code, sections, depth + 1);
+            }
+        }
+    }
+}
+
+impl LanguageParser for MarkdownParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        let Some(tree) = self.parser.parse(code, None) else {
+            return Vec::new();
+        };
+
+        let mut sections = Vec::new();
+        Self::sections(tree.root_node(), code, &mut sections, 0);
+
+        let mut symbols = Vec::new();
+        for section in sections {
+            self.register_handled_node(section.heading.kind(), section.heading.kind_id());
+
+            let mut symbol = Symbol::new(
+                symbol_counter.next_id(),
+                section.title.as_str(),
+                SymbolKind::Module,
+                file_id,
+                range_from_node(&section.node),
+            )
+            .with_signature(format!("{} {}", "#".repeat(section.level), section.title))
+            .with_visibility(Visibility::Public);
+            if let Some(doc) = self.extract_doc_comment(&section.node, code) {
+                symbol = symbol.with_doc(doc);
+            }
+            symbol.scope_context = Some(ScopeContext::Module);
+            symbols.push(symbol);
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// The text of a section up to its first subsection
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        if node.kind() != "section" {
+            return None;
+        }
+
+        let mut cursor = node.walk();
+        let blocks: Vec<&str> = node
+            .named_children(&mut cursor)
+            .skip(usize::from(Self::heading_of(node).is_some()))
+            .take_while(|child| child.kind() != "section")
+            .map(|child| code[child.byte_range()].trim())
+            .filter(|text| !text.is_empty())
+            .collect();
+        if blocks.is_empty() {
+            return None;
+        }
+        Some(truncate_for_display(&blocks.join("\n\n"), MAX_DOC_LEN))
+    }
+
+    fn find_calls<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_implementations<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    /// Code references resolve across languages; see
+    /// [`super::references::find_references`]
+    fn find_uses<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_defines<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_imports(&mut self, _code: &str, _file_id: FileId) -> Vec<Import> {
+        Vec::new()
+    }
+
+    fn language(&self) -> Language {
+        Language::Markdown
+    }
+}
+
+impl NodeTracker for MarkdownParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = MarkdownParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(MarkdownParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_headings_are_sections() {
+        let code = r#"Preamble without a heading.
+
+# Authentication
+
+Users sign in with `authenticate_user`.
+
+## The `login` flow ##
+
+```rust
+let session = SessionStore::open(&config)?;
+```
+
+Sessions
+--------
+
+Stored in Redis.
+
+# C#
+"#;
+        let symbols = parse(code);
+        assert_eq!(symbols.len(), 4);
+
+        let auth = find(&symbols, "Authentication");
+        assert_eq!(auth.kind, SymbolKind::Module);
+        assert_eq!(auth.signature.as_deref(), Some("# Authentication"));
+        assert_eq!(
+            auth.doc_comment.as_deref(),
+            Some("Users sign in with `authenticate_user`.")
+        );
+        assert_eq!(auth.range.start_line, 2);
+        assert!(auth.range.end_line >= 16, "Spans its subsections");
+
+        let login = find(&symbols, "The login flow");
+        assert_eq!(login.signature.as_deref(), Some("## The login flow"));
+        assert_eq!(
+            login.doc_comment.as_deref(),
+            Some("```rust\nlet session = SessionStore::open(&config)?;\n```")
+        );
+
+        let sessions = find(&symbols, "Sessions");
+        assert_eq!(sessions.signature.as_deref(), Some("## Sessions"));
+        assert_eq!(sessions.doc_comment.as_deref(), Some("Stored in Redis."));
+
+        assert!(find(&symbols, "C#").doc_comment.is_none());
+    }
+}
@@ -0,0 +1,229 @@
+//! Code references of Markdown prose
+//!
+//! An inline code span naming an identifier refers to the code symbol of
+//! that name, in any language:
+//!
+//! ```markdown
+//! Call `authenticate_user()` before `SessionStore::open`, or see
+//! `auth.login` and `User#save`.
+//! ```
+//!
+//! The last segment of a qualified name is the referenced symbol and the
+//! segment before it its container (`SessionStore`, `auth`, `User`). Spans
+//! holding anything else (`cargo build`, `--verbose`, `retries = 3`)
+//! refer to nothing, and code blocks are examples, not references.
+
+use crate::Range;
+use crate::parsing::parser::check_recursion_depth;
+use tree_sitter::Node;
+
+/// Nodes whose text is prose, in the block grammar
+const PROSE_NODES: &[&str] = &["inline", "pipe_table_cell"];
+
+/// Separators of qualified names: `Type::method`, `module.function`,
+/// `Class#method`
+const SEPARATORS: &[&str] = &["::", ".", "#"];
+
+/// An inline code span naming a code symbol
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct CodeReference<'a> {
+    /// The referenced symbol: `open` in `SessionStore::open`
+    pub name: &'a str,
+    /// Its container, when qualified: `SessionStore`
+    pub receiver: Option<&'a str>,
+    /// The span as written, without backticks
+    pub text: &'a str,
+    /// Where the span is, backticks included
+    pub range: Range,
+}
+
+/// Code references of a document tree, in document order
+pub fn find_references<'a>(root: Node, code: &'a str) -> Vec<CodeReference<'a>> {
+    let mut references = Vec::new();
+    visit(root, code, &mut references, 0);
+    references
+}
+
+fn visit<'a>(node: Node, code: &'a str, references: &mut Vec<CodeReference<'a>>, depth: usize) {
+    if !check_recursion_depth(depth, node) {
+        return;
+    }
+
+    if PROSE_NODES.contains(&node.kind()) {
+        for (span, text) in code_spans(&code[node.byte_range()]) {
+            if let Some((receiver, name)) = parse_reference(text) {
+                references.push(CodeReference {
+                    name,
+                    receiver,
+                    text: text.trim(),
+                    range: range_in(&node, code, span),
+                });
+            }
+        }
+        return;
+    }
+
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        visit(child, code, references, depth + 1);
+    }
+}
+
+/// Inline code spans of a prose text, as (byte span with backticks,
+/// content)
+///
+/// A span opens with a run of backticks and closes with the next run of
+/// the same length; an unmatched run is literal text, as are escaped
+/// backticks.
+fn code_spans(text: &str) -> Vec<(std::ops::Range<usize>, &str)> {
+    let bytes = text.as_bytes();
+    let run_at = |start: usize| {
+        bytes[start..]
+            .iter()
+            .take_while(|&&byte| byte == b'`')
+            .count()
+    };
+
+    let mut spans = Vec::new();
+    let mut i = 0;
+    while i < bytes.len() {
+        match bytes[i] {
+            b'\\' => i += 2,
+            b'`' => {
+                let open = run_at(i);
+                let content_start = i + open;
+                let mut j = content_start;
+                let mut close = None;
+                while j < bytes.len() {
+                    if bytes[j] == b'`' {
+                        let run = run_at(j);
+                        if run == open {
+                            close = Some(j);
+                            break;
+                        }
+                        j += run;
+                    } else {
+                        j += 1;
+                    }
+                }
+                match close {
+                    Some(close) => {
+                        spans.push((i..close + open, &text[content_start..close]));
+                        i = close + open;
+                    }
+                    None => i = content_start,
+                }
+            }
+            _ => i += 1,
+        }
+    }
+    spans
+}
+
+fn is_identifier(segment: &str) -> bool {
+    let mut chars = segment.chars();
+    chars
+        .next()
+        .is_some_and(|first| first.is_ascii_alphabetic() || first == '_')
+        && chars.all(|c| c.is_ascii_alphanumeric() || c == '_')
+}
+
+/// `SessionStore::open()` -> (`Some("SessionStore")`, `open`)
+fn parse_reference(text: &str) -> Option<(Option<&str>, &str)> {
+    let text = text.trim();
+    let text = text.strip_suffix("()").unwrap_or(text);
+
+    let (qualifier, name) = SEPARATORS
+        .iter()
+        .filter_map(|separator| {
+            text.rfind(separator)
+                .map(|at| (&text[..at], &text[at + separator.len()..]))
+        })
+        .min_by_key(|(_, name)| name.len())
+        .map_or((None, text), |(qualifier, name)| (Some(qualifier), name));
+    if !is_identifier(name) {
+        return None;
+    }
+
+    let Some(qualifier) = qualifier else {
+        return Some((None, name));
+    };
+    let segments: Vec<&str> = SEPARATORS.iter().fold(vec![qualifier], |parts, separator| {
+        parts
+            .into_iter()
+            .flat_map(|part| part.split(separator))
+            .collect()
+    });
+    if !segments.iter().all(|segment| is_identifier(segment)) {
+        return None;
+    }
+    Some((segments.last().copied(), name))
+}
+
+/// Position of a byte span of a node's text
+fn range_in(node: &Node, code: &str, span: std::ops::Range<usize>) -> Range {
+    let text = &code[node.byte_range()];
+    let start = node.start_position();
+    let position = |offset: usize| {
+        let before = &text[..offset];
+        match before.rfind('\n') {
+            Some(newline) => (
+                (start.row + before.matches('\n').count()) as u32,
+                (offset - newline - 1) as u16,
+            ),
+            None => (start.row as u32, (start.column + offset) as u16),
+        }
+    };
+    let (start_line, start_column) = position(span.start);
+    let (end_line, end_column) = position(span.end);
+    Range::new(start_line, start_column, end_line, end_column)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_code_spans() {
+        let spans: Vec<&str> = code_spans("Use `a`, ``b ` c`` and \\`d")
+            .into_iter()
+            .map(|(_, content)| content)
+            .collect();
+        assert_eq!(spans, vec!["a", "b ` c"]);
+    }
+
+    #[test]
+    fn test_parse_reference() {
+        assert_eq!(
+            parse_reference("authenticate_user()"),
+            Some((None, "authenticate_user"))
+        );
+        assert_eq!(
+            parse_reference("crate::auth::SessionStore::open"),
+            Some((Some("SessionStore"), "open"))
+        );
+        assert_eq!(parse_reference("auth.login"), Some((Some("auth"), "login")));
+        assert_eq!(parse_reference("User#save"), Some((Some("User"), "save")));
+        assert_eq!(parse_reference("cargo build"), None);
+        assert_eq!(parse_reference("--verbose"), None);
+        assert_eq!(parse_reference("src/main.rs"), None);
+        assert_eq!(parse_reference("x = 1"), None);
+    }
+
+    #[test]
+    fn test_references_skip_code_blocks() {
+        let code =
+            "# Auth\n\nCall `login()` first.\n\n```rust\nlet user = `not_a_reference`;\n```\n";
+        let mut parser = tree_sitter::Parser::new();
+        parser
+            .set_language(&tree_sitter_md::LANGUAGE.into())
+            .unwrap();
+        let tree = parser.parse(code, None).unwrap();
+
+        let references = find_references(tree.root_node(), code);
+        assert_eq!(references.len(), 1);
+        assert_eq!(references[0].name, "login");
+        assert_eq!(references[0].text, "login()");
+        assert_eq!(references[0].range, Range::new(2, 5, 2, 14));
+    }
+}
@@ -0,0 +1,294 @@
+//! Size and complexity of functions
+//!
+//! The measures are language-agnostic: they walk the syntax tree looking for
+//! node kinds the grammars share, so one pass serves every language.
+//!
+//! - cyclomatic complexity: one, plus one for each branch, loop, case,
+//!   catch, conditional expression and short-circuit `&&`/`||`/`and`/`or`.
+//!   A branch counts towards the innermost function holding it, closures
+//!   towards the function they are written in.
+//! - lines: from the first line of the function to its last
+//! - parameters: the entries of its parameter list, receivers included
+//!
+//! The indexer runs it when `indexing.symbol_metrics` is set.
+
+use crate::Range;
+use crate::symbol::SymbolMetrics;
+use tree_sitter::{Node, Point};
+
+/// Node kinds opening a path of their own
+const DECISIONS: &[&str] = &[
+    // Branches
+    "if_statement",
+    "if_expression",
+    "elif_clause",
+    "else_if_clause",
+    "if_clause",
+    "if",
+    "elsif",
+    "unless",
+    "if_modifier",
+    "unless_modifier",
+    "guard_statement",
+    "conditional_expression",
+    "ternary_expression",
+    // Loops
+    "for_statement",
+    "for_expression",
+    "for_in_statement",
+    "for_in_clause",
+    "enhanced_for_statement",
+    "foreach_statement",
+    "for",
+    "while_statement",
+    "while_expression",
+    "while",
+    "while_modifier",
+    "until",
+    "until_modifier",
+    "do_statement",
+    "do_while_statement",
+    "repeat_statement",
+    // Cases
+    "match_arm",
+    "case_clause",
+    "switch_case",
+    "switch_section",
+    "switch_block_statement_group",
+    "switch_rule",
+    "case_statement",
+    "expression_case",
+    "type_case",
+    "communication_case",
+    "when_entry",
+    "when",
+    // Handlers
+    "catch_clause",
+    "catch_block",
+    "except_clause",
+    "rescue",
+];
+
+/// Short-circuit operators, a branch each
+const SHORT_CIRCUITS: &[&str] = &["&&", "||", "and", "or"];
+
+/// Node kinds of parameter lists, for grammars without a `parameters` field
+const PARAMETER_LISTS: &[&str] = &[
+    "parameters",
+    "formal_parameters",
+    "formal_parameter_list",
+    "parameter_list",
+    "function_value_parameters",
+    "method_parameters",
+];
+
+fn point(line: u32, column: u16) -> Point {
+    Point::new(line as usize, column as usize)
+}
+
+fn contains(range: &Range, at: Point) -> bool {
+    let start = point(range.start_line, range.start_column);
+    let end = point(range.end_line, range.end_column);
+    start <= at && at < end
+}
+
+fn is_decision(node: Node) -> bool {
+    if node.is_named() {
+        return DECISIONS.contains(&node.kind());
+    }
+    // `||` also opens a Rust closure, so only operators of an expression
+    SHORT_CIRCUITS.contains(&node.kind())
+        && node.parent().is_some_and(|parent| {
+            let kind = parent.kind();
+            kind.contains("binary") || kind.contains("boolean") || kind.contains("logical")
+        })
+}
+
+/// Where each branch of the tree starts
+fn decisions(root: Node) -> Vec<Point> {
+    let mut points = Vec::new();
+    let mut cursor = root.walk();
+    let mut stack = vec![root];
+    while let Some(node) = stack.pop() {
+        if is_decision(node) {
+            points.push(node.start_position());
+        }
+        stack.extend(node.children(&mut cursor));
+    }
+    points
+}
+
+/// The parameter list of the function at `range`, searched a few levels
+/// down from the function's node and never inside its body
+fn parameter_list<'tree>(root: Node<'tree>, range: &Range) -> Option<Node<'tree>> {
+    let function = root.descendant_for_point_range(
+        point(range.start_line, range.start_column),
+        point(range.end_line, range.end_column),
+    )?;
+    let mut level = vec![function];
+    for _ in 0..3 {
+        let mut next = Vec::new();
+        for node in level {
+            if let Some(parameters) = node.child_by_field_name("parameters") {
+                return Some(parameters);
+            }
+            if PARAMETER_LISTS.contains(&node.kind()) {
+                return Some(node);
+            }
+            let mut cursor = node.walk();
+            next.extend(node.named_children(&mut cursor).filter(|child| {
+                let kind = child.kind();
+                !kind.contains("body") && !kind.contains("block")
+            }));
+        }
+        level = next;
+    }
+    None
+}
+
+fn parameter_count(root: Node, range: &Range) -> u32 {
+    let mut cursor = root.walk();
+    match parameter_list(root, range) {
+        Some(list) => list
+            .named_children(&mut cursor)
+            .filter(|child| !child.kind().contains("comment"))
+            .count() as u32,
+        // Swift lists the parameters on the declaration itself
+        None => root
+            .descendant_for_point_range(
+                point(range.start_line, range.start_column),
+                point(range.end_line, range.end_column),
+            )
+            .map(|function| {
+                function
+                    .named_children(&mut cursor)
+                    .filter(|child| child.kind() == "parameter")
+                    .count() as u32
+            })
+            .unwrap_or(0),
+    }
+}
+
+/// The metrics of the functions at `functions`, in the same order
+pub fn measure(root: Node, functions: &[Range]) -> Vec<SymbolMetrics> {
+    let mut metrics: Vec<SymbolMetrics> = functions
+        .iter()
+        .map(|range| SymbolMetrics {
+            complexity: 1,
+            lines: range.end_line.saturating_sub(range.start_line) + 1,
+            parameters: parameter_count(root, range),
+        })
+        .collect();
+
+    // Innermost first, so the first function holding a branch owns it
+    let mut by_size: Vec<usize> = (0..functions.len()).collect();
+    by_size.sort_by_key(|&index| {
+        let range = &functions[index];
+        (
+            range.end_line.saturating_sub(range.start_line),
+            range.end_column.abs_diff(range.start_column),
+        )
+    });
+    for at in decisions(root) {
+        if let Some(&owner) = by_size
+            .iter()
+            .find(|&&index| contains(&functions[index], at))
+        {
+            metrics[owner].complexity += 1;
+        }
+    }
+    metrics
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn measure_source(language: tree_sitter::Language, code: &str) -> Vec<SymbolMetrics> {
+        let mut parser = tree_sitter::Parser::new();
+        parser.set_language(&language).unwrap();
+        let tree = parser.parse(code, None).unwrap();
+        let root = tree.root_node();
+        let mut functions = Vec::new();
+        let mut stack = vec![root];
+        let mut cursor = root.walk();
+        while let Some(node) = stack.pop() {
+            if matches!(node.kind(), "function_item" | "function_definition") {
+                let (start, end) = (node.start_position(), node.end_position());
+                functions.push(Range::new(
+                    start.row as u32,
+                    start.column as u16,
+                    end.row as u32,
+                    end.column as u16,
+                ));
+            }
+            stack.extend(node.children(&mut cursor));
+        }
+        functions.sort_by_key(|range| range.start_line);
+        measure(root, &functions)
+    }
+
+    #[test]
+    fn test_rust_branches_loops_and_arms() {
+        let metrics = measure_source(
+            tree_sitter_rust::LANGUAGE.into(),
+            r#"
+fn classify(value: i32, strict: bool) -> &'static str {
+    if value < 0 && strict {
+        return "negative";
+    }
+    for _ in 0..3 {
+        let check = || value > 10;
+        if check() || value == 0 {
+            break;
+        }
+    }
+    match value {
+        0 => "zero",
+        1 => "one",
+        _ => "many",
+    }
+}
+
+fn empty() {}
+"#,
+        );
+
+        assert_eq!(
+            metrics,
+            [
+                SymbolMetrics {
+                    // if, &&, for, if, ||, three arms
+                    complexity: 9,
+                    lines: 16,
+                    parameters: 2,
+                },
+                SymbolMetrics {
+                    complexity: 1,
+                    lines: 1,
+                    parameters: 0,
+                },
+            ]
+        );
+    }
+
+    #[test]
+    fn test_nested_function_owns_its_branches() {
+        let metrics = measure_source(
+            tree_sitter_python::LANGUAGE.into(),
+            r#"
+def outer(self, items, *rest):
+    def inner(item):
+        if item and item.ok:
+            return item
+    for item in items:
+        inner(item)
+"#,
+        );
+
+        assert_eq!(metrics[0].complexity, 2, "only the loop is outer's");
+        assert_eq!(metrics[0].parameters, 3);
+        assert_eq!(metrics[1].complexity, 3);
+        assert_eq!(metrics[1].parameters, 1);
+    }
+}
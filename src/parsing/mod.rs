@@ -1,47 +1,83 @@
+pub mod bash;
 pub mod behavior_state;
 pub mod c;
 pub mod clojure;
 pub mod context;
 pub mod cpp;
 pub mod csharp;
+pub mod css;
+pub mod dart;
+pub mod deprecation;
+pub mod directives;
+pub mod elixir;
+pub mod embedded;
 pub mod factory;
 pub mod gdscript;
 pub mod go;
+pub mod graphql;
+pub mod hcl;
+pub mod headers;
+pub mod html;
 pub mod import;
 pub mod java;
 pub mod javascript;
+pub mod jupyter;
+pub mod kinds;
 pub mod kotlin;
 pub mod language;
 pub mod language_behavior;
 pub mod lua;
+pub mod markdown;
 pub mod method_call;
+pub mod metrics;
 pub mod parser;
 pub mod paths;
 pub mod php;
+pub mod plugin;
+pub mod protobuf;
 pub mod python;
 pub mod registry;
 pub mod resolution;
+pub mod ruby;
 pub mod rust;
+pub mod scala;
+pub mod sfc;
+pub mod sql;
+pub mod strings;
+pub mod svelte;
 pub mod swift;
+pub mod todo;
 pub mod typescript;
+pub mod value_reference;
+pub mod vue;
+pub mod zig;
 
+pub use bash::{BashBehavior, BashParser};
 pub use c::{CBehavior, CParser};
 pub use clojure::{ClojureBehavior, ClojureParser};
 pub use context::{ParserContext, ScopeType};
 pub use cpp::{CppBehavior, CppParser};
 pub use csharp::{CSharpBehavior, CSharpParser};
+pub use css::{CssBehavior, CssParser};
+pub use dart::{DartBehavior, DartParser};
+pub use elixir::{ElixirBehavior, ElixirParser};
 pub use factory::{ParserFactory, ParserWithBehavior};
 pub use gdscript::{GdscriptBehavior, GdscriptParser};
 pub use go::{GoBehavior, GoParser};
+pub use graphql::{GraphQLBehavior, GraphQLParser};
+pub use hcl::{HclBehavior, HclParser};
+pub use html::{HtmlBehavior, HtmlParser};
 pub use import::Import;
 pub use java::{JavaBehavior, JavaParser};
 pub use javascript::{JavaScriptBehavior, JavaScriptParser};
+pub use jupyter::{JupyterBehavior, JupyterParser};
 pub use kotlin::{KotlinBehavior, KotlinParser};
 pub use language::Language;
 pub use language_behavior::{
     LanguageBehavior, LanguageMetadata, RelationRole, default_relationship_compatibility,
 };
 pub use lua::{LuaBehavior, LuaParser};
+pub use markdown::{MarkdownBehavior, MarkdownParser};
 pub use method_call::{MethodCall, MethodCallResolver};
 pub use parser::{
     HandledNode, LanguageParser, NodeTracker, NodeTrackingState, safe_substring_window,
@@ -51,12 +87,21 @@ pub use paths::{
     normalize_for_module_path, strip_extension, strip_source_root, strip_source_root_owned,
 };
 pub use php::{PhpBehavior, PhpParser};
+pub use plugin::{PluginBehavior, PluginParser};
+pub use protobuf::{ProtobufBehavior, ProtobufParser};
 pub use python::{PythonBehavior, PythonParser};
 pub use registry::{LanguageDefinition, LanguageId, LanguageRegistry, RegistryError, get_registry};
 pub use resolution::{
     CallerContext, GenericInheritanceResolver, GenericResolutionContext, InheritanceResolver,
     PipelineSymbolCache, ResolutionScope, ResolveResult, ScopeLevel,
 };
+pub use ruby::{RubyBehavior, RubyParser};
 pub use rust::{RustBehavior, RustParser};
+pub use scala::{ScalaBehavior, ScalaParser};
+pub use sql::{SqlBehavior, SqlParser};
+pub use svelte::{SvelteBehavior, SvelteParser};
 pub use swift::{SwiftBehavior, SwiftParser};
 pub use typescript::{TypeScriptBehavior, TypeScriptParser};
+pub use value_reference::{Access, ValueReference};
+pub use vue::{VueBehavior, VueParser};
+pub use zig::{ZigBehavior, ZigParser};
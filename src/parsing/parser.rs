@@ -4,10 +4,13 @@
 //! must implement to work with the indexing system.
 
 use crate::parsing::method_call::MethodCall;
+use crate::parsing::value_reference::ValueReference;
 use crate::types::SymbolCounter;
 use crate::{FileId, Range, Symbol};
 use std::any::Any;
+use std::cell::Cell;
 use std::collections::HashSet;
+use std::sync::atomic::{AtomicUsize, Ordering};
 use tree_sitter::Node;
 
 /// Common interface for all language parsers
@@ -74,6 +77,25 @@ pub trait LanguageParser: Send + Sync {
     /// Zero-cost: Returns string slices into the source code
     fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)>;
 
+    /// Find type usage between symbols whose names are not slices of the source
+    ///
+    /// Returns tuples of (context_name, used_name, range)
+    ///
+    /// Note: Returns owned strings for languages that name symbols by a
+    /// constructed address (e.g., Terraform's `aws_instance.web` for
+    /// `resource "aws_instance" "web"`). Default implementation returns empty.
+    fn find_uses_owned(&mut self, _code: &str) -> Vec<(String, String, Range)> {
+        Vec::new()
+    }
+
+    /// Find reads and writes of fields and module-level values (constants,
+    /// statics, globals)
+    ///
+    /// Default implementation returns empty - languages can override.
+    fn find_value_references(&mut self, _code: &str) -> Vec<ValueReference> {
+        Vec::new()
+    }
+
     /// Find method definitions (in traits/interfaces or types)
     ///
     /// Returns tuples of (definer_name, method_name, range)
@@ -210,14 +232,116 @@ pub fn safe_truncate_str(s: &str, max_bytes: usize) -> &str {
 /// - Default Rust stack size: 2MB
 /// - Average stack frame size: ~4KB per recursive call
 /// - Safety margin: 500 levels uses ~2MB, well within limits
+///
+/// This is the default of `indexing.max_ast_depth`; see [`set_max_ast_depth`].
 pub const MAX_AST_DEPTH: usize = 500;
 
+/// Stack reserved per level of the depth budget by threads that parse
+pub const AST_DEPTH_STACK_BYTES: usize = 16 * 1024;
+
+static AST_DEPTH_BUDGET: AtomicUsize = AtomicUsize::new(MAX_AST_DEPTH);
+
+thread_local! {
+    /// Where this thread's traversal first ran out of budget since the last
+    /// [`take_depth_exceeded`], as (line, column)
+    static DEPTH_EXCEEDED: Cell<Option<(usize, usize)>> = const { Cell::new(None) };
+
+    /// The grammar errors of the first tree with any this thread traversed
+    /// since the last [`take_grammar_errors`]
+    static GRAMMAR_ERRORS: Cell<Option<GrammarErrors>> = const { Cell::new(None) };
+}
+
+/// The nodes of a tree its grammar did not match
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct GrammarErrors {
+    /// `ERROR` and missing nodes
+    pub count: usize,
+    /// 1-based (line, column) of the first
+    pub first: (usize, usize),
+}
+
+impl GrammarErrors {
+    /// The grammar errors of the tree under `root`, none if it has none
+    pub fn of(root: Node) -> Option<Self> {
+        if !root.has_error() {
+            return None;
+        }
+        let mut count = 0;
+        let mut first = None;
+        let mut cursor = root.walk();
+        // Only subtrees holding an error are entered
+        loop {
+            let node = cursor.node();
+            if node.is_error() || node.is_missing() {
+                count += 1;
+                let start = node.start_position();
+                first.get_or_insert((start.row + 1, start.column + 1));
+            }
+            if !node.is_error() && node.has_error() && cursor.goto_first_child() {
+                continue;
+            }
+            while !cursor.goto_next_sibling() {
+                if !cursor.goto_parent() {
+                    return first.map(|first| Self { count, first });
+                }
+            }
+        }
+    }
+}
+
+/// Set the depth budget of every parser, from `indexing.max_ast_depth`
+pub fn set_max_ast_depth(depth: usize) {
+    AST_DEPTH_BUDGET.store(depth.max(1), Ordering::Relaxed);
+}
+
+/// The depth budget of every parser
+pub fn max_ast_depth() -> usize {
+    AST_DEPTH_BUDGET.load(Ordering::Relaxed)
+}
+
+/// Stack size for a thread that parses with a depth budget of `depth`, no
+/// less than 8MB
+pub fn parser_stack_size(depth: usize) -> usize {
+    depth
+        .saturating_mul(AST_DEPTH_STACK_BYTES)
+        .max(8 * 1024 * 1024)
+}
+
+/// Where the parses on this thread first went past the depth budget since
+/// the last call, as a 1-based (line, column), and reset it
+pub fn take_depth_exceeded() -> Option<(usize, usize)> {
+    DEPTH_EXCEEDED.with(Cell::take)
+}
+
+/// The grammar errors of the tree the parses on this thread traversed
+/// since the last call, and reset them
+pub fn take_grammar_errors() -> Option<GrammarErrors> {
+    GRAMMAR_ERRORS.with(Cell::take)
+}
+
+/// Run `parse` without its parses touching the depth and grammar errors
+/// recorded on this thread, for a second parse of a file's text whose
+/// trees are not the file's own
+pub fn keeping_parse_diagnostics<T>(parse: impl FnOnce() -> T) -> T {
+    let depth_exceeded = take_depth_exceeded();
+    let grammar_errors = take_grammar_errors();
+    let result = parse();
+    DEPTH_EXCEEDED.with(|cell| cell.set(depth_exceeded));
+    GRAMMAR_ERRORS.with(|cell| cell.set(grammar_errors));
+    result
+}
+
 /// Check if recursion depth exceeds safe limits
 ///
 /// This function provides centralized depth checking to prevent stack overflow
 /// when processing deeply nested AST structures. All language parsers should
 /// call this at the start of their recursive extract_symbols_from_node method.
 ///
+/// Past the budget the subtree is skipped, so the file degrades to the
+/// symbols above it instead of the thread overflowing its stack; the first
+/// cut is recorded for [`take_depth_exceeded`]. The grammar errors of the
+/// tree are recorded as its root goes by, for [`take_grammar_errors`].
+///
 /// # Arguments
 ///
 /// * `depth` - Current recursion depth
@@ -254,11 +378,28 @@ pub const MAX_AST_DEPTH: usize = 500;
 /// ```
 #[inline]
 pub fn check_recursion_depth(depth: usize, node: Node) -> bool {
-    if depth > MAX_AST_DEPTH {
-        tracing::warn!(
-            "[parser] maximum AST depth ({MAX_AST_DEPTH}) exceeded at line {}:{}. Skipping subtree to prevent stack overflow.",
+    if depth == 0 && node.has_error() && node.parent().is_none() {
+        GRAMMAR_ERRORS.with(|errors| {
+            if errors.get().is_none() {
+                errors.set(GrammarErrors::of(node));
+            }
+        });
+    }
+    let budget = max_ast_depth();
+    if depth > budget {
+        let position = (
             node.start_position().row + 1,
-            node.start_position().column + 1
+            node.start_position().column + 1,
+        );
+        DEPTH_EXCEEDED.with(|first| {
+            if first.get().is_none() {
+                first.set(Some(position));
+            }
+        });
+        tracing::debug!(
+            "[parser] maximum AST depth ({budget}) exceeded at line {}:{}. Skipping subtree to prevent stack overflow.",
+            position.0,
+            position.1
         );
         return false;
     }
@@ -411,4 +552,54 @@ mod tests {
 
         eprintln!("✅ Issue #29 fixed - no panic on emoji boundaries!");
     }
+
+    #[test]
+    fn test_depth_budget_reports_first_cutoff() {
+        let mut parser = tree_sitter::Parser::new();
+        parser
+            .set_language(&tree_sitter_rust::LANGUAGE.into())
+            .unwrap();
+        let tree = parser.parse("fn f() {}\n", None).unwrap();
+        let root = tree.root_node();
+
+        assert!(take_depth_exceeded().is_none());
+        assert!(check_recursion_depth(max_ast_depth(), root));
+        assert!(take_depth_exceeded().is_none());
+        assert!(!check_recursion_depth(max_ast_depth() + 1, root));
+        assert!(!check_recursion_depth(max_ast_depth() + 2, root));
+        assert_eq!(take_depth_exceeded(), Some((1, 1)));
+        assert!(take_depth_exceeded().is_none());
+
+        assert_eq!(parser_stack_size(1), 8 * 1024 * 1024);
+        assert_eq!(parser_stack_size(2000), 2000 * AST_DEPTH_STACK_BYTES);
+    }
+
+    #[test]
+    fn test_grammar_errors_recorded_at_the_root() {
+        let mut parser = tree_sitter::Parser::new();
+        parser
+            .set_language(&tree_sitter_rust::LANGUAGE.into())
+            .unwrap();
+        let clean = parser.parse("fn f() {}\n", None).unwrap();
+        assert!(GrammarErrors::of(clean.root_node()).is_none());
+
+        let broken = parser
+            .parse("fn f() {}\n\nfn g( {\n    let x = ;\n}\n", None)
+            .unwrap();
+        let root = broken.root_node();
+        let errors = GrammarErrors::of(root).unwrap();
+        assert!(errors.count >= 1);
+        // Past the clean `f`
+        assert!(errors.first.0 >= 3);
+
+        assert!(take_grammar_errors().is_none());
+        // Only the root of a tree records its errors
+        if let Some(child) = root.named_child(1) {
+            assert!(check_recursion_depth(0, child));
+            assert!(take_grammar_errors().is_none());
+        }
+        assert!(check_recursion_depth(0, root));
+        assert_eq!(take_grammar_errors(), Some(errors));
+        assert!(take_grammar_errors().is_none());
+    }
 }
@@ -0,0 +1,168 @@
+//! Language plugin behavior implementation
+//!
+//! A plugin describes its language's syntax, not its module system, so the
+//! behavior is generic: a file's module path is its path from the project
+//! root without the extension, joined by the plugin's `module_separator`
+//! (`src/app/router.gleam` is `src.app.router`), every definition has
+//! public visibility but locals, and names resolve within the file and
+//! through imports matching a module path.
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::parsing::registry::LanguageId;
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Language plugin behavior implementation
+#[derive(Clone)]
+pub struct PluginBehavior {
+    language_id: LanguageId,
+    language: Language,
+    extensions: &'static [&'static str],
+    separator: &'static str,
+    state: BehaviorState,
+}
+
+impl PluginBehavior {
+    /// Create a behavior for a plugin language
+    pub fn new(
+        language_id: LanguageId,
+        language: Language,
+        extensions: &'static [&'static str],
+        separator: &'static str,
+    ) -> Self {
+        Self {
+            language_id,
+            language,
+            extensions,
+            separator,
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for PluginBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl LanguageBehavior for PluginBehavior {
+    fn language_id(&self) -> LanguageId {
+        self.language_id
+    }
+
+    fn format_module_path(&self, base_path: &str, symbol_name: &str) -> String {
+        if base_path.is_empty() {
+            symbol_name.to_string()
+        } else {
+            format!("{base_path}{}{symbol_name}", self.separator)
+        }
+    }
+
+    /// Visibility comes from where a definition is (see
+    /// [`super::PluginParser`]), not from its signature
+    fn parse_visibility(&self, _signature: &str) -> Visibility {
+        Visibility::Public
+    }
+
+    fn module_separator(&self) -> &'static str {
+        self.separator
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &[]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join(self.separator))
+        }
+    }
+
+    fn module_path_from_file(
+        &self,
+        file_path: &Path,
+        workspace_root: &Path,
+        extensions: &[&str],
+    ) -> Option<String> {
+        let relative_path = file_path.strip_prefix(workspace_root).ok()?;
+        let path = relative_path.to_str()?;
+        let path = extensions
+            .iter()
+            .chain(self.extensions.iter())
+            .find_map(|extension| path.strip_suffix(&format!(".{extension}")))
+            .unwrap_or(path);
+        let components: Vec<&str> = path
+            .split(std::path::MAIN_SEPARATOR)
+            .filter(|s| !s.is_empty())
+            .collect();
+        self.format_path_as_module(&components)
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(crate::parsing::GenericResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn behavior(separator: &'static str) -> PluginBehavior {
+        PluginBehavior::new(
+            LanguageId::new("gleam"),
+            tree_sitter_python::LANGUAGE.into(),
+            &["gleam"],
+            separator,
+        )
+    }
+
+    #[test]
+    fn test_module_path_uses_plugin_separator() {
+        let root = Path::new("/project");
+        let file = Path::new("/project/src/app/router.gleam");
+
+        assert_eq!(
+            behavior(".").module_path_from_file(file, root, &[]),
+            Some("src.app.router".to_string())
+        );
+        assert_eq!(
+            behavior("/").module_path_from_file(file, root, &[]),
+            Some("src/app/router".to_string())
+        );
+        assert_eq!(
+            behavior("::").format_module_path("app::router", "route"),
+            "app::router::route"
+        );
+    }
+}
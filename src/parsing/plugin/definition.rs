@@ -0,0 +1,152 @@
+//! Language plugin definitions for the registry
+//!
+//! Each loaded plugin is a language definition like the compiled-in ones,
+//! registered when the plugin is loaded (see [`super::register_plugin`]).
+
+use std::sync::Arc;
+
+use super::{Grammar, PluginBehavior, PluginManifest, PluginParser, leak};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// A language added by a plugin
+pub struct PluginLanguage {
+    id: LanguageId,
+    name: &'static str,
+    extensions: &'static [&'static str],
+    separator: &'static str,
+    grammar: Arc<Grammar>,
+}
+
+impl PluginLanguage {
+    /// The language of a plugin manifest and its loaded grammar
+    pub fn new(manifest: &PluginManifest, grammar: Arc<Grammar>) -> Self {
+        let extensions: Vec<&'static str> = manifest
+            .extensions
+            .iter()
+            .map(|extension| leak(extension.clone()))
+            .collect();
+        Self {
+            id: LanguageId::new(leak(manifest.id.clone())),
+            name: leak(manifest.display_name().to_string()),
+            extensions: Box::leak(extensions.into_boxed_slice()),
+            separator: leak(manifest.module_separator.clone()),
+            grammar,
+        }
+    }
+
+    /// The plugin's grammar
+    pub fn grammar(&self) -> &Arc<Grammar> {
+        &self.grammar
+    }
+}
+
+impl LanguageDefinition for PluginLanguage {
+    fn id(&self) -> LanguageId {
+        self.id
+    }
+
+    fn name(&self) -> &'static str {
+        self.name
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        self.extensions
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser =
+            PluginParser::new(self.id, self.grammar.clone()).map_err(IndexError::General)?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(PluginBehavior::new(
+            self.id,
+            self.grammar.language().clone(),
+            self.extensions,
+            self.separator,
+        ))
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Installing a plugin enables it
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Installing a plugin enables it
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn language() -> PluginLanguage {
+        let manifest: PluginManifest = toml::from_str(
+            r#"
+id = "pyish"
+name = "Pyish"
+extensions = ["pyish"]
+"#,
+        )
+        .unwrap();
+        let grammar = Grammar::native(
+            "pyish",
+            tree_sitter_python::LANGUAGE.into(),
+            "(function_definition name: (identifier) @name) @definition.function",
+        )
+        .unwrap();
+        PluginLanguage::new(&manifest, Arc::new(grammar))
+    }
+
+    #[test]
+    fn test_plugin_definition() {
+        let language = language();
+
+        assert_eq!(language.id(), LanguageId::new("pyish"));
+        assert_eq!(language.name(), "Pyish");
+        assert_eq!(language.extensions(), &["pyish"]);
+        assert_eq!(language.create_behavior().module_separator(), ".");
+    }
+
+    #[test]
+    fn test_plugin_enabled_unless_disabled() {
+        let language = language();
+        let mut settings = Settings::default();
+        assert!(language.is_enabled(&settings));
+
+        settings.languages.insert(
+            "pyish".to_string(),
+            crate::config::LanguageConfig {
+                enabled: false,
+                extensions: Vec::new(),
+                parser_options: Default::default(),
+                config_files: Vec::new(),
+                projects: Vec::new(),
+                limits: Default::default(),
+                scope: Default::default(),
+                kinds: Vec::new(),
+            },
+        );
+        assert!(!language.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_plugin_parser_indexes_definitions() {
+        let mut parser = language().create_parser(&Settings::default()).unwrap();
+        let mut counter = crate::types::SymbolCounter::new();
+        let symbols = parser.parse(
+            "def greet(name):\n    return name\n",
+            crate::FileId::new(1).unwrap(),
+            &mut counter,
+        );
+
+        assert_eq!(symbols.len(), 1);
+        assert_eq!(symbols[0].name.as_ref(), "greet");
+    }
+}
@@ -0,0 +1,272 @@
+//! Grammars of language plugins and the roles of their tags captures
+//!
+//! A plugin's grammar is a tree-sitter language loaded from WASM, parsed
+//! by parsers that run it in a wasmtime store, and its tags query is
+//! compiled once against it. The names of the query's captures say what
+//! each captured node is:
+//!
+//! | Capture | Role |
+//! |---------|------|
+//! | `@definition.function`, `.method`, `.class`, ... | a symbol of that kind |
+//! | `@name` | the symbol's or reference's name |
+//! | `@doc` | the symbol's documentation |
+//! | `@reference.call`, `.send` | a call |
+//! | `@reference.implementation` | an implemented interface or trait |
+//! | `@reference.extends` | an extended type |
+//! | `@reference.class`, `.type`, `.interface`, ... | a type use |
+//! | `@reference.import` | an imported path |
+//!
+//! `@reference.extends` and `@reference.import` are codanna's; the others
+//! are those of tree-sitter's code navigation queries. Other captures are
+//! ignored, so a grammar's own `tags.scm` can be used as is.
+
+use super::LanguagePluginError;
+use crate::SymbolKind;
+use tree_sitter::{Language, Parser, Query};
+
+/// What the references of a tags query are
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum Reference {
+    Call,
+    Implementation,
+    Extends,
+    Use,
+    Import,
+}
+
+/// What a capture of a tags query is
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum CaptureRole {
+    Definition(SymbolKind),
+    Reference(Reference),
+    Name,
+    Doc,
+    Ignored,
+}
+
+impl CaptureRole {
+    /// The role of a capture name
+    pub fn of(capture: &str) -> Self {
+        if let Some(kind) = capture.strip_prefix("definition.") {
+            return match kind {
+                "function" => Self::Definition(SymbolKind::Function),
+                "method" | "constructor" => Self::Definition(SymbolKind::Method),
+                "class" => Self::Definition(SymbolKind::Class),
+                "struct" | "union" | "record" => Self::Definition(SymbolKind::Struct),
+                "enum" => Self::Definition(SymbolKind::Enum),
+                "interface" => Self::Definition(SymbolKind::Interface),
+                "trait" | "protocol" => Self::Definition(SymbolKind::Trait),
+                "module" | "namespace" | "package" => Self::Definition(SymbolKind::Module),
+                "type" => Self::Definition(SymbolKind::TypeAlias),
+                "macro" => Self::Definition(SymbolKind::Macro),
+                "constant" => Self::Definition(SymbolKind::Constant),
+                "field" | "property" => Self::Definition(SymbolKind::Field),
+                "variable" => Self::Definition(SymbolKind::Variable),
+                _ => Self::Ignored,
+            };
+        }
+        if let Some(kind) = capture.strip_prefix("reference.") {
+            return match kind {
+                "call" | "send" => Self::Reference(Reference::Call),
+                "implementation" => Self::Reference(Reference::Implementation),
+                "extends" => Self::Reference(Reference::Extends),
+                "import" => Self::Reference(Reference::Import),
+                "class" | "type" | "interface" | "trait" | "module" => {
+                    Self::Reference(Reference::Use)
+                }
+                _ => Self::Ignored,
+            };
+        }
+        match capture {
+            "name" => Self::Name,
+            "doc" => Self::Doc,
+            _ => Self::Ignored,
+        }
+    }
+}
+
+/// A plugin's grammar and its compiled tags query
+pub struct Grammar {
+    language: Language,
+    tags: Query,
+    roles: Vec<CaptureRole>,
+    /// The engine running the grammar, for grammars loaded from WASM
+    #[cfg(feature = "language-plugins")]
+    engine: Option<tree_sitter::wasmtime::Engine>,
+}
+
+impl std::fmt::Debug for Grammar {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("Grammar")
+            .field("patterns", &self.tags.pattern_count())
+            .finish()
+    }
+}
+
+/// Compile a tags query and name the roles of its captures
+fn compile_tags(
+    plugin: &str,
+    language: &Language,
+    tags: &str,
+) -> Result<(Query, Vec<CaptureRole>), LanguagePluginError> {
+    let query = Query::new(language, tags).map_err(|e| LanguagePluginError::Tags {
+        plugin: plugin.to_string(),
+        reason: e.to_string(),
+    })?;
+    let roles: Vec<CaptureRole> = query
+        .capture_names()
+        .iter()
+        .map(|name| CaptureRole::of(name))
+        .collect();
+    if !roles
+        .iter()
+        .any(|role| matches!(role, CaptureRole::Definition(_)))
+    {
+        return Err(LanguagePluginError::Tags {
+            plugin: plugin.to_string(),
+            reason: "no @definition captures".to_string(),
+        });
+    }
+    Ok((query, roles))
+}
+
+impl Grammar {
+    /// A grammar compiled into the program, such as the test grammars
+    pub fn native(
+        plugin: &str,
+        language: Language,
+        tags: &str,
+    ) -> Result<Self, LanguagePluginError> {
+        let (tags, roles) = compile_tags(plugin, &language, tags)?;
+        Ok(Self {
+            language,
+            tags,
+            roles,
+            #[cfg(feature = "language-plugins")]
+            engine: None,
+        })
+    }
+
+    /// A grammar compiled to WASM, exporting `tree_sitter_<name>`
+    #[cfg(feature = "language-plugins")]
+    pub fn wasm(
+        plugin: &str,
+        name: &str,
+        wasm: &[u8],
+        tags: &str,
+    ) -> Result<Self, LanguagePluginError> {
+        let failed = |reason: String| LanguagePluginError::Grammar {
+            plugin: plugin.to_string(),
+            reason,
+        };
+        let engine = tree_sitter::wasmtime::Engine::default();
+        let mut store = tree_sitter::WasmStore::new(&engine).map_err(|e| failed(e.to_string()))?;
+        let language = store
+            .load_language(name, wasm)
+            .map_err(|e| failed(e.to_string()))?;
+
+        let (tags, roles) = compile_tags(plugin, &language, tags)?;
+        Ok(Self {
+            language,
+            tags,
+            roles,
+            engine: Some(engine),
+        })
+    }
+
+    /// Without WASM support no plugin grammar can be loaded
+    #[cfg(not(feature = "language-plugins"))]
+    pub fn wasm(
+        plugin: &str,
+        _name: &str,
+        _wasm: &[u8],
+        _tags: &str,
+    ) -> Result<Self, LanguagePluginError> {
+        Err(LanguagePluginError::Unsupported {
+            plugin: plugin.to_string(),
+        })
+    }
+
+    /// The tree-sitter language
+    pub fn language(&self) -> &Language {
+        &self.language
+    }
+
+    /// The compiled tags query
+    pub fn tags(&self) -> &Query {
+        &self.tags
+    }
+
+    /// Role of each capture of the tags query, by capture index
+    pub fn roles(&self) -> &[CaptureRole] {
+        &self.roles
+    }
+
+    /// A parser for the grammar, with a store of its own to run WASM in
+    pub fn parser(&self) -> Result<Parser, String> {
+        let mut parser = Parser::new();
+        #[cfg(feature = "language-plugins")]
+        if let Some(engine) = &self.engine {
+            let store = tree_sitter::WasmStore::new(engine).map_err(|e| e.to_string())?;
+            parser.set_wasm_store(store).map_err(|e| e.to_string())?;
+        }
+        parser
+            .set_language(&self.language)
+            .map_err(|e| e.to_string())?;
+        Ok(parser)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_capture_roles() {
+        assert_eq!(
+            CaptureRole::of("definition.function"),
+            CaptureRole::Definition(SymbolKind::Function)
+        );
+        assert_eq!(
+            CaptureRole::of("definition.constructor"),
+            CaptureRole::Definition(SymbolKind::Method)
+        );
+        assert_eq!(
+            CaptureRole::of("reference.send"),
+            CaptureRole::Reference(Reference::Call)
+        );
+        assert_eq!(
+            CaptureRole::of("reference.interface"),
+            CaptureRole::Reference(Reference::Use)
+        );
+        assert_eq!(CaptureRole::of("name"), CaptureRole::Name);
+        assert_eq!(CaptureRole::of("local.scope"), CaptureRole::Ignored);
+        assert_eq!(CaptureRole::of("definition.selector"), CaptureRole::Ignored);
+    }
+
+    #[test]
+    fn test_tags_query_is_checked() {
+        let python = || -> Language { tree_sitter_python::LANGUAGE.into() };
+
+        assert!(
+            Grammar::native(
+                "pyish",
+                python(),
+                "(class_definition name: (identifier) @name) @definition.class"
+            )
+            .is_ok()
+        );
+        assert!(matches!(
+            Grammar::native("pyish", python(), "(no_such_node) @definition.class"),
+            Err(LanguagePluginError::Tags { .. })
+        ));
+        assert!(matches!(
+            Grammar::native(
+                "pyish",
+                python(),
+                "(call function: (identifier) @name) @reference.call"
+            ),
+            Err(LanguagePluginError::Tags { .. })
+        ));
+    }
+}
@@ -0,0 +1,163 @@
+//! Language plugin manifests
+//!
+//! `plugin.toml` names the language and the files of the plugin:
+//!
+//! ```toml
+//! id = "gleam"                       # settings key, as in [languages.gleam]
+//! name = "Gleam"
+//! extensions = ["gleam"]
+//! grammar = "tree-sitter-gleam.wasm" # default: grammar.wasm
+//! grammar_name = "gleam"             # default: the id
+//! tags = "queries/tags.scm"          # default: tags.scm
+//! module_separator = "/"             # default: "."
+//! ```
+//!
+//! `grammar_name` is the name the grammar was generated with, the `gleam`
+//! of the `tree_sitter_gleam` function its WASM module exports. Paths are
+//! relative to the plugin directory.
+
+use super::LanguagePluginError;
+use serde::Deserialize;
+use std::path::{Path, PathBuf};
+
+/// The `plugin.toml` of a language plugin
+#[derive(Debug, Clone, Deserialize)]
+#[serde(deny_unknown_fields)]
+pub struct PluginManifest {
+    /// Language identifier, the key of its settings
+    pub id: String,
+    /// Human-readable name, the id if not given
+    #[serde(default)]
+    pub name: Option<String>,
+    /// File extensions, without the dot
+    pub extensions: Vec<String>,
+    /// The grammar compiled to WASM
+    #[serde(default = "default_grammar")]
+    pub grammar: PathBuf,
+    #[serde(default)]
+    grammar_name: Option<String>,
+    /// The tags query mapping the grammar's nodes to symbols
+    #[serde(default = "default_tags")]
+    pub tags: PathBuf,
+    /// Separator of module path segments
+    #[serde(default = "default_module_separator")]
+    pub module_separator: String,
+}
+
+fn default_grammar() -> PathBuf {
+    PathBuf::from("grammar.wasm")
+}
+
+fn default_tags() -> PathBuf {
+    PathBuf::from("tags.scm")
+}
+
+fn default_module_separator() -> String {
+    ".".to_string()
+}
+
+impl PluginManifest {
+    /// Read and validate a manifest file
+    pub fn load(path: &Path) -> Result<Self, LanguagePluginError> {
+        let invalid = |reason: String| LanguagePluginError::Manifest {
+            path: path.to_path_buf(),
+            reason,
+        };
+        let text = std::fs::read_to_string(path).map_err(|e| invalid(e.to_string()))?;
+        let mut manifest: Self = toml::from_str(&text).map_err(|e| invalid(e.to_string()))?;
+        manifest.validate().map_err(invalid)?;
+        Ok(manifest)
+    }
+
+    /// Check the id and extensions, dropping the dots of `.gleam`
+    fn validate(&mut self) -> Result<(), String> {
+        let valid_id = !self.id.is_empty()
+            && self
+                .id
+                .chars()
+                .all(|c| c.is_ascii_lowercase() || c.is_ascii_digit() || c == '_' || c == '-');
+        if !valid_id {
+            return Err(format!(
+                "id '{}' must be lowercase letters, digits, '_' or '-'",
+                self.id
+            ));
+        }
+
+        for extension in &mut self.extensions {
+            *extension = extension.trim_start_matches('.').to_lowercase();
+        }
+        self.extensions.retain(|extension| !extension.is_empty());
+        if self.extensions.is_empty() {
+            return Err("no file extensions".to_string());
+        }
+        Ok(())
+    }
+
+    /// Display name of the language
+    pub fn display_name(&self) -> &str {
+        self.name.as_deref().unwrap_or(&self.id)
+    }
+
+    /// Name the grammar was generated with
+    pub fn grammar_name(&self) -> &str {
+        self.grammar_name.as_deref().unwrap_or(&self.id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(text: &str) -> Result<PluginManifest, String> {
+        let mut manifest: PluginManifest = toml::from_str(text).map_err(|e| e.to_string())?;
+        manifest.validate()?;
+        Ok(manifest)
+    }
+
+    #[test]
+    fn test_defaults() {
+        let manifest = parse(
+            r#"
+id = "gleam"
+extensions = [".gleam"]
+"#,
+        )
+        .unwrap();
+
+        assert_eq!(manifest.display_name(), "gleam");
+        assert_eq!(manifest.extensions, vec!["gleam"]);
+        assert_eq!(manifest.grammar, PathBuf::from("grammar.wasm"));
+        assert_eq!(manifest.grammar_name(), "gleam");
+        assert_eq!(manifest.tags, PathBuf::from("tags.scm"));
+        assert_eq!(manifest.module_separator, ".");
+    }
+
+    #[test]
+    fn test_all_fields() {
+        let manifest = parse(
+            r#"
+id = "acme-rules"
+name = "Acme Rules"
+extensions = ["rules", "RULE"]
+grammar = "build/acme.wasm"
+grammar_name = "acme_rules"
+tags = "queries/tags.scm"
+module_separator = "::"
+"#,
+        )
+        .unwrap();
+
+        assert_eq!(manifest.display_name(), "Acme Rules");
+        assert_eq!(manifest.extensions, vec!["rules", "rule"]);
+        assert_eq!(manifest.grammar_name(), "acme_rules");
+        assert_eq!(manifest.module_separator, "::");
+    }
+
+    #[test]
+    fn test_invalid_manifests() {
+        assert!(parse(r#"id = "Gleam""#).is_err(), "extensions are required");
+        assert!(parse("id = \"Gleam\"\nextensions = [\"gleam\"]").is_err());
+        assert!(parse("id = \"gleam\"\nextensions = [\".\"]").is_err());
+        assert!(parse("id = \"gleam\"\nextensions = [\"gleam\"]\nversion = 2").is_err());
+    }
+}
@@ -0,0 +1,250 @@
+//! Language plugins: third-party grammars without forking codanna
+//!
+//! A plugin adds a language from a directory, with no code compiled into
+//! codanna:
+//!
+//! ```text
+//! .codanna/languages/gleam/
+//! ├── plugin.toml             id, name, extensions (see [`manifest`])
+//! ├── grammar.wasm            tree-sitter build --wasm
+//! └── tags.scm                the grammar's tags query
+//! ```
+//!
+//! The tags query is the mapping: it uses the capture names of
+//! tree-sitter's code navigation queries, which most grammars already ship
+//! as `queries/tags.scm`, so the nodes a pattern captures as
+//! `@definition.function` or `@reference.call` become symbols and
+//! relationships of the normal indexing pipeline (see [`parser`]).
+//!
+//! Plugins are read from the directories of `indexing.language_plugins`
+//! at startup and registered with the language registry like the
+//! compiled-in languages, enabled unless `languages.<id>.enabled` is false.
+//! Loading WASM grammars needs the `language-plugins` feature, on by
+//! default.
+
+pub mod behavior;
+pub mod definition;
+pub mod grammar;
+pub mod manifest;
+pub mod parser;
+
+pub use behavior::PluginBehavior;
+pub use definition::PluginLanguage;
+pub use grammar::Grammar;
+pub use manifest::PluginManifest;
+pub use parser::PluginParser;
+
+use crate::Settings;
+use crate::parsing::{LanguageId, get_registry};
+use std::collections::HashMap;
+use std::path::{Path, PathBuf};
+use std::sync::{Arc, LazyLock, Mutex};
+use thiserror::Error;
+
+/// Manifest file of a plugin directory
+pub const MANIFEST_FILE: &str = "plugin.toml";
+
+/// Language plugin errors with actionable suggestions
+#[derive(Error, Debug)]
+pub enum LanguagePluginError {
+    #[error(
+        "Invalid language plugin manifest {path}: {reason}\nSuggestion: plugin.toml needs an `id` and a list of `extensions`"
+    )]
+    Manifest { path: PathBuf, reason: String },
+
+    #[error(
+        "Failed to load the grammar of language plugin '{plugin}': {reason}\nSuggestion: Build the grammar with `tree-sitter build --wasm` and check `grammar` and `grammar_name` in plugin.toml"
+    )]
+    Grammar { plugin: String, reason: String },
+
+    #[error(
+        "Invalid tags query of language plugin '{plugin}': {reason}\nSuggestion: Check that the query's node names match the grammar"
+    )]
+    Tags { plugin: String, reason: String },
+
+    #[error(
+        "Language plugin '{plugin}' conflicts with a registered language: {reason}\nSuggestion: Choose another id or remove the extension from plugin.toml"
+    )]
+    Conflict { plugin: String, reason: String },
+
+    #[error(
+        "Language plugin '{plugin}' needs WASM support\nSuggestion: Build codanna with the `language-plugins` feature"
+    )]
+    Unsupported { plugin: String },
+}
+
+/// Grammars of the loaded plugins, by language
+static GRAMMARS: LazyLock<Mutex<HashMap<LanguageId, Arc<Grammar>>>> =
+    LazyLock::new(|| Mutex::new(HashMap::new()));
+
+/// The grammar of a loaded plugin language
+pub fn grammar(id: LanguageId) -> Option<Arc<Grammar>> {
+    GRAMMARS.lock().ok()?.get(&id).cloned()
+}
+
+/// Whether the plugin of a language id has been loaded
+fn is_loaded(id: &str) -> bool {
+    GRAMMARS
+        .lock()
+        .is_ok_and(|grammars| grammars.keys().any(|loaded| loaded.as_str() == id))
+}
+
+/// Directories holding plugins, per `indexing.language_plugins`
+fn plugin_dirs(settings: &Settings) -> Vec<PathBuf> {
+    let root = settings.workspace_root.as_deref();
+    settings
+        .indexing
+        .language_plugins
+        .iter()
+        .map(|dir| match root {
+            Some(root) if dir.is_relative() => root.join(dir),
+            _ => dir.clone(),
+        })
+        .collect()
+}
+
+/// Read the plugin of a directory
+pub fn load_plugin(dir: &Path) -> Result<PluginLanguage, LanguagePluginError> {
+    let manifest = PluginManifest::load(&dir.join(MANIFEST_FILE))?;
+    let tags = std::fs::read_to_string(dir.join(&manifest.tags)).map_err(|e| {
+        LanguagePluginError::Tags {
+            plugin: manifest.id.clone(),
+            reason: format!("{}: {e}", manifest.tags.display()),
+        }
+    })?;
+    let wasm =
+        std::fs::read(dir.join(&manifest.grammar)).map_err(|e| LanguagePluginError::Grammar {
+            plugin: manifest.id.clone(),
+            reason: format!("{}: {e}", manifest.grammar.display()),
+        })?;
+
+    let grammar = Grammar::wasm(&manifest.id, manifest.grammar_name(), &wasm, &tags)?;
+    Ok(PluginLanguage::new(&manifest, Arc::new(grammar)))
+}
+
+/// Register a plugin language with the global registry
+///
+/// A language registered before, compiled in or by another plugin, keeps
+/// its id and its extensions.
+pub fn register_plugin(language: PluginLanguage) -> Result<LanguageId, LanguagePluginError> {
+    use crate::parsing::LanguageDefinition;
+
+    let id = language.id();
+    let registry = get_registry();
+    let mut registry = registry.lock().map_err(|e| LanguagePluginError::Conflict {
+        plugin: id.to_string(),
+        reason: format!("failed to acquire registry lock: {e}"),
+    })?;
+
+    if registry.is_available(id) {
+        return Err(LanguagePluginError::Conflict {
+            plugin: id.to_string(),
+            reason: format!("the id '{id}' is taken"),
+        });
+    }
+    if let Some(taken) = language
+        .extensions()
+        .iter()
+        .find_map(|ext| registry.get_by_extension(ext).map(|def| (ext, def.id())))
+    {
+        return Err(LanguagePluginError::Conflict {
+            plugin: id.to_string(),
+            reason: format!("'.{}' files are {}", taken.0, taken.1),
+        });
+    }
+
+    if let Ok(mut grammars) = GRAMMARS.lock() {
+        grammars.insert(id, language.grammar().clone());
+    }
+    registry.register(Arc::new(language));
+    Ok(id)
+}
+
+/// Load and register the plugins of the configured directories
+///
+/// Returns the plugins that failed; the others are registered. Plugins
+/// already loaded are skipped, so calling this again is harmless.
+pub fn load_language_plugins(settings: &Settings) -> Vec<LanguagePluginError> {
+    let mut errors = Vec::new();
+    for dir in plugin_dirs(settings) {
+        let Ok(entries) = std::fs::read_dir(&dir) else {
+            continue;
+        };
+        let mut plugins: Vec<PathBuf> = entries
+            .filter_map(|entry| entry.ok().map(|entry| entry.path()))
+            .filter(|path| path.join(MANIFEST_FILE).is_file())
+            .collect();
+        plugins.sort();
+
+        for plugin in plugins {
+            let loaded = PluginManifest::load(&plugin.join(MANIFEST_FILE))
+                .is_ok_and(|manifest| is_loaded(&manifest.id));
+            if loaded {
+                continue;
+            }
+            match load_plugin(&plugin).and_then(register_plugin) {
+                Ok(id) => {
+                    tracing::debug!("loaded language plugin '{id}' from {}", plugin.display())
+                }
+                Err(e) => errors.push(e),
+            }
+        }
+    }
+    errors
+}
+
+/// A string of a plugin manifest, for the `'static` identifiers of the
+/// registry
+///
+/// Plugins are loaded once at startup and live for the entire program.
+fn leak(s: String) -> &'static str {
+    Box::leak(s.into_boxed_str())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_plugin_dirs_are_from_workspace_root() {
+        let settings = Settings {
+            workspace_root: Some(PathBuf::from("/project")),
+            ..Settings::default()
+        };
+
+        assert_eq!(
+            plugin_dirs(&settings),
+            vec![PathBuf::from("/project/.codanna/languages")]
+        );
+    }
+
+    #[test]
+    fn test_missing_plugin_dirs_load_nothing() {
+        let mut settings = Settings::default();
+        settings.indexing.language_plugins = vec![PathBuf::from("/nonexistent/languages")];
+
+        assert!(load_language_plugins(&settings).is_empty());
+    }
+
+    #[test]
+    fn test_plugin_cannot_take_a_registered_extension() {
+        let manifest: PluginManifest = toml::from_str(
+            r#"
+id = "pyish"
+extensions = ["pyish", "py"]
+"#,
+        )
+        .unwrap();
+        let grammar = Grammar::native(
+            "pyish",
+            tree_sitter_python::LANGUAGE.into(),
+            "(function_definition name: (identifier) @name) @definition.function",
+        )
+        .unwrap();
+        let language = PluginLanguage::new(&manifest, Arc::new(grammar));
+
+        let error = register_plugin(language).unwrap_err();
+        assert!(matches!(error, LanguagePluginError::Conflict { .. }));
+        assert!(error.to_string().contains("'.py' files are python"));
+    }
+}
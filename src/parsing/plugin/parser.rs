@@ -0,0 +1,529 @@
+//! Language plugin parser implementation
+//!
+//! Runs a plugin's tags query (see [`super::grammar`]) over each file and
+//! turns its matches into symbols and relationships. In a pattern like
+//!
+//! ```scheme
+//! ((comment)* @doc
+//!  .
+//!  (function_definition name: (identifier) @name) @definition.function)
+//!
+//! (call function: (identifier) @name) @reference.call
+//! ```
+//!
+//! the node captured as `@definition.function` is the symbol, `@name` its
+//! name and `@doc` its documentation; without `@doc` captures the comments
+//! right before a definition document it. A definition inside a class-like
+//! definition is its member (a function becomes a method), one inside a
+//! function is local to it. A reference comes from the innermost
+//! definition enclosing it; references outside any definition are dropped.
+//!
+//! | Capture | Relationship |
+//! |---------|--------------|
+//! | `@reference.call` | the enclosing definition calls `@name` |
+//! | `@reference.implementation` | it implements `@name` |
+//! | `@reference.extends` | it extends `@name` |
+//! | `@reference.class`, `.type`, ... | it uses `@name` |
+//! | `@reference.import` | the file imports `@name` |
+
+use super::grammar::{CaptureRole, Grammar, Reference};
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageId, LanguageParser, NodeTracker, NodeTrackingState,
+    truncate_for_display,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use std::sync::Arc;
+use tree_sitter::{Node, Parser, QueryCursor, StreamingIterator};
+
+/// Longest first line kept as a definition's signature
+const MAX_SIGNATURE_LEN: usize = 200;
+
+/// Comment delimiters stripped from documentation, opening and closing
+const BLOCK_DELIMITERS: &[(&str, &str)] = &[
+    ("/**", "*/"),
+    ("/*!", "*/"),
+    ("/*", "*/"),
+    ("\"\"\"", "\"\"\""),
+    ("'''", "'''"),
+    ("{-|", "-}"),
+    ("{-", "-}"),
+    ("(*", "*)"),
+    ("--[[", "]]"),
+];
+
+/// Characters of line comment markers: `///`, `#`, `--`, `;;`, `%`
+const LINE_MARKERS: &[char] = &['/', '!', '#', ';', '-', '%', '*'];
+
+/// A definition or reference the tags query matched
+#[derive(Debug, Clone)]
+struct Tag<'a> {
+    role: CaptureRole,
+    name: &'a str,
+    /// The captured definition or reference
+    bytes: std::ops::Range<usize>,
+    range: Range,
+    signature: &'a str,
+    doc: Option<String>,
+}
+
+impl Tag<'_> {
+    fn encloses(&self, other: &Tag) -> bool {
+        self.bytes.start <= other.bytes.start
+            && other.bytes.end <= self.bytes.end
+            && self.bytes != other.bytes
+    }
+
+    fn kind(&self) -> Option<SymbolKind> {
+        match self.role {
+            CaptureRole::Definition(kind) => Some(kind),
+            _ => None,
+        }
+    }
+}
+
+fn is_class_like(kind: SymbolKind) -> bool {
+    matches!(
+        kind,
+        SymbolKind::Class
+            | SymbolKind::Struct
+            | SymbolKind::Enum
+            | SymbolKind::Interface
+            | SymbolKind::Trait
+    )
+}
+
+fn is_function_like(kind: SymbolKind) -> bool {
+    matches!(
+        kind,
+        SymbolKind::Function | SymbolKind::Method | SymbolKind::Macro
+    )
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// The text of a comment without its delimiters
+fn clean_comment(text: &str) -> String {
+    let text = text.trim();
+    if let Some((open, close)) = BLOCK_DELIMITERS
+        .iter()
+        .find(|(open, close)| text.starts_with(open) && text.ends_with(close))
+        .filter(|(open, close)| text.len() >= open.len() + close.len())
+    {
+        let inner = &text[open.len()..text.len() - close.len()];
+        return inner
+            .lines()
+            .map(|line| {
+                let line = line.trim();
+                line.strip_prefix('*').unwrap_or(line).trim()
+            })
+            .collect::<Vec<_>>()
+            .join("\n")
+            .trim()
+            .to_string();
+    }
+    text.lines()
+        .map(|line| line.trim().trim_start_matches(LINE_MARKERS).trim())
+        .collect::<Vec<_>>()
+        .join("\n")
+        .trim()
+        .to_string()
+}
+
+/// Documentation from doc comment texts, `None` when they say nothing
+fn join_docs<'a>(docs: impl IntoIterator<Item = &'a str>) -> Option<String> {
+    let doc = docs
+        .into_iter()
+        .map(clean_comment)
+        .filter(|doc| !doc.is_empty())
+        .collect::<Vec<_>>()
+        .join("\n");
+    (!doc.is_empty()).then_some(doc)
+}
+
+/// Language plugin parser
+pub struct PluginParser {
+    parser: Parser,
+    grammar: Arc<Grammar>,
+    language_id: LanguageId,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for PluginParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("PluginParser")
+            .field("language", &self.language_id.as_str())
+            .finish()
+    }
+}
+
+impl PluginParser {
+    /// Create a parser for a plugin language
+    pub fn new(language_id: LanguageId, grammar: Arc<Grammar>) -> Result<Self, String> {
+        Ok(Self {
+            parser: grammar.parser()?,
+            grammar,
+            language_id,
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    /// Definitions and references of a file, in document order
+    fn tags<'a>(&mut self, code: &'a str) -> Vec<Tag<'a>> {
+        let Some(tree) = self.parser.parse(code, None) else {
+            return Vec::new();
+        };
+        let grammar = self.grammar.clone();
+        let roles = grammar.roles();
+
+        let mut tags = Vec::new();
+        let mut seen = HashSet::new();
+        let mut cursor = QueryCursor::new();
+        let mut matches = cursor.matches(grammar.tags(), tree.root_node(), code.as_bytes());
+        while let Some(found) = matches.next() {
+            let mut tagged = None;
+            let mut name = None;
+            let mut docs = Vec::new();
+            for capture in found.captures {
+                match roles[capture.index as usize] {
+                    role @ (CaptureRole::Definition(_) | CaptureRole::Reference(_)) => {
+                        tagged = Some((role, capture.node));
+                    }
+                    CaptureRole::Name => name = Some(capture.node),
+                    CaptureRole::Doc => docs.push(capture.node),
+                    CaptureRole::Ignored => {}
+                }
+            }
+            let (Some((role, node)), Some(name)) = (tagged, name) else {
+                continue;
+            };
+            // Overlapping patterns may match a node twice
+            if !seen.insert((node.id(), name.id(), role)) {
+                continue;
+            }
+            let Some(name_text) = code.get(name.byte_range()) else {
+                continue;
+            };
+
+            let doc = match role {
+                CaptureRole::Definition(_) if docs.is_empty() => {
+                    self.extract_doc_comment(&node, code)
+                }
+                CaptureRole::Definition(_) => {
+                    join_docs(docs.iter().filter_map(|doc| code.get(doc.byte_range())))
+                }
+                _ => None,
+            };
+            let text = &code[node.byte_range()];
+            tags.push(Tag {
+                role,
+                name: name_text,
+                bytes: node.byte_range(),
+                range: range_from_node(&node),
+                signature: text.lines().next().unwrap_or("").trim(),
+                doc,
+            });
+        }
+        tags.sort_by_key(|tag| (tag.bytes.start, std::cmp::Reverse(tag.bytes.end)));
+        tags
+    }
+
+    /// The innermost definition enclosing a tag
+    fn container<'t, 'a>(tags: &'t [Tag<'a>], tag: &Tag) -> Option<&'t Tag<'a>> {
+        tags.iter()
+            .filter(|other| other.kind().is_some() && other.encloses(tag))
+            .min_by_key(|other| other.bytes.len())
+    }
+
+    /// (enclosing definition, name, range) of each reference of a kind
+    fn references<'a>(&mut self, code: &'a str, kind: Reference) -> Vec<(&'a str, &'a str, Range)> {
+        let tags = self.tags(code);
+        tags.iter()
+            .filter(|tag| tag.role == CaptureRole::Reference(kind))
+            .filter_map(|tag| {
+                let container = Self::container(&tags, tag)?;
+                Some((container.name, tag.name, tag.range))
+            })
+            .collect()
+    }
+}
+
+impl NodeTracker for PluginParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+impl LanguageParser for PluginParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        let tags = self.tags(code);
+
+        let mut symbols = Vec::new();
+        for tag in &tags {
+            let Some(mut kind) = tag.kind() else {
+                continue;
+            };
+            let container = Self::container(&tags, tag);
+            let parent = container.and_then(|parent| Some((parent, parent.kind()?)));
+
+            let (scope, visibility) = match parent {
+                Some((parent, parent_kind)) if is_class_like(parent_kind) => {
+                    if kind == SymbolKind::Function {
+                        kind = SymbolKind::Method;
+                    }
+                    (
+                        ScopeContext::ClassMember {
+                            class_name: Some(parent.name.into()),
+                        },
+                        Visibility::Public,
+                    )
+                }
+                Some((parent, parent_kind)) if is_function_like(parent_kind) => (
+                    ScopeContext::Local {
+                        hoisted: false,
+                        parent_name: Some(parent.name.into()),
+                        parent_kind: Some(parent_kind),
+                    },
+                    Visibility::Private,
+                ),
+                _ => (ScopeContext::Module, Visibility::Public),
+            };
+
+            let mut symbol =
+                Symbol::new(symbol_counter.next_id(), tag.name, kind, file_id, tag.range)
+                    .with_signature(truncate_for_display(tag.signature, MAX_SIGNATURE_LEN))
+                    .with_visibility(visibility);
+            if let Some(doc) = &tag.doc {
+                symbol = symbol.with_doc(doc.as_str());
+            }
+            symbol.scope_context = Some(scope);
+            symbols.push(symbol);
+        }
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// The comments right before a node
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let mut comments = Vec::new();
+        let mut next_row = node.start_position().row;
+        let mut sibling = node.prev_sibling();
+        while let Some(comment) = sibling {
+            if !comment.kind().contains("comment") || comment.end_position().row + 1 < next_row {
+                break;
+            }
+            comments.push(code.get(comment.byte_range())?);
+            next_row = comment.start_position().row;
+            sibling = comment.prev_sibling();
+        }
+        comments.reverse();
+        join_docs(comments)
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        self.references(code, Reference::Call)
+    }
+
+    fn find_implementations<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        self.references(code, Reference::Implementation)
+    }
+
+    fn find_extends<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        self.references(code, Reference::Extends)
+    }
+
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        self.references(code, Reference::Use)
+    }
+
+    /// Members of class-like definitions
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let tags = self.tags(code);
+        tags.iter()
+            .filter(|tag| tag.kind().is_some())
+            .filter_map(|tag| {
+                let container = Self::container(&tags, tag)?;
+                is_class_like(container.kind()?).then_some((container.name, tag.name, tag.range))
+            })
+            .collect()
+    }
+
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        self.tags(code)
+            .iter()
+            .filter(|tag| tag.role == CaptureRole::Reference(Reference::Import))
+            .map(|tag| Import {
+                path: tag.name.trim_matches(['"', '\'']).to_string(),
+                alias: None,
+                file_id,
+                is_glob: false,
+                is_type_only: false,
+            })
+            .collect()
+    }
+
+    fn language(&self) -> Language {
+        Language::Plugin(self.language_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// A tags query over the Python grammar, standing in for a plugin's
+    const TAGS: &str = r#"
+(class_definition name: (identifier) @name) @definition.class
+
+(function_definition name: (identifier) @name) @definition.function
+
+(call function: (identifier) @name) @reference.call
+
+(class_definition
+  superclasses: (argument_list (identifier) @name) @reference.extends)
+
+(import_from_statement module_name: (dotted_name) @name) @reference.import
+"#;
+
+    const CODE: &str = r#"from shapes import base
+
+# A shape with corners.
+# Corners are counted clockwise.
+class Polygon(Shape):
+    def area(self):
+        def half(x):
+            return x / 2
+        return half(measure(self))
+
+def measure(shape):
+    return 1
+
+measure(None)
+"#;
+
+    fn parser() -> PluginParser {
+        let grammar = Grammar::native("pyish", tree_sitter_python::LANGUAGE.into(), TAGS).unwrap();
+        PluginParser::new(LanguageId::new("pyish"), Arc::new(grammar)).unwrap()
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("symbol {name} not found"))
+    }
+
+    #[test]
+    fn test_definitions() {
+        let mut counter = SymbolCounter::new();
+        let symbols = parser().parse(CODE, FileId::new(1).unwrap(), &mut counter);
+
+        let polygon = find(&symbols, "Polygon");
+        assert_eq!(polygon.kind, SymbolKind::Class);
+        assert_eq!(polygon.signature.as_deref(), Some("class Polygon(Shape):"));
+        assert_eq!(
+            polygon.doc_comment.as_deref(),
+            Some("A shape with corners.\nCorners are counted clockwise.")
+        );
+        assert_eq!(polygon.range.start_line, 4);
+        assert_eq!(polygon.scope_context, Some(ScopeContext::Module));
+
+        let area = find(&symbols, "area");
+        assert_eq!(area.kind, SymbolKind::Method, "A function of a class");
+        assert_eq!(
+            area.scope_context,
+            Some(ScopeContext::ClassMember {
+                class_name: Some("Polygon".into())
+            })
+        );
+
+        let half = find(&symbols, "half");
+        assert_eq!(half.kind, SymbolKind::Function);
+        assert_eq!(half.visibility, Visibility::Private);
+        assert!(matches!(
+            half.scope_context,
+            Some(ScopeContext::Local {
+                parent_kind: Some(SymbolKind::Method),
+                ..
+            })
+        ));
+
+        assert_eq!(find(&symbols, "measure").visibility, Visibility::Public);
+        assert_eq!(symbols.len(), 4);
+    }
+
+    #[test]
+    fn test_relationships() {
+        let mut parser = parser();
+
+        let calls = parser.find_calls(CODE);
+        assert!(
+            calls
+                .iter()
+                .any(|(from, to, _)| *from == "area" && *to == "half")
+        );
+        assert!(
+            calls
+                .iter()
+                .any(|(from, to, _)| *from == "area" && *to == "measure")
+        );
+        assert_eq!(calls.len(), 2, "The module-level call has no caller");
+
+        let extends = parser.find_extends(CODE);
+        assert_eq!(extends.len(), 1);
+        assert_eq!((extends[0].0, extends[0].1), ("Polygon", "Shape"));
+
+        let defines = parser.find_defines(CODE);
+        assert_eq!(defines.len(), 1);
+        assert_eq!((defines[0].0, defines[0].1), ("Polygon", "area"));
+
+        let imports = parser.find_imports(CODE, FileId::new(1).unwrap());
+        assert_eq!(imports.len(), 1);
+        assert_eq!(imports[0].path, "shapes");
+
+        assert_eq!(
+            parser.language(),
+            Language::Plugin(LanguageId::new("pyish"))
+        );
+    }
+
+    #[test]
+    fn test_clean_comment() {
+        assert_eq!(clean_comment("/// Adds two numbers"), "Adds two numbers");
+        assert_eq!(
+            clean_comment("/**\n * Adds two\n * numbers\n */"),
+            "Adds two\nnumbers"
+        );
+        assert_eq!(clean_comment("-- | Adds"), "| Adds");
+        assert_eq!(clean_comment("{-| Adds -}"), "Adds");
+        assert_eq!(
+            clean_comment("\"\"\"Adds two numbers.\"\"\""),
+            "Adds two numbers."
+        );
+        assert_eq!(clean_comment("#"), "");
+    }
+}
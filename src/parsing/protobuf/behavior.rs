@@ -0,0 +1,246 @@
+//! Protobuf-specific language behavior implementation
+//!
+//! `import "acme/users/v1/users.proto"` names a file relative to an include
+//! root, usually the project root or a `proto/` directory, so a file's
+//! module path is its path from that root without the extension:
+//! `proto/acme/users/v1/users.proto` is `acme/users/v1/users`.
+
+use super::handlers;
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::symbol::ScopeContext;
+use crate::{FileId, Symbol, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Protobuf language behavior implementation
+#[derive(Clone)]
+pub struct ProtobufBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl ProtobufBehavior {
+    /// Create a new Protobuf behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_proto::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for ProtobufBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for ProtobufBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+fn class_name(symbol: &Symbol) -> Option<&str> {
+    match symbol.scope_context.as_ref()? {
+        ScopeContext::ClassMember {
+            class_name: Some(class),
+        } => Some(class),
+        _ => None,
+    }
+}
+
+impl LanguageBehavior for ProtobufBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("protobuf")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// Every declaration is visible to the files importing it
+    fn parse_visibility(&self, _signature: &str) -> Visibility {
+        Visibility::Public
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "/"
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &["proto", "protos"]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("/"))
+        }
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::ProtobufResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    /// `google/protobuf/timestamp.proto` becomes `google/protobuf/timestamp`
+    fn normalize_import_path(
+        &self,
+        import_path: &str,
+        _importing_module: Option<&str>,
+        _file_path: &Path,
+    ) -> Option<String> {
+        let path = import_path.strip_suffix(".proto").unwrap_or(import_path);
+        let path = path.trim_start_matches("./");
+        (!path.is_empty()).then(|| path.to_string())
+    }
+
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        import_path == symbol_module_path
+    }
+
+    /// An RPC is served by a handler named after it in each language with
+    /// gRPC servers
+    fn cross_language_targets(
+        &self,
+        name: &str,
+        kind: crate::SymbolKind,
+        _signature: Option<&str>,
+        _scope: Option<&ScopeContext>,
+    ) -> Vec<(crate::parsing::LanguageId, String)> {
+        if kind == crate::SymbolKind::Method {
+            handlers::handler_names(name)
+        } else {
+            Vec::new()
+        }
+    }
+
+    /// Handlers are written by hand: the client and server stubs generated
+    /// for the RPC's service share its method names and are rejected, and a
+    /// handler taking the RPC's request message outranks one that does not
+    fn cross_language_target_rank(&self, caller: &Symbol, candidate: &Symbol) -> Option<u8> {
+        if let (Some(service), Some(class)) = (class_name(caller), class_name(candidate)) {
+            if handlers::is_generated_stub(class, service) {
+                return None;
+            }
+        }
+        let request = caller.signature.as_deref().and_then(handlers::request_type);
+        let takes_request = request.is_some_and(|request| {
+            candidate
+                .signature
+                .as_deref()
+                .is_some_and(|signature| signature.contains(request))
+        });
+        Some(if takes_request { 0 } else { 1 })
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn method(name: &str, class: &str) -> Symbol {
+        let mut symbol = Symbol::new(
+            crate::SymbolId::new(1).unwrap(),
+            name,
+            crate::SymbolKind::Method,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 1, 0),
+        );
+        symbol.scope_context = Some(ScopeContext::ClassMember {
+            class_name: Some(class.into()),
+        });
+        symbol
+    }
+
+    #[test]
+    fn test_module_path_from_file() {
+        let behavior = ProtobufBehavior::new();
+        let root = Path::new("/project");
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/proto/acme/users/v1/users.proto"),
+                root,
+                &["proto"]
+            ),
+            Some("acme/users/v1/users".to_string())
+        );
+    }
+
+    #[test]
+    fn test_normalize_import_path() {
+        let behavior = ProtobufBehavior::new();
+        let file = Path::new("proto/acme/orders/v1/orders.proto");
+
+        assert_eq!(
+            behavior.normalize_import_path("acme/users/v1/users.proto", None, file),
+            Some("acme/users/v1/users".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("google/protobuf/timestamp.proto", None, file),
+            Some("google/protobuf/timestamp".to_string())
+        );
+    }
+
+    #[test]
+    fn test_handler_ranking() {
+        let behavior = ProtobufBehavior::new();
+        let rpc = method("GetUser", "UserService")
+            .with_signature("rpc GetUser(GetUserRequest) returns (User)");
+
+        let handler = method("GetUser", "userServer").with_signature(
+            "func (s *userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error)",
+        );
+        assert_eq!(behavior.cross_language_target_rank(&rpc, &handler), Some(0));
+        assert_eq!(
+            behavior.cross_language_target_rank(&rpc, &method("getUser", "Handlers")),
+            Some(1)
+        );
+
+        for stub in [
+            "userServiceClient",
+            "UnimplementedUserServiceServer",
+            "UserService",
+        ] {
+            assert_eq!(
+                behavior.cross_language_target_rank(&rpc, &method("GetUser", stub)),
+                None,
+                "{stub}"
+            );
+        }
+    }
+}
@@ -0,0 +1,89 @@
+//! Protobuf language definition for the registry
+//!
+//! Provides the Protocol Buffers language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{ProtobufBehavior, ProtobufParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Protocol Buffers language definition
+pub struct ProtobufLanguage;
+
+impl ProtobufLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("protobuf");
+}
+
+impl LanguageDefinition for ProtobufLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Protocol Buffers"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["proto"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = ProtobufParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(ProtobufBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Protobuf is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Protobuf is enabled by default
+    }
+}
+
+/// Register Protobuf language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(ProtobufLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_protobuf_definition() {
+        let protobuf = ProtobufLanguage;
+
+        assert_eq!(protobuf.id(), LanguageId::new("protobuf"));
+        assert_eq!(protobuf.name(), "Protocol Buffers");
+        assert!(protobuf.extensions().contains(&"proto"));
+    }
+
+    #[test]
+    fn test_protobuf_enabled_by_default() {
+        let protobuf = ProtobufLanguage;
+        let settings = Settings::default();
+
+        assert!(protobuf.default_enabled());
+        assert!(protobuf.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_protobuf_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("protobuf")));
+    }
+}
@@ -0,0 +1,145 @@
+//! RPC handler naming across gRPC code generators
+//!
+//! Generated servers dispatch each RPC to a method the project implements
+//! by hand, named after the RPC in the target language's convention:
+//!
+//! | Language | Generator | `rpc GetUser` handler |
+//! |----------|-----------|-----------------------|
+//! | Rust | tonic | `get_user` |
+//! | Go | protoc-gen-go-grpc | `GetUser` |
+//! | TypeScript | grpc-js, protobuf-ts | `getUser` |
+//!
+//! Each RPC records a call to the handler name of every target language,
+//! resolved among that language's symbols, so `find_callers` on a handler
+//! reaches the RPC and, from there, the clients calling it.
+
+use crate::parsing::LanguageId;
+
+/// `GetHTTPStatus` -> `get_http_status`, as tonic names trait methods
+pub fn to_snake_case(name: &str) -> String {
+    let chars: Vec<char> = name.chars().collect();
+    let mut snake = String::with_capacity(name.len() + 4);
+    for (i, &c) in chars.iter().enumerate() {
+        if c.is_uppercase() {
+            let prev = i.checked_sub(1).map(|p| chars[p]);
+            let next = chars.get(i + 1);
+            let starts_word = prev.is_some_and(|p| p.is_lowercase() || p.is_ascii_digit())
+                || (prev.is_some_and(char::is_uppercase) && next.is_some_and(|n| n.is_lowercase()));
+            if starts_word && !snake.ends_with('_') {
+                snake.push('_');
+            }
+            snake.extend(c.to_lowercase());
+        } else {
+            snake.push(c);
+        }
+    }
+    snake
+}
+
+/// `GetUser` -> `getUser`
+pub fn to_lower_camel_case(name: &str) -> String {
+    let mut chars = name.chars();
+    match chars.next() {
+        Some(first) => first.to_lowercase().chain(chars).collect(),
+        None => String::new(),
+    }
+}
+
+/// Handler name of `rpc` in each language with gRPC servers
+pub fn handler_names(rpc: &str) -> Vec<(LanguageId, String)> {
+    vec![
+        (LanguageId::new("rust"), to_snake_case(rpc)),
+        (LanguageId::new("go"), rpc.to_string()),
+        (LanguageId::new("typescript"), to_lower_camel_case(rpc)),
+    ]
+}
+
+/// Request message of an RPC signature:
+/// `rpc Watch(stream acme.WatchRequest) returns (Event)` -> `WatchRequest`
+pub fn request_type(signature: &str) -> Option<&str> {
+    let start = signature.find('(')? + 1;
+    let end = start + signature[start..].find(')')?;
+    let request = signature[start..end].trim();
+    let request = request.strip_prefix("stream ").unwrap_or(request).trim();
+    let name = request.rsplit('.').next()?;
+    (!name.is_empty()).then_some(name)
+}
+
+/// Whether `class` is a type generated for `service` rather than the
+/// project's implementation of it: the service trait or interface itself,
+/// its client, its server wrapper or the unimplemented base
+/// (`UserServiceClient`, `userServiceClient`, `UnimplementedUserServiceServer`,
+/// `IUserServiceServer`)
+pub fn is_generated_stub(class: &str, service: &str) -> bool {
+    let class = class.to_ascii_lowercase();
+    let service = service.to_ascii_lowercase();
+    let bare = ["unimplemented", "unsafe", "i"]
+        .iter()
+        .find_map(|prefix| {
+            class
+                .strip_prefix(prefix)
+                .filter(|rest| rest.starts_with(&service))
+        })
+        .unwrap_or(&class);
+
+    let Some(suffix) = bare.strip_prefix(service.as_str()) else {
+        return false;
+    };
+    matches!(suffix, "" | "client" | "server" | "service" | "clientimpl")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_handler_names() {
+        assert_eq!(
+            handler_names("GetUser"),
+            vec![
+                (LanguageId::new("rust"), "get_user".to_string()),
+                (LanguageId::new("go"), "GetUser".to_string()),
+                (LanguageId::new("typescript"), "getUser".to_string()),
+            ]
+        );
+        assert_eq!(to_snake_case("GetHTTPStatus"), "get_http_status");
+        assert_eq!(to_snake_case("ListV2Users"), "list_v2_users");
+        assert_eq!(to_snake_case("Ping"), "ping");
+    }
+
+    #[test]
+    fn test_request_type() {
+        assert_eq!(
+            request_type("rpc GetUser(GetUserRequest) returns (User)"),
+            Some("GetUserRequest")
+        );
+        assert_eq!(
+            request_type("rpc Watch(stream acme.v1.WatchRequest) returns (stream Event)"),
+            Some("WatchRequest")
+        );
+        assert_eq!(request_type("rpc Broken"), None);
+    }
+
+    #[test]
+    fn test_generated_stub_names() {
+        for class in [
+            "UserService",
+            "UserServiceClient",
+            "userServiceClient",
+            "UserServiceServer",
+            "UnimplementedUserServiceServer",
+            "UnsafeUserServiceServer",
+            "IUserServiceServer",
+        ] {
+            assert!(is_generated_stub(class, "UserService"), "{class}");
+        }
+        for class in [
+            "UserServer",
+            "UserServiceImpl",
+            "Handlers",
+            "InvoiceServiceClient",
+        ] {
+            assert!(!is_generated_stub(class, "UserService"), "{class}");
+        }
+    }
+}
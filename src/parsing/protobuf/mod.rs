@@ -0,0 +1,21 @@
+//! Protobuf language parser implementation
+//!
+//! Indexes the messages, enums, services and RPCs of `.proto` schemas, and
+//! links each RPC to the handlers implementing it in Rust, Go and
+//! TypeScript (see [`handlers`]), so callers can be followed across the
+//! gRPC boundary.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod handlers;
+pub mod parser;
+pub mod resolution;
+
+pub use behavior::ProtobufBehavior;
+pub use definition::ProtobufLanguage;
+pub use parser::ProtobufParser;
+pub use resolution::ProtobufResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
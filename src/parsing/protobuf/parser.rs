@@ -0,0 +1,611 @@
+//! Protobuf language parser implementation
+//!
+//! Extracts the declarations of `.proto` schemas using tree-sitter-proto.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | `message` | Struct |
+//! | fields, `oneof` fields, `map<K, V>` fields | Field |
+//! | `enum` | Enum |
+//! | enum values | Constant |
+//! | `service` | Interface |
+//! | `rpc` | Method |
+//!
+//! Nested messages and enums are members of the message declaring them;
+//! `oneof` fields belong to the enclosing message.
+//!
+//! ## Relationships
+//!
+//! Messages use the message and enum types of their fields, and RPCs use
+//! their request and response types. Qualified type names
+//! (`google.protobuf.Timestamp`) are recorded by their last part. `import`
+//! statements are imports of the named file. Links from RPCs to their
+//! handlers in other languages are added during indexing (see
+//! [`super::handlers`]).
+//!
+//! ## Documentation
+//!
+//! `//` and `/* */` comments directly above a declaration are its doc
+//! comment; a comment trailing the previous declaration on its line is not.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, NodeTracker, NodeTrackingState, ParserContext,
+    ScopeType,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Protobuf-specific parsing errors
+#[derive(Error, Debug)]
+pub enum ProtobufParseError {
+    #[error(
+        "Failed to initialize Protobuf parser: {reason}\nSuggestion: Ensure tree-sitter-proto is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// Protobuf language parser
+pub struct ProtobufParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for ProtobufParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("ProtobufParser")
+            .field("language", &"Protobuf")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Whitespace-collapsed text
+fn collapse(text: &str) -> String {
+    text.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+fn child_of_kind<'t>(node: &Node<'t>, kind: &str) -> Option<Node<'t>> {
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find(|child| child.kind() == kind)
+}
+
+/// Name of a declaration: the identifier of its `*_name` child, or its own
+/// identifier for fields, enum values and oneofs
+fn declaration_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    let name_kind = match node.kind() {
+        "message" => "message_name",
+        "enum" => "enum_name",
+        "service" => "service_name",
+        "rpc" => "rpc_name",
+        _ => "identifier",
+    };
+    let name = child_of_kind(node, name_kind)?;
+    Some(&code[name.byte_range()])
+}
+
+/// Last part of a `message_or_enum_type`: `google.protobuf.Timestamp` ->
+/// `Timestamp`
+fn type_name<'t>(node: &Node<'t>) -> Option<Node<'t>> {
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .filter(|child| child.kind() == "identifier")
+        .last()
+}
+
+/// Declaration text up to its body or terminating `;`
+fn header(node: &Node, code: &str) -> String {
+    let text = &code[node.byte_range()];
+    let end = text.find(['{', ';']).unwrap_or(text.len());
+    collapse(&text[..end])
+}
+
+impl ProtobufParser {
+    /// Create a new Protobuf parser instance
+    pub fn new() -> Result<Self, ProtobufParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_proto::LANGUAGE.into())
+            .map_err(|e| ProtobufParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn create_symbol(
+        &self,
+        counter: &mut SymbolCounter,
+        name: &str,
+        kind: SymbolKind,
+        file_id: FileId,
+        node: &Node,
+        signature: String,
+        code: &str,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(
+            counter.next_id(),
+            name,
+            kind,
+            file_id,
+            range_from_node(node),
+        )
+        .with_signature(signature)
+        .with_visibility(Visibility::Public);
+        if let Some(doc) = self.extract_doc_comment(node, code) {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(self.context.current_scope_context());
+        symbol
+    }
+
+    /// Extract symbols from the declarations under `node`
+    fn extract_declarations(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            let kind = match child.kind() {
+                "message" => SymbolKind::Struct,
+                "enum" => SymbolKind::Enum,
+                "service" => SymbolKind::Interface,
+                "field" | "oneof_field" | "map_field" => SymbolKind::Field,
+                "enum_field" => SymbolKind::Constant,
+                "rpc" => SymbolKind::Method,
+                // Fields of a oneof are fields of the message holding it
+                "oneof" => {
+                    self.extract_declarations(child, code, file_id, counter, symbols, depth + 1);
+                    continue;
+                }
+                _ => continue,
+            };
+            let Some(name) = declaration_name(&child, code) else {
+                continue;
+            };
+            self.register_handled_node(child.kind(), child.kind_id());
+
+            // Fields and values are their whole declaration
+            let signature = match kind {
+                SymbolKind::Field | SymbolKind::Constant => {
+                    collapse(code[child.byte_range()].trim_end_matches(';'))
+                }
+                _ => header(&child, code),
+            };
+            let symbol = self.create_symbol(counter, name, kind, file_id, &child, signature, code);
+            symbols.push(symbol);
+
+            if matches!(
+                kind,
+                SymbolKind::Struct | SymbolKind::Enum | SymbolKind::Interface
+            ) {
+                let body = match child.kind() {
+                    "message" => child_of_kind(&child, "message_body"),
+                    "enum" => child_of_kind(&child, "enum_body"),
+                    // RPCs sit directly in the service
+                    _ => Some(child),
+                };
+                if let Some(body) = body {
+                    let saved_class = self.context.current_class().map(|s| s.to_string());
+                    self.context.enter_scope(ScopeType::Class);
+                    self.context.set_current_class(Some(name.to_string()));
+                    self.extract_declarations(body, code, file_id, counter, symbols, depth + 1);
+                    self.context.exit_scope();
+                    self.context.set_current_class(saved_class);
+                }
+            }
+        }
+    }
+
+    /// Message and enum types used by messages and RPCs under `node`
+    fn collect_uses<'a>(
+        node: Node,
+        code: &'a str,
+        owner: Option<&'a str>,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            match child.kind() {
+                "message" => {
+                    let name = declaration_name(&child, code);
+                    Self::collect_uses(child, code, name, uses, depth + 1);
+                }
+                "rpc" => {
+                    let Some(rpc) = declaration_name(&child, code) else {
+                        continue;
+                    };
+                    let mut types = child.walk();
+                    for message in child
+                        .named_children(&mut types)
+                        .filter(|node| node.kind() == "message_or_enum_type")
+                    {
+                        if let Some(used) = type_name(&message) {
+                            uses.push((rpc, &code[used.byte_range()], range_from_node(&used)));
+                        }
+                    }
+                }
+                "message_or_enum_type" => {
+                    if let (Some(owner), Some(used)) = (owner, type_name(&child)) {
+                        uses.push((owner, &code[used.byte_range()], range_from_node(&used)));
+                    }
+                }
+                // Nested enums use nothing
+                "enum" | "comment" => {}
+                _ => Self::collect_uses(child, code, owner, uses, depth + 1),
+            }
+        }
+    }
+
+    /// RPCs of every service
+    fn collect_defines<'a>(
+        node: Node,
+        code: &'a str,
+        defines: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        let mut cursor = node.walk();
+        for service in node
+            .named_children(&mut cursor)
+            .filter(|child| child.kind() == "service")
+        {
+            let Some(service_name) = declaration_name(&service, code) else {
+                continue;
+            };
+            let mut rpcs = service.walk();
+            for rpc in service
+                .named_children(&mut rpcs)
+                .filter(|child| child.kind() == "rpc")
+            {
+                if let Some(rpc_name) = declaration_name(&rpc, code) {
+                    defines.push((service_name, rpc_name, range_from_node(&rpc)));
+                }
+            }
+        }
+    }
+
+    /// `// text` / `/* text */` -> `text`
+    fn comment_text(node: &Node, code: &str) -> String {
+        let text = &code[node.byte_range()];
+        if let Some(line) = text.strip_prefix("//") {
+            return line.trim().to_string();
+        }
+        text.trim_start_matches("/*")
+            .trim_end_matches("*/")
+            .lines()
+            .map(|line| line.trim().trim_start_matches('*').trim())
+            .collect::<Vec<_>>()
+            .join("\n")
+            .trim()
+            .to_string()
+    }
+}
+
+impl LanguageParser for ProtobufParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+
+        let mut symbols = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            self.extract_declarations(
+                tree.root_node(),
+                code,
+                file_id,
+                symbol_counter,
+                &mut symbols,
+                0,
+            );
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// Comment lines directly above the declaration, without a blank line
+    /// between
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let mut lines = Vec::new();
+        let mut next_row = node.start_position().row;
+        let mut current = node.prev_named_sibling();
+        while let Some(prev) = current {
+            if prev.kind() != "comment" || prev.end_position().row + 1 != next_row {
+                break;
+            }
+            // `string name = 1; // trailing` documents the field before it
+            let before = prev.prev_named_sibling();
+            if before.is_some_and(|before| {
+                before.kind() != "comment" && before.end_position().row == prev.start_position().row
+            }) {
+                break;
+            }
+            lines.push(Self::comment_text(&prev, code));
+            next_row = prev.start_position().row;
+            current = before;
+        }
+
+        if lines.is_empty() {
+            return None;
+        }
+        lines.reverse();
+        let doc = lines.join("\n").trim().to_string();
+        (!doc.is_empty()).then_some(doc)
+    }
+
+    fn find_calls<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        // Schemas declare, they do not call
+        Vec::new()
+    }
+
+    fn find_implementations<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    /// Field types of messages, request and response types of RPCs
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut uses = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::collect_uses(tree.root_node(), code, None, &mut uses, 0);
+        }
+        uses
+    }
+
+    /// Services define their RPCs
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut defines = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::collect_defines(tree.root_node(), code, &mut defines);
+        }
+        defines
+    }
+
+    /// `import "path/to/file.proto";`, `weak` and `public` included
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let mut imports = Vec::new();
+        let Some(tree) = self.parser.parse(code, None) else {
+            return imports;
+        };
+
+        let root = tree.root_node();
+        let mut cursor = root.walk();
+        for import in root
+            .named_children(&mut cursor)
+            .filter(|child| child.kind() == "import")
+        {
+            let Some(path) = import
+                .child_by_field_name("path")
+                .or_else(|| child_of_kind(&import, "string"))
+            else {
+                continue;
+            };
+            imports.push(Import {
+                path: code[path.byte_range()]
+                    .trim_matches(['"', '\''])
+                    .to_string(),
+                alias: None,
+                file_id,
+                // Every declaration of the imported file becomes visible
+                is_glob: true,
+                is_type_only: false,
+            });
+        }
+        imports
+    }
+
+    fn language(&self) -> Language {
+        Language::Protobuf
+    }
+}
+
+impl NodeTracker for ProtobufParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = ProtobufParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+    }
+
+    fn member_of(symbol: &Symbol) -> Option<&str> {
+        match symbol.scope_context.as_ref()? {
+            ScopeContext::ClassMember {
+                class_name: Some(class),
+            } => Some(class),
+            _ => None,
+        }
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(ProtobufParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_messages_and_fields() {
+        let code = r#"syntax = "proto3";
+
+// A registered account
+message User {
+  string email = 1; // login name
+  repeated string roles = 2;
+  map<string, string> labels = 3;
+  oneof contact {
+    string phone = 4;
+  }
+
+  message Address {
+    string city = 1;
+  }
+}
+"#;
+        let symbols = parse(code);
+
+        let user = find(&symbols, "User");
+        assert_eq!(user.kind, SymbolKind::Struct);
+        assert_eq!(user.signature.as_deref(), Some("message User"));
+        assert_eq!(user.doc_comment.as_deref(), Some("A registered account"));
+        assert_eq!(user.scope_context, Some(ScopeContext::Module));
+
+        let email = find(&symbols, "email");
+        assert_eq!(email.kind, SymbolKind::Field);
+        assert_eq!(email.signature.as_deref(), Some("string email = 1"));
+        assert_eq!(member_of(email), Some("User"));
+
+        // The trailing comment of `email` does not document `roles`
+        assert_eq!(find(&symbols, "roles").doc_comment, None);
+
+        for field in ["labels", "phone"] {
+            assert_eq!(member_of(find(&symbols, field)), Some("User"), "{field}");
+        }
+
+        let address = find(&symbols, "Address");
+        assert_eq!(address.kind, SymbolKind::Struct);
+        assert_eq!(member_of(address), Some("User"));
+        assert_eq!(member_of(find(&symbols, "city")), Some("Address"));
+    }
+
+    #[test]
+    fn test_enums_and_services() {
+        let code = r#"syntax = "proto3";
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+}
+
+service UserService {
+  // Looks up one user
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc WatchUsers(stream WatchRequest) returns (stream User) {}
+}
+"#;
+        let symbols = parse(code);
+
+        assert_eq!(find(&symbols, "Status").kind, SymbolKind::Enum);
+        let active = find(&symbols, "STATUS_ACTIVE");
+        assert_eq!(active.kind, SymbolKind::Constant);
+        assert_eq!(member_of(active), Some("Status"));
+
+        let service = find(&symbols, "UserService");
+        assert_eq!(service.kind, SymbolKind::Interface);
+
+        let get_user = find(&symbols, "GetUser");
+        assert_eq!(get_user.kind, SymbolKind::Method);
+        assert_eq!(member_of(get_user), Some("UserService"));
+        assert_eq!(
+            get_user.signature.as_deref(),
+            Some("rpc GetUser(GetUserRequest) returns (User)")
+        );
+        assert_eq!(get_user.doc_comment.as_deref(), Some("Looks up one user"));
+        assert_eq!(
+            find(&symbols, "WatchUsers").signature.as_deref(),
+            Some("rpc WatchUsers(stream WatchRequest) returns (stream User)")
+        );
+    }
+
+    #[test]
+    fn test_uses_defines_and_imports() {
+        let code = r#"syntax = "proto3";
+
+import "google/protobuf/timestamp.proto";
+import public "acme/common.proto";
+
+message User {
+  google.protobuf.Timestamp created_at = 1;
+  Status status = 2;
+  int64 id = 3;
+}
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+}
+"#;
+        let mut parser = ProtobufParser::new().unwrap();
+
+        let uses = parser.find_uses(code);
+        let pairs: Vec<(&str, &str)> = uses.iter().map(|(from, to, _)| (*from, *to)).collect();
+        assert_eq!(
+            pairs,
+            vec![
+                ("User", "Timestamp"),
+                ("User", "Status"),
+                ("GetUser", "GetUserRequest"),
+                ("GetUser", "User"),
+            ]
+        );
+
+        let defines = parser.find_defines(code);
+        assert_eq!(defines.len(), 1);
+        assert_eq!((defines[0].0, defines[0].1), ("UserService", "GetUser"));
+
+        let imports = parser.find_imports(code, FileId::new(1).unwrap());
+        let paths: Vec<&str> = imports.iter().map(|i| i.path.as_str()).collect();
+        assert_eq!(
+            paths,
+            vec!["google/protobuf/timestamp.proto", "acme/common.proto"]
+        );
+    }
+}
@@ -268,6 +268,17 @@ impl LanguageBehavior for PythonBehavior {
         ))
     }
 
+    /// Every import binds its name in the module's namespace, and paths are
+    /// already absolute (`normalize_import_path`)
+    fn reexport_import_path(
+        &self,
+        import: &crate::parsing::Import,
+        _module: &str,
+        _file_path: &Path,
+    ) -> Option<String> {
+        Some(import.path.clone())
+    }
+
     fn module_separator(&self) -> &'static str {
         "."
     }
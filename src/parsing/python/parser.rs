@@ -13,6 +13,7 @@
 
 use crate::parsing::Import;
 use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::value_reference::{Access, ValueReference, is_constant_case};
 use crate::parsing::{
     HandledNode, Language, LanguageParser, MethodCall, NodeTracker, NodeTrackingState,
     ParserContext, ScopeType,
@@ -871,6 +872,129 @@ impl PythonParser {
         }
     }
 
+    /// Attribute accesses (`self.timeout`, `config.MAX_RETRIES`) and reads
+    /// of SCREAMING_CASE names inside functions. Callees are left to
+    /// find_method_calls. A bare name assigned in a function is a local
+    /// unless declared `global`, so only attribute targets are writes.
+    fn find_value_references_in_node<'a>(
+        &self,
+        node: Node,
+        code: &'a str,
+        references: &mut Vec<ValueReference>,
+        current_function: &mut Option<&'a str>,
+    ) {
+        match node.kind() {
+            "function_definition" => {
+                if let Some(name) = self.extract_function_name(node, code) {
+                    let old_function = *current_function;
+                    *current_function = Some(name);
+                    for child in node.children(&mut node.walk()) {
+                        self.find_value_references_in_node(
+                            child,
+                            code,
+                            references,
+                            current_function,
+                        );
+                    }
+                    *current_function = old_function;
+                    return;
+                }
+            }
+            "import_statement"
+            | "import_from_statement"
+            | "global_statement"
+            | "nonlocal_statement" => return,
+            "attribute" => {
+                if let (Some(context), Some(object), Some(attribute)) = (
+                    *current_function,
+                    node.child_by_field_name("object"),
+                    node.child_by_field_name("attribute"),
+                ) {
+                    if !Self::is_callee(node) {
+                        references.push(
+                            ValueReference::new(
+                                context,
+                                &code[attribute.byte_range()],
+                                Self::access_of(node),
+                                self.node_to_range(node),
+                            )
+                            .with_receiver(&code[object.byte_range()]),
+                        );
+                    }
+                }
+            }
+            "identifier" => {
+                let name = &code[node.byte_range()];
+                if let Some(context) = *current_function {
+                    if is_constant_case(name) && Self::names_value(node) {
+                        references.push(ValueReference::new(
+                            context,
+                            name,
+                            Access::Read,
+                            self.node_to_range(node),
+                        ));
+                    }
+                }
+            }
+            _ => {}
+        }
+
+        for child in node.children(&mut node.walk()) {
+            self.find_value_references_in_node(child, code, references, current_function);
+        }
+    }
+
+    /// Whether an expression is the function a call calls
+    fn is_callee(node: Node) -> bool {
+        node.parent().is_some_and(|call| {
+            call.kind() == "call" && call.child_by_field_name("function") == Some(node)
+        })
+    }
+
+    /// Whether an identifier reads a value: not a binding, a parameter, a
+    /// callee or an attribute name
+    fn names_value(node: Node) -> bool {
+        let Some(parent) = node.parent() else {
+            return false;
+        };
+        let is_field = |field: &str| parent.child_by_field_name(field) == Some(node);
+        match parent.kind() {
+            "attribute" => is_field("object"),
+            "call" => !is_field("function"),
+            "keyword_argument" | "default_parameter" | "typed_default_parameter" => {
+                !is_field("name")
+            }
+            "assignment" | "augmented_assignment" | "for_statement" | "for_in_clause" => {
+                !is_field("left")
+            }
+            "parameters"
+            | "typed_parameter"
+            | "lambda_parameters"
+            | "function_definition"
+            | "class_definition"
+            | "dotted_name"
+            | "aliased_import"
+            | "pattern_list"
+            | "tuple_pattern"
+            | "list_splat_pattern"
+            | "as_pattern_target" => false,
+            _ => true,
+        }
+    }
+
+    /// Whether an expression is the target of an assignment
+    fn access_of(node: Node) -> Access {
+        let assigned = node.parent().is_some_and(|parent| {
+            matches!(parent.kind(), "assignment" | "augmented_assignment")
+                && parent.child_by_field_name("left") == Some(node)
+        });
+        if assigned {
+            Access::Write
+        } else {
+            Access::Read
+        }
+    }
+
     /// Extract the target of a function call
     fn extract_call_target<'a>(&self, node: Node, code: &'a str) -> Option<&'a str> {
         // Get the function being called
@@ -1504,6 +1628,20 @@ impl LanguageParser for PythonParser {
         self.find_variable_types_in_node(root_node, code, &mut variable_types);
         variable_types
     }
+
+    fn find_value_references(&mut self, code: &str) -> Vec<ValueReference> {
+        let tree = match self.parser.parse(code, None) {
+            Some(tree) => tree,
+            None => return Vec::new(),
+        };
+
+        let root_node = tree.root_node();
+        let mut references = Vec::new();
+        let mut current_function = None;
+
+        self.find_value_references_in_node(root_node, code, &mut references, &mut current_function);
+        references
+    }
 }
 
 impl NodeTracker for PythonParser {
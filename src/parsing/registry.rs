@@ -17,6 +17,7 @@
 //! abstractions and type safety.
 
 use std::collections::HashMap;
+use std::path::Path;
 use std::sync::Arc;
 use thiserror::Error;
 
@@ -26,6 +27,7 @@ use crate::{IndexResult, Settings};
 /// Type alias for parser and behavior pair to reduce complexity
 pub type ParserBehaviorPair = (Box<dyn LanguageParser>, Box<dyn LanguageBehavior>);
 
+use serde::de::IntoDeserializer;
 use serde::{Deserialize, Deserializer, Serialize, Serializer};
 
 /// Type-safe language identifier
@@ -75,21 +77,37 @@ impl<'de> Deserialize<'de> for LanguageId {
         // Convert to a static string by matching known languages
         // This is necessary because LanguageId requires &'static str
         let static_str = match s.as_str() {
+            "bash" => "bash",
             "c" => "c",
             "clojure" => "clojure",
             "cpp" => "cpp",
             "csharp" => "csharp",
+            "css" => "css",
+            "dart" => "dart",
+            "elixir" => "elixir",
             "gdscript" => "gdscript",
             "go" => "go",
+            "graphql" => "graphql",
+            "hcl" => "hcl",
+            "html" => "html",
             "java" => "java",
             "javascript" => "javascript",
             "kotlin" => "kotlin",
             "lua" => "lua",
+            "markdown" => "markdown",
+            "jupyter" => "jupyter",
             "php" => "php",
+            "protobuf" => "protobuf",
             "python" => "python",
+            "ruby" => "ruby",
             "rust" => "rust",
+            "scala" => "scala",
+            "sql" => "sql",
+            "svelte" => "svelte",
             "swift" => "swift",
             "typescript" => "typescript",
+            "vue" => "vue",
+            "zig" => "zig",
             // For unknown languages, we leak the string to get 'static lifetime
             // This is safe because language identifiers are typically created once
             // at startup and live for the entire program
@@ -100,6 +118,34 @@ impl<'de> Deserialize<'de> for LanguageId {
     }
 }
 
+/// Deserialize a language through the registry, so a plugin language read
+/// back for every file keeps its registered name instead of leaking one
+pub fn deserialize_registered<'de, D>(deserializer: D) -> Result<LanguageId, D::Error>
+where
+    D: Deserializer<'de>,
+{
+    let name = String::deserialize(deserializer)?;
+    let registered = get_registry()
+        .lock()
+        .ok()
+        .and_then(|registry| registry.find_language_id(&name));
+    match registered {
+        Some(id) => Ok(id),
+        None => LanguageId::deserialize(name.into_deserializer()),
+    }
+}
+
+/// [`deserialize_registered`] for an optional language
+pub fn deserialize_registered_opt<'de, D>(deserializer: D) -> Result<Option<LanguageId>, D::Error>
+where
+    D: Deserializer<'de>,
+{
+    #[derive(Deserialize)]
+    struct Registered(#[serde(deserialize_with = "deserialize_registered")] LanguageId);
+
+    Ok(Option::<Registered>::deserialize(deserializer)?.map(|Registered(id)| id))
+}
+
 /// Registry errors with actionable suggestions
 #[derive(Error, Debug)]
 pub enum RegistryError {
@@ -232,6 +278,35 @@ impl LanguageRegistry {
         self.extension_map.get(ext).and_then(|id| self.get(*id))
     }
 
+    /// The language of the file at `path` under `settings`
+    ///
+    /// An extension a language lists under `[languages.<name>]` without
+    /// having it built in (`extensions = ["h"]` under cpp) goes to that
+    /// language, before the built-in mapping. A file outside the `include`
+    /// globs of its language, or inside its `exclude` globs, has none.
+    #[must_use]
+    pub fn language_for_path(&self, path: &Path, settings: &Settings) -> Option<LanguageId> {
+        let extension = path.extension()?.to_str()?;
+        let mapped = settings.languages.iter().find_map(|(name, config)| {
+            let listed = config
+                .extensions
+                .iter()
+                .any(|ext| ext.strip_prefix('.').unwrap_or(ext) == extension);
+            if !listed {
+                return None;
+            }
+            let id = self.find_language_id(name)?;
+            let built_in = self.get(id)?.extensions().contains(&extension);
+            (!built_in).then_some(id)
+        });
+        let id = mapped.or_else(|| self.get_by_extension(extension).map(|def| def.id()))?;
+        let in_scope = settings
+            .languages
+            .get(id.as_str())
+            .is_none_or(|config| config.scope.takes(settings.workspace_relative(path)));
+        in_scope.then_some(id)
+    }
+
     /// Convert a string to LanguageId by looking up registered languages
     ///
     /// This is useful when reading language identifiers from storage
@@ -392,6 +467,22 @@ fn initialize_registry(registry: &mut LanguageRegistry) {
     super::clojure::register(registry);
     super::lua::register(registry);
     super::swift::register(registry);
+    super::ruby::register(registry);
+    super::scala::register(registry);
+    super::zig::register(registry);
+    super::dart::register(registry);
+    super::elixir::register(registry);
+    super::bash::register(registry);
+    super::sql::register(registry);
+    super::protobuf::register(registry);
+    super::graphql::register(registry);
+    super::hcl::register(registry);
+    super::vue::register(registry);
+    super::svelte::register(registry);
+    super::markdown::register(registry);
+    super::jupyter::register(registry);
+    super::css::register(registry);
+    super::html::register(registry);
 }
 
 /// Get the global registry
@@ -0,0 +1,283 @@
+//! Ruby-specific language behavior implementation
+//!
+//! Module paths follow the Zeitwerk/Rails autoload convention: a file's
+//! path below its load root maps to the constant it defines, so
+//! `lib/billing/invoice_total.rb` becomes `Billing::InvoiceTotal`.
+//! `require "billing/invoice_total"` is camelized the same way, which lets
+//! require paths match symbol module paths directly.
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Convert a snake_case path segment to its CamelCase constant name
+fn camelize(segment: &str) -> String {
+    segment
+        .split('_')
+        .filter(|part| !part.is_empty())
+        .map(|part| {
+            let mut chars = part.chars();
+            match chars.next() {
+                Some(first) => first.to_uppercase().chain(chars).collect::<String>(),
+                None => String::new(),
+            }
+        })
+        .collect()
+}
+
+/// Camelize every segment of a `/` or `::` separated path
+fn camelize_path(path: &str) -> String {
+    path.split(['/', ':'])
+        .filter(|segment| !segment.is_empty() && *segment != ".")
+        .map(camelize)
+        .collect::<Vec<_>>()
+        .join("::")
+}
+
+/// Ruby language behavior implementation
+#[derive(Clone)]
+pub struct RubyBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl RubyBehavior {
+    /// Create a new Ruby behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_ruby::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for RubyBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for RubyBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for RubyBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("ruby")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    fn configure_symbol(&self, symbol: &mut crate::Symbol, module_path: Option<&str>) {
+        // Visibility comes from `private`/`protected` sections, which the
+        // signature alone cannot express; keep what the parser assigned.
+        if let Some(path) = module_path {
+            symbol.module_path = Some(path.to_string().into());
+        }
+    }
+
+    fn parse_visibility(&self, signature: &str) -> Visibility {
+        if signature.starts_with("private ") {
+            Visibility::Private
+        } else if signature.starts_with("protected ") {
+            Visibility::Module
+        } else {
+            Visibility::Public
+        }
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "::"
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &[
+            "app/models",
+            "app/controllers",
+            "app/helpers",
+            "app/jobs",
+            "app/mailers",
+            "app/channels",
+            "app/services",
+            "lib",
+        ]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(
+                components
+                    .iter()
+                    .map(|c| camelize(c))
+                    .collect::<Vec<_>>()
+                    .join("::"),
+            )
+        }
+    }
+
+    fn supports_traits(&self) -> bool {
+        true // Mixin modules play the trait role
+    }
+
+    fn supports_inherent_methods(&self) -> bool {
+        true
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::RubyResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    /// `require_relative "../models/user"` is anchored to the importing
+    /// file's module path before module matching.
+    fn normalize_import_path(
+        &self,
+        import_path: &str,
+        importing_module: Option<&str>,
+        _file_path: &Path,
+    ) -> Option<String> {
+        if !import_path.starts_with('.') {
+            return None;
+        }
+
+        let mut segments: Vec<String> = importing_module
+            .map(|module| module.split("::").map(str::to_string).collect())
+            .unwrap_or_default();
+        // The importing file itself is the last segment
+        segments.pop();
+
+        for part in import_path.split('/') {
+            match part {
+                "" | "." => {}
+                ".." => {
+                    segments.pop();
+                }
+                other => segments.push(camelize(other)),
+            }
+        }
+
+        Some(segments.join("::"))
+    }
+
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        let camelized = camelize_path(import_path);
+        camelized == symbol_module_path || symbol_module_path.starts_with(&format!("{camelized}::"))
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+
+    /// Private and protected restrict the receiver, not the file: a class
+    /// reopened in another file still calls its private helpers.
+    fn is_symbol_visible_from_file(&self, _symbol: &crate::Symbol, _from_file: FileId) -> bool {
+        true
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_camelize() {
+        assert_eq!(camelize("invoice_total"), "InvoiceTotal");
+        assert_eq!(camelize("user"), "User");
+        assert_eq!(
+            camelize_path("billing/invoice_total"),
+            "Billing::InvoiceTotal"
+        );
+    }
+
+    #[test]
+    fn test_module_path_from_file() {
+        let behavior = RubyBehavior::new();
+        let root = Path::new("/project");
+        let exts = &["rb"];
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/lib/billing/invoice_total.rb"),
+                root,
+                exts
+            ),
+            Some("Billing::InvoiceTotal".to_string())
+        );
+        assert_eq!(
+            behavior.module_path_from_file(Path::new("/project/app/models/user.rb"), root, exts),
+            Some("User".to_string())
+        );
+    }
+
+    #[test]
+    fn test_import_matches_symbol() {
+        let behavior = RubyBehavior::new();
+
+        assert!(behavior.import_matches_symbol(
+            "billing/invoice_total",
+            "Billing::InvoiceTotal",
+            None
+        ));
+        assert!(behavior.import_matches_symbol("billing", "Billing::InvoiceTotal", None));
+        assert!(!behavior.import_matches_symbol("json", "Billing::InvoiceTotal", None));
+    }
+
+    #[test]
+    fn test_normalize_require_relative() {
+        let behavior = RubyBehavior::new();
+        let file = Path::new("lib/billing/invoice.rb");
+
+        assert_eq!(
+            behavior.normalize_import_path("./line_item", Some("Billing::Invoice"), file),
+            Some("Billing::LineItem".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("../shared/money", Some("Billing::Invoice"), file),
+            Some("Shared::Money".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("json", Some("Billing::Invoice"), file),
+            None
+        );
+    }
+
+    #[test]
+    fn test_module_separator() {
+        let behavior = RubyBehavior::new();
+        assert_eq!(behavior.module_separator(), "::");
+    }
+}
@@ -0,0 +1,91 @@
+//! Ruby language definition for the registry
+//!
+//! Provides the Ruby language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{RubyBehavior, RubyParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Ruby language definition
+pub struct RubyLanguage;
+
+impl RubyLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("ruby");
+}
+
+impl LanguageDefinition for RubyLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Ruby"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["rb", "rake", "gemspec", "ru"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = RubyParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(RubyBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Ruby is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Ruby is enabled by default
+    }
+}
+
+/// Register Ruby language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(RubyLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_ruby_definition() {
+        let ruby = RubyLanguage;
+
+        assert_eq!(ruby.id(), LanguageId::new("ruby"));
+        assert_eq!(ruby.name(), "Ruby");
+        assert!(ruby.extensions().contains(&"rb"));
+        assert!(ruby.extensions().contains(&"rake"));
+        assert!(ruby.extensions().contains(&"gemspec"));
+    }
+
+    #[test]
+    fn test_ruby_enabled_by_default() {
+        let ruby = RubyLanguage;
+        let settings = Settings::default();
+
+        assert!(ruby.default_enabled());
+        assert!(ruby.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_ruby_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("ruby")));
+    }
+}
@@ -0,0 +1,20 @@
+//! Ruby language parser implementation
+//!
+//! Indexes classes, modules, methods (instance and singleton), constants and
+//! the accessors generated by `attr_reader`/`attr_writer`/`attr_accessor`.
+//! Mixins (`include`/`extend`/`prepend`) are modelled as implements edges so
+//! module-heavy codebases such as Rails apps get a navigable hierarchy.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+pub mod resolution;
+
+pub use behavior::RubyBehavior;
+pub use definition::RubyLanguage;
+pub use parser::RubyParser;
+pub use resolution::RubyResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
@@ -0,0 +1,1163 @@
+//! Ruby language parser implementation
+//!
+//! Extracts symbols and relationships from Ruby source using tree-sitter-ruby.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | class | Class |
+//! | module | Module |
+//! | def (in class/module) | Method |
+//! | def (top level) | Function |
+//! | def self.x / class << self | Method |
+//! | attr_reader/attr_writer/attr_accessor | Method (one per generated accessor) |
+//! | CONSTANT = ... | Constant |
+//!
+//! ## Relationships
+//!
+//! - `class A < B` is reported through `find_extends`
+//! - `include`/`extend`/`prepend` mixins are reported through
+//!   `find_implementations` (the type implements the module)
+//! - Types define their methods and generated accessors (`find_defines`)
+//! - `require`/`require_relative` are reported as imports
+//!
+//! ## Visibility
+//!
+//! `private`/`protected`/`public` sections, `private def x` and
+//! `private :x` are honoured. Protected maps to `Visibility::Module`.
+//! Singleton methods ignore section visibility, as in Ruby itself.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, MethodCall, NodeTracker, NodeTrackingState,
+    ParserContext, ScopeType,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+// Caller sentinel; literal matched by Python/Lua downstream resolvers.
+const MODULE_SCOPE: &str = "<module>";
+
+/// Class-body calls that declare structure rather than invoke behaviour
+const DECLARATION_CALLS: &[&str] = &[
+    "attr_reader",
+    "attr_writer",
+    "attr_accessor",
+    "include",
+    "extend",
+    "prepend",
+    "require",
+    "require_relative",
+    "load",
+    "private",
+    "protected",
+    "public",
+    "module_function",
+    "private_constant",
+    "private_class_method",
+    "public_class_method",
+];
+
+/// Ruby-specific parsing errors
+#[derive(Error, Debug)]
+pub enum RubyParseError {
+    #[error(
+        "Failed to initialize Ruby parser: {reason}\nSuggestion: Ensure tree-sitter-ruby is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// Ruby language parser
+pub struct RubyParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+    /// Default visibility of each open class/module body (innermost last)
+    visibility_stack: Vec<Visibility>,
+    /// Inside a `class << self` body
+    in_singleton_class: bool,
+}
+
+impl std::fmt::Debug for RubyParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("RubyParser")
+            .field("language", &"Ruby")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Last segment of a constant path: `Admin::User` -> `User`
+fn simple_constant_name<'a>(node: &Node, code: &'a str) -> &'a str {
+    match node.kind() {
+        "scope_resolution" => node
+            .child_by_field_name("name")
+            .map(|name| &code[name.byte_range()])
+            .unwrap_or(&code[node.byte_range()]),
+        _ => &code[node.byte_range()],
+    }
+}
+
+fn visibility_keyword(text: &str) -> Option<Visibility> {
+    match text {
+        "public" => Some(Visibility::Public),
+        "private" => Some(Visibility::Private),
+        "protected" => Some(Visibility::Module),
+        _ => None,
+    }
+}
+
+/// Whether `node` sits directly in a class, module or `class << self` body
+fn in_type_body(node: &Node) -> bool {
+    node.parent()
+        .filter(|parent| parent.kind() == "body_statement")
+        .and_then(|body| body.parent())
+        .is_some_and(|owner| matches!(owner.kind(), "class" | "module" | "singleton_class"))
+}
+
+/// Symbol names from `:name`, `"name"` or `name` arguments
+fn symbol_argument_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    match node.kind() {
+        "simple_symbol" => Some(code[node.byte_range()].trim_start_matches(':')),
+        "string" => {
+            let mut cursor = node.walk();
+            node.named_children(&mut cursor)
+                .find(|child| child.kind() == "string_content")
+                .map(|content| &code[content.byte_range()])
+        }
+        "identifier" => Some(&code[node.byte_range()]),
+        _ => None,
+    }
+}
+
+/// Method name and argument list of a receiver-less call
+fn bare_call<'a, 't>(node: &Node<'t>, code: &'a str) -> Option<(&'a str, Option<Node<'t>>)> {
+    if node.kind() != "call" || node.child_by_field_name("receiver").is_some() {
+        return None;
+    }
+    let method = node.child_by_field_name("method")?;
+    Some((
+        &code[method.byte_range()],
+        node.child_by_field_name("arguments"),
+    ))
+}
+
+fn method_boundary_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    if matches!(node.kind(), "method" | "singleton_method") {
+        node.child_by_field_name("name")
+            .map(|name| &code[name.byte_range()])
+    } else {
+        None
+    }
+}
+
+impl RubyParser {
+    /// Create a new Ruby parser instance
+    pub fn new() -> Result<Self, RubyParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_ruby::LANGUAGE.into())
+            .map_err(|e| RubyParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+            visibility_stack: Vec::new(),
+            in_singleton_class: false,
+        })
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn create_symbol(
+        &self,
+        counter: &mut SymbolCounter,
+        name: &str,
+        kind: SymbolKind,
+        file_id: FileId,
+        range: Range,
+        signature: String,
+        doc_comment: Option<String>,
+        visibility: Visibility,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(counter.next_id(), name, kind, file_id, range)
+            .with_signature(signature)
+            .with_visibility(visibility);
+        if let Some(doc) = doc_comment {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(self.context.current_scope_context());
+        symbol
+    }
+
+    fn section_visibility(&self) -> Visibility {
+        self.visibility_stack
+            .last()
+            .copied()
+            .unwrap_or(Visibility::Public)
+    }
+
+    /// Extract symbols from AST node recursively
+    fn extract_symbols_from_node(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        match node.kind() {
+            "class" | "module" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_type(node, code, file_id, counter, symbols, depth);
+            }
+            "singleton_class" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                let saved = self.in_singleton_class;
+                self.in_singleton_class = true;
+                if let Some(body) = node.child_by_field_name("body") {
+                    self.extract_children(body, code, file_id, counter, symbols, depth);
+                }
+                self.in_singleton_class = saved;
+            }
+            "method" | "singleton_method" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_method(node, code, file_id, counter, symbols, None, depth);
+            }
+            "call" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                if !self.process_declaration_call(node, code, file_id, counter, symbols, depth) {
+                    self.extract_children(node, code, file_id, counter, symbols, depth);
+                }
+            }
+            "identifier" => {
+                // Bare `private` / `protected` / `public` switch the section default
+                if in_type_body(&node) {
+                    if let Some(visibility) = visibility_keyword(&code[node.byte_range()]) {
+                        self.register_handled_node(node.kind(), node.kind_id());
+                        if let Some(current) = self.visibility_stack.last_mut() {
+                            *current = visibility;
+                        }
+                    }
+                }
+            }
+            "assignment" => {
+                let is_constant = node
+                    .child_by_field_name("left")
+                    .is_some_and(|left| left.kind() == "constant");
+                if is_constant && !self.context.is_in_function() {
+                    self.register_handled_node(node.kind(), node.kind_id());
+                    self.process_constant(node, code, file_id, counter, symbols);
+                } else {
+                    self.extract_children(node, code, file_id, counter, symbols, depth);
+                }
+            }
+            _ => {
+                self.extract_children(node, code, file_id, counter, symbols, depth);
+            }
+        }
+    }
+
+    fn extract_children(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            self.extract_symbols_from_node(child, code, file_id, counter, symbols, depth + 1);
+        }
+    }
+
+    /// Process `class Name < Base ... end` and `module Name ... end`
+    fn process_type(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(name_node) = node.child_by_field_name("name") else {
+            return;
+        };
+        let name = simple_constant_name(&name_node, code);
+        let kind = if node.kind() == "class" {
+            SymbolKind::Class
+        } else {
+            SymbolKind::Module
+        };
+
+        let header_end = node
+            .child_by_field_name("superclass")
+            .unwrap_or(name_node)
+            .end_byte();
+        let signature = code[node.start_byte()..header_end].to_string();
+        let doc_comment = self.extract_doc_comment(&node, code);
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            signature,
+            doc_comment,
+            Visibility::Public,
+        );
+        symbols.push(symbol);
+
+        let saved_class = self.context.current_class().map(|s| s.to_string());
+        let saved_singleton = self.in_singleton_class;
+        self.context.enter_scope(ScopeType::Class);
+        self.context.set_current_class(Some(name.to_string()));
+        self.visibility_stack.push(Visibility::Public);
+        self.in_singleton_class = false;
+
+        if let Some(body) = node.child_by_field_name("body") {
+            self.extract_children(body, code, file_id, counter, symbols, depth);
+        }
+
+        self.in_singleton_class = saved_singleton;
+        self.visibility_stack.pop();
+        self.context.exit_scope();
+        self.context.set_current_class(saved_class);
+    }
+
+    /// Process `def name(...)` and `def self.name(...)`
+    #[allow(clippy::too_many_arguments)]
+    fn process_method(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        visibility_override: Option<Visibility>,
+        depth: usize,
+    ) {
+        let Some(name_node) = node.child_by_field_name("name") else {
+            return;
+        };
+        let name = &code[name_node.byte_range()];
+
+        let is_singleton = node.kind() == "singleton_method" || self.in_singleton_class;
+        let in_type = self.context.is_in_class();
+        let kind = if in_type || node.kind() == "singleton_method" {
+            SymbolKind::Method
+        } else {
+            SymbolKind::Function
+        };
+
+        let header_end = node
+            .child_by_field_name("parameters")
+            .unwrap_or(name_node)
+            .end_byte();
+        let mut signature = code[node.start_byte()..header_end].to_string();
+        if self.in_singleton_class && node.kind() == "method" {
+            signature = signature.replacen("def ", "def self.", 1);
+        }
+
+        // Section visibility only applies to instance methods
+        let visibility = visibility_override.unwrap_or(if is_singleton {
+            Visibility::Public
+        } else {
+            self.section_visibility()
+        });
+        let doc_comment = self.extract_doc_comment(&node, code);
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            signature,
+            doc_comment,
+            visibility,
+        );
+        symbols.push(symbol);
+
+        let saved_function = self.context.current_function().map(|s| s.to_string());
+        self.context.enter_scope(ScopeType::function());
+        self.context.set_current_function(Some(name.to_string()));
+
+        if let Some(body) = node.child_by_field_name("body") {
+            self.extract_children(body, code, file_id, counter, symbols, depth);
+        }
+
+        self.context.exit_scope();
+        self.context.set_current_function(saved_function);
+    }
+
+    /// Handle class-body declaration calls (`attr_*`, visibility modifiers)
+    ///
+    /// Returns true when the call was consumed.
+    fn process_declaration_call(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) -> bool {
+        if !in_type_body(&node) {
+            return false;
+        }
+        let Some((method, arguments)) = bare_call(&node, code) else {
+            return false;
+        };
+
+        match method {
+            "attr_reader" | "attr_writer" | "attr_accessor" => {
+                if let Some(arguments) = arguments {
+                    self.process_attr(node, arguments, method, code, file_id, counter, symbols);
+                }
+                true
+            }
+            _ => {
+                let Some(visibility) = visibility_keyword(method) else {
+                    return false;
+                };
+                let Some(arguments) = arguments else {
+                    if let Some(current) = self.visibility_stack.last_mut() {
+                        *current = visibility;
+                    }
+                    return true;
+                };
+
+                let mut cursor = arguments.walk();
+                for arg in arguments.named_children(&mut cursor) {
+                    if matches!(arg.kind(), "method" | "singleton_method") {
+                        // `private def helper ... end`
+                        self.process_method(
+                            arg,
+                            code,
+                            file_id,
+                            counter,
+                            symbols,
+                            Some(visibility),
+                            depth + 1,
+                        );
+                    } else if let Some(target) = symbol_argument_name(&arg, code) {
+                        // `private :helper` retro-applies to an earlier def
+                        self.apply_visibility(symbols, target, visibility);
+                    }
+                }
+                true
+            }
+        }
+    }
+
+    /// Emit accessor methods generated by `attr_reader :a` and friends
+    #[allow(clippy::too_many_arguments)]
+    fn process_attr(
+        &mut self,
+        node: Node,
+        arguments: Node,
+        method: &str,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let visibility = self.section_visibility();
+        let doc_comment = self.extract_doc_comment(&node, code);
+
+        let mut cursor = arguments.walk();
+        for arg in arguments.named_children(&mut cursor) {
+            let Some(attr) = symbol_argument_name(&arg, code) else {
+                continue;
+            };
+
+            let mut names = Vec::with_capacity(2);
+            if method != "attr_writer" {
+                names.push(attr.to_string());
+            }
+            if method != "attr_reader" {
+                names.push(format!("{attr}="));
+            }
+
+            for name in names {
+                let symbol = self.create_symbol(
+                    counter,
+                    &name,
+                    SymbolKind::Method,
+                    file_id,
+                    range_from_node(&arg),
+                    format!("{method} :{attr}"),
+                    doc_comment.clone(),
+                    visibility,
+                );
+                symbols.push(symbol);
+            }
+        }
+    }
+
+    fn apply_visibility(&self, symbols: &mut [Symbol], name: &str, visibility: Visibility) {
+        let Some(class_name) = self.context.current_class() else {
+            return;
+        };
+        for symbol in symbols.iter_mut().rev() {
+            let same_type = matches!(
+                &symbol.scope_context,
+                Some(ScopeContext::ClassMember { class_name: Some(owner) }) if owner.as_ref() == class_name
+            );
+            if same_type && symbol.name.as_ref() == name {
+                symbol.visibility = visibility;
+            }
+        }
+    }
+
+    /// Process `CONSTANT = value`
+    fn process_constant(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(left) = node.child_by_field_name("left") else {
+            return;
+        };
+        let name = &code[left.byte_range()];
+        let signature = code[node.byte_range()]
+            .lines()
+            .next()
+            .unwrap_or(name)
+            .trim()
+            .to_string();
+        let doc_comment = self.extract_doc_comment(&node, code);
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            SymbolKind::Constant,
+            file_id,
+            range_from_node(&node),
+            signature,
+            doc_comment,
+            Visibility::Public,
+        );
+        symbols.push(symbol);
+    }
+
+    fn extract_calls_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        calls: &mut Vec<(&'a str, &'a str, Range)>,
+        current_method: Option<&'a str>,
+    ) {
+        let context = method_boundary_name(&node, code).or(current_method);
+
+        if let Some((method, _)) = bare_call(&node, code) {
+            if !DECLARATION_CALLS.contains(&method) {
+                let caller = context.unwrap_or(MODULE_SCOPE);
+                calls.push((caller, method, range_from_node(&node)));
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_calls_from_node(child, code, calls, context);
+        }
+    }
+
+    fn extract_method_calls_from_node(
+        node: Node,
+        code: &str,
+        out: &mut Vec<MethodCall>,
+        current_method: Option<&str>,
+    ) {
+        let context = method_boundary_name(&node, code).or(current_method);
+
+        if node.kind() == "call" {
+            if let (Some(receiver), Some(method)) = (
+                node.child_by_field_name("receiver"),
+                node.child_by_field_name("method"),
+            ) {
+                let caller = context.unwrap_or(MODULE_SCOPE);
+                let mut call =
+                    MethodCall::new(caller, &code[method.byte_range()], range_from_node(&node))
+                        .with_receiver(&code[receiver.byte_range()]);
+                if matches!(receiver.kind(), "constant" | "scope_resolution") {
+                    call = call.static_method();
+                }
+                out.push(call);
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_method_calls_from_node(child, code, out, context);
+        }
+    }
+
+    /// `include`/`extend`/`prepend` inside class and module bodies
+    fn extract_mixins_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        mixins: &mut Vec<(&'a str, &'a str, Range)>,
+        current_type: Option<&'a str>,
+    ) {
+        let current_type = if matches!(node.kind(), "class" | "module") {
+            node.child_by_field_name("name")
+                .map(|name| simple_constant_name(&name, code))
+        } else {
+            current_type
+        };
+
+        if let (Some(type_name), Some((method, Some(arguments)))) =
+            (current_type, bare_call(&node, code))
+        {
+            if matches!(method, "include" | "extend" | "prepend") && in_type_body(&node) {
+                let mut cursor = arguments.walk();
+                for arg in arguments.named_children(&mut cursor) {
+                    if matches!(arg.kind(), "constant" | "scope_resolution") {
+                        mixins.push((
+                            type_name,
+                            simple_constant_name(&arg, code),
+                            range_from_node(&arg),
+                        ));
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_mixins_from_node(child, code, mixins, current_type);
+        }
+    }
+
+    fn extract_extends_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        extends: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if node.kind() == "class" {
+            if let (Some(name), Some(superclass)) = (
+                node.child_by_field_name("name"),
+                node.child_by_field_name("superclass"),
+            ) {
+                if let Some(base) = superclass.named_child(0) {
+                    if matches!(base.kind(), "constant" | "scope_resolution") {
+                        extends.push((
+                            simple_constant_name(&name, code),
+                            simple_constant_name(&base, code),
+                            range_from_node(&node),
+                        ));
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_extends_from_node(child, code, extends);
+        }
+    }
+
+    fn extract_defines_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        defines: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if matches!(node.kind(), "class" | "module") {
+            if let (Some(name), Some(body)) = (
+                node.child_by_field_name("name"),
+                node.child_by_field_name("body"),
+            ) {
+                Self::collect_body_defines(body, code, simple_constant_name(&name, code), defines);
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_defines_from_node(child, code, defines);
+        }
+    }
+
+    /// Emit defines for the members of one class/module body
+    fn collect_body_defines<'a>(
+        body: Node,
+        code: &'a str,
+        type_name: &'a str,
+        defines: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        let mut cursor = body.walk();
+        for member in body.named_children(&mut cursor) {
+            match member.kind() {
+                "method" | "singleton_method" => {
+                    if let Some(name) = member.child_by_field_name("name") {
+                        defines.push((
+                            type_name,
+                            &code[name.byte_range()],
+                            range_from_node(&member),
+                        ));
+                    }
+                }
+                "singleton_class" => {
+                    if let Some(inner) = member.child_by_field_name("body") {
+                        Self::collect_body_defines(inner, code, type_name, defines);
+                    }
+                }
+                "call" => {
+                    let Some((method, Some(arguments))) = bare_call(&member, code) else {
+                        continue;
+                    };
+                    let mut args = arguments.walk();
+                    for arg in arguments.named_children(&mut args) {
+                        match method {
+                            "attr_reader" | "attr_accessor" => {
+                                if let Some(attr) = symbol_argument_name(&arg, code) {
+                                    defines.push((type_name, attr, range_from_node(&arg)));
+                                }
+                            }
+                            "private" | "protected" | "public"
+                                if matches!(arg.kind(), "method" | "singleton_method") =>
+                            {
+                                if let Some(name) = arg.child_by_field_name("name") {
+                                    defines.push((
+                                        type_name,
+                                        &code[name.byte_range()],
+                                        range_from_node(&arg),
+                                    ));
+                                }
+                            }
+                            _ => {}
+                        }
+                    }
+                }
+                _ => {}
+            }
+        }
+    }
+
+    /// `x = Foo.new` / `@x = Admin::Foo.new(...)` bindings
+    fn extract_variable_types_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        bindings: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if node.kind() == "assignment" {
+            if let (Some(left), Some(right)) = (
+                node.child_by_field_name("left"),
+                node.child_by_field_name("right"),
+            ) {
+                let is_variable = matches!(
+                    left.kind(),
+                    "identifier" | "instance_variable" | "class_variable" | "global_variable"
+                );
+                if is_variable && right.kind() == "call" {
+                    let receiver = right.child_by_field_name("receiver");
+                    let method = right
+                        .child_by_field_name("method")
+                        .map(|m| &code[m.byte_range()]);
+                    if let (Some(receiver), Some("new")) = (receiver, method) {
+                        if matches!(receiver.kind(), "constant" | "scope_resolution") {
+                            bindings.push((
+                                &code[left.byte_range()],
+                                simple_constant_name(&receiver, code),
+                                range_from_node(&node),
+                            ));
+                        }
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_variable_types_from_node(child, code, bindings);
+        }
+    }
+
+    fn extract_imports_from_node(
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        imports: &mut Vec<Import>,
+    ) {
+        if let Some((method, Some(arguments))) = bare_call(&node, code) {
+            if matches!(method, "require" | "require_relative") {
+                let path = arguments
+                    .named_child(0)
+                    .filter(|arg| arg.kind() == "string")
+                    .and_then(|arg| symbol_argument_name(&arg, code));
+                if let Some(path) = path {
+                    // require_relative is always file-relative; mark it so the
+                    // behavior can anchor it to the importing file
+                    let path = if method == "require_relative" && !path.starts_with('.') {
+                        format!("./{path}")
+                    } else {
+                        path.to_string()
+                    };
+                    imports.push(Import {
+                        path,
+                        alias: None,
+                        file_id,
+                        is_glob: false,
+                        is_type_only: false,
+                    });
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_imports_from_node(child, code, file_id, imports);
+        }
+    }
+}
+
+impl LanguageParser for RubyParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+        self.visibility_stack.clear();
+        self.in_singleton_class = false;
+
+        let mut symbols = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            self.extract_symbols_from_node(
+                tree.root_node(),
+                code,
+                file_id,
+                symbol_counter,
+                &mut symbols,
+                0,
+            );
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// Consecutive `#` comments directly above the definition
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let mut lines = Vec::new();
+        let mut next_row = node.start_position().row;
+        let mut current = node.prev_sibling();
+
+        while let Some(prev) = current {
+            if prev.kind() != "comment" || prev.end_position().row + 1 < next_row {
+                break;
+            }
+            let text = &code[prev.byte_range()];
+            if text.starts_with("=begin") {
+                let inner = text
+                    .trim_start_matches("=begin")
+                    .trim_end()
+                    .trim_end_matches("=end");
+                lines.push(inner.trim().to_string());
+            } else {
+                lines.push(text.trim_start_matches('#').trim().to_string());
+            }
+            next_row = prev.start_position().row;
+            current = prev.prev_sibling();
+        }
+
+        if lines.is_empty() {
+            None
+        } else {
+            lines.reverse();
+            Some(lines.join("\n"))
+        }
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_calls_from_node(tree.root_node(), code, &mut calls, None);
+        }
+        calls
+    }
+
+    fn find_method_calls(&mut self, code: &str) -> Vec<MethodCall> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_method_calls_from_node(tree.root_node(), code, &mut calls, None);
+        }
+        calls
+    }
+
+    fn find_implementations<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut mixins = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_mixins_from_node(tree.root_node(), code, &mut mixins, None);
+        }
+        mixins
+    }
+
+    fn find_extends<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut extends = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_extends_from_node(tree.root_node(), code, &mut extends);
+        }
+        extends
+    }
+
+    fn find_uses<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        // Ruby has no type annotations to report
+        Vec::new()
+    }
+
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut defines = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_defines_from_node(tree.root_node(), code, &mut defines);
+        }
+        defines
+    }
+
+    fn find_variable_types<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut bindings = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_variable_types_from_node(tree.root_node(), code, &mut bindings);
+        }
+        bindings
+    }
+
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let mut imports = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_imports_from_node(tree.root_node(), code, file_id, &mut imports);
+        }
+        imports
+    }
+
+    fn language(&self) -> Language {
+        Language::Ruby
+    }
+}
+
+impl NodeTracker for RubyParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = RubyParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(RubyParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_parse_class_module_and_methods() {
+        let code = r#"
+module Billing
+  # Computes invoice totals
+  class Invoice < Document
+    TAX_RATE = 0.2
+
+    def total(lines)
+      lines.sum
+    end
+
+    def self.build
+      new
+    end
+  end
+end
+
+def helper; end
+"#;
+        let symbols = parse(code);
+
+        let find = |name: &str| symbols.iter().find(|s| s.name.as_ref() == name).unwrap();
+        assert_eq!(find("Billing").kind, SymbolKind::Module);
+        assert_eq!(find("Invoice").kind, SymbolKind::Class);
+        assert_eq!(
+            find("Invoice").doc_comment.as_deref(),
+            Some("Computes invoice totals")
+        );
+        assert_eq!(find("TAX_RATE").kind, SymbolKind::Constant);
+        assert_eq!(find("total").kind, SymbolKind::Method);
+        assert_eq!(find("build").kind, SymbolKind::Method);
+        assert_eq!(find("helper").kind, SymbolKind::Function);
+        assert!(matches!(
+            &find("total").scope_context,
+            Some(ScopeContext::ClassMember { class_name: Some(c) }) if c.as_ref() == "Invoice"
+        ));
+    }
+
+    #[test]
+    fn test_attr_accessors_generate_methods() {
+        let code = r#"
+class User
+  attr_accessor :name
+  attr_reader :id
+  attr_writer :token
+end
+"#;
+        let symbols = parse(code);
+        let names: Vec<&str> = symbols
+            .iter()
+            .filter(|s| s.kind == SymbolKind::Method)
+            .map(|s| s.name.as_ref())
+            .collect();
+
+        for expected in ["name", "name=", "id", "token="] {
+            assert!(names.contains(&expected), "missing {expected}: {names:?}");
+        }
+        assert!(!names.contains(&"id="));
+        assert!(!names.contains(&"token"));
+    }
+
+    #[test]
+    fn test_visibility_sections() {
+        let code = r#"
+class Account
+  def open; end
+
+  private
+
+  def audit; end
+
+  protected def compare(other); end
+
+  public
+
+  def close; end
+  def reset; end
+  private :reset
+end
+"#;
+        let symbols = parse(code);
+        let vis = |name: &str| {
+            symbols
+                .iter()
+                .find(|s| s.name.as_ref() == name)
+                .unwrap()
+                .visibility
+        };
+
+        assert_eq!(vis("open"), Visibility::Public);
+        assert_eq!(vis("audit"), Visibility::Private);
+        assert_eq!(vis("compare"), Visibility::Module);
+        assert_eq!(vis("close"), Visibility::Public);
+        assert_eq!(vis("reset"), Visibility::Private);
+    }
+
+    #[test]
+    fn test_mixins_reported_as_implementations() {
+        let mut parser = RubyParser::new().unwrap();
+        let code = r#"
+class Order < ApplicationRecord
+  include Comparable
+  extend ActiveSupport::Concern
+  prepend Auditing
+end
+"#;
+        let mixins = parser.find_implementations(code);
+        for module in ["Comparable", "Concern", "Auditing"] {
+            assert!(
+                mixins
+                    .iter()
+                    .any(|(ty, m, _)| *ty == "Order" && *m == module),
+                "Order should mix in {module}: {mixins:?}"
+            );
+        }
+
+        let extends = parser.find_extends(code);
+        assert!(
+            extends
+                .iter()
+                .any(|(ty, base, _)| *ty == "Order" && *base == "ApplicationRecord")
+        );
+    }
+
+    #[test]
+    fn test_calls_and_imports() {
+        let mut parser = RubyParser::new().unwrap();
+        let code = r#"
+require "json"
+require_relative "models/user"
+
+class Service
+  def run
+    repo = Repository.new
+    repo.fetch
+    log("done")
+  end
+end
+"#;
+        let calls = parser.find_calls(code);
+        assert!(calls.iter().any(|(c, m, _)| *c == "run" && *m == "log"));
+        assert!(!calls.iter().any(|(_, m, _)| *m == "require"));
+
+        let method_calls = parser.find_method_calls(code);
+        assert!(method_calls.iter().any(|c| c.caller == "run"
+            && c.method_name == "fetch"
+            && c.receiver.as_deref() == Some("repo")));
+        assert!(
+            method_calls
+                .iter()
+                .any(|c| c.method_name == "new" && c.is_static)
+        );
+
+        let bindings = parser.find_variable_types(code);
+        assert!(
+            bindings
+                .iter()
+                .any(|(v, t, _)| *v == "repo" && *t == "Repository")
+        );
+
+        let imports = parser.find_imports(code, FileId::new(1).unwrap());
+        let paths: Vec<&str> = imports.iter().map(|i| i.path.as_str()).collect();
+        assert_eq!(paths, vec!["json", "./models/user"]);
+    }
+}
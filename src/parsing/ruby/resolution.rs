@@ -0,0 +1,239 @@
+//! Ruby-specific symbol resolution
+//!
+//! Ruby resolves names through:
+//! - Local variables and parameters of the current method
+//! - Constants and methods of the enclosing class/module (including mixins)
+//! - Top-level definitions and required files
+
+use crate::parsing::resolution::{ImportBinding, ImportOrigin, default_compatible_relationship};
+use crate::parsing::{Import, ResolutionScope, ScopeLevel, ScopeType};
+use crate::{FileId, RelationKind, SymbolId};
+use std::collections::HashMap;
+
+/// Ruby resolution context
+///
+/// Tracks local, type-level and imported scopes. Qualified constant
+/// references (`Billing::Invoice`) fall back to their last segment.
+pub struct RubyResolutionContext {
+    #[allow(dead_code)]
+    file_id: FileId,
+
+    /// Method locals and parameters
+    local_scope: HashMap<String, SymbolId>,
+
+    /// Class/module members and top-level definitions in this file
+    module_scope: HashMap<String, SymbolId>,
+
+    /// Symbols made available by `require`
+    imported_scope: HashMap<String, SymbolId>,
+
+    /// Scope stack for nested contexts
+    scope_stack: Vec<ScopeType>,
+
+    /// Import bindings keyed by visible name
+    import_bindings: HashMap<String, ImportBinding>,
+}
+
+impl RubyResolutionContext {
+    pub fn new(file_id: FileId) -> Self {
+        Self {
+            file_id,
+            local_scope: HashMap::new(),
+            module_scope: HashMap::new(),
+            imported_scope: HashMap::new(),
+            scope_stack: Vec::new(),
+            import_bindings: HashMap::new(),
+        }
+    }
+}
+
+impl ResolutionScope for RubyResolutionContext {
+    fn as_any_mut(&mut self) -> &mut dyn std::any::Any {
+        self
+    }
+
+    fn resolve(&self, name: &str) -> Option<SymbolId> {
+        if let Some(&id) = self.local_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.module_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.imported_scope.get(name) {
+            return Some(id);
+        }
+
+        // `Billing::Invoice` / `::Invoice` -> `Invoice`
+        if let Some((_, last)) = name.rsplit_once("::") {
+            if !last.is_empty() {
+                return self.resolve(last);
+            }
+        }
+
+        None
+    }
+
+    fn add_symbol(&mut self, name: String, symbol_id: SymbolId, scope_level: ScopeLevel) {
+        match scope_level {
+            ScopeLevel::Local => {
+                self.local_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Module | ScopeLevel::Global => {
+                self.module_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Package => {
+                self.imported_scope.insert(name, symbol_id);
+            }
+        }
+    }
+
+    fn enter_scope(&mut self, scope_type: ScopeType) {
+        self.scope_stack.push(scope_type);
+    }
+
+    fn exit_scope(&mut self) {
+        if let Some(scope) = self.scope_stack.pop() {
+            if matches!(scope, ScopeType::Function { .. }) {
+                self.clear_local_scope();
+            }
+        }
+    }
+
+    fn clear_local_scope(&mut self) {
+        self.local_scope.clear();
+    }
+
+    fn symbols_in_scope(&self) -> Vec<(String, SymbolId, ScopeLevel)> {
+        let mut symbols = Vec::new();
+
+        for (name, &id) in &self.local_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Local));
+        }
+        for (name, &id) in &self.module_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Module));
+        }
+        for (name, &id) in &self.imported_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Package));
+        }
+
+        symbols
+    }
+
+    fn resolve_relationship(
+        &self,
+        _from_name: &str,
+        to_name: &str,
+        _kind: RelationKind,
+        _from_file: FileId,
+    ) -> Option<SymbolId> {
+        self.resolve(to_name)
+    }
+
+    fn populate_imports(&mut self, imports: &[Import]) {
+        for import in imports {
+            // `require "billing/invoice"` exposes the file's last segment
+            let name = import
+                .path
+                .rsplit(['/', ':'])
+                .find(|segment| !segment.is_empty())
+                .unwrap_or(&import.path)
+                .to_string();
+            self.import_bindings.insert(
+                name.clone(),
+                ImportBinding {
+                    import: import.clone(),
+                    exposed_name: name,
+                    origin: ImportOrigin::Unknown,
+                    resolved_symbol: None,
+                },
+            );
+        }
+    }
+
+    fn register_import_binding(&mut self, binding: ImportBinding) {
+        self.import_bindings
+            .insert(binding.exposed_name.clone(), binding);
+    }
+
+    fn import_binding(&self, name: &str) -> Option<ImportBinding> {
+        self.import_bindings.get(name).cloned()
+    }
+
+    /// Mixins target modules: `include Comparable` is Class -> Module (or
+    /// Module -> Module for concerns), which the default table rejects.
+    fn is_compatible_relationship(
+        &self,
+        from_kind: crate::SymbolKind,
+        to_kind: crate::SymbolKind,
+        rel_kind: crate::RelationKind,
+    ) -> bool {
+        use crate::RelationKind::*;
+        use crate::SymbolKind::*;
+
+        match rel_kind {
+            Implements => matches!(from_kind, Class | Module) && to_kind == Module,
+            ImplementedBy => from_kind == Module && matches!(to_kind, Class | Module),
+            Calls => {
+                // Class-body DSL calls (`validates`, `has_many`) come from the class
+                let caller = matches!(from_kind, Function | Method | Module | Class);
+                let callee = matches!(to_kind, Function | Method | Class);
+                caller && callee
+            }
+            _ => default_compatible_relationship(from_kind, to_kind, rel_kind),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::SymbolKind;
+
+    #[test]
+    fn test_scope_resolution_order() {
+        let mut context = RubyResolutionContext::new(FileId::new(1).unwrap());
+
+        let local_id = SymbolId::new(1).unwrap();
+        let module_id = SymbolId::new(2).unwrap();
+        let import_id = SymbolId::new(3).unwrap();
+
+        context.add_symbol("x".to_string(), import_id, ScopeLevel::Package);
+        context.add_symbol("x".to_string(), module_id, ScopeLevel::Module);
+        context.add_symbol("x".to_string(), local_id, ScopeLevel::Local);
+
+        assert_eq!(context.resolve("x"), Some(local_id));
+        context.clear_local_scope();
+        assert_eq!(context.resolve("x"), Some(module_id));
+    }
+
+    #[test]
+    fn test_qualified_constant_falls_back_to_last_segment() {
+        let mut context = RubyResolutionContext::new(FileId::new(1).unwrap());
+        let id = SymbolId::new(7).unwrap();
+        context.add_symbol("Invoice".to_string(), id, ScopeLevel::Module);
+
+        assert_eq!(context.resolve("Billing::Invoice"), Some(id));
+        assert_eq!(context.resolve("::Invoice"), Some(id));
+    }
+
+    #[test]
+    fn test_mixins_are_compatible_implements() {
+        let context = RubyResolutionContext::new(FileId::new(1).unwrap());
+
+        assert!(context.is_compatible_relationship(
+            SymbolKind::Class,
+            SymbolKind::Module,
+            RelationKind::Implements
+        ));
+        assert!(context.is_compatible_relationship(
+            SymbolKind::Module,
+            SymbolKind::Module,
+            RelationKind::Implements
+        ));
+        assert!(!context.is_compatible_relationship(
+            SymbolKind::Method,
+            SymbolKind::Module,
+            RelationKind::Implements
+        ));
+    }
+}
@@ -0,0 +1,178 @@
+//! Attributes the Rust parser keeps on a function's signature
+//!
+//! Signatures leave attributes out, except those telling what a function is
+//! for: test attributes (`#[test]`, `#[tokio::test]`) and foreign function
+//! bindings (`#[pyfunction]` of PyO3, `#[napi]` of napi-rs). A binding lets
+//! the resolver link a Python or JavaScript call to the Rust function it
+//! reaches across the FFI boundary.
+
+/// The language a binding exposes a Rust function to
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum BindingHost {
+    /// PyO3: `#[pyfunction]`, `#[pyo3(name = "..")]`
+    Python,
+    /// napi-rs: `#[napi]`, `#[napi(js_name = "..")]`
+    Node,
+}
+
+impl BindingHost {
+    /// The host calls of a language go through: Node for JavaScript and
+    /// TypeScript
+    pub fn of_language(language: &str) -> Option<Self> {
+        match language {
+            "python" => Some(Self::Python),
+            "javascript" | "typescript" => Some(Self::Node),
+            _ => None,
+        }
+    }
+}
+
+/// The path of an attribute: `tokio::test` for `#[tokio::test(flavor = "..")]`
+pub(super) fn path(attribute: &str) -> &str {
+    attribute
+        .trim()
+        .trim_start_matches("#[")
+        .split(['(', ']'])
+        .next()
+        .unwrap_or_default()
+        .trim()
+}
+
+/// Whether an attribute marks a test: `#[test]`, `#[tokio::test]`,
+/// `#[rstest]` or `#[test_case(..)]`
+pub fn is_test_attribute(attribute: &str) -> bool {
+    let path = path(attribute);
+    path == "test" || path.ends_with("::test") || path == "rstest" || path == "test_case"
+}
+
+/// The language an attribute binds a function to, if it is a binding
+pub fn binding_host(attribute: &str) -> Option<BindingHost> {
+    match path(attribute).rsplit("::").next()? {
+        "pyfunction" | "pyo3" => Some(BindingHost::Python),
+        "napi" => Some(BindingHost::Node),
+        _ => None,
+    }
+}
+
+/// Whether the parser keeps an attribute on the signature
+pub fn is_kept(attribute: &str) -> bool {
+    is_test_attribute(attribute) || binding_host(attribute).is_some()
+}
+
+/// The attributes a signature starts with, as the parser kept them
+pub fn signature_attributes(signature: &str) -> impl Iterator<Item = &str> {
+    signature
+        .lines()
+        .map(str::trim)
+        .take_while(|line| line.starts_with("#["))
+}
+
+/// The value of a `key = "value"` argument of an attribute
+fn string_argument<'a>(attribute: &'a str, key: &str) -> Option<&'a str> {
+    let arguments = attribute.split_once('(')?.1.rsplit_once(')')?.0;
+    arguments.split(',').find_map(|argument| {
+        let (name, value) = argument.split_once('=')?;
+        (name.trim() == key)
+            .then(|| value.trim().strip_prefix('"')?.strip_suffix('"'))
+            .flatten()
+    })
+}
+
+/// `compute_sum` as napi-rs exposes it: `computeSum`
+fn camel_case(name: &str) -> String {
+    let mut camel = String::with_capacity(name.len());
+    let mut upper = false;
+    for c in name.chars() {
+        if c == '_' && !camel.is_empty() {
+            upper = true;
+        } else if upper {
+            camel.extend(c.to_uppercase());
+            upper = false;
+        } else {
+            camel.push(c);
+        }
+    }
+    camel
+}
+
+/// The names a function with this signature answers to in the languages
+/// its bindings expose it to: its own name in Python unless `name` renames
+/// it, its camelCase name in JavaScript unless `js_name` does
+pub fn exported_names(name: &str, signature: &str) -> Vec<(BindingHost, String)> {
+    let name = name.strip_prefix("r#").unwrap_or(name);
+    let mut names: Vec<(BindingHost, String)> = Vec::new();
+    for attribute in signature_attributes(signature) {
+        let Some(host) = binding_host(attribute) else {
+            continue;
+        };
+        let renamed = match host {
+            BindingHost::Python => string_argument(attribute, "name"),
+            BindingHost::Node => string_argument(attribute, "js_name"),
+        };
+        match names.iter_mut().find(|(bound, _)| *bound == host) {
+            Some((_, exported)) => {
+                if let Some(renamed) = renamed {
+                    *exported = renamed.to_string();
+                }
+            }
+            None => names.push((
+                host,
+                match (host, renamed) {
+                    (_, Some(renamed)) => renamed.to_string(),
+                    (BindingHost::Python, None) => name.to_string(),
+                    (BindingHost::Node, None) => camel_case(name),
+                },
+            )),
+        }
+    }
+    names
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_attributes_are_told_from_bindings() {
+        assert!(is_test_attribute("#[test]"));
+        assert!(is_test_attribute(
+            "#[tokio::test(flavor = \"multi_thread\")]"
+        ));
+        assert!(!is_test_attribute("#[pyfunction]"));
+        assert_eq!(
+            binding_host("#[pyo3::pyfunction]"),
+            Some(BindingHost::Python)
+        );
+        assert_eq!(
+            binding_host("#[napi(js_name = \"x\")]"),
+            Some(BindingHost::Node)
+        );
+        assert_eq!(binding_host("#[inline]"), None);
+        assert!(!is_kept("#[derive(Debug)]"));
+    }
+
+    #[test]
+    fn test_exported_names_follow_each_binding() {
+        assert_eq!(
+            exported_names("compute_sum", "#[pyfunction]\nfn compute_sum(a: i64)"),
+            [(BindingHost::Python, "compute_sum".to_string())]
+        );
+        assert_eq!(
+            exported_names(
+                "compute_sum",
+                "#[pyfunction]\n#[pyo3(name = \"compute\", signature = (a))]\nfn compute_sum(a: i64)"
+            ),
+            [(BindingHost::Python, "compute".to_string())]
+        );
+        assert_eq!(
+            exported_names("compute_sum", "#[napi]\npub fn compute_sum(a: i64)"),
+            [(BindingHost::Node, "computeSum".to_string())]
+        );
+        assert_eq!(
+            exported_names("sum", "#[napi(js_name = \"add\")]\npub fn sum(a: i64)"),
+            [(BindingHost::Node, "add".to_string())]
+        );
+        assert!(exported_names("sum", "#[test]\nfn sum()").is_empty());
+        assert!(exported_names("sum", "fn sum()").is_empty());
+    }
+}
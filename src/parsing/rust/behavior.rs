@@ -5,7 +5,7 @@ use crate::FileId;
 use crate::Visibility;
 use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
 use crate::parsing::{InheritanceResolver, LanguageBehavior, ResolutionScope};
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
 use std::sync::{Arc, RwLock};
 use tree_sitter::{Language, Node};
 
@@ -13,22 +13,96 @@ use tree_sitter::{Language, Node};
 /// type field to the bare type-identifier name. Out-of-scope kinds (tuple, fn,
 /// impl Trait, dyn Trait, type parameters) yield `None`.
 fn find_parameter_type(node: Node, code: &str, var_name: &str) -> Option<String> {
+    reduce_type_to_name(find_parameter_type_node(node, code, var_name)?, code)
+}
+
+/// The type field of the first `parameter` whose pattern is `var_name`.
+fn find_parameter_type_node<'t>(node: Node<'t>, code: &str, var_name: &str) -> Option<Node<'t>> {
     if node.kind() == "parameter" {
         if let Some(pattern) = node.child_by_field_name("pattern") {
             if &code[pattern.byte_range()] == var_name {
-                let type_node = node.child_by_field_name("type")?;
-                return reduce_type_to_name(type_node, code);
+                return node.child_by_field_name("type");
             }
         }
     }
     for child in node.children(&mut node.walk()) {
-        if let Some(found) = find_parameter_type(child, code, var_name) {
+        if let Some(found) = find_parameter_type_node(child, code, var_name) {
             return Some(found);
         }
     }
     None
 }
 
+/// Trait bounds on the type of parameter `var_name`, behind any references:
+/// those of an `impl`/`dyn` type, or those its type parameter is declared
+/// with in the generics or the `where` clause of the signature.
+fn find_parameter_bounds(root: Node, code: &str, var_name: &str) -> Vec<String> {
+    let mut bounds = Vec::new();
+    let Some(mut type_node) = find_parameter_type_node(root, code, var_name) else {
+        return bounds;
+    };
+    while type_node.kind() == "reference_type" {
+        let Some(inner) = type_node.child_by_field_name("type") else {
+            return bounds;
+        };
+        type_node = inner;
+    }
+    match type_node.kind() {
+        "abstract_type" | "dynamic_type" => {
+            if let Some(bound) = type_node.child_by_field_name("trait") {
+                collect_bound_names(bound, code, &mut bounds);
+            }
+        }
+        "type_identifier" => {
+            let name = &code[type_node.byte_range()];
+            collect_type_parameter_bounds(root, code, name, &mut bounds);
+        }
+        _ => {}
+    }
+    bounds
+}
+
+/// Bounds of type parameter `name` in `<name: ...>` and `where name: ...`.
+fn collect_type_parameter_bounds(node: Node, code: &str, name: &str, bounds: &mut Vec<String>) {
+    let declared = match node.kind() {
+        "type_parameter" => node.child_by_field_name("name"),
+        "where_predicate" => node.child_by_field_name("left"),
+        _ => None,
+    };
+    if declared.is_some_and(|declared| &code[declared.byte_range()] == name) {
+        if let Some(trait_bounds) = node.child_by_field_name("bounds") {
+            collect_bound_names(trait_bounds, code, bounds);
+        }
+    }
+    for child in node.children(&mut node.walk()) {
+        collect_type_parameter_bounds(child, code, name, bounds);
+    }
+}
+
+/// Trait names of a bound list, without lifetimes and `?Sized`.
+fn collect_bound_names(node: Node, code: &str, bounds: &mut Vec<String>) {
+    match node.kind() {
+        "trait_bounds" | "bounded_type" => {
+            for child in node.named_children(&mut node.walk()) {
+                collect_bound_names(child, code, bounds);
+            }
+        }
+        "higher_ranked_trait_bound" => {
+            if let Some(bound) = node.child_by_field_name("type") {
+                collect_bound_names(bound, code, bounds);
+            }
+        }
+        "lifetime" | "removed_trait_bound" => {}
+        _ => {
+            if let Some(name) = reduce_type_to_name(node, code) {
+                if !bounds.contains(&name) {
+                    bounds.push(name);
+                }
+            }
+        }
+    }
+}
+
 /// Strip references / lifetimes / mut / generics / module path to reach the
 /// bare leaf type name.
 fn reduce_type_to_name(node: Node, code: &str) -> Option<String> {
@@ -204,6 +278,17 @@ impl LanguageBehavior for RustBehavior {
         find_parameter_type(tree.root_node(), signature, var_name)
     }
 
+    fn parameter_type_bounds(&self, signature: &str, var_name: &str) -> Vec<String> {
+        let mut parser = tree_sitter::Parser::new();
+        if parser.set_language(&self.language).is_err() {
+            return Vec::new();
+        }
+        let Some(tree) = parser.parse(signature, None) else {
+            return Vec::new();
+        };
+        find_parameter_bounds(tree.root_node(), signature, var_name)
+    }
+
     fn is_resolvable_symbol(&self, symbol: &crate::Symbol) -> bool {
         use crate::SymbolKind;
         use crate::symbol::ScopeContext;
@@ -331,6 +416,30 @@ impl LanguageBehavior for RustBehavior {
         self.state.get_module_path(file_id)
     }
 
+    /// `pub use` paths made absolute: `self::` and `super::` against the
+    /// module, other non-`crate::` paths as 2018 paths relative to it
+    fn reexport_import_path(
+        &self,
+        import: &crate::parsing::Import,
+        module: &str,
+        _file_path: &Path,
+    ) -> Option<String> {
+        let path = import.path.as_str();
+        if path.starts_with("crate::") {
+            return Some(path.to_string());
+        }
+        if let Some(rest) = path.strip_prefix("self::") {
+            return Some(format!("{module}::{rest}"));
+        }
+        let mut base = module;
+        let mut rest = path;
+        while let Some(tail) = rest.strip_prefix("super::") {
+            base = base.rsplit_once("::")?.0;
+            rest = tail;
+        }
+        Some(format!("{base}::{rest}"))
+    }
+
     fn import_matches_symbol(
         &self,
         import_path: &str,
@@ -532,6 +641,40 @@ mod tests {
         );
     }
 
+    #[test]
+    fn test_reexport_import_path_is_absolute() {
+        let behavior = RustBehavior::new();
+        let path = |import_path: &str| {
+            let import = crate::parsing::Import {
+                path: import_path.to_string(),
+                alias: None,
+                file_id: FileId::new(1).unwrap(),
+                is_glob: false,
+                is_type_only: false,
+            };
+            behavior.reexport_import_path(&import, "crate::api::v1", Path::new("src/api/v1.rs"))
+        };
+
+        assert_eq!(
+            path("crate::inner::parse").as_deref(),
+            Some("crate::inner::parse")
+        );
+        assert_eq!(
+            path("self::wire::Frame").as_deref(),
+            Some("crate::api::v1::wire::Frame")
+        );
+        assert_eq!(path("super::Client").as_deref(), Some("crate::api::Client"));
+        assert_eq!(
+            path("super::super::Config").as_deref(),
+            Some("crate::Config")
+        );
+        assert_eq!(
+            path("wire::Frame").as_deref(),
+            Some("crate::api::v1::wire::Frame")
+        );
+        assert_eq!(path("super::super::super::Config"), None);
+    }
+
     #[test]
     fn test_import_matches_symbol_reexport_cases() {
         let behavior = RustBehavior::new();
@@ -0,0 +1,863 @@
+//! Symbols Rust macros generate
+//!
+//! The parser sees a macro invocation, not its expansion, so the `clone` of
+//! a `#[derive(Clone)]` or a function written by a `macro_rules!` rule
+//! never reaches the index. This pass synthesizes the common cases without
+//! expanding anything:
+//!
+//! - derives of std and serde traits: the trait impl and its methods
+//! - thiserror's `#[derive(Error)]`: `Display::fmt`, and `From::from` for
+//!   each `#[from]` field
+//! - the functions, methods and trait impls of a `macro_rules!` defined in
+//!   the same file, for rules whose matcher has no repetition
+//!
+//! The indexer runs it when `indexing.rust_macros` is set.
+
+use crate::parsing::rust::attributes;
+use crate::{Range, SymbolKind, Visibility};
+use std::collections::HashMap;
+use tree_sitter::Node;
+
+/// Methods of the derivable traits, with the signature of their impl;
+/// marker traits have none
+const DERIVED_METHODS: &[(&str, &[(&str, &str)])] = &[
+    ("Clone", &[("clone", "fn clone(&self) -> Self")]),
+    ("Copy", &[]),
+    (
+        "Debug",
+        &[(
+            "fmt",
+            "fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result",
+        )],
+    ),
+    ("Default", &[("default", "fn default() -> Self")]),
+    ("PartialEq", &[("eq", "fn eq(&self, other: &Self) -> bool")]),
+    ("Eq", &[]),
+    (
+        "PartialOrd",
+        &[(
+            "partial_cmp",
+            "fn partial_cmp(&self, other: &Self) -> Option<Ordering>",
+        )],
+    ),
+    ("Ord", &[("cmp", "fn cmp(&self, other: &Self) -> Ordering")]),
+    (
+        "Hash",
+        &[("hash", "fn hash<H: Hasher>(&self, state: &mut H)")],
+    ),
+    (
+        "Serialize",
+        &[(
+            "serialize",
+            "fn serialize<S: Serializer>(&self, serializer: S) -> Result<S::Ok, S::Error>",
+        )],
+    ),
+    (
+        "Deserialize",
+        &[(
+            "deserialize",
+            "fn deserialize<D: Deserializer<'de>>(deserializer: D) -> Result<Self, D::Error>",
+        )],
+    ),
+];
+
+/// A function or method a macro generates
+#[derive(Debug, Clone, PartialEq)]
+pub struct GeneratedSymbol {
+    pub name: String,
+    /// `Method` when the symbol belongs to an impl, `Function` otherwise
+    pub kind: SymbolKind,
+    /// The derive attribute, `#[from]` field or invocation generating it
+    pub range: Range,
+    pub signature: String,
+    pub visibility: Visibility,
+    /// The type whose impl holds the method
+    pub owner: Option<String>,
+    /// What generates it: `#[derive(Clone)]` or `getter!`
+    pub origin: String,
+}
+
+/// A trait impl a macro generates
+#[derive(Debug, Clone, PartialEq)]
+pub struct GeneratedImpl {
+    pub type_name: String,
+    pub trait_name: String,
+    /// The deriving item, or the invocation writing the impl
+    pub range: Range,
+}
+
+/// Everything the macros of a file generate
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct Expansion {
+    pub symbols: Vec<GeneratedSymbol>,
+    pub impls: Vec<GeneratedImpl>,
+}
+
+/// Whether a file may use a macro this pass knows, to skip parsing it
+/// again when it cannot
+pub fn may_generate(content: &str) -> bool {
+    content.contains("derive") || content.contains("macro_rules!")
+}
+
+/// The symbols and impls generated in a file
+pub fn expand(root: Node, code: &str) -> Expansion {
+    let mut macros = HashMap::new();
+    collect_macros(root, code, &mut macros);
+
+    let mut expansion = Expansion::default();
+    walk(root, code, None, &macros, &mut expansion);
+    expansion
+}
+
+/// One element of a matcher: a token to match as is, or a fragment to bind
+#[derive(Debug, Clone, PartialEq)]
+enum Matcher {
+    Token(String),
+    Binding { name: String, fragment: String },
+}
+
+/// A rule of a `macro_rules!`: its matcher, and the token tree it writes
+struct Rule<'tree> {
+    matcher: Vec<Matcher>,
+    transcriber: Node<'tree>,
+}
+
+fn text<'a>(node: Node, code: &'a str) -> &'a str {
+    &code[node.byte_range()]
+}
+
+fn range_of(node: Node) -> Range {
+    Range::new(
+        node.start_position().row as u32,
+        node.start_position().column as u16,
+        node.end_position().row as u32,
+        node.end_position().column as u16,
+    )
+}
+
+/// The children of a token tree between its delimiters
+fn inner_tokens(tree: Node) -> Vec<Node> {
+    let count = tree.child_count();
+    let mut cursor = tree.walk();
+    tree.children(&mut cursor)
+        .enumerate()
+        .filter(|(index, _)| *index > 0 && *index + 1 < count)
+        .map(|(_, child)| child)
+        .collect()
+}
+
+/// `Config` for `crate::config::Config<'a>` or `&Config`
+fn bare_name(type_text: &str) -> &str {
+    let name = type_text
+        .trim()
+        .trim_start_matches('&')
+        .split('<')
+        .next()
+        .unwrap_or_default()
+        .trim();
+    name.rsplit("::").next().unwrap_or(name).trim()
+}
+
+fn is_identifier(name: &str) -> bool {
+    !name.is_empty()
+        && !name.starts_with(|c: char| c.is_ascii_digit())
+        && name.chars().all(|c| c.is_alphanumeric() || c == '_')
+}
+
+/// `macro_rules!` definitions, by name, anywhere in the file
+fn collect_macros<'tree>(
+    node: Node<'tree>,
+    code: &str,
+    macros: &mut HashMap<String, Vec<Rule<'tree>>>,
+) {
+    if node.kind() == "macro_definition" {
+        if let Some(name) = node.child_by_field_name("name") {
+            let mut cursor = node.walk();
+            let rules = node
+                .children(&mut cursor)
+                .filter(|child| child.kind() == "macro_rule")
+                .filter_map(|rule| {
+                    Some(Rule {
+                        matcher: matcher(rule.child_by_field_name("left")?, code)?,
+                        transcriber: rule.child_by_field_name("right")?,
+                    })
+                })
+                .collect();
+            macros.insert(text(name, code).to_string(), rules);
+        }
+        return;
+    }
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        collect_macros(child, code, macros);
+    }
+}
+
+/// The matcher of a rule, `None` when it nests or repeats
+fn matcher(pattern: Node, code: &str) -> Option<Vec<Matcher>> {
+    inner_tokens(pattern)
+        .into_iter()
+        .map(|token| match token.kind() {
+            "token_binding_pattern" => Some(Matcher::Binding {
+                name: text(token.child_by_field_name("name")?, code).to_string(),
+                fragment: text(token.child_by_field_name("type")?, code).to_string(),
+            }),
+            "token_tree_pattern" | "token_repetition_pattern" => None,
+            _ => Some(Matcher::Token(text(token, code).to_string())),
+        })
+        .collect()
+}
+
+/// Change in `<>` nesting of a token: `->` and `=>` are arrows, not
+/// brackets
+fn angle_depth(token: &str) -> i32 {
+    if token == "->" || token == "=>" {
+        return 0;
+    }
+    token.matches('<').count() as i32 - token.matches('>').count() as i32
+}
+
+/// The fragments a matcher binds in the tokens of an invocation, keyed by
+/// metavariable (`$name`)
+///
+/// Identifiers, literals and token trees are one token; other
+/// fragments run to the token the matcher expects next, outside `<>`.
+fn match_rule(matcher: &[Matcher], tokens: &[Node], code: &str) -> Option<HashMap<String, String>> {
+    let mut bindings = HashMap::new();
+    let mut at = 0;
+    for (index, element) in matcher.iter().enumerate() {
+        match element {
+            Matcher::Token(expected) => {
+                if text(*tokens.get(at)?, code) != expected.as_str() {
+                    return None;
+                }
+                at += 1;
+            }
+            Matcher::Binding { name, fragment } => {
+                let end = match (fragment.as_str(), matcher.get(index + 1)) {
+                    ("ident" | "literal" | "tt", _) | (_, Some(Matcher::Binding { .. })) => at + 1,
+                    (_, Some(Matcher::Token(next))) => {
+                        let mut depth = 0;
+                        at + tokens[at..].iter().position(|token| {
+                            let token = text(*token, code);
+                            let stop = depth == 0 && token == next.as_str();
+                            depth += angle_depth(token);
+                            stop
+                        })?
+                    }
+                    (_, None) => tokens.len(),
+                };
+                if end <= at || end > tokens.len() {
+                    return None;
+                }
+                let bound = &code[tokens[at].start_byte()..tokens[end - 1].end_byte()];
+                bindings.insert(name.clone(), bound.to_string());
+                at = end;
+            }
+        }
+    }
+    (at == tokens.len()).then_some(bindings)
+}
+
+/// Text with its metavariables replaced by what they bound, the longest
+/// names first so `$name` does not eat into `$name_len`
+fn substitute(template: &str, bindings: &HashMap<String, String>) -> String {
+    let mut names: Vec<&String> = bindings.keys().collect();
+    names.sort_by_key(|name| std::cmp::Reverse(name.len()));
+    let mut result = template.to_string();
+    for name in names {
+        result = result.replace(name.as_str(), &bindings[name]);
+    }
+    result
+}
+
+/// Outer attributes of an item, doc comments between them skipped
+fn outer_attributes(item: Node) -> Vec<Node> {
+    let mut found = Vec::new();
+    let mut sibling = item.prev_named_sibling();
+    while let Some(node) = sibling {
+        match node.kind() {
+            "attribute_item" => found.push(node),
+            "line_comment" | "block_comment" => {}
+            _ => break,
+        }
+        sibling = node.prev_named_sibling();
+    }
+    found.reverse();
+    found
+}
+
+/// The traits of a `#[derive(..)]`, as written: `serde::Serialize`
+fn derived_traits(attribute: &str) -> Vec<&str> {
+    if attributes::path(attribute) != "derive" {
+        return Vec::new();
+    }
+    let Some(arguments) = attribute
+        .split_once('(')
+        .and_then(|(_, rest)| rest.rsplit_once(')'))
+        .map(|(arguments, _)| arguments)
+    else {
+        return Vec::new();
+    };
+    arguments
+        .split(',')
+        .map(str::trim)
+        .filter(|path| !path.is_empty())
+        .collect()
+}
+
+/// Attribute items under a node with the given path
+fn nested_attributes<'tree>(
+    node: Node<'tree>,
+    code: &str,
+    path: &str,
+    found: &mut Vec<Node<'tree>>,
+) {
+    if node.kind() == "attribute_item" && attributes::path(text(node, code)) == path {
+        found.push(node);
+    }
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        nested_attributes(child, code, path, found);
+    }
+}
+
+/// Whether an invocation stands where items do: at module level or in an
+/// impl, trait or mod body, not inside a function where it writes locals
+fn is_item_level(invocation: Node) -> bool {
+    let mut parent = invocation.parent();
+    if parent.is_some_and(|node| node.kind() == "expression_statement") {
+        parent = parent.and_then(|node| node.parent());
+    }
+    parent.is_some_and(|node| matches!(node.kind(), "source_file" | "declaration_list"))
+}
+
+/// Derives and invocations under a node, `owner` naming the type of the
+/// impl it is in
+fn walk(
+    node: Node,
+    code: &str,
+    owner: Option<&str>,
+    macros: &HashMap<String, Vec<Rule>>,
+    expansion: &mut Expansion,
+) {
+    match node.kind() {
+        "struct_item" | "enum_item" | "union_item" => derive(node, code, expansion),
+        "impl_item" => {
+            if let (Some(type_node), Some(body)) = (
+                node.child_by_field_name("type"),
+                node.child_by_field_name("body"),
+            ) {
+                let type_name = bare_name(text(type_node, code)).to_string();
+                walk(body, code, Some(&type_name), macros, expansion);
+            }
+            return;
+        }
+        "macro_invocation" if is_item_level(node) => {
+            invoke(node, code, owner, macros, expansion);
+            return;
+        }
+        // Items written in a function body are its locals
+        "function_item" => return,
+        _ => {}
+    }
+    // A mod body does not belong to the impl around it
+    let owner = if node.kind() == "mod_item" {
+        None
+    } else {
+        owner
+    };
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        walk(child, code, owner, macros, expansion);
+    }
+}
+
+/// The impls and methods the derives of a struct, enum or union generate
+fn derive(item: Node, code: &str, expansion: &mut Expansion) {
+    let Some(name) = item.child_by_field_name("name") else {
+        return;
+    };
+    let type_name = text(name, code);
+    for attribute in outer_attributes(item) {
+        for path in derived_traits(text(attribute, code)) {
+            let trait_name = bare_name(path);
+            if trait_name == "Error" {
+                derive_error(item, type_name, code, expansion);
+                continue;
+            }
+            let Some((_, methods)) = DERIVED_METHODS
+                .iter()
+                .find(|(derivable, _)| *derivable == trait_name)
+            else {
+                continue;
+            };
+            expansion.impls.push(GeneratedImpl {
+                type_name: type_name.to_string(),
+                trait_name: trait_name.to_string(),
+                range: range_of(item),
+            });
+            for (method, signature) in methods.iter() {
+                expansion.symbols.push(GeneratedSymbol {
+                    name: method.to_string(),
+                    kind: SymbolKind::Method,
+                    range: range_of(attribute),
+                    signature: signature.to_string(),
+                    visibility: Visibility::Public,
+                    owner: Some(type_name.to_string()),
+                    origin: format!("#[derive({trait_name})]"),
+                });
+            }
+        }
+    }
+}
+
+/// thiserror's derive: `Error`, `Display` through the `#[error(..)]`
+/// messages, and `From` for each `#[from]` field
+///
+/// An `Error` derive without messages is some other crate's and is left
+/// alone.
+fn derive_error(item: Node, type_name: &str, code: &str, expansion: &mut Expansion) {
+    let mut messages: Vec<Node> = outer_attributes(item)
+        .into_iter()
+        .filter(|attribute| attributes::path(text(*attribute, code)) == "error")
+        .collect();
+    if let Some(body) = item.child_by_field_name("body") {
+        nested_attributes(body, code, "error", &mut messages);
+    }
+    let Some(message) = messages.first() else {
+        return;
+    };
+
+    let origin = "#[derive(Error)]".to_string();
+    let implement = |trait_name: &str| GeneratedImpl {
+        type_name: type_name.to_string(),
+        trait_name: trait_name.to_string(),
+        range: range_of(item),
+    };
+    expansion.impls.push(implement("Error"));
+    expansion.impls.push(implement("Display"));
+    expansion.symbols.push(GeneratedSymbol {
+        name: "fmt".to_string(),
+        kind: SymbolKind::Method,
+        // Not the derive: a derived `Debug::fmt` sits there
+        range: range_of(*message),
+        signature: "fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result".to_string(),
+        visibility: Visibility::Public,
+        owner: Some(type_name.to_string()),
+        origin: origin.clone(),
+    });
+
+    let mut sources = Vec::new();
+    if let Some(body) = item.child_by_field_name("body") {
+        nested_attributes(body, code, "from", &mut sources);
+    }
+    let mut implements_from = false;
+    for source in sources {
+        let mut field = source.next_named_sibling();
+        while let Some(node) = field {
+            if !matches!(
+                node.kind(),
+                "attribute_item" | "line_comment" | "block_comment" | "visibility_modifier"
+            ) {
+                break;
+            }
+            field = node.next_named_sibling();
+        }
+        let Some(field) = field else {
+            continue;
+        };
+        let source_type = match field.kind() {
+            "field_declaration" => field.child_by_field_name("type"),
+            _ => Some(field),
+        };
+        let Some(source_type) = source_type else {
+            continue;
+        };
+        if !implements_from {
+            expansion.impls.push(implement("From"));
+            implements_from = true;
+        }
+        expansion.symbols.push(GeneratedSymbol {
+            name: "from".to_string(),
+            kind: SymbolKind::Method,
+            range: range_of(field),
+            signature: format!("fn from(source: {}) -> Self", text(source_type, code)),
+            visibility: Visibility::Public,
+            owner: Some(type_name.to_string()),
+            origin: origin.clone(),
+        });
+    }
+}
+
+/// The items an invocation of a same-file `macro_rules!` writes, through
+/// its first matching rule
+fn invoke(
+    invocation: Node,
+    code: &str,
+    owner: Option<&str>,
+    macros: &HashMap<String, Vec<Rule>>,
+    expansion: &mut Expansion,
+) {
+    let Some(name) = invocation.child_by_field_name("macro") else {
+        return;
+    };
+    let Some(rules) = macros.get(bare_name(text(name, code))) else {
+        return;
+    };
+    let Some(arguments) = invocation
+        .children(&mut invocation.walk())
+        .find(|child| child.kind() == "token_tree")
+    else {
+        return;
+    };
+    let tokens = inner_tokens(arguments);
+    let Some((rule, bindings)) = rules
+        .iter()
+        .find_map(|rule| Some((rule, match_rule(&rule.matcher, &tokens, code)?)))
+    else {
+        return;
+    };
+    let site = Written {
+        code,
+        bindings: &bindings,
+        range: range_of(invocation),
+        origin: format!("{}!", text(name, code)),
+    };
+    site.transcribe(rule.transcriber, owner, expansion);
+}
+
+/// An invocation being written out
+struct Written<'a> {
+    code: &'a str,
+    bindings: &'a HashMap<String, String>,
+    range: Range,
+    origin: String,
+}
+
+impl Written<'_> {
+    /// Source between two byte offsets, metavariables substituted
+    fn source(&self, start: usize, end: usize) -> String {
+        substitute(self.code[start..end].trim(), self.bindings)
+    }
+
+    fn transcribe(&self, tree: Node, owner: Option<&str>, expansion: &mut Expansion) {
+        let tokens = inner_tokens(tree);
+        let is_block =
+            |token: &Node| token.kind() == "token_tree" && text(*token, self.code).starts_with('{');
+        let mut index = 0;
+        while index < tokens.len() {
+            let token = tokens[index];
+            match text(token, self.code) {
+                "fn" => {
+                    let body = (index + 1..tokens.len())
+                        .find(|at| is_block(&tokens[*at]) || text(tokens[*at], self.code) == ";");
+                    if let (Some(name), Some(body)) = (tokens.get(index + 1), body) {
+                        self.function(&tokens, index, *name, tokens[body], owner, expansion);
+                        index = body;
+                    }
+                }
+                "impl" => {
+                    if let Some(body) = (index + 1..tokens.len()).find(|at| is_block(&tokens[*at]))
+                    {
+                        let header = self.source(token.end_byte(), tokens[body].start_byte());
+                        let header = strip_leading_generics(&header);
+                        let (trait_name, type_text) = match header.split_once(" for ") {
+                            Some((trait_name, type_text)) => (Some(trait_name), type_text),
+                            None => (None, header),
+                        };
+                        let type_name = bare_name(type_text).to_string();
+                        if let Some(trait_name) = trait_name {
+                            expansion.impls.push(GeneratedImpl {
+                                type_name: type_name.clone(),
+                                trait_name: bare_name(trait_name).to_string(),
+                                range: self.range,
+                            });
+                        }
+                        self.transcribe(tokens[body], Some(&type_name), expansion);
+                        index = body;
+                    }
+                }
+                _ if is_block(&token) => self.transcribe(token, owner, expansion),
+                _ => {}
+            }
+            index += 1;
+        }
+    }
+
+    /// The function whose `fn` keyword is `tokens[at]`
+    fn function(
+        &self,
+        tokens: &[Node],
+        at: usize,
+        name: Node,
+        body: Node,
+        owner: Option<&str>,
+        expansion: &mut Expansion,
+    ) {
+        let name = substitute(text(name, self.code), self.bindings);
+        if !is_identifier(&name) {
+            return;
+        }
+        // `pub`, `pub(crate)` or a `$vis` before the keyword
+        let mut start = at;
+        if start > 0 {
+            let previous = text(tokens[start - 1], self.code);
+            if previous == "pub" || previous.starts_with('$') {
+                start -= 1;
+            } else if previous.starts_with('(')
+                && start > 1
+                && text(tokens[start - 2], self.code) == "pub"
+            {
+                start -= 2;
+            }
+        }
+        let signature = self.source(tokens[start].start_byte(), body.start_byte());
+        let visibility = if signature.starts_with("pub(crate)") {
+            Visibility::Crate
+        } else if signature.starts_with("pub") {
+            Visibility::Public
+        } else {
+            Visibility::Private
+        };
+        expansion.symbols.push(GeneratedSymbol {
+            name,
+            kind: if owner.is_some() {
+                SymbolKind::Method
+            } else {
+                SymbolKind::Function
+            },
+            range: self.range,
+            signature,
+            visibility,
+            owner: owner.map(str::to_string),
+            origin: self.origin.clone(),
+        });
+    }
+}
+
+/// `Show for Wrapper<T>` for the header `<T> Show for Wrapper<T>`
+fn strip_leading_generics(header: &str) -> &str {
+    if !header.starts_with('<') {
+        return header;
+    }
+    let mut depth = 0;
+    for (offset, c) in header.char_indices() {
+        match c {
+            '<' => depth += 1,
+            '>' => {
+                depth -= 1;
+                if depth == 0 {
+                    return header[offset + 1..].trim();
+                }
+            }
+            _ => {}
+        }
+    }
+    header
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn expand_source(code: &str) -> Expansion {
+        let mut parser = tree_sitter::Parser::new();
+        parser
+            .set_language(&tree_sitter_rust::LANGUAGE.into())
+            .unwrap();
+        let tree = parser.parse(code, None).unwrap();
+        expand(tree.root_node(), code)
+    }
+
+    fn methods(expansion: &Expansion) -> Vec<(Option<&str>, &str)> {
+        expansion
+            .symbols
+            .iter()
+            .map(|symbol| (symbol.owner.as_deref(), symbol.name.as_str()))
+            .collect()
+    }
+
+    fn impls(expansion: &Expansion) -> Vec<(&str, &str)> {
+        expansion
+            .impls
+            .iter()
+            .map(|generated| (generated.type_name.as_str(), generated.trait_name.as_str()))
+            .collect()
+    }
+
+    #[test]
+    fn test_derives_implement_traits_and_their_methods() {
+        let expansion = expand_source(
+            r#"
+/// Settings of a run
+#[derive(Debug, Clone, Copy, serde::Serialize, Builder)]
+pub struct Config {
+    retries: u32,
+}
+"#,
+        );
+
+        assert_eq!(
+            impls(&expansion),
+            [
+                ("Config", "Debug"),
+                ("Config", "Clone"),
+                ("Config", "Copy"),
+                ("Config", "Serialize")
+            ],
+            "unknown derives generate nothing"
+        );
+        assert_eq!(
+            methods(&expansion),
+            [
+                (Some("Config"), "fmt"),
+                (Some("Config"), "clone"),
+                (Some("Config"), "serialize")
+            ]
+        );
+        let clone = &expansion.symbols[1];
+        assert_eq!(clone.kind, SymbolKind::Method);
+        assert_eq!(clone.signature, "fn clone(&self) -> Self");
+        assert_eq!(clone.origin, "#[derive(Clone)]");
+        assert_eq!(clone.range.start_line, 2, "at the derive");
+        assert_eq!(expansion.impls[0].range.start_line, 3, "from the struct");
+    }
+
+    #[test]
+    fn test_thiserror_generates_display_and_from() {
+        let expansion = expand_source(
+            r#"
+#[derive(Debug, thiserror::Error)]
+pub enum LoadError {
+    #[error("cannot read: {0}")]
+    Io(#[from] std::io::Error),
+    #[error("bad syntax")]
+    Parse {
+        #[from]
+        source: toml::de::Error,
+    },
+}
+
+#[derive(Error)]
+pub struct NotThiserror;
+"#,
+        );
+
+        assert_eq!(
+            impls(&expansion),
+            [
+                ("LoadError", "Debug"),
+                ("LoadError", "Error"),
+                ("LoadError", "Display"),
+                ("LoadError", "From")
+            ]
+        );
+        assert_eq!(
+            methods(&expansion),
+            [
+                (Some("LoadError"), "fmt"),
+                (Some("LoadError"), "fmt"),
+                (Some("LoadError"), "from"),
+                (Some("LoadError"), "from")
+            ]
+        );
+        assert_ne!(
+            expansion.symbols[0].range, expansion.symbols[1].range,
+            "Debug::fmt and Display::fmt"
+        );
+        assert_eq!(
+            expansion.symbols[2].signature,
+            "fn from(source: std::io::Error) -> Self"
+        );
+        assert_eq!(
+            expansion.symbols[3].signature,
+            "fn from(source: toml::de::Error) -> Self"
+        );
+    }
+
+    #[test]
+    fn test_macro_rules_write_functions_methods_and_impls() {
+        let expansion = expand_source(
+            r#"
+macro_rules! getter {
+    ($name:ident, $field:ident: $ty:ty) => {
+        pub fn $name(&self) -> &$ty {
+            &self.$field
+        }
+    };
+}
+
+macro_rules! handler {
+    ($name:ident for $target:ident) => {
+        fn $name() {}
+
+        impl Handler for $target {
+            fn handle(&self) {}
+        }
+    };
+}
+
+macro_rules! repeated {
+    ($($name:ident),*) => {
+        $(fn $name() {})*
+    };
+}
+
+struct Config;
+
+impl Config {
+    getter!(label, name: Vec<String>);
+}
+
+handler!(on_start for Config);
+repeated!(a, b);
+
+fn main() {
+    handler!(local for Config);
+}
+"#,
+        );
+
+        assert_eq!(
+            methods(&expansion),
+            [
+                (Some("Config"), "label"),
+                (None, "on_start"),
+                (Some("Config"), "handle")
+            ]
+        );
+        assert_eq!(impls(&expansion), [("Config", "Handler")]);
+
+        let label = &expansion.symbols[0];
+        assert_eq!(label.kind, SymbolKind::Method);
+        assert_eq!(label.signature, "pub fn label(&self) -> &Vec<String>");
+        assert_eq!(label.visibility, Visibility::Public);
+        assert_eq!(label.origin, "getter!");
+        assert_eq!(label.range.start_line, 28, "at the invocation");
+
+        let on_start = &expansion.symbols[1];
+        assert_eq!(on_start.kind, SymbolKind::Function);
+        assert_eq!(on_start.signature, "fn on_start()");
+        assert_eq!(on_start.visibility, Visibility::Private);
+    }
+
+    #[test]
+    fn test_names_and_substitution() {
+        assert_eq!(bare_name("&crate::config::Config<'a>"), "Config");
+        assert_eq!(
+            strip_leading_generics("<T> Show for Wrapper<T>"),
+            "Show for Wrapper<T>"
+        );
+        assert_eq!(angle_depth("->"), 0);
+        assert_eq!(angle_depth(">>"), -2);
+
+        let mut bindings = HashMap::new();
+        bindings.insert("$name".to_string(), "id".to_string());
+        bindings.insert("$name_len".to_string(), "8".to_string());
+        assert_eq!(
+            substitute("fn $name() -> [u8; $name_len]", &bindings),
+            "fn id() -> [u8; 8]"
+        );
+    }
+}
@@ -1,8 +1,10 @@
 //! Rust language parser implementation
 
+pub mod attributes;
 pub mod audit;
 pub mod behavior;
 pub mod definition;
+pub mod macros;
 pub mod parser;
 pub mod resolution;
 
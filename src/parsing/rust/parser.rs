@@ -30,9 +30,11 @@
 //! - Using generator-based tree traversal or manual state machines
 //! - Eliminating all intermediate allocations
 
+use super::attributes;
 use crate::parsing::Import;
 use crate::parsing::method_call::MethodCall;
 use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::value_reference::{Access, ValueReference, is_constant_case};
 use crate::parsing::{
     HandledNode, Language, LanguageParser, NodeTracker, NodeTrackingState, ParserContext, ScopeType,
 };
@@ -342,8 +344,13 @@ impl RustParser {
                     if let Some(mut symbol) =
                         self.create_symbol(counter, node, name_node, kind, file_id, code)
                     {
-                        // Extract and add function signature
-                        let signature = self.extract_signature(node, code);
+                        // Extract and add function signature; a test or an FFI
+                        // binding keeps the attribute that makes it one
+                        let mut signature = self.extract_signature(node, code);
+                        let kept = self.kept_attributes(&node, code);
+                        if !kept.is_empty() {
+                            signature = format!("{}\n{signature}", kept.join("\n"));
+                        }
                         symbol = symbol.with_signature(signature);
                         symbols.push(symbol);
                     }
@@ -810,6 +817,182 @@ impl RustParser {
         methods
     }
 
+    /// Find reads and writes of fields, constants and statics
+    pub fn find_value_references(&mut self, code: &str) -> Vec<ValueReference> {
+        let tree = match self.parser.parse(code, None) {
+            Some(tree) => tree,
+            None => return Vec::new(),
+        };
+
+        let root_node = tree.root_node();
+        let mut references = Vec::new();
+
+        self.find_value_references_in_node(root_node, code, &mut references);
+
+        references
+    }
+
+    /// Field expressions (`self.config.timeout`), struct literal fields
+    /// (`Config { timeout: 5 }`) and SCREAMING_CASE names (`MAX_RETRIES`,
+    /// `Config::DEFAULT`) inside functions. Callees are left to
+    /// find_method_calls; `use` paths and attributes name no value.
+    fn find_value_references_in_node(
+        &self,
+        node: Node,
+        code: &str,
+        references: &mut Vec<ValueReference>,
+    ) {
+        if matches!(
+            node.kind(),
+            "use_declaration" | "attribute_item" | "inner_attribute_item"
+        ) {
+            return;
+        }
+
+        if let Some(context) = self.find_containing_function(node, code) {
+            let range = Range::new(
+                node.start_position().row as u32,
+                node.start_position().column as u16,
+                node.end_position().row as u32,
+                node.end_position().column as u16,
+            );
+            match node.kind() {
+                "field_expression" if !Self::is_callee(node) => {
+                    if let (Some(value), Some(field)) = (
+                        node.child_by_field_name("value"),
+                        node.child_by_field_name("field"),
+                    ) {
+                        // Tuple fields (`self.0`) name nothing to resolve
+                        if field.kind() == "field_identifier" {
+                            references.push(
+                                ValueReference::new(
+                                    context,
+                                    &code[field.byte_range()],
+                                    Self::access_of(node),
+                                    range,
+                                )
+                                .with_receiver(&code[value.byte_range()]),
+                            );
+                        }
+                    }
+                }
+                "field_initializer" | "shorthand_field_initializer" => {
+                    let field = if node.kind() == "field_initializer" {
+                        node.child_by_field_name("field")
+                    } else {
+                        node.named_child(0)
+                    };
+                    let struct_name = node
+                        .parent()
+                        .and_then(|list| list.parent())
+                        .filter(|literal| literal.kind() == "struct_expression")
+                        .and_then(|literal| literal.child_by_field_name("name"))
+                        .map(|name| {
+                            code[name.byte_range()]
+                                .split('<')
+                                .next()
+                                .unwrap_or_default()
+                        })
+                        .and_then(|name| name.rsplit("::").next());
+                    if let (Some(field), Some(struct_name)) = (field, struct_name) {
+                        references.push(
+                            ValueReference::new(
+                                context,
+                                &code[field.byte_range()],
+                                Access::Write,
+                                range,
+                            )
+                            .with_receiver(struct_name),
+                        );
+                    }
+                }
+                "identifier" => {
+                    let name = &code[node.byte_range()];
+                    if is_constant_case(name) && Self::names_value(node) {
+                        references.push(ValueReference::new(
+                            context,
+                            name,
+                            Self::access_of(node),
+                            range,
+                        ));
+                    }
+                }
+                "scoped_identifier"
+                    if !Self::is_callee(node)
+                        && node
+                            .parent()
+                            .is_none_or(|parent| parent.kind() != "scoped_identifier") =>
+                {
+                    if let (Some(path), Some(name)) = (
+                        node.child_by_field_name("path"),
+                        node.child_by_field_name("name"),
+                    ) {
+                        let name = &code[name.byte_range()];
+                        if is_constant_case(name) {
+                            references.push(
+                                ValueReference::new(context, name, Self::access_of(node), range)
+                                    .with_receiver(&code[path.byte_range()]),
+                            );
+                        }
+                    }
+                }
+                _ => {}
+            }
+        }
+
+        // Recurse into children
+        for child in node.children(&mut node.walk()) {
+            self.find_value_references_in_node(child, code, references);
+        }
+    }
+
+    /// Whether an expression is the function a call expression calls
+    fn is_callee(node: Node) -> bool {
+        let mut callee = node;
+        if let Some(parent) = node.parent().filter(|p| p.kind() == "generic_function") {
+            callee = parent;
+        }
+        callee.parent().is_some_and(|call| {
+            call.kind() == "call_expression" && call.child_by_field_name("function") == Some(callee)
+        })
+    }
+
+    /// Whether an identifier reads or writes a value: not a definition, a
+    /// callee, a macro name or a segment of a path
+    fn names_value(node: Node) -> bool {
+        let Some(parent) = node.parent() else {
+            return false;
+        };
+        match parent.kind() {
+            "scoped_identifier"
+            | "shorthand_field_initializer"
+            | "const_item"
+            | "static_item"
+            | "const_parameter"
+            | "enum_variant"
+            | "function_item"
+            | "macro_invocation"
+            | "parameter" => false,
+            "let_declaration" => parent.child_by_field_name("pattern") != Some(node),
+            _ => !Self::is_callee(node),
+        }
+    }
+
+    /// Whether an expression is the target of an assignment
+    fn access_of(node: Node) -> Access {
+        let assigned = node.parent().is_some_and(|parent| {
+            matches!(
+                parent.kind(),
+                "assignment_expression" | "compound_assignment_expr"
+            ) && parent.child_by_field_name("left") == Some(node)
+        });
+        if assigned {
+            Access::Write
+        } else {
+            Access::Read
+        }
+    }
+
     fn find_calls_in_node<'a>(
         &self,
         node: Node,
@@ -1217,6 +1400,28 @@ impl RustParser {
         code[start..end].trim().to_string()
     }
 
+    /// The attributes above a function the signature keeps, in source
+    /// order: test attributes and FFI bindings (see [`attributes`])
+    fn kept_attributes<'a>(&self, node: &Node, code: &'a str) -> Vec<&'a str> {
+        let mut kept = Vec::new();
+        let mut current = node.prev_sibling();
+        while let Some(sibling) = current {
+            match sibling.kind() {
+                "attribute_item" => {
+                    let text = code[sibling.byte_range()].trim();
+                    if attributes::is_kept(text) {
+                        kept.push(text);
+                    }
+                }
+                "line_comment" | "block_comment" => {}
+                _ => break,
+            }
+            current = sibling.prev_sibling();
+        }
+        kept.reverse();
+        kept
+    }
+
     /// Extract struct signature including generics and visibility
     fn extract_struct_signature(&self, node: Node, code: &str) -> String {
         let start = node.start_byte();
@@ -1786,6 +1991,9 @@ impl LanguageParser for RustParser {
     fn find_inherent_methods(&mut self, code: &str) -> Vec<(String, String, Range)> {
         self.find_inherent_methods(code)
     }
+    fn find_value_references(&mut self, code: &str) -> Vec<ValueReference> {
+        self.find_value_references(code)
+    }
 }
 
 impl NodeTracker for RustParser {
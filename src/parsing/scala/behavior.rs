@@ -0,0 +1,230 @@
+//! Scala-specific language behavior implementation
+//!
+//! Module paths follow the sbt/Maven layout: a file's path below its source
+//! root becomes a dotted path, so `src/main/scala/billing/Invoice.scala`
+//! becomes `billing.Invoice`. Scala does not require packages to mirror
+//! directories, but virtually every build does, so import matching compares
+//! the package part of the path.
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, Visibility};
+use std::path::PathBuf;
+use tree_sitter::Language;
+
+/// Package part of a file module path (`billing.Invoice` -> `billing`)
+fn package_of(module_path: &str) -> &str {
+    module_path
+        .rsplit_once('.')
+        .map(|(package, _)| package)
+        .unwrap_or("")
+}
+
+/// Scala language behavior implementation
+#[derive(Clone)]
+pub struct ScalaBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl ScalaBehavior {
+    /// Create a new Scala behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_scala::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for ScalaBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for ScalaBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for ScalaBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("scala")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    fn configure_symbol(&self, symbol: &mut crate::Symbol, module_path: Option<&str>) {
+        // The parser reads access modifiers from the AST, including qualified
+        // `private[pkg]`; keep what it assigned.
+        if let Some(path) = module_path {
+            symbol.module_path = Some(path.to_string().into());
+        }
+    }
+
+    fn parse_visibility(&self, signature: &str) -> Visibility {
+        if signature.contains("private[") {
+            Visibility::Crate
+        } else if signature.contains("private ") {
+            Visibility::Private
+        } else if signature.contains("protected ") || signature.contains("protected[") {
+            Visibility::Module
+        } else {
+            Visibility::Public
+        }
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "."
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &["src/main/scala", "src/test/scala", "app", "src"]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("."))
+        }
+    }
+
+    fn supports_traits(&self) -> bool {
+        true
+    }
+
+    fn supports_inherent_methods(&self) -> bool {
+        true // Scala 3 extension methods
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::ScalaResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        importing_module: Option<&str>,
+    ) -> bool {
+        let symbol_package = package_of(symbol_module_path);
+
+        // `import billing.*` / `import billing._` (normalized to `.*` by the parser)
+        if let Some(base) = import_path
+            .strip_suffix(".*")
+            .or_else(|| import_path.strip_suffix("._"))
+        {
+            return symbol_package == base || symbol_module_path == base;
+        }
+
+        // `import billing.Invoice`: the type may live in `Invoice.scala` or
+        // in any other file of the `billing` package
+        if import_path == symbol_module_path || package_of(import_path) == symbol_package {
+            return true;
+        }
+
+        // Same package: no import needed
+        importing_module.is_some_and(|current| package_of(current) == symbol_package)
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+
+    /// Plain `private` members are only reachable from the class and its
+    /// companion, which must share a file. Qualified `private[pkg]` and
+    /// protected members are reachable from other files.
+    fn is_symbol_visible_from_file(&self, symbol: &crate::Symbol, from_file: FileId) -> bool {
+        symbol.file_id == from_file || symbol.visibility != Visibility::Private
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::path::Path;
+
+    #[test]
+    fn test_module_path_from_file() {
+        let behavior = ScalaBehavior::new();
+        let root = Path::new("/project");
+        let exts = &["scala"];
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/src/main/scala/billing/Invoice.scala"),
+                root,
+                exts
+            ),
+            Some("billing.Invoice".to_string())
+        );
+    }
+
+    #[test]
+    fn test_import_matches_symbol() {
+        let behavior = ScalaBehavior::new();
+
+        assert!(behavior.import_matches_symbol("billing.Invoice", "billing.Invoice", None));
+        assert!(behavior.import_matches_symbol("billing.LineItem", "billing.Invoice", None));
+        assert!(behavior.import_matches_symbol("billing.*", "billing.Invoice", None));
+        assert!(behavior.import_matches_symbol("billing._", "billing.Invoice", None));
+        assert!(!behavior.import_matches_symbol("shipping.*", "billing.Invoice", None));
+        assert!(behavior.import_matches_symbol(
+            "scala.util.Try",
+            "billing.Invoice",
+            Some("billing.Payment")
+        ));
+    }
+
+    #[test]
+    fn test_parse_visibility() {
+        let behavior = ScalaBehavior::new();
+
+        assert_eq!(
+            behavior.parse_visibility("private def audit()"),
+            Visibility::Private
+        );
+        assert_eq!(
+            behavior.parse_visibility("private[billing] def audit()"),
+            Visibility::Crate
+        );
+        assert_eq!(
+            behavior.parse_visibility("protected def audit()"),
+            Visibility::Module
+        );
+        assert_eq!(behavior.parse_visibility("def total"), Visibility::Public);
+    }
+
+    #[test]
+    fn test_module_separator() {
+        let behavior = ScalaBehavior::new();
+        assert_eq!(behavior.module_separator(), ".");
+    }
+}
@@ -0,0 +1,90 @@
+//! Scala language definition for the registry
+//!
+//! Provides the Scala language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{ScalaBehavior, ScalaParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Scala language definition
+pub struct ScalaLanguage;
+
+impl ScalaLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("scala");
+}
+
+impl LanguageDefinition for ScalaLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Scala"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["scala", "sc"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = ScalaParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(ScalaBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Scala is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Scala is enabled by default
+    }
+}
+
+/// Register Scala language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(ScalaLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_scala_definition() {
+        let scala = ScalaLanguage;
+
+        assert_eq!(scala.id(), LanguageId::new("scala"));
+        assert_eq!(scala.name(), "Scala");
+        assert!(scala.extensions().contains(&"scala"));
+        assert!(scala.extensions().contains(&"sc"));
+    }
+
+    #[test]
+    fn test_scala_enabled_by_default() {
+        let scala = ScalaLanguage;
+        let settings = Settings::default();
+
+        assert!(scala.default_enabled());
+        assert!(scala.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_scala_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("scala")));
+    }
+}
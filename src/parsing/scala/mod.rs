@@ -0,0 +1,20 @@
+//! Scala language parser implementation
+//!
+//! Indexes classes, objects, traits, enums, methods and members for Scala 2
+//! and Scala 3 sources. `given` instances and `implicit` definitions are
+//! tagged `SymbolKind::Given` so implicit-resolution sites stay discoverable.
+//! Trait mixins (`extends A with B`) are recorded as implements edges.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+pub mod resolution;
+
+pub use behavior::ScalaBehavior;
+pub use definition::ScalaLanguage;
+pub use parser::ScalaParser;
+pub use resolution::ScalaResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
@@ -0,0 +1,1274 @@
+//! Scala language parser implementation
+//!
+//! Extracts symbols and relationships from Scala 2 and Scala 3 source using
+//! tree-sitter-scala.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | class / case class | Class |
+//! | object / case object | Module |
+//! | trait | Trait |
+//! | enum (Scala 3) | Enum (cases are Constant) |
+//! | def (in class/object/trait) | Method |
+//! | def (top level, extension) | Function |
+//! | val/var (in class/object/trait) | Field |
+//! | val/var (top level) | Variable |
+//! | type alias | TypeAlias |
+//! | given / implicit def, val, class | Given |
+//!
+//! `object` maps to Module so a companion object and its class stay
+//! distinguishable when they share a name.
+//!
+//! ## Relationships
+//!
+//! - `extends A with B` heritage is emitted on both the Extends and
+//!   Implements channels; `ScalaResolutionContext` keeps Implements only for
+//!   trait targets (a heritage list cannot tell a class from a trait at parse
+//!   time, same as Swift)
+//! - Types define their members (`find_defines`)
+//! - Scala 3 extension methods are reported against their receiver type via
+//!   `find_inherent_methods`
+//! - `import a.b.{C, D => E}` yields one import per selector
+//!
+//! ## Visibility
+//!
+//! Members are public unless marked: `private` maps to Private,
+//! `protected` to Module, and qualified `private[pkg]` to Crate.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, MethodCall, NodeTracker, NodeTrackingState,
+    ParserContext, ScopeType,
+};
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Standard-library types filtered from type usage tracking
+const SCALA_BUILTIN_TYPES: &[&str] = &[
+    "Int", "Long", "Short", "Byte", "Float", "Double", "Boolean", "Char", "String", "Unit", "Any",
+    "AnyRef", "AnyVal", "Nothing", "Null", "Option", "Some", "None", "List", "Seq", "Vector",
+    "Map", "Set", "Array", "Either", "Future", "Try",
+];
+
+/// Scala-specific parsing errors
+#[derive(Error, Debug)]
+pub enum ScalaParseError {
+    #[error(
+        "Failed to initialize Scala parser: {reason}\nSuggestion: Ensure tree-sitter-scala is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// Scala language parser
+pub struct ScalaParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for ScalaParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("ScalaParser")
+            .field("language", &"Scala")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+fn is_type_definition(kind: &str) -> bool {
+    matches!(
+        kind,
+        "class_definition" | "object_definition" | "trait_definition" | "enum_definition"
+    )
+}
+
+fn is_function_node(kind: &str) -> bool {
+    matches!(kind, "function_definition" | "function_declaration")
+}
+
+/// Whether the definition carries an anonymous keyword child (`case`, `implicit`)
+fn has_keyword(node: &Node, code: &str, keyword: &str) -> bool {
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        if child.kind() == "modifiers" {
+            let mut inner = child.walk();
+            if child
+                .children(&mut inner)
+                .any(|modifier| &code[modifier.byte_range()] == keyword)
+            {
+                return true;
+            }
+        } else if !child.is_named() && &code[child.byte_range()] == keyword {
+            return true;
+        }
+    }
+    false
+}
+
+fn visibility_from_modifiers(node: &Node, code: &str) -> Visibility {
+    let mut cursor = node.walk();
+    let Some(modifiers) = node
+        .children(&mut cursor)
+        .find(|child| child.kind() == "modifiers")
+    else {
+        return Visibility::Public;
+    };
+
+    let mut inner = modifiers.walk();
+    for modifier in modifiers.children(&mut inner) {
+        if modifier.kind() != "access_modifier" {
+            continue;
+        }
+        let text = &code[modifier.byte_range()];
+        // `private[billing]` is package-scoped, closer to Crate than Private
+        return if text.contains('[') && !text.contains("[this]") {
+            Visibility::Crate
+        } else if text.starts_with("protected") {
+            Visibility::Module
+        } else {
+            Visibility::Private
+        };
+    }
+    Visibility::Public
+}
+
+/// Base name of a type node: `Ordering[Int]` -> `Ordering`, `a.b.C` -> `C`
+fn base_type_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    match node.kind() {
+        "type_identifier" | "identifier" => Some(&code[node.byte_range()]),
+        "generic_type" => node
+            .child_by_field_name("type")
+            .or_else(|| node.named_child(0))
+            .and_then(|inner| base_type_name(&inner, code)),
+        "stable_type_identifier" => {
+            let text = &code[node.byte_range()];
+            text.rsplit('.').next()
+        }
+        _ => None,
+    }
+}
+
+/// Synthesized name for an anonymous given, following the Scala 3 compiler
+/// convention: `given Ordering[Int]` -> `given_Ordering_Int`
+fn synthesized_given_name(type_text: &str) -> String {
+    let mut name = String::from("given");
+    for part in type_text
+        .split(|c: char| !c.is_alphanumeric() && c != '_')
+        .filter(|part| !part.is_empty())
+    {
+        name.push('_');
+        name.push_str(part);
+    }
+    name
+}
+
+/// Split an import body into full paths, expanding `{A, B => C}` selectors
+///
+/// Returns `(path, alias, is_glob)` triples.
+fn split_import_body(body: &str) -> Vec<(String, Option<String>, bool)> {
+    let mut clauses = Vec::new();
+    let mut depth = 0usize;
+    let mut start = 0;
+    for (i, c) in body.char_indices() {
+        match c {
+            '{' => depth += 1,
+            '}' => depth = depth.saturating_sub(1),
+            ',' if depth == 0 => {
+                clauses.push(&body[start..i]);
+                start = i + 1;
+            }
+            _ => {}
+        }
+    }
+    clauses.push(&body[start..]);
+
+    let mut imports = Vec::new();
+    for clause in clauses {
+        let clause: String = clause.split_whitespace().collect::<Vec<_>>().join(" ");
+        if clause.is_empty() {
+            continue;
+        }
+
+        if let Some(open) = clause.find('{') {
+            let prefix = clause[..open].trim_end_matches('.');
+            let selectors = clause[open + 1..].trim_end_matches('}');
+            for selector in selectors.split(',') {
+                imports.extend(import_selector(prefix, selector.trim()));
+            }
+        } else if let Some((prefix, last)) = clause.rsplit_once('.') {
+            imports.extend(import_selector(prefix, last.trim()));
+        } else {
+            imports.push((clause, None, false));
+        }
+    }
+    imports
+}
+
+fn import_selector(prefix: &str, selector: &str) -> Option<(String, Option<String>, bool)> {
+    if selector.is_empty() {
+        return None;
+    }
+    if matches!(selector, "_" | "*" | "given") {
+        return Some((format!("{prefix}.*"), None, true));
+    }
+
+    // Scala 2 `A => B` and Scala 3 `A as B`
+    let (name, alias) = match selector
+        .split_once("=>")
+        .or_else(|| selector.split_once(" as "))
+    {
+        Some((name, alias)) => (name.trim(), Some(alias.trim())),
+        None => (selector, None),
+    };
+    // `A => _` hides A rather than importing it
+    if alias == Some("_") {
+        return None;
+    }
+
+    Some((format!("{prefix}.{name}"), alias.map(str::to_string), false))
+}
+
+impl ScalaParser {
+    /// Create a new Scala parser instance
+    pub fn new() -> Result<Self, ScalaParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_scala::LANGUAGE.into())
+            .map_err(|e| ScalaParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn create_symbol(
+        &self,
+        counter: &mut SymbolCounter,
+        name: &str,
+        kind: SymbolKind,
+        file_id: FileId,
+        range: Range,
+        signature: String,
+        doc_comment: Option<String>,
+        visibility: Visibility,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(counter.next_id(), name, kind, file_id, range)
+            .with_signature(signature)
+            .with_visibility(visibility);
+        if let Some(doc) = doc_comment {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(self.context.current_scope_context());
+        symbol
+    }
+
+    /// Declaration text up to (not including) the body
+    fn header_signature(node: &Node, code: &str) -> String {
+        let end = node
+            .child_by_field_name("body")
+            .map(|body| body.start_byte())
+            .unwrap_or(node.end_byte());
+        let header = code[node.start_byte()..end].trim_end();
+        // `def f: Int =`, `object A:` (Scala 3 braceless), `given X with`
+        let header = header
+            .strip_suffix('=')
+            .or_else(|| header.strip_suffix(':'))
+            .or_else(|| header.strip_suffix("with"))
+            .unwrap_or(header);
+        header.trim_end().to_string()
+    }
+
+    /// Extract symbols from AST node recursively
+    fn extract_symbols_from_node(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        match node.kind() {
+            kind if is_type_definition(kind) => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_type(node, code, file_id, counter, symbols, depth);
+            }
+            kind if is_function_node(kind) => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_function(node, code, file_id, counter, symbols, depth);
+            }
+            "val_definition" | "var_definition" | "val_declaration" | "var_declaration" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                // Locals are not indexed; their initializers may still hold
+                // anonymous classes, which are not indexed either
+                if !self.context.is_in_function() {
+                    self.process_value(node, code, file_id, counter, symbols);
+                }
+            }
+            "given_definition" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_given(node, code, file_id, counter, symbols, depth);
+            }
+            "type_definition" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_type_alias(node, code, file_id, counter, symbols);
+            }
+            "simple_enum_case" | "full_enum_case" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_enum_case(node, code, file_id, counter, symbols);
+            }
+            "extension_definition" => {
+                // Members are emitted as top-level functions; their receiver
+                // attribution is reported by find_inherent_methods
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.extract_children(node, code, file_id, counter, symbols, depth);
+            }
+            "package_clause" | "import_declaration" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                if let Some(body) = node.child_by_field_name("body") {
+                    self.extract_children(body, code, file_id, counter, symbols, depth);
+                }
+            }
+            _ => {
+                self.extract_children(node, code, file_id, counter, symbols, depth);
+            }
+        }
+    }
+
+    fn extract_children(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            self.extract_symbols_from_node(child, code, file_id, counter, symbols, depth + 1);
+        }
+    }
+
+    /// Process class, object, trait and enum definitions
+    fn process_type(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(name_node) = node.child_by_field_name("name") else {
+            return;
+        };
+        let name = &code[name_node.byte_range()];
+
+        let kind = if has_keyword(&node, code, "implicit") {
+            SymbolKind::Given
+        } else {
+            match node.kind() {
+                "object_definition" => SymbolKind::Module,
+                "trait_definition" => SymbolKind::Trait,
+                "enum_definition" => SymbolKind::Enum,
+                _ => SymbolKind::Class,
+            }
+        };
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            Self::header_signature(&node, code),
+            self.extract_doc_comment(&node, code),
+            visibility_from_modifiers(&node, code),
+        );
+        symbols.push(symbol);
+
+        let saved_class = self.context.current_class().map(|s| s.to_string());
+        let saved_function = self.context.current_function().map(|s| s.to_string());
+        self.context.enter_scope(ScopeType::Class);
+        self.context.set_current_class(Some(name.to_string()));
+
+        if let Some(body) = node.child_by_field_name("body") {
+            self.extract_children(body, code, file_id, counter, symbols, depth);
+        }
+
+        self.context.exit_scope();
+        self.context.set_current_class(saved_class);
+        self.context.set_current_function(saved_function);
+    }
+
+    /// Process `def` definitions and abstract declarations
+    fn process_function(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(name_node) = node.child_by_field_name("name") else {
+            return;
+        };
+        let name = &code[name_node.byte_range()];
+
+        let kind = if has_keyword(&node, code, "implicit") {
+            SymbolKind::Given
+        } else if self.context.is_in_class() {
+            SymbolKind::Method
+        } else {
+            SymbolKind::Function
+        };
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            Self::header_signature(&node, code),
+            self.extract_doc_comment(&node, code),
+            visibility_from_modifiers(&node, code),
+        );
+        symbols.push(symbol);
+
+        let saved_function = self.context.current_function().map(|s| s.to_string());
+        self.context.enter_scope(ScopeType::function());
+        self.context.set_current_function(Some(name.to_string()));
+
+        if let Some(body) = node.child_by_field_name("body") {
+            self.extract_symbols_from_node(body, code, file_id, counter, symbols, depth + 1);
+        }
+
+        self.context.exit_scope();
+        self.context.set_current_function(saved_function);
+    }
+
+    /// Process member and top-level `val`/`var` definitions
+    fn process_value(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(pattern) = node
+            .child_by_field_name("pattern")
+            .or_else(|| node.child_by_field_name("name"))
+        else {
+            return;
+        };
+        // Only simple bindings; tuple/extractor patterns are skipped
+        if pattern.kind() != "identifier" {
+            return;
+        }
+        let name = &code[pattern.byte_range()];
+
+        let kind = if has_keyword(&node, code, "implicit") {
+            SymbolKind::Given
+        } else if self.context.is_in_class() {
+            SymbolKind::Field
+        } else {
+            SymbolKind::Variable
+        };
+
+        let header_end = node
+            .child_by_field_name("type")
+            .unwrap_or(pattern)
+            .end_byte();
+        let signature = code[node.start_byte()..header_end].to_string();
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            signature,
+            self.extract_doc_comment(&node, code),
+            visibility_from_modifiers(&node, code),
+        );
+        symbols.push(symbol);
+    }
+
+    /// Process Scala 3 `given` instances (named or anonymous)
+    fn process_given(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let name = match node.child_by_field_name("name") {
+            Some(name) => code[name.byte_range()].to_string(),
+            None => {
+                let type_text = node
+                    .child_by_field_name("return_type")
+                    .map(|t| &code[t.byte_range()])
+                    .unwrap_or("");
+                synthesized_given_name(type_text)
+            }
+        };
+
+        let symbol = self.create_symbol(
+            counter,
+            &name,
+            SymbolKind::Given,
+            file_id,
+            range_from_node(&node),
+            Self::header_signature(&node, code),
+            self.extract_doc_comment(&node, code),
+            visibility_from_modifiers(&node, code),
+        );
+        symbols.push(symbol);
+
+        // `given Ordering[Int] with { def compare ... }` members belong to the given
+        if let Some(body) = node
+            .child_by_field_name("body")
+            .filter(|body| body.kind() == "template_body")
+        {
+            let saved_class = self.context.current_class().map(|s| s.to_string());
+            self.context.enter_scope(ScopeType::Class);
+            self.context.set_current_class(Some(name));
+            self.extract_children(body, code, file_id, counter, symbols, depth);
+            self.context.exit_scope();
+            self.context.set_current_class(saved_class);
+        }
+    }
+
+    fn process_type_alias(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(name_node) = node.child_by_field_name("name") else {
+            return;
+        };
+        let symbol = self.create_symbol(
+            counter,
+            &code[name_node.byte_range()],
+            SymbolKind::TypeAlias,
+            file_id,
+            range_from_node(&node),
+            code[node.byte_range()].trim().to_string(),
+            self.extract_doc_comment(&node, code),
+            visibility_from_modifiers(&node, code),
+        );
+        symbols.push(symbol);
+    }
+
+    fn process_enum_case(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(name_node) = node.child_by_field_name("name") else {
+            return;
+        };
+        let symbol = self.create_symbol(
+            counter,
+            &code[name_node.byte_range()],
+            SymbolKind::Constant,
+            file_id,
+            range_from_node(&node),
+            format!("case {}", code[node.byte_range()].trim()),
+            None,
+            Visibility::Public,
+        );
+        symbols.push(symbol);
+    }
+
+    /// Name of the enclosing definition a call is attributed to
+    fn owner_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+        if is_function_node(node.kind()) || is_type_definition(node.kind()) {
+            node.child_by_field_name("name")
+                .map(|name| &code[name.byte_range()])
+        } else {
+            None
+        }
+    }
+
+    fn extract_calls_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        calls: &mut Vec<(&'a str, &'a str, Range)>,
+        current_owner: Option<&'a str>,
+    ) {
+        let owner = Self::owner_name(&node, code).or(current_owner);
+
+        if let Some(caller) = owner {
+            match node.kind() {
+                "call_expression" => {
+                    let callee = node
+                        .child_by_field_name("function")
+                        .filter(|function| function.kind() == "identifier");
+                    if let Some(callee) = callee {
+                        calls.push((caller, &code[callee.byte_range()], range_from_node(&node)));
+                    }
+                }
+                "instance_expression" => {
+                    // `new Invoice(...)` constructs the class
+                    let mut cursor = node.walk();
+                    let target = node
+                        .named_children(&mut cursor)
+                        .find_map(|child| base_type_name(&child, code));
+                    if let Some(target) = target {
+                        calls.push((caller, target, range_from_node(&node)));
+                    }
+                }
+                _ => {}
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_calls_from_node(child, code, calls, owner);
+        }
+    }
+
+    fn extract_method_calls_from_node(
+        node: Node,
+        code: &str,
+        out: &mut Vec<MethodCall>,
+        current_owner: Option<&str>,
+    ) {
+        let owner = Self::owner_name(&node, code).or(current_owner);
+
+        if node.kind() == "call_expression" {
+            let field = node
+                .child_by_field_name("function")
+                .filter(|function| function.kind() == "field_expression");
+            if let (Some(caller), Some(field)) = (owner, field) {
+                if let (Some(receiver), Some(method)) = (
+                    field.child_by_field_name("value"),
+                    field.child_by_field_name("field"),
+                ) {
+                    let receiver_text = &code[receiver.byte_range()];
+                    let mut call =
+                        MethodCall::new(caller, &code[method.byte_range()], range_from_node(&node))
+                            .with_receiver(receiver_text);
+                    // `Invoice.apply(...)`: capitalised identifiers name objects
+                    if receiver.kind() == "identifier"
+                        && receiver_text.starts_with(|c: char| c.is_uppercase())
+                    {
+                        call = call.static_method();
+                    }
+                    out.push(call);
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_method_calls_from_node(child, code, out, owner);
+        }
+    }
+
+    /// Heritage pairs from `extends A with B` / `extends A, B`
+    fn extract_heritage_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        heritage: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if is_type_definition(node.kind()) {
+            if let (Some(name), Some(extends)) = (
+                node.child_by_field_name("name"),
+                node.child_by_field_name("extend"),
+            ) {
+                let derived = &code[name.byte_range()];
+                let mut cursor = extends.walk();
+                for parent in extends.named_children(&mut cursor) {
+                    if let Some(base) = base_type_name(&parent, code) {
+                        heritage.push((derived, base, range_from_node(&parent)));
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_heritage_from_node(child, code, heritage);
+        }
+    }
+
+    fn extract_defines_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        defines: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if is_type_definition(node.kind()) {
+            if let (Some(name), Some(body)) = (
+                node.child_by_field_name("name"),
+                node.child_by_field_name("body"),
+            ) {
+                let owner = &code[name.byte_range()];
+                let mut cursor = body.walk();
+                for member in body.named_children(&mut cursor) {
+                    let member_name = match member.kind() {
+                        kind if is_function_node(kind) || kind == "given_definition" => {
+                            member.child_by_field_name("name")
+                        }
+                        "val_definition" | "var_definition" => member
+                            .child_by_field_name("pattern")
+                            .filter(|pattern| pattern.kind() == "identifier"),
+                        "val_declaration" | "var_declaration" => member.child_by_field_name("name"),
+                        _ => None,
+                    };
+                    if let Some(member_name) = member_name {
+                        defines.push((
+                            owner,
+                            &code[member_name.byte_range()],
+                            range_from_node(&member),
+                        ));
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_defines_from_node(child, code, defines);
+        }
+    }
+
+    /// Parameter and return types referenced by functions
+    fn extract_uses_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if is_function_node(node.kind()) {
+            if let Some(name) = node.child_by_field_name("name") {
+                let function_name = &code[name.byte_range()];
+                let mut cursor = node.walk();
+                for child in node.children(&mut cursor) {
+                    let is_signature_part = child.kind() == "parameters"
+                        || node
+                            .child_by_field_name("return_type")
+                            .is_some_and(|ret| ret.id() == child.id());
+                    if is_signature_part {
+                        Self::collect_type_identifiers(child, code, function_name, uses);
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_uses_from_node(child, code, uses);
+        }
+    }
+
+    fn collect_type_identifiers<'a>(
+        node: Node,
+        code: &'a str,
+        user: &'a str,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if node.kind() == "type_identifier" {
+            let type_name = &code[node.byte_range()];
+            if !SCALA_BUILTIN_TYPES.contains(&type_name) {
+                uses.push((user, type_name, range_from_node(&node)));
+            }
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::collect_type_identifiers(child, code, user, uses);
+        }
+    }
+
+    /// `val x: Foo = ...`, `val x = new Foo(...)` and `val x = Foo(...)`
+    fn extract_variable_types_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        bindings: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if matches!(node.kind(), "val_definition" | "var_definition") {
+            let pattern = node
+                .child_by_field_name("pattern")
+                .filter(|pattern| pattern.kind() == "identifier");
+            if let Some(pattern) = pattern {
+                let declared = node
+                    .child_by_field_name("type")
+                    .and_then(|t| base_type_name(&t, code));
+                let inferred = node.child_by_field_name("value").and_then(|value| {
+                    match value.kind() {
+                        "instance_expression" => {
+                            let mut cursor = value.walk();
+                            value
+                                .named_children(&mut cursor)
+                                .find_map(|child| base_type_name(&child, code))
+                        }
+                        // Case class / companion `apply`
+                        "call_expression" => value
+                            .child_by_field_name("function")
+                            .filter(|f| f.kind() == "identifier")
+                            .map(|f| &code[f.byte_range()])
+                            .filter(|name| name.starts_with(|c: char| c.is_uppercase())),
+                        _ => None,
+                    }
+                });
+                if let Some(type_name) = declared.or(inferred) {
+                    bindings.push((
+                        &code[pattern.byte_range()],
+                        type_name,
+                        range_from_node(&node),
+                    ));
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_variable_types_from_node(child, code, bindings);
+        }
+    }
+
+    /// Scala 3 `extension (s: String) def shout = ...` receiver attribution
+    fn extract_extension_methods_from_node(
+        node: Node,
+        code: &str,
+        methods: &mut Vec<(String, String, Range)>,
+    ) {
+        if node.kind() == "extension_definition" {
+            let receiver = node
+                .child_by_field_name("parameters")
+                .and_then(|params| {
+                    let mut cursor = params.walk();
+                    params
+                        .named_children(&mut cursor)
+                        .find(|param| param.kind() == "parameter")
+                })
+                .and_then(|param| param.child_by_field_name("type"))
+                .and_then(|t| base_type_name(&t, code));
+
+            if let Some(receiver) = receiver {
+                let mut stack = vec![node];
+                while let Some(current) = stack.pop() {
+                    let mut cursor = current.walk();
+                    for child in current.named_children(&mut cursor) {
+                        if is_function_node(child.kind()) {
+                            if let Some(name) = child.child_by_field_name("name") {
+                                methods.push((
+                                    receiver.to_string(),
+                                    code[name.byte_range()].to_string(),
+                                    range_from_node(&child),
+                                ));
+                            }
+                        } else if child.kind() == "template_body" {
+                            stack.push(child);
+                        }
+                    }
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_extension_methods_from_node(child, code, methods);
+        }
+    }
+
+    fn extract_imports_from_node(
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        imports: &mut Vec<Import>,
+    ) {
+        if node.kind() == "import_declaration" {
+            let text = &code[node.byte_range()];
+            let body = text.trim_start().trim_start_matches("import").trim();
+            for (path, alias, is_glob) in split_import_body(body) {
+                imports.push(Import {
+                    path,
+                    alias,
+                    file_id,
+                    is_glob,
+                    is_type_only: false,
+                });
+            }
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_imports_from_node(child, code, file_id, imports);
+        }
+    }
+}
+
+impl LanguageParser for ScalaParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+
+        let mut symbols = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            self.extract_symbols_from_node(
+                tree.root_node(),
+                code,
+                file_id,
+                symbol_counter,
+                &mut symbols,
+                0,
+            );
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// Scaladoc (`/** ... */`) directly above the definition
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let prev = node.prev_sibling()?;
+        if prev.kind() != "block_comment" {
+            return None;
+        }
+        let text = &code[prev.byte_range()];
+        let inner = text.strip_prefix("/**")?.strip_suffix("*/")?;
+
+        let doc = inner
+            .lines()
+            .map(|line| line.trim().trim_start_matches('*').trim())
+            .filter(|line| !line.is_empty())
+            .collect::<Vec<_>>()
+            .join("\n");
+
+        if doc.is_empty() { None } else { Some(doc) }
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_calls_from_node(tree.root_node(), code, &mut calls, None);
+        }
+        calls
+    }
+
+    fn find_method_calls(&mut self, code: &str) -> Vec<MethodCall> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_method_calls_from_node(tree.root_node(), code, &mut calls, None);
+        }
+        calls
+    }
+
+    fn find_implementations<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        // Heritage lists cannot tell a superclass from a mixed-in trait;
+        // emit every pair and let ScalaResolutionContext gate by target kind.
+        let mut heritage = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_heritage_from_node(tree.root_node(), code, &mut heritage);
+        }
+        heritage
+    }
+
+    fn find_extends<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut heritage = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_heritage_from_node(tree.root_node(), code, &mut heritage);
+        }
+        heritage
+    }
+
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut uses = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_uses_from_node(tree.root_node(), code, &mut uses);
+        }
+        uses
+    }
+
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut defines = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_defines_from_node(tree.root_node(), code, &mut defines);
+        }
+        defines
+    }
+
+    fn find_variable_types<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut bindings = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_variable_types_from_node(tree.root_node(), code, &mut bindings);
+        }
+        bindings
+    }
+
+    /// Scala 3 extension methods attributed to their receiver type
+    fn find_inherent_methods(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let mut methods = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_extension_methods_from_node(tree.root_node(), code, &mut methods);
+        }
+        methods
+    }
+
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let mut imports = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_imports_from_node(tree.root_node(), code, file_id, &mut imports);
+        }
+        imports
+    }
+
+    fn language(&self) -> Language {
+        Language::Scala
+    }
+}
+
+impl NodeTracker for ScalaParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = ScalaParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn kind_of(symbols: &[Symbol], name: &str) -> Option<SymbolKind> {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .map(|s| s.kind)
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(ScalaParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_types_and_members() {
+        let code = r#"
+package billing
+
+/** A billable line */
+case class LineItem(sku: String, cents: Long)
+
+trait Priced {
+  def total: Long
+}
+
+class Invoice(items: List[LineItem]) extends Priced {
+  val currency = "EUR"
+  def total: Long = items.map(_.cents).sum
+  private def audit(): Unit = ()
+}
+
+object Invoice {
+  def empty: Invoice = new Invoice(Nil)
+}
+"#;
+        let symbols = parse(code);
+
+        assert_eq!(kind_of(&symbols, "LineItem"), Some(SymbolKind::Class));
+        assert_eq!(kind_of(&symbols, "Priced"), Some(SymbolKind::Trait));
+        assert_eq!(kind_of(&symbols, "currency"), Some(SymbolKind::Field));
+        assert_eq!(kind_of(&symbols, "empty"), Some(SymbolKind::Method));
+
+        let kinds: Vec<_> = symbols
+            .iter()
+            .filter(|s| s.name.as_ref() == "Invoice")
+            .map(|s| s.kind)
+            .collect();
+        assert!(kinds.contains(&SymbolKind::Class));
+        assert!(kinds.contains(&SymbolKind::Module));
+
+        let line_item = symbols
+            .iter()
+            .find(|s| s.name.as_ref() == "LineItem")
+            .unwrap();
+        assert!(
+            line_item
+                .signature
+                .as_deref()
+                .unwrap()
+                .starts_with("case class")
+        );
+        assert_eq!(line_item.doc_comment.as_deref(), Some("A billable line"));
+
+        let audit = symbols.iter().find(|s| s.name.as_ref() == "audit").unwrap();
+        assert_eq!(audit.visibility, Visibility::Private);
+    }
+
+    #[test]
+    fn test_given_and_implicit_definitions() {
+        let code = r#"
+object Instances {
+  given Ordering[Money] = Ordering.by(_.cents)
+  given moneyShow: Show[Money] with {
+    def show(m: Money): String = m.toString
+  }
+  implicit val defaultCurrency: Currency = Currency.EUR
+  implicit def toMoney(cents: Long): Money = Money(cents)
+  implicit class RichMoney(m: Money) {
+    def doubled: Money = Money(m.cents * 2)
+  }
+}
+"#;
+        let symbols = parse(code);
+
+        assert_eq!(
+            kind_of(&symbols, "given_Ordering_Money"),
+            Some(SymbolKind::Given)
+        );
+        assert_eq!(kind_of(&symbols, "moneyShow"), Some(SymbolKind::Given));
+        assert_eq!(
+            kind_of(&symbols, "defaultCurrency"),
+            Some(SymbolKind::Given)
+        );
+        assert_eq!(kind_of(&symbols, "toMoney"), Some(SymbolKind::Given));
+        assert_eq!(kind_of(&symbols, "RichMoney"), Some(SymbolKind::Given));
+        assert_eq!(kind_of(&symbols, "doubled"), Some(SymbolKind::Method));
+    }
+
+    #[test]
+    fn test_heritage_and_defines() {
+        let code = r#"
+trait Shape
+trait Named extends Shape
+class Circle(r: Double) extends Base(r) with Shape with Named {
+  def area: Double = r * r
+}
+"#;
+        let mut parser = ScalaParser::new().unwrap();
+
+        let extends = parser.find_extends(code);
+        assert!(
+            extends
+                .iter()
+                .any(|(d, b, _)| *d == "Named" && *b == "Shape")
+        );
+        assert!(
+            extends
+                .iter()
+                .any(|(d, b, _)| *d == "Circle" && *b == "Base")
+        );
+        assert!(
+            extends
+                .iter()
+                .any(|(d, b, _)| *d == "Circle" && *b == "Named")
+        );
+
+        let defines = parser.find_defines(code);
+        assert!(
+            defines
+                .iter()
+                .any(|(t, m, _)| *t == "Circle" && *m == "area")
+        );
+    }
+
+    #[test]
+    fn test_split_import_body() {
+        let imports = split_import_body("scala.collection.mutable.{Map => MMap, Set, _}");
+        assert_eq!(
+            imports,
+            vec![
+                (
+                    "scala.collection.mutable.Map".to_string(),
+                    Some("MMap".to_string()),
+                    false
+                ),
+                ("scala.collection.mutable.Set".to_string(), None, false),
+                ("scala.collection.mutable.*".to_string(), None, true),
+            ]
+        );
+
+        let imports = split_import_body("billing.Invoice, billing.LineItem as Item");
+        assert_eq!(imports[0], ("billing.Invoice".to_string(), None, false));
+        assert_eq!(
+            imports[1],
+            (
+                "billing.LineItem".to_string(),
+                Some("Item".to_string()),
+                false
+            )
+        );
+    }
+
+    #[test]
+    fn test_extension_methods_attributed_to_receiver() {
+        let code = r#"
+extension (m: Money)
+  def formatted: String = s"${m.cents}"
+  def isZero: Boolean = m.cents == 0
+"#;
+        let mut parser = ScalaParser::new().unwrap();
+        let methods = parser.find_inherent_methods(code);
+        assert!(
+            methods
+                .iter()
+                .any(|(t, m, _)| t == "Money" && m == "formatted")
+        );
+    }
+
+    #[test]
+    fn test_synthesized_given_name() {
+        assert_eq!(
+            synthesized_given_name("Ordering[Int]"),
+            "given_Ordering_Int"
+        );
+        assert_eq!(synthesized_given_name("Show[List[A]]"), "given_Show_List_A");
+    }
+}
@@ -0,0 +1,277 @@
+//! Scala-specific symbol resolution
+//!
+//! Scala resolves names through:
+//! - Local values and parameters of the current method
+//! - Members of the enclosing class/object/trait (including mixed-in traits)
+//! - Top-level definitions of the package and imported names
+
+use crate::parsing::resolution::{ImportBinding, ImportOrigin, default_compatible_relationship};
+use crate::parsing::{Import, ResolutionScope, ScopeLevel, ScopeType};
+use crate::{FileId, RelationKind, SymbolId};
+use std::collections::HashMap;
+
+/// Scala resolution context
+///
+/// Tracks local, type-level and imported scopes. Qualified references
+/// (`billing.Invoice`) fall back to their last segment.
+pub struct ScalaResolutionContext {
+    #[allow(dead_code)]
+    file_id: FileId,
+
+    /// Method locals and parameters
+    local_scope: HashMap<String, SymbolId>,
+
+    /// Class/module members and top-level definitions in this file
+    module_scope: HashMap<String, SymbolId>,
+
+    /// Symbols made available by `import`
+    imported_scope: HashMap<String, SymbolId>,
+
+    /// Scope stack for nested contexts
+    scope_stack: Vec<ScopeType>,
+
+    /// Import bindings keyed by visible name
+    import_bindings: HashMap<String, ImportBinding>,
+}
+
+impl ScalaResolutionContext {
+    pub fn new(file_id: FileId) -> Self {
+        Self {
+            file_id,
+            local_scope: HashMap::new(),
+            module_scope: HashMap::new(),
+            imported_scope: HashMap::new(),
+            scope_stack: Vec::new(),
+            import_bindings: HashMap::new(),
+        }
+    }
+}
+
+impl ResolutionScope for ScalaResolutionContext {
+    fn as_any_mut(&mut self) -> &mut dyn std::any::Any {
+        self
+    }
+
+    fn resolve(&self, name: &str) -> Option<SymbolId> {
+        if let Some(&id) = self.local_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.module_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.imported_scope.get(name) {
+            return Some(id);
+        }
+
+        // `billing.Invoice` / `_root_.billing.Invoice` -> `Invoice`
+        if let Some((_, last)) = name.rsplit_once('.') {
+            if !last.is_empty() {
+                return self.resolve(last);
+            }
+        }
+
+        None
+    }
+
+    fn add_symbol(&mut self, name: String, symbol_id: SymbolId, scope_level: ScopeLevel) {
+        match scope_level {
+            ScopeLevel::Local => {
+                self.local_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Module | ScopeLevel::Global => {
+                self.module_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Package => {
+                self.imported_scope.insert(name, symbol_id);
+            }
+        }
+    }
+
+    fn enter_scope(&mut self, scope_type: ScopeType) {
+        self.scope_stack.push(scope_type);
+    }
+
+    fn exit_scope(&mut self) {
+        if let Some(scope) = self.scope_stack.pop() {
+            if matches!(scope, ScopeType::Function { .. }) {
+                self.clear_local_scope();
+            }
+        }
+    }
+
+    fn clear_local_scope(&mut self) {
+        self.local_scope.clear();
+    }
+
+    fn symbols_in_scope(&self) -> Vec<(String, SymbolId, ScopeLevel)> {
+        let mut symbols = Vec::new();
+
+        for (name, &id) in &self.local_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Local));
+        }
+        for (name, &id) in &self.module_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Module));
+        }
+        for (name, &id) in &self.imported_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Package));
+        }
+
+        symbols
+    }
+
+    fn resolve_relationship(
+        &self,
+        _from_name: &str,
+        to_name: &str,
+        _kind: RelationKind,
+        _from_file: FileId,
+    ) -> Option<SymbolId> {
+        self.resolve(to_name)
+    }
+
+    fn populate_imports(&mut self, imports: &[Import]) {
+        for import in imports {
+            // Wildcards expose nothing by name; members resolve via the index
+            if import.is_glob {
+                continue;
+            }
+            // `import billing.{Invoice => Bill}` exposes the alias
+            let name = import.alias.clone().unwrap_or_else(|| {
+                import
+                    .path
+                    .rsplit('.')
+                    .next()
+                    .unwrap_or(&import.path)
+                    .to_string()
+            });
+            self.import_bindings.insert(
+                name.clone(),
+                ImportBinding {
+                    import: import.clone(),
+                    exposed_name: name,
+                    origin: ImportOrigin::Unknown,
+                    resolved_symbol: None,
+                },
+            );
+        }
+    }
+
+    fn register_import_binding(&mut self, binding: ImportBinding) {
+        self.import_bindings
+            .insert(binding.exposed_name.clone(), binding);
+    }
+
+    fn import_binding(&self, name: &str) -> Option<ImportBinding> {
+        self.import_bindings.get(name).cloned()
+    }
+
+    /// `extends A with B` is emitted on both channels (see the parser);
+    /// Implements keeps trait targets and Extends rejects them, except for
+    /// trait-to-trait inheritance. Givens participate like other members.
+    fn is_compatible_relationship(
+        &self,
+        from_kind: crate::SymbolKind,
+        to_kind: crate::SymbolKind,
+        rel_kind: crate::RelationKind,
+    ) -> bool {
+        use crate::RelationKind::*;
+        use crate::SymbolKind::*;
+
+        match rel_kind {
+            Implements => matches!(from_kind, Class | Module | Enum | Given) && to_kind == Trait,
+            ImplementedBy => from_kind == Trait && matches!(to_kind, Class | Module | Enum | Given),
+            Extends => {
+                let extendable = matches!(from_kind, Class | Module | Trait | Enum | Given);
+                let can_be_extended = matches!(to_kind, Class | Trait);
+                let mixin = from_kind != Trait && to_kind == Trait;
+                extendable && can_be_extended && !mixin
+            }
+            ExtendedBy => {
+                let extendable = matches!(to_kind, Class | Module | Trait | Enum | Given);
+                let can_be_extended = matches!(from_kind, Class | Trait);
+                let mixin = to_kind != Trait && from_kind == Trait;
+                extendable && can_be_extended && !mixin
+            }
+            Calls => {
+                // Constructor bodies run from the class/object itself
+                let caller = matches!(from_kind, Function | Method | Given | Module | Class);
+                let callee = matches!(to_kind, Function | Method | Given | Class);
+                caller && callee
+            }
+            Defines => {
+                let container = matches!(from_kind, Class | Module | Trait | Enum | Given);
+                let member = matches!(to_kind, Method | Function | Constant | Field | Given);
+                container && member
+            }
+            _ => default_compatible_relationship(from_kind, to_kind, rel_kind),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::SymbolKind;
+
+    #[test]
+    fn test_scope_resolution_order() {
+        let mut context = ScalaResolutionContext::new(FileId::new(1).unwrap());
+
+        let local_id = SymbolId::new(1).unwrap();
+        let module_id = SymbolId::new(2).unwrap();
+        let import_id = SymbolId::new(3).unwrap();
+
+        context.add_symbol("x".to_string(), import_id, ScopeLevel::Package);
+        context.add_symbol("x".to_string(), module_id, ScopeLevel::Module);
+        context.add_symbol("x".to_string(), local_id, ScopeLevel::Local);
+
+        assert_eq!(context.resolve("x"), Some(local_id));
+        context.clear_local_scope();
+        assert_eq!(context.resolve("x"), Some(module_id));
+    }
+
+    #[test]
+    fn test_qualified_name_falls_back_to_last_segment() {
+        let mut context = ScalaResolutionContext::new(FileId::new(1).unwrap());
+        let id = SymbolId::new(7).unwrap();
+        context.add_symbol("Invoice".to_string(), id, ScopeLevel::Module);
+
+        assert_eq!(context.resolve("billing.Invoice"), Some(id));
+    }
+
+    #[test]
+    fn test_trait_mixins_split_between_extends_and_implements() {
+        let context = ScalaResolutionContext::new(FileId::new(1).unwrap());
+
+        assert!(context.is_compatible_relationship(
+            SymbolKind::Class,
+            SymbolKind::Trait,
+            RelationKind::Implements
+        ));
+        assert!(!context.is_compatible_relationship(
+            SymbolKind::Class,
+            SymbolKind::Trait,
+            RelationKind::Extends
+        ));
+        assert!(context.is_compatible_relationship(
+            SymbolKind::Class,
+            SymbolKind::Class,
+            RelationKind::Extends
+        ));
+        assert!(!context.is_compatible_relationship(
+            SymbolKind::Class,
+            SymbolKind::Class,
+            RelationKind::Implements
+        ));
+        assert!(context.is_compatible_relationship(
+            SymbolKind::Trait,
+            SymbolKind::Trait,
+            RelationKind::Extends
+        ));
+        assert!(context.is_compatible_relationship(
+            SymbolKind::Module,
+            SymbolKind::Given,
+            RelationKind::Defines
+        ));
+    }
+}
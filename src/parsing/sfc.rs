@@ -0,0 +1,443 @@
+//! Single-file components
+//!
+//! Vue and Svelte components keep their script, markup and styles in one
+//! file, each in a top-level element:
+//!
+//! ```vue
+//! <script setup lang="ts">
+//! defineProps<{ title: string }>()
+//! </script>
+//!
+//! <template>
+//!   <h1>{{ title }}</h1>
+//! </template>
+//! ```
+//!
+//! [`split`] finds the top-level elements without an HTML parser, and
+//! [`mask`] blanks every byte outside the chosen blocks while keeping line
+//! breaks, so a script parser run on the result reports positions that are
+//! positions in the component file itself.
+
+use std::ops::Range;
+
+/// Name of a component until the behavior names it after its file: a
+/// Svelte component, or a Vue component without a `name` option
+pub const COMPONENT_PLACEHOLDER: &str = "<component>";
+
+/// Elements whose content is raw text: a `<div>` in a script is not an
+/// element
+const RAW_TEXT_TAGS: &[&str] = &["script", "style"];
+
+/// A top-level `<tag ...>...</tag>` element of a component file
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Block<'a> {
+    /// Lowercase tag name: `script`, `template`, `style`
+    pub tag: String,
+    /// Opening tag as written: `<script setup lang="ts">`
+    pub open_tag: &'a str,
+    /// Byte range of the content between the opening and closing tags
+    pub content: Range<usize>,
+    /// Byte range of the whole element, tags included
+    pub span: Range<usize>,
+}
+
+impl Block<'_> {
+    /// Value of an attribute, `Some("")` for a bare attribute such as
+    /// `setup`
+    pub fn attribute(&self, name: &str) -> Option<&str> {
+        let inner = self.open_tag.trim_start_matches('<').trim_end_matches('>');
+        let inner = inner.trim_end_matches('/');
+        let mut rest = inner[self.tag.len().min(inner.len())..].trim_start();
+
+        while !rest.is_empty() {
+            let key_end = rest
+                .find(|c: char| c.is_whitespace() || c == '=')
+                .unwrap_or(rest.len());
+            let key = &rest[..key_end];
+            rest = rest[key_end..].trim_start();
+
+            let value = if let Some(after) = rest.strip_prefix('=') {
+                let after = after.trim_start();
+                let (value, remainder) = match after.chars().next() {
+                    Some(quote @ ('"' | '\'')) => {
+                        let body = &after[1..];
+                        let end = body.find(quote).unwrap_or(body.len());
+                        (&body[..end], body.get(end + 1..).unwrap_or(""))
+                    }
+                    _ => {
+                        let end = after.find(char::is_whitespace).unwrap_or(after.len());
+                        (&after[..end], &after[end..])
+                    }
+                };
+                rest = remainder.trim_start();
+                value
+            } else {
+                ""
+            };
+
+            if key.eq_ignore_ascii_case(name) {
+                return Some(value);
+            }
+            if key.is_empty() {
+                break;
+            }
+        }
+        None
+    }
+}
+
+/// Byte offset just past the `>` closing the tag that starts at `start`,
+/// skipping `>` inside quoted attribute values
+pub(crate) fn tag_end(code: &str, start: usize) -> Option<usize> {
+    let bytes = code.as_bytes();
+    let mut quote = None;
+    for (offset, &byte) in bytes[start..].iter().enumerate() {
+        match (quote, byte) {
+            (Some(q), b) if b == q => quote = None,
+            (Some(_), _) => {}
+            (None, b'"' | b'\'') => quote = Some(byte),
+            (None, b'>') => return Some(start + offset + 1),
+            _ => {}
+        }
+    }
+    None
+}
+
+/// Tag name starting at `start` (just past `<` or `</`)
+fn tag_name(code: &str, start: usize) -> &str {
+    let rest = &code[start..];
+    let end = rest
+        .find(|c: char| !(c.is_ascii_alphanumeric() || c == '-' || c == ':' || c == '.'))
+        .unwrap_or(rest.len());
+    &rest[..end]
+}
+
+/// Whether `code[at..]` opens (`<tag`) or closes (`</tag`) an element named
+/// `tag`, case-insensitively
+pub(crate) fn is_tag_at(code: &str, at: usize, tag: &str) -> bool {
+    code.get(at..at + tag.len())
+        .is_some_and(|name| name.eq_ignore_ascii_case(tag))
+        && tag_name(code, at).len() == tag.len()
+}
+
+/// Start of the closing tag of the element named `tag` whose content starts
+/// at `from`, and the offset past it
+pub(crate) fn closing_tag(code: &str, from: usize, tag: &str) -> Option<(usize, usize)> {
+    let raw_text = RAW_TEXT_TAGS.contains(&tag);
+    let mut depth = 0usize;
+    let mut cursor = from;
+
+    while let Some(found) = code[cursor..].find('<') {
+        let at = cursor + found;
+        if code[at..].starts_with("<!--") && !raw_text {
+            cursor = code[at..]
+                .find("-->")
+                .map_or(code.len(), |end| at + end + 3);
+            continue;
+        }
+        if code[at..].starts_with("</") && is_tag_at(code, at + 2, tag) {
+            let end = tag_end(code, at)?;
+            if depth == 0 {
+                return Some((at, end));
+            }
+            depth -= 1;
+            cursor = end;
+            continue;
+        }
+        if !raw_text && is_tag_at(code, at + 1, tag) {
+            let end = tag_end(code, at)?;
+            if !code[..end].ends_with("/>") {
+                depth += 1;
+            }
+            cursor = end;
+            continue;
+        }
+        cursor = at + 1;
+    }
+    None
+}
+
+/// The top-level elements of a component file, in source order
+///
+/// Comments between elements are skipped and text outside elements is
+/// ignored. An element left unclosed runs to the end of the file.
+pub fn split(code: &str) -> Vec<Block<'_>> {
+    let mut blocks = Vec::new();
+    let mut cursor = 0;
+
+    while let Some(found) = code[cursor..].find('<') {
+        let start = cursor + found;
+        let rest = &code[start..];
+
+        if rest.starts_with("<!--") {
+            cursor = rest.find("-->").map_or(code.len(), |end| start + end + 3);
+            continue;
+        }
+        if !rest[1..].starts_with(|c: char| c.is_ascii_alphabetic()) {
+            cursor = start + 1;
+            continue;
+        }
+
+        let Some(open_end) = tag_end(code, start) else {
+            break;
+        };
+        let tag = tag_name(code, start + 1).to_ascii_lowercase();
+        let open_tag = &code[start..open_end];
+
+        if open_tag.ends_with("/>") {
+            blocks.push(Block {
+                tag,
+                open_tag,
+                content: open_end..open_end,
+                span: start..open_end,
+            });
+            cursor = open_end;
+            continue;
+        }
+
+        let (content_end, block_end) =
+            closing_tag(code, open_end, &tag).unwrap_or((code.len(), code.len()));
+        blocks.push(Block {
+            tag,
+            open_tag,
+            content: open_end..content_end,
+            span: start..block_end,
+        });
+        cursor = block_end;
+    }
+
+    blocks
+}
+
+/// `code` with every byte outside `keep` replaced by a space, line breaks
+/// excepted
+///
+/// The result has the length and line layout of `code`, so byte offsets and
+/// row/column positions in it are the same as in `code`.
+pub fn mask(code: &str, keep: &[Range<usize>]) -> String {
+    let mut bytes: Vec<u8> = code
+        .bytes()
+        .map(|byte| {
+            if matches!(byte, b'\n' | b'\r') {
+                byte
+            } else {
+                b' '
+            }
+        })
+        .collect();
+    for range in keep {
+        bytes[range.clone()].copy_from_slice(&code.as_bytes()[range.clone()]);
+    }
+    // Kept ranges start and end at ASCII tag delimiters, and everything else
+    // is ASCII whitespace
+    String::from_utf8(bytes).unwrap_or_default()
+}
+
+/// The slice of `code` at the offset `slice` has in `source`, a [`mask`]
+/// of `code`
+pub fn remap<'a>(code: &'a str, source: &str, slice: &str) -> Option<&'a str> {
+    let offset = (slice.as_ptr() as usize).checked_sub(source.as_ptr() as usize);
+    match offset {
+        Some(offset) if offset + slice.len() <= source.len() => {
+            code.get(offset..offset + slice.len())
+        }
+        // A name the script parser spelled itself rather than sliced
+        _ => code
+            .find(slice)
+            .map(|start| &code[start..start + slice.len()]),
+    }
+}
+
+/// [`remap`] for the relationships a script parser found in `source`
+pub fn remap_all<'a>(
+    code: &'a str,
+    source: &str,
+    found: Vec<(&str, &str, crate::Range)>,
+) -> Vec<(&'a str, &'a str, crate::Range)> {
+    found
+        .into_iter()
+        .filter_map(|(from, to, range)| {
+            Some((remap(code, source, from)?, remap(code, source, to)?, range))
+        })
+        .collect()
+}
+
+/// Text of an HTML comment directly above byte offset `before`, with
+/// nothing but whitespace in between
+pub fn leading_comment(code: &str, before: usize) -> Option<String> {
+    let preceding = code[..before].trim_end();
+    let body = preceding.strip_suffix("-->")?;
+    let start = body.rfind("<!--")?;
+    let doc = body[start + 4..]
+        .lines()
+        .map(str::trim)
+        .collect::<Vec<_>>()
+        .join("\n")
+        .trim()
+        .to_string();
+    (!doc.is_empty()).then_some(doc)
+}
+
+/// Tags of `code[span]` naming a component rather than an HTML element:
+/// `<UserAvatar>`, `<UserAvatar/>`, with the byte range of each name
+pub fn component_tags(code: &str, span: Range<usize>) -> Vec<(&str, Range<usize>)> {
+    let mut tags = Vec::new();
+    let mut cursor = span.start;
+
+    while let Some(found) = code[cursor..span.end].find('<') {
+        let at = cursor + found;
+        if code[at..].starts_with("<!--") {
+            cursor = code[at..].find("-->").map_or(span.end, |end| at + end + 3);
+            continue;
+        }
+        if code[at + 1..].starts_with(|c: char| c.is_ascii_uppercase()) {
+            let name = tag_name(code, at + 1);
+            tags.push((name, at + 1..at + 1 + name.len()));
+        }
+        cursor = at + 1;
+    }
+
+    tags
+}
+
+/// Component name for a file name, in PascalCase as templates write it:
+/// `user-card` / `user_card` / `UserCard` -> `UserCard`
+pub fn component_name(file_name: &str) -> String {
+    file_name
+        .split(['-', '_'])
+        .filter(|part| !part.is_empty())
+        .map(|part| {
+            let mut chars = part.chars();
+            chars
+                .next()
+                .map(|first| first.to_uppercase().chain(chars).collect::<String>())
+                .unwrap_or_default()
+        })
+        .collect()
+}
+
+/// Row and column range of the bytes `span` of `code`
+pub fn range_of(code: &str, span: Range<usize>) -> crate::Range {
+    let position = |offset: usize| {
+        let before = &code[..offset];
+        let row = before.matches('\n').count();
+        let column = before.len() - before.rfind('\n').map_or(0, |newline| newline + 1);
+        (row as u32, column as u16)
+    };
+    let (start_row, start_column) = position(span.start);
+    let (end_row, end_column) = position(span.end);
+    crate::Range::new(start_row, start_column, end_row, end_column)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const COMPONENT: &str = r#"<!-- A user card -->
+<script setup lang="ts">
+const html = "<div>";
+</script>
+
+<template>
+  <template v-if="ok"><span /></template>
+  <Child />
+</template>
+
+<style scoped>
+.card { color: red; }
+</style>
+"#;
+
+    #[test]
+    fn test_split_top_level_blocks() {
+        let blocks = split(COMPONENT);
+        let tags: Vec<&str> = blocks.iter().map(|block| block.tag.as_str()).collect();
+        assert_eq!(tags, vec!["script", "template", "style"]);
+
+        let script = &blocks[0];
+        assert_eq!(script.open_tag, r#"<script setup lang="ts">"#);
+        assert_eq!(
+            COMPONENT[script.content.clone()].trim(),
+            r#"const html = "<div>";"#
+        );
+
+        let template = &blocks[1];
+        assert!(COMPONENT[template.content.clone()].contains("<Child />"));
+        assert!(
+            COMPONENT[template.content.clone()]
+                .trim_end()
+                .ends_with("<Child />")
+        );
+    }
+
+    #[test]
+    fn test_attributes() {
+        let blocks = split(COMPONENT);
+        assert_eq!(blocks[0].attribute("lang"), Some("ts"));
+        assert_eq!(blocks[0].attribute("setup"), Some(""));
+        assert_eq!(blocks[0].attribute("src"), None);
+        assert_eq!(blocks[2].attribute("scoped"), Some(""));
+    }
+
+    #[test]
+    fn test_mask_keeps_positions() {
+        let blocks = split(COMPONENT);
+        let masked = mask(COMPONENT, &[blocks[0].content.clone()]);
+
+        assert_eq!(masked.len(), COMPONENT.len());
+        assert_eq!(masked.lines().count(), COMPONENT.lines().count());
+        let offset = COMPONENT.find("const html").unwrap();
+        assert_eq!(masked.find("const html"), Some(offset));
+        assert!(!masked.contains("<template>"));
+        assert!(!masked.contains(".card"));
+    }
+
+    #[test]
+    fn test_remap() {
+        let blocks = split(COMPONENT);
+        let masked = mask(COMPONENT, &[blocks[0].content.clone()]);
+        let offset = masked.find("html").unwrap();
+        let found = &masked[offset..offset + 4];
+
+        let remapped = remap(COMPONENT, &masked, found).unwrap();
+        assert_eq!(remapped, "html");
+        assert_eq!(remapped.as_ptr(), COMPONENT[offset..].as_ptr());
+    }
+
+    #[test]
+    fn test_leading_comment() {
+        let blocks = split(COMPONENT);
+        let script_start = COMPONENT.find("<script").unwrap();
+        assert_eq!(
+            leading_comment(COMPONENT, script_start),
+            Some("A user card".to_string())
+        );
+        let template_start = COMPONENT.find("<template>").unwrap();
+        assert_eq!(leading_comment(COMPONENT, template_start), None);
+        assert_eq!(blocks.len(), 3);
+    }
+
+    #[test]
+    fn test_component_tags() {
+        let blocks = split(COMPONENT);
+        let tags: Vec<&str> = component_tags(COMPONENT, blocks[1].content.clone())
+            .into_iter()
+            .map(|(name, _)| name)
+            .collect();
+        assert_eq!(tags, vec!["Child"]);
+    }
+
+    #[test]
+    fn test_component_name() {
+        assert_eq!(component_name("user-card"), "UserCard");
+        assert_eq!(component_name("UserCard"), "UserCard");
+        assert_eq!(component_name("base_button"), "BaseButton");
+    }
+
+    #[test]
+    fn test_range_of() {
+        let offset = COMPONENT.find("const html").unwrap();
+        let range = range_of(COMPONENT, offset..offset + 5);
+        assert_eq!(range, crate::Range::new(2, 0, 2, 5));
+    }
+}
@@ -0,0 +1,179 @@
+//! SQL-specific language behavior implementation
+//!
+//! SQL files have no module system; every file adds to one shared schema.
+//! A file's module path is its path from the project root without the
+//! extension, `db/migrations/001_users.sql` being `db/migrations/001_users`,
+//! which keeps migrations defining the same table apart.
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// SQL language behavior implementation
+#[derive(Clone)]
+pub struct SqlBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl SqlBehavior {
+    /// Create a new SQL behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_sequel::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for SqlBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for SqlBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for SqlBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("sql")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    /// Schema objects are visible to every connection with access to the
+    /// schema
+    fn parse_visibility(&self, _signature: &str) -> Visibility {
+        Visibility::Public
+    }
+
+    /// The synthetic `<module>` symbol takes the file's name
+    fn configure_symbol(&self, symbol: &mut crate::Symbol, module_path: Option<&str>) {
+        if let Some(path) = module_path {
+            symbol.module_path = Some(path.to_string().into());
+            if symbol.kind == crate::types::SymbolKind::Module {
+                let short = path.rsplit('/').next().unwrap_or(path);
+                symbol.name = crate::types::compact_string(short);
+            }
+        }
+    }
+
+    fn normalize_caller_name(&self, name: &str, file_id: FileId) -> String {
+        if name == "<module>" {
+            self.get_module_path_for_file(file_id)
+                .map(|path| path.rsplit('/').next().unwrap_or(&path).to_string())
+                .unwrap_or_else(|| name.to_string())
+        } else {
+            name.to_string()
+        }
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "/"
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &[]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("/"))
+        }
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::SqlResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    /// SQL files import nothing
+    fn normalize_import_path(
+        &self,
+        _import_path: &str,
+        _importing_module: Option<&str>,
+        _file_path: &Path,
+    ) -> Option<String> {
+        None
+    }
+
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        import_path == symbol_module_path
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_module_path_from_file() {
+        let behavior = SqlBehavior::new();
+        let root = Path::new("/project");
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/db/migrations/001_users.sql"),
+                root,
+                &["sql"]
+            ),
+            Some("db/migrations/001_users".to_string())
+        );
+    }
+
+    #[test]
+    fn test_module_symbol_takes_file_name() {
+        let behavior = SqlBehavior::new();
+        let mut symbol = crate::Symbol::new(
+            crate::SymbolId::new(1).unwrap(),
+            "<module>",
+            crate::SymbolKind::Module,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 10, 0),
+        );
+
+        behavior.configure_symbol(&mut symbol, Some("db/seed"));
+        assert_eq!(symbol.name.as_ref(), "seed");
+        assert_eq!(symbol.module_path.as_deref(), Some("db/seed"));
+    }
+}
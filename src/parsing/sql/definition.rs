@@ -0,0 +1,89 @@
+//! SQL language definition for the registry
+//!
+//! Provides the SQL language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{SqlBehavior, SqlParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// SQL language definition
+pub struct SqlLanguage;
+
+impl SqlLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("sql");
+}
+
+impl LanguageDefinition for SqlLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "SQL"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["sql"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = SqlParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(SqlBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // SQL is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // SQL is enabled by default
+    }
+}
+
+/// Register SQL language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(SqlLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_sql_definition() {
+        let sql = SqlLanguage;
+
+        assert_eq!(sql.id(), LanguageId::new("sql"));
+        assert_eq!(sql.name(), "SQL");
+        assert!(sql.extensions().contains(&"sql"));
+    }
+
+    #[test]
+    fn test_sql_enabled_by_default() {
+        let sql = SqlLanguage;
+        let settings = Settings::default();
+
+        assert!(sql.default_enabled());
+        assert!(sql.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_sql_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("sql")));
+    }
+}
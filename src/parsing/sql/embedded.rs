@@ -0,0 +1,534 @@
+//! SQL queries embedded in string literals
+//!
+//! Application code reaches the schema through query strings such as
+//! `cursor.execute("SELECT * FROM users WHERE id = %s")`. The scanner reads
+//! the string literals of any tree-sitter grammar, keeps those that read as
+//! a SQL statement and extracts the tables they name, so the enclosing
+//! function can be linked to the tables created by the project's `.sql`
+//! files.
+//!
+//! The scan is lexical, not a SQL parse. It follows `FROM`, `JOIN`, `INTO`,
+//! `UPDATE ... SET`, `ALTER`/`DROP TABLE`, `REFERENCES` and `CALL`, looks
+//! into subqueries but not into function arguments
+//! (`EXTRACT(YEAR FROM created_at)`), leaves out CTE names and ignores
+//! placeholders such as `{table}`, `%s` or `${table}`.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::{Range, RelationKind};
+use std::collections::HashSet;
+use tree_sitter::Node;
+
+/// Statements a query string starts with
+const QUERY_VERBS: &[&str] = &[
+    "select", "insert", "update", "delete", "with", "merge", "call",
+];
+
+/// Words ending a table list; anything else after a table is its alias
+const CLAUSE_KEYWORDS: &[&str] = &[
+    "where",
+    "join",
+    "left",
+    "right",
+    "inner",
+    "outer",
+    "full",
+    "cross",
+    "natural",
+    "on",
+    "using",
+    "group",
+    "order",
+    "limit",
+    "offset",
+    "fetch",
+    "having",
+    "union",
+    "except",
+    "intersect",
+    "window",
+    "returning",
+    "set",
+    "values",
+    "select",
+    "for",
+];
+
+/// Longest query text kept as relationship context
+const MAX_CONTEXT_LEN: usize = 120;
+
+/// A table or routine named by a query
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SqlReference {
+    /// Unqualified, unquoted name (`"public"."users"` -> `users`)
+    pub name: String,
+    /// `Uses` for tables and views, `Calls` for `CALL proc(...)`
+    pub kind: RelationKind,
+    /// Byte offset of `name` in the query text
+    pub offset: usize,
+}
+
+/// A reference found in a string literal of a host language
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct EmbeddedReference {
+    pub name: String,
+    pub kind: RelationKind,
+    /// Position of the name in the host file
+    pub range: Range,
+    /// The query, whitespace collapsed and shortened
+    pub query: String,
+}
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Token<'a> {
+    Word(&'a str, usize),
+    Punct(char),
+}
+
+impl<'a> Token<'a> {
+    fn word(&self) -> Option<&'a str> {
+        match self {
+            Token::Word(text, _) => Some(text),
+            Token::Punct(_) => None,
+        }
+    }
+
+    fn is_keyword(&self, keyword: &str) -> bool {
+        self.word().is_some_and(|w| w.eq_ignore_ascii_case(keyword))
+    }
+}
+
+/// Split a query into words and punctuation, dropping string literals,
+/// comments and numbers
+fn tokenize(query: &str) -> Vec<Token<'_>> {
+    let bytes = query.as_bytes();
+    let mut tokens = Vec::new();
+    let mut i = 0;
+
+    while i < bytes.len() {
+        let c = bytes[i] as char;
+        if c.is_ascii_whitespace() {
+            i += 1;
+        } else if c == '\'' {
+            // String literal, `''` escapes included
+            i += 1;
+            while i < bytes.len() && bytes[i] != b'\'' {
+                i += 1;
+            }
+            i += 1;
+        } else if c == '-' && bytes.get(i + 1) == Some(&b'-') {
+            while i < bytes.len() && bytes[i] != b'\n' {
+                i += 1;
+            }
+        } else if c.is_ascii_digit() {
+            while i < bytes.len() && (bytes[i] as char).is_ascii_alphanumeric() {
+                i += 1;
+            }
+        } else if c.is_alphabetic() || matches!(c, '_' | '"' | '`' | '[') || !c.is_ascii() {
+            let start = i;
+            loop {
+                match bytes.get(i).map(|&b| b as char) {
+                    Some(quote @ ('"' | '`' | '[')) => {
+                        let close = if quote == '[' { ']' } else { quote };
+                        i += 1;
+                        while i < bytes.len() && bytes[i] as char != close {
+                            i += 1;
+                        }
+                        i += 1;
+                    }
+                    Some(c) if c.is_alphanumeric() || matches!(c, '_' | '$' | '.') => i += 1,
+                    Some(c) if !c.is_ascii() => i += 1,
+                    _ => break,
+                }
+            }
+            let end = i.min(bytes.len());
+            // Multi-byte characters are consumed byte by byte; only cut on
+            // a boundary
+            if let Some(text) = query.get(start..end) {
+                tokens.push(Token::Word(text, start));
+            }
+        } else {
+            tokens.push(Token::Punct(c));
+            i += 1;
+        }
+    }
+
+    tokens
+}
+
+/// `"public"."users"` / `dbo.[Orders]` -> `users` / `Orders`
+pub(crate) fn object_name(word: &str) -> Option<String> {
+    let last = word.rsplit('.').next()?;
+    let name: String = last
+        .chars()
+        .filter(|c| !matches!(c, '"' | '`' | '[' | ']'))
+        .collect();
+    let valid = !name.is_empty()
+        && name
+            .chars()
+            .all(|c| c.is_alphanumeric() || matches!(c, '_' | '$'));
+    valid.then_some(name)
+}
+
+/// Offset of the statement keyword when a string literal reads as a SQL
+/// statement
+///
+/// Literal prefixes (`r#"`, `f"`, `@"`) are skipped. The keyword must be
+/// written in one case: `SELECT` and `select` start queries, `Select`
+/// starts a sentence.
+pub fn query_start(text: &str) -> Option<usize> {
+    statement_start(text, QUERY_VERBS)
+}
+
+/// Offset of `CREATE` when a string literal reads as a SQL definition
+/// (`CREATE TABLE`, `create view`), as [`query_start`] reads queries
+pub fn definition_start(text: &str) -> Option<usize> {
+    statement_start(text, &["create"])
+}
+
+fn statement_start(text: &str, verbs: &[&str]) -> Option<usize> {
+    let mut offset = 0;
+    let verb = loop {
+        let rest = &text[offset..];
+        let skipped = rest.len() - rest.trim_start_matches(|c: char| !c.is_alphabetic()).len();
+        offset += skipped;
+        let rest = &text[offset..];
+        let end = rest
+            .find(|c: char| !c.is_alphabetic())
+            .unwrap_or(rest.len());
+        let (word, after) = rest.split_at(end);
+        if word.len() <= 2 && after.starts_with(['"', '\'', '`', '#']) {
+            offset += end;
+            continue;
+        }
+        break word;
+    };
+
+    let one_case = verb.chars().all(|c| c.is_uppercase()) || verb.chars().all(|c| c.is_lowercase());
+    let is_verb = verbs
+        .iter()
+        .any(|keyword| verb.eq_ignore_ascii_case(keyword));
+    (one_case && is_verb).then_some(offset)
+}
+
+/// Cheap check whether a file may hold a query at all, to skip the
+/// literal walk for most files
+pub fn may_contain_query(code: &str) -> bool {
+    let lower = code.to_ascii_lowercase();
+    [
+        "select ",
+        "insert into",
+        "update ",
+        "delete from",
+        "merge into",
+        "call ",
+    ]
+    .iter()
+    .any(|phrase| lower.contains(phrase))
+}
+
+/// Tables and routines a query names, in order of first appearance
+pub fn find_references(query: &str) -> Vec<SqlReference> {
+    let tokens = tokenize(query);
+    let mut references: Vec<SqlReference> = Vec::new();
+
+    // `WITH recent AS (...)` names a query, not a table
+    let ctes: HashSet<String> = tokens
+        .windows(3)
+        .filter(|w| w[1].is_keyword("as") && w[2] == Token::Punct('('))
+        .filter_map(|w| w[0].word().and_then(object_name))
+        .map(|name| name.to_lowercase())
+        .collect();
+
+    // Records the name at `index`; false when no name is there
+    let mut add = |index: usize, kind: RelationKind| {
+        let Some(&Token::Word(word, offset)) = tokens.get(index) else {
+            return false;
+        };
+        if CLAUSE_KEYWORDS
+            .iter()
+            .any(|keyword| word.eq_ignore_ascii_case(keyword))
+        {
+            return false;
+        }
+        let Some(name) = object_name(word) else {
+            return false;
+        };
+        if kind == RelationKind::Uses && ctes.contains(&name.to_lowercase()) {
+            return true;
+        }
+        if !references.iter().any(|r| r.name == name && r.kind == kind) {
+            // The unquoted name is a substring of the word's last segment
+            let offset = offset + word.rfind(name.as_str()).unwrap_or(0);
+            references.push(SqlReference { name, kind, offset });
+        }
+        true
+    };
+    let opens_paren = |index: usize| tokens.get(index) == Some(&Token::Punct('('));
+
+    // One entry per open parenthesis: whether it opens a subquery
+    let mut parens: Vec<bool> = Vec::new();
+    for (i, token) in tokens.iter().enumerate() {
+        let in_query = parens.last().copied().unwrap_or(true);
+
+        match *token {
+            Token::Punct('(') => {
+                let subquery = tokens
+                    .get(i + 1)
+                    .is_some_and(|t| t.is_keyword("select") || t.is_keyword("with"));
+                parens.push(subquery);
+            }
+            Token::Punct(')') => {
+                parens.pop();
+            }
+            Token::Word(word, _) if in_query => match word.to_ascii_lowercase().as_str() {
+                keyword @ ("from" | "join") => {
+                    let mut table = i + 1;
+                    if tokens.get(table).is_some_and(|t| t.is_keyword("only")) {
+                        table += 1;
+                    }
+                    // `FROM unnest(...)` reads from a function. A table may
+                    // be followed by an alias and, after `FROM`, more tables.
+                    while !opens_paren(table + 1)
+                        && add(table, RelationKind::Uses)
+                        && keyword == "from"
+                    {
+                        let mut next = table + 1;
+                        if tokens.get(next).is_some_and(|t| t.is_keyword("as")) {
+                            next += 2;
+                        } else if tokens.get(next).and_then(|t| t.word()).is_some_and(|w| {
+                            !CLAUSE_KEYWORDS.iter().any(|k| w.eq_ignore_ascii_case(k))
+                        }) {
+                            next += 1;
+                        }
+                        if tokens.get(next) != Some(&Token::Punct(',')) {
+                            break;
+                        }
+                        table = next + 1;
+                    }
+                }
+                "into" | "references" => {
+                    add(i + 1, RelationKind::Uses);
+                }
+                // `ALTER TABLE t`, `DROP TABLE IF EXISTS t`, `TRUNCATE TABLE t`
+                "table"
+                    if i > 0
+                        && ["alter", "drop", "truncate", "lock"]
+                            .iter()
+                            .any(|keyword| tokens[i - 1].is_keyword(keyword)) =>
+                {
+                    let table = (i + 1..tokens.len())
+                        .find(|&index| {
+                            !["if", "exists", "only"]
+                                .iter()
+                                .any(|keyword| tokens[index].is_keyword(keyword))
+                        })
+                        .unwrap_or(tokens.len());
+                    add(table, RelationKind::Uses);
+                }
+                // `UPDATE t SET` / `UPDATE t AS x SET`, not `FOR UPDATE` or
+                // `DO UPDATE SET`
+                "update" => {
+                    let sets = (i + 2..=i + 4)
+                        .any(|index| tokens.get(index).is_some_and(|t| t.is_keyword("set")));
+                    let follows_clause = i > 0
+                        && ["for", "do", "key"]
+                            .iter()
+                            .any(|keyword| tokens[i - 1].is_keyword(keyword));
+                    if sets && !follows_clause {
+                        add(i + 1, RelationKind::Uses);
+                    }
+                }
+                "call" if opens_paren(i + 2) => {
+                    add(i + 1, RelationKind::Calls);
+                }
+                _ => {}
+            },
+            _ => {}
+        }
+    }
+
+    references
+}
+
+/// Whitespace-collapsed query, cut to `MAX_CONTEXT_LEN` characters
+fn query_context(query: &str) -> String {
+    let collapsed = query.split_whitespace().collect::<Vec<_>>().join(" ");
+    if collapsed.chars().count() <= MAX_CONTEXT_LEN {
+        collapsed
+    } else {
+        let cut: String = collapsed.chars().take(MAX_CONTEXT_LEN).collect();
+        format!("{cut}...")
+    }
+}
+
+/// Whether a node kind is a string literal in any of the grammars
+pub(crate) fn is_string_literal(kind: &str) -> bool {
+    kind.contains("string") || kind.contains("heredoc") || kind == "text_block"
+}
+
+/// Range of `len` bytes at `offset` into the text of `node`
+pub(crate) fn range_in_node(node: &Node, text: &str, offset: usize, len: usize) -> Range {
+    let start = node.start_position();
+    let before = &text[..offset];
+    let (row, column) = match before.rfind('\n') {
+        Some(newline) => (
+            start.row + before.matches('\n').count(),
+            offset - newline - 1,
+        ),
+        None => (start.row, start.column + offset),
+    };
+    Range::new(row as u32, column as u16, row as u32, (column + len) as u16)
+}
+
+/// Table and routine references of the SQL queries in `root`'s string
+/// literals
+pub fn find_embedded_references(root: Node, code: &str) -> Vec<EmbeddedReference> {
+    let mut references = Vec::new();
+    walk_literals(root, code, &mut references, 0);
+    references
+}
+
+fn walk_literals(node: Node, code: &str, references: &mut Vec<EmbeddedReference>, depth: usize) {
+    if !check_recursion_depth(depth, node) {
+        return;
+    }
+
+    // The outermost literal node holds the whole query, interpolations
+    // and concatenated parts included
+    if is_string_literal(node.kind()) {
+        let text = &code[node.byte_range()];
+        // Tokenizing from the keyword keeps the literal's own quotes out
+        if let Some(start) = query_start(text) {
+            let query_text = &text[start..];
+            let query = query_context(query_text.trim_end_matches(|c: char| !c.is_alphanumeric()));
+            for reference in find_references(query_text) {
+                let offset = start + reference.offset;
+                references.push(EmbeddedReference {
+                    range: range_in_node(&node, text, offset, reference.name.len()),
+                    name: reference.name,
+                    kind: reference.kind,
+                    query: query.clone(),
+                });
+            }
+        }
+        return;
+    }
+
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        walk_literals(child, code, references, depth + 1);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn names(query: &str) -> Vec<String> {
+        find_references(query).into_iter().map(|r| r.name).collect()
+    }
+
+    #[test]
+    fn test_tables_of_select() {
+        assert_eq!(
+            names("SELECT u.id FROM public.users u JOIN orders AS o ON o.user_id = u.id"),
+            vec!["users", "orders"]
+        );
+        assert_eq!(
+            names("select * from accounts a, \"Teams\" t where a.team = t.id"),
+            vec!["accounts", "Teams"]
+        );
+    }
+
+    #[test]
+    fn test_tables_of_writes() {
+        assert_eq!(
+            names("INSERT INTO audit_log (event) VALUES ('user FROM fake')"),
+            vec!["audit_log"]
+        );
+        assert_eq!(
+            names("UPDATE users SET email = $1 WHERE id = $2"),
+            vec!["users"]
+        );
+        assert_eq!(
+            names("DELETE FROM sessions WHERE expires_at < now()"),
+            vec!["sessions"]
+        );
+        // Row locks and upserts are not updates of another table
+        assert_eq!(
+            names(
+                "INSERT INTO stats (id) VALUES (1) ON CONFLICT (id) DO UPDATE SET hits = stats.hits + 1"
+            ),
+            vec!["stats"]
+        );
+        assert_eq!(
+            names("SELECT * FROM jobs FOR UPDATE SKIP LOCKED"),
+            vec!["jobs"]
+        );
+    }
+
+    #[test]
+    fn test_subqueries_ctes_and_functions() {
+        assert_eq!(
+            names(
+                "WITH recent AS (SELECT * FROM orders) SELECT EXTRACT(YEAR FROM created) FROM recent JOIN (SELECT id FROM customers) c ON true"
+            ),
+            vec!["orders", "customers"]
+        );
+        assert_eq!(
+            names("SELECT * FROM generate_series(1, 10)"),
+            Vec::<String>::new()
+        );
+        // Placeholders name no table
+        assert_eq!(names("SELECT * FROM {table} JOIN %s"), Vec::<String>::new());
+    }
+
+    #[test]
+    fn test_call_is_a_call() {
+        let references = find_references("CALL refresh_totals(42)");
+        assert_eq!(references.len(), 1);
+        assert_eq!(references[0].name, "refresh_totals");
+        assert_eq!(references[0].kind, RelationKind::Calls);
+    }
+
+    #[test]
+    fn test_query_start() {
+        assert_eq!(query_start("\"SELECT * FROM users\""), Some(1));
+        assert_eq!(query_start("r#\"\n  delete from sessions\"#"), Some(6));
+        assert_eq!(query_start("f'select * from {table}'"), Some(2));
+        assert_eq!(query_start("\"Select a file to upload\""), None);
+        assert_eq!(query_start("\"Deleted from cache\""), None);
+        assert_eq!(query_start("\"users\""), None);
+    }
+
+    #[test]
+    fn test_definition_start() {
+        assert_eq!(
+            definition_start("`\n  CREATE TABLE users (id INT)`"),
+            Some(4)
+        );
+        assert_eq!(
+            definition_start("\"create view active as select 1\""),
+            Some(1)
+        );
+        assert_eq!(definition_start("\"Create a new account\""), None);
+        assert_eq!(definition_start("\"SELECT * FROM users\""), None);
+    }
+
+    #[test]
+    fn test_references_in_host_literals() {
+        let code = "def load(db):\n    return db.execute(\"\"\"\n        SELECT * FROM users\n    \"\"\"\n    )\n";
+        let mut parser = tree_sitter::Parser::new();
+        parser
+            .set_language(&tree_sitter_python::LANGUAGE.into())
+            .unwrap();
+        let tree = parser.parse(code, None).unwrap();
+
+        let references = find_embedded_references(tree.root_node(), code);
+        assert_eq!(references.len(), 1);
+        assert_eq!(references[0].name, "users");
+        assert_eq!(references[0].kind, RelationKind::Uses);
+        assert_eq!(references[0].query, "SELECT * FROM users");
+        assert_eq!(references[0].range, Range::new(2, 22, 2, 27));
+    }
+}
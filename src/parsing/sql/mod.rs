@@ -0,0 +1,22 @@
+//! SQL language parser implementation
+//!
+//! Indexes the tables, columns, views, types and routines created by `.sql`
+//! files, and the tables each definition or migration statement reads and
+//! writes. The [`embedded`] scanner finds the same references in query
+//! strings of other languages, so "where do we touch table X" covers
+//! application code as well as the schema.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod embedded;
+pub mod parser;
+pub mod resolution;
+
+pub use behavior::SqlBehavior;
+pub use definition::SqlLanguage;
+pub use parser::SqlParser;
+pub use resolution::SqlResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
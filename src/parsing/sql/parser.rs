@@ -0,0 +1,651 @@
+//! SQL language parser implementation
+//!
+//! Extracts schema objects and the tables statements touch using
+//! tree-sitter-sequel.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | SQL file | Module (`<module>`, renamed to the file name) |
+//! | `CREATE TABLE` | Struct |
+//! | table columns | Field |
+//! | `CREATE VIEW` / `CREATE MATERIALIZED VIEW` | TypeAlias |
+//! | `CREATE FUNCTION` / `CREATE PROCEDURE` | Function |
+//! | `CREATE TYPE ... AS ENUM` | Enum |
+//! | `CREATE TYPE ... AS (...)` | Struct |
+//!
+//! Schema objects are global to the database and carry a global scope;
+//! schema-qualified names (`billing.invoices`) are indexed by their last
+//! part.
+//!
+//! ## Relationships
+//!
+//! Tables, views and functions record the tables they read or write
+//! (`FROM`, `JOIN`, `INSERT INTO`, `UPDATE`, `REFERENCES`) as uses, and
+//! `CALL proc()` as calls. Statements outside definitions, such as data
+//! migrations, are attributed to the file. References are read lexically
+//! with the scanner also used for queries embedded in other languages (see
+//! [`super::embedded`]), so procedural bodies the grammar does not parse
+//! still contribute their tables.
+//!
+//! ## Documentation
+//!
+//! `--` and `/* */` comments directly above a statement are its doc comment.
+
+use super::embedded;
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, NodeTracker, NodeTrackingState, ParserContext,
+    ScopeType,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, RelationKind, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Owner of statements outside any definition
+const MODULE_SCOPE: &str = "<module>";
+
+/// Statement kinds that create a named schema object
+const DEFINITION_KINDS: &[&str] = &[
+    "create_table",
+    "create_view",
+    "create_materialized_view",
+    "create_function",
+    "create_procedure",
+    "create_type",
+];
+
+/// SQL-specific parsing errors
+#[derive(Error, Debug)]
+pub enum SqlParseError {
+    #[error(
+        "Failed to initialize SQL parser: {reason}\nSuggestion: Ensure tree-sitter-sequel is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// A schema object created by a statement
+struct Definition<'a> {
+    name: &'a str,
+    kind: SymbolKind,
+    signature: String,
+}
+
+/// SQL language parser
+pub struct SqlParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for SqlParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("SqlParser")
+            .field("language", &"SQL")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// `"public"."Users"` -> `Users`, as a slice of the source
+fn unquoted_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    let text = &code[node.byte_range()];
+    let name = embedded::object_name(text)?;
+    let start = node.start_byte() + text.rfind(name.as_str())?;
+    Some(&code[start..start + name.len()])
+}
+
+/// Whitespace-collapsed text
+fn collapse(text: &str) -> String {
+    text.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+/// A routine up to its body: `CREATE FUNCTION total(a int) RETURNS int`
+fn routine_header(text: &str) -> String {
+    // ASCII lowercasing keeps byte offsets
+    let lower = text.to_ascii_lowercase();
+    let end = [" as ", " as\n", "\nas ", " begin", "\nbegin", "$"]
+        .iter()
+        .filter_map(|marker| lower.find(marker))
+        .min()
+        .unwrap_or(text.len());
+    collapse(&text[..end])
+}
+
+fn has_child_kind(node: &Node, kind: &str) -> bool {
+    let mut cursor = node.walk();
+    node.children(&mut cursor).any(|child| child.kind() == kind)
+}
+
+/// `CREATE [OR REPLACE] PROCEDURE name` read from text the grammar could
+/// not parse
+fn lexical_definition<'a>(node: &Node, code: &'a str) -> Option<Definition<'a>> {
+    let text = &code[node.byte_range()];
+    let mut words = text
+        .split_whitespace()
+        .map(|word| word.trim_end_matches(|c: char| c == '(' || c == ';'));
+    if !words.next()?.eq_ignore_ascii_case("create") {
+        return None;
+    }
+    let kind = loop {
+        let word = words.next()?.to_ascii_lowercase();
+        match word.as_str() {
+            "or" | "replace" | "definer" => {}
+            "function" | "procedure" => break SymbolKind::Function,
+            _ => return None,
+        }
+    };
+    let word = words.next()?;
+    let name = embedded::object_name(word.split('(').next()?)?;
+
+    let start = node.start_byte() + text.find(word)? + word.rfind(name.as_str())?;
+    Some(Definition {
+        name: &code[start..start + name.len()],
+        kind,
+        signature: routine_header(text),
+    })
+}
+
+impl SqlParser {
+    /// Create a new SQL parser instance
+    pub fn new() -> Result<Self, SqlParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_sequel::LANGUAGE.into())
+            .map_err(|e| SqlParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn create_symbol(
+        &self,
+        counter: &mut SymbolCounter,
+        name: &str,
+        kind: SymbolKind,
+        file_id: FileId,
+        range: Range,
+        signature: String,
+        doc_comment: Option<String>,
+        scope: Option<ScopeContext>,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(counter.next_id(), name, kind, file_id, range)
+            .with_signature(signature)
+            .with_visibility(Visibility::Public);
+        if let Some(doc) = doc_comment {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(scope.unwrap_or_else(|| self.context.current_scope_context()));
+        symbol
+    }
+
+    /// Top-level statements, with transactions unwrapped
+    fn collect_statements<'t>(node: Node<'t>, statements: &mut Vec<Node<'t>>, depth: usize) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            match child.kind() {
+                "comment" | "marginalia" => {}
+                "transaction" | "block" => Self::collect_statements(child, statements, depth + 1),
+                _ => statements.push(child),
+            }
+        }
+    }
+
+    /// The `create_*` node of a statement
+    fn definition_node<'t>(statement: Node<'t>) -> Option<Node<'t>> {
+        if DEFINITION_KINDS.contains(&statement.kind()) {
+            return Some(statement);
+        }
+        let mut cursor = statement.walk();
+        statement
+            .named_children(&mut cursor)
+            .find(|child| DEFINITION_KINDS.contains(&child.kind()))
+    }
+
+    /// The schema object a statement creates
+    fn definition<'a>(statement: &Node, code: &'a str) -> Option<Definition<'a>> {
+        if statement.is_error() {
+            return lexical_definition(statement, code);
+        }
+        let node = Self::definition_node(*statement)?;
+
+        let mut cursor = node.walk();
+        let name_node = node
+            .named_children(&mut cursor)
+            .find(|child| child.kind() == "object_reference")?;
+        let name = unquoted_name(&name_node, code)?;
+
+        let kind = match node.kind() {
+            "create_table" => SymbolKind::Struct,
+            "create_view" | "create_materialized_view" => SymbolKind::TypeAlias,
+            "create_type" if has_child_kind(&node, "keyword_enum") => SymbolKind::Enum,
+            "create_type" => SymbolKind::Struct,
+            _ => SymbolKind::Function,
+        };
+
+        // Tables, views and types are named by their header
+        // (`CREATE TABLE users`), routines by their parameters and result
+        let signature = if kind == SymbolKind::Function {
+            routine_header(&code[node.byte_range()])
+        } else {
+            collapse(&code[node.start_byte()..name_node.end_byte()])
+        };
+
+        Some(Definition {
+            name,
+            kind,
+            signature,
+        })
+    }
+
+    /// Extract symbols from the statements of a file
+    fn extract_statements(
+        &mut self,
+        root: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let mut statements = Vec::new();
+        Self::collect_statements(root, &mut statements, 0);
+
+        for statement in statements {
+            let Some(definition) = Self::definition(&statement, code) else {
+                continue;
+            };
+            let node = Self::definition_node(statement).unwrap_or(statement);
+            if !node.is_error() {
+                self.register_handled_node(node.kind(), node.kind_id());
+            }
+
+            let symbol = self.create_symbol(
+                counter,
+                definition.name,
+                definition.kind,
+                file_id,
+                range_from_node(&statement),
+                definition.signature,
+                self.extract_doc_comment(&statement, code),
+                Some(ScopeContext::Global),
+            );
+            symbols.push(symbol);
+
+            if node.kind() == "create_table" {
+                self.context.enter_scope(ScopeType::Class);
+                self.context
+                    .set_current_class(Some(definition.name.to_string()));
+                self.extract_columns(node, code, file_id, counter, symbols, 0);
+                self.context.exit_scope();
+                self.context.set_current_class(None);
+            }
+        }
+    }
+
+    /// Columns of a `CREATE TABLE`
+    fn extract_columns(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            match child.kind() {
+                "column_definition" => {
+                    self.register_handled_node(child.kind(), child.kind_id());
+                    let Some(name_node) = child
+                        .child_by_field_name("name")
+                        .or_else(|| child.named_child(0))
+                    else {
+                        continue;
+                    };
+                    let Some(name) = unquoted_name(&name_node, code) else {
+                        continue;
+                    };
+                    let symbol = self.create_symbol(
+                        counter,
+                        name,
+                        SymbolKind::Field,
+                        file_id,
+                        range_from_node(&child),
+                        collapse(&code[child.byte_range()]),
+                        self.extract_doc_comment(&child, code),
+                        None,
+                    );
+                    symbols.push(symbol);
+                }
+                "column_definitions" => {
+                    self.extract_columns(child, code, file_id, counter, symbols, depth + 1);
+                }
+                _ => {}
+            }
+        }
+    }
+
+    /// Doc comment of the file: the comment block opening it
+    fn file_doc_comment(root: &Node, code: &str) -> Option<String> {
+        let first = root.named_child(0)?;
+        if !matches!(first.kind(), "comment" | "marginalia") {
+            return None;
+        }
+        let mut lines = vec![Self::comment_text(&first, code)];
+        let mut last_row = first.end_position().row;
+        let mut current = first.next_named_sibling();
+        while let Some(next) = current {
+            if !matches!(next.kind(), "comment" | "marginalia")
+                || next.start_position().row != last_row + 1
+            {
+                // A comment block directly above a statement documents it
+                if next.start_position().row == last_row + 1 {
+                    return None;
+                }
+                break;
+            }
+            lines.push(Self::comment_text(&next, code));
+            last_row = next.end_position().row;
+            current = next.next_named_sibling();
+        }
+
+        let doc = lines.join("\n").trim().to_string();
+        (!doc.is_empty()).then_some(doc)
+    }
+
+    /// `-- text` / `/* text */` -> `text`
+    fn comment_text(node: &Node, code: &str) -> String {
+        let text = &code[node.byte_range()];
+        if let Some(line) = text.strip_prefix("--") {
+            return line.trim().to_string();
+        }
+        text.trim_start_matches("/*")
+            .trim_end_matches("*/")
+            .lines()
+            .map(|line| line.trim().trim_start_matches('*').trim())
+            .collect::<Vec<_>>()
+            .join("\n")
+            .trim()
+            .to_string()
+    }
+
+    /// References of every statement, with the definition owning them
+    fn statement_references<'a>(
+        &mut self,
+        code: &'a str,
+        kind: RelationKind,
+    ) -> Vec<(&'a str, &'a str, Range)> {
+        let mut references = Vec::new();
+        let Some(tree) = self.parser.parse(code, None) else {
+            return references;
+        };
+        let mut statements = Vec::new();
+        Self::collect_statements(tree.root_node(), &mut statements, 0);
+
+        for statement in statements {
+            let owner = Self::definition(&statement, code)
+                .map(|definition| definition.name)
+                .unwrap_or(MODULE_SCOPE);
+            let text = &code[statement.byte_range()];
+            for reference in embedded::find_references(text) {
+                // A recursive view or function names itself
+                if reference.kind != kind || reference.name == owner {
+                    continue;
+                }
+                let start = statement.start_byte() + reference.offset;
+                let name = &code[start..start + reference.name.len()];
+                let range = embedded::range_in_node(&statement, text, reference.offset, name.len());
+                references.push((owner, name, range));
+            }
+        }
+        references
+    }
+}
+
+impl LanguageParser for SqlParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+
+        let mut symbols = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            let root = tree.root_node();
+
+            // The file owns statements outside definitions. SqlBehavior
+            // renames it to the file's name during indexing.
+            let mut module_symbol = Symbol::new(
+                symbol_counter.next_id(),
+                MODULE_SCOPE,
+                SymbolKind::Module,
+                file_id,
+                range_from_node(&root),
+            );
+            if let Some(doc) = Self::file_doc_comment(&root, code) {
+                module_symbol = module_symbol.with_doc(doc);
+            }
+            module_symbol.scope_context = Some(ScopeContext::Module);
+            symbols.push(module_symbol);
+
+            self.extract_statements(root, code, file_id, symbol_counter, &mut symbols);
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// Comment lines directly above the statement, without a blank line
+    /// between
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let mut lines = Vec::new();
+        let mut next_row = node.start_position().row;
+        let mut current = node.prev_named_sibling();
+        while let Some(prev) = current {
+            if !matches!(prev.kind(), "comment" | "marginalia")
+                || prev.end_position().row + 1 != next_row
+            {
+                break;
+            }
+            lines.push(Self::comment_text(&prev, code));
+            next_row = prev.start_position().row;
+            current = prev.prev_named_sibling();
+        }
+
+        if lines.is_empty() {
+            return None;
+        }
+        lines.reverse();
+        let doc = lines.join("\n").trim().to_string();
+        (!doc.is_empty()).then_some(doc)
+    }
+
+    /// `CALL proc()` statements
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        self.statement_references(code, RelationKind::Calls)
+    }
+
+    fn find_implementations<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        // Schema objects implement nothing
+        Vec::new()
+    }
+
+    /// Tables and views read or written by each definition
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        self.statement_references(code, RelationKind::Uses)
+    }
+
+    fn find_defines<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        Vec::new()
+    }
+
+    fn find_imports(&mut self, _code: &str, _file_id: FileId) -> Vec<Import> {
+        // SQL files share one schema and import nothing
+        Vec::new()
+    }
+
+    fn language(&self) -> Language {
+        Language::Sql
+    }
+}
+
+impl NodeTracker for SqlParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = SqlParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(SqlParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_table_and_columns() {
+        let symbols = parse(
+            "-- Registered accounts\nCREATE TABLE public.users (\n    id serial PRIMARY KEY,\n    email text NOT NULL\n);\n",
+        );
+
+        let users = find(&symbols, "users");
+        assert_eq!(users.kind, SymbolKind::Struct);
+        assert_eq!(
+            users.signature.as_deref(),
+            Some("CREATE TABLE public.users")
+        );
+        assert_eq!(users.doc_comment.as_deref(), Some("Registered accounts"));
+        assert_eq!(users.scope_context, Some(ScopeContext::Global));
+
+        let email = find(&symbols, "email");
+        assert_eq!(email.kind, SymbolKind::Field);
+        assert_eq!(email.signature.as_deref(), Some("email text NOT NULL"));
+        assert_eq!(
+            email.scope_context,
+            Some(ScopeContext::ClassMember {
+                class_name: Some("users".into())
+            })
+        );
+    }
+
+    #[test]
+    fn test_views_types_and_functions() {
+        let code = r#"
+CREATE VIEW active_users AS SELECT * FROM users WHERE active;
+
+CREATE TYPE mood AS ENUM ('happy', 'sad');
+
+CREATE FUNCTION order_total(order_id integer) RETURNS numeric AS $$
+    SELECT sum(amount) FROM line_items WHERE line_items.order_id = order_id;
+$$ LANGUAGE sql;
+"#;
+        let symbols = parse(code);
+
+        assert_eq!(find(&symbols, "active_users").kind, SymbolKind::TypeAlias);
+        assert_eq!(find(&symbols, "mood").kind, SymbolKind::Enum);
+
+        let total = find(&symbols, "order_total");
+        assert_eq!(total.kind, SymbolKind::Function);
+        assert_eq!(
+            total.signature.as_deref(),
+            Some("CREATE FUNCTION order_total(order_id integer) RETURNS numeric")
+        );
+    }
+
+    #[test]
+    fn test_definitions_use_tables() {
+        let code = r#"
+CREATE TABLE orders (id int, user_id int REFERENCES users(id));
+CREATE VIEW recent_orders AS SELECT * FROM orders o JOIN users u ON u.id = o.user_id;
+INSERT INTO audit_log (event) VALUES ('seeded');
+"#;
+        let mut parser = SqlParser::new().unwrap();
+        let uses = parser.find_uses(code);
+
+        for (owner, table) in [
+            ("orders", "users"),
+            ("recent_orders", "orders"),
+            ("recent_orders", "users"),
+            (MODULE_SCOPE, "audit_log"),
+        ] {
+            assert!(
+                uses.iter().any(|(o, t, _)| *o == owner && *t == table),
+                "{owner} should use {table}, got {uses:?}"
+            );
+        }
+    }
+
+    #[test]
+    fn test_lexical_procedure_header() {
+        let code =
+            "CREATE OR REPLACE PROCEDURE archive_orders()\nLANGUAGE plpgsql AS $$ BEGIN END $$;";
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_sequel::LANGUAGE.into())
+            .unwrap();
+        let tree = parser.parse(code, None).unwrap();
+
+        let definition = lexical_definition(&tree.root_node(), code).unwrap();
+        assert_eq!(definition.name, "archive_orders");
+        assert_eq!(definition.kind, SymbolKind::Function);
+        assert_eq!(
+            definition.signature,
+            "CREATE OR REPLACE PROCEDURE archive_orders() LANGUAGE plpgsql"
+        );
+    }
+}
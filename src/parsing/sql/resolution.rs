@@ -0,0 +1,213 @@
+//! SQL-specific symbol resolution
+//!
+//! A schema is shared by every file that migrates it, so tables, views and
+//! functions are global names. Resolution checks:
+//! - Function parameters
+//! - Objects created in the file itself
+//! - Objects created by other files of the project
+
+use crate::parsing::resolution::{ImportBinding, default_compatible_relationship};
+use crate::parsing::{Import, ResolutionScope, ScopeLevel, ScopeType};
+use crate::{FileId, RelationKind, SymbolId};
+use std::collections::HashMap;
+
+/// SQL resolution context
+///
+/// Tracks parameter, file-level and project-level scopes.
+pub struct SqlResolutionContext {
+    #[allow(dead_code)]
+    file_id: FileId,
+
+    /// Function parameters
+    local_scope: HashMap<String, SymbolId>,
+
+    /// Objects created in this file
+    module_scope: HashMap<String, SymbolId>,
+
+    /// Objects created by other files
+    imported_scope: HashMap<String, SymbolId>,
+
+    /// Scope stack for nested contexts
+    scope_stack: Vec<ScopeType>,
+
+    /// Import bindings keyed by visible name
+    import_bindings: HashMap<String, ImportBinding>,
+}
+
+impl SqlResolutionContext {
+    pub fn new(file_id: FileId) -> Self {
+        Self {
+            file_id,
+            local_scope: HashMap::new(),
+            module_scope: HashMap::new(),
+            imported_scope: HashMap::new(),
+            scope_stack: Vec::new(),
+            import_bindings: HashMap::new(),
+        }
+    }
+}
+
+impl ResolutionScope for SqlResolutionContext {
+    fn as_any_mut(&mut self) -> &mut dyn std::any::Any {
+        self
+    }
+
+    fn resolve(&self, name: &str) -> Option<SymbolId> {
+        if let Some(&id) = self.local_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.module_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.imported_scope.get(name) {
+            return Some(id);
+        }
+
+        None
+    }
+
+    fn add_symbol(&mut self, name: String, symbol_id: SymbolId, scope_level: ScopeLevel) {
+        match scope_level {
+            ScopeLevel::Local => {
+                self.local_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Module | ScopeLevel::Global => {
+                self.module_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Package => {
+                self.imported_scope.insert(name, symbol_id);
+            }
+        }
+    }
+
+    fn enter_scope(&mut self, scope_type: ScopeType) {
+        self.scope_stack.push(scope_type);
+    }
+
+    fn exit_scope(&mut self) {
+        if let Some(scope) = self.scope_stack.pop() {
+            if matches!(scope, ScopeType::Function { .. }) {
+                self.clear_local_scope();
+            }
+        }
+    }
+
+    fn clear_local_scope(&mut self) {
+        self.local_scope.clear();
+    }
+
+    fn symbols_in_scope(&self) -> Vec<(String, SymbolId, ScopeLevel)> {
+        let mut symbols = Vec::new();
+
+        for (name, &id) in &self.local_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Local));
+        }
+        for (name, &id) in &self.module_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Module));
+        }
+        for (name, &id) in &self.imported_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Package));
+        }
+
+        symbols
+    }
+
+    fn resolve_relationship(
+        &self,
+        _from_name: &str,
+        to_name: &str,
+        _kind: RelationKind,
+        _from_file: FileId,
+    ) -> Option<SymbolId> {
+        self.resolve(to_name)
+    }
+
+    fn populate_imports(&mut self, _imports: &[Import]) {
+        // SQL files import nothing; objects of other files are resolved
+        // through the index
+    }
+
+    fn register_import_binding(&mut self, binding: ImportBinding) {
+        self.import_bindings
+            .insert(binding.exposed_name.clone(), binding);
+    }
+
+    fn import_binding(&self, name: &str) -> Option<ImportBinding> {
+        self.import_bindings.get(name).cloned()
+    }
+
+    /// Views are queries over tables and other views, and foreign keys make
+    /// a table use the table it references.
+    fn is_compatible_relationship(
+        &self,
+        from_kind: crate::SymbolKind,
+        to_kind: crate::SymbolKind,
+        rel_kind: crate::RelationKind,
+    ) -> bool {
+        use crate::RelationKind::*;
+        use crate::SymbolKind::*;
+
+        match rel_kind {
+            Uses if from_kind == TypeAlias => matches!(to_kind, Struct | TypeAlias | Function),
+            UsedBy if to_kind == TypeAlias => matches!(from_kind, Struct | TypeAlias | Function),
+            _ => default_compatible_relationship(from_kind, to_kind, rel_kind),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_scope_resolution_order() {
+        let mut context = SqlResolutionContext::new(FileId::new(1).unwrap());
+
+        let local_id = SymbolId::new(1).unwrap();
+        let module_id = SymbolId::new(2).unwrap();
+        let import_id = SymbolId::new(3).unwrap();
+
+        context.add_symbol("x".to_string(), import_id, ScopeLevel::Package);
+        context.add_symbol("x".to_string(), module_id, ScopeLevel::Module);
+        context.add_symbol("x".to_string(), local_id, ScopeLevel::Local);
+
+        assert_eq!(context.resolve("x"), Some(local_id));
+        context.clear_local_scope();
+        assert_eq!(context.resolve("x"), Some(module_id));
+    }
+
+    #[test]
+    fn test_local_scope_ends_with_function() {
+        let mut context = SqlResolutionContext::new(FileId::new(1).unwrap());
+        let id = SymbolId::new(4).unwrap();
+
+        context.enter_scope(ScopeType::function());
+        context.add_symbol("amount".to_string(), id, ScopeLevel::Local);
+        assert_eq!(context.resolve("amount"), Some(id));
+
+        context.exit_scope();
+        assert_eq!(context.resolve("amount"), None);
+    }
+
+    #[test]
+    fn test_views_use_tables() {
+        let context = SqlResolutionContext::new(FileId::new(1).unwrap());
+
+        assert!(context.is_compatible_relationship(
+            crate::SymbolKind::TypeAlias,
+            crate::SymbolKind::Struct,
+            RelationKind::Uses
+        ));
+        // Foreign keys
+        assert!(context.is_compatible_relationship(
+            crate::SymbolKind::Struct,
+            crate::SymbolKind::Struct,
+            RelationKind::Uses
+        ));
+        assert!(!context.is_compatible_relationship(
+            crate::SymbolKind::TypeAlias,
+            crate::SymbolKind::Field,
+            RelationKind::Uses
+        ));
+    }
+}
@@ -0,0 +1,432 @@
+//! Notable string literals
+//!
+//! Most string literals are keys, names and fragments, but four kinds say
+//! where a behavior lives: the URLs code calls, the HTTP routes it serves,
+//! the format strings of its logs and errors, and its messages. A literal
+//! is one of them when its text reads as one:
+//!
+//! | Kind | Text |
+//! |------|------|
+//! | `url` | `http://`, `https://`, `ws://`, `wss://` or `ftp://` and no spaces |
+//! | `route` | a path from `/`, outside the filesystem roots (`/usr`, `/tmp`) |
+//! | `format` | words with placeholders: `{}`, `{name}`, `%s`, `%d`, `${name}` |
+//! | `message` | two words or more of prose, on one line |
+//!
+//! Literals are found by node kind as [`crate::parsing::sql::embedded`]
+//! finds queries, the outermost of nested ones; SQL, docstrings, multi-line
+//! text and literals over [`MAX_LEN`] characters are left out. The indexer
+//! runs it when `indexing.string_literals` is on.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::sql::embedded::{definition_start, is_string_literal, query_start};
+use crate::{FileId, Range, SymbolId};
+use serde::{Deserialize, Serialize};
+use tree_sitter::Node;
+
+/// Longest literal text indexed, in characters
+pub const MAX_LEN: usize = 300;
+
+/// Shortest message, in characters
+const MIN_MESSAGE_LEN: usize = 10;
+
+const URL_SCHEMES: &[&str] = &["http://", "https://", "ws://", "wss://", "ftp://"];
+
+/// First segments of filesystem paths, which are not routes
+const FILESYSTEM_ROOTS: &[&str] = &[
+    "bin",
+    "boot",
+    "dev",
+    "etc",
+    "home",
+    "lib",
+    "mnt",
+    "opt",
+    "private",
+    "proc",
+    "root",
+    "run",
+    "sbin",
+    "sys",
+    "tmp",
+    "usr",
+    "var",
+    "Applications",
+    "Library",
+    "System",
+    "Users",
+    "Volumes",
+];
+
+/// Conversions closing a printf placeholder
+const PRINTF_CONVERSIONS: &str = "sdifuxXoeEgGcpqvrT@";
+
+/// What a notable literal is
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum StringKind {
+    Url,
+    Route,
+    Format,
+    Message,
+}
+
+impl StringKind {
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            StringKind::Url => "url",
+            StringKind::Route => "route",
+            StringKind::Format => "format",
+            StringKind::Message => "message",
+        }
+    }
+
+    /// The kind named `name`, case-insensitive
+    pub fn from_name(name: &str) -> Option<Self> {
+        match name.to_ascii_lowercase().as_str() {
+            "url" => Some(StringKind::Url),
+            "route" => Some(StringKind::Route),
+            "format" => Some(StringKind::Format),
+            "message" => Some(StringKind::Message),
+            _ => None,
+        }
+    }
+}
+
+/// A notable literal as found in the source
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct StringLiteral {
+    pub kind: StringKind,
+    /// The text between the quotes, escapes as written
+    pub text: String,
+    /// Zero-based, like symbol ranges
+    pub line: u32,
+    pub column: u16,
+}
+
+/// A notable literal of the index, with the symbol holding it
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct IndexedString {
+    pub kind: StringKind,
+    pub text: String,
+    pub file_id: FileId,
+    pub file_path: String,
+    /// Zero-based, like symbol ranges
+    pub line: u32,
+    pub column: u16,
+    pub symbol_id: Option<SymbolId>,
+}
+
+/// The text of a literal between its quotes, past prefixes (`r#"`, `f'`,
+/// `@"`, `$"`) and triple quotes; None for literals not quoted
+fn literal_body(text: &str) -> Option<&str> {
+    let quote = text.find(['"', '\'', '`'])?;
+    let (prefix, rest) = text.split_at(quote);
+    if prefix.len() > 3
+        || !prefix
+            .chars()
+            .all(|c| c.is_ascii_alphanumeric() || matches!(c, '@' | '$' | '#'))
+    {
+        return None;
+    }
+    let mark = rest.chars().next()?;
+    let body = rest
+        .trim_start_matches(mark)
+        .trim_end_matches('#')
+        .trim_end_matches(mark);
+    Some(body.trim_end_matches("\\n"))
+}
+
+/// Length of the placeholder starting `text`, if one does
+fn placeholder_len(text: &str) -> Option<usize> {
+    let mut chars = text.char_indices();
+    match chars.next()?.1 {
+        '{' => {
+            let close = text.find('}')?;
+            let inner = &text[1..close];
+            inner
+                .chars()
+                .all(|c| c.is_alphanumeric() || "_:?.<>^#+-".contains(c))
+                .then_some(close + 1)
+        }
+        '$' | '#' if text[1..].starts_with('{') => text.find('}').map(|close| close + 1),
+        '%' => {
+            for (offset, c) in chars {
+                if PRINTF_CONVERSIONS.contains(c) {
+                    return Some(offset + 1);
+                }
+                if !(c.is_ascii_digit() || "-+#.*l".contains(c)) {
+                    return None;
+                }
+            }
+            None
+        }
+        _ => None,
+    }
+}
+
+/// The text of a format string around its placeholders, in order; the
+/// whole text when it has none
+pub fn template_segments(text: &str) -> Vec<&str> {
+    let mut segments = Vec::new();
+    let mut start = 0;
+    let mut offset = 0;
+    while offset < text.len() {
+        let rest = &text[offset..];
+        // `{{` and `%%` escape the character
+        if rest.starts_with("{{") || rest.starts_with("%%") {
+            offset += 2;
+            continue;
+        }
+        if let Some(len) = placeholder_len(rest) {
+            segments.push(&text[start..offset]);
+            offset += len;
+            start = offset;
+            continue;
+        }
+        offset += rest.chars().next().map_or(1, char::len_utf8);
+    }
+    segments.push(&text[start..]);
+    segments.retain(|segment| !segment.is_empty());
+    segments
+}
+
+/// Words of letters only, past their punctuation
+fn prose_words(text: &str) -> usize {
+    text.split_whitespace()
+        .map(|word| word.trim_matches(|c: char| c.is_ascii_punctuation()))
+        .filter(|word| !word.is_empty() && word.chars().all(char::is_alphabetic))
+        .count()
+}
+
+fn is_url(body: &str) -> bool {
+    URL_SCHEMES.iter().any(|scheme| {
+        body.strip_prefix(scheme)
+            .is_some_and(|rest| rest.len() > 2 && !rest.contains(char::is_whitespace))
+    })
+}
+
+fn is_route(body: &str) -> bool {
+    let Some(path) = body.strip_prefix('/') else {
+        return false;
+    };
+    let first = path.split(['/', '?']).next().unwrap_or("");
+    !path.starts_with('/')
+        && path.chars().any(char::is_alphabetic)
+        && path
+            .chars()
+            .all(|c| c.is_alphanumeric() || "/-_.:{}<>*?=&%~+@".contains(c))
+        && !FILESYSTEM_ROOTS.contains(&first)
+}
+
+fn is_format(body: &str) -> bool {
+    let segments = template_segments(body);
+    segments.concat().len() < body.len()
+        && segments.iter().any(|segment| {
+            segment
+                .split(|c: char| !c.is_alphabetic())
+                .any(|word| word.chars().count() >= 2)
+        })
+}
+
+fn is_message(body: &str) -> bool {
+    body.chars().count() >= MIN_MESSAGE_LEN
+        && body.chars().next().is_some_and(char::is_alphabetic)
+        && prose_words(body) >= 2
+}
+
+/// The kind of literal the text between the quotes reads as
+pub fn classify(body: &str) -> Option<StringKind> {
+    if body.is_empty()
+        || body.chars().count() > MAX_LEN
+        || body.contains('\n')
+        || query_start(body).is_some()
+        || definition_start(body).is_some()
+    {
+        return None;
+    }
+    if is_url(body) {
+        Some(StringKind::Url)
+    } else if is_route(body) {
+        Some(StringKind::Route)
+    } else if is_format(body) {
+        Some(StringKind::Format)
+    } else if is_message(body) {
+        Some(StringKind::Message)
+    } else {
+        None
+    }
+}
+
+/// The notable literals of the tree, in source order
+pub fn extract_strings(root: Node, code: &str) -> Vec<StringLiteral> {
+    let mut strings = Vec::new();
+    walk_literals(root, code, &mut strings, 0);
+    strings
+}
+
+fn walk_literals(node: Node, code: &str, strings: &mut Vec<StringLiteral>, depth: usize) {
+    if !check_recursion_depth(depth, node) {
+        return;
+    }
+
+    let kind = node.kind();
+    if is_string_literal(kind) {
+        // Docstrings and directives (`"use strict"`) stand alone as statements
+        let statement = node
+            .parent()
+            .is_some_and(|parent| parent.kind() == "expression_statement");
+        let literal = code
+            .get(node.byte_range())
+            .filter(|_| !statement && !kind.contains("heredoc"))
+            .and_then(literal_body)
+            .and_then(|body| Some((classify(body)?, body)));
+        if let Some((kind, body)) = literal {
+            let start = node.start_position();
+            strings.push(StringLiteral {
+                kind,
+                text: body.to_string(),
+                line: start.row as u32,
+                column: start.column as u16,
+            });
+        }
+        return;
+    }
+
+    let mut cursor = node.walk();
+    for child in node.named_children(&mut cursor) {
+        walk_literals(child, code, strings, depth + 1);
+    }
+}
+
+/// Which of the symbols at `ranges` holds the literal: the innermost
+pub fn owner(literal: &StringLiteral, ranges: &[Range]) -> Option<usize> {
+    let position = (literal.line, literal.column);
+    ranges
+        .iter()
+        .enumerate()
+        .filter(|(_, range)| {
+            (range.start_line, range.start_column) <= position
+                && position < (range.end_line, range.end_column)
+        })
+        .min_by_key(|(_, range)| {
+            (
+                range.end_line.saturating_sub(range.start_line),
+                std::cmp::Reverse(range.start_column),
+            )
+        })
+        .map(|(index, _)| index)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn extract(language: tree_sitter::Language, code: &str) -> Vec<StringLiteral> {
+        let mut parser = tree_sitter::Parser::new();
+        parser.set_language(&language).unwrap();
+        let tree = parser.parse(code, None).unwrap();
+        extract_strings(tree.root_node(), code)
+    }
+
+    #[test]
+    fn test_classify() {
+        use StringKind::*;
+
+        assert_eq!(classify("https://api.example.com/v1/users"), Some(Url));
+        assert_eq!(classify("/api/users/{id}"), Some(Route));
+        assert_eq!(classify("/health"), Some(Route));
+        assert_eq!(classify("failed to open {}: {err}"), Some(Format));
+        assert_eq!(classify("retrying in %d seconds"), Some(Format));
+        assert_eq!(classify("Connection refused by the peer"), Some(Message));
+
+        assert_eq!(classify("/usr/local/bin"), None, "a filesystem path");
+        assert_eq!(classify("//comment"), None);
+        assert_eq!(classify("{}: {}"), None, "placeholders without words");
+        assert_eq!(
+            classify("Reached 100% of the quota"),
+            Some(Message),
+            "not a printf"
+        );
+        assert_eq!(classify("btn btn-primary"), None, "one word of prose");
+        assert_eq!(classify("user_id"), None);
+        assert_eq!(classify("SELECT id FROM users WHERE id = %s"), None);
+        assert_eq!(classify("first line\nsecond line"), None);
+        assert_eq!(classify(&"word ".repeat(100)), None, "over the limit");
+    }
+
+    #[test]
+    fn test_template_segments() {
+        assert_eq!(
+            template_segments("failed to open {path}: {}"),
+            ["failed to open ", ": "]
+        );
+        assert_eq!(template_segments("%-8s took %.2fms"), [" took ", "ms"]);
+        assert_eq!(template_segments("user ${name} left"), ["user ", " left"]);
+        assert_eq!(
+            template_segments("{{literal}} and 100%%"),
+            ["{{literal}} and 100%%"]
+        );
+    }
+
+    #[test]
+    fn test_literals_of_rust() {
+        let strings = extract(
+            tree_sitter_rust::LANGUAGE.into(),
+            r##"
+fn serve() {
+    let url = "https://example.com/hook";
+    router.route("/users/:id", get(show));
+    log::warn!("retry {} of {}", attempt, limit);
+    return Err(r#"Token has expired"#.into());
+    let key = "user_id";
+}
+"##,
+        );
+
+        let found: Vec<(StringKind, &str, u32)> = strings
+            .iter()
+            .map(|s| (s.kind, s.text.as_str(), s.line))
+            .collect();
+        assert_eq!(
+            found,
+            [
+                (StringKind::Url, "https://example.com/hook", 2),
+                (StringKind::Route, "/users/:id", 3),
+                (StringKind::Format, "retry {} of {}", 4),
+                (StringKind::Message, "Token has expired", 5),
+            ]
+        );
+        assert_eq!(strings[0].column, 14);
+    }
+
+    #[test]
+    fn test_docstrings_and_prefixes_of_python() {
+        let strings = extract(
+            tree_sitter_python::LANGUAGE.into(),
+            "def load(path):\n    \"\"\"Load the file at path.\"\"\"\n    raise ValueError(f\"cannot read {path}\")\n",
+        );
+
+        assert_eq!(strings.len(), 1);
+        assert_eq!(strings[0].kind, StringKind::Format);
+        assert_eq!(strings[0].text, "cannot read {path}");
+    }
+
+    #[test]
+    fn test_owner_is_innermost() {
+        let literal = |line: u32, column: u16| StringLiteral {
+            kind: StringKind::Message,
+            text: String::new(),
+            line,
+            column,
+        };
+        let ranges = [
+            Range::new(5, 0, 30, 1),  // impl
+            Range::new(10, 4, 20, 5), // method
+            Range::new(40, 0, 50, 1), // function
+        ];
+
+        assert_eq!(owner(&literal(12, 8), &ranges), Some(1));
+        assert_eq!(owner(&literal(25, 8), &ranges), Some(0));
+        assert_eq!(owner(&literal(35, 8), &ranges), None, "file level");
+    }
+}
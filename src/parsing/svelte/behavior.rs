@@ -0,0 +1,215 @@
+//! Svelte-specific language behavior implementation
+//!
+//! Module paths, imports and visibility follow JavaScript, as for Vue:
+//! `src/lib/UserCard.svelte` is `src.lib.UserCard`, and
+//! `import UserCard from './UserCard.svelte'` matches it. A component is
+//! always named after its file, in PascalCase as markup writes it
+//! (`user-card.svelte` is `UserCard`).
+
+use crate::parsing::JavaScriptBehavior;
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::parsing::sfc::{self, COMPONENT_PLACEHOLDER};
+use crate::parsing::typescript::TypeScriptResolutionContext;
+use crate::symbol::ScopeContext;
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Svelte language behavior implementation
+#[derive(Clone)]
+pub struct SvelteBehavior {
+    language: Language,
+    state: BehaviorState,
+    /// Module path and import rules of the script
+    script: JavaScriptBehavior,
+}
+
+impl SvelteBehavior {
+    /// Create a new Svelte behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_typescript::LANGUAGE_TSX.into(),
+            state: BehaviorState::new(),
+            script: JavaScriptBehavior::new(),
+        }
+    }
+
+    /// Component name for a file's module path
+    fn component_name(module_path: &str) -> String {
+        sfc::component_name(module_path.rsplit('.').next().unwrap_or(module_path))
+    }
+}
+
+impl StatefulBehavior for SvelteBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for SvelteBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for SvelteBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("svelte")
+    }
+
+    /// The component and its props take the file's name
+    fn configure_symbol(&self, symbol: &mut crate::Symbol, module_path: Option<&str>) {
+        let Some(path) = module_path else {
+            return;
+        };
+        symbol.module_path = Some(path.to_string().into());
+
+        let component = Self::component_name(path);
+        if symbol.name.as_ref() == COMPONENT_PLACEHOLDER {
+            symbol.name = crate::types::compact_string(&component);
+        }
+        if let Some(ScopeContext::ClassMember { class_name }) = &mut symbol.scope_context {
+            if class_name.as_deref() == Some(COMPONENT_PLACEHOLDER) {
+                *class_name = Some(crate::types::compact_string(&component));
+            }
+        }
+    }
+
+    fn normalize_caller_name(&self, name: &str, file_id: FileId) -> String {
+        if name == COMPONENT_PLACEHOLDER {
+            self.get_module_path_for_file(file_id)
+                .map(|path| Self::component_name(&path))
+                .unwrap_or_else(|| name.to_string())
+        } else {
+            name.to_string()
+        }
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    fn self_receiver_aliases(&self) -> &'static [&'static str] {
+        &["this"]
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "."
+    }
+
+    fn module_path_from_file(
+        &self,
+        file_path: &Path,
+        project_root: &Path,
+        extensions: &[&str],
+    ) -> Option<String> {
+        self.script
+            .module_path_from_file(file_path, project_root, extensions)
+    }
+
+    fn parse_visibility(&self, signature: &str) -> Visibility {
+        self.script.parse_visibility(signature)
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        self.script.format_path_as_module(components)
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(TypeScriptResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        self.script.create_inheritance_resolver()
+    }
+
+    /// `./UserCard.svelte` matches the module of `UserCard.svelte`
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        importing_module: Option<&str>,
+    ) -> bool {
+        let import_path = import_path.strip_suffix(".svelte").unwrap_or(import_path);
+        self.script
+            .import_matches_symbol(import_path, symbol_module_path, importing_module)
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_component_takes_file_name() {
+        let behavior = SvelteBehavior::new();
+        let mut component = crate::Symbol::new(
+            crate::SymbolId::new(1).unwrap(),
+            COMPONENT_PLACEHOLDER,
+            crate::SymbolKind::Class,
+            FileId::new(1).unwrap(),
+            crate::Range::new(0, 0, 20, 0),
+        );
+        let mut prop = crate::Symbol::new(
+            crate::SymbolId::new(2).unwrap(),
+            "user",
+            crate::SymbolKind::Field,
+            FileId::new(1).unwrap(),
+            crate::Range::new(3, 2, 3, 12),
+        );
+        prop.scope_context = Some(ScopeContext::ClassMember {
+            class_name: Some(COMPONENT_PLACEHOLDER.into()),
+        });
+
+        behavior.configure_symbol(&mut component, Some("src.lib.user-card"));
+        behavior.configure_symbol(&mut prop, Some("src.lib.user-card"));
+
+        assert_eq!(component.name.as_ref(), "UserCard");
+        assert_eq!(component.module_path.as_deref(), Some("src.lib.user-card"));
+        assert_eq!(
+            prop.scope_context,
+            Some(ScopeContext::ClassMember {
+                class_name: Some("UserCard".into())
+            })
+        );
+    }
+
+    #[test]
+    fn test_import_with_svelte_extension() {
+        let behavior = SvelteBehavior::new();
+
+        assert!(behavior.import_matches_symbol(
+            "./UserCard.svelte",
+            "src.lib.UserCard",
+            Some("src.lib")
+        ));
+        assert!(behavior.import_matches_symbol(
+            "../lib/UserCard",
+            "src.lib.UserCard",
+            Some("src.routes")
+        ));
+    }
+}
@@ -0,0 +1,230 @@
+//! Props and reactive declarations of a Svelte script
+//!
+//! Props are the `export let` declarations of the instance script (Svelte 3
+//! and 4) or the bindings destructured from `$props()` (Svelte 5), and
+//! reactive declarations are the `$:` statements assigning a name:
+//!
+//! ```svelte
+//! <script lang="ts">
+//!   export let title: string
+//!   let { count = 0, class: className }: Props = $props()
+//!   $: doubled = count * 2
+//! </script>
+//! ```
+//!
+//! Both are top-level statements; `$:` blocks and effects without an
+//! assignment declare nothing. Runes such as `let total = $derived(...)`
+//! are ordinary declarations of the script.
+
+use tree_sitter::Node;
+
+/// What a component declaration declares
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum DeclarationKind {
+    Prop,
+    Reactive,
+}
+
+/// A prop or reactive declaration and the node declaring it
+#[derive(Debug, Clone)]
+pub struct Declaration<'a, 't> {
+    pub kind: DeclarationKind,
+    pub name: &'a str,
+    pub node: Node<'t>,
+}
+
+/// Read the props and reactive declarations of an instance script tree
+///
+/// `code` is the text the tree was parsed from, or text with the same byte
+/// layout.
+pub fn read<'a, 't>(root: Node<'t>, code: &'a str) -> Vec<Declaration<'a, 't>> {
+    let mut declarations = Vec::new();
+
+    let mut cursor = root.walk();
+    for statement in root.named_children(&mut cursor) {
+        match statement.kind() {
+            "export_statement" => {
+                if let Some(declaration) = statement.child_by_field_name("declaration") {
+                    exported_props(declaration, code, &mut declarations);
+                }
+            }
+            "lexical_declaration" | "variable_declaration" => {
+                rune_props(statement, code, &mut declarations);
+            }
+            "labeled_statement" => {
+                if let Some(name) = reactive_name(&statement, code) {
+                    declarations.push(Declaration {
+                        kind: DeclarationKind::Reactive,
+                        name,
+                        node: statement,
+                    });
+                }
+            }
+            _ => {}
+        }
+    }
+
+    declarations
+}
+
+fn declarators<'t>(declaration: Node<'t>) -> Vec<Node<'t>> {
+    let mut cursor = declaration.walk();
+    declaration
+        .named_children(&mut cursor)
+        .filter(|child| child.kind() == "variable_declarator")
+        .collect()
+}
+
+/// `export let title` and `export var title`; `export const` is a read-only
+/// export, not a prop
+fn exported_props<'a, 't>(
+    declaration: Node<'t>,
+    code: &'a str,
+    declarations: &mut Vec<Declaration<'a, 't>>,
+) {
+    let is_prop = match declaration.kind() {
+        "lexical_declaration" => declaration
+            .child_by_field_name("kind")
+            .is_some_and(|kind| &code[kind.byte_range()] == "let"),
+        "variable_declaration" => true,
+        _ => false,
+    };
+    if !is_prop {
+        return;
+    }
+
+    for declarator in declarators(declaration) {
+        if let Some(name) = declarator
+            .child_by_field_name("name")
+            .filter(|name| name.kind() == "identifier")
+        {
+            declarations.push(Declaration {
+                kind: DeclarationKind::Prop,
+                name: &code[name.byte_range()],
+                node: declaration,
+            });
+        }
+    }
+}
+
+/// `let { title, count = 0, class: className } = $props()`
+fn rune_props<'a, 't>(
+    declaration: Node<'t>,
+    code: &'a str,
+    declarations: &mut Vec<Declaration<'a, 't>>,
+) {
+    for declarator in declarators(declaration) {
+        let is_props_call = declarator
+            .child_by_field_name("value")
+            .filter(|value| value.kind() == "call_expression")
+            .and_then(|call| call.child_by_field_name("function"))
+            .is_some_and(|function| &code[function.byte_range()] == "$props");
+        let Some(pattern) = declarator
+            .child_by_field_name("name")
+            .filter(|name| is_props_call && name.kind() == "object_pattern")
+        else {
+            continue;
+        };
+
+        let mut cursor = pattern.walk();
+        for element in pattern.named_children(&mut cursor) {
+            let key = match element.kind() {
+                "shorthand_property_identifier_pattern" => Some(element),
+                "object_assignment_pattern" => element.child_by_field_name("left"),
+                "pair_pattern" => element.child_by_field_name("key"),
+                _ => None,
+            };
+            let name = key.and_then(|key| match key.kind() {
+                "shorthand_property_identifier_pattern" | "property_identifier" => {
+                    Some(&code[key.byte_range()])
+                }
+                "string" => {
+                    let text = &code[key.byte_range()];
+                    text.get(1..text.len().saturating_sub(1))
+                }
+                _ => None,
+            });
+            if let Some(name) = name {
+                declarations.push(Declaration {
+                    kind: DeclarationKind::Prop,
+                    name,
+                    node: element,
+                });
+            }
+        }
+    }
+}
+
+/// `doubled` in `$: doubled = count * 2`
+fn reactive_name<'a>(statement: &Node, code: &'a str) -> Option<&'a str> {
+    let label = statement.child_by_field_name("label")?;
+    if &code[label.byte_range()] != "$" {
+        return None;
+    }
+    let body = statement.child_by_field_name("body")?;
+    if body.kind() != "expression_statement" {
+        return None;
+    }
+    let mut cursor = body.walk();
+    let expression = body.named_children(&mut cursor).next()?;
+    if expression.kind() != "assignment_expression" {
+        return None;
+    }
+    let left = expression.child_by_field_name("left")?;
+    (left.kind() == "identifier").then(|| &code[left.byte_range()])
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tree_sitter::Parser;
+
+    fn declared(code: &str) -> Vec<(DeclarationKind, String)> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_typescript::LANGUAGE_TSX.into())
+            .unwrap();
+        let tree = parser.parse(code, None).unwrap();
+        read(tree.root_node(), code)
+            .into_iter()
+            .map(|declaration| (declaration.kind, declaration.name.to_string()))
+            .collect()
+    }
+
+    #[test]
+    fn test_exported_props_and_reactive_declarations() {
+        let code = r#"
+export let title: string
+export let count = 0
+export const version = '1.0'
+let local = 1
+$: doubled = count * 2
+$: console.log(count)
+$: { local += 1 }
+"#;
+        assert_eq!(
+            declared(code),
+            vec![
+                (DeclarationKind::Prop, "title".to_string()),
+                (DeclarationKind::Prop, "count".to_string()),
+                (DeclarationKind::Reactive, "doubled".to_string()),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_rune_props() {
+        let code = r#"
+let { title, count = 0, class: className, ...rest }: Props = $props()
+let other = $state(0)
+"#;
+        assert_eq!(
+            declared(code),
+            vec![
+                (DeclarationKind::Prop, "title".to_string()),
+                (DeclarationKind::Prop, "count".to_string()),
+                (DeclarationKind::Prop, "class".to_string()),
+            ]
+        );
+    }
+}
@@ -0,0 +1,89 @@
+//! Svelte language definition for the registry
+//!
+//! Provides the Svelte language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{SvelteBehavior, SvelteParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Svelte language definition
+pub struct SvelteLanguage;
+
+impl SvelteLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("svelte");
+}
+
+impl LanguageDefinition for SvelteLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Svelte"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["svelte"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = SvelteParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(SvelteBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Svelte is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Svelte is enabled by default
+    }
+}
+
+/// Register Svelte language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(SvelteLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_svelte_definition() {
+        let svelte = SvelteLanguage;
+
+        assert_eq!(svelte.id(), LanguageId::new("svelte"));
+        assert_eq!(svelte.name(), "Svelte");
+        assert!(svelte.extensions().contains(&"svelte"));
+    }
+
+    #[test]
+    fn test_svelte_enabled_by_default() {
+        let svelte = SvelteLanguage;
+        let settings = Settings::default();
+
+        assert!(svelte.default_enabled());
+        assert!(svelte.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_svelte_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("svelte")));
+    }
+}
@@ -0,0 +1,18 @@
+//! Svelte component parser implementation
+//!
+//! Indexes `.svelte` components: the component itself, its props and
+//! reactive declarations (see [`component`]) and everything its scripts
+//! declare, read by the TypeScript parser on the scripts alone.
+
+pub mod audit;
+pub mod behavior;
+pub mod component;
+pub mod definition;
+pub mod parser;
+
+pub use behavior::SvelteBehavior;
+pub use definition::SvelteLanguage;
+pub use parser::SvelteParser;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
@@ -0,0 +1,527 @@
+//! Svelte component parser
+//!
+//! A `.svelte` file is split into its top-level blocks (see
+//! [`crate::parsing::sfc`]): the instance `<script>`, the module script
+//! (`<script context="module">` or `<script module>`), `<style>`, and the
+//! markup around them. Both scripts are parsed by the TypeScript parser,
+//! which reads JavaScript scripts as well, with everything else blanked
+//! out, so every position reported is a position in the `.svelte` file.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | the component (`<component>`, renamed to the file name) | Class |
+//! | props (`export let`, `$props()` bindings) | Field |
+//! | reactive declarations (`$: doubled = count * 2`) | Variable |
+//! | declarations of the scripts | as in TypeScript |
+//!
+//! ## Relationships
+//!
+//! The component defines its props and uses the components its markup
+//! renders (`<Avatar />`). Calls, imports, type uses and inheritance are
+//! those of the scripts.
+//!
+//! ## Documentation
+//!
+//! An `<!-- @component ... -->` comment documents the component, and
+//! `/** */` comments document props.
+
+use super::component::{self, DeclarationKind};
+use crate::parsing::sfc::{self, COMPONENT_PLACEHOLDER};
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, MethodCall, NodeTracker, NodeTrackingState,
+    TypeScriptParser, truncate_for_display,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use tree_sitter::{Node, Parser};
+
+/// Longest declaration kept as a prop or reactive declaration's signature
+const MAX_SIGNATURE_LEN: usize = 120;
+
+/// Marker of the comment documenting a component
+const COMPONENT_DOC_MARKER: &str = "@component";
+
+/// The scripts and markup of a component file
+struct Script<'a> {
+    blocks: Vec<sfc::Block<'a>>,
+    /// The file with everything but its script blocks blanked out
+    source: String,
+    /// The file with everything but its instance script blanked out
+    instance: String,
+}
+
+impl<'a> Script<'a> {
+    fn new(code: &'a str) -> Self {
+        let blocks = sfc::split(code);
+        let scripts: Vec<&sfc::Block> = blocks
+            .iter()
+            .filter(|block| block.tag == "script")
+            .collect();
+        let all: Vec<_> = scripts.iter().map(|block| block.content.clone()).collect();
+        let instance: Vec<_> = scripts
+            .iter()
+            .filter(|block| !is_module_script(block))
+            .map(|block| block.content.clone())
+            .collect();
+
+        Self {
+            source: sfc::mask(code, &all),
+            instance: sfc::mask(code, &instance),
+            blocks,
+        }
+    }
+
+    /// Byte ranges of the markup: everything outside scripts and styles
+    fn markup(&self, code: &str) -> Vec<std::ops::Range<usize>> {
+        let mut ranges = Vec::new();
+        let mut start = 0;
+        for block in &self.blocks {
+            if matches!(block.tag.as_str(), "script" | "style") {
+                ranges.push(start..block.span.start);
+                start = block.span.end;
+            }
+        }
+        ranges.push(start..code.len());
+        ranges
+    }
+}
+
+/// `<script context="module">` (Svelte 3 and 4) or `<script module>`
+/// (Svelte 5)
+fn is_module_script(block: &sfc::Block) -> bool {
+    block.attribute("context") == Some("module") || block.attribute("module").is_some()
+}
+
+/// Text of the `<!-- @component ... -->` comment
+fn component_doc(code: &str) -> Option<String> {
+    let mut rest = code;
+    while let Some(start) = rest.find("<!--") {
+        let body = &rest[start + 4..];
+        let end = body.find("-->")?;
+        if let Some(doc) = body[..end].trim_start().strip_prefix(COMPONENT_DOC_MARKER) {
+            let doc = doc
+                .lines()
+                .map(str::trim)
+                .collect::<Vec<_>>()
+                .join("\n")
+                .trim()
+                .to_string();
+            return (!doc.is_empty()).then_some(doc);
+        }
+        rest = &body[end + 3..];
+    }
+    None
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Whitespace-collapsed declaration text, without a trailing `;` or `,`
+fn declaration_text(node: &Node, code: &str) -> String {
+    let text = code[node.byte_range()]
+        .split_whitespace()
+        .collect::<Vec<_>>()
+        .join(" ");
+    truncate_for_display(text.trim_end_matches([';', ',']), MAX_SIGNATURE_LEN)
+}
+
+/// Svelte component parser
+pub struct SvelteParser {
+    typescript: TypeScriptParser,
+    /// Reads the props and reactive declarations of the instance script
+    parser: Parser,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for SvelteParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("SvelteParser")
+            .field("language", &"Svelte")
+            .finish()
+    }
+}
+
+impl SvelteParser {
+    /// Create a new Svelte parser instance
+    pub fn new() -> Result<Self, String> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_typescript::LANGUAGE_TSX.into())
+            .map_err(|e| format!("Failed to set Svelte script language: {e}"))?;
+
+        Ok(Self {
+            typescript: TypeScriptParser::new()?,
+            parser,
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    /// The component symbol
+    fn component_symbol(
+        script: &Script,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+    ) -> Symbol {
+        let mut component = Symbol::new(
+            counter.next_id(),
+            COMPONENT_PLACEHOLDER,
+            SymbolKind::Class,
+            file_id,
+            sfc::range_of(code, 0..code.len()),
+        )
+        .with_visibility(Visibility::Public);
+
+        let opening = script
+            .blocks
+            .iter()
+            .find(|block| block.tag == "script" && !is_module_script(block))
+            .or_else(|| script.blocks.iter().find(|block| block.tag == "script"));
+        if let Some(block) = opening {
+            component = component.with_signature(block.open_tag);
+        }
+        if let Some(doc) = component_doc(code) {
+            component = component.with_doc(doc);
+        }
+        component.scope_context = Some(ScopeContext::Module);
+        component
+    }
+
+    /// Turn the script variables declaring props into members of the
+    /// component, and add the props and reactive declarations the script
+    /// parser does not index
+    fn apply_declarations(
+        &mut self,
+        script: &Script,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(tree) = self.parser.parse(&script.instance, None) else {
+            return;
+        };
+
+        for declaration in component::read(tree.root_node(), code) {
+            self.register_handled_node(declaration.node.kind(), declaration.node.kind_id());
+
+            let node = declaration.node;
+            let first_row = node.start_position().row as u32;
+            let last_row = node.end_position().row as u32;
+            let existing = symbols.iter_mut().find(|symbol| {
+                symbol.name.as_ref() == declaration.name
+                    && (first_row..=last_row).contains(&symbol.range.start_line)
+            });
+
+            match declaration.kind {
+                DeclarationKind::Prop => {
+                    let member = Some(ScopeContext::ClassMember {
+                        class_name: Some(COMPONENT_PLACEHOLDER.into()),
+                    });
+                    match existing {
+                        Some(symbol) => {
+                            symbol.kind = SymbolKind::Field;
+                            symbol.visibility = Visibility::Public;
+                            symbol.signature = Some(declaration_text(&node, code).into());
+                            symbol.scope_context = member;
+                            if symbol.doc_comment.is_none() {
+                                if let Some(doc) = self.extract_doc_comment(&node, code) {
+                                    symbol.doc_comment = Some(doc.into());
+                                }
+                            }
+                        }
+                        None => {
+                            let mut symbol = Symbol::new(
+                                counter.next_id(),
+                                declaration.name,
+                                SymbolKind::Field,
+                                file_id,
+                                range_from_node(&node),
+                            )
+                            .with_signature(declaration_text(&node, code))
+                            .with_visibility(Visibility::Public);
+                            if let Some(doc) = self.extract_doc_comment(&node, code) {
+                                symbol = symbol.with_doc(doc);
+                            }
+                            symbol.scope_context = member;
+                            symbols.push(symbol);
+                        }
+                    }
+                }
+                DeclarationKind::Reactive => {
+                    if existing.is_none() {
+                        let mut symbol = Symbol::new(
+                            counter.next_id(),
+                            declaration.name,
+                            SymbolKind::Variable,
+                            file_id,
+                            range_from_node(&node),
+                        )
+                        .with_signature(declaration_text(&node, code))
+                        .with_visibility(Visibility::Private);
+                        if let Some(doc) = self.extract_doc_comment(&node, code) {
+                            symbol = symbol.with_doc(doc);
+                        }
+                        symbol.scope_context = Some(ScopeContext::Module);
+                        symbols.push(symbol);
+                    }
+                }
+            }
+        }
+    }
+}
+
+impl NodeTracker for SvelteParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+impl LanguageParser for SvelteParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        let script = Script::new(code);
+
+        let mut symbols = vec![Self::component_symbol(
+            &script,
+            code,
+            file_id,
+            symbol_counter,
+        )];
+        symbols.extend(
+            self.typescript
+                .parse(&script.source, file_id, symbol_counter),
+        );
+        self.apply_declarations(&script, code, file_id, symbol_counter, &mut symbols);
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// `/** */` comments, as in TypeScript
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        self.typescript.extract_doc_comment(node, code)
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let calls = self.typescript.find_calls(&script.source);
+        sfc::remap_all(code, &script.source, calls)
+    }
+
+    fn find_method_calls(&mut self, code: &str) -> Vec<MethodCall> {
+        let script = Script::new(code);
+        self.typescript.find_method_calls(&script.source)
+    }
+
+    fn find_implementations<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let implementations = self.typescript.find_implementations(&script.source);
+        sfc::remap_all(code, &script.source, implementations)
+    }
+
+    fn find_extends<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let extends = self.typescript.find_extends(&script.source);
+        sfc::remap_all(code, &script.source, extends)
+    }
+
+    /// Type uses of the scripts, and the components the markup renders
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let found = self.typescript.find_uses(&script.source);
+        let mut uses = sfc::remap_all(code, &script.source, found);
+
+        for markup in script.markup(code) {
+            for (tag, span) in sfc::component_tags(code, markup) {
+                uses.push((COMPONENT_PLACEHOLDER, tag, sfc::range_of(code, span)));
+            }
+        }
+
+        uses
+    }
+
+    fn find_uses_owned(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let script = Script::new(code);
+        self.typescript.find_uses_owned(&script.source)
+    }
+
+    /// Definitions of the scripts, and the component's props
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let found = self.typescript.find_defines(&script.source);
+        let mut defines = sfc::remap_all(code, &script.source, found);
+
+        if let Some(tree) = self.parser.parse(&script.instance, None) {
+            for declaration in component::read(tree.root_node(), code) {
+                if declaration.kind == DeclarationKind::Prop {
+                    defines.push((
+                        COMPONENT_PLACEHOLDER,
+                        declaration.name,
+                        range_from_node(&declaration.node),
+                    ));
+                }
+            }
+        }
+
+        defines
+    }
+
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let script = Script::new(code);
+        self.typescript.find_imports(&script.source, file_id)
+    }
+
+    fn language(&self) -> Language {
+        Language::Svelte
+    }
+
+    fn find_variable_types<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let bindings = self.typescript.find_variable_types(&script.source);
+        sfc::remap_all(code, &script.source, bindings)
+    }
+
+    fn find_variable_types_with_substitution(
+        &mut self,
+        code: &str,
+    ) -> Option<Vec<(String, String, Range)>> {
+        let script = Script::new(code);
+        self.typescript
+            .find_variable_types_with_substitution(&script.source)
+    }
+
+    fn find_inherent_methods(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let script = Script::new(code);
+        self.typescript.find_inherent_methods(&script.source)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const COMPONENT: &str = r#"<!--
+  @component
+  Button with a counter
+-->
+<script context="module" lang="ts">
+  export const VARIANTS = ['primary', 'ghost']
+</script>
+
+<script lang="ts">
+  import Icon from './Icon.svelte'
+
+  /** Text of the button */
+  export let label: string
+  export let count = 0
+
+  $: doubled = count * 2
+
+  function increment() {
+    count += 1
+  }
+</script>
+
+<button on:click={increment}>
+  <Icon name="plus" />
+  {label}: {doubled}
+</button>
+
+<style>
+  button { padding: 0.5rem; }
+</style>
+"#;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = SvelteParser::new().unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, FileId::new(1).unwrap(), &mut counter)
+    }
+
+    fn find<'s>(symbols: &'s [Symbol], name: &str) -> &'s Symbol {
+        symbols
+            .iter()
+            .find(|symbol| symbol.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("symbol {name} not found"))
+    }
+
+    #[test]
+    fn test_component_props_and_reactive_declarations() {
+        let symbols = parse(COMPONENT);
+
+        let component = find(&symbols, COMPONENT_PLACEHOLDER);
+        assert_eq!(component.kind, SymbolKind::Class);
+        assert_eq!(
+            component.doc_comment.as_deref(),
+            Some("Button with a counter")
+        );
+        assert_eq!(
+            component.signature.as_deref(),
+            Some(r#"<script lang="ts">"#)
+        );
+
+        let label = find(&symbols, "label");
+        assert_eq!(label.kind, SymbolKind::Field);
+        assert_eq!(label.signature.as_deref(), Some("let label: string"));
+        assert_eq!(label.doc_comment.as_deref(), Some("Text of the button"));
+        assert_eq!(
+            label.scope_context,
+            Some(ScopeContext::ClassMember {
+                class_name: Some(COMPONENT_PLACEHOLDER.into())
+            })
+        );
+        assert_eq!(find(&symbols, "count").kind, SymbolKind::Field);
+
+        let doubled = find(&symbols, "doubled");
+        assert_eq!(doubled.kind, SymbolKind::Variable);
+        assert_eq!(doubled.signature.as_deref(), Some("$: doubled = count * 2"));
+        assert_eq!(doubled.range.start_line, 15);
+
+        // Module script exports are not props
+        assert_ne!(find(&symbols, "VARIANTS").kind, SymbolKind::Field);
+    }
+
+    #[test]
+    fn test_markup_components_and_defines() {
+        let mut parser = SvelteParser::new().unwrap();
+
+        let uses = parser.find_uses(COMPONENT);
+        assert!(uses.contains(&(COMPONENT_PLACEHOLDER, "Icon", Range::new(23, 3, 23, 7))));
+
+        let defines = parser.find_defines(COMPONENT);
+        let props: Vec<&str> = defines
+            .iter()
+            .filter(|(component, _, _)| *component == COMPONENT_PLACEHOLDER)
+            .map(|(_, prop, _)| *prop)
+            .collect();
+        assert_eq!(props, vec!["label", "count"]);
+
+        let imports = parser.find_imports(COMPONENT, FileId::new(1).unwrap());
+        assert!(imports.iter().any(|import| import.path == "./Icon.svelte"));
+    }
+}
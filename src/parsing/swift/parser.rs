@@ -32,6 +32,9 @@ const NODE_DEINIT_DECLARATION: &str = "deinit_declaration";
 const NODE_PROPERTY_DECLARATION: &str = "property_declaration";
 const NODE_TYPEALIAS_DECLARATION: &str = "typealias_declaration";
 const NODE_SUBSCRIPT_DECLARATION: &str = "subscript_declaration";
+const NODE_PROTOCOL_FUNCTION_DECLARATION: &str = "protocol_function_declaration";
+const NODE_PROTOCOL_PROPERTY_DECLARATION: &str = "protocol_property_declaration";
+const NODE_COMPUTED_PROPERTY: &str = "computed_property";
 const NODE_IMPORT_DECLARATION: &str = "import_declaration";
 const NODE_ENUM_ENTRY: &str = "enum_entry";
 const NODE_MODIFIERS: &str = "modifiers";
@@ -310,10 +313,14 @@ impl SwiftParser {
                 self.process_deinit_declaration(node, code, file_id, symbols, counter);
             }
 
-            NODE_PROPERTY_DECLARATION => {
+            NODE_PROPERTY_DECLARATION | NODE_PROTOCOL_PROPERTY_DECLARATION => {
                 self.process_property_declaration(node, code, file_id, symbols, counter);
             }
 
+            NODE_PROTOCOL_FUNCTION_DECLARATION => {
+                self.process_protocol_function_declaration(node, code, file_id, symbols, counter);
+            }
+
             NODE_TYPEALIAS_DECLARATION => {
                 self.process_typealias_declaration(node, code, file_id, symbols, counter);
             }
@@ -973,7 +980,18 @@ impl SwiftParser {
             _ => return,
         };
 
-        let signature = self.extract_signature(node, code);
+        // Computed properties (`var area: Double { width * height }`) keep
+        // only the declaration head, like function signatures
+        let mut cursor = node.walk();
+        let computed = node
+            .children(&mut cursor)
+            .find(|child| child.kind() == NODE_COMPUTED_PROPERTY);
+        let signature = match computed {
+            Some(body) => code[node.start_byte()..body.start_byte()]
+                .trim()
+                .to_string(),
+            None => self.extract_signature(node, code),
+        };
         let visibility = self.determine_visibility(node, code);
         let doc_comment = self.doc_comment_for(&node, code);
         let range = self.node_to_range(node);
@@ -996,6 +1014,42 @@ impl SwiftParser {
         symbols.push(symbol);
     }
 
+    /// Process protocol method requirements (`func draw(in rect: Rect)`)
+    fn process_protocol_function_declaration(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        symbols: &mut Vec<Symbol>,
+        counter: &mut SymbolCounter,
+    ) {
+        self.register_node_recursively(node);
+
+        let name_node = node.child_by_field_name("name").or_else(|| {
+            let mut cursor = node.walk();
+            node.children(&mut cursor)
+                .find(|child| child.kind() == NODE_SIMPLE_IDENTIFIER)
+        });
+        let name = match name_node {
+            Some(n) => self.trimmed_text(code, n).to_string(),
+            None => return,
+        };
+
+        let signature = self.extract_signature(node, code);
+        let doc_comment = self.doc_comment_for(&node, code);
+        let range = self.node_to_range(node);
+
+        let mut symbol = Symbol::new(counter.next_id(), name, SymbolKind::Method, file_id, range);
+        symbol.signature = Some(signature.into());
+        symbol.visibility = self.determine_visibility(node, code);
+        if let Some(doc) = doc_comment {
+            symbol.doc_comment = Some(doc.into());
+        }
+        symbol.scope_context = Some(class_member_or_module(self.context.current_class()));
+
+        symbols.push(symbol);
+    }
+
     /// Process typealias declarations
     fn process_typealias_declaration(
         &mut self,
@@ -1169,6 +1223,10 @@ impl SwiftParser {
                     if child.kind() == NODE_TYPE_IDENTIFIER {
                         class_name = Some(self.trimmed_text(code, child));
                         break;
+                    } else if child.kind() == NODE_USER_TYPE {
+                        // Extension: `extension Point: Equatable` conforms Point
+                        class_name = self.extract_type_name(child, code);
+                        break;
                     }
                 }
                 class_name
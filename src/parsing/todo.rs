@@ -0,0 +1,243 @@
+//! TODO, FIXME and other tagged comments
+//!
+//! Comments are found by node kind, which the grammars name `comment`,
+//! `line_comment`, `block_comment` and the like, so one pass serves every
+//! language. A tag counts at the start of a comment line, optionally
+//! followed by its author in parentheses: `TODO(alice): retry on timeout`.
+//!
+//! The indexer runs it for the tags in `indexing.todo_tags`.
+
+use crate::{FileId, Range, SymbolId};
+use serde::{Deserialize, Serialize};
+use tree_sitter::Node;
+
+/// Characters opening a comment line in the languages parsed
+pub(crate) const MARKERS: &[char] = &['/', '*', '#', '-', ';', '!', '<', '%', '['];
+
+/// Characters closing a block comment
+pub(crate) const CLOSERS: &[&str] = &["*/", "-->", "]]"];
+
+/// How far below its comment a symbol may start and still own the todo,
+/// leaving room for attributes and decorators
+const FOLLOWING_LINES: u32 = 3;
+
+/// A tagged comment as found in the source
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct TodoComment {
+    pub tag: String,
+    pub author: Option<String>,
+    pub text: String,
+    /// Zero-based, like symbol ranges
+    pub line: u32,
+    pub column: u16,
+    /// Last line of the run of comments holding it
+    pub end_line: u32,
+}
+
+/// A tagged comment of the index, with the symbol it is about
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Todo {
+    pub tag: String,
+    pub author: Option<String>,
+    pub text: String,
+    pub file_id: FileId,
+    pub file_path: String,
+    /// Zero-based, like symbol ranges
+    pub line: u32,
+    pub column: u16,
+    pub symbol_id: Option<SymbolId>,
+}
+
+pub(crate) fn is_comment(node: Node) -> bool {
+    node.kind().contains("comment")
+}
+
+/// The last line of the comments following each other from `node`
+pub(crate) fn run_end(node: Node) -> u32 {
+    let mut end = node.end_position();
+    let mut next = node.next_sibling();
+    while let Some(sibling) =
+        next.filter(|sibling| is_comment(*sibling) && sibling.start_position().row <= end.row + 1)
+    {
+        end = sibling.end_position();
+        next = sibling.next_sibling();
+    }
+    // Line comments of some grammars hold their newline
+    if end.column == 0 && end.row > node.start_position().row {
+        end.row -= 1;
+    }
+    end.row as u32
+}
+
+/// The tag, author and text of a comment line starting with one of `tags`
+fn parse_line(line: &str, tags: &[String]) -> Option<(String, Option<String>, String)> {
+    let body = line.trim_start().trim_start_matches(MARKERS).trim_start();
+    let tag = tags.iter().find(|tag| {
+        body.strip_prefix(tag.as_str()).is_some_and(|rest| {
+            rest.chars()
+                .next()
+                .is_none_or(|c| c == ':' || c == '(' || c.is_whitespace())
+        })
+    })?;
+
+    let mut rest = &body[tag.len()..];
+    let mut author = None;
+    if let Some((name, after)) = rest
+        .strip_prefix('(')
+        .and_then(|inner| inner.split_once(')'))
+    {
+        author = Some(name.trim().to_string()).filter(|name| !name.is_empty());
+        rest = after;
+    }
+    let mut text = rest.trim_start().trim_start_matches([':', '-']).trim();
+    for closer in CLOSERS {
+        text = text.strip_suffix(closer).unwrap_or(text).trim_end();
+    }
+    Some((tag.clone(), author, text.to_string()))
+}
+
+/// The comments of the tree tagged with one of `tags`, in source order
+pub fn extract_todos(root: Node, code: &str, tags: &[String]) -> Vec<TodoComment> {
+    let mut todos = Vec::new();
+    let mut cursor = root.walk();
+    let mut stack = vec![root];
+    while let Some(node) = stack.pop() {
+        if !is_comment(node) {
+            stack.extend(node.children(&mut cursor));
+            continue;
+        }
+        let Some(comment) = code.get(node.byte_range()) else {
+            continue;
+        };
+        let start = node.start_position();
+        let end_line = run_end(node);
+        for (offset, line) in comment.lines().enumerate() {
+            let Some((tag, author, text)) = parse_line(line, tags) else {
+                continue;
+            };
+            let column = if offset == 0 {
+                start.column
+            } else {
+                line.len() - line.trim_start().len()
+            };
+            todos.push(TodoComment {
+                tag,
+                author,
+                text,
+                line: (start.row + offset) as u32,
+                column: column as u16,
+                end_line,
+            });
+        }
+    }
+    todos.sort_by_key(|todo| (todo.line, todo.column));
+    todos
+}
+
+/// Which of the symbols at `ranges` the todo is about: the first starting
+/// just below its comment, otherwise the innermost holding it. A todo
+/// written inside a symbol never goes to one past that symbol's end.
+pub fn owner(todo: &TodoComment, ranges: &[Range]) -> Option<usize> {
+    let holds = |range: &Range, line: u32| range.start_line <= line && line <= range.end_line;
+    let container = ranges
+        .iter()
+        .enumerate()
+        .filter(|(_, range)| holds(range, todo.line))
+        .min_by_key(|(_, range)| range.end_line.saturating_sub(range.start_line))
+        .map(|(index, _)| index);
+    let below = ranges
+        .iter()
+        .enumerate()
+        .filter(|(_, range)| {
+            range.start_line > todo.end_line
+                && range.start_line <= todo.end_line + FOLLOWING_LINES
+                && container.is_none_or(|index| holds(&ranges[index], range.start_line))
+        })
+        .min_by_key(|(_, range)| (range.start_line, std::cmp::Reverse(range.end_line)))
+        .map(|(index, _)| index);
+    below.or(container)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn tags() -> Vec<String> {
+        ["TODO", "FIXME", "HACK"].map(String::from).to_vec()
+    }
+
+    fn extract(language: tree_sitter::Language, code: &str) -> Vec<TodoComment> {
+        let mut parser = tree_sitter::Parser::new();
+        parser.set_language(&language).unwrap();
+        let tree = parser.parse(code, None).unwrap();
+        extract_todos(tree.root_node(), code, &tags())
+    }
+
+    #[test]
+    fn test_tags_authors_and_block_comments() {
+        let todos = extract(
+            tree_sitter_rust::LANGUAGE.into(),
+            r#"
+// TODO(alice): retry on timeout
+fn fetch() {
+    let todo = "TODO: not a comment";
+    /* FIXME the cache
+       HACK - skips validation */
+}
+// todo: lowercase is prose
+// TODOS are not a tag
+"#,
+        );
+
+        let found: Vec<(&str, Option<&str>, &str, u32)> = todos
+            .iter()
+            .map(|t| (t.tag.as_str(), t.author.as_deref(), t.text.as_str(), t.line))
+            .collect();
+        assert_eq!(
+            found,
+            [
+                ("TODO", Some("alice"), "retry on timeout", 1),
+                ("FIXME", None, "the cache", 4),
+                ("HACK", None, "skips validation", 5),
+            ]
+        );
+        assert_eq!(todos[2].column, 7);
+    }
+
+    #[test]
+    fn test_python_hash_comments() {
+        let todos = extract(
+            tree_sitter_python::LANGUAGE.into(),
+            "def run():\n    # HACK: until the API is fixed\n    pass\n",
+        );
+
+        assert_eq!(todos.len(), 1);
+        assert_eq!(todos[0].text, "until the API is fixed");
+        assert_eq!((todos[0].line, todos[0].column), (1, 4));
+    }
+
+    #[test]
+    fn test_owner_below_comment_or_enclosing() {
+        let todo = |line: u32, end_line: u32| TodoComment {
+            tag: "TODO".to_string(),
+            author: None,
+            text: String::new(),
+            line,
+            column: 0,
+            end_line,
+        };
+        let ranges = [
+            Range::new(5, 0, 30, 1),  // impl
+            Range::new(10, 4, 20, 5), // method
+            Range::new(22, 4, 29, 5), // method
+            Range::new(32, 0, 40, 1), // function
+        ];
+
+        assert_eq!(owner(&todo(3, 4), &ranges), Some(0), "just above the impl");
+        assert_eq!(owner(&todo(8, 8), &ranges), Some(1), "above a method");
+        assert_eq!(owner(&todo(15, 15), &ranges), Some(1), "inside a method");
+        assert_eq!(owner(&todo(29, 29), &ranges), Some(2), "not past its end");
+        assert_eq!(owner(&todo(31, 31), &ranges), Some(3));
+        assert_eq!(owner(&todo(45, 45), &ranges), None, "file level");
+    }
+}
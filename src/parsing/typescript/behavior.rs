@@ -74,6 +74,44 @@ fn reduce_type_annotation(node: Node, code: &str) -> Option<String> {
     }
 }
 
+/// Constraint of the type parameter `name` (`<T extends Shape>`), as the
+/// names of the types it requires: one per member of an intersection.
+fn find_type_parameter_bounds(node: Node, code: &str, name: &str) -> Vec<String> {
+    if node.kind() == "type_parameter"
+        && node
+            .child_by_field_name("name")
+            .is_some_and(|declared| &code[declared.byte_range()] == name)
+    {
+        let mut bounds = Vec::new();
+        if let Some(constraint) = node
+            .child_by_field_name("constraint")
+            .and_then(|constraint| constraint.named_child(0))
+        {
+            collect_bound_names(constraint, code, &mut bounds);
+        }
+        return bounds;
+    }
+    for child in node.children(&mut node.walk()) {
+        let bounds = find_type_parameter_bounds(child, code, name);
+        if !bounds.is_empty() {
+            return bounds;
+        }
+    }
+    Vec::new()
+}
+
+fn collect_bound_names(node: Node, code: &str, bounds: &mut Vec<String>) {
+    if node.kind() == "intersection_type" {
+        for part in node.named_children(&mut node.walk()) {
+            collect_bound_names(part, code, bounds);
+        }
+    } else if let Some(name) = reduce_type_annotation(node, code) {
+        if !bounds.contains(&name) {
+            bounds.push(name);
+        }
+    }
+}
+
 use super::resolution::{TypeScriptInheritanceResolver, TypeScriptResolutionContext};
 
 /// TypeScript language behavior implementation
@@ -183,6 +221,22 @@ impl LanguageBehavior for TypeScriptBehavior {
         find_parameter_type(tree.root_node(), &wrapped, var_name)
     }
 
+    fn parameter_type_bounds(&self, signature: &str, var_name: &str) -> Vec<String> {
+        let wrapped = format!("class __W__ {{ {signature} {{}} }}");
+        let mut parser = tree_sitter::Parser::new();
+        if parser.set_language(&self.get_language()).is_err() {
+            return Vec::new();
+        }
+        let Some(tree) = parser.parse(&wrapped, None) else {
+            return Vec::new();
+        };
+        let root = tree.root_node();
+        match find_parameter_type(root, &wrapped, var_name) {
+            Some(type_name) => find_type_parameter_bounds(root, &wrapped, &type_name),
+            None => Vec::new(),
+        }
+    }
+
     fn get_language(&self) -> Language {
         tree_sitter_typescript::LANGUAGE_TYPESCRIPT.into()
     }
@@ -429,6 +483,13 @@ impl LanguageBehavior for TypeScriptBehavior {
                 }
             }
 
+            // Re-exported by a barrel: `export { a as b } from './a'` in the
+            // target module (alias map built by the Phase 2 pre-pass)
+            if resolved_symbol.is_none() {
+                resolved_symbol =
+                    cache.resolve_module_alias(&format!("{target_module}.{local_name}"));
+            }
+
             // Determine origin
             let origin = if resolved_symbol.is_some() {
                 ImportOrigin::Internal
@@ -516,6 +577,43 @@ impl LanguageBehavior for TypeScriptBehavior {
         self.state.get_file_path(file_id)
     }
 
+    /// Relative paths made absolute against the file's directory: the
+    /// module itself for an `index` file, its parent otherwise. Package and
+    /// tsconfig alias paths are left to import resolution.
+    fn reexport_import_path(
+        &self,
+        import: &crate::parsing::Import,
+        module: &str,
+        file_path: &Path,
+    ) -> Option<String> {
+        let mut path: Vec<&str> = module.split('.').filter(|s| !s.is_empty()).collect();
+        if file_path.file_stem().is_none_or(|stem| stem != "index") {
+            path.pop();
+        }
+        let mut rest = import.path.as_str();
+        if let Some(tail) = rest.strip_prefix("./") {
+            rest = tail;
+        } else if rest.starts_with("../") {
+            while let Some(tail) = rest.strip_prefix("../") {
+                path.pop()?;
+                rest = tail;
+            }
+        } else {
+            return None;
+        }
+        // Module paths drop extensions and `/index`, as module_path_from_file does
+        for segment in rest.split('/').filter(|s| !s.is_empty()) {
+            let segment = [".tsx", ".ts", ".jsx", ".js", ".mjs"]
+                .iter()
+                .find_map(|ext| segment.strip_suffix(ext))
+                .unwrap_or(segment);
+            if segment != "index" {
+                path.push(segment);
+            }
+        }
+        Some(path.join("."))
+    }
+
     fn import_matches_symbol(
         &self,
         import_path: &str,
@@ -6,6 +6,7 @@
 //! When migrating or updating the parser, ensure compatibility with ABI-14 features.
 
 use crate::parsing::Import;
+use crate::parsing::javascript::test_blocks::TestCall;
 use crate::parsing::parser::check_recursion_depth;
 use crate::parsing::{
     LanguageParser, MethodCall, NodeTracker, NodeTrackingState, ParserContext, ScopeType,
@@ -532,6 +533,46 @@ impl TypeScriptParser {
                     );
                 }
             }
+            "call_expression" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                let test = TestCall::of(node, code);
+                if let Some(test) = test.filter(|test| !test.suite) {
+                    symbols.push(self.process_test_block(
+                        &test,
+                        node,
+                        file_id,
+                        counter,
+                        module_path,
+                    ));
+                }
+
+                let mut cursor = node.walk();
+                for child in node.children(&mut cursor) {
+                    self.extract_symbols_from_node(
+                        child,
+                        code,
+                        file_id,
+                        counter,
+                        symbols,
+                        module_path,
+                        depth + 1,
+                    );
+                }
+                // Anonymous callbacks are not walked for symbols; those of
+                // tests and suites are, for the tests and helpers they hold
+                if let Some(body) = test.and_then(|test| test.callback.child_by_field_name("body"))
+                {
+                    self.extract_symbols_from_node(
+                        body,
+                        code,
+                        file_id,
+                        counter,
+                        symbols,
+                        module_path,
+                        depth + 1,
+                    );
+                }
+            }
             _ => {
                 // Track all nodes we encounter, even if not extracting symbols
                 self.register_handled_node(node.kind(), node.kind_id());
@@ -941,6 +982,34 @@ impl TypeScriptParser {
         }
     }
 
+    /// Process a test block (`it("adds", () => ..)`) as a function named by
+    /// its description
+    fn process_test_block(
+        &mut self,
+        test: &TestCall,
+        node: Node,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        module_path: &str,
+    ) -> Symbol {
+        self.create_symbol(
+            counter.next_id(),
+            test.description.to_string(),
+            SymbolKind::Function,
+            file_id,
+            Range::new(
+                node.start_position().row as u32,
+                node.start_position().column as u16,
+                node.end_position().row as u32,
+                node.end_position().column as u16,
+            ),
+            Some(test.signature()),
+            None,
+            module_path,
+            Visibility::Private,
+        )
+    }
+
     /// Process arrow functions
     fn process_arrow_function(
         &mut self,
@@ -1531,7 +1600,7 @@ impl TypeScriptParser {
                 is_type_only,
             });
         } else {
-            // Named re-exports - just track the module being imported from
+            // Named re-exports - track the module being imported from
             imports.push(Import {
                 path: source_path.to_string(),
                 alias: None,
@@ -1539,6 +1608,38 @@ impl TypeScriptParser {
                 is_glob: false,
                 is_type_only,
             });
+
+            // ...and each re-exported name, as `<source>/<name>` with the
+            // exported name as alias when renamed, so the re-export pre-pass
+            // can expose `export { a as b } from './a'` as the barrel's `b`
+            let mut cursor = node.walk();
+            let clauses: Vec<Node> = node
+                .children(&mut cursor)
+                .filter(|child| child.kind() == "export_clause")
+                .collect();
+            for clause in clauses {
+                let mut cursor = clause.walk();
+                for specifier in clause.named_children(&mut cursor) {
+                    if specifier.kind() != "export_specifier" {
+                        continue;
+                    }
+                    let Some(name) = specifier.child_by_field_name("name") else {
+                        continue;
+                    };
+                    let name = &code[name.byte_range()];
+                    let alias = specifier
+                        .child_by_field_name("alias")
+                        .map(|alias| code[alias.byte_range()].to_string())
+                        .filter(|alias| alias != name);
+                    imports.push(Import {
+                        path: format!("{source_path}/{name}"),
+                        alias,
+                        file_id,
+                        is_glob: false,
+                        is_type_only,
+                    });
+                }
+            }
         }
     }
 
@@ -1575,7 +1676,10 @@ impl TypeScriptParser {
         }
         // Handle function context - track which function we're inside
         // CRITICAL: Only set NEW context when entering a function, otherwise INHERIT current context
-        let function_context = if node.kind() == "function_declaration"
+        let function_context = if let Some(test) = TestCall::described_by_callback(*node, code) {
+            // A test block's callback runs as the test it describes
+            Some(test)
+        } else if node.kind() == "function_declaration"
             || node.kind() == "generator_function_declaration"
             || node.kind() == "method_definition"
             || node.kind() == "arrow_function"
@@ -2272,7 +2376,9 @@ impl TypeScriptParser {
     ) {
         // Track function context - SAME FIX as extract_calls_recursive
         // Only set NEW context when entering a function, otherwise INHERIT
-        let function_context = if node.kind() == "function_declaration"
+        let function_context = if let Some(test) = TestCall::described_by_callback(*node, code) {
+            Some(test)
+        } else if node.kind() == "function_declaration"
             || node.kind() == "generator_function_declaration"
             || node.kind() == "method_definition"
             || node.kind() == "arrow_function"
@@ -2798,8 +2904,13 @@ export * from './common';
             );
         }
 
-        // Verify counts (per-specifier imports now included)
-        assert_eq!(imports.len(), 8, "Should extract 8 imports");
+        // Verify counts (per-specifier imports and re-exports now included)
+        assert_eq!(imports.len(), 9, "Should extract 9 imports");
+        assert!(
+            imports
+                .iter()
+                .any(|i| i.path == "./Button/Button" && i.alias.is_none())
+        );
 
         // Verify specific imports
         // Named imports create one Import per specifier with local alias
@@ -3055,8 +3166,13 @@ export { default as MyButton } from './Button';
             );
         }
 
-        // Should have 7 imports (per-specifier named imports)
-        assert_eq!(imports.len(), 7, "Should have 7 imports");
+        // Should have 8 imports (per-specifier named imports and re-exports)
+        assert_eq!(imports.len(), 8, "Should have 8 imports");
+        assert!(
+            imports
+                .iter()
+                .any(|i| i.path == "./Button/default" && i.alias.as_deref() == Some("MyButton"))
+        );
 
         // Check for React default import
         let react_default = imports
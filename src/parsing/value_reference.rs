@@ -0,0 +1,104 @@
+//! Reads and writes of fields and module-level values
+//!
+//! A `ValueReference` is what a parser reports for `self.config.timeout = 5`
+//! or a read of `MAX_RETRIES`: the function touching the value, the value's
+//! name, the receiver it is reached through and whether it is written. The
+//! PARSE stage turns each into a `References` relationship whose metadata
+//! context is the access (`"read"` or `"write"`).
+
+use crate::Range;
+
+/// How a reference touches its value
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Access {
+    Read,
+    /// Assigned, compound-assigned or initialized (`Config { timeout: 5 }`)
+    Write,
+}
+
+impl Access {
+    /// The access as stored in relationship metadata
+    pub fn as_str(self) -> &'static str {
+        match self {
+            Self::Read => "read",
+            Self::Write => "write",
+        }
+    }
+}
+
+/// A read or write of a field, constant, static or global
+///
+/// ```rust
+/// use codanna::Range;
+/// use codanna::parsing::{Access, ValueReference};
+///
+/// let range = Range::new(3, 4, 3, 23);
+///
+/// // self.timeout = 5
+/// let write = ValueReference::new("configure", "timeout", Access::Write, range)
+///     .with_receiver("self");
+///
+/// // if attempts > MAX_RETRIES
+/// let read = ValueReference::new("retry", "MAX_RETRIES", Access::Read, range);
+/// ```
+#[derive(Debug, Clone, PartialEq)]
+pub struct ValueReference {
+    /// The function or method the reference appears in
+    pub context: String,
+
+    /// The referenced name, without qualification: `timeout` for
+    /// `self.config.timeout`
+    pub name: String,
+
+    /// The expression or type the value is reached through
+    ///
+    /// - `None` for a bare name (`MAX_RETRIES`)
+    /// - `Some("self.config")` for `self.config.timeout`
+    /// - `Some("Config")` for `Config::DEFAULT` or `Config { timeout: 5 }`
+    pub receiver: Option<String>,
+
+    pub access: Access,
+
+    /// Location of the reference
+    pub range: Range,
+}
+
+impl ValueReference {
+    pub fn new(context: &str, name: &str, access: Access, range: Range) -> Self {
+        Self {
+            context: context.to_string(),
+            name: name.to_string(),
+            receiver: None,
+            access,
+            range,
+        }
+    }
+
+    pub fn with_receiver(mut self, receiver: &str) -> Self {
+        self.receiver = Some(receiver.to_string());
+        self
+    }
+}
+
+/// Whether a name follows the SCREAMING_CASE convention of constants,
+/// statics and module-level globals
+pub fn is_constant_case(name: &str) -> bool {
+    name.chars().any(|c| c.is_alphabetic())
+        && name
+            .chars()
+            .all(|c| c.is_uppercase() || c.is_ascii_digit() || c == '_')
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_constant_case() {
+        assert!(is_constant_case("MAX_RETRIES"));
+        assert!(is_constant_case("V2"));
+        assert!(!is_constant_case("MaxRetries"));
+        assert!(!is_constant_case("__"));
+        assert!(!is_constant_case("max"));
+    }
+}
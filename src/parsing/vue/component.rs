@@ -0,0 +1,376 @@
+//! Component options of a Vue script
+//!
+//! A component declares its name, props and emitted events through the
+//! compiler macros of `<script setup>`:
+//!
+//! ```ts
+//! defineOptions({ name: 'UserCard' })
+//! const props = defineProps<{ user: User; compact?: boolean }>()
+//! const emit = defineEmits<{ (e: 'select', id: number): void }>()
+//! const open = defineModel<boolean>('open')
+//! ```
+//!
+//! or through the options object of `export default { ... }` and
+//! `export default defineComponent({ ... })`. Props and events may be
+//! declared by type (inline or a named interface or type alias of the
+//! script), by an object of runtime declarations or by an array of names.
+//! `defineModel` declares a prop; its `update:` event is implied.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::sfc::COMPONENT_PLACEHOLDER;
+use tree_sitter::Node;
+
+/// What a component member declares
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum MemberKind {
+    Prop,
+    Event,
+}
+
+/// A declared prop or event and the node declaring it
+#[derive(Debug, Clone)]
+pub struct Member<'a, 't> {
+    pub kind: MemberKind,
+    pub name: &'a str,
+    pub node: Node<'t>,
+}
+
+/// Name and members a component script declares
+#[derive(Debug, Default)]
+pub struct ComponentApi<'a, 't> {
+    /// The `name` option, if any
+    pub name: Option<&'a str>,
+    pub members: Vec<Member<'a, 't>>,
+}
+
+impl ComponentApi<'_, '_> {
+    /// The component's name, or [`COMPONENT_PLACEHOLDER`]
+    pub fn component_name(&self) -> &str {
+        self.name.unwrap_or(COMPONENT_PLACEHOLDER)
+    }
+}
+
+/// Read the component options of a script tree
+///
+/// `code` is the text the tree was parsed from, or text with the same byte
+/// layout.
+pub fn read<'a, 't>(root: Node<'t>, code: &'a str) -> ComponentApi<'a, 't> {
+    let mut api = ComponentApi::default();
+    visit(root, root, code, &mut api, 0);
+    api
+}
+
+fn visit<'a, 't>(
+    node: Node<'t>,
+    root: Node<'t>,
+    code: &'a str,
+    api: &mut ComponentApi<'a, 't>,
+    depth: usize,
+) {
+    if !check_recursion_depth(depth, node) {
+        return;
+    }
+
+    match node.kind() {
+        "call_expression" => match callee(&node, code) {
+            Some("defineProps") => declarations(node, root, code, MemberKind::Prop, api),
+            Some("defineEmits") => declarations(node, root, code, MemberKind::Event, api),
+            Some("defineModel") => model(node, code, api),
+            Some("defineOptions" | "defineComponent") => {
+                if let Some(options) = first_argument(&node) {
+                    options_object(options, code, api);
+                }
+            }
+            _ => {}
+        },
+        "export_statement" => {
+            if let Some(value) = node.child_by_field_name("value") {
+                if value.kind() == "object" {
+                    options_object(value, code, api);
+                }
+            }
+        }
+        _ => {}
+    }
+
+    let mut cursor = node.walk();
+    for child in node.children(&mut cursor) {
+        visit(child, root, code, api, depth + 1);
+    }
+}
+
+/// `defineProps` in `defineProps<...>()`
+fn callee<'a>(call: &Node, code: &'a str) -> Option<&'a str> {
+    let function = call.child_by_field_name("function")?;
+    (function.kind() == "identifier").then(|| &code[function.byte_range()])
+}
+
+fn first_argument<'t>(call: &Node<'t>) -> Option<Node<'t>> {
+    let arguments = call.child_by_field_name("arguments")?;
+    let mut cursor = arguments.walk();
+    arguments.named_children(&mut cursor).next()
+}
+
+/// `'select'` / `"select"` -> `select`
+fn string_text<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    if node.kind() != "string" {
+        return None;
+    }
+    let text = &code[node.byte_range()];
+    text.get(1..text.len().saturating_sub(1))
+}
+
+/// Name of an object key or type member: `title`, `'update:title'`
+fn key_text<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    match node.kind() {
+        "property_identifier" | "shorthand_property_identifier" | "identifier" => {
+            Some(&code[node.byte_range()])
+        }
+        "string" => string_text(node, code),
+        _ => None,
+    }
+}
+
+/// Members declared by the type argument or the first argument of a
+/// `defineProps` / `defineEmits` call
+fn declarations<'a, 't>(
+    call: Node<'t>,
+    root: Node<'t>,
+    code: &'a str,
+    kind: MemberKind,
+    api: &mut ComponentApi<'a, 't>,
+) {
+    if let Some(type_arguments) = call.child_by_field_name("type_arguments") {
+        let mut cursor = type_arguments.walk();
+        if let Some(declared) = type_arguments.named_children(&mut cursor).next() {
+            if let Some(body) = type_body(declared, root, code) {
+                type_members(body, code, kind, api);
+            }
+        }
+        return;
+    }
+    if let Some(argument) = first_argument(&call) {
+        runtime_members(argument, code, kind, api);
+    }
+}
+
+/// The object type a props or emits type stands for: inline, or the body
+/// of the interface or type alias of that name declared in the script
+fn type_body<'t>(declared: Node<'t>, root: Node<'t>, code: &str) -> Option<Node<'t>> {
+    match declared.kind() {
+        "object_type" => Some(declared),
+        "type_identifier" => {
+            let name = &code[declared.byte_range()];
+            let mut cursor = root.walk();
+            root.named_children(&mut cursor).find_map(|statement| {
+                let declaration = if statement.kind() == "export_statement" {
+                    statement.child_by_field_name("declaration")?
+                } else {
+                    statement
+                };
+                let declared_name = declaration.child_by_field_name("name")?;
+                if &code[declared_name.byte_range()] != name {
+                    return None;
+                }
+                match declaration.kind() {
+                    "interface_declaration" => declaration.child_by_field_name("body"),
+                    "type_alias_declaration" => declaration
+                        .child_by_field_name("value")
+                        .filter(|value| value.kind() == "object_type"),
+                    _ => None,
+                }
+            })
+        }
+        _ => None,
+    }
+}
+
+/// `{ title: string; count?: number }` for props,
+/// `{ (e: 'select', id: number): void }` or `{ select: [id: number] }` for
+/// events
+fn type_members<'a, 't>(
+    body: Node<'t>,
+    code: &'a str,
+    kind: MemberKind,
+    api: &mut ComponentApi<'a, 't>,
+) {
+    let mut cursor = body.walk();
+    for member in body.named_children(&mut cursor) {
+        let name = match member.kind() {
+            "property_signature" | "method_signature" => member
+                .child_by_field_name("name")
+                .and_then(|name| key_text(&name, code)),
+            "call_signature" if kind == MemberKind::Event => event_parameter(&member, code),
+            _ => None,
+        };
+        if let Some(name) = name {
+            api.members.push(Member {
+                kind,
+                name,
+                node: member,
+            });
+        }
+    }
+}
+
+/// `'select'` in `(e: 'select', id: number): void`
+fn event_parameter<'a>(signature: &Node, code: &'a str) -> Option<&'a str> {
+    let parameters = signature.child_by_field_name("parameters")?;
+    let mut cursor = parameters.walk();
+    let first = parameters.named_children(&mut cursor).next()?;
+    let annotation = first.child_by_field_name("type")?;
+    let mut cursor = annotation.walk();
+    let literal = annotation
+        .named_children(&mut cursor)
+        .find(|child| child.kind() == "literal_type")?;
+    let mut cursor = literal.walk();
+    let string = literal.named_children(&mut cursor).next()?;
+    string_text(&string, code)
+}
+
+/// `['title', 'count']` or `{ title: String, count: { type: Number } }`
+fn runtime_members<'a, 't>(
+    declared: Node<'t>,
+    code: &'a str,
+    kind: MemberKind,
+    api: &mut ComponentApi<'a, 't>,
+) {
+    let mut cursor = declared.walk();
+    for entry in declared.named_children(&mut cursor) {
+        let name = match (declared.kind(), entry.kind()) {
+            ("array", "string") => string_text(&entry, code),
+            ("object", "pair") => entry
+                .child_by_field_name("key")
+                .and_then(|key| key_text(&key, code)),
+            ("object", "method_definition") => entry
+                .child_by_field_name("name")
+                .and_then(|name| key_text(&name, code)),
+            ("object", "shorthand_property_identifier") => key_text(&entry, code),
+            _ => None,
+        };
+        if let Some(name) = name {
+            api.members.push(Member {
+                kind,
+                name,
+                node: entry,
+            });
+        }
+    }
+}
+
+/// `defineModel('open')` declares `open`, `defineModel()` `modelValue`
+fn model<'a, 't>(call: Node<'t>, code: &'a str, api: &mut ComponentApi<'a, 't>) {
+    let name = first_argument(&call)
+        .and_then(|argument| string_text(&argument, code))
+        .unwrap_or("modelValue");
+    api.members.push(Member {
+        kind: MemberKind::Prop,
+        name,
+        node: call,
+    });
+}
+
+/// `{ name: 'UserCard', props: ..., emits: ... }`
+fn options_object<'a, 't>(options: Node<'t>, code: &'a str, api: &mut ComponentApi<'a, 't>) {
+    if options.kind() != "object" {
+        return;
+    }
+
+    let mut cursor = options.walk();
+    for pair in options.named_children(&mut cursor) {
+        if pair.kind() != "pair" {
+            continue;
+        }
+        let (Some(key), Some(value)) = (
+            pair.child_by_field_name("key")
+                .and_then(|key| key_text(&key, code)),
+            pair.child_by_field_name("value"),
+        ) else {
+            continue;
+        };
+        match key {
+            "name" => {
+                if let Some(name) = string_text(&value, code) {
+                    api.name = Some(name);
+                }
+            }
+            "props" => runtime_members(value, code, MemberKind::Prop, api),
+            "emits" => runtime_members(value, code, MemberKind::Event, api),
+            _ => {}
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tree_sitter::Parser;
+
+    fn with_api(code: &str, check: impl FnOnce(&ComponentApi)) {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_typescript::LANGUAGE_TSX.into())
+            .unwrap();
+        let tree = parser.parse(code, None).unwrap();
+        check(&read(tree.root_node(), code));
+    }
+
+    fn names(api: &ComponentApi, kind: MemberKind) -> Vec<String> {
+        api.members
+            .iter()
+            .filter(|member| member.kind == kind)
+            .map(|member| member.name.to_string())
+            .collect()
+    }
+
+    #[test]
+    fn test_script_setup_macros() {
+        let code = r#"
+interface Props { user: User; compact?: boolean }
+defineOptions({ name: 'UserCard' })
+const props = withDefaults(defineProps<Props>(), { compact: false })
+const emit = defineEmits<{
+  (e: 'select', id: number): void
+  (e: 'update:compact', value: boolean): void
+}>()
+const open = defineModel<boolean>('open')
+"#;
+        with_api(code, |api| {
+            assert_eq!(api.component_name(), "UserCard");
+            assert_eq!(
+                names(api, MemberKind::Prop),
+                vec!["user", "compact", "open"]
+            );
+            assert_eq!(
+                names(api, MemberKind::Event),
+                vec!["select", "update:compact"]
+            );
+        });
+    }
+
+    #[test]
+    fn test_options_object() {
+        let code = r#"
+export default defineComponent({
+  props: { title: String, count: { type: Number, default: 0 } },
+  emits: ['close'],
+})
+"#;
+        with_api(code, |api| {
+            assert_eq!(api.component_name(), COMPONENT_PLACEHOLDER);
+            assert_eq!(names(api, MemberKind::Prop), vec!["title", "count"]);
+            assert_eq!(names(api, MemberKind::Event), vec!["close"]);
+        });
+    }
+
+    #[test]
+    fn test_runtime_array_props() {
+        with_api(
+            "export default { name: 'Badge', props: ['label'] }",
+            |api| {
+                assert_eq!(api.component_name(), "Badge");
+                assert_eq!(names(api, MemberKind::Prop), vec!["label"]);
+            },
+        );
+    }
+}
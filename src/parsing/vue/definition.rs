@@ -0,0 +1,89 @@
+//! Vue language definition for the registry
+//!
+//! Provides the Vue language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{VueBehavior, VueParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Vue language definition
+pub struct VueLanguage;
+
+impl VueLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("vue");
+}
+
+impl LanguageDefinition for VueLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Vue"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["vue"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = VueParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(VueBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Vue is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Vue is enabled by default
+    }
+}
+
+/// Register Vue language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(VueLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_vue_definition() {
+        let vue = VueLanguage;
+
+        assert_eq!(vue.id(), LanguageId::new("vue"));
+        assert_eq!(vue.name(), "Vue");
+        assert!(vue.extensions().contains(&"vue"));
+    }
+
+    #[test]
+    fn test_vue_enabled_by_default() {
+        let vue = VueLanguage;
+        let settings = Settings::default();
+
+        assert!(vue.default_enabled());
+        assert!(vue.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_vue_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("vue")));
+    }
+}
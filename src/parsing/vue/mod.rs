@@ -0,0 +1,18 @@
+//! Vue single-file component parser implementation
+//!
+//! Indexes `.vue` components: the component itself, the props and events
+//! it declares (see [`component`]) and everything its `<script>` declares,
+//! read by the TypeScript or JavaScript parser on the script alone.
+
+pub mod audit;
+pub mod behavior;
+pub mod component;
+pub mod definition;
+pub mod parser;
+
+pub use behavior::VueBehavior;
+pub use definition::VueLanguage;
+pub use parser::VueParser;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
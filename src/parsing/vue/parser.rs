@@ -0,0 +1,476 @@
+//! Vue single-file component parser
+//!
+//! A `.vue` file is split into its top-level blocks (see
+//! [`crate::parsing::sfc`]). The `<script>` and `<script setup>` blocks are
+//! parsed together, by the TypeScript parser when either is
+//! `lang="ts"` or `lang="tsx"` and by the JavaScript parser otherwise. The
+//! template and styles are blanked out first, so every position reported
+//! is a position in the `.vue` file.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | the component (`name` option, else `<component>`, renamed to the file name) | Class |
+//! | props (`defineProps`, `defineModel`, `props:`) | Field |
+//! | emitted events (`defineEmits`, `emits:`) | Method |
+//! | declarations of the script | as in TypeScript / JavaScript |
+//!
+//! ## Relationships
+//!
+//! The component defines its props and events and uses the components its
+//! template renders (`<UserAvatar />`). Calls, imports, type uses and
+//! inheritance are those of the script.
+//!
+//! ## Documentation
+//!
+//! An HTML comment directly above the first block documents the component,
+//! and `/** */` comments document props and events.
+
+use super::component::{self, ComponentApi, MemberKind};
+use crate::parsing::sfc::{self, COMPONENT_PLACEHOLDER};
+use crate::parsing::{
+    HandledNode, Import, JavaScriptParser, Language, LanguageParser, MethodCall, NodeTracker,
+    NodeTrackingState, TypeScriptParser, truncate_for_display,
+};
+use crate::symbol::ScopeContext;
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use tree_sitter::{Node, Parser};
+
+/// Longest prop or event declaration kept as its signature
+const MAX_SIGNATURE_LEN: usize = 120;
+
+/// The script of a component file
+struct Script<'a> {
+    blocks: Vec<sfc::Block<'a>>,
+    /// The file with everything but its script blocks blanked out
+    source: String,
+    typescript: bool,
+}
+
+impl<'a> Script<'a> {
+    fn new(code: &'a str) -> Self {
+        let blocks = sfc::split(code);
+        let scripts: Vec<&sfc::Block> = blocks
+            .iter()
+            .filter(|block| block.tag == "script")
+            .collect();
+        let typescript = scripts
+            .iter()
+            .any(|block| matches!(block.attribute("lang"), Some("ts" | "tsx")));
+        let keep: Vec<_> = scripts.iter().map(|block| block.content.clone()).collect();
+        let source = sfc::mask(code, &keep);
+
+        Self {
+            blocks,
+            source,
+            typescript,
+        }
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+/// Vue single-file component parser
+pub struct VueParser {
+    typescript: TypeScriptParser,
+    javascript: JavaScriptParser,
+    /// Reads the component options of the script
+    parser: Parser,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for VueParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("VueParser")
+            .field("language", &"Vue")
+            .finish()
+    }
+}
+
+impl VueParser {
+    /// Create a new Vue parser instance
+    pub fn new() -> Result<Self, String> {
+        let mut parser = Parser::new();
+        // The TSX grammar reads TypeScript and JavaScript scripts alike
+        parser
+            .set_language(&tree_sitter_typescript::LANGUAGE_TSX.into())
+            .map_err(|e| format!("Failed to set Vue script language: {e}"))?;
+
+        Ok(Self {
+            typescript: TypeScriptParser::new()?,
+            javascript: JavaScriptParser::new()?,
+            parser,
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    /// The parser for the script's language
+    fn script_parser(&mut self, script: &Script) -> &mut dyn LanguageParser {
+        if script.typescript {
+            &mut self.typescript
+        } else {
+            &mut self.javascript
+        }
+    }
+
+    /// The component and its props and events
+    fn component_symbols(
+        &mut self,
+        api: &ComponentApi,
+        script: &Script,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        let name = api.name.unwrap_or(COMPONENT_PLACEHOLDER);
+        let mut symbols = Vec::new();
+
+        let mut component = Symbol::new(
+            counter.next_id(),
+            name,
+            SymbolKind::Class,
+            file_id,
+            sfc::range_of(code, 0..code.len()),
+        )
+        .with_visibility(Visibility::Public);
+        let opening = script
+            .blocks
+            .iter()
+            .find(|block| block.tag == "script")
+            .or(script.blocks.first());
+        if let Some(block) = opening {
+            component = component.with_signature(block.open_tag);
+        }
+        if let Some(first) = script.blocks.first() {
+            if let Some(doc) = sfc::leading_comment(code, first.span.start) {
+                component = component.with_doc(doc);
+            }
+        }
+        component.scope_context = Some(ScopeContext::Module);
+        symbols.push(component);
+
+        for member in &api.members {
+            self.register_handled_node(member.node.kind(), member.node.kind_id());
+
+            let kind = match member.kind {
+                MemberKind::Prop => SymbolKind::Field,
+                MemberKind::Event => SymbolKind::Method,
+            };
+            let declaration = code[member.node.byte_range()]
+                .split_whitespace()
+                .collect::<Vec<_>>()
+                .join(" ");
+            let declaration = declaration.trim_end_matches([';', ',']);
+
+            let mut symbol = Symbol::new(
+                counter.next_id(),
+                member.name,
+                kind,
+                file_id,
+                range_from_node(&member.node),
+            )
+            .with_signature(truncate_for_display(declaration, MAX_SIGNATURE_LEN))
+            .with_visibility(Visibility::Public);
+            if let Some(doc) = self.extract_doc_comment(&member.node, code) {
+                symbol = symbol.with_doc(doc);
+            }
+            symbol.scope_context = Some(ScopeContext::ClassMember {
+                class_name: Some(name.into()),
+            });
+            symbols.push(symbol);
+        }
+
+        symbols
+    }
+}
+
+impl NodeTracker for VueParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+impl LanguageParser for VueParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        let script = Script::new(code);
+
+        let mut symbols = match self.parser.parse(&script.source, None) {
+            Some(tree) => {
+                let api = component::read(tree.root_node(), code);
+                self.component_symbols(&api, &script, code, file_id, symbol_counter)
+            }
+            None => Vec::new(),
+        };
+        symbols.extend(
+            self.script_parser(&script)
+                .parse(&script.source, file_id, symbol_counter),
+        );
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// `/** */` comments, as in the script's language
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        self.typescript.extract_doc_comment(node, code)
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let calls = self.script_parser(&script).find_calls(&script.source);
+        sfc::remap_all(code, &script.source, calls)
+    }
+
+    fn find_method_calls(&mut self, code: &str) -> Vec<MethodCall> {
+        let script = Script::new(code);
+        self.script_parser(&script)
+            .find_method_calls(&script.source)
+    }
+
+    fn find_implementations<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let implementations = self
+            .script_parser(&script)
+            .find_implementations(&script.source);
+        sfc::remap_all(code, &script.source, implementations)
+    }
+
+    fn find_extends<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let extends = self.script_parser(&script).find_extends(&script.source);
+        sfc::remap_all(code, &script.source, extends)
+    }
+
+    /// Type uses of the script, and the components the template renders
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let found = self.script_parser(&script).find_uses(&script.source);
+        let mut uses = sfc::remap_all(code, &script.source, found);
+
+        let name = self
+            .parser
+            .parse(&script.source, None)
+            .and_then(|tree| component::read(tree.root_node(), code).name)
+            .unwrap_or(COMPONENT_PLACEHOLDER);
+        for template in script.blocks.iter().filter(|block| block.tag == "template") {
+            for (tag, span) in sfc::component_tags(code, template.content.clone()) {
+                uses.push((name, tag, sfc::range_of(code, span)));
+            }
+        }
+
+        uses
+    }
+
+    fn find_uses_owned(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let script = Script::new(code);
+        self.script_parser(&script).find_uses_owned(&script.source)
+    }
+
+    /// Definitions of the script, and the component's props and events
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let found = self.script_parser(&script).find_defines(&script.source);
+        let mut defines = sfc::remap_all(code, &script.source, found);
+
+        if let Some(tree) = self.parser.parse(&script.source, None) {
+            let api = component::read(tree.root_node(), code);
+            let name = api.name.unwrap_or(COMPONENT_PLACEHOLDER);
+            for member in &api.members {
+                defines.push((name, member.name, range_from_node(&member.node)));
+            }
+        }
+
+        defines
+    }
+
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let script = Script::new(code);
+        self.script_parser(&script)
+            .find_imports(&script.source, file_id)
+    }
+
+    fn language(&self) -> Language {
+        Language::Vue
+    }
+
+    fn find_variable_types<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let script = Script::new(code);
+        let bindings = self
+            .script_parser(&script)
+            .find_variable_types(&script.source);
+        sfc::remap_all(code, &script.source, bindings)
+    }
+
+    fn find_variable_types_with_substitution(
+        &mut self,
+        code: &str,
+    ) -> Option<Vec<(String, String, Range)>> {
+        let script = Script::new(code);
+        self.script_parser(&script)
+            .find_variable_types_with_substitution(&script.source)
+    }
+
+    fn find_inherent_methods(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let script = Script::new(code);
+        self.script_parser(&script)
+            .find_inherent_methods(&script.source)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const COMPONENT: &str = r#"<!-- Card showing a user -->
+<script setup lang="ts">
+import { computed } from 'vue'
+import UserAvatar from './UserAvatar.vue'
+
+defineOptions({ name: 'UserCard' })
+
+const props = defineProps<{
+  /** The user to show */
+  user: User
+  compact?: boolean
+}>()
+
+const emit = defineEmits<{ (e: 'select', id: number): void }>()
+
+function select() {
+  emit('select', props.user.id)
+}
+</script>
+
+<template>
+  <div class="card" @click="select">
+    <UserAvatar :user="user" />
+  </div>
+</template>
+
+<style scoped>
+.card { padding: 1rem; }
+</style>
+"#;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = VueParser::new().unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, FileId::new(1).unwrap(), &mut counter)
+    }
+
+    fn find<'s>(symbols: &'s [Symbol], name: &str) -> &'s Symbol {
+        symbols
+            .iter()
+            .find(|symbol| symbol.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("symbol {name} not found"))
+    }
+
+    #[test]
+    fn test_component_props_and_events() {
+        let symbols = parse(COMPONENT);
+
+        let component = find(&symbols, "UserCard");
+        assert_eq!(component.kind, SymbolKind::Class);
+        assert_eq!(
+            component.doc_comment.as_deref(),
+            Some("Card showing a user")
+        );
+        assert_eq!(
+            component.signature.as_deref(),
+            Some(r#"<script setup lang="ts">"#)
+        );
+
+        let user = find(&symbols, "user");
+        assert_eq!(user.kind, SymbolKind::Field);
+        assert_eq!(user.signature.as_deref(), Some("user: User"));
+        assert_eq!(user.doc_comment.as_deref(), Some("The user to show"));
+        assert_eq!(find(&symbols, "compact").kind, SymbolKind::Field);
+        assert_eq!(find(&symbols, "select").kind, SymbolKind::Method);
+    }
+
+    #[test]
+    fn test_script_positions_are_file_positions() {
+        let symbols = parse(COMPONENT);
+
+        let select = symbols
+            .iter()
+            .find(|symbol| symbol.name.as_ref() == "select" && symbol.kind == SymbolKind::Function)
+            .expect("script function");
+        assert_eq!(select.range.start_line, 15);
+    }
+
+    #[test]
+    fn test_template_components_and_defines() {
+        let mut parser = VueParser::new().unwrap();
+
+        let uses = parser.find_uses(COMPONENT);
+        assert!(uses.contains(&("UserCard", "UserAvatar", Range::new(22, 5, 22, 15))));
+
+        let defines = parser.find_defines(COMPONENT);
+        let members: Vec<&str> = defines
+            .iter()
+            .filter(|(component, _, _)| *component == "UserCard")
+            .map(|(_, member, _)| *member)
+            .collect();
+        assert_eq!(members, vec!["user", "compact", "select"]);
+
+        let calls = parser.find_calls(COMPONENT);
+        assert!(
+            calls
+                .iter()
+                .any(|(from, to, _)| *from == "select" && *to == "emit")
+        );
+    }
+
+    #[test]
+    fn test_javascript_component_without_name() {
+        let code = r#"<template><p>{{ label }}</p></template>
+<script>
+export default {
+  props: ['label'],
+  emits: ['close'],
+}
+</script>
+"#;
+        let symbols = parse(code);
+
+        assert_eq!(
+            find(&symbols, COMPONENT_PLACEHOLDER).kind,
+            SymbolKind::Class
+        );
+        assert_eq!(find(&symbols, "label").kind, SymbolKind::Field);
+        assert_eq!(find(&symbols, "close").kind, SymbolKind::Method);
+
+        let mut parser = VueParser::new().unwrap();
+        assert!(
+            parser
+                .find_imports(code, FileId::new(1).unwrap())
+                .is_empty()
+        );
+    }
+}
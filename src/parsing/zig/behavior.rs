@@ -0,0 +1,225 @@
+//! Zig-specific language behavior implementation
+//!
+//! Every Zig file is a struct, and `@import("math/geometry.zig")` is
+//! resolved relative to the importing file. Module paths are dotted file
+//! paths below `src` (`src/math/geometry.zig` becomes `math.geometry`), and
+//! relative import paths are rewritten to the same form at parse time.
+//! Package imports such as `@import("std")` are left untouched.
+
+use crate::parsing::LanguageBehavior;
+use crate::parsing::ResolutionScope;
+use crate::parsing::behavior_state::{BehaviorState, StatefulBehavior};
+use crate::{FileId, Visibility};
+use std::path::{Path, PathBuf};
+use tree_sitter::Language;
+
+/// Zig language behavior implementation
+#[derive(Clone)]
+pub struct ZigBehavior {
+    language: Language,
+    state: BehaviorState,
+}
+
+impl ZigBehavior {
+    /// Create a new Zig behavior instance
+    pub fn new() -> Self {
+        Self {
+            language: tree_sitter_zig::LANGUAGE.into(),
+            state: BehaviorState::new(),
+        }
+    }
+}
+
+impl StatefulBehavior for ZigBehavior {
+    fn state(&self) -> &BehaviorState {
+        &self.state
+    }
+}
+
+impl Default for ZigBehavior {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl LanguageBehavior for ZigBehavior {
+    fn language_id(&self) -> crate::parsing::registry::LanguageId {
+        crate::parsing::registry::LanguageId::new("zig")
+    }
+
+    fn format_module_path(&self, base_path: &str, _symbol_name: &str) -> String {
+        base_path.to_string()
+    }
+
+    fn parse_visibility(&self, signature: &str) -> Visibility {
+        if signature.starts_with("pub ") {
+            Visibility::Public
+        } else {
+            Visibility::Private
+        }
+    }
+
+    fn configure_symbol(&self, symbol: &mut crate::Symbol, module_path: Option<&str>) {
+        // Container fields carry no `pub` but are always accessible; keep
+        // what the parser assigned.
+        if let Some(path) = module_path {
+            symbol.module_path = Some(path.to_string().into());
+        }
+    }
+
+    fn module_separator(&self) -> &'static str {
+        "."
+    }
+
+    fn source_roots(&self) -> &'static [&'static str] {
+        &["src"]
+    }
+
+    fn format_path_as_module(&self, components: &[&str]) -> Option<String> {
+        if components.is_empty() {
+            None
+        } else {
+            Some(components.join("."))
+        }
+    }
+
+    fn supports_traits(&self) -> bool {
+        false
+    }
+
+    fn supports_inherent_methods(&self) -> bool {
+        true // Container-level functions
+    }
+
+    fn get_language(&self) -> Language {
+        self.language.clone()
+    }
+
+    fn create_resolution_context(&self, file_id: FileId) -> Box<dyn ResolutionScope> {
+        Box::new(super::ZigResolutionContext::new(file_id))
+    }
+
+    fn create_inheritance_resolver(&self) -> Box<dyn crate::parsing::InheritanceResolver> {
+        Box::new(crate::parsing::GenericInheritanceResolver::new())
+    }
+
+    /// `@import("vector.zig")` in `math.geometry` becomes `math.vector`
+    fn normalize_import_path(
+        &self,
+        import_path: &str,
+        importing_module: Option<&str>,
+        _file_path: &Path,
+    ) -> Option<String> {
+        // `std.mem` and other package imports are already canonical
+        let (file_path, member_path) = match import_path.split_once(".zig") {
+            Some((file, rest)) => (file, rest),
+            None => return None,
+        };
+
+        let mut segments: Vec<String> = importing_module
+            .map(|module| module.split('.').map(str::to_string).collect())
+            .unwrap_or_default();
+        // The importing file itself is the last segment
+        segments.pop();
+
+        for part in file_path.split('/') {
+            match part {
+                "" | "." => {}
+                ".." => {
+                    segments.pop();
+                }
+                other => segments.push(other.to_string()),
+            }
+        }
+
+        Some(format!("{}{member_path}", segments.join(".")))
+    }
+
+    fn import_matches_symbol(
+        &self,
+        import_path: &str,
+        symbol_module_path: &str,
+        _importing_module: Option<&str>,
+    ) -> bool {
+        import_path == symbol_module_path
+            || symbol_module_path.starts_with(&format!("{import_path}."))
+    }
+
+    // Override import tracking methods to use state
+    fn register_file(&self, path: PathBuf, file_id: FileId, module_path: String) {
+        self.register_file_with_state(path, file_id, module_path);
+    }
+
+    fn add_import(&self, import: crate::parsing::Import) {
+        self.add_import_with_state(import);
+    }
+
+    fn get_imports_for_file(&self, file_id: FileId) -> Vec<crate::parsing::Import> {
+        self.get_imports_from_state(file_id)
+    }
+
+    fn get_module_path_for_file(&self, file_id: FileId) -> Option<String> {
+        self.state.get_module_path(file_id)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_module_path_from_file() {
+        let behavior = ZigBehavior::new();
+        let root = Path::new("/project");
+
+        assert_eq!(
+            behavior.module_path_from_file(
+                Path::new("/project/src/math/geometry.zig"),
+                root,
+                &["zig"]
+            ),
+            Some("math.geometry".to_string())
+        );
+    }
+
+    #[test]
+    fn test_normalize_relative_import() {
+        let behavior = ZigBehavior::new();
+        let file = Path::new("src/math/geometry.zig");
+
+        assert_eq!(
+            behavior.normalize_import_path("vector.zig", Some("math.geometry"), file),
+            Some("math.vector".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("../util/strings.zig", Some("math.geometry"), file),
+            Some("util.strings".to_string())
+        );
+        assert_eq!(
+            behavior.normalize_import_path("std", Some("math.geometry"), file),
+            None
+        );
+    }
+
+    #[test]
+    fn test_import_matches_symbol() {
+        let behavior = ZigBehavior::new();
+
+        assert!(behavior.import_matches_symbol("math.vector", "math.vector", None));
+        assert!(behavior.import_matches_symbol("math", "math.vector", None));
+        assert!(!behavior.import_matches_symbol("math.vec", "math.vector", None));
+    }
+
+    #[test]
+    fn test_parse_visibility() {
+        let behavior = ZigBehavior::new();
+        assert_eq!(
+            behavior.parse_visibility("pub fn init() Point"),
+            Visibility::Public
+        );
+        assert_eq!(
+            behavior.parse_visibility("fn helper() void"),
+            Visibility::Private
+        );
+    }
+}
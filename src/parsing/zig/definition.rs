@@ -0,0 +1,89 @@
+//! Zig language definition for the registry
+//!
+//! Provides the Zig language implementation that self-registers
+//! with the global registry.
+
+use std::sync::Arc;
+
+use super::{ZigBehavior, ZigParser};
+use crate::parsing::{LanguageBehavior, LanguageDefinition, LanguageId, LanguageParser};
+use crate::{IndexError, IndexResult, Settings};
+
+/// Zig language definition
+pub struct ZigLanguage;
+
+impl ZigLanguage {
+    /// Language identifier constant
+    pub const ID: LanguageId = LanguageId::new("zig");
+}
+
+impl LanguageDefinition for ZigLanguage {
+    fn id(&self) -> LanguageId {
+        Self::ID
+    }
+
+    fn name(&self) -> &'static str {
+        "Zig"
+    }
+
+    fn extensions(&self) -> &'static [&'static str] {
+        &["zig"]
+    }
+
+    fn create_parser(&self, _settings: &Settings) -> IndexResult<Box<dyn LanguageParser>> {
+        let parser = ZigParser::new().map_err(|e| IndexError::General(e.to_string()))?;
+        Ok(Box::new(parser))
+    }
+
+    fn create_behavior(&self) -> Box<dyn LanguageBehavior> {
+        Box::new(ZigBehavior::new())
+    }
+
+    fn default_enabled(&self) -> bool {
+        true // Zig is enabled by default
+    }
+
+    fn is_enabled(&self, settings: &Settings) -> bool {
+        settings
+            .languages
+            .get(self.id().as_str())
+            .map(|config| config.enabled)
+            .unwrap_or(true) // Zig is enabled by default
+    }
+}
+
+/// Register Zig language with the global registry
+pub(crate) fn register(registry: &mut crate::parsing::LanguageRegistry) {
+    registry.register(Arc::new(ZigLanguage));
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::parsing::{LanguageId, get_registry};
+
+    #[test]
+    fn test_zig_definition() {
+        let zig = ZigLanguage;
+
+        assert_eq!(zig.id(), LanguageId::new("zig"));
+        assert_eq!(zig.name(), "Zig");
+        assert!(zig.extensions().contains(&"zig"));
+    }
+
+    #[test]
+    fn test_zig_enabled_by_default() {
+        let zig = ZigLanguage;
+        let settings = Settings::default();
+
+        assert!(zig.default_enabled());
+        assert!(zig.is_enabled(&settings));
+    }
+
+    #[test]
+    fn test_zig_in_registry() {
+        let registry = get_registry();
+        let registry = registry.lock().unwrap();
+        assert!(registry.is_available(LanguageId::new("zig")));
+    }
+}
@@ -0,0 +1,21 @@
+//! Zig language parser implementation
+//!
+//! Indexes functions, containers (structs, enums, unions, opaques and error
+//! sets), container fields and top-level declarations. Container-level
+//! functions are attributed to their container so method-style queries find
+//! them, including members of anonymous structs returned by generic type
+//! constructors.
+
+pub mod audit;
+pub mod behavior;
+pub mod definition;
+pub mod parser;
+pub mod resolution;
+
+pub use behavior::ZigBehavior;
+pub use definition::ZigLanguage;
+pub use parser::ZigParser;
+pub use resolution::ZigResolutionContext;
+
+// Re-export for registry registration
+pub(crate) use definition::register;
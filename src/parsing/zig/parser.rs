@@ -0,0 +1,1108 @@
+//! Zig language parser implementation
+//!
+//! Extracts symbols and relationships from Zig source using tree-sitter-zig.
+//!
+//! ## Supported Constructs
+//!
+//! | Construct | SymbolKind |
+//! |-----------|------------|
+//! | `const S = struct { ... }` / union / opaque | Struct |
+//! | `const E = enum { ... }` | Enum (values are Constant) |
+//! | `const E = error { ... }` | Enum |
+//! | fn (in a container) | Method |
+//! | fn (top level) | Function |
+//! | container field | Field |
+//! | top-level / container `const` | Constant |
+//! | top-level / container `var` | Variable |
+//!
+//! Zig has no methods as such: functions declared inside a container are
+//! namespaced by it and callable with method syntax. They are emitted as
+//! Methods with a `ClassMember` scope naming the container, and reported
+//! through `find_inherent_methods`.
+//!
+//! Generic types are functions returning `type`
+//! (`fn ArrayList(comptime T: type) type { return struct { ... }; }`). The
+//! returned anonymous struct's members are attributed to the function name.
+//!
+//! ## Relationships
+//!
+//! - `@import("x.zig")` bindings are reported as imports
+//! - Containers define their fields, constants and functions (`find_defines`)
+//! - `obj.method()` calls are reported as method calls
+//!
+//! ## Visibility
+//!
+//! Declarations are file-private unless marked `pub`. Container fields are
+//! always accessible and are Public.
+
+use crate::parsing::parser::check_recursion_depth;
+use crate::parsing::{
+    HandledNode, Import, Language, LanguageParser, MethodCall, NodeTracker, NodeTrackingState,
+    ParserContext, ScopeType,
+};
+use crate::types::SymbolCounter;
+use crate::{FileId, Range, Symbol, SymbolKind, Visibility};
+use std::any::Any;
+use std::collections::HashSet;
+use thiserror::Error;
+use tree_sitter::{Node, Parser};
+
+/// Primitive types filtered from type usage tracking
+const ZIG_BUILTIN_TYPES: &[&str] = &[
+    "u8",
+    "u16",
+    "u32",
+    "u64",
+    "u128",
+    "usize",
+    "i8",
+    "i16",
+    "i32",
+    "i64",
+    "i128",
+    "isize",
+    "f16",
+    "f32",
+    "f64",
+    "f128",
+    "bool",
+    "void",
+    "noreturn",
+    "type",
+    "anyerror",
+    "anytype",
+    "anyopaque",
+    "comptime_int",
+    "comptime_float",
+    "c_int",
+    "c_uint",
+    "c_long",
+    "c_ulong",
+    "c_char",
+];
+
+/// Zig-specific parsing errors
+#[derive(Error, Debug)]
+pub enum ZigParseError {
+    #[error(
+        "Failed to initialize Zig parser: {reason}\nSuggestion: Ensure tree-sitter-zig is properly installed"
+    )]
+    ParserInitFailed { reason: String },
+}
+
+/// Zig language parser
+pub struct ZigParser {
+    parser: Parser,
+    context: ParserContext,
+    node_tracker: NodeTrackingState,
+}
+
+impl std::fmt::Debug for ZigParser {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("ZigParser")
+            .field("language", &"Zig")
+            .finish()
+    }
+}
+
+fn range_from_node(node: &Node) -> Range {
+    let start = node.start_position();
+    let end = node.end_position();
+    Range::new(
+        start.row as u32,
+        start.column as u16,
+        end.row as u32,
+        end.column as u16,
+    )
+}
+
+fn is_container(kind: &str) -> bool {
+    matches!(
+        kind,
+        "struct_declaration"
+            | "union_declaration"
+            | "enum_declaration"
+            | "opaque_declaration"
+            | "error_set_declaration"
+    )
+}
+
+/// Whether the node carries the anonymous `pub` keyword
+fn is_pub(node: &Node) -> bool {
+    let mut cursor = node.walk();
+    node.children(&mut cursor)
+        .any(|child| !child.is_named() && child.kind() == "pub")
+}
+
+fn visibility_of(node: &Node) -> Visibility {
+    if is_pub(node) {
+        Visibility::Public
+    } else {
+        Visibility::Private
+    }
+}
+
+/// Name of a `const`/`var` declaration: the first identifier child
+fn declaration_name<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    let mut cursor = node.walk();
+    node.named_children(&mut cursor)
+        .find(|child| child.kind() == "identifier")
+        .map(|child| &code[child.byte_range()])
+}
+
+/// Initializer of a `const`/`var` declaration: the node following `=`
+fn declaration_value<'a>(node: &Node<'a>) -> Option<Node<'a>> {
+    let mut cursor = node.walk();
+    let mut after_eq = false;
+    for child in node.children(&mut cursor) {
+        if after_eq && child.is_named() && child.kind() != "comment" {
+            return Some(child);
+        }
+        if child.kind() == "=" {
+            after_eq = true;
+        }
+    }
+    None
+}
+
+/// `@import("path")` target, if the node is an import call
+fn import_target<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    if node.kind() != "builtin_function" {
+        return None;
+    }
+    let mut cursor = node.walk();
+    let mut children = node.named_children(&mut cursor);
+    let builtin = children.next()?;
+    if &code[builtin.byte_range()] != "@import" {
+        return None;
+    }
+    let arguments = children.next()?;
+    let text = &code[arguments.byte_range()];
+    let start = text.find('"')? + 1;
+    let end = start + text[start..].find('"')?;
+    Some(&text[start..end])
+}
+
+/// `@import("std").mem` -> the import call inside a field access chain
+fn find_import_in_value<'a>(node: &Node, code: &'a str) -> Option<&'a str> {
+    match node.kind() {
+        "builtin_function" => import_target(node, code),
+        "field_expression" => node
+            .child_by_field_name("object")
+            .or_else(|| node.named_child(0))
+            .and_then(|object| find_import_in_value(&object, code)),
+        _ => None,
+    }
+}
+
+/// Whether a function returns `type` (`fn List(comptime T: type) type`)
+fn returns_type(node: &Node, code: &str) -> bool {
+    node.child_by_field_name("type")
+        .is_some_and(|ret| &code[ret.byte_range()] == "type")
+}
+
+/// The struct literal returned by a type-constructor function body
+fn returned_container<'a>(body: &Node<'a>) -> Option<Node<'a>> {
+    let mut stack = vec![*body];
+    while let Some(node) = stack.pop() {
+        if is_container(node.kind()) {
+            return Some(node);
+        }
+        // Do not descend into nested functions
+        if node.kind() == "function_declaration" {
+            continue;
+        }
+        let mut cursor = node.walk();
+        stack.extend(node.named_children(&mut cursor));
+    }
+    None
+}
+
+/// Name and body of a container: `const S = struct {..}` or a type-constructor
+/// function returning an anonymous struct
+fn named_container<'a>(node: &Node<'a>, code: &'a str) -> Option<(&'a str, Node<'a>)> {
+    match node.kind() {
+        "variable_declaration" => declaration_name(node, code)
+            .zip(declaration_value(node).filter(|value| is_container(value.kind()))),
+        "function_declaration" if returns_type(node, code) => node
+            .child_by_field_name("name")
+            .map(|name| &code[name.byte_range()])
+            .zip(
+                node.child_by_field_name("body")
+                    .and_then(|body| returned_container(&body)),
+            ),
+        _ => None,
+    }
+}
+
+impl ZigParser {
+    /// Create a new Zig parser instance
+    pub fn new() -> Result<Self, ZigParseError> {
+        let mut parser = Parser::new();
+        parser
+            .set_language(&tree_sitter_zig::LANGUAGE.into())
+            .map_err(|e| ZigParseError::ParserInitFailed {
+                reason: format!("tree-sitter error: {e}"),
+            })?;
+
+        Ok(Self {
+            parser,
+            context: ParserContext::new(),
+            node_tracker: NodeTrackingState::new(),
+        })
+    }
+
+    #[allow(clippy::too_many_arguments)]
+    fn create_symbol(
+        &self,
+        counter: &mut SymbolCounter,
+        name: &str,
+        kind: SymbolKind,
+        file_id: FileId,
+        range: Range,
+        signature: String,
+        doc_comment: Option<String>,
+        visibility: Visibility,
+    ) -> Symbol {
+        let mut symbol = Symbol::new(counter.next_id(), name, kind, file_id, range)
+            .with_signature(signature)
+            .with_visibility(visibility);
+        if let Some(doc) = doc_comment {
+            symbol = symbol.with_doc(doc);
+        }
+        symbol.scope_context = Some(self.context.current_scope_context());
+        symbol
+    }
+
+    /// Extract symbols from AST node recursively
+    fn extract_symbols_from_node(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        if !check_recursion_depth(depth, node) {
+            return;
+        }
+
+        match node.kind() {
+            "function_declaration" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_function(node, code, file_id, counter, symbols, depth);
+            }
+            "variable_declaration" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_variable(node, code, file_id, counter, symbols, depth);
+            }
+            "container_field" => {
+                self.register_handled_node(node.kind(), node.kind_id());
+                self.process_container_field(node, code, file_id, counter, symbols);
+            }
+            "comptime_declaration" | "test_declaration" => {
+                // Unnamed blocks; nested declarations are still indexed
+                self.register_handled_node(node.kind(), node.kind_id());
+                let saved_function = self.context.current_function().map(|s| s.to_string());
+                self.context.enter_scope(ScopeType::function());
+                self.extract_children(node, code, file_id, counter, symbols, depth);
+                self.context.exit_scope();
+                self.context.set_current_function(saved_function);
+            }
+            _ => {
+                self.extract_children(node, code, file_id, counter, symbols, depth);
+            }
+        }
+    }
+
+    fn extract_children(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            self.extract_symbols_from_node(child, code, file_id, counter, symbols, depth + 1);
+        }
+    }
+
+    /// Walk a container's members with `name` as the enclosing class
+    fn process_container_body(
+        &mut self,
+        container: Node,
+        name: &str,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        self.register_handled_node(container.kind(), container.kind_id());
+
+        let saved_class = self.context.current_class().map(|s| s.to_string());
+        let saved_function = self.context.current_function().map(|s| s.to_string());
+        self.context.enter_scope(ScopeType::Class);
+        self.context.set_current_class(Some(name.to_string()));
+
+        self.extract_children(container, code, file_id, counter, symbols, depth);
+
+        self.context.exit_scope();
+        self.context.set_current_class(saved_class);
+        self.context.set_current_function(saved_function);
+    }
+
+    fn process_function(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(name_node) = node.child_by_field_name("name") else {
+            return;
+        };
+        let name = &code[name_node.byte_range()];
+
+        let kind = if self.context.is_in_class() {
+            SymbolKind::Method
+        } else {
+            SymbolKind::Function
+        };
+
+        let signature_end = node
+            .child_by_field_name("body")
+            .map(|body| body.start_byte())
+            .unwrap_or(node.end_byte());
+        let signature = code[node.start_byte()..signature_end]
+            .trim_end()
+            .trim_end_matches(';')
+            .to_string();
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            signature,
+            self.extract_doc_comment(&node, code),
+            visibility_of(&node),
+        );
+        symbols.push(symbol);
+
+        let Some(body) = node.child_by_field_name("body") else {
+            return;
+        };
+
+        // `fn ArrayList(comptime T: type) type { return struct { ... }; }`
+        if returns_type(&node, code) {
+            if let Some(container) = returned_container(&body) {
+                self.process_container_body(
+                    container, name, code, file_id, counter, symbols, depth,
+                );
+                return;
+            }
+        }
+
+        let saved_function = self.context.current_function().map(|s| s.to_string());
+        self.context.enter_scope(ScopeType::function());
+        self.context.set_current_function(Some(name.to_string()));
+
+        self.extract_symbols_from_node(body, code, file_id, counter, symbols, depth + 1);
+
+        self.context.exit_scope();
+        self.context.set_current_function(saved_function);
+    }
+
+    /// Process `const`/`var` declarations, including named containers
+    fn process_variable(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+        depth: usize,
+    ) {
+        let Some(name) = declaration_name(&node, code) else {
+            return;
+        };
+        let value = declaration_value(&node);
+
+        if let Some(container) = value.filter(|value| is_container(value.kind())) {
+            let kind = match container.kind() {
+                "enum_declaration" | "error_set_declaration" => SymbolKind::Enum,
+                _ => SymbolKind::Struct,
+            };
+            // `const Point = struct` plus any layout/tag prefix
+            let container_head = code[container.byte_range()]
+                .split('{')
+                .next()
+                .unwrap_or("")
+                .trim();
+            let head_end = node
+                .children(&mut node.walk())
+                .find(|child| child.kind() == "=")
+                .map(|eq| eq.start_byte())
+                .unwrap_or(container.start_byte());
+            let signature = format!(
+                "{} = {container_head}",
+                code[node.start_byte()..head_end].trim_end()
+            );
+
+            let symbol = self.create_symbol(
+                counter,
+                name,
+                kind,
+                file_id,
+                range_from_node(&node),
+                signature,
+                self.extract_doc_comment(&node, code),
+                visibility_of(&node),
+            );
+            symbols.push(symbol);
+
+            self.process_container_body(container, name, code, file_id, counter, symbols, depth);
+            return;
+        }
+
+        // Function locals are not indexed
+        if self.context.is_in_function() {
+            return;
+        }
+
+        // `const std = @import("std");` is an import, reported by find_imports
+        if value.is_some_and(|value| find_import_in_value(&value, code).is_some()) {
+            return;
+        }
+
+        let is_var = node
+            .children(&mut node.walk())
+            .any(|child| child.kind() == "var");
+        let kind = if is_var {
+            SymbolKind::Variable
+        } else {
+            SymbolKind::Constant
+        };
+
+        let signature_end = value
+            .map(|value| value.start_byte())
+            .unwrap_or(node.end_byte());
+        let signature = code[node.start_byte()..signature_end]
+            .trim_end()
+            .trim_end_matches(['=', ';'])
+            .trim_end()
+            .to_string();
+
+        let symbol = self.create_symbol(
+            counter,
+            name,
+            kind,
+            file_id,
+            range_from_node(&node),
+            signature,
+            self.extract_doc_comment(&node, code),
+            visibility_of(&node),
+        );
+        symbols.push(symbol);
+
+        if let Some(value) = value {
+            self.extract_symbols_from_node(value, code, file_id, counter, symbols, depth + 1);
+        }
+    }
+
+    fn process_container_field(
+        &mut self,
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        counter: &mut SymbolCounter,
+        symbols: &mut Vec<Symbol>,
+    ) {
+        let Some(name_node) = node
+            .child_by_field_name("name")
+            .or_else(|| node.named_child(0))
+            .filter(|name| name.kind() == "identifier")
+        else {
+            return;
+        };
+
+        // Enum values read as constants; struct/union members as fields
+        let in_enum = node
+            .parent()
+            .is_some_and(|parent| parent.kind() == "enum_declaration");
+        let kind = if in_enum {
+            SymbolKind::Constant
+        } else {
+            SymbolKind::Field
+        };
+
+        let symbol = self.create_symbol(
+            counter,
+            &code[name_node.byte_range()],
+            kind,
+            file_id,
+            range_from_node(&node),
+            code[node.byte_range()]
+                .trim_end_matches(',')
+                .trim()
+                .to_string(),
+            self.extract_doc_comment(&node, code),
+            Visibility::Public,
+        );
+        symbols.push(symbol);
+    }
+
+    fn extract_calls_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        calls: &mut Vec<(&'a str, &'a str, Range)>,
+        current_function: Option<&'a str>,
+    ) {
+        let function = if node.kind() == "function_declaration" {
+            node.child_by_field_name("name")
+                .map(|name| &code[name.byte_range()])
+        } else {
+            current_function
+        };
+
+        if let Some(caller) = function {
+            if node.kind() == "call_expression" {
+                let callee = node
+                    .child_by_field_name("function")
+                    .filter(|callee| callee.kind() == "identifier");
+                if let Some(callee) = callee {
+                    calls.push((caller, &code[callee.byte_range()], range_from_node(&node)));
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_calls_from_node(child, code, calls, function);
+        }
+    }
+
+    fn extract_method_calls_from_node(
+        node: Node,
+        code: &str,
+        out: &mut Vec<MethodCall>,
+        current_function: Option<&str>,
+    ) {
+        let function = if node.kind() == "function_declaration" {
+            node.child_by_field_name("name")
+                .map(|name| &code[name.byte_range()])
+        } else {
+            current_function
+        };
+
+        if node.kind() == "call_expression" {
+            let field = node
+                .child_by_field_name("function")
+                .filter(|callee| callee.kind() == "field_expression");
+            if let (Some(caller), Some(field)) = (function, field) {
+                if let (Some(receiver), Some(method)) = (
+                    field.child_by_field_name("object"),
+                    field.child_by_field_name("member"),
+                ) {
+                    let receiver_text = &code[receiver.byte_range()];
+                    let mut call =
+                        MethodCall::new(caller, &code[method.byte_range()], range_from_node(&node))
+                            .with_receiver(receiver_text);
+                    // `Point.init(...)`: types are conventionally TitleCase
+                    if receiver.kind() == "identifier"
+                        && receiver_text.starts_with(|c: char| c.is_uppercase())
+                    {
+                        call = call.static_method();
+                    }
+                    out.push(call);
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_method_calls_from_node(child, code, out, function);
+        }
+    }
+
+    fn extract_defines_from_node<'a>(
+        node: Node<'a>,
+        code: &'a str,
+        defines: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if let Some((owner, container)) = named_container(&node, code) {
+            let mut cursor = container.walk();
+            for member in container.named_children(&mut cursor) {
+                let member_name = match member.kind() {
+                    "function_declaration" => member
+                        .child_by_field_name("name")
+                        .map(|name| &code[name.byte_range()]),
+                    "variable_declaration" => declaration_name(&member, code),
+                    "container_field" => member
+                        .child_by_field_name("name")
+                        .or_else(|| member.named_child(0))
+                        .filter(|name| name.kind() == "identifier")
+                        .map(|name| &code[name.byte_range()]),
+                    _ => None,
+                };
+                if let Some(member_name) = member_name {
+                    defines.push((owner, member_name, range_from_node(&member)));
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_defines_from_node(child, code, defines);
+        }
+    }
+
+    /// Container-level functions reported against their container
+    fn extract_container_functions_from_node(
+        node: Node,
+        code: &str,
+        methods: &mut Vec<(String, String, Range)>,
+    ) {
+        if let Some((owner, container)) = named_container(&node, code) {
+            let mut cursor = container.walk();
+            for member in container.named_children(&mut cursor) {
+                if member.kind() != "function_declaration" {
+                    continue;
+                }
+                if let Some(name) = member.child_by_field_name("name") {
+                    methods.push((
+                        owner.to_string(),
+                        code[name.byte_range()].to_string(),
+                        range_from_node(&member),
+                    ));
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_container_functions_from_node(child, code, methods);
+        }
+    }
+
+    /// Parameter and return types referenced by functions
+    fn extract_uses_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if node.kind() == "function_declaration" {
+            if let Some(name) = node.child_by_field_name("name") {
+                let function_name = &code[name.byte_range()];
+                for part in [
+                    node.child_by_field_name("parameters"),
+                    node.child_by_field_name("type"),
+                ]
+                .into_iter()
+                .flatten()
+                {
+                    Self::collect_type_identifiers(part, code, function_name, uses);
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_uses_from_node(child, code, uses);
+        }
+    }
+
+    fn collect_type_identifiers<'a>(
+        node: Node,
+        code: &'a str,
+        user: &'a str,
+        uses: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        // Parameter names are identifiers too; only look at type positions
+        if node.kind() == "parameter" {
+            if let Some(ty) = node.child_by_field_name("type") {
+                Self::collect_type_identifiers(ty, code, user, uses);
+            }
+            return;
+        }
+
+        if node.kind() == "identifier" {
+            let type_name = &code[node.byte_range()];
+            if !ZIG_BUILTIN_TYPES.contains(&type_name) {
+                uses.push((user, type_name, range_from_node(&node)));
+            }
+            return;
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::collect_type_identifiers(child, code, user, uses);
+        }
+    }
+
+    /// `var list: List = ...` and `const p = Point.init(...)`
+    fn extract_variable_types_from_node<'a>(
+        node: Node,
+        code: &'a str,
+        bindings: &mut Vec<(&'a str, &'a str, Range)>,
+    ) {
+        if node.kind() == "variable_declaration" {
+            if let Some(name) = declaration_name(&node, code) {
+                let declared = node
+                    .child_by_field_name("type")
+                    .filter(|ty| ty.kind() == "identifier")
+                    .map(|ty| &code[ty.byte_range()]);
+                let inferred = declaration_value(&node).and_then(|value| {
+                    match value.kind() {
+                        // `Point.init(...)` / `Point{ .x = 1 }`
+                        "call_expression" => value
+                            .child_by_field_name("function")
+                            .filter(|f| f.kind() == "field_expression")
+                            .and_then(|f| f.child_by_field_name("object"))
+                            .filter(|object| object.kind() == "identifier")
+                            .map(|object| &code[object.byte_range()]),
+                        "struct_initializer" => value
+                            .named_child(0)
+                            .filter(|ty| ty.kind() == "identifier")
+                            .map(|ty| &code[ty.byte_range()]),
+                        _ => None,
+                    }
+                    .filter(|ty| ty.starts_with(|c: char| c.is_uppercase()))
+                });
+                if let Some(type_name) = declared.or(inferred) {
+                    bindings.push((name, type_name, range_from_node(&node)));
+                }
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_variable_types_from_node(child, code, bindings);
+        }
+    }
+
+    fn extract_imports_from_node(
+        node: Node,
+        code: &str,
+        file_id: FileId,
+        imports: &mut Vec<Import>,
+    ) {
+        if node.kind() == "variable_declaration" {
+            let target = declaration_value(&node)
+                .and_then(|value| find_import_in_value(&value, code).map(|path| (path, value)));
+            if let Some((path, value)) = target {
+                // `const mem = @import("std").mem;` imports `std.mem`
+                let member_path = if value.kind() == "field_expression" {
+                    code[value.byte_range()]
+                        .split_once(')')
+                        .map(|(_, rest)| rest.trim().to_string())
+                        .unwrap_or_default()
+                } else {
+                    String::new()
+                };
+                let name = declaration_name(&node, code);
+                let last_segment = if member_path.is_empty() {
+                    path.trim_end_matches(".zig").rsplit('/').next()
+                } else {
+                    member_path.rsplit('.').next()
+                };
+                let alias = name
+                    .filter(|name| Some(*name) != last_segment)
+                    .map(str::to_string);
+
+                imports.push(Import {
+                    path: format!("{path}{member_path}"),
+                    alias,
+                    file_id,
+                    is_glob: false,
+                    is_type_only: false,
+                });
+                return;
+            }
+        }
+
+        let mut cursor = node.walk();
+        for child in node.named_children(&mut cursor) {
+            Self::extract_imports_from_node(child, code, file_id, imports);
+        }
+    }
+}
+
+impl LanguageParser for ZigParser {
+    fn parse(
+        &mut self,
+        code: &str,
+        file_id: FileId,
+        symbol_counter: &mut SymbolCounter,
+    ) -> Vec<Symbol> {
+        self.context = ParserContext::new();
+
+        let mut symbols = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            self.extract_symbols_from_node(
+                tree.root_node(),
+                code,
+                file_id,
+                symbol_counter,
+                &mut symbols,
+                0,
+            );
+        }
+
+        symbols
+    }
+
+    fn as_any(&self) -> &dyn Any {
+        self
+    }
+
+    /// Consecutive `///` doc comment lines directly above the declaration
+    fn extract_doc_comment(&self, node: &Node, code: &str) -> Option<String> {
+        let mut lines = Vec::new();
+        let mut current = node.prev_sibling();
+        while let Some(prev) = current {
+            if prev.kind() != "comment" {
+                break;
+            }
+            let text = &code[prev.byte_range()];
+            let Some(doc) = text.strip_prefix("///") else {
+                break;
+            };
+            lines.push(doc.trim().to_string());
+            current = prev.prev_sibling();
+        }
+
+        if lines.is_empty() {
+            return None;
+        }
+        lines.reverse();
+        Some(lines.join("\n"))
+    }
+
+    fn find_calls<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_calls_from_node(tree.root_node(), code, &mut calls, None);
+        }
+        calls
+    }
+
+    fn find_method_calls(&mut self, code: &str) -> Vec<MethodCall> {
+        let mut calls = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_method_calls_from_node(tree.root_node(), code, &mut calls, None);
+        }
+        calls
+    }
+
+    fn find_implementations<'a>(&mut self, _code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        // Zig has no interfaces or inheritance
+        Vec::new()
+    }
+
+    fn find_uses<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut uses = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_uses_from_node(tree.root_node(), code, &mut uses);
+        }
+        uses
+    }
+
+    fn find_defines<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut defines = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_defines_from_node(tree.root_node(), code, &mut defines);
+        }
+        defines
+    }
+
+    fn find_variable_types<'a>(&mut self, code: &'a str) -> Vec<(&'a str, &'a str, Range)> {
+        let mut bindings = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_variable_types_from_node(tree.root_node(), code, &mut bindings);
+        }
+        bindings
+    }
+
+    /// Container-level functions attributed to their struct, enum or union
+    fn find_inherent_methods(&mut self, code: &str) -> Vec<(String, String, Range)> {
+        let mut methods = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_container_functions_from_node(tree.root_node(), code, &mut methods);
+        }
+        methods
+    }
+
+    fn find_imports(&mut self, code: &str, file_id: FileId) -> Vec<Import> {
+        let mut imports = Vec::new();
+        if let Some(tree) = self.parser.parse(code, None) {
+            Self::extract_imports_from_node(tree.root_node(), code, file_id, &mut imports);
+        }
+        imports
+    }
+
+    fn language(&self) -> Language {
+        Language::Zig
+    }
+}
+
+impl NodeTracker for ZigParser {
+    fn get_handled_nodes(&self) -> &HashSet<HandledNode> {
+        self.node_tracker.get_handled_nodes()
+    }
+
+    fn register_handled_node(&mut self, node_kind: &str, node_id: u16) {
+        self.node_tracker.register_handled_node(node_kind, node_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(code: &str) -> Vec<Symbol> {
+        let mut parser = ZigParser::new().unwrap();
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        parser.parse(code, file_id, &mut counter)
+    }
+
+    fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .unwrap_or_else(|| panic!("Should find '{name}'"))
+    }
+
+    #[test]
+    fn test_parser_creation() {
+        assert!(ZigParser::new().is_ok());
+    }
+
+    #[test]
+    fn test_struct_methods_and_fields() {
+        let code = r#"
+const std = @import("std");
+
+/// A 2D point
+pub const Point = struct {
+    x: f32,
+    y: f32,
+
+    pub fn init(x: f32, y: f32) Point {
+        return .{ .x = x, .y = y };
+    }
+
+    fn lengthSquared(self: Point) f32 {
+        return self.x * self.x + self.y * self.y;
+    }
+};
+
+pub fn main() void {
+    const p = Point.init(1, 2);
+    _ = p;
+}
+"#;
+        let symbols = parse(code);
+
+        let point = find(&symbols, "Point");
+        assert_eq!(point.kind, SymbolKind::Struct);
+        assert_eq!(point.visibility, Visibility::Public);
+        assert_eq!(point.doc_comment.as_deref(), Some("A 2D point"));
+
+        let init = find(&symbols, "init");
+        assert_eq!(init.kind, SymbolKind::Method);
+        assert_eq!(init.visibility, Visibility::Public);
+        assert!(matches!(
+            &init.scope_context,
+            Some(crate::symbol::ScopeContext::ClassMember { class_name: Some(name) })
+                if name.as_ref() == "Point"
+        ));
+
+        assert_eq!(
+            find(&symbols, "lengthSquared").visibility,
+            Visibility::Private
+        );
+        assert_eq!(find(&symbols, "x").kind, SymbolKind::Field);
+        assert_eq!(find(&symbols, "main").kind, SymbolKind::Function);
+
+        // Imports and locals are not symbols
+        assert!(!symbols.iter().any(|s| s.name.as_ref() == "std"));
+        assert!(!symbols.iter().any(|s| s.name.as_ref() == "p"));
+    }
+
+    #[test]
+    fn test_generic_type_constructor() {
+        let code = r#"
+pub fn Stack(comptime T: type) type {
+    return struct {
+        items: []T,
+
+        pub fn push(self: *@This(), item: T) void {
+            _ = self;
+            _ = item;
+        }
+    };
+}
+"#;
+        let symbols = parse(code);
+        assert_eq!(find(&symbols, "Stack").kind, SymbolKind::Function);
+        assert_eq!(find(&symbols, "push").kind, SymbolKind::Method);
+
+        let mut parser = ZigParser::new().unwrap();
+        let methods = parser.find_inherent_methods(code);
+        assert!(methods.iter().any(|(t, m, _)| t == "Stack" && m == "push"));
+        assert!(!methods.iter().any(|(_, m, _)| m == "items"));
+    }
+
+    #[test]
+    fn test_enum_values_and_constants() {
+        let code = r#"
+const Color = enum {
+    red,
+    green,
+
+    pub fn isWarm(self: Color) bool {
+        return self == .red;
+    }
+};
+
+pub const max_items: usize = 64;
+var counter: u32 = 0;
+"#;
+        let symbols = parse(code);
+        assert_eq!(find(&symbols, "Color").kind, SymbolKind::Enum);
+        assert_eq!(find(&symbols, "Color").visibility, Visibility::Private);
+        assert_eq!(find(&symbols, "red").kind, SymbolKind::Constant);
+        assert_eq!(find(&symbols, "isWarm").kind, SymbolKind::Method);
+        assert_eq!(find(&symbols, "max_items").kind, SymbolKind::Constant);
+        assert_eq!(find(&symbols, "counter").kind, SymbolKind::Variable);
+    }
+
+    #[test]
+    fn test_imports() {
+        let code = r#"
+const std = @import("std");
+const mem = @import("std").mem;
+const geometry = @import("math/geometry.zig");
+"#;
+        let mut parser = ZigParser::new().unwrap();
+        let imports = parser.find_imports(code, FileId::new(1).unwrap());
+
+        assert!(imports.iter().any(|i| i.path == "std" && i.alias.is_none()));
+        assert!(imports.iter().any(|i| i.path == "std.mem"));
+        assert!(
+            imports
+                .iter()
+                .any(|i| i.path == "math/geometry.zig" && i.alias.is_none())
+        );
+    }
+}
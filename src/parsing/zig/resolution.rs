@@ -0,0 +1,218 @@
+//! Zig-specific symbol resolution
+//!
+//! Zig resolves names through:
+//! - Locals and parameters of the current function
+//! - Declarations of the enclosing container and the file (a file is itself
+//!   a struct)
+//! - `@import` bindings
+
+use crate::parsing::resolution::{ImportBinding, ImportOrigin};
+use crate::parsing::{Import, ResolutionScope, ScopeLevel, ScopeType};
+use crate::{FileId, RelationKind, SymbolId};
+use std::collections::HashMap;
+
+/// Zig resolution context
+///
+/// Tracks local, container-level and imported scopes. Qualified references
+/// (`geometry.Point`) fall back to their last segment.
+pub struct ZigResolutionContext {
+    #[allow(dead_code)]
+    file_id: FileId,
+
+    /// Method locals and parameters
+    local_scope: HashMap<String, SymbolId>,
+
+    /// Container members and top-level declarations in this file
+    module_scope: HashMap<String, SymbolId>,
+
+    /// Symbols made available by `@import`
+    imported_scope: HashMap<String, SymbolId>,
+
+    /// Scope stack for nested contexts
+    scope_stack: Vec<ScopeType>,
+
+    /// Import bindings keyed by visible name
+    import_bindings: HashMap<String, ImportBinding>,
+}
+
+impl ZigResolutionContext {
+    pub fn new(file_id: FileId) -> Self {
+        Self {
+            file_id,
+            local_scope: HashMap::new(),
+            module_scope: HashMap::new(),
+            imported_scope: HashMap::new(),
+            scope_stack: Vec::new(),
+            import_bindings: HashMap::new(),
+        }
+    }
+}
+
+impl ResolutionScope for ZigResolutionContext {
+    fn as_any_mut(&mut self) -> &mut dyn std::any::Any {
+        self
+    }
+
+    fn resolve(&self, name: &str) -> Option<SymbolId> {
+        if let Some(&id) = self.local_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.module_scope.get(name) {
+            return Some(id);
+        }
+        if let Some(&id) = self.imported_scope.get(name) {
+            return Some(id);
+        }
+
+        // `geometry.Point` / `std.mem.Allocator` -> last segment
+        if let Some((_, last)) = name.rsplit_once('.') {
+            if !last.is_empty() {
+                return self.resolve(last);
+            }
+        }
+
+        None
+    }
+
+    fn add_symbol(&mut self, name: String, symbol_id: SymbolId, scope_level: ScopeLevel) {
+        match scope_level {
+            ScopeLevel::Local => {
+                self.local_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Module | ScopeLevel::Global => {
+                self.module_scope.insert(name, symbol_id);
+            }
+            ScopeLevel::Package => {
+                self.imported_scope.insert(name, symbol_id);
+            }
+        }
+    }
+
+    fn enter_scope(&mut self, scope_type: ScopeType) {
+        self.scope_stack.push(scope_type);
+    }
+
+    fn exit_scope(&mut self) {
+        if let Some(scope) = self.scope_stack.pop() {
+            if matches!(scope, ScopeType::Function { .. }) {
+                self.clear_local_scope();
+            }
+        }
+    }
+
+    fn clear_local_scope(&mut self) {
+        self.local_scope.clear();
+    }
+
+    fn symbols_in_scope(&self) -> Vec<(String, SymbolId, ScopeLevel)> {
+        let mut symbols = Vec::new();
+
+        for (name, &id) in &self.local_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Local));
+        }
+        for (name, &id) in &self.module_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Module));
+        }
+        for (name, &id) in &self.imported_scope {
+            symbols.push((name.clone(), id, ScopeLevel::Package));
+        }
+
+        symbols
+    }
+
+    fn resolve_relationship(
+        &self,
+        _from_name: &str,
+        to_name: &str,
+        _kind: RelationKind,
+        _from_file: FileId,
+    ) -> Option<SymbolId> {
+        self.resolve(to_name)
+    }
+
+    fn populate_imports(&mut self, imports: &[Import]) {
+        for import in imports {
+            // `const geo = @import("math/geometry.zig")` exposes `geo`;
+            // without an alias the binding is the file stem
+            let name = import.alias.clone().unwrap_or_else(|| {
+                let path = import.path.trim_end_matches(".zig");
+                path.rsplit(['/', '.']).next().unwrap_or(path).to_string()
+            });
+            self.import_bindings.insert(
+                name.clone(),
+                ImportBinding {
+                    import: import.clone(),
+                    exposed_name: name,
+                    origin: ImportOrigin::Unknown,
+                    resolved_symbol: None,
+                },
+            );
+        }
+    }
+
+    fn register_import_binding(&mut self, binding: ImportBinding) {
+        self.import_bindings
+            .insert(binding.exposed_name.clone(), binding);
+    }
+
+    fn import_binding(&self, name: &str) -> Option<ImportBinding> {
+        self.import_bindings.get(name).cloned()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_scope_resolution_order() {
+        let mut context = ZigResolutionContext::new(FileId::new(1).unwrap());
+
+        let local_id = SymbolId::new(1).unwrap();
+        let module_id = SymbolId::new(2).unwrap();
+        let import_id = SymbolId::new(3).unwrap();
+
+        context.add_symbol("x".to_string(), import_id, ScopeLevel::Package);
+        context.add_symbol("x".to_string(), module_id, ScopeLevel::Module);
+        context.add_symbol("x".to_string(), local_id, ScopeLevel::Local);
+
+        assert_eq!(context.resolve("x"), Some(local_id));
+        context.clear_local_scope();
+        assert_eq!(context.resolve("x"), Some(module_id));
+    }
+
+    #[test]
+    fn test_qualified_name_falls_back_to_last_segment() {
+        let mut context = ZigResolutionContext::new(FileId::new(1).unwrap());
+        let id = SymbolId::new(7).unwrap();
+        context.add_symbol("Point".to_string(), id, ScopeLevel::Module);
+
+        assert_eq!(context.resolve("geometry.Point"), Some(id));
+    }
+
+    #[test]
+    fn test_import_binding_uses_alias_or_file_stem() {
+        let mut context = ZigResolutionContext::new(FileId::new(1).unwrap());
+        let file_id = FileId::new(1).unwrap();
+        context.populate_imports(&[
+            Import {
+                path: "math/geometry.zig".to_string(),
+                alias: None,
+                file_id,
+                is_glob: false,
+                is_type_only: false,
+            },
+            Import {
+                path: "std.mem".to_string(),
+                alias: Some("memory".to_string()),
+                file_id,
+                is_glob: false,
+                is_type_only: false,
+            },
+        ]);
+
+        assert!(context.import_binding("geometry").is_some());
+        assert!(context.import_binding("memory").is_some());
+        assert!(context.import_binding("mem").is_none());
+    }
+}
@@ -542,6 +542,9 @@ mod tests {
             parser_options: HashMap::new(),
             config_files,
             projects: Vec::new(),
+            limits: Default::default(),
+            scope: Default::default(),
+            kinds: Vec::new(),
         };
         settings.languages.insert(language_id.to_string(), config);
         settings
@@ -237,6 +237,9 @@ mod tests {
             extensions: vec![".ts".to_string(), ".tsx".to_string()],
             parser_options: Default::default(),
             projects: Vec::new(),
+            limits: Default::default(),
+            scope: Default::default(),
+            kinds: Vec::new(),
         };
         settings
             .languages
@@ -224,6 +224,9 @@ mod tests {
             parser_options: HashMap::new(),
             config_files,
             projects: Vec::new(),
+            limits: Default::default(),
+            scope: Default::default(),
+            kinds: Vec::new(),
         };
         settings
             .languages
@@ -260,6 +263,9 @@ mod tests {
             parser_options: HashMap::new(),
             config_files: vec![],
             projects: Vec::new(),
+            limits: Default::default(),
+            scope: Default::default(),
+            kinds: Vec::new(),
         };
         settings
             .languages
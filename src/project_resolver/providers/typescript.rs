@@ -220,6 +220,9 @@ mod tests {
             parser_options: HashMap::new(),
             config_files,
             projects: Vec::new(),
+            limits: Default::default(),
+            scope: Default::default(),
+            kinds: Vec::new(),
         };
         settings
             .languages
@@ -256,6 +259,9 @@ mod tests {
             parser_options: HashMap::new(),
             config_files: vec![],
             projects: Vec::new(),
+            limits: Default::default(),
+            scope: Default::default(),
+            kinds: Vec::new(),
         };
         settings
             .languages
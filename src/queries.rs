@@ -0,0 +1,241 @@
+//! Query history and saved queries
+//!
+//! Searches run through `codanna mcp`, `codanna retrieve search` and the MCP
+//! server are remembered in `.codanna/queries.json`, most recent last, for
+//! `codanna retrieve history`. A query can also be saved under a name with
+//! `codanna query save`, and run again by that name from the CLI or with the
+//! `run_saved_query` MCP tool. Either way a query is an MCP tool call: the
+//! tool and its arguments, as `codanna mcp --args` takes them.
+
+use crate::{IndexError, IndexResult, Settings};
+use serde::{Deserialize, Serialize};
+use serde_json::{Map, Value};
+use std::collections::BTreeMap;
+use std::fmt;
+use std::path::{Path, PathBuf};
+
+/// File holding the history and the saved queries, next to the index
+const QUERIES_FILE: &str = "queries.json";
+
+/// Most history entries kept; older ones are dropped
+pub const HISTORY_LIMIT: usize = 100;
+
+/// Tools whose calls go into the history
+pub const RECORDED_TOOLS: &[&str] = &[
+    "search_symbols",
+    "query_symbols",
+    "semantic_search_docs",
+    "semantic_search_with_context",
+    "find_similar_symbols",
+    "search_documents",
+];
+
+/// An MCP tool and its arguments
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct QueryCall {
+    pub tool: String,
+    #[serde(default)]
+    pub arguments: Map<String, Value>,
+}
+
+impl QueryCall {
+    /// A call of `tool`; null arguments are left out
+    pub fn new(tool: &str, mut arguments: Map<String, Value>) -> Self {
+        arguments.retain(|_, value| !value.is_null());
+        Self {
+            tool: tool.to_string(),
+            arguments,
+        }
+    }
+
+    /// A call of `tool` with the arguments of a request
+    pub fn from_request(tool: &str, request: &impl Serialize) -> Self {
+        match serde_json::to_value(request) {
+            Ok(Value::Object(arguments)) => Self::new(tool, arguments),
+            _ => Self::new(tool, Map::new()),
+        }
+    }
+}
+
+/// As `codanna mcp` takes it: `semantic_search_docs limit:5 query:"hot paths"`
+impl fmt::Display for QueryCall {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "{}", self.tool)?;
+        for (key, value) in &self.arguments {
+            match value {
+                Value::String(s) if s.is_empty() || s.contains(char::is_whitespace) => {
+                    write!(f, " {key}:{value}")?
+                }
+                Value::String(s) => write!(f, " {key}:{s}")?,
+                other => write!(f, " {key}:{other}")?,
+            }
+        }
+        Ok(())
+    }
+}
+
+/// A query kept under a name
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct SavedQuery {
+    #[serde(flatten)]
+    pub call: QueryCall,
+    /// Seconds since the Unix epoch
+    pub saved_at: u64,
+}
+
+/// A query as it was run
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct HistoryEntry {
+    #[serde(flatten)]
+    pub call: QueryCall,
+    /// Seconds since the Unix epoch
+    pub at: u64,
+}
+
+/// The contents of `queries.json`
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct QueryStore {
+    #[serde(default)]
+    pub saved: BTreeMap<String, SavedQuery>,
+    /// Oldest first
+    #[serde(default)]
+    pub history: Vec<HistoryEntry>,
+}
+
+/// Where the queries of the index at `settings.index_path` are kept
+pub fn queries_path(settings: &Settings) -> PathBuf {
+    settings
+        .index_path
+        .parent()
+        .unwrap_or(Path::new("."))
+        .join(QUERIES_FILE)
+}
+
+/// Whether `name` can name a saved query: letters, digits, `.`, `_` and `-`
+pub fn is_valid_query_name(name: &str) -> bool {
+    !name.is_empty()
+        && name
+            .chars()
+            .all(|c| c.is_ascii_alphanumeric() || matches!(c, '.' | '_' | '-'))
+}
+
+impl QueryStore {
+    /// The store at `path`; empty when there is none yet or it cannot be read
+    pub fn load(path: &Path) -> Self {
+        std::fs::read_to_string(path)
+            .ok()
+            .and_then(|json| serde_json::from_str(&json).ok())
+            .unwrap_or_default()
+    }
+
+    pub fn save(&self, path: &Path) -> IndexResult<()> {
+        let json = serde_json::to_string_pretty(self)
+            .map_err(|e| IndexError::General(format!("Failed to serialize queries: {e}")))?;
+        if let Some(dir) = path.parent() {
+            std::fs::create_dir_all(dir)?;
+        }
+        std::fs::write(path, json)?;
+        Ok(())
+    }
+
+    /// Add a run of `call` to the history. Running the query that was run
+    /// last only moves its time.
+    pub fn push_history(&mut self, call: QueryCall, at: u64) {
+        if let Some(last) = self.history.last_mut() {
+            if last.call == call {
+                last.at = at;
+                return;
+            }
+        }
+        self.history.push(HistoryEntry { call, at });
+        if self.history.len() > HISTORY_LIMIT {
+            let excess = self.history.len() - HISTORY_LIMIT;
+            self.history.drain(..excess);
+        }
+    }
+
+    /// The most recent `limit` entries of the history, newest first
+    pub fn recent(&self, limit: usize) -> impl Iterator<Item = &HistoryEntry> {
+        self.history.iter().rev().take(limit)
+    }
+}
+
+/// Remember a run of `call` in the history of the index `settings` name.
+/// Failing to write it never fails the query.
+pub fn record_query(settings: &Settings, call: QueryCall) {
+    if !RECORDED_TOOLS.contains(&call.tool.as_str()) || !settings.index_path.exists() {
+        return;
+    }
+    let path = queries_path(settings);
+    let mut store = QueryStore::load(&path);
+    store.push_history(call, crate::indexing::get_utc_timestamp());
+    if let Err(e) = store.save(&path) {
+        tracing::debug!("failed to record query history: {e}");
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn call(tool: &str, arguments: Value) -> QueryCall {
+        match arguments {
+            Value::Object(map) => QueryCall::new(tool, map),
+            _ => unreachable!(),
+        }
+    }
+
+    #[test]
+    fn test_call_display() {
+        let query = call(
+            "semantic_search_docs",
+            json!({"query": "hot paths", "limit": 5, "lang": "rust", "kind": null}),
+        );
+        assert_eq!(
+            query.to_string(),
+            "semantic_search_docs lang:rust limit:5 query:\"hot paths\""
+        );
+    }
+
+    #[test]
+    fn test_history_is_capped_and_collapses_reruns() {
+        let mut store = QueryStore::default();
+        let first = call("search_symbols", json!({"query": "parse"}));
+        store.push_history(first.clone(), 1);
+        store.push_history(first.clone(), 2);
+        assert_eq!(store.history.len(), 1);
+        assert_eq!(store.history[0].at, 2);
+
+        for i in 0..HISTORY_LIMIT as u64 {
+            store.push_history(call("search_symbols", json!({"query": i})), 10 + i);
+        }
+        assert_eq!(store.history.len(), HISTORY_LIMIT);
+        assert_ne!(store.history[0].call, first);
+        let newest: Vec<u64> = store.recent(2).map(|entry| entry.at).collect();
+        assert_eq!(newest, [9 + HISTORY_LIMIT as u64, 8 + HISTORY_LIMIT as u64]);
+    }
+
+    #[test]
+    fn test_store_round_trip() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = dir.path().join(QUERIES_FILE);
+        assert_eq!(QueryStore::load(&path), QueryStore::default());
+
+        let mut store = QueryStore::default();
+        store.saved.insert(
+            "hotpaths".to_string(),
+            SavedQuery {
+                call: call("semantic_search_docs", json!({"query": "hot loop"})),
+                saved_at: 7,
+            },
+        );
+        store.push_history(call("search_symbols", json!({"query": "parse"})), 8);
+        store.save(&path).unwrap();
+        assert_eq!(QueryStore::load(&path), store);
+
+        assert!(is_valid_query_name("hot-paths_v1.2"));
+        assert!(!is_valid_query_name(""));
+        assert!(!is_valid_query_name("../x"));
+    }
+}
@@ -1,4 +1,4 @@
-use crate::types::SymbolId;
+use crate::types::{FileId, SymbolId};
 use serde::{Deserialize, Serialize};
 
 #[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
@@ -112,10 +112,29 @@ impl RelationKind {
 }
 
 impl RelationshipMetadata {
+    /// Context of a call edge to one of the targets a call through a
+    /// generic bound (`T: Shape`, `impl Shape`, `T extends Shape`) may
+    /// dispatch to: the bound's own method or a subtype's implementation
+    pub const POSSIBLE_TARGET: &'static str = "possible target";
+
+    /// Context of the `Implements` edge from a C or C++ definition to its
+    /// declaration in a header
+    pub const DECLARATION: &'static str = "declaration";
+
     pub fn new() -> Self {
         Self::default()
     }
 
+    /// Whether the edge is one of several possible targets of the call
+    pub fn is_possible_target(&self) -> bool {
+        self.context.as_deref() == Some(Self::POSSIBLE_TARGET)
+    }
+
+    /// Whether the edge links a definition to its declaration
+    pub fn is_declaration_link(&self) -> bool {
+        self.context.as_deref() == Some(Self::DECLARATION)
+    }
+
     pub fn at_position(mut self, line: u32, column: u16) -> Self {
         self.line = Some(line);
         self.column = Some(column);
@@ -154,6 +173,20 @@ impl RelationshipEdge {
     }
 }
 
+/// A relationship to a name no symbol of the index has, kept by shard
+/// indexes so another shard can supply the target
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct UnresolvedReference {
+    pub from_id: SymbolId,
+    pub to_name: String,
+    pub kind: RelationKind,
+    pub file_id: FileId,
+    pub file_path: String,
+    /// Zero-based, like symbol ranges
+    pub line: u32,
+    pub column: u16,
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
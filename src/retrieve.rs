@@ -9,6 +9,7 @@ use crate::io::{
     envelope::{EntityType as EnvelopeEntityType, Envelope, ResultCode},
 };
 use crate::symbol::context::SymbolContext;
+use crate::symbol::name_match::SearchMode;
 use serde::Serialize;
 use std::fmt::Display;
 
@@ -362,11 +363,13 @@ pub fn retrieve_symbol(
 
 /// Execute retrieve callers command
 ///
-/// Uses QueryContext for symbol resolution with ambiguous handling.
+/// Uses QueryContext for symbol resolution with ambiguous handling. A
+/// `depth` above 1 lists every function that eventually calls `function`.
 pub fn retrieve_callers(
     indexer: &IndexFacade,
     function: &str,
     language: Option<&str>,
+    depth: usize,
     format: OutputFormat,
     fields: Option<Vec<String>>,
 ) -> ExitCode {
@@ -387,6 +390,21 @@ pub fn retrieve_callers(
         other => return ctx.handle_resolve_error(other, function),
     };
 
+    if depth > 1 {
+        let reached = indexer.get_transitive_callers(symbol.id, depth);
+        if reached.is_empty() {
+            return ctx.output_empty(function, &format!("No functions call '{function}'"));
+        }
+        return output_call_graph(
+            &ctx,
+            reached,
+            ContextIncludes::CALLS | ContextIncludes::DEFINITIONS,
+            function,
+            language,
+            "caller",
+        );
+    }
+
     // Get callers for this specific symbol
     let callers = indexer.get_calling_functions_with_metadata(symbol.id);
 
@@ -439,11 +457,13 @@ pub fn retrieve_callers(
 
 /// Execute retrieve calls command
 ///
-/// Uses QueryContext for symbol resolution with ambiguous handling.
+/// Uses QueryContext for symbol resolution with ambiguous handling. A
+/// `depth` above 1 lists every function `function` eventually calls.
 pub fn retrieve_calls(
     indexer: &IndexFacade,
     function: &str,
     language: Option<&str>,
+    depth: usize,
     format: OutputFormat,
     fields: Option<Vec<String>>,
 ) -> ExitCode {
@@ -464,6 +484,21 @@ pub fn retrieve_calls(
         other => return ctx.handle_resolve_error(other, function),
     };
 
+    if depth > 1 {
+        let reached = indexer.get_transitive_calls(symbol.id, depth);
+        if reached.is_empty() {
+            return ctx.output_empty(function, &format!("'{function}' makes no function calls"));
+        }
+        return output_call_graph(
+            &ctx,
+            reached,
+            ContextIncludes::CALLERS | ContextIncludes::DEFINITIONS,
+            function,
+            language,
+            "call",
+        );
+    }
+
     // Get calls for this specific symbol
     let calls = indexer.get_called_functions_with_metadata(symbol.id);
 
@@ -514,6 +549,74 @@ pub fn retrieve_calls(
     }
 }
 
+/// A function reached by a transitive calls/callers query
+#[derive(Serialize)]
+pub struct CallGraphItem {
+    #[serde(flatten)]
+    pub context: SymbolContext,
+    /// Call edges between this function and the queried one
+    pub depth: usize,
+    /// The function on the other side of the edge that reached this one
+    pub via: crate::SymbolId,
+}
+
+impl Display for CallGraphItem {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(f, "[depth {}] {}", self.depth, self.context)
+    }
+}
+
+/// Output the functions reached by a transitive calls/callers query,
+/// nearest first.
+fn output_call_graph(
+    ctx: &QueryContext,
+    reached: Vec<crate::indexing::CallGraphEntry>,
+    includes: crate::symbol::context::ContextIncludes,
+    function: &str,
+    language: Option<&str>,
+    noun: &str,
+) -> ExitCode {
+    let items: Vec<CallGraphItem> = reached
+        .into_iter()
+        .filter_map(|entry| {
+            let context = ctx.indexer.get_symbol_context(entry.symbol.id, includes)?;
+            Some(CallGraphItem {
+                context,
+                depth: entry.depth,
+                via: entry.via,
+            })
+        })
+        .collect();
+
+    let count = items.len();
+
+    if ctx.format == OutputFormat::Json {
+        let mut envelope = Envelope::success(items)
+            .with_entity_type(ctx.entity_type)
+            .with_count(count)
+            .with_query(function)
+            .with_message(format!("Found {count} transitive {noun}(s)"))
+            .with_hint("Each result's via names the function it was reached through");
+
+        if let Some(lang) = language {
+            envelope = envelope.with_lang(lang);
+        }
+
+        let json = if let Some(ref f) = ctx.fields {
+            envelope.to_json_with_fields(f)
+        } else {
+            envelope.to_json()
+        };
+
+        println!("{}", json.expect("envelope serialization"));
+    } else {
+        for item in &items {
+            println!("{item}");
+        }
+    }
+    ExitCode::Success
+}
+
 /// Execute retrieve implementations command
 ///
 /// Uses QueryContext for symbol resolution with ambiguous handling.
@@ -594,9 +697,535 @@ pub fn retrieve_implementations(
     }
 }
 
+/// Execute retrieve hierarchy command
+///
+/// Uses QueryContext for symbol resolution with ambiguous handling. A type
+/// without supertypes or subtypes is a success with empty trees.
+pub fn retrieve_hierarchy(
+    indexer: &IndexFacade,
+    type_name: &str,
+    language: Option<&str>,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    // Use QueryContext for symbol resolution
+    let ctx = QueryContext::new(
+        indexer,
+        format,
+        fields.clone(),
+        EnvelopeEntityType::Hierarchy,
+        "hierarchy",
+    );
+
+    // Resolve type symbol (handles not-found, ambiguous, invalid id)
+    let type_symbol = match ctx.resolve_symbol(type_name, language) {
+        ResolveResult::Found(s) => s,
+        other => return ctx.handle_resolve_error(other, type_name),
+    };
+
+    let Some(hierarchy) = indexer.get_type_hierarchy(type_symbol.id) else {
+        return ctx.output_not_found(type_name);
+    };
+
+    if format == OutputFormat::Json {
+        let count = hierarchy.len();
+        let mut envelope = Envelope::success(hierarchy)
+            .with_entity_type(EnvelopeEntityType::Hierarchy)
+            .with_count(count)
+            .with_query(type_name)
+            .with_message(format!("Found {count} supertype(s) and subtype(s)"))
+            .with_hint("Use symbol_id for precise lookup");
+
+        if let Some(lang) = language {
+            envelope = envelope.with_lang(lang);
+        }
+
+        let json = if let Some(ref f) = fields {
+            envelope.to_json_with_fields(f)
+        } else {
+            envelope.to_json()
+        };
+
+        println!("{}", json.expect("envelope serialization"));
+    } else {
+        // Text output
+        print!("{}", crate::mcp::service::render_hierarchy(&hierarchy));
+    }
+    ExitCode::Success
+}
+
+/// Execute retrieve impact command
+///
+/// Uses QueryContext for symbol resolution with ambiguous handling. A symbol
+/// nothing depends on is a success with no levels.
+pub fn retrieve_impact(
+    indexer: &IndexFacade,
+    symbol_name: &str,
+    language: Option<&str>,
+    depth: usize,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    // Use QueryContext for symbol resolution
+    let ctx = QueryContext::new(
+        indexer,
+        format,
+        fields.clone(),
+        EnvelopeEntityType::ImpactGraph,
+        "impact",
+    );
+
+    // Resolve symbol (handles not-found, ambiguous, invalid id)
+    let symbol = match ctx.resolve_symbol(symbol_name, language) {
+        ResolveResult::Found(s) => s,
+        other => return ctx.handle_resolve_error(other, symbol_name),
+    };
+
+    let Some(impact) = indexer.get_change_impact(symbol.id, depth) else {
+        return ctx.output_not_found(symbol_name);
+    };
+
+    if format == OutputFormat::Json {
+        let count = impact.len();
+        let files = impact.files().count();
+        let mut envelope = Envelope::success(impact)
+            .with_entity_type(EnvelopeEntityType::ImpactGraph)
+            .with_count(count)
+            .with_query(symbol_name)
+            .with_depth(depth as u32)
+            .with_message(format!(
+                "Found {count} affected symbol(s) in {files} other file(s)"
+            ))
+            .with_hint("Use symbol_id for precise lookup");
+
+        if let Some(lang) = language {
+            envelope = envelope.with_lang(lang);
+        }
+
+        let json = if let Some(ref f) = fields {
+            envelope.to_json_with_fields(f)
+        } else {
+            envelope.to_json()
+        };
+
+        println!("{}", json.expect("envelope serialization"));
+    } else {
+        // Text output
+        print!("{}", crate::mcp::service::render_change_impact(&impact));
+    }
+    ExitCode::Success
+}
+
+/// Execute retrieve tests-for command
+///
+/// Uses QueryContext for symbol resolution with ambiguous handling. Tests
+/// reaching `function` through helpers are listed with their call distance.
+pub fn retrieve_tests_for(
+    indexer: &IndexFacade,
+    function: &str,
+    language: Option<&str>,
+    depth: usize,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    // Use QueryContext for symbol resolution
+    let ctx = QueryContext::new(
+        indexer,
+        format,
+        fields.clone(),
+        EnvelopeEntityType::Test,
+        "tests",
+    );
+
+    // Resolve symbol (handles not-found, ambiguous, invalid id)
+    let symbol = match ctx.resolve_symbol(function, language) {
+        ResolveResult::Found(s) => s,
+        other => return ctx.handle_resolve_error(other, function),
+    };
+
+    let tests = crate::analysis::tests_for(indexer, symbol.id, depth);
+    if tests.is_empty() {
+        return ctx.output_empty(function, &format!("No tests call '{function}'"));
+    }
+
+    if format == OutputFormat::Json {
+        let count = tests.len();
+        let mut envelope = Envelope::success(tests)
+            .with_entity_type(EnvelopeEntityType::Test)
+            .with_count(count)
+            .with_query(function)
+            .with_depth(depth as u32)
+            .with_message(format!("Found {count} test(s)"))
+            .with_hint("Use symbol_id for precise lookup");
+
+        if let Some(lang) = language {
+            envelope = envelope.with_lang(lang);
+        }
+
+        let json = if let Some(ref f) = fields {
+            envelope.to_json_with_fields(f)
+        } else {
+            envelope.to_json()
+        };
+
+        println!("{}", json.expect("envelope serialization"));
+    } else {
+        // Text output
+        print!("{}", crate::analysis::render_tests(&tests));
+    }
+    ExitCode::Success
+}
+
+/// A read or write of a field or module-level value
+#[derive(Serialize)]
+pub struct ReferenceItem {
+    /// The function or method touching the value
+    pub symbol: Symbol,
+    /// `read` or `write`
+    pub access: Option<Box<str>>,
+    /// Line of the reference site (1-based)
+    pub line: Option<u32>,
+}
+
+impl Display for ReferenceItem {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        let line = self.line.unwrap_or(self.symbol.range.start_line + 1);
+        write!(
+            f,
+            "{} in {} at {}:{line} [symbol_id:{}]",
+            self.access.as_deref().unwrap_or("reference"),
+            self.symbol.name,
+            self.symbol.file_path,
+            self.symbol.id.value()
+        )
+    }
+}
+
+/// Execute retrieve references command
+///
+/// Uses QueryContext for symbol resolution with ambiguous handling; fields
+/// are named by their type (`Config::timeout` or `Config.timeout`). Lists
+/// every site reading or writing the value, by file and line.
+pub fn retrieve_references(
+    indexer: &IndexFacade,
+    symbol_name: &str,
+    language: Option<&str>,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    // Use QueryContext for symbol resolution
+    let ctx = QueryContext::new(
+        indexer,
+        format,
+        fields,
+        EnvelopeEntityType::Reference,
+        "references",
+    );
+
+    // Resolve symbol (handles not-found, ambiguous, invalid id)
+    let symbol = match ctx.resolve_symbol(symbol_name, language) {
+        ResolveResult::Found(s) => s,
+        other => return ctx.handle_resolve_error(other, symbol_name),
+    };
+
+    let mut references: Vec<ReferenceItem> = indexer
+        .get_referencing_symbols_with_metadata(symbol.id)
+        .into_iter()
+        .map(|(symbol, metadata)| ReferenceItem {
+            access: metadata.as_ref().and_then(|meta| meta.context.clone()),
+            line: metadata.and_then(|meta| meta.line).map(|line| line + 1),
+            symbol,
+        })
+        .collect();
+    if references.is_empty() {
+        return ctx.output_empty(symbol_name, &format!("No references to '{symbol_name}'"));
+    }
+    references.sort_by(|a, b| (&a.symbol.file_path, a.line).cmp(&(&b.symbol.file_path, b.line)));
+
+    ctx.output_success(
+        references,
+        symbol_name,
+        Some("Use symbol_id for precise lookup"),
+    )
+}
+
+/// Execute retrieve api command
+///
+/// Lists the symbols at or above a visibility. Text output groups them by
+/// module, one symbol a row without line numbers, to diff between releases.
+pub fn retrieve_api(
+    indexer: &IndexFacade,
+    filter: &crate::export::GraphFilter,
+    visibility: crate::Visibility,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    use crate::analysis::{api_surface, render_api};
+
+    let ctx = QueryContext::new(indexer, format, fields, EnvelopeEntityType::Api, "api");
+    let items = api_surface(&indexer.get_all_symbols(), visibility, filter);
+    if items.is_empty() {
+        return ctx.output_empty("api", "No symbols in the API surface");
+    }
+    if format == OutputFormat::Json {
+        return ctx.output_success(items, "api", Some("Diff two listings to compare releases"));
+    }
+    print!("{}", render_api(&items));
+    ExitCode::Success
+}
+
+/// Execute retrieve todos command
+///
+/// Lists the tagged comments the filter accepts, each with the symbol it is
+/// about when it has one.
+pub fn retrieve_todos(
+    indexer: &IndexFacade,
+    filter: &crate::analysis::TodoFilter,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    use crate::analysis::{list_todos, render_todos};
+
+    let ctx = QueryContext::new(indexer, format, fields, EnvelopeEntityType::Todo, "todos");
+    let items = list_todos(indexer.get_todos(), filter, |id| {
+        indexer.get_symbol(id).map(|symbol| symbol.name.to_string())
+    });
+    if items.is_empty() {
+        return ctx.output_empty("todos", "No todos found");
+    }
+    if format == OutputFormat::Json {
+        return ctx.output_success(items, "todos", Some("Use symbol_id for precise lookup"));
+    }
+    print!("{}", render_todos(&items));
+    ExitCode::Success
+}
+
+/// Execute retrieve strings command
+///
+/// Lists the indexed string literals the filter accepts, with the symbol
+/// holding each; none unless `indexing.string_literals` was on.
+pub fn retrieve_strings(
+    indexer: &IndexFacade,
+    filter: &crate::analysis::StringFilter,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    use crate::analysis::{list_strings, render_strings};
+
+    let ctx = QueryContext::new(
+        indexer,
+        format,
+        fields,
+        EnvelopeEntityType::StringLiteral,
+        "strings",
+    );
+    let items = list_strings(indexer.get_strings(), filter, |id| {
+        indexer.get_symbol(id).map(|symbol| symbol.name.to_string())
+    });
+    if items.is_empty() {
+        let message = if indexer.settings().indexing.string_literals {
+            "No strings found"
+        } else {
+            "No strings found (enable indexing.string_literals and reindex)"
+        };
+        return ctx.output_empty("strings", message);
+    }
+    if format == OutputFormat::Json {
+        return ctx.output_success(items, "strings", Some("Use symbol_id for precise lookup"));
+    }
+    print!("{}", render_strings(&items));
+    ExitCode::Success
+}
+
+/// Execute retrieve owned-by command
+///
+/// Lists the symbols of the files the workspace's CODEOWNERS gives `owner`,
+/// a team (`@org/team`), user or email.
+pub fn retrieve_owned_by(
+    indexer: &IndexFacade,
+    owner: &str,
+    filter: &crate::export::GraphFilter,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    use crate::analysis::{owned_by, render_owned};
+
+    let ctx = QueryContext::new(
+        indexer,
+        format,
+        fields,
+        EnvelopeEntityType::Symbol,
+        "owned-by",
+    );
+    let items = owned_by(&indexer.get_all_symbols(), owner, filter, |path| {
+        indexer.owners_of(path)
+    });
+    if items.is_empty() {
+        // Also where the workspace has no CODEOWNERS
+        return ctx.output_empty(owner, &format!("No symbols owned by {owner}"));
+    }
+    if format == OutputFormat::Json {
+        return ctx.output_success(items, owner, Some("Use symbol_id for precise lookup"));
+    }
+    print!("{}", render_owned(&items));
+    ExitCode::Success
+}
+
+/// Execute retrieve signature command
+///
+/// Lists the functions and methods whose parameter and return types fit a
+/// signature like `fn(&Path) -> Result<Vec<Symbol>>`, best first.
+pub fn retrieve_signature(
+    indexer: &IndexFacade,
+    query: &str,
+    filter: &crate::export::GraphFilter,
+    limit: usize,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    use crate::SymbolKind;
+    use crate::analysis::{
+        find_by_signature, parse_signature, render_signature_matches, supertype_names,
+    };
+
+    let signature = match parse_signature(query) {
+        Ok(signature) => signature,
+        Err(e) => {
+            if format == OutputFormat::Json {
+                let envelope: Envelope<()> =
+                    Envelope::error(ResultCode::InvalidQuery, format!("Invalid signature: {e}"))
+                        .with_hint("Write a signature like fn(&Path) -> Result<Vec<Symbol>>");
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else {
+                eprintln!("Invalid signature: {e}");
+            }
+            return ExitCode::GeneralError;
+        }
+    };
+
+    let ctx = QueryContext::new(
+        indexer,
+        format,
+        fields,
+        EnvelopeEntityType::SearchResult,
+        "signature",
+    );
+    let symbols = indexer.get_all_symbols();
+    // Which types implement or extend which, for the subtype tolerance
+    let mut edges = Vec::new();
+    for symbol in &symbols {
+        if matches!(
+            symbol.kind,
+            SymbolKind::Struct
+                | SymbolKind::Class
+                | SymbolKind::Enum
+                | SymbolKind::Interface
+                | SymbolKind::Trait
+        ) {
+            let parents = indexer
+                .get_implemented_traits(symbol.id)
+                .into_iter()
+                .chain(indexer.get_extends(symbol.id));
+            for parent in parents {
+                edges.push((symbol.name.to_string(), parent.name.to_string()));
+            }
+        }
+    }
+    let supertypes = supertype_names(edges.iter().map(|(sub, sup)| (sub.as_str(), sup.as_str())));
+
+    let mut matches = find_by_signature(&symbols, &signature, filter, &supertypes);
+    matches.truncate(limit);
+    if matches.is_empty() {
+        return ctx.output_empty(query, "No symbols fit the signature");
+    }
+    if format == OutputFormat::Json {
+        return ctx.output_success(matches, query, Some("Use symbol_id for precise lookup"));
+    }
+    print!("{}", render_signature_matches(&matches));
+    ExitCode::Success
+}
+
+/// A symbol close in meaning to the one `retrieve similar` compares with
+#[derive(Serialize)]
+pub struct SimilarSymbol {
+    pub symbol: Symbol,
+    /// Cosine similarity of the embeddings
+    pub score: f32,
+}
+
+impl Display for SimilarSymbol {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(
+            f,
+            "{:.2}  {:?} {} at {}:{} [symbol_id:{}]",
+            self.score,
+            self.symbol.kind,
+            self.symbol.name,
+            self.symbol.file_path,
+            self.symbol.range.start_line + 1,
+            self.symbol.id.value()
+        )
+    }
+}
+
+/// Execute retrieve similar command
+///
+/// Uses QueryContext for symbol resolution with ambiguous handling. Lists
+/// the symbols whose embeddings are nearest to that of the symbol, best
+/// first, leaving it out.
+pub fn retrieve_similar(
+    indexer: &IndexFacade,
+    symbol_name: &str,
+    filter: &crate::semantic::SemanticFilter,
+    limit: usize,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    let ctx = QueryContext::new(
+        indexer,
+        format,
+        fields,
+        EnvelopeEntityType::Symbol,
+        "similar",
+    );
+    let symbol = match ctx.resolve_symbol(symbol_name, None) {
+        ResolveResult::Found(symbol) => symbol,
+        other => return ctx.handle_resolve_error(other, symbol_name),
+    };
+
+    let similar = match indexer.similar_symbols(symbol.id, limit, filter) {
+        Ok(similar) => similar,
+        Err(e) => {
+            if format == OutputFormat::Json {
+                let envelope: Envelope<()> = Envelope::error(ResultCode::IndexError, e.to_string())
+                    .with_entity_type(EnvelopeEntityType::Symbol)
+                    .with_query(symbol_name)
+                    .with_hint("Enable semantic search in settings.toml and rebuild the index");
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else {
+                eprintln!("Error: {e}");
+            }
+            return ExitCode::GeneralError;
+        }
+    };
+    if similar.is_empty() {
+        return ctx.output_empty(
+            symbol_name,
+            &format!("No symbols similar to {}", symbol.name),
+        );
+    }
+    let items = similar
+        .into_iter()
+        .map(|(symbol, score)| SimilarSymbol { symbol, score })
+        .collect();
+    ctx.output_success(items, symbol_name, Some("Use symbol_id for precise lookup"))
+}
+
 /// Execute retrieve search command
 ///
 /// Full-text search with optional filters. Uses Envelope for JSON output.
+/// With `context_lines`, each result carries the source of its symbol with
+/// that many lines around it.
 pub fn retrieve_search(
     indexer: &IndexFacade,
     query: &str,
@@ -604,10 +1233,13 @@ pub fn retrieve_search(
     kind: Option<&str>,
     module: Option<&str>,
     language: Option<&str>,
+    mode: SearchMode,
+    context_lines: Option<usize>,
     format: OutputFormat,
     fields: Option<Vec<String>>,
 ) -> ExitCode {
     use crate::symbol::context::ContextIncludes;
+    use crate::symbol::snippet::SourceSnippet;
 
     // One kind vocabulary (SymbolKind::from_str) shared with the MCP and
     // CLI JSON surfaces; retrieve keeps its warn-and-ignore policy.
@@ -619,16 +1251,41 @@ pub fn retrieve_search(
         }
     });
 
-    let search_results = indexer
-        .search(query, limit, kind_filter, module, language)
-        .unwrap_or_default();
+    let search_results =
+        match indexer.search_with_mode(query, mode, limit, kind_filter, module, language) {
+            Ok(results) => results,
+            // A pattern that does not compile is the caller's to fix
+            Err(e) if mode == SearchMode::Regex => {
+                if format == OutputFormat::Json {
+                    let envelope: Envelope<()> =
+                        Envelope::error(ResultCode::InvalidQuery, e.to_string())
+                            .with_entity_type(EnvelopeEntityType::SearchResult)
+                            .with_query(query)
+                            .with_hint(
+                                "Regex mode takes a Rust regular expression, like ^get_.*_id$",
+                            );
+                    println!("{}", envelope.to_json().expect("envelope serialization"));
+                } else {
+                    eprintln!("Error: {e}");
+                }
+                return ExitCode::GeneralError;
+            }
+            Err(_) => Vec::new(),
+        };
 
     // Transform search results to SymbolContext with relationships
+    let workspace_root = indexer.settings().workspace_root.as_deref();
     let results_with_context: Vec<SymbolContext> = search_results
         .into_iter()
         .filter_map(|result| {
             indexer.get_symbol_context(result.symbol_id, ContextIncludes::SYMBOL_CARD)
         })
+        .map(|mut ctx| {
+            if let Some(lines) = context_lines {
+                ctx.source = SourceSnippet::of_symbol(&ctx.symbol, workspace_root, lines);
+            }
+            ctx
+        })
         .collect();
 
     let count = results_with_context.len();
@@ -681,6 +1338,64 @@ pub fn retrieve_search(
     }
 }
 
+/// Execute retrieve query command
+///
+/// Compiles the query language of `crate::symbol::dsl` onto the index, so a
+/// compound filter is one lookup.
+pub fn retrieve_query(
+    indexer: &IndexFacade,
+    query: &str,
+    limit: usize,
+    format: OutputFormat,
+    fields: Option<Vec<String>>,
+) -> ExitCode {
+    use crate::symbol::context::ContextIncludes;
+    use crate::symbol::dsl::{QueryField, SymbolQuery};
+
+    let framework_kinds = indexer.settings().framework_kinds();
+    let parsed = match SymbolQuery::parse_with_kinds(query, &framework_kinds) {
+        Ok(parsed) => parsed,
+        Err(e) => {
+            if format == OutputFormat::Json {
+                let envelope: Envelope<()> =
+                    Envelope::error(ResultCode::InvalidQuery, format!("Invalid query: {e}"))
+                        .with_entity_type(EnvelopeEntityType::SearchResult)
+                        .with_query(query)
+                        .with_hint(format!("Filters: {}", QueryField::KEYS));
+                println!("{}", envelope.to_json().expect("envelope serialization"));
+            } else {
+                eprintln!("Invalid query: {e}");
+            }
+            return ExitCode::GeneralError;
+        }
+    };
+
+    let ctx = QueryContext::new(
+        indexer,
+        format,
+        fields,
+        EnvelopeEntityType::SearchResult,
+        "query",
+    );
+    let results = match indexer.query_symbols(&parsed, limit) {
+        Ok(results) => results,
+        Err(e) => {
+            eprintln!("Error: {e}");
+            return ExitCode::GeneralError;
+        }
+    };
+    let contexts: Vec<SymbolContext> = results
+        .into_iter()
+        .filter_map(|result| {
+            indexer.get_symbol_context(result.symbol_id, ContextIncludes::SYMBOL_CARD)
+        })
+        .collect();
+    if contexts.is_empty() {
+        return ctx.output_empty(query, "No symbols match the query");
+    }
+    ctx.output_success(contexts, query, Some("Use symbol_id for precise lookup"))
+}
+
 /// Execute retrieve describe command
 ///
 /// Uses QueryContext for symbol resolution with ambiguous handling.
@@ -714,6 +1429,8 @@ pub fn retrieve_describe(
         symbol: symbol.clone(),
         file_path,
         relationships: Default::default(),
+        owners: indexer.owners_of(&symbol.file_path),
+        source: None,
     };
 
     // Get calls for this specific symbol
@@ -749,6 +1466,22 @@ pub fn retrieve_describe(
             if !impls.is_empty() {
                 context.relationships.implements = Some(impls);
             }
+            // C#: the other parts of a partial type
+            let parts = indexer.get_partial_parts(symbol.id);
+            if !parts.is_empty() {
+                context.relationships.parts = Some(parts);
+            }
+        }
+        SymbolKind::Function | SymbolKind::Method => {
+            // C/C++: the header declaration and the definition of a function
+            let declarations = indexer.get_declarations(symbol.id);
+            if !declarations.is_empty() {
+                context.relationships.declarations = Some(declarations);
+            }
+            let definitions = indexer.get_definitions(symbol.id);
+            if !definitions.is_empty() {
+                context.relationships.definitions = Some(definitions);
+            }
         }
         _ => {}
     }
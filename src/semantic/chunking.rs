@@ -0,0 +1,264 @@
+//! Chunks of long symbol bodies
+//!
+//! An embedding model reads a window of a few hundred tokens and drops the
+//! rest, so the code embedding of a long function says nothing of its
+//! middle and end. With `semantic_search.code_chunks` the body of each
+//! function and method is split into chunks that fit the window, each
+//! embedded apart, and a search scores the symbol by its best chunk.
+//!
+//! A chunk ends before a line starting a statement of the body itself,
+//! where one lies past the middle of the chunk: a line at the nesting depth
+//! and indentation of the body's statements, counting the brackets of the
+//! line outside strings and the comments of the language. Each chunk
+//! repeats the last lines of the one before it, so a statement split
+//! where no boundary fit is whole in one of them.
+
+/// Characters per token the limits assume, about that of code for the
+/// tokenizers of the embedding models
+const CHARS_PER_TOKEN: usize = 4;
+
+/// Size of the chunks of a body
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct ChunkLimits {
+    /// Most characters of a chunk
+    pub max_chars: usize,
+    /// Characters a chunk repeats of the end of the one before it
+    pub overlap_chars: usize,
+}
+
+impl ChunkLimits {
+    /// Chunks of `tokens` repeating `overlap` tokens of the one before,
+    /// at most half a chunk
+    pub fn from_tokens(tokens: usize, overlap: usize) -> Self {
+        let max_chars = tokens.max(16) * CHARS_PER_TOKEN;
+        Self {
+            max_chars,
+            overlap_chars: (overlap * CHARS_PER_TOKEN).min(max_chars / 2),
+        }
+    }
+}
+
+/// The body `lines` of a symbol of `language` in chunks of at most
+/// `limits.max_chars`, or in one if they fit; a line longer than a chunk
+/// is a chunk of its own
+pub fn chunk_lines(lines: &[&str], language: &str, limits: ChunkLimits) -> Vec<String> {
+    // Offsets of the lines in the body, and of its end
+    let mut offsets = Vec::with_capacity(lines.len() + 1);
+    let mut offset = 0;
+    for line in lines {
+        offsets.push(offset);
+        offset += line.len() + 1;
+    }
+    offsets.push(offset);
+    if offset <= limits.max_chars {
+        return vec![lines.join("\n")];
+    }
+
+    let starts = statement_starts(lines, line_comment(language));
+    let mut chunks = Vec::new();
+    let mut start = 0;
+    while start < lines.len() {
+        let mut end = start + 1;
+        while end < lines.len() && offsets[end + 1] - offsets[start] <= limits.max_chars {
+            end += 1;
+        }
+        if end < lines.len() {
+            end = (start + 1..=end)
+                .rev()
+                .find(|&i| starts[i] && offsets[i] - offsets[start] >= limits.max_chars / 2)
+                .unwrap_or(end);
+        }
+        chunks.push(lines[start..end].join("\n"));
+        if end == lines.len() {
+            break;
+        }
+
+        let mut next = end;
+        while next > start + 1 && offsets[end] - offsets[next - 1] <= limits.overlap_chars {
+            next -= 1;
+        }
+        start = next;
+    }
+    chunks
+}
+
+/// The token starting a line comment in `language`
+fn line_comment(language: &str) -> &'static str {
+    match language {
+        "python" | "ruby" | "bash" | "elixir" | "gdscript" | "hcl" => "#",
+        "lua" | "sql" => "--",
+        "clojure" => ";",
+        _ => "//",
+    }
+}
+
+/// Whether each line starts a statement of the body, as the module
+/// describes; the first line, with the signature, does not
+fn statement_starts(lines: &[&str], comment: &str) -> Vec<bool> {
+    let indent = |line: &str| line.len() - line.trim_start().len();
+    let mut depths = Vec::with_capacity(lines.len());
+    let mut depth = 0i32;
+    for line in lines {
+        depths.push(depth);
+        depth += bracket_balance(line, comment);
+    }
+
+    let body = || {
+        lines
+            .iter()
+            .zip(&depths)
+            .skip(1)
+            .filter(|(line, _)| !line.trim().is_empty())
+    };
+    let Some(level) = body().map(|(_, depth)| *depth).min() else {
+        return vec![false; lines.len()];
+    };
+    let column = body()
+        .filter(|(_, depth)| **depth == level)
+        .map(|(line, _)| indent(line))
+        .min()
+        .unwrap_or(0);
+
+    lines
+        .iter()
+        .zip(&depths)
+        .enumerate()
+        .map(|(i, (line, depth))| {
+            let text = line.trim_start();
+            i > 0
+                && !text.is_empty()
+                && *depth == level
+                && indent(line) == column
+                && !continues_statement(text)
+        })
+        .collect()
+}
+
+/// Whether a line at the statement level goes on with the statement or
+/// block before it rather than starting one
+fn continues_statement(text: &str) -> bool {
+    const CONTINUATIONS: [&str; 4] = [".", "&&", "||", "?"];
+    const KEYWORDS: [&str; 7] = [
+        "else", "elif", "except", "finally", "catch", "rescue", "end",
+    ];
+    text.starts_with([')', ']', '}'])
+        || CONTINUATIONS.iter().any(|token| text.starts_with(token))
+        || KEYWORDS.iter().any(|keyword| {
+            text.strip_prefix(keyword)
+                .is_some_and(|rest| !rest.starts_with(|c: char| c.is_alphanumeric() || c == '_'))
+        })
+}
+
+/// Opening minus closing brackets of a line, outside strings and comments
+fn bracket_balance(line: &str, comment: &str) -> i32 {
+    let mut balance = 0;
+    let mut quote = None;
+    let mut escaped = false;
+    for (i, c) in line.char_indices() {
+        if let Some(open) = quote {
+            if escaped {
+                escaped = false;
+            } else if c == '\\' {
+                escaped = true;
+            } else if c == open {
+                quote = None;
+            }
+            continue;
+        }
+        match c {
+            '"' | '`' => quote = Some(c),
+            '(' | '[' | '{' => balance += 1,
+            ')' | ']' | '}' => balance -= 1,
+            _ if line[i..].starts_with(comment) => break,
+            _ => {}
+        }
+    }
+    balance
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn limits(max_chars: usize, overlap_chars: usize) -> ChunkLimits {
+        ChunkLimits {
+            max_chars,
+            overlap_chars,
+        }
+    }
+
+    #[test]
+    fn test_short_body_is_one_chunk() {
+        let lines = ["fn add(a: u32, b: u32) -> u32 {", "    a + b", "}"];
+        assert_eq!(
+            chunk_lines(&lines, "rust", limits(100, 10)),
+            vec![lines.join("\n")]
+        );
+        assert_eq!(
+            ChunkLimits::from_tokens(256, 1000),
+            limits(1024, 512),
+            "overlap is at most half a chunk"
+        );
+    }
+
+    #[test]
+    fn test_chunks_end_between_statements() {
+        let code = "\
+fn load(path: &Path) -> Result<Config> {
+    let text = std::fs::read_to_string(path)?;
+    let mut config: Config = toml::from_str(&text)?;
+    if config.workers == 0 {
+        config.workers = num_cpus::get();
+    }
+    config.validate()?;
+    let cache = path.with_extension(\"cache {\");
+    config.cache = Some(cache);
+    Ok(config)
+}";
+        let lines: Vec<&str> = code.lines().collect();
+        let chunks = chunk_lines(&lines, "rust", limits(240, 0));
+        assert!(chunks.len() > 1, "{chunks:?}");
+        for chunk in &chunks {
+            assert!(chunk.len() <= 240, "{chunk}");
+        }
+        // The if block stays whole, and the brace in a string does not
+        // nest the statements after it
+        assert!(chunks[0].ends_with("num_cpus::get();\n    }"), "{chunks:?}");
+        assert!(chunks[1].starts_with("    config.validate()"), "{chunks:?}");
+        assert_eq!(chunks.last().unwrap().lines().last(), Some("}"));
+        assert!(statement_starts(&lines, "//")[8]);
+    }
+
+    #[test]
+    fn test_chunks_overlap_and_cover_the_body() {
+        let lines: Vec<String> = std::iter::once("def handler(event):".to_string())
+            .chain((0..40).map(|i| format!("    step_{i:02}(event)  # ({{")))
+            .collect();
+        let lines: Vec<&str> = lines.iter().map(String::as_str).collect();
+        let chunks = chunk_lines(&lines, "python", limits(200, 60));
+        assert!(chunks.len() > 3);
+        for pair in chunks.windows(2) {
+            let last = pair[0].lines().last().unwrap();
+            assert!(pair[1].contains(last), "{pair:?}");
+        }
+        for line in &lines {
+            assert!(chunks.iter().any(|chunk| chunk.contains(line)));
+        }
+    }
+
+    #[test]
+    fn test_statement_starts_follow_indentation() {
+        let lines = [
+            "def parse(text):",
+            "    try:",
+            "        value = int(text)",
+            "    except ValueError:",
+            "        value = None",
+            "    return value",
+        ];
+        assert_eq!(
+            statement_starts(&lines, "#"),
+            [false, true, false, false, false, true]
+        );
+    }
+}
@@ -0,0 +1,291 @@
+//! Natural language of doc comments
+//!
+//! An English-only embedding model places a Japanese or German doc comment
+//! far from any English query, however well the two match in meaning.
+//! Indexing detects the language of the doc comments of each file, to warn
+//! when such a model cannot search them. The script tells Japanese,
+//! Chinese, Korean and Cyrillic text apart; frequent words and accented
+//! letters tell apart the languages written in Latin letters. Text between
+//! backticks is code and left out.
+
+use std::fmt;
+
+/// Language of a doc comment
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, PartialOrd, Ord)]
+pub enum DocLanguage {
+    English,
+    German,
+    French,
+    Spanish,
+    Portuguese,
+    Italian,
+    Dutch,
+    /// Any language written in Cyrillic letters
+    Cyrillic,
+    Japanese,
+    Chinese,
+    Korean,
+    /// Written in another script: Greek, Arabic, Hebrew, Devanagari...
+    Other,
+}
+
+impl DocLanguage {
+    pub fn name(self) -> &'static str {
+        match self {
+            Self::English => "English",
+            Self::German => "German",
+            Self::French => "French",
+            Self::Spanish => "Spanish",
+            Self::Portuguese => "Portuguese",
+            Self::Italian => "Italian",
+            Self::Dutch => "Dutch",
+            Self::Cyrillic => "Cyrillic",
+            Self::Japanese => "Japanese",
+            Self::Chinese => "Chinese",
+            Self::Korean => "Korean",
+            Self::Other => "other",
+        }
+    }
+}
+
+impl fmt::Display for DocLanguage {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.name())
+    }
+}
+
+/// Frequent words of the languages written in Latin letters, which code
+/// identifiers rarely are
+const WORDS: &[(DocLanguage, &[&str])] = &[
+    (
+        DocLanguage::English,
+        &[
+            "the", "and", "of", "to", "is", "are", "this", "that", "with", "for", "from",
+            "returns", "if", "it", "be", "an", "by", "or", "when", "which", "not",
+        ],
+    ),
+    (
+        DocLanguage::German,
+        &[
+            "der", "die", "das", "und", "ist", "nicht", "ein", "eine", "mit", "für", "von", "zu",
+            "den", "dem", "des", "wird", "werden", "gibt", "zurück", "wenn", "oder", "auf", "sich",
+            "auch", "bei", "nach", "einen", "einer", "es",
+        ],
+    ),
+    (
+        DocLanguage::French,
+        &[
+            "le", "la", "les", "et", "est", "une", "de", "des", "du", "pour", "dans", "que", "qui",
+            "avec", "pas", "sur", "par", "renvoie", "retourne", "si", "ce", "cette", "sont", "au",
+            "aux",
+        ],
+    ),
+    (
+        DocLanguage::Spanish,
+        &[
+            "el", "la", "los", "las", "es", "una", "de", "del", "para", "que", "con", "por", "si",
+            "se", "devuelve", "retorna", "como", "este", "esta", "son", "al", "cuando", "lo",
+        ],
+    ),
+    (
+        DocLanguage::Portuguese,
+        &[
+            "os", "as", "é", "um", "uma", "de", "do", "da", "dos", "das", "para", "que", "com",
+            "não", "se", "retorna", "em", "no", "na", "ao", "quando", "são",
+        ],
+    ),
+    (
+        DocLanguage::Italian,
+        &[
+            "il", "lo", "gli", "un", "una", "di", "del", "della", "per", "che", "con", "non",
+            "ritorna", "se", "sono", "nel", "alla", "al", "quando", "questo", "è",
+        ],
+    ),
+    (
+        DocLanguage::Dutch,
+        &[
+            "de", "het", "een", "en", "is", "van", "voor", "niet", "met", "op", "dat", "die",
+            "wordt", "geeft", "als", "bij", "naar", "zijn", "ook",
+        ],
+    ),
+];
+
+/// Letters found in one of the Latin-script languages much more than in
+/// the others
+fn letter_hint(c: char) -> Option<DocLanguage> {
+    match c {
+        'ß' | 'ä' | 'ö' | 'ü' => Some(DocLanguage::German),
+        'ñ' | '¿' | '¡' => Some(DocLanguage::Spanish),
+        'ã' | 'õ' => Some(DocLanguage::Portuguese),
+        'ê' | 'â' | 'î' | 'û' | 'ô' | 'ç' => Some(DocLanguage::French),
+        'ì' | 'ò' => Some(DocLanguage::Italian),
+        _ => None,
+    }
+}
+
+/// Script of a letter
+#[derive(Clone, Copy, PartialEq, Eq)]
+enum Script {
+    Latin,
+    Kana,
+    Han,
+    Hangul,
+    Cyrillic,
+    Other,
+}
+
+fn script(c: char) -> Script {
+    match c as u32 {
+        0x3040..=0x30FF | 0x31F0..=0x31FF | 0xFF66..=0xFF9D => Script::Kana,
+        0x4E00..=0x9FFF | 0x3400..=0x4DBF | 0xF900..=0xFAFF => Script::Han,
+        0xAC00..=0xD7AF | 0x1100..=0x11FF | 0x3130..=0x318F => Script::Hangul,
+        0x0400..=0x052F => Script::Cyrillic,
+        _ if c.is_ascii_alphabetic() => Script::Latin,
+        0x00C0..=0x024F => Script::Latin,
+        _ => Script::Other,
+    }
+}
+
+/// Language of `text`, None when it has too few words to tell
+pub fn detect_doc_language(text: &str) -> Option<DocLanguage> {
+    let mut prose = String::with_capacity(text.len());
+    for (i, part) in text.split('`').enumerate() {
+        if i % 2 == 0 {
+            prose.push_str(part);
+            prose.push(' ');
+        }
+    }
+
+    // A Han or kana character carries about as much as a Latin word
+    let (mut latin, mut kana, mut han, mut hangul, mut cyrillic, mut other) = (0, 0, 0, 0, 0, 0);
+    for c in prose.chars().filter(|c| c.is_alphabetic()) {
+        match script(c) {
+            Script::Latin => latin += 1,
+            Script::Kana => kana += 1,
+            Script::Han => han += 1,
+            Script::Hangul => hangul += 1,
+            Script::Cyrillic => cyrillic += 1,
+            Script::Other => other += 1,
+        }
+    }
+    let cjk = kana + han + hangul;
+    if cjk >= 2 && cjk * 4 >= latin {
+        return Some(if kana > 0 {
+            DocLanguage::Japanese
+        } else if hangul >= han {
+            DocLanguage::Korean
+        } else {
+            DocLanguage::Chinese
+        });
+    }
+    if cyrillic + other >= 4 && cyrillic + other >= latin {
+        return Some(if cyrillic >= other {
+            DocLanguage::Cyrillic
+        } else {
+            DocLanguage::Other
+        });
+    }
+
+    let prose = prose.to_lowercase();
+    let mut hits = [0usize; WORDS.len()];
+    for word in prose.split(|c: char| !c.is_alphabetic()) {
+        for (hit, (_, words)) in hits.iter_mut().zip(WORDS) {
+            if words.contains(&word) {
+                *hit += 1;
+            }
+        }
+    }
+    for language in prose.chars().filter_map(letter_hint) {
+        if let Some(i) = WORDS.iter().position(|(l, _)| *l == language) {
+            hits[i] += 1;
+        }
+    }
+
+    // English is listed first and wins ties
+    let (best, &count) = hits
+        .iter()
+        .enumerate()
+        .rev()
+        .max_by_key(|&(_, count)| count)?;
+    let english = hits[0];
+    if count == english && english > 0 {
+        Some(DocLanguage::English)
+    } else if count >= 2 {
+        Some(WORDS[best].0)
+    } else {
+        None
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_detects_scripts() {
+        for (text, language) in [
+            (
+                "インデックス内のシンボル数を返します。",
+                DocLanguage::Japanese,
+            ),
+            ("`parse_file` の結果を返す", DocLanguage::Japanese),
+            ("返回索引中的符号数量。", DocLanguage::Chinese),
+            ("인덱스의 심볼 수를 반환합니다.", DocLanguage::Korean),
+            (
+                "Возвращает число символов в индексе.",
+                DocLanguage::Cyrillic,
+            ),
+            ("Επιστρέφει τον αριθμό των συμβόλων.", DocLanguage::Other),
+        ] {
+            assert_eq!(detect_doc_language(text), Some(language), "{text}");
+        }
+    }
+
+    #[test]
+    fn test_detects_latin_languages() {
+        for (text, language) in [
+            (
+                "Returns the number of symbols in the index.",
+                DocLanguage::English,
+            ),
+            (
+                "Gibt die Anzahl der Symbole im Index zurück.",
+                DocLanguage::German,
+            ),
+            (
+                "Renvoie le nombre de symboles dans l'index.",
+                DocLanguage::French,
+            ),
+            (
+                "Devuelve el número de símbolos en el índice.",
+                DocLanguage::Spanish,
+            ),
+            (
+                "Retorna o número de símbolos no índice, não os arquivos.",
+                DocLanguage::Portuguese,
+            ),
+            (
+                "Ritorna il numero di simboli nel indice.",
+                DocLanguage::Italian,
+            ),
+            (
+                "Geeft het aantal symbolen van de index.",
+                DocLanguage::Dutch,
+            ),
+        ] {
+            assert_eq!(detect_doc_language(text), Some(language), "{text}");
+        }
+    }
+
+    #[test]
+    fn test_code_alone_is_undecided() {
+        assert_eq!(detect_doc_language(""), None);
+        assert_eq!(detect_doc_language("`die_and_restart`"), None);
+        assert_eq!(detect_doc_language("FIXME parse_file"), None);
+        // Identifiers in backticks do not outvote the prose around them
+        assert_eq!(
+            detect_doc_language("Gibt `the_index` zurück, wenn es fehlt."),
+            Some(DocLanguage::German)
+        );
+    }
+}
@@ -0,0 +1,152 @@
+//! Structured filters on semantic search
+//!
+//! A filter narrows the symbols a semantic search ranks, so "Python
+//! functions under services/ about retry logic" is one query. It is applied
+//! before ranking: the `limit` best matches all pass it.
+
+use crate::symbol::Visibility;
+use crate::{Symbol, SymbolKind};
+use glob::Pattern;
+
+/// Which symbols a semantic search ranks; an empty filter takes them all
+#[derive(Debug, Clone, Default)]
+pub struct SemanticFilter {
+    /// Only symbols of this language
+    pub language: Option<String>,
+    /// Only symbols of this kind
+    pub kind: Option<SymbolKind>,
+    /// Only symbols in files this glob matches, or under this path
+    pub path: Option<Pattern>,
+    /// Only symbols of this visibility
+    pub visibility: Option<Visibility>,
+}
+
+impl SemanticFilter {
+    /// A filter on language alone
+    pub fn language(language: Option<&str>) -> Self {
+        Self {
+            language: language.map(str::to_string),
+            ..Default::default()
+        }
+    }
+
+    /// Parse a filter from tool arguments; names the argument that is invalid
+    pub fn parse(
+        language: Option<&str>,
+        kind: Option<&str>,
+        path: Option<&str>,
+        visibility: Option<&str>,
+    ) -> Result<Self, String> {
+        let kind = kind
+            .map(|kind| kind.parse::<SymbolKind>().map_err(|e| format!("kind: {e}")))
+            .transpose()?;
+        let path = path
+            .map(|path| {
+                Pattern::new(path.trim_start_matches("./"))
+                    .map_err(|e| format!("path: invalid pattern '{path}': {e}"))
+            })
+            .transpose()?;
+        let visibility = visibility
+            .map(|visibility| visibility.parse().map_err(|e| format!("visibility: {e}")))
+            .transpose()?;
+        Ok(Self {
+            language: language.map(str::to_string),
+            kind,
+            path,
+            visibility,
+        })
+    }
+
+    /// Whether the filter goes past the language, which the semantic index
+    /// knows by itself
+    pub fn needs_symbols(&self) -> bool {
+        self.kind.is_some() || self.path.is_some() || self.visibility.is_some()
+    }
+
+    /// Whether a symbol passes the filter
+    pub fn accepts(&self, symbol: &Symbol) -> bool {
+        if let Some(language) = &self.language {
+            if symbol.language_id.map(|id| id.as_str()) != Some(language.as_str()) {
+                return false;
+            }
+        }
+        if self.kind.is_some_and(|kind| symbol.kind != kind) {
+            return false;
+        }
+        if self
+            .visibility
+            .is_some_and(|visibility| symbol.visibility != visibility)
+        {
+            return false;
+        }
+        self.path.as_ref().is_none_or(|path| {
+            let file = symbol.file_path.trim_start_matches("./");
+            path.matches(file) || file.starts_with(path.as_str())
+        })
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::types::{FileId, Range, SymbolId};
+
+    fn symbol(kind: SymbolKind, file: &str, visibility: Visibility) -> Symbol {
+        Symbol::new(
+            SymbolId::new(1).unwrap(),
+            "retry",
+            kind,
+            FileId::new(1).unwrap(),
+            Range::new(1, 0, 4, 0),
+        )
+        .with_file_path(file)
+        .with_visibility(visibility)
+        .with_language_id(crate::parsing::LanguageId::new("python"))
+    }
+
+    #[test]
+    fn test_filter_on_kind_path_and_visibility() {
+        let filter = SemanticFilter::parse(
+            Some("python"),
+            Some("function"),
+            Some("**/services/**"),
+            None,
+        )
+        .unwrap();
+        assert!(filter.needs_symbols());
+        assert!(filter.accepts(&symbol(
+            SymbolKind::Function,
+            "app/services/http.py",
+            Visibility::Public
+        )));
+        assert!(!filter.accepts(&symbol(
+            SymbolKind::Class,
+            "app/services/http.py",
+            Visibility::Public
+        )));
+        assert!(!filter.accepts(&symbol(
+            SymbolKind::Function,
+            "app/models/user.py",
+            Visibility::Public
+        )));
+
+        // A plain path takes everything under it
+        let filter =
+            SemanticFilter::parse(None, None, Some("./app/models"), Some("private")).unwrap();
+        assert!(filter.accepts(&symbol(
+            SymbolKind::Function,
+            "app/models/user.py",
+            Visibility::Private
+        )));
+        assert!(!filter.accepts(&symbol(
+            SymbolKind::Function,
+            "app/models/user.py",
+            Visibility::Public
+        )));
+
+        assert!(!SemanticFilter::language(Some("rust")).needs_symbols());
+        let err = SemanticFilter::parse(None, Some("routine"), None, None).unwrap_err();
+        assert!(err.starts_with("kind: "));
+        assert!(SemanticFilter::parse(None, None, None, Some("exported")).is_err());
+    }
+}
@@ -0,0 +1,98 @@
+//! Hybrid lexical and vector ranking
+//!
+//! Vector similarity ranks a doc comment that says what a query means above
+//! a symbol named exactly what the query says. Hybrid search also runs the
+//! query through Tantivy and fuses both rankings, so exact identifier
+//! matches stay near the top.
+
+use crate::SymbolId;
+use crate::config::HybridFusion;
+use std::collections::HashMap;
+
+/// Constant of reciprocal-rank fusion, damping the weight of the top ranks
+const RRF_K: f32 = 60.0;
+
+/// Fuse vector matches (cosine similarity) and lexical matches (BM25), each
+/// best first, into one ranking, best first. `lexical_weight` (0 to 1) is
+/// the share of the lexical side; the scores come out between 0 and 1.
+///
+/// - `Weighted` adds the cosine similarity and the BM25 score relative to
+///   the best lexical match
+/// - `Rrf` adds `1 / (60 + rank)` from each ranking, ignoring the scores
+pub fn fuse(
+    vector: &[(SymbolId, f32)],
+    lexical: &[(SymbolId, f32)],
+    fusion: HybridFusion,
+    lexical_weight: f32,
+) -> Vec<(SymbolId, f32)> {
+    let lexical_weight = lexical_weight.clamp(0.0, 1.0);
+    let vector_weight = 1.0 - lexical_weight;
+    let mut scores: HashMap<SymbolId, f32> = HashMap::new();
+
+    match fusion {
+        HybridFusion::Weighted => {
+            for &(id, similarity) in vector {
+                *scores.entry(id).or_default() += vector_weight * similarity.max(0.0);
+            }
+            let best = lexical.iter().map(|(_, score)| *score).fold(0.0, f32::max);
+            if best > 0.0 {
+                for &(id, score) in lexical {
+                    *scores.entry(id).or_default() += lexical_weight * score / best;
+                }
+            }
+        }
+        HybridFusion::Rrf => {
+            // Scaled so that the top match of both rankings scores 1
+            let scale = RRF_K + 1.0;
+            for (rank, &(id, _)) in vector.iter().enumerate() {
+                *scores.entry(id).or_default() +=
+                    vector_weight * scale / (RRF_K + 1.0 + rank as f32);
+            }
+            for (rank, &(id, _)) in lexical.iter().enumerate() {
+                *scores.entry(id).or_default() +=
+                    lexical_weight * scale / (RRF_K + 1.0 + rank as f32);
+            }
+        }
+    }
+
+    let mut fused: Vec<(SymbolId, f32)> = scores.into_iter().collect();
+    fused.sort_by(|a, b| b.1.total_cmp(&a.1).then(a.0.value().cmp(&b.0.value())));
+    fused
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn id(n: u32) -> SymbolId {
+        SymbolId::new(n).unwrap()
+    }
+
+    #[test]
+    fn test_exact_lexical_match_outranks_fuzzy_vector_matches() {
+        let vector = [(id(1), 0.62), (id(2), 0.58), (id(3), 0.41)];
+        // Symbol 3 is named exactly what was searched for
+        let lexical = [(id(3), 12.0), (id(2), 3.0)];
+
+        let fused = fuse(&vector, &lexical, HybridFusion::Weighted, 0.5);
+        assert_eq!(fused[0].0, id(3));
+        assert!((fused[0].1 - 0.705).abs() < 1e-6);
+        assert!(fused.iter().all(|(_, score)| (0.0..=1.0).contains(score)));
+
+        let fused = fuse(&vector, &lexical, HybridFusion::Rrf, 0.5);
+        let ids: Vec<_> = fused.iter().map(|(id, _)| *id).collect();
+        assert_eq!(ids, vec![id(3), id(2), id(1)]);
+
+        // Weight 0 is the vector ranking alone
+        let fused = fuse(&vector, &lexical, HybridFusion::Weighted, 0.0);
+        let ids: Vec<_> = fused.iter().map(|(id, _)| *id).collect();
+        assert_eq!(ids, vec![id(1), id(2), id(3)]);
+    }
+
+    #[test]
+    fn test_rrf_top_of_both_rankings_scores_one() {
+        let fused = fuse(&[(id(7), 0.3)], &[(id(7), 1.5)], HybridFusion::Rrf, 0.3);
+        assert_eq!(fused.len(), 1);
+        assert!((fused[0].1 - 1.0).abs() < 1e-6);
+    }
+}
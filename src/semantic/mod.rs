@@ -3,16 +3,25 @@
 //! This module provides a simple API for semantic search on documentation,
 //! designed to integrate with the existing indexing system.
 
+pub mod chunking;
+mod doc_language;
+mod filter;
+pub mod hybrid;
 mod metadata;
 mod pool;
+pub mod quantized;
 pub(crate) mod remote;
+mod rerank;
 mod simple;
 mod storage;
 
+pub use doc_language::{DocLanguage, detect_doc_language};
+pub use filter::SemanticFilter;
 pub use metadata::{EmbeddingBackendKind, SemanticMetadata};
 pub use pool::{EmbeddingBackend, EmbeddingPool};
-pub use remote::RemoteEmbedder;
-pub use simple::{SemanticSearchError, SimpleSemanticSearch};
+pub use remote::{RemoteEmbedder, RemoteLimits};
+pub use rerank::{Reranker, parse_reranker_model};
+pub use simple::{SemanticSearchError, SimpleSemanticSearch, cosine_similarity, text_hash};
 pub use storage::SemanticVectorStorage;
 
 // Re-export key types
@@ -20,6 +29,10 @@ pub use fastembed::{EmbeddingModel, TextEmbedding};
 
 /// Similarity threshold recommendations based on testing
 pub mod thresholds {
+    /// Threshold for near-identical documents (e.g., a copy-pasted function
+    /// and its doc comment)
+    pub const DUPLICATE: f32 = 0.92;
+
     /// Threshold for very similar documents (e.g., same concept, different wording)
     pub const VERY_SIMILAR: f32 = 0.75;
 
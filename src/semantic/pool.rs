@@ -6,8 +6,9 @@
 //!   - `RemoteEmbedder`  — OpenAI-compatible HTTP server (async, batched)
 
 use crate::SymbolId;
+use crate::config::ExecutionProvider;
 use crossbeam_channel::{Receiver, Sender, bounded};
-use fastembed::{EmbeddingModel, InitOptions, TextEmbedding};
+use fastembed::{EmbeddingModel, ExecutionProviderDispatch, InitOptions, TextEmbedding};
 use std::sync::Arc;
 use std::sync::atomic::{AtomicUsize, Ordering};
 
@@ -40,6 +41,15 @@ impl EmbeddingBackend {
         }
     }
 
+    /// What produces this backend's embeddings, so equal texts map to equal
+    /// vectors only under the same id.
+    pub fn model_id(&self) -> String {
+        match self {
+            EmbeddingBackend::Local(pool) => pool.model_name().to_string(),
+            EmbeddingBackend::Remote(r) => r.model_id(),
+        }
+    }
+
     /// Model name / URL for metadata and logging.
     pub fn model_name(&self) -> &str {
         match self {
@@ -48,6 +58,15 @@ impl EmbeddingBackend {
         }
     }
 
+    /// Whether the model is known to handle English alone. A remote
+    /// model is never known to.
+    pub fn is_english_only(&self) -> bool {
+        match self {
+            EmbeddingBackend::Local(pool) => !pool.is_multilingual(),
+            EmbeddingBackend::Remote(_) => false,
+        }
+    }
+
     /// Embed a single text synchronously.
     /// Remote backend blocks the calling thread via `tokio::task::block_in_place`.
     pub fn embed_one(&self, text: &str) -> Result<Vec<f32>, SemanticSearchError> {
@@ -210,6 +229,9 @@ pub struct EmbeddingPool {
     embed_workers: rayon::ThreadPool,
     dimensions: usize,
     model_name: String,
+    multilingual: bool,
+    /// Query and passage prefixes of the model, see `crate::vector::text_prefixes`
+    prefixes: Option<(&'static str, &'static str)>,
     usage_counters: Vec<AtomicUsize>,
 }
 
@@ -223,9 +245,20 @@ impl EmbeddingPool {
     ///
     /// Each model instance uses ~86MB of memory for AllMiniLML6V2.
     pub fn new(pool_size: usize, model: EmbeddingModel) -> Result<Self, SemanticSearchError> {
+        Self::with_execution_provider(pool_size, model, ExecutionProvider::Cpu)
+    }
+
+    /// Create a pool whose instances run on `provider`. A provider that is
+    /// unavailable, or a model that fails to load on it, falls back to the CPU.
+    pub fn with_execution_provider(
+        pool_size: usize,
+        model: EmbeddingModel,
+        provider: ExecutionProvider,
+    ) -> Result<Self, SemanticSearchError> {
         let pool_size = pool_size.max(1);
+        let mut providers = execution_providers(provider);
 
-        let cache_dir = crate::init::models_dir();
+        let cache_dir = crate::init::model_cache_dir();
         let model_name = crate::vector::model_to_string(&model);
 
         tracing::info!(
@@ -239,16 +272,34 @@ impl EmbeddingPool {
         let mut models = Vec::with_capacity(pool_size);
 
         for i in 0..pool_size {
-            let mut text_model = TextEmbedding::try_new(
+            let options = || {
                 InitOptions::new(model.clone())
                     .with_cache_dir(cache_dir.clone())
-                    .with_show_download_progress(i == 0),
-            )
-            .map_err(|e| {
+                    .with_show_download_progress(i == 0)
+            };
+            let loaded = if providers.is_empty() {
+                TextEmbedding::try_new(options())
+            } else {
+                match TextEmbedding::try_new(options().with_execution_providers(providers.clone()))
+                {
+                    Ok(loaded) => Ok(loaded),
+                    Err(e) => {
+                        tracing::warn!(
+                            target: "semantic",
+                            "{} execution provider failed ({e}), falling back to CPU",
+                            provider.as_str()
+                        );
+                        providers.clear();
+                        TextEmbedding::try_new(options())
+                    }
+                }
+            };
+            let mut text_model = loaded.map_err(|e| {
                 SemanticSearchError::ModelInitError(format!(
-                    "Failed to initialize model instance {}: {}",
+                    "Failed to initialize model instance {}: {}{}",
                     i + 1,
-                    e
+                    e,
+                    crate::init::model_path_hint(&model_name)
                 ))
             })?;
 
@@ -281,6 +332,8 @@ impl EmbeddingPool {
             embed_workers,
             dimensions,
             model_name,
+            multilingual: crate::vector::is_multilingual_model(&model),
+            prefixes: crate::vector::text_prefixes(&model),
             usage_counters,
         })
     }
@@ -313,13 +366,23 @@ impl EmbeddingPool {
         &self.model_name
     }
 
-    /// Generate embedding for a single text. Thread-safe via pool acquire/release.
+    /// Whether the model was trained on many languages.
+    pub fn is_multilingual(&self) -> bool {
+        self.multilingual
+    }
+
+    /// Generate embedding for a single text, a query. Thread-safe via pool
+    /// acquire/release.
     pub fn embed_one(&self, text: &str) -> Result<Vec<f32>, SemanticSearchError> {
         if text.trim().is_empty() {
             return Err(SemanticSearchError::EmbeddingError(
                 "Empty text".to_string(),
             ));
         }
+        let text = match self.prefixes {
+            Some((query, _)) => format!("{query}{text}"),
+            None => text.to_string(),
+        };
 
         let mut instance = self.acquire()?;
         let result = instance
@@ -354,7 +417,7 @@ impl EmbeddingPool {
         }
     }
 
-    /// Generate embeddings for multiple items in parallel using rayon.
+    /// Generate embeddings for multiple items, passages, in parallel using rayon.
     ///
     /// Uses batched embedding (64 docs per model call) for throughput.
     /// Failed embeddings are logged and skipped.
@@ -381,7 +444,13 @@ impl EmbeddingPool {
                 .chunks(BATCH_SIZE)
                 .par_bridge()
                 .map(|batch| {
-                    let texts: Vec<&str> = batch.iter().map(|(_, doc, _)| *doc).collect();
+                    let texts: Vec<String> = batch
+                        .iter()
+                        .map(|(_, doc, _)| match self.prefixes {
+                            Some((_, passage)) => format!("{passage}{doc}"),
+                            None => doc.to_string(),
+                        })
+                        .collect();
 
                     let mut instance = self.acquire()?;
                     let embeddings_result = instance.model.embed(texts, None);
@@ -421,6 +490,44 @@ impl EmbeddingPool {
     }
 }
 
+/// The ONNX Runtime providers to register for `provider`, leaving out any
+/// this build or machine lacks. Empty means the CPU.
+fn execution_providers(provider: ExecutionProvider) -> Vec<ExecutionProviderDispatch> {
+    use ort::execution_providers::{
+        CUDAExecutionProvider, CoreMLExecutionProvider, DirectMLExecutionProvider,
+        ExecutionProvider as _,
+    };
+
+    let wanted = |kind| provider == ExecutionProvider::Auto || provider == kind;
+    let mut candidates = Vec::new();
+    if wanted(ExecutionProvider::Cuda) {
+        let ep = CUDAExecutionProvider::default();
+        candidates.push(("cuda", ep.is_available().unwrap_or(false), ep.build()));
+    }
+    if wanted(ExecutionProvider::CoreMl) {
+        let ep = CoreMLExecutionProvider::default();
+        candidates.push(("coreml", ep.is_available().unwrap_or(false), ep.build()));
+    }
+    if wanted(ExecutionProvider::DirectMl) {
+        let ep = DirectMLExecutionProvider::default();
+        candidates.push(("directml", ep.is_available().unwrap_or(false), ep.build()));
+    }
+
+    let mut providers = Vec::new();
+    for (name, available, dispatch) in candidates {
+        if available {
+            tracing::info!(target: "semantic", "Embedding with the {name} execution provider");
+            providers.push(dispatch);
+        } else if provider != ExecutionProvider::Auto {
+            tracing::warn!(
+                target: "semantic",
+                "{name} execution provider is not available in this build or on this machine, using CPU"
+            );
+        }
+    }
+    providers
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
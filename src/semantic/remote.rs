@@ -3,10 +3,16 @@
 //! Replaces local fastembed when `semantic_search.remote_url` is configured
 //! (or the `CODANNA_EMBED_URL` environment variable is set).
 //!
-//! Compatible with Infinity, OpenAI, vLLM, and any server that serves
-//! POST /v1/embeddings with the OpenAI request/response schema.
+//! Compatible with Ollama, Infinity, OpenAI, vLLM, and any server that
+//! serves POST /v1/embeddings with the OpenAI request/response schema.
+//!
+//! Texts go out in batches; a request that times out, fails to connect or
+//! gets 429 or a 5xx is retried with exponential backoff (or after the
+//! server's Retry-After), and requests can be spaced to a rate limit so a
+//! shared server is not flooded.
 
 use std::future::Future;
+use std::sync::Arc;
 use std::time::Duration;
 
 use reqwest::Client;
@@ -74,12 +80,78 @@ struct EmbedData {
     embedding: Vec<f32>,
 }
 
+// ── Limits ─────────────────────────────────────────────────────────────────
+
+/// How an embedder batches, retries and paces its requests.
+#[derive(Debug, Clone)]
+pub struct RemoteLimits {
+    /// Texts per request
+    pub batch_size: usize,
+    /// Retries of a request that failed transiently
+    pub max_retries: u32,
+    /// Most requests started per minute (unlimited when None)
+    pub requests_per_minute: Option<u32>,
+}
+
+impl Default for RemoteLimits {
+    fn default() -> Self {
+        Self {
+            batch_size: 64,
+            max_retries: 3,
+            requests_per_minute: None,
+        }
+    }
+}
+
+/// Spaces requests evenly: each waits for the slot after the previous one.
+struct RateLimiter {
+    interval: Duration,
+    next: tokio::sync::Mutex<tokio::time::Instant>,
+}
+
+impl RateLimiter {
+    fn per_minute(requests: u32) -> Self {
+        Self {
+            interval: Duration::from_secs(60) / requests.max(1),
+            next: tokio::sync::Mutex::new(tokio::time::Instant::now()),
+        }
+    }
+
+    async fn wait(&self) {
+        let mut next = self.next.lock().await;
+        let now = tokio::time::Instant::now();
+        if *next > now {
+            tokio::time::sleep_until(*next).await;
+        }
+        *next = (*next).max(now) + self.interval;
+    }
+}
+
+/// A request that failed, and whether trying it again may succeed
+struct RequestError {
+    error: SemanticSearchError,
+    retry: bool,
+    /// Delay the server asked for (Retry-After)
+    retry_after: Option<Duration>,
+}
+
+impl RequestError {
+    fn fatal(message: String) -> Self {
+        Self {
+            error: SemanticSearchError::EmbeddingError(message),
+            retry: false,
+            retry_after: None,
+        }
+    }
+}
+
 // ── RemoteEmbedder ─────────────────────────────────────────────────────────
 
 /// Embedding client for an OpenAI-compatible HTTP server.
 ///
-/// All requests are batched in chunks of `BATCH_SIZE` to avoid hitting
-/// server request-size limits. Each request has a 30-second timeout.
+/// Requests are batched in chunks of `RemoteLimits::batch_size` to avoid
+/// hitting server request-size limits. Each request has a 30-second
+/// timeout.
 #[derive(Clone)]
 pub struct RemoteEmbedder {
     client: Client,
@@ -87,11 +159,15 @@ pub struct RemoteEmbedder {
     model: String,
     dim: usize,
     api_key: Option<String>,
+    limits: RemoteLimits,
+    limiter: Option<Arc<RateLimiter>>,
 }
 
-const BATCH_SIZE: usize = 64;
 const REQUEST_TIMEOUT_SECS: u64 = 30;
 const MAX_TEXT_CHARS: usize = 2000;
+/// First retry delay, doubled on each further retry
+const RETRY_BASE_DELAY: Duration = Duration::from_millis(500);
+const RETRY_MAX_DELAY: Duration = Duration::from_secs(30);
 
 impl RemoteEmbedder {
     /// Build a RemoteEmbedder, probing the server to confirm the dimension
@@ -101,6 +177,7 @@ impl RemoteEmbedder {
         model: &str,
         expected_dim: Option<usize>,
         api_key: Option<String>,
+        limits: RemoteLimits,
     ) -> Result<Self, SemanticSearchError> {
         let client = Client::builder()
             .timeout(Duration::from_secs(REQUEST_TIMEOUT_SECS))
@@ -110,16 +187,24 @@ impl RemoteEmbedder {
             })?;
 
         let url = format!("{}/v1/embeddings", base_url.trim_end_matches('/'));
+        let limiter = limits
+            .requests_per_minute
+            .map(|requests| Arc::new(RateLimiter::per_minute(requests)));
+        let mut embedder = Self {
+            client,
+            url,
+            model: model.to_string(),
+            dim: 0,
+            api_key,
+            limits: RemoteLimits {
+                batch_size: limits.batch_size.max(1),
+                ..limits
+            },
+            limiter,
+        };
 
         // Probe with a single text to determine / validate dimension
-        let probe = Self::request(
-            &client,
-            &url,
-            model,
-            &["probe".to_string()],
-            api_key.as_deref(),
-        )
-        .await?;
+        let probe = embedder.request(&["probe".to_string()]).await?;
         let actual_dim = probe.first().map(|v| v.len()).ok_or_else(|| {
             SemanticSearchError::ModelInitError(
                 "Remote server returned empty embedding on probe".into(),
@@ -136,16 +221,12 @@ impl RemoteEmbedder {
 
         tracing::info!(
             target: "semantic",
-            "Remote embedding backend ready: url={url} model={model} dim={actual_dim}"
+            "Remote embedding backend ready: url={} model={model} dim={actual_dim}",
+            embedder.url
         );
 
-        Ok(Self {
-            client,
-            url,
-            model: model.to_string(),
-            dim: actual_dim,
-            api_key,
-        })
+        embedder.dim = actual_dim;
+        Ok(embedder)
     }
 
     /// Output dimension of this embedding model.
@@ -153,8 +234,13 @@ impl RemoteEmbedder {
         self.dim
     }
 
+    /// Model name and endpoint, which together say what produced an embedding.
+    pub fn model_id(&self) -> String {
+        format!("{}@{}", self.model, self.url)
+    }
+
     /// Embed a batch of texts, truncating each to `MAX_TEXT_CHARS` characters.
-    /// Sends requests in chunks of `BATCH_SIZE`.
+    /// Sends requests in chunks of `RemoteLimits::batch_size`.
     pub async fn embed(&self, texts: &[String]) -> Result<Vec<Vec<f32>>, SemanticSearchError> {
         let mut results: Vec<(usize, Vec<f32>)> = Vec::with_capacity(texts.len());
 
@@ -170,15 +256,9 @@ impl RemoteEmbedder {
             })
             .collect();
 
-        for (chunk_start, chunk) in truncated.chunks(BATCH_SIZE).enumerate() {
-            let embeddings = Self::request(
-                &self.client,
-                &self.url,
-                &self.model,
-                chunk,
-                self.api_key.as_deref(),
-            )
-            .await?;
+        let batch_size = self.limits.batch_size;
+        for (chunk_start, chunk) in truncated.chunks(batch_size).enumerate() {
+            let embeddings = self.request(chunk).await?;
 
             if embeddings.len() != chunk.len() {
                 return Err(SemanticSearchError::EmbeddingError(format!(
@@ -192,12 +272,12 @@ impl RemoteEmbedder {
                 if emb.len() != self.dim {
                     return Err(SemanticSearchError::EmbeddingError(format!(
                         "Remote embedding at index {} has dim {}, expected {}",
-                        chunk_start * BATCH_SIZE + i,
+                        chunk_start * batch_size + i,
                         emb.len(),
                         self.dim
                     )));
                 }
-                results.push((chunk_start * BATCH_SIZE + i, emb));
+                results.push((chunk_start * batch_size + i, emb));
             }
         }
 
@@ -206,38 +286,73 @@ impl RemoteEmbedder {
         Ok(results.into_iter().map(|(_, emb)| emb).collect())
     }
 
-    async fn request(
-        client: &Client,
-        url: &str,
-        model: &str,
-        texts: &[String],
-        api_key: Option<&str>,
-    ) -> Result<Vec<Vec<f32>>, SemanticSearchError> {
+    /// Send one request, retrying transient failures up to
+    /// `RemoteLimits::max_retries` times.
+    async fn request(&self, texts: &[String]) -> Result<Vec<Vec<f32>>, SemanticSearchError> {
+        let mut attempt = 0;
+        loop {
+            if let Some(limiter) = &self.limiter {
+                limiter.wait().await;
+            }
+            match self.request_once(texts).await {
+                Ok(embeddings) => return Ok(embeddings),
+                Err(e) if e.retry && attempt < self.limits.max_retries => {
+                    let delay = e.retry_after.unwrap_or_else(|| {
+                        (RETRY_BASE_DELAY * 2u32.saturating_pow(attempt)).min(RETRY_MAX_DELAY)
+                    });
+                    attempt += 1;
+                    tracing::warn!(
+                        target: "semantic",
+                        "{}; retry {attempt}/{} in {delay:?}",
+                        e.error,
+                        self.limits.max_retries
+                    );
+                    tokio::time::sleep(delay).await;
+                }
+                Err(e) => return Err(e.error),
+            }
+        }
+    }
+
+    async fn request_once(&self, texts: &[String]) -> Result<Vec<Vec<f32>>, RequestError> {
         let body = EmbedRequest {
-            model,
+            model: &self.model,
             input: texts,
         };
 
-        let mut req = client.post(url).json(&body);
-        if let Some(key) = api_key {
+        let mut req = self.client.post(&self.url).json(&body);
+        if let Some(key) = &self.api_key {
             req = req.bearer_auth(key);
         }
 
-        let resp = req.send().await.map_err(|e| {
-            SemanticSearchError::EmbeddingError(format!("Remote embed request failed: {e}"))
+        let resp = req.send().await.map_err(|e| RequestError {
+            retry: e.is_timeout() || e.is_connect() || e.is_request(),
+            error: SemanticSearchError::EmbeddingError(format!("Remote embed request failed: {e}")),
+            retry_after: None,
         })?;
 
         if !resp.status().is_success() {
             let status = resp.status();
+            let retry_after = resp
+                .headers()
+                .get(reqwest::header::RETRY_AFTER)
+                .and_then(|value| value.to_str().ok())
+                .and_then(|value| value.trim().parse().ok())
+                .map(Duration::from_secs);
             let text = resp.text().await.unwrap_or_default();
-            return Err(SemanticSearchError::EmbeddingError(format!(
-                "Remote embed server returned {status}: {text}"
-            )));
+            return Err(RequestError {
+                error: SemanticSearchError::EmbeddingError(format!(
+                    "Remote embed server returned {status}: {text}"
+                )),
+                retry: status == reqwest::StatusCode::TOO_MANY_REQUESTS || status.is_server_error(),
+                retry_after,
+            });
         }
 
-        let parsed: EmbedResponse = resp.json().await.map_err(|e| {
-            SemanticSearchError::EmbeddingError(format!("Failed to parse embed response: {e}"))
-        })?;
+        let parsed: EmbedResponse = resp
+            .json()
+            .await
+            .map_err(|e| RequestError::fatal(format!("Failed to parse embed response: {e}")))?;
 
         // Sort by index and validate contiguous range [0, len)
         let mut data = parsed.data;
@@ -245,7 +360,7 @@ impl RemoteEmbedder {
 
         for (expected, d) in data.iter().enumerate() {
             if d.index != expected {
-                return Err(SemanticSearchError::EmbeddingError(format!(
+                return Err(RequestError::fatal(format!(
                     "Remote embed response has non-contiguous index: expected {expected}, got {}",
                     d.index
                 )));
@@ -255,3 +370,123 @@ impl RemoteEmbedder {
         Ok(data.into_iter().map(|d| d.embedding).collect())
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::io::{Read, Write};
+    use std::net::TcpListener;
+
+    /// Serve `responses` in order, one per connection, as (status line, body)
+    fn serve(responses: Vec<(&'static str, String)>) -> String {
+        let listener = TcpListener::bind("127.0.0.1:0").unwrap();
+        let addr = listener.local_addr().unwrap();
+        std::thread::spawn(move || {
+            for (status, body) in responses {
+                let Ok((mut stream, _)) = listener.accept() else {
+                    return;
+                };
+                // Read the whole request before answering
+                let mut request = Vec::new();
+                let mut chunk = [0u8; 4096];
+                loop {
+                    let Ok(read) = stream.read(&mut chunk) else {
+                        break;
+                    };
+                    request.extend_from_slice(&chunk[..read]);
+                    let text = String::from_utf8_lossy(&request);
+                    if let Some(end) = text.find("\r\n\r\n") {
+                        let length = text[..end]
+                            .lines()
+                            .find_map(|line| {
+                                let (name, value) = line.split_once(':')?;
+                                name.eq_ignore_ascii_case("content-length")
+                                    .then(|| value.trim().parse::<usize>().ok())?
+                            })
+                            .unwrap_or(0);
+                        if request.len() >= end + 4 + length {
+                            break;
+                        }
+                    }
+                    if read == 0 {
+                        break;
+                    }
+                }
+                let response = format!(
+                    "HTTP/1.1 {status}\r\nContent-Type: application/json\r\nRetry-After: 0\r\nContent-Length: {}\r\nConnection: close\r\n\r\n{body}",
+                    body.len()
+                );
+                let _ = stream.write_all(response.as_bytes());
+            }
+        });
+        format!("http://{addr}")
+    }
+
+    fn embeddings(count: usize) -> String {
+        let data: Vec<String> = (0..count)
+            .map(|i| format!(r#"{{"index":{i},"embedding":[0.5,{i}.0]}}"#))
+            .collect();
+        format!(r#"{{"data":[{}]}}"#, data.join(","))
+    }
+
+    #[tokio::test(flavor = "multi_thread")]
+    async fn test_transient_failures_are_retried() {
+        let url = serve(vec![
+            ("503 Service Unavailable", String::new()),
+            ("200 OK", embeddings(1)),
+            ("429 Too Many Requests", String::new()),
+            ("200 OK", embeddings(2)),
+        ]);
+        let embedder = RemoteEmbedder::new(&url, "test", Some(2), None, RemoteLimits::default())
+            .await
+            .unwrap();
+        assert_eq!(embedder.dim(), 2);
+
+        let texts = vec!["one".to_string(), "two".to_string()];
+        let result = embedder.embed(&texts).await.unwrap();
+        assert_eq!(result, vec![vec![0.5, 0.0], vec![0.5, 1.0]]);
+    }
+
+    #[tokio::test(flavor = "multi_thread")]
+    async fn test_client_errors_and_exhausted_retries_fail() {
+        let url = serve(vec![("400 Bad Request", String::new())]);
+        assert!(
+            RemoteEmbedder::new(&url, "test", None, None, RemoteLimits::default())
+                .await
+                .is_err()
+        );
+
+        let url = serve(vec![
+            ("500 Internal Server Error", String::new()),
+            ("500 Internal Server Error", String::new()),
+        ]);
+        let limits = RemoteLimits {
+            max_retries: 1,
+            ..Default::default()
+        };
+        assert!(
+            RemoteEmbedder::new(&url, "test", None, None, limits)
+                .await
+                .is_err()
+        );
+    }
+
+    #[tokio::test(flavor = "multi_thread")]
+    async fn test_batches_follow_the_batch_size() {
+        let url = serve(vec![
+            ("200 OK", embeddings(1)),
+            ("200 OK", embeddings(2)),
+            ("200 OK", embeddings(1)),
+        ]);
+        let limits = RemoteLimits {
+            batch_size: 2,
+            requests_per_minute: Some(6000),
+            ..Default::default()
+        };
+        let embedder = RemoteEmbedder::new(&url, "test", None, None, limits)
+            .await
+            .unwrap();
+        let texts: Vec<String> = ["a", "b", "c"].iter().map(|t| t.to_string()).collect();
+        assert_eq!(embedder.embed(&texts).await.unwrap().len(), 3);
+    }
+}
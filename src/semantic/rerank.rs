@@ -0,0 +1,98 @@
+//! Cross-encoder re-ranking of semantic search hits
+//!
+//! An embedding compares a query and a doc comment it encoded separately.
+//! A cross-encoder reads both together, which orders the top hits of a
+//! natural-language query much more precisely, at a cost too high for the
+//! whole index. `semantic_search.rerank` runs one over the top candidates.
+
+use fastembed::{RerankInitOptions, RerankerModel, TextRerank};
+use std::sync::Mutex;
+
+use super::SemanticSearchError;
+
+/// Parse a reranker model name, as in `semantic_search.rerank_model`.
+///
+/// Accepts the short names `bge-reranker-base` (English and Chinese),
+/// `bge-reranker-v2-m3` (multilingual), `jina-reranker-v1-turbo-en` and
+/// `jina-reranker-v2-base-multilingual`.
+pub fn parse_reranker_model(name: &str) -> Result<RerankerModel, String> {
+    match name.to_lowercase().as_str() {
+        "bge-reranker-base" => Ok(RerankerModel::BGERerankerBase),
+        "bge-reranker-v2-m3" => Ok(RerankerModel::BGERerankerV2M3),
+        "jina-reranker-v1-turbo-en" => Ok(RerankerModel::JINARerankerV1TurboEn),
+        "jina-reranker-v2-base-multilingual" => Ok(RerankerModel::JINARerankerV2BaseMultiligual),
+        _ => Err(format!(
+            "Unknown reranker model: {name}. Available: bge-reranker-base, bge-reranker-v2-m3, \
+             jina-reranker-v1-turbo-en, jina-reranker-v2-base-multilingual"
+        )),
+    }
+}
+
+/// A loaded cross-encoder
+pub struct Reranker {
+    model: Mutex<TextRerank>,
+}
+
+impl Reranker {
+    /// Load `model_name`, downloading it to the models directory on first use
+    pub fn new(model_name: &str) -> Result<Self, SemanticSearchError> {
+        let model =
+            parse_reranker_model(model_name).map_err(SemanticSearchError::ModelInitError)?;
+        let model = TextRerank::try_new(
+            RerankInitOptions::new(model)
+                .with_cache_dir(crate::init::model_cache_dir())
+                .with_show_download_progress(false),
+        )
+        .map_err(|e| {
+            SemanticSearchError::ModelInitError(format!(
+                "Failed to load reranker {model_name}: {e}{}",
+                crate::init::model_path_hint(model_name)
+            ))
+        })?;
+        Ok(Self {
+            model: Mutex::new(model),
+        })
+    }
+
+    /// Relevance of each document to `query`, between 0 and 1, in the order
+    /// of `documents`
+    pub fn scores(&self, query: &str, documents: &[&str]) -> Result<Vec<f32>, SemanticSearchError> {
+        if documents.is_empty() {
+            return Ok(Vec::new());
+        }
+        let mut model = self.model.lock().map_err(|_| {
+            SemanticSearchError::EmbeddingError("reranker lock poisoned".to_string())
+        })?;
+        let ranked = model
+            .rerank(query, documents, false, None)
+            .map_err(|e| SemanticSearchError::EmbeddingError(e.to_string()))?;
+
+        // Results come back best first; the model scores are logits
+        let mut scores = vec![0.0; documents.len()];
+        for result in ranked {
+            if let Some(score) = scores.get_mut(result.index) {
+                *score = 1.0 / (1.0 + (-result.score).exp());
+            }
+        }
+        Ok(scores)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_reranker_model() {
+        assert!(matches!(
+            parse_reranker_model("BGE-Reranker-Base"),
+            Ok(RerankerModel::BGERerankerBase)
+        ));
+        assert!(matches!(
+            parse_reranker_model("bge-reranker-v2-m3"),
+            Ok(RerankerModel::BGERerankerV2M3)
+        ));
+        let err = parse_reranker_model("cross-encoder").unwrap_err();
+        assert!(err.contains("bge-reranker-base"));
+    }
+}
@@ -1,10 +1,14 @@
 //! Simple semantic search implementation for documentation comments
 
 use crate::SymbolId;
+use crate::config::{SemanticVectors, VectorQuantization};
+use crate::semantic::quantized::{self, QuantizedVectors};
+use crate::vector::{MappedVectors, VectorId};
 use fastembed::{EmbeddingModel, InitOptions, TextEmbedding};
-use std::collections::HashMap;
+use std::borrow::Cow;
+use std::collections::{HashMap, HashSet};
 use std::path::Path;
-use std::sync::Mutex;
+use std::sync::{Arc, LazyLock, Mutex, Weak};
 
 /// Error type for semantic search operations
 #[derive(Debug, thiserror::Error)]
@@ -28,6 +32,11 @@ pub enum SemanticSearchError {
         suggestion: String,
     },
 
+    #[error(
+        "Embedding model changed: the index was embedded with {index}, settings.toml selects {configured}\nSuggestion: Run 'codanna index' to re-embed with {configured}"
+    )]
+    ModelChanged { index: String, configured: String },
+
     #[error("Invalid ID: {id}\nSuggestion: {suggestion}")]
     InvalidId { id: u32, suggestion: String },
 
@@ -40,6 +49,282 @@ pub enum SemanticSearchError {
     },
 }
 
+/// Embeddings by symbol ID.
+///
+/// The embeddings of a loaded index are read in place from the mapped
+/// vector file, so they cost no heap and processes serving the same index
+/// share their pages. Embeddings stored or removed after loading are kept
+/// in memory over the mapped ones until the next load. Quantized
+/// embeddings are mapped the same way.
+#[derive(Default)]
+struct EmbeddingStore {
+    mapped: Option<MappedVectors>,
+    quantized: Option<QuantizedVectors>,
+    /// Mapped embeddings neither replaced nor removed
+    mapped_live: usize,
+    /// Embeddings stored since loading, replacing any mapped one
+    owned: HashMap<SymbolId, Vec<f32>>,
+    /// Mapped embeddings removed since loading
+    removed: HashSet<SymbolId>,
+}
+
+impl EmbeddingStore {
+    fn mapped(mapped: MappedVectors) -> Self {
+        Self {
+            mapped_live: mapped.len(),
+            mapped: Some(mapped),
+            ..Default::default()
+        }
+    }
+
+    fn quantized(quantized: QuantizedVectors) -> Self {
+        Self {
+            mapped_live: quantized.len(),
+            quantized: Some(quantized),
+            ..Default::default()
+        }
+    }
+
+    fn from_vectors(vectors: Vec<(SymbolId, Vec<f32>)>) -> Self {
+        Self {
+            owned: vectors.into_iter().collect(),
+            ..Default::default()
+        }
+    }
+
+    fn in_map(&self, id: SymbolId) -> bool {
+        self.quantized
+            .as_ref()
+            .is_some_and(|quantized| quantized.contains(id))
+            || self
+                .mapped
+                .as_ref()
+                .zip(VectorId::new(id.to_u32()))
+                .is_some_and(|(mapped, id)| mapped.contains(id))
+    }
+
+    fn is_mapped_live(&self, id: SymbolId) -> bool {
+        !self.owned.contains_key(&id) && !self.removed.contains(&id) && self.in_map(id)
+    }
+
+    fn len(&self) -> usize {
+        self.owned.len() + self.mapped_live
+    }
+
+    fn is_empty(&self) -> bool {
+        self.len() == 0
+    }
+
+    fn contains_key(&self, id: &SymbolId) -> bool {
+        self.owned.contains_key(id) || self.is_mapped_live(*id)
+    }
+
+    fn insert(&mut self, id: SymbolId, embedding: Vec<f32>) {
+        if self.is_mapped_live(id) {
+            self.mapped_live -= 1;
+        }
+        self.owned.insert(id, embedding);
+    }
+
+    fn remove(&mut self, id: SymbolId) {
+        if self.is_mapped_live(id) {
+            self.mapped_live -= 1;
+        }
+        self.owned.remove(&id);
+        if self.in_map(id) {
+            self.removed.insert(id);
+        }
+    }
+
+    fn clear(&mut self) {
+        *self = Self::default();
+    }
+
+    /// Embeddings at full precision: owned and mapped ones as stored,
+    /// quantized ones dequantized
+    fn iter(&self) -> impl Iterator<Item = (SymbolId, Cow<'_, [f32]>)> {
+        let dequantized = self
+            .iter_quantized()
+            .map(|(id, scale, codes)| (id, Cow::Owned(quantized::dequantize(scale, codes))));
+        self.iter_full()
+            .map(|(id, embedding)| (id, Cow::Borrowed(embedding)))
+            .chain(dequantized)
+    }
+
+    /// Owned and mapped float embeddings
+    fn iter_full(&self) -> impl Iterator<Item = (SymbolId, &[f32])> {
+        let owned = self
+            .owned
+            .iter()
+            .map(|(id, embedding)| (*id, embedding.as_slice()));
+        let mapped = self
+            .mapped
+            .iter()
+            .flat_map(|mapped| mapped.iter())
+            .filter_map(|(id, embedding)| {
+                let id = SymbolId::new(id.get())?;
+                (!self.is_overlaid(id)).then_some((id, embedding))
+            });
+        owned.chain(mapped)
+    }
+
+    /// Quantized embeddings not replaced or removed since loading
+    fn iter_quantized(&self) -> impl Iterator<Item = (SymbolId, f32, &[i8])> {
+        self.quantized
+            .iter()
+            .flat_map(|quantized| quantized.iter())
+            .filter(|(id, _, _)| !self.is_overlaid(*id))
+    }
+
+    fn is_overlaid(&self, id: SymbolId) -> bool {
+        self.owned.contains_key(&id) || self.removed.contains(&id)
+    }
+
+    /// The embedding of `id` at full precision, if stored
+    fn get(&self, id: SymbolId) -> Option<Cow<'_, [f32]>> {
+        if let Some(embedding) = self.owned.get(&id) {
+            return Some(Cow::Borrowed(embedding));
+        }
+        if self.removed.contains(&id) {
+            return None;
+        }
+        let mapped = self
+            .mapped
+            .as_ref()
+            .zip(VectorId::new(id.to_u32()))
+            .and_then(|(mapped, id)| mapped.get(id));
+        if let Some(embedding) = mapped {
+            return Some(Cow::Borrowed(embedding));
+        }
+        let (scale, codes) = self.quantized.as_ref()?.get(id)?;
+        Some(Cow::Owned(quantized::dequantize(scale, codes)))
+    }
+
+    /// Cosine similarity of `query` and the embedding of `id`, if stored;
+    /// quantized embeddings are scored against the full-precision query
+    fn similarity(&self, id: SymbolId, query: &[f32]) -> Option<f32> {
+        if let Some(embedding) = self.owned.get(&id) {
+            return Some(cosine_similarity(query, embedding));
+        }
+        if self.removed.contains(&id) {
+            return None;
+        }
+        let mapped = self
+            .mapped
+            .as_ref()
+            .zip(VectorId::new(id.to_u32()))
+            .and_then(|(mapped, id)| mapped.get(id));
+        if let Some(embedding) = mapped {
+            return Some(cosine_similarity(query, embedding));
+        }
+        let (_, codes) = self.quantized.as_ref()?.get(id)?;
+        Some(quantized::cosine_f32_i8(query, codes))
+    }
+}
+
+/// Embeddings of the body chunks of symbols after the first, which the
+/// code embedding holds, by chunk ID; see [`crate::semantic::chunking`]
+#[derive(Default)]
+struct ChunkStore {
+    vectors: EmbeddingStore,
+    /// The symbol of each chunk
+    owners: HashMap<SymbolId, SymbolId>,
+    /// The chunks of each symbol
+    by_symbol: HashMap<SymbolId, Vec<SymbolId>>,
+    /// Hash of the text of each chunk, see [`text_hash`]
+    hashes: HashMap<SymbolId, u64>,
+    /// ID of the next chunk stored
+    next: u32,
+}
+
+/// The chunks of a [`ChunkStore`] as `chunks/chunks.json` holds them
+#[derive(Debug, Default, serde::Serialize, serde::Deserialize)]
+struct StoredChunks {
+    owners: std::collections::BTreeMap<u32, u32>,
+    #[serde(default)]
+    hashes: std::collections::BTreeMap<u32, u64>,
+}
+
+impl ChunkStore {
+    fn is_empty(&self) -> bool {
+        self.owners.is_empty()
+    }
+
+    fn insert(&mut self, symbol_id: SymbolId, embedding: Vec<f32>, hash: u64) {
+        let Some(chunk) = SymbolId::new(self.next.max(1)) else {
+            return;
+        };
+        self.next = chunk.to_u32() + 1;
+        self.vectors.insert(chunk, embedding);
+        self.owners.insert(chunk, symbol_id);
+        self.by_symbol.entry(symbol_id).or_default().push(chunk);
+        self.hashes.insert(chunk, hash);
+    }
+
+    fn remove(&mut self, symbol_id: SymbolId) {
+        for chunk in self.by_symbol.remove(&symbol_id).unwrap_or_default() {
+            self.vectors.remove(chunk);
+            self.owners.remove(&chunk);
+            self.hashes.remove(&chunk);
+        }
+    }
+
+    /// The embeddings of the chunks of `symbol_id` with their text hashes
+    fn of(&self, symbol_id: SymbolId) -> impl Iterator<Item = (u64, Cow<'_, [f32]>)> {
+        self.by_symbol
+            .get(&symbol_id)
+            .into_iter()
+            .flatten()
+            .filter_map(|chunk| Some((*self.hashes.get(chunk)?, self.vectors.get(*chunk)?)))
+    }
+
+    /// The similarity of `query` and the best chunk of `symbol_id`
+    fn similarity(&self, symbol_id: SymbolId, query: &[f32]) -> Option<f32> {
+        self.by_symbol
+            .get(&symbol_id)?
+            .iter()
+            .filter_map(|chunk| self.vectors.similarity(*chunk, query))
+            .reduce(f32::max)
+    }
+
+    fn stored(&self) -> StoredChunks {
+        StoredChunks {
+            owners: self
+                .owners
+                .iter()
+                .map(|(chunk, symbol_id)| (chunk.to_u32(), symbol_id.to_u32()))
+                .collect(),
+            hashes: self
+                .hashes
+                .iter()
+                .map(|(chunk, hash)| (chunk.to_u32(), *hash))
+                .collect(),
+        }
+    }
+
+    fn from_stored(vectors: EmbeddingStore, stored: StoredChunks) -> Self {
+        let mut chunks = Self {
+            vectors,
+            next: stored.owners.keys().max().map_or(1, |last| last + 1),
+            ..Self::default()
+        };
+        for (chunk, symbol_id) in stored.owners {
+            let (Some(chunk), Some(symbol_id)) = (SymbolId::new(chunk), SymbolId::new(symbol_id))
+            else {
+                continue;
+            };
+            chunks.owners.insert(chunk, symbol_id);
+            chunks.by_symbol.entry(symbol_id).or_default().push(chunk);
+        }
+        chunks.hashes = stored
+            .hashes
+            .into_iter()
+            .filter_map(|(chunk, hash)| SymbolId::new(chunk).map(|id| (id, hash)))
+            .collect();
+        chunks
+    }
+}
+
 /// Advanced semantic search engine for documentation analysis
 ///
 /// This implementation uses state-of-the-art embeddings to find
@@ -48,26 +333,52 @@ pub enum SemanticSearchError {
 /// Updated: Final test - embedding cleanup working correctly!
 pub struct SimpleSemanticSearch {
     /// Embeddings indexed by symbol ID
-    embeddings: HashMap<SymbolId, Vec<f32>>,
+    embeddings: EmbeddingStore,
+
+    /// Embeddings of the code of symbols (kind, name and signature), with
+    /// `semantic_search.code_embeddings`
+    code: EmbeddingStore,
+
+    /// Further chunks of long bodies, with `semantic_search.code_chunks`
+    chunks: ChunkStore,
 
     /// Language mapping for each symbol (for language-filtered search)
     symbol_languages: HashMap<SymbolId, String>,
 
     /// The embedding model for query-time embedding (None in remote mode — caller
     /// must use `search_with_embedding` and provide the query vector externally).
-    model: Option<Mutex<TextEmbedding>>,
+    /// Loaded indexes of the same model share it, see [`shared_text_model`].
+    model: Option<Arc<Mutex<TextEmbedding>>>,
+
+    /// Query and passage prefixes of the local model, see `crate::vector::text_prefixes`
+    prefixes: Option<(&'static str, &'static str)>,
 
     /// Model dimensions for validation
     dimensions: usize,
 
     /// Metadata for tracking model info and timestamps
     metadata: Option<crate::semantic::SemanticMetadata>,
+
+    /// Format `save` writes: that of the loaded file
+    quantization: VectorQuantization,
+
+    /// Hash of the text embedded for each symbol, see [`text_hash`]
+    doc_hashes: HashMap<SymbolId, u64>,
+
+    /// Hash of the code text embedded for each symbol
+    code_hashes: HashMap<SymbolId, u64>,
+
+    /// Embeddings of removed symbols by code flag and text hash, for the
+    /// symbols re-indexed with the same text, see `retire_embeddings`
+    retired: HashMap<(bool, u64), Vec<f32>>,
 }
 
 impl std::fmt::Debug for SimpleSemanticSearch {
     fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
         f.debug_struct("SimpleSemanticSearch")
             .field("embeddings_count", &self.embeddings.len())
+            .field("code_embeddings_count", &self.code.len())
+            .field("chunk_embeddings_count", &self.chunks.owners.len())
             .field("dimensions", &self.dimensions)
             .field("model", &"<TextEmbedding>")
             .field("metadata", &self.metadata)
@@ -75,6 +386,56 @@ impl std::fmt::Debug for SimpleSemanticSearch {
     }
 }
 
+/// Hash of a text to embed, telling the texts of a re-indexed symbol
+/// that changed from those that did not
+pub fn text_hash(text: &str) -> u64 {
+    use sha2::{Digest, Sha256};
+    let digest = Sha256::digest(text.as_bytes());
+    let mut bytes = [0u8; 8];
+    bytes.copy_from_slice(&digest[..8]);
+    u64::from_le_bytes(bytes)
+}
+
+/// Text hashes as `text_hashes.json` holds them
+#[derive(Debug, Default, serde::Serialize, serde::Deserialize)]
+struct StoredTextHashes {
+    #[serde(default)]
+    doc: std::collections::BTreeMap<u32, u64>,
+    #[serde(default)]
+    code: std::collections::BTreeMap<u32, u64>,
+}
+
+/// Query-time models of the loaded indexes, by model name
+static TEXT_MODELS: LazyLock<Mutex<HashMap<String, Weak<Mutex<TextEmbedding>>>>> =
+    LazyLock::new(|| Mutex::new(HashMap::new()));
+
+/// The query-time model `model_name`, loaded unless an index loaded earlier
+/// still holds it. A server of several projects loads each model once.
+fn shared_text_model(
+    model: EmbeddingModel,
+    model_name: &str,
+) -> Result<Arc<Mutex<TextEmbedding>>, SemanticSearchError> {
+    let mut models = TEXT_MODELS.lock().unwrap_or_else(|e| e.into_inner());
+    if let Some(loaded) = models.get(model_name).and_then(Weak::upgrade) {
+        return Ok(loaded);
+    }
+
+    let text_model = TextEmbedding::try_new(
+        InitOptions::new(model)
+            .with_cache_dir(crate::init::model_cache_dir())
+            .with_show_download_progress(false),
+    )
+    .map_err(|e| {
+        SemanticSearchError::ModelInitError(format!(
+            "Failed to load model '{model_name}': {e}{}",
+            crate::init::model_path_hint(model_name)
+        ))
+    })?;
+    let text_model = Arc::new(Mutex::new(text_model));
+    models.insert(model_name.to_string(), Arc::downgrade(&text_model));
+    Ok(text_model)
+}
+
 impl SimpleSemanticSearch {
     /// Create a new semantic search instance using default model (AllMiniLML6V2).
     ///
@@ -107,7 +468,7 @@ impl SimpleSemanticSearch {
 
     /// Create with a specific model enum.
     pub fn with_model(model: EmbeddingModel) -> Result<Self, SemanticSearchError> {
-        let cache_dir = crate::init::models_dir();
+        let cache_dir = crate::init::model_cache_dir();
         let model_name = crate::vector::model_to_string(&model);
 
         // Check if models directory has any content (indicating cached models)
@@ -117,12 +478,13 @@ impl SimpleSemanticSearch {
                 .is_ok_and(|mut entries| entries.any(|_| true));
 
         // Inform user what's happening
-        if has_cached_models {
+        if has_cached_models || crate::init::model_path().is_some() {
             eprintln!("Loading embedding model '{model_name}' from cache...");
         } else {
             eprintln!("Downloading embedding model '{model_name}' (first time only)...");
         }
 
+        let prefixes = crate::vector::text_prefixes(&model);
         let mut text_model = TextEmbedding::try_new(
             InitOptions::new(model)
                 .with_cache_dir(cache_dir)
@@ -130,7 +492,8 @@ impl SimpleSemanticSearch {
         )
         .map_err(|e| {
             SemanticSearchError::ModelInitError(format!(
-                "Failed to initialize model '{model_name}': {e}"
+                "Failed to initialize model '{model_name}': {e}{}",
+                crate::init::model_path_hint(&model_name)
             ))
         })?;
 
@@ -148,11 +511,18 @@ impl SimpleSemanticSearch {
         );
 
         Ok(Self {
-            embeddings: HashMap::new(),
+            embeddings: EmbeddingStore::default(),
+            code: EmbeddingStore::default(),
+            chunks: ChunkStore::default(),
             symbol_languages: HashMap::new(),
-            model: Some(Mutex::new(text_model)),
+            prefixes,
+            model: Some(Arc::new(Mutex::new(text_model))),
             dimensions,
             metadata: Some(metadata),
+            quantization: VectorQuantization::None,
+            doc_hashes: HashMap::new(),
+            code_hashes: HashMap::new(),
+            retired: HashMap::new(),
         })
     }
 
@@ -174,6 +544,10 @@ impl SimpleSemanticSearch {
                     .to_string(),
             )
         })?;
+        let doc = match self.prefixes {
+            Some((_, passage)) => format!("{passage}{doc}"),
+            None => doc.to_string(),
+        };
         let embeddings = model
             .lock()
             .unwrap()
@@ -216,11 +590,24 @@ impl SimpleSemanticSearch {
 
     /// Store pre-generated embeddings produced by an `EmbeddingBackend`.
     pub fn store_embeddings(&mut self, items: Vec<(SymbolId, Vec<f32>, String)>) -> usize {
+        self.store(items, false)
+    }
+
+    /// Store pre-generated embeddings of the code of symbols.
+    pub fn store_code_embeddings(&mut self, items: Vec<(SymbolId, Vec<f32>, String)>) -> usize {
+        self.store(items, true)
+    }
+
+    fn store(&mut self, items: Vec<(SymbolId, Vec<f32>, String)>, code: bool) -> usize {
         let mut count = 0;
         let mut dropped = 0usize;
         for (symbol_id, embedding, language) in items {
             if embedding.len() == self.dimensions {
-                self.embeddings.insert(symbol_id, embedding);
+                if code {
+                    self.code.insert(symbol_id, embedding);
+                } else {
+                    self.embeddings.insert(symbol_id, embedding);
+                }
                 self.symbol_languages.insert(symbol_id, language);
                 count += 1;
             } else {
@@ -239,6 +626,23 @@ impl SimpleSemanticSearch {
         count
     }
 
+    /// Store the embeddings of body chunks after the first, as (symbol,
+    /// embedding, text hash), replacing the chunks the symbols had
+    pub fn store_chunk_embeddings(&mut self, items: Vec<(SymbolId, Vec<f32>, u64)>) -> usize {
+        let symbols: HashSet<SymbolId> = items.iter().map(|(id, _, _)| *id).collect();
+        for symbol_id in symbols {
+            self.chunks.remove(symbol_id);
+        }
+        let mut count = 0;
+        for (symbol_id, embedding, hash) in items {
+            if embedding.len() == self.dimensions {
+                self.chunks.insert(symbol_id, embedding, hash);
+                count += 1;
+            }
+        }
+        count
+    }
+
     /// Search using a pre-computed query embedding vector.
     ///
     /// Use this in remote-embedding mode where the caller obtains the query
@@ -260,20 +664,8 @@ impl SimpleSemanticSearch {
                 self.dimensions
             )));
         }
-        let mut similarities: Vec<(SymbolId, f32)> = self
-            .embeddings
-            .iter()
-            .filter_map(|(id, emb)| {
-                let sim = cosine_similarity(query_embedding, emb);
-                if sim >= threshold {
-                    Some((*id, sim))
-                } else {
-                    None
-                }
-            })
-            .collect();
-        similarities.sort_by(|a, b| b.1.partial_cmp(&a.1).unwrap());
-        similarities.truncate(limit);
+        let mut similarities = self.rank(query_embedding, limit, |_| true);
+        similarities.retain(|(_, sim)| *sim >= threshold);
         Ok(similarities)
     }
 
@@ -307,21 +699,7 @@ impl SimpleSemanticSearch {
                 self.dimensions
             )));
         }
-        let candidates: Vec<(&SymbolId, &Vec<f32>)> = if let Some(lang) = language {
-            self.embeddings
-                .iter()
-                .filter(|(id, _)| self.symbol_languages.get(id).is_some_and(|l| l == lang))
-                .collect()
-        } else {
-            self.embeddings.iter().collect()
-        };
-        let mut similarities: Vec<(SymbolId, f32)> = candidates
-            .into_iter()
-            .map(|(id, emb)| (*id, cosine_similarity(query_embedding, emb)))
-            .collect();
-        similarities.sort_by(|a, b| b.1.partial_cmp(&a.1).unwrap());
-        similarities.truncate(limit);
-        Ok(similarities)
+        Ok(self.rank(query_embedding, limit, |id| self.in_language(id, language)))
     }
 
     pub fn search(
@@ -333,36 +711,9 @@ impl SimpleSemanticSearch {
             return Err(SemanticSearchError::NoEmbeddings);
         }
 
-        let model = self.model.as_ref().ok_or_else(|| {
-            SemanticSearchError::ModelInitError(
-                "No local model available — use search_with_embedding() in remote mode".to_string(),
-            )
-        })?;
+        let query_embedding = self.embed_query(query)?;
 
-        // Generate query embedding
-        let query_embeddings = model
-            .lock()
-            .unwrap()
-            .embed(vec![query], None)
-            .map_err(|e| SemanticSearchError::EmbeddingError(e.to_string()))?;
-        let query_embedding = query_embeddings.into_iter().next().unwrap();
-
-        // Calculate similarities
-        let mut similarities: Vec<(SymbolId, f32)> = self
-            .embeddings
-            .iter()
-            .map(|(id, embedding)| {
-                let similarity = cosine_similarity(&query_embedding, embedding);
-                (*id, similarity)
-            })
-            .collect();
-
-        // Sort by similarity descending
-        similarities.sort_by(|a, b| b.1.partial_cmp(&a.1).unwrap());
-
-        // Return top results
-        similarities.truncate(limit);
-        Ok(similarities)
+        Ok(self.rank(&query_embedding, limit, |_| true))
     }
 
     /// Search for similar documentation with language filtering
@@ -379,49 +730,232 @@ impl SimpleSemanticSearch {
             return Err(SemanticSearchError::NoEmbeddings);
         }
 
+        let query_embedding = self.embed_query(query)?;
+
+        // Filter embeddings by language BEFORE computing similarity
+        Ok(self.rank(&query_embedding, limit, |id| self.in_language(id, language)))
+    }
+
+    /// Embed `query` with the local model
+    pub fn embed_query(&self, query: &str) -> Result<Vec<f32>, SemanticSearchError> {
         let model = self.model.as_ref().ok_or_else(|| {
             SemanticSearchError::ModelInitError(
                 "No local model available — use search_with_embedding() in remote mode".to_string(),
             )
         })?;
 
-        // Generate query embedding
+        let query = match self.prefixes {
+            Some((prefix, _)) => format!("{prefix}{query}"),
+            None => query.to_string(),
+        };
         let query_embeddings = model
             .lock()
             .unwrap()
             .embed(vec![query], None)
             .map_err(|e| SemanticSearchError::EmbeddingError(e.to_string()))?;
-        let query_embedding = query_embeddings.into_iter().next().unwrap();
+        Ok(query_embeddings.into_iter().next().unwrap())
+    }
 
-        // Filter embeddings by language BEFORE computing similarity
-        let filtered_embeddings: Vec<(&SymbolId, &Vec<f32>)> = if let Some(lang) = language {
-            self.embeddings
-                .iter()
-                .filter(|(id, _)| {
-                    self.symbol_languages
-                        .get(id)
-                        .is_some_and(|symbol_lang| symbol_lang == lang)
-                })
-                .collect()
-        } else {
-            self.embeddings.iter().collect()
+    /// Search the doc comment embeddings, the code embeddings or both with
+    /// a query vector, best first. A symbol with both embeddings scores
+    /// `(1 - code_weight) * doc + code_weight * code`; one with a single
+    /// embedding scores that one. Only symbols of `language` and, when
+    /// given, in `allowed` are ranked.
+    pub fn search_vectors(
+        &self,
+        query_embedding: &[f32],
+        limit: usize,
+        language: Option<&str>,
+        allowed: Option<&HashSet<SymbolId>>,
+        vectors: SemanticVectors,
+        code_weight: f32,
+    ) -> Result<Vec<(SymbolId, f32)>, SemanticSearchError> {
+        self.search_vectors_weighted(query_embedding, limit, language, allowed, vectors, |_| {
+            code_weight
+        })
+    }
+
+    /// [`Self::search_vectors`] with a code weight per symbol, as the
+    /// `[overrides]` of its file set it.
+    pub fn search_vectors_weighted(
+        &self,
+        query_embedding: &[f32],
+        limit: usize,
+        language: Option<&str>,
+        allowed: Option<&HashSet<SymbolId>>,
+        vectors: SemanticVectors,
+        code_weight: impl Fn(SymbolId) -> f32,
+    ) -> Result<Vec<(SymbolId, f32)>, SemanticSearchError> {
+        let (store, code) = match vectors {
+            SemanticVectors::Docs => (&self.embeddings, false),
+            SemanticVectors::Code => (&self.code, true),
+            SemanticVectors::Both if self.code.is_empty() => (&self.embeddings, false),
+            SemanticVectors::Both if self.embeddings.is_empty() => (&self.code, true),
+            SemanticVectors::Both => {
+                let keep =
+                    |id| self.in_language(id, language) && allowed.is_none_or(|a| a.contains(&id));
+                return Ok(self.blend(query_embedding, limit, keep, code_weight));
+            }
         };
+        if store.is_empty() {
+            return Err(SemanticSearchError::NoEmbeddings);
+        }
+        if query_embedding.len() != self.dimensions {
+            return Err(SemanticSearchError::EmbeddingError(format!(
+                "Query embedding dimension {} does not match index dimension {}",
+                query_embedding.len(),
+                self.dimensions
+            )));
+        }
+        let keep = |id| self.in_language(id, language) && allowed.is_none_or(|a| a.contains(&id));
+        Ok(if code {
+            self.rank_code(query_embedding, limit, keep)
+        } else {
+            Self::rank_in(store, query_embedding, limit, keep)
+        })
+    }
+
+    /// The best matches of either embedding set, scored on both
+    fn blend(
+        &self,
+        query: &[f32],
+        limit: usize,
+        keep: impl Fn(SymbolId) -> bool + Copy,
+        code_weight: impl Fn(SymbolId) -> f32,
+    ) -> Vec<(SymbolId, f32)> {
+        let candidates = limit.saturating_mul(quantized::RESCORE_FACTOR);
+        let ids: HashSet<SymbolId> = Self::rank_in(&self.embeddings, query, candidates, keep)
+            .into_iter()
+            .chain(self.rank_code(query, candidates, keep))
+            .map(|(id, _)| id)
+            .collect();
 
-        // Calculate similarities only for filtered embeddings
-        let mut similarities: Vec<(SymbolId, f32)> = filtered_embeddings
+        let mut blended: Vec<(SymbolId, f32)> = ids
             .into_iter()
-            .map(|(id, embedding)| {
-                let similarity = cosine_similarity(&query_embedding, embedding);
-                (*id, similarity)
+            .filter_map(|id| {
+                let score = match (
+                    self.embeddings.similarity(id, query),
+                    self.code_similarity(id, query),
+                ) {
+                    (Some(doc), Some(code)) => {
+                        let code_weight = code_weight(id).clamp(0.0, 1.0);
+                        (1.0 - code_weight) * doc + code_weight * code
+                    }
+                    (Some(score), None) | (None, Some(score)) => score,
+                    (None, None) => return None,
+                };
+                Some((id, score))
             })
             .collect();
+        blended.sort_by(|a, b| b.1.total_cmp(&a.1).then(a.0.value().cmp(&b.0.value())));
+        blended.truncate(limit);
+        blended
+    }
+
+    /// The code similarity of a symbol: that of its code embedding or, if
+    /// better, of its best body chunk
+    fn code_similarity(&self, id: SymbolId, query: &[f32]) -> Option<f32> {
+        let code = self.code.similarity(id, query);
+        match (code, self.chunks.similarity(id, query)) {
+            (Some(code), Some(chunk)) => Some(code.max(chunk)),
+            (code, chunk) => code.or(chunk),
+        }
+    }
+
+    /// The `limit` symbols whose code is most similar to `query`, scored by
+    /// [`Self::code_similarity`], among those `keep` accepts
+    fn rank_code(
+        &self,
+        query: &[f32],
+        limit: usize,
+        keep: impl Fn(SymbolId) -> bool + Copy,
+    ) -> Vec<(SymbolId, f32)> {
+        let ranked = Self::rank_in(&self.code, query, limit, keep);
+        if self.chunks.is_empty() {
+            return ranked;
+        }
+        // Several chunks of a symbol may rank, so more are taken
+        let owners = &self.chunks.owners;
+        let chunks = Self::rank_in(
+            &self.chunks.vectors,
+            query,
+            limit.saturating_mul(quantized::RESCORE_FACTOR),
+            |chunk| owners.get(&chunk).is_some_and(|id| keep(*id)),
+        );
+        let ids: HashSet<SymbolId> = ranked
+            .into_iter()
+            .map(|(id, _)| id)
+            .chain(
+                chunks
+                    .into_iter()
+                    .filter_map(|(chunk, _)| owners.get(&chunk).copied()),
+            )
+            .collect();
+        let mut scored: Vec<(SymbolId, f32)> = ids
+            .into_iter()
+            .filter_map(|id| Some((id, self.code_similarity(id, query)?)))
+            .collect();
+        scored.sort_by(|a, b| b.1.total_cmp(&a.1).then(a.0.value().cmp(&b.0.value())));
+        scored.truncate(limit);
+        scored
+    }
+
+    fn in_language(&self, id: SymbolId, language: Option<&str>) -> bool {
+        language.is_none_or(|lang| {
+            self.symbol_languages
+                .get(&id)
+                .is_some_and(|symbol_lang| symbol_lang == lang)
+        })
+    }
+
+    /// The `limit` embeddings most similar to `query` among those `keep`
+    /// accepts, best first. Quantized embeddings are first ranked on int8
+    /// similarity, and the best `RESCORE_FACTOR * limit` of them rescored
+    /// against the full-precision query.
+    fn rank(
+        &self,
+        query: &[f32],
+        limit: usize,
+        keep: impl Fn(SymbolId) -> bool,
+    ) -> Vec<(SymbolId, f32)> {
+        Self::rank_in(&self.embeddings, query, limit, keep)
+    }
+
+    fn rank_in(
+        store: &EmbeddingStore,
+        query: &[f32],
+        limit: usize,
+        keep: impl Fn(SymbolId) -> bool,
+    ) -> Vec<(SymbolId, f32)> {
+        let mut similarities: Vec<(SymbolId, f32)> = store
+            .iter_full()
+            .filter(|(id, _)| keep(*id))
+            .map(|(id, embedding)| (id, cosine_similarity(query, embedding)))
+            .collect();
+
+        if store.quantized.is_some() {
+            let (_, query_codes) = quantized::quantize(query);
+            let mut coarse: Vec<(SymbolId, f32, &[i8])> = store
+                .iter_quantized()
+                .filter(|(id, _, _)| keep(*id))
+                .map(|(id, _, codes)| (id, quantized::cosine_i8(&query_codes, codes), codes))
+                .collect();
+            let candidates = limit.saturating_mul(quantized::RESCORE_FACTOR);
+            if coarse.len() > candidates {
+                coarse.select_nth_unstable_by(candidates, |a, b| b.1.total_cmp(&a.1));
+                coarse.truncate(candidates);
+            }
+            similarities.extend(
+                coarse
+                    .into_iter()
+                    .map(|(id, _, codes)| (id, quantized::cosine_f32_i8(query, codes))),
+            );
+        }
 
         // Sort by similarity descending
         similarities.sort_by(|a, b| b.1.partial_cmp(&a.1).unwrap());
-
-        // Return top results
         similarities.truncate(limit);
-        Ok(similarities)
+        similarities
     }
 
     /// Search with a similarity threshold.
@@ -463,10 +997,65 @@ impl SimpleSemanticSearch {
         self.embeddings.len()
     }
 
+    /// Number of stored code embeddings
+    pub fn code_embedding_count(&self) -> usize {
+        self.code.len()
+    }
+
+    /// Number of stored body chunks after the first of each symbol
+    pub fn chunk_embedding_count(&self) -> usize {
+        self.chunks.owners.len()
+    }
+
+    /// Whether `symbol_id` has a stored embedding
+    pub fn has_embedding(&self, symbol_id: SymbolId) -> bool {
+        self.embeddings.contains_key(&symbol_id)
+    }
+
+    /// The documented symbols among `symbols` without a stored embedding,
+    /// as (id, doc comment, language) in ID order
+    pub fn missing_embeddings(
+        &self,
+        symbols: impl IntoIterator<Item = crate::Symbol>,
+    ) -> Vec<(SymbolId, String, String)> {
+        let mut missing: Vec<_> = symbols
+            .into_iter()
+            .filter(|symbol| !self.has_embedding(symbol.id))
+            .filter_map(|symbol| {
+                let doc = symbol.doc_comment?;
+                let language = symbol
+                    .language_id
+                    .map(|language| language.as_str().to_string())
+                    .unwrap_or_default();
+                Some((symbol.id, doc.to_string(), language))
+            })
+            .collect();
+        missing.sort_unstable_by_key(|(id, _, _)| id.to_u32());
+        missing
+    }
+
+    /// Every stored embedding with its symbol; quantized embeddings come
+    /// back dequantized
+    pub fn embeddings(&self) -> impl Iterator<Item = (SymbolId, Cow<'_, [f32]>)> {
+        self.embeddings.iter()
+    }
+
+    /// The stored embedding of the doc comment of `symbol_id` or, with
+    /// `code`, of its code
+    pub fn embedding_of(&self, symbol_id: SymbolId, code: bool) -> Option<Vec<f32>> {
+        let store = if code { &self.code } else { &self.embeddings };
+        store.get(symbol_id).map(Cow::into_owned)
+    }
+
     /// Clear all embeddings
     pub fn clear(&mut self) {
         self.embeddings.clear();
+        self.code.clear();
+        self.chunks = ChunkStore::default();
         self.symbol_languages.clear();
+        self.doc_hashes.clear();
+        self.code_hashes.clear();
+        self.retired.clear();
     }
 
     /// Remove embeddings for specific symbols
@@ -475,22 +1064,94 @@ impl SimpleSemanticSearch {
     /// that no longer exist.
     pub fn remove_embeddings(&mut self, symbol_ids: &[SymbolId]) {
         for id in symbol_ids {
-            self.embeddings.remove(id);
+            self.embeddings.remove(*id);
+            self.code.remove(*id);
+            self.chunks.remove(*id);
             self.symbol_languages.remove(id);
+            self.doc_hashes.remove(id);
+            self.code_hashes.remove(id);
+        }
+    }
+
+    /// Record the texts the stored embeddings of symbols were made of, doc
+    /// comments or, with `code`, code texts
+    pub fn record_texts<'a>(
+        &mut self,
+        texts: impl IntoIterator<Item = (SymbolId, &'a str)>,
+        code: bool,
+    ) {
+        let (store, hashes) = if code {
+            (&self.code, &mut self.code_hashes)
+        } else {
+            (&self.embeddings, &mut self.doc_hashes)
+        };
+        for (symbol_id, text) in texts {
+            if store.contains_key(&symbol_id) {
+                hashes.insert(symbol_id, text_hash(text));
+            }
         }
     }
 
+    /// Remove the embeddings of `symbol_ids` like `remove_embeddings`,
+    /// keeping those of recorded texts for [`Self::take_retired`]
+    ///
+    /// A file re-indexed gets new symbols; those whose doc comment or code
+    /// did not change take the embedding back instead of being embedded
+    /// again. Retired embeddings are not saved and stay until
+    /// [`Self::discard_retired`].
+    pub fn retire_embeddings(&mut self, symbol_ids: &[SymbolId]) {
+        for id in symbol_ids {
+            let stores = [
+                (false, &self.embeddings, &self.doc_hashes),
+                (true, &self.code, &self.code_hashes),
+            ];
+            for (code, store, hashes) in stores {
+                if let (Some(hash), Some(embedding)) = (hashes.get(id), store.get(*id)) {
+                    self.retired.insert((code, *hash), embedding.into_owned());
+                }
+            }
+            for (hash, embedding) in self.chunks.of(*id) {
+                self.retired.insert((true, hash), embedding.into_owned());
+            }
+        }
+        self.remove_embeddings(symbol_ids);
+    }
+
+    /// The retired embedding of `text`, a doc comment or, with `code`, a
+    /// code text
+    pub fn take_retired(&self, text: &str, code: bool) -> Option<Vec<f32>> {
+        if self.retired.is_empty() {
+            return None;
+        }
+        self.retired.get(&(code, text_hash(text))).cloned()
+    }
+
+    /// Drop the retired embeddings no re-indexed symbol took
+    pub fn discard_retired(&mut self) {
+        self.retired = HashMap::new();
+    }
+
     /// Get the metadata if available
     pub fn metadata(&self) -> Option<&crate::semantic::SemanticMetadata> {
         self.metadata.as_ref()
     }
 
-    /// Save embeddings to disk using the efficient vector storage
+    /// Save embeddings to disk using the efficient vector storage, in the
+    /// format they were loaded from
     ///
     /// # Arguments
     /// * `path` - Path where semantic data should be stored
     pub fn save(&self, path: &Path) -> Result<(), SemanticSearchError> {
-        use crate::semantic::{SemanticMetadata, SemanticVectorStorage};
+        self.save_with_quantization(path, self.quantization)
+    }
+
+    /// Save embeddings to disk, as float32 vectors or quantized
+    pub fn save_with_quantization(
+        &self,
+        path: &Path,
+        quantization: VectorQuantization,
+    ) -> Result<(), SemanticSearchError> {
+        use crate::semantic::SemanticMetadata;
         use crate::vector::VectorDimension;
 
         // Ensure the directory exists
@@ -520,6 +1181,102 @@ impl SimpleSemanticSearch {
             }
         })?;
 
+        Self::write_store(path, dimension, quantization, &self.embeddings)?;
+
+        // Code embeddings live in a directory of their own, in the same format
+        let code_path = path.join("code");
+        if !self.code.is_empty() {
+            Self::write_store(&code_path, dimension, quantization, &self.code)?;
+        } else if code_path.exists() {
+            std::fs::remove_dir_all(&code_path).map_err(|e| SemanticSearchError::StorageError {
+                message: format!("Failed to remove {}: {e}", code_path.display()),
+                suggestion: "Check directory permissions".to_string(),
+            })?;
+        }
+
+        // Body chunks too, with the symbol and text hash of each
+        let chunks_path = path.join("chunks");
+        if !self.chunks.is_empty() {
+            Self::write_store(&chunks_path, dimension, quantization, &self.chunks.vectors)?;
+            let chunks_json = serde_json::to_string(&self.chunks.stored()).map_err(|e| {
+                SemanticSearchError::StorageError {
+                    message: format!("Failed to serialize body chunks: {e}"),
+                    suggestion: "This is likely a bug in the code".to_string(),
+                }
+            })?;
+            Self::write_json(&chunks_path.join("chunks.json"), chunks_json, "body chunks")?;
+        } else if chunks_path.exists() {
+            std::fs::remove_dir_all(&chunks_path).map_err(|e| {
+                SemanticSearchError::StorageError {
+                    message: format!("Failed to remove {}: {e}", chunks_path.display()),
+                    suggestion: "Check directory permissions".to_string(),
+                }
+            })?;
+        }
+
+        // Metadata is written only after the vector file is in place, so it
+        // never claims embeddings that are not durably on disk.
+        metadata.save(path)?;
+
+        // Save language mappings as a JSON file (convert SymbolId to u32 for serialization)
+        let languages_path = path.join("languages.json");
+        let languages_map: std::collections::BTreeMap<u32, String> = self
+            .symbol_languages
+            .iter()
+            .map(|(id, lang)| (id.to_u32(), lang.clone()))
+            .collect();
+        let languages_json = serde_json::to_string(&languages_map).map_err(|e| {
+            SemanticSearchError::StorageError {
+                message: format!("Failed to serialize language mappings: {e}"),
+                suggestion: "This is likely a bug in the code".to_string(),
+            }
+        })?;
+        Self::write_json(&languages_path, languages_json, "language mappings")?;
+
+        // Hashes of the embedded texts, telling unchanged symbols of a
+        // re-indexed file (see `retire_embeddings`)
+        let to_stored = |hashes: &HashMap<SymbolId, u64>| {
+            hashes
+                .iter()
+                .map(|(id, hash)| (id.to_u32(), *hash))
+                .collect()
+        };
+        let hashes = StoredTextHashes {
+            doc: to_stored(&self.doc_hashes),
+            code: to_stored(&self.code_hashes),
+        };
+        let hashes_json =
+            serde_json::to_string(&hashes).map_err(|e| SemanticSearchError::StorageError {
+                message: format!("Failed to serialize text hashes: {e}"),
+                suggestion: "This is likely a bug in the code".to_string(),
+            })?;
+        Self::write_json(&path.join("text_hashes.json"), hashes_json, "text hashes")?;
+
+        Ok(())
+    }
+
+    /// Write `json` to the file at `path` through a temporary file, so a
+    /// crash leaves the previous one; `what` names it in errors
+    fn write_json(path: &Path, json: String, what: &str) -> Result<(), SemanticSearchError> {
+        let tmp = path.with_extension("json.tmp");
+        std::fs::write(&tmp, json).map_err(|e| SemanticSearchError::StorageError {
+            message: format!("Failed to write {what}: {e}"),
+            suggestion: "Check disk space and file permissions".to_string(),
+        })?;
+        std::fs::rename(&tmp, path).map_err(|e| SemanticSearchError::StorageError {
+            message: format!("Failed to swap {what} into place: {e}"),
+            suggestion: "Check directory permissions".to_string(),
+        })
+    }
+
+    /// Write the embeddings of `store` to `path` in the format of
+    /// `quantization`, replacing the file of the other format
+    fn write_store(
+        path: &Path,
+        dimension: crate::vector::VectorDimension,
+        quantization: VectorQuantization,
+        store: &EmbeddingStore,
+    ) -> Result<(), SemanticSearchError> {
         // Stage the vector file, then rename over the live one: a crash at
         // any point leaves the previous generation loadable instead of the
         // delete-then-rewrite window destroying all persisted embeddings.
@@ -537,69 +1294,71 @@ impl SimpleSemanticSearch {
             suggestion: "Check directory permissions".to_string(),
         })?;
 
-        let mut storage = SemanticVectorStorage::new(&staging_dir, dimension)?;
-
-        // Convert HashMap to Vec for batch save
-        let embeddings: Vec<(SymbolId, Vec<f32>)> = self
-            .embeddings
+        // Convert to Vec for batch save, in ID order so the same
+        // embeddings always make the same file
+        let mut embeddings: Vec<(SymbolId, Vec<f32>)> = store
             .iter()
-            .map(|(id, embedding)| (*id, embedding.clone()))
+            .map(|(id, embedding)| (id, embedding.into_owned()))
             .collect();
+        embeddings.sort_unstable_by_key(|(id, _)| id.to_u32());
+
+        // Only one format is kept; the other file goes once the new one is
+        // in place
+        let (live, other) = match quantization {
+            VectorQuantization::Int8 => {
+                (quantized::quantized_path(path), path.join("segment_0.vec"))
+            }
+            VectorQuantization::None => {
+                (path.join("segment_0.vec"), quantized::quantized_path(path))
+            }
+        };
+        Self::stage_embeddings(&staging_dir, dimension, quantization, &embeddings)?;
+        let staged = staging_dir.join(live.file_name().expect("vector files have a name"));
+        std::fs::rename(&staged, &live).map_err(|e| SemanticSearchError::StorageError {
+            message: format!("Failed to swap vector file into place: {e}"),
+            suggestion: "Check directory permissions".to_string(),
+        })?;
+        let _ = std::fs::remove_dir_all(&staging_dir);
+        if other.exists() {
+            std::fs::remove_file(&other).map_err(|e| SemanticSearchError::StorageError {
+                message: format!("Failed to remove {}: {e}", other.display()),
+                suggestion: "Check directory permissions".to_string(),
+            })?;
+        }
+        Ok(())
+    }
+
+    /// Write `embeddings` to `staging_dir` in the format of `quantization`,
+    /// synced to disk
+    fn stage_embeddings(
+        staging_dir: &Path,
+        dimension: crate::vector::VectorDimension,
+        quantization: VectorQuantization,
+        embeddings: &[(SymbolId, Vec<f32>)],
+    ) -> Result<(), SemanticSearchError> {
+        if quantization == VectorQuantization::Int8 {
+            return QuantizedVectors::write(
+                &quantized::quantized_path(staging_dir),
+                dimension.get(),
+                embeddings
+                    .iter()
+                    .map(|(id, embedding)| (*id, embedding.as_slice())),
+            );
+        }
 
-        // Save all embeddings
-        storage.save_batch(&embeddings)?;
+        let mut storage = crate::semantic::SemanticVectorStorage::new(staging_dir, dimension)?;
+        storage.save_batch(embeddings)?;
         drop(storage);
 
-        let staged_vec = staging_dir.join("segment_0.vec");
         // write(true): Windows FlushFileBuffers requires GENERIC_WRITE
         std::fs::OpenOptions::new()
             .write(true)
-            .open(&staged_vec)
+            .open(staging_dir.join("segment_0.vec"))
             .and_then(|f| f.sync_all())
             .map_err(|e| SemanticSearchError::StorageError {
                 message: format!("Failed to sync staged vector file: {e}"),
                 suggestion: "Check disk space and staged-file write access".to_string(),
-            })?;
-        std::fs::rename(&staged_vec, path.join("segment_0.vec")).map_err(|e| {
-            SemanticSearchError::StorageError {
-                message: format!("Failed to swap vector file into place: {e}"),
-                suggestion: "Check directory permissions".to_string(),
-            }
-        })?;
-        let _ = std::fs::remove_dir_all(&staging_dir);
-
-        // Metadata is written only after the vector file is in place, so it
-        // never claims embeddings that are not durably on disk.
-        metadata.save(path)?;
-
-        // Save language mappings as a JSON file (convert SymbolId to u32 for serialization)
-        let languages_path = path.join("languages.json");
-        let languages_map: HashMap<u32, String> = self
-            .symbol_languages
-            .iter()
-            .map(|(id, lang)| (id.to_u32(), lang.clone()))
-            .collect();
-        let languages_json = serde_json::to_string(&languages_map).map_err(|e| {
-            SemanticSearchError::StorageError {
-                message: format!("Failed to serialize language mappings: {e}"),
-                suggestion: "This is likely a bug in the code".to_string(),
-            }
-        })?;
-        let languages_tmp = path.join("languages.json.tmp");
-        std::fs::write(&languages_tmp, languages_json).map_err(|e| {
-            SemanticSearchError::StorageError {
-                message: format!("Failed to write language mappings: {e}"),
-                suggestion: "Check disk space and file permissions".to_string(),
-            }
-        })?;
-        std::fs::rename(&languages_tmp, &languages_path).map_err(|e| {
-            SemanticSearchError::StorageError {
-                message: format!("Failed to swap language mappings into place: {e}"),
-                suggestion: "Check directory permissions".to_string(),
-            }
-        })?;
-
-        Ok(())
+            })
     }
 
     /// Create an empty semantic search instance for remote-embedding mode.
@@ -611,11 +1370,18 @@ impl SimpleSemanticSearch {
         let metadata =
             crate::semantic::SemanticMetadata::new_remote(model_name.to_string(), dimensions, 0);
         Self {
-            embeddings: HashMap::new(),
+            embeddings: EmbeddingStore::default(),
+            code: EmbeddingStore::default(),
+            chunks: ChunkStore::default(),
             symbol_languages: HashMap::new(),
             model: None,
+            prefixes: None,
             dimensions,
             metadata: Some(metadata),
+            quantization: VectorQuantization::None,
+            doc_hashes: HashMap::new(),
+            code_hashes: HashMap::new(),
+            retired: HashMap::new(),
         }
     }
 
@@ -646,48 +1412,168 @@ impl SimpleSemanticSearch {
             .collect())
     }
 
+    /// Load the hashes of the embedded doc comments and code texts from
+    /// `text_hashes.json`; an index saved before they were kept has none,
+    /// and its symbols are embedded again when their file changes
+    fn load_text_hashes(
+        path: &Path,
+    ) -> Result<(HashMap<SymbolId, u64>, HashMap<SymbolId, u64>), SemanticSearchError> {
+        let hashes_path = path.join("text_hashes.json");
+        if !hashes_path.exists() {
+            return Ok((HashMap::new(), HashMap::new()));
+        }
+        let hashes_json = std::fs::read_to_string(&hashes_path).map_err(|e| {
+            SemanticSearchError::StorageError {
+                message: format!("Failed to read text hashes: {e}"),
+                suggestion: "Text hashes file may be corrupted".to_string(),
+            }
+        })?;
+        let hashes: StoredTextHashes =
+            serde_json::from_str(&hashes_json).map_err(|e| SemanticSearchError::StorageError {
+                message: format!("Failed to parse text hashes: {e}"),
+                suggestion: "Try rebuilding the semantic index".to_string(),
+            })?;
+        let from_stored = |hashes: std::collections::BTreeMap<u32, u64>| {
+            hashes
+                .into_iter()
+                .filter_map(|(id, hash)| SymbolId::new(id).map(|sid| (sid, hash)))
+                .collect()
+        };
+        Ok((from_stored(hashes.doc), from_stored(hashes.code)))
+    }
+
+    /// Map the stored embeddings for reading in place, or read them into
+    /// memory where the platform cannot map them. Returns them with their
+    /// dimension and format.
+    fn load_embeddings(
+        path: &Path,
+    ) -> Result<(EmbeddingStore, usize, VectorQuantization), SemanticSearchError> {
+        // A save that changed format and crashed before removing the old
+        // file leaves both; the newer one is the last save
+        let q8_path = quantized::quantized_path(path);
+        let modified = |path: &Path| std::fs::metadata(path).and_then(|m| m.modified()).ok();
+        if q8_path.exists() && modified(&q8_path) >= modified(&path.join("segment_0.vec")) {
+            let quantized = QuantizedVectors::open(&q8_path)?;
+            let dimension = quantized.dimension();
+            return Ok((
+                EmbeddingStore::quantized(quantized),
+                dimension,
+                VectorQuantization::Int8,
+            ));
+        }
+
+        let mut storage = crate::semantic::SemanticVectorStorage::open(path)?;
+        let dimension = storage.dimension().get();
+        let embeddings = match crate::semantic::SemanticVectorStorage::map(path)? {
+            Some(mapped) => EmbeddingStore::mapped(mapped),
+            None => EmbeddingStore::from_vectors(storage.load_all()?),
+        };
+        Ok((embeddings, dimension, VectorQuantization::None))
+    }
+
+    /// Load the code embeddings saved next to `path`'s, if any, checking
+    /// they have `dimension` values
+    fn load_code_embeddings(
+        path: &Path,
+        dimension: usize,
+    ) -> Result<EmbeddingStore, SemanticSearchError> {
+        let code_path = path.join("code");
+        if !code_path.exists() {
+            return Ok(EmbeddingStore::default());
+        }
+        Self::load_embeddings_of(&code_path, dimension, "Code embeddings")
+    }
+
+    /// Load the body chunks saved next to `path`'s embeddings, if any
+    fn load_chunk_embeddings(
+        path: &Path,
+        dimension: usize,
+    ) -> Result<ChunkStore, SemanticSearchError> {
+        let chunks_path = path.join("chunks");
+        let stored_path = chunks_path.join("chunks.json");
+        if !stored_path.exists() {
+            return Ok(ChunkStore::default());
+        }
+        let vectors = Self::load_embeddings_of(&chunks_path, dimension, "Body chunks")?;
+        let chunks_json = std::fs::read_to_string(&stored_path).map_err(|e| {
+            SemanticSearchError::StorageError {
+                message: format!("Failed to read body chunks: {e}"),
+                suggestion: "Body chunks file may be corrupted".to_string(),
+            }
+        })?;
+        let stored: StoredChunks =
+            serde_json::from_str(&chunks_json).map_err(|e| SemanticSearchError::StorageError {
+                message: format!("Failed to parse body chunks: {e}"),
+                suggestion: "Try rebuilding the semantic index".to_string(),
+            })?;
+        Ok(ChunkStore::from_stored(vectors, stored))
+    }
+
+    /// Load the embeddings in `dir`, named `what` in errors, checking they
+    /// have `dimension` values
+    fn load_embeddings_of(
+        dir: &Path,
+        dimension: usize,
+        what: &str,
+    ) -> Result<EmbeddingStore, SemanticSearchError> {
+        let (store, stored_dimension, _) = Self::load_embeddings(dir)?;
+        if stored_dimension != dimension {
+            return Err(SemanticSearchError::DimensionMismatch {
+                expected: dimension,
+                actual: stored_dimension,
+                suggestion: format!(
+                    "{what} were saved with another model. Re-index with: codanna index <path> --force"
+                ),
+            });
+        }
+        Ok(store)
+    }
+
     /// Load an existing semantic index without initialising a local embedding model.
     ///
     /// Used in remote-embedding mode: stored vectors are loaded for similarity
     /// search but query embedding is handled externally via `search_with_embedding`.
     pub fn load_remote(path: &Path) -> Result<Self, SemanticSearchError> {
-        use crate::semantic::{SemanticMetadata, SemanticVectorStorage};
+        use crate::semantic::SemanticMetadata;
 
         let metadata = SemanticMetadata::load(path)?;
-        let mut storage = SemanticVectorStorage::open(path)?;
+        let (embeddings, dimension, quantization) = Self::load_embeddings(path)?;
 
         // Verify storage dimension matches metadata to catch corrupted indexes.
-        if storage.dimension().get() != metadata.dimension {
+        if dimension != metadata.dimension {
             return Err(SemanticSearchError::DimensionMismatch {
                 expected: metadata.dimension,
-                actual: storage.dimension().get(),
+                actual: dimension,
                 suggestion: format!(
                     "Remote index was built with {}-dimensional embeddings but storage has {}. Re-index with: codanna index <path> --force",
-                    metadata.dimension,
-                    storage.dimension().get()
+                    metadata.dimension, dimension
                 ),
             });
         }
 
-        let embeddings_vec = storage.load_all()?;
-        let mut embeddings = HashMap::with_capacity(embeddings_vec.len());
-        for (id, embedding) in embeddings_vec {
-            embeddings.insert(id, embedding);
-        }
-
+        let code = Self::load_code_embeddings(path, dimension)?;
+        let chunks = Self::load_chunk_embeddings(path, dimension)?;
         let symbol_languages = Self::load_symbol_languages(path)?;
+        let (doc_hashes, code_hashes) = Self::load_text_hashes(path)?;
 
         Ok(Self {
             embeddings,
+            code,
+            chunks,
             symbol_languages,
             model: None,
+            prefixes: None,
             dimensions: metadata.dimension,
             metadata: Some(metadata),
+            quantization,
+            doc_hashes,
+            code_hashes,
+            retired: HashMap::new(),
         })
     }
 
     pub fn load(path: &Path) -> Result<Self, SemanticSearchError> {
-        use crate::semantic::{SemanticMetadata, SemanticVectorStorage};
+        use crate::semantic::SemanticMetadata;
 
         // Load metadata first
         let metadata = SemanticMetadata::load(path)?;
@@ -710,65 +1596,56 @@ impl SimpleSemanticSearch {
             })?;
 
         // Open existing storage
-        let mut storage = SemanticVectorStorage::open(path)?;
+        let (embeddings, dimension, quantization) = Self::load_embeddings(path)?;
 
         // Verify dimension matches
-        if storage.dimension().get() != metadata.dimension {
+        if dimension != metadata.dimension {
             return Err(SemanticSearchError::DimensionMismatch {
                 expected: metadata.dimension,
-                actual: storage.dimension().get(),
+                actual: dimension,
                 suggestion: format!(
-                    "Index was created with a {}-dimension model. Re-index with: codanna index <path> --force",
-                    storage.dimension().get()
+                    "Index was created with a {dimension}-dimension model. Re-index with: codanna index <path> --force"
                 ),
             });
         }
 
-        // Load all embeddings
-        let embeddings_vec = storage.load_all()?;
-
         // Verify count matches metadata
-        if embeddings_vec.len() != metadata.embedding_count {
+        if embeddings.len() != metadata.embedding_count {
             eprintln!(
                 "WARNING: Expected {} embeddings but found {}",
                 metadata.embedding_count,
-                embeddings_vec.len()
+                embeddings.len()
             );
         }
 
-        // Convert to HashMap
-        let mut embeddings = HashMap::with_capacity(embeddings_vec.len());
-        for (id, embedding) in embeddings_vec {
-            embeddings.insert(id, embedding);
-        }
-
         // Create new instance with model from metadata
-        let text_model = TextEmbedding::try_new(
-            InitOptions::new(model)
-                .with_cache_dir(crate::init::models_dir())
-                .with_show_download_progress(false),
-        )
-        .map_err(|e| {
-            SemanticSearchError::ModelInitError(format!(
-                "Failed to load model '{}': {}",
-                metadata.model_name, e
-            ))
-        })?;
+        let prefixes = crate::vector::text_prefixes(&model);
+        let text_model = shared_text_model(model, &metadata.model_name)?;
 
+        let code = Self::load_code_embeddings(path, dimension)?;
+        let chunks = Self::load_chunk_embeddings(path, dimension)?;
         let symbol_languages = Self::load_symbol_languages(path)?;
+        let (doc_hashes, code_hashes) = Self::load_text_hashes(path)?;
 
         Ok(Self {
             embeddings,
+            code,
+            chunks,
             symbol_languages,
-            model: Some(Mutex::new(text_model)),
+            prefixes,
+            model: Some(text_model),
             dimensions: metadata.dimension,
             metadata: Some(metadata),
+            quantization,
+            doc_hashes,
+            code_hashes,
+            retired: HashMap::new(),
         })
     }
 }
 
 /// Calculate cosine similarity between two vectors
-fn cosine_similarity(a: &[f32], b: &[f32]) -> f32 {
+pub fn cosine_similarity(a: &[f32], b: &[f32]) -> f32 {
     let dot_product: f32 = a.iter().zip(b.iter()).map(|(x, y)| x * y).sum();
     let magnitude_a: f32 = a.iter().map(|x| x * x).sum::<f32>().sqrt();
     let magnitude_b: f32 = b.iter().map(|x| x * x).sum::<f32>().sqrt();
@@ -813,6 +1690,281 @@ mod tests {
         assert_eq!(reloaded.embedding_count(), 2);
     }
 
+    #[test]
+    fn test_loaded_embeddings_stay_mapped_under_changes() {
+        let dir = tempfile::tempdir().unwrap();
+        let ids: Vec<SymbolId> = (1..=3).map(|i| SymbolId::new(i).unwrap()).collect();
+        let mut search = SimpleSemanticSearch::new_empty(2, "remote-model");
+        search.store_embeddings(vec![
+            (ids[0], vec![1.0, 0.0], "rust".to_string()),
+            (ids[1], vec![0.0, 1.0], "rust".to_string()),
+        ]);
+        search.save(dir.path()).unwrap();
+
+        let mut loaded = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        assert_eq!(loaded.embedding_count(), 2);
+        let top = loaded.search_with_embedding(&[1.0, 0.0], 1, 0.0).unwrap();
+        assert_eq!(top[0].0, ids[0]);
+
+        // Replacing, adding and removing lay over the mapped file
+        loaded.store_embeddings(vec![
+            (ids[0], vec![0.0, 1.0], "rust".to_string()),
+            (ids[2], vec![1.0, 1.0], "rust".to_string()),
+        ]);
+        loaded.remove_embeddings(&[ids[1]]);
+        assert_eq!(loaded.embedding_count(), 2);
+        let mut stored: Vec<_> = loaded
+            .embeddings()
+            .map(|(id, e)| (id, e.to_vec()))
+            .collect();
+        stored.sort_by_key(|(id, _)| id.to_u32());
+        assert_eq!(
+            stored,
+            vec![(ids[0], vec![0.0, 1.0]), (ids[2], vec![1.0, 1.0])]
+        );
+
+        // Saving over a mapped file keeps what the map reads valid
+        loaded.save(dir.path()).unwrap();
+        assert_eq!(loaded.embedding_count(), 2);
+        let reloaded = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        assert_eq!(reloaded.embedding_count(), 2);
+        loaded.remove_embeddings(&[ids[0]]);
+        loaded.store_embeddings(vec![(ids[0], vec![1.0, 0.0], "rust".to_string())]);
+        loaded.remove_embeddings(&[ids[0]]);
+        assert_eq!(loaded.embedding_count(), 1);
+    }
+
+    #[test]
+    fn test_retired_embeddings_of_unchanged_texts() {
+        let dir = tempfile::tempdir().unwrap();
+        let ids: Vec<SymbolId> = (1..=4).map(|i| SymbolId::new(i).unwrap()).collect();
+        let mut search = SimpleSemanticSearch::new_empty(2, "remote-model");
+        search.store_embeddings(vec![
+            (ids[0], vec![1.0, 0.0], "rust".to_string()),
+            (ids[1], vec![0.0, 1.0], "rust".to_string()),
+        ]);
+        search.store_code_embeddings(vec![(ids[0], vec![0.5, 0.5], "rust".to_string())]);
+        search.record_texts(
+            [(ids[0], "Parses a file."), (ids[1], "Opens a file.")],
+            false,
+        );
+        search.record_texts([(ids[0], "function parse"), (ids[3], "never stored")], true);
+        search.save(dir.path()).unwrap();
+
+        // The hashes are saved with the embeddings
+        let mut loaded = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        loaded.retire_embeddings(&ids[..2]);
+        assert_eq!(loaded.embedding_count(), 0);
+        assert_eq!(loaded.code_embedding_count(), 0);
+
+        assert_eq!(
+            loaded.take_retired("Parses a file.", false),
+            Some(vec![1.0, 0.0])
+        );
+        assert_eq!(
+            loaded.take_retired("function parse", true),
+            Some(vec![0.5, 0.5])
+        );
+        assert_eq!(loaded.take_retired("function parse", false), None);
+        assert_eq!(loaded.take_retired("Parses a file!", false), None);
+        assert_eq!(loaded.take_retired("never stored", true), None);
+
+        // Retired embeddings are not saved
+        loaded.save(dir.path()).unwrap();
+        let reloaded = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        assert_eq!(reloaded.take_retired("Opens a file.", false), None);
+
+        loaded.discard_retired();
+        assert_eq!(loaded.take_retired("Opens a file.", false), None);
+    }
+
+    #[test]
+    fn test_quantized_embeddings_round_trip() {
+        let dir = tempfile::tempdir().unwrap();
+        let ids: Vec<SymbolId> = (1..=3).map(|i| SymbolId::new(i).unwrap()).collect();
+        let mut search = SimpleSemanticSearch::new_empty(3, "remote-model");
+        search.store_embeddings(vec![
+            (ids[0], vec![1.0, 0.0, 0.0], "rust".to_string()),
+            (ids[1], vec![0.6, 0.8, 0.0], "rust".to_string()),
+            (ids[2], vec![0.0, 0.0, 1.0], "python".to_string()),
+        ]);
+        search
+            .save_with_quantization(dir.path(), VectorQuantization::Int8)
+            .unwrap();
+        assert!(quantized::quantized_path(dir.path()).exists());
+        assert!(!dir.path().join("segment_0.vec").exists());
+
+        let mut loaded = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        assert_eq!(loaded.embedding_count(), 3);
+        let top = loaded
+            .search_with_embedding(&[0.7, 0.7, 0.0], 3, 0.0)
+            .unwrap();
+        assert_eq!(top[0].0, ids[1]);
+        assert!((top[0].1 - cosine_similarity(&[0.7, 0.7, 0.0], &[0.6, 0.8, 0.0])).abs() < 0.01);
+        let python = loaded
+            .search_with_embedding_and_language(&[0.7, 0.7, 0.0], 3, Some("python"))
+            .unwrap();
+        assert_eq!(python.len(), 1);
+
+        // Changes lay over the quantized file, and saves keep its format
+        loaded.store_embeddings(vec![(ids[2], vec![1.0, 0.0, 0.0], "rust".to_string())]);
+        loaded.remove_embeddings(&[ids[0]]);
+        loaded.save(dir.path()).unwrap();
+        let reloaded = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        assert_eq!(reloaded.embedding_count(), 2);
+        let top = reloaded
+            .search_with_embedding(&[1.0, 0.0, 0.0], 1, 0.0)
+            .unwrap();
+        assert_eq!(top[0].0, ids[2]);
+
+        reloaded
+            .save_with_quantization(dir.path(), VectorQuantization::None)
+            .unwrap();
+        assert!(!quantized::quantized_path(dir.path()).exists());
+        assert_eq!(
+            SimpleSemanticSearch::load_remote(dir.path())
+                .unwrap()
+                .embedding_count(),
+            2
+        );
+    }
+
+    #[test]
+    fn test_code_embeddings_searched_apart_and_blended() {
+        let dir = tempfile::tempdir().unwrap();
+        let ids: Vec<SymbolId> = (1..=3).map(|i| SymbolId::new(i).unwrap()).collect();
+        let mut search = SimpleSemanticSearch::new_empty(3, "remote-model");
+        search.store_embeddings(vec![
+            (ids[0], vec![1.0, 0.0, 0.0], "rust".to_string()),
+            (ids[1], vec![0.0, 1.0, 0.0], "rust".to_string()),
+        ]);
+        search.store_code_embeddings(vec![
+            (ids[1], vec![1.0, 0.0, 0.0], "rust".to_string()),
+            (ids[2], vec![0.8, 0.6, 0.0], "rust".to_string()),
+        ]);
+        let query = [1.0, 0.0, 0.0];
+        let ranked = |search: &SimpleSemanticSearch, vectors| -> Vec<SymbolId> {
+            search
+                .search_vectors(&query, 3, None, None, vectors, 0.5)
+                .unwrap()
+                .into_iter()
+                .map(|(id, _)| id)
+                .collect()
+        };
+
+        assert_eq!(ranked(&search, SemanticVectors::Docs), vec![ids[0], ids[1]]);
+        assert_eq!(ranked(&search, SemanticVectors::Code), vec![ids[1], ids[2]]);
+        // Symbol 2 averages a poor doc match with a perfect code match;
+        // undocumented symbol 3 keeps its code similarity
+        assert_eq!(
+            ranked(&search, SemanticVectors::Both),
+            vec![ids[0], ids[2], ids[1]]
+        );
+        // Symbols outside the allowed set are not ranked at all
+        let allowed = HashSet::from([ids[1]]);
+        let filtered = search
+            .search_vectors(&query, 1, None, Some(&allowed), SemanticVectors::Both, 0.5)
+            .unwrap();
+        assert_eq!(filtered, vec![(ids[1], 0.5)]);
+
+        search.save(dir.path()).unwrap();
+        assert!(dir.path().join("code").join("segment_0.vec").exists());
+        let mut loaded = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        assert_eq!(loaded.embedding_count(), 2);
+        assert_eq!(loaded.code_embedding_count(), 2);
+        assert_eq!(
+            ranked(&loaded, SemanticVectors::Both),
+            vec![ids[0], ids[2], ids[1]]
+        );
+
+        loaded.remove_embeddings(&ids[1..]);
+        assert_eq!(loaded.code_embedding_count(), 0);
+        loaded.save(dir.path()).unwrap();
+        assert!(!dir.path().join("code").exists());
+    }
+
+    #[test]
+    fn test_body_chunks_score_their_symbol() {
+        let dir = tempfile::tempdir().unwrap();
+        let ids: Vec<SymbolId> = (1..=2).map(|i| SymbolId::new(i).unwrap()).collect();
+        let mut search = SimpleSemanticSearch::new_empty(3, "remote-model");
+        search.store_code_embeddings(vec![
+            (ids[0], vec![0.0, 1.0, 0.0], "rust".to_string()),
+            (ids[1], vec![0.8, 0.6, 0.0], "rust".to_string()),
+        ]);
+        // The end of the body of symbol 1 matches best
+        search.store_chunk_embeddings(vec![
+            (ids[0], vec![0.0, 0.0, 1.0], text_hash("chunk a")),
+            (ids[0], vec![1.0, 0.0, 0.0], text_hash("chunk b")),
+        ]);
+        let query = [1.0, 0.0, 0.0];
+        let ranked = |search: &SimpleSemanticSearch| -> Vec<SymbolId> {
+            search
+                .search_vectors(&query, 2, None, None, SemanticVectors::Code, 0.5)
+                .unwrap()
+                .into_iter()
+                .map(|(id, _)| id)
+                .collect()
+        };
+        assert_eq!(ranked(&search), vec![ids[0], ids[1]]);
+
+        search.save(dir.path()).unwrap();
+        assert!(dir.path().join("chunks").join("chunks.json").exists());
+        let mut loaded = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        assert_eq!(loaded.chunk_embedding_count(), 2);
+        assert_eq!(ranked(&loaded), vec![ids[0], ids[1]]);
+
+        // Chunks stored for a symbol replace those it had, and new ones
+        // do not reuse the IDs of loaded ones
+        loaded.store_chunk_embeddings(vec![(ids[1], vec![0.0, 0.0, 1.0], 7)]);
+        loaded.store_chunk_embeddings(vec![(ids[1], vec![0.0, 1.0, 0.0], 8)]);
+        assert_eq!(loaded.chunk_embedding_count(), 3);
+
+        loaded.retire_embeddings(&ids[..1]);
+        assert_eq!(loaded.chunk_embedding_count(), 1);
+        assert_eq!(
+            loaded.take_retired("chunk b", true),
+            Some(vec![1.0, 0.0, 0.0])
+        );
+        assert_eq!(ranked(&loaded), vec![ids[1]]);
+
+        loaded.remove_embeddings(&ids[1..]);
+        loaded.save(dir.path()).unwrap();
+        assert!(!dir.path().join("chunks").exists());
+    }
+
+    #[test]
+    fn test_embedding_of_reads_every_store() {
+        let dir = tempfile::tempdir().unwrap();
+        let ids: Vec<SymbolId> = (1..=2).map(|i| SymbolId::new(i).unwrap()).collect();
+        let mut search = SimpleSemanticSearch::new_empty(3, "remote-model");
+        search.store_embeddings(vec![(ids[0], vec![1.0, 0.0, 0.0], "rust".to_string())]);
+        search.store_code_embeddings(vec![(ids[1], vec![0.0, 1.0, 0.0], "rust".to_string())]);
+        assert_eq!(
+            search.embedding_of(ids[0], false),
+            Some(vec![1.0, 0.0, 0.0])
+        );
+        assert_eq!(search.embedding_of(ids[1], true), Some(vec![0.0, 1.0, 0.0]));
+        assert!(search.embedding_of(ids[1], false).is_none());
+
+        // Mapped after a load, dequantized from int8
+        search.save(dir.path()).unwrap();
+        let mut loaded = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        assert_eq!(
+            loaded.embedding_of(ids[0], false),
+            Some(vec![1.0, 0.0, 0.0])
+        );
+        loaded.remove_embeddings(&[ids[0]]);
+        assert!(loaded.embedding_of(ids[0], false).is_none());
+
+        search
+            .save_with_quantization(dir.path(), VectorQuantization::Int8)
+            .unwrap();
+        let quantized = SimpleSemanticSearch::load_remote(dir.path()).unwrap();
+        let restored = quantized.embedding_of(ids[0], false).unwrap();
+        assert!((cosine_similarity(&restored, &[1.0, 0.0, 0.0]) - 1.0).abs() < 0.01);
+    }
+
     #[test]
     #[ignore = "Downloads 86MB model - run with --ignored for semantic tests"]
     fn test_remove_embeddings() {
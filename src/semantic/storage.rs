@@ -3,7 +3,7 @@
 //! This module provides efficient persistence for semantic embeddings by leveraging
 //! the existing MmapVectorStorage infrastructure, achieving <1μs access times.
 
-use crate::vector::{MmapVectorStorage, SegmentOrdinal, VectorDimension, VectorId};
+use crate::vector::{MappedVectors, MmapVectorStorage, SegmentOrdinal, VectorDimension, VectorId};
 use crate::{SymbolId, semantic::SemanticSearchError};
 use std::path::Path;
 
@@ -63,6 +63,20 @@ impl SemanticVectorStorage {
         Ok(Self { storage, dimension })
     }
 
+    /// Maps the stored embeddings for reading in place.
+    ///
+    /// Returns `None` where the platform cannot read them in place; use
+    /// `load_all` there.
+    pub fn map(path: &Path) -> Result<Option<MappedVectors>, SemanticSearchError> {
+        MappedVectors::open(path, SegmentOrdinal::new(0)).map_err(|e| {
+            SemanticSearchError::StorageError {
+                message: format!("Failed to map storage: {e}"),
+                suggestion: "The storage file may be corrupted. Try rebuilding the semantic index."
+                    .to_string(),
+            }
+        })
+    }
+
     /// Opens existing storage or creates new if doesn't exist.
     pub fn open_or_create(
         path: &Path,
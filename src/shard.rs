@@ -0,0 +1,366 @@
+//! Index shards for monorepos
+//!
+//! A shard is a subtree of the workspace, named in `[indexing.shards]`,
+//! with an index of its own under `.codanna/shards/<name>/`. Run with
+//! `codanna --shard <name>`, `index` builds or updates only that subtree and
+//! every other command reads only its index, so the team owning a subtree
+//! never pays for reindexing the rest of the repository.
+//!
+//! Paths stay relative to the workspace root, as in the main index. Each
+//! shard resolves relationships within itself and keeps the names it found
+//! no symbol for; [`ShardSet`] opens every shard and links those names to
+//! the symbols of the other shards, which `codanna shards` queries.
+
+use crate::indexing::facade::IndexFacade;
+use crate::storage::IndexPersistence;
+use crate::{IndexError, IndexResult, RelationKind, Settings, Symbol, SymbolKind, Visibility};
+use serde::Serialize;
+use std::collections::HashMap;
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+
+/// Where the shards of the index at `settings.index_path` live
+pub fn shards_dir(settings: &Settings) -> PathBuf {
+    settings
+        .index_path
+        .parent()
+        .unwrap_or(Path::new("."))
+        .join("shards")
+}
+
+/// A name is a directory under the shards, so it must stay one level below
+/// them
+fn check_name(name: &str) -> IndexResult<()> {
+    let valid = name
+        .chars()
+        .all(|c| c.is_ascii_alphanumeric() || matches!(c, '.' | '_' | '-'));
+    if !valid || name.chars().all(|c| c == '.') {
+        return Err(IndexError::General(format!(
+            "Invalid shard name '{name}': use letters, digits, '.', '_' and '-'"
+        )));
+    }
+    Ok(())
+}
+
+/// The settings of the shard `name`: its index and its subtree take the
+/// place of the main index and the indexed paths
+pub fn shard_settings(settings: &Settings, name: &str) -> IndexResult<Settings> {
+    let Some(path) = settings.indexing.shards.get(name) else {
+        let known: Vec<&str> = settings
+            .indexing
+            .shards
+            .keys()
+            .map(String::as_str)
+            .collect();
+        return Err(IndexError::General(if known.is_empty() {
+            format!("Unknown shard '{name}': settings.toml has no [indexing.shards]")
+        } else {
+            format!(
+                "Unknown shard '{name}'. Configured shards: {}",
+                known.join(", ")
+            )
+        }));
+    };
+    check_name(name)?;
+
+    let path = match settings.workspace_root.as_deref() {
+        Some(root) if path.is_relative() => root.join(path),
+        _ => path.clone(),
+    };
+    // Indexed paths are compared with the ones the index recorded, which
+    // are canonical
+    let path = path.canonicalize().unwrap_or(path);
+
+    let mut shard = settings.clone();
+    shard.index_path = shards_dir(settings).join(name).join("index");
+    shard.indexing.indexed_paths = vec![path];
+    shard.indexing.keep_unresolved = true;
+    Ok(shard)
+}
+
+/// A relationship from a symbol of one shard to a symbol of another,
+/// linked by a name the first left unresolved
+#[derive(Debug, Clone, Serialize)]
+pub struct ShardLink {
+    pub kind: RelationKind,
+    pub from_shard: String,
+    pub from: Symbol,
+    pub to_shard: String,
+    pub to: Symbol,
+    /// Line of the reference, zero-based like symbol ranges
+    pub line: u32,
+}
+
+/// The indexed shards of a workspace, queried together
+pub struct ShardSet {
+    shards: Vec<(String, IndexFacade)>,
+    unindexed: Vec<String>,
+}
+
+impl ShardSet {
+    /// Open the index of every configured shard, in settings order
+    pub fn open(settings: &Settings) -> IndexResult<Self> {
+        let mut shards = Vec::new();
+        let mut unindexed = Vec::new();
+        for name in settings.indexing.shards.keys() {
+            let shard = shard_settings(settings, name)?;
+            let persistence = IndexPersistence::new(shard.index_path.clone());
+            if !persistence.exists() {
+                unindexed.push(name.clone());
+                continue;
+            }
+            let facade = persistence.load_facade_lite(Arc::new(shard))?;
+            shards.push((name.clone(), facade));
+        }
+        Ok(Self { shards, unindexed })
+    }
+
+    /// The indexed shards, by name
+    pub fn shards(&self) -> impl Iterator<Item = (&str, &IndexFacade)> {
+        self.shards
+            .iter()
+            .map(|(name, facade)| (name.as_str(), facade))
+    }
+
+    /// Configured shards without an index yet
+    pub fn unindexed(&self) -> &[String] {
+        &self.unindexed
+    }
+
+    /// The symbols named `name` in every shard, with the shard of each
+    pub fn find_symbols_by_name(&self, name: &str, language: Option<&str>) -> Vec<(&str, Symbol)> {
+        self.shards()
+            .flat_map(|(shard, facade)| {
+                facade
+                    .find_symbols_by_name(name, language)
+                    .into_iter()
+                    .map(move |symbol| (shard, symbol))
+            })
+            .collect()
+    }
+
+    /// Link the unresolved references of each shard to the other shards.
+    ///
+    /// A reference is linked when exactly one symbol of the other shards
+    /// fits it: named as its last segment (`serve` of `api::serve`), of the
+    /// same language, not private and of a kind it can target. Like within
+    /// a shard, an ambiguous name is left unresolved rather than guessed.
+    pub fn links(&self) -> Vec<ShardLink> {
+        let mut named: HashMap<String, Vec<(usize, Symbol)>> = HashMap::new();
+        let mut links = Vec::new();
+        for (from_shard, (from_name, facade)) in self.shards.iter().enumerate() {
+            for reference in facade.get_unresolved() {
+                let Some(from) = facade.get_symbol(reference.from_id) else {
+                    continue;
+                };
+                let name = last_segment(&reference.to_name);
+                if name.is_empty() {
+                    continue;
+                }
+                let candidates = named
+                    .entry(name.to_string())
+                    .or_insert_with(|| self.lookup(name));
+                let mut targets = candidates.iter().filter(|(shard, symbol)| {
+                    *shard != from_shard
+                        && symbol.language_id == from.language_id
+                        && can_target(reference.kind, symbol)
+                });
+                let (Some((to_shard, to)), None) = (targets.next(), targets.next()) else {
+                    continue;
+                };
+                links.push(ShardLink {
+                    kind: reference.kind,
+                    from_shard: from_name.clone(),
+                    to_shard: self.shards[*to_shard].0.clone(),
+                    to: to.clone(),
+                    from,
+                    line: reference.line,
+                });
+            }
+        }
+        links
+    }
+
+    fn lookup(&self, name: &str) -> Vec<(usize, Symbol)> {
+        self.shards
+            .iter()
+            .enumerate()
+            .flat_map(|(shard, (_, facade))| {
+                facade
+                    .find_symbols_by_name(name, None)
+                    .into_iter()
+                    .map(move |symbol| (shard, symbol))
+            })
+            .collect()
+    }
+}
+
+/// The name a possibly qualified reference ends with: `serve` of
+/// `api::serve`, `api.serve` or `Api->serve`
+fn last_segment(name: &str) -> &str {
+    name.rsplit([':', '.', '>', '\\', '/'])
+        .next()
+        .unwrap_or(name)
+}
+
+/// Whether a reference of `kind` can be to `symbol`
+fn can_target(kind: RelationKind, symbol: &Symbol) -> bool {
+    use SymbolKind::*;
+    if symbol.visibility == Visibility::Private {
+        return false;
+    }
+    match kind {
+        RelationKind::Calls => matches!(symbol.kind, Function | Method | Macro | Class | Struct),
+        RelationKind::Extends | RelationKind::Implements | RelationKind::Uses => matches!(
+            symbol.kind,
+            Struct | Enum | Trait | Interface | Class | TypeAlias
+        ),
+        _ => !matches!(symbol.kind, Parameter | Variable | Field),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn workspace_settings(root: &Path) -> Settings {
+        let mut settings = Settings {
+            index_path: root.join(".codanna/index"),
+            workspace_root: Some(root.to_path_buf()),
+            ..Default::default()
+        };
+        for (name, path) in [("api", "services/api"), ("web", "apps/web")] {
+            settings
+                .indexing
+                .shards
+                .insert(name.to_string(), PathBuf::from(path));
+        }
+        settings
+    }
+
+    #[test]
+    fn test_shard_settings() {
+        let root = tempfile::tempdir().unwrap();
+        let root = root.path().canonicalize().unwrap();
+        std::fs::create_dir_all(root.join("services/api")).unwrap();
+        let settings = workspace_settings(&root);
+
+        let api = shard_settings(&settings, "api").unwrap();
+        assert_eq!(api.index_path, root.join(".codanna/shards/api/index"));
+        assert_eq!(api.indexing.indexed_paths, [root.join("services/api")]);
+        assert_eq!(api.workspace_root, settings.workspace_root);
+
+        let unknown = shard_settings(&settings, "mobile").unwrap_err().to_string();
+        assert!(unknown.contains("api, web"), "{unknown}");
+        assert!(shard_settings(&Settings::default(), "api").is_err());
+
+        let mut settings = settings;
+        settings
+            .indexing
+            .shards
+            .insert("../escape".to_string(), PathBuf::from("services"));
+        assert!(shard_settings(&settings, "../escape").is_err());
+    }
+
+    #[test]
+    fn test_shards_index_their_own_subtree() {
+        let root = tempfile::tempdir().unwrap();
+        let root = root.path().canonicalize().unwrap();
+        std::fs::create_dir_all(root.join("services/api")).unwrap();
+        std::fs::create_dir_all(root.join("apps/web")).unwrap();
+        std::fs::write(root.join("services/api/lib.rs"), "pub fn serve() {}\n").unwrap();
+        std::fs::write(root.join("apps/web/lib.rs"), "pub fn render() {}\n").unwrap();
+        let settings = workspace_settings(&root);
+
+        let mut shards = Vec::new();
+        for name in ["api", "web"] {
+            let shard = shard_settings(&settings, name).unwrap();
+            let subtree = shard.indexing.indexed_paths[0].clone();
+            let mut facade = IndexFacade::new(Arc::new(shard)).unwrap();
+            facade
+                .index_directory_with_options(&subtree, false, false, false, None)
+                .unwrap();
+            shards.push(facade);
+        }
+
+        let (api, web) = (&shards[0], &shards[1]);
+        assert_eq!(api.find_symbols_by_name("serve", None).len(), 1);
+        assert!(api.find_symbols_by_name("render", None).is_empty());
+        assert_eq!(web.find_symbols_by_name("render", None).len(), 1);
+        let symbol = &api.find_symbols_by_name("serve", None)[0];
+        assert_eq!(&*symbol.file_path, "services/api/lib.rs");
+        assert!(root.join(".codanna/shards/web/index").exists());
+        assert!(!root.join(".codanna/index").exists());
+    }
+
+    #[test]
+    fn test_links_resolve_across_shards() {
+        let root = tempfile::tempdir().unwrap();
+        let root = root.path().canonicalize().unwrap();
+        std::fs::create_dir_all(root.join("services/api")).unwrap();
+        std::fs::create_dir_all(root.join("apps/web")).unwrap();
+        std::fs::write(
+            root.join("services/api/lib.rs"),
+            "pub fn serve() {}\nfn helper() {}\n",
+        )
+        .unwrap();
+        std::fs::write(
+            root.join("apps/web/lib.rs"),
+            "pub fn render() {\n    serve();\n    helper();\n    local();\n}\nfn local() {}\n",
+        )
+        .unwrap();
+        let mut settings = workspace_settings(&root);
+        settings
+            .indexing
+            .shards
+            .insert("docs".to_string(), PathBuf::from("docs"));
+
+        for name in ["api", "web"] {
+            let shard = shard_settings(&settings, name).unwrap();
+            let subtree = shard.indexing.indexed_paths[0].clone();
+            let mut facade = IndexFacade::new(Arc::new(shard)).unwrap();
+            facade
+                .index_directory_with_options(&subtree, false, false, false, None)
+                .unwrap();
+        }
+
+        let set = ShardSet::open(&settings).unwrap();
+        assert_eq!(set.unindexed(), ["docs"]);
+        let (_, web) = set.shards().find(|(name, _)| *name == "web").unwrap();
+        let kept: Vec<String> = web
+            .get_unresolved()
+            .into_iter()
+            .map(|reference| reference.to_name)
+            .collect();
+        assert!(kept.contains(&"serve".to_string()), "{kept:?}");
+        assert!(
+            !kept.contains(&"local".to_string()),
+            "resolved in the shard"
+        );
+
+        let found = set.find_symbols_by_name("serve", None);
+        assert_eq!(found.len(), 1);
+        assert_eq!(found[0].0, "api");
+
+        // helper is private to its shard
+        let links = set.links();
+        assert_eq!(links.len(), 1, "{links:?}");
+        let link = &links[0];
+        assert_eq!(link.kind, RelationKind::Calls);
+        assert_eq!(
+            (&*link.from.name, link.from_shard.as_str()),
+            ("render", "web")
+        );
+        assert_eq!((&*link.to.name, link.to_shard.as_str()), ("serve", "api"));
+        assert_eq!(link.line, 1);
+    }
+
+    #[test]
+    fn test_last_segment() {
+        assert_eq!(last_segment("serve"), "serve");
+        assert_eq!(last_segment("api::serve"), "serve");
+        assert_eq!(last_segment("client.Fetch"), "Fetch");
+        assert_eq!(last_segment("Api->serve"), "serve");
+        assert_eq!(last_segment("App\\Http\\Kernel"), "Kernel");
+    }
+}
@@ -0,0 +1,228 @@
+//! Index snapshots of git revisions
+//!
+//! `codanna index --rev <commit>` indexes a commit as the object store holds
+//! it, so release branches and old code can be indexed without a checkout.
+//! The commit's source files are written to a scratch directory outside the
+//! workspace for the pipeline to read, and removed once it is done; the
+//! snapshot keeps only its index, under `.codanna/snapshots/<name>/`.
+
+use crate::git;
+use crate::indexing::facade::IndexFacade;
+use crate::parsing::get_registry;
+use crate::{IndexError, IndexResult, Settings};
+use serde::{Deserialize, Serialize};
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+
+/// File describing a snapshot, next to its index
+const INFO_FILE: &str = "snapshot.json";
+
+/// Ignore files the pipeline honors, exported along with the sources
+const IGNORE_FILES: &[&str] = &[".gitignore", ".codannaignore"];
+
+/// What a snapshot was built from
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SnapshotInfo {
+    pub name: String,
+    /// The revision as given
+    pub rev: String,
+    /// Full SHA it resolved to
+    pub commit: String,
+    /// Seconds since the Unix epoch
+    pub created_at: u64,
+    pub files: u32,
+    pub symbols: usize,
+}
+
+impl SnapshotInfo {
+    /// The commit abbreviated as git log shows it
+    pub fn short_commit(&self) -> &str {
+        self.commit.get(..8).unwrap_or(&self.commit)
+    }
+}
+
+/// Where the snapshots of the index at `settings.index_path` live
+pub fn snapshots_dir(settings: &Settings) -> PathBuf {
+    settings
+        .index_path
+        .parent()
+        .unwrap_or(Path::new("."))
+        .join("snapshots")
+}
+
+/// The default snapshot name of a revision: `feature/auth` is `feature-auth`
+pub fn snapshot_name(rev: &str) -> String {
+    rev.chars()
+        .map(|c| {
+            if c.is_ascii_alphanumeric() || matches!(c, '.' | '_' | '-') {
+                c
+            } else {
+                '-'
+            }
+        })
+        .collect()
+}
+
+/// A name is a directory under the snapshots, which creating one replaces,
+/// so it must stay one level below them
+fn check_name(name: &str) -> IndexResult<()> {
+    if name.is_empty() || name.chars().all(|c| c == '.') || name.contains(['/', '\\']) {
+        return Err(IndexError::General(format!(
+            "Invalid snapshot name '{name}': use letters, digits, '.', '_' and '-'"
+        )));
+    }
+    Ok(())
+}
+
+/// Index the commit `rev` of the repository at `repo` into the snapshot
+/// `name`, replacing a snapshot of that name. The work tree and the main
+/// index are left alone.
+pub fn create_snapshot(
+    settings: &Settings,
+    repo: &Path,
+    rev: &str,
+    name: &str,
+    progress: bool,
+) -> IndexResult<SnapshotInfo> {
+    check_name(name)?;
+    let git_error = |e: git::GitError| IndexError::General(e.message());
+    let commit = git::resolve_commit(repo, rev).map_err(git_error)?;
+
+    let dir = std::path::absolute(snapshots_dir(settings).join(name))?;
+    if dir.exists() {
+        std::fs::remove_dir_all(&dir)?;
+    }
+    std::fs::create_dir_all(&dir)?;
+
+    let scratch = tempfile::Builder::new().prefix("codanna-rev-").tempdir()?;
+    let tree = scratch.path().canonicalize()?;
+    let extensions: Vec<String> = get_registry()
+        .lock()
+        .map(|registry| {
+            registry
+                .enabled_extensions(settings)
+                .map(|ext| ext.to_string())
+                .collect()
+        })
+        .unwrap_or_default();
+    let keep = |path: &str| {
+        let path = Path::new(path);
+        let file_name = path.file_name().and_then(|name| name.to_str());
+        let extension = path.extension().and_then(|ext| ext.to_str());
+        file_name.is_some_and(|name| IGNORE_FILES.contains(&name))
+            || extension.is_some_and(|ext| extensions.iter().any(|known| known == ext))
+    };
+    git::export_tree(repo, &commit, &tree, keep).map_err(git_error)?;
+
+    // Paths are stored relative to the exported tree, as they are in the
+    // repository. Blame needs a work tree, which the export is not.
+    let mut snapshot_settings = settings.clone();
+    snapshot_settings.workspace_root = Some(tree.clone());
+    snapshot_settings.index_path = dir.join("index");
+    snapshot_settings.indexing.indexed_paths = vec![tree.clone()];
+    snapshot_settings.indexing.git_blame = false;
+
+    let mut facade = IndexFacade::new(Arc::new(snapshot_settings))?;
+    facade.index_directory_with_options(&tree, progress, false, true, None)?;
+
+    let info = SnapshotInfo {
+        name: name.to_string(),
+        rev: rev.to_string(),
+        commit,
+        created_at: crate::indexing::get_utc_timestamp(),
+        files: facade.file_count(),
+        symbols: facade.symbol_count(),
+    };
+    let json = serde_json::to_string_pretty(&info)
+        .map_err(|e| IndexError::General(format!("Failed to serialize snapshot info: {e}")))?;
+    std::fs::write(dir.join(INFO_FILE), json)?;
+    Ok(info)
+}
+
+/// Open the index of the snapshot `name`
+pub fn open_snapshot(settings: &Settings, name: &str) -> IndexResult<(SnapshotInfo, IndexFacade)> {
+    check_name(name)?;
+    let dir = std::path::absolute(snapshots_dir(settings).join(name))?;
+    let info = std::fs::read_to_string(dir.join(INFO_FILE)).map_err(|_| {
+        IndexError::General(format!(
+            "Snapshot '{name}' not found. Create it with 'codanna index --rev <commit> --name {name}'"
+        ))
+    })?;
+    let info: SnapshotInfo = serde_json::from_str(&info)
+        .map_err(|e| IndexError::General(format!("Invalid snapshot '{name}': {e}")))?;
+
+    let mut snapshot_settings = settings.clone();
+    snapshot_settings.workspace_root = None;
+    snapshot_settings.index_path = dir.join("index");
+    let facade = IndexFacade::new(Arc::new(snapshot_settings))?;
+    Ok((info, facade))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::process::Command;
+
+    fn git(dir: &Path, args: &[&str]) {
+        let output = Command::new("git")
+            .args(["-c", "user.name=Test", "-c", "user.email=test@test.com"])
+            .args(["-c", "commit.gpgsign=false"])
+            .args(args)
+            .current_dir(dir)
+            .output()
+            .unwrap();
+        assert!(
+            output.status.success(),
+            "git {} failed: {}",
+            args.join(" "),
+            String::from_utf8_lossy(&output.stderr)
+        );
+    }
+
+    #[test]
+    fn test_snapshot_names() {
+        assert_eq!(snapshot_name("main"), "main");
+        assert_eq!(snapshot_name("feature/auth"), "feature-auth");
+        assert_eq!(snapshot_name("v1.2.0"), "v1.2.0");
+        assert_eq!(snapshot_name("HEAD~3"), "HEAD-3");
+
+        let settings = Settings::default();
+        for name in ["", "..", "../index", "a/b"] {
+            let result = create_snapshot(&settings, Path::new("."), "HEAD", name, false);
+            assert!(result.is_err(), "{name:?} must be rejected");
+        }
+        assert!(open_snapshot(&settings, "missing").is_err());
+    }
+
+    #[test]
+    fn test_snapshot_indexes_the_revision_not_the_work_tree() {
+        let repo = tempfile::tempdir().unwrap();
+        std::fs::create_dir_all(repo.path().join("src")).unwrap();
+        std::fs::write(repo.path().join("src/lib.rs"), "pub fn released() {}\n").unwrap();
+        git(repo.path(), &["init", "-q"]);
+        git(repo.path(), &["add", "-A"]);
+        git(repo.path(), &["commit", "-q", "-m", "release"]);
+        git(repo.path(), &["tag", "v1"]);
+        std::fs::write(repo.path().join("src/lib.rs"), "pub fn unreleased() {}\n").unwrap();
+
+        let workspace = tempfile::tempdir().unwrap();
+        let settings = Settings {
+            index_path: workspace.path().join(".codanna/index"),
+            workspace_root: None,
+            ..Default::default()
+        };
+        let info = create_snapshot(&settings, repo.path(), "v1", "v1", false).unwrap();
+
+        assert_eq!(info.files, 1);
+        assert_eq!(info.commit.len(), 40);
+        let dir = workspace.path().join(".codanna/snapshots/v1");
+        assert!(dir.join(INFO_FILE).exists());
+
+        let (opened, facade) = open_snapshot(&settings, "v1").unwrap();
+        assert_eq!(opened, info);
+        assert_eq!(facade.find_symbols_by_name("released", None).len(), 1);
+        assert!(facade.find_symbols_by_name("unreleased", None).is_empty());
+        let symbol = &facade.find_symbols_by_name("released", None)[0];
+        assert_eq!(&*symbol.file_path, "src/lib.rs");
+    }
+}
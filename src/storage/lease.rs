@@ -0,0 +1,403 @@
+//! Writer lease and generation of an index directory
+//!
+//! The watcher of a server, `codanna index` in a terminal and `codanna mcp
+//! --watch` all write the same index directory. A writer takes the lease
+//! first: an exclusive lock on `writer.lock`, which the system releases
+//! when the process dies, so a crashed writer never leaves the index
+//! locked. `writer.json` names the holder, with a heartbeat it refreshes
+//! while it writes, so a process kept waiting can say who it waits for and
+//! whether that process still makes progress. Asking whether the lease is
+//! held reads `writer.json` rather than probing the lock, so a reader
+//! asking never makes a writer taking the lease find it taken.
+//!
+//! Releasing the lease bumps the number in `generation`. Readers reload
+//! when it changed and no writer holds the lease, instead of on the first
+//! file a write touched, which could pair a committed symbol index with
+//! embeddings not yet saved.
+
+use crate::indexing::get_utc_timestamp;
+use serde::{Deserialize, Serialize};
+use std::fs::{File, OpenOptions, TryLockError};
+use std::path::{Path, PathBuf};
+use std::sync::mpsc::{self, RecvTimeoutError, Sender};
+use std::thread::JoinHandle;
+use std::time::{Duration, Instant};
+
+/// How often the holder refreshes its heartbeat
+pub const HEARTBEAT_INTERVAL: Duration = Duration::from_secs(5);
+
+/// A holder whose heartbeat is older than this is reported as not
+/// responding
+pub const STALE_AFTER: Duration = Duration::from_secs(30);
+
+/// How long a writer waits for the holder to release the lease, unless it
+/// has reason to wait less
+pub const WRITER_WAIT: Duration = Duration::from_secs(60);
+
+/// How often a waiting writer tries the lease again
+const RETRY_INTERVAL: Duration = Duration::from_millis(100);
+
+const LOCK_FILE: &str = "writer.lock";
+const HOLDER_FILE: &str = "writer.json";
+const GENERATION_FILE: &str = "generation";
+
+/// The process holding the lease, as `writer.json` records it
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct LeaseHolder {
+    pub pid: u32,
+    /// What the holder is doing, such as `codanna index`
+    pub command: String,
+    /// When it took the lease, in seconds since the epoch
+    pub acquired_at: u64,
+    /// When it last refreshed the heartbeat, in seconds since the epoch
+    pub heartbeat_at: u64,
+}
+
+impl LeaseHolder {
+    /// Whether the heartbeat is fresh at `now`
+    pub fn is_responding(&self, now: u64) -> bool {
+        now.saturating_sub(self.heartbeat_at) <= STALE_AFTER.as_secs()
+    }
+}
+
+impl std::fmt::Display for LeaseHolder {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        let now = get_utc_timestamp();
+        write!(
+            f,
+            "pid {} ({}), writing for {}s",
+            self.pid,
+            self.command,
+            now.saturating_sub(self.acquired_at)
+        )?;
+        if !self.is_responding(now) {
+            write!(
+                f,
+                ", no heartbeat for {}s",
+                now.saturating_sub(self.heartbeat_at)
+            )?;
+        }
+        Ok(())
+    }
+}
+
+/// Why the lease could not be taken
+#[derive(Debug, thiserror::Error)]
+pub enum LeaseError {
+    #[error("{}", held_message(.0))]
+    Held(Option<LeaseHolder>),
+
+    #[error("Failed to take the index writer lease: {0}")]
+    Io(#[from] std::io::Error),
+}
+
+fn held_message(holder: &Option<LeaseHolder>) -> String {
+    match holder {
+        Some(holder) => format!("The index is being written by {holder}"),
+        None => "The index is being written by another process".to_string(),
+    }
+}
+
+/// The right to write an index directory, released on drop
+#[derive(Debug)]
+pub struct IndexLease {
+    dir: PathBuf,
+    /// Locked while the lease is held; closing it releases the lock
+    _lock: File,
+    /// Dropped to stop the heartbeat
+    stop: Option<Sender<()>>,
+    heartbeat: Option<JoinHandle<()>>,
+    /// Whether releasing bumps the generation
+    changed: bool,
+}
+
+impl IndexLease {
+    /// Take the lease of the index at `dir` for `command`, or name the
+    /// process holding it
+    pub fn try_acquire(dir: &Path, command: &str) -> Result<Self, LeaseError> {
+        std::fs::create_dir_all(dir)?;
+        let lock = OpenOptions::new()
+            .read(true)
+            .write(true)
+            .create(true)
+            .truncate(false)
+            .open(dir.join(LOCK_FILE))?;
+        match lock.try_lock() {
+            Ok(()) => {}
+            Err(TryLockError::WouldBlock) => return Err(LeaseError::Held(Self::holder(dir))),
+            Err(TryLockError::Error(e)) => return Err(LeaseError::Io(e)),
+        }
+
+        let now = get_utc_timestamp();
+        let mut holder = LeaseHolder {
+            pid: std::process::id(),
+            command: command.to_string(),
+            acquired_at: now,
+            heartbeat_at: now,
+        };
+        let json = serde_json::to_string(&holder).map_err(std::io::Error::from)?;
+        write_atomic(&dir.join(HOLDER_FILE), &json)?;
+
+        let (stop, stopped) = mpsc::channel::<()>();
+        let holder_path = dir.join(HOLDER_FILE);
+        let heartbeat = std::thread::Builder::new()
+            .name("index-lease".to_string())
+            .spawn(move || {
+                while let Err(RecvTimeoutError::Timeout) = stopped.recv_timeout(HEARTBEAT_INTERVAL)
+                {
+                    holder.heartbeat_at = get_utc_timestamp();
+                    let written = serde_json::to_string(&holder)
+                        .map_err(std::io::Error::from)
+                        .and_then(|json| write_atomic(&holder_path, &json));
+                    if let Err(e) = written {
+                        tracing::warn!(target: "lease", "Failed to refresh the writer heartbeat: {e}");
+                    }
+                }
+            })?;
+
+        Ok(Self {
+            dir: dir.to_path_buf(),
+            _lock: lock,
+            stop: Some(stop),
+            heartbeat: Some(heartbeat),
+            changed: true,
+        })
+    }
+
+    /// Take the lease, waiting up to `wait` for the holder to release it;
+    /// `waiting` is called once, with the holder, if the lease is held
+    pub fn acquire(
+        dir: &Path,
+        command: &str,
+        wait: Duration,
+        waiting: impl FnOnce(&LeaseError),
+    ) -> Result<Self, LeaseError> {
+        let deadline = Instant::now() + wait;
+        let mut waiting = Some(waiting);
+        loop {
+            match Self::try_acquire(dir, command) {
+                Err(e @ LeaseError::Held(_)) if Instant::now() < deadline => {
+                    if let Some(waiting) = waiting.take() {
+                        waiting(&e);
+                    }
+                    std::thread::sleep(RETRY_INTERVAL);
+                }
+                result => return result,
+            }
+        }
+    }
+
+    /// Release the lease of a write that changed nothing, leaving the
+    /// generation as it was so readers do not reload
+    pub fn release_unchanged(mut self) {
+        self.changed = false;
+    }
+
+    /// Whether a process holds the lease of the index at `dir`
+    pub fn is_held(dir: &Path) -> bool {
+        Self::holder(dir).is_some()
+    }
+
+    /// The process holding the lease of the index at `dir`, if one does and
+    /// has recorded itself
+    pub fn holder(dir: &Path) -> Option<LeaseHolder> {
+        let json = std::fs::read_to_string(dir.join(HOLDER_FILE)).ok()?;
+        let holder: LeaseHolder = serde_json::from_str(&json).ok()?;
+        // A crashed holder leaves its record behind
+        if !process_is_alive(holder.pid) {
+            return None;
+        }
+        // Its pid taken by another process since: only then is the lock
+        // probed, briefly contending with a writer taking it
+        if !holder.is_responding(get_utc_timestamp()) && !lock_is_taken(dir) {
+            return None;
+        }
+        Some(holder)
+    }
+
+    /// The number of writes released in the index at `dir`, 0 before the
+    /// first
+    pub fn generation(dir: &Path) -> u64 {
+        std::fs::read_to_string(dir.join(GENERATION_FILE))
+            .ok()
+            .and_then(|text| text.trim().parse().ok())
+            .unwrap_or(0)
+    }
+
+    /// Give the index at `to` the generation of the index at `from`, which
+    /// it is about to replace, so releasing the lease moves readers of
+    /// `from` past both
+    pub fn carry_generation(from: &Path, to: &Path) -> std::io::Result<()> {
+        let generation = Self::generation(from);
+        write_atomic(&to.join(GENERATION_FILE), &generation.to_string())
+    }
+}
+
+impl Drop for IndexLease {
+    fn drop(&mut self) {
+        drop(self.stop.take());
+        if let Some(heartbeat) = self.heartbeat.take() {
+            let _ = heartbeat.join();
+        }
+        // The generation moves before the lock goes, so a reader that sees
+        // no writer sees the write as well
+        if self.changed {
+            let generation = Self::generation(&self.dir) + 1;
+            if let Err(e) = write_atomic(&self.dir.join(GENERATION_FILE), &generation.to_string()) {
+                tracing::warn!(target: "lease", "Failed to bump the index generation: {e}");
+            }
+        }
+        let _ = std::fs::remove_file(self.dir.join(HOLDER_FILE));
+    }
+}
+
+fn process_is_alive(pid: u32) -> bool {
+    use sysinfo::{Pid, ProcessRefreshKind, ProcessesToUpdate, System};
+    let mut sys = System::new();
+    let pid = Pid::from_u32(pid);
+    sys.refresh_processes_specifics(
+        ProcessesToUpdate::Some(&[pid]),
+        true,
+        ProcessRefreshKind::nothing(),
+    );
+    sys.process(pid).is_some()
+}
+
+/// Whether a process holds the exclusive lock of `writer.lock` in `dir`
+fn lock_is_taken(dir: &Path) -> bool {
+    let Ok(lock) = File::open(dir.join(LOCK_FILE)) else {
+        return false;
+    };
+    // A shared lock is refused only while a writer holds the exclusive one
+    matches!(lock.try_lock_shared(), Err(TryLockError::WouldBlock))
+}
+
+/// Write `contents` to `path` through a temporary file, so readers see the
+/// previous contents or the new ones
+fn write_atomic(path: &Path, contents: &str) -> std::io::Result<()> {
+    let tmp = path.with_extension("tmp");
+    std::fs::write(&tmp, contents)?;
+    std::fs::rename(&tmp, path)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_lease_is_exclusive_and_names_its_holder() {
+        let dir = tempfile::tempdir().unwrap();
+        assert!(!IndexLease::is_held(dir.path()));
+        assert_eq!(IndexLease::generation(dir.path()), 0);
+
+        let lease = IndexLease::try_acquire(dir.path(), "codanna index").unwrap();
+        assert!(IndexLease::is_held(dir.path()));
+        let holder = IndexLease::holder(dir.path()).unwrap();
+        assert_eq!(holder.pid, std::process::id());
+        assert_eq!(holder.command, "codanna index");
+
+        match IndexLease::try_acquire(dir.path(), "watcher") {
+            Err(LeaseError::Held(Some(held))) => assert_eq!(held, holder),
+            other => panic!("expected the lease to be held, got {other:?}"),
+        }
+        let error = IndexLease::acquire(dir.path(), "watcher", Duration::ZERO, |_| {})
+            .unwrap_err()
+            .to_string();
+        assert!(error.contains("codanna index"), "{error}");
+
+        drop(lease);
+        assert!(!IndexLease::is_held(dir.path()));
+        assert_eq!(IndexLease::holder(dir.path()), None);
+        assert_eq!(IndexLease::generation(dir.path()), 1);
+
+        let again = IndexLease::try_acquire(dir.path(), "watcher").unwrap();
+        drop(again);
+        assert_eq!(IndexLease::generation(dir.path()), 2);
+
+        // A write that changed nothing leaves readers be
+        IndexLease::try_acquire(dir.path(), "settings sync")
+            .unwrap()
+            .release_unchanged();
+        assert_eq!(IndexLease::generation(dir.path()), 2);
+        assert!(!IndexLease::is_held(dir.path()));
+
+        let replacement = tempfile::tempdir().unwrap();
+        IndexLease::carry_generation(dir.path(), replacement.path()).unwrap();
+        assert_eq!(IndexLease::generation(replacement.path()), 2);
+    }
+
+    #[test]
+    fn test_is_held_reads_the_record_of_a_live_holder() {
+        let dir = tempfile::tempdir().unwrap();
+        let now = get_utc_timestamp();
+        let record = |pid: u32, heartbeat_at: u64| {
+            let holder = LeaseHolder {
+                pid,
+                command: "codanna index".to_string(),
+                acquired_at: now - 120,
+                heartbeat_at,
+            };
+            std::fs::write(
+                dir.path().join(HOLDER_FILE),
+                serde_json::to_string(&holder).unwrap(),
+            )
+            .unwrap();
+        };
+
+        // Left behind by a crashed writer; PID 0 is never a normal process
+        record(0, now);
+        assert!(!IndexLease::is_held(dir.path()));
+
+        // A live, responding holder counts without touching the lock
+        record(std::process::id(), now);
+        assert!(IndexLease::is_held(dir.path()));
+
+        // A stale one only while the lock is taken
+        record(std::process::id(), now - 60);
+        assert!(!IndexLease::is_held(dir.path()));
+        std::fs::remove_file(dir.path().join(HOLDER_FILE)).unwrap();
+        let lease = IndexLease::try_acquire(dir.path(), "codanna index").unwrap();
+        record(std::process::id(), now - 60);
+        assert!(IndexLease::is_held(dir.path()));
+        drop(lease);
+        assert!(!IndexLease::is_held(dir.path()));
+    }
+
+    #[test]
+    fn test_acquire_waits_for_the_holder() {
+        let dir = tempfile::tempdir().unwrap();
+        let lease = IndexLease::try_acquire(dir.path(), "codanna index").unwrap();
+        let releaser = std::thread::spawn(move || {
+            std::thread::sleep(Duration::from_millis(300));
+            drop(lease);
+        });
+
+        let mut waited_for = None;
+        let taken = IndexLease::acquire(dir.path(), "watcher", Duration::from_secs(10), |e| {
+            waited_for = Some(e.to_string())
+        });
+        releaser.join().unwrap();
+        assert!(taken.is_ok());
+        assert!(waited_for.unwrap().contains("codanna index"));
+    }
+
+    #[test]
+    fn test_holder_reports_a_stale_heartbeat() {
+        let now = get_utc_timestamp();
+        let holder = LeaseHolder {
+            pid: 42,
+            command: "codanna index".to_string(),
+            acquired_at: now - 120,
+            heartbeat_at: now - 60,
+        };
+        assert!(!holder.is_responding(now));
+        assert!(holder.to_string().contains("no heartbeat for 60s"));
+        assert!(
+            LeaseHolder {
+                heartbeat_at: now,
+                ..holder
+            }
+            .is_responding(now)
+        );
+    }
+}
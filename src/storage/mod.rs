@@ -1,10 +1,12 @@
 pub mod error;
+pub mod lease;
 pub mod metadata;
 pub mod metadata_keys;
 pub mod persistence;
 pub mod tantivy;
 pub use error::{StorageError, StorageResult};
+pub use lease::{IndexLease, LeaseError, LeaseHolder};
 pub use metadata::{DataSource, EMISSION_SEMANTICS_VERSION, IndexMetadata};
 pub use metadata_keys::MetadataKey;
 pub use persistence::IndexPersistence;
-pub use tantivy::{DocumentIndex, SearchResult};
+pub use tantivy::{CompactStats, DocumentIndex, SearchResult, SegmentHealth};
@@ -132,6 +132,13 @@ impl IndexPersistence {
                         "[persistence] semantic search disabled — index incompatible: {suggestion}"
                     );
                 }
+                Err(IndexError::SemanticSearch(
+                    ref e @ crate::semantic::SemanticSearchError::ModelChanged { .. },
+                )) => {
+                    // The embeddings are of another model: as above, text
+                    // search stays; `codanna index` re-embeds
+                    tracing::error!("[persistence] semantic search disabled — {e}");
+                }
                 Err(e) => {
                     tracing::warn!("[persistence] failed to load semantic search: {e}");
                 }
@@ -223,6 +230,13 @@ impl IndexPersistence {
         tantivy_path.join("meta.json").exists()
     }
 
+    /// Check if an indexing run was interrupted after committing files,
+    /// leaving relationships to resolve (`codanna index --resume`)
+    pub fn has_checkpoint(&self) -> bool {
+        let tantivy_path = self.base_path.join("tantivy");
+        crate::indexing::pipeline::Checkpoint::path(&tantivy_path).exists()
+    }
+
     /// Delete the persisted index
     pub fn clear(&self) -> Result<(), std::io::Error> {
         let tantivy_path = self.base_path.join("tantivy");
@@ -304,6 +318,11 @@ impl IndexPersistence {
             project.symbol_count = metadata.symbol_count;
             project.file_count = metadata.file_count;
             project.last_modified = metadata.last_modified;
+            project.index_path = Some(
+                self.base_path
+                    .canonicalize()
+                    .unwrap_or_else(|_| self.base_path.clone()),
+            );
 
             // Get doc count from data source
             if let DataSource::Tantivy { doc_count, .. } = &metadata.data_source {
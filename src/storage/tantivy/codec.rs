@@ -138,6 +138,25 @@ impl DocumentIndex {
                 .unwrap_or(""),
         );
 
+        if let Some(metrics) = symbol.metrics {
+            doc.add_u64(self.schema.complexity, metrics.complexity as u64);
+            doc.add_u64(self.schema.parameter_count, metrics.parameters as u64);
+        }
+
+        if let Some(authorship) = &symbol.authorship {
+            doc.add_text(self.schema.last_author, &authorship.author);
+            doc.add_text(self.schema.last_commit, &authorship.commit);
+            doc.add_u64(self.schema.last_modified, authorship.timestamp);
+        }
+
+        for tag in &symbol.tags {
+            doc.add_text(self.schema.tags, tag);
+        }
+
+        if let Some(kind) = &symbol.framework_kind {
+            doc.add_text(self.schema.framework_kind, kind);
+        }
+
         writer.add_document(doc)?;
 
         Ok(())
@@ -234,6 +253,45 @@ impl DocumentIndex {
             .and_then(|v| v.as_str())
             .and_then(decode_scope_context);
 
+        // Indexes built before metrics, or without them, have no complexity
+        let metrics = doc
+            .get_first(self.schema.complexity)
+            .and_then(|v| v.as_u64())
+            .map(|complexity| crate::symbol::SymbolMetrics {
+                complexity: complexity as u32,
+                lines: end_line.saturating_sub(start_line) + 1,
+                parameters: doc
+                    .get_first(self.schema.parameter_count)
+                    .and_then(|v| v.as_u64())
+                    .unwrap_or(0) as u32,
+            });
+
+        let authorship = doc
+            .get_first(self.schema.last_commit)
+            .and_then(|v| v.as_str())
+            .map(|commit| crate::symbol::Authorship {
+                author: doc
+                    .get_first(self.schema.last_author)
+                    .and_then(|v| v.as_str())
+                    .unwrap_or_default()
+                    .to_string(),
+                commit: commit.to_string(),
+                timestamp: doc
+                    .get_first(self.schema.last_modified)
+                    .and_then(|v| v.as_u64())
+                    .unwrap_or(0),
+            });
+
+        let tags = doc
+            .get_all(self.schema.tags)
+            .filter_map(|v| v.as_str())
+            .map(str::to_string)
+            .collect();
+        let framework_kind = doc
+            .get_first(self.schema.framework_kind)
+            .and_then(|v| v.as_str())
+            .map(Into::into);
+
         Ok(Symbol {
             id: SymbolId(symbol_id as u32),
             name: name.into(),
@@ -276,6 +334,10 @@ impl DocumentIndex {
                             .and_then(|registry| registry.find_language_id(lang_str))
                     })
             },
+            metrics,
+            authorship,
+            tags,
+            framework_kind,
         })
     }
 
@@ -518,7 +580,7 @@ mod tests {
     fn test_scope_context_codec_round_trips_every_variant() {
         use crate::{ScopeContext, SymbolKind};
 
-        const ALL_KINDS: [SymbolKind; 14] = [
+        const ALL_KINDS: [SymbolKind; 15] = [
             SymbolKind::Function,
             SymbolKind::Method,
             SymbolKind::Struct,
@@ -533,6 +595,7 @@ mod tests {
             SymbolKind::Parameter,
             SymbolKind::TypeAlias,
             SymbolKind::Macro,
+            SymbolKind::Given,
         ];
 
         let mut cases: Vec<Option<ScopeContext>> = vec![
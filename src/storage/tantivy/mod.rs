@@ -45,6 +45,30 @@ pub struct SearchResult {
     pub context: Option<String>,
 }
 
+/// What [`DocumentIndex::compact`] removed
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize)]
+pub struct CompactStats {
+    /// Symbols of files no longer registered in the index
+    pub orphaned_symbols: usize,
+    /// Relationships whose source or target symbol is gone
+    pub dangling_relationships: usize,
+    /// Deleted documents purged from the segments
+    pub purged_documents: u64,
+    pub segments_before: usize,
+    pub segments_after: usize,
+}
+
+/// What [`DocumentIndex::segment_health`] found
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize)]
+pub struct SegmentHealth {
+    pub segments: usize,
+    pub documents: u64,
+    /// Deleted documents still taking room in the segments
+    pub deleted_documents: u64,
+    /// Index files whose checksum does not match their contents
+    pub corrupted_files: Vec<PathBuf>,
+}
+
 /// Highlighted text region
 #[derive(Debug, Clone, Serialize)]
 pub struct TextHighlight {
@@ -64,6 +88,9 @@ pub struct DocumentIndex {
     heap_size: usize,
     /// Maximum retry attempts for transient errors
     max_retry_attempts: u32,
+    /// Write with one thread and no background merges, so the same
+    /// documents always make the same segments (`indexing.deterministic`)
+    deterministic: bool,
     /// Pending symbol counter during batch operations
     pending_symbol_counter: Mutex<Option<u32>>,
     /// Pending file counter during batch operations
@@ -95,8 +122,13 @@ impl DocumentIndex {
         let index_path = index_path.as_ref().to_path_buf();
         std::fs::create_dir_all(&index_path)?;
 
-        // Extract and validate heap size
-        let heap_size = settings.indexing.tantivy_heap_mb * 1_000_000;
+        // Extract and validate heap size; a memory budget leaves the
+        // writer an eighth of it
+        let heap_mb = match settings.indexing.max_memory_mb {
+            0 => settings.indexing.tantivy_heap_mb,
+            budget => settings.indexing.tantivy_heap_mb.min(budget / 8),
+        };
+        let heap_size = heap_mb * 1_000_000;
         let heap_size = heap_size.clamp(10_000_000, 1_000_000_000); // 10MB-1GB
 
         let max_retry_attempts = settings.indexing.max_retry_attempts;
@@ -135,6 +167,7 @@ impl DocumentIndex {
             writer: RwLock::new(None),
             heap_size,
             max_retry_attempts,
+            deterministic: settings.indexing.deterministic,
             pending_symbol_counter: Mutex::new(None),
             pending_file_counter: Mutex::new(None),
             strip_bases: Self::collect_strip_bases(settings),
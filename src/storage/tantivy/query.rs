@@ -1,14 +1,19 @@
 use crate::storage::{MetadataKey, StorageError, StorageResult};
+use crate::symbol::dsl::{QueryField, QueryTerm, SymbolQuery, glob_regex, path_regex};
 use crate::{FileId, RelationKind, Relationship, SymbolId, SymbolKind};
+use std::collections::HashSet;
 use std::path::PathBuf;
 use tantivy::{
     TantivyDocument as Document, Term,
     collector::{Count, TopDocs},
-    query::{BooleanQuery, FuzzyTermQuery, Occur, Query, QueryParser, TermQuery},
+    query::{
+        BooleanQuery, FuzzyTermQuery, Occur, Query, QueryParser, RegexQuery, TermQuery,
+        TermSetQuery,
+    },
     schema::{IndexRecordOption, Value},
 };
 
-use super::{DocumentIndex, SearchResult};
+use super::{DocumentIndex, SearchResult, SegmentHealth};
 
 impl DocumentIndex {
     /// Search returning every match: count-first, then an exact-limit drain.
@@ -26,17 +31,9 @@ impl DocumentIndex {
         searcher.search(query, &TopDocs::with_limit(count).order_by_score())
     }
 
-    /// Search for documents
-    pub fn search(
-        &self,
-        query_str: &str,
-        limit: usize,
-        kind_filter: Option<SymbolKind>,
-        module_filter: Option<&str>,
-        language_filter: Option<&str>,
-    ) -> StorageResult<Vec<SearchResult>> {
-        let searcher = self.reader.searcher();
-
+    /// The full-text part of a search: names, doc comments, signatures and
+    /// context, with typo tolerance on names
+    fn text_query(&self, query_str: &str) -> Box<dyn Query> {
         let query_parser = QueryParser::for_index(
             &self.index,
             vec![
@@ -94,21 +91,30 @@ impl DocumentIndex {
         let name_term = Term::from_field_text(self.schema.name, query_str);
         let fuzzy_whole_word_query = FuzzyTermQuery::new(name_term, 1, true);
 
-        // All queries will be collected here.
-        let mut all_clauses: Vec<(Occur, Box<dyn Query>)> = Vec::new();
-
         // The text search part: must match one of:
         // 1. Main query (ngram partial matching)
         // 2. Fuzzy on ngram tokens (typos in short queries)
         // 3. Fuzzy on whole word (typos in full symbol names)
-        all_clauses.push((
-            Occur::Must,
-            Box::new(BooleanQuery::new(vec![
-                (Occur::Should, main_query),
-                (Occur::Should, Box::new(fuzzy_ngram_query)),
-                (Occur::Should, Box::new(fuzzy_whole_word_query)),
-            ])),
-        ));
+        Box::new(BooleanQuery::new(vec![
+            (Occur::Should, main_query),
+            (Occur::Should, Box::new(fuzzy_ngram_query)),
+            (Occur::Should, Box::new(fuzzy_whole_word_query)),
+        ]))
+    }
+
+    /// Search for documents
+    pub fn search(
+        &self,
+        query_str: &str,
+        limit: usize,
+        kind_filter: Option<SymbolKind>,
+        module_filter: Option<&str>,
+        language_filter: Option<&str>,
+    ) -> StorageResult<Vec<SearchResult>> {
+        let searcher = self.reader.searcher();
+
+        let mut all_clauses: Vec<(Occur, Box<dyn Query>)> =
+            vec![(Occur::Must, self.text_query(query_str))];
 
         // Add mandatory filters.
         all_clauses.push((
@@ -149,103 +155,268 @@ impl DocumentIndex {
         let top_docs =
             searcher.search(&final_query, &TopDocs::with_limit(limit).order_by_score())?;
 
-        let mut results = Vec::new();
-        for (score, doc_address) in top_docs {
-            let doc: Document = searcher.doc(doc_address)?;
+        top_docs
+            .into_iter()
+            .map(|(score, doc_address)| {
+                let doc: Document = searcher.doc(doc_address)?;
+                self.search_result(&doc, score)
+            })
+            .collect()
+    }
 
-            // Extract fields
-            let symbol_id = doc
-                .get_first(self.schema.symbol_id)
-                .and_then(|v| v.as_u64())
-                .and_then(|id| SymbolId::new(id as u32))
-                .ok_or(StorageError::InvalidFieldValue {
-                    field: "symbol_id".to_string(),
-                    reason: "not a valid u32".to_string(),
-                })?;
+    /// The search result of a symbol document
+    fn search_result(&self, doc: &Document, score: f32) -> StorageResult<SearchResult> {
+        // Extract fields
+        let symbol_id = doc
+            .get_first(self.schema.symbol_id)
+            .and_then(|v| v.as_u64())
+            .and_then(|id| SymbolId::new(id as u32))
+            .ok_or(StorageError::InvalidFieldValue {
+                field: "symbol_id".to_string(),
+                reason: "not a valid u32".to_string(),
+            })?;
 
-            let name = doc
-                .get_first(self.schema.name)
-                .and_then(|v| v.as_str())
-                .unwrap_or("")
-                .to_string();
+        let name = doc
+            .get_first(self.schema.name)
+            .and_then(|v| v.as_str())
+            .unwrap_or("")
+            .to_string();
+
+        let file_path = doc
+            .get_first(self.schema.file_path)
+            .and_then(|v| v.as_str())
+            .unwrap_or("")
+            .to_string();
+        let file_path = self.to_portable_file_path(&file_path).unwrap_or(file_path);
+
+        let line = doc
+            .get_first(self.schema.line_number)
+            .and_then(|v| v.as_u64())
+            .unwrap_or(0) as u32;
 
-            let file_path = doc
-                .get_first(self.schema.file_path)
-                .and_then(|v| v.as_str())
-                .unwrap_or("")
-                .to_string();
-            let file_path = self.to_portable_file_path(&file_path).unwrap_or(file_path);
+        let column = doc
+            .get_first(self.schema.column)
+            .and_then(|v| v.as_u64())
+            .unwrap_or(0) as u16;
+
+        let doc_comment = doc
+            .get_first(self.schema.doc_comment)
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+
+        let signature = doc
+            .get_first(self.schema.signature)
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+
+        let context = doc
+            .get_first(self.schema.context)
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
+
+        // Extract kind from its stored Debug representation via the one
+        // kind vocabulary (SymbolKind::from_str); a partial hand-rolled
+        // map here misreported Class/Interface/Enum rows as Function.
+        let kind_str = doc
+            .get_first(self.schema.kind)
+            .and_then(|v| v.as_str())
+            .unwrap_or("");
+        let kind = kind_str.to_lowercase().parse::<SymbolKind>().map_err(|e| {
+            StorageError::InvalidFieldValue {
+                field: "kind".to_string(),
+                reason: e.to_string(),
+            }
+        })?;
 
-            let line = doc
-                .get_first(self.schema.line_number)
-                .and_then(|v| v.as_u64())
-                .unwrap_or(0) as u32;
+        let module_path = doc
+            .get_first(self.schema.module_path)
+            .and_then(|v| v.as_str())
+            .unwrap_or("")
+            .to_string();
 
-            let column = doc
-                .get_first(self.schema.column)
-                .and_then(|v| v.as_u64())
-                .unwrap_or(0) as u16;
+        let language_id = doc
+            .get_first(self.schema.language)
+            .and_then(|v| v.as_str())
+            .map(|s| s.to_string());
 
-            let doc_comment = doc
-                .get_first(self.schema.doc_comment)
-                .and_then(|v| v.as_str())
-                .map(|s| s.to_string());
+        Ok(SearchResult {
+            symbol_id,
+            name,
+            kind,
+            file_path,
+            // Stored line_number is the 0-indexed range start; scalar
+            // line fields are 1-indexed editor coordinates.
+            line: line + 1,
+            column,
+            language_id,
+            doc_comment,
+            signature,
+            module_path,
+            score,
+            highlights: Vec::new(), // TODO: Implement highlighting
+            context,
+        })
+    }
 
-            let signature = doc
-                .get_first(self.schema.signature)
-                .and_then(|v| v.as_str())
-                .map(|s| s.to_string());
+    /// Symbols matching every term of `query`, best first when it has
+    /// words to rank by, else in file and line order
+    pub fn query_symbols(
+        &self,
+        query: &SymbolQuery,
+        limit: usize,
+    ) -> StorageResult<Vec<SearchResult>> {
+        let mut clauses: Vec<(Occur, Box<dyn Query>)> = vec![(
+            Occur::Must,
+            Box::new(TermQuery::new(
+                Term::from_field_text(self.schema.doc_type, "symbol"),
+                IndexRecordOption::Basic,
+            )),
+        )];
+        for term in &query.terms {
+            let mut alternatives = Vec::with_capacity(term.values.len());
+            if term.field.is_relation() {
+                let ids = self.related_symbols(term)?;
+                if ids.is_empty() {
+                    if term.negated {
+                        continue;
+                    }
+                    // Nothing is related that way, so nothing matches
+                    return Ok(Vec::new());
+                }
+                let terms = ids
+                    .into_iter()
+                    .map(|id| Term::from_field_u64(self.schema.symbol_id, id.0 as u64));
+                alternatives.push(Box::new(TermSetQuery::new(terms)) as Box<dyn Query>);
+            } else {
+                for value in &term.values {
+                    alternatives.push(self.term_query(term.field, value)?);
+                }
+            }
+            let occur = if term.negated {
+                Occur::MustNot
+            } else {
+                Occur::Must
+            };
+            clauses.push((
+                occur,
+                Box::new(BooleanQuery::new(
+                    alternatives
+                        .into_iter()
+                        .map(|query| (Occur::Should, query))
+                        .collect(),
+                )),
+            ));
+        }
+        let final_query = BooleanQuery::new(clauses);
 
-            let context = doc
-                .get_first(self.schema.context)
-                .and_then(|v| v.as_str())
-                .map(|s| s.to_string());
+        let searcher = self.reader.searcher();
+        if query.has_text() {
+            let top_docs =
+                searcher.search(&final_query, &TopDocs::with_limit(limit).order_by_score())?;
+            return top_docs
+                .into_iter()
+                .map(|(score, doc_address)| {
+                    let doc: Document = searcher.doc(doc_address)?;
+                    self.search_result(&doc, score)
+                })
+                .collect();
+        }
 
-            // Extract kind from its stored Debug representation via the one
-            // kind vocabulary (SymbolKind::from_str); a partial hand-rolled
-            // map here misreported Class/Interface/Enum rows as Function.
-            let kind_str = doc
-                .get_first(self.schema.kind)
-                .and_then(|v| v.as_str())
-                .unwrap_or("");
-            let kind = kind_str.to_lowercase().parse::<SymbolKind>().map_err(|e| {
-                StorageError::InvalidFieldValue {
-                    field: "kind".to_string(),
-                    reason: e.to_string(),
-                }
-            })?;
+        let mut results = Self::search_all(&searcher, &final_query)?
+            .into_iter()
+            .map(|(score, doc_address)| {
+                let doc: Document = searcher.doc(doc_address)?;
+                self.search_result(&doc, score)
+            })
+            .collect::<StorageResult<Vec<_>>>()?;
+        results.sort_by(|a, b| {
+            (&a.file_path, a.line, a.column).cmp(&(&b.file_path, b.line, b.column))
+        });
+        results.truncate(limit);
+        Ok(results)
+    }
 
-            let module_path = doc
-                .get_first(self.schema.module_path)
-                .and_then(|v| v.as_str())
-                .unwrap_or("")
-                .to_string();
+    /// The query of one value of a term on the symbol's own fields
+    fn term_query(&self, field: QueryField, value: &str) -> StorageResult<Box<dyn Query>> {
+        let exact = |field, text: &str| -> Box<dyn Query> {
+            Box::new(TermQuery::new(
+                Term::from_field_text(field, text),
+                IndexRecordOption::Basic,
+            ))
+        };
+        let regex = |field, pattern: &str| -> StorageResult<Box<dyn Query>> {
+            let query = RegexQuery::from_pattern(pattern, field)
+                .map_err(|e| StorageError::General(format!("Invalid pattern '{value}': {e}")))?;
+            Ok(Box::new(query))
+        };
+        // Words of a text field, in order
+        let words = |field| -> Box<dyn Query> {
+            let parser = QueryParser::for_index(&self.index, vec![field]);
+            let phrase = format!("\"{}\"", value.replace('"', ""));
+            parser
+                .parse_query(&phrase)
+                .unwrap_or_else(|_| exact(field, &value.to_lowercase()))
+        };
 
-            let language_id = doc
-                .get_first(self.schema.language)
-                .and_then(|v| v.as_str())
-                .map(|s| s.to_string());
+        Ok(match field {
+            // Parsing took only built-in and framework kinds
+            QueryField::Kind => match value.parse::<SymbolKind>() {
+                Ok(kind) => exact(self.schema.kind, &format!("{kind:?}")),
+                Err(_) => exact(self.schema.framework_kind, &value.to_lowercase()),
+            },
+            QueryField::Lang => exact(self.schema.language, &value.to_lowercase()),
+            QueryField::Name if value.contains('*') => {
+                regex(self.schema.name, &glob_regex(value, false))?
+            }
+            QueryField::Name => exact(self.schema.name, value),
+            QueryField::Module if value.contains('*') => {
+                regex(self.schema.module_path, &glob_regex(value, false))?
+            }
+            QueryField::Module => exact(self.schema.module_path, value),
+            QueryField::Path => regex(self.schema.file_path, &path_regex(value))?,
+            QueryField::Author => exact(self.schema.last_author, value),
+            QueryField::Tag => exact(self.schema.tags, &value.to_lowercase()),
+            QueryField::Doc => words(self.schema.doc_comment),
+            QueryField::Signature => words(self.schema.signature),
+            QueryField::Text => self.text_query(value),
+            QueryField::Calls
+            | QueryField::CalledBy
+            | QueryField::Implements
+            | QueryField::Extends
+            | QueryField::Uses => {
+                return Err(StorageError::General(format!(
+                    "{field:?} is not a field of the symbol"
+                )));
+            }
+        })
+    }
 
-            results.push(SearchResult {
-                symbol_id,
-                name,
-                kind,
-                file_path,
-                // Stored line_number is the 0-indexed range start; scalar
-                // line fields are 1-indexed editor coordinates.
-                line: line + 1,
-                column,
-                language_id,
-                doc_comment,
-                signature,
-                module_path,
-                score,
-                highlights: Vec::new(), // TODO: Implement highlighting
-                context,
-            });
+    /// The symbols related as a relationship term says to a symbol named
+    /// by one of its values
+    fn related_symbols(&self, term: &QueryTerm) -> StorageResult<HashSet<SymbolId>> {
+        // The stored relationship, and whether the symbols sought are its
+        // sources (else its targets)
+        let (kind, sources) = match term.field {
+            QueryField::Calls => (RelationKind::Calls, true),
+            QueryField::CalledBy => (RelationKind::Calls, false),
+            QueryField::Implements => (RelationKind::Implements, true),
+            QueryField::Extends => (RelationKind::Extends, true),
+            QueryField::Uses => (RelationKind::Uses, true),
+            _ => return Ok(HashSet::new()),
+        };
+        let mut ids = HashSet::new();
+        for value in &term.values {
+            for symbol in self.find_symbols_by_name(value, None)? {
+                if sources {
+                    let edges = self.get_relationships_to(symbol.id, kind)?;
+                    ids.extend(edges.into_iter().map(|(from, _, _)| from));
+                } else {
+                    let edges = self.get_relationships_from(symbol.id, kind)?;
+                    ids.extend(edges.into_iter().map(|(_, to, _)| to));
+                }
+            }
         }
-
-        Ok(results)
+        Ok(ids)
     }
 
     /// Get total number of indexed documents
@@ -254,6 +425,25 @@ impl DocumentIndex {
         Ok(searcher.num_docs())
     }
 
+    /// Segments and documents of the index, with the files that fail
+    /// their checksum
+    pub fn segment_health(&self) -> StorageResult<SegmentHealth> {
+        let searcher = self.reader.searcher();
+        let mut corrupted_files: Vec<PathBuf> =
+            self.index.validate_checksum()?.into_iter().collect();
+        corrupted_files.sort();
+        Ok(SegmentHealth {
+            segments: searcher.segment_readers().len(),
+            documents: searcher.num_docs(),
+            deleted_documents: searcher
+                .segment_readers()
+                .iter()
+                .map(|segment| u64::from(segment.num_deleted_docs()))
+                .sum(),
+            corrupted_files,
+        })
+    }
+
     /// Find a symbol by its ID
     pub fn find_symbol_by_id(&self, id: SymbolId) -> StorageResult<Option<crate::Symbol>> {
         let searcher = self.reader.searcher();
@@ -777,6 +967,41 @@ impl DocumentIndex {
         Ok(relationships)
     }
 
+    /// Get the symbols with a relationship of any kind to a symbol
+    pub fn get_relationship_sources(&self, to_id: SymbolId) -> StorageResult<Vec<SymbolId>> {
+        let searcher = self.reader.searcher();
+        let query = BooleanQuery::from(vec![
+            (
+                Occur::Must,
+                Box::new(TermQuery::new(
+                    Term::from_field_text(self.schema.doc_type, "relationship"),
+                    IndexRecordOption::Basic,
+                )) as Box<dyn Query>,
+            ),
+            (
+                Occur::Must,
+                Box::new(TermQuery::new(
+                    Term::from_field_u64(self.schema.to_symbol_id, to_id.0 as u64),
+                    IndexRecordOption::Basic,
+                )) as Box<dyn Query>,
+            ),
+        ]);
+
+        let mut sources = Vec::new();
+        for (_score, doc_address) in Self::search_all(&searcher, &query)? {
+            let doc = searcher.doc::<Document>(doc_address)?;
+            if let Some(from_id) = doc
+                .get_first(self.schema.from_symbol_id)
+                .and_then(|v| v.as_u64())
+                .and_then(|id| SymbolId::new(id as u32))
+            {
+                sources.push(from_id);
+            }
+        }
+
+        Ok(sources)
+    }
+
     /// Get all relationships of a specific kind
     pub fn get_all_relationships_by_kind(
         &self,
@@ -936,8 +1161,150 @@ impl DocumentIndex {
         Ok(imports)
     }
 
+    /// All todo documents, in file and line order
+    pub fn get_todos(&self) -> StorageResult<Vec<crate::parsing::todo::Todo>> {
+        let query = TermQuery::new(
+            Term::from_field_text(self.schema.doc_type, "todo"),
+            IndexRecordOption::Basic,
+        );
+        let searcher = self.reader.searcher();
+        let top_docs = Self::search_all(&searcher, &query)
+            .map_err(|e| StorageError::General(format!("Todo search failed: {e}")))?;
+
+        let mut todos = Vec::with_capacity(top_docs.len());
+        for (_score, doc_address) in top_docs {
+            let doc: Document = searcher.doc(doc_address).map_err(|e| {
+                StorageError::General(format!("Failed to retrieve todo document: {e}"))
+            })?;
+            let text = |field| {
+                doc.get_first(field)
+                    .and_then(|v| v.as_str())
+                    .map(|s| s.to_string())
+            };
+            let number = |field| doc.get_first(field).and_then(|v| v.as_u64());
+
+            let file_id = number(self.schema.file_id)
+                .and_then(|id| FileId::new(id as u32))
+                .ok_or_else(|| StorageError::General("Missing todo file_id".to_string()))?;
+            todos.push(crate::parsing::todo::Todo {
+                tag: text(self.schema.todo_tag).unwrap_or_default(),
+                author: text(self.schema.todo_author),
+                text: text(self.schema.todo_text).unwrap_or_default(),
+                file_id,
+                file_path: text(self.schema.file_path).unwrap_or_default(),
+                line: number(self.schema.line_number).unwrap_or(0) as u32,
+                column: number(self.schema.column).unwrap_or(0) as u16,
+                symbol_id: number(self.schema.todo_symbol_id)
+                    .and_then(|id| SymbolId::new(id as u32)),
+            });
+        }
+        todos.sort_by(|a, b| (&a.file_path, a.line).cmp(&(&b.file_path, b.line)));
+        Ok(todos)
+    }
+
+    /// All string literal documents, in file and position order
+    pub fn get_strings(&self) -> StorageResult<Vec<crate::parsing::strings::IndexedString>> {
+        use crate::parsing::strings::{IndexedString, StringKind};
+
+        let query = TermQuery::new(
+            Term::from_field_text(self.schema.doc_type, "string"),
+            IndexRecordOption::Basic,
+        );
+        let searcher = self.reader.searcher();
+        let top_docs = Self::search_all(&searcher, &query)
+            .map_err(|e| StorageError::General(format!("String search failed: {e}")))?;
+
+        let mut strings = Vec::with_capacity(top_docs.len());
+        for (_score, doc_address) in top_docs {
+            let doc: Document = searcher.doc(doc_address).map_err(|e| {
+                StorageError::General(format!("Failed to retrieve string document: {e}"))
+            })?;
+            let text = |field| {
+                doc.get_first(field)
+                    .and_then(|v| v.as_str())
+                    .map(|s| s.to_string())
+            };
+            let number = |field| doc.get_first(field).and_then(|v| v.as_u64());
+
+            let file_id = number(self.schema.file_id)
+                .and_then(|id| FileId::new(id as u32))
+                .ok_or_else(|| StorageError::General("Missing string file_id".to_string()))?;
+            let Some(kind) = text(self.schema.string_kind)
+                .as_deref()
+                .and_then(StringKind::from_name)
+            else {
+                continue;
+            };
+            strings.push(IndexedString {
+                kind,
+                text: text(self.schema.string_text).unwrap_or_default(),
+                file_id,
+                file_path: text(self.schema.file_path).unwrap_or_default(),
+                line: number(self.schema.line_number).unwrap_or(0) as u32,
+                column: number(self.schema.column).unwrap_or(0) as u16,
+                symbol_id: number(self.schema.string_symbol_id)
+                    .and_then(|id| SymbolId::new(id as u32)),
+            });
+        }
+        strings.sort_by(|a, b| {
+            (&a.file_path, a.line, a.column).cmp(&(&b.file_path, b.line, b.column))
+        });
+        Ok(strings)
+    }
+
+    /// All unresolved reference documents, in file and position order
+    pub fn get_unresolved(&self) -> StorageResult<Vec<crate::relationship::UnresolvedReference>> {
+        let query = TermQuery::new(
+            Term::from_field_text(self.schema.doc_type, "unresolved"),
+            IndexRecordOption::Basic,
+        );
+        let searcher = self.reader.searcher();
+        let top_docs = Self::search_all(&searcher, &query)
+            .map_err(|e| StorageError::General(format!("Unresolved search failed: {e}")))?;
+
+        let mut references = Vec::with_capacity(top_docs.len());
+        for (_score, doc_address) in top_docs {
+            let doc: Document = searcher.doc(doc_address).map_err(|e| {
+                StorageError::General(format!("Failed to retrieve unresolved document: {e}"))
+            })?;
+            let text = |field| {
+                doc.get_first(field)
+                    .and_then(|v| v.as_str())
+                    .map(|s| s.to_string())
+            };
+            let number = |field| doc.get_first(field).and_then(|v| v.as_u64());
+
+            let (Some(file_id), Some(from_id)) = (
+                number(self.schema.file_id).and_then(|id| FileId::new(id as u32)),
+                number(self.schema.unresolved_symbol_id).and_then(|id| SymbolId::new(id as u32)),
+            ) else {
+                return Err(StorageError::General(
+                    "Missing unresolved file_id or symbol id".to_string(),
+                ));
+            };
+            let Some(kind) = text(self.schema.unresolved_kind)
+                .as_deref()
+                .and_then(relation_kind_from_name)
+            else {
+                continue;
+            };
+            references.push(crate::relationship::UnresolvedReference {
+                from_id,
+                to_name: text(self.schema.unresolved_name).unwrap_or_default(),
+                kind,
+                file_id,
+                file_path: text(self.schema.file_path).unwrap_or_default(),
+                line: number(self.schema.line_number).unwrap_or(0) as u32,
+                column: number(self.schema.column).unwrap_or(0) as u16,
+            });
+        }
+        references.sort_by(|a, b| {
+            (&a.file_path, a.line, a.column).cmp(&(&b.file_path, b.line, b.column))
+        });
+        Ok(references)
+    }
+
     /// Query all relationships from the index
-    #[cfg(test)]
     pub(crate) fn query_relationships(
         &self,
     ) -> StorageResult<Vec<(SymbolId, SymbolId, crate::Relationship)>> {
@@ -986,21 +1353,9 @@ impl DocumentIndex {
                 .and_then(|v| v.as_f64())
                 .unwrap_or(1.0) as f32;
 
-            // Parse RelationKind from string
-            let kind = match kind_str {
-                "Calls" => RelationKind::Calls,
-                "CalledBy" => RelationKind::CalledBy,
-                "Extends" => RelationKind::Extends,
-                "ExtendedBy" => RelationKind::ExtendedBy,
-                "Implements" => RelationKind::Implements,
-                "ImplementedBy" => RelationKind::ImplementedBy,
-                "Uses" => RelationKind::Uses,
-                "UsedBy" => RelationKind::UsedBy,
-                "Defines" => RelationKind::Defines,
-                "DefinedIn" => RelationKind::DefinedIn,
-                "References" => RelationKind::References,
-                "ReferencedBy" => RelationKind::ReferencedBy,
-                _ => continue, // Skip unknown relation kinds
+            // Skip unknown relation kinds
+            let Some(kind) = relation_kind_from_name(kind_str) else {
+                continue;
             };
 
             let mut relationship = Relationship::new(kind).with_weight(weight);
@@ -1096,6 +1451,25 @@ impl DocumentIndex {
     }
 }
 
+/// The kind a relationship document names, as `{:?}` writes it
+fn relation_kind_from_name(name: &str) -> Option<RelationKind> {
+    Some(match name {
+        "Calls" => RelationKind::Calls,
+        "CalledBy" => RelationKind::CalledBy,
+        "Extends" => RelationKind::Extends,
+        "ExtendedBy" => RelationKind::ExtendedBy,
+        "Implements" => RelationKind::Implements,
+        "ImplementedBy" => RelationKind::ImplementedBy,
+        "Uses" => RelationKind::Uses,
+        "UsedBy" => RelationKind::UsedBy,
+        "Defines" => RelationKind::Defines,
+        "DefinedIn" => RelationKind::DefinedIn,
+        "References" => RelationKind::References,
+        "ReferencedBy" => RelationKind::ReferencedBy,
+        _ => return None,
+    })
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -1188,6 +1562,274 @@ mod tests {
         );
     }
 
+    #[test]
+    fn test_store_and_retrieve_symbol_metrics() {
+        use crate::symbol::SymbolMetrics;
+
+        let temp_dir = TempDir::new().unwrap();
+        let settings = crate::config::Settings::default();
+        let index = DocumentIndex::new(temp_dir.path(), &settings).unwrap();
+        index.start_batch().unwrap();
+
+        let metrics = SymbolMetrics {
+            complexity: 7,
+            lines: 6,
+            parameters: 2,
+        };
+        let measured = crate::Symbol::new(
+            SymbolId::new(1).unwrap(),
+            "measured",
+            SymbolKind::Function,
+            FileId::new(1).unwrap(),
+            crate::Range::new(10, 0, 15, 1),
+        )
+        .with_metrics(metrics);
+        let plain = crate::Symbol::new(
+            SymbolId::new(2).unwrap(),
+            "Plain",
+            SymbolKind::Struct,
+            FileId::new(1).unwrap(),
+            crate::Range::new(20, 0, 22, 1),
+        );
+        index.index_symbol(&measured, "src/test.rs").unwrap();
+        index.index_symbol(&plain, "src/test.rs").unwrap();
+        index.commit_batch().unwrap();
+
+        let measured = index.find_symbol_by_id(measured.id).unwrap().unwrap();
+        assert_eq!(measured.metrics, Some(metrics));
+        let plain = index.find_symbol_by_id(plain.id).unwrap().unwrap();
+        assert_eq!(plain.metrics, None);
+    }
+
+    #[test]
+    fn test_store_and_retrieve_symbol_authorship() {
+        use crate::symbol::Authorship;
+
+        let temp_dir = TempDir::new().unwrap();
+        let settings = crate::config::Settings::default();
+        let index = DocumentIndex::new(temp_dir.path(), &settings).unwrap();
+        index.start_batch().unwrap();
+
+        let authorship = Authorship {
+            author: "Alice".to_string(),
+            commit: "1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d".to_string(),
+            timestamp: 1_740_787_200,
+        };
+        let symbol = crate::Symbol::new(
+            SymbolId::new(1).unwrap(),
+            "blamed",
+            SymbolKind::Function,
+            FileId::new(1).unwrap(),
+            crate::Range::new(10, 0, 15, 1),
+        )
+        .with_authorship(authorship.clone());
+        index.index_symbol(&symbol, "src/test.rs").unwrap();
+        index.commit_batch().unwrap();
+
+        let symbol = index.find_symbol_by_id(symbol.id).unwrap().unwrap();
+        assert_eq!(symbol.authorship, Some(authorship.clone()));
+        assert_eq!(authorship.summary(), "Alice in 1a2b3c4d (2025-03-01)");
+    }
+
+    #[test]
+    fn test_store_and_query_symbol_tags() {
+        let temp_dir = TempDir::new().unwrap();
+        let settings = crate::config::Settings::default();
+        let index = DocumentIndex::new(temp_dir.path(), &settings).unwrap();
+        index.start_batch().unwrap();
+
+        let tags = vec!["deprecated".to_string(), "internal".to_string()];
+        let symbol = |id: u32, name: &str| {
+            crate::Symbol::new(
+                SymbolId::new(id).unwrap(),
+                name,
+                SymbolKind::Function,
+                FileId::new(1).unwrap(),
+                crate::Range::new(id, 0, id, 10),
+            )
+        };
+        let tagged = symbol(1, "old").with_tags(tags.clone());
+        index.index_symbol(&tagged, "src/test.rs").unwrap();
+        index
+            .index_symbol(&symbol(2, "new"), "src/test.rs")
+            .unwrap();
+        index.commit_batch().unwrap();
+
+        let found = index.find_symbol_by_id(tagged.id).unwrap().unwrap();
+        assert_eq!(found.tags, tags);
+
+        let query = SymbolQuery::parse("tag:internal").unwrap();
+        let names: Vec<String> = index
+            .query_symbols(&query, 10)
+            .unwrap()
+            .into_iter()
+            .map(|result| result.name)
+            .collect();
+        assert_eq!(names, vec!["old"]);
+    }
+
+    #[test]
+    fn test_store_and_query_framework_kinds() {
+        let temp_dir = TempDir::new().unwrap();
+        let settings = crate::config::Settings::default();
+        let index = DocumentIndex::new(temp_dir.path(), &settings).unwrap();
+        index.start_batch().unwrap();
+
+        let symbol = |id: u32, name: &str| {
+            crate::Symbol::new(
+                SymbolId::new(id).unwrap(),
+                name,
+                SymbolKind::Function,
+                FileId::new(1).unwrap(),
+                crate::Range::new(id, 0, id, 10),
+            )
+        };
+        let view = symbol(1, "index").with_framework_kind("django_view");
+        index.index_symbol(&view, "app/views.py").unwrap();
+        index
+            .index_symbol(&symbol(2, "slugify"), "app/views.py")
+            .unwrap();
+        index.commit_batch().unwrap();
+
+        let found = index.find_symbol_by_id(view.id).unwrap().unwrap();
+        assert_eq!(found.framework_kind.as_deref(), Some("django_view"));
+
+        let framework = ["django_view".to_string()];
+        let names = |query: &str| -> Vec<String> {
+            let query = SymbolQuery::parse_with_kinds(query, &framework).unwrap();
+            index
+                .query_symbols(&query, 10)
+                .unwrap()
+                .into_iter()
+                .map(|result| result.name)
+                .collect()
+        };
+        assert_eq!(names("kind:django_view"), vec!["index"]);
+        assert_eq!(names("kind:function"), vec!["index", "slugify"]);
+        assert_eq!(names("kind:function -kind:django_view"), vec!["slugify"]);
+    }
+
+    #[test]
+    fn test_store_and_remove_todos() {
+        use crate::parsing::todo::Todo;
+
+        let temp_dir = TempDir::new().unwrap();
+        let settings = crate::config::Settings::default();
+        let index = DocumentIndex::new(temp_dir.path(), &settings).unwrap();
+        index.start_batch().unwrap();
+
+        let todo = Todo {
+            tag: "FIXME".to_string(),
+            author: Some("alice".to_string()),
+            text: "retry on timeout".to_string(),
+            file_id: FileId::new(1).unwrap(),
+            file_path: "src/test.rs".to_string(),
+            line: 11,
+            column: 4,
+            symbol_id: SymbolId::new(3),
+        };
+        let file_level = Todo {
+            tag: "TODO".to_string(),
+            author: None,
+            text: "split this module".to_string(),
+            line: 0,
+            column: 0,
+            symbol_id: None,
+            ..todo.clone()
+        };
+        index.store_todo(&todo).unwrap();
+        index.store_todo(&file_level).unwrap();
+        index.commit_batch().unwrap();
+
+        assert_eq!(index.get_todos().unwrap(), [file_level, todo]);
+        assert_eq!(index.count_symbols().unwrap(), 0, "todos are not symbols");
+
+        index.start_batch().unwrap();
+        index.remove_file_documents("src/test.rs").unwrap();
+        index.commit_batch().unwrap();
+        assert!(index.get_todos().unwrap().is_empty());
+    }
+
+    #[test]
+    fn test_store_and_remove_strings() {
+        use crate::parsing::strings::{IndexedString, StringKind};
+
+        let temp_dir = TempDir::new().unwrap();
+        let settings = crate::config::Settings::default();
+        let index = DocumentIndex::new(temp_dir.path(), &settings).unwrap();
+        index.start_batch().unwrap();
+
+        let route = IndexedString {
+            kind: StringKind::Route,
+            text: "/users/{id}".to_string(),
+            file_id: FileId::new(1).unwrap(),
+            file_path: "src/routes.rs".to_string(),
+            line: 8,
+            column: 16,
+            symbol_id: SymbolId::new(2),
+        };
+        let message = IndexedString {
+            kind: StringKind::Message,
+            text: "User not found".to_string(),
+            line: 3,
+            column: 0,
+            symbol_id: None,
+            ..route.clone()
+        };
+        index.store_string(&route).unwrap();
+        index.store_string(&message).unwrap();
+        index.commit_batch().unwrap();
+
+        assert_eq!(index.get_strings().unwrap(), [message, route]);
+        assert!(
+            index.get_todos().unwrap().is_empty(),
+            "strings are not todos"
+        );
+
+        index.start_batch().unwrap();
+        index.remove_file_documents("src/routes.rs").unwrap();
+        index.commit_batch().unwrap();
+        assert!(index.get_strings().unwrap().is_empty());
+    }
+
+    #[test]
+    fn test_store_and_remove_unresolved() {
+        use crate::relationship::UnresolvedReference;
+
+        let temp_dir = TempDir::new().unwrap();
+        let settings = crate::config::Settings::default();
+        let index = DocumentIndex::new(temp_dir.path(), &settings).unwrap();
+        index.start_batch().unwrap();
+
+        let call = UnresolvedReference {
+            from_id: SymbolId::new(3).unwrap(),
+            to_name: "serve".to_string(),
+            kind: RelationKind::Calls,
+            file_id: FileId::new(1).unwrap(),
+            file_path: "apps/web/lib.rs".to_string(),
+            line: 4,
+            column: 8,
+        };
+        let base = UnresolvedReference {
+            to_name: "Handler".to_string(),
+            kind: RelationKind::Implements,
+            line: 1,
+            column: 0,
+            ..call.clone()
+        };
+        index.store_unresolved(&call).unwrap();
+        index.store_unresolved(&base).unwrap();
+        index.commit_batch().unwrap();
+
+        assert_eq!(index.get_unresolved().unwrap(), [base, call]);
+        assert!(index.query_relationships().unwrap().is_empty());
+
+        index.start_batch().unwrap();
+        index.remove_file_documents("apps/web/lib.rs").unwrap();
+        index.commit_batch().unwrap();
+        assert!(index.get_unresolved().unwrap().is_empty());
+    }
+
     #[test]
     fn test_fuzzy_search() {
         let temp_dir = TempDir::new().unwrap();
@@ -1443,6 +2085,7 @@ mod tests {
             language_id: LanguageId::new("rust"),
             timestamp: 1234567890,
             mtime: 0,
+            encoding: None,
         };
         index.store_file_registration(&registration).unwrap();
 
@@ -1496,6 +2139,7 @@ mod tests {
                 language_id: LanguageId::new("rust"),
                 timestamp: 1234567890,
                 mtime: 0,
+                encoding: None,
             };
             index.store_file_registration(&registration).unwrap();
             println!("  - Added: {path}");
@@ -2268,6 +2912,7 @@ mod tests {
                 language_id: LanguageId::new("rust"),
                 timestamp: 1234567890,
                 mtime: 0,
+                encoding: None,
             };
             index.store_file_registration(&registration).unwrap();
 
@@ -2347,6 +2992,7 @@ mod tests {
             language_id: LanguageId::new("rust"),
             timestamp: 1234567890,
             mtime: 0,
+            encoding: None,
         };
         index.store_file_registration(&registration).unwrap();
 
@@ -25,7 +25,14 @@ pub struct IndexSchema {
     pub context: Field,
     pub visibility: Field,
     pub scope_context: Field,
-    pub language: Field, // Language identifier for the symbol
+    pub language: Field,   // Language identifier for the symbol
+    pub complexity: Field, // Cyclomatic complexity of a measured function
+    pub parameter_count: Field,
+    pub last_author: Field, // From git blame, with last_commit and last_modified
+    pub last_commit: Field,
+    pub last_modified: Field,
+    pub tags: Field,           // Labels of `codanna:tag=` directives, a value each
+    pub framework_kind: Field, // Kind of a `[[languages.<name>.kinds]]` rule
 
     // Relationship fields
     pub from_symbol_id: Field,
@@ -43,6 +50,7 @@ pub struct IndexSchema {
     pub file_hash: Field,
     pub file_timestamp: Field,
     pub file_mtime: Field,
+    pub file_encoding: Field, // Set for files transcoded to UTF-8
 
     // Metadata fields
     pub meta_key: Field,
@@ -59,6 +67,22 @@ pub struct IndexSchema {
     pub import_alias: Field,        // Optional alias
     pub import_is_glob: Field,      // Boolean (0/1) for glob imports
     pub import_is_type_only: Field, // Boolean (0/1) for type-only imports (TypeScript)
+
+    // Todo fields (tagged comments; location in file_path, line_number, column)
+    pub todo_tag: Field,
+    pub todo_author: Field,
+    pub todo_text: Field,
+    pub todo_symbol_id: Field, // Symbol the comment is about, if any
+
+    // String literal fields (notable literals; location as for todos)
+    pub string_kind: Field,
+    pub string_text: Field,
+    pub string_symbol_id: Field, // Symbol holding the literal, if any
+
+    // Unresolved reference fields (names kept by shard indexes; location as for todos)
+    pub unresolved_name: Field,
+    pub unresolved_kind: Field,
+    pub unresolved_symbol_id: Field, // Symbol making the reference
 }
 
 impl IndexSchema {
@@ -118,6 +142,21 @@ impl IndexSchema {
         let scope_context = builder.add_text_field("scope_context", STRING | STORED);
         let language = builder.add_text_field("language", STRING | STORED | FAST);
 
+        // Function metrics; line counts come from the range
+        let complexity = builder.add_u64_field("complexity", STORED);
+        let parameter_count = builder.add_u64_field("parameter_count", STORED);
+
+        // Authorship from git blame
+        let last_author = builder.add_text_field("last_author", STRING | STORED);
+        let last_commit = builder.add_text_field("last_commit", STRING | STORED);
+        let last_modified = builder.add_u64_field("last_modified", STORED);
+
+        // Labels of `codanna:tag=` directives, for exact `tag:` queries
+        let tags = builder.add_text_field("tags", STRING | STORED);
+
+        // Kinds frameworks define, for exact `kind:` queries
+        let framework_kind = builder.add_text_field("framework_kind", STRING | STORED);
+
         // Relationship fields
         let from_symbol_id = builder.add_u64_field("from_symbol_id", indexed_u64_options.clone());
         let to_symbol_id = builder.add_u64_field("to_symbol_id", indexed_u64_options.clone());
@@ -134,6 +173,7 @@ impl IndexSchema {
         let file_hash = builder.add_text_field("file_hash", STRING | STORED);
         let file_timestamp = builder.add_u64_field("file_timestamp", STORED | FAST);
         let file_mtime = builder.add_u64_field("file_mtime", STORED | FAST);
+        let file_encoding = builder.add_text_field("file_encoding", STRING | STORED);
 
         // Metadata fields (for counters, etc.)
         let meta_key = builder.add_text_field("meta_key", STRING | STORED | FAST);
@@ -151,6 +191,22 @@ impl IndexSchema {
         let import_is_glob = builder.add_u64_field("import_is_glob", STORED);
         let import_is_type_only = builder.add_u64_field("import_is_type_only", STORED);
 
+        // Todo fields
+        let todo_tag = builder.add_text_field("todo_tag", STRING | STORED);
+        let todo_author = builder.add_text_field("todo_author", STRING | STORED);
+        let todo_text = builder.add_text_field("todo_text", text_options.clone());
+        let todo_symbol_id = builder.add_u64_field("todo_symbol_id", STORED);
+
+        // String literal fields
+        let string_kind = builder.add_text_field("string_kind", STRING | STORED);
+        let string_text = builder.add_text_field("string_text", text_options);
+        let string_symbol_id = builder.add_u64_field("string_symbol_id", STORED);
+
+        // Unresolved reference fields
+        let unresolved_name = builder.add_text_field("unresolved_name", STRING | STORED);
+        let unresolved_kind = builder.add_text_field("unresolved_kind", STRING | STORED);
+        let unresolved_symbol_id = builder.add_u64_field("unresolved_symbol_id", STORED);
+
         let schema = builder.build();
         let index_schema = IndexSchema {
             doc_type,
@@ -170,6 +226,13 @@ impl IndexSchema {
             visibility,
             scope_context,
             language,
+            complexity,
+            parameter_count,
+            last_author,
+            last_commit,
+            last_modified,
+            tags,
+            framework_kind,
             from_symbol_id,
             to_symbol_id,
             relation_kind,
@@ -183,6 +246,7 @@ impl IndexSchema {
             file_hash,
             file_timestamp,
             file_mtime,
+            file_encoding,
             meta_key,
             meta_value,
             cluster_id,
@@ -193,6 +257,16 @@ impl IndexSchema {
             import_alias,
             import_is_glob,
             import_is_type_only,
+            todo_tag,
+            todo_author,
+            todo_text,
+            todo_symbol_id,
+            string_kind,
+            string_text,
+            string_symbol_id,
+            unresolved_name,
+            unresolved_kind,
+            unresolved_symbol_id,
         };
 
         (schema, index_schema)
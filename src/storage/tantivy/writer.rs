@@ -1,18 +1,29 @@
 use crate::storage::{MetadataKey, StorageError, StorageResult};
 use crate::{FileId, Relationship, SymbolId};
+use std::collections::{HashMap, HashSet};
 use tantivy::{
     IndexWriter, TantivyDocument as Document, Term,
+    indexer::NoMergePolicy,
     query::{BooleanQuery, Occur, TermQuery},
     schema::IndexRecordOption,
 };
 
-use super::DocumentIndex;
+use super::{CompactStats, DocumentIndex};
 
 impl DocumentIndex {
     /// Create index writer with retry logic for transient errors
     fn create_writer_with_retry(&self) -> Result<IndexWriter<Document>, tantivy::TantivyError> {
         for attempt in 0..self.max_retry_attempts {
-            match self.index.writer::<Document>(self.heap_size) {
+            let writer = if self.deterministic {
+                // Threads split documents between segments as they race,
+                // and merges run whenever a merge thread gets to them
+                self.index
+                    .writer_with_num_threads::<Document>(1, self.heap_size)
+                    .inspect(|writer| writer.set_merge_policy(Box::new(NoMergePolicy)))
+            } else {
+                self.index.writer::<Document>(self.heap_size)
+            };
+            match writer {
                 Ok(writer) => return Ok(writer),
                 Err(e) => {
                     // Check for transient I/O errors using ErrorKind
@@ -195,6 +206,88 @@ impl DocumentIndex {
         Ok(())
     }
 
+    /// Compact the index: drop the symbols of files no longer registered
+    /// and the relationships whose ends are gone, then merge every segment
+    /// into one, which purges the documents incremental runs deleted, and
+    /// remove the segment files the merge made obsolete.
+    ///
+    /// Must not run inside a batch.
+    pub fn compact(&self) -> StorageResult<CompactStats> {
+        if self
+            .writer
+            .read()
+            .map_err(|_| StorageError::LockPoisoned)?
+            .is_some()
+        {
+            return Err(StorageError::General(
+                "Cannot compact the index while a batch is in progress".to_string(),
+            ));
+        }
+        let mut stats = CompactStats {
+            segments_before: self.index.searchable_segment_ids()?.len(),
+            ..Default::default()
+        };
+
+        let symbols = self.get_all_symbols(self.count_symbols()?.max(1))?;
+        let mut registered: HashMap<FileId, bool> = HashMap::new();
+        let mut live: HashSet<SymbolId> = HashSet::new();
+        let mut orphaned = Vec::new();
+        for symbol in &symbols {
+            let is_registered = match registered.get(&symbol.file_id) {
+                Some(&known) => known,
+                None => {
+                    let known = self.get_file_path(symbol.file_id)?.is_some();
+                    registered.insert(symbol.file_id, known);
+                    known
+                }
+            };
+            if is_registered {
+                live.insert(symbol.id);
+            } else {
+                orphaned.push(symbol.id);
+            }
+        }
+        // Deleting by an id removes every relationship to or from it, all
+        // of them dangling once its symbol is gone
+        let mut gone: HashSet<SymbolId> = orphaned.iter().copied().collect();
+        for (from, to, _) in self.query_relationships()? {
+            if !live.contains(&from) || !live.contains(&to) {
+                stats.dangling_relationships += 1;
+                gone.extend([from, to].into_iter().filter(|id| !live.contains(id)));
+            }
+        }
+        stats.orphaned_symbols = orphaned.len();
+
+        if !gone.is_empty() {
+            self.start_batch()?;
+            for id in &orphaned {
+                self.delete_symbol(*id)?;
+            }
+            for id in &gone {
+                self.delete_relationships_for_symbol(*id)?;
+            }
+            self.commit_batch()?;
+        }
+
+        let searcher = self.reader.searcher();
+        stats.purged_documents = searcher
+            .segment_readers()
+            .iter()
+            .map(|segment| u64::from(segment.num_deleted_docs()))
+            .sum();
+        let segments = self.index.searchable_segment_ids()?;
+        let mut writer = self.create_writer_with_retry()?;
+        if segments.len() > 1 || stats.purged_documents > 0 {
+            writer.merge(&segments).wait()?;
+        }
+        writer.garbage_collect_files().wait()?;
+        writer.wait_merging_threads()?;
+        self.reader.reload()?;
+
+        stats.segments_after = self.index.searchable_segment_ids()?.len();
+        Ok(stats)
+    }
+
     /// Update the pending symbol counter (for cross-file symbol ID continuity in batches)
     pub fn update_pending_symbol_counter(&self, new_value: u32) -> StorageResult<()> {
         if let Ok(mut pending_guard) = self.pending_symbol_counter.lock() {
@@ -245,6 +338,24 @@ impl DocumentIndex {
         Ok(())
     }
 
+    /// Delete the relationships a symbol is the source of
+    pub fn delete_relationships_from_symbol(&self, id: SymbolId) -> StorageResult<()> {
+        let writer_lock = match self.writer.read() {
+            Ok(lock) => lock,
+            Err(poisoned) => {
+                eprintln!(
+                    "Warning: Recovering from poisoned writer rwlock in delete_relationships"
+                );
+                poisoned.into_inner()
+            }
+        };
+        let writer = writer_lock.as_ref().ok_or(StorageError::NoActiveBatch)?;
+
+        let from_term = Term::from_field_u64(self.schema.from_symbol_id, id.0 as u64);
+        writer.delete_term(from_term);
+        Ok(())
+    }
+
     /// Store a relationship between two symbols
     pub(crate) fn store_relationship(
         &self,
@@ -324,6 +435,9 @@ impl DocumentIndex {
         doc.add_text(self.schema.file_hash, &registration.content_hash);
         doc.add_u64(self.schema.file_timestamp, registration.timestamp);
         doc.add_u64(self.schema.file_mtime, registration.mtime);
+        if let Some(encoding) = &registration.encoding {
+            doc.add_text(self.schema.file_encoding, encoding);
+        }
         // Store language for incremental indexing (parser selection)
         doc.add_text(self.schema.language, registration.language_id.as_str());
 
@@ -407,6 +521,103 @@ impl DocumentIndex {
         Ok(())
     }
 
+    /// Store a todo document in the index
+    ///
+    /// Carries the file path, so removing the file's documents removes it.
+    pub fn store_todo(&self, todo: &crate::parsing::todo::Todo) -> StorageResult<()> {
+        let writer_lock = match self.writer.read() {
+            Ok(lock) => lock,
+            Err(poisoned) => {
+                eprintln!("Warning: Recovering from poisoned writer rwlock in store_todo");
+                poisoned.into_inner()
+            }
+        };
+        let writer = writer_lock.as_ref().ok_or(StorageError::NoActiveBatch)?;
+
+        let mut doc = Document::new();
+        doc.add_text(self.schema.doc_type, "todo");
+        doc.add_text(self.schema.todo_tag, &todo.tag);
+        if let Some(author) = &todo.author {
+            doc.add_text(self.schema.todo_author, author);
+        }
+        doc.add_text(self.schema.todo_text, &todo.text);
+        doc.add_u64(self.schema.file_id, todo.file_id.value() as u64);
+        doc.add_text(self.schema.file_path, &todo.file_path);
+        doc.add_u64(self.schema.line_number, todo.line as u64);
+        doc.add_u64(self.schema.column, todo.column as u64);
+        if let Some(symbol_id) = todo.symbol_id {
+            doc.add_u64(self.schema.todo_symbol_id, symbol_id.value() as u64);
+        }
+
+        writer.add_document(doc)?;
+        Ok(())
+    }
+
+    /// Store a string literal document in the index
+    ///
+    /// Carries the file path, so removing the file's documents removes it.
+    pub fn store_string(
+        &self,
+        literal: &crate::parsing::strings::IndexedString,
+    ) -> StorageResult<()> {
+        let writer_lock = match self.writer.read() {
+            Ok(lock) => lock,
+            Err(poisoned) => {
+                eprintln!("Warning: Recovering from poisoned writer rwlock in store_string");
+                poisoned.into_inner()
+            }
+        };
+        let writer = writer_lock.as_ref().ok_or(StorageError::NoActiveBatch)?;
+
+        let mut doc = Document::new();
+        doc.add_text(self.schema.doc_type, "string");
+        doc.add_text(self.schema.string_kind, literal.kind.as_str());
+        doc.add_text(self.schema.string_text, &literal.text);
+        doc.add_u64(self.schema.file_id, literal.file_id.value() as u64);
+        doc.add_text(self.schema.file_path, &literal.file_path);
+        doc.add_u64(self.schema.line_number, literal.line as u64);
+        doc.add_u64(self.schema.column, literal.column as u64);
+        if let Some(symbol_id) = literal.symbol_id {
+            doc.add_u64(self.schema.string_symbol_id, symbol_id.value() as u64);
+        }
+
+        writer.add_document(doc)?;
+        Ok(())
+    }
+
+    /// Store an unresolved reference document in the index
+    ///
+    /// Carries the file path, so removing the file's documents removes it.
+    pub fn store_unresolved(
+        &self,
+        reference: &crate::relationship::UnresolvedReference,
+    ) -> StorageResult<()> {
+        let writer_lock = match self.writer.read() {
+            Ok(lock) => lock,
+            Err(poisoned) => {
+                eprintln!("Warning: Recovering from poisoned writer rwlock in store_unresolved");
+                poisoned.into_inner()
+            }
+        };
+        let writer = writer_lock.as_ref().ok_or(StorageError::NoActiveBatch)?;
+
+        let mut doc = Document::new();
+        doc.add_text(self.schema.doc_type, "unresolved");
+        doc.add_text(self.schema.unresolved_name, &reference.to_name);
+        doc.add_text(self.schema.unresolved_kind, format!("{:?}", reference.kind));
+        doc.add_u64(
+            self.schema.unresolved_symbol_id,
+            reference.from_id.value() as u64,
+        );
+        doc.add_u64(self.schema.file_id, reference.file_id.value() as u64);
+        doc.add_text(self.schema.file_path, &reference.file_path);
+        doc.add_u64(self.schema.line_number, reference.line as u64);
+        doc.add_u64(self.schema.column, reference.column as u64);
+
+        writer.add_document(doc)?;
+        Ok(())
+    }
+
     /// Store metadata (counters, etc.)
     pub(crate) fn store_metadata(&self, key: MetadataKey, value: u64) -> StorageResult<()> {
         let writer_lock = match self.writer.read() {
@@ -468,4 +679,69 @@ mod tests {
             "staged delete must be discarded by rollback"
         );
     }
+
+    #[test]
+    fn test_compact_drops_dead_documents() {
+        use crate::indexing::pipeline::FileRegistration;
+        use crate::parsing::LanguageId;
+
+        let temp_dir = TempDir::new().unwrap();
+        let settings = crate::config::Settings::default();
+        let index = DocumentIndex::new(temp_dir.path(), &settings).unwrap();
+        let symbol = |id: u32, file_id: u32| {
+            crate::Symbol::new(
+                SymbolId::new(id).unwrap(),
+                format!("symbol_{id}"),
+                crate::SymbolKind::Function,
+                FileId::new(file_id).unwrap(),
+                crate::Range::new(id, 0, id, 10),
+            )
+        };
+        let calls = crate::Relationship::new(crate::RelationKind::Calls);
+
+        index.start_batch().unwrap();
+        index
+            .store_file_registration(&FileRegistration {
+                path: "src/lib.rs".into(),
+                file_id: FileId::new(1).unwrap(),
+                content_hash: "abc".to_string(),
+                language_id: LanguageId::new("rust"),
+                timestamp: 1700000000,
+                mtime: 1700000000,
+                encoding: None,
+            })
+            .unwrap();
+        index.index_symbol(&symbol(1, 1), "src/lib.rs").unwrap();
+        index.index_symbol(&symbol(2, 1), "src/lib.rs").unwrap();
+        // File 2 was never registered, symbol 4 was never stored
+        index.index_symbol(&symbol(3, 2), "src/gone.rs").unwrap();
+        let id = |id| SymbolId::new(id).unwrap();
+        index.store_relationship(id(1), id(2), &calls).unwrap();
+        index.store_relationship(id(1), id(3), &calls).unwrap();
+        index.store_relationship(id(2), id(4), &calls).unwrap();
+        index.commit_batch().unwrap();
+        // A second commit leaves a second segment with a deleted document
+        index.start_batch().unwrap();
+        index.delete_symbol(id(2)).unwrap();
+        index.index_symbol(&symbol(2, 1), "src/lib.rs").unwrap();
+        index.commit_batch().unwrap();
+
+        let stats = index.compact().unwrap();
+        assert_eq!(stats.orphaned_symbols, 1);
+        assert_eq!(stats.dangling_relationships, 2);
+        assert!(stats.purged_documents >= 1);
+        assert!(stats.segments_before >= 2);
+        assert_eq!(stats.segments_after, 1);
+
+        assert!(index.find_symbol_by_id(id(3)).unwrap().is_none());
+        assert!(index.find_symbol_by_id(id(2)).unwrap().is_some());
+        let relationships = index.query_relationships().unwrap();
+        assert_eq!(relationships.len(), 1);
+        assert_eq!((relationships[0].0, relationships[0].1), (id(1), id(2)));
+
+        // Nothing left to remove the second time
+        let stats = index.compact().unwrap();
+        assert_eq!(stats.orphaned_symbols + stats.dangling_relationships, 0);
+        assert_eq!(stats.purged_documents, 0);
+    }
 }
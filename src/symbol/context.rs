@@ -1,6 +1,7 @@
 //! Symbol context aggregation for comprehensive metadata display
 
 use crate::relationship::RelationshipMetadata;
+use crate::symbol::snippet::SourceSnippet;
 use crate::{Symbol, Visibility};
 use bitflags::bitflags;
 use serde::Serialize;
@@ -15,6 +16,12 @@ pub struct SymbolContext {
     pub file_path: String,
     /// All relationships this symbol has
     pub relationships: SymbolRelationships,
+    /// Owners CODEOWNERS gives the symbol's file
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub owners: Vec<String>,
+    /// Source lines of the symbol, when the caller asked for them
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub source: Option<SourceSnippet>,
 }
 
 /// Container for all types of symbol relationships
@@ -24,6 +31,12 @@ pub struct SymbolRelationships {
     pub implements: Option<Vec<Symbol>>,
     /// What types implement this trait
     pub implemented_by: Option<Vec<Symbol>>,
+    /// Where this C/C++ definition is declared (its header prototypes)
+    pub declarations: Option<Vec<Symbol>>,
+    /// Where this C/C++ header declaration is defined
+    pub definitions: Option<Vec<Symbol>>,
+    /// The other declarations of this C# partial type
+    pub parts: Option<Vec<Symbol>>,
     /// What base class(es) this class extends
     pub extends: Option<Vec<Symbol>>,
     /// What classes extend this base class
@@ -133,6 +146,7 @@ impl SymbolContext {
         let mut output = String::new();
         self.append_header(&mut output, indent);
         self.append_metadata(&mut output, indent);
+        self.append_source(&mut output, indent);
         self.append_relationships(&mut output, indent);
         output
     }
@@ -167,10 +181,36 @@ impl SymbolContext {
             ));
         }
 
-        // Documentation preview
+        if let Some(kind) = &self.symbol.framework_kind {
+            output.push_str(&format!("{indent}Framework kind: {kind}\n"));
+        }
+
+        if !self.symbol.tags.is_empty() {
+            output.push_str(&format!("{indent}Tags: {}\n", self.symbol.tags.join(", ")));
+        }
+
+        if let Some(metrics) = &self.symbol.metrics {
+            output.push_str(&format!(
+                "{indent}Metrics: complexity {}, {} lines, {} parameters\n",
+                metrics.complexity, metrics.lines, metrics.parameters
+            ));
+        }
+
+        if let Some(authorship) = &self.symbol.authorship {
+            output.push_str(&format!("{indent}Last changed: {}\n", authorship.summary()));
+        }
+
+        if !self.owners.is_empty() {
+            output.push_str(&format!("{indent}Owners: {}\n", self.owners.join(" ")));
+        }
+
+        // Documentation preview; in full next to the source
         if let Some(doc) = self.symbol.as_doc_comment() {
             let preview: Vec<&str> = doc.lines().take(2).collect();
-            if !preview.is_empty() {
+            if self.source.is_some() {
+                output.push_str(&format!("{indent}Doc:\n"));
+                Self::write_multiline(output, doc, indent, 2);
+            } else if !preview.is_empty() {
                 output.push_str(&format!("{}Doc: {}", indent, preview.join(" ")));
                 if doc.lines().count() > 2 {
                     output.push_str("...");
@@ -180,6 +220,18 @@ impl SymbolContext {
         }
     }
 
+    fn append_source(&self, output: &mut String, indent: &str) {
+        if let Some(source) = &self.source {
+            output.push_str(&format!(
+                "{indent}Source ({}-{}{}):\n",
+                source.start_line,
+                source.end_line,
+                if source.truncated { ", truncated" } else { "" }
+            ));
+            Self::write_multiline(output, &source.code, indent, 2);
+        }
+    }
+
     fn append_relationships(&self, output: &mut String, indent: &str) {
         // Implementations
         if let Some(impls) = &self.relationships.implements {
@@ -217,6 +269,51 @@ impl SymbolContext {
             }
         }
 
+        // Header declarations and their definitions (C/C++)
+        if let Some(declarations) = &self.relationships.declarations {
+            if !declarations.is_empty() {
+                output.push_str(&format!("{indent}Declared at:\n"));
+                for declaration in declarations {
+                    output.push_str(&format!(
+                        "{}  - {} ({:?}) at {}\n",
+                        indent,
+                        declaration.name,
+                        declaration.kind,
+                        SymbolContext::symbol_location(declaration)
+                    ));
+                }
+            }
+        }
+        if let Some(definitions) = &self.relationships.definitions {
+            if !definitions.is_empty() {
+                output.push_str(&format!("{indent}Defined at:\n"));
+                for definition in definitions {
+                    output.push_str(&format!(
+                        "{}  - {} ({:?}) at {}\n",
+                        indent,
+                        definition.name,
+                        definition.kind,
+                        SymbolContext::symbol_location(definition)
+                    ));
+                }
+            }
+        }
+
+        if let Some(parts) = &self.relationships.parts {
+            if !parts.is_empty() {
+                output.push_str(&format!("{indent}Other parts:\n"));
+                for part in parts {
+                    output.push_str(&format!(
+                        "{}  - {} ({:?}) at {}\n",
+                        indent,
+                        part.name,
+                        part.kind,
+                        SymbolContext::symbol_location(part)
+                    ));
+                }
+            }
+        }
+
         // Extends (what base class this extends)
         if let Some(extends) = &self.relationships.extends {
             if !extends.is_empty() {
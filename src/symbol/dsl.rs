@@ -0,0 +1,324 @@
+//! The query language of `retrieve query` and `query_symbols`
+//!
+//! A query is a list of terms that all must hold, so a compound filter is
+//! one call instead of several intersected by the client:
+//!
+//! ```text
+//! kind:function lang:rust path:src/parsing/** calls:parse_file doc:"utf-8"
+//! ```
+//!
+//! - `kind:`, `lang:`, `name:`, `module:`, `path:`, `author:` and `tag:`
+//!   match the symbol's own fields. Names and modules are exact unless they
+//!   hold a `*`; paths are globs, or take everything under a plain
+//!   directory. Tags are those of `codanna:tag=` directives, and kinds
+//!   include the framework kinds of `[[languages.<name>.kinds]]` rules.
+//! - `doc:` and `sig:` match words of the doc comment and signature, in
+//!   order when quoted.
+//! - `calls:`, `called_by:`, `implements:`, `extends:` and `uses:` take the
+//!   symbols related that way to a symbol of that name.
+//! - A bare word is searched for like `retrieve search` does.
+//!
+//! `-` before a term negates it, and `a,b` takes either value, except in
+//! words: `kind:function,method -path:tests/**`. Values with spaces are
+//! quoted.
+
+/// The field a term matches
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum QueryField {
+    Kind,
+    Lang,
+    Name,
+    Module,
+    Path,
+    Author,
+    Tag,
+    Doc,
+    Signature,
+    Calls,
+    CalledBy,
+    Implements,
+    Extends,
+    Uses,
+    /// A bare word, searched for in names, doc comments and signatures
+    Text,
+}
+
+impl QueryField {
+    /// Keys accepted before the `:`, as listed in errors
+    pub const KEYS: &'static str = "kind, lang, name, module, path, author, tag, doc, sig, calls, called_by, implements, extends, uses";
+
+    fn from_key(key: &str) -> Option<Self> {
+        Some(match key {
+            "kind" => Self::Kind,
+            "lang" | "language" => Self::Lang,
+            "name" => Self::Name,
+            "module" => Self::Module,
+            "path" | "file" => Self::Path,
+            "author" => Self::Author,
+            "tag" => Self::Tag,
+            "doc" => Self::Doc,
+            "sig" | "signature" => Self::Signature,
+            "calls" => Self::Calls,
+            "called_by" => Self::CalledBy,
+            "implements" => Self::Implements,
+            "extends" => Self::Extends,
+            "uses" => Self::Uses,
+            _ => return None,
+        })
+    }
+
+    /// Whether the term is on the symbol's relationships
+    pub fn is_relation(self) -> bool {
+        matches!(
+            self,
+            Self::Calls | Self::CalledBy | Self::Implements | Self::Extends | Self::Uses
+        )
+    }
+}
+
+/// One term of a query: the field matches one of `values`, or none of
+/// them when negated
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct QueryTerm {
+    pub field: QueryField,
+    pub values: Vec<String>,
+    pub negated: bool,
+}
+
+/// A parsed query
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SymbolQuery {
+    pub terms: Vec<QueryTerm>,
+}
+
+impl SymbolQuery {
+    /// Parse `query`, naming the term that is invalid
+    pub fn parse(query: &str) -> Result<Self, String> {
+        Self::parse_with_kinds(query, &[])
+    }
+
+    /// Parse `query`, where `kind:` also takes the framework kinds
+    /// `framework_kinds` (see [`crate::parsing::kinds`])
+    pub fn parse_with_kinds(query: &str, framework_kinds: &[String]) -> Result<Self, String> {
+        let mut terms = Vec::new();
+        for word in split_words(query)? {
+            let (negated, word) = match word.strip_prefix('-') {
+                Some(rest) if !rest.is_empty() => (true, rest.to_string()),
+                _ => (false, word),
+            };
+            let (field, value) = match split_key(&word) {
+                Some((key, value)) => {
+                    let field = QueryField::from_key(&key.to_lowercase()).ok_or_else(|| {
+                        format!(
+                            "unknown filter '{key}:'; expected one of: {}",
+                            QueryField::KEYS
+                        )
+                    })?;
+                    (field, value)
+                }
+                None => (QueryField::Text, word.as_str()),
+            };
+
+            // Words are matched as written, commas and all
+            let values: Vec<String> = if matches!(
+                field,
+                QueryField::Text | QueryField::Doc | QueryField::Signature
+            ) {
+                vec![value.to_string()]
+            } else {
+                value
+                    .split(',')
+                    .map(str::trim)
+                    .filter(|value| !value.is_empty())
+                    .map(str::to_string)
+                    .collect()
+            };
+            if values.iter().all(|value| value.is_empty()) {
+                return Err(format!("'{word}' has no value"));
+            }
+            if field == QueryField::Kind {
+                for value in &values {
+                    if let Err(e) = value.parse::<crate::SymbolKind>() {
+                        if !framework_kinds.contains(&value.to_lowercase()) {
+                            return Err(format!("kind: {e}"));
+                        }
+                    }
+                }
+            }
+            terms.push(QueryTerm {
+                field,
+                values,
+                negated,
+            });
+        }
+        if terms.iter().all(|term| term.negated) {
+            return Err("the query needs a term that is not negated".to_string());
+        }
+        Ok(Self { terms })
+    }
+
+    /// Whether a bare word ranks the results
+    pub fn has_text(&self) -> bool {
+        self.terms
+            .iter()
+            .any(|term| term.field == QueryField::Text && !term.negated)
+    }
+}
+
+/// Split on whitespace outside double quotes, dropping the quotes
+fn split_words(query: &str) -> Result<Vec<String>, String> {
+    let mut words = Vec::new();
+    let mut word = String::new();
+    let mut quoted = false;
+    // A quoted "" is a word of its own even though empty
+    let mut started = false;
+    for c in query.chars() {
+        match c {
+            '"' => {
+                quoted = !quoted;
+                started = true;
+            }
+            c if c.is_whitespace() && !quoted => {
+                if started {
+                    words.push(std::mem::take(&mut word));
+                    started = false;
+                }
+            }
+            c => {
+                word.push(c);
+                started = true;
+            }
+        }
+    }
+    if quoted {
+        return Err("unclosed '\"' in the query".to_string());
+    }
+    if started {
+        words.push(word);
+    }
+    if words.is_empty() {
+        return Err("empty query".to_string());
+    }
+    Ok(words)
+}
+
+/// `key` and `value` of `key:value`; words like `std::io` are text
+fn split_key(word: &str) -> Option<(&str, &str)> {
+    let (key, value) = word.split_once(':')?;
+    let is_key = !key.is_empty()
+        && key.chars().all(|c| c.is_ascii_alphanumeric() || c == '_')
+        && !value.starts_with(':');
+    is_key.then_some((key, value))
+}
+
+/// The regex of the names or paths `pattern` matches: `**` crosses
+/// directories when `directories`, `*` and `?` do not
+pub fn glob_regex(pattern: &str, directories: bool) -> String {
+    let mut regex = String::new();
+    let mut chars = pattern.chars().peekable();
+    while let Some(c) = chars.next() {
+        match c {
+            '*' if chars.peek() == Some(&'*') => {
+                chars.next();
+                if directories && chars.peek() == Some(&'/') {
+                    chars.next();
+                    regex.push_str("(.*/)?");
+                } else {
+                    regex.push_str(".*");
+                }
+            }
+            '*' if directories => regex.push_str("[^/]*"),
+            '*' => regex.push_str(".*"),
+            '?' if directories => regex.push_str("[^/]"),
+            '?' => regex.push('.'),
+            c => regex.push_str(&regex::escape(c.encode_utf8(&mut [0; 4]))),
+        }
+    }
+    regex
+}
+
+/// The regex of the file paths `path:` takes: anywhere in the stored path,
+/// and everything under it when it is a plain directory
+pub fn path_regex(path: &str) -> String {
+    let path = path.trim_start_matches("./").trim_end_matches('/');
+    let mut regex = String::new();
+    if !path.starts_with('/') && !path.starts_with("**") {
+        regex.push_str("(.*/)?");
+    }
+    regex.push_str(&glob_regex(path, true));
+    if !path.contains(['*', '?']) {
+        regex.push_str("(/.*)?");
+    }
+    regex
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_compound_query() {
+        let query = SymbolQuery::parse(
+            r#"kind:function,method lang:rust path:src/parsing/** -calls:unwrap doc:"utf-8 text" parse std::io"#,
+        )
+        .unwrap();
+        let fields: Vec<_> = query
+            .terms
+            .iter()
+            .map(|term| (term.field, term.negated))
+            .collect();
+        assert_eq!(
+            fields,
+            vec![
+                (QueryField::Kind, false),
+                (QueryField::Lang, false),
+                (QueryField::Path, false),
+                (QueryField::Calls, true),
+                (QueryField::Doc, false),
+                (QueryField::Text, false),
+                (QueryField::Text, false),
+            ]
+        );
+        assert_eq!(query.terms[0].values, vec!["function", "method"]);
+        assert_eq!(query.terms[4].values, vec!["utf-8 text"]);
+        assert_eq!(query.terms[6].values, vec!["std::io"]);
+        assert!(query.has_text());
+
+        assert!(
+            SymbolQuery::parse("colour:red")
+                .unwrap_err()
+                .contains("unknown filter")
+        );
+        assert!(
+            SymbolQuery::parse("kind:routine")
+                .unwrap_err()
+                .starts_with("kind: ")
+        );
+        assert!(SymbolQuery::parse("-kind:function").is_err());
+        let framework = ["react_component".to_string()];
+        assert!(SymbolQuery::parse_with_kinds("kind:function,react_component", &framework).is_ok());
+        assert!(SymbolQuery::parse_with_kinds("kind:routine", &framework).is_err());
+        assert!(SymbolQuery::parse("doc:\"open").is_err());
+        assert!(SymbolQuery::parse("name:").is_err());
+        assert!(SymbolQuery::parse("  ").is_err());
+    }
+
+    #[test]
+    fn test_path_regex() {
+        let matches = |path: &str, file: &str| {
+            regex::Regex::new(&format!("^{}$", path_regex(path)))
+                .unwrap()
+                .is_match(file)
+        };
+        assert!(matches("src/parsing/**", "src/parsing/rust/parser.rs"));
+        assert!(matches(
+            "src/parsing/**",
+            "/home/me/codanna/src/parsing/mod.rs"
+        ));
+        assert!(!matches("src/parsing/**", "src/indexing/mod.rs"));
+        assert!(matches("src/parsing", "src/parsing/mod.rs"));
+        assert!(matches("**/tests/*.rs", "crates/a/tests/api.rs"));
+        assert!(!matches("src/*.rs", "src/parsing/mod.rs"));
+        assert_eq!(glob_regex("parse_*", false), "parse_.*");
+    }
+}
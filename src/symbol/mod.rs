@@ -1,4 +1,7 @@
 pub mod context;
+pub mod dsl;
+pub mod name_match;
+pub mod snippet;
 
 use crate::parsing::registry::LanguageId;
 use crate::types::{CompactString, FileId, Range, SymbolId, SymbolKind, compact_string};
@@ -18,6 +21,34 @@ pub enum Visibility {
     Private,
 }
 
+impl Visibility {
+    /// Lowercase name, as the API listing prints it
+    pub fn as_str(self) -> &'static str {
+        match self {
+            Self::Public => "public",
+            Self::Crate => "crate",
+            Self::Module => "module",
+            Self::Private => "private",
+        }
+    }
+}
+
+impl std::str::FromStr for Visibility {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "public" => Ok(Self::Public),
+            "crate" => Ok(Self::Crate),
+            "module" => Ok(Self::Module),
+            "private" => Ok(Self::Private),
+            _ => Err(format!(
+                "unknown visibility '{s}'; expected one of: public, crate, module, private"
+            )),
+        }
+    }
+}
+
 /// Scope context for symbol definition
 ///
 /// This enum represents where a symbol is defined in the code structure,
@@ -76,6 +107,51 @@ pub struct Symbol {
     /// This field enables language-specific filtering in searches.
     /// It's Optional for backward compatibility - existing indexes will have None.
     pub language_id: Option<LanguageId>,
+    /// Size and complexity, for functions and methods measured at index time
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub metrics: Option<SymbolMetrics>,
+    /// The last commit touching the symbol's lines, when indexed with git blame
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub authorship: Option<Authorship>,
+    /// Labels of `codanna:tag=` directives above the symbol, lowercase
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub tags: Vec<String>,
+    /// Kind of a `[[languages.<name>.kinds]]` rule matching the symbol
+    /// (`react_component`), on top of its own
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub framework_kind: Option<Box<str>>,
+}
+
+/// Size and complexity of a function or method
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SymbolMetrics {
+    /// Cyclomatic complexity: one plus the branches of the body
+    pub complexity: u32,
+    /// Lines from the first line of the symbol to its last
+    pub lines: u32,
+    /// Entries of the parameter list
+    pub parameters: u32,
+}
+
+/// Who last changed a symbol, and in which commit
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Authorship {
+    pub author: String,
+    /// Full commit hash
+    pub commit: String,
+    /// Author time of the commit, seconds since the Unix epoch
+    pub timestamp: u64,
+}
+
+impl Authorship {
+    /// `alice in 1a2b3c4d (2025-03-01)`
+    pub fn summary(&self) -> String {
+        let date = chrono::DateTime::from_timestamp(self.timestamp as i64, 0)
+            .map(|date| date.format("%Y-%m-%d").to_string())
+            .unwrap_or_default();
+        let commit = self.commit.get(..8).unwrap_or(&self.commit);
+        format!("{} in {commit} ({date})", self.author)
+    }
 }
 
 #[repr(C, align(32))]
@@ -114,6 +190,10 @@ impl Symbol {
             visibility: Visibility::Private,
             scope_context: None, // Default to None for backward compatibility
             language_id: None,   // Default to None for backward compatibility
+            metrics: None,
+            authorship: None,
+            tags: Vec::new(),
+            framework_kind: None,
         }
     }
 
@@ -166,6 +246,35 @@ impl Symbol {
         self
     }
 
+    pub fn with_metrics(mut self, metrics: SymbolMetrics) -> Self {
+        self.metrics = Some(metrics);
+        self
+    }
+
+    pub fn with_authorship(mut self, authorship: Authorship) -> Self {
+        self.authorship = Some(authorship);
+        self
+    }
+
+    pub fn with_tags(mut self, tags: Vec<String>) -> Self {
+        self.tags = tags;
+        self
+    }
+
+    pub fn with_framework_kind(mut self, kind: impl Into<Box<str>>) -> Self {
+        self.framework_kind = Some(kind.into());
+        self
+    }
+
+    /// The framework kind when a rule gave one, else the symbol's own kind,
+    /// as stats count symbols
+    pub fn kind_name(&self) -> String {
+        match &self.framework_kind {
+            Some(kind) => kind.to_string(),
+            None => format!("{:?}", self.kind),
+        }
+    }
+
     /// Get the symbol name as a string slice
     pub fn as_name(&self) -> &str {
         &self.name
@@ -327,6 +436,7 @@ impl CompactSymbol {
             11 => SymbolKind::Parameter,
             12 => SymbolKind::TypeAlias,
             13 => SymbolKind::Macro,
+            14 => SymbolKind::Given,
             _ => return None,
         };
 
@@ -343,6 +453,10 @@ impl CompactSymbol {
             visibility: Visibility::Private,
             scope_context: None, // CompactSymbol doesn't store scope info yet
             language_id: None,   // CompactSymbol doesn't store language info yet
+            metrics: None,
+            authorship: None,
+            tags: Vec::new(),
+            framework_kind: None,
         })
     }
 }
@@ -467,6 +581,7 @@ mod tests {
             SymbolKind::Parameter,
             SymbolKind::TypeAlias,
             SymbolKind::Macro,
+            SymbolKind::Given,
         ];
 
         let mut string_table = StringTable::new();
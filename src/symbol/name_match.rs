@@ -0,0 +1,248 @@
+//! Fuzzy and regex matching of symbol names
+//!
+//! Full-text search looks for the query in names, doc comments and
+//! signatures. These modes look at names alone. Fuzzy matching ignores case
+//! and separators, so `parse_file`, `parseFile` and `ParseFile` are one name.
+//! It tolerates a typo in names up to seven letters long and two in longer
+//! ones, and also matches prefixes, substrings and word initials. Regex
+//! matching runs a regular expression over the names as written.
+
+use regex::Regex;
+use std::str::FromStr;
+
+/// How `search_symbols` and `retrieve search` match the query
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum SearchMode {
+    /// Full-text search over names, doc comments and signatures
+    #[default]
+    Text,
+    /// Typo-tolerant, case- and separator-insensitive name matching
+    Fuzzy,
+    /// A regular expression over names
+    Regex,
+}
+
+impl SearchMode {
+    pub fn as_str(self) -> &'static str {
+        match self {
+            Self::Text => "text",
+            Self::Fuzzy => "fuzzy",
+            Self::Regex => "regex",
+        }
+    }
+}
+
+impl FromStr for SearchMode {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_lowercase().as_str() {
+            "text" => Ok(Self::Text),
+            "fuzzy" => Ok(Self::Fuzzy),
+            "regex" => Ok(Self::Regex),
+            _ => Err(format!(
+                "unknown search mode '{s}'; expected one of: text, fuzzy, regex"
+            )),
+        }
+    }
+}
+
+/// A fuzzy query or a regex, scoring names from 0 to 1
+#[derive(Debug, Clone)]
+pub enum NameMatcher {
+    Fuzzy {
+        /// The query folded like the names it is compared to
+        folded: Vec<char>,
+    },
+    Regex(Regex),
+}
+
+impl NameMatcher {
+    pub fn fuzzy(query: &str) -> Self {
+        Self::Fuzzy {
+            folded: fold(query),
+        }
+    }
+
+    pub fn regex(pattern: &str) -> Result<Self, regex::Error> {
+        Regex::new(pattern).map(Self::Regex)
+    }
+
+    /// How well `name` matches, None when it does not
+    pub fn score(&self, name: &str) -> Option<f32> {
+        match self {
+            Self::Fuzzy { folded } => fuzzy_score(folded, name),
+            Self::Regex(regex) => {
+                let found = regex.find(name)?;
+                if found.start() == 0 && found.end() == name.len() {
+                    Some(1.0)
+                } else {
+                    Some(0.5 + 0.4 * found.len() as f32 / name.len().max(1) as f32)
+                }
+            }
+        }
+    }
+}
+
+/// Lowercase letters and digits of a name, without separators
+fn fold(name: &str) -> Vec<char> {
+    name.chars()
+        .filter(|c| c.is_alphanumeric())
+        .flat_map(char::to_lowercase)
+        .collect()
+}
+
+/// First letter of each word of a name, lowercase: `p`, `f` and `i` for
+/// `parse_file_info` and `ParseFileInfo` alike
+fn initials(name: &str) -> Vec<char> {
+    let mut initials = Vec::new();
+    let mut previous: Option<char> = None;
+    for c in name.chars() {
+        let starts_word = c.is_alphanumeric()
+            && match previous {
+                None => true,
+                Some(p) => !p.is_alphanumeric() || (p.is_lowercase() && c.is_uppercase()),
+            };
+        if starts_word {
+            initials.extend(c.to_lowercase());
+        }
+        previous = Some(c);
+    }
+    initials
+}
+
+/// Typos tolerated in a query of `len` letters
+fn allowed_typos(len: usize) -> usize {
+    match len {
+        0..=3 => 0,
+        4..=7 => 1,
+        _ => 2,
+    }
+}
+
+/// Edits turning `a` into `b`: insertions, deletions, substitutions and
+/// swaps of adjacent letters
+fn edit_distance(a: &[char], b: &[char]) -> usize {
+    let mut rows = vec![vec![0; b.len() + 1]; a.len() + 1];
+    for (i, row) in rows.iter_mut().enumerate() {
+        row[0] = i;
+    }
+    for (j, cell) in rows[0].iter_mut().enumerate() {
+        *cell = j;
+    }
+    for i in 1..=a.len() {
+        for j in 1..=b.len() {
+            let cost = usize::from(a[i - 1] != b[j - 1]);
+            let mut best = (rows[i - 1][j] + 1)
+                .min(rows[i][j - 1] + 1)
+                .min(rows[i - 1][j - 1] + cost);
+            if i > 1 && j > 1 && a[i - 1] == b[j - 2] && a[i - 2] == b[j - 1] {
+                best = best.min(rows[i - 2][j - 2] + 1);
+            }
+            rows[i][j] = best;
+        }
+    }
+    rows[a.len()][b.len()]
+}
+
+/// Fewest edits turning `query` into some substring of `name`
+fn substring_distance(query: &[char], name: &[char]) -> usize {
+    // Starting anywhere in the name is free
+    let mut previous = vec![0; name.len() + 1];
+    for (i, &q) in query.iter().enumerate() {
+        let mut current = vec![i + 1; name.len() + 1];
+        for (j, &n) in name.iter().enumerate() {
+            current[j + 1] = (previous[j] + usize::from(q != n))
+                .min(previous[j + 1] + 1)
+                .min(current[j] + 1);
+        }
+        previous = current;
+    }
+    previous.into_iter().min().unwrap_or(query.len())
+}
+
+fn fuzzy_score(query: &[char], name: &str) -> Option<f32> {
+    if query.is_empty() {
+        return None;
+    }
+    let folded = fold(name);
+    if folded == query {
+        return Some(1.0);
+    }
+    let allowed = allowed_typos(query.len());
+    // Shorter names rank above longer ones matching as well
+    let coverage = query.len().min(folded.len()) as f32 / folded.len().max(1) as f32;
+
+    let typos = edit_distance(query, &folded);
+    if typos <= allowed {
+        return Some(0.9 - 0.1 * typos as f32);
+    }
+    if folded.starts_with(query) {
+        return Some(0.6 + 0.2 * coverage);
+    }
+    if folded.windows(query.len()).any(|window| window == query) {
+        return Some(0.5 + 0.2 * coverage);
+    }
+    let typos = substring_distance(query, &folded);
+    if typos <= allowed {
+        return Some(0.4 + 0.2 * coverage - 0.1 * typos as f32);
+    }
+    if query.len() >= 2 && initials(name).starts_with(query) {
+        return Some(0.4);
+    }
+    None
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn fuzzy(query: &str, name: &str) -> Option<f32> {
+        NameMatcher::fuzzy(query).score(name)
+    }
+
+    #[test]
+    fn test_fuzzy_ignores_case_and_separators() {
+        assert_eq!(fuzzy("parse_file", "parseFile"), Some(1.0));
+        assert_eq!(fuzzy("ParseFile", "parse_file"), Some(1.0));
+        assert_eq!(fuzzy("PARSE-FILE", "ParseFile"), Some(1.0));
+    }
+
+    #[test]
+    fn test_fuzzy_tolerates_typos() {
+        // A dropped letter and a swap
+        assert_eq!(fuzzy("ArchivService", "ArchiveService"), Some(0.9 - 0.1));
+        assert!(fuzzy("prase_file", "parse_file").is_some());
+        // Too many for a short query
+        assert!(fuzzy("fxo", "foo").is_none());
+        // A typo inside a longer name
+        assert!(fuzzy("Servce", "ArchiveService").is_some());
+        assert!(fuzzy("indexer", "parse_file").is_none());
+    }
+
+    #[test]
+    fn test_fuzzy_ranks_exact_over_prefix_over_substring() {
+        let exact = fuzzy("parse", "parse").unwrap();
+        let prefix = fuzzy("parse", "parse_file_contents").unwrap();
+        let substring = fuzzy("parse", "reparse_all_files").unwrap();
+        let acronym = fuzzy("pfc", "parse_file_contents").unwrap();
+        assert!(exact > prefix && prefix > substring && substring > acronym);
+        // Shorter names first among prefixes
+        assert!(fuzzy("parse", "parse_file").unwrap() > prefix);
+    }
+
+    #[test]
+    fn test_regex_scores_full_matches_first() {
+        let matcher = NameMatcher::regex("^get_.*_id$").unwrap();
+        assert_eq!(matcher.score("get_symbol_id"), Some(1.0));
+        assert!(matcher.score("get_symbol").is_none());
+
+        let matcher = NameMatcher::regex("Index").unwrap();
+        assert!(matcher.score("Index").unwrap() > matcher.score("SimpleIndexer").unwrap());
+        assert!(matcher.score("index").is_none());
+        assert!(NameMatcher::regex("(unclosed").is_err());
+
+        assert_eq!("Fuzzy".parse::<SearchMode>(), Ok(SearchMode::Fuzzy));
+        assert!("glob".parse::<SearchMode>().is_err());
+    }
+}
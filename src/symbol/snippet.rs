@@ -0,0 +1,136 @@
+//! Source text of a symbol for search results
+//!
+//! Search results name a file and a line range, and an agent reading them
+//! usually opens the file next. A snippet carries the lines of the symbol,
+//! with some lines around it, so the result alone is enough to read. The
+//! window is capped: a few hundred lines of a long function would crowd out
+//! every other result.
+
+use serde::Serialize;
+use std::path::Path;
+
+use crate::Symbol;
+
+/// Most lines of context above and below a symbol
+pub const MAX_CONTEXT_LINES: usize = 50;
+
+/// Most bytes of source in one snippet; the rest is cut at a line boundary
+pub const MAX_SNIPPET_BYTES: usize = 8 * 1024;
+
+/// Lines of a source file around a symbol
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct SourceSnippet {
+    /// First line of `code`, 1-indexed
+    pub start_line: u32,
+    /// Last line of `code`, 1-indexed
+    pub end_line: u32,
+    pub code: String,
+    /// Set when the size cap cut the snippet short
+    #[serde(skip_serializing_if = "std::ops::Not::not")]
+    pub truncated: bool,
+}
+
+impl SourceSnippet {
+    /// Lines `start_line..=end_line` (0-indexed) of `source`, with up to
+    /// `context_lines` more on each side
+    pub fn from_source(
+        source: &str,
+        start_line: u32,
+        end_line: u32,
+        context_lines: usize,
+    ) -> Option<Self> {
+        let lines: Vec<&str> = source.lines().collect();
+        let start = start_line as usize;
+        if start >= lines.len() {
+            return None;
+        }
+        let context = context_lines.min(MAX_CONTEXT_LINES);
+        let first = start.saturating_sub(context);
+        let last = (end_line.max(start_line) as usize)
+            .saturating_add(context)
+            .min(lines.len() - 1);
+
+        let mut code = String::new();
+        let mut end = first;
+        let mut truncated = false;
+        for (i, line) in lines[first..=last].iter().enumerate() {
+            // The first line always goes in, however long
+            if i > 0 && code.len() + line.len() + 1 > MAX_SNIPPET_BYTES {
+                truncated = true;
+                break;
+            }
+            if i > 0 {
+                code.push('\n');
+            }
+            code.push_str(line);
+            end = first + i;
+        }
+
+        Some(Self {
+            start_line: first as u32 + 1,
+            end_line: end as u32 + 1,
+            code,
+            truncated,
+        })
+    }
+
+    /// Snippet of `symbol`, read from its file; relative paths are taken
+    /// from `workspace_root` when given. None when the file cannot be read
+    /// or no longer has the symbol's lines.
+    pub fn of_symbol(
+        symbol: &Symbol,
+        workspace_root: Option<&Path>,
+        context_lines: usize,
+    ) -> Option<Self> {
+        let path = Path::new(&*symbol.file_path);
+        let path = match workspace_root {
+            Some(root) if path.is_relative() => root.join(path),
+            _ => path.to_path_buf(),
+        };
+        let source = crate::indexing::encoding::read_to_string(&path).ok()?;
+        Self::from_source(
+            &source,
+            symbol.range.start_line,
+            symbol.range.end_line,
+            context_lines,
+        )
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const SOURCE: &str = "use std::fmt;\n\n/// Adds one\nfn add_one(x: i32) -> i32 {\n    x + 1\n}\n\nfn main() {}\n";
+
+    #[test]
+    fn test_snippet_includes_context_lines() {
+        let snippet = SourceSnippet::from_source(SOURCE, 3, 5, 1).unwrap();
+        assert_eq!(snippet.start_line, 3);
+        assert_eq!(snippet.end_line, 7);
+        assert_eq!(
+            snippet.code,
+            "/// Adds one\nfn add_one(x: i32) -> i32 {\n    x + 1\n}\n"
+        );
+        assert!(!snippet.truncated);
+
+        // The window stops at the ends of the file
+        let snippet = SourceSnippet::from_source(SOURCE, 0, 0, 10).unwrap();
+        assert_eq!((snippet.start_line, snippet.end_line), (1, 8));
+        assert!(SourceSnippet::from_source(SOURCE, 20, 22, 1).is_none());
+    }
+
+    #[test]
+    fn test_snippet_is_capped() {
+        let line = "x".repeat(100);
+        let source = vec![line.as_str(); 500].join("\n");
+        let snippet = SourceSnippet::from_source(&source, 0, 499, 0).unwrap();
+        assert!(snippet.truncated);
+        assert!(snippet.code.len() <= MAX_SNIPPET_BYTES);
+        assert_eq!(snippet.code.lines().count(), snippet.end_line as usize);
+
+        // Context beyond the cap is not read
+        let snippet = SourceSnippet::from_source(&source, 250, 250, 1000).unwrap();
+        assert_eq!(snippet.start_line as usize, 251 - MAX_CONTEXT_LINES);
+    }
+}
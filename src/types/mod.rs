@@ -57,6 +57,8 @@ pub enum SymbolKind {
     Parameter,
     TypeAlias,
     Macro,
+    /// Scala `given` instances and `implicit` definitions
+    Given,
 }
 
 /// Unknown kind string passed to a `kind` filter.
@@ -70,7 +72,7 @@ impl std::fmt::Display for UnknownSymbolKind {
         write!(
             f,
             "unknown symbol kind '{}'; expected one of: function, method, struct, enum, trait, \
-             interface, class, module, variable, constant, field, parameter, typealias, macro",
+             interface, class, module, variable, constant, field, parameter, typealias, macro, given",
             self.input
         )
     }
@@ -158,6 +160,7 @@ impl FromStr for SymbolKind {
             "parameter" => Ok(SymbolKind::Parameter),
             "typealias" | "type_alias" => Ok(SymbolKind::TypeAlias),
             "macro" => Ok(SymbolKind::Macro),
+            "given" | "implicit" => Ok(SymbolKind::Given),
             _ => Err(UnknownSymbolKind {
                 input: s.to_string(),
             }),
@@ -241,9 +244,10 @@ mod tests {
             SymbolKind::Parameter,
             SymbolKind::TypeAlias,
             SymbolKind::Macro,
+            SymbolKind::Given,
         ];
 
-        assert_eq!(kinds.len(), 14);
+        assert_eq!(kinds.len(), 15);
     }
 
     #[test]
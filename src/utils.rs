@@ -4,8 +4,16 @@ use chrono::Utc;
 
 /// Get current UTC timestamp in seconds since UNIX_EPOCH.
 ///
-/// Uses chrono for accurate cross-platform timestamp.
+/// Uses chrono for accurate cross-platform timestamp. `SOURCE_DATE_EPOCH`,
+/// when set, takes the place of the clock, so reproducible builds of an
+/// index record the same times.
 pub fn get_utc_timestamp() -> u64 {
+    if let Some(epoch) = std::env::var("SOURCE_DATE_EPOCH")
+        .ok()
+        .and_then(|epoch| epoch.trim().parse().ok())
+    {
+        return epoch;
+    }
     Utc::now().timestamp() as u64
 }
 
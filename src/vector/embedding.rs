@@ -175,6 +175,11 @@ use std::sync::Mutex;
 /// ## Code-Specialized Models
 /// - `JinaEmbeddingsV2BaseCode` - Jina code embeddings, 768 dimensions
 ///
+/// ## Short Names
+/// `all-minilm`, `bge-small`, `bge-base`, `bge-large`, `multilingual-e5`
+/// (small), `multilingual-e5-base`, `multilingual-e5-large`, `nomic-embed`
+/// and `jina-code` name the models above.
+///
 /// # Example
 /// ```ignore
 /// let model = parse_embedding_model("MultilingualE5Small")?;
@@ -221,8 +226,19 @@ pub fn parse_embedding_model(model_name: &str) -> Result<EmbeddingModel, VectorE
         "JinaEmbeddingsV2BaseCode" => Ok(EmbeddingModel::JinaEmbeddingsV2BaseCode),
         "EmbeddingGemma300M" => Ok(EmbeddingModel::EmbeddingGemma300M),
 
+        // Short names
+        "all-minilm" => Ok(EmbeddingModel::AllMiniLML6V2),
+        "bge-small" => Ok(EmbeddingModel::BGESmallENV15),
+        "bge-base" => Ok(EmbeddingModel::BGEBaseENV15),
+        "bge-large" => Ok(EmbeddingModel::BGELargeENV15),
+        "multilingual-e5" | "multilingual-e5-small" => Ok(EmbeddingModel::MultilingualE5Small),
+        "multilingual-e5-base" => Ok(EmbeddingModel::MultilingualE5Base),
+        "multilingual-e5-large" => Ok(EmbeddingModel::MultilingualE5Large),
+        "nomic-embed" => Ok(EmbeddingModel::NomicEmbedTextV15),
+        "jina-code" => Ok(EmbeddingModel::JinaEmbeddingsV2BaseCode),
+
         _ => Err(VectorError::EmbeddingFailed(format!(
-            "Unknown embedding model: '{model_name}'. Supported models: AllMiniLML6V2, MultilingualE5Small, MultilingualE5Base, MultilingualE5Large, BGESmallZHV15, BGELargeZHV15, JinaEmbeddingsV2BaseCode, and more. See documentation for full list."
+            "Unknown embedding model: '{model_name}'. Supported models: AllMiniLML6V2 (all-minilm), BGESmallENV15 (bge-small), MultilingualE5Small (multilingual-e5), MultilingualE5Base, MultilingualE5Large, BGESmallZHV15, BGELargeZHV15, JinaEmbeddingsV2BaseCode, and more. See documentation for full list."
         ))),
     }
 }
@@ -270,6 +286,35 @@ pub fn model_to_string(model: &EmbeddingModel) -> String {
     .to_string()
 }
 
+/// Whether `model` was trained on many languages, so that an English query
+/// finds doc comments written in Japanese or German.
+pub fn is_multilingual_model(model: &EmbeddingModel) -> bool {
+    matches!(
+        model,
+        EmbeddingModel::MultilingualE5Small
+            | EmbeddingModel::MultilingualE5Base
+            | EmbeddingModel::MultilingualE5Large
+            | EmbeddingModel::ParaphraseMLMiniLML12V2
+            | EmbeddingModel::ParaphraseMLMiniLML12V2Q
+            | EmbeddingModel::ParaphraseMLMpnetBaseV2
+            | EmbeddingModel::EmbeddingGemma300M
+    )
+}
+
+/// Prefixes `model` was trained to see before a query and before a passage.
+///
+/// The E5 models rank noticeably worse, across languages most of all,
+/// when texts lack them.
+pub fn text_prefixes(model: &EmbeddingModel) -> Option<(&'static str, &'static str)> {
+    matches!(
+        model,
+        EmbeddingModel::MultilingualE5Small
+            | EmbeddingModel::MultilingualE5Base
+            | EmbeddingModel::MultilingualE5Large
+    )
+    .then_some(("query: ", "passage: "))
+}
+
 /// Trait for generating embeddings from text.
 ///
 /// Implementations of this trait should be thread-safe and
@@ -335,7 +380,7 @@ impl FastEmbedGenerator {
 
         let mut text_model = TextEmbedding::try_new(
             InitOptions::new(model)
-                .with_cache_dir(crate::init::models_dir())
+                .with_cache_dir(crate::init::model_cache_dir())
                 .with_show_download_progress(show_progress),
         )
         .map_err(|e| VectorError::EmbeddingFailed(
@@ -535,6 +580,7 @@ pub fn create_symbol_text(
         crate::types::SymbolKind::Class => "class",
         crate::types::SymbolKind::Field => "field",
         crate::types::SymbolKind::Parameter => "parameter",
+        crate::types::SymbolKind::Given => "given",
     };
 
     if let Some(sig) = signature {
@@ -582,6 +628,34 @@ mod tests {
         }
     }
 
+    #[test]
+    fn test_short_model_names() {
+        for (short, name) in [
+            ("bge-small", "BGESmallENV15"),
+            ("multilingual-e5", "MultilingualE5Small"),
+            ("multilingual-e5-large", "MultilingualE5Large"),
+            ("jina-code", "JinaEmbeddingsV2BaseCode"),
+        ] {
+            let model = parse_embedding_model(short).unwrap();
+            assert_eq!(model_to_string(&model), name);
+        }
+        assert!(parse_embedding_model("bge-tiny").is_err());
+    }
+
+    #[test]
+    fn test_multilingual_models_and_prefixes() {
+        let e5 = parse_embedding_model("multilingual-e5").unwrap();
+        assert!(is_multilingual_model(&e5));
+        assert_eq!(text_prefixes(&e5), Some(("query: ", "passage: ")));
+
+        let minilm = parse_embedding_model("all-minilm").unwrap();
+        assert!(!is_multilingual_model(&minilm));
+        assert_eq!(text_prefixes(&minilm), None);
+        assert!(is_multilingual_model(
+            &EmbeddingModel::ParaphraseMLMiniLML12V2
+        ));
+    }
+
     #[test]
     fn test_create_symbol_text() {
         use crate::types::SymbolKind;
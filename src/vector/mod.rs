@@ -27,11 +27,11 @@ pub use clustering::{
 #[cfg(test)]
 pub use embedding::MockEmbeddingGenerator;
 pub use embedding::{
-    EmbeddingGenerator, FastEmbedGenerator, create_symbol_text, model_to_string,
-    parse_embedding_model,
+    EmbeddingGenerator, FastEmbedGenerator, create_symbol_text, is_multilingual_model,
+    model_to_string, parse_embedding_model, text_prefixes,
 };
 pub use engine::VectorSearchEngine;
-pub use storage::{ConcurrentVectorStorage, MmapVectorStorage, VectorStorageError};
+pub use storage::{ConcurrentVectorStorage, MappedVectors, MmapVectorStorage, VectorStorageError};
 pub use types::{
     ClusterId, Score, SegmentOrdinal, VECTOR_DIMENSION_384, VectorDimension, VectorError, VectorId,
 };
@@ -16,6 +16,7 @@
 //! - Memory usage: 4 bytes per dimension per vector
 //! - Startup time: <1ms (mmap is lazy-loaded by OS)
 
+use std::collections::HashMap;
 use std::fs::{File, OpenOptions};
 use std::io::{self, Write};
 use std::path::{Path, PathBuf};
@@ -461,6 +462,128 @@ impl MmapVectorStorage {
     }
 }
 
+/// Read-only view of a vector file that hands out vectors in place.
+///
+/// Vectors are read straight from the mapped pages instead of being copied
+/// to the heap: a page is loaded the first time it is touched, and every
+/// process mapping the same file shares it through the OS page cache. Only
+/// the offset of each vector is kept in memory.
+#[derive(Debug)]
+pub struct MappedVectors {
+    mmap: Mmap,
+    dimension: VectorDimension,
+    /// Offset of the data of each vector; the last record of an ID wins,
+    /// as it does for readers of `read_all_vectors`
+    offsets: HashMap<VectorId, usize>,
+}
+
+impl MappedVectors {
+    /// Maps the vector file of `segment`.
+    ///
+    /// Returns `None` where vectors cannot be read in place: on big-endian
+    /// targets, whose f32 layout is not the file's, and off Unix, where a
+    /// live map would keep the file from being replaced on the next save.
+    /// Callers then read the vectors with `read_all_vectors`.
+    pub fn open(
+        base_path: impl AsRef<Path>,
+        segment: SegmentOrdinal,
+    ) -> Result<Option<Self>, VectorStorageError> {
+        if cfg!(any(not(unix), target_endian = "big")) {
+            return Ok(None);
+        }
+
+        let path = MmapVectorStorage::segment_path(base_path.as_ref(), segment);
+        let file = File::open(&path)?;
+        // SAFETY: vector files are saved to a staging file and renamed over
+        // the live one, so a map keeps reading the generation it opened.
+        // An in-place writer (write_batch) only appends past the mapped
+        // length and rewrites the header, which is read once, here.
+        let mmap = unsafe { MmapOptions::new().map(&file)? };
+
+        let (version, dimension, _) = MmapVectorStorage::read_header(&mmap)?;
+        if version != STORAGE_VERSION {
+            return Err(VectorError::VersionMismatch {
+                expected: STORAGE_VERSION,
+                actual: version,
+            }
+            .into());
+        }
+        // Maps start on a page boundary, so this only fails for a
+        // platform that maps otherwise
+        if mmap.as_ptr().align_offset(std::mem::align_of::<f32>()) != 0 {
+            return Ok(None);
+        }
+
+        let record_size = BYTES_PER_ID + dimension.get() * BYTES_PER_F32;
+        let mut offsets = HashMap::new();
+        let mut offset = HEADER_SIZE;
+        while offset + record_size <= mmap.len() {
+            let id_bytes = [
+                mmap[offset],
+                mmap[offset + 1],
+                mmap[offset + 2],
+                mmap[offset + 3],
+            ];
+            let id = VectorId::from_bytes(id_bytes).ok_or_else(|| {
+                VectorStorageError::InvalidFormat("Invalid vector ID".to_string())
+            })?;
+            offsets.insert(id, offset + BYTES_PER_ID);
+            offset += record_size;
+        }
+
+        Ok(Some(Self {
+            mmap,
+            dimension,
+            offsets,
+        }))
+    }
+
+    /// The vector stored for `id`, without copying it.
+    #[must_use]
+    pub fn get(&self, id: VectorId) -> Option<&[f32]> {
+        self.offsets.get(&id).map(|&offset| self.vector_at(offset))
+    }
+
+    #[must_use]
+    pub fn contains(&self, id: VectorId) -> bool {
+        self.offsets.contains_key(&id)
+    }
+
+    /// Every vector with its ID, in no particular order.
+    pub fn iter(&self) -> impl Iterator<Item = (VectorId, &[f32])> {
+        self.offsets
+            .iter()
+            .map(|(id, &offset)| (*id, self.vector_at(offset)))
+    }
+
+    /// Returns the number of distinct vectors.
+    #[must_use]
+    pub fn len(&self) -> usize {
+        self.offsets.len()
+    }
+
+    #[must_use]
+    pub fn is_empty(&self) -> bool {
+        self.offsets.is_empty()
+    }
+
+    /// Returns the vector dimension.
+    #[must_use]
+    pub fn dimension(&self) -> VectorDimension {
+        self.dimension
+    }
+
+    fn vector_at(&self, offset: usize) -> &[f32] {
+        let dimension = self.dimension.get();
+        let bytes = &self.mmap[offset..offset + dimension * BYTES_PER_F32];
+        // SAFETY: `open` only maps on little-endian targets, so the stored
+        // bytes are native f32s, and checked the map is f32-aligned; every
+        // offset is a multiple of 4 past the 16-byte header. The slice
+        // borrows the map, and any bit pattern is a valid f32.
+        unsafe { std::slice::from_raw_parts(bytes.as_ptr().cast::<f32>(), dimension) }
+    }
+}
+
 /// Thread-safe wrapper for MmapVectorStorage.
 ///
 /// Allows concurrent read access to vectors from multiple threads.
@@ -575,6 +698,34 @@ mod tests {
         );
     }
 
+    #[test]
+    fn test_mapped_vectors_read_in_place() {
+        let temp_dir = TempDir::new().unwrap();
+        let segment = SegmentOrdinal::new(0);
+        let dimension = VectorDimension::new(3).unwrap();
+
+        let mut storage = MmapVectorStorage::open_or_create(&temp_dir, segment, dimension).unwrap();
+        let (first, second) = (VectorId::new(1).unwrap(), VectorId::new(2).unwrap());
+        let (a, b, c) = ([1.0f32, 2.0, 3.0], [4.0f32, 5.0, 6.0], [7.0f32, 8.0, 9.0]);
+        storage
+            .write_batch(&[(first, a.as_slice()), (second, b.as_slice())])
+            .unwrap();
+        // A later record of the same ID replaces the earlier one
+        storage.write_batch(&[(first, c.as_slice())]).unwrap();
+
+        let Some(mapped) = MappedVectors::open(&temp_dir, segment).unwrap() else {
+            // Targets that cannot read in place fall back to read_all_vectors
+            return;
+        };
+        assert_eq!(mapped.len(), 2);
+        assert_eq!(mapped.dimension(), dimension);
+        assert_eq!(mapped.get(first), Some(&[7.0, 8.0, 9.0][..]));
+        assert_eq!(mapped.get(second), Some(&[4.0, 5.0, 6.0][..]));
+        assert!(mapped.get(VectorId::new(3).unwrap()).is_none());
+        let total: f32 = mapped.iter().map(|(_, v)| v.iter().sum::<f32>()).sum();
+        assert_eq!(total, 39.0);
+    }
+
     #[test]
     fn test_write_and_read_vectors() {
         let temp_dir = TempDir::new().unwrap();
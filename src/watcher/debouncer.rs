@@ -2,6 +2,9 @@
 //!
 //! Debouncing prevents excessive re-indexing when files are saved
 //! multiple times in quick succession (e.g., auto-save, IDE formatting).
+//! A batch window holds the files that settled for a while longer, so a
+//! checkout or a formatter run touching many files is indexed as one
+//! batch, and the hooks fire once for it.
 
 use std::collections::HashMap;
 use std::path::PathBuf;
@@ -17,6 +20,10 @@ pub struct Debouncer {
     pending: HashMap<PathBuf, Instant>,
     /// How long a file must be stable before processing.
     duration: Duration,
+    /// How long settled files wait for the rest of their batch.
+    batch_window: Duration,
+    /// When the first file of the current batch settled.
+    batch_started: Option<Instant>,
 }
 
 impl Debouncer {
@@ -25,9 +32,18 @@ impl Debouncer {
         Self {
             pending: HashMap::new(),
             duration: Duration::from_millis(debounce_ms),
+            batch_window: Duration::ZERO,
+            batch_started: None,
         }
     }
 
+    /// Hold settled paths up to `batch_window_ms` while other changes are
+    /// still pending.
+    pub fn with_batch_window(mut self, batch_window_ms: u64) -> Self {
+        self.batch_window = Duration::from_millis(batch_window_ms);
+        self
+    }
+
     /// Record a file change event.
     ///
     /// Resets the debounce timer for this path.
@@ -38,13 +54,34 @@ impl Debouncer {
     /// Remove a path from pending (e.g., when file is deleted).
     pub fn remove(&mut self, path: &PathBuf) {
         self.pending.remove(path);
+        if self.pending.is_empty() {
+            self.batch_started = None;
+        }
     }
 
     /// Take all paths that have been stable for the debounce duration.
     ///
     /// Returns paths ready for processing and removes them from pending.
+    /// Within the batch window they are returned only once no change is
+    /// pending anymore.
     pub fn take_ready(&mut self) -> Vec<PathBuf> {
         let now = Instant::now();
+        if !self.batch_window.is_zero() {
+            let settled = self
+                .pending
+                .values()
+                .filter(|last_change| now.duration_since(**last_change) >= self.duration)
+                .count();
+            if settled == 0 {
+                return Vec::new();
+            }
+            let started = *self.batch_started.get_or_insert(now);
+            if settled < self.pending.len() && now.duration_since(started) < self.batch_window {
+                return Vec::new();
+            }
+            self.batch_started = None;
+        }
+
         let mut ready = Vec::new();
 
         self.pending.retain(|path, last_change| {
@@ -147,6 +184,26 @@ mod tests {
         assert_eq!(ready[0], path2);
     }
 
+    #[test]
+    fn test_batch_window_waits_for_pending_changes() {
+        let mut debouncer = Debouncer::new(20).with_batch_window(200);
+
+        let path1 = PathBuf::from("/test/file1.rs");
+        let path2 = PathBuf::from("/test/file2.rs");
+        debouncer.record(path1.clone());
+        sleep(Duration::from_millis(30));
+        debouncer.record(path2.clone());
+
+        // path1 settled, but path2 is still changing
+        assert!(debouncer.take_ready().is_empty());
+
+        sleep(Duration::from_millis(30));
+        let mut ready = debouncer.take_ready();
+        ready.sort();
+        assert_eq!(ready, vec![path1, path2]);
+        assert!(!debouncer.has_pending());
+    }
+
     #[test]
     fn test_debouncer_remove() {
         let mut debouncer = Debouncer::new(50);
@@ -1,10 +1,12 @@
 //! Handler trait and action types for the unified watcher.
 
 use std::path::{Path, PathBuf};
+use std::sync::Arc;
 
 use async_trait::async_trait;
 
 use super::WatchError;
+use crate::config::Settings;
 
 /// Actions returned by handlers for the UnifiedWatcher to execute.
 #[derive(Debug, Clone)]
@@ -21,10 +23,12 @@ pub enum WatchAction {
     /// Remove a document from the store.
     RemoveDocument { path: PathBuf },
 
-    /// Configuration changed - index new directories.
+    /// Configuration changed - index new directories and take up the
+    /// reloaded settings a running server can.
     ReloadConfig {
         added: Vec<PathBuf>,
         removed: Vec<PathBuf>,
+        settings: Arc<Settings>,
     },
 
     /// No action needed (e.g., file unchanged).
@@ -1,9 +1,12 @@
 //! Handler for configuration file changes.
 //!
-//! Watches settings.toml and triggers directory indexing when indexed_paths changes.
+//! Watches settings.toml, triggers directory indexing when indexed_paths
+//! changes and hands the settings a running server can take up to the
+//! watcher, logging the changed keys that wait for a restart.
 
 use std::collections::HashSet;
 use std::path::{Path, PathBuf};
+use std::sync::Arc;
 
 use async_trait::async_trait;
 use tokio::sync::RwLock;
@@ -13,55 +16,79 @@ use crate::watcher::{WatchAction, WatchError, WatchHandler};
 
 /// Handler for configuration file changes.
 ///
-/// Watches settings.toml and detects changes to indexed_paths.
-/// Returns ReloadConfig action with added/removed directories.
+/// Watches settings.toml and detects changes to indexed_paths and to the
+/// settings applied at runtime. Returns ReloadConfig action with
+/// added/removed directories and the reloaded settings.
 pub struct ConfigFileHandler {
     /// Path to settings.toml.
     settings_path: PathBuf,
-    /// Last known indexed_paths for diffing.
-    last_indexed_paths: RwLock<HashSet<PathBuf>>,
+    /// Profile the settings are loaded with, on every reload.
+    profile: Option<String>,
+    /// Settings as last applied, for diffing.
+    last_settings: RwLock<Settings>,
 }
 
 impl ConfigFileHandler {
     /// Create a new config file handler.
-    pub fn new(settings_path: PathBuf) -> Result<Self, WatchError> {
-        // Load initial indexed_paths
-        let config = Settings::load_from(&settings_path).map_err(|e| WatchError::ConfigError {
-            reason: format!("Failed to load config: {e}"),
-        })?;
-
-        let initial_paths: HashSet<PathBuf> = config.indexing.indexed_paths.into_iter().collect();
+    pub fn new(settings_path: PathBuf, profile: Option<String>) -> Result<Self, WatchError> {
+        // Load initial settings
+        let config =
+            Settings::load_from_profile(&settings_path, profile.as_deref()).map_err(|e| {
+                WatchError::ConfigError {
+                    reason: format!("Failed to load config: {e}"),
+                }
+            })?;
 
         Ok(Self {
             settings_path,
-            last_indexed_paths: RwLock::new(initial_paths),
+            profile,
+            last_settings: RwLock::new(config),
         })
     }
 
-    /// Compute diff between current and previous indexed_paths.
-    async fn compute_diff(&self) -> Result<(Vec<PathBuf>, Vec<PathBuf>), WatchError> {
+    /// Reload the config and diff it against the last applied settings.
+    async fn compute_diff(&self) -> Result<WatchAction, WatchError> {
         // Reload config
-        let new_config =
-            Settings::load_from(&self.settings_path).map_err(|e| WatchError::ConfigError {
+        let new_config = Settings::load_from_profile(&self.settings_path, self.profile.as_deref())
+            .map_err(|e| WatchError::ConfigError {
                 reason: format!("Failed to reload config: {e}"),
             })?;
 
-        let new_paths: HashSet<PathBuf> = new_config.indexing.indexed_paths.into_iter().collect();
+        let mut last_settings = self.last_settings.write().await;
+        let changes = last_settings.reload_changes(&new_config);
+        if !changes.applied.is_empty() {
+            crate::log_event!("config", "applied", "{}", changes.applied.join(", "));
+        }
+        if !changes.need_restart.is_empty() {
+            tracing::warn!(
+                "[config] restart codanna serve to apply: {}",
+                changes.need_restart.join(", ")
+            );
+        }
+        if changes.applied.is_empty() {
+            return Ok(WatchAction::None);
+        }
 
-        let last_paths = self.last_indexed_paths.read().await;
+        let last_paths: HashSet<PathBuf> = last_settings
+            .indexing
+            .indexed_paths
+            .iter()
+            .cloned()
+            .collect();
+        let new_paths: HashSet<PathBuf> =
+            new_config.indexing.indexed_paths.iter().cloned().collect();
 
         // Compute added and removed
         let added: Vec<PathBuf> = new_paths.difference(&last_paths).cloned().collect();
         let removed: Vec<PathBuf> = last_paths.difference(&new_paths).cloned().collect();
 
-        // Update stored paths if there were changes
-        if !added.is_empty() || !removed.is_empty() {
-            drop(last_paths);
-            let mut write_lock = self.last_indexed_paths.write().await;
-            *write_lock = new_paths;
-        }
+        *last_settings = last_settings.with_live_values_of(&new_config);
 
-        Ok((added, removed))
+        Ok(WatchAction::ReloadConfig {
+            added,
+            removed,
+            settings: Arc::new(new_config),
+        })
     }
 }
 
@@ -85,14 +112,7 @@ impl WatchHandler for ConfigFileHandler {
         // Small delay to ensure file write is complete
         tokio::time::sleep(tokio::time::Duration::from_millis(100)).await;
 
-        let (added, removed) = self.compute_diff().await?;
-
-        if added.is_empty() && removed.is_empty() {
-            // indexed_paths unchanged
-            return Ok(WatchAction::None);
-        }
-
-        Ok(WatchAction::ReloadConfig { added, removed })
+        self.compute_diff().await
     }
 
     async fn on_delete(&self, _path: &Path) -> Result<WatchAction, WatchError> {
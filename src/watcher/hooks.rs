@@ -0,0 +1,179 @@
+//! Hooks fired after the watcher updates the index.
+//!
+//! A shell command and a webhook, both optional, are told which files a
+//! batch of changes reindexed or removed, so caches built from the index
+//! can be invalidated. Both run in the background: a slow hook never holds
+//! up the next batch, and a failing one is logged and forgotten.
+
+use std::path::{Path, PathBuf};
+use std::time::Duration;
+
+use serde::Serialize;
+
+use crate::config::FileWatchConfig;
+
+/// How long a webhook may take to answer
+const WEBHOOK_TIMEOUT: Duration = Duration::from_secs(10);
+
+/// What a webhook is sent, and a shell hook given on stdin
+#[derive(Debug, Serialize)]
+pub struct IndexUpdate<'a> {
+    pub event: &'static str,
+    pub files: &'a [PathBuf],
+}
+
+/// The hooks of `[file_watch]`
+#[derive(Debug, Clone, Default)]
+pub struct IndexHooks {
+    command: Option<String>,
+    webhook: Option<String>,
+    workspace_root: PathBuf,
+}
+
+impl IndexHooks {
+    /// Hooks of the settings, run from `workspace_root`
+    pub fn new(config: &FileWatchConfig, workspace_root: &Path) -> Self {
+        let set = |value: &Option<String>| value.clone().filter(|value| !value.trim().is_empty());
+        Self {
+            command: set(&config.on_index),
+            webhook: set(&config.webhook),
+            workspace_root: workspace_root.to_path_buf(),
+        }
+    }
+
+    /// Whether there is a hook to fire
+    pub fn is_empty(&self) -> bool {
+        self.command.is_none() && self.webhook.is_none()
+    }
+
+    /// Fire the hooks for `files`, without waiting for them
+    pub fn fire(&self, files: &[PathBuf]) {
+        if self.is_empty() || files.is_empty() {
+            return;
+        }
+        let payload = serde_json::to_string(&IndexUpdate {
+            event: "index_updated",
+            files,
+        })
+        .expect("payload serialization");
+
+        if let Some(command) = &self.command {
+            let command = command.clone();
+            let payload = payload.clone();
+            let files = changed_files(files);
+            let workspace_root = self.workspace_root.clone();
+            tokio::spawn(async move {
+                if let Err(e) = run_command(&command, &payload, &files, &workspace_root).await {
+                    tracing::warn!("[watcher] on_index hook failed: {e}");
+                }
+            });
+        }
+        if let Some(url) = &self.webhook {
+            let url = url.clone();
+            tokio::spawn(async move {
+                if let Err(e) = post_webhook(&url, payload).await {
+                    tracing::warn!("[watcher] webhook {url} failed: {e}");
+                }
+            });
+        }
+    }
+}
+
+/// The files one a line, as `CODANNA_CHANGED_FILES` holds them
+fn changed_files(files: &[PathBuf]) -> String {
+    files
+        .iter()
+        .map(|file| file.display().to_string())
+        .collect::<Vec<_>>()
+        .join("\n")
+}
+
+async fn run_command(
+    command: &str,
+    payload: &str,
+    files: &str,
+    workspace_root: &Path,
+) -> Result<(), String> {
+    use tokio::io::AsyncWriteExt;
+
+    let mut shell = if cfg!(windows) {
+        let mut shell = tokio::process::Command::new("cmd");
+        shell.arg("/C");
+        shell
+    } else {
+        let mut shell = tokio::process::Command::new("sh");
+        shell.arg("-c");
+        shell
+    };
+    let mut child = shell
+        .arg(command)
+        .current_dir(workspace_root)
+        .env("CODANNA_CHANGED_FILES", files)
+        .stdin(std::process::Stdio::piped())
+        .stdout(std::process::Stdio::null())
+        .spawn()
+        .map_err(|e| e.to_string())?;
+    if let Some(mut stdin) = child.stdin.take() {
+        // A hook that ignores its stdin closes it early; that is no failure
+        let _ = stdin.write_all(payload.as_bytes()).await;
+    }
+    let status = child.wait().await.map_err(|e| e.to_string())?;
+    if status.success() {
+        crate::debug_event!("watcher", "on_index hook done");
+        Ok(())
+    } else {
+        Err(format!("'{command}' exited with {status}"))
+    }
+}
+
+async fn post_webhook(url: &str, payload: String) -> Result<(), String> {
+    let response = reqwest::Client::new()
+        .post(url)
+        .timeout(WEBHOOK_TIMEOUT)
+        .header(reqwest::header::CONTENT_TYPE, "application/json")
+        .body(payload)
+        .send()
+        .await
+        .map_err(|e| e.to_string())?;
+    if response.status().is_success() {
+        crate::debug_event!("watcher", "webhook done", "{url}");
+        Ok(())
+    } else {
+        Err(format!("answered {}", response.status()))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[cfg(unix)]
+    #[tokio::test]
+    async fn test_command_hook_gets_the_files() {
+        let dir = tempfile::tempdir().unwrap();
+        let files = [PathBuf::from("src/a.rs"), PathBuf::from("src/b.rs")];
+        let payload = serde_json::to_string(&IndexUpdate {
+            event: "index_updated",
+            files: &files,
+        })
+        .unwrap();
+
+        run_command(
+            "printf '%s' \"$CODANNA_CHANGED_FILES\" > files.txt && cat > payload.json",
+            &payload,
+            &changed_files(&files),
+            dir.path(),
+        )
+        .await
+        .unwrap();
+
+        let written = std::fs::read_to_string(dir.path().join("files.txt")).unwrap();
+        assert_eq!(written, "src/a.rs\nsrc/b.rs");
+        let sent = std::fs::read_to_string(dir.path().join("payload.json")).unwrap();
+        assert_eq!(
+            sent,
+            r#"{"event":"index_updated","files":["src/a.rs","src/b.rs"]}"#
+        );
+        assert!(run_command("exit 3", "", "", dir.path()).await.is_err());
+    }
+}
@@ -12,6 +12,7 @@ use tracing::{debug, info, warn};
 
 use crate::indexing::facade::IndexFacade;
 use crate::mcp::notifications::{FileChangeEvent, NotificationBroadcaster};
+use crate::storage::IndexLease;
 use crate::{IndexPersistence, Settings};
 
 /// Watches for external index changes and hot-reloads them.
@@ -19,13 +20,16 @@ use crate::{IndexPersistence, Settings};
 /// This watcher polls `meta.json` and `state.json` to detect when the index
 /// is modified by external processes (e.g., `codanna index` in another terminal,
 /// CI/CD pipelines). It does NOT watch source files - that's handled by UnifiedWatcher.
+/// While a writer holds the index lease it waits, and it reloads when the
+/// generation a released lease bumps moved (see [`crate::storage::lease`]).
 pub struct HotReloadWatcher {
     index_path: PathBuf,
     facade: Arc<RwLock<IndexFacade>>,
-    settings: Arc<Settings>,
     persistence: IndexPersistence,
     last_modified: Option<SystemTime>,
     last_doc_modified: Option<SystemTime>,
+    /// Generation of the index loaded last
+    generation: u64,
     check_interval: Duration,
     broadcaster: Option<Arc<NotificationBroadcaster>>,
 }
@@ -52,13 +56,15 @@ impl HotReloadWatcher {
             .ok()
             .and_then(|meta| meta.modified().ok());
 
+        let generation = IndexLease::generation(&index_path);
+
         Self {
             index_path,
             facade,
-            settings,
             persistence,
             last_modified,
             last_doc_modified,
+            generation,
             check_interval,
             broadcaster: None,
         }
@@ -95,16 +101,25 @@ impl HotReloadWatcher {
             return Ok(());
         }
 
+        // Never load halfway through a write: the symbols may be committed
+        // before the embeddings are saved
+        if IndexLease::is_held(&self.index_path) {
+            tracing::trace!("Index writer active, deferring reload");
+            return Ok(());
+        }
+        let generation = IndexLease::generation(&self.index_path);
+
         // Get current modification time of the index metadata file
         let meta_file_path = self.index_path.join("tantivy").join("meta.json");
         let metadata = std::fs::metadata(&meta_file_path)?;
         let current_modified = metadata.modified()?;
 
         // Check if file has been modified
-        let should_reload = match self.last_modified {
-            Some(last) => current_modified > last,
-            None => true,
-        };
+        let should_reload = generation != self.generation
+            || match self.last_modified {
+                Some(last) => current_modified > last,
+                None => true,
+            };
 
         if !should_reload {
             tracing::trace!("Index file unchanged");
@@ -113,8 +128,10 @@ impl HotReloadWatcher {
 
         crate::log_event!("hot-reload", "reloading", "{}", self.index_path.display());
 
-        // Load the new index as a facade
-        match self.persistence.load_facade(self.settings.clone()) {
+        // Load the new index as a facade, keeping any settings reloaded
+        // from settings.toml since startup
+        let settings = self.facade.read().await.settings().clone();
+        match self.persistence.load_facade(settings) {
             Ok(new_facade) => {
                 // Get write lock and replace the facade
                 let mut facade_guard = self.facade.write().await;
@@ -122,6 +139,7 @@ impl HotReloadWatcher {
 
                 // Update last modified time
                 self.last_modified = Some(current_modified);
+                self.generation = generation;
 
                 // Ensure semantic search stays attached after hot reloads
                 let mut restored_semantic = false;
@@ -16,11 +16,15 @@
 //!    |         |         |
 //! CodeHandler DocHandler ConfigHandler
 //! ```
+//!
+//! The `[file_watch]` settings tune the debounce and batch window, ignore
+//! paths, and name the hooks fired after each batch is indexed.
 
 mod debouncer;
 mod error;
 mod handler;
 pub mod handlers;
+mod hooks;
 mod hot_reload;
 mod path_registry;
 mod unified;
@@ -28,6 +32,7 @@ mod unified;
 pub use debouncer::Debouncer;
 pub use error::WatchError;
 pub use handler::{WatchAction, WatchHandler};
+pub use hooks::{IndexHooks, IndexUpdate};
 pub use hot_reload::{HotReloadWatcher, IndexStats};
 pub use path_registry::PathRegistry;
 pub use unified::{UnifiedWatcher, UnifiedWatcherBuilder};
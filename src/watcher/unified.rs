@@ -8,14 +8,18 @@ use notify::{Event, EventKind, RecursiveMode, Watcher};
 use tokio::sync::{RwLock, mpsc};
 use tokio::time::{Duration, sleep};
 
+use crate::config::FileWatchConfig;
 use crate::documents::DocumentStore;
 use crate::documents::config::ChunkingConfig;
 use crate::indexing::facade::IndexFacade;
 use crate::mcp::notifications::{FileChangeEvent, NotificationBroadcaster};
+use crate::storage::IndexLease;
+use crate::storage::lease::WRITER_WAIT;
 
 use super::debouncer::Debouncer;
 use super::error::WatchError;
 use super::handler::{WatchAction, WatchHandler};
+use super::hooks::IndexHooks;
 use super::path_registry::PathRegistry;
 
 /// Unified file watcher with pluggable handlers.
@@ -45,6 +49,10 @@ pub struct UnifiedWatcher {
     index_path: PathBuf,
     /// Workspace root for path resolution.
     workspace_root: PathBuf,
+    /// Files whose changes are ignored, relative to the workspace root.
+    ignore: Vec<glob::Pattern>,
+    /// Hooks fired after each batch updates an index.
+    hooks: IndexHooks,
 }
 
 impl UnifiedWatcher {
@@ -122,10 +130,7 @@ impl UnifiedWatcher {
 
                 // Process debounced changes
                 _ = &mut timeout => {
-                    let ready = self.debouncer.take_ready();
-                    for path in ready {
-                        self.process_modification(&path).await;
-                    }
+                    self.process_debounced().await;
                 }
 
                 // Handle broadcast notifications
@@ -164,7 +169,13 @@ impl UnifiedWatcher {
 
     /// Handle an incoming file event.
     async fn handle_event(&mut self, event: Event) {
+        let mut removed = Vec::new();
         for path in event.paths {
+            if self.is_ignored(&path) {
+                crate::trace_event!("watcher", "ignored", "{}", path.display());
+                continue;
+            }
+
             // Check if any handler cares about this path
             let matched = self.handlers.iter().any(|h| h.matches(&path));
             if !matched {
@@ -186,21 +197,54 @@ impl UnifiedWatcher {
                 EventKind::Remove(_) => {
                     // Handle deletions immediately
                     self.debouncer.remove(&path);
-                    self.process_deletion(&path).await;
+                    if self.process_deletion(&path).await {
+                        removed.push(path);
+                    }
                 }
                 _ => {}
             }
         }
+        self.broadcast_index_updated(removed);
+    }
+
+    /// Whether a path matches one of the ignore patterns.
+    fn is_ignored(&self, path: &Path) -> bool {
+        let relative = path.strip_prefix(&self.workspace_root).unwrap_or(path);
+        self.ignore
+            .iter()
+            .any(|pattern| pattern.matches_path(relative))
     }
 
-    /// Process a debounced file modification.
-    async fn process_modification(&self, path: &Path) {
+    /// Tell subscribers and hooks which files a batch of changes updated,
+    /// if any.
+    fn broadcast_index_updated(&self, files: Vec<PathBuf>) {
+        if !files.is_empty() {
+            self.hooks.fire(&files);
+            self.broadcaster
+                .send(FileChangeEvent::IndexUpdated { files });
+        }
+    }
+
+    /// Process the modifications the debouncer has settled, as one batch.
+    async fn process_debounced(&mut self) {
+        let ready = self.debouncer.take_ready();
+        let mut updated = Vec::new();
+        for path in ready {
+            if self.process_modification(&path).await {
+                updated.push(path);
+            }
+        }
+        self.broadcast_index_updated(updated);
+    }
+
+    /// Process a debounced file modification. Returns whether an index changed.
+    async fn process_modification(&self, path: &Path) -> bool {
         // Check if file still exists (handles rename-as-modify on macOS)
         if !path.exists() {
-            self.process_deletion(path).await;
-            return;
+            return self.process_deletion(path).await;
         }
 
+        let mut updated = false;
         for handler in &self.handlers {
             if !handler.matches(path) {
                 continue;
@@ -209,20 +253,21 @@ impl UnifiedWatcher {
             crate::log_event!(handler.name(), "modified", "{}", path.display());
 
             match handler.on_modify(path).await {
-                Ok(action) => {
-                    if let Err(e) = self.execute_action(action, handler.name()).await {
-                        tracing::error!("[{}] action error: {e}", handler.name());
-                    }
-                }
+                Ok(action) => match self.execute_action(action, handler.name()).await {
+                    Ok(changed) => updated |= changed,
+                    Err(e) => tracing::error!("[{}] action error: {e}", handler.name()),
+                },
                 Err(e) => {
                     tracing::error!("[{}] handler error: {e}", handler.name());
                 }
             }
         }
+        updated
     }
 
-    /// Process a file deletion.
-    async fn process_deletion(&self, path: &Path) {
+    /// Process a file deletion. Returns whether an index changed.
+    async fn process_deletion(&self, path: &Path) -> bool {
+        let mut updated = false;
         for handler in &self.handlers {
             if !handler.matches(path) {
                 continue;
@@ -231,26 +276,56 @@ impl UnifiedWatcher {
             crate::log_event!(handler.name(), "deleted", "{}", path.display());
 
             match handler.on_delete(path).await {
-                Ok(action) => {
-                    if let Err(e) = self.execute_action(action, handler.name()).await {
-                        tracing::error!("[{}] action error: {e}", handler.name());
-                    }
-                }
+                Ok(action) => match self.execute_action(action, handler.name()).await {
+                    Ok(changed) => updated |= changed,
+                    Err(e) => tracing::error!("[{}] action error: {e}", handler.name()),
+                },
                 Err(e) => {
                     tracing::error!("[{}] handler error: {e}", handler.name());
                 }
             }
         }
+        updated
+    }
+
+    /// Take the writer lease of the index, waiting for another process
+    /// holding it; None, logged, when it keeps it
+    async fn writer_lease(&self, handler_name: &str) -> Option<IndexLease> {
+        let index_path = self.index_path.clone();
+        let name = handler_name.to_string();
+        let acquired = tokio::task::spawn_blocking(move || {
+            IndexLease::acquire(&index_path, "codanna serve --watch", WRITER_WAIT, |held| {
+                crate::log_event!(&name, "waiting", "{held}");
+            })
+        })
+        .await;
+        match acquired {
+            Ok(Ok(lease)) => Some(lease),
+            Ok(Err(e)) => {
+                tracing::error!("[{handler_name}] {e}; change not indexed");
+                None
+            }
+            Err(e) => {
+                tracing::error!("[{handler_name}] writer lease task failed: {e}");
+                None
+            }
+        }
     }
 
     /// Execute an action returned by a handler.
+    ///
+    /// Returns whether it changed the code or document index for the file.
     async fn execute_action(
         &self,
         action: WatchAction,
         handler_name: &str,
-    ) -> Result<(), WatchError> {
+    ) -> Result<bool, WatchError> {
+        let mut changed = false;
         match action {
             WatchAction::ReindexCode { path } => {
+                let Some(lease) = self.writer_lease(handler_name).await else {
+                    return Ok(false);
+                };
                 let mut indexer = self.facade.write().await;
                 match indexer.index_file(&path) {
                     Ok(result) => {
@@ -272,9 +347,11 @@ impl UnifiedWatcher {
                                 // Notify
                                 self.broadcaster
                                     .send(FileChangeEvent::FileReindexed { path: path.clone() });
+                                changed = true;
                             }
                             IndexingResult::Cached(_) => {
                                 crate::debug_event!(handler_name, "unchanged (hash match)");
+                                lease.release_unchanged();
                             }
                         }
                     }
@@ -285,6 +362,9 @@ impl UnifiedWatcher {
             }
 
             WatchAction::RemoveCode { path } => {
+                let Some(_lease) = self.writer_lease(handler_name).await else {
+                    return Ok(false);
+                };
                 let mut indexer = self.facade.write().await;
                 if let Err(e) = indexer.remove_file(&path) {
                     tracing::error!("[{handler_name}] failed to remove: {e}");
@@ -292,6 +372,7 @@ impl UnifiedWatcher {
                     crate::log_event!(handler_name, "removed");
                     self.broadcaster
                         .send(FileChangeEvent::FileDeleted { path: path.clone() });
+                    changed = true;
                 }
             }
 
@@ -303,6 +384,7 @@ impl UnifiedWatcher {
                             crate::log_event!(handler_name, "reindexed", "{chunks} chunks");
                             self.broadcaster
                                 .send(FileChangeEvent::FileReindexed { path: path.clone() });
+                            changed = true;
                         }
                         Ok(None) => {
                             crate::debug_event!(handler_name, "not in index, skipped");
@@ -322,6 +404,7 @@ impl UnifiedWatcher {
                             crate::log_event!(handler_name, "removed");
                             self.broadcaster
                                 .send(FileChangeEvent::FileDeleted { path: path.clone() });
+                            changed = true;
                         }
                         Ok(false) => {
                             crate::debug_event!(handler_name, "was not in index");
@@ -333,26 +416,36 @@ impl UnifiedWatcher {
                 }
             }
 
-            WatchAction::ReloadConfig { added, removed } => {
+            WatchAction::ReloadConfig {
+                added,
+                removed,
+                settings,
+            } => {
+                // Before indexing, so new directories see the new values
+                self.facade.write().await.apply_live_settings(&settings);
+                self.broadcaster.send(FileChangeEvent::SettingsReloaded);
+
                 if !added.is_empty() {
                     crate::log_event!("config", "adding directories", "{}", added.len());
                     for path in &added {
                         tracing::info!("  + {}", path.display());
                     }
 
-                    let mut indexer = self.facade.write().await;
-                    for path in &added {
-                        crate::log_event!("config", "indexing", "{}", path.display());
-                        match indexer.index_directory(path, false) {
-                            Ok(stats) => {
-                                tracing::info!(
-                                    "  indexed {} files, {} symbols",
-                                    stats.files_indexed,
-                                    stats.symbols_found
-                                );
-                            }
-                            Err(e) => {
-                                tracing::error!("  failed: {e}");
+                    if let Some(_lease) = self.writer_lease("config").await {
+                        let mut indexer = self.facade.write().await;
+                        for path in &added {
+                            crate::log_event!("config", "indexing", "{}", path.display());
+                            match indexer.index_directory(path, false) {
+                                Ok(stats) => {
+                                    tracing::info!(
+                                        "  indexed {} files, {} symbols",
+                                        stats.files_indexed,
+                                        stats.symbols_found
+                                    );
+                                }
+                                Err(e) => {
+                                    tracing::error!("  failed: {e}");
+                                }
                             }
                         }
                     }
@@ -376,7 +469,7 @@ impl UnifiedWatcher {
             }
         }
 
-        Ok(())
+        Ok(changed)
     }
 
     /// Handle IndexReloaded notification - refresh all handlers.
@@ -435,7 +528,7 @@ pub struct UnifiedWatcherBuilder {
     chunking_config: ChunkingConfig,
     index_path: Option<PathBuf>,
     workspace_root: Option<PathBuf>,
-    debounce_ms: u64,
+    file_watch: FileWatchConfig,
 }
 
 impl UnifiedWatcherBuilder {
@@ -449,7 +542,7 @@ impl UnifiedWatcherBuilder {
             chunking_config: ChunkingConfig::default(),
             index_path: None,
             workspace_root: None,
-            debounce_ms: 500,
+            file_watch: FileWatchConfig::default(),
         }
     }
 
@@ -497,7 +590,14 @@ impl UnifiedWatcherBuilder {
 
     /// Set the debounce duration in milliseconds.
     pub fn debounce_ms(mut self, ms: u64) -> Self {
-        self.debounce_ms = ms;
+        self.file_watch.debounce_ms = ms;
+        self
+    }
+
+    /// Take the debounce, batch window, ignore patterns and hooks of
+    /// `[file_watch]`.
+    pub fn file_watch(mut self, config: &FileWatchConfig) -> Self {
+        self.file_watch = config.clone();
         self
     }
 
@@ -519,6 +619,18 @@ impl UnifiedWatcherBuilder {
             .index_path
             .unwrap_or_else(|| workspace_root.join(".codanna/index"));
 
+        let ignore = self
+            .file_watch
+            .ignore
+            .iter()
+            .map(|pattern| {
+                glob::Pattern::new(pattern).map_err(|e| WatchError::ConfigError {
+                    reason: format!("file_watch.ignore: invalid pattern '{pattern}': {e}"),
+                })
+            })
+            .collect::<Result<Vec<_>, _>>()?;
+        let hooks = IndexHooks::new(&self.file_watch, &workspace_root);
+
         // Create channel for events
         let (tx, rx) = mpsc::channel(100);
 
@@ -530,7 +642,8 @@ impl UnifiedWatcherBuilder {
         Ok(UnifiedWatcher {
             handlers: self.handlers,
             registry: PathRegistry::new(),
-            debouncer: Debouncer::new(self.debounce_ms),
+            debouncer: Debouncer::new(self.file_watch.debounce_ms)
+                .with_batch_window(self.file_watch.batch_window_ms),
             event_rx: rx,
             _watcher: watcher,
             broadcaster,
@@ -539,6 +652,8 @@ impl UnifiedWatcherBuilder {
             chunking_config: self.chunking_config,
             index_path,
             workspace_root,
+            ignore,
+            hooks,
         })
     }
 }
@@ -548,3 +663,96 @@ impl Default for UnifiedWatcherBuilder {
         Self::new()
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use async_trait::async_trait;
+
+    /// Reindexes the Rust files it is told about, by absolute path
+    struct RustFiles;
+
+    #[async_trait]
+    impl WatchHandler for RustFiles {
+        fn name(&self) -> &str {
+            "code"
+        }
+
+        fn matches(&self, path: &Path) -> bool {
+            path.extension().is_some_and(|ext| ext == "rs")
+        }
+
+        async fn tracked_paths(&self) -> Vec<PathBuf> {
+            Vec::new()
+        }
+
+        async fn on_modify(&self, path: &Path) -> Result<WatchAction, WatchError> {
+            Ok(WatchAction::ReindexCode {
+                path: path.to_path_buf(),
+            })
+        }
+
+        async fn on_delete(&self, path: &Path) -> Result<WatchAction, WatchError> {
+            Ok(WatchAction::RemoveCode {
+                path: path.to_path_buf(),
+            })
+        }
+    }
+
+    #[tokio::test]
+    async fn test_debounced_batch_is_announced_once() {
+        let dir = tempfile::tempdir().unwrap();
+        let root = dir.path().canonicalize().unwrap();
+        let settings = crate::Settings {
+            workspace_root: Some(root.clone()),
+            index_path: root.join(".codanna/index"),
+            ..Default::default()
+        };
+        let files: Vec<PathBuf> = ["a.rs", "b.rs", "c.rs"]
+            .iter()
+            .map(|name| root.join(name))
+            .collect();
+        let mut facade = IndexFacade::new(Arc::new(settings.clone())).unwrap();
+        for (i, file) in files.iter().enumerate() {
+            std::fs::write(file, format!("pub fn before_{i}() {{}}\n")).unwrap();
+            facade.index_file(file).unwrap();
+        }
+
+        let broadcaster = Arc::new(NotificationBroadcaster::new(100));
+        let mut watcher = UnifiedWatcher::builder()
+            .broadcaster(broadcaster.clone())
+            .indexer(Arc::new(RwLock::new(facade)))
+            .index_path(settings.index_path.clone())
+            .workspace_root(root.clone())
+            .debounce_ms(0)
+            .handler(RustFiles)
+            .build()
+            .unwrap();
+        let mut events = broadcaster.subscribe();
+        let mut index_updates = || {
+            std::iter::from_fn(|| events.try_recv().ok())
+                .filter_map(|event| match event {
+                    FileChangeEvent::IndexUpdated { files } => Some(files),
+                    _ => None,
+                })
+                .collect::<Vec<_>>()
+        };
+
+        for (i, file) in files.iter().enumerate() {
+            std::fs::write(file, format!("pub fn after_{i}() {{}}\n")).unwrap();
+            watcher.debouncer.record(file.clone());
+        }
+        watcher.process_debounced().await;
+        let mut updates = index_updates();
+        assert_eq!(updates.len(), 1, "{updates:?}");
+        updates[0].sort();
+        assert_eq!(updates[0], files);
+
+        // Saved again without a change: the index is as it was
+        for file in &files {
+            watcher.debouncer.record(file.clone());
+        }
+        watcher.process_debounced().await;
+        assert!(index_updates().is_empty());
+    }
+}
@@ -0,0 +1,41 @@
+#!/usr/bin/env bash
+# Deploys the application to the target environment.
+
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+readonly DEFAULT_ENV="staging"
+export REGISTRY="registry.example.com"
+
+source "$SCRIPT_DIR/lib/common.sh"
+. ./lib/logging.sh
+
+# Builds and tags the container image
+# for the given version.
+build_image() {
+    local version="$1"
+    log_info "building $version"
+    docker build -t "$REGISTRY/app:$version" .
+}
+
+function push_image {
+    docker push "$REGISTRY/app:$1" | tee push.log
+}
+
+deploy() {
+    local env="${1:-$DEFAULT_ENV}"
+    build_image "$(git rev-parse --short HEAD)"
+    push_image latest
+    kubectl apply -f "deploy/$env.yaml"
+}
+
+usage() {
+    echo "usage: $0 [env]" >&2
+}
+
+if [[ $# -gt 1 ]]; then
+    usage
+    exit 1
+fi
+
+deploy "$@"
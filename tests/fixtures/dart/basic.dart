@@ -0,0 +1,79 @@
+import 'package:flutter/material.dart';
+import 'package:http/http.dart' as http;
+import 'src/models/user.dart' show User;
+
+/// Maximum number of retries for network calls
+const int maxRetries = 3;
+
+var requestCount = 0;
+
+typedef Formatter = String Function(int value);
+
+/// Logs messages with a tag
+mixin Logging {
+  void log(String message) {
+    print(message);
+  }
+}
+
+/// Visual density of a widget
+enum Density { compact, comfortable }
+
+abstract class Repository {
+  Future<User> fetch(String id);
+}
+
+class HttpRepository implements Repository {
+  final http.Client _client;
+
+  HttpRepository(this._client);
+
+  @override
+  Future<User> fetch(String id) async {
+    final response = await _client.get(Uri.parse(id));
+    requestCount++;
+    return User.fromJson(response.body);
+  }
+}
+
+/// Shows a counter that increments when tapped
+class CounterPage extends StatefulWidget {
+  const CounterPage({super.key});
+
+  @override
+  State<CounterPage> createState() => _CounterPageState();
+}
+
+class _CounterPageState extends State<CounterPage> with Logging {
+  int _count = 0;
+
+  void _increment() {
+    setState(() {
+      _count++;
+    });
+    log('incremented');
+  }
+
+  /// Builds the counter layout
+  @override
+  Widget build(BuildContext context) {
+    return Scaffold(
+      body: Center(child: Text('$_count')),
+      floatingActionButton: FloatingActionButton(onPressed: _increment),
+    );
+  }
+}
+
+extension StringCasing on String {
+  String capitalize() => this[0].toUpperCase() + substring(1);
+}
+
+Stream<int> countdown(int from) async* {
+  for (var i = from; i >= 0; i--) {
+    yield i;
+  }
+}
+
+void main() {
+  runApp(const MaterialApp(home: CounterPage()));
+}
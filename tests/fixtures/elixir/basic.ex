@@ -0,0 +1,71 @@
+defmodule MyApp.Accounts.User do
+  @moduledoc """
+  A registered user account.
+  """
+
+  use Ecto.Schema
+  import Ecto.Changeset
+  alias MyApp.Repo
+  alias MyApp.Accounts.{Role, Team}
+  alias MyApp.Mailer, as: Mail
+  require Logger
+
+  defstruct [:name, :email, role: :member]
+
+  @doc """
+  Builds a changeset for registration.
+  """
+  @spec changeset(map(), map()) :: map()
+  def changeset(user, attrs) do
+    user
+    |> cast(attrs, [:name, :email])
+    |> validate_required([:name])
+    |> normalize_email()
+  end
+
+  @doc "Sends the welcome email"
+  def welcome(user), do: Mail.deliver(user, "Welcome!")
+
+  def fetch(id) when is_integer(id) do
+    Logger.debug("fetching user")
+    Repo.get(__MODULE__, id)
+  end
+
+  def fetch(_id), do: nil
+
+  defp normalize_email(changeset) do
+    update_change(changeset, :email, &String.downcase/1)
+  end
+
+  defmacro admin?(user) do
+    quote do
+      unquote(user).role == :admin
+    end
+  end
+
+  defdelegate count(query), to: Repo, as: :aggregate
+end
+
+defprotocol MyApp.Greeter do
+  @doc "Returns a greeting for the value"
+  def greet(value)
+end
+
+defimpl MyApp.Greeter, for: MyApp.Accounts.User do
+  def greet(user), do: "Hello, " <> user.name
+end
+
+defmodule MyApp.Worker do
+  use GenServer
+
+  def start_link(opts) do
+    GenServer.start_link(__MODULE__, opts, name: __MODULE__)
+  end
+
+  @impl true
+  def init(state), do: {:ok, state}
+
+  defimpl String.Chars do
+    def to_string(_worker), do: "worker"
+  end
+end
@@ -0,0 +1,46 @@
+terraform {
+  required_version = ">= 1.5"
+}
+
+variable "region" {
+  type        = string
+  description = "AWS region to deploy into"
+}
+
+variable "instance_count" {
+  type    = number
+  default = 2
+}
+
+locals {
+  name = "web"
+  tags = {
+    Name = local.name
+  }
+}
+
+provider "aws" {
+  region = var.region
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+module "vpc" {
+  source     = "./modules/vpc"
+  cidr_block = "10.0.0.0/16"
+}
+
+# Web frontend instances
+resource "aws_instance" "web" {
+  count         = var.instance_count
+  ami           = data.aws_ami.ubuntu.id
+  subnet_id     = module.vpc.public_subnet_id
+  tags          = local.tags
+}
+
+output "web_ips" {
+  description = "Public addresses of the web instances"
+  value       = aws_instance.web[*].public_ip
+}
@@ -0,0 +1,36 @@
+-- Embedded scripting layer: globals, locals and table-assigned functions
+
+local json, log = require("cjson"), require("app.log")
+settings = require("app.settings")
+
+local M = {}
+
+DEBUG_LEVEL = 2
+local retries = 3
+
+--- Entry point registered by the host
+function on_load()
+    M.init({})
+end
+
+local function clamp(value)
+    return math.min(value, retries)
+end
+
+--- Decode the host payload
+M.decode = function(payload)
+    log.debug("decode")
+    return json.decode(payload)
+end
+
+M.handlers = {}
+
+M.handlers.tick = function(dt)
+    return clamp(dt)
+end
+
+function M.init(opts)
+    settings.load(opts)
+end
+
+return M
@@ -0,0 +1,54 @@
+# frozen_string_literal: true
+
+require "json"
+require_relative "./support/money"
+
+# Shared ordering behaviour for billable records
+module Billable
+  def <=>(other)
+    total <=> other.total
+  end
+end
+
+module Billing
+  # An invoice for a single customer
+  class Invoice < Record
+    include Comparable
+    include Billable
+    extend Forwardable
+
+    TAX_RATE = 0.2
+
+    attr_reader :customer
+    attr_accessor :total
+
+    # Build an invoice from a hash of attributes
+    def self.build(attrs)
+      new(attrs)
+    end
+
+    def amount
+      total * TAX_RATE
+    end
+
+    def to_json(*args)
+      JSON.generate(customer: customer, total: amount)
+    end
+
+    protected
+
+    def comparable_total
+      amount
+    end
+
+    private
+
+    def recalculate
+      self.total = Money.round(amount)
+    end
+  end
+end
+
+def process(invoice)
+  invoice.recalculate
+end
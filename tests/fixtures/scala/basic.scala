@@ -0,0 +1,53 @@
+package billing
+
+import scala.collection.mutable.{Map => MutableMap, ListBuffer}
+import billing.pricing._
+
+/** Something that can be priced */
+trait Priced {
+  def total: Long
+}
+
+trait Discountable extends Priced {
+  def discount(percent: Int): Long = total * percent / 100
+}
+
+/** A single invoice line */
+case class LineItem(sku: String, cents: Long)
+
+class Invoice(val items: List[LineItem]) extends Document("invoice") with Discountable {
+  val currency: String = "EUR"
+  private val audit = ListBuffer.empty[String]
+  protected var revision = 0
+
+  def total: Long = items.map(_.cents).sum
+
+  private[billing] def recalculate(): Unit = {
+    val log = new AuditLog()
+    log.record(total)
+  }
+}
+
+object Invoice {
+  def empty: Invoice = new Invoice(Nil)
+
+  implicit val defaultOrdering: Ordering[Invoice] = Ordering.by(_.total)
+
+  implicit def fromItems(items: List[LineItem]): Invoice = new Invoice(items)
+}
+
+enum Status:
+  case Draft, Sent, Paid
+
+type Cents = Long
+
+given Ordering[LineItem] = Ordering.by(_.cents)
+
+given invoiceShow: Show[Invoice] with {
+  def show(invoice: Invoice): String = invoice.total.toString
+}
+
+extension (item: LineItem)
+  def formatted: String = s"${item.sku}: ${item.cents}"
+
+def summarize(invoice: Invoice): String = invoice.items.mkString(", ")
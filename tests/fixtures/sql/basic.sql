@@ -0,0 +1,30 @@
+-- Schema for the accounts service.
+
+-- Registered accounts
+CREATE TABLE users (
+    id serial PRIMARY KEY,
+    email text NOT NULL UNIQUE,
+    created_at timestamptz DEFAULT now()
+);
+
+CREATE TABLE billing.invoices (
+    id serial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users(id),
+    total numeric(10, 2)
+);
+
+CREATE TYPE invoice_status AS ENUM ('draft', 'sent', 'paid');
+
+/* Users with at least one unpaid invoice */
+CREATE VIEW users_with_debt AS
+SELECT u.id, u.email
+FROM users u
+JOIN billing.invoices i ON i.user_id = u.id
+WHERE i.total > 0;
+
+-- Sum of a user's invoices
+CREATE FUNCTION user_balance(target integer) RETURNS numeric AS $$
+    SELECT coalesce(sum(total), 0) FROM billing.invoices WHERE user_id = target;
+$$ LANGUAGE sql;
+
+INSERT INTO users (email) VALUES ('admin@example.com');
@@ -0,0 +1,84 @@
+//! Geometry primitives used by the fixture tests
+
+const std = @import("std");
+const mem = @import("std").mem;
+const vec = @import("math/vector.zig");
+
+/// Maximum number of shapes in a scene
+pub const max_shapes: usize = 256;
+
+var shape_count: u32 = 0;
+
+/// A point in 2D space
+pub const Point = struct {
+    x: f32,
+    y: f32,
+
+    /// Create a point
+    pub fn init(x: f32, y: f32) Point {
+        return .{ .x = x, .y = y };
+    }
+
+    pub fn distance(self: Point, other: Point) f32 {
+        const dx = self.x - other.x;
+        const dy = self.y - other.y;
+        return @sqrt(dx * dx + dy * dy);
+    }
+
+    fn lengthSquared(self: Point) f32 {
+        return self.x * self.x + self.y * self.y;
+    }
+};
+
+pub const Shape = union(enum) {
+    circle: f32,
+    rect: Point,
+};
+
+pub const Color = enum {
+    red,
+    green,
+    blue,
+
+    pub fn isWarm(self: Color) bool {
+        return self == .red;
+    }
+};
+
+pub const ShapeError = error{
+    TooMany,
+    Degenerate,
+};
+
+/// A growable list of items
+pub fn List(comptime T: type) type {
+    return struct {
+        items: []T,
+        len: usize,
+
+        pub fn append(self: *@This(), item: T) void {
+            self.items[self.len] = item;
+            self.len += 1;
+        }
+    };
+}
+
+comptime {
+    std.debug.assert(max_shapes > 0);
+}
+
+pub fn main() void {
+    const origin = Point.init(0, 0);
+    const far = Point.init(3, 4);
+    _ = origin.distance(far);
+    registerShape();
+}
+
+fn registerShape() void {
+    shape_count += 1;
+}
+
+test "distance" {
+    const a = Point.init(0, 0);
+    try std.testing.expect(a.distance(Point.init(3, 4)) == 5);
+}
@@ -67,6 +67,12 @@ async fn test_gdscript_semantic_search_and_analyze_impact() {
             limit: 1,
             threshold: None,
             lang: Some("gdscript".to_string()),
+            kind: None,
+            path: None,
+            visibility: None,
+            page_size: None,
+            cursor: None,
+            project: None,
         }))
         .await
         .expect("semantic_search_with_context should succeed");
@@ -98,6 +104,9 @@ async fn test_gdscript_semantic_search_and_analyze_impact() {
             symbol_name: None,
             symbol_id: Some(apply_damage_symbol_id),
             max_depth: 2,
+            page_size: None,
+            cursor: None,
+            project: None,
         }))
         .await
         .expect("analyze_impact should succeed");
@@ -168,6 +168,13 @@ async fn test_kotlin_semantic_search_and_dependency_injection() {
             limit: 5,
             threshold: None,
             lang: Some("kotlin".to_string()),
+            kind: None,
+            path: None,
+            visibility: None,
+            vectors: None,
+            page_size: None,
+            cursor: None,
+            project: None,
         }))
         .await
         .expect("semantic_search_docs should succeed");
@@ -194,6 +201,12 @@ async fn test_kotlin_semantic_search_and_dependency_injection() {
             limit: 3,
             threshold: None,
             lang: Some("kotlin".to_string()),
+            kind: None,
+            path: None,
+            visibility: None,
+            page_size: None,
+            cursor: None,
+            project: None,
         }))
         .await
         .expect("semantic_search_with_context should succeed");
@@ -218,6 +231,7 @@ async fn test_kotlin_semantic_search_and_dependency_injection() {
         .find_symbol(Parameters(FindSymbolRequest {
             name: "ReadWritePgClient".to_string(),
             lang: Some("kotlin".to_string()),
+            project: None,
         }))
         .await
         .expect("find_symbol should succeed");
@@ -332,6 +346,13 @@ class UserService(
             limit: 10,
             threshold: None,
             lang: Some("kotlin".to_string()),
+            kind: None,
+            path: None,
+            visibility: None,
+            vectors: None,
+            page_size: None,
+            cursor: None,
+            project: None,
         }))
         .await
         .expect("semantic_search_docs should succeed");
@@ -1,7 +1,8 @@
 //! Test to verify MCP schema generation for usize fields
 
 use codanna::mcp::{
-    AnalyzeImpactRequest, GetIndexInfoRequest, SearchSymbolsRequest, SemanticSearchRequest,
+    AnalyzeImpactRequest, GetIndexInfoRequest, ListProjectsRequest, SearchSymbolsRequest,
+    SemanticSearchRequest,
 };
 
 #[test]
@@ -90,3 +91,19 @@ fn test_get_index_info_schema_has_properties() {
     );
     println!("[OK] GetIndexInfoRequest schema is MCP-spec compliant and OpenAI-compatible.");
 }
+
+/// `list_projects` takes no parameter: its schema needs the same `properties`
+/// and `additionalProperties` as any other.
+#[test]
+fn test_list_projects_schema_has_properties() {
+    let schema = rmcp::schemars::schema_for!(ListProjectsRequest);
+    let root = serde_json::to_value(&schema).unwrap();
+
+    assert_eq!(root.get("type").and_then(|v| v.as_str()), Some("object"));
+    assert!(root.get("properties").is_some(), "Got:\n{root}");
+    assert_eq!(
+        root.get("additionalProperties").and_then(|v| v.as_bool()),
+        Some(false),
+        "Got:\n{root}"
+    );
+}
@@ -26,6 +26,8 @@ fn create_test_settings(config_files: Vec<PathBuf>, index_path: PathBuf) -> Sett
         extensions: vec!["ts".to_string(), "tsx".to_string()],
         parser_options: HashMap::new(),
         projects: Vec::new(),
+        limits: Default::default(),
+        scope: Default::default(),
     };
 
     settings
@@ -313,6 +315,8 @@ fn test_provider_not_active_when_disabled() {
         extensions: vec!["ts".to_string()],
         parser_options: HashMap::new(),
         projects: Vec::new(),
+        limits: Default::default(),
+        scope: Default::default(),
     };
 
     settings
@@ -0,0 +1,136 @@
+//! References from SQL query strings resolve among SQL symbols only.
+//!
+//! A Python function running `SELECT * FROM users` uses the `users` table
+//! of a `.sql` file, not a same-named Python class, and never a column.
+//! The relationship carries `target_language = sql`; the RESOLVE stage
+//! looks the name up in that language and fails closed on ambiguity.
+
+use codanna::config::Settings;
+use codanna::indexing::pipeline::types::{
+    ResolutionContext, ResolvedBatch, SymbolLookupCache, UnresolvedRelationship,
+};
+use codanna::indexing::pipeline::{ResolveStage, ResolveStats};
+use codanna::parsing::resolution::GenericResolutionContext;
+use codanna::parsing::{LanguageBehavior, LanguageId, ParserFactory};
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, Range, SymbolId};
+use codanna::{RelationKind, Symbol, SymbolKind, Visibility};
+use std::collections::HashMap;
+use std::sync::Arc;
+
+fn python() -> LanguageId {
+    LanguageId::new("python")
+}
+
+fn sql() -> LanguageId {
+    LanguageId::new("sql")
+}
+
+fn build_behaviors() -> HashMap<LanguageId, Arc<dyn LanguageBehavior>> {
+    let settings = Settings::load().expect("Failed to load settings");
+    let factory = ParserFactory::new(Arc::new(settings));
+    let mut map = HashMap::new();
+    for lang in [python(), sql()] {
+        let behavior: Arc<dyn LanguageBehavior> =
+            Arc::from(factory.create_behavior_from_registry(lang));
+        map.insert(lang, behavior);
+    }
+    map
+}
+
+fn symbol(id: u32, name: &str, kind: SymbolKind, file: u32, lang: LanguageId) -> Symbol {
+    let mut sym = Symbol::new(
+        SymbolId::new(id).unwrap(),
+        name,
+        kind,
+        FileId::new(file).unwrap(),
+        Range::new(1, 0, 6, 1),
+    );
+    sym.language_id = Some(lang);
+    sym.visibility = Visibility::Public;
+    sym.scope_context = Some(ScopeContext::Global);
+    sym
+}
+
+fn query_reference(to_name: &str) -> UnresolvedRelationship {
+    UnresolvedRelationship {
+        from_id: Some(SymbolId::new(1).unwrap()),
+        from_name: "load_user".into(),
+        to_name: to_name.into(),
+        file_id: FileId::new(1).unwrap(),
+        kind: RelationKind::Uses,
+        metadata: None,
+        to_range: Some(Range::new(3, 30, 3, 35)),
+        target_language: Some(sql()),
+    }
+}
+
+fn resolve(
+    cache: Arc<SymbolLookupCache>,
+    rel: UnresolvedRelationship,
+) -> (ResolvedBatch, ResolveStats) {
+    let stage = ResolveStage::new(Arc::clone(&cache), build_behaviors());
+    let context = ResolutionContext {
+        file_id: rel.file_id,
+        language_id: python(),
+        imports: vec![],
+        local_symbols: vec![],
+        scope: Box::new(GenericResolutionContext::new(rel.file_id)),
+        unresolved_rels: vec![rel],
+        variable_bindings: vec![],
+    };
+    stage.resolve(&context)
+}
+
+#[test]
+fn query_resolves_to_sql_table_over_same_named_class() {
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(symbol(1, "load_user", SymbolKind::Function, 1, python()));
+    cache.insert(symbol(2, "users", SymbolKind::Class, 1, python()));
+    cache.insert(symbol(3, "users", SymbolKind::Struct, 2, sql()));
+
+    let (batch, _stats) = resolve(cache, query_reference("users"));
+    assert_eq!(batch.len(), 1);
+    assert_eq!(
+        batch.relationships[0].to_id,
+        SymbolId::new(3).unwrap(),
+        "the SQL table wins over the Python class in the caller's own file"
+    );
+}
+
+#[test]
+fn query_never_resolves_to_column() {
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(symbol(1, "load_user", SymbolKind::Function, 1, python()));
+    cache.insert(symbol(2, "sessions", SymbolKind::Struct, 2, sql()));
+    cache.insert(symbol(3, "sessions", SymbolKind::Field, 3, sql()));
+
+    let (batch, _stats) = resolve(cache, query_reference("sessions"));
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(2).unwrap());
+}
+
+#[test]
+fn two_sql_tables_of_one_name_fail_closed() {
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(symbol(1, "load_user", SymbolKind::Function, 1, python()));
+    cache.insert(symbol(2, "events", SymbolKind::Struct, 2, sql()));
+    cache.insert(symbol(3, "events", SymbolKind::Struct, 3, sql()));
+
+    let (batch, _stats) = resolve(cache, query_reference("events"));
+    assert_eq!(batch.len(), 0);
+}
+
+#[test]
+fn query_without_sql_table_stays_unresolved() {
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(symbol(1, "load_user", SymbolKind::Function, 1, python()));
+    cache.insert(symbol(2, "users", SymbolKind::Class, 1, python()));
+
+    let (batch, _stats) = resolve(cache, query_reference("users"));
+    assert_eq!(
+        batch.len(),
+        0,
+        "a query string never links to application code"
+    );
+}
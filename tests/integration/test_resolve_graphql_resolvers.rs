@@ -0,0 +1,211 @@
+//! GraphQL schema fields resolve to their resolvers in application code.
+//!
+//! `user` on `type Query` records a call to `user` in TypeScript, `User` in
+//! Go, `resolve_user` and `user` in Python, and so on, each with that
+//! `target_language`. The RESOLVE stage looks the name up in the target
+//! language; the graphql behavior prefers resolvers in a class named after
+//! the field's type, and only lets root operation fields fall back to
+//! free functions.
+
+use codanna::config::Settings;
+use codanna::indexing::pipeline::types::{
+    ResolutionContext, ResolvedBatch, SymbolLookupCache, UnresolvedRelationship,
+};
+use codanna::indexing::pipeline::{ResolveStage, ResolveStats};
+use codanna::parsing::resolution::GenericResolutionContext;
+use codanna::parsing::{LanguageBehavior, LanguageId, ParserFactory};
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, Range, SymbolId};
+use codanna::{RelationKind, Symbol, SymbolKind, Visibility};
+use std::collections::HashMap;
+use std::sync::Arc;
+
+fn graphql() -> LanguageId {
+    LanguageId::new("graphql")
+}
+
+fn typescript() -> LanguageId {
+    LanguageId::new("typescript")
+}
+
+fn build_behaviors() -> HashMap<LanguageId, Arc<dyn LanguageBehavior>> {
+    let settings = Settings::load().expect("Failed to load settings");
+    let factory = ParserFactory::new(Arc::new(settings));
+    let mut map = HashMap::new();
+    for lang in [graphql(), typescript()] {
+        let behavior: Arc<dyn LanguageBehavior> =
+            Arc::from(factory.create_behavior_from_registry(lang));
+        map.insert(lang, behavior);
+    }
+    map
+}
+
+fn symbol(
+    id: u32,
+    name: &str,
+    kind: SymbolKind,
+    class: Option<&str>,
+    file: u32,
+    lang: LanguageId,
+) -> Symbol {
+    let mut sym = Symbol::new(
+        SymbolId::new(id).unwrap(),
+        name,
+        kind,
+        FileId::new(file).unwrap(),
+        Range::new(1, 0, 6, 1),
+    );
+    sym.language_id = Some(lang);
+    sym.visibility = Visibility::Public;
+    sym.scope_context = Some(match class {
+        Some(class) => ScopeContext::ClassMember {
+            class_name: Some(class.into()),
+        },
+        None => ScopeContext::Module,
+    });
+    sym
+}
+
+fn resolver_call(field: &Symbol) -> UnresolvedRelationship {
+    UnresolvedRelationship {
+        from_id: Some(field.id),
+        from_name: field.name.as_ref().into(),
+        to_name: field.name.as_ref().into(),
+        file_id: field.file_id,
+        kind: RelationKind::Calls,
+        metadata: None,
+        to_range: Some(Range::new(3, 2, 3, 20)),
+        target_language: Some(typescript()),
+    }
+}
+
+fn resolve(
+    cache: Arc<SymbolLookupCache>,
+    rel: UnresolvedRelationship,
+) -> (ResolvedBatch, ResolveStats) {
+    let stage = ResolveStage::new(Arc::clone(&cache), build_behaviors());
+    let context = ResolutionContext {
+        file_id: rel.file_id,
+        language_id: graphql(),
+        imports: vec![],
+        local_symbols: vec![],
+        scope: Box::new(GenericResolutionContext::new(rel.file_id)),
+        unresolved_rels: vec![rel],
+        variable_bindings: vec![],
+    };
+    stage.resolve(&context)
+}
+
+#[test]
+fn root_field_resolves_to_resolver_class_method() {
+    let field = symbol(1, "user", SymbolKind::Method, Some("Query"), 1, graphql());
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(field.clone());
+    cache.insert(symbol(
+        2,
+        "user",
+        SymbolKind::Method,
+        Some("SessionStore"),
+        2,
+        typescript(),
+    ));
+    cache.insert(symbol(
+        3,
+        "user",
+        SymbolKind::Method,
+        Some("QueryResolver"),
+        3,
+        typescript(),
+    ));
+
+    let (batch, _stats) = resolve(cache, resolver_call(&field));
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
+
+#[test]
+fn root_field_falls_back_to_free_function() {
+    let field = symbol(1, "user", SymbolKind::Method, Some("Query"), 1, graphql());
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(field.clone());
+    cache.insert(symbol(
+        2,
+        "user",
+        SymbolKind::Method,
+        Some("SessionStore"),
+        2,
+        typescript(),
+    ));
+    cache.insert(symbol(
+        3,
+        "user",
+        SymbolKind::Function,
+        None,
+        3,
+        typescript(),
+    ));
+
+    let (batch, _stats) = resolve(cache, resolver_call(&field));
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
+
+#[test]
+fn type_field_only_links_to_its_resolver_class() {
+    let field = symbol(1, "posts", SymbolKind::Field, Some("User"), 1, graphql());
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(field.clone());
+    cache.insert(symbol(
+        2,
+        "posts",
+        SymbolKind::Function,
+        None,
+        2,
+        typescript(),
+    ));
+
+    let (batch, _stats) = resolve(Arc::clone(&cache), resolver_call(&field));
+    assert_eq!(
+        batch.len(),
+        0,
+        "a free `posts` function is not evidence of a User.posts resolver"
+    );
+
+    cache.insert(symbol(
+        3,
+        "posts",
+        SymbolKind::Method,
+        Some("UserResolver"),
+        3,
+        typescript(),
+    ));
+    let (batch, _stats) = resolve(cache, resolver_call(&field));
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
+
+#[test]
+fn two_equal_resolvers_fail_closed() {
+    let field = symbol(1, "user", SymbolKind::Method, Some("Query"), 1, graphql());
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(field.clone());
+    cache.insert(symbol(
+        2,
+        "user",
+        SymbolKind::Function,
+        None,
+        2,
+        typescript(),
+    ));
+    cache.insert(symbol(
+        3,
+        "user",
+        SymbolKind::Function,
+        None,
+        3,
+        typescript(),
+    ));
+
+    let (batch, _stats) = resolve(cache, resolver_call(&field));
+    assert_eq!(batch.len(), 0);
+}
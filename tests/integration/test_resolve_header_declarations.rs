@@ -0,0 +1,234 @@
+//! C and C++ definitions pair with their header declarations.
+//!
+//! `widget.h` declares what `widget.cpp` defines. The PARSE stage records
+//! a link from each definition to a declaration of its name; the RESOLVE
+//! stage pairs it with the same-name function (or method of the same
+//! class) of a header of the definition's module, else of the one header
+//! its file includes, and sends calls of a declaration to its definition.
+
+use codanna::config::Settings;
+use codanna::indexing::pipeline::types::{
+    ResolutionContext, ResolvedBatch, SymbolLookupCache, UnresolvedRelationship,
+};
+use codanna::indexing::pipeline::{ResolveStage, ResolveStats};
+use codanna::parsing::resolution::GenericResolutionContext;
+use codanna::parsing::{Import, LanguageBehavior, LanguageId, ParserFactory};
+use codanna::relationship::RelationshipMetadata;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, Range, SymbolId};
+use codanna::{RelationKind, Symbol, SymbolKind, Visibility};
+use std::collections::HashMap;
+use std::sync::Arc;
+
+fn c() -> LanguageId {
+    LanguageId::new("c")
+}
+
+fn cpp() -> LanguageId {
+    LanguageId::new("cpp")
+}
+
+fn build_behaviors() -> HashMap<LanguageId, Arc<dyn LanguageBehavior>> {
+    let settings = Settings::load().expect("Failed to load settings");
+    let factory = ParserFactory::new(Arc::new(settings));
+    let mut map = HashMap::new();
+    for lang in [c(), cpp()] {
+        let behavior: Arc<dyn LanguageBehavior> =
+            Arc::from(factory.create_behavior_from_registry(lang));
+        map.insert(lang, behavior);
+    }
+    map
+}
+
+/// A public symbol of `path`, in the module named after the file
+fn symbol(id: u32, name: &str, kind: SymbolKind, path: &str, file: u32) -> Symbol {
+    let module = path.rsplit_once('.').map_or(path, |(stem, _)| stem);
+    let mut sym = Symbol::new(
+        SymbolId::new(id).unwrap(),
+        name,
+        kind,
+        FileId::new(file).unwrap(),
+        Range::new(id, 0, id, 20),
+    )
+    .with_file_path(format!("src/{path}"))
+    .with_module_path(format!("{}::{name}", module.replace('/', "::")));
+    sym.language_id = Some(cpp());
+    sym.visibility = Visibility::Public;
+    sym.scope_context = Some(ScopeContext::Module);
+    sym
+}
+
+fn member(sym: Symbol, class_name: &str) -> Symbol {
+    let mut sym = sym;
+    sym.kind = SymbolKind::Method;
+    sym.scope_context = Some(ScopeContext::ClassMember {
+        class_name: Some(class_name.into()),
+    });
+    sym
+}
+
+fn declaration_link(from: &Symbol) -> UnresolvedRelationship {
+    let metadata = RelationshipMetadata::new()
+        .at_position(from.range.start_line, 0)
+        .with_context(RelationshipMetadata::DECLARATION);
+    UnresolvedRelationship {
+        from_id: Some(from.id),
+        from_name: from.name.as_ref().into(),
+        to_name: from.name.as_ref().into(),
+        file_id: from.file_id,
+        kind: RelationKind::Implements,
+        metadata: Some(metadata),
+        to_range: Some(from.range),
+        target_language: None,
+    }
+}
+
+fn call(from: &Symbol, name: &str) -> UnresolvedRelationship {
+    UnresolvedRelationship {
+        from_id: Some(from.id),
+        from_name: from.name.as_ref().into(),
+        to_name: name.into(),
+        file_id: from.file_id,
+        kind: RelationKind::Calls,
+        metadata: Some(RelationshipMetadata::new().at_position(from.range.start_line, 4)),
+        to_range: Some(from.range),
+        target_language: None,
+    }
+}
+
+fn include(path: &str, file: u32) -> Import {
+    Import {
+        path: path.to_string(),
+        alias: None,
+        file_id: FileId::new(file).unwrap(),
+        is_glob: false,
+        is_type_only: false,
+    }
+}
+
+fn resolve(
+    cache: &Arc<SymbolLookupCache>,
+    rel: UnresolvedRelationship,
+    imports: Vec<Import>,
+) -> (ResolvedBatch, ResolveStats) {
+    let stage = ResolveStage::new(Arc::clone(cache), build_behaviors());
+    let context = ResolutionContext {
+        file_id: rel.file_id,
+        language_id: cpp(),
+        imports,
+        local_symbols: vec![],
+        scope: Box::new(GenericResolutionContext::new(rel.file_id)),
+        unresolved_rels: vec![rel],
+        variable_bindings: vec![],
+    };
+    stage.resolve(&context)
+}
+
+fn cache(symbols: &[Symbol]) -> Arc<SymbolLookupCache> {
+    let cache = Arc::new(SymbolLookupCache::new());
+    for sym in symbols {
+        cache.insert(sym.clone());
+    }
+    cache
+}
+
+#[test]
+fn definition_links_to_the_declaration_of_its_header() {
+    let declared = symbol(1, "area", SymbolKind::Function, "widget.h", 1);
+    let other = symbol(2, "area", SymbolKind::Function, "shapes.h", 2);
+    let defined = symbol(3, "area", SymbolKind::Function, "widget.cpp", 3);
+    let cache = cache(&[declared.clone(), other, defined.clone()]);
+
+    let (batch, _stats) = resolve(&cache, declaration_link(&defined), vec![]);
+    assert_eq!(batch.len(), 1);
+    let link = &batch.relationships[0];
+    assert_eq!((link.from_id, link.to_id), (defined.id, declared.id));
+    assert_eq!(link.kind, RelationKind::Implements);
+    assert!(link.metadata.as_ref().unwrap().is_declaration_link());
+}
+
+#[test]
+fn definition_links_to_the_header_it_includes() {
+    let declared = symbol(1, "render", SymbolKind::Function, "api/render.h", 1);
+    let other = symbol(2, "render", SymbolKind::Function, "legacy/draw.h", 2);
+    let defined = symbol(3, "render", SymbolKind::Function, "impl/gl.cpp", 3);
+    let cache = cache(&[declared.clone(), other, defined.clone()]);
+
+    let (batch, _stats) = resolve(&cache, declaration_link(&defined), vec![]);
+    assert_eq!(batch.len(), 0, "no module or include pairs them");
+
+    let (batch, _stats) = resolve(
+        &cache,
+        declaration_link(&defined),
+        vec![include("api/render.h", 3)],
+    );
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, declared.id);
+}
+
+#[test]
+fn out_of_line_method_links_to_its_class_declaration() {
+    let widget = member(
+        symbol(1, "resize", SymbolKind::Method, "widget.h", 1),
+        "Widget",
+    );
+    let dialog = member(
+        symbol(2, "resize", SymbolKind::Method, "widget.h", 1),
+        "Dialog",
+    );
+    let defined = member(
+        symbol(3, "resize", SymbolKind::Method, "widget.cpp", 3),
+        "Widget",
+    );
+    let cache = cache(&[widget.clone(), dialog, defined.clone()]);
+
+    let (batch, _stats) = resolve(&cache, declaration_link(&defined), vec![]);
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, widget.id);
+}
+
+#[test]
+fn overloads_fail_closed() {
+    let first = symbol(1, "scale", SymbolKind::Function, "widget.h", 1);
+    let second = symbol(2, "scale", SymbolKind::Function, "widget.h", 1);
+    let defined = symbol(3, "scale", SymbolKind::Function, "widget.cpp", 3);
+    let cache = cache(&[first, second, defined.clone()]);
+
+    let (batch, _stats) = resolve(&cache, declaration_link(&defined), vec![]);
+    assert_eq!(batch.len(), 0);
+}
+
+#[test]
+fn calls_across_the_header_reach_the_definition() {
+    let declared = symbol(1, "area", SymbolKind::Function, "widget.h", 1);
+    let defined = symbol(2, "area", SymbolKind::Function, "widget.cpp", 2);
+    let caller = symbol(3, "main", SymbolKind::Function, "main.cpp", 3);
+    let cache = cache(&[declared, defined.clone(), caller.clone()]);
+
+    let (batch, _stats) = resolve(&cache, call(&caller, "area"), vec![include("widget.h", 3)]);
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, defined.id);
+}
+
+#[test]
+fn calls_of_a_declaration_without_definition_stay() {
+    let declared = symbol(1, "area", SymbolKind::Function, "widget.h", 1);
+    let caller = symbol(2, "main", SymbolKind::Function, "main.cpp", 2);
+    let cache = cache(&[declared.clone(), caller.clone()]);
+
+    let (batch, _stats) = resolve(&cache, call(&caller, "area"), vec![include("widget.h", 2)]);
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, declared.id);
+}
+
+#[test]
+fn c_header_declares_what_cpp_defines() {
+    let mut declared = symbol(1, "area", SymbolKind::Function, "widget.h", 1);
+    declared.language_id = Some(c());
+    let defined = symbol(2, "area", SymbolKind::Function, "widget.cpp", 2);
+    let cache = cache(&[declared.clone(), defined.clone()]);
+
+    let (batch, _stats) = resolve(&cache, declaration_link(&defined), vec![]);
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, declared.id);
+}
@@ -0,0 +1,204 @@
+//! Code names in Markdown documents resolve to symbols of other languages.
+//!
+//! A section whose prose reads `` `authenticate_user` `` or
+//! `` `SessionStore::open` `` records a use of `authenticate_user` or
+//! `open` (receiver `SessionStore`) with no target language. The markdown
+//! behavior references other languages, so the RESOLVE stage looks the
+//! name up in every language but Markdown; a receiver must name the
+//! candidate's container, and public symbols outrank private ones.
+
+use codanna::config::Settings;
+use codanna::indexing::pipeline::types::{
+    ResolutionContext, ResolvedBatch, SymbolLookupCache, UnresolvedRelationship,
+};
+use codanna::indexing::pipeline::{ResolveStage, ResolveStats};
+use codanna::parsing::resolution::GenericResolutionContext;
+use codanna::parsing::{LanguageBehavior, LanguageId, ParserFactory};
+use codanna::relationship::RelationshipMetadata;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, Range, SymbolId};
+use codanna::{RelationKind, Symbol, SymbolKind, Visibility};
+use std::collections::HashMap;
+use std::sync::Arc;
+
+fn markdown() -> LanguageId {
+    LanguageId::new("markdown")
+}
+
+fn rust() -> LanguageId {
+    LanguageId::new("rust")
+}
+
+fn python() -> LanguageId {
+    LanguageId::new("python")
+}
+
+fn build_behaviors() -> HashMap<LanguageId, Arc<dyn LanguageBehavior>> {
+    let settings = Settings::load().expect("Failed to load settings");
+    let factory = ParserFactory::new(Arc::new(settings));
+    let mut map = HashMap::new();
+    for lang in [markdown(), rust(), python()] {
+        let behavior: Arc<dyn LanguageBehavior> =
+            Arc::from(factory.create_behavior_from_registry(lang));
+        map.insert(lang, behavior);
+    }
+    map
+}
+
+fn symbol(
+    id: u32,
+    name: &str,
+    kind: SymbolKind,
+    class: Option<&str>,
+    visibility: Visibility,
+    lang: LanguageId,
+) -> Symbol {
+    let mut sym = Symbol::new(
+        SymbolId::new(id).unwrap(),
+        name,
+        kind,
+        FileId::new(id).unwrap(),
+        Range::new(1, 0, 6, 1),
+    );
+    sym.language_id = Some(lang);
+    sym.visibility = visibility;
+    sym.scope_context = Some(match class {
+        Some(class) => ScopeContext::ClassMember {
+            class_name: Some(class.into()),
+        },
+        None => ScopeContext::Module,
+    });
+    sym
+}
+
+fn section() -> Symbol {
+    symbol(
+        1,
+        "Authentication",
+        SymbolKind::Module,
+        None,
+        Visibility::Public,
+        markdown(),
+    )
+}
+
+fn reference(section: &Symbol, name: &str, receiver: Option<&str>) -> UnresolvedRelationship {
+    let mut metadata = RelationshipMetadata::new().at_position(3, 10);
+    if let Some(receiver) = receiver {
+        metadata = metadata.with_receiver(receiver);
+    }
+    UnresolvedRelationship {
+        from_id: Some(section.id),
+        from_name: section.name.as_ref().into(),
+        to_name: name.into(),
+        file_id: section.file_id,
+        kind: RelationKind::Uses,
+        metadata: Some(metadata),
+        to_range: Some(Range::new(3, 10, 3, 30)),
+        target_language: None,
+    }
+}
+
+fn resolve(
+    cache: Arc<SymbolLookupCache>,
+    rel: UnresolvedRelationship,
+) -> (ResolvedBatch, ResolveStats) {
+    let stage = ResolveStage::new(Arc::clone(&cache), build_behaviors());
+    let context = ResolutionContext {
+        file_id: rel.file_id,
+        language_id: markdown(),
+        imports: vec![],
+        local_symbols: vec![],
+        scope: Box::new(GenericResolutionContext::new(rel.file_id)),
+        unresolved_rels: vec![rel],
+        variable_bindings: vec![],
+    };
+    stage.resolve(&context)
+}
+
+#[test]
+fn name_resolves_in_another_language() {
+    let section = section();
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(section.clone());
+    cache.insert(symbol(
+        2,
+        "authenticate_user",
+        SymbolKind::Module,
+        None,
+        Visibility::Public,
+        markdown(),
+    ));
+    cache.insert(symbol(
+        3,
+        "authenticate_user",
+        SymbolKind::Function,
+        None,
+        Visibility::Public,
+        rust(),
+    ));
+
+    let (batch, _stats) = resolve(cache, reference(&section, "authenticate_user", None));
+    assert_eq!(batch.len(), 1, "headings of other documents are not code");
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
+
+#[test]
+fn receiver_picks_the_container() {
+    let section = section();
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(section.clone());
+    cache.insert(symbol(
+        2,
+        "open",
+        SymbolKind::Method,
+        Some("FileHandle"),
+        Visibility::Public,
+        python(),
+    ));
+    cache.insert(symbol(
+        3,
+        "open",
+        SymbolKind::Method,
+        Some("SessionStore"),
+        Visibility::Public,
+        rust(),
+    ));
+
+    let (batch, _stats) = resolve(
+        Arc::clone(&cache),
+        reference(&section, "open", Some("SessionStore")),
+    );
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+
+    let (batch, _stats) = resolve(cache, reference(&section, "open", None));
+    assert_eq!(batch.len(), 0, "an unqualified `open` is ambiguous");
+}
+
+#[test]
+fn public_symbol_outranks_private_one() {
+    let section = section();
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(section.clone());
+    cache.insert(symbol(
+        2,
+        "SessionStore",
+        SymbolKind::Class,
+        None,
+        Visibility::Private,
+        python(),
+    ));
+    cache.insert(symbol(
+        3,
+        "SessionStore",
+        SymbolKind::Struct,
+        None,
+        Visibility::Public,
+        rust(),
+    ));
+
+    let (batch, _stats) = resolve(cache, reference(&section, "SessionStore", None));
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
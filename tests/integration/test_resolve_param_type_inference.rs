@@ -133,6 +133,7 @@ fn instance_call_unresolved(
         kind: RelationKind::Calls,
         metadata: Some(meta),
         to_range: None,
+        target_language: None,
     }
 }
 
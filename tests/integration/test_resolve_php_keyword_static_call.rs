@@ -86,6 +86,7 @@ fn static_call_unresolved(
         kind: RelationKind::Calls,
         metadata: Some(meta),
         to_range: None,
+        target_language: None,
     }
 }
 
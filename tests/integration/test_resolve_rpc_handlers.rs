@@ -0,0 +1,203 @@
+//! RPCs of `.proto` services resolve to their handlers in other languages.
+//!
+//! `rpc GetUser` records a call to `get_user` in Rust, `GetUser` in Go and
+//! `getUser` in TypeScript, each with that `target_language`. The RESOLVE
+//! stage looks the name up in the target language; the protobuf behavior
+//! rejects the stubs generated for the service and prefers a handler
+//! taking the RPC's request message.
+
+use codanna::config::Settings;
+use codanna::indexing::pipeline::types::{
+    ResolutionContext, ResolvedBatch, SymbolLookupCache, UnresolvedRelationship,
+};
+use codanna::indexing::pipeline::{ResolveStage, ResolveStats};
+use codanna::parsing::resolution::GenericResolutionContext;
+use codanna::parsing::{LanguageBehavior, LanguageId, ParserFactory};
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, Range, SymbolId};
+use codanna::{RelationKind, Symbol, SymbolKind, Visibility};
+use std::collections::HashMap;
+use std::sync::Arc;
+
+fn protobuf() -> LanguageId {
+    LanguageId::new("protobuf")
+}
+
+fn go() -> LanguageId {
+    LanguageId::new("go")
+}
+
+fn build_behaviors() -> HashMap<LanguageId, Arc<dyn LanguageBehavior>> {
+    let settings = Settings::load().expect("Failed to load settings");
+    let factory = ParserFactory::new(Arc::new(settings));
+    let mut map = HashMap::new();
+    for lang in [protobuf(), go()] {
+        let behavior: Arc<dyn LanguageBehavior> =
+            Arc::from(factory.create_behavior_from_registry(lang));
+        map.insert(lang, behavior);
+    }
+    map
+}
+
+fn method(
+    id: u32,
+    name: &str,
+    class: &str,
+    file: u32,
+    lang: LanguageId,
+    signature: &str,
+) -> Symbol {
+    let mut sym = Symbol::new(
+        SymbolId::new(id).unwrap(),
+        name,
+        SymbolKind::Method,
+        FileId::new(file).unwrap(),
+        Range::new(1, 0, 6, 1),
+    )
+    .with_signature(signature);
+    sym.language_id = Some(lang);
+    sym.visibility = Visibility::Public;
+    sym.scope_context = Some(ScopeContext::ClassMember {
+        class_name: Some(class.into()),
+    });
+    sym
+}
+
+fn rpc() -> Symbol {
+    method(
+        1,
+        "GetUser",
+        "UserService",
+        1,
+        protobuf(),
+        "rpc GetUser(GetUserRequest) returns (User)",
+    )
+}
+
+fn handler_call() -> UnresolvedRelationship {
+    UnresolvedRelationship {
+        from_id: Some(SymbolId::new(1).unwrap()),
+        from_name: "GetUser".into(),
+        to_name: "GetUser".into(),
+        file_id: FileId::new(1).unwrap(),
+        kind: RelationKind::Calls,
+        metadata: None,
+        to_range: Some(Range::new(3, 2, 3, 45)),
+        target_language: Some(go()),
+    }
+}
+
+fn resolve(
+    cache: Arc<SymbolLookupCache>,
+    rel: UnresolvedRelationship,
+) -> (ResolvedBatch, ResolveStats) {
+    let stage = ResolveStage::new(Arc::clone(&cache), build_behaviors());
+    let context = ResolutionContext {
+        file_id: rel.file_id,
+        language_id: protobuf(),
+        imports: vec![],
+        local_symbols: vec![],
+        scope: Box::new(GenericResolutionContext::new(rel.file_id)),
+        unresolved_rels: vec![rel],
+        variable_bindings: vec![],
+    };
+    stage.resolve(&context)
+}
+
+#[test]
+fn rpc_resolves_to_handler_over_generated_stubs() {
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(rpc());
+    cache.insert(method(
+        2,
+        "GetUser",
+        "userServiceClient",
+        2,
+        go(),
+        "func (c *userServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)",
+    ));
+    cache.insert(method(
+        3,
+        "GetUser",
+        "UnimplementedUserServiceServer",
+        2,
+        go(),
+        "func (UnimplementedUserServiceServer) GetUser(context.Context, *GetUserRequest) (*User, error)",
+    ));
+    cache.insert(method(
+        4,
+        "GetUser",
+        "server",
+        3,
+        go(),
+        "func (s *server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error)",
+    ));
+
+    let (batch, _stats) = resolve(cache, handler_call());
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(4).unwrap());
+}
+
+#[test]
+fn handler_taking_the_request_outranks_same_named_method() {
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(rpc());
+    cache.insert(method(
+        2,
+        "GetUser",
+        "userRepository",
+        2,
+        go(),
+        "func (r *userRepository) GetUser(id int64) (*User, error)",
+    ));
+    cache.insert(method(
+        3,
+        "GetUser",
+        "server",
+        3,
+        go(),
+        "func (s *server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error)",
+    ));
+
+    let (batch, _stats) = resolve(cache, handler_call());
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
+
+#[test]
+fn two_equal_handlers_fail_closed() {
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(rpc());
+    cache.insert(method(
+        2,
+        "GetUser",
+        "userRepository",
+        2,
+        go(),
+        "func (r *userRepository) GetUser(id int64) (*User, error)",
+    ));
+    cache.insert(method(
+        3,
+        "GetUser",
+        "adminRepository",
+        3,
+        go(),
+        "func (r *adminRepository) GetUser(id int64) (*User, error)",
+    ));
+
+    let (batch, _stats) = resolve(cache, handler_call());
+    assert_eq!(batch.len(), 0);
+}
+
+#[test]
+fn rpc_never_resolves_to_itself() {
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(rpc());
+
+    let (batch, _stats) = resolve(cache, handler_call());
+    assert_eq!(
+        batch.len(),
+        0,
+        "the RPC is not a Go symbol, so it is no handler of itself"
+    );
+}
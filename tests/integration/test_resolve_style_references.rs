@@ -0,0 +1,141 @@
+//! Classes, ids and custom properties named in markup resolve to the
+//! stylesheet rules defining them.
+//!
+//! `className="btn"` in a component records a reference to `.btn`,
+//! `id="sidebar"` one to `#sidebar` and `var(--accent)` one to `--accent`,
+//! each with CSS as its target language. The RESOLVE stage looks the name
+//! up among the symbols of stylesheets only, so an HTML element identified
+//! as `#sidebar` is never the target, and a name defined by two
+//! stylesheets is ambiguous.
+
+use codanna::config::Settings;
+use codanna::indexing::pipeline::types::{
+    ResolutionContext, ResolvedBatch, SymbolLookupCache, UnresolvedRelationship,
+};
+use codanna::indexing::pipeline::{ResolveStage, ResolveStats};
+use codanna::parsing::resolution::GenericResolutionContext;
+use codanna::parsing::{LanguageBehavior, LanguageId, ParserFactory};
+use codanna::relationship::RelationshipMetadata;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, Range, SymbolId};
+use codanna::{RelationKind, Symbol, SymbolKind, Visibility};
+use std::collections::HashMap;
+use std::sync::Arc;
+
+fn css() -> LanguageId {
+    LanguageId::new("css")
+}
+
+fn html() -> LanguageId {
+    LanguageId::new("html")
+}
+
+fn typescript() -> LanguageId {
+    LanguageId::new("typescript")
+}
+
+fn build_behaviors() -> HashMap<LanguageId, Arc<dyn LanguageBehavior>> {
+    let settings = Settings::load().expect("Failed to load settings");
+    let factory = ParserFactory::new(Arc::new(settings));
+    let mut map = HashMap::new();
+    for lang in [css(), html(), typescript()] {
+        let behavior: Arc<dyn LanguageBehavior> =
+            Arc::from(factory.create_behavior_from_registry(lang));
+        map.insert(lang, behavior);
+    }
+    map
+}
+
+fn symbol(id: u32, name: &str, kind: SymbolKind, lang: LanguageId) -> Symbol {
+    let mut sym = Symbol::new(
+        SymbolId::new(id).unwrap(),
+        name,
+        kind,
+        FileId::new(id).unwrap(),
+        Range::new(1, 0, 6, 1),
+    );
+    sym.language_id = Some(lang);
+    sym.visibility = Visibility::Public;
+    sym.scope_context = Some(ScopeContext::Module);
+    sym
+}
+
+fn component() -> Symbol {
+    symbol(1, "Button", SymbolKind::Function, typescript())
+}
+
+fn reference(from: &Symbol, name: &str, via: &str) -> UnresolvedRelationship {
+    let metadata = RelationshipMetadata::new()
+        .at_position(3, 20)
+        .with_context(via);
+    UnresolvedRelationship {
+        from_id: Some(from.id),
+        from_name: from.name.as_ref().into(),
+        to_name: name.into(),
+        file_id: from.file_id,
+        kind: RelationKind::References,
+        metadata: Some(metadata),
+        to_range: Some(Range::new(3, 20, 3, 23)),
+        target_language: Some(css()),
+    }
+}
+
+fn resolve(
+    cache: Arc<SymbolLookupCache>,
+    rel: UnresolvedRelationship,
+) -> (ResolvedBatch, ResolveStats) {
+    let stage = ResolveStage::new(Arc::clone(&cache), build_behaviors());
+    let context = ResolutionContext {
+        file_id: rel.file_id,
+        language_id: typescript(),
+        imports: vec![],
+        local_symbols: vec![],
+        scope: Box::new(GenericResolutionContext::new(rel.file_id)),
+        unresolved_rels: vec![rel],
+        variable_bindings: vec![],
+    };
+    stage.resolve(&context)
+}
+
+#[test]
+fn class_resolves_to_its_rule() {
+    let button = component();
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(button.clone());
+    cache.insert(symbol(2, ".btn", SymbolKind::Class, css()));
+    cache.insert(symbol(3, "--accent", SymbolKind::Variable, css()));
+
+    let (batch, _stats) = resolve(Arc::clone(&cache), reference(&button, ".btn", "className"));
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(2).unwrap());
+    assert_eq!(batch.relationships[0].kind, RelationKind::References);
+
+    let (batch, _stats) = resolve(cache, reference(&button, "--accent", "var"));
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
+
+#[test]
+fn id_resolves_among_stylesheets_only() {
+    let button = component();
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(button.clone());
+    cache.insert(symbol(2, "#sidebar", SymbolKind::Constant, html()));
+    cache.insert(symbol(3, "#sidebar", SymbolKind::Constant, css()));
+
+    let (batch, _stats) = resolve(cache, reference(&button, "#sidebar", "id"));
+    assert_eq!(batch.len(), 1, "the element is not the rule styling it");
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
+
+#[test]
+fn class_of_two_stylesheets_is_ambiguous() {
+    let button = component();
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(button.clone());
+    cache.insert(symbol(2, ".btn", SymbolKind::Class, css()));
+    cache.insert(symbol(3, ".btn", SymbolKind::Class, css()));
+
+    let (batch, _stats) = resolve(cache, reference(&button, ".btn", "class"));
+    assert_eq!(batch.len(), 0);
+}
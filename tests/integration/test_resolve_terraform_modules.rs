@@ -0,0 +1,152 @@
+//! Terraform module calls resolve to the variables of the called module.
+//!
+//! `module "vpc" { source = "./modules/vpc", cidr_block = ... }` records a
+//! call from `module.vpc` to `var.cidr_block`, targeted at HCL symbols.
+//! The hcl behavior only accepts the variable declared in the `source`
+//! directory, so a same-named variable of the caller's own module is never
+//! picked. Plain references stay within the directory they appear in.
+
+use codanna::config::Settings;
+use codanna::indexing::pipeline::types::{
+    ResolutionContext, ResolvedBatch, SymbolLookupCache, UnresolvedRelationship,
+};
+use codanna::indexing::pipeline::{ResolveStage, ResolveStats};
+use codanna::parsing::resolution::GenericResolutionContext;
+use codanna::parsing::{LanguageBehavior, LanguageId, ParserFactory};
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, Range, SymbolId};
+use codanna::{RelationKind, Symbol, SymbolKind, Visibility};
+use std::collections::HashMap;
+use std::sync::Arc;
+
+fn hcl() -> LanguageId {
+    LanguageId::new("hcl")
+}
+
+fn build_behaviors() -> HashMap<LanguageId, Arc<dyn LanguageBehavior>> {
+    let settings = Settings::load().expect("Failed to load settings");
+    let factory = ParserFactory::new(Arc::new(settings));
+    let mut map = HashMap::new();
+    let behavior: Arc<dyn LanguageBehavior> =
+        Arc::from(factory.create_behavior_from_registry(hcl()));
+    map.insert(hcl(), behavior);
+    map
+}
+
+fn symbol(id: u32, name: &str, kind: SymbolKind, file: u32, module_path: &str) -> Symbol {
+    let mut sym = Symbol::new(
+        SymbolId::new(id).unwrap(),
+        name,
+        kind,
+        FileId::new(file).unwrap(),
+        Range::new(1, 0, 6, 1),
+    );
+    sym.language_id = Some(hcl());
+    sym.visibility = Visibility::Public;
+    sym.module_path = Some(module_path.into());
+    sym.scope_context = Some(ScopeContext::Module);
+    sym
+}
+
+fn module_call(source: &str) -> Symbol {
+    symbol(1, "module.vpc", SymbolKind::Module, 1, ".").with_signature(format!(
+        r#"module "vpc" {{ source = "{source}", cidr_block }}"#
+    ))
+}
+
+fn relationship(
+    from: &Symbol,
+    to_name: &str,
+    kind: RelationKind,
+    target_language: Option<LanguageId>,
+) -> UnresolvedRelationship {
+    UnresolvedRelationship {
+        from_id: Some(from.id),
+        from_name: from.name.as_ref().into(),
+        to_name: to_name.into(),
+        file_id: from.file_id,
+        kind,
+        metadata: None,
+        to_range: Some(Range::new(3, 2, 3, 30)),
+        target_language,
+    }
+}
+
+fn resolve(
+    cache: Arc<SymbolLookupCache>,
+    rel: UnresolvedRelationship,
+) -> (ResolvedBatch, ResolveStats) {
+    let stage = ResolveStage::new(Arc::clone(&cache), build_behaviors());
+    let context = ResolutionContext {
+        file_id: rel.file_id,
+        language_id: hcl(),
+        imports: vec![],
+        local_symbols: vec![],
+        scope: Box::new(GenericResolutionContext::new(rel.file_id)),
+        unresolved_rels: vec![rel],
+        variable_bindings: vec![],
+    };
+    stage.resolve(&context)
+}
+
+#[test]
+fn module_input_resolves_to_child_variable() {
+    let call = module_call("./modules/vpc");
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(call.clone());
+    cache.insert(symbol(2, "var.cidr_block", SymbolKind::Variable, 2, "."));
+    cache.insert(symbol(
+        3,
+        "var.cidr_block",
+        SymbolKind::Variable,
+        3,
+        "modules/vpc",
+    ));
+
+    let rel = relationship(&call, "var.cidr_block", RelationKind::Calls, Some(hcl()));
+    let (batch, _stats) = resolve(cache, rel);
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
+
+#[test]
+fn registry_module_inputs_stay_unresolved() {
+    let call = module_call("terraform-aws-modules/vpc/aws");
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(call.clone());
+    cache.insert(symbol(
+        2,
+        "var.cidr_block",
+        SymbolKind::Variable,
+        2,
+        "modules/vpc",
+    ));
+
+    let rel = relationship(&call, "var.cidr_block", RelationKind::Calls, Some(hcl()));
+    let (batch, _stats) = resolve(cache, rel);
+    assert_eq!(
+        batch.len(),
+        0,
+        "a registry module's variables are not in the project"
+    );
+}
+
+#[test]
+fn reference_resolves_within_its_module() {
+    let resource = symbol(1, "aws_instance.web", SymbolKind::Struct, 1, ".");
+    let cache = Arc::new(SymbolLookupCache::new());
+    cache.insert(resource.clone());
+    cache.insert(symbol(
+        2,
+        "var.region",
+        SymbolKind::Variable,
+        2,
+        "modules/vpc",
+    ));
+    cache.insert(symbol(3, "var.region", SymbolKind::Variable, 3, "."));
+
+    let rel = relationship(&resource, "var.region", RelationKind::Uses, None);
+    let (batch, _stats) = resolve(cache, rel);
+    assert_eq!(batch.len(), 1);
+    assert_eq!(batch.relationships[0].to_id, SymbolId::new(3).unwrap());
+}
@@ -72,3 +72,24 @@ mod test_resolve_param_type_inference;
 
 #[path = "integration/test_resolve_php_keyword_static_call.rs"]
 mod test_resolve_php_keyword_static_call;
+
+#[path = "integration/test_resolve_embedded_sql.rs"]
+mod test_resolve_embedded_sql;
+
+#[path = "integration/test_resolve_rpc_handlers.rs"]
+mod test_resolve_rpc_handlers;
+
+#[path = "integration/test_resolve_graphql_resolvers.rs"]
+mod test_resolve_graphql_resolvers;
+
+#[path = "integration/test_resolve_terraform_modules.rs"]
+mod test_resolve_terraform_modules;
+
+#[path = "integration/test_resolve_markdown_references.rs"]
+mod test_resolve_markdown_references;
+
+#[path = "integration/test_resolve_style_references.rs"]
+mod test_resolve_style_references;
+
+#[path = "integration/test_resolve_header_declarations.rs"]
+mod test_resolve_header_declarations;
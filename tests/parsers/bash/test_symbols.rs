@@ -0,0 +1,121 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::bash::BashParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/bash/basic.sh")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = BashParser::new().expect("Failed to create Bash parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+#[test]
+fn test_bash_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(!symbols.is_empty(), "Should extract symbols from Bash code");
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.scope_context);
+    }
+}
+
+#[test]
+fn test_bash_functions() {
+    let symbols = parse_fixture();
+
+    for name in ["build_image", "push_image", "deploy", "usage"] {
+        let function = find(&symbols, name);
+        assert_eq!(function.kind, SymbolKind::Function);
+        assert_eq!(function.scope_context, Some(ScopeContext::Global));
+    }
+
+    assert_eq!(
+        find(&symbols, "build_image").doc_comment.as_deref(),
+        Some("Builds and tags the container image\nfor the given version.")
+    );
+    assert_eq!(
+        find(&symbols, "push_image").signature.as_deref(),
+        Some("function push_image")
+    );
+}
+
+#[test]
+fn test_bash_script_symbol() {
+    let symbols = parse_fixture();
+
+    let script = find(&symbols, "<module>");
+    assert_eq!(script.kind, SymbolKind::Module);
+    assert_eq!(
+        script.doc_comment.as_deref(),
+        Some("Deploys the application to the target environment.")
+    );
+}
+
+#[test]
+fn test_bash_variables() {
+    let symbols = parse_fixture();
+
+    assert_eq!(find(&symbols, "SCRIPT_DIR").kind, SymbolKind::Variable);
+    assert_eq!(find(&symbols, "DEFAULT_ENV").kind, SymbolKind::Constant);
+
+    let registry = find(&symbols, "REGISTRY");
+    assert_eq!(registry.kind, SymbolKind::Variable);
+    assert_eq!(registry.scope_context, Some(ScopeContext::Global));
+
+    // `local` variables stay out of the index
+    assert!(!symbols.iter().any(|s| s.name.as_ref() == "version"));
+    assert!(!symbols.iter().any(|s| s.name.as_ref() == "env"));
+}
+
+#[test]
+fn test_bash_sourced_files() {
+    let mut parser = BashParser::new().unwrap();
+    let imports = parser.find_imports(load_basic_fixture(), FileId::new(1).unwrap());
+
+    let paths: Vec<&str> = imports.iter().map(|i| i.path.as_str()).collect();
+    assert_eq!(paths, ["$SCRIPT_DIR/lib/common.sh", "./lib/logging.sh"]);
+    assert!(imports.iter().all(|i| i.is_glob));
+}
+
+#[test]
+fn test_bash_command_calls() {
+    let mut parser = BashParser::new().unwrap();
+    let calls = parser.find_calls(load_basic_fixture());
+
+    let called = |caller: &str, callee: &str| {
+        calls
+            .iter()
+            .any(|(from, to, _)| *from == caller && *to == callee)
+    };
+
+    // Functions of the script and of sourced scripts
+    assert!(called("deploy", "build_image"));
+    assert!(called("deploy", "push_image"));
+    assert!(called("build_image", "log_info"));
+    // External commands, including those in pipelines and substitutions
+    assert!(called("build_image", "docker"));
+    assert!(called("push_image", "tee"));
+    assert!(called("deploy", "git"));
+    assert!(called("deploy", "kubectl"));
+    // Top-level commands belong to the script
+    assert!(called("<module>", "deploy"));
+    assert!(called("<module>", "usage"));
+    assert!(called("<module>", "dirname"));
+
+    assert!(!calls.iter().any(|(_, to, _)| *to == "source" || *to == "."));
+}
@@ -0,0 +1,27 @@
+use codanna::parsing::c::CParser;
+use codanna::types::SymbolCounter;
+use codanna::{FileId, SymbolKind};
+
+#[test]
+fn test_c_file_level_prototypes_are_functions() {
+    let code = r#"
+int area(struct shape *s);
+struct shape *make(void);
+void (*handler)(int);
+
+int main(void) {
+    int local(int);
+    return area(make());
+}
+"#;
+    let mut parser = CParser::new().unwrap();
+    let mut counter = SymbolCounter::new();
+    let symbols = parser.parse(code, FileId::new(1).unwrap(), &mut counter);
+
+    let functions: Vec<(&str, u32)> = symbols
+        .iter()
+        .filter(|s| s.kind == SymbolKind::Function)
+        .map(|s| (s.name.as_ref(), s.range.start_line))
+        .collect();
+    assert_eq!(functions, vec![("area", 1), ("make", 2), ("main", 5)]);
+}
@@ -0,0 +1,81 @@
+use codanna::parsing::cpp::CppParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::SymbolCounter;
+use codanna::{FileId, Symbol, SymbolKind};
+
+fn parse(code: &str) -> Vec<Symbol> {
+    let mut parser = CppParser::new().unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, FileId::new(1).unwrap(), &mut counter)
+}
+
+fn class_of(symbol: &Symbol) -> Option<&str> {
+    match &symbol.scope_context {
+        Some(ScopeContext::ClassMember { class_name }) => class_name.as_deref(),
+        _ => None,
+    }
+}
+
+#[test]
+fn test_cpp_header_prototypes_are_functions() {
+    let code = r#"
+namespace ui {
+int area(const Widget& w);
+Widget* make(int w);
+class Widget {
+public:
+    void resize(int w);
+};
+}
+void (*on_resize)(int);
+"#;
+    let symbols = parse(code);
+    let functions: Vec<&str> = symbols
+        .iter()
+        .filter(|s| s.kind == SymbolKind::Function)
+        .map(|s| s.name.as_ref())
+        .collect();
+    assert_eq!(
+        functions,
+        vec!["area", "make"],
+        "function pointers are not prototypes"
+    );
+
+    let resize = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "resize")
+        .unwrap();
+    assert_eq!(resize.kind, SymbolKind::Method);
+    assert_eq!(class_of(resize), Some("Widget"));
+}
+
+#[test]
+fn test_cpp_out_of_line_members_belong_to_their_class() {
+    let code = r#"
+void Widget::resize(int w) {
+    int helper(int);
+    width = w;
+}
+
+ui::Widget* ui::Widget::clone() const {
+    return nullptr;
+}
+
+template <typename T>
+void Stack<T>::push(T value) {}
+"#;
+    let symbols = parse(code);
+    let members: Vec<(&str, SymbolKind, Option<&str>)> = symbols
+        .iter()
+        .map(|s| (s.name.as_ref(), s.kind, class_of(s)))
+        .collect();
+    assert_eq!(
+        members,
+        vec![
+            ("resize", SymbolKind::Method, Some("Widget")),
+            ("clone", SymbolKind::Method, Some("Widget")),
+            ("push", SymbolKind::Method, Some("Stack")),
+        ],
+        "local prototypes are not indexed"
+    );
+}
@@ -0,0 +1,80 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::css::CssParser;
+use codanna::parsing::css::usages::find_usages;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/css/basic.css")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = CssParser::new().expect("Failed to create CSS parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+#[test]
+fn test_css_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(!symbols.is_empty(), "Should extract symbols from CSS code");
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.signature);
+    }
+}
+
+#[test]
+fn test_css_selectors_and_properties() {
+    let symbols = parse_fixture();
+    let names: Vec<&str> = symbols.iter().map(|s| s.name.as_ref()).collect();
+    assert_eq!(
+        names,
+        vec![
+            "--accent",
+            "--space",
+            ".btn",
+            ".btn--ghost",
+            "#sidebar",
+            ".menu"
+        ]
+    );
+
+    let btn = find(&symbols, ".btn");
+    assert_eq!(btn.kind, SymbolKind::Class);
+    assert_eq!(btn.doc_comment.as_deref(), Some("Primary call to action"));
+    assert_eq!(btn.range.start_line, 6, "Defined by its first rule");
+
+    assert_eq!(
+        find(&symbols, ".btn--ghost").signature.as_deref(),
+        Some(".btn:hover, .btn--ghost")
+    );
+    assert_eq!(find(&symbols, "#sidebar").kind, SymbolKind::Constant);
+    assert_eq!(find(&symbols, "--space").kind, SymbolKind::Variable);
+}
+
+#[test]
+fn test_css_custom_property_usages() {
+    let usages: Vec<(String, u32)> = find_usages(load_basic_fixture())
+        .into_iter()
+        .map(|usage| (usage.name, usage.range.start_line))
+        .collect();
+    assert_eq!(
+        usages,
+        vec![
+            ("--space".to_string(), 7),
+            ("--accent".to_string(), 8),
+            ("--space".to_string(), 17),
+        ]
+    );
+}
@@ -0,0 +1,212 @@
+use codanna::parsing::LanguageParser;
+use codanna::parsing::dart::DartParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+use codanna::{SymbolKind, Visibility};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/dart/basic.dart")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = DartParser::new().expect("Failed to create Dart parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+fn class_of(symbol: &codanna::Symbol) -> Option<&str> {
+    match &symbol.scope_context {
+        Some(ScopeContext::ClassMember {
+            class_name: Some(name),
+        }) => Some(name.as_ref()),
+        _ => None,
+    }
+}
+
+#[test]
+fn test_dart_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(!symbols.is_empty(), "Should extract symbols from Dart code");
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.visibility);
+    }
+}
+
+#[test]
+fn test_dart_extracts_types() {
+    let symbols = parse_fixture();
+
+    assert_eq!(find(&symbols, "Repository").kind, SymbolKind::Class);
+    assert_eq!(find(&symbols, "CounterPage").kind, SymbolKind::Class);
+    assert_eq!(find(&symbols, "Logging").kind, SymbolKind::Trait);
+    assert_eq!(find(&symbols, "Density").kind, SymbolKind::Enum);
+    assert_eq!(find(&symbols, "compact").kind, SymbolKind::Constant);
+    assert_eq!(find(&symbols, "Formatter").kind, SymbolKind::TypeAlias);
+
+    let state = find(&symbols, "_CounterPageState");
+    assert_eq!(state.kind, SymbolKind::Class);
+    assert_eq!(state.visibility, Visibility::Private);
+}
+
+#[test]
+fn test_dart_doc_comments_are_kept() {
+    let symbols = parse_fixture();
+
+    assert_eq!(
+        find(&symbols, "CounterPage").doc_comment.as_deref(),
+        Some("Shows a counter that increments when tapped")
+    );
+    // The comment sits above `@override`
+    assert_eq!(
+        find(&symbols, "build").doc_comment.as_deref(),
+        Some("Builds the counter layout")
+    );
+    assert_eq!(
+        find(&symbols, "maxRetries").doc_comment.as_deref(),
+        Some("Maximum number of retries for network calls")
+    );
+}
+
+#[test]
+fn test_dart_widget_build_method() {
+    let symbols = parse_fixture();
+
+    let build = find(&symbols, "build");
+    assert_eq!(build.kind, SymbolKind::Method);
+    assert_eq!(class_of(build), Some("_CounterPageState"));
+    assert!(
+        build
+            .signature
+            .as_deref()
+            .is_some_and(|sig| sig.contains("Widget build(BuildContext context)"))
+    );
+
+    let increment = find(&symbols, "_increment");
+    assert_eq!(increment.visibility, Visibility::Private);
+    assert_eq!(find(&symbols, "_count").kind, SymbolKind::Field);
+}
+
+#[test]
+fn test_dart_async_signatures() {
+    let symbols = parse_fixture();
+
+    let fetch = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "fetch" && class_of(s) == Some("HttpRepository"))
+        .expect("Should find HttpRepository.fetch");
+    assert!(
+        fetch
+            .signature
+            .as_deref()
+            .is_some_and(|sig| sig.ends_with("async"))
+    );
+
+    let countdown = find(&symbols, "countdown");
+    assert_eq!(countdown.kind, SymbolKind::Function);
+    assert!(
+        countdown
+            .signature
+            .as_deref()
+            .is_some_and(|sig| sig.ends_with("async*"))
+    );
+}
+
+#[test]
+fn test_dart_extension_methods() {
+    let symbols = parse_fixture();
+    let capitalize = find(&symbols, "capitalize");
+    assert_eq!(capitalize.kind, SymbolKind::Method);
+    assert_eq!(class_of(capitalize), Some("String"));
+    assert!(!symbols.iter().any(|s| s.name.as_ref() == "StringCasing"));
+
+    let mut parser = DartParser::new().unwrap();
+    let methods = parser.find_inherent_methods(load_basic_fixture());
+    assert!(
+        methods
+            .iter()
+            .any(|(ty, method, _)| ty == "String" && method == "capitalize")
+    );
+}
+
+#[test]
+fn test_dart_relationships() {
+    let code = load_basic_fixture();
+    let mut parser = DartParser::new().unwrap();
+
+    let extends = parser.find_extends(code);
+    assert!(
+        extends
+            .iter()
+            .any(|(from, to, _)| *from == "CounterPage" && *to == "StatefulWidget")
+    );
+
+    let implementations = parser.find_implementations(code);
+    assert!(
+        implementations
+            .iter()
+            .any(|(from, to, _)| *from == "HttpRepository" && *to == "Repository")
+    );
+    assert!(
+        implementations
+            .iter()
+            .any(|(from, to, _)| *from == "_CounterPageState" && *to == "Logging")
+    );
+
+    // Widgets composed by `build` are constructor calls
+    let calls = parser.find_calls(code);
+    for widget in ["Scaffold", "Center", "Text", "FloatingActionButton"] {
+        assert!(
+            calls
+                .iter()
+                .any(|(caller, callee, _)| *caller == "build" && *callee == widget),
+            "build should call {widget}, got {calls:?}"
+        );
+    }
+    assert!(
+        calls
+            .iter()
+            .any(|(caller, callee, _)| *caller == "_increment" && *callee == "setState")
+    );
+    assert!(
+        calls
+            .iter()
+            .any(|(caller, callee, _)| *caller == "main" && *callee == "MaterialApp")
+    );
+
+    let method_calls = parser.find_method_calls(code);
+    let from_json = method_calls
+        .iter()
+        .find(|c| c.method_name == "fromJson")
+        .expect("User.fromJson call should be extracted");
+    assert_eq!(from_json.caller, "fetch");
+    assert_eq!(from_json.receiver.as_deref(), Some("User"));
+    assert!(from_json.is_static);
+
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+    assert!(
+        imports
+            .iter()
+            .any(|i| i.path == "package:http/http.dart" && i.alias.as_deref() == Some("http"))
+    );
+    assert!(
+        imports
+            .iter()
+            .any(|i| i.path == "package:flutter/material.dart" && i.is_glob)
+    );
+    assert!(
+        imports
+            .iter()
+            .any(|i| i.path == "src/models/user.dart" && !i.is_glob)
+    );
+}
@@ -0,0 +1,214 @@
+use codanna::parsing::LanguageParser;
+use codanna::parsing::elixir::ElixirParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+use codanna::{SymbolKind, Visibility};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/elixir/basic.ex")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = ElixirParser::new().expect("Failed to create Elixir parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+fn module_of(symbol: &codanna::Symbol) -> Option<&str> {
+    match &symbol.scope_context {
+        Some(ScopeContext::ClassMember {
+            class_name: Some(name),
+        }) => Some(name.as_ref()),
+        _ => None,
+    }
+}
+
+#[test]
+fn test_elixir_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(
+        !symbols.is_empty(),
+        "Should extract symbols from Elixir code"
+    );
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.visibility);
+    }
+}
+
+#[test]
+fn test_elixir_modules_and_protocols() {
+    let symbols = parse_fixture();
+
+    let user = find(&symbols, "MyApp.Accounts.User");
+    assert_eq!(user.kind, SymbolKind::Module);
+    assert_eq!(
+        user.doc_comment.as_deref(),
+        Some("A registered user account.")
+    );
+
+    assert_eq!(find(&symbols, "MyApp.Greeter").kind, SymbolKind::Interface);
+    assert_eq!(find(&symbols, "MyApp.Worker").kind, SymbolKind::Module);
+
+    // `defimpl` modules are named after the protocol and the type
+    let greeter_impl = find(&symbols, "MyApp.Greeter.MyApp.Accounts.User");
+    assert_eq!(greeter_impl.kind, SymbolKind::Module);
+    let chars_impl = find(&symbols, "String.Chars.MyApp.Worker");
+    assert_eq!(chars_impl.kind, SymbolKind::Module);
+    assert_eq!(module_of(chars_impl), Some("MyApp.Worker"));
+}
+
+#[test]
+fn test_elixir_functions_and_visibility() {
+    let symbols = parse_fixture();
+
+    let changeset = find(&symbols, "changeset");
+    assert_eq!(changeset.kind, SymbolKind::Function);
+    assert_eq!(changeset.visibility, Visibility::Public);
+    assert_eq!(module_of(changeset), Some("MyApp.Accounts.User"));
+    // The comment sits above `@spec`
+    assert_eq!(
+        changeset.doc_comment.as_deref(),
+        Some("Builds a changeset for registration.")
+    );
+
+    let normalize = find(&symbols, "normalize_email");
+    assert_eq!(normalize.visibility, Visibility::Private);
+
+    assert_eq!(find(&symbols, "admin?").kind, SymbolKind::Macro);
+    assert_eq!(find(&symbols, "count").kind, SymbolKind::Function);
+    assert_eq!(
+        find(&symbols, "welcome").doc_comment.as_deref(),
+        Some("Sends the welcome email")
+    );
+
+    // `fetch/1` has two clauses but is one function
+    let fetches: Vec<_> = symbols
+        .iter()
+        .filter(|s| s.name.as_ref() == "fetch")
+        .collect();
+    assert_eq!(fetches.len(), 1);
+    assert_eq!(
+        fetches[0].signature.as_deref(),
+        Some("def fetch(id) when is_integer(id)")
+    );
+
+    let greet = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "greet" && module_of(s) == Some("MyApp.Greeter"))
+        .expect("Protocol function should be indexed");
+    assert_eq!(greet.kind, SymbolKind::Function);
+}
+
+#[test]
+fn test_elixir_struct_fields() {
+    let symbols = parse_fixture();
+
+    for field in ["name", "email", "role"] {
+        let symbol = find(&symbols, field);
+        assert_eq!(symbol.kind, SymbolKind::Field);
+        assert_eq!(module_of(symbol), Some("MyApp.Accounts.User"));
+    }
+}
+
+#[test]
+fn test_elixir_protocol_implementations() {
+    let mut parser = ElixirParser::new().unwrap();
+    let implementations = parser.find_implementations(load_basic_fixture());
+
+    assert!(
+        implementations
+            .iter()
+            .any(|(ty, proto, _)| *ty == "MyApp.Accounts.User" && *proto == "MyApp.Greeter")
+    );
+    // Without `for:` the enclosing module implements the protocol
+    assert!(
+        implementations
+            .iter()
+            .any(|(ty, proto, _)| *ty == "MyApp.Worker" && *proto == "String.Chars")
+    );
+}
+
+#[test]
+fn test_elixir_use_is_a_relationship() {
+    let mut parser = ElixirParser::new().unwrap();
+    let uses = parser.find_uses(load_basic_fixture());
+
+    assert!(
+        uses.iter()
+            .any(|(module, used, _)| *module == "MyApp.Accounts.User" && *used == "Ecto.Schema")
+    );
+    assert!(
+        uses.iter()
+            .any(|(module, used, _)| *module == "MyApp.Worker" && *used == "GenServer")
+    );
+}
+
+#[test]
+fn test_elixir_calls_and_imports() {
+    let code = load_basic_fixture();
+    let mut parser = ElixirParser::new().unwrap();
+
+    let calls = parser.find_calls(code);
+    for callee in ["cast", "validate_required", "normalize_email"] {
+        assert!(
+            calls
+                .iter()
+                .any(|(caller, called, _)| *caller == "changeset" && *called == callee),
+            "changeset should call {callee}, got {calls:?}"
+        );
+    }
+    // The head of a definition is not a call
+    assert!(!calls.iter().any(|(caller, called, _)| caller == called));
+    assert!(
+        calls
+            .iter()
+            .any(|(caller, called, _)| *caller == "fetch" && *called == "is_integer")
+    );
+
+    let method_calls = parser.find_method_calls(code);
+    let repo_get = method_calls
+        .iter()
+        .find(|c| c.method_name == "get")
+        .expect("Repo.get call should be extracted");
+    assert_eq!(repo_get.caller, "fetch");
+    assert_eq!(repo_get.receiver.as_deref(), Some("Repo"));
+    assert!(repo_get.is_static);
+    assert!(
+        method_calls
+            .iter()
+            .any(|c| c.caller == "welcome" && c.method_name == "deliver")
+    );
+
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+    assert!(
+        imports
+            .iter()
+            .any(|i| i.path == "MyApp.Repo" && i.alias.as_deref() == Some("Repo"))
+    );
+    assert!(
+        imports
+            .iter()
+            .any(|i| i.path == "MyApp.Mailer" && i.alias.as_deref() == Some("Mail"))
+    );
+    for path in ["MyApp.Accounts.Role", "MyApp.Accounts.Team"] {
+        assert!(imports.iter().any(|i| i.path == path), "missing {path}");
+    }
+    assert!(
+        imports
+            .iter()
+            .any(|i| i.path == "Ecto.Changeset" && i.is_glob)
+    );
+    assert!(imports.iter().any(|i| i.path == "Logger" && !i.is_glob));
+    assert!(imports.iter().any(|i| i.path == "GenServer" && i.is_glob));
+}
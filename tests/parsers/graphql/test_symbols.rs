@@ -0,0 +1,156 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::graphql::GraphQLParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/graphql/basic.graphql")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = GraphQLParser::new().expect("Failed to create GraphQL parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+fn member_of(class: &str) -> Option<ScopeContext> {
+    Some(ScopeContext::ClassMember {
+        class_name: Some(class.into()),
+    })
+}
+
+#[test]
+fn test_graphql_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(
+        !symbols.is_empty(),
+        "Should extract symbols from GraphQL code"
+    );
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.scope_context);
+    }
+}
+
+#[test]
+fn test_graphql_types_and_fields() {
+    let symbols = parse_fixture();
+
+    let user = find(&symbols, "User");
+    assert_eq!(user.kind, SymbolKind::Struct);
+    assert_eq!(user.doc_comment.as_deref(), Some("A registered account"));
+    assert_eq!(user.signature.as_deref(), Some("type User implements Node"));
+    assert_eq!(user.scope_context, Some(ScopeContext::Module));
+
+    let posts = find(&symbols, "posts");
+    assert_eq!(posts.kind, SymbolKind::Field);
+    assert_eq!(posts.scope_context, member_of("User"));
+    assert_eq!(posts.doc_comment.as_deref(), Some("Posts, newest first"));
+
+    assert_eq!(find(&symbols, "Node").kind, SymbolKind::Interface);
+    assert_eq!(find(&symbols, "NewUser").kind, SymbolKind::Struct);
+    assert_eq!(find(&symbols, "SearchResult").kind, SymbolKind::TypeAlias);
+    assert_eq!(find(&symbols, "DateTime").kind, SymbolKind::TypeAlias);
+}
+
+#[test]
+fn test_graphql_enums() {
+    let symbols = parse_fixture();
+
+    let status = find(&symbols, "Status");
+    assert_eq!(status.kind, SymbolKind::Enum);
+    assert_eq!(
+        status.doc_comment.as_deref(),
+        Some("Publication state of an account")
+    );
+
+    let active = find(&symbols, "ACTIVE");
+    assert_eq!(active.kind, SymbolKind::Constant);
+    assert_eq!(active.scope_context, member_of("Status"));
+}
+
+#[test]
+fn test_graphql_root_fields() {
+    let symbols = parse_fixture();
+
+    let user = find(&symbols, "user");
+    assert_eq!(user.kind, SymbolKind::Method);
+    assert_eq!(user.scope_context, member_of("Query"));
+    assert_eq!(user.signature.as_deref(), Some("user(id: ID!): User"));
+    assert_eq!(user.doc_comment.as_deref(), Some("Look up an account"));
+
+    let create = find(&symbols, "createUser");
+    assert_eq!(create.kind, SymbolKind::Method);
+    assert_eq!(create.scope_context, member_of("Mutation"));
+}
+
+#[test]
+fn test_graphql_operations_and_fragments() {
+    let symbols = parse_fixture();
+
+    let get_user = find(&symbols, "GetUser");
+    assert_eq!(get_user.kind, SymbolKind::Function);
+    assert_eq!(
+        get_user.signature.as_deref(),
+        Some("query GetUser($id: ID!)")
+    );
+
+    assert_eq!(find(&symbols, "AddUser").kind, SymbolKind::Function);
+
+    let fragment = find(&symbols, "UserFields");
+    assert_eq!(fragment.kind, SymbolKind::Function);
+    assert_eq!(
+        fragment.signature.as_deref(),
+        Some("fragment UserFields on User")
+    );
+}
+
+#[test]
+fn test_graphql_relationships() {
+    let code = load_basic_fixture();
+    let mut parser = GraphQLParser::new().unwrap();
+
+    let calls = parser.find_method_calls(code);
+    let user_call = calls
+        .iter()
+        .find(|call| call.caller == "GetUser" && call.method_name == "user")
+        .expect("GetUser should call Query.user");
+    assert_eq!(user_call.receiver.as_deref(), Some("Query"));
+    assert!(
+        calls
+            .iter()
+            .any(|call| call.caller == "GetUser" && call.method_name == "UserFields")
+    );
+
+    let implementations = parser.find_implementations(code);
+    assert!(
+        implementations
+            .iter()
+            .any(|(from, to, _)| *from == "Post" && *to == "Node")
+    );
+
+    let uses = parser.find_uses(code);
+    assert!(
+        uses.iter()
+            .any(|(from, to, _)| *from == "SearchResult" && *to == "Post")
+    );
+    assert!(
+        uses.iter()
+            .any(|(from, to, _)| *from == "UserFields" && *to == "User")
+    );
+
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+    assert_eq!(imports.len(), 1);
+    assert_eq!(imports[0].path, "./fragments.graphql");
+}
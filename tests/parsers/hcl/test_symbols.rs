@@ -0,0 +1,112 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::hcl::HclParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/hcl/basic.tf")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = HclParser::new().expect("Failed to create HCL parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+#[test]
+fn test_hcl_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(!symbols.is_empty(), "Should extract symbols from HCL code");
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.scope_context);
+    }
+}
+
+#[test]
+fn test_hcl_resources_and_data_sources() {
+    let symbols = parse_fixture();
+
+    let web = find(&symbols, "aws_instance.web");
+    assert_eq!(web.kind, SymbolKind::Struct);
+    assert_eq!(
+        web.signature.as_deref(),
+        Some(r#"resource "aws_instance" "web""#)
+    );
+    assert_eq!(web.doc_comment.as_deref(), Some("Web frontend instances"));
+    assert_eq!(web.scope_context, Some(ScopeContext::Module));
+
+    assert_eq!(
+        find(&symbols, "data.aws_ami.ubuntu").kind,
+        SymbolKind::Struct
+    );
+    assert_eq!(find(&symbols, "provider.aws").kind, SymbolKind::Struct);
+}
+
+#[test]
+fn test_hcl_variables_outputs_and_locals() {
+    let symbols = parse_fixture();
+
+    let region = find(&symbols, "var.region");
+    assert_eq!(region.kind, SymbolKind::Variable);
+    assert_eq!(
+        region.doc_comment.as_deref(),
+        Some("AWS region to deploy into")
+    );
+
+    let ips = find(&symbols, "output.web_ips");
+    assert_eq!(ips.kind, SymbolKind::Variable);
+    assert_eq!(
+        ips.doc_comment.as_deref(),
+        Some("Public addresses of the web instances")
+    );
+
+    assert_eq!(find(&symbols, "local.name").kind, SymbolKind::Variable);
+    assert_eq!(find(&symbols, "local.tags").kind, SymbolKind::Variable);
+}
+
+#[test]
+fn test_hcl_modules() {
+    let symbols = parse_fixture();
+
+    let vpc = find(&symbols, "module.vpc");
+    assert_eq!(vpc.kind, SymbolKind::Module);
+    assert_eq!(
+        vpc.signature.as_deref(),
+        Some(r#"module "vpc" { source = "./modules/vpc", cidr_block }"#)
+    );
+
+    let mut parser = HclParser::new().unwrap();
+    let imports = parser.find_imports(load_basic_fixture(), FileId::new(1).unwrap());
+    assert_eq!(imports.len(), 1);
+    assert_eq!(imports[0].path, "./modules/vpc");
+}
+
+#[test]
+fn test_hcl_references() {
+    let mut parser = HclParser::new().unwrap();
+    let uses = parser.find_uses_owned(load_basic_fixture());
+    let uses_of = |owner: &str, target: &str| {
+        uses.iter()
+            .any(|(from, to, _)| from == owner && to == target)
+    };
+
+    assert!(uses_of("aws_instance.web", "var.instance_count"));
+    assert!(uses_of("aws_instance.web", "data.aws_ami.ubuntu"));
+    assert!(uses_of("aws_instance.web", "module.vpc"));
+    assert!(uses_of("aws_instance.web", "local.tags"));
+    assert!(uses_of("output.web_ips", "aws_instance.web"));
+    assert!(uses_of("local.tags", "local.name"));
+    assert!(uses_of("provider.aws", "var.region"));
+}
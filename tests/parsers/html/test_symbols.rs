@@ -0,0 +1,67 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::css::usages::find_usages;
+use codanna::parsing::html::HtmlParser;
+use codanna::parsing::html::parser::DOCUMENT_PLACEHOLDER;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/html/basic.html")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = HtmlParser::new().expect("Failed to create HTML parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+#[test]
+fn test_html_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(!symbols.is_empty(), "Should extract symbols from HTML code");
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.signature);
+    }
+}
+
+#[test]
+fn test_html_document_and_ids() {
+    let symbols = parse_fixture();
+    let names: Vec<&str> = symbols.iter().map(|s| s.name.as_ref()).collect();
+    assert_eq!(names, vec![DOCUMENT_PLACEHOLDER, "#sidebar", "#content"]);
+
+    let document = &symbols[0];
+    assert_eq!(document.kind, SymbolKind::Module);
+    assert_eq!(
+        document.signature.as_deref(),
+        Some("<title>Dashboard</title>")
+    );
+
+    let sidebar = &symbols[1];
+    assert_eq!(sidebar.kind, SymbolKind::Constant);
+    assert_eq!(sidebar.doc_comment.as_deref(), Some("Site navigation"));
+    assert_eq!((sidebar.range.start_line, sidebar.range.end_line), (8, 12));
+}
+
+#[test]
+fn test_html_style_usages() {
+    let usages: Vec<(String, &str)> = find_usages(load_basic_fixture())
+        .into_iter()
+        .map(|usage| (usage.name, usage.via))
+        .collect();
+    assert_eq!(
+        usages,
+        vec![
+            ("#sidebar".to_string(), "id"),
+            (".menu".to_string(), "class"),
+            (".btn".to_string(), "class"),
+            (".btn--ghost".to_string(), "class"),
+            ("#content".to_string(), "id"),
+            ("--space".to_string(), "var"),
+        ]
+    );
+}
@@ -0,0 +1,108 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::jupyter::JupyterParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/jupyter/basic.ipynb")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = JupyterParser::new().expect("Failed to create Jupyter parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str, kind: SymbolKind) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name && s.kind == kind)
+        .unwrap_or_else(|| panic!("Should find {kind:?} '{name}'"))
+}
+
+#[test]
+fn test_jupyter_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(
+        !symbols.is_empty(),
+        "Should extract symbols from a notebook"
+    );
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.scope_context);
+    }
+}
+
+#[test]
+fn test_jupyter_code_cells() {
+    let symbols = parse_fixture();
+
+    // Positions are lines of the notebook file, inside the cell
+    let load = find(&symbols, "load_customers", SymbolKind::Function);
+    assert_eq!(
+        load.doc_comment.as_deref(),
+        Some("Read the customer export.")
+    );
+    assert_eq!(load.range.start_line, 20);
+
+    let model = find(&symbols, "ChurnModel", SymbolKind::Class);
+    assert_eq!(model.range.start_line, 38);
+    let fit = find(&symbols, "fit", SymbolKind::Method);
+    assert_eq!(fit.range.start_line, 39);
+}
+
+#[test]
+fn test_jupyter_cells() {
+    let symbols = parse_fixture();
+
+    let intro = find(&symbols, "Churn analysis", SymbolKind::Module);
+    assert_eq!(intro.signature.as_deref(), Some("# Churn analysis"));
+    assert!(
+        intro
+            .doc_comment
+            .as_deref()
+            .is_some_and(|doc| doc.contains("scores each account"))
+    );
+    assert_eq!(intro.scope_context, Some(ScopeContext::Module));
+
+    // A markdown cell without a heading is numbered
+    let note = find(&symbols, "Cell 3", SymbolKind::Module);
+    assert_eq!(
+        note.doc_comment.as_deref(),
+        Some("The model is refitted whenever the export changes.")
+    );
+
+    let setup = find(&symbols, "Cell 2", SymbolKind::Module);
+    assert_eq!(setup.signature.as_deref(), Some("%matplotlib inline"));
+    assert!(setup.range.start_line <= 20 && 23 <= setup.range.end_line);
+    assert!(setup.doc_comment.is_none());
+
+    // A `%%bash` cell is still a cell, though none of its code is Python
+    let shell = find(&symbols, "Cell 5", SymbolKind::Module);
+    assert_eq!(shell.signature.as_deref(), Some("%%bash"));
+}
+
+#[test]
+fn test_jupyter_relationships() {
+    let code = load_basic_fixture();
+    let mut parser = JupyterParser::new().unwrap();
+
+    // Defined in the second cell, called in the fourth
+    let calls = parser.find_calls(code);
+    let call = calls
+        .iter()
+        .find(|(_, to, _)| *to == "load_customers")
+        .expect("Should find the call to load_customers");
+    assert_eq!(call.2.start_line, 43);
+
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+    assert!(
+        imports
+            .iter()
+            .any(|import| import.path == "sklearn.linear_model.LogisticRegression")
+    );
+}
@@ -0,0 +1,99 @@
+use codanna::parsing::{LanguageParser, kotlin::KotlinParser};
+use codanna::types::{FileId, SymbolCounter};
+use codanna::{ScopeContext, SymbolKind};
+
+const CODE: &str = r#"
+data class Money(val cents: Long) {
+    fun plus(other: Money): Money = Money(cents + other.cents)
+
+    companion object {
+        fun of(cents: Long): Money = Money(cents)
+    }
+}
+
+fun Money.formatted(): String = "${cents / 100}.${cents % 100}"
+
+fun Money.Companion.zero(): Money = Money(0)
+
+fun List<Money>.total(): Money = fold(Money(0)) { acc, m -> acc.plus(m) }
+
+fun String?.orEmptyMoney(): Money = Money(0)
+
+fun topLevel(): Int = 42
+"#;
+
+#[test]
+fn test_extension_functions_attributed_to_receiver() {
+    let mut parser = KotlinParser::new().expect("Failed to create parser");
+    let methods = parser.find_inherent_methods(CODE);
+
+    let pairs: Vec<(&str, &str)> = methods
+        .iter()
+        .map(|(receiver, name, _)| (receiver.as_str(), name.as_str()))
+        .collect();
+
+    println!("Inherent methods: {pairs:?}");
+
+    assert!(pairs.contains(&("Money", "formatted")));
+    assert!(
+        pairs.contains(&("Money", "zero")),
+        "Companion extensions are reached through the class"
+    );
+    assert!(
+        pairs.contains(&("List", "total")),
+        "Generic receivers are reduced to the base type"
+    );
+    assert!(
+        pairs.contains(&("String", "orEmptyMoney")),
+        "Nullable receivers drop the `?`"
+    );
+    assert!(
+        !pairs
+            .iter()
+            .any(|(_, name)| *name == "topLevel" || *name == "plus"),
+        "Only extension functions are reported"
+    );
+}
+
+#[test]
+fn test_data_class_and_companion_object_symbols() {
+    let mut parser = KotlinParser::new().expect("Failed to create parser");
+    let mut counter = SymbolCounter::new();
+    let symbols = parser.parse(CODE, FileId::new(1).unwrap(), &mut counter);
+
+    let money = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "Money")
+        .expect("Should find data class Money");
+    assert_eq!(money.kind, SymbolKind::Class);
+    assert!(
+        money
+            .signature
+            .as_deref()
+            .is_some_and(|sig| sig.contains("data")),
+        "Data class signature should keep the `data` modifier"
+    );
+
+    let companion = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "Companion")
+        .expect("Should emit the companion object");
+    assert_eq!(companion.kind, SymbolKind::Class);
+    assert!(matches!(
+        &companion.scope_context,
+        Some(ScopeContext::ClassMember { class_name: Some(name) }) if name.as_ref() == "Money"
+    ));
+
+    let of = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "of")
+        .expect("Should find companion member");
+    assert_eq!(of.kind, SymbolKind::Method);
+    assert!(
+        matches!(
+            &of.scope_context,
+            Some(ScopeContext::ClassMember { class_name: Some(name) }) if name.as_ref() == "Money"
+        ),
+        "Companion members stay attributed to the enclosing class"
+    );
+}
@@ -0,0 +1,126 @@
+use codanna::parsing::{LanguageParser, lua::LuaParser};
+use codanna::symbol::ScopeContext;
+use codanna::types::SymbolCounter;
+use codanna::{FileId, Symbol, SymbolKind};
+
+fn load_scopes_fixture() -> &'static str {
+    include_str!("../../fixtures/lua/scopes.lua")
+}
+
+fn parse_fixture() -> Vec<Symbol> {
+    let mut parser = LuaParser::new().expect("Failed to create Lua parser");
+    let mut counter = SymbolCounter::new();
+    parser.parse(load_scopes_fixture(), FileId(1), &mut counter)
+}
+
+fn find<'a>(symbols: &'a [Symbol], name: &str) -> &'a Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("{name} should be extracted"))
+}
+
+#[test]
+fn test_lua_table_assigned_function_is_member() {
+    let symbols = parse_fixture();
+
+    let decode = find(&symbols, "decode");
+    assert_eq!(decode.kind, SymbolKind::Function);
+    assert_eq!(
+        decode.signature.as_deref(),
+        Some("M.decode = function(payload)")
+    );
+    assert_eq!(
+        decode.doc_comment.as_deref(),
+        Some("Decode the host payload")
+    );
+    match &decode.scope_context {
+        Some(ScopeContext::ClassMember { class_name }) => {
+            assert_eq!(class_name.as_deref(), Some("M"));
+        }
+        other => panic!("expected ClassMember of M, got {other:?}"),
+    }
+
+    // Nested tables own their functions, like `function M.handlers.tick()`
+    let tick = find(&symbols, "tick");
+    assert_eq!(tick.kind, SymbolKind::Function);
+    match &tick.scope_context {
+        Some(ScopeContext::ClassMember { class_name }) => {
+            assert_eq!(class_name.as_deref(), Some("M.handlers"));
+        }
+        other => panic!("expected ClassMember of M.handlers, got {other:?}"),
+    }
+
+    // Parameters of the function value are walked like any function body
+    let payload = find(&symbols, "payload");
+    assert_eq!(payload.kind, SymbolKind::Parameter);
+}
+
+#[test]
+fn test_lua_global_and_local_scope() {
+    let symbols = parse_fixture();
+
+    for name in ["on_load", "DEBUG_LEVEL"] {
+        assert_eq!(
+            find(&symbols, name).scope_context,
+            Some(ScopeContext::Global),
+            "{name} is assigned without `local`"
+        );
+    }
+
+    for name in ["clamp", "retries", "M"] {
+        assert_ne!(
+            find(&symbols, name).scope_context,
+            Some(ScopeContext::Global),
+            "{name} is declared `local`"
+        );
+    }
+}
+
+#[test]
+fn test_lua_table_assigned_function_calls() {
+    let code = load_scopes_fixture();
+    let mut parser = LuaParser::new().expect("Failed to create Lua parser");
+
+    let calls = parser.find_calls(code);
+    for (caller, callee) in [("decode", "debug"), ("decode", "decode"), ("tick", "clamp")] {
+        assert!(
+            calls.iter().any(|(c, f, _)| *c == caller && *f == callee),
+            "{caller} should call {callee}, got {calls:?}"
+        );
+    }
+
+    let method_calls = parser.find_method_calls(code);
+    let debug = method_calls
+        .iter()
+        .find(|c| c.method_name == "debug")
+        .expect("log.debug call should be extracted");
+    assert_eq!(debug.caller, "decode");
+    assert_eq!(debug.receiver.as_deref(), Some("log"));
+}
+
+#[test]
+fn test_lua_require_aliases() {
+    let code = load_scopes_fixture();
+    let mut parser = LuaParser::new().expect("Failed to create Lua parser");
+
+    let imports = parser.find_imports(code, FileId(1));
+    let aliases: Vec<(&str, Option<&str>)> = imports
+        .iter()
+        .map(|i| (i.path.as_str(), i.alias.as_deref()))
+        .collect();
+
+    assert!(
+        aliases.contains(&("cjson", Some("json"))),
+        "got {aliases:?}"
+    );
+    assert!(
+        aliases.contains(&("app.log", Some("log"))),
+        "got {aliases:?}"
+    );
+    // Global require still names the binding
+    assert!(
+        aliases.contains(&("app.settings", Some("settings"))),
+        "got {aliases:?}"
+    );
+}
@@ -0,0 +1,90 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::markdown::MarkdownParser;
+use codanna::parsing::markdown::references::find_references;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/markdown/basic.md")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = MarkdownParser::new().expect("Failed to create Markdown parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find section '{name}'"))
+}
+
+#[test]
+fn test_markdown_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(
+        !symbols.is_empty(),
+        "Should extract symbols from Markdown code"
+    );
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.signature);
+    }
+}
+
+#[test]
+fn test_markdown_sections() {
+    let symbols = parse_fixture();
+    assert_eq!(symbols.len(), 4);
+    assert!(symbols.iter().all(|s| s.kind == SymbolKind::Module));
+
+    let auth = find(&symbols, "Authentication");
+    assert_eq!(auth.signature.as_deref(), Some("# Authentication"));
+    assert!(
+        auth.doc_comment
+            .as_deref()
+            .is_some_and(|doc| doc.starts_with("Requests are authenticated")),
+        "Documented by its own prose"
+    );
+
+    let login = find(&symbols, "The login flow");
+    assert_eq!(login.signature.as_deref(), Some("## The login flow"));
+    let doc = login.doc_comment.as_deref().unwrap();
+    assert!(doc.contains("2. `SessionStore::open` creates the session."));
+    assert!(doc.contains("```rust"), "Code blocks are part of the doc");
+
+    let sessions = find(&symbols, "Sessions");
+    assert_eq!(sessions.signature.as_deref(), Some("## Sessions"));
+    assert!(find(&symbols, "Configuration").range.start_line < sessions.range.start_line);
+}
+
+#[test]
+fn test_markdown_code_references() {
+    let code = load_basic_fixture();
+    let mut parser = tree_sitter::Parser::new();
+    parser
+        .set_language(&tree_sitter_md::LANGUAGE.into())
+        .unwrap();
+    let tree = parser.parse(code, None).unwrap();
+
+    let references: Vec<(Option<&str>, &str)> = find_references(tree.root_node(), code)
+        .into_iter()
+        .map(|reference| (reference.receiver, reference.name))
+        .collect();
+    assert_eq!(
+        references,
+        vec![
+            (None, "authenticate_user"),
+            (None, "UserRepository"),
+            (None, "login"),
+            (Some("SessionStore"), "open"),
+            (Some("auth"), "load_config"),
+            (None, "SESSION_TTL"),
+        ]
+    );
+}
@@ -0,0 +1 @@
+mod test_symbols;
@@ -0,0 +1,120 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::protobuf::ProtobufParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/protobuf/basic.proto")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = ProtobufParser::new().expect("Failed to create Protobuf parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+fn member_of(class: &str) -> Option<ScopeContext> {
+    Some(ScopeContext::ClassMember {
+        class_name: Some(class.into()),
+    })
+}
+
+#[test]
+fn test_protobuf_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(
+        !symbols.is_empty(),
+        "Should extract symbols from Protobuf code"
+    );
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.scope_context);
+    }
+}
+
+#[test]
+fn test_protobuf_messages_and_fields() {
+    let symbols = parse_fixture();
+
+    let user = find(&symbols, "User");
+    assert_eq!(user.kind, SymbolKind::Struct);
+    assert_eq!(user.doc_comment.as_deref(), Some("A registered account"));
+    assert_eq!(user.scope_context, Some(ScopeContext::Module));
+
+    let created_at = find(&symbols, "created_at");
+    assert_eq!(created_at.kind, SymbolKind::Field);
+    assert_eq!(
+        created_at.signature.as_deref(),
+        Some("google.protobuf.Timestamp created_at = 4")
+    );
+    assert_eq!(created_at.scope_context, member_of("User"));
+
+    // A trailing comment documents nothing
+    assert_eq!(find(&symbols, "status").doc_comment, None);
+
+    let address = find(&symbols, "Address");
+    assert_eq!(address.kind, SymbolKind::Struct);
+    assert_eq!(address.scope_context, member_of("User"));
+}
+
+#[test]
+fn test_protobuf_enums() {
+    let symbols = parse_fixture();
+
+    assert_eq!(find(&symbols, "Status").kind, SymbolKind::Enum);
+    let active = find(&symbols, "STATUS_ACTIVE");
+    assert_eq!(active.kind, SymbolKind::Constant);
+    assert_eq!(active.scope_context, member_of("Status"));
+}
+
+#[test]
+fn test_protobuf_services_and_rpcs() {
+    let symbols = parse_fixture();
+
+    let service = find(&symbols, "UserService");
+    assert_eq!(service.kind, SymbolKind::Interface);
+    assert_eq!(service.doc_comment.as_deref(), Some("Account lookups"));
+
+    let rpc = find(&symbols, "GetUser");
+    assert_eq!(rpc.kind, SymbolKind::Method);
+    assert_eq!(rpc.scope_context, member_of("UserService"));
+    assert_eq!(
+        rpc.signature.as_deref(),
+        Some("rpc GetUser(GetUserRequest) returns (User)")
+    );
+    assert_eq!(rpc.doc_comment.as_deref(), Some("Fetches one user by id"));
+}
+
+#[test]
+fn test_protobuf_uses_and_imports() {
+    let mut parser = ProtobufParser::new().unwrap();
+    let code = load_basic_fixture();
+
+    let uses = parser.find_uses(code);
+    for (owner, used) in [
+        ("User", "Status"),
+        ("User", "Timestamp"),
+        ("GetUser", "GetUserRequest"),
+        ("GetUser", "User"),
+    ] {
+        assert!(
+            uses.iter().any(|(o, u, _)| *o == owner && *u == used),
+            "{owner} should use {used}, got {uses:?}"
+        );
+    }
+
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+    assert_eq!(imports.len(), 1);
+    assert_eq!(imports[0].path, "google/protobuf/timestamp.proto");
+}
@@ -0,0 +1,55 @@
+#[cfg(test)]
+mod tests {
+    use codanna::parsing::python::PythonParser;
+    use codanna::parsing::{Access, LanguageParser};
+
+    const CODE: &str = r#"
+import config
+
+MAX_RETRIES = 3
+
+class Client:
+    timeout = 30
+
+    def connect(self, attempts=MAX_RETRIES):
+        self.timeout = config.DEFAULT_TIMEOUT
+        self.session.open()
+        LOCAL = 1
+        return attempts < MAX_RETRIES
+"#;
+
+    #[test]
+    fn test_attribute_and_constant_references() {
+        let mut parser = PythonParser::new().expect("Failed to create parser");
+        let found: Vec<(String, String, Option<String>, Access)> = parser
+            .find_value_references(CODE)
+            .into_iter()
+            .map(|r| (r.context, r.name, r.receiver, r.access))
+            .collect();
+        let has = |name: &str, receiver: Option<&str>, access: Access| {
+            found.iter().any(|(c, n, r, a)| {
+                c == "connect" && n == name && r.as_deref() == receiver && *a == access
+            })
+        };
+
+        assert!(has("timeout", Some("self"), Access::Write), "{found:?}");
+        assert!(
+            has("DEFAULT_TIMEOUT", Some("config"), Access::Read),
+            "{found:?}"
+        );
+        assert!(has("session", Some("self"), Access::Read), "{found:?}");
+        assert!(has("MAX_RETRIES", None, Access::Read), "{found:?}");
+        assert_eq!(
+            found
+                .iter()
+                .filter(|(_, n, _, _)| n == "MAX_RETRIES")
+                .count(),
+            2,
+            "the default value and the comparison read it: {found:?}"
+        );
+        assert!(
+            !found.iter().any(|(_, n, _, _)| n == "open" || n == "LOCAL"),
+            "callees and local bindings are not references: {found:?}"
+        );
+    }
+}
@@ -0,0 +1,135 @@
+use codanna::parsing::LanguageParser;
+use codanna::parsing::ruby::RubyParser;
+use codanna::types::{FileId, SymbolCounter};
+use codanna::{SymbolKind, Visibility};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/ruby/basic.rb")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = RubyParser::new().expect("Failed to create Ruby parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+#[test]
+fn test_ruby_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(!symbols.is_empty(), "Should extract symbols from Ruby code");
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.visibility);
+    }
+}
+
+#[test]
+fn test_ruby_extracts_classes_and_modules() {
+    let symbols = parse_fixture();
+
+    let invoice = symbols.iter().find(|s| s.name.as_ref() == "Invoice");
+    assert_eq!(invoice.map(|s| s.kind), Some(SymbolKind::Class));
+
+    for name in ["Billing", "Billable"] {
+        let module = symbols.iter().find(|s| s.name.as_ref() == name);
+        assert_eq!(
+            module.map(|s| s.kind),
+            Some(SymbolKind::Module),
+            "{name} should be a Module"
+        );
+    }
+}
+
+#[test]
+fn test_ruby_extracts_methods_and_functions() {
+    let symbols = parse_fixture();
+
+    let build = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "build")
+        .expect("Should find singleton method 'build'");
+    assert_eq!(build.kind, SymbolKind::Method);
+    assert!(
+        build
+            .doc_comment
+            .as_deref()
+            .is_some_and(|doc| doc.contains("Build an invoice")),
+        "Doc comment should be attached to 'build'"
+    );
+
+    let process = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "process")
+        .expect("Should find top-level 'process'");
+    assert_eq!(process.kind, SymbolKind::Function);
+}
+
+#[test]
+fn test_ruby_attr_generates_accessors() {
+    let symbols = parse_fixture();
+    let names: Vec<&str> = symbols
+        .iter()
+        .filter(|s| s.kind == SymbolKind::Method)
+        .map(|s| s.name.as_ref())
+        .collect();
+
+    assert!(names.contains(&"customer"), "attr_reader generates reader");
+    assert!(!names.contains(&"customer="), "attr_reader has no writer");
+    assert!(names.contains(&"total"), "attr_accessor generates reader");
+    assert!(names.contains(&"total="), "attr_accessor generates writer");
+}
+
+#[test]
+fn test_ruby_visibility_sections() {
+    let symbols = parse_fixture();
+    let visibility_of = |name: &str| {
+        symbols
+            .iter()
+            .find(|s| s.name.as_ref() == name)
+            .map(|s| s.visibility)
+    };
+
+    assert_eq!(visibility_of("amount"), Some(Visibility::Public));
+    assert_eq!(visibility_of("comparable_total"), Some(Visibility::Module));
+    assert_eq!(visibility_of("recalculate"), Some(Visibility::Private));
+}
+
+#[test]
+fn test_ruby_mixins_and_superclass() {
+    let code = load_basic_fixture();
+    let mut parser = RubyParser::new().expect("Failed to create Ruby parser");
+
+    let implementations = parser.find_implementations(code);
+    assert!(
+        implementations
+            .iter()
+            .any(|(from, to, _)| *from == "Invoice" && *to == "Comparable")
+    );
+    assert!(
+        implementations
+            .iter()
+            .any(|(from, to, _)| *from == "Invoice" && *to == "Forwardable")
+    );
+
+    let extends = parser.find_extends(code);
+    assert!(
+        extends
+            .iter()
+            .any(|(from, to, _)| *from == "Invoice" && *to == "Record")
+    );
+}
+
+#[test]
+fn test_ruby_imports() {
+    let code = load_basic_fixture();
+    let mut parser = RubyParser::new().expect("Failed to create Ruby parser");
+
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+    let paths: Vec<&str> = imports.iter().map(|i| i.path.as_str()).collect();
+
+    assert!(paths.contains(&"json"));
+    assert!(paths.contains(&"./support/money"));
+}
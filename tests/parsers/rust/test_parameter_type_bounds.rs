@@ -0,0 +1,55 @@
+use codanna::parsing::LanguageBehavior;
+use codanna::parsing::rust::RustBehavior;
+
+#[test]
+fn test_rust_parameter_type_bounds_inline_generics() {
+    let behavior = RustBehavior::new();
+    let signature = "fn render<T: Shape + Clone>(shape: &T)";
+    assert_eq!(
+        behavior.parameter_type_bounds(signature, "shape"),
+        ["Shape", "Clone"]
+    );
+}
+
+#[test]
+fn test_rust_parameter_type_bounds_where_clause() {
+    let behavior = RustBehavior::new();
+    let signature = "fn render<'a, T>(shape: &'a T) where T: fmt::Display + 'a";
+    assert_eq!(
+        behavior.parameter_type_bounds(signature, "shape"),
+        ["Display"]
+    );
+}
+
+#[test]
+fn test_rust_parameter_type_bounds_impl_and_dyn() {
+    let behavior = RustBehavior::new();
+    assert_eq!(
+        behavior.parameter_type_bounds("fn render(shape: impl Shape)", "shape"),
+        ["Shape"]
+    );
+    assert_eq!(
+        behavior.parameter_type_bounds("fn render(shape: &dyn Shape)", "shape"),
+        ["Shape"]
+    );
+}
+
+#[test]
+fn test_rust_parameter_type_bounds_concrete_type_has_none() {
+    let behavior = RustBehavior::new();
+    let signature = "fn render<T: Shape>(shape: &Circle, other: T)";
+    assert!(
+        behavior
+            .parameter_type_bounds(signature, "shape")
+            .is_empty()
+    );
+    assert_eq!(
+        behavior.parameter_type_bounds(signature, "other"),
+        ["Shape"]
+    );
+    assert!(
+        behavior
+            .parameter_type_bounds(signature, "missing")
+            .is_empty()
+    );
+}
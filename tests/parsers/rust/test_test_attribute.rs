@@ -0,0 +1,94 @@
+#[cfg(test)]
+mod tests {
+    use codanna::parsing::LanguageParser;
+    use codanna::parsing::rust::RustParser;
+    use codanna::types::{FileId, SymbolCounter};
+
+    #[test]
+    fn test_functions_keep_their_test_attribute() {
+        let code = r#"
+#[inline]
+fn add(a: u32, b: u32) -> u32 {
+    a + b
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn fixture() -> u32 {
+        1
+    }
+
+    #[test]
+    fn adds() {
+        assert_eq!(add(fixture(), 1), 2);
+    }
+
+    #[tokio::test(flavor = "multi_thread")]
+    // runs on the runtime
+    async fn adds_async() {}
+}
+"#;
+
+        let mut parser = RustParser::new().expect("Failed to create parser");
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        let symbols = parser.parse(code, file_id, &mut counter);
+        let signature = |name: &str| {
+            symbols
+                .iter()
+                .find(|s| s.name.as_ref() == name)
+                .and_then(|s| s.signature.as_deref())
+                .unwrap_or_else(|| panic!("{name} extracted"))
+                .to_string()
+        };
+
+        assert_eq!(signature("add"), "fn add(a: u32, b: u32) -> u32");
+        assert_eq!(signature("fixture"), "fn fixture() -> u32");
+        assert_eq!(signature("adds"), "#[test]\nfn adds()");
+        assert_eq!(
+            signature("adds_async"),
+            "#[tokio::test(flavor = \"multi_thread\")]\nasync fn adds_async()"
+        );
+    }
+
+    #[test]
+    fn test_functions_keep_their_binding_attributes() {
+        let code = r#"
+#[pyfunction]
+#[pyo3(name = "compute")]
+#[inline]
+fn compute_sum(a: i64, b: i64) -> i64 {
+    a + b
+}
+
+#[napi(js_name = "add")]
+pub fn sum(a: i64, b: i64) -> i64 {
+    a + b
+}
+"#;
+
+        let mut parser = RustParser::new().expect("Failed to create parser");
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        let symbols = parser.parse(code, file_id, &mut counter);
+        let signature = |name: &str| {
+            symbols
+                .iter()
+                .find(|s| s.name.as_ref() == name)
+                .and_then(|s| s.signature.as_deref())
+                .unwrap_or_else(|| panic!("{name} extracted"))
+                .to_string()
+        };
+
+        assert_eq!(
+            signature("compute_sum"),
+            "#[pyfunction]\n#[pyo3(name = \"compute\")]\nfn compute_sum(a: i64, b: i64) -> i64"
+        );
+        assert_eq!(
+            signature("sum"),
+            "#[napi(js_name = \"add\")]\npub fn sum(a: i64, b: i64) -> i64"
+        );
+    }
+}
@@ -0,0 +1,109 @@
+#[cfg(test)]
+mod tests {
+    use codanna::parsing::rust::RustParser;
+    use codanna::parsing::{Access, LanguageParser};
+
+    const CODE: &str = r#"
+const MAX_RETRIES: u32 = 3;
+
+impl Server {
+    fn configure(&mut self, cfg: &Config) {
+        self.timeout = cfg.timeout;
+        self.stats.retries += 1;
+        self.handle();
+        let limit = limits::MAX_RETRIES;
+    }
+}
+
+fn build() -> Config {
+    Config { timeout: MAX_RETRIES, verbose }
+}
+"#;
+
+    fn references(code: &str) -> Vec<(String, String, Option<String>, Access)> {
+        let mut parser = RustParser::new().expect("Failed to create parser");
+        parser
+            .find_value_references(code)
+            .into_iter()
+            .map(|r| (r.context, r.name, r.receiver, r.access))
+            .collect()
+    }
+
+    fn has(
+        found: &[(String, String, Option<String>, Access)],
+        context: &str,
+        name: &str,
+        receiver: Option<&str>,
+        access: Access,
+    ) -> bool {
+        found.iter().any(|(c, n, r, a)| {
+            c == context && n == name && r.as_deref() == receiver && *a == access
+        })
+    }
+
+    #[test]
+    fn test_field_reads_and_writes() {
+        let found = references(CODE);
+        assert!(
+            has(&found, "configure", "timeout", Some("self"), Access::Write),
+            "{found:?}"
+        );
+        assert!(
+            has(&found, "configure", "timeout", Some("cfg"), Access::Read),
+            "{found:?}"
+        );
+        assert!(
+            has(
+                &found,
+                "configure",
+                "retries",
+                Some("self.stats"),
+                Access::Write
+            ),
+            "compound assignment writes: {found:?}"
+        );
+        assert!(
+            has(&found, "configure", "stats", Some("self"), Access::Read),
+            "{found:?}"
+        );
+        assert!(
+            !found.iter().any(|(_, name, _, _)| name == "handle"),
+            "method callees belong to find_method_calls: {found:?}"
+        );
+    }
+
+    #[test]
+    fn test_constants_and_struct_literals() {
+        let found = references(CODE);
+        assert!(
+            has(
+                &found,
+                "configure",
+                "MAX_RETRIES",
+                Some("limits"),
+                Access::Read
+            ),
+            "{found:?}"
+        );
+        assert!(
+            has(&found, "build", "MAX_RETRIES", None, Access::Read),
+            "{found:?}"
+        );
+        assert!(
+            has(&found, "build", "timeout", Some("Config"), Access::Write),
+            "{found:?}"
+        );
+        assert!(
+            has(&found, "build", "verbose", Some("Config"), Access::Write),
+            "{found:?}"
+        );
+        assert_eq!(
+            found
+                .iter()
+                .filter(|(_, name, _, _)| name == "MAX_RETRIES")
+                .count(),
+            2,
+            "the definition outside any function is not a reference: {found:?}"
+        );
+    }
+}
@@ -0,0 +1,164 @@
+use codanna::parsing::LanguageParser;
+use codanna::parsing::scala::ScalaParser;
+use codanna::types::{FileId, SymbolCounter};
+use codanna::{SymbolKind, Visibility};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/scala/basic.scala")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = ScalaParser::new().expect("Failed to create Scala parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+#[test]
+fn test_scala_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(
+        !symbols.is_empty(),
+        "Should extract symbols from Scala code"
+    );
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.visibility);
+    }
+}
+
+#[test]
+fn test_scala_extracts_types() {
+    let symbols = parse_fixture();
+
+    assert_eq!(find(&symbols, "Priced").kind, SymbolKind::Trait);
+    assert_eq!(find(&symbols, "Discountable").kind, SymbolKind::Trait);
+    assert_eq!(find(&symbols, "LineItem").kind, SymbolKind::Class);
+    assert_eq!(find(&symbols, "Status").kind, SymbolKind::Enum);
+    assert_eq!(find(&symbols, "Paid").kind, SymbolKind::Constant);
+    assert_eq!(find(&symbols, "Cents").kind, SymbolKind::TypeAlias);
+
+    let line_item = find(&symbols, "LineItem");
+    assert!(
+        line_item
+            .signature
+            .as_deref()
+            .is_some_and(|sig| sig.starts_with("case class")),
+        "Case classes keep the `case` keyword in their signature"
+    );
+    assert_eq!(
+        line_item.doc_comment.as_deref(),
+        Some("A single invoice line")
+    );
+
+    // Class and companion object share a name but not a kind
+    let invoice_kinds: Vec<_> = symbols
+        .iter()
+        .filter(|s| s.name.as_ref() == "Invoice")
+        .map(|s| s.kind)
+        .collect();
+    assert!(invoice_kinds.contains(&SymbolKind::Class));
+    assert!(invoice_kinds.contains(&SymbolKind::Module));
+}
+
+#[test]
+fn test_scala_extracts_members() {
+    let symbols = parse_fixture();
+
+    assert_eq!(find(&symbols, "total").kind, SymbolKind::Method);
+    assert_eq!(find(&symbols, "empty").kind, SymbolKind::Method);
+    assert_eq!(find(&symbols, "currency").kind, SymbolKind::Field);
+    assert_eq!(find(&symbols, "summarize").kind, SymbolKind::Function);
+    assert_eq!(find(&symbols, "formatted").kind, SymbolKind::Function);
+
+    // Method locals are not indexed
+    assert!(!symbols.iter().any(|s| s.name.as_ref() == "log"));
+}
+
+#[test]
+fn test_scala_visibility() {
+    let symbols = parse_fixture();
+
+    assert_eq!(find(&symbols, "audit").visibility, Visibility::Private);
+    assert_eq!(find(&symbols, "revision").visibility, Visibility::Module);
+    assert_eq!(find(&symbols, "recalculate").visibility, Visibility::Crate);
+    assert_eq!(find(&symbols, "currency").visibility, Visibility::Public);
+}
+
+#[test]
+fn test_scala_given_and_implicit_symbols() {
+    let symbols = parse_fixture();
+
+    for name in [
+        "defaultOrdering",
+        "fromItems",
+        "given_Ordering_LineItem",
+        "invoiceShow",
+    ] {
+        assert_eq!(
+            find(&symbols, name).kind,
+            SymbolKind::Given,
+            "{name} should be tagged Given"
+        );
+    }
+
+    // Members of a given instance are ordinary methods
+    assert_eq!(find(&symbols, "show").kind, SymbolKind::Method);
+}
+
+#[test]
+fn test_scala_trait_relationships() {
+    let code = load_basic_fixture();
+    let mut parser = ScalaParser::new().unwrap();
+
+    let extends = parser.find_extends(code);
+    assert!(
+        extends
+            .iter()
+            .any(|(from, to, _)| *from == "Discountable" && *to == "Priced")
+    );
+    assert!(
+        extends
+            .iter()
+            .any(|(from, to, _)| *from == "Invoice" && *to == "Document")
+    );
+
+    let implementations = parser.find_implementations(code);
+    assert!(
+        implementations
+            .iter()
+            .any(|(from, to, _)| *from == "Invoice" && *to == "Discountable")
+    );
+}
+
+#[test]
+fn test_scala_imports() {
+    let code = load_basic_fixture();
+    let mut parser = ScalaParser::new().unwrap();
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+
+    let mutable_map = imports
+        .iter()
+        .find(|i| i.path == "scala.collection.mutable.Map")
+        .expect("Should expand selector imports");
+    assert_eq!(mutable_map.alias.as_deref(), Some("MutableMap"));
+    assert!(
+        imports
+            .iter()
+            .any(|i| i.path == "scala.collection.mutable.ListBuffer")
+    );
+    assert!(
+        imports
+            .iter()
+            .any(|i| i.path == "billing.pricing.*" && i.is_glob)
+    );
+}
@@ -0,0 +1,126 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::sql::SqlParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/sql/basic.sql")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = SqlParser::new().expect("Failed to create SQL parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+#[test]
+fn test_sql_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(!symbols.is_empty(), "Should extract symbols from SQL code");
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.scope_context);
+    }
+}
+
+#[test]
+fn test_sql_file_module() {
+    let symbols = parse_fixture();
+
+    let module = find(&symbols, "<module>");
+    assert_eq!(module.kind, SymbolKind::Module);
+    assert_eq!(
+        module.doc_comment.as_deref(),
+        Some("Schema for the accounts service.")
+    );
+}
+
+#[test]
+fn test_sql_tables_and_columns() {
+    let symbols = parse_fixture();
+
+    let users = find(&symbols, "users");
+    assert_eq!(users.kind, SymbolKind::Struct);
+    assert_eq!(users.doc_comment.as_deref(), Some("Registered accounts"));
+    assert_eq!(users.scope_context, Some(ScopeContext::Global));
+
+    // Schema-qualified tables are indexed by their last part
+    let invoices = find(&symbols, "invoices");
+    assert_eq!(invoices.kind, SymbolKind::Struct);
+    assert_eq!(
+        invoices.signature.as_deref(),
+        Some("CREATE TABLE billing.invoices")
+    );
+
+    for (column, table) in [
+        ("email", "users"),
+        ("created_at", "users"),
+        ("total", "invoices"),
+    ] {
+        let symbol = find(&symbols, column);
+        assert_eq!(symbol.kind, SymbolKind::Field);
+        assert_eq!(
+            symbol.scope_context,
+            Some(ScopeContext::ClassMember {
+                class_name: Some(table.into())
+            })
+        );
+    }
+}
+
+#[test]
+fn test_sql_views_types_and_functions() {
+    let symbols = parse_fixture();
+
+    let view = find(&symbols, "users_with_debt");
+    assert_eq!(view.kind, SymbolKind::TypeAlias);
+    assert_eq!(
+        view.doc_comment.as_deref(),
+        Some("Users with at least one unpaid invoice")
+    );
+
+    assert_eq!(find(&symbols, "invoice_status").kind, SymbolKind::Enum);
+
+    let balance = find(&symbols, "user_balance");
+    assert_eq!(balance.kind, SymbolKind::Function);
+    assert_eq!(
+        balance.signature.as_deref(),
+        Some("CREATE FUNCTION user_balance(target integer) RETURNS numeric")
+    );
+    assert_eq!(
+        balance.doc_comment.as_deref(),
+        Some("Sum of a user's invoices")
+    );
+}
+
+#[test]
+fn test_sql_table_uses() {
+    let mut parser = SqlParser::new().unwrap();
+    let uses = parser.find_uses(load_basic_fixture());
+
+    for (owner, table) in [
+        ("invoices", "users"),
+        ("users_with_debt", "users"),
+        ("users_with_debt", "invoices"),
+        ("user_balance", "invoices"),
+        ("<module>", "users"),
+    ] {
+        assert!(
+            uses.iter().any(|(o, t, _)| *o == owner && *t == table),
+            "{owner} should use {table}, got {uses:?}"
+        );
+    }
+    // A table does not use itself
+    assert!(!uses.iter().any(|(o, t, _)| o == t));
+}
@@ -0,0 +1,124 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::sfc::COMPONENT_PLACEHOLDER;
+use codanna::parsing::svelte::SvelteParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/svelte/basic.svelte")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = SvelteParser::new().expect("Failed to create Svelte parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str, kind: SymbolKind) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name && s.kind == kind)
+        .unwrap_or_else(|| panic!("Should find {kind:?} '{name}'"))
+}
+
+#[test]
+fn test_svelte_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(
+        !symbols.is_empty(),
+        "Should extract symbols from Svelte code"
+    );
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.scope_context);
+    }
+}
+
+#[test]
+fn test_svelte_component() {
+    let symbols = parse_fixture();
+
+    // Named after its file by the behavior
+    let component = find(&symbols, COMPONENT_PLACEHOLDER, SymbolKind::Class);
+    assert_eq!(
+        component.doc_comment.as_deref(),
+        Some("Editable list of todo items")
+    );
+    assert_eq!(
+        component.signature.as_deref(),
+        Some(r#"<script lang="ts">"#)
+    );
+    assert_eq!(component.range.start_line, 0);
+    assert_eq!(component.scope_context, Some(ScopeContext::Module));
+}
+
+#[test]
+fn test_svelte_props_and_reactive_declarations() {
+    let symbols = parse_fixture();
+    let member_of_component = Some(ScopeContext::ClassMember {
+        class_name: Some(COMPONENT_PLACEHOLDER.into()),
+    });
+
+    let todos = find(&symbols, "todos", SymbolKind::Field);
+    assert_eq!(todos.signature.as_deref(), Some("let todos: Todo[] = []"));
+    assert_eq!(todos.doc_comment.as_deref(), Some("Items to show"));
+    assert_eq!(todos.scope_context, member_of_component);
+    find(&symbols, "readonly", SymbolKind::Field);
+
+    let remaining = find(&symbols, "remaining", SymbolKind::Variable);
+    assert_eq!(remaining.range.start_line, 24);
+
+    // Exports of the module script are not props
+    assert!(
+        !symbols
+            .iter()
+            .any(|s| s.name.as_ref() == "FILTERS" && s.kind == SymbolKind::Field)
+    );
+}
+
+#[test]
+fn test_svelte_script_declarations() {
+    let symbols = parse_fixture();
+
+    // Declarations of the scripts keep their line in the .svelte file
+    let complete = find(&symbols, "complete", SymbolKind::Function);
+    assert_eq!(complete.range.start_line, 26);
+    find(&symbols, "Todo", SymbolKind::Interface);
+
+    assert!(
+        !symbols.iter().any(|s| s.name.as_ref() == "todo-list"),
+        "Markup and styles are not indexed"
+    );
+}
+
+#[test]
+fn test_svelte_relationships() {
+    let code = load_basic_fixture();
+    let mut parser = SvelteParser::new().expect("Failed to create Svelte parser");
+
+    let uses = parser.find_uses(code);
+    assert!(
+        uses.iter()
+            .any(|(from, to, _)| *from == COMPONENT_PLACEHOLDER && *to == "TodoItem"),
+        "The markup renders TodoItem: {uses:?}"
+    );
+
+    let defines = parser.find_defines(code);
+    assert!(
+        defines
+            .iter()
+            .any(|(from, to, _)| *from == COMPONENT_PLACEHOLDER && *to == "todos")
+    );
+
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+    assert!(
+        imports
+            .iter()
+            .any(|import| import.path == "./TodoItem.svelte")
+    );
+    assert!(imports.iter().any(|import| import.path == "svelte"));
+}
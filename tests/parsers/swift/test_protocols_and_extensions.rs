@@ -0,0 +1,121 @@
+//! Swift protocol, extension, and computed property extraction tests
+
+use codanna::parsing::LanguageParser;
+use codanna::parsing::swift::SwiftParser;
+use codanna::types::{FileId, SymbolCounter};
+use codanna::{ScopeContext, SymbolKind};
+
+const CODE: &str = r#"
+public protocol Shape {
+    var area: Double { get }
+    func describe() -> String
+}
+
+public struct Rect {
+    let width: Double
+    let height: Double
+
+    var area: Double {
+        width * height
+    }
+}
+
+extension Rect: Shape, Equatable {
+    func describe() -> String {
+        "Rect \(width)x\(height)"
+    }
+}
+
+extension Shape {
+    func summary() -> String {
+        describe()
+    }
+}
+"#;
+
+fn class_name(scope: &Option<ScopeContext>) -> Option<&str> {
+    match scope {
+        Some(ScopeContext::ClassMember {
+            class_name: Some(name),
+        }) => Some(name.as_ref()),
+        _ => None,
+    }
+}
+
+#[test]
+fn test_protocol_requirements_are_members() {
+    let mut parser = SwiftParser::new().expect("Failed to create Swift parser");
+    let mut counter = SymbolCounter::new();
+    let symbols = parser.parse(CODE, FileId::new(1).unwrap(), &mut counter);
+
+    let shape = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "Shape")
+        .expect("Should find protocol Shape");
+    assert_eq!(shape.kind, SymbolKind::Interface);
+
+    let requirement = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "describe" && class_name(&s.scope_context) == Some("Shape"))
+        .expect("Should emit protocol method requirement");
+    assert_eq!(requirement.kind, SymbolKind::Method);
+
+    let area_requirement = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "area" && class_name(&s.scope_context) == Some("Shape"));
+    assert!(
+        area_requirement.is_some_and(|s| s.kind == SymbolKind::Field),
+        "Should emit protocol property requirement"
+    );
+}
+
+#[test]
+fn test_computed_property_signature_excludes_body() {
+    let mut parser = SwiftParser::new().expect("Failed to create Swift parser");
+    let mut counter = SymbolCounter::new();
+    let symbols = parser.parse(CODE, FileId::new(1).unwrap(), &mut counter);
+
+    let area = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "area" && class_name(&s.scope_context) == Some("Rect"))
+        .expect("Should find computed property Rect.area");
+    assert_eq!(area.kind, SymbolKind::Field);
+
+    let signature = area.signature.as_deref().unwrap_or_default();
+    assert_eq!(signature, "var area: Double");
+}
+
+#[test]
+fn test_protocol_extension_members_attributed_to_protocol() {
+    let mut parser = SwiftParser::new().expect("Failed to create Swift parser");
+    let mut counter = SymbolCounter::new();
+    let symbols = parser.parse(CODE, FileId::new(1).unwrap(), &mut counter);
+
+    let summary = symbols
+        .iter()
+        .find(|s| s.name.as_ref() == "summary")
+        .expect("Should find protocol extension method");
+    assert_eq!(summary.kind, SymbolKind::Method);
+    assert_eq!(class_name(&summary.scope_context), Some("Shape"));
+}
+
+#[test]
+fn test_extension_conformance_is_reported() {
+    let mut parser = SwiftParser::new().expect("Failed to create Swift parser");
+
+    let implementations = parser.find_implementations(CODE);
+    println!("Implementations: {implementations:?}");
+
+    assert!(
+        implementations
+            .iter()
+            .any(|(t, p, _)| *t == "Rect" && *p == "Shape"),
+        "extension Rect: Shape must report conformance"
+    );
+    assert!(
+        implementations
+            .iter()
+            .any(|(t, p, _)| *t == "Rect" && *p == "Equatable"),
+        "every protocol in the extension heritage list is reported"
+    );
+}
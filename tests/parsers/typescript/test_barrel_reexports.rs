@@ -0,0 +1,60 @@
+#[cfg(test)]
+mod tests {
+    use codanna::parsing::typescript::{TypeScriptBehavior, TypeScriptParser};
+    use codanna::parsing::{Import, LanguageBehavior, LanguageParser};
+    use codanna::types::FileId;
+    use std::path::Path;
+
+    #[test]
+    fn test_barrel_specifiers_become_imports_of_their_source() {
+        let code = r#"
+export { Button as PrimaryButton, Icon } from './Button';
+export * from './utils';
+"#;
+        let mut parser = TypeScriptParser::new().expect("Failed to create parser");
+        let imports = parser.find_imports(code, FileId::new(1).unwrap());
+
+        let renamed = imports
+            .iter()
+            .find(|i| i.path == "./Button/Button")
+            .expect("renamed specifier must become an import of its source");
+        assert_eq!(renamed.alias.as_deref(), Some("PrimaryButton"));
+        let kept = imports
+            .iter()
+            .find(|i| i.path == "./Button/Icon")
+            .expect("specifier must become an import of its source");
+        assert_eq!(kept.alias, None);
+        assert!(
+            imports.iter().any(|i| i.path == "./utils" && i.is_glob),
+            "export * must stay a glob import. Got: {imports:?}"
+        );
+    }
+
+    #[test]
+    fn test_reexport_import_path_resolves_against_the_barrel() {
+        let behavior = TypeScriptBehavior::new();
+        let import = |path: &str| Import {
+            path: path.to_string(),
+            alias: None,
+            file_id: FileId::new(1).unwrap(),
+            is_glob: false,
+            is_type_only: false,
+        };
+
+        // An index file's module is its directory
+        let index = Path::new("src/components/index.ts");
+        assert_eq!(
+            behavior.reexport_import_path(&import("./Button/Button"), "src.components", index),
+            Some("src.components.Button.Button".to_string())
+        );
+        let file = Path::new("src/components/all.ts");
+        assert_eq!(
+            behavior.reexport_import_path(&import("../utils/index.ts"), "src.components.all", file),
+            Some("src.utils".to_string())
+        );
+        assert_eq!(
+            behavior.reexport_import_path(&import("react"), "src.components", index),
+            None
+        );
+    }
+}
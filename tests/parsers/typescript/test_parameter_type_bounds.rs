@@ -0,0 +1,37 @@
+use codanna::parsing::LanguageBehavior;
+use codanna::parsing::typescript::TypeScriptBehavior;
+
+#[test]
+fn test_typescript_parameter_type_bounds_constraint() {
+    let behavior = TypeScriptBehavior::new();
+    let signature = "render<T extends Shape>(shape: T): void";
+    assert_eq!(
+        behavior.parameter_type_bounds(signature, "shape"),
+        ["Shape"]
+    );
+}
+
+#[test]
+fn test_typescript_parameter_type_bounds_intersection() {
+    let behavior = TypeScriptBehavior::new();
+    let signature = "render<T extends Shape & Named>(shape: T | undefined): void";
+    assert_eq!(
+        behavior.parameter_type_bounds(signature, "shape"),
+        ["Shape", "Named"]
+    );
+}
+
+#[test]
+fn test_typescript_parameter_type_bounds_unconstrained_or_concrete() {
+    let behavior = TypeScriptBehavior::new();
+    assert!(
+        behavior
+            .parameter_type_bounds("render<T>(shape: T): void", "shape")
+            .is_empty()
+    );
+    assert!(
+        behavior
+            .parameter_type_bounds("render(shape: Circle): void", "shape")
+            .is_empty()
+    );
+}
@@ -0,0 +1,74 @@
+#[cfg(test)]
+mod tests {
+    use codanna::parsing::LanguageParser;
+    use codanna::parsing::typescript::TypeScriptParser;
+    use codanna::types::{FileId, SymbolCounter};
+
+    const CODE: &str = r#"
+import { add } from "./math";
+
+describe("math", () => {
+    const twice = (n: number) => add(n, n);
+
+    it("adds numbers", () => {
+        expect(add(1, 2)).toBe(3);
+    });
+
+    test.only("doubles", function () {
+        expect(twice(2)).toBe(4);
+    });
+});
+"#;
+
+    #[test]
+    fn test_blocks_are_symbols_named_by_description() {
+        let mut parser = TypeScriptParser::new().expect("Failed to create parser");
+        let file_id = FileId::new(1).unwrap();
+        let mut counter = SymbolCounter::new();
+        let symbols = parser.parse(CODE, file_id, &mut counter);
+
+        let adds = symbols
+            .iter()
+            .find(|s| s.name.as_ref() == "adds numbers")
+            .expect("it block extracted");
+        assert_eq!(adds.signature.as_deref(), Some("it(\"adds numbers\")"));
+        let doubles = symbols
+            .iter()
+            .find(|s| s.name.as_ref() == "doubles")
+            .expect("test.only block extracted");
+        assert_eq!(doubles.signature.as_deref(), Some("test.only(\"doubles\")"));
+        assert!(
+            symbols.iter().any(|s| s.name.as_ref() == "twice"),
+            "helpers inside a suite are extracted"
+        );
+        assert!(
+            !symbols.iter().any(|s| s.name.as_ref() == "math"),
+            "suites are not tests"
+        );
+    }
+
+    #[test]
+    fn test_blocks_are_the_callers_of_their_calls() {
+        let mut parser = TypeScriptParser::new().expect("Failed to create parser");
+        let calls = parser.find_calls(CODE);
+
+        assert!(
+            calls
+                .iter()
+                .any(|(caller, called, _)| *caller == "adds numbers" && *called == "add"),
+            "{calls:?}"
+        );
+        assert!(
+            calls
+                .iter()
+                .any(|(caller, called, _)| *caller == "doubles" && *called == "twice"),
+            "{calls:?}"
+        );
+        assert!(
+            calls
+                .iter()
+                .any(|(caller, called, _)| *caller == "twice" && *called == "add"),
+            "{calls:?}"
+        );
+    }
+}
@@ -0,0 +1,116 @@
+use codanna::SymbolKind;
+use codanna::parsing::LanguageParser;
+use codanna::parsing::vue::VueParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/vue/basic.vue")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = VueParser::new().expect("Failed to create Vue parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str, kind: SymbolKind) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name && s.kind == kind)
+        .unwrap_or_else(|| panic!("Should find {kind:?} '{name}'"))
+}
+
+#[test]
+fn test_vue_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(!symbols.is_empty(), "Should extract symbols from Vue code");
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.scope_context);
+    }
+}
+
+#[test]
+fn test_vue_component() {
+    let symbols = parse_fixture();
+
+    let component = find(&symbols, "TodoList", SymbolKind::Class);
+    assert_eq!(
+        component.doc_comment.as_deref(),
+        Some("Editable list of todo items")
+    );
+    assert_eq!(
+        component.signature.as_deref(),
+        Some(r#"<script setup lang="ts">"#)
+    );
+    assert_eq!(component.range.start_line, 0);
+    assert_eq!(component.scope_context, Some(ScopeContext::Module));
+}
+
+#[test]
+fn test_vue_props_and_events() {
+    let symbols = parse_fixture();
+    let member_of_component = Some(ScopeContext::ClassMember {
+        class_name: Some("TodoList".into()),
+    });
+
+    let todos = find(&symbols, "todos", SymbolKind::Field);
+    assert_eq!(todos.signature.as_deref(), Some("todos: Todo[]"));
+    assert_eq!(todos.doc_comment.as_deref(), Some("Items to show"));
+    assert_eq!(todos.scope_context, member_of_component);
+    find(&symbols, "readonly", SymbolKind::Field);
+
+    let filter = find(&symbols, "filter", SymbolKind::Field);
+    assert_eq!(
+        filter.signature.as_deref(),
+        Some("defineModel<string>('filter')")
+    );
+
+    let complete = find(&symbols, "complete", SymbolKind::Method);
+    assert_eq!(
+        complete.signature.as_deref(),
+        Some("(e: 'complete', id: number): void")
+    );
+    assert_eq!(
+        complete.doc_comment.as_deref(),
+        Some("An item was ticked off")
+    );
+    assert_eq!(complete.scope_context, member_of_component);
+    find(&symbols, "remove", SymbolKind::Method);
+}
+
+#[test]
+fn test_vue_script_declarations() {
+    let symbols = parse_fixture();
+
+    // Declarations of the script keep their line in the .vue file
+    let complete = find(&symbols, "complete", SymbolKind::Function);
+    assert_eq!(complete.range.start_line, 30);
+    find(&symbols, "Todo", SymbolKind::Interface);
+
+    assert!(
+        !symbols.iter().any(|s| s.name.as_ref() == "todo-list"),
+        "Styles are not indexed"
+    );
+}
+
+#[test]
+fn test_vue_relationships() {
+    let code = load_basic_fixture();
+    let mut parser = VueParser::new().expect("Failed to create Vue parser");
+
+    let uses = parser.find_uses(code);
+    assert!(
+        uses.iter()
+            .any(|(from, to, _)| *from == "TodoList" && *to == "TodoItem"),
+        "The template renders TodoItem: {uses:?}"
+    );
+
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+    assert!(imports.iter().any(|import| import.path == "./TodoItem.vue"));
+    assert!(imports.iter().any(|import| import.path == "vue"));
+}
@@ -0,0 +1,179 @@
+use codanna::parsing::LanguageParser;
+use codanna::parsing::zig::ZigParser;
+use codanna::symbol::ScopeContext;
+use codanna::types::{FileId, SymbolCounter};
+use codanna::{SymbolKind, Visibility};
+
+fn load_basic_fixture() -> &'static str {
+    include_str!("../../fixtures/zig/basic.zig")
+}
+
+fn parse_fixture() -> Vec<codanna::Symbol> {
+    let code = load_basic_fixture();
+    let mut parser = ZigParser::new().expect("Failed to create Zig parser");
+    let file_id = FileId::new(1).unwrap();
+    let mut counter = SymbolCounter::new();
+    parser.parse(code, file_id, &mut counter)
+}
+
+fn find<'a>(symbols: &'a [codanna::Symbol], name: &str) -> &'a codanna::Symbol {
+    symbols
+        .iter()
+        .find(|s| s.name.as_ref() == name)
+        .unwrap_or_else(|| panic!("Should find symbol '{name}'"))
+}
+
+fn class_of(symbol: &codanna::Symbol) -> Option<&str> {
+    match &symbol.scope_context {
+        Some(ScopeContext::ClassMember {
+            class_name: Some(name),
+        }) => Some(name.as_ref()),
+        _ => None,
+    }
+}
+
+#[test]
+fn test_zig_parses_without_error() {
+    let symbols = parse_fixture();
+    assert!(!symbols.is_empty(), "Should extract symbols from Zig code");
+
+    println!("Extracted {} symbols:", symbols.len());
+    for sym in &symbols {
+        println!("  {:?} {} ({:?})", sym.kind, sym.name, sym.visibility);
+    }
+}
+
+#[test]
+fn test_zig_extracts_containers() {
+    let symbols = parse_fixture();
+
+    assert_eq!(find(&symbols, "Point").kind, SymbolKind::Struct);
+    assert_eq!(find(&symbols, "Shape").kind, SymbolKind::Struct);
+    assert_eq!(find(&symbols, "Color").kind, SymbolKind::Enum);
+    assert_eq!(find(&symbols, "ShapeError").kind, SymbolKind::Enum);
+    assert_eq!(find(&symbols, "blue").kind, SymbolKind::Constant);
+    assert_eq!(find(&symbols, "x").kind, SymbolKind::Field);
+
+    let point = find(&symbols, "Point");
+    assert_eq!(point.doc_comment.as_deref(), Some("A point in 2D space"));
+    assert!(
+        point
+            .signature
+            .as_deref()
+            .is_some_and(|sig| sig.starts_with("pub const Point = struct"))
+    );
+}
+
+#[test]
+fn test_zig_container_functions_are_methods() {
+    let symbols = parse_fixture();
+
+    for (method, container) in [
+        ("init", "Point"),
+        ("distance", "Point"),
+        ("lengthSquared", "Point"),
+        ("isWarm", "Color"),
+        ("append", "List"),
+    ] {
+        let symbol = find(&symbols, method);
+        assert_eq!(
+            symbol.kind,
+            SymbolKind::Method,
+            "{method} should be a Method"
+        );
+        assert_eq!(
+            class_of(symbol),
+            Some(container),
+            "{method} belongs to {container}"
+        );
+    }
+
+    assert_eq!(find(&symbols, "main").kind, SymbolKind::Function);
+    assert_eq!(find(&symbols, "List").kind, SymbolKind::Function);
+    assert_eq!(
+        find(&symbols, "init").doc_comment.as_deref(),
+        Some("Create a point")
+    );
+}
+
+#[test]
+fn test_zig_pub_visibility() {
+    let symbols = parse_fixture();
+
+    assert_eq!(find(&symbols, "init").visibility, Visibility::Public);
+    assert_eq!(
+        find(&symbols, "lengthSquared").visibility,
+        Visibility::Private
+    );
+    assert_eq!(
+        find(&symbols, "registerShape").visibility,
+        Visibility::Private
+    );
+    assert_eq!(find(&symbols, "max_shapes").visibility, Visibility::Public);
+    assert_eq!(find(&symbols, "shape_count").kind, SymbolKind::Variable);
+    assert_eq!(find(&symbols, "max_shapes").kind, SymbolKind::Constant);
+}
+
+#[test]
+fn test_zig_skips_locals_and_imports() {
+    let symbols = parse_fixture();
+
+    for name in ["std", "mem", "vec", "dx", "origin", "a"] {
+        assert!(
+            !symbols.iter().any(|s| s.name.as_ref() == name),
+            "{name} should not be indexed"
+        );
+    }
+}
+
+#[test]
+fn test_zig_inherent_methods_and_defines() {
+    let code = load_basic_fixture();
+    let mut parser = ZigParser::new().unwrap();
+
+    let methods = parser.find_inherent_methods(code);
+    assert!(
+        methods
+            .iter()
+            .any(|(t, m, _)| t == "Point" && m == "distance")
+    );
+    assert!(methods.iter().any(|(t, m, _)| t == "List" && m == "append"));
+    assert!(!methods.iter().any(|(_, m, _)| m == "x"));
+
+    let defines = parser.find_defines(code);
+    assert!(defines.iter().any(|(t, m, _)| *t == "Point" && *m == "x"));
+    assert!(
+        defines
+            .iter()
+            .any(|(t, m, _)| *t == "Color" && *m == "isWarm")
+    );
+}
+
+#[test]
+fn test_zig_calls_and_imports() {
+    let code = load_basic_fixture();
+    let mut parser = ZigParser::new().unwrap();
+
+    let calls = parser.find_calls(code);
+    assert!(
+        calls
+            .iter()
+            .any(|(from, to, _)| *from == "main" && *to == "registerShape")
+    );
+
+    let method_calls = parser.find_method_calls(code);
+    assert!(
+        method_calls
+            .iter()
+            .any(|c| c.caller == "main" && c.method_name == "init" && c.is_static)
+    );
+
+    let imports = parser.find_imports(code, FileId::new(1).unwrap());
+    assert!(imports.iter().any(|i| i.path == "std"));
+    assert!(imports.iter().any(|i| i.path == "std.mem"));
+    let vector = imports
+        .iter()
+        .find(|i| i.path == "math/vector.zig")
+        .expect("Should find file import");
+    assert_eq!(vector.alias.as_deref(), Some("vec"));
+}
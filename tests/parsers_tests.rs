@@ -10,6 +10,9 @@ mod test_typescript_resolution_pipeline;
 #[path = "parsers/typescript/test_call_tracking.rs"]
 mod test_typescript_call_tracking;
 
+#[path = "parsers/typescript/test_test_blocks.rs"]
+mod test_typescript_test_blocks;
+
 #[path = "parsers/typescript/test_nested_functions.rs"]
 mod test_typescript_nested_functions;
 
@@ -25,6 +28,9 @@ mod test_javascript_nested_functions;
 #[path = "parsers/typescript/test_default_export.rs"]
 mod test_typescript_default_export;
 
+#[path = "parsers/typescript/test_barrel_reexports.rs"]
+mod test_typescript_barrel_reexports;
+
 #[path = "parsers/javascript/test_default_export.rs"]
 mod test_javascript_default_export;
 
@@ -88,9 +94,15 @@ mod test_kotlin_reddit_challenge;
 #[path = "parsers/kotlin/test_visibility.rs"]
 mod test_kotlin_visibility;
 
+#[path = "parsers/kotlin/test_inherent_methods.rs"]
+mod test_kotlin_inherent_methods;
+
 #[path = "parsers/swift/test_relationships.rs"]
 mod test_swift_relationships;
 
+#[path = "parsers/swift/test_protocols_and_extensions.rs"]
+mod test_swift_protocols_and_extensions;
+
 #[path = "parsers/swift/debug_relationships.rs"]
 mod debug_swift_relationships;
 
@@ -130,6 +142,9 @@ mod test_lua_call_tracking;
 #[path = "parsers/lua/test_relationships.rs"]
 mod test_lua_relationships;
 
+#[path = "parsers/lua/test_scopes.rs"]
+mod test_lua_scopes;
+
 #[path = "parsers/swift/test_nested_types.rs"]
 mod test_swift_nested_types;
 
@@ -145,12 +160,18 @@ mod test_swift_scope_context_handlers;
 #[path = "parsers/cpp/test_method_call_static.rs"]
 mod test_cpp_method_call_static;
 
+#[path = "parsers/cpp/test_declarations.rs"]
+mod test_cpp_declarations;
+
 #[path = "parsers/php/test_method_call_static.rs"]
 mod test_php_method_call_static;
 
 #[path = "parsers/c/test_method_call_static.rs"]
 mod test_c_method_call_static;
 
+#[path = "parsers/c/test_declarations.rs"]
+mod test_c_declarations;
+
 #[path = "parsers/lua/test_method_call_static.rs"]
 mod test_lua_method_call_static;
 
@@ -196,15 +217,30 @@ mod test_cpp_self_receiver_aliases;
 #[path = "parsers/rust/test_extract_parameter_type.rs"]
 mod test_rust_extract_parameter_type;
 
+#[path = "parsers/rust/test_parameter_type_bounds.rs"]
+mod test_rust_parameter_type_bounds;
+
 #[path = "parsers/rust/test_module_path_out_of_tree.rs"]
 mod test_rust_module_path_out_of_tree;
 
+#[path = "parsers/rust/test_test_attribute.rs"]
+mod test_rust_test_attribute;
+
+#[path = "parsers/rust/test_value_references.rs"]
+mod test_rust_value_references;
+
+#[path = "parsers/python/test_value_references.rs"]
+mod test_python_value_references;
+
 #[path = "parsers/python/test_extract_parameter_type.rs"]
 mod test_python_extract_parameter_type;
 
 #[path = "parsers/typescript/test_extract_parameter_type.rs"]
 mod test_typescript_extract_parameter_type;
 
+#[path = "parsers/typescript/test_parameter_type_bounds.rs"]
+mod test_typescript_parameter_type_bounds;
+
 #[path = "parsers/go/test_extract_parameter_type.rs"]
 mod test_go_extract_parameter_type;
 
@@ -213,3 +249,51 @@ mod test_java_extract_parameter_type;
 
 #[path = "parsers/java/test_method_kind.rs"]
 mod test_java_method_kind;
+
+#[path = "parsers/ruby/test_symbols.rs"]
+mod test_ruby_symbols;
+
+#[path = "parsers/scala/test_symbols.rs"]
+mod test_scala_symbols;
+
+#[path = "parsers/zig/test_symbols.rs"]
+mod test_zig_symbols;
+
+#[path = "parsers/dart/test_symbols.rs"]
+mod test_dart_symbols;
+
+#[path = "parsers/elixir/test_symbols.rs"]
+mod test_elixir_symbols;
+
+#[path = "parsers/bash/test_symbols.rs"]
+mod test_bash_symbols;
+
+#[path = "parsers/sql/test_symbols.rs"]
+mod test_sql_symbols;
+
+#[path = "parsers/protobuf/test_symbols.rs"]
+mod test_protobuf_symbols;
+
+#[path = "parsers/graphql/test_symbols.rs"]
+mod test_graphql_symbols;
+
+#[path = "parsers/hcl/test_symbols.rs"]
+mod test_hcl_symbols;
+
+#[path = "parsers/vue/test_symbols.rs"]
+mod test_vue_symbols;
+
+#[path = "parsers/svelte/test_symbols.rs"]
+mod test_svelte_symbols;
+
+#[path = "parsers/markdown/test_symbols.rs"]
+mod test_markdown_symbols;
+
+#[path = "parsers/jupyter/test_symbols.rs"]
+mod test_jupyter_symbols;
+
+#[path = "parsers/css/test_symbols.rs"]
+mod test_css_symbols;
+
+#[path = "parsers/html/test_symbols.rs"]
+mod test_html_symbols;